@@ -96,6 +96,8 @@ export interface GrafanaConfig {
   disableSanitizeHtml: boolean;
   theme: GrafanaTheme;
   pluginsToPreload: string[];
+  sigV4AuthEnabled: boolean;
+  sigV4AllowedAuthProviders: string[];
   featureToggles: FeatureToggles;
   licenseInfo: LicenseInfo;
 }
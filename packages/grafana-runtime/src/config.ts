@@ -48,6 +48,8 @@ export class GrafanaBootConfig implements GrafanaConfig {
   disableSanitizeHtml = false;
   theme: GrafanaTheme;
   pluginsToPreload: string[] = [];
+  sigV4AuthEnabled = false;
+  sigV4AllowedAuthProviders: string[] = [];
   featureToggles: FeatureToggles = {
     transformations: false,
     expressions: false,
@@ -80,4 +80,24 @@ describe('DataSourceWithBackend', () => {
       }
     `);
   });
+
+  test('the queries of dashboard panels tell their dashboard and panel', () => {
+    const settings = {
+      name: 'test',
+      id: 1234,
+      jsonData: {},
+    } as DataSourceInstanceSettings<DataSourceJsonData>;
+
+    mockDatasourceRequest.mockReset();
+    mockDatasourceRequest.mockReturnValue(Promise.resolve({}));
+    const ds = new MyDataSource(settings);
+    ds.query({
+      dashboardId: 12,
+      panelId: 3,
+      targets: [{ refId: 'A' }],
+    } as DataQueryRequest);
+
+    const args = mockDatasourceRequest.mock.calls[0][0];
+    expect(args.headers).toEqual({ 'X-Dashboard-Id': 12, 'X-Panel-Id': 3 });
+  });
 });
@@ -9,7 +9,7 @@ import {
 } from '@grafana/data';
 import { Observable, from, of } from 'rxjs';
 import { config } from '..';
-import { getBackendSrv } from '../services';
+import { BackendSrvRequest, getBackendSrv } from '../services';
 import { toDataQueryResponse } from './queryResponse';
 
 const ExpressionDatasourceID = '__expr__';
@@ -101,13 +101,22 @@ export class DataSourceWithBackend<
       body.to = range.to.valueOf().toString();
     }
 
+    const options: BackendSrvRequest = {
+      url: '/api/ds/query',
+      method: 'POST',
+      data: body,
+      requestId,
+    };
+    // The queries of the dashboard panels are recorded to warm the query cache
+    if (request.dashboardId !== undefined) {
+      options.headers = {
+        'X-Dashboard-Id': request.dashboardId,
+        'X-Panel-Id': request.panelId,
+      };
+    }
+
     const req: Promise<DataQueryResponse> = getBackendSrv()
-      .datasourceRequest({
-        url: '/api/ds/query',
-        method: 'POST',
-        data: body,
-        requestId,
-      })
+      .datasourceRequest(options)
       .then((rsp: any) => {
         const dqs = toDataQueryResponse(rsp);
         if (this.processResponse) {
@@ -1,6 +1,7 @@
 import React from 'react';
 import { HttpSettingsBaseProps } from './types';
 import { Switch } from '../Forms/Legacy/Switch/Switch';
+import { FormField } from '../FormField/FormField';
 
 export const HttpProxySettings: React.FC<HttpSettingsBaseProps> = ({ dataSourceConfig, onChange }) => {
   return (
@@ -40,6 +41,36 @@ export const HttpProxySettings: React.FC<HttpSettingsBaseProps> = ({ dataSourceC
           tooltip="Forward the user's upstream OAuth identity to the data source (Their access token gets passed along)."
         />
       </div>
+      {dataSourceConfig.jsonData.oauthPassThru && (
+        <>
+          <div className="gf-form">
+            <FormField
+              label="Token Audience"
+              labelWidth={13}
+              inputWidth={18}
+              placeholder="optional"
+              value={dataSourceConfig.jsonData.oauthPassThruAudience || ''}
+              onChange={event =>
+                onChange({ ...dataSourceConfig.jsonData, oauthPassThruAudience: event.currentTarget.value })
+              }
+              tooltip="Exchange the user's access token for a token of this audience before forwarding it, with the token exchange of the identity provider."
+            />
+          </div>
+          <div className="gf-form">
+            <FormField
+              label="Token Scopes"
+              labelWidth={13}
+              inputWidth={18}
+              placeholder="optional"
+              value={dataSourceConfig.jsonData.oauthPassThruScopes || ''}
+              onChange={event =>
+                onChange({ ...dataSourceConfig.jsonData, oauthPassThruScopes: event.currentTarget.value })
+              }
+              tooltip="Space separated scopes of the exchanged token."
+            />
+          </div>
+        </>
+      )}
     </>
   );
 };
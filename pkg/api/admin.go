@@ -7,6 +7,7 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
 )
 
 func AdminGetSettings(c *models.ReqContext) {
@@ -38,6 +39,27 @@ func AdminGetSettings(c *models.ReqContext) {
 	c.JSON(200, settings)
 }
 
+// AdminReloadSettings reloads the settings that can change without restarting Grafana
+// POST /api/admin/settings/reload
+func (hs *HTTPServer) AdminReloadSettings(c *models.ReqContext) Response {
+	if err := hs.Cfg.Reload(); err != nil {
+		return Error(500, "Failed to reload settings: "+err.Error(), err)
+	}
+
+	return Success("Settings reloaded")
+}
+
+// AdminFlushAWSCredentialsCache drops the AWS credentials cached for the CloudWatch data sources
+// POST /api/admin/aws/credentials-cache/flush
+func AdminFlushAWSCredentialsCache(c *models.ReqContext) Response {
+	cmd := models.FlushAWSCredentialsCacheCommand{}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return Error(500, "Failed to flush the AWS credentials cache", err)
+	}
+
+	return JSON(200, util.DynMap{"message": "AWS credentials cache flushed", "flushed": cmd.Result})
+}
+
 func AdminGetStats(c *models.ReqContext) {
 
 	statsQuery := models.GetAdminStatsQuery{}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/datakeys"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func (hs *HTTPServer) AdminRotateDataKeys(c *models.ReqContext) Response {
+	dataKey, err := hs.DataKeysService.RotateDataKeys(c.Req.Context())
+	if err != nil {
+		if err == datakeys.ErrEnvelopeEncryptionDisabled {
+			return Error(400, err.Error(), err)
+		}
+		return Error(500, "Failed to rotate data keys", err)
+	}
+
+	return JSON(200, util.DynMap{"message": "Data keys rotated", "name": dataKey.Name})
+}
+
+func (hs *HTTPServer) AdminReEncryptSecrets(c *models.ReqContext) Response {
+	cmd := models.ReEncryptSecretsCommand{}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return Error(500, "Failed to re-encrypt secrets", err)
+	}
+
+	return JSON(200, util.DynMap{"message": "Secrets re-encrypted", "reEncrypted": cmd.Result})
+}
@@ -0,0 +1,14 @@
+package api
+
+import (
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func (hs *HTTPServer) AdminGetMigrationStatus(c *models.ReqContext) Response {
+	status, err := hs.SQLStore.MigrationStatus()
+	if err != nil {
+		return Error(500, "Failed to get migration status", err)
+	}
+
+	return JSON(200, status)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
 )
 
 func (server *HTTPServer) AdminProvisioningReloadDashboards(c *models.ReqContext) Response {
@@ -15,6 +16,14 @@ func (server *HTTPServer) AdminProvisioningReloadDashboards(c *models.ReqContext
 }
 
 func (server *HTTPServer) AdminProvisioningReloadDatasources(c *models.ReqContext) Response {
+	if c.QueryBool("dryRun") {
+		changes, err := server.ProvisioningService.DryRunDatasources()
+		if err != nil {
+			return Error(500, "", err)
+		}
+		return JSON(200, util.DynMap{"message": "Datasources config checked", "changes": changes})
+	}
+
 	err := server.ProvisioningService.ProvisionDatasources()
 	if err != nil {
 		return Error(500, "", err)
@@ -37,3 +46,27 @@ func (server *HTTPServer) AdminProvisioningReloadNotifications(c *models.ReqCont
 	}
 	return Success("Notifications config reloaded")
 }
+
+func (server *HTTPServer) AdminProvisioningReloadOrgs(c *models.ReqContext) Response {
+	err := server.ProvisioningService.ProvisionOrgs()
+	if err != nil {
+		return Error(500, "Failed to reload orgs config", err)
+	}
+	return Success("Orgs config reloaded")
+}
+
+func (server *HTTPServer) AdminProvisioningGetStatus(c *models.ReqContext) Response {
+	status, err := server.ProvisioningService.GetStatus()
+	if err != nil {
+		return Error(500, "Failed to get provisioning status", err)
+	}
+	return JSON(200, status)
+}
+
+func (server *HTTPServer) AdminProvisioningRevertDrift(c *models.ReqContext) Response {
+	status, err := server.ProvisioningService.RevertDrift()
+	if err != nil && err != context.Canceled {
+		return Error(500, "Failed to revert drifted resources", err)
+	}
+	return JSON(200, status)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/alerting"
 	"github.com/grafana/grafana/pkg/services/guardian"
 	"github.com/grafana/grafana/pkg/services/search"
+	"github.com/grafana/grafana/pkg/util"
 )
 
 func ValidateOrgAlert(c *models.ReqContext) {
@@ -270,6 +271,9 @@ func GetAlertNotificationByUID(c *models.ReqContext) Response {
 
 func CreateAlertNotification(c *models.ReqContext, cmd models.CreateAlertNotificationCommand) Response {
 	cmd.OrgId = c.OrgId
+	if resp := validateSecretReferences(c.OrgId, cmd.SecureSettings); resp != nil {
+		return resp
+	}
 
 	if err := bus.Dispatch(&cmd); err != nil {
 		return Error(500, "Failed to create alert notification", err)
@@ -280,6 +284,9 @@ func CreateAlertNotification(c *models.ReqContext, cmd models.CreateAlertNotific
 
 func UpdateAlertNotification(c *models.ReqContext, cmd models.UpdateAlertNotificationCommand) Response {
 	cmd.OrgId = c.OrgId
+	if resp := validateSecretReferences(c.OrgId, cmd.SecureSettings); resp != nil {
+		return resp
+	}
 
 	if err := bus.Dispatch(&cmd); err != nil {
 		return Error(500, "Failed to update alert notification", err)
@@ -292,12 +299,42 @@ func UpdateAlertNotification(c *models.ReqContext, cmd models.UpdateAlertNotific
 	return JSON(200, dtos.NewAlertNotification(cmd.Result))
 }
 
+// UpdateAlertNotificationByUID updates the alert notification with the uid of the path, or creates
+// it if it doesn't exist. The version of the notification is only checked when it's set, the
+// conflicts returning 409, so that the same request can be repeated.
 func UpdateAlertNotificationByUID(c *models.ReqContext, cmd models.UpdateAlertNotificationWithUidCommand) Response {
 	cmd.OrgId = c.OrgId
 	cmd.Uid = c.Params("uid")
+	if resp := validateSecretReferences(c.OrgId, cmd.SecureSettings); resp != nil {
+		return resp
+	}
+
+	query := models.GetAlertNotificationsWithUidQuery{OrgId: c.OrgId, Uid: cmd.Uid}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get alert notification", err)
+	}
+
+	if query.Result == nil {
+		createCmd := models.CreateAlertNotificationCommand{
+			Uid:                   cmd.Uid,
+			Name:                  cmd.Name,
+			Type:                  cmd.Type,
+			SendReminder:          cmd.SendReminder,
+			DisableResolveMessage: cmd.DisableResolveMessage,
+			Frequency:             cmd.Frequency,
+			IsDefault:             cmd.IsDefault,
+			Settings:              cmd.Settings,
+			SecureSettings:        cmd.SecureSettings,
+			OrgId:                 c.OrgId,
+		}
+		if err := bus.Dispatch(&createCmd); err != nil {
+			return alertNotificationUpsertErrorToApiResponse(err)
+		}
+		return JSON(200, dtos.NewAlertNotification(createCmd.Result))
+	}
 
 	if err := bus.Dispatch(&cmd); err != nil {
-		return Error(500, "Failed to update alert notification", err)
+		return alertNotificationUpsertErrorToApiResponse(err)
 	}
 
 	if cmd.Result == nil {
@@ -307,6 +344,18 @@ func UpdateAlertNotificationByUID(c *models.ReqContext, cmd models.UpdateAlertNo
 	return JSON(200, dtos.NewAlertNotification(cmd.Result))
 }
 
+func alertNotificationUpsertErrorToApiResponse(err error) Response {
+	switch err {
+	case models.ErrAlertNotificationWithSameNameExists:
+		return Conflict("name-exists", err)
+	case models.ErrAlertNotificationWithSameUIDExists:
+		return Conflict("uid-exists", err)
+	case models.ErrAlertNotificationVersionMismatch:
+		return Conflict("version-mismatch", err)
+	}
+	return Error(500, "Failed to update alert notification", err)
+}
+
 func DeleteAlertNotification(c *models.ReqContext) Response {
 	cmd := models.DeleteAlertNotificationCommand{
 		OrgId: c.OrgId,
@@ -336,19 +385,127 @@ func DeleteAlertNotificationByUID(c *models.ReqContext) Response {
 //POST /api/alert-notifications/test
 func NotificationTest(c *models.ReqContext, dto dtos.NotificationTestCommand) Response {
 	cmd := &alerting.NotificationTestCommand{
-		Name:     dto.Name,
-		Type:     dto.Type,
-		Settings: dto.Settings,
+		OrgID:          c.OrgId,
+		Name:           dto.Name,
+		Type:           dto.Type,
+		Settings:       dto.Settings,
+		SecureSettings: dto.SecureSettings,
+		Alert:          newNotificationTestAlert(dto.Alert),
 	}
 
-	if err := bus.Dispatch(cmd); err != nil {
+	return sendTestNotification(c, cmd)
+}
+
+// POST /api/alert-notifications/:notificationId/test
+func NotificationTestByID(c *models.ReqContext, dto dtos.SavedNotificationTestCommand) Response {
+	query := &models.GetAlertNotificationsQuery{
+		OrgId: c.OrgId,
+		Id:    c.ParamsInt64("notificationId"),
+	}
+
+	if query.Id == 0 {
+		return Error(404, "Alert notification not found", nil)
+	}
+
+	if err := bus.Dispatch(query); err != nil {
+		return Error(500, "Failed to get alert notifications", err)
+	}
+
+	if query.Result == nil {
+		return Error(404, "Alert notification not found", nil)
+	}
+
+	return sendTestNotification(c, newSavedNotificationTestCommand(query.Result, dto))
+}
+
+// POST /api/alert-notifications/uid/:uid/test
+func NotificationTestByUID(c *models.ReqContext, dto dtos.SavedNotificationTestCommand) Response {
+	query := &models.GetAlertNotificationsWithUidQuery{
+		OrgId: c.OrgId,
+		Uid:   c.Params("uid"),
+	}
+
+	if query.Uid == "" {
+		return Error(404, "Alert notification not found", nil)
+	}
+
+	if err := bus.Dispatch(query); err != nil {
+		return Error(500, "Failed to get alert notifications", err)
+	}
+
+	if query.Result == nil {
+		return Error(404, "Alert notification not found", nil)
+	}
+
+	return sendTestNotification(c, newSavedNotificationTestCommand(query.Result, dto))
+}
+
+func newSavedNotificationTestCommand(notification *models.AlertNotification, dto dtos.SavedNotificationTestCommand) *alerting.NotificationTestCommand {
+	return &alerting.NotificationTestCommand{
+		OrgID:          notification.OrgId,
+		Name:           notification.Name,
+		Type:           notification.Type,
+		Settings:       notification.Settings,
+		SecureSettings: notification.SecureSettings.Decrypt(),
+		Provisioned:    notification.Provisioned,
+		Alert:          newNotificationTestAlert(dto.Alert),
+	}
+}
+
+func newNotificationTestAlert(dto *dtos.NotificationTestAlert) *alerting.NotificationTestAlert {
+	if dto == nil {
+		return nil
+	}
+
+	alert := &alerting.NotificationTestAlert{
+		Title:    dto.Title,
+		Message:  dto.Message,
+		State:    dto.State,
+		Tags:     dto.Tags,
+		ImageURL: dto.ImageURL,
+		Error:    dto.Error,
+	}
+	for _, match := range dto.EvalMatches {
+		alert.EvalMatches = append(alert.EvalMatches, &alerting.EvalMatch{
+			Metric: match.Metric,
+			Tags:   match.Tags,
+			Value:  match.Value,
+		})
+	}
+	return alert
+}
+
+// sendTestNotification sends a test notification, and returns the webhooks and emails the channel
+// sent, also when it failed. Only admins get the responses of the upstreams, the others only get
+// their status and error.
+func sendTestNotification(c *models.ReqContext, cmd *alerting.NotificationTestCommand) Response {
+	err := bus.DispatchCtx(c.Req.Context(), cmd)
+	if !c.HasRole(models.ROLE_ADMIN) {
+		for i := range cmd.Result {
+			cmd.Result[i].Response = ""
+		}
+	}
+	if err != nil {
 		if err == models.ErrSmtpNotEnabled {
 			return Error(412, err.Error(), err)
 		}
-		return Error(500, "Failed to send alert notifications", err)
+		if err == alerting.ErrInvalidTestAlertState {
+			return Error(400, err.Error(), err)
+		}
+		if len(cmd.Result) == 0 {
+			return Error(500, "Failed to send alert notifications", err)
+		}
+		return JSON(500, util.DynMap{
+			"message":    "Failed to send alert notifications",
+			"error":      err.Error(),
+			"deliveries": cmd.Result,
+		})
 	}
 
-	return Success("Test notification sent")
+	return JSON(200, util.DynMap{
+		"message":    "Test notification sent",
+		"deliveries": cmd.Result,
+	})
 }
 
 //POST /api/alerts/:alertId/pause
@@ -1,11 +1,14 @@
 package api
 
 import (
+	"context"
 	"testing"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/alerting"
 	"github.com/grafana/grafana/pkg/services/search"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -128,6 +131,61 @@ func TestAlertingApiEndpoint(t *testing.T) {
 	})
 }
 
+func TestNotificationTestApiEndpoint(t *testing.T) {
+	Convey("Given a notification channel answering with a webhook response", t, func() {
+		cmd := dtos.NotificationTestCommand{Type: "webhook", Settings: simplejson.New()}
+
+		notificationTestScenario("When an editor tests it", models.ROLE_EDITOR, cmd, func(sc *scenarioContext) {
+			sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+			So(sc.resp.Code, ShouldEqual, 200)
+
+			delivery := sc.ToJSON().Get("deliveries").GetIndex(0)
+			So(delivery.Get("status").MustInt(), ShouldEqual, 200)
+			So(delivery.Get("response").MustString(), ShouldBeEmpty)
+		})
+
+		notificationTestScenario("When an admin tests it", models.ROLE_ADMIN, cmd, func(sc *scenarioContext) {
+			sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+			So(sc.resp.Code, ShouldEqual, 200)
+
+			delivery := sc.ToJSON().Get("deliveries").GetIndex(0)
+			So(delivery.Get("status").MustInt(), ShouldEqual, 200)
+			So(delivery.Get("response").MustString(), ShouldEqual, "internal details")
+		})
+	})
+}
+
+func notificationTestScenario(desc string, role models.RoleType, cmd dtos.NotificationTestCommand, fn scenarioFunc) {
+	Convey(desc, func() {
+		defer bus.ClearBusHandlers()
+
+		bus.AddHandlerCtx("test", func(ctx context.Context, cmd *alerting.NotificationTestCommand) error {
+			cmd.Result = []models.NotificationDelivery{{
+				Type:     models.NotificationDeliveryWebhook,
+				Method:   "POST",
+				URL:      "http://internal",
+				Status:   200,
+				Response: "internal details",
+			}}
+			return nil
+		})
+
+		sc := setupScenarioContext("/api/alert-notifications/test")
+		sc.defaultHandler = Wrap(func(c *models.ReqContext) Response {
+			sc.context = c
+			sc.context.UserId = TestUserID
+			sc.context.OrgId = TestOrgID
+			sc.context.OrgRole = role
+
+			return NotificationTest(c, cmd)
+		})
+
+		sc.m.Post("/api/alert-notifications/test", sc.defaultHandler)
+
+		fn(sc)
+	})
+}
+
 func CallPauseAlert(sc *scenarioContext) {
 	bus.AddHandler("test", func(cmd *models.PauseAlertCommand) error {
 		return nil
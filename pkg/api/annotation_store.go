@@ -0,0 +1,41 @@
+package api
+
+import (
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// GET /api/org/annotation-store
+func GetAnnotationStore(c *models.ReqContext) Response {
+	query := models.GetAnnotationStoreQuery{OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get annotation store", err)
+	}
+
+	if query.Result == nil {
+		return JSON(200, util.DynMap{"datasourceId": 0})
+	}
+
+	return JSON(200, util.DynMap{
+		"datasourceId": query.Result.DatasourceId,
+		"updated":      query.Result.Updated,
+	})
+}
+
+// PUT /api/org/annotation-store
+func SetAnnotationStore(c *models.ReqContext, cmd models.SetAnnotationStoreCommand) Response {
+	cmd.OrgId = c.OrgId
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		switch err {
+		case models.ErrDataSourceNotFound:
+			return Error(404, "Data source not found", err)
+		case models.ErrAnnotationStoreInvalidDataSource:
+			return Error(400, err.Error(), err)
+		}
+		return Error(500, "Failed to update annotation store", err)
+	}
+
+	return Success("Annotation store updated")
+}
@@ -132,6 +132,7 @@ func (hs *HTTPServer) registerRoutes() {
 
 			userRoute.Get("/preferences", Wrap(GetUserPreferences))
 			userRoute.Put("/preferences", bind(dtos.UpdatePrefsCmd{}), Wrap(UpdateUserPreferences))
+			userRoute.Get("/preferences/resolved", Wrap(GetResolvedUserPreferences))
 
 			userRoute.Get("/auth-tokens", Wrap(hs.GetUserAuthTokens))
 			userRoute.Post("/revoke-auth-token", bind(models.RevokeAuthTokenCmd{}), Wrap(hs.RevokeUserAuthToken))
@@ -152,7 +153,7 @@ func (hs *HTTPServer) registerRoutes() {
 
 		// team (admin permission required)
 		apiRoute.Group("/teams", func(teamsRoute routing.RouteRegister) {
-			teamsRoute.Post("/", bind(models.CreateTeamCommand{}), Wrap(hs.CreateTeam))
+			teamsRoute.Post("/", quota("team"), bind(models.CreateTeamCommand{}), Wrap(hs.CreateTeam))
 			teamsRoute.Put("/:teamId", bind(models.UpdateTeamCommand{}), Wrap(hs.UpdateTeam))
 			teamsRoute.Delete("/:teamId", Wrap(hs.DeleteTeamByID))
 			teamsRoute.Get("/:teamId/members", Wrap(hs.GetTeamMembers))
@@ -192,6 +193,14 @@ func (hs *HTTPServer) registerRoutes() {
 			// prefs
 			orgRoute.Get("/preferences", Wrap(GetOrgPreferences))
 			orgRoute.Put("/preferences", bind(dtos.UpdatePrefsCmd{}), Wrap(UpdateOrgPreferences))
+
+			// annotation store
+			orgRoute.Get("/annotation-store", Wrap(GetAnnotationStore))
+			orgRoute.Put("/annotation-store", bind(models.SetAnnotationStoreCommand{}), Wrap(SetAnnotationStore))
+
+			// query stats
+			orgRoute.Get("/query-stats/panels", Wrap(hs.GetSlowestPanels))
+			orgRoute.Get("/query-stats/dashboards", Wrap(hs.GetSlowestDashboards))
 		}, reqOrgAdmin)
 
 		// current org without requirement of user to be org admin
@@ -217,6 +226,7 @@ func (hs *HTTPServer) registerRoutes() {
 			orgsRoute.Delete("/users/:userId", Wrap(RemoveOrgUser))
 			orgsRoute.Get("/quotas", Wrap(GetOrgQuotas))
 			orgsRoute.Put("/quotas/:target", bind(models.UpdateOrgQuotaCmd{}), Wrap(UpdateOrgQuota))
+			orgsRoute.Get("/usage", Wrap(GetOrgUsage))
 		}, reqGrafanaAdmin)
 
 		// orgs (admin routes)
@@ -245,6 +255,15 @@ func (hs *HTTPServer) registerRoutes() {
 			datasourceRoute.Delete("/name/:name", Wrap(DeleteDataSourceByName))
 			datasourceRoute.Get("/:id", Wrap(GetDataSourceById))
 			datasourceRoute.Get("/name/:name", Wrap(GetDataSourceByName))
+			datasourceRoute.Get("/uid/:uid", Wrap(GetDataSourceByUID))
+			datasourceRoute.Get("/uid/:uid/usage", Wrap(GetDataSourceUsageByUID))
+			datasourceRoute.Get("/:id/shadow-stats", Wrap(hs.GetDataSourceShadowStats))
+			datasourceRoute.Put("/uid/:uid", bind(models.UpdateDataSourceCommand{}), Wrap(hs.PutDataSourceByUID))
+			datasourceRoute.Get("/:id/correlations", Wrap(GetCorrelations))
+			datasourceRoute.Post("/:id/correlations", bind(models.CreateCorrelationCommand{}), Wrap(CreateCorrelation))
+			datasourceRoute.Get("/:id/correlations/:correlationUid", Wrap(GetCorrelation))
+			datasourceRoute.Put("/:id/correlations/:correlationUid", bind(models.UpdateCorrelationCommand{}), Wrap(UpdateCorrelation))
+			datasourceRoute.Delete("/:id/correlations/:correlationUid", Wrap(DeleteCorrelation))
 		}, reqOrgAdmin)
 
 		apiRoute.Get("/datasources/id/:name", Wrap(GetDataSourceIdByName), reqSignedIn)
@@ -257,6 +276,7 @@ func (hs *HTTPServer) registerRoutes() {
 		apiRoute.Any("/plugins/:pluginId/resources/*", hs.CallResource)
 
 		apiRoute.Group("/plugins", func(pluginRoute routing.RouteRegister) {
+			pluginRoute.Get("/errors", Wrap(hs.GetPluginErrorsList))
 			pluginRoute.Get("/:pluginId/dashboards/", Wrap(GetPluginDashboards))
 			pluginRoute.Post("/:pluginId/settings", bind(models.UpdatePluginSettingCmd{}), Wrap(UpdatePluginSetting))
 			pluginRoute.Get("/:pluginId/metrics", Wrap(hs.CollectPluginMetrics))
@@ -268,12 +288,15 @@ func (hs *HTTPServer) registerRoutes() {
 		apiRoute.Any("/datasources/:id/resources", hs.CallDatasourceResource)
 		apiRoute.Any("/datasources/:id/resources/*", hs.CallDatasourceResource)
 		apiRoute.Any("/datasources/:id/health", Wrap(hs.CheckDatasourceHealth))
+		apiRoute.Get("/datasources/uid/:uid/health", Wrap(hs.CheckDatasourceHealthByUID))
+		apiRoute.Post("/datasources/:id/log-context", bind(dtos.LogContextRequest{}), Wrap(hs.GetDatasourceLogContext))
 
 		// Folders
 		apiRoute.Group("/folders", func(folderRoute routing.RouteRegister) {
 			folderRoute.Get("/", Wrap(GetFolders))
 			folderRoute.Get("/id/:id", Wrap(GetFolderByID))
 			folderRoute.Post("/", bind(models.CreateFolderCommand{}), Wrap(hs.CreateFolder))
+			folderRoute.Put("/uid/:uid", bind(models.UpdateFolderCommand{}), Wrap(hs.PutFolderByUID))
 
 			folderRoute.Group("/:uid", func(folderUidRoute routing.RouteRegister) {
 				folderUidRoute.Get("/", Wrap(GetFolderByUID))
@@ -283,6 +306,7 @@ func (hs *HTTPServer) registerRoutes() {
 				folderUidRoute.Group("/permissions", func(folderPermissionRoute routing.RouteRegister) {
 					folderPermissionRoute.Get("/", Wrap(GetFolderPermissionList))
 					folderPermissionRoute.Post("/", bind(dtos.UpdateDashboardAclCommand{}), Wrap(UpdateFolderPermissions))
+					folderPermissionRoute.Get("/preview/:userId", Wrap(GetFolderPermissionPreview))
 				})
 			})
 		})
@@ -290,7 +314,9 @@ func (hs *HTTPServer) registerRoutes() {
 		// Dashboard
 		apiRoute.Group("/dashboards", func(dashboardRoute routing.RouteRegister) {
 			dashboardRoute.Get("/uid/:uid", Wrap(hs.GetDashboard))
+			dashboardRoute.Put("/uid/:uid", bind(models.SaveDashboardCommand{}), Wrap(hs.PutDashboardByUID))
 			dashboardRoute.Delete("/uid/:uid", Wrap(DeleteDashboardByUID))
+			dashboardRoute.Post("/uid/:uid/interpolate", bind(dtos.InterpolateDashboardCommand{}), Wrap(hs.InterpolateDashboard))
 
 			dashboardRoute.Get("/db/:slug", Wrap(hs.GetDashboard))
 			dashboardRoute.Delete("/db/:slug", Wrap(DeleteDashboardBySlug))
@@ -301,6 +327,7 @@ func (hs *HTTPServer) registerRoutes() {
 			dashboardRoute.Get("/home", Wrap(hs.GetHomeDashboard))
 			dashboardRoute.Get("/tags", GetDashboardTags)
 			dashboardRoute.Post("/import", bind(dtos.ImportDashboardCommand{}), Wrap(ImportDashboard))
+			dashboardRoute.Post("/validate", bind(dtos.ValidateDashboardCommand{}), Wrap(ValidateDashboard))
 
 			dashboardRoute.Group("/id/:dashboardId", func(dashIdRoute routing.RouteRegister) {
 				dashIdRoute.Get("/versions", Wrap(GetDashboardVersions))
@@ -310,8 +337,34 @@ func (hs *HTTPServer) registerRoutes() {
 				dashIdRoute.Group("/permissions", func(dashboardPermissionRoute routing.RouteRegister) {
 					dashboardPermissionRoute.Get("/", Wrap(GetDashboardPermissionList))
 					dashboardPermissionRoute.Post("/", bind(dtos.UpdateDashboardAclCommand{}), Wrap(UpdateDashboardPermissions))
+					dashboardPermissionRoute.Get("/preview/:userId", Wrap(GetDashboardPermissionPreview))
 				})
 			})
+		}, hs.encodeResponse(routeGroupDashboards))
+
+		// Query history
+		apiRoute.Group("/query-history", func(queryHistoryRoute routing.RouteRegister) {
+			queryHistoryRoute.Get("/", Wrap(SearchQueryHistory))
+			queryHistoryRoute.Post("/", bind(models.CreateQueryHistoryCommand{}), Wrap(CreateQueryHistory))
+			queryHistoryRoute.Post("/migrate", bind(models.MigrateQueryHistoryCommand{}), Wrap(MigrateQueryHistory))
+			queryHistoryRoute.Post("/star/:uid", Wrap(StarQueryHistory))
+			queryHistoryRoute.Delete("/star/:uid", Wrap(UnstarQueryHistory))
+			queryHistoryRoute.Patch("/:uid", bind(models.PatchQueryHistoryCommentCommand{}), Wrap(PatchQueryHistoryComment))
+			queryHistoryRoute.Delete("/:uid", Wrap(DeleteQueryHistory))
+			queryHistoryRoute.Post("/:uid/share", bind(models.ShareQueryHistoryCommand{}), Wrap(ShareQueryHistory))
+			queryHistoryRoute.Delete("/:uid/share/:teamId", Wrap(UnshareQueryHistory))
+		}, middleware.EnsureEditorOrViewerCanEdit)
+
+		// Library panels
+		apiRoute.Group("/library-panels", func(libraryPanelRoute routing.RouteRegister) {
+			libraryPanelRoute.Get("/", Wrap(SearchLibraryPanels))
+			libraryPanelRoute.Post("/", bind(models.CreateLibraryPanelCommand{}), Wrap(CreateLibraryPanel))
+			libraryPanelRoute.Get("/:uid", Wrap(GetLibraryPanelByUID))
+			libraryPanelRoute.Patch("/:uid", bind(models.PatchLibraryPanelCommand{}), Wrap(PatchLibraryPanel))
+			libraryPanelRoute.Delete("/:uid", Wrap(DeleteLibraryPanel))
+			libraryPanelRoute.Get("/:uid/dashboards", Wrap(GetLibraryPanelDashboards))
+			libraryPanelRoute.Post("/:uid/dashboards/:dashboardId", Wrap(ConnectLibraryPanel))
+			libraryPanelRoute.Delete("/:uid/dashboards/:dashboardId", Wrap(DisconnectLibraryPanel))
 		})
 
 		// Dashboard snapshots
@@ -327,21 +380,45 @@ func (hs *HTTPServer) registerRoutes() {
 			playlistRoute.Get("/:id/dashboards", ValidateOrgPlaylist, Wrap(GetPlaylistDashboards))
 			playlistRoute.Delete("/:id", reqEditorRole, ValidateOrgPlaylist, Wrap(DeletePlaylist))
 			playlistRoute.Put("/:id", reqEditorRole, bind(models.UpdatePlaylistCommand{}), ValidateOrgPlaylist, Wrap(UpdatePlaylist))
-			playlistRoute.Post("/", reqEditorRole, bind(models.CreatePlaylistCommand{}), Wrap(CreatePlaylist))
+			playlistRoute.Post("/", reqEditorRole, quota("playlist"), bind(models.CreatePlaylistCommand{}), Wrap(CreatePlaylist))
+			playlistRoute.Post("/:id/kiosk-token", reqEditorRole, ValidateOrgPlaylist, Wrap(CreatePlaylistKioskToken))
+			playlistRoute.Delete("/:id/kiosk-token", reqEditorRole, ValidateOrgPlaylist, Wrap(DeletePlaylistKioskToken))
 		})
 
+		// Kiosk devices
+		apiRoute.Group("/kiosk-devices", func(kioskDeviceRoute routing.RouteRegister) {
+			kioskDeviceRoute.Get("/", Wrap(GetKioskDevices))
+			kioskDeviceRoute.Post("/", bind(models.CreateKioskDeviceCommand{}), Wrap(CreateKioskDevice))
+			kioskDeviceRoute.Get("/:id", Wrap(GetKioskDevice))
+			kioskDeviceRoute.Put("/:id", bind(models.UpdateKioskDeviceCommand{}), Wrap(hs.UpdateKioskDevice))
+			kioskDeviceRoute.Delete("/:id", Wrap(DeleteKioskDevice))
+			kioskDeviceRoute.Post("/:id/token", Wrap(RegenerateKioskDeviceToken))
+			kioskDeviceRoute.Post("/:id/refresh", Wrap(hs.RefreshKioskDevice))
+		}, reqOrgAdmin)
+
+		// Reports
+		apiRoute.Group("/reports", func(reportRoute routing.RouteRegister) {
+			reportRoute.Get("/", Wrap(GetReports))
+			reportRoute.Get("/:id", Wrap(GetReport))
+			reportRoute.Post("/", bind(models.CreateReportCommand{}), Wrap(CreateReport))
+			reportRoute.Put("/:id", bind(models.UpdateReportCommand{}), Wrap(UpdateReport))
+			reportRoute.Delete("/:id", Wrap(DeleteReport))
+			reportRoute.Post("/:id/send", Wrap(hs.SendReport))
+		}, reqEditorRole)
+
 		// Search
 		apiRoute.Get("/search/sorting", Wrap(hs.ListSortOptions))
-		apiRoute.Get("/search/", Wrap(Search))
+		apiRoute.Get("/search/", hs.encodeResponse(routeGroupSearch), Wrap(Search))
 
 		// metrics
-		apiRoute.Post("/tsdb/query", bind(dtos.MetricRequest{}), Wrap(hs.QueryMetrics))
+		apiRoute.Post("/tsdb/query", hs.encodeResponse(routeGroupQuery), bind(dtos.MetricRequest{}), Wrap(hs.QueryMetrics))
 		apiRoute.Get("/tsdb/testdata/scenarios", Wrap(GetTestDataScenarios))
 		apiRoute.Get("/tsdb/testdata/gensql", reqGrafanaAdmin, Wrap(GenerateSQLTestData))
 		apiRoute.Get("/tsdb/testdata/random-walk", Wrap(GetTestDataRandomWalk))
 
 		// DataSource w/ expressions
-		apiRoute.Post("/ds/query", bind(dtos.MetricRequest{}), Wrap(hs.QueryMetricsV2))
+		apiRoute.Post("/ds/query", hs.encodeResponse(routeGroupQuery), bind(dtos.MetricRequest{}), Wrap(hs.QueryMetricsV2))
+		apiRoute.Post("/ds/export", bind(dtos.ExportMetricsRequest{}), Wrap(hs.ExportMetrics))
 
 		apiRoute.Group("/alerts", func(alertsRoute routing.RouteRegister) {
 			alertsRoute.Post("/test", bind(dtos.AlertTestCommand{}), Wrap(AlertTest))
@@ -359,8 +436,10 @@ func (hs *HTTPServer) registerRoutes() {
 			alertNotifications.Post("/", bind(models.CreateAlertNotificationCommand{}), Wrap(CreateAlertNotification))
 			alertNotifications.Put("/:notificationId", bind(models.UpdateAlertNotificationCommand{}), Wrap(UpdateAlertNotification))
 			alertNotifications.Get("/:notificationId", Wrap(GetAlertNotificationByID))
+			alertNotifications.Post("/:notificationId/test", bind(dtos.SavedNotificationTestCommand{}), Wrap(NotificationTestByID))
 			alertNotifications.Delete("/:notificationId", Wrap(DeleteAlertNotification))
 			alertNotifications.Get("/uid/:uid", Wrap(GetAlertNotificationByUID))
+			alertNotifications.Post("/uid/:uid/test", bind(dtos.SavedNotificationTestCommand{}), Wrap(NotificationTestByUID))
 			alertNotifications.Put("/uid/:uid", bind(models.UpdateAlertNotificationWithUidCommand{}), Wrap(UpdateAlertNotificationByUID))
 			alertNotifications.Delete("/uid/:uid", Wrap(DeleteAlertNotificationByUID))
 		}, reqEditorRole)
@@ -389,6 +468,7 @@ func (hs *HTTPServer) registerRoutes() {
 	// admin api
 	r.Group("/api/admin", func(adminRoute routing.RouteRegister) {
 		adminRoute.Get("/settings", AdminGetSettings)
+		adminRoute.Post("/settings/reload", Wrap(hs.AdminReloadSettings))
 		adminRoute.Post("/users", bind(dtos.AdminCreateUserForm{}), AdminCreateUser)
 		adminRoute.Put("/users/:id/password", bind(dtos.AdminUpdateUserPasswordForm{}), AdminUpdateUserPassword)
 		adminRoute.Put("/users/:id/permissions", bind(dtos.AdminUpdateUserPermissionsForm{}), AdminUpdateUserPermissions)
@@ -398,6 +478,9 @@ func (hs *HTTPServer) registerRoutes() {
 		adminRoute.Get("/users/:id/quotas", Wrap(GetUserQuotas))
 		adminRoute.Put("/users/:id/quotas/:target", bind(models.UpdateUserQuotaCmd{}), Wrap(UpdateUserQuota))
 		adminRoute.Get("/stats", AdminGetStats)
+		adminRoute.Get("/preferences", Wrap(GetInstancePreferences))
+		adminRoute.Put("/preferences", bind(dtos.UpdatePrefsCmd{}), Wrap(UpdateInstancePreferences))
+		adminRoute.Get("/snapshots", Wrap(AdminSearchDashboardSnapshots))
 		adminRoute.Post("/pause-all-alerts", bind(dtos.PauseAllAlertsCommand{}), Wrap(PauseAllAlerts))
 
 		adminRoute.Post("/users/:id/logout", Wrap(hs.AdminLogoutUser))
@@ -408,6 +491,17 @@ func (hs *HTTPServer) registerRoutes() {
 		adminRoute.Post("/provisioning/plugins/reload", Wrap(hs.AdminProvisioningReloadPlugins))
 		adminRoute.Post("/provisioning/datasources/reload", Wrap(hs.AdminProvisioningReloadDatasources))
 		adminRoute.Post("/provisioning/notifications/reload", Wrap(hs.AdminProvisioningReloadNotifications))
+		adminRoute.Post("/provisioning/orgs/reload", Wrap(hs.AdminProvisioningReloadOrgs))
+		adminRoute.Get("/provisioning/status", Wrap(hs.AdminProvisioningGetStatus))
+		adminRoute.Post("/provisioning/status/revert", Wrap(hs.AdminProvisioningRevertDrift))
+		adminRoute.Post("/plugins/:pluginId/install", bind(dtos.InstallPluginCommand{}), Wrap(hs.AdminInstallPlugin))
+		adminRoute.Post("/plugins/:pluginId/update", bind(dtos.InstallPluginCommand{}), Wrap(hs.AdminUpdatePlugin))
+		adminRoute.Post("/plugins/:pluginId/uninstall", Wrap(hs.AdminUninstallPlugin))
+		adminRoute.Post("/plugins/:pluginId/restart", Wrap(hs.AdminRestartPlugin))
+		adminRoute.Post("/encryption/rotate-data-keys", Wrap(hs.AdminRotateDataKeys))
+		adminRoute.Post("/encryption/reencrypt-secrets", Wrap(hs.AdminReEncryptSecrets))
+		adminRoute.Post("/aws/credentials-cache/flush", Wrap(AdminFlushAWSCredentialsCache))
+		adminRoute.Get("/migrations/status", Wrap(hs.AdminGetMigrationStatus))
 		adminRoute.Post("/ldap/reload", Wrap(hs.ReloadLDAPCfg))
 		adminRoute.Post("/ldap/sync/:id", Wrap(hs.PostSyncUserWithLDAP))
 		adminRoute.Get("/ldap/:username", Wrap(hs.GetUserFromLDAP))
@@ -425,17 +519,26 @@ func (hs *HTTPServer) registerRoutes() {
 	r.Get("/avatar/:hash", avatarCacheServer.Handler)
 
 	// Websocket
-	r.Any("/ws", hs.streamManager.Serve)
+	r.Any("/ws", reqSignedIn, hs.streamManager.Serve)
 
 	// streams
 	//r.Post("/api/streams/push", reqSignedIn, bind(dtos.StreamMessage{}), liveConn.PushToStream)
 
 	// Snapshots
-	r.Post("/api/snapshots/", reqSnapshotPublicModeOrSignedIn, bind(models.CreateDashboardSnapshotCommand{}), CreateDashboardSnapshot)
+	r.Post("/api/snapshots/", reqSnapshotPublicModeOrSignedIn, quota("snapshot"), bind(models.CreateDashboardSnapshotCommand{}), CreateDashboardSnapshot)
 	r.Get("/api/snapshot/shared-options/", reqSignedIn, GetSharingOptions)
 	r.Get("/api/snapshots/:key", GetDashboardSnapshot)
 	r.Get("/api/snapshots-delete/:deleteKey", reqSnapshotPublicModeOrSignedIn, Wrap(DeleteDashboardSnapshotByDeleteKey))
 	r.Delete("/api/snapshots/:key", reqEditorRole, Wrap(DeleteDashboardSnapshot))
 
+	// Playlist kiosks
+	r.Get("/api/playlist-kiosk", Wrap(GetKioskPlaylist))
+	r.Get("/api/playlist-kiosk/dashboards/:uid", Wrap(GetKioskPlaylistDashboard))
+
+	// Kiosk devices
+	r.Get("/api/kiosk-device", Wrap(GetKioskDeviceKiosk))
+	r.Get("/api/kiosk-device/dashboards/:uid", Wrap(GetKioskDeviceDashboard))
+	r.Any("/api/kiosk-device/ws", hs.ServeKioskDeviceLive)
+
 	r.Get("/*", reqSignedIn, hs.Index)
 }
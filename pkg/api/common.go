@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 
 	"github.com/grafana/grafana/pkg/models"
@@ -118,6 +119,12 @@ func Error(status int, message string, err error) *NormalResponse {
 	return resp
 }
 
+// Conflict creates the response of a request conflicting with the current state of a resource,
+// the status telling the kind of conflict, like version-mismatch or name-exists
+func Conflict(status string, err error) *NormalResponse {
+	return JSON(409, map[string]interface{}{"status": status, "message": err.Error()})
+}
+
 // Respond create a response
 func Respond(status int, body interface{}) *NormalResponse {
 	var b []byte
@@ -150,3 +157,36 @@ func (r *RedirectResponse) WriteTo(ctx *models.ReqContext) {
 func Redirect(location string) *RedirectResponse {
 	return &RedirectResponse{location: location}
 }
+
+// StreamingResponse writes the body directly to the response, for bodies that
+// are too large to be kept in memory
+type StreamingResponse struct {
+	status int
+	header http.Header
+	stream func(w io.Writer) error
+}
+
+func (r *StreamingResponse) WriteTo(ctx *models.ReqContext) {
+	header := ctx.Resp.Header()
+	for k, v := range r.header {
+		header[k] = v
+	}
+	ctx.Resp.WriteHeader(r.status)
+	if err := r.stream(ctx.Resp); err != nil {
+		ctx.Logger.Error("Error streaming response", "err", err)
+	}
+}
+
+func (r *StreamingResponse) Header(key, value string) *StreamingResponse {
+	r.header.Set(key, value)
+	return r
+}
+
+// Stream creates a response that is written by stream
+func Stream(status int, stream func(w io.Writer) error) *StreamingResponse {
+	return &StreamingResponse{
+		status: status,
+		header: make(http.Header),
+		stream: stream,
+	}
+}
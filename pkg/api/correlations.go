@@ -0,0 +1,174 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// GET /api/datasources/:id/correlations
+func GetCorrelations(c *models.ReqContext) Response {
+	ds, rsp := getCorrelationSourceDataSource(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	query := models.GetCorrelationsBySourceQuery{SourceDatasourceId: ds.Id, OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get correlations", err)
+	}
+
+	return JSON(200, query.Result)
+}
+
+// GET /api/datasources/:id/correlations/:correlationUid
+func GetCorrelation(c *models.ReqContext) Response {
+	ds, rsp := getCorrelationSourceDataSource(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	query := models.GetCorrelationQuery{Uid: c.Params(":correlationUid"), SourceDatasourceId: ds.Id, OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return toCorrelationError(err)
+	}
+
+	return JSON(200, toCorrelationDTO(query.Result))
+}
+
+// POST /api/datasources/:id/correlations
+func CreateCorrelation(c *models.ReqContext, cmd models.CreateCorrelationCommand) Response {
+	ds, rsp := getCorrelationSourceDataSource(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	cmd.Label = strings.TrimSpace(cmd.Label)
+	cmd.Field = strings.TrimSpace(cmd.Field)
+	if err := validateCorrelation(cmd.Label, cmd.Field, cmd.Target); err != nil {
+		return toCorrelationError(err)
+	}
+
+	cmd.SourceDatasourceId = ds.Id
+	cmd.OrgId = c.OrgId
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toCorrelationError(err)
+	}
+
+	return JSON(200, util.DynMap{
+		"message":     "Correlation created",
+		"correlation": toCorrelationDTO(cmd.Result),
+	})
+}
+
+// PUT /api/datasources/:id/correlations/:correlationUid
+func UpdateCorrelation(c *models.ReqContext, cmd models.UpdateCorrelationCommand) Response {
+	ds, rsp := getCorrelationSourceDataSource(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	cmd.Label = strings.TrimSpace(cmd.Label)
+	cmd.Field = strings.TrimSpace(cmd.Field)
+	if err := validateCorrelation(cmd.Label, cmd.Field, cmd.Target); err != nil {
+		return toCorrelationError(err)
+	}
+
+	cmd.Uid = c.Params(":correlationUid")
+	cmd.SourceDatasourceId = ds.Id
+	cmd.OrgId = c.OrgId
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toCorrelationError(err)
+	}
+
+	return JSON(200, util.DynMap{
+		"message":     "Correlation updated",
+		"correlation": toCorrelationDTO(cmd.Result),
+	})
+}
+
+// DELETE /api/datasources/:id/correlations/:correlationUid
+func DeleteCorrelation(c *models.ReqContext) Response {
+	ds, rsp := getCorrelationSourceDataSource(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	cmd := models.DeleteCorrelationCommand{Uid: c.Params(":correlationUid"), SourceDatasourceId: ds.Id, OrgId: c.OrgId}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toCorrelationError(err)
+	}
+
+	return Success("Correlation deleted")
+}
+
+// getCorrelationsForQueryResponse returns the correlations that let the results of
+// a data source be linked to queries in other data sources. Failing to get them
+// should not fail the query, so errors are only logged.
+func (hs *HTTPServer) getCorrelationsForQueryResponse(ds *models.DataSource) []*models.CorrelationDTO {
+	query := models.GetCorrelationsBySourceQuery{SourceDatasourceId: ds.Id, OrgId: ds.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		hs.log.Warn("Failed to get correlations for data source", "datasourceId", ds.Id, "error", err)
+		return nil
+	}
+
+	return query.Result
+}
+
+func getCorrelationSourceDataSource(c *models.ReqContext) (*models.DataSource, Response) {
+	query := models.GetDataSourceByIdQuery{Id: c.ParamsInt64(":id"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		if err == models.ErrDataSourceNotFound {
+			return nil, Error(404, "Data source not found", nil)
+		}
+		return nil, Error(500, "Failed to query datasources", err)
+	}
+
+	return query.Result, nil
+}
+
+func validateCorrelation(label string, field string, target *simplejson.Json) error {
+	if label == "" {
+		return models.ErrCorrelationLabelEmpty
+	}
+
+	if field == "" {
+		return models.ErrCorrelationFieldEmpty
+	}
+
+	if target == nil || target.Interface() == nil {
+		return models.ErrCorrelationTargetEmpty
+	}
+
+	return nil
+}
+
+func toCorrelationDTO(correlation *models.Correlation) *models.CorrelationDTO {
+	return &models.CorrelationDTO{
+		Uid:                correlation.Uid,
+		SourceDatasourceId: correlation.SourceDatasourceId,
+		TargetDatasourceId: correlation.TargetDatasourceId,
+		Label:              correlation.Label,
+		Description:        correlation.Description,
+		Field:              correlation.Field,
+		Target:             correlation.Target,
+	}
+}
+
+func toCorrelationError(err error) Response {
+	if err == models.ErrCorrelationLabelEmpty ||
+		err == models.ErrCorrelationFieldEmpty ||
+		err == models.ErrCorrelationTargetEmpty ||
+		err == models.ErrCorrelationTargetDataSourceMissing {
+		return Error(400, err.Error(), nil)
+	}
+
+	if err == models.ErrCorrelationNotFound {
+		return Error(404, err.Error(), nil)
+	}
+
+	return Error(500, "Correlation API error", err)
+}
@@ -131,11 +131,30 @@ func (hs *HTTPServer) GetDashboard(c *models.ReqContext) Response {
 	// make sure db version is in sync with json model version
 	dash.Data.Set("version", dash.Version)
 
+	if err := loadLibraryPanelsForDashboard(c, dash.Data); err != nil {
+		return Error(500, "Error while loading library panels", err)
+	}
+
+	// the sensitive fields, like the tokens embedded in text panels, are only sent to the users
+	// who can change them
+	if !canSave {
+		models.RedactSensitiveFields(dash.Data)
+	}
+
 	dto := dtos.DashboardFullWithMeta{
 		Dashboard: dash.Data,
 		Meta:      meta,
 	}
 
+	hs.UsageInsights.Record(&models.UsageEvent{
+		EventType:    models.UsageEventDashboardView,
+		OrgId:        c.OrgId,
+		UserId:       c.UserId,
+		UserLogin:    c.Login,
+		DashboardId:  dash.Id,
+		DashboardUid: dash.Uid,
+	})
+
 	c.TimeRequest(metrics.MApiDashboardGet)
 	return JSON(200, dto)
 }
@@ -212,8 +231,43 @@ func (hs *HTTPServer) PostDashboard(c *models.ReqContext, cmd models.SaveDashboa
 	cmd.UserId = c.UserId
 
 	dash := cmd.GetDashboardModel()
+	return hs.saveDashboard(c, cmd, dash, dash.Id == 0 && dash.Uid == "", dashboardSaveErrorToApiResponse)
+}
+
+// PutDashboardByUID creates the dashboard with the uid of the path, or updates it if it exists. The
+// version of the dashboard is only checked when it's set, the conflicts returning 409, so that
+// the same request can be repeated.
+func (hs *HTTPServer) PutDashboardByUID(c *models.ReqContext, cmd models.SaveDashboardCommand) Response {
+	cmd.OrgId = c.OrgId
+	cmd.UserId = c.UserId
+
+	uid := c.Params(":uid")
+	cmd.Dashboard.Set("uid", uid)
+
+	query := models.GetDashboardQuery{OrgId: c.OrgId, Uid: uid}
+	err := bus.Dispatch(&query)
+	if err != nil && err != models.ErrDashboardNotFound {
+		return Error(500, "Failed to get dashboard", err)
+	}
 
-	newDashboard := dash.Id == 0 && dash.Uid == ""
+	newDashboard := err == models.ErrDashboardNotFound
+	if newDashboard {
+		cmd.Dashboard.Del("id")
+		cmd.Dashboard.Del("version")
+	} else {
+		cmd.Dashboard.Set("id", query.Result.Id)
+		// overwriting would also replace a dashboard with the same name, rather than conflict with it
+		if _, ok := cmd.Dashboard.CheckGet("version"); !ok || cmd.Overwrite {
+			cmd.Dashboard.Set("version", query.Result.Version)
+		}
+	}
+	cmd.Overwrite = false
+
+	return hs.saveDashboard(c, cmd, cmd.GetDashboardModel(), newDashboard, dashboardUpsertErrorToApiResponse)
+}
+
+func (hs *HTTPServer) saveDashboard(c *models.ReqContext, cmd models.SaveDashboardCommand, dash *models.Dashboard,
+	newDashboard bool, toApiResponse func(error) Response) Response {
 	if newDashboard {
 		limitReached, err := hs.QuotaService.QuotaReached(c, "dashboard")
 		if err != nil {
@@ -244,7 +298,17 @@ func (hs *HTTPServer) PostDashboard(c *models.ReqContext, cmd models.SaveDashboa
 
 	dashboard, err := dashboards.NewService().SaveDashboard(dashItem, allowUiUpdate)
 	if err != nil {
-		return dashboardSaveErrorToApiResponse(err)
+		return toApiResponse(err)
+	}
+
+	syncCmd := models.SyncLibraryPanelConnectionsCommand{
+		DashboardId: dashboard.Id,
+		OrgId:       c.OrgId,
+		UserId:      c.UserId,
+		Uids:        models.GetLibraryPanelUIDs(dash.Data),
+	}
+	if err := bus.Dispatch(&syncCmd); err != nil {
+		libraryPanelsLogger.Error("Could not update library panel connections", "dashboard", dashboard.Title, "error", err)
 	}
 
 	if hs.Cfg.EditorsCanAdmin && newDashboard {
@@ -266,6 +330,20 @@ func (hs *HTTPServer) PostDashboard(c *models.ReqContext, cmd models.SaveDashboa
 	})
 }
 
+// dashboardUpsertErrorToApiResponse is dashboardSaveErrorToApiResponse for PUT requests by uid,
+// returning 409 for the conflicts
+func dashboardUpsertErrorToApiResponse(err error) Response {
+	switch err {
+	case models.ErrDashboardWithSameNameInFolderExists:
+		return Conflict("name-exists", err)
+	case models.ErrDashboardVersionMismatch:
+		return Conflict("version-mismatch", err)
+	case models.ErrDashboardWithSameUIDExists:
+		return Conflict("uid-exists", err)
+	}
+	return dashboardSaveErrorToApiResponse(err)
+}
+
 func dashboardSaveErrorToApiResponse(err error) Response {
 	if err == models.ErrDashboardTitleEmpty ||
 		err == models.ErrDashboardWithSameNameAsFolder ||
@@ -276,6 +354,7 @@ func dashboardSaveErrorToApiResponse(err error) Response {
 		err == models.ErrDashboardWithSameUIDExists ||
 		err == models.ErrFolderNotFound ||
 		err == models.ErrDashboardFolderCannotHaveParent ||
+		err == models.ErrDashboardFolderNestingTooDeep ||
 		err == models.ErrDashboardFolderNameExists ||
 		err == models.ErrDashboardRefreshIntervalTooShort ||
 		err == models.ErrDashboardCannotSaveProvisionedDashboard {
@@ -301,7 +380,7 @@ func dashboardSaveErrorToApiResponse(err error) Response {
 	if pluginErr, ok := err.(models.UpdatePluginDashboardError); ok {
 		message := "The dashboard belongs to plugin " + pluginErr.PluginId + "."
 		// look up plugin name
-		if pluginDef, exist := plugins.Plugins[pluginErr.PluginId]; exist {
+		if pluginDef, exist := plugins.GetPlugin(pluginErr.PluginId); exist {
 			message = "The dashboard belongs to plugin " + pluginDef.Name + "."
 		}
 		return JSON(412, util.DynMap{"status": "plugin-dashboard", "message": message})
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/templating"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// POST /api/dashboards/uid/:uid/interpolate
+func (hs *HTTPServer) InterpolateDashboard(c *models.ReqContext, cmd dtos.InterpolateDashboardCommand) Response {
+	dash, rsp := getDashboardHelper(c.OrgId, "", 0, c.Params(":uid"))
+	if rsp != nil {
+		return rsp
+	}
+
+	g := guardian.New(dash.Id, c.OrgId, c.SignedInUser)
+	if canView, err := g.CanView(); err != nil || !canView {
+		return dashboardGuardianResponse(err)
+	}
+	if canSave, _ := g.CanSave(); !canSave {
+		models.RedactSensitiveFields(dash.Data)
+	}
+
+	values := make(map[string][]string, len(cmd.Variables))
+	for name, value := range cmd.Variables {
+		switch v := value.(type) {
+		case string:
+			values[name] = []string{v}
+		case []interface{}:
+			for _, item := range v {
+				values[name] = append(values[name], fmt.Sprint(item))
+			}
+		default:
+			return Error(400, fmt.Sprintf("Value of variable %s must be a string or an array of strings", name), nil)
+		}
+	}
+
+	from := cmd.From
+	if from == "" {
+		from = dash.Data.GetPath("time", "from").MustString("now-6h")
+	}
+	to := cmd.To
+	if to == "" {
+		to = dash.Data.GetPath("time", "to").MustString("now")
+	}
+
+	result, err := templating.Resolve(c.Req.Context(), templating.ResolveOptions{
+		Dashboard:       dash,
+		User:            c.SignedInUser,
+		TimeRange:       tsdb.NewTimeRange(from, to),
+		Values:          values,
+		DatasourceCache: hs.DatasourceCache,
+	})
+	if err != nil {
+		return Error(500, "Failed to interpolate dashboard", err)
+	}
+
+	return JSON(200, result)
+}
@@ -42,6 +42,57 @@ func GetDashboardPermissionList(c *models.ReqContext) Response {
 	return JSON(200, acl)
 }
 
+// GET /api/dashboards/id/:dashboardId/permissions/preview/:userId
+func GetDashboardPermissionPreview(c *models.ReqContext) Response {
+	dashID := c.ParamsInt64(":dashboardId")
+
+	dash, rsp := getDashboardHelper(c.OrgId, "", dashID, "")
+	if rsp != nil {
+		return rsp
+	}
+
+	g := guardian.New(dashID, c.OrgId, c.SignedInUser)
+	if canAdmin, err := g.CanAdmin(); err != nil || !canAdmin {
+		return dashboardGuardianResponse(err)
+	}
+
+	return permissionPreviewHelper(c, dash)
+}
+
+// permissionPreviewHelper returns the effective permission for the dashboard or folder
+// of the user in the :userId param
+func permissionPreviewHelper(c *models.ReqContext, dash *models.Dashboard) Response {
+	query := models.GetSignedInUserQuery{UserId: c.ParamsInt64(":userId"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		if err == models.ErrUserNotFound {
+			return Error(404, "User not found", err)
+		}
+		return Error(500, "Failed to get user", err)
+	}
+
+	if query.Result.OrgId != c.OrgId {
+		return Error(400, "User is not a member of the organization", nil)
+	}
+
+	preview, err := guardian.GetPermissionPreview(dash, query.Result)
+	if err != nil {
+		return Error(500, "Failed to get permission preview", err)
+	}
+
+	for _, perm := range preview.MatchingRules {
+		perm.UserAvatarUrl = dtos.GetGravatarUrl(perm.UserEmail)
+
+		if perm.TeamId > 0 {
+			perm.TeamAvatarUrl = dtos.GetGravatarUrlWithDefault(perm.TeamEmail, perm.Team)
+		}
+		if perm.Slug != "" {
+			perm.Url = models.GetDashboardFolderUrl(perm.IsFolder, perm.Uid, perm.Slug)
+		}
+	}
+
+	return JSON(200, preview)
+}
+
 func UpdateDashboardPermissions(c *models.ReqContext, apiCmd dtos.UpdateDashboardAclCommand) Response {
 	dashID := c.ParamsInt64(":dashboardId")
 
@@ -78,6 +78,9 @@ func CreateDashboardSnapshot(c *models.ReqContext, cmd models.CreateDashboardSna
 	cmd.OrgId = c.OrgId
 	cmd.UserId = c.UserId
 
+	// snapshots are shared with anyone who has their key, so they never hold sensitive fields
+	models.RedactSensitiveFields(cmd.Dashboard)
+
 	if cmd.External {
 		if !setting.ExternalEnabled {
 			c.JsonApiErr(403, "External dashboard creation is disabled", nil)
@@ -306,3 +309,37 @@ func SearchDashboardSnapshots(c *models.ReqContext) Response {
 
 	return JSON(200, dtos)
 }
+
+// GET /api/admin/snapshots
+func AdminSearchDashboardSnapshots(c *models.ReqContext) Response {
+	perPage := c.QueryInt("perpage")
+	if perPage <= 0 {
+		perPage = 1000
+	}
+	page := c.QueryInt("page")
+	if page < 1 {
+		page = 1
+	}
+
+	query := models.SearchAllDashboardSnapshotsQuery{
+		Query:  c.Query("query"),
+		OrgId:  c.QueryInt64("orgId"),
+		UserId: c.QueryInt64("userId"),
+		Page:   page,
+		Limit:  perPage,
+	}
+
+	if expired := c.Query("expired"); expired != "" {
+		isExpired := c.QueryBool("expired")
+		query.Expired = &isExpired
+	}
+
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to search snapshots", err)
+	}
+
+	query.Result.Page = page
+	query.Result.PerPage = perPage
+
+	return JSON(200, query.Result)
+}
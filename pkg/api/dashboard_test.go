@@ -780,6 +780,7 @@ func TestDashboardApiEndpoint(t *testing.T) {
 				{SaveError: models.ErrDashboardVersionMismatch, ExpectedStatusCode: 412},
 				{SaveError: models.ErrDashboardTitleEmpty, ExpectedStatusCode: 400},
 				{SaveError: models.ErrDashboardFolderCannotHaveParent, ExpectedStatusCode: 400},
+				{SaveError: models.ErrDashboardFolderNestingTooDeep, ExpectedStatusCode: 400},
 				{SaveError: alerting.ValidationError{Reason: "Mu"}, ExpectedStatusCode: 422},
 				{SaveError: models.ErrDashboardFailedGenerateUniqueUid, ExpectedStatusCode: 500},
 				{SaveError: models.ErrDashboardTypeMismatch, ExpectedStatusCode: 400},
@@ -811,6 +812,40 @@ func TestDashboardApiEndpoint(t *testing.T) {
 				})
 			}
 		})
+
+		Convey("Given conflicting requests for creating or updating a dashboard by uid", func() {
+			testCases := []struct {
+				SaveError          error
+				ExpectedStatusCode int
+			}{
+				{SaveError: models.ErrDashboardWithSameNameInFolderExists, ExpectedStatusCode: 409},
+				{SaveError: models.ErrDashboardVersionMismatch, ExpectedStatusCode: 409},
+				{SaveError: models.ErrDashboardWithSameUIDExists, ExpectedStatusCode: 409},
+				{SaveError: models.ErrDashboardTitleEmpty, ExpectedStatusCode: 400},
+			}
+
+			cmd := models.SaveDashboardCommand{
+				OrgId: 1,
+				Dashboard: simplejson.NewFromAny(map[string]interface{}{
+					"title": "Dash",
+				}),
+			}
+
+			for _, tc := range testCases {
+				mock := &dashboards.FakeDashboardService{
+					SaveDashboardError: tc.SaveError,
+				}
+
+				putDashboardScenario(fmt.Sprintf("Expect '%s' error when calling PUT on", tc.SaveError.Error()), "/api/dashboards/uid/dash", "/api/dashboards/uid/:uid", mock, cmd, func(sc *scenarioContext) {
+					bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+						return models.ErrDashboardNotFound
+					})
+
+					sc.fakeReqWithParams("PUT", sc.url, map[string]string{}).exec()
+					So(sc.resp.Code, ShouldEqual, tc.ExpectedStatusCode)
+				})
+			}
+		})
 	})
 
 	Convey("Given two dashboards being compared", t, func() {
@@ -1141,6 +1176,10 @@ func postDashboardScenario(desc string, url string, routePattern string, mock *d
 			return hs.PostDashboard(c, cmd)
 		})
 
+		bus.AddHandler("test", func(cmd *models.SyncLibraryPanelConnectionsCommand) error {
+			return nil
+		})
+
 		origNewDashboardService := dashboards.NewService
 		dashboards.MockDashboardService(mock)
 
@@ -1160,6 +1199,47 @@ func postDashboardScenario(desc string, url string, routePattern string, mock *d
 	})
 }
 
+func putDashboardScenario(desc string, url string, routePattern string, mock *dashboards.FakeDashboardService, cmd models.SaveDashboardCommand, fn scenarioFunc) {
+	Convey(desc+" "+url, func() {
+		defer bus.ClearBusHandlers()
+
+		hs := HTTPServer{
+			Bus:                 bus.GetBus(),
+			Cfg:                 setting.NewCfg(),
+			ProvisioningService: provisioning.NewProvisioningServiceMock(),
+		}
+
+		sc := setupScenarioContext(url)
+		sc.defaultHandler = Wrap(func(c *models.ReqContext) Response {
+			sc.context = c
+			sc.context.SignedInUser = &models.SignedInUser{OrgId: cmd.OrgId, UserId: cmd.UserId}
+
+			return hs.PutDashboardByUID(c, cmd)
+		})
+
+		bus.AddHandler("test", func(cmd *models.SyncLibraryPanelConnectionsCommand) error {
+			return nil
+		})
+
+		origNewDashboardService := dashboards.NewService
+		dashboards.MockDashboardService(mock)
+
+		origProvisioningService := dashboards.NewProvisioningService
+		dashboards.NewProvisioningService = func() dashboards.DashboardProvisioningService {
+			return mockDashboardProvisioningService{}
+		}
+
+		sc.m.Put(routePattern, sc.defaultHandler)
+
+		defer func() {
+			dashboards.NewService = origNewDashboardService
+			dashboards.NewProvisioningService = origProvisioningService
+		}()
+
+		fn(sc)
+	})
+}
+
 func postDiffScenario(desc string, url string, routePattern string, cmd dtos.CalculateDiffOptions, role models.RoleType, fn scenarioFunc) {
 	Convey(desc+" "+url, func() {
 		defer bus.ClearBusHandlers()
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const dashboardGridColumnCount = 24
+
+var builtInDataSourceNames = map[string]bool{
+	"-- Grafana --":   true,
+	"-- Mixed --":     true,
+	"-- Dashboard --": true,
+}
+
+var templateVariableTypes = map[string]bool{
+	"query":      true,
+	"custom":     true,
+	"constant":   true,
+	"datasource": true,
+	"interval":   true,
+	"textbox":    true,
+	"adhoc":      true,
+}
+
+// ValidateDashboard checks a dashboard JSON model without saving it and reports
+// the problems found as errors and warnings.
+func ValidateDashboard(c *models.ReqContext, cmd dtos.ValidateDashboardCommand) Response {
+	result, err := validateDashboardJSON(c.OrgId, c.SignedInUser, cmd.Dashboard)
+	if err != nil {
+		return Error(500, "Failed to validate dashboard", err)
+	}
+
+	return JSON(200, result)
+}
+
+type dashboardValidator struct {
+	dataSources map[string]bool
+	panelIds    map[int64]string
+	result      *dtos.DashboardValidationResult
+}
+
+func validateDashboardJSON(orgId int64, user *models.SignedInUser, dashboard *simplejson.Json) (*dtos.DashboardValidationResult, error) {
+	query := models.GetDataSourcesQuery{OrgId: orgId, User: user}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+
+	v := &dashboardValidator{
+		dataSources: make(map[string]bool),
+		panelIds:    make(map[int64]string),
+		result: &dtos.DashboardValidationResult{
+			Errors:   make([]*dtos.DashboardValidationIssue, 0),
+			Warnings: make([]*dtos.DashboardValidationIssue, 0),
+		},
+	}
+
+	for _, ds := range query.Result {
+		v.dataSources[ds.Name] = true
+	}
+
+	v.validateDashboard(dashboard)
+	v.result.Valid = len(v.result.Errors) == 0
+
+	return v.result, nil
+}
+
+func (v *dashboardValidator) addError(path string, format string, args ...interface{}) {
+	v.result.Errors = append(v.result.Errors, &dtos.DashboardValidationIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *dashboardValidator) addWarning(path string, format string, args ...interface{}) {
+	v.result.Warnings = append(v.result.Warnings, &dtos.DashboardValidationIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *dashboardValidator) validateDashboard(dashboard *simplejson.Json) {
+	if _, err := dashboard.Map(); err != nil {
+		v.addError("", "Dashboard must be a JSON object")
+		return
+	}
+
+	dash := models.NewDashboardFromJson(dashboard)
+
+	if strings.TrimSpace(dash.Title) == "" {
+		v.addError("title", models.ErrDashboardTitleEmpty.Error())
+	}
+
+	if !util.IsValidShortUID(dash.Uid) {
+		v.addError("uid", models.ErrDashboardInvalidUid.Error())
+	} else if len(dash.Uid) > 40 {
+		v.addError("uid", models.ErrDashboardUidToLong.Error())
+	}
+
+	if _, ok := dashboard.CheckGet("schemaVersion"); !ok {
+		v.addWarning("schemaVersion", "Schema version is missing, the dashboard will be migrated from the oldest version")
+	}
+
+	if err := dashboards.ValidateDashboardRefreshInterval(dash); err != nil {
+		if err == models.ErrDashboardRefreshIntervalTooShort {
+			v.addWarning("refresh", "%s, the minimum refresh interval will be used", err.Error())
+		} else {
+			v.addError("refresh", "Invalid refresh interval: %s", err.Error())
+		}
+	}
+
+	if panels, ok := dashboard.CheckGet("panels"); ok {
+		v.validatePanels("panels", panels)
+	}
+
+	if list := dashboard.GetPath("templating", "list"); list.Interface() != nil {
+		v.validateTemplating("templating.list", list)
+	}
+
+	for i, annotation := range dashboard.GetPath("annotations", "list").MustArray() {
+		path := fmt.Sprintf("annotations.list[%d]", i)
+		v.validateDataSourceRef(path+".datasource", simplejson.NewFromAny(annotation).Get("datasource"))
+	}
+}
+
+func (v *dashboardValidator) validatePanels(path string, panels *simplejson.Json) {
+	list, err := panels.Array()
+	if err != nil {
+		v.addError(path, "Panels must be an array")
+		return
+	}
+
+	for i, p := range list {
+		panelPath := fmt.Sprintf("%s[%d]", path, i)
+		panel := simplejson.NewFromAny(p)
+		if _, err := panel.Map(); err != nil {
+			v.addError(panelPath, "Panel must be a JSON object")
+			continue
+		}
+
+		v.validatePanel(panelPath, panel)
+	}
+}
+
+func (v *dashboardValidator) validatePanel(path string, panel *simplejson.Json) {
+	if id, err := panel.Get("id").Int64(); err != nil {
+		v.addWarning(path+".id", "Panel has no id, one will be assigned when the dashboard is loaded")
+	} else if other, exists := v.panelIds[id]; exists {
+		v.addError(path+".id", "Panel id %d is already used by %s", id, other)
+	} else {
+		v.panelIds[id] = path
+	}
+
+	panelType := panel.Get("type").MustString()
+	if panelType == "" {
+		v.addError(path+".type", "Panel type is missing")
+	} else if _, exists := plugins.GetPanel(panelType); !exists && panelType != "row" {
+		v.addWarning(path+".type", "Panel plugin %s is not installed", panelType)
+	}
+
+	v.validateGridPos(path+".gridPos", panel)
+
+	// library panels are replaced by their stored model when the dashboard is loaded
+	if panel.GetPath("libraryPanel", "uid").MustString() != "" {
+		return
+	}
+
+	v.validateDataSourceRef(path+".datasource", panel.Get("datasource"))
+	for i, target := range panel.Get("targets").MustArray() {
+		v.validateDataSourceRef(fmt.Sprintf("%s.targets[%d].datasource", path, i), simplejson.NewFromAny(target).Get("datasource"))
+	}
+
+	if panels, ok := panel.CheckGet("panels"); ok {
+		if panelType != "row" {
+			v.addWarning(path+".panels", "Only row panels can contain other panels")
+		}
+		v.validatePanels(path+".panels", panels)
+	}
+}
+
+func (v *dashboardValidator) validateGridPos(path string, panel *simplejson.Json) {
+	gridPos, ok := panel.CheckGet("gridPos")
+	if !ok {
+		v.addWarning(path, "Panel has no grid position, it will be placed at the top of the dashboard")
+		return
+	}
+
+	values := map[string]int64{}
+	for _, key := range []string{"x", "y", "w", "h"} {
+		value, err := gridPos.Get(key).Int64()
+		if err != nil {
+			v.addError(path+"."+key, "Grid position %s must be a number", key)
+			return
+		}
+		values[key] = value
+	}
+
+	if values["x"] < 0 || values["y"] < 0 {
+		v.addError(path, "Grid position cannot be negative")
+	}
+
+	if values["w"] <= 0 || values["h"] <= 0 {
+		v.addError(path, "Grid width and height must be greater than zero")
+	}
+
+	if values["x"]+values["w"] > dashboardGridColumnCount {
+		v.addError(path, "Panel does not fit in the %d columns of the grid", dashboardGridColumnCount)
+	}
+}
+
+func (v *dashboardValidator) validateTemplating(path string, list *simplejson.Json) {
+	variables, err := list.Array()
+	if err != nil {
+		v.addError(path, "Template variables must be an array")
+		return
+	}
+
+	names := make(map[string]bool)
+	for i, item := range variables {
+		variablePath := fmt.Sprintf("%s[%d]", path, i)
+		variable := simplejson.NewFromAny(item)
+
+		name := variable.Get("name").MustString()
+		if name == "" {
+			v.addError(variablePath+".name", "Template variable name is missing")
+		} else if names[name] {
+			v.addError(variablePath+".name", "Template variable %s is defined more than once", name)
+		}
+		names[name] = true
+
+		variableType := variable.Get("type").MustString()
+		if !templateVariableTypes[variableType] {
+			v.addWarning(variablePath+".type", "Unknown template variable type %s", variableType)
+		}
+
+		if variableType == "query" || variableType == "adhoc" {
+			v.validateDataSourceRef(variablePath+".datasource", variable.Get("datasource"))
+		}
+	}
+}
+
+// validateDataSourceRef checks that a data source referenced by name exists in the org.
+// Missing references use the default data source and references to template variables
+// can only be resolved when the dashboard is loaded.
+func (v *dashboardValidator) validateDataSourceRef(path string, ref *simplejson.Json) {
+	if ref.Interface() == nil {
+		return
+	}
+
+	name, err := ref.String()
+	if err != nil {
+		v.addError(path, "Data source must be referenced by name")
+		return
+	}
+
+	if name == "" || builtInDataSourceNames[name] || strings.HasPrefix(name, "$") {
+		return
+	}
+
+	if !v.dataSources[name] {
+		v.addError(path, "Data source %s not found", name)
+	}
+}
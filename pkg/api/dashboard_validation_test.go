@@ -0,0 +1,126 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+func TestValidateDashboardJSON(t *testing.T) {
+	bus.ClearBusHandlers()
+	defer bus.ClearBusHandlers()
+
+	bus.AddHandler("test", func(query *models.GetDataSourcesQuery) error {
+		query.Result = []*models.DataSource{{Name: "Prometheus"}}
+		return nil
+	})
+
+	origPanels := plugins.Panels
+	plugins.Panels = map[string]*plugins.PanelPlugin{"graph": {}}
+	defer func() { plugins.Panels = origPanels }()
+
+	validate := func(t *testing.T, dashboard map[string]interface{}) *dtos.DashboardValidationResult {
+		result, err := validateDashboardJSON(1, &models.SignedInUser{OrgId: 1}, simplejson.NewFromAny(dashboard))
+		require.NoError(t, err)
+		return result
+	}
+
+	paths := func(issues []*dtos.DashboardValidationIssue) []string {
+		result := make([]string, 0, len(issues))
+		for _, issue := range issues {
+			result = append(result, issue.Path)
+		}
+		return result
+	}
+
+	t.Run("A valid dashboard has no errors or warnings", func(t *testing.T) {
+		result := validate(t, map[string]interface{}{
+			"title":         "Valid",
+			"uid":           "valid",
+			"schemaVersion": 25,
+			"panels": []interface{}{
+				map[string]interface{}{
+					"id":         1,
+					"type":       "graph",
+					"datasource": "Prometheus",
+					"gridPos":    map[string]interface{}{"x": 0, "y": 0, "w": 12, "h": 8},
+				},
+			},
+			"templating": map[string]interface{}{
+				"list": []interface{}{
+					map[string]interface{}{"name": "instance", "type": "query", "datasource": "$ds"},
+				},
+			},
+		})
+
+		assert.True(t, result.Valid)
+		assert.Empty(t, result.Errors)
+		assert.Empty(t, result.Warnings)
+	})
+
+	t.Run("Reports errors for invalid panels, data sources and variables", func(t *testing.T) {
+		result := validate(t, map[string]interface{}{
+			"title":         "",
+			"schemaVersion": 25,
+			"panels": []interface{}{
+				map[string]interface{}{
+					"id":         1,
+					"type":       "graph",
+					"datasource": "Unknown",
+					"gridPos":    map[string]interface{}{"x": 20, "y": 0, "w": 12, "h": 8},
+				},
+				map[string]interface{}{
+					"id":      2,
+					"type":    "row",
+					"gridPos": map[string]interface{}{"x": 0, "y": 8, "w": 24, "h": 1},
+					"panels": []interface{}{
+						map[string]interface{}{
+							"id":      1,
+							"gridPos": map[string]interface{}{"x": 0, "y": 9, "w": 24, "h": 8},
+						},
+					},
+				},
+			},
+			"templating": map[string]interface{}{
+				"list": []interface{}{
+					map[string]interface{}{"name": "instance", "type": "custom"},
+					map[string]interface{}{"name": "instance", "type": "custom"},
+				},
+			},
+		})
+
+		assert.False(t, result.Valid)
+		assert.ElementsMatch(t, []string{
+			"title",
+			"panels[0].gridPos",
+			"panels[0].datasource",
+			"panels[1].panels[0].id",
+			"panels[1].panels[0].type",
+			"templating.list[1].name",
+		}, paths(result.Errors))
+	})
+
+	t.Run("Reports warnings for things that are fixed when the dashboard is loaded", func(t *testing.T) {
+		result := validate(t, map[string]interface{}{
+			"title": "Warnings",
+			"panels": []interface{}{
+				map[string]interface{}{"type": "worldmap-panel"},
+			},
+		})
+
+		assert.True(t, result.Valid)
+		assert.ElementsMatch(t, []string{
+			"schemaVersion",
+			"panels[0].id",
+			"panels[0].type",
+			"panels[0].gridPos",
+		}, paths(result.Warnings))
+	})
+}
@@ -27,7 +27,7 @@ func (hs *HTTPServer) ProxyDataSourceRequest(c *models.ReqContext) {
 	}
 
 	// find plugin
-	plugin, ok := plugins.DataSources[ds.Type]
+	plugin, ok := plugins.GetDataSource(ds.Type)
 	if !ok {
 		c.JsonApiErr(500, "Unable to find datasource plugin", err)
 		return
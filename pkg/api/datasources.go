@@ -2,17 +2,22 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/api/datasource"
 	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/pluginproxy"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/grafana/grafana/pkg/plugins/datasource/wrapper"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
 	"github.com/grafana/grafana/pkg/util"
 )
 
@@ -43,7 +48,7 @@ func GetDataSources(c *models.ReqContext) Response {
 			ReadOnly:  ds.ReadOnly,
 		}
 
-		if plugin, exists := plugins.DataSources[ds.Type]; exists {
+		if plugin, exists := plugins.GetDataSource(ds.Type); exists {
 			dsItem.TypeLogoUrl = plugin.Info.Logos.Small
 		} else {
 			dsItem.TypeLogoUrl = "public/img/icn-datasource.svg"
@@ -92,6 +97,11 @@ func DeleteDataSourceById(c *models.ReqContext) Response {
 		return Error(403, "Cannot delete read-only data source", nil)
 	}
 
+	usage, resp := checkDataSourceUsageBeforeDelete(c, ds)
+	if resp != nil {
+		return resp
+	}
+
 	cmd := &models.DeleteDataSourceByIdCommand{Id: id, OrgId: c.OrgId}
 
 	err = bus.Dispatch(cmd)
@@ -99,7 +109,7 @@ func DeleteDataSourceById(c *models.ReqContext) Response {
 		return Error(500, "Failed to delete datasource", err)
 	}
 
-	return Success("Data source deleted")
+	return dataSourceDeleted(usage)
 }
 
 func DeleteDataSourceByName(c *models.ReqContext) Response {
@@ -121,13 +131,56 @@ func DeleteDataSourceByName(c *models.ReqContext) Response {
 		return Error(403, "Cannot delete read-only data source", nil)
 	}
 
+	usage, resp := checkDataSourceUsageBeforeDelete(c, getCmd.Result)
+	if resp != nil {
+		return resp
+	}
+
 	cmd := &models.DeleteDataSourceByNameCommand{Name: name, OrgId: c.OrgId}
 	err := bus.Dispatch(cmd)
 	if err != nil {
 		return Error(500, "Failed to delete datasource", err)
 	}
 
-	return Success("Data source deleted")
+	return dataSourceDeleted(usage)
+}
+
+// checkDataSourceUsageBeforeDelete returns the usage of a data source about to be deleted, and a
+// 409 response when it's in use and deleting the used data sources is blocked. A failure to get the
+// usage doesn't prevent the deletion.
+func checkDataSourceUsageBeforeDelete(c *models.ReqContext, ds *models.DataSource) (*models.DataSourceUsage, Response) {
+	query := &models.GetDataSourceUsageQuery{OrgId: c.OrgId, DataSource: ds}
+	if err := bus.Dispatch(query); err != nil {
+		datasourcesLogger.Warn("Failed to get the usage of the data source to delete", "uid", ds.Uid, "error", err)
+		return nil, nil
+	}
+
+	if !query.Result.InUse() {
+		return nil, nil
+	}
+
+	if setting.BlockDeletingUsedDataSources {
+		return nil, JSON(409, util.DynMap{
+			"message": models.ErrDataSourceInUse.Error(),
+			"usage":   query.Result,
+		})
+	}
+
+	datasourcesLogger.Warn("Deleting a data source still in use", "uid", ds.Uid, "dashboards", len(query.Result.Dashboards),
+		"alertRules", len(query.Result.AlertRules), "annotations", len(query.Result.Annotations))
+	return query.Result, nil
+}
+
+func dataSourceDeleted(usage *models.DataSourceUsage) Response {
+	if usage == nil {
+		return Success("Data source deleted")
+	}
+
+	return JSON(200, util.DynMap{
+		"message": "Data source deleted",
+		"warning": models.ErrDataSourceInUse.Error(),
+		"usage":   usage,
+	})
 }
 
 func validateURL(tp string, u string) Response {
@@ -148,6 +201,9 @@ func AddDataSource(c *models.ReqContext, cmd models.AddDataSourceCommand) Respon
 	if resp := validateURL(cmd.Type, cmd.Url); resp != nil {
 		return resp
 	}
+	if resp := validateSecretReferences(c.OrgId, cmd.SecureJsonData); resp != nil {
+		return resp
+	}
 
 	if err := bus.Dispatch(&cmd); err != nil {
 		if err == models.ErrDataSourceNameExists || err == models.ErrDataSourceUidExists {
@@ -173,6 +229,9 @@ func UpdateDataSource(c *models.ReqContext, cmd models.UpdateDataSourceCommand)
 	if resp := validateURL(cmd.Type, cmd.Url); resp != nil {
 		return resp
 	}
+	if resp := validateSecretReferences(c.OrgId, cmd.SecureJsonData); resp != nil {
+		return resp
+	}
 
 	err := fillWithSecureJSONData(&cmd)
 	if err != nil {
@@ -209,6 +268,114 @@ func UpdateDataSource(c *models.ReqContext, cmd models.UpdateDataSourceCommand)
 	})
 }
 
+// PUT /api/datasources/uid/:uid
+// PutDataSourceByUID creates the data source with the uid of the path, or updates it if it exists.
+// The version of the data source is only checked when it's set, the conflicts returning 409, so
+// that the same request can be repeated.
+func (hs *HTTPServer) PutDataSourceByUID(c *models.ReqContext, cmd models.UpdateDataSourceCommand) Response {
+	cmd.OrgId = c.OrgId
+	cmd.Uid = c.Params(":uid")
+	if resp := validateURL(cmd.Type, cmd.Url); resp != nil {
+		return resp
+	}
+	if resp := validateSecretReferences(c.OrgId, cmd.SecureJsonData); resp != nil {
+		return resp
+	}
+
+	query := models.GetDataSourceByUidQuery{Uid: cmd.Uid, OrgId: c.OrgId}
+	err := bus.Dispatch(&query)
+	if err == models.ErrDataSourceNotFound {
+		return hs.addDataSourceWithUID(c, cmd)
+	}
+	if err != nil {
+		return Error(500, "Failed to query datasources", err)
+	}
+
+	current := query.Result
+	if current.ReadOnly {
+		return Error(403, models.ErrDatasourceIsReadOnly.Error(), nil)
+	}
+	if cmd.Version != 0 && cmd.Version != current.Version {
+		return Conflict("version-mismatch", models.ErrDataSourceUpdatingOldVersion)
+	}
+	cmd.Id = current.Id
+	cmd.Version = current.Version
+
+	sameName := models.GetDataSourceByNameQuery{Name: cmd.Name, OrgId: c.OrgId}
+	if err := bus.Dispatch(&sameName); err == nil && sameName.Result.Id != current.Id {
+		return Conflict("name-exists", models.ErrDataSourceNameExists)
+	}
+
+	if err := fillWithSecureJSONData(&cmd); err != nil {
+		return Error(500, "Failed to update datasource", err)
+	}
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		if err == models.ErrDataSourceUpdatingOldVersion {
+			return Conflict("version-mismatch", err)
+		}
+		return Error(500, "Failed to update datasource", err)
+	}
+
+	updated := models.GetDataSourceByIdQuery{Id: cmd.Id, OrgId: c.OrgId}
+	if err := bus.Dispatch(&updated); err != nil {
+		return Error(500, "Failed to query datasources", err)
+	}
+
+	return JSON(200, util.DynMap{
+		"message":    "Datasource updated",
+		"id":         cmd.Id,
+		"name":       cmd.Name,
+		"datasource": convertModelToDtos(updated.Result),
+	})
+}
+
+func (hs *HTTPServer) addDataSourceWithUID(c *models.ReqContext, cmd models.UpdateDataSourceCommand) Response {
+	limitReached, err := hs.QuotaService.QuotaReached(c, "data_source")
+	if err != nil {
+		return Error(500, "Failed to get quota", err)
+	}
+	if limitReached {
+		return Error(403, "Quota reached", nil)
+	}
+
+	addCmd := models.AddDataSourceCommand{
+		Name:              cmd.Name,
+		Type:              cmd.Type,
+		Access:            cmd.Access,
+		Url:               cmd.Url,
+		Password:          cmd.Password,
+		Database:          cmd.Database,
+		User:              cmd.User,
+		BasicAuth:         cmd.BasicAuth,
+		BasicAuthUser:     cmd.BasicAuthUser,
+		BasicAuthPassword: cmd.BasicAuthPassword,
+		WithCredentials:   cmd.WithCredentials,
+		IsDefault:         cmd.IsDefault,
+		JsonData:          cmd.JsonData,
+		SecureJsonData:    cmd.SecureJsonData,
+		Uid:               cmd.Uid,
+		OrgId:             cmd.OrgId,
+	}
+
+	if err := bus.Dispatch(&addCmd); err != nil {
+		if err == models.ErrDataSourceNameExists {
+			return Conflict("name-exists", err)
+		}
+		if err == models.ErrDataSourceUidExists {
+			return Conflict("uid-exists", err)
+		}
+		return Error(500, "Failed to add datasource", err)
+	}
+
+	return JSON(200, util.DynMap{
+		"message":    "Datasource added",
+		"id":         addCmd.Result.Id,
+		"name":       addCmd.Result.Name,
+		"datasource": convertModelToDtos(addCmd.Result),
+	})
+}
+
 func fillWithSecureJSONData(cmd *models.UpdateDataSourceCommand) error {
 	if len(cmd.SecureJsonData) == 0 {
 		return nil
@@ -262,6 +429,58 @@ func GetDataSourceByName(c *models.ReqContext) Response {
 	return JSON(200, &dtos)
 }
 
+// Get /api/datasources/uid/:uid
+func GetDataSourceByUID(c *models.ReqContext) Response {
+	query := models.GetDataSourceByUidQuery{Uid: c.Params(":uid"), OrgId: c.OrgId}
+
+	if err := bus.Dispatch(&query); err != nil {
+		if err == models.ErrDataSourceNotFound {
+			return Error(404, "Data source not found", nil)
+		}
+		return Error(500, "Failed to query datasources", err)
+	}
+
+	dtos := convertModelToDtos(query.Result)
+	return JSON(200, &dtos)
+}
+
+// GET /api/datasources/uid/:uid/usage
+func GetDataSourceUsageByUID(c *models.ReqContext) Response {
+	dsQuery := models.GetDataSourceByUidQuery{Uid: c.Params(":uid"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&dsQuery); err != nil {
+		if err == models.ErrDataSourceNotFound {
+			return Error(404, "Data source not found", nil)
+		}
+		return Error(500, "Failed to query datasources", err)
+	}
+
+	query := models.GetDataSourceUsageQuery{OrgId: c.OrgId, DataSource: dsQuery.Result}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get the usage of the data source", err)
+	}
+
+	return JSON(200, query.Result)
+}
+
+// GetDataSourceShadowStats returns the comparison of the responses of a data source and of its shadow
+// data source
+// GET /api/datasources/:id/shadow-stats
+func (hs *HTTPServer) GetDataSourceShadowStats(c *models.ReqContext) Response {
+	query := models.GetDataSourceByIdQuery{Id: c.ParamsInt64(":id"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		if err == models.ErrDataSourceNotFound {
+			return Error(404, "Data source not found", nil)
+		}
+		return Error(500, "Failed to query datasources", err)
+	}
+
+	stats := hs.ShadowQueryService.Stats(query.Result.Id)
+	if stats == nil {
+		return Error(404, "No request was sent to the shadow data source", nil)
+	}
+	return JSON(200, stats)
+}
+
 // Get /api/datasources/id/:name
 func GetDataSourceIdByName(c *models.ReqContext) Response {
 	query := models.GetDataSourceByNameQuery{Name: c.Params(":name"), OrgId: c.OrgId}
@@ -294,16 +513,29 @@ func (hs *HTTPServer) CallDatasourceResource(c *models.ReqContext) {
 		return
 	}
 
+	// the query endpoints of the built-in data sources serve their resources themselves
+	result, err := tsdb.CallResource(c.Req.Context(), ds, c.Params("*"), c.Req.URL.Query())
+	if err != tsdb.ErrResourcesNotSupported {
+		writeTsdbResource(c, result, err)
+		return
+	}
+
 	// find plugin
-	plugin, ok := plugins.DataSources[ds.Type]
+	plugin, ok := plugins.GetDataSource(ds.Type)
 	if !ok {
-		c.JsonApiErr(500, "Unable to find datasource plugin", err)
+		c.JsonApiErr(500, "Unable to find datasource plugin", nil)
 		return
 	}
 
 	dsInstanceSettings, err := wrapper.ModelToInstanceSettings(ds)
 	if err != nil {
 		c.JsonApiErr(500, "Unable to process datasource instance model", err)
+		return
+	}
+
+	// forward the OAuth identity of the user to the plugin, like the data source proxy does
+	if ds.JsonData != nil && ds.JsonData.Get("oauthPassThru").MustBool() {
+		pluginproxy.AddOAuthPassThruAuth(c, c.Req.Request, ds)
 	}
 
 	pCtx := backend.PluginContext{
@@ -315,6 +547,19 @@ func (hs *HTTPServer) CallDatasourceResource(c *models.ReqContext) {
 	hs.BackendPluginManager.CallResource(pCtx, c, c.Params("*"))
 }
 
+func writeTsdbResource(c *models.ReqContext, result interface{}, err error) {
+	switch {
+	case err == nil:
+		c.JSON(200, result)
+	case err == tsdb.ErrResourceNotFound:
+		c.JsonApiErr(404, "Not found", err)
+	case errors.Is(err, tsdb.ErrInvalidResourceRequest):
+		c.JsonApiErr(400, err.Error(), nil)
+	default:
+		c.JsonApiErr(500, "Failed to call resource", err)
+	}
+}
+
 func convertModelToDtos(ds *models.DataSource) dtos.DataSource {
 	dto := dtos.DataSource{
 		Id:                ds.Id,
@@ -359,9 +604,36 @@ func (hs *HTTPServer) CheckDatasourceHealth(c *models.ReqContext) Response {
 		return Error(500, "Unable to load datasource metadata", err)
 	}
 
+	return hs.checkDatasourceHealth(c, ds)
+}
+
+// CheckDatasourceHealthByUID checks the health of a datasource with its backend plugin, or the
+// query endpoint of its type for the built-in datasources
+// GET /api/datasources/uid/:uid/health
+func (hs *HTTPServer) CheckDatasourceHealthByUID(c *models.ReqContext) Response {
+	query := models.GetDataSourceByUidQuery{Uid: c.Params(":uid"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		if err == models.ErrDataSourceNotFound {
+			return Error(404, "Data source not found", nil)
+		}
+		return Error(500, "Failed to query datasources", err)
+	}
+
+	ds, err := hs.DatasourceCache.GetDatasource(query.Result.Id, c.SignedInUser, c.SkipCache)
+	if err != nil {
+		if err == models.ErrDataSourceAccessDenied {
+			return Error(403, "Access denied to datasource", err)
+		}
+		return Error(500, "Unable to load datasource metadata", err)
+	}
+
+	return hs.checkDatasourceHealth(c, ds)
+}
+
+func (hs *HTTPServer) checkDatasourceHealth(c *models.ReqContext, ds *models.DataSource) Response {
 	plugin, ok := hs.PluginManager.GetDatasource(ds.Type)
 	if !ok {
-		return Error(500, "Unable to find datasource plugin", err)
+		return Error(500, "Unable to find datasource plugin", nil)
 	}
 
 	dsInstanceSettings, err := wrapper.ModelToInstanceSettings(ds)
@@ -376,6 +648,9 @@ func (hs *HTTPServer) CheckDatasourceHealth(c *models.ReqContext) Response {
 	}
 
 	resp, err := hs.BackendPluginManager.CheckHealth(c.Req.Context(), pCtx)
+	if errors.Is(err, backendplugin.ErrPluginNotRegistered) {
+		return checkTsdbDatasourceHealth(c, ds)
+	}
 	if err != nil {
 		return translatePluginRequestErrorToAPIError(err)
 	}
@@ -402,3 +677,59 @@ func (hs *HTTPServer) CheckDatasourceHealth(c *models.ReqContext) Response {
 
 	return JSON(200, payload)
 }
+
+// checkTsdbDatasourceHealth checks the health of a datasource without backend plugin, with the
+// same payload as the plugins
+func checkTsdbDatasourceHealth(c *models.ReqContext, ds *models.DataSource) Response {
+	result, err := tsdb.CheckHealth(c.Req.Context(), ds)
+	if err != nil {
+		if err == tsdb.ErrHealthCheckNotSupported {
+			return Error(404, "Health check not supported by the datasource", err)
+		}
+		return Error(500, "Datasource health check failed", err)
+	}
+
+	payload := map[string]interface{}{
+		"status":  string(result.Status),
+		"message": result.Message,
+		"details": result.Details,
+	}
+
+	if result.Status != tsdb.HealthStatusOk {
+		return JSON(503, payload)
+	}
+
+	return JSON(200, payload)
+}
+
+// GetDatasourceLogContext fetches a page of the rows before or after a log row, for the data
+// sources whose query endpoint supports it
+// POST /api/datasources/:id/log-context
+func (hs *HTTPServer) GetDatasourceLogContext(c *models.ReqContext, req dtos.LogContextRequest) Response {
+	ds, err := hs.DatasourceCache.GetDatasource(c.ParamsInt64("id"), c.SignedInUser, c.SkipCache)
+	if err != nil {
+		if err == models.ErrDataSourceAccessDenied {
+			return Error(403, "Access denied to datasource", err)
+		}
+		return Error(500, "Unable to load datasource metadata", err)
+	}
+
+	query := &tsdb.LogContextQuery{
+		Model:     req.Query,
+		Row:       req.Row,
+		Direction: req.Direction,
+		Limit:     req.Limit,
+	}
+	result, err := tsdb.LogContext(c.Req.Context(), ds, query, req.Cursor)
+	if err != nil {
+		if err == tsdb.ErrLogContextNotSupported {
+			return Error(404, "Log context not supported by the datasource", err)
+		}
+		if errors.Is(err, tsdb.ErrInvalidLogContextQuery) {
+			return Error(400, err.Error(), err)
+		}
+		return Error(500, "Failed to fetch the log context", err)
+	}
+
+	return JSON(200, result)
+}
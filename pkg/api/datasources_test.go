@@ -2,8 +2,10 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 
+	"github.com/grafana/grafana/pkg/components/securejsondata"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/stretchr/testify/assert"
 
@@ -110,6 +112,39 @@ func TestAddDataSource_URLWithoutProtocol(t *testing.T) {
 	assert.Equal(t, 200, sc.resp.Code)
 }
 
+// Adding data sources referencing secrets the org isn't allowed to reference should lead to an error.
+func TestAddDataSource_SecretNotAllowed(t *testing.T) {
+	defer bus.ClearBusHandlers()
+
+	securejsondata.SetReferenceResolver(func(orgID int64, provisioned bool, value string) (string, bool, error) {
+		if value != "$__vault{org/db#password}" {
+			return "", false, nil
+		}
+		return "", true, errors.New("secret not allowed for the organization")
+	})
+	defer securejsondata.SetReferenceResolver(nil)
+
+	bus.AddHandler("sql", func(cmd *models.AddDataSourceCommand) error {
+		t.Fatal("the data source shouldn't be added")
+		return nil
+	})
+
+	sc := setupScenarioContext("/api/datasources")
+	sc.t = t
+
+	sc.m.Post(sc.url, Wrap(func(c *models.ReqContext) Response {
+		return AddDataSource(c, models.AddDataSourceCommand{
+			Name:           "Test",
+			Url:            "http://localhost:5432",
+			SecureJsonData: map[string]string{"password": "$__vault{org/db#password}"},
+		})
+	}))
+
+	sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+
+	assert.Equal(t, 400, sc.resp.Code)
+}
+
 // Updating data sources with invalid URLs should lead to an error.
 func TestUpdateDataSource_InvalidURL(t *testing.T) {
 	defer bus.ClearBusHandlers()
@@ -48,6 +48,12 @@ func formatShort(interval time.Duration) string {
 }
 
 func NewAlertNotification(notification *models.AlertNotification) *AlertNotification {
+	// only return which secure settings are set, never their values
+	secureFields := make(map[string]bool)
+	for field := range notification.SecureSettings {
+		secureFields[field] = true
+	}
+
 	return &AlertNotification{
 		Id:                    notification.Id,
 		Uid:                   notification.Uid,
@@ -60,6 +66,9 @@ func NewAlertNotification(notification *models.AlertNotification) *AlertNotifica
 		SendReminder:          notification.SendReminder,
 		DisableResolveMessage: notification.DisableResolveMessage,
 		Settings:              notification.Settings,
+		SecureFields:          secureFields,
+		Provisioned:           notification.Provisioned,
+		Version:               notification.Version,
 	}
 }
 
@@ -75,6 +84,9 @@ type AlertNotification struct {
 	Created               time.Time        `json:"created"`
 	Updated               time.Time        `json:"updated"`
 	Settings              *simplejson.Json `json:"settings"`
+	SecureFields          map[string]bool  `json:"secureFields"`
+	Provisioned           bool             `json:"provisioned"`
+	Version               int              `json:"version"`
 }
 
 func NewAlertNotificationLookup(notification *models.AlertNotification) *AlertNotificationLookup {
@@ -122,12 +134,31 @@ type EvalMatch struct {
 }
 
 type NotificationTestCommand struct {
-	Name                  string           `json:"name"`
-	Type                  string           `json:"type"`
-	SendReminder          bool             `json:"sendReminder"`
-	DisableResolveMessage bool             `json:"disableResolveMessage"`
-	Frequency             string           `json:"frequency"`
-	Settings              *simplejson.Json `json:"settings"`
+	Name                  string            `json:"name"`
+	Type                  string            `json:"type"`
+	SendReminder          bool              `json:"sendReminder"`
+	DisableResolveMessage bool              `json:"disableResolveMessage"`
+	Frequency             string            `json:"frequency"`
+	Settings              *simplejson.Json  `json:"settings"`
+	SecureSettings        map[string]string `json:"secureSettings"`
+	// Alert is the synthetic alert sent, the default test alert when omitted
+	Alert *NotificationTestAlert `json:"alert"`
+}
+
+// SavedNotificationTestCommand tests a saved notification channel
+type SavedNotificationTestCommand struct {
+	Alert *NotificationTestAlert `json:"alert"`
+}
+
+// NotificationTestAlert is a synthetic alert sent to test a notification channel
+type NotificationTestAlert struct {
+	Title       string                `json:"title"`
+	Message     string                `json:"message"`
+	State       models.AlertStateType `json:"state"`
+	Tags        map[string]string     `json:"tags"`
+	ImageURL    string                `json:"imageUrl"`
+	EvalMatches []*EvalMatch          `json:"evalMatches"`
+	Error       string                `json:"error"`
 }
 
 type PauseAlertCommand struct {
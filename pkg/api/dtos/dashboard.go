@@ -56,3 +56,26 @@ type CalculateDiffTarget struct {
 type RestoreDashboardVersionCommand struct {
 	Version int `json:"version" binding:"Required"`
 }
+
+type InterpolateDashboardCommand struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Variables override the current values of the variables saved in the dashboard,
+	// the values are strings or arrays of strings
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type ValidateDashboardCommand struct {
+	Dashboard *simplejson.Json `json:"dashboard" binding:"Required"`
+}
+
+type DashboardValidationResult struct {
+	Valid    bool                        `json:"valid"`
+	Errors   []*DashboardValidationIssue `json:"errors"`
+	Warnings []*DashboardValidationIssue `json:"warnings"`
+}
+
+type DashboardValidationIssue struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
@@ -7,6 +7,7 @@ type Folder struct {
 	Uid       string    `json:"uid"`
 	Title     string    `json:"title"`
 	Url       string    `json:"url"`
+	ParentUid string    `json:"parentUid"`
 	HasAcl    bool      `json:"hasAcl"`
 	CanSave   bool      `json:"canSave"`
 	CanEdit   bool      `json:"canEdit"`
@@ -19,7 +20,8 @@ type Folder struct {
 }
 
 type FolderSearchHit struct {
-	Id    int64  `json:"id"`
-	Uid   string `json:"uid"`
-	Title string `json:"title"`
+	Id        int64  `json:"id"`
+	Uid       string `json:"uid"`
+	Title     string `json:"title"`
+	ParentUid string `json:"parentUid"`
 }
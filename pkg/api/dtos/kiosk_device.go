@@ -0,0 +1,19 @@
+package dtos
+
+// KioskDeviceDashboard is a dashboard of a kiosk device, with its version so that the device can
+// poll for changes
+type KioskDeviceDashboard struct {
+	Uid     string `json:"uid"`
+	Title   string `json:"title"`
+	Url     string `json:"url"`
+	Version int    `json:"version"`
+}
+
+// KioskDeviceKioskDTO is the kiosk device as shown to the device that uses its token
+type KioskDeviceKioskDTO struct {
+	Id           int64                  `json:"id"`
+	Name         string                 `json:"name"`
+	Channel      string                 `json:"channel"`
+	PollInterval string                 `json:"pollInterval"`
+	Dashboards   []KioskDeviceDashboard `json:"dashboards"`
+}
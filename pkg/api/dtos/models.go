@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
 )
 
 type AnyId struct {
@@ -36,16 +37,36 @@ type CurrentUser struct {
 	IsGrafanaAdmin             bool              `json:"isGrafanaAdmin"`
 	GravatarUrl                string            `json:"gravatarUrl"`
 	Timezone                   string            `json:"timezone"`
+	WeekStart                  string            `json:"weekStart"`
 	Locale                     string            `json:"locale"`
 	HelpFlags1                 models.HelpFlags1 `json:"helpFlags1"`
 	HasEditPermissionInFolders bool              `json:"hasEditPermissionInFolders"`
 }
 
 type MetricRequest struct {
-	From    string             `json:"from"`
-	To      string             `json:"to"`
-	Queries []*simplejson.Json `json:"queries"`
-	Debug   bool               `json:"debug"`
+	From            string                 `json:"from"`
+	To              string                 `json:"to"`
+	Queries         []*simplejson.Json     `json:"queries"`
+	Debug           bool                   `json:"debug"`
+	Transformations []*tsdb.Transformation `json:"transformations"`
+}
+
+// ExportMetricsRequest runs the queries of a MetricRequest and exports the result as a file
+type ExportMetricsRequest struct {
+	MetricRequest
+	Format   string `json:"format" binding:"Required"`
+	Timezone string `json:"timezone"`
+	Filename string `json:"filename"`
+}
+
+// LogContextRequest fetches the rows before or after a log row. The first page is fetched
+// without cursor, and the next ones with the cursor of the previous page.
+type LogContextRequest struct {
+	Query     *simplejson.Json         `json:"query"`
+	Row       *tsdb.LogRow             `json:"row" binding:"Required"`
+	Direction tsdb.LogContextDirection `json:"direction" binding:"Required"`
+	Limit     int                      `json:"limit"`
+	Cursor    string                   `json:"cursor"`
 }
 
 type UserStars struct {
@@ -1,12 +1,21 @@
 package dtos
 
 type PlaylistDashboard struct {
-	Id    int64  `json:"id"`
-	Slug  string `json:"slug"`
-	Title string `json:"title"`
-	Uri   string `json:"uri"`
-	Url   string `json:"url"`
-	Order int    `json:"order"`
+	Id       int64  `json:"id"`
+	Uid      string `json:"uid"`
+	Slug     string `json:"slug"`
+	Title    string `json:"title"`
+	Uri      string `json:"uri"`
+	Url      string `json:"url"`
+	Order    int    `json:"order"`
+	Interval string `json:"interval,omitempty"`
+}
+
+// PlaylistKioskDTO is the playlist as shown to kiosks that use the kiosk token
+type PlaylistKioskDTO struct {
+	Name       string                  `json:"name"`
+	Interval   string                  `json:"interval"`
+	Dashboards PlaylistDashboardsSlice `json:"dashboards"`
 }
 
 type PlaylistDashboardsSlice []PlaylistDashboard
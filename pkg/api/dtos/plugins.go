@@ -54,6 +54,10 @@ func (slice PluginList) Swap(i, j int) {
 	slice[i], slice[j] = slice[j], slice[i]
 }
 
+type InstallPluginCommand struct {
+	Version string `json:"version"`
+}
+
 type ImportDashboardCommand struct {
 	PluginId  string                         `json:"pluginId"`
 	Path      string                         `json:"path"`
@@ -22,9 +22,10 @@ func GetFolders(c *models.ReqContext) Response {
 
 	for _, f := range folders {
 		result = append(result, dtos.FolderSearchHit{
-			Id:    f.Id,
-			Uid:   f.Uid,
-			Title: f.Title,
+			Id:        f.Id,
+			Uid:       f.Uid,
+			Title:     f.Title,
+			ParentUid: f.ParentUid,
 		})
 	}
 
@@ -55,10 +56,14 @@ func GetFolderByID(c *models.ReqContext) Response {
 }
 
 func (hs *HTTPServer) CreateFolder(c *models.ReqContext, cmd models.CreateFolderCommand) Response {
+	return hs.createFolder(c, cmd, toFolderError)
+}
+
+func (hs *HTTPServer) createFolder(c *models.ReqContext, cmd models.CreateFolderCommand, toError func(error) Response) Response {
 	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser)
 	err := s.CreateFolder(&cmd)
 	if err != nil {
-		return toFolderError(err)
+		return toError(err)
 	}
 
 	if hs.Cfg.EditorsCanAdmin {
@@ -82,6 +87,39 @@ func UpdateFolder(c *models.ReqContext, cmd models.UpdateFolderCommand) Response
 	return JSON(200, toFolderDto(g, cmd.Result))
 }
 
+// PutFolderByUID creates the folder with the uid of the path, or updates it if it exists. The
+// version of the folder is only checked when it's set, the conflicts returning 409, so that the
+// same request can be repeated.
+func (hs *HTTPServer) PutFolderByUID(c *models.ReqContext, cmd models.UpdateFolderCommand) Response {
+	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser)
+	uid := c.Params(":uid")
+
+	existing, err := s.GetFolderByUID(uid)
+	if err == models.ErrFolderNotFound {
+		createCmd := models.CreateFolderCommand{Uid: uid, Title: cmd.Title}
+		if cmd.ParentUid != nil {
+			createCmd.ParentUid = *cmd.ParentUid
+		}
+		return hs.createFolder(c, createCmd, toFolderUpsertError)
+	}
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	// overwriting would also replace a folder with the same name, rather than conflict with it
+	if cmd.Version == 0 || cmd.Overwrite {
+		cmd.Version = existing.Version
+		cmd.Overwrite = false
+	}
+
+	if err := s.UpdateFolder(uid, &cmd); err != nil {
+		return toFolderUpsertError(err)
+	}
+
+	g := guardian.New(cmd.Result.Id, c.OrgId, c.SignedInUser)
+	return JSON(200, toFolderDto(g, cmd.Result))
+}
+
 func DeleteFolder(c *models.ReqContext) Response {
 	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser)
 	f, err := s.DeleteFolder(c.Params(":uid"))
@@ -114,6 +152,7 @@ func toFolderDto(g guardian.DashboardGuardian, folder *models.Folder) dtos.Folde
 		Uid:       folder.Uid,
 		Title:     folder.Title,
 		Url:       folder.Url,
+		ParentUid: folder.ParentUid,
 		HasAcl:    folder.HasAcl,
 		CanSave:   canSave,
 		CanEdit:   canEdit,
@@ -126,10 +165,27 @@ func toFolderDto(g guardian.DashboardGuardian, folder *models.Folder) dtos.Folde
 	}
 }
 
+// toFolderUpsertError is toFolderError for PutFolderByUID, returning 409 for the conflicts
+func toFolderUpsertError(err error) Response {
+	switch err {
+	case models.ErrFolderSameNameExists:
+		return Conflict("name-exists", err)
+	case models.ErrFolderWithSameUIDExists:
+		return Conflict("uid-exists", err)
+	case models.ErrFolderVersionMismatch:
+		return Conflict("version-mismatch", err)
+	}
+	return toFolderError(err)
+}
+
 func toFolderError(err error) Response {
 	if err == models.ErrFolderTitleEmpty ||
 		err == models.ErrFolderSameNameExists ||
 		err == models.ErrFolderWithSameUIDExists ||
+		err == models.ErrFolderParentNotFound ||
+		err == models.ErrFolderHasConnectedLibraryPanels ||
+		err == models.ErrDashboardFolderCannotHaveParent ||
+		err == models.ErrDashboardFolderNestingTooDeep ||
 		err == models.ErrDashboardTypeMismatch ||
 		err == models.ErrDashboardInvalidUid ||
 		err == models.ErrDashboardUidToLong {
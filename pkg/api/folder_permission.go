@@ -47,6 +47,29 @@ func GetFolderPermissionList(c *models.ReqContext) Response {
 	return JSON(200, acl)
 }
 
+// GET /api/folders/:uid/permissions/preview/:userId
+func GetFolderPermissionPreview(c *models.ReqContext) Response {
+	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser)
+	folder, err := s.GetFolderByUID(c.Params(":uid"))
+
+	if err != nil {
+		return toFolderError(err)
+	}
+
+	g := guardian.New(folder.Id, c.OrgId, c.SignedInUser)
+
+	if canAdmin, err := g.CanAdmin(); err != nil || !canAdmin {
+		return toFolderError(models.ErrFolderAccessDenied)
+	}
+
+	dash, rsp := getDashboardHelper(c.OrgId, "", folder.Id, "")
+	if rsp != nil {
+		return rsp
+	}
+
+	return permissionPreviewHelper(c, dash)
+}
+
 func UpdateFolderPermissions(c *models.ReqContext, apiCmd dtos.UpdateDashboardAclCommand) Response {
 	s := dashboards.NewFolderService(c.OrgId, c.SignedInUser)
 	folder, err := s.GetFolderByUID(c.Params(":uid"))
@@ -48,6 +48,7 @@ func TestFoldersApiEndpoint(t *testing.T) {
 				{Error: models.ErrFolderWithSameUIDExists, ExpectedStatusCode: 400},
 				{Error: models.ErrFolderTitleEmpty, ExpectedStatusCode: 400},
 				{Error: models.ErrFolderSameNameExists, ExpectedStatusCode: 400},
+				{Error: models.ErrFolderParentNotFound, ExpectedStatusCode: 400},
 				{Error: models.ErrDashboardInvalidUid, ExpectedStatusCode: 400},
 				{Error: models.ErrDashboardUidToLong, ExpectedStatusCode: 400},
 				{Error: models.ErrFolderAccessDenied, ExpectedStatusCode: 403},
@@ -106,6 +107,7 @@ func TestFoldersApiEndpoint(t *testing.T) {
 				{Error: models.ErrFolderWithSameUIDExists, ExpectedStatusCode: 400},
 				{Error: models.ErrFolderTitleEmpty, ExpectedStatusCode: 400},
 				{Error: models.ErrFolderSameNameExists, ExpectedStatusCode: 400},
+				{Error: models.ErrFolderParentNotFound, ExpectedStatusCode: 400},
 				{Error: models.ErrDashboardInvalidUid, ExpectedStatusCode: 400},
 				{Error: models.ErrDashboardUidToLong, ExpectedStatusCode: 400},
 				{Error: models.ErrFolderAccessDenied, ExpectedStatusCode: 403},
@@ -131,6 +133,77 @@ func TestFoldersApiEndpoint(t *testing.T) {
 				})
 			}
 		})
+
+		Convey("Given a request for putting a folder which doesn't exist", func() {
+			cmd := models.UpdateFolderCommand{
+				Title: "Folder",
+			}
+
+			mock := &fakeFolderService{
+				GetFolderByUIDError: models.ErrFolderNotFound,
+				CreateFolderResult:  &models.Folder{Id: 1, Uid: "uid", Title: "Folder"},
+			}
+
+			putFolderByUIDScenario("When calling PUT on", "/api/folders/uid/uid", "/api/folders/uid/:uid", mock, cmd, func(sc *scenarioContext) {
+				callUpdateFolder(sc)
+
+				Convey("It should create the folder with the uid", func() {
+					So(sc.resp.Code, ShouldEqual, 200)
+					So(mock.CreatedFolderUids, ShouldResemble, []string{"uid"})
+				})
+			})
+		})
+
+		Convey("Given a request for putting a folder without version", func() {
+			cmd := models.UpdateFolderCommand{
+				Title: "Folder upd",
+			}
+
+			mock := &fakeFolderService{
+				GetFolderByUIDResult: &models.Folder{Id: 1, Uid: "uid", Title: "Folder", Version: 3},
+				UpdateFolderResult:   &models.Folder{Id: 1, Uid: "uid", Title: "Folder upd"},
+			}
+
+			putFolderByUIDScenario("When calling PUT on", "/api/folders/uid/uid", "/api/folders/uid/:uid", mock, cmd, func(sc *scenarioContext) {
+				callUpdateFolder(sc)
+
+				Convey("It should update the current version", func() {
+					So(sc.resp.Code, ShouldEqual, 200)
+					So(mock.UpdatedFolderVersion, ShouldEqual, 3)
+				})
+			})
+		})
+
+		Convey("Given incorrect requests for putting a folder", func() {
+			testCases := []struct {
+				Error              error
+				ExpectedStatusCode int
+			}{
+				{Error: models.ErrFolderWithSameUIDExists, ExpectedStatusCode: 409},
+				{Error: models.ErrFolderSameNameExists, ExpectedStatusCode: 409},
+				{Error: models.ErrFolderVersionMismatch, ExpectedStatusCode: 409},
+				{Error: models.ErrFolderTitleEmpty, ExpectedStatusCode: 400},
+			}
+
+			cmd := models.UpdateFolderCommand{
+				Title:   "Folder upd",
+				Version: 1,
+			}
+
+			for _, tc := range testCases {
+				mock := &fakeFolderService{
+					GetFolderByUIDResult: &models.Folder{Id: 1, Uid: "uid", Title: "Folder", Version: 2},
+					UpdateFolderError:    tc.Error,
+				}
+
+				putFolderByUIDScenario(fmt.Sprintf("Expect '%s' error when calling PUT on", tc.Error.Error()), "/api/folders/uid/uid", "/api/folders/uid/:uid", mock, cmd, func(sc *scenarioContext) {
+					callUpdateFolder(sc)
+					if sc.resp.Code != tc.ExpectedStatusCode {
+						t.Errorf("For error '%s' expected status code %d, actual %d", tc.Error, tc.ExpectedStatusCode, sc.resp.Code)
+					}
+				})
+			}
+		})
 	})
 }
 
@@ -197,6 +270,36 @@ func updateFolderScenario(desc string, url string, routePattern string, mock *fa
 	})
 }
 
+func putFolderByUIDScenario(desc string, url string, routePattern string, mock *fakeFolderService, cmd models.UpdateFolderCommand, fn scenarioFunc) {
+	Convey(desc+" "+url, func() {
+		defer bus.ClearBusHandlers()
+
+		hs := HTTPServer{
+			Bus: bus.GetBus(),
+			Cfg: setting.NewCfg(),
+		}
+
+		sc := setupScenarioContext(url)
+		sc.defaultHandler = Wrap(func(c *models.ReqContext) Response {
+			sc.context = c
+			sc.context.SignedInUser = &models.SignedInUser{OrgId: TestOrgID, UserId: TestUserID}
+
+			return hs.PutFolderByUID(c, cmd)
+		})
+
+		origNewFolderService := dashboards.NewFolderService
+		mockFolderService(mock)
+
+		sc.m.Put(routePattern, sc.defaultHandler)
+
+		defer func() {
+			dashboards.NewFolderService = origNewFolderService
+		}()
+
+		fn(sc)
+	})
+}
+
 type fakeFolderService struct {
 	GetFoldersResult     []*models.Folder
 	GetFoldersError      error
@@ -211,6 +314,8 @@ type fakeFolderService struct {
 	DeleteFolderResult   *models.Folder
 	DeleteFolderError    error
 	DeletedFolderUids    []string
+	CreatedFolderUids    []string
+	UpdatedFolderVersion int
 }
 
 func (s *fakeFolderService) GetFolders(limit int64) ([]*models.Folder, error) {
@@ -226,11 +331,13 @@ func (s *fakeFolderService) GetFolderByUID(uid string) (*models.Folder, error) {
 }
 
 func (s *fakeFolderService) CreateFolder(cmd *models.CreateFolderCommand) error {
+	s.CreatedFolderUids = append(s.CreatedFolderUids, cmd.Uid)
 	cmd.Result = s.CreateFolderResult
 	return s.CreateFolderError
 }
 
 func (s *fakeFolderService) UpdateFolder(existingUID string, cmd *models.UpdateFolderCommand) error {
+	s.UpdatedFolderVersion = cmd.Version
 	cmd.Result = s.UpdateFolderResult
 	return s.UpdateFolderError
 }
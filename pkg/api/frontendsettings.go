@@ -97,8 +97,19 @@ func (hs *HTTPServer) getFrontendSettingsMap(c *models.ReqContext) (map[string]i
 		dsMap["jsonData"] = jsonData
 
 		if ds.Access == models.DS_ACCESS_DIRECT {
+			password, err := ds.DecryptedPassword()
+			if err != nil {
+				log.Error(3, "Could not resolve password of data source %v: %v", ds.Name, err)
+				continue
+			}
+			basicAuthPassword, err := ds.DecryptedBasicAuthPassword()
+			if err != nil {
+				log.Error(3, "Could not resolve basic auth password of data source %v: %v", ds.Name, err)
+				continue
+			}
+
 			if ds.BasicAuth {
-				dsMap["basicAuth"] = util.GetBasicAuthHeader(ds.BasicAuthUser, ds.DecryptedBasicAuthPassword())
+				dsMap["basicAuth"] = util.GetBasicAuthHeader(ds.BasicAuthUser, basicAuthPassword)
 			}
 			if ds.WithCredentials {
 				dsMap["withCredentials"] = ds.WithCredentials
@@ -106,13 +117,13 @@ func (hs *HTTPServer) getFrontendSettingsMap(c *models.ReqContext) (map[string]i
 
 			if ds.Type == models.DS_INFLUXDB_08 {
 				dsMap["username"] = ds.User
-				dsMap["password"] = ds.DecryptedPassword()
+				dsMap["password"] = password
 				dsMap["url"] = url + "/db/" + ds.Database
 			}
 
 			if ds.Type == models.DS_INFLUXDB {
 				dsMap["username"] = ds.User
-				dsMap["password"] = ds.DecryptedPassword()
+				dsMap["password"] = password
 				dsMap["url"] = url
 			}
 		}
@@ -130,12 +141,12 @@ func (hs *HTTPServer) getFrontendSettingsMap(c *models.ReqContext) (map[string]i
 	}
 
 	// add datasources that are built in (meaning they are not added via data sources page, nor have any entry in datasource table)
-	for _, ds := range plugins.DataSources {
+	for _, ds := range plugins.GetDataSources() {
 		if ds.BuiltIn {
 			datasources[ds.Name] = map[string]interface{}{
 				"type": ds.Type,
 				"name": ds.Name,
-				"meta": plugins.DataSources[ds.Id],
+				"meta": ds,
 			}
 		}
 	}
@@ -207,6 +218,8 @@ func (hs *HTTPServer) getFrontendSettingsMap(c *models.ReqContext) (map[string]i
 		"editorsCanAdmin":            hs.Cfg.EditorsCanAdmin,
 		"disableSanitizeHtml":        hs.Cfg.DisableSanitizeHtml,
 		"pluginsToPreload":           pluginsToPreload,
+		"sigV4AuthEnabled":           setting.AWSSigV4AuthEnabled,
+		"sigV4AllowedAuthProviders":  setting.AWSSigV4AllowedAuthProviders,
 		"buildInfo": map[string]interface{}{
 			"hideVersion":   hideVersion,
 			"version":       version,
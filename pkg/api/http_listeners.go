@@ -0,0 +1,141 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var listenerLogger = log.New("http.server")
+
+// listenFdsStart is the first file descriptor passed by systemd socket activation
+const listenFdsStart = 3
+
+// unixAddressPrefix prefixes the Unix socket paths of additional_listen_addresses
+const unixAddressPrefix = "unix:"
+
+// listeners opens the listeners of the HTTP server: the sockets passed by systemd with socket
+// activation, otherwise the address of the protocol and the additional addresses.
+func (hs *HTTPServer) listeners() ([]net.Listener, error) {
+	if hs.Cfg.SocketActivation {
+		listeners, err := activatedListeners()
+		if err != nil {
+			return nil, err
+		}
+		if len(listeners) == 0 {
+			return nil, fmt.Errorf("socket activation is enabled, but systemd passed no socket")
+		}
+		return listeners, nil
+	}
+
+	var listeners []net.Listener
+
+	switch setting.Protocol {
+	case setting.HTTP, setting.HTTPS, setting.HTTP2:
+		listener, err := listenTCP(hs.httpSrv.Addr)
+		if err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, listener)
+	case setting.SOCKET:
+		listener, err := listenUnix(setting.SocketPath)
+		if err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, listener)
+	default:
+		hs.log.Error("Invalid protocol", "protocol", setting.Protocol)
+		return nil, fmt.Errorf("invalid protocol %q", setting.Protocol)
+	}
+
+	for _, address := range hs.Cfg.AdditionalListenAddresses {
+		var listener net.Listener
+		var err error
+		if strings.HasPrefix(address, unixAddressPrefix) {
+			listener, err = listenUnix(strings.TrimPrefix(address, unixAddressPrefix))
+		} else {
+			listener, err = listenTCP(address)
+		}
+		if err != nil {
+			closeListeners(listeners)
+			return nil, err
+		}
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+func listenTCP(address string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, errutil.Wrapf(err, "failed to open listener on address %s", address)
+	}
+	return listener, nil
+}
+
+func listenUnix(path string) (net.Listener, error) {
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		return nil, errutil.Wrapf(err, "failed to open listener for socket %s", path)
+	}
+
+	// Make socket writable by group
+	if err := os.Chmod(path, 0660); err != nil {
+		closeListeners([]net.Listener{listener})
+		return nil, errutil.Wrapf(err, "failed to change socket permissions")
+	}
+	return listener, nil
+}
+
+// activatedListeners returns the listeners of the sockets passed by systemd socket activation,
+// as described by sd_listen_fds(3). The environment variables are unset so that the processes
+// started by Grafana, like the backend plugins, don't take them as theirs.
+func activatedListeners() ([]net.Listener, error) {
+	defer func() {
+		for _, name := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+			if err := os.Unsetenv(name); err != nil {
+				listenerLogger.Warn("Failed to unset socket activation environment variable", "name", name, "error", err)
+			}
+		}
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	fds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || fds <= 0 {
+		return nil, nil
+	}
+
+	listeners := make([]net.Listener, 0, fds)
+	for fd := listenFdsStart; fd < listenFdsStart+fds; fd++ {
+		file := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+		// the listener gets a duplicate of the file descriptor
+		listener, err := net.FileListener(file)
+		if closeErr := file.Close(); closeErr != nil {
+			listenerLogger.Warn("Failed to close socket file descriptor", "fd", fd, "error", closeErr)
+		}
+		if err != nil {
+			closeListeners(listeners)
+			return nil, errutil.Wrapf(err, "failed to use the socket passed by systemd as file descriptor %d", fd)
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+func closeListeners(listeners []net.Listener) {
+	for _, listener := range listeners {
+		if err := listener.Close(); err != nil {
+			listenerLogger.Warn("Failed to close listener", "address", listener.Addr().String(), "error", err)
+		}
+	}
+}
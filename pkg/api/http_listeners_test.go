@@ -0,0 +1,78 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListeners(t *testing.T) {
+	origProtocol := setting.Protocol
+	t.Cleanup(func() {
+		setting.Protocol = origProtocol
+	})
+	setting.Protocol = setting.HTTP
+
+	t.Run("opens the listeners of the protocol and the additional addresses", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "grafana-listeners")
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			err := os.RemoveAll(dir)
+			require.NoError(t, err)
+		})
+		socketPath := filepath.Join(dir, "grafana.sock")
+
+		hs := &HTTPServer{
+			log:     log.New("test"),
+			Cfg:     setting.NewCfg(),
+			httpSrv: &http.Server{Addr: "127.0.0.1:0"},
+		}
+		hs.Cfg.AdditionalListenAddresses = []string{"[::1]:0", unixAddressPrefix + socketPath}
+
+		listeners, err := hs.listeners()
+		if err != nil {
+			// the sandbox may not have an IPv6 loopback
+			hs.Cfg.AdditionalListenAddresses = []string{unixAddressPrefix + socketPath}
+			listeners, err = hs.listeners()
+		}
+		require.NoError(t, err)
+		defer closeListeners(listeners)
+
+		require.Len(t, listeners, len(hs.Cfg.AdditionalListenAddresses)+1)
+		require.Equal(t, "tcp", listeners[0].Addr().Network())
+		require.Equal(t, socketPath, listeners[len(listeners)-1].Addr().String())
+
+		info, err := os.Stat(socketPath)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0660), info.Mode().Perm())
+	})
+
+	t.Run("fails and closes the opened listeners when an address can't be listened on", func(t *testing.T) {
+		hs := &HTTPServer{
+			log:     log.New("test"),
+			Cfg:     setting.NewCfg(),
+			httpSrv: &http.Server{Addr: "127.0.0.1:0"},
+		}
+		hs.Cfg.AdditionalListenAddresses = []string{"invalid:address:0"}
+
+		_, err := hs.listeners()
+		require.Error(t, err)
+	})
+
+	t.Run("fails when socket activation is enabled without sockets from systemd", func(t *testing.T) {
+		hs := &HTTPServer{
+			log: log.New("test"),
+			Cfg: setting.NewCfg(),
+		}
+		hs.Cfg.SocketActivation = true
+
+		_, err := hs.listeners()
+		require.Error(t, err)
+	})
+}
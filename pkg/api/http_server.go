@@ -8,7 +8,9 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/grafana/grafana/pkg/services/search"
 
@@ -26,14 +28,22 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/datakeys"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/hooks"
+	"github.com/grafana/grafana/pkg/services/liveingest"
 	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/orgusage"
 	"github.com/grafana/grafana/pkg/services/provisioning"
+	"github.com/grafana/grafana/pkg/services/querycache"
+	"github.com/grafana/grafana/pkg/services/querystats"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/reporting"
+	"github.com/grafana/grafana/pkg/services/shadowquery"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/usageinsights"
 	"github.com/grafana/grafana/pkg/setting"
-	"github.com/grafana/grafana/pkg/util/errutil"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	macaron "gopkg.in/macaron.v1"
@@ -53,29 +63,40 @@ type HTTPServer struct {
 	context       context.Context
 	streamManager *live.StreamManager
 	httpSrv       *http.Server
-
-	RouteRegister        routing.RouteRegister            `inject:""`
-	Bus                  bus.Bus                          `inject:""`
-	RenderService        rendering.Service                `inject:""`
-	Cfg                  *setting.Cfg                     `inject:""`
-	HooksService         *hooks.HooksService              `inject:""`
-	CacheService         *localcache.CacheService         `inject:""`
-	DatasourceCache      datasources.CacheService         `inject:""`
-	AuthTokenService     models.UserTokenService          `inject:""`
-	QuotaService         *quota.QuotaService              `inject:""`
-	RemoteCacheService   *remotecache.RemoteCache         `inject:""`
-	ProvisioningService  provisioning.ProvisioningService `inject:""`
-	Login                *login.LoginService              `inject:""`
-	License              models.Licensing                 `inject:""`
-	BackendPluginManager backendplugin.Manager            `inject:""`
-	PluginManager        *plugins.PluginManager           `inject:""`
-	SearchService        *search.SearchService            `inject:""`
+	httpSrvMu     sync.Mutex
+
+	RouteRegister        routing.RouteRegister               `inject:""`
+	Bus                  bus.Bus                             `inject:""`
+	RenderService        rendering.Service                   `inject:""`
+	Cfg                  *setting.Cfg                        `inject:""`
+	HooksService         *hooks.HooksService                 `inject:""`
+	CacheService         *localcache.CacheService            `inject:""`
+	DatasourceCache      datasources.CacheService            `inject:""`
+	AuthTokenService     models.UserTokenService             `inject:""`
+	QuotaService         *quota.QuotaService                 `inject:""`
+	RemoteCacheService   *remotecache.RemoteCache            `inject:""`
+	ProvisioningService  provisioning.ProvisioningService    `inject:""`
+	Login                *login.LoginService                 `inject:""`
+	License              models.Licensing                    `inject:""`
+	BackendPluginManager backendplugin.Manager               `inject:""`
+	PluginManager        *plugins.PluginManager              `inject:""`
+	SearchService        *search.SearchService               `inject:""`
+	OrgUsageService      *orgusage.OrgUsageService           `inject:""`
+	ReportingService     *reporting.ReportingService         `inject:""`
+	DataKeysService      *datakeys.DataKeysService           `inject:""`
+	QueryCacheService    *querycache.QueryCacheService       `inject:""`
+	QueryStatsService    *querystats.QueryStatsService       `inject:""`
+	ShadowQueryService   *shadowquery.ShadowQueryService     `inject:""`
+	SQLStore             *sqlstore.SqlStore                  `inject:""`
+	UsageInsights        *usageinsights.UsageInsightsService `inject:""`
+	LiveIngestService    *liveingest.LiveIngestService       `inject:""`
 }
 
 func (hs *HTTPServer) Init() error {
 	hs.log = log.New("http.server")
 
-	hs.streamManager = live.NewStreamManager()
+	hs.streamManager = live.NewStreamManager(&dataSourceStreams{Manager: hs.BackendPluginManager}, hs.getStreamPluginContext)
+	hs.registerLiveChannels()
 	hs.macaron = hs.newMacaron()
 	hs.registerRoutes()
 
@@ -87,11 +108,14 @@ func (hs *HTTPServer) Run(ctx context.Context) error {
 
 	hs.applyRoutes()
 	hs.streamManager.Run(ctx)
+	hs.LiveIngestService.Start(ctx, hs.streamManager)
 
+	hs.httpSrvMu.Lock()
 	hs.httpSrv = &http.Server{
 		Addr:    fmt.Sprintf("%s:%s", setting.HttpAddr, setting.HttpPort),
 		Handler: hs.macaron,
 	}
+	hs.httpSrvMu.Unlock()
 	switch setting.Protocol {
 	case setting.HTTP2:
 		if err := hs.configureHttp2(); err != nil {
@@ -103,69 +127,94 @@ func (hs *HTTPServer) Run(ctx context.Context) error {
 		}
 	}
 
-	var listener net.Listener
-	switch setting.Protocol {
-	case setting.HTTP, setting.HTTPS, setting.HTTP2:
-		var err error
-		listener, err = net.Listen("tcp", hs.httpSrv.Addr)
-		if err != nil {
-			return errutil.Wrapf(err, "failed to open listener on address %s", hs.httpSrv.Addr)
-		}
-	case setting.SOCKET:
-		var err error
-		listener, err = net.ListenUnix("unix", &net.UnixAddr{Name: setting.SocketPath, Net: "unix"})
-		if err != nil {
-			return errutil.Wrapf(err, "failed to open listener for socket %s", setting.SocketPath)
-		}
-
-		// Make socket writable by group
-		if err := os.Chmod(setting.SocketPath, 0660); err != nil {
-			return errutil.Wrapf(err, "failed to change socket permissions")
-		}
-	default:
-		hs.log.Error("Invalid protocol", "protocol", setting.Protocol)
-		return fmt.Errorf("invalid protocol %q", setting.Protocol)
+	listeners, err := hs.listeners()
+	if err != nil {
+		return err
+	}
+	for _, listener := range listeners {
+		hs.log.Info("HTTP Server Listen", "address", listener.Addr().String(), "protocol",
+			setting.Protocol, "subUrl", setting.AppSubUrl, "socket", setting.SocketPath)
 	}
-
-	hs.log.Info("HTTP Server Listen", "address", listener.Addr().String(), "protocol",
-		setting.Protocol, "subUrl", setting.AppSubUrl, "socket", setting.SocketPath)
 
 	var wg sync.WaitGroup
 	wg.Add(1)
 
-	// handle http shutdown on server context done
+	// handle http shutdown on server context done, the server is usually drained by then
 	go func() {
 		defer wg.Done()
 
 		<-ctx.Done()
-		if err := hs.httpSrv.Shutdown(context.Background()); err != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), hs.Cfg.ShutdownTimeout)
+		defer cancel()
+		if err := hs.shutdown(shutdownCtx); err != nil {
 			hs.log.Error("Failed to shutdown server", "error", err)
 		}
 	}()
 
-	switch setting.Protocol {
-	case setting.HTTP, setting.SOCKET:
-		if err := hs.httpSrv.Serve(listener); err != nil {
-			if err == http.ErrServerClosed {
-				hs.log.Debug("server was shutdown gracefully")
-				return nil
+	// every listener is served until the server is shut down, or one of them fails
+	errs := make(chan error, len(listeners))
+	for _, listener := range listeners {
+		go func(listener net.Listener) {
+			errs <- hs.serve(listener)
+		}(listener)
+	}
+
+	for range listeners {
+		if err := <-errs; err != http.ErrServerClosed {
+			// the server is closed for the other listeners to stop as well
+			if closeErr := hs.httpSrv.Close(); closeErr != nil {
+				hs.log.Error("Failed to close server", "error", closeErr)
 			}
 			return err
 		}
+	}
+
+	// wait for the requests in progress
+	wg.Wait()
+	hs.log.Debug("server was shutdown gracefully")
+
+	return nil
+}
+
+// serve serves the requests of a listener until the server is shut down
+func (hs *HTTPServer) serve(listener net.Listener) error {
+	switch setting.Protocol {
+	case setting.HTTP, setting.SOCKET:
+		return hs.httpSrv.Serve(listener)
 	case setting.HTTP2, setting.HTTPS:
-		if err := hs.httpSrv.ServeTLS(listener, setting.CertFile, setting.KeyFile); err != nil {
-			if err == http.ErrServerClosed {
-				hs.log.Debug("server was shutdown gracefully")
-				return nil
-			}
-			return err
-		}
+		return hs.httpSrv.ServeTLS(listener, setting.CertFile, setting.KeyFile)
 	default:
 		panic(fmt.Sprintf("Unhandled protocol %q", setting.Protocol))
 	}
+}
 
-	wg.Wait()
+// DrainStage returns the stage in which the HTTP server is drained, first.
+func (hs *HTTPServer) DrainStage() registry.DrainStage {
+	return registry.DrainRequests
+}
+
+// Drain stops accepting requests and waits for the requests in progress to be served, so
+// that the queries and dashboard saves in progress aren't cut.
+func (hs *HTTPServer) Drain(ctx context.Context) error {
+	hs.log.Info("Stopped accepting requests, waiting for the requests in progress")
+	return hs.shutdown(ctx)
+}
 
+// shutdown closes the listener and the idle connections, then the active ones when ctx is done
+// before they're idle.
+func (hs *HTTPServer) shutdown(ctx context.Context) error {
+	hs.httpSrvMu.Lock()
+	defer hs.httpSrvMu.Unlock()
+	if hs.httpSrv == nil {
+		return nil
+	}
+
+	if err := hs.httpSrv.Shutdown(ctx); err != nil {
+		if closeErr := hs.httpSrv.Close(); closeErr != nil {
+			hs.log.Warn("Failed to close connections", "error", closeErr)
+		}
+		return err
+	}
 	return nil
 }
 
@@ -274,6 +323,7 @@ func (hs *HTTPServer) applyRoutes() {
 func (hs *HTTPServer) addMiddlewaresAndStaticRoutes() {
 	m := hs.macaron
 
+	m.Use(middleware.Tracing())
 	m.Use(middleware.Logger())
 
 	if setting.EnableGzip {
@@ -282,11 +332,20 @@ func (hs *HTTPServer) addMiddlewaresAndStaticRoutes() {
 
 	m.Use(middleware.Recovery())
 
-	for _, route := range plugins.StaticRoutes {
-		pluginRoute := path.Join("/public/plugins/", route.PluginId)
-		hs.log.Debug("Plugins: Adding route", "route", pluginRoute, "dir", route.Directory)
-		hs.mapStatic(m, route.Directory, "", pluginRoute)
-	}
+	// the routes of the plugins are looked up on each request, since plugins can be installed
+	// after startup
+	m.Use(httpstatic.Dynamic(func(c *macaron.Context) (string, string) {
+		if !strings.HasPrefix(c.Req.URL.Path, "/public/plugins/") {
+			return "", ""
+		}
+
+		pluginID := strings.SplitN(strings.TrimPrefix(c.Req.URL.Path, "/public/plugins/"), "/", 2)[0]
+		route, exists := plugins.GetStaticRoute(pluginID)
+		if !exists {
+			return "", ""
+		}
+		return route.Directory, path.Join("/public/plugins/", route.PluginId)
+	}, httpstatic.StaticOptions{SkipLogging: true, AddHeaders: staticHeaders("")}))
 
 	hs.mapStatic(m, setting.StaticRootPath, "build", "public/build")
 	hs.mapStatic(m, setting.StaticRootPath, "", "public")
@@ -309,6 +368,7 @@ func (hs *HTTPServer) addMiddlewaresAndStaticRoutes() {
 	}))
 
 	m.Use(hs.healthHandler)
+	m.Use(hs.readinessHandler)
 	m.Use(hs.metricsEndpoint)
 	m.Use(middleware.GetContextHandler(
 		hs.AuthTokenService,
@@ -316,6 +376,8 @@ func (hs *HTTPServer) addMiddlewaresAndStaticRoutes() {
 		hs.RenderService,
 	))
 	m.Use(middleware.OrgRedirect())
+	m.Use(middleware.OrgApiUsage(hs.OrgUsageService))
+	m.Use(middleware.RateLimit(hs.Cfg.RateLimit))
 
 	// needs to be after context handler
 	if setting.EnforceDomain {
@@ -325,6 +387,23 @@ func (hs *HTTPServer) addMiddlewaresAndStaticRoutes() {
 	m.Use(middleware.HandleNoCacheHeader())
 }
 
+// API route groups whose responses can be compressed and tagged
+const (
+	routeGroupDashboards = "dashboards"
+	routeGroupSearch     = "search"
+	routeGroupQuery      = "query"
+)
+
+// encodeResponse returns the middleware compressing and tagging the responses of a route group,
+// as configured by compressed_routes and etag_routes
+func (hs *HTTPServer) encodeResponse(group string) macaron.Handler {
+	return middleware.EncodeResponse(middleware.ResponseEncodingOptions{
+		Compress:        hs.Cfg.CompressedRoutes[group],
+		CompressMinSize: hs.Cfg.CompressMinSize,
+		ETag:            hs.Cfg.ETagRoutes[group],
+	})
+}
+
 func (hs *HTTPServer) metricsEndpoint(ctx *macaron.Context) {
 	if !hs.Cfg.MetricsEndpointEnabled {
 		return
@@ -339,8 +418,10 @@ func (hs *HTTPServer) metricsEndpoint(ctx *macaron.Context) {
 		return
 	}
 
+	// metrics of backend plugins that conflict with Grafana's own metrics are left out
+	gatherer := prometheus.Gatherers{prometheus.DefaultGatherer, hs.BackendPluginManager}
 	promhttp.
-		HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{}).
+		HandlerFor(gatherer, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError}).
 		ServeHTTP(ctx.Resp, ctx.Req.Request)
 }
 
@@ -372,33 +453,126 @@ func (hs *HTTPServer) healthHandler(ctx *macaron.Context) {
 	}
 }
 
-func (hs *HTTPServer) mapStatic(m *macaron.Macaron, rootDir string, dir string, prefix string) {
-	headers := func(c *macaron.Context) {
-		c.Resp.Header().Set("Cache-Control", "public, max-age=3600")
+// Dependencies checked by the readiness endpoint, failing it when listed in readiness_checks
+const (
+	readinessDatabase    = "database"
+	readinessRemoteCache = "remote_cache"
+	readinessRenderer    = "renderer"
+	readinessPlugins     = "plugins"
+)
+
+// readinessCheckTimeout bounds the checks of the readiness endpoint reaching out to other services
+const readinessCheckTimeout = 5 * time.Second
+
+// readinessHandler reports whether the dependencies of Grafana are healthy, answering with 503
+// when one of the dependencies listed in readiness_checks isn't. A renderer that isn't configured
+// is reported as disabled.
+func (hs *HTTPServer) readinessHandler(ctx *macaron.Context) {
+	notHeadOrGet := ctx.Req.Method != http.MethodGet && ctx.Req.Method != http.MethodHead
+	if notHeadOrGet || ctx.Req.URL.Path != "/api/health/ready" {
+		return
 	}
 
-	if prefix == "public/build" {
-		headers = func(c *macaron.Context) {
-			c.Resp.Header().Set("Cache-Control", "public, max-age=31536000")
-		}
+	checkCtx, cancel := context.WithTimeout(ctx.Req.Context(), readinessCheckTimeout)
+	defer cancel()
+
+	data := simplejson.New()
+	if !hs.Cfg.AnonymousHideVersion {
+		data.Set("version", setting.BuildVersion)
+		data.Set("commit", setting.BuildCommit)
 	}
 
-	if setting.Env == setting.DEV {
-		headers = func(c *macaron.Context) {
-			c.Resp.Header().Set("Cache-Control", "max-age=0, must-revalidate, no-cache")
+	ready := true
+	report := func(dependency string, key string, err error) {
+		if err == nil {
+			data.Set(key, "ok")
+			return
+		}
+
+		hs.log.Debug("Readiness check failed", "dependency", dependency, "error", err)
+		data.Set(key, "failing")
+		if hs.Cfg.ReadinessChecks[dependency] {
+			ready = false
 		}
 	}
 
+	report(readinessDatabase, "database", bus.Dispatch(&models.GetDBHealthQuery{}))
+	report(readinessRemoteCache, "remoteCache", hs.checkRemoteCache())
+
+	if err := hs.RenderService.CheckHealth(checkCtx); err == rendering.ErrNoRenderer {
+		data.Set("renderer", "disabled")
+	} else {
+		report(readinessRenderer, "renderer", err)
+	}
+
+	var pluginsErr error
+	if exited := hs.BackendPluginManager.ExitedPlugins(); len(exited) > 0 {
+		pluginsErr = fmt.Errorf("plugin processes exited: %v", exited)
+	}
+	report(readinessPlugins, "plugins", pluginsErr)
+
+	ctx.Resp.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if ready {
+		ctx.Resp.WriteHeader(200)
+	} else {
+		ctx.Resp.WriteHeader(503)
+	}
+
+	dataBytes, _ := data.EncodePretty()
+	if _, err := ctx.Resp.Write(dataBytes); err != nil {
+		hs.log.Error("Failed to write to response", "err", err)
+	}
+}
+
+// checkRemoteCache writes a value to the remote cache and reads it back, under a key of its own
+// so that the instances sharing the cache don't overwrite each other's value
+func (hs *HTTPServer) checkRemoteCache() error {
+	key := "health-check-" + setting.InstanceName
+	value := time.Now().String()
+	if err := hs.RemoteCacheService.Set(key, value, time.Minute); err != nil {
+		return err
+	}
+
+	cached, err := hs.RemoteCacheService.Get(key)
+	if err != nil {
+		return err
+	}
+	if cached != value {
+		return fmt.Errorf("remote cache returned %v instead of %v", cached, value)
+	}
+	return nil
+}
+
+func (hs *HTTPServer) mapStatic(m *macaron.Macaron, rootDir string, dir string, prefix string) {
 	m.Use(httpstatic.Static(
 		path.Join(rootDir, dir),
 		httpstatic.StaticOptions{
 			SkipLogging: true,
 			Prefix:      prefix,
-			AddHeaders:  headers,
+			AddHeaders:  staticHeaders(prefix),
 		},
 	))
 }
 
+// staticHeaders returns the function setting the cache headers of the static files served under prefix
+func staticHeaders(prefix string) func(c *macaron.Context) {
+	if setting.Env == setting.DEV {
+		return func(c *macaron.Context) {
+			c.Resp.Header().Set("Cache-Control", "max-age=0, must-revalidate, no-cache")
+		}
+	}
+
+	if prefix == "public/build" {
+		return func(c *macaron.Context) {
+			c.Resp.Header().Set("Cache-Control", "public, max-age=31536000")
+		}
+	}
+
+	return func(c *macaron.Context) {
+		c.Resp.Header().Set("Cache-Control", "public, max-age=3600")
+	}
+}
+
 func (hs *HTTPServer) metricsEndpointBasicAuthEnabled() bool {
 	return hs.Cfg.MetricsEndpointBasicAuthUsername != "" && hs.Cfg.MetricsEndpointBasicAuthPassword != ""
 }
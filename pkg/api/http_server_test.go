@@ -1,10 +1,22 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/setting"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/require"
+	macaron "gopkg.in/macaron.v1"
 )
 
 func TestHTTPServer(t *testing.T) {
@@ -28,3 +40,65 @@ func TestHTTPServer(t *testing.T) {
 		})
 	})
 }
+
+type fakeExitedPluginsManager struct {
+	backendplugin.Manager
+	exited []string
+}
+
+func (m *fakeExitedPluginsManager) ExitedPlugins() []string {
+	return m.exited
+}
+
+func TestReadinessHandler(t *testing.T) {
+	var dbErr error
+	bus.ClearBusHandlers()
+	bus.AddHandler("test", func(query *models.GetDBHealthQuery) error {
+		return dbErr
+	})
+	t.Cleanup(bus.ClearBusHandlers)
+
+	cfg := setting.NewCfg()
+	cfg.ReadinessChecks = map[string]bool{"database": true, "remote_cache": true}
+	hs := &HTTPServer{
+		Cfg:                  cfg,
+		log:                  log.New("test"),
+		RemoteCacheService:   remotecache.NewFakeStore(t),
+		RenderService:        &rendering.RenderingService{Cfg: cfg},
+		BackendPluginManager: &fakeExitedPluginsManager{exited: []string{"test-plugin"}},
+	}
+
+	m := macaron.New()
+	m.Use(hs.readinessHandler)
+
+	ready := func() (int, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/health/ready", nil))
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec.Code, body
+	}
+
+	t.Run("Should report every dependency and fail on the required ones only", func(t *testing.T) {
+		code, body := ready()
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, "ok", body["database"])
+		require.Equal(t, "ok", body["remoteCache"])
+		require.Equal(t, "disabled", body["renderer"])
+		require.Equal(t, "failing", body["plugins"])
+
+		cfg.ReadinessChecks["plugins"] = true
+		defer delete(cfg.ReadinessChecks, "plugins")
+		code, _ = ready()
+		require.Equal(t, http.StatusServiceUnavailable, code)
+	})
+
+	t.Run("Should not be ready when the database is failing", func(t *testing.T) {
+		dbErr = errors.New("database is down")
+		defer func() { dbErr = nil }()
+
+		code, body := ready()
+		require.Equal(t, http.StatusServiceUnavailable, code)
+		require.Equal(t, "failing", body["database"])
+	})
+}
@@ -0,0 +1,341 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/live"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// liveKioskFeature is the live channel of the kiosk devices, published to with the device id as path
+const liveKioskFeature = "kiosk"
+
+// kioskDevicePollInterval is how often the kiosk devices check the versions of their dashboards,
+// in case they missed a message of their channel
+const kioskDevicePollInterval = "30s"
+
+// Messages sent to the channel of a kiosk device
+const (
+	// kioskDeviceRefresh asks the device to reload the dashboard it shows
+	kioskDeviceRefresh = "refresh"
+	// kioskDeviceDashboardsChanged asks the device to get its dashboards again
+	kioskDeviceDashboardsChanged = "dashboards-changed"
+	// kioskDeviceDashboardSaved tells the device that one of its dashboards has a new version
+	kioskDeviceDashboardSaved = "dashboard-saved"
+)
+
+type kioskDeviceMessage struct {
+	Type    string `json:"type"`
+	Uid     string `json:"uid,omitempty"`
+	Version int    `json:"version,omitempty"`
+}
+
+func kioskDeviceChannel(deviceID int64) string {
+	return live.ScopeGrafana + "/" + liveKioskFeature + "/" + strconv.FormatInt(deviceID, 10)
+}
+
+// canSubscribeKioskDeviceChannel allows a kiosk device, and the admins of its organization, to
+// subscribe to the channel of the device
+func canSubscribeKioskDeviceChannel(user *models.SignedInUser, path string) error {
+	id, err := strconv.ParseInt(path, 10, 64)
+	if err != nil {
+		return live.ErrStreamNotFound
+	}
+
+	if user.IsAnonymous {
+		if user.Login != models.KioskDeviceLogin(id) {
+			return live.ErrStreamPermissionDenied
+		}
+		return nil
+	}
+
+	if user.OrgRole != models.ROLE_ADMIN {
+		return live.ErrStreamPermissionDenied
+	}
+	if err := bus.Dispatch(&models.GetKioskDeviceByIdQuery{Id: id, OrgId: user.OrgId}); err != nil {
+		if err == models.ErrKioskDeviceNotFound {
+			return live.ErrStreamNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (hs *HTTPServer) publishKioskDeviceMessage(orgID int64, deviceID int64, msg kioskDeviceMessage) {
+	channel := kioskDeviceChannel(deviceID)
+	if err := hs.streamManager.Publish(orgID, channel, msg); err != nil {
+		hs.log.Warn("Failed to publish to kiosk device", "channel", channel, "error", err)
+	}
+}
+
+// publishKioskDashboardSaved tells the kiosk devices showing a dashboard that it has been saved
+func (hs *HTTPServer) publishKioskDashboardSaved(evt *events.DashboardSaved) error {
+	query := models.GetKioskDevicesByDashboardQuery{OrgId: evt.OrgId, DashboardUid: evt.Uid}
+	if err := bus.Dispatch(&query); err != nil {
+		hs.log.Warn("Failed to get the kiosk devices of dashboard", "uid", evt.Uid, "error", err)
+		return nil
+	}
+
+	for _, id := range query.Result {
+		hs.publishKioskDeviceMessage(evt.OrgId, id, kioskDeviceMessage{Type: kioskDeviceDashboardSaved, Uid: evt.Uid, Version: evt.Version})
+	}
+	return nil
+}
+
+// GET /api/kiosk-devices
+func GetKioskDevices(c *models.ReqContext) Response {
+	query := models.GetKioskDevicesQuery{OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get kiosk devices", err)
+	}
+
+	return JSON(200, query.Result)
+}
+
+// GET /api/kiosk-devices/:id
+func GetKioskDevice(c *models.ReqContext) Response {
+	query := models.GetKioskDeviceByIdQuery{Id: c.ParamsInt64(":id"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		if err == models.ErrKioskDeviceNotFound {
+			return Error(404, "Kiosk device not found", err)
+		}
+		return Error(500, "Failed to get kiosk device", err)
+	}
+
+	return JSON(200, query.Result)
+}
+
+func kioskDeviceSaveError(err error, message string) Response {
+	switch err {
+	case models.ErrKioskDeviceNotFound:
+		return Error(404, "Kiosk device not found", err)
+	case models.ErrKioskDeviceNameEmpty:
+		return Error(400, err.Error(), err)
+	case models.ErrKioskDeviceNameTaken:
+		return Error(409, err.Error(), err)
+	}
+	return Error(500, message, err)
+}
+
+// POST /api/kiosk-devices
+func CreateKioskDevice(c *models.ReqContext, cmd models.CreateKioskDeviceCommand) Response {
+	cmd.OrgId = c.OrgId
+	if err := bus.Dispatch(&cmd); err != nil {
+		return kioskDeviceSaveError(err, "Failed to create kiosk device")
+	}
+
+	return JSON(200, util.DynMap{
+		"id":            cmd.Result.Id,
+		"name":          cmd.Result.Name,
+		"dashboardUids": cmd.Result.DashboardUids,
+		"token":         cmd.Token,
+	})
+}
+
+// PUT /api/kiosk-devices/:id
+func (hs *HTTPServer) UpdateKioskDevice(c *models.ReqContext, cmd models.UpdateKioskDeviceCommand) Response {
+	cmd.Id = c.ParamsInt64(":id")
+	cmd.OrgId = c.OrgId
+	if err := bus.Dispatch(&cmd); err != nil {
+		return kioskDeviceSaveError(err, "Failed to update kiosk device")
+	}
+
+	hs.publishKioskDeviceMessage(c.OrgId, cmd.Id, kioskDeviceMessage{Type: kioskDeviceDashboardsChanged})
+	return JSON(200, cmd.Result)
+}
+
+// DELETE /api/kiosk-devices/:id
+func DeleteKioskDevice(c *models.ReqContext) Response {
+	cmd := models.DeleteKioskDeviceCommand{Id: c.ParamsInt64(":id"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&cmd); err != nil {
+		if err == models.ErrKioskDeviceNotFound {
+			return Error(404, "Kiosk device not found", err)
+		}
+		return Error(500, "Failed to delete kiosk device", err)
+	}
+
+	return Success("Kiosk device deleted")
+}
+
+// POST /api/kiosk-devices/:id/token
+func RegenerateKioskDeviceToken(c *models.ReqContext) Response {
+	cmd := models.RegenerateKioskDeviceTokenCommand{Id: c.ParamsInt64(":id"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&cmd); err != nil {
+		if err == models.ErrKioskDeviceNotFound {
+			return Error(404, "Kiosk device not found", err)
+		}
+		return Error(500, "Failed to regenerate kiosk device token", err)
+	}
+
+	return JSON(200, util.DynMap{
+		"token": cmd.Result,
+	})
+}
+
+// POST /api/kiosk-devices/:id/refresh
+func (hs *HTTPServer) RefreshKioskDevice(c *models.ReqContext) Response {
+	query := models.GetKioskDeviceByIdQuery{Id: c.ParamsInt64(":id"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		if err == models.ErrKioskDeviceNotFound {
+			return Error(404, "Kiosk device not found", err)
+		}
+		return Error(500, "Failed to get kiosk device", err)
+	}
+
+	hs.publishKioskDeviceMessage(c.OrgId, query.Result.Id, kioskDeviceMessage{Type: kioskDeviceRefresh})
+	return Success("Kiosk device refresh requested")
+}
+
+// getKioskTokenDevice returns the kiosk device of a kiosk token
+func getKioskTokenDevice(token *models.KioskToken) (*models.KioskDeviceDTO, error) {
+	if token.DeviceId == 0 {
+		return nil, models.ErrKioskDeviceNotFound
+	}
+
+	query := models.GetKioskDeviceByIdQuery{Id: token.DeviceId, OrgId: token.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+	return query.Result, nil
+}
+
+func getKioskDevice(c *models.ReqContext) (*models.KioskDeviceDTO, *models.KioskToken, Response) {
+	token, rsp := getKioskToken(c)
+	if rsp != nil {
+		return nil, nil, rsp
+	}
+
+	device, err := getKioskTokenDevice(token)
+	if err != nil {
+		if err == models.ErrKioskDeviceNotFound {
+			return nil, nil, Error(404, "Kiosk device not found", err)
+		}
+		return nil, nil, Error(500, "Failed to get kiosk device", err)
+	}
+
+	return device, token, nil
+}
+
+// loadKioskDeviceDashboards returns the dashboards of a device it can see, in the order they were
+// bound to the device. The dashboards that have been deleted are skipped.
+func loadKioskDeviceDashboards(device *models.KioskDeviceDTO, user *models.SignedInUser) ([]*models.Dashboard, error) {
+	if len(device.DashboardUids) == 0 {
+		return []*models.Dashboard{}, nil
+	}
+
+	query := models.GetDashboardsQuery{DashboardUIds: device.DashboardUids, OrgId: device.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+
+	byUid := make(map[string]*models.Dashboard, len(query.Result))
+	for _, dash := range query.Result {
+		byUid[dash.Uid] = dash
+	}
+
+	result := make([]*models.Dashboard, 0, len(query.Result))
+	for _, uid := range device.DashboardUids {
+		dash, ok := byUid[uid]
+		if !ok {
+			continue
+		}
+
+		canView, err := guardian.New(dash.Id, device.OrgId, user).CanView()
+		if err != nil {
+			return nil, err
+		}
+		if canView {
+			result = append(result, dash)
+		}
+	}
+	return result, nil
+}
+
+// GET /api/kiosk-device
+func GetKioskDeviceKiosk(c *models.ReqContext) Response {
+	device, token, rsp := getKioskDevice(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	dashboards, err := loadKioskDeviceDashboards(device, token.SignedInUser())
+	if err != nil {
+		return Error(500, "Could not load dashboards", err)
+	}
+
+	if err := bus.Dispatch(&models.UpdateKioskDeviceLastSeenCommand{Id: device.Id}); err != nil {
+		c.Logger.Warn("Failed to update the last seen time of kiosk device", "id", device.Id, "error", err)
+	}
+
+	result := dtos.KioskDeviceKioskDTO{
+		Id:           device.Id,
+		Name:         device.Name,
+		Channel:      kioskDeviceChannel(device.Id),
+		PollInterval: kioskDevicePollInterval,
+		Dashboards:   make([]dtos.KioskDeviceDashboard, 0, len(dashboards)),
+	}
+	for _, dash := range dashboards {
+		result.Dashboards = append(result.Dashboards, dtos.KioskDeviceDashboard{
+			Uid:     dash.Uid,
+			Title:   dash.Title,
+			Url:     dash.GetUrl(),
+			Version: dash.Version,
+		})
+	}
+
+	return JSON(200, result)
+}
+
+// GET /api/kiosk-device/dashboards/:uid
+func GetKioskDeviceDashboard(c *models.ReqContext) Response {
+	device, token, rsp := getKioskDevice(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	dashboards, err := loadKioskDeviceDashboards(device, token.SignedInUser())
+	if err != nil {
+		return Error(500, "Could not load dashboards", err)
+	}
+
+	uid := c.Params(":uid")
+	for _, dash := range dashboards {
+		if dash.Uid != uid {
+			continue
+		}
+
+		// the dashboard is shared with anyone having the device token
+		models.RedactSensitiveFields(dash.Data)
+
+		return JSON(200, dtos.DashboardFullWithMeta{
+			Dashboard: dash.Data,
+			Meta: dtos.DashboardMeta{
+				Type:    models.DashTypeDB,
+				Slug:    dash.Slug,
+				Url:     dash.GetUrl(),
+				Created: dash.Created,
+				Updated: dash.Updated,
+				Version: dash.Version,
+			},
+		})
+	}
+
+	return Error(404, "Dashboard not found for kiosk device", nil)
+}
+
+// GET /api/kiosk-device/ws
+func (hs *HTTPServer) ServeKioskDeviceLive(c *models.ReqContext) {
+	_, token, rsp := getKioskDevice(c)
+	if rsp != nil {
+		rsp.WriteTo(c)
+		return
+	}
+
+	c.SignedInUser = token.SignedInUser()
+	hs.streamManager.Serve(c)
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// getKioskToken returns the kiosk token of a playlist or of a kiosk device, which the kiosks send in
+// the X-Grafana-Kiosk-Token header
+func getKioskToken(c *models.ReqContext) (*models.KioskToken, Response) {
+	if c.KioskToken == nil {
+		return nil, Error(401, "Kiosk token missing from the X-Grafana-Kiosk-Token header", nil)
+	}
+
+	return c.KioskToken, nil
+}
+
+// kioskTokenDashboardIDs returns the ids of the dashboards the kiosks using a token can see
+func kioskTokenDashboardIDs(token *models.KioskToken) ([]int64, error) {
+	ids := make([]int64, 0)
+
+	if token.PlaylistId != 0 {
+		playlist, err := getKioskTokenPlaylist(token)
+		if err != nil {
+			return nil, err
+		}
+		dashboards, err := loadKioskPlaylistDashboards(playlist, token.SignedInUser())
+		if err != nil {
+			return nil, err
+		}
+		for _, dash := range dashboards {
+			ids = append(ids, dash.Id)
+		}
+		return ids, nil
+	}
+
+	device, err := getKioskTokenDevice(token)
+	if err != nil {
+		return nil, err
+	}
+	dashboards, err := loadKioskDeviceDashboards(device, token.SignedInUser())
+	if err != nil {
+		return nil, err
+	}
+	for _, dash := range dashboards {
+		ids = append(ids, dash.Id)
+	}
+	return ids, nil
+}
+
+// checkKioskQuery limits the data queries authenticated with a kiosk token to the data sources of
+// the dashboards of the token. The dashboard is told by the X-Dashboard-Id header.
+func checkKioskQuery(c *models.ReqContext, ds *models.DataSource) Response {
+	if c.KioskToken == nil {
+		return nil
+	}
+
+	dashboardID, err := strconv.ParseInt(c.Req.Header.Get("X-Dashboard-Id"), 10, 64)
+	if err != nil {
+		return Error(403, "Kiosk queries must set the X-Dashboard-Id header", nil)
+	}
+
+	ids, err := kioskTokenDashboardIDs(c.KioskToken)
+	if err != nil {
+		return Error(500, "Could not load dashboards", err)
+	}
+
+	found := false
+	for _, id := range ids {
+		if id == dashboardID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return Error(403, "Dashboard not found for kiosk token", nil)
+	}
+
+	query := models.GetDashboardQuery{Id: dashboardID, OrgId: c.KioskToken.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(403, "Dashboard not found for kiosk token", err)
+	}
+
+	if !dashboardUsesDataSource(query.Result.Data, ds) {
+		return Error(403, "Data source not used by the dashboard", nil)
+	}
+	return nil
+}
+
+// dashboardUsesDataSource checks if a panel of a dashboard queries a data source, by its name, as
+// the default data source or through a data source template variable of its type.
+func dashboardUsesDataSource(dashboard *simplejson.Json, ds *models.DataSource) bool {
+	variableTypes := make(map[string]string)
+	for _, variable := range dashboard.GetPath("templating", "list").MustArray() {
+		v := simplejson.NewFromAny(variable)
+		if v.Get("type").MustString() == "datasource" {
+			variableTypes[v.Get("name").MustString()] = v.Get("query").MustString()
+		}
+	}
+
+	matches := func(ref *simplejson.Json) bool {
+		name, _ := ref.Interface().(string)
+		switch {
+		case name == "" || name == "default":
+			return ds.IsDefault
+		case strings.HasPrefix(name, "$"):
+			variable := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(name, "$"), "{"), "}")
+			pluginType, exists := variableTypes[variable]
+			return exists && pluginType == ds.Type
+		}
+		return name == ds.Name
+	}
+
+	var walk func(panels []interface{}) bool
+	walk = func(panels []interface{}) bool {
+		for _, p := range panels {
+			panel := simplejson.NewFromAny(p)
+			// the panels without queries, like text panels and rows, don't use their data source
+			targets := panel.Get("targets").MustArray()
+			if len(targets) > 0 && matches(panel.Get("datasource")) {
+				return true
+			}
+			for _, target := range targets {
+				if ref, exists := simplejson.NewFromAny(target).CheckGet("datasource"); exists && matches(ref) {
+					return true
+				}
+			}
+			if walk(panel.Get("panels").MustArray()) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if walk(dashboard.Get("panels").MustArray()) {
+		return true
+	}
+	// dashboards of the schema before version 16 have their panels in rows
+	for _, row := range dashboard.Get("rows").MustArray() {
+		if walk(simplejson.NewFromAny(row).Get("panels").MustArray()) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	macaron "gopkg.in/macaron.v1"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+)
+
+func TestDashboardUsesDataSource(t *testing.T) {
+	dashboard := simplejson.NewFromAny(map[string]interface{}{
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{"name": "ds", "type": "datasource", "query": "prometheus"},
+			},
+		},
+		"panels": []interface{}{
+			map[string]interface{}{"type": "text", "datasource": nil},
+			map[string]interface{}{
+				"type":       "graph",
+				"datasource": "Graphite",
+				"targets":    []interface{}{map[string]interface{}{"refId": "A"}},
+			},
+			map[string]interface{}{
+				"type": "row",
+				"panels": []interface{}{
+					map[string]interface{}{
+						"type":       "graph",
+						"datasource": "-- Mixed --",
+						"targets": []interface{}{
+							map[string]interface{}{"refId": "A", "datasource": "Loki"},
+							map[string]interface{}{"refId": "B", "datasource": "${ds}"},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	assert.True(t, dashboardUsesDataSource(dashboard, &models.DataSource{Name: "Graphite", Type: "graphite"}))
+	assert.True(t, dashboardUsesDataSource(dashboard, &models.DataSource{Name: "Loki", Type: "loki"}))
+	assert.True(t, dashboardUsesDataSource(dashboard, &models.DataSource{Name: "Other Prometheus", Type: "prometheus"}))
+	assert.False(t, dashboardUsesDataSource(dashboard, &models.DataSource{Name: "MySQL", Type: "mysql", IsDefault: true}))
+	assert.False(t, dashboardUsesDataSource(dashboard, &models.DataSource{Name: "Graphite 2", Type: "graphite"}))
+
+	withDefault := simplejson.NewFromAny(map[string]interface{}{
+		"panels": []interface{}{
+			map[string]interface{}{"type": "graph", "targets": []interface{}{map[string]interface{}{"refId": "A"}}},
+		},
+	})
+	assert.True(t, dashboardUsesDataSource(withDefault, &models.DataSource{Name: "MySQL", Type: "mysql", IsDefault: true}))
+	assert.False(t, dashboardUsesDataSource(withDefault, &models.DataSource{Name: "Loki", Type: "loki"}))
+}
+
+func TestCheckKioskQuery(t *testing.T) {
+	bus.ClearBusHandlers()
+	defer bus.ClearBusHandlers()
+
+	bus.AddHandler("test", func(query *models.GetKioskDeviceByIdQuery) error {
+		query.Result = &models.KioskDeviceDTO{Id: query.Id, OrgId: query.OrgId, DashboardUids: []string{"lobby"}}
+		return nil
+	})
+	lobby := &models.Dashboard{
+		Id:    2,
+		Uid:   "lobby",
+		OrgId: 1,
+		Data: simplejson.NewFromAny(map[string]interface{}{
+			"panels": []interface{}{
+				map[string]interface{}{"datasource": "Graphite", "targets": []interface{}{map[string]interface{}{"refId": "A"}}},
+			},
+		}),
+	}
+	bus.AddHandler("test", func(query *models.GetDashboardsQuery) error {
+		query.Result = []*models.Dashboard{lobby}
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+		if query.Id != lobby.Id {
+			return models.ErrDashboardNotFound
+		}
+		query.Result = lobby
+		return nil
+	})
+
+	origNewGuardian := guardian.New
+	defer func() { guardian.New = origNewGuardian }()
+	guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanViewValue: true})
+
+	token := &models.KioskToken{Id: 1, OrgId: 1, DeviceId: 3}
+	newContext := func(dashboardID string) *models.ReqContext {
+		req, err := http.NewRequest("POST", "/api/ds/query", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Dashboard-Id", dashboardID)
+		return &models.ReqContext{
+			Context:      &macaron.Context{Req: macaron.Request{Request: req}},
+			SignedInUser: token.SignedInUser(),
+			KioskToken:   token,
+		}
+	}
+	graphite := &models.DataSource{Name: "Graphite", Type: "graphite"}
+
+	t.Run("Should allow the data sources of the dashboards of the token", func(t *testing.T) {
+		assert.Nil(t, checkKioskQuery(newContext("2"), graphite))
+	})
+
+	t.Run("Should refuse the other data sources", func(t *testing.T) {
+		rsp := checkKioskQuery(newContext("2"), &models.DataSource{Name: "Loki", Type: "loki"})
+		require.NotNil(t, rsp)
+		assert.Equal(t, 403, rsp.(*NormalResponse).status)
+	})
+
+	t.Run("Should refuse the other dashboards", func(t *testing.T) {
+		rsp := checkKioskQuery(newContext("4"), graphite)
+		require.NotNil(t, rsp)
+		assert.Equal(t, 403, rsp.(*NormalResponse).status)
+
+		rsp = checkKioskQuery(newContext(""), graphite)
+		require.NotNil(t, rsp)
+		assert.Equal(t, 403, rsp.(*NormalResponse).status)
+	})
+
+	t.Run("Should allow the queries not authenticated with a kiosk token", func(t *testing.T) {
+		c := newContext("")
+		c.KioskToken = nil
+		assert.Nil(t, checkKioskQuery(c, graphite))
+	})
+}
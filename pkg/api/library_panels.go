@@ -0,0 +1,422 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+var libraryPanelsLogger = log.New("library-panels")
+
+// GET /api/library-panels
+func SearchLibraryPanels(c *models.ReqContext) Response {
+	perPage := c.QueryInt("perpage")
+	if perPage <= 0 {
+		perPage = 100
+	}
+	page := c.QueryInt("page")
+	if page < 1 {
+		page = 1
+	}
+
+	folderIds := make([]int64, 0)
+	for _, id := range c.QueryStrings("folderId") {
+		folderId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return Error(400, "Invalid folderId", err)
+		}
+		folderIds = append(folderIds, folderId)
+	}
+
+	viewableFolderIds, err := getViewableLibraryPanelFolderIds(c, folderIds)
+	if err != nil {
+		return Error(500, "Failed to get folders", err)
+	}
+
+	if len(viewableFolderIds) == 0 && (len(folderIds) > 0 || c.OrgRole != models.ROLE_ADMIN) {
+		return JSON(200, make([]*models.LibraryPanelDTO, 0))
+	}
+
+	query := models.SearchLibraryPanelsQuery{
+		OrgId:     c.OrgId,
+		Name:      c.Query("name"),
+		FolderIds: viewableFolderIds,
+		Limit:     perPage,
+		Page:      page,
+	}
+
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to search library panels", err)
+	}
+
+	result := make([]*models.LibraryPanelDTO, 0, len(query.Result))
+	for _, libraryPanel := range query.Result {
+		dto := libraryPanel.ToDTO()
+		dto.Meta.CanEdit, _ = guardian.New(libraryPanel.FolderId, c.OrgId, c.SignedInUser).CanSave()
+		if !dto.Meta.CanEdit {
+			models.RedactPanelSensitiveFields(dto.Model)
+		}
+		result = append(result, dto)
+	}
+
+	return JSON(200, result)
+}
+
+// GET /api/library-panels/:uid
+func GetLibraryPanelByUID(c *models.ReqContext) Response {
+	query := models.GetLibraryPanelQuery{Uid: c.Params(":uid"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return toLibraryPanelError(err, "Failed to get library panel")
+	}
+
+	g := guardian.New(query.Result.FolderId, c.OrgId, c.SignedInUser)
+	if canView, err := g.CanView(); err != nil || !canView {
+		return libraryPanelGuardianResponse(err)
+	}
+
+	dto := query.Result.ToDTO()
+	dto.Meta.CanEdit, _ = g.CanSave()
+	if !dto.Meta.CanEdit {
+		models.RedactPanelSensitiveFields(dto.Model)
+	}
+
+	return JSON(200, dto)
+}
+
+// POST /api/library-panels
+func CreateLibraryPanel(c *models.ReqContext, cmd models.CreateLibraryPanelCommand) Response {
+	cmd.OrgId = c.OrgId
+	cmd.UserId = c.UserId
+
+	if rsp := validateLibraryPanel(cmd.Uid, cmd.Name, cmd.Model); rsp != nil {
+		return rsp
+	}
+
+	if rsp := checkLibraryPanelFolder(c, cmd.FolderId); rsp != nil {
+		return rsp
+	}
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toLibraryPanelError(err, "Failed to create library panel")
+	}
+
+	return getLibraryPanelDTOResponse(c, cmd.Result.Uid)
+}
+
+// PATCH /api/library-panels/:uid
+func PatchLibraryPanel(c *models.ReqContext, cmd models.PatchLibraryPanelCommand) Response {
+	cmd.Uid = c.Params(":uid")
+	cmd.OrgId = c.OrgId
+	cmd.UserId = c.UserId
+
+	query := models.GetLibraryPanelQuery{Uid: cmd.Uid, OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return toLibraryPanelError(err, "Failed to get library panel")
+	}
+
+	if rsp := checkLibraryPanelFolder(c, query.Result.FolderId); rsp != nil {
+		return rsp
+	}
+
+	if cmd.FolderId != nil && *cmd.FolderId != query.Result.FolderId {
+		if rsp := checkLibraryPanelFolder(c, *cmd.FolderId); rsp != nil {
+			return rsp
+		}
+	}
+
+	if cmd.Model != nil && len(cmd.Model.MustMap()) == 0 {
+		return Error(400, models.ErrLibraryPanelModelEmpty.Error(), nil)
+	}
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toLibraryPanelError(err, "Failed to update library panel")
+	}
+
+	return getLibraryPanelDTOResponse(c, cmd.Uid)
+}
+
+// DELETE /api/library-panels/:uid
+func DeleteLibraryPanel(c *models.ReqContext) Response {
+	query := models.GetLibraryPanelQuery{Uid: c.Params(":uid"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return toLibraryPanelError(err, "Failed to get library panel")
+	}
+
+	if rsp := checkLibraryPanelFolder(c, query.Result.FolderId); rsp != nil {
+		return rsp
+	}
+
+	cmd := models.DeleteLibraryPanelCommand{Uid: query.Result.Uid, OrgId: c.OrgId}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toLibraryPanelError(err, "Failed to delete library panel")
+	}
+
+	return JSON(200, util.DynMap{"message": "Library panel deleted"})
+}
+
+// GET /api/library-panels/:uid/dashboards
+func GetLibraryPanelDashboards(c *models.ReqContext) Response {
+	panelQuery := models.GetLibraryPanelQuery{Uid: c.Params(":uid"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&panelQuery); err != nil {
+		return toLibraryPanelError(err, "Failed to get library panel")
+	}
+
+	if canView, err := guardian.New(panelQuery.Result.FolderId, c.OrgId, c.SignedInUser).CanView(); err != nil || !canView {
+		return libraryPanelGuardianResponse(err)
+	}
+
+	query := models.GetLibraryPanelDashboardsQuery{Uid: panelQuery.Result.Uid, OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get connected dashboards", err)
+	}
+
+	// only list the dashboards the user is allowed to see
+	result := make([]*models.LibraryPanelConnectedDashboard, 0, len(query.Result))
+	for _, dashboard := range query.Result {
+		if canView, _ := guardian.New(dashboard.Id, c.OrgId, c.SignedInUser).CanView(); canView {
+			result = append(result, dashboard)
+		}
+	}
+
+	return JSON(200, result)
+}
+
+// POST /api/library-panels/:uid/dashboards/:dashboardId
+func ConnectLibraryPanel(c *models.ReqContext) Response {
+	dashboardId := c.ParamsInt64(":dashboardId")
+	if canSave, err := guardian.New(dashboardId, c.OrgId, c.SignedInUser).CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	query := models.GetLibraryPanelQuery{Uid: c.Params(":uid"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return toLibraryPanelError(err, "Failed to get library panel")
+	}
+
+	if canView, err := guardian.New(query.Result.FolderId, c.OrgId, c.SignedInUser).CanView(); err != nil || !canView {
+		return libraryPanelGuardianResponse(err)
+	}
+
+	cmd := models.ConnectLibraryPanelCommand{
+		Uid:         query.Result.Uid,
+		DashboardId: dashboardId,
+		OrgId:       c.OrgId,
+		UserId:      c.UserId,
+	}
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toLibraryPanelError(err, "Failed to connect library panel")
+	}
+
+	return JSON(200, util.DynMap{"message": "Library panel connected"})
+}
+
+// DELETE /api/library-panels/:uid/dashboards/:dashboardId
+func DisconnectLibraryPanel(c *models.ReqContext) Response {
+	dashboardId := c.ParamsInt64(":dashboardId")
+	if canSave, err := guardian.New(dashboardId, c.OrgId, c.SignedInUser).CanSave(); err != nil || !canSave {
+		return dashboardGuardianResponse(err)
+	}
+
+	cmd := models.DisconnectLibraryPanelCommand{
+		Uid:         c.Params(":uid"),
+		DashboardId: dashboardId,
+		OrgId:       c.OrgId,
+	}
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toLibraryPanelError(err, "Failed to disconnect library panel")
+	}
+
+	return JSON(200, util.DynMap{"message": "Library panel disconnected"})
+}
+
+// loadLibraryPanelsForDashboard replaces the panels of a dashboard that reference a library
+// panel with the stored library panel model, keeping the id and position of the dashboard panel.
+// Only the library panels in folders the user can view are loaded.
+func loadLibraryPanelsForDashboard(c *models.ReqContext, dashboard *simplejson.Json) error {
+	uids := models.GetLibraryPanelUIDs(dashboard)
+	if len(uids) == 0 {
+		return nil
+	}
+
+	query := models.GetLibraryPanelsByUIDsQuery{Uids: uids, OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return err
+	}
+
+	viewableFolders := make(map[int64]bool)
+	libraryPanels := make(map[string]*models.LibraryPanel)
+	for _, libraryPanel := range query.Result {
+		canView, ok := viewableFolders[libraryPanel.FolderId]
+		if !ok {
+			var err error
+			canView, err = guardian.New(libraryPanel.FolderId, c.OrgId, c.SignedInUser).CanView()
+			if err != nil {
+				return err
+			}
+			viewableFolders[libraryPanel.FolderId] = canView
+		}
+		if canView {
+			libraryPanels[libraryPanel.Uid] = libraryPanel
+		}
+	}
+
+	return replaceLibraryPanels(dashboard.Get("panels").MustArray(), libraryPanels)
+}
+
+func replaceLibraryPanels(panels []interface{}, libraryPanels map[string]*models.LibraryPanel) error {
+	for i, p := range panels {
+		panel := simplejson.NewFromAny(p)
+		if err := replaceLibraryPanels(panel.Get("panels").MustArray(), libraryPanels); err != nil {
+			return err
+		}
+
+		uid := panel.GetPath("libraryPanel", "uid").MustString()
+		libraryPanel, ok := libraryPanels[uid]
+		if uid == "" || !ok {
+			continue
+		}
+
+		// copy the model so that panels using the same library panel don't share state
+		data, err := libraryPanel.Model.Encode()
+		if err != nil {
+			return err
+		}
+		model, err := simplejson.NewJson(data)
+		if err != nil {
+			return err
+		}
+
+		model.Set("id", panel.Get("id").Interface())
+		model.Set("gridPos", panel.Get("gridPos").Interface())
+		model.Set("libraryPanel", map[string]interface{}{
+			"uid":     libraryPanel.Uid,
+			"name":    libraryPanel.Name,
+			"version": libraryPanel.Version,
+		})
+
+		panels[i] = model.Interface()
+	}
+
+	return nil
+}
+
+func getLibraryPanelDTOResponse(c *models.ReqContext, uid string) Response {
+	query := models.GetLibraryPanelQuery{Uid: uid, OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return toLibraryPanelError(err, "Failed to get library panel")
+	}
+
+	dto := query.Result.ToDTO()
+	dto.Meta.CanEdit = true
+	return JSON(200, dto)
+}
+
+// getViewableLibraryPanelFolderIds returns the folders the user can view library panels in,
+// limited to the requested folders if any. Org admins can see all folders.
+func getViewableLibraryPanelFolderIds(c *models.ReqContext, requested []int64) ([]int64, error) {
+	if c.OrgRole == models.ROLE_ADMIN {
+		return requested, nil
+	}
+
+	folders, err := dashboards.NewFolderService(c.OrgId, c.SignedInUser).GetFolders(0)
+	if err != nil {
+		return nil, err
+	}
+
+	viewable := make(map[int64]bool)
+	if canView, _ := guardian.New(0, c.OrgId, c.SignedInUser).CanView(); canView {
+		viewable[0] = true
+	}
+	for _, folder := range folders {
+		viewable[folder.Id] = true
+	}
+
+	result := make([]int64, 0)
+	if len(requested) == 0 {
+		for id := range viewable {
+			result = append(result, id)
+		}
+		return result, nil
+	}
+
+	for _, id := range requested {
+		if viewable[id] {
+			result = append(result, id)
+		}
+	}
+
+	return result, nil
+}
+
+func checkLibraryPanelFolder(c *models.ReqContext, folderId int64) Response {
+	if folderId > 0 {
+		query := models.GetDashboardQuery{Id: folderId, OrgId: c.OrgId}
+		if err := bus.Dispatch(&query); err != nil || !query.Result.IsFolder {
+			return toFolderError(models.ErrFolderNotFound)
+		}
+	}
+
+	if canSave, err := guardian.New(folderId, c.OrgId, c.SignedInUser).CanSave(); err != nil || !canSave {
+		return libraryPanelGuardianResponse(err)
+	}
+
+	return nil
+}
+
+func validateLibraryPanel(uid string, name string, model *simplejson.Json) Response {
+	if name == "" {
+		return Error(400, models.ErrLibraryPanelNameEmpty.Error(), nil)
+	}
+
+	if model == nil || len(model.MustMap()) == 0 {
+		return Error(400, models.ErrLibraryPanelModelEmpty.Error(), nil)
+	}
+
+	if uid != "" {
+		if !util.IsValidShortUID(uid) {
+			return Error(400, models.ErrLibraryPanelInvalidUid.Error(), nil)
+		}
+		if len(uid) > 40 {
+			return Error(400, models.ErrLibraryPanelUidTooLong.Error(), nil)
+		}
+	}
+
+	return nil
+}
+
+func libraryPanelGuardianResponse(err error) Response {
+	if err != nil {
+		return Error(500, "Error while checking library panel permissions", err)
+	}
+
+	return Error(403, "Access denied to this library panel", nil)
+}
+
+func toLibraryPanelError(err error, message string) Response {
+	if err == models.ErrLibraryPanelNotFound || err == models.ErrDashboardNotFound {
+		return Error(404, err.Error(), nil)
+	}
+
+	if err == models.ErrLibraryPanelWithSameNameExists ||
+		err == models.ErrLibraryPanelWithSameUIDExists {
+		return Error(400, err.Error(), nil)
+	}
+
+	if err == models.ErrLibraryPanelHasConnectedDashboards {
+		return Error(403, err.Error(), nil)
+	}
+
+	if err == models.ErrLibraryPanelVersionMismatch {
+		return JSON(412, util.DynMap{"status": "version-mismatch", "message": err.Error()})
+	}
+
+	return Error(500, message, err)
+}
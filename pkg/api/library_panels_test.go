@@ -0,0 +1,70 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+)
+
+func TestLoadLibraryPanelsForDashboard(t *testing.T) {
+	bus.ClearBusHandlers()
+	defer bus.ClearBusHandlers()
+
+	bus.AddHandler("test", func(query *models.GetLibraryPanelsByUIDsQuery) error {
+		query.Result = []*models.LibraryPanel{{
+			Uid:      "requests",
+			Name:     "Requests",
+			FolderId: 2,
+			Version:  3,
+			Model:    simplejson.NewFromAny(map[string]interface{}{"type": "graph", "title": "Requests"}),
+		}}
+		return nil
+	})
+
+	origNewGuardian := guardian.New
+	defer func() { guardian.New = origNewGuardian }()
+
+	c := &models.ReqContext{SignedInUser: &models.SignedInUser{OrgId: 1}}
+	newDashboard := func() *simplejson.Json {
+		return simplejson.NewFromAny(map[string]interface{}{
+			"panels": []interface{}{
+				map[string]interface{}{
+					"id":           1,
+					"gridPos":      map[string]interface{}{"x": 0, "y": 0, "w": 12, "h": 8},
+					"libraryPanel": map[string]interface{}{"uid": "requests"},
+				},
+			},
+		})
+	}
+
+	t.Run("Should load the library panels in folders the user can view", func(t *testing.T) {
+		fakeGuardian := &guardian.FakeDashboardGuardian{CanViewValue: true}
+		guardian.MockDashboardGuardian(fakeGuardian)
+
+		dashboard := newDashboard()
+		require.NoError(t, loadLibraryPanelsForDashboard(c, dashboard))
+
+		panel := dashboard.Get("panels").GetIndex(0)
+		assert.Equal(t, int64(2), fakeGuardian.DashId)
+		assert.Equal(t, "graph", panel.Get("type").MustString())
+		assert.Equal(t, 1, panel.Get("id").MustInt())
+		assert.Equal(t, 3, panel.GetPath("libraryPanel", "version").MustInt())
+	})
+
+	t.Run("Should not load the library panels in folders the user can't view", func(t *testing.T) {
+		guardian.MockDashboardGuardian(&guardian.FakeDashboardGuardian{CanViewValue: false})
+
+		dashboard := newDashboard()
+		require.NoError(t, loadLibraryPanelsForDashboard(c, dashboard))
+
+		panel := dashboard.Get("panels").GetIndex(0)
+		assert.Empty(t, panel.Get("type").MustString())
+		assert.Equal(t, "requests", panel.GetPath("libraryPanel", "uid").MustString())
+	})
+}
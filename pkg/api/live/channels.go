@@ -0,0 +1,53 @@
+package live
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// ScopeGrafana is the scope of the channels of core features, named grafana/<feature>/<path>
+const ScopeGrafana = "grafana"
+
+var ErrPublishQueueFull = errors.New("Live publish queue is full")
+
+// ChannelHandler checks whether a user can subscribe to the channel of a core feature at path. It
+// returns ErrStreamPermissionDenied or ErrStreamNotFound when the user can't.
+type ChannelHandler func(user *models.SignedInUser, path string) error
+
+// parseCoreChannel returns the feature and path of a stream name, and false if the stream isn't
+// a channel of a core feature
+func parseCoreChannel(name string) (string, string, bool) {
+	parts := strings.SplitN(name, "/", 3)
+	if len(parts) != 3 || parts[0] != ScopeGrafana || parts[1] == "" || parts[2] == "" {
+		return "", "", false
+	}
+
+	return parts[1], parts[2], true
+}
+
+// coreChannels are the channels the core features publish to
+type coreChannels struct {
+	mu       sync.RWMutex
+	handlers map[string]ChannelHandler
+}
+
+func (cc *coreChannels) register(feature string, handler ChannelHandler) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.handlers[feature] = handler
+}
+
+// authorize returns whether the user can subscribe to the channel of a feature
+func (cc *coreChannels) authorize(user *models.SignedInUser, feature string, path string) error {
+	cc.mu.RLock()
+	handler, ok := cc.handlers[feature]
+	cc.mu.RUnlock()
+
+	if !ok {
+		return ErrStreamNotFound
+	}
+	return handler(user, path)
+}
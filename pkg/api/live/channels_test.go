@@ -0,0 +1,78 @@
+package live
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCoreChannel(t *testing.T) {
+	feature, path, ok := parseCoreChannel("grafana/dashboard/abc")
+	require.True(t, ok)
+	require.Equal(t, "dashboard", feature)
+	require.Equal(t, "abc", path)
+
+	for _, name := range []string{"grafana/dashboard", "grafana//abc", "plugin/dashboard/abc"} {
+		_, _, ok := parseCoreChannel(name)
+		require.False(t, ok, name)
+	}
+}
+
+func TestCoreChannels(t *testing.T) {
+	sm := NewStreamManager(nil, nil)
+	sm.RegisterChannel("dashboard", func(user *models.SignedInUser, path string) error {
+		if path == "forbidden" {
+			return ErrStreamPermissionDenied
+		}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	sm.Run(ctx)
+
+	newConn := func(orgID int64) *connection {
+		c := newConnection(ctx, nil, &models.SignedInUser{OrgId: orgID}, sm.hub, log.New("test"))
+		sm.hub.register <- c
+		return c
+	}
+	subscribe := func(c *connection, name string) {
+		c.handleMessage([]byte(`{"action":"subscribe","stream":"` + name + `"}`))
+	}
+	receive := func(c *connection) string {
+		select {
+		case message := <-c.send:
+			return string(message)
+		case <-time.After(time.Second):
+			t.Fatal("no message received")
+			return ""
+		}
+	}
+
+	org1 := newConn(1)
+	org2 := newConn(2)
+	subscribe(org1, "grafana/dashboard/abc")
+	subscribe(org2, "grafana/dashboard/abc")
+	subscribe(org1, "grafana/dashboard/forbidden")
+	subscribe(org1, "grafana/unknown/abc")
+
+	t.Run("Messages are published to the subscribers in the org", func(t *testing.T) {
+		require.NoError(t, sm.Publish(1, "grafana/dashboard/abc", map[string]int{"version": 2}))
+		require.Equal(t, `{"stream":"grafana/dashboard/abc","data":{"version":2}}`, receive(org1))
+
+		require.NoError(t, sm.Publish(2, "grafana/dashboard/abc", map[string]int{"version": 3}))
+		require.Equal(t, `{"stream":"grafana/dashboard/abc","data":{"version":3}}`, receive(org2))
+		require.Empty(t, org1.send)
+	})
+
+	t.Run("Subscriptions refused by the feature are ignored", func(t *testing.T) {
+		require.NoError(t, sm.Publish(1, "grafana/dashboard/forbidden", "message"))
+		require.NoError(t, sm.Publish(1, "grafana/unknown/abc", "message"))
+		require.NoError(t, sm.Publish(1, "grafana/dashboard/abc", "last"))
+		require.Equal(t, `{"stream":"grafana/dashboard/abc","data":"last"}`, receive(org1))
+	})
+}
@@ -1,12 +1,14 @@
 package live
 
 import (
+	"context"
 	"net/http"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
 )
 
 const (
@@ -32,17 +34,21 @@ var upgrader = websocket.Upgrader{
 }
 
 type connection struct {
+	ctx  context.Context
 	hub  *hub
 	ws   *websocket.Conn
+	user *models.SignedInUser
 	send chan []byte
 	log  log.Logger
 }
 
-func newConnection(ws *websocket.Conn, hub *hub, logger log.Logger) *connection {
+func newConnection(ctx context.Context, ws *websocket.Conn, user *models.SignedInUser, hub *hub, logger log.Logger) *connection {
 	return &connection{
+		ctx:  ctx,
 		hub:  hub,
 		send: make(chan []byte, 256),
 		ws:   ws,
+		user: user,
 		log:  logger,
 	}
 }
@@ -87,6 +93,16 @@ func (c *connection) handleMessage(message []byte) {
 		return
 	}
 
+	if channel, ok := ParsePluginChannel(streamName); ok {
+		c.handlePluginChannelMessage(msgType, streamName, channel)
+		return
+	}
+
+	if feature, path, ok := parseCoreChannel(streamName); ok {
+		c.handleCoreChannelMessage(msgType, streamName, feature, path)
+		return
+	}
+
 	switch msgType {
 	case "subscribe":
 		c.hub.subChannel <- &streamSubscription{name: streamName, conn: c}
@@ -96,6 +112,33 @@ func (c *connection) handleMessage(message []byte) {
 
 }
 
+func (c *connection) handlePluginChannelMessage(msgType string, streamName string, channel PluginChannel) {
+	switch msgType {
+	case "subscribe":
+		sub, err := c.hub.plugins.subscription(c.ctx, c, streamName, channel)
+		if err != nil {
+			c.log.Warn("Failed to subscribe to plugin stream", "stream", streamName, "userId", c.user.UserId, "error", err)
+			return
+		}
+		c.hub.subChannel <- sub
+	case "unsubscribe":
+		c.hub.subChannel <- &streamSubscription{name: streamKey(c.user.OrgId, streamName), conn: c, remove: true}
+	}
+}
+
+func (c *connection) handleCoreChannelMessage(msgType string, streamName string, feature string, path string) {
+	switch msgType {
+	case "subscribe":
+		if err := c.hub.channels.authorize(c.user, feature, path); err != nil {
+			c.log.Warn("Failed to subscribe to channel", "stream", streamName, "userId", c.user.UserId, "error", err)
+			return
+		}
+		c.hub.subChannel <- &streamSubscription{name: streamKey(c.user.OrgId, streamName), conn: c}
+	case "unsubscribe":
+		c.hub.subChannel <- &streamSubscription{name: streamKey(c.user.OrgId, streamName), conn: c, remove: true}
+	}
+}
+
 func (c *connection) write(mt int, payload []byte) error {
 	if err := c.ws.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
 		return err
@@ -8,31 +8,55 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 )
 
+// publishQueueSize is how many messages published to channels can wait to be broadcast
+const publishQueueSize = 256
+
 type hub struct {
 	log         log.Logger
 	connections map[*connection]bool
 	streams     map[string]map[*connection]bool
+	runners     map[string]*streamRunner
+	plugins     *pluginStreams
+	channels    *coreChannels
 
 	register      chan *connection
 	unregister    chan *connection
 	streamChannel chan *dtos.StreamMessage
 	subChannel    chan *streamSubscription
+	publish       chan *streamPublication
+	runnerDone    chan *streamRunner
 }
 
 type streamSubscription struct {
 	conn   *connection
 	name   string
 	remove bool
+	// start runs the stream when it gets its first subscriber, for streams run by plugins
+	start func(ctx context.Context, publish func([]byte) error)
+}
+
+type streamPublication struct {
+	name    string
+	message []byte
+}
+
+type streamRunner struct {
+	name   string
+	cancel context.CancelFunc
 }
 
 func newHub() *hub {
 	return &hub{
 		connections:   make(map[*connection]bool),
 		streams:       make(map[string]map[*connection]bool),
+		runners:       make(map[string]*streamRunner),
 		register:      make(chan *connection),
 		unregister:    make(chan *connection),
 		streamChannel: make(chan *dtos.StreamMessage),
 		subChannel:    make(chan *streamSubscription),
+		publish:       make(chan *streamPublication, publishQueueSize),
+		runnerDone:    make(chan *streamRunner),
+		channels:      &coreChannels{handlers: make(map[string]ChannelHandler)},
 		log:           log.New("stream.hub"),
 	}
 }
@@ -41,6 +65,9 @@ func (h *hub) run(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
+			for _, runner := range h.runners {
+				runner.cancel()
+			}
 			return
 		case c := <-h.register:
 			h.connections[c] = true
@@ -52,6 +79,12 @@ func (h *hub) run(ctx context.Context) {
 				delete(h.connections, c)
 				close(c.send)
 			}
+			for name, subscribers := range h.streams {
+				if subscribers[c] {
+					delete(subscribers, c)
+					h.stopUnusedStream(name)
+				}
+			}
 			// hand stream subscriptions
 		case sub := <-h.subChannel:
 			h.log.Info("Subscribing", "channel", sub.name, "remove", sub.remove)
@@ -60,6 +93,7 @@ func (h *hub) run(ctx context.Context) {
 			// handle unsubscribe
 			if exists && sub.remove {
 				delete(subscribers, sub.conn)
+				h.stopUnusedStream(sub.name)
 				continue
 			}
 
@@ -69,31 +103,83 @@ func (h *hub) run(ctx context.Context) {
 			}
 
 			subscribers[sub.conn] = true
+			if _, running := h.runners[sub.name]; !running && sub.start != nil {
+				h.startStream(ctx, sub)
+			}
 
 			// handle stream messages
 		case message := <-h.streamChannel:
-			subscribers, exists := h.streams[message.Stream]
-			if !exists || len(subscribers) == 0 {
-				h.log.Info("Message to stream without subscribers", "stream", message.Stream)
-				continue
+			messageBytes, _ := simplejson.NewFromAny(message).Encode()
+			h.broadcast(message.Stream, messageBytes)
+
+		case publication := <-h.publish:
+			h.broadcast(publication.name, publication.message)
+
+		case runner := <-h.runnerDone:
+			if h.runners[runner.name] == runner {
+				delete(h.runners, runner.name)
 			}
+		}
+	}
+}
 
-			messageBytes, _ := simplejson.NewFromAny(message).Encode()
-			for sub := range subscribers {
-				// check if channel is open
-				if _, ok := h.connections[sub]; !ok {
-					delete(subscribers, sub)
-					continue
-				}
+func (h *hub) broadcast(stream string, messageBytes []byte) {
+	subscribers, exists := h.streams[stream]
+	if !exists || len(subscribers) == 0 {
+		h.log.Info("Message to stream without subscribers", "stream", stream)
+		return
+	}
 
-				select {
-				case sub.send <- messageBytes:
-				default:
-					close(sub.send)
-					delete(h.connections, sub)
-					delete(subscribers, sub)
-				}
+	for sub := range subscribers {
+		// check if channel is open
+		if _, ok := h.connections[sub]; !ok {
+			delete(subscribers, sub)
+			continue
+		}
+
+		select {
+		case sub.send <- messageBytes:
+		default:
+			close(sub.send)
+			delete(h.connections, sub)
+			delete(subscribers, sub)
+		}
+	}
+}
+
+// startStream runs a plugin stream until it has no subscribers left
+func (h *hub) startStream(ctx context.Context, sub *streamSubscription) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	runner := &streamRunner{name: sub.name, cancel: cancel}
+	h.runners[sub.name] = runner
+
+	h.log.Debug("Starting plugin stream", "stream", sub.name)
+	go func() {
+		sub.start(streamCtx, func(message []byte) error {
+			select {
+			case h.publish <- &streamPublication{name: sub.name, message: message}:
+				return nil
+			case <-streamCtx.Done():
+				return streamCtx.Err()
 			}
+		})
+
+		select {
+		case h.runnerDone <- runner:
+		case <-ctx.Done():
 		}
+	}()
+}
+
+func (h *hub) stopUnusedStream(name string) {
+	if len(h.streams[name]) > 0 {
+		return
+	}
+
+	delete(h.streams, name)
+	if runner, running := h.runners[name]; running {
+		h.log.Debug("Stopping plugin stream without subscribers", "stream", name)
+		runner.cancel()
+		delete(h.runners, name)
 	}
 }
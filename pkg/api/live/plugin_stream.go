@@ -0,0 +1,117 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+)
+
+// Plugin channel scopes
+const (
+	ScopePlugin     = "plugin"
+	ScopeDatasource = "ds"
+)
+
+var (
+	ErrStreamNotFound         = errors.New("Stream not found")
+	ErrStreamPermissionDenied = errors.New("Permission denied to stream")
+)
+
+// PluginChannel is a stream of a backend plugin, named plugin/<pluginId>/<path>, or
+// ds/<datasourceId>/<path> for the streams of a data source.
+type PluginChannel struct {
+	Scope string
+	Id    string
+	Path  string
+}
+
+// ParsePluginChannel returns the plugin channel of a stream name, and false if the stream
+// isn't streamed by a plugin
+func ParsePluginChannel(name string) (PluginChannel, bool) {
+	parts := strings.SplitN(name, "/", 3)
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return PluginChannel{}, false
+	}
+
+	if parts[0] != ScopePlugin && parts[0] != ScopeDatasource {
+		return PluginChannel{}, false
+	}
+
+	return PluginChannel{Scope: parts[0], Id: parts[1], Path: parts[2]}, true
+}
+
+// PluginContextProvider returns the plugin context with which a user accesses a plugin channel
+type PluginContextProvider func(user *models.SignedInUser, channel PluginChannel) (backend.PluginContext, error)
+
+// channelMessage is a message sent to the subscribers of a channel
+type channelMessage struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// pluginStreams routes the subscriptions to plugin channels to the backend plugins. Streams are
+// kept per org, and run with the plugin context of the user who subscribed first.
+type pluginStreams struct {
+	manager          backendplugin.Manager
+	getPluginContext PluginContextProvider
+	log              log.Logger
+}
+
+// streamKey returns the key of a plugin stream in the hub, as the same channel is a different
+// stream in every org
+func streamKey(orgID int64, name string) string {
+	return fmt.Sprintf("%d/%s", orgID, name)
+}
+
+// subscription asks the plugin whether the user can subscribe to a channel, and returns the
+// subscription to the stream of the channel
+func (ps *pluginStreams) subscription(ctx context.Context, conn *connection, name string, channel PluginChannel) (*streamSubscription, error) {
+	pCtx, err := ps.getPluginContext(conn.user, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ps.manager.SubscribeStream(ctx, &backendplugin.SubscribeStreamRequest{
+		PluginContext: pCtx,
+		Path:          channel.Path,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.Status {
+	case backendplugin.SubscribeStreamStatusOK:
+	case backendplugin.SubscribeStreamStatusPermissionDenied:
+		return nil, ErrStreamPermissionDenied
+	default:
+		return nil, ErrStreamNotFound
+	}
+
+	key := streamKey(conn.user.OrgId, name)
+	return &streamSubscription{
+		name: key,
+		conn: conn,
+		start: func(ctx context.Context, publish func([]byte) error) {
+			err := ps.manager.RunStream(ctx, &backendplugin.RunStreamRequest{
+				PluginContext: pCtx,
+				Path:          channel.Path,
+			}, backendplugin.StreamPacketSenderFunc(func(packet *backendplugin.StreamPacket) error {
+				message, err := json.Marshal(channelMessage{Stream: name, Data: packet.Data})
+				if err != nil {
+					return err
+				}
+				return publish(message)
+			}))
+			if err != nil && !errors.Is(err, context.Canceled) {
+				ps.log.Error("Plugin stream stopped", "stream", key, "error", err)
+			}
+		},
+	}, nil
+}
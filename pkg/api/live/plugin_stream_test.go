@@ -0,0 +1,127 @@
+package live
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePluginChannel(t *testing.T) {
+	channel, ok := ParsePluginChannel("plugin/test-app/metrics/cpu")
+	require.True(t, ok)
+	require.Equal(t, PluginChannel{Scope: ScopePlugin, Id: "test-app", Path: "metrics/cpu"}, channel)
+
+	channel, ok = ParsePluginChannel("ds/3/tail")
+	require.True(t, ok)
+	require.Equal(t, PluginChannel{Scope: ScopeDatasource, Id: "3", Path: "tail"}, channel)
+
+	for _, name := range []string{"random-walk", "plugin/test-app", "plugin//path", "other/test-app/path"} {
+		_, ok := ParsePluginChannel(name)
+		require.False(t, ok, name)
+	}
+}
+
+func TestPluginStreams(t *testing.T) {
+	manager := &fakeStreamManager{stopped: make(chan int64, 2)}
+	h := newHub()
+	h.plugins = &pluginStreams{
+		manager: manager,
+		getPluginContext: func(user *models.SignedInUser, channel PluginChannel) (backend.PluginContext, error) {
+			return backend.PluginContext{OrgID: user.OrgId, PluginID: channel.Id}, nil
+		},
+		log: log.New("test"),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go h.run(ctx)
+
+	newConn := func(orgID int64) *connection {
+		c := &connection{ctx: ctx, hub: h, user: &models.SignedInUser{OrgId: orgID}, send: make(chan []byte, 10)}
+		h.register <- c
+		return c
+	}
+	subscribe := func(c *connection, name string) {
+		channel, ok := ParsePluginChannel(name)
+		require.True(t, ok)
+		sub, err := h.plugins.subscription(ctx, c, name, channel)
+		require.NoError(t, err)
+		h.subChannel <- sub
+	}
+	receive := func(c *connection) string {
+		select {
+		case message := <-c.send:
+			return string(message)
+		case <-time.After(time.Second):
+			t.Fatal("no message received")
+			return ""
+		}
+	}
+
+	org1 := newConn(1)
+	org2 := newConn(2)
+
+	t.Run("Streams are run per org", func(t *testing.T) {
+		subscribe(org1, "plugin/test-app/stream")
+		require.Equal(t, `{"stream":"plugin/test-app/stream","data":1}`, receive(org1))
+
+		subscribe(org2, "plugin/test-app/stream")
+		require.Equal(t, `{"stream":"plugin/test-app/stream","data":2}`, receive(org2))
+		require.Empty(t, org1.send)
+	})
+
+	t.Run("Plugins can refuse subscriptions", func(t *testing.T) {
+		channel, _ := ParsePluginChannel("plugin/test-app/forbidden")
+		_, err := h.plugins.subscription(ctx, org1, "plugin/test-app/forbidden", channel)
+		require.Equal(t, ErrStreamPermissionDenied, err)
+	})
+
+	t.Run("Streams are stopped when they have no subscribers left", func(t *testing.T) {
+		h.subChannel <- &streamSubscription{name: streamKey(1, "plugin/test-app/stream"), conn: org1, remove: true}
+		select {
+		case orgID := <-manager.stopped:
+			require.Equal(t, int64(1), orgID)
+		case <-time.After(time.Second):
+			t.Fatal("stream not stopped")
+		}
+
+		h.unregister <- org2
+		select {
+		case orgID := <-manager.stopped:
+			require.Equal(t, int64(2), orgID)
+		case <-time.After(time.Second):
+			t.Fatal("stream not stopped")
+		}
+	})
+}
+
+type fakeStreamManager struct {
+	backendplugin.Manager
+	stopped chan int64
+}
+
+func (m *fakeStreamManager) SubscribeStream(ctx context.Context, req *backendplugin.SubscribeStreamRequest) (*backendplugin.SubscribeStreamResponse, error) {
+	if req.Path == "forbidden" {
+		return &backendplugin.SubscribeStreamResponse{Status: backendplugin.SubscribeStreamStatusPermissionDenied}, nil
+	}
+
+	return &backendplugin.SubscribeStreamResponse{Status: backendplugin.SubscribeStreamStatusOK}, nil
+}
+
+func (m *fakeStreamManager) RunStream(ctx context.Context, req *backendplugin.RunStreamRequest, sender backendplugin.StreamPacketSender) error {
+	orgID := req.PluginContext.OrgID
+	if err := sender.Send(&backendplugin.StreamPacket{Data: []byte(fmt.Sprint(orgID))}); err != nil {
+		return err
+	}
+
+	<-ctx.Done()
+	m.stopped <- orgID
+	return ctx.Err()
+}
@@ -2,12 +2,13 @@ package live
 
 import (
 	"context"
-	"net/http"
+	"encoding/json"
 	"sync"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 )
 
 type StreamManager struct {
@@ -17,10 +18,20 @@ type StreamManager struct {
 	hub           *hub
 }
 
-func NewStreamManager() *StreamManager {
+// NewStreamManager returns a stream manager that routes the subscriptions to plugin channels to the
+// backend plugins streaming them
+func NewStreamManager(pluginManager backendplugin.Manager, getPluginContext PluginContextProvider) *StreamManager {
+	logger := log.New("stream.manager")
+	hub := newHub()
+	hub.plugins = &pluginStreams{
+		manager:          pluginManager,
+		getPluginContext: getPluginContext,
+		log:              logger,
+	}
+
 	return &StreamManager{
-		hub:           newHub(),
-		log:           log.New("stream.manager"),
+		hub:           hub,
+		log:           logger,
 		streams:       make(map[string]*Stream),
 		streamRWMutex: &sync.RWMutex{},
 	}
@@ -35,22 +46,48 @@ func (sm *StreamManager) Run(context context.Context) {
 	}()
 }
 
-func (sm *StreamManager) Serve(w http.ResponseWriter, r *http.Request) {
+func (sm *StreamManager) Serve(ctx *models.ReqContext) {
 	sm.log.Info("Upgrading to WebSocket")
 
-	ws, err := upgrader.Upgrade(w, r, nil)
+	ws, err := upgrader.Upgrade(ctx.Resp, ctx.Req.Request, nil)
 	if err != nil {
 		sm.log.Error("Failed to upgrade connection to WebSocket", "error", err)
 		return
 	}
 
-	c := newConnection(ws, sm.hub, sm.log)
+	c := newConnection(ctx.Req.Context(), ws, ctx.SignedInUser, sm.hub, sm.log)
 	sm.hub.register <- c
 
 	go c.writePump()
 	c.readPump()
 }
 
+// RegisterChannel adds the channels of a core feature, named grafana/<feature>/<path>. The handler
+// checks whether users can subscribe to them.
+func (sm *StreamManager) RegisterChannel(feature string, handler ChannelHandler) {
+	sm.hub.channels.register(feature, handler)
+}
+
+// Publish sends data to the subscribers of a channel in an org, without waiting for it to be
+// delivered. Messages are dropped when the subscribers can't keep up.
+func (sm *StreamManager) Publish(orgID int64, channel string, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	message, err := json.Marshal(channelMessage{Stream: channel, Data: encoded})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case sm.hub.publish <- &streamPublication{name: streamKey(orgID, channel), message: message}:
+		return nil
+	default:
+		return ErrPublishQueueFull
+	}
+}
+
 func (s *StreamManager) GetStreamList() models.StreamList {
 	list := make(models.StreamList, 0)
 
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"github.com/grafana/grafana/pkg/api/live"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/liveingest"
+)
+
+// Live channels of the core features, published to with the dashboard uid as path
+const (
+	liveDashboardFeature = "dashboard"
+	liveAlertsFeature    = "alerts"
+)
+
+// registerLiveChannels adds the live channels of the core features, and publishes their events
+// to them: the edits of a dashboard to grafana/dashboard/<uid>, the alert state changes of its
+// panels to grafana/alerts/<uid>, and the messages of a kiosk device to grafana/kiosk/<id>. The
+// Live ingest bridges publish to grafana/ingest/<channel> themselves.
+func (hs *HTTPServer) registerLiveChannels() {
+	hs.streamManager.RegisterChannel(liveDashboardFeature, canViewDashboardChannel)
+	hs.streamManager.RegisterChannel(liveAlertsFeature, canViewDashboardChannel)
+	hs.streamManager.RegisterChannel(liveKioskFeature, canSubscribeKioskDeviceChannel)
+	hs.streamManager.RegisterChannel(liveingest.Feature, hs.LiveIngestService.AuthorizeChannel)
+
+	hs.Bus.AddEventListener(hs.publishDashboardSaved)
+	hs.Bus.AddEventListener(hs.publishAlertStateChanged)
+	hs.Bus.AddEventListener(hs.publishKioskDashboardSaved)
+}
+
+// canViewDashboardChannel allows the users who can view a dashboard to subscribe to its channels
+func canViewDashboardChannel(user *models.SignedInUser, uid string) error {
+	query := models.GetDashboardQuery{Uid: uid, OrgId: user.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		if err == models.ErrDashboardNotFound {
+			return live.ErrStreamNotFound
+		}
+		return err
+	}
+
+	canView, err := guardian.New(query.Result.Id, user.OrgId, user).CanView()
+	if err != nil {
+		return err
+	}
+	if !canView {
+		return live.ErrStreamPermissionDenied
+	}
+	return nil
+}
+
+func (hs *HTTPServer) publishDashboardSaved(evt *events.DashboardSaved) error {
+	channel := live.ScopeGrafana + "/" + liveDashboardFeature + "/" + evt.Uid
+	if err := hs.streamManager.Publish(evt.OrgId, channel, evt); err != nil {
+		hs.log.Warn("Failed to publish dashboard edit", "channel", channel, "error", err)
+	}
+	return nil
+}
+
+func (hs *HTTPServer) publishAlertStateChanged(evt *events.AlertStateChanged) error {
+	query := models.GetDashboardRefByIdQuery{Id: evt.DashboardId}
+	if err := bus.Dispatch(&query); err != nil {
+		hs.log.Warn("Failed to get the dashboard of alert", "alertId", evt.Id, "error", err)
+		return nil
+	}
+
+	channel := live.ScopeGrafana + "/" + liveAlertsFeature + "/" + query.Result.Uid
+	if err := hs.streamManager.Publish(evt.OrgId, channel, evt); err != nil {
+		hs.log.Warn("Failed to publish alert state change", "channel", channel, "error", err)
+	}
+	return nil
+}
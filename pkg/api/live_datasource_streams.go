@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// dataSourceStreams routes the streams of the data sources which aren't backend plugins, like
+// CloudWatch, to their query endpoints. The other streams are run by the backend plugins.
+type dataSourceStreams struct {
+	backendplugin.Manager
+}
+
+func (m *dataSourceStreams) SubscribeStream(ctx context.Context, req *backendplugin.SubscribeStreamRequest) (*backendplugin.SubscribeStreamResponse, error) {
+	resp, err := m.Manager.SubscribeStream(ctx, req)
+	if !errors.Is(err, backendplugin.ErrPluginNotRegistered) || req.PluginContext.DataSourceInstanceSettings == nil {
+		return resp, err
+	}
+
+	ds, err := getStreamDataSource(req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tsdb.SubscribeStream(ctx, ds, req.Path)
+	if errors.Is(err, tsdb.ErrStreamNotFound) || errors.Is(err, tsdb.ErrStreamingNotSupported) {
+		return &backendplugin.SubscribeStreamResponse{Status: backendplugin.SubscribeStreamStatusNotFound}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &backendplugin.SubscribeStreamResponse{Status: backendplugin.SubscribeStreamStatusOK}, nil
+}
+
+func (m *dataSourceStreams) RunStream(ctx context.Context, req *backendplugin.RunStreamRequest, sender backendplugin.StreamPacketSender) error {
+	err := m.Manager.RunStream(ctx, req, sender)
+	if !errors.Is(err, backendplugin.ErrPluginNotRegistered) || req.PluginContext.DataSourceInstanceSettings == nil {
+		return err
+	}
+
+	ds, err := getStreamDataSource(req.PluginContext)
+	if err != nil {
+		return err
+	}
+
+	return tsdb.RunStream(ctx, ds, req.Path, func(message []byte) error {
+		return sender.Send(&backendplugin.StreamPacket{Data: json.RawMessage(message)})
+	})
+}
+
+// getStreamDataSource returns the data source of a stream, whose access by the user was checked
+// with the plugin context
+func getStreamDataSource(pCtx backend.PluginContext) (*models.DataSource, error) {
+	query := models.GetDataSourceByIdQuery{Id: pCtx.DataSourceInstanceSettings.ID, OrgId: pCtx.OrgID}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+	return query.Result, nil
+}
@@ -222,6 +222,12 @@ func (hs *HTTPServer) loginUserWithUser(user *models.User, c *models.ReqContext)
 
 	hs.log.Info("Successful Login", "User", user.Email)
 	middleware.WriteSessionCookie(c, userToken.UnhashedToken, hs.Cfg.LoginMaxLifetimeDays)
+	hs.UsageInsights.Record(&models.UsageEvent{
+		EventType: models.UsageEventLogin,
+		OrgId:     user.OrgId,
+		UserId:    user.Id,
+		UserLogin: user.Login,
+	})
 	return nil
 }
 
@@ -2,15 +2,20 @@ package api
 
 import (
 	"context"
+	"errors"
 	"sort"
+	"strconv"
+	"time"
 
 	"github.com/grafana/grafana/pkg/models"
-	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/setting"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/services/querycache"
 	"github.com/grafana/grafana/pkg/tsdb"
 	"github.com/grafana/grafana/pkg/tsdb/testdatasource"
 	"github.com/grafana/grafana/pkg/util"
@@ -19,36 +24,71 @@ import (
 // QueryMetricsV2 returns query metrics
 // POST /api/ds/query   DataSource query w/ expressions
 func (hs *HTTPServer) QueryMetricsV2(c *models.ReqContext, reqDto dtos.MetricRequest) Response {
+	resp, ds, cacheStatus, errRsp := hs.queryMetricsV2(c, reqDto)
+	if errRsp != nil {
+		return errRsp
+	}
+
+	statusCode := 200
+	for _, res := range resp.Results {
+		if res.Error != nil {
+			res.ErrorString = res.Error.Error()
+			resp.Message = res.ErrorString
+			statusCode = 400
+		}
+	}
+
+	hs.recordDataRequest(c, ds, resp.Message)
+	return withQueryCacheHeaders(observeQueryResponse(JSON(statusCode, &resp), ds), cacheStatus)
+}
+
+// queryMetricsV2 runs the queries of the request, with or without expressions. The data source
+// of the queries is returned unless they're expressions.
+func (hs *HTTPServer) queryMetricsV2(c *models.ReqContext, reqDto dtos.MetricRequest) (*tsdb.Response, *models.DataSource, querycache.CacheStatus, Response) {
+	var cacheStatus querycache.CacheStatus
 	if len(reqDto.Queries) == 0 {
-		return Error(500, "No queries found in query", nil)
+		return nil, nil, cacheStatus, Error(500, "No queries found in query", nil)
 	}
 
 	request := &tsdb.TsdbQuery{
-		TimeRange: tsdb.NewTimeRange(reqDto.From, reqDto.To),
-		Debug:     reqDto.Debug,
-		User:      c.SignedInUser,
+		TimeRange:       tsdb.NewTimeRange(reqDto.From, reqDto.To),
+		Debug:           reqDto.Debug,
+		User:            c.SignedInUser,
+		Transformations: reqDto.Transformations,
+	}
+
+	hasExpr := false
+	for _, query := range reqDto.Queries {
+		if expr.IsExpressionQuery(query) {
+			hasExpr = true
+		}
 	}
 
-	expr := false
 	var ds *models.DataSource
 	for i, query := range reqDto.Queries {
-		name := query.Get("datasource").MustString("")
-		if name == "__expr__" {
-			expr = true
-		}
+		isExpr := expr.IsExpressionQuery(query)
 
 		datasourceID, err := query.Get("datasourceId").Int64()
-		if err != nil {
-			return Error(500, "datasource missing ID", nil)
+		if err != nil && !isExpr {
+			return nil, nil, cacheStatus, Error(500, "datasource missing ID", nil)
 		}
 
-		if i == 0 && !expr {
-			ds, err = hs.DatasourceCache.GetDatasource(datasourceID, c.SignedInUser, c.SkipCache)
+		// the data source of every query is checked with expressions, the queries may be of
+		// different data sources
+		queryDs := ds
+		if !isExpr && (i == 0 || hasExpr) {
+			queryDs, err = hs.DatasourceCache.GetDatasource(datasourceID, c.SignedInUser, c.SkipCache)
 			if err != nil {
 				if err == models.ErrDataSourceAccessDenied {
-					return Error(403, "Access denied to datasource", err)
+					return nil, nil, cacheStatus, Error(403, "Access denied to datasource", err)
 				}
-				return Error(500, "Unable to load datasource meta data", err)
+				return nil, nil, cacheStatus, Error(500, "Unable to load datasource meta data", err)
+			}
+			if rsp := checkKioskQuery(c, queryDs); rsp != nil {
+				return nil, nil, cacheStatus, rsp
+			}
+			if i == 0 {
+				ds = queryDs
 			}
 		}
 
@@ -58,38 +98,30 @@ func (hs *HTTPServer) QueryMetricsV2(c *models.ReqContext, reqDto dtos.MetricReq
 			IntervalMs:    query.Get("intervalMs").MustInt64(1000),
 			QueryType:     query.Get("queryType").MustString(""),
 			Model:         query,
-			DataSource:    ds,
+			DataSource:    queryDs,
 		})
 	}
 
 	var resp *tsdb.Response
 	var err error
-	if !expr {
-		resp, err = tsdb.HandleRequest(c.Req.Context(), ds, request)
+	if !hasExpr {
+		resp, cacheStatus, err = hs.handleDataSourceRequest(c, ds, request)
 		if err != nil {
-			return Error(500, "Metric request error", err)
+			return nil, nil, cacheStatus, metricRequestError(err)
 		}
+		resp.Correlations = hs.getCorrelationsForQueryResponse(ds)
 	} else {
 		if !setting.IsExpressionsEnabled() {
-			return Error(404, "Expressions feature toggle is not enabled", nil)
+			return nil, nil, cacheStatus, Error(404, "Expressions feature toggle is not enabled", nil)
 		}
 
-		resp, err = plugins.Transform.Transform(c.Req.Context(), request)
+		resp, err = tsdb.HandleRequest(c.Req.Context(), expr.DataSource(c.OrgId), request)
 		if err != nil {
-			return Error(500, "Transform request error", err)
-		}
-	}
-
-	statusCode := 200
-	for _, res := range resp.Results {
-		if res.Error != nil {
-			res.ErrorString = res.Error.Error()
-			resp.Message = res.ErrorString
-			statusCode = 400
+			return nil, nil, cacheStatus, metricRequestError(err)
 		}
 	}
 
-	return JSON(statusCode, &resp)
+	return resp, ds, cacheStatus, nil
 }
 
 // QueryMetrics returns query metrics
@@ -113,11 +145,15 @@ func (hs *HTTPServer) QueryMetrics(c *models.ReqContext, reqDto dtos.MetricReque
 		}
 		return Error(500, "Unable to load datasource meta data", err)
 	}
+	if rsp := checkKioskQuery(c, ds); rsp != nil {
+		return rsp
+	}
 
 	request := &tsdb.TsdbQuery{
-		TimeRange: timeRange,
-		Debug:     reqDto.Debug,
-		User:      c.SignedInUser,
+		TimeRange:       timeRange,
+		Debug:           reqDto.Debug,
+		User:            c.SignedInUser,
+		Transformations: reqDto.Transformations,
 	}
 
 	for _, query := range reqDto.Queries {
@@ -130,10 +166,11 @@ func (hs *HTTPServer) QueryMetrics(c *models.ReqContext, reqDto dtos.MetricReque
 		})
 	}
 
-	resp, err := tsdb.HandleRequest(c.Req.Context(), ds, request)
+	resp, cacheStatus, err := hs.handleDataSourceRequest(c, ds, request)
 	if err != nil {
-		return Error(500, "Metric request error", err)
+		return metricRequestError(err)
 	}
+	resp.Correlations = hs.getCorrelationsForQueryResponse(ds)
 
 	statusCode := 200
 	for _, res := range resp.Results {
@@ -144,7 +181,108 @@ func (hs *HTTPServer) QueryMetrics(c *models.ReqContext, reqDto dtos.MetricReque
 		}
 	}
 
-	return JSON(statusCode, &resp)
+	hs.recordDataRequest(c, ds, resp.Message)
+	return withQueryCacheHeaders(observeQueryResponse(JSON(statusCode, &resp), ds), cacheStatus)
+}
+
+// handleDataSourceRequest runs the queries of a data source through the query cache. The queries
+// of a dashboard panel, told by the X-Dashboard-Id and X-Panel-Id headers, are recorded to warm
+// the query cache and to report the slowest panels, and cancelled when they run longer than the
+// panel execution budget of the data source.
+func (hs *HTTPServer) handleDataSourceRequest(c *models.ReqContext, ds *models.DataSource, request *tsdb.TsdbQuery) (*tsdb.Response, querycache.CacheStatus, error) {
+	dashboardID, err := strconv.ParseInt(c.Req.Header.Get("X-Dashboard-Id"), 10, 64)
+	if err != nil {
+		return hs.runDataSourceRequest(c.Req.Context(), c, ds, request)
+	}
+	panelID, _ := strconv.ParseInt(c.Req.Header.Get("X-Panel-Id"), 10, 64)
+	hs.QueryCacheService.RecordDashboardRequest(dashboardID, panelID, ds, request)
+
+	ctx := c.Req.Context()
+	budget := tsdb.QueryLimitsFor(ds).PanelBudget
+	if budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, budget)
+		defer cancel()
+	}
+
+	start := time.Now()
+	resp, cacheStatus, err := hs.runDataSourceRequest(ctx, c, ds, request)
+	budgetExceeded := budget > 0 && ctx.Err() == context.DeadlineExceeded && c.Req.Context().Err() == nil
+	hs.QueryStatsService.RecordPanelQuery(c.OrgId, dashboardID, panelID, ds, time.Since(start), budgetExceeded)
+	if budgetExceeded {
+		return nil, cacheStatus, &tsdb.PanelBudgetExceededError{Budget: budget}
+	}
+	return resp, cacheStatus, err
+}
+
+// runDataSourceRequest runs the queries of a data source through the query cache, and sends them to
+// its shadow data source too when they aren't served from the cache
+func (hs *HTTPServer) runDataSourceRequest(ctx context.Context, c *models.ReqContext, ds *models.DataSource, request *tsdb.TsdbQuery) (*tsdb.Response, querycache.CacheStatus, error) {
+	start := time.Now()
+	resp, cacheStatus, err := hs.QueryCacheService.HandleRequest(ctx, ds, request, c.SkipCache)
+	if cacheStatus.Status != querycache.StatusHit {
+		hs.ShadowQueryService.ShadowRequest(ds, request, resp, err, time.Since(start))
+	}
+	return resp, cacheStatus, err
+}
+
+// metricRequestError returns the response of a query request which failed, 403 when a query
+// rule denied one of its queries and 400 when its transformations failed or it exceeded the
+// panel execution budget
+func metricRequestError(err error) Response {
+	var deniedErr *tsdb.QueryDeniedError
+	if errors.As(err, &deniedErr) {
+		return Error(403, deniedErr.Error(), err)
+	}
+	var transformationErr *tsdb.TransformationError
+	if errors.As(err, &transformationErr) {
+		return Error(400, transformationErr.Error(), err)
+	}
+	var budgetErr *tsdb.PanelBudgetExceededError
+	if errors.As(err, &budgetErr) {
+		return Error(400, budgetErr.Error(), err)
+	}
+	return Error(500, "Metric request error", err)
+}
+
+// recordDataRequest records the usage event of a request querying a data source, or expressions
+// when ds is nil
+func (hs *HTTPServer) recordDataRequest(c *models.ReqContext, ds *models.DataSource, errMsg string) {
+	event := &models.UsageEvent{
+		EventType: models.UsageEventDataRequest,
+		OrgId:     c.OrgId,
+		UserId:    c.UserId,
+		UserLogin: c.Login,
+		Error:     errMsg,
+	}
+	if ds != nil {
+		event.DatasourceId = ds.Id
+		event.DatasourceType = ds.Type
+	}
+	hs.UsageInsights.Record(event)
+}
+
+// observeQueryResponse records the size of the response to the queries of a data source
+func observeQueryResponse(rsp *NormalResponse, ds *models.DataSource) *NormalResponse {
+	if ds == nil {
+		return rsp
+	}
+
+	metrics.MDataSourceQueryResponseBytes.WithLabelValues(ds.Type, ds.Uid).Observe(float64(len(rsp.body)))
+	return rsp
+}
+
+// withQueryCacheHeaders reports whether the response of a query request was served from the query cache
+func withQueryCacheHeaders(rsp *NormalResponse, cacheStatus querycache.CacheStatus) *NormalResponse {
+	if cacheStatus.Status == "" {
+		return rsp
+	}
+
+	rsp.Header("X-Cache", cacheStatus.Status)
+	if cacheStatus.Status != querycache.StatusBypass {
+		rsp.Header("Cache-Control", "private,max-age="+strconv.Itoa(int(cacheStatus.MaxAge.Seconds())))
+	}
+	return rsp
 }
 
 // GET /api/tsdb/testdata/scenarios
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/dataexport"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+var exportFilenameRegex = regexp.MustCompile(`[^\w\-. ]+`)
+
+// ExportMetrics runs the queries of a panel and streams the result as a CSV, Excel or Parquet file
+// POST /api/ds/export
+func (hs *HTTPServer) ExportMetrics(c *models.ReqContext, reqDto dtos.ExportMetricsRequest) Response {
+	contentType, err := dataexport.ContentType(reqDto.Format)
+	if err != nil {
+		return Error(400, fmt.Sprintf("Unknown export format %s, use csv, xlsx or parquet", reqDto.Format), nil)
+	}
+
+	location := time.UTC
+	if reqDto.Timezone != "" && reqDto.Timezone != "utc" {
+		location, err = time.LoadLocation(reqDto.Timezone)
+		if err != nil {
+			return Error(400, "Invalid timezone", err)
+		}
+	}
+
+	resp, _, _, errRsp := hs.queryMetricsV2(c, reqDto.MetricRequest)
+	if errRsp != nil {
+		return errRsp
+	}
+
+	frames, err := exportFrames(reqDto.MetricRequest, resp)
+	if err != nil {
+		return Error(400, err.Error(), nil)
+	}
+
+	if err := dataexport.Validate(reqDto.Format, frames); err != nil {
+		return Error(400, err.Error(), nil)
+	}
+
+	filename := exportFilenameRegex.ReplaceAllString(reqDto.Filename, "")
+	if filename == "" {
+		filename = "grafana_data_export"
+	}
+
+	return Stream(200, func(w io.Writer) error {
+		return dataexport.Write(w, reqDto.Format, frames, location)
+	}).
+		Header("Content-Type", contentType).
+		Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, reqDto.Format))
+}
+
+// exportFrames returns the frames of the results in the order of the queries. Time series
+// and tables of data sources that do not return data frames are converted to frames.
+func exportFrames(reqDto dtos.MetricRequest, resp *tsdb.Response) (data.Frames, error) {
+	frames := make(data.Frames, 0)
+
+	for _, query := range reqDto.Queries {
+		refID := query.Get("refId").MustString("A")
+		res, ok := resp.Results[refID]
+		if !ok {
+			continue
+		}
+
+		if res.Error != nil {
+			return nil, fmt.Errorf("Query %s failed: %s", refID, res.Error.Error())
+		}
+
+		if res.Dataframes != nil {
+			decoded, err := res.Dataframes.Decoded()
+			if err != nil {
+				return nil, err
+			}
+			frames = append(frames, decoded...)
+		}
+
+		for _, series := range res.Series {
+			frame, err := tsdb.SeriesToFrame(series)
+			if err != nil {
+				return nil, err
+			}
+			frame.RefID = refID
+			frames = append(frames, frame)
+		}
+
+		for _, table := range res.Tables {
+			frame := tsdb.TableToFrame(table)
+			frame.RefID = refID
+			frames = append(frames, frame)
+		}
+	}
+
+	return frames, nil
+}
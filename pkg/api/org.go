@@ -19,6 +19,34 @@ func GetOrgByID(c *models.ReqContext) Response {
 	return getOrgHelper(c.ParamsInt64(":orgId"))
 }
 
+// GET /api/orgs/:orgId/usage
+func GetOrgUsage(c *models.ReqContext) Response {
+	orgID := c.ParamsInt64(":orgId")
+
+	if err := bus.Dispatch(&models.GetOrgByIdQuery{Id: orgID}); err != nil {
+		if err == models.ErrOrgNotFound {
+			return Error(404, "Organization not found", err)
+		}
+
+		return Error(500, "Failed to get organization", err)
+	}
+
+	query := models.GetOrgUsageQuery{OrgId: orgID, Days: c.QueryInt("days")}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get organization usage", err)
+	}
+
+	if setting.Quota.Enabled {
+		quotaQuery := models.GetOrgQuotasQuery{OrgId: orgID}
+		if err := bus.Dispatch(&quotaQuery); err != nil {
+			return Error(500, "Failed to get org quotas", err)
+		}
+		query.Result.Quotas = quotaQuery.Result
+	}
+
+	return JSON(200, query.Result)
+}
+
 // Get /api/orgs/name/:name
 func GetOrgByName(c *models.ReqContext) Response {
 	query := models.GetOrgByNameQuery{Name: c.Params(":name")}
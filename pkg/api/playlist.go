@@ -4,7 +4,9 @@ import (
 	"net/http"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/gtime"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
 )
 
 func ValidateOrgPlaylist(c *models.ReqContext) {
@@ -73,11 +75,12 @@ func GetPlaylist(c *models.ReqContext) Response {
 	playlistDTOs, _ := LoadPlaylistItemDTOs(id)
 
 	dto := &models.PlaylistDTO{
-		Id:       cmd.Result.Id,
-		Name:     cmd.Result.Name,
-		Interval: cmd.Result.Interval,
-		OrgId:    cmd.Result.OrgId,
-		Items:    playlistDTOs,
+		Id:            cmd.Result.Id,
+		Name:          cmd.Result.Name,
+		Interval:      cmd.Result.Interval,
+		OrgId:         cmd.Result.OrgId,
+		HasKioskToken: cmd.Result.HasKioskToken,
+		Items:         playlistDTOs,
 	}
 
 	return JSON(200, dto)
@@ -100,6 +103,7 @@ func LoadPlaylistItemDTOs(id int64) ([]models.PlaylistItemDTO, error) {
 			Value:      item.Value,
 			Order:      item.Order,
 			Title:      item.Title,
+			Interval:   item.Interval,
 		})
 	}
 
@@ -149,9 +153,27 @@ func DeletePlaylist(c *models.ReqContext) Response {
 	return JSON(200, "")
 }
 
+func validatePlaylistItems(items []models.PlaylistItemDTO) error {
+	for _, item := range items {
+		if item.Interval == "" {
+			continue
+		}
+
+		if _, err := gtime.ParseInterval(item.Interval); err != nil {
+			return models.ErrPlaylistItemInvalidInterval
+		}
+	}
+
+	return nil
+}
+
 func CreatePlaylist(c *models.ReqContext, cmd models.CreatePlaylistCommand) Response {
 	cmd.OrgId = c.OrgId
 
+	if err := validatePlaylistItems(cmd.Items); err != nil {
+		return Error(400, err.Error(), err)
+	}
+
 	if err := bus.Dispatch(&cmd); err != nil {
 		return Error(500, "Failed to create playlist", err)
 	}
@@ -163,6 +185,10 @@ func UpdatePlaylist(c *models.ReqContext, cmd models.UpdatePlaylistCommand) Resp
 	cmd.OrgId = c.OrgId
 	cmd.Id = c.ParamsInt64(":id")
 
+	if err := validatePlaylistItems(cmd.Items); err != nil {
+		return Error(400, err.Error(), err)
+	}
+
 	if err := bus.Dispatch(&cmd); err != nil {
 		return Error(500, "Failed to save playlist", err)
 	}
@@ -175,3 +201,28 @@ func UpdatePlaylist(c *models.ReqContext, cmd models.UpdatePlaylistCommand) Resp
 	cmd.Result.Items = playlistDTOs
 	return JSON(200, cmd.Result)
 }
+
+// POST /api/playlists/:id/kiosk-token
+func CreatePlaylistKioskToken(c *models.ReqContext) Response {
+	cmd := models.CreatePlaylistKioskTokenCommand{Id: c.ParamsInt64(":id"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&cmd); err != nil {
+		if err == models.ErrPlaylistNotFound {
+			return Error(404, "Playlist not found", err)
+		}
+		return Error(500, "Failed to create kiosk token", err)
+	}
+
+	return JSON(200, util.DynMap{
+		"token": cmd.Result,
+	})
+}
+
+// DELETE /api/playlists/:id/kiosk-token
+func DeletePlaylistKioskToken(c *models.ReqContext) Response {
+	cmd := models.DeletePlaylistKioskTokenCommand{Id: c.ParamsInt64(":id"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return Error(500, "Failed to delete kiosk token", err)
+	}
+
+	return Success("Kiosk token deleted")
+}
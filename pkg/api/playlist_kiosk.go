@@ -0,0 +1,124 @@
+package api
+
+import (
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+)
+
+func loadKioskPlaylistDashboards(playlist *models.Playlist, user *models.SignedInUser) (dtos.PlaylistDashboardsSlice, error) {
+	dashboards, err := LoadPlaylistDashboards(playlist.OrgId, user, playlist.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(dtos.PlaylistDashboardsSlice, 0)
+	for _, dash := range dashboards {
+		canView, err := guardian.New(dash.Id, playlist.OrgId, user).CanView()
+		if err != nil {
+			return nil, err
+		}
+
+		if canView {
+			result = append(result, dash)
+		}
+	}
+
+	return result, nil
+}
+
+// getKioskTokenPlaylist returns the playlist of a kiosk token
+func getKioskTokenPlaylist(token *models.KioskToken) (*models.Playlist, error) {
+	if token.PlaylistId == 0 {
+		return nil, models.ErrPlaylistNotFound
+	}
+
+	query := models.GetPlaylistByIdQuery{Id: token.PlaylistId}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+	if query.Result.Id == 0 || query.Result.OrgId != token.OrgId {
+		return nil, models.ErrPlaylistNotFound
+	}
+
+	return query.Result, nil
+}
+
+func getKioskPlaylist(c *models.ReqContext) (*models.Playlist, *models.KioskToken, Response) {
+	token, rsp := getKioskToken(c)
+	if rsp != nil {
+		return nil, nil, rsp
+	}
+
+	playlist, err := getKioskTokenPlaylist(token)
+	if err != nil {
+		if err == models.ErrPlaylistNotFound {
+			return nil, nil, Error(404, "Playlist not found", err)
+		}
+		return nil, nil, Error(500, "Failed to get playlist", err)
+	}
+
+	return playlist, token, nil
+}
+
+// GET /api/playlist-kiosk
+func GetKioskPlaylist(c *models.ReqContext) Response {
+	playlist, token, rsp := getKioskPlaylist(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	dashboards, err := loadKioskPlaylistDashboards(playlist, token.SignedInUser())
+	if err != nil {
+		return Error(500, "Could not load dashboards", err)
+	}
+
+	return JSON(200, dtos.PlaylistKioskDTO{
+		Name:       playlist.Name,
+		Interval:   playlist.Interval,
+		Dashboards: dashboards,
+	})
+}
+
+// GET /api/playlist-kiosk/dashboards/:uid
+func GetKioskPlaylistDashboard(c *models.ReqContext) Response {
+	playlist, token, rsp := getKioskPlaylist(c)
+	if rsp != nil {
+		return rsp
+	}
+
+	dashboards, err := loadKioskPlaylistDashboards(playlist, token.SignedInUser())
+	if err != nil {
+		return Error(500, "Could not load dashboards", err)
+	}
+
+	uid := c.Params(":uid")
+	for _, dash := range dashboards {
+		if dash.Uid != uid {
+			continue
+		}
+
+		query := models.GetDashboardQuery{Uid: uid, OrgId: playlist.OrgId}
+		if err := bus.Dispatch(&query); err != nil {
+			return Error(404, "Dashboard not found", err)
+		}
+
+		// the playlist is shared with anyone having its token
+		models.RedactSensitiveFields(query.Result.Data)
+
+		return JSON(200, dtos.DashboardFullWithMeta{
+			Dashboard: query.Result.Data,
+			Meta: dtos.DashboardMeta{
+				Type:    models.DashTypeDB,
+				Slug:    query.Result.Slug,
+				Url:     query.Result.GetUrl(),
+				Created: query.Result.Created,
+				Updated: query.Result.Updated,
+				Version: query.Result.Version,
+			},
+		})
+	}
+
+	return Error(404, "Dashboard not found in playlist", nil)
+}
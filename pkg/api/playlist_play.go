@@ -11,31 +11,57 @@ import (
 	"github.com/grafana/grafana/pkg/services/search"
 )
 
-func populateDashboardsByID(dashboardByIDs []int64, dashboardIDOrder map[int64]int) (dtos.PlaylistDashboardsSlice, error) {
+func toPlaylistDashboards(dashboards []*models.Dashboard, itemFor func(dash *models.Dashboard) models.PlaylistItem) dtos.PlaylistDashboardsSlice {
 	result := make(dtos.PlaylistDashboardsSlice, 0)
 
-	if len(dashboardByIDs) > 0 {
-		dashboardQuery := models.GetDashboardsQuery{DashboardIds: dashboardByIDs}
-		if err := bus.Dispatch(&dashboardQuery); err != nil {
-			return result, err
-		}
+	for _, item := range dashboards {
+		playlistItem := itemFor(item)
+		result = append(result, dtos.PlaylistDashboard{
+			Id:       item.Id,
+			Uid:      item.Uid,
+			Slug:     item.Slug,
+			Title:    item.Title,
+			Uri:      "db/" + item.Slug,
+			Url:      models.GetDashboardUrl(item.Uid, item.Slug),
+			Order:    playlistItem.Order,
+			Interval: playlistItem.Interval,
+		})
+	}
 
-		for _, item := range dashboardQuery.Result {
-			result = append(result, dtos.PlaylistDashboard{
-				Id:    item.Id,
-				Slug:  item.Slug,
-				Title: item.Title,
-				Uri:   "db/" + item.Slug,
-				Url:   models.GetDashboardUrl(item.Uid, item.Slug),
-				Order: dashboardIDOrder[item.Id],
-			})
-		}
+	return result
+}
+
+func populateDashboardsByID(dashboardByIDs []int64, dashboardIDItems map[int64]models.PlaylistItem) (dtos.PlaylistDashboardsSlice, error) {
+	if len(dashboardByIDs) == 0 {
+		return make(dtos.PlaylistDashboardsSlice, 0), nil
 	}
 
-	return result, nil
+	dashboardQuery := models.GetDashboardsQuery{DashboardIds: dashboardByIDs}
+	if err := bus.Dispatch(&dashboardQuery); err != nil {
+		return make(dtos.PlaylistDashboardsSlice, 0), err
+	}
+
+	return toPlaylistDashboards(dashboardQuery.Result, func(dash *models.Dashboard) models.PlaylistItem {
+		return dashboardIDItems[dash.Id]
+	}), nil
 }
 
-func populateDashboardsByTag(orgID int64, signedInUser *models.SignedInUser, dashboardByTag []string, dashboardTagOrder map[string]int) dtos.PlaylistDashboardsSlice {
+func populateDashboardsByUID(orgID int64, dashboardByUIDs []string, dashboardUIDItems map[string]models.PlaylistItem) (dtos.PlaylistDashboardsSlice, error) {
+	if len(dashboardByUIDs) == 0 {
+		return make(dtos.PlaylistDashboardsSlice, 0), nil
+	}
+
+	dashboardQuery := models.GetDashboardsQuery{DashboardUIds: dashboardByUIDs, OrgId: orgID}
+	if err := bus.Dispatch(&dashboardQuery); err != nil {
+		return make(dtos.PlaylistDashboardsSlice, 0), err
+	}
+
+	return toPlaylistDashboards(dashboardQuery.Result, func(dash *models.Dashboard) models.PlaylistItem {
+		return dashboardUIDItems[dash.Uid]
+	}), nil
+}
+
+func populateDashboardsByTag(orgID int64, signedInUser *models.SignedInUser, dashboardByTag []string, dashboardTagItems map[string]models.PlaylistItem) dtos.PlaylistDashboardsSlice {
 	result := make(dtos.PlaylistDashboardsSlice, 0)
 
 	for _, tag := range dashboardByTag {
@@ -51,12 +77,14 @@ func populateDashboardsByTag(orgID int64, signedInUser *models.SignedInUser, das
 		if err := bus.Dispatch(&searchQuery); err == nil {
 			for _, item := range searchQuery.Result {
 				result = append(result, dtos.PlaylistDashboard{
-					Id:    item.Id,
-					Slug:  item.Slug,
-					Title: item.Title,
-					Uri:   item.Uri,
-					Url:   item.Url,
-					Order: dashboardTagOrder[tag],
+					Id:       item.Id,
+					Uid:      item.Uid,
+					Slug:     item.Slug,
+					Title:    item.Title,
+					Uri:      item.Uri,
+					Url:      item.Url,
+					Order:    dashboardTagItems[tag].Order,
+					Interval: dashboardTagItems[tag].Interval,
 				})
 			}
 		}
@@ -69,28 +97,34 @@ func LoadPlaylistDashboards(orgID int64, signedInUser *models.SignedInUser, play
 	playlistItems, _ := LoadPlaylistItems(playlistID)
 
 	dashboardByIDs := make([]int64, 0)
+	dashboardByUIDs := make([]string, 0)
 	dashboardByTag := make([]string, 0)
-	dashboardIDOrder := make(map[int64]int)
-	dashboardTagOrder := make(map[string]int)
+	dashboardIDItems := make(map[int64]models.PlaylistItem)
+	dashboardUIDItems := make(map[string]models.PlaylistItem)
+	dashboardTagItems := make(map[string]models.PlaylistItem)
 
 	for _, i := range playlistItems {
-		if i.Type == "dashboard_by_id" {
+		switch i.Type {
+		case models.PlaylistItemTypeDashboardById:
 			dashboardID, _ := strconv.ParseInt(i.Value, 10, 64)
 			dashboardByIDs = append(dashboardByIDs, dashboardID)
-			dashboardIDOrder[dashboardID] = i.Order
-		}
-
-		if i.Type == "dashboard_by_tag" {
+			dashboardIDItems[dashboardID] = i
+		case models.PlaylistItemTypeDashboardByUid:
+			dashboardByUIDs = append(dashboardByUIDs, i.Value)
+			dashboardUIDItems[i.Value] = i
+		case models.PlaylistItemTypeDashboardByTag:
 			dashboardByTag = append(dashboardByTag, i.Value)
-			dashboardTagOrder[i.Value] = i.Order
+			dashboardTagItems[i.Value] = i
 		}
 	}
 
 	result := make(dtos.PlaylistDashboardsSlice, 0)
 
-	var k, _ = populateDashboardsByID(dashboardByIDs, dashboardIDOrder)
+	var k, _ = populateDashboardsByID(dashboardByIDs, dashboardIDItems)
 	result = append(result, k...)
-	result = append(result, populateDashboardsByTag(orgID, signedInUser, dashboardByTag, dashboardTagOrder)...)
+	var u, _ = populateDashboardsByUID(orgID, dashboardByUIDs, dashboardUIDItems)
+	result = append(result, u...)
+	result = append(result, populateDashboardsByTag(orgID, signedInUser, dashboardByTag, dashboardTagItems)...)
 
 	sort.Sort(result)
 	return result, nil
@@ -17,9 +17,15 @@ import (
 func ApplyRoute(ctx context.Context, req *http.Request, proxyPath string, route *plugins.AppPluginRoute, ds *models.DataSource) {
 	proxyPath = strings.TrimPrefix(proxyPath, route.Path)
 
+	secureJsonData, err := ds.DecryptedValues()
+	if err != nil {
+		logger.Error("Error resolving data source secrets", "error", err)
+		return
+	}
+
 	data := templateData{
 		JsonData:       ds.JsonData.Interface().(map[string]interface{}),
-		SecureJsonData: ds.SecureJsonData.Decrypt(),
+		SecureJsonData: secureJsonData,
 	}
 
 	interpolatedURL, err := InterpolateString(route.URL, data)
@@ -2,10 +2,12 @@ package pluginproxy
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
@@ -19,6 +21,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/datasource"
 	"github.com/grafana/grafana/pkg/bus"
 	glog "github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/login/social"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
@@ -44,13 +47,18 @@ type DataSourceProxy struct {
 
 type handleResponseTransport struct {
 	transport http.RoundTripper
+	ds        *models.DataSource
 }
 
 func (t *handleResponseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
 	res, err := t.transport.RoundTrip(req)
+	metrics.MDataSourceProxyDuration.WithLabelValues(t.ds.Type, t.ds.Uid).Observe(time.Since(start).Seconds())
 	if err != nil {
+		metrics.MDataSourceProxyStatus.WithLabelValues(t.ds.Type, t.ds.Uid, "error").Inc()
 		return nil, err
 	}
+	metrics.MDataSourceProxyStatus.WithLabelValues(t.ds.Type, t.ds.Uid, strconv.Itoa(res.StatusCode)).Inc()
 	res.Header.Del("Set-Cookie")
 	return res, nil
 }
@@ -101,12 +109,20 @@ func (proxy *DataSourceProxy) HandleRequest() {
 		return
 	}
 
+	// resolve the secrets of the data source here so that the director, which can't fail,
+	// doesn't send the request without them
+	if _, err := proxy.ds.DecryptedValues(); err != nil {
+		proxy.ctx.JsonApiErr(500, "Failed to resolve data source secrets", err)
+		return
+	}
+
 	proxyErrorLogger := logger.New("userId", proxy.ctx.UserId, "orgId", proxy.ctx.OrgId, "uname", proxy.ctx.Login, "path", proxy.ctx.Req.URL.Path, "remote_addr", proxy.ctx.RemoteAddr(), "referer", proxy.ctx.Req.Referer())
 
 	reverseProxy := &httputil.ReverseProxy{
 		Director:      proxy.getDirector(),
 		FlushInterval: time.Millisecond * 200,
 		ErrorLog:      log.New(&logWrapper{logger: proxyErrorLogger}, "", 0),
+		ErrorHandler:  proxy.handleProxyError,
 	}
 
 	transport, err := proxy.ds.GetHttpTransport()
@@ -117,6 +133,7 @@ func (proxy *DataSourceProxy) HandleRequest() {
 
 	reverseProxy.Transport = &handleResponseTransport{
 		transport: transport,
+		ds:        proxy.ds,
 	}
 
 	proxy.logRequest()
@@ -143,6 +160,29 @@ func (proxy *DataSourceProxy) HandleRequest() {
 	reverseProxy.ServeHTTP(proxy.ctx.Resp, proxy.ctx.Req.Request)
 }
 
+// handleProxyError reports the requests to the data source that failed, telling which limit of
+// the data source was hit or whether its address is blocked if any.
+func (proxy *DataSourceProxy) handleProxyError(w http.ResponseWriter, req *http.Request, err error) {
+	var limitErr models.ResponseLimitError
+	var blockedErr models.NetworkBlockedError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &blockedErr):
+		logger.Warn("Blocked data proxy request", "datasource", proxy.ds.Name, "ip", blockedErr.IP)
+		proxy.ctx.JsonApiErr(403, "Data source address is not allowed: "+blockedErr.Error(), err)
+	case errors.As(err, &limitErr):
+		proxy.ctx.JsonApiErr(502, "Data source response too large: "+limitErr.Error(), err)
+	case errors.As(err, &netErr) && netErr.Timeout():
+		timeout := proxy.ds.HTTPSettings().Timeout
+		proxy.ctx.JsonApiErr(504, fmt.Sprintf("Data source request timed out after %s", timeout), err)
+	case errors.Is(err, context.Canceled):
+		// the client went away, there's no one to respond to
+		logger.Debug("Data proxy request canceled", "path", req.URL.Path)
+	default:
+		proxy.ctx.JsonApiErr(502, "Bad Gateway", err)
+	}
+}
+
 func (proxy *DataSourceProxy) addTraceFromHeaderValue(span opentracing.Span, headerName string, tagName string) {
 	panelId := proxy.ctx.Req.Header.Get(headerName)
 	dashId, err := strconv.Atoi(panelId)
@@ -162,14 +202,14 @@ func (proxy *DataSourceProxy) getDirector() func(req *http.Request) {
 		if proxy.ds.Type == models.DS_INFLUXDB_08 {
 			req.URL.Path = util.JoinURLFragments(proxy.targetUrl.Path, "db/"+proxy.ds.Database+"/"+proxy.proxyPath)
 			reqQueryVals.Add("u", proxy.ds.User)
-			reqQueryVals.Add("p", proxy.ds.DecryptedPassword())
+			reqQueryVals.Add("p", proxy.decryptedPassword())
 			req.URL.RawQuery = reqQueryVals.Encode()
 		} else if proxy.ds.Type == models.DS_INFLUXDB {
 			req.URL.Path = util.JoinURLFragments(proxy.targetUrl.Path, proxy.proxyPath)
 			req.URL.RawQuery = reqQueryVals.Encode()
 			if !proxy.ds.BasicAuth {
 				req.Header.Del("Authorization")
-				req.Header.Add("Authorization", util.GetBasicAuthHeader(proxy.ds.User, proxy.ds.DecryptedPassword()))
+				req.Header.Add("Authorization", util.GetBasicAuthHeader(proxy.ds.User, proxy.decryptedPassword()))
 			}
 		} else {
 			req.URL.Path = util.JoinURLFragments(proxy.targetUrl.Path, proxy.proxyPath)
@@ -177,7 +217,7 @@ func (proxy *DataSourceProxy) getDirector() func(req *http.Request) {
 
 		if proxy.ds.BasicAuth {
 			req.Header.Del("Authorization")
-			req.Header.Add("Authorization", util.GetBasicAuthHeader(proxy.ds.BasicAuthUser, proxy.ds.DecryptedBasicAuthPassword()))
+			req.Header.Add("Authorization", util.GetBasicAuthHeader(proxy.ds.BasicAuthUser, proxy.decryptedBasicAuthPassword()))
 		}
 
 		dsAuth := req.Header.Get("X-DS-Authorization")
@@ -210,11 +250,27 @@ func (proxy *DataSourceProxy) getDirector() func(req *http.Request) {
 		}
 
 		if proxy.ds.JsonData != nil && proxy.ds.JsonData.Get("oauthPassThru").MustBool() {
-			addOAuthPassThruAuth(proxy.ctx, req)
+			AddOAuthPassThruAuth(proxy.ctx, req, proxy.ds)
 		}
 	}
 }
 
+func (proxy *DataSourceProxy) decryptedPassword() string {
+	password, err := proxy.ds.DecryptedPassword()
+	if err != nil {
+		logger.Error("Failed to resolve data source password", "datasource", proxy.ds.Name, "err", err)
+	}
+	return password
+}
+
+func (proxy *DataSourceProxy) decryptedBasicAuthPassword() string {
+	password, err := proxy.ds.DecryptedBasicAuthPassword()
+	if err != nil {
+		logger.Error("Failed to resolve data source basic auth password", "datasource", proxy.ds.Name, "err", err)
+	}
+	return password
+}
+
 func (proxy *DataSourceProxy) validateRequest() error {
 	if !checkWhiteList(proxy.ctx, proxy.targetUrl.Host) {
 		return errors.New("Target url is not a valid target")
@@ -269,7 +325,7 @@ func (proxy *DataSourceProxy) validateRequest() error {
 }
 
 func (proxy *DataSourceProxy) logRequest() {
-	if !setting.DataProxyLogging {
+	if !setting.GetDataProxySettings().Logging {
 		return
 	}
 
@@ -303,7 +359,10 @@ func checkWhiteList(c *models.ReqContext, host string) bool {
 	return true
 }
 
-func addOAuthPassThruAuth(c *models.ReqContext, req *http.Request) {
+// AddOAuthPassThruAuth sets the Authorization header of the request to the OAuth access token of the
+// signed in user, refreshing the token if it has expired. The token is exchanged for a token of the
+// audience and scopes of the data source if it sets oauthPassThruAudience or oauthPassThruScopes.
+func AddOAuthPassThruAuth(c *models.ReqContext, req *http.Request, ds *models.DataSource) {
 	authInfoQuery := &models.GetAuthInfoQuery{UserId: c.UserId}
 	if err := bus.Dispatch(authInfoQuery); err != nil {
 		logger.Error("Error fetching oauth information for user", "error", err)
@@ -342,6 +401,27 @@ func addOAuthPassThruAuth(c *models.ReqContext, req *http.Request) {
 			return
 		}
 	}
+
+	if exchange := oauthTokenExchangeRequest(ds); !exchange.IsZero() {
+		token, err = connect.ExchangeToken(c.Req.Context(), token, exchange)
+		if err != nil {
+			logger.Error("Failed to exchange access token for the data source", "provider", provider,
+				"datasource", ds.Name, "audience", exchange.Audience, "error", err)
+			return
+		}
+	}
+
 	req.Header.Del("Authorization")
 	req.Header.Add("Authorization", fmt.Sprintf("%s %s", token.Type(), token.AccessToken))
 }
+
+// oauthTokenExchangeRequest returns the audience and scopes of the tokens forwarded to a data source
+func oauthTokenExchangeRequest(ds *models.DataSource) social.TokenExchangeRequest {
+	if ds == nil || ds.JsonData == nil {
+		return social.TokenExchangeRequest{}
+	}
+	return social.TokenExchangeRequest{
+		Audience: strings.TrimSpace(ds.JsonData.Get("oauthPassThruAudience").MustString()),
+		Scopes:   util.SplitString(ds.JsonData.Get("oauthPassThruScopes").MustString()),
+	}
+}
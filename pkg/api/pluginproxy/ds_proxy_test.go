@@ -426,6 +426,64 @@ func TestDSRouteRule(t *testing.T) {
 			})
 		})
 
+		Convey("When proxying a datasource that exchanges the oauth token for its audience", func() {
+			idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil || r.Form.Get("subject_token") != "testtoken" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"access_token": "token-for-` + r.Form.Get("audience") + `", "token_type": "Bearer", "expires_in": 300}`))
+			}))
+			defer idp.Close()
+
+			social.SocialMap["generic_oauth"] = &social.SocialGenericOAuth{
+				SocialBase: &social.SocialBase{
+					Config: &oauth2.Config{Endpoint: oauth2.Endpoint{TokenURL: idp.URL}},
+				},
+			}
+
+			bus.AddHandler("test", func(query *models.GetAuthInfoQuery) error {
+				query.Result = &models.UserAuth{
+					Id:               1,
+					UserId:           1,
+					AuthModule:       "generic_oauth",
+					OAuthAccessToken: "testtoken",
+					OAuthTokenType:   "Bearer",
+					OAuthExpiry:      time.Now().AddDate(0, 0, 1),
+				}
+				return nil
+			})
+
+			plugin := &plugins.DataSourcePlugin{}
+			ds := &models.DataSource{
+				Type: "custom-datasource",
+				Url:  "http://host/root/",
+				JsonData: simplejson.NewFromAny(map[string]interface{}{
+					"oauthPassThru":         true,
+					"oauthPassThruAudience": "metrics-api",
+				}),
+			}
+
+			req, _ := http.NewRequest("GET", "http://localhost/asd", nil)
+			ctx := &models.ReqContext{
+				SignedInUser: &models.SignedInUser{UserId: 1},
+				Context: &macaron.Context{
+					Req: macaron.Request{Request: req},
+				},
+			}
+			proxy, err := NewDataSourceProxy(ds, plugin, ctx, "/path/to/folder/", &setting.Cfg{})
+			So(err, ShouldBeNil)
+			req, err = http.NewRequest(http.MethodGet, "http://grafana.com/sub", nil)
+			So(err, ShouldBeNil)
+
+			proxy.getDirector()(req)
+
+			Convey("Should have the exchanged access token in header", func() {
+				So(req.Header.Get("Authorization"), ShouldEqual, "Bearer token-for-metrics-api")
+			})
+		})
+
 		Convey("When SendUserHeader config is enabled", func() {
 			req := getDatasourceProxiedRequest(
 				&models.ReqContext{
@@ -29,12 +29,17 @@ func getHeaders(route *plugins.AppPluginRoute, orgId int64, appID string) (http.
 		return nil, err
 	}
 
+	secureJsonData, err := query.Result.DecryptedValues()
+	if err != nil {
+		return nil, err
+	}
+
 	data := templateData{
 		JsonData:       query.Result.JsonData,
-		SecureJsonData: query.Result.SecureJsonData.Decrypt(),
+		SecureJsonData: secureJsonData,
 	}
 
-	err := addHeaders(&result, route, data)
+	err = addHeaders(&result, route, data)
 	return result, err
 }
 
@@ -44,9 +49,14 @@ func updateURL(route *plugins.AppPluginRoute, orgId int64, appID string) (string
 		return "", err
 	}
 
+	secureJsonData, err := query.Result.DecryptedValues()
+	if err != nil {
+		return "", err
+	}
+
 	data := templateData{
 		JsonData:       query.Result.JsonData,
-		SecureJsonData: query.Result.SecureJsonData.Decrypt(),
+		SecureJsonData: secureJsonData,
 	}
 	interpolated, err := InterpolateString(route.URL, data)
 	if err != nil {
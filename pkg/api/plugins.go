@@ -5,11 +5,13 @@ import (
 	"errors"
 	"net/http"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/live"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
@@ -24,7 +26,7 @@ var ErrPluginNotFound error = errors.New("plugin not found, no installed plugin
 
 func (hs *HTTPServer) getPluginContext(pluginID string, user *models.SignedInUser) (backend.PluginContext, error) {
 	pc := backend.PluginContext{}
-	plugin, exists := plugins.Plugins[pluginID]
+	plugin, exists := plugins.GetPlugin(pluginID)
 	if !exists {
 		return pc, ErrPluginNotFound
 	}
@@ -45,7 +47,10 @@ func (hs *HTTPServer) getPluginContext(pluginID string, user *models.SignedInUse
 		if err != nil {
 			return pc, errutil.Wrap("Failed to unmarshal plugin json data", err)
 		}
-		decryptedSecureJSONData = ps.DecryptedValues()
+		decryptedSecureJSONData, err = ps.DecryptedValues()
+		if err != nil {
+			return pc, errutil.Wrap("Failed to resolve plugin secrets", err)
+		}
 		updated = ps.Updated
 	}
 
@@ -61,6 +66,38 @@ func (hs *HTTPServer) getPluginContext(pluginID string, user *models.SignedInUse
 	}, nil
 }
 
+// getStreamPluginContext returns the plugin context with which a user accesses the streams of a plugin or of a data source
+func (hs *HTTPServer) getStreamPluginContext(user *models.SignedInUser, channel live.PluginChannel) (backend.PluginContext, error) {
+	if channel.Scope == live.ScopePlugin {
+		return hs.getPluginContext(channel.Id, user)
+	}
+
+	datasourceID, err := strconv.ParseInt(channel.Id, 10, 64)
+	if err != nil {
+		return backend.PluginContext{}, live.ErrStreamNotFound
+	}
+
+	ds, err := hs.DatasourceCache.GetDatasource(datasourceID, user, false)
+	if err != nil {
+		if err == models.ErrDataSourceAccessDenied {
+			return backend.PluginContext{}, live.ErrStreamPermissionDenied
+		}
+		return backend.PluginContext{}, err
+	}
+
+	dsInstanceSettings, err := wrapper.ModelToInstanceSettings(ds)
+	if err != nil {
+		return backend.PluginContext{}, errutil.Wrap("Failed to convert datasource", err)
+	}
+
+	return backend.PluginContext{
+		OrgID:                      user.OrgId,
+		PluginID:                   ds.Type,
+		User:                       wrapper.BackendUserFromSignedInUser(user),
+		DataSourceInstanceSettings: dsInstanceSettings,
+	}, nil
+}
+
 func (hs *HTTPServer) GetPluginList(c *models.ReqContext) Response {
 	typeFilter := c.Query("type")
 	enabledFilter := c.Query("enabled")
@@ -79,7 +116,7 @@ func (hs *HTTPServer) GetPluginList(c *models.ReqContext) Response {
 	}
 
 	result := make(dtos.PluginList, 0)
-	for _, pluginDef := range plugins.Plugins {
+	for _, pluginDef := range plugins.GetPlugins() {
 		// filter out app sub plugins
 		if embeddedFilter == "0" && pluginDef.IncludedInAppId != "" {
 			continue
@@ -127,7 +164,7 @@ func (hs *HTTPServer) GetPluginList(c *models.ReqContext) Response {
 		}
 
 		// filter out built in data sources
-		if ds, exists := plugins.DataSources[pluginDef.Id]; exists {
+		if ds, exists := plugins.GetDataSource(pluginDef.Id); exists {
 			if ds.BuiltIn {
 				continue
 			}
@@ -140,10 +177,15 @@ func (hs *HTTPServer) GetPluginList(c *models.ReqContext) Response {
 	return JSON(200, result)
 }
 
+// GetPluginErrorsList returns the plugins that failed to load because of their signature
+func (hs *HTTPServer) GetPluginErrorsList(c *models.ReqContext) Response {
+	return JSON(200, hs.PluginManager.ScanningErrors())
+}
+
 func GetPluginSettingByID(c *models.ReqContext) Response {
 	pluginID := c.Params(":pluginId")
 
-	def, exists := plugins.Plugins[pluginID]
+	def, exists := plugins.GetPlugin(pluginID)
 	if !exists {
 		return Error(404, "Plugin not found, no installed plugin with that id", nil)
 	}
@@ -184,9 +226,12 @@ func UpdatePluginSetting(c *models.ReqContext, cmd models.UpdatePluginSettingCmd
 	cmd.OrgId = c.OrgId
 	cmd.PluginId = pluginID
 
-	if _, ok := plugins.Apps[cmd.PluginId]; !ok {
+	if _, ok := plugins.GetApp(cmd.PluginId); !ok {
 		return Error(404, "Plugin not installed.", nil)
 	}
+	if resp := validateSecretReferences(c.OrgId, cmd.SecureJsonData); resp != nil {
+		return resp
+	}
 
 	if err := bus.Dispatch(&cmd); err != nil {
 		return Error(500, "Failed to update plugin setting", err)
@@ -264,7 +309,7 @@ func ImportDashboard(c *models.ReqContext, apiCmd dtos.ImportDashboardCommand) R
 // /api/plugins/:pluginId/metrics
 func (hs *HTTPServer) CollectPluginMetrics(c *models.ReqContext) Response {
 	pluginID := c.Params("pluginId")
-	plugin, exists := plugins.Plugins[pluginID]
+	plugin, exists := plugins.GetPlugin(pluginID)
 	if !exists {
 		return Error(404, "Plugin not found", nil)
 	}
@@ -364,6 +409,76 @@ func (hs *HTTPServer) getCachedPluginSettings(pluginID string, user *models.Sign
 	return query.Result, nil
 }
 
+// AdminInstallPlugin installs a plugin from the grafana.com catalog
+func (hs *HTTPServer) AdminInstallPlugin(c *models.ReqContext, cmd dtos.InstallPluginCommand) Response {
+	if !hs.Cfg.PluginAdminEnabled {
+		return Error(404, "Plugin management is disabled", nil)
+	}
+
+	result, err := hs.PluginManager.InstallPlugin(c.Params(":pluginId"), cmd.Version)
+	if err != nil {
+		return translatePluginInstallErrorToAPIError("Failed to install plugin", err)
+	}
+
+	return JSON(200, result)
+}
+
+// AdminUpdatePlugin updates an installed plugin from the grafana.com catalog
+func (hs *HTTPServer) AdminUpdatePlugin(c *models.ReqContext, cmd dtos.InstallPluginCommand) Response {
+	if !hs.Cfg.PluginAdminEnabled {
+		return Error(404, "Plugin management is disabled", nil)
+	}
+
+	result, err := hs.PluginManager.UpdatePlugin(c.Params(":pluginId"), cmd.Version)
+	if err != nil {
+		return translatePluginInstallErrorToAPIError("Failed to update plugin", err)
+	}
+
+	return JSON(200, result)
+}
+
+// AdminUninstallPlugin uninstalls a plugin installed in the plugins directory
+func (hs *HTTPServer) AdminUninstallPlugin(c *models.ReqContext) Response {
+	if !hs.Cfg.PluginAdminEnabled {
+		return Error(404, "Plugin management is disabled", nil)
+	}
+
+	result, err := hs.PluginManager.UninstallPlugin(c.Req.Context(), c.Params(":pluginId"))
+	if err != nil {
+		return translatePluginInstallErrorToAPIError("Failed to uninstall plugin", err)
+	}
+
+	return JSON(200, result)
+}
+
+// AdminRestartPlugin restarts the process of a backend plugin
+func (hs *HTTPServer) AdminRestartPlugin(c *models.ReqContext) Response {
+	if err := hs.BackendPluginManager.RestartPlugin(c.Req.Context(), c.Params(":pluginId")); err != nil {
+		return translatePluginRequestErrorToAPIError(err)
+	}
+
+	return Success("Plugin restarted")
+}
+
+func translatePluginInstallErrorToAPIError(message string, err error) Response {
+	var notFound plugins.PluginNotFoundError
+	if errors.As(err, &notFound) || errors.Is(err, plugins.ErrPluginNotInstalled) ||
+		errors.Is(err, plugins.ErrPluginVersionNotFound) {
+		return Error(404, err.Error(), err)
+	}
+
+	if errors.Is(err, plugins.ErrPluginAlreadyInstalled) {
+		return Error(409, err.Error(), err)
+	}
+
+	if errors.Is(err, plugins.ErrPluginNotManaged) || errors.Is(err, plugins.ErrInvalidPluginId) ||
+		errors.Is(err, plugins.ErrPluginVersionUnsupported) {
+		return Error(400, err.Error(), err)
+	}
+
+	return Error(500, message, err)
+}
+
 func translatePluginRequestErrorToAPIError(err error) Response {
 	if errors.Is(err, backendplugin.ErrPluginNotRegistered) {
 		return Error(404, "Plugin not found", err)
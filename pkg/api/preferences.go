@@ -31,13 +31,29 @@ func getPreferencesFor(orgID, userID, teamID int64) Response {
 		return Error(500, "Failed to get preferences", err)
 	}
 
-	dto := dtos.Prefs{
-		Theme:           prefsQuery.Result.Theme,
-		HomeDashboardID: prefsQuery.Result.HomeDashboardId,
-		Timezone:        prefsQuery.Result.Timezone,
+	return JSON(200, prefsToDto(prefsQuery.Result))
+}
+
+func prefsToDto(prefs *models.Preferences) *dtos.Prefs {
+	return &dtos.Prefs{
+		Theme:           prefs.Theme,
+		HomeDashboardID: prefs.HomeDashboardId,
+		Timezone:        prefs.Timezone,
+		WeekStart:       prefs.WeekStart,
+	}
+}
+
+// GetResolvedUserPreferences returns the preferences applying to the user, resolved from the ones
+// of the instance, organization, teams and user
+// GET /api/user/preferences/resolved
+func GetResolvedUserPreferences(c *models.ReqContext) Response {
+	prefsQuery := models.GetPreferencesWithDefaultsQuery{User: c.SignedInUser}
+
+	if err := bus.Dispatch(&prefsQuery); err != nil {
+		return Error(500, "Failed to get preferences", err)
 	}
 
-	return JSON(200, &dto)
+	return JSON(200, prefsToDto(prefsQuery.Result))
 }
 
 // PUT /api/user/preferences
@@ -46,12 +62,17 @@ func UpdateUserPreferences(c *models.ReqContext, dtoCmd dtos.UpdatePrefsCmd) Res
 }
 
 func updatePreferencesFor(orgID, userID, teamId int64, dtoCmd *dtos.UpdatePrefsCmd) Response {
+	if !models.IsValidWeekStart(dtoCmd.WeekStart) {
+		return Error(400, "Invalid week start, expected a day of the week like monday", nil)
+	}
+
 	saveCmd := models.SavePreferencesCommand{
 		UserId:          userID,
 		OrgId:           orgID,
 		TeamId:          teamId,
 		Theme:           dtoCmd.Theme,
 		Timezone:        dtoCmd.Timezone,
+		WeekStart:       dtoCmd.WeekStart,
 		HomeDashboardId: dtoCmd.HomeDashboardID,
 	}
 
@@ -71,3 +92,21 @@ func GetOrgPreferences(c *models.ReqContext) Response {
 func UpdateOrgPreferences(c *models.ReqContext, dtoCmd dtos.UpdatePrefsCmd) Response {
 	return updatePreferencesFor(c.OrgId, 0, 0, &dtoCmd)
 }
+
+// GetInstancePreferences returns the default preferences of all the organizations
+// GET /api/admin/preferences
+func GetInstancePreferences(c *models.ReqContext) Response {
+	return getPreferencesFor(0, 0, 0)
+}
+
+// UpdateInstancePreferences saves the default preferences of all the organizations. The home
+// dashboard belongs to an organization, the one of the instance is set with the
+// default_home_dashboard_path setting instead.
+// PUT /api/admin/preferences
+func UpdateInstancePreferences(c *models.ReqContext, dtoCmd dtos.UpdatePrefsCmd) Response {
+	if dtoCmd.HomeDashboardID != 0 {
+		return Error(400, "The home dashboard can't be set for the instance", nil)
+	}
+
+	return updatePreferencesFor(0, 0, 0, &dtoCmd)
+}
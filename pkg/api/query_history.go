@@ -0,0 +1,166 @@
+package api
+
+import (
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// GET /api/query-history
+func SearchQueryHistory(c *models.ReqContext) Response {
+	query := models.SearchQueryHistoryQuery{
+		OrgId:          c.OrgId,
+		UserId:         c.UserId,
+		DatasourceUids: c.QueryStrings("datasourceUid"),
+		SearchString:   c.Query("searchString"),
+		OnlyStarred:    c.QueryBool("onlyStarred"),
+		Sort:           c.Query("sort"),
+		Page:           c.QueryInt("page"),
+		Limit:          c.QueryInt("limit"),
+	}
+
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to search query history", err)
+	}
+
+	return JSON(200, query.Result)
+}
+
+// POST /api/query-history
+func CreateQueryHistory(c *models.ReqContext, cmd models.CreateQueryHistoryCommand) Response {
+	if len(cmd.Queries.MustArray()) == 0 {
+		return toQueryHistoryError(models.ErrQueryHistoryQueriesEmpty)
+	}
+
+	query := models.GetDataSourcesQuery{OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to query datasources", err)
+	}
+
+	found := false
+	for _, ds := range query.Result {
+		if ds.Uid == cmd.DatasourceUid {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return toQueryHistoryError(models.ErrQueryHistoryDatasourceNotFound)
+	}
+
+	cmd.OrgId = c.OrgId
+	cmd.UserId = c.UserId
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toQueryHistoryError(err)
+	}
+
+	return JSON(200, util.DynMap{
+		"message": "Query added to query history",
+		"uid":     cmd.Result.Uid,
+	})
+}
+
+// PATCH /api/query-history/:uid
+func PatchQueryHistoryComment(c *models.ReqContext, cmd models.PatchQueryHistoryCommentCommand) Response {
+	cmd.Uid = c.Params(":uid")
+	cmd.OrgId = c.OrgId
+	cmd.UserId = c.UserId
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toQueryHistoryError(err)
+	}
+
+	return Success("Query comment updated")
+}
+
+// DELETE /api/query-history/:uid
+func DeleteQueryHistory(c *models.ReqContext) Response {
+	cmd := models.DeleteQueryHistoryCommand{Uid: c.Params(":uid"), OrgId: c.OrgId, UserId: c.UserId}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toQueryHistoryError(err)
+	}
+
+	return Success("Query deleted from query history")
+}
+
+// POST /api/query-history/star/:uid
+func StarQueryHistory(c *models.ReqContext) Response {
+	cmd := models.StarQueryHistoryCommand{Uid: c.Params(":uid"), OrgId: c.OrgId, UserId: c.UserId}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toQueryHistoryError(err)
+	}
+
+	return Success("Query starred")
+}
+
+// DELETE /api/query-history/star/:uid
+func UnstarQueryHistory(c *models.ReqContext) Response {
+	cmd := models.UnstarQueryHistoryCommand{Uid: c.Params(":uid"), OrgId: c.OrgId, UserId: c.UserId}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toQueryHistoryError(err)
+	}
+
+	return Success("Query unstarred")
+}
+
+// POST /api/query-history/:uid/share
+func ShareQueryHistory(c *models.ReqContext, cmd models.ShareQueryHistoryCommand) Response {
+	cmd.Uid = c.Params(":uid")
+	cmd.OrgId = c.OrgId
+	cmd.UserId = c.UserId
+	cmd.IsAdmin = c.OrgRole == models.ROLE_ADMIN
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toQueryHistoryError(err)
+	}
+
+	return Success("Query shared with team")
+}
+
+// DELETE /api/query-history/:uid/share/:teamId
+func UnshareQueryHistory(c *models.ReqContext) Response {
+	cmd := models.UnshareQueryHistoryCommand{
+		Uid:    c.Params(":uid"),
+		TeamId: c.ParamsInt64(":teamId"),
+		OrgId:  c.OrgId,
+		UserId: c.UserId,
+	}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return toQueryHistoryError(err)
+	}
+
+	return Success("Query no longer shared with team")
+}
+
+// POST /api/query-history/migrate
+func MigrateQueryHistory(c *models.ReqContext, cmd models.MigrateQueryHistoryCommand) Response {
+	cmd.OrgId = c.OrgId
+	cmd.UserId = c.UserId
+	if err := bus.Dispatch(&cmd); err != nil {
+		return Error(500, "Failed to migrate query history", err)
+	}
+
+	return JSON(200, util.DynMap{
+		"message":      "Query history successfully migrated",
+		"totalCount":   cmd.TotalCount,
+		"starredCount": cmd.StarredCount,
+		"skippedCount": cmd.SkippedCount,
+	})
+}
+
+func toQueryHistoryError(err error) Response {
+	if err == models.ErrQueryHistoryQueriesEmpty ||
+		err == models.ErrQueryHistoryDatasourceNotFound ||
+		err == models.ErrQueryHistoryAlreadyStarred ||
+		err == models.ErrQueryHistoryTeamNotFound {
+		return Error(400, err.Error(), nil)
+	}
+
+	if err == models.ErrQueryHistoryAccessDenied {
+		return Error(403, err.Error(), nil)
+	}
+
+	if err == models.ErrQueryHistoryNotFound {
+		return Error(404, err.Error(), nil)
+	}
+
+	return Error(500, "Query history error", err)
+}
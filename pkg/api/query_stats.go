@@ -0,0 +1,79 @@
+package api
+
+import (
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/querystats"
+)
+
+// defaultQueryStatsLimit is the number of panels or dashboards reported when no limit is set
+const defaultQueryStatsLimit = 20
+
+type slowPanel struct {
+	*querystats.PanelQueryStats
+	dashboardRef
+}
+
+type slowDashboard struct {
+	*querystats.DashboardQueryStats
+	dashboardRef
+}
+
+// dashboardRef is the dashboard of query stats, empty when it was deleted
+type dashboardRef struct {
+	DashboardUID   string `json:"dashboardUid"`
+	DashboardTitle string `json:"dashboardTitle"`
+	DashboardURL   string `json:"dashboardUrl"`
+}
+
+// GetSlowestPanels returns the panels of the current organization with the longest query durations
+// GET /api/org/query-stats/panels
+func (hs *HTTPServer) GetSlowestPanels(c *models.ReqContext) Response {
+	limit := c.QueryInt("limit")
+	if limit <= 0 {
+		limit = defaultQueryStatsLimit
+	}
+
+	refs := make(map[int64]dashboardRef)
+	result := make([]slowPanel, 0)
+	for _, panel := range hs.QueryStatsService.SlowestPanels(c.OrgId, c.Query("sort"), limit) {
+		result = append(result, slowPanel{PanelQueryStats: panel, dashboardRef: getDashboardRef(c.OrgId, panel.DashboardID, refs)})
+	}
+	return JSON(200, result)
+}
+
+// GetSlowestDashboards returns the dashboards of the current organization with the longest query
+// durations of their panels
+// GET /api/org/query-stats/dashboards
+func (hs *HTTPServer) GetSlowestDashboards(c *models.ReqContext) Response {
+	limit := c.QueryInt("limit")
+	if limit <= 0 {
+		limit = defaultQueryStatsLimit
+	}
+
+	refs := make(map[int64]dashboardRef)
+	result := make([]slowDashboard, 0)
+	for _, dashboard := range hs.QueryStatsService.SlowestDashboards(c.OrgId, c.Query("sort"), limit) {
+		result = append(result, slowDashboard{DashboardQueryStats: dashboard, dashboardRef: getDashboardRef(c.OrgId, dashboard.DashboardID, refs)})
+	}
+	return JSON(200, result)
+}
+
+// getDashboardRef returns the uid, title and url of a dashboard, memoized in refs
+func getDashboardRef(orgID, dashboardID int64, refs map[int64]dashboardRef) dashboardRef {
+	if ref, ok := refs[dashboardID]; ok {
+		return ref
+	}
+
+	var ref dashboardRef
+	query := models.GetDashboardQuery{Id: dashboardID, OrgId: orgID}
+	if err := bus.Dispatch(&query); err == nil {
+		ref = dashboardRef{
+			DashboardUID:   query.Result.Uid,
+			DashboardTitle: query.Result.Title,
+			DashboardURL:   models.GetDashboardUrl(query.Result.Uid, query.Result.Slug),
+		}
+	}
+	refs[dashboardID] = ref
+	return ref
+}
@@ -3,9 +3,7 @@ package api
 import (
 	"fmt"
 	"net/http"
-	"runtime"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/grafana/grafana/pkg/models"
@@ -72,15 +70,6 @@ func (hs *HTTPServer) RenderToPng(c *models.ReqContext) {
 		return
 	}
 
-	if err != nil && err == rendering.ErrPhantomJSNotInstalled {
-		if strings.HasPrefix(runtime.GOARCH, "arm") {
-			c.Handle(500, "Rendering failed - PhantomJS isn't included in arm build per default", err)
-		} else {
-			c.Handle(500, "Rendering failed - PhantomJS isn't installed correctly", err)
-		}
-		return
-	}
-
 	if err != nil {
 		c.Handle(500, "Rendering failed.", err)
 		return
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/reporting"
+)
+
+// GET /api/reports
+func GetReports(c *models.ReqContext) Response {
+	query := models.GetReportsQuery{OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return Error(500, "Failed to get reports", err)
+	}
+
+	return JSON(200, query.Result)
+}
+
+// GET /api/reports/:id
+func GetReport(c *models.ReqContext) Response {
+	query := models.GetReportByIdQuery{Id: c.ParamsInt64(":id"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		if err == models.ErrReportNotFound {
+			return Error(404, "Report not found", err)
+		}
+		return Error(500, "Failed to get report", err)
+	}
+
+	return JSON(200, query.Result)
+}
+
+// POST /api/reports
+func CreateReport(c *models.ReqContext, cmd models.CreateReportCommand) Response {
+	nextRun, rsp := validateReportSettings(c, &cmd.ReportSettings)
+	if rsp != nil {
+		return rsp
+	}
+
+	cmd.OrgId = c.OrgId
+	cmd.UserId = c.UserId
+	cmd.NextRunAt = nextRun
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		if err == models.ErrReportWithSameNameExists {
+			return Error(409, err.Error(), err)
+		}
+		return Error(500, "Failed to create report", err)
+	}
+
+	return JSON(200, cmd.Result)
+}
+
+// PUT /api/reports/:id
+func UpdateReport(c *models.ReqContext, cmd models.UpdateReportCommand) Response {
+	nextRun, rsp := validateReportSettings(c, &cmd.ReportSettings)
+	if rsp != nil {
+		return rsp
+	}
+
+	cmd.Id = c.ParamsInt64(":id")
+	cmd.OrgId = c.OrgId
+	cmd.UserId = c.UserId
+	cmd.NextRunAt = nextRun
+
+	if err := bus.Dispatch(&cmd); err != nil {
+		switch err {
+		case models.ErrReportNotFound:
+			return Error(404, "Report not found", err)
+		case models.ErrReportWithSameNameExists:
+			return Error(409, err.Error(), err)
+		}
+		return Error(500, "Failed to update report", err)
+	}
+
+	return JSON(200, cmd.Result)
+}
+
+// DELETE /api/reports/:id
+func DeleteReport(c *models.ReqContext) Response {
+	cmd := models.DeleteReportCommand{Id: c.ParamsInt64(":id"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&cmd); err != nil {
+		if err == models.ErrReportNotFound {
+			return Error(404, "Report not found", err)
+		}
+		return Error(500, "Failed to delete report", err)
+	}
+
+	return Success("Report deleted")
+}
+
+// POST /api/reports/:id/send
+func (hs *HTTPServer) SendReport(c *models.ReqContext) Response {
+	query := models.GetReportByIdQuery{Id: c.ParamsInt64(":id"), OrgId: c.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		if err == models.ErrReportNotFound {
+			return Error(404, "Report not found", err)
+		}
+		return Error(500, "Failed to get report", err)
+	}
+
+	if err := hs.ReportingService.SendReport(c.Req.Context(), query.Result); err != nil {
+		if err == models.ErrSmtpNotEnabled {
+			return Error(400, "SMTP is not configured, reports cannot be sent", err)
+		}
+		return Error(500, "Failed to send report", err)
+	}
+
+	return Success("Report sent")
+}
+
+// validateReportSettings checks the settings and that the user can view the dashboards of
+// the report, and returns when the report runs next
+func validateReportSettings(c *models.ReqContext, settings *models.ReportSettings) (int64, Response) {
+	nextRun, err := reporting.ValidateSettings(settings, time.Now())
+	if err != nil {
+		return 0, Error(400, err.Error(), nil)
+	}
+
+	for _, uid := range settings.Dashboards {
+		query := models.GetDashboardQuery{Uid: uid, OrgId: c.OrgId}
+		if err := bus.Dispatch(&query); err != nil || query.Result.IsFolder {
+			return 0, Error(400, fmt.Sprintf("Dashboard %s not found", uid), err)
+		}
+
+		g := guardian.New(query.Result.Id, c.OrgId, c.SignedInUser)
+		if canView, err := g.CanView(); err != nil || !canView {
+			return 0, dashboardGuardianResponse(err)
+		}
+	}
+
+	return nextRun.Unix(), nil
+}
@@ -0,0 +1,14 @@
+package api
+
+import (
+	"github.com/grafana/grafana/pkg/components/securejsondata"
+)
+
+// validateSecretReferences returns a bad request response when the secure settings saved for an org
+// reference secrets the org isn't allowed to reference, or secrets that can't be fetched
+func validateSecretReferences(orgID int64, secureSettings map[string]string) Response {
+	if _, err := securejsondata.ResolveReferences(orgID, false, secureSettings); err != nil {
+		return Error(400, "Invalid reference to a secret: "+err.Error(), err)
+	}
+	return nil
+}
@@ -198,6 +198,22 @@ func Static(directory string, staticOpt ...StaticOptions) macaron.Handler {
 	}
 }
 
+// Dynamic returns a middleware handler that serves static files in the directory that directory
+// returns for each request, e.g. for the plugins installed after startup. The prefix is stripped
+// from the request path, and no file is served when the directory is empty.
+func Dynamic(directory func(ctx *macaron.Context) (dir string, prefix string), opt StaticOptions) macaron.Handler {
+	return func(ctx *macaron.Context, log *log.Logger) {
+		dir, prefix := directory(ctx)
+		if dir == "" {
+			return
+		}
+
+		dirOpt := opt
+		dirOpt.Prefix = prefix
+		staticHandler(ctx, log, prepareStaticOption(dir, dirOpt))
+	}
+}
+
 // Statics registers multiple static middleware handlers all at once.
 func Statics(opt StaticOptions, dirs ...string) macaron.Handler {
 	if len(dirs) == 0 {
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"reflect"
+
+	"github.com/opentracing/opentracing-go"
 )
 
 // HandlerFunc defines a handler function interface.
@@ -91,6 +93,13 @@ func (b *InProcBus) DispatchCtx(ctx context.Context, msg Msg) error {
 		return ErrHandlerNotFound
 	}
 
+	// only messages dispatched in a traced request are traced
+	if opentracing.SpanFromContext(ctx) != nil {
+		var span opentracing.Span
+		span, ctx = opentracing.StartSpanFromContext(ctx, "bus "+msgName)
+		defer span.Finish()
+	}
+
 	var params = []reflect.Value{}
 	params = append(params, reflect.ValueOf(ctx))
 	params = append(params, reflect.ValueOf(msg))
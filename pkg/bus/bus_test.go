@@ -5,6 +5,8 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/stretchr/testify/require"
 )
 
@@ -128,3 +130,29 @@ func TestEvent_NoRegisteredListener(t *testing.T) {
 	err := bus.Publish(&testQuery{})
 	require.NoError(t, err, "unable to publish event")
 }
+
+func TestDispatchCtx_Tracing(t *testing.T) {
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	t.Cleanup(func() {
+		opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+	})
+
+	bus := New()
+	bus.AddHandlerCtx(func(ctx context.Context, query *testQuery) error {
+		return nil
+	})
+
+	err := bus.DispatchCtx(context.Background(), &testQuery{})
+	require.NoError(t, err)
+	require.Empty(t, tracer.FinishedSpans(), "expected messages dispatched outside of a trace not to be traced")
+
+	parent := tracer.StartSpan("parent")
+	err = bus.DispatchCtx(opentracing.ContextWithSpan(context.Background(), parent), &testQuery{})
+	require.NoError(t, err)
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "bus testQuery", spans[0].OperationName)
+	require.Equal(t, parent.(*mocktracer.MockSpan).SpanContext.SpanID, spans[0].ParentID)
+}
@@ -9,29 +9,38 @@ import (
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/services"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
 	"github.com/urfave/cli/v2"
 )
 
+func loadConfig(cmd *utils.ContextCommandLine) (*setting.Cfg, error) {
+	cfg := setting.NewCfg()
+
+	configOptions := strings.Split(cmd.String("configOverrides"), " ")
+	if err := cfg.Load(&setting.CommandLineArgs{
+		Config:   cmd.ConfigFile(),
+		HomePath: cmd.HomePath(),
+		Args:     append(configOptions, cmd.Args().Slice()...), // tailing arguments have precedence over the options string
+	}); err != nil {
+		return nil, errutil.Wrap("failed to load configuration", err)
+	}
+
+	if cmd.Bool("debug") {
+		cfg.LogConfigSources()
+	}
+
+	return cfg, nil
+}
+
 func runDbCommand(command func(commandLine utils.CommandLine, sqlStore *sqlstore.SqlStore) error) func(context *cli.Context) error {
 	return func(context *cli.Context) error {
 		cmd := &utils.ContextCommandLine{Context: context}
-		debug := cmd.Bool("debug")
-
-		cfg := setting.NewCfg()
 
-		configOptions := strings.Split(cmd.String("configOverrides"), " ")
-		if err := cfg.Load(&setting.CommandLineArgs{
-			Config:   cmd.ConfigFile(),
-			HomePath: cmd.HomePath(),
-			Args:     append(configOptions, cmd.Args().Slice()...), // tailing arguments have precedence over the options string
-		}); err != nil {
-			return errutil.Wrap("failed to load configuration", err)
-		}
-
-		if debug {
-			cfg.LogConfigSources()
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
 		}
 
 		engine := &sqlstore.SqlStore{}
@@ -50,6 +59,32 @@ func runDbCommand(command func(commandLine utils.CommandLine, sqlStore *sqlstore
 	}
 }
 
+// runMigrationCommand runs a command with the migrator of the database, without running the migrations.
+func runMigrationCommand(command func(commandLine utils.CommandLine, mg *migrator.Migrator) error) func(context *cli.Context) error {
+	return func(context *cli.Context) error {
+		cmd := &utils.ContextCommandLine{Context: context}
+
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		engine := &sqlstore.SqlStore{}
+		engine.Cfg = cfg
+		mg, err := engine.InitMigrator()
+		if err != nil {
+			return errutil.Wrap("failed to initialize SQL engine", err)
+		}
+
+		if err := command(cmd, mg); err != nil {
+			return err
+		}
+
+		logger.Info("\n\n")
+		return nil
+	}
+}
+
 func runPluginCommand(command func(commandLine utils.CommandLine) error) func(context *cli.Context) error {
 	return func(context *cli.Context) error {
 		cmd := &utils.ContextCommandLine{Context: context}
@@ -130,6 +165,32 @@ var adminCommands = []*cli.Command{
 			},
 		},
 	},
+	{
+		Name:  "migrations",
+		Usage: "Inspects and locks the migrations of your db",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "status",
+				Usage:  "Shows the last executed migration, the pending ones and who holds the migration lock",
+				Action: runMigrationCommand(migrationStatusCommand),
+			},
+			{
+				Name:   "dry-run",
+				Usage:  "Lists the pending migrations and their SQL without running them",
+				Action: runMigrationCommand(migrationDryRunCommand),
+			},
+			{
+				Name:   "lock",
+				Usage:  "Takes the migration lock so that no Grafana instance runs migrations until it's released, e.g. during a rollout",
+				Action: runMigrationCommand(migrationLockCommand),
+			},
+			{
+				Name:   "unlock",
+				Usage:  "Releases the migration lock, whoever holds it",
+				Action: runMigrationCommand(migrationUnlockCommand),
+			},
+		},
+	},
 }
 
 var Commands = []*cli.Command{
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"errors"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func migrationStatusCommand(c utils.CommandLine, mg *migrator.Migrator) error {
+	status, err := mg.Status()
+	if err != nil {
+		return err
+	}
+
+	if status.LastMigration != "" {
+		logger.Infof("last migration: %s (executed %s)\n", status.LastMigration, status.LastExecuted.Format(time.RFC3339))
+	}
+	logger.Infof("executed migrations: %d of %d\n", status.Executed, status.Total)
+
+	if len(status.Pending) > 0 {
+		logger.Infof("pending migrations: %s\n", color.YellowString("%d", len(status.Pending)))
+		for _, id := range status.Pending {
+			logger.Infof("  %s\n", id)
+		}
+	} else {
+		logger.Infof("pending migrations: %s\n", color.GreenString("none"))
+	}
+
+	if status.Lock != nil {
+		logger.Infof("migration lock: %s by %s since %s\n", color.YellowString("held"), status.Lock.Holder, status.Lock.Acquired.Format(time.RFC3339))
+	} else {
+		logger.Infof("migration lock: %s\n", color.GreenString("free"))
+	}
+
+	return nil
+}
+
+func migrationDryRunCommand(c utils.CommandLine, mg *migrator.Migrator) error {
+	pending, err := mg.PendingMigrations()
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		logger.Info("no pending migrations\n")
+		return nil
+	}
+
+	logger.Infof("%d pending migrations:\n", len(pending))
+	for _, m := range pending {
+		logger.Infof("\n%s\n", color.YellowString(m.Id()))
+		if _, ok := m.(migrator.CodeMigration); ok {
+			logger.Info("-- code migration\n")
+			continue
+		}
+		logger.Infof("%s\n", m.Sql(mg.Dialect))
+	}
+
+	return nil
+}
+
+func migrationLockCommand(c utils.CommandLine, mg *migrator.Migrator) error {
+	if err := mg.Lock(migrator.LockHolder()); err != nil {
+		if errors.Is(err, migrator.ErrMigrationLocked) {
+			if lock, lockErr := mg.GetLock(); lockErr == nil && lock != nil {
+				logger.Errorf("migrations are already locked by %s since %s\n", lock.Holder, lock.Acquired.Format(time.RFC3339))
+			}
+		}
+		return err
+	}
+
+	logger.Infof("Migrations locked %s\n", color.GreenString("✔"))
+	return nil
+}
+
+func migrationUnlockCommand(c utils.CommandLine, mg *migrator.Migrator) error {
+	if err := mg.Unlock(); err != nil {
+		return err
+	}
+
+	logger.Infof("Migrations unlocked %s\n", color.GreenString("✔"))
+	return nil
+}
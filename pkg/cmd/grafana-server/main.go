@@ -24,12 +24,16 @@ import (
 	_ "github.com/grafana/grafana/pkg/tsdb/cloudwatch"
 	_ "github.com/grafana/grafana/pkg/tsdb/elasticsearch"
 	_ "github.com/grafana/grafana/pkg/tsdb/graphite"
+	_ "github.com/grafana/grafana/pkg/tsdb/httpapi"
 	_ "github.com/grafana/grafana/pkg/tsdb/influxdb"
+	_ "github.com/grafana/grafana/pkg/tsdb/jaeger"
+	_ "github.com/grafana/grafana/pkg/tsdb/loki"
 	_ "github.com/grafana/grafana/pkg/tsdb/mysql"
 	_ "github.com/grafana/grafana/pkg/tsdb/opentsdb"
 	_ "github.com/grafana/grafana/pkg/tsdb/postgres"
 	_ "github.com/grafana/grafana/pkg/tsdb/prometheus"
 	_ "github.com/grafana/grafana/pkg/tsdb/testdatasource"
+	_ "github.com/grafana/grafana/pkg/tsdb/zipkin"
 )
 
 var version = "5.0.0"
@@ -146,6 +150,9 @@ func listenToSystemSignals(server *Server) {
 		select {
 		case <-sighupChan:
 			log.Reload()
+			if err := server.cfg.Reload(); err != nil {
+				server.log.Error("Failed to reload settings", "error", err)
+			}
 		case sig := <-signalChan:
 			server.Shutdown(fmt.Sprintf("System signal: %s", sig))
 		}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/facebookgo/inject"
@@ -22,6 +23,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	_ "github.com/grafana/grafana/pkg/infra/metrics"
 	_ "github.com/grafana/grafana/pkg/infra/remotecache"
+	_ "github.com/grafana/grafana/pkg/infra/scheduler"
 	_ "github.com/grafana/grafana/pkg/infra/serverlock"
 	_ "github.com/grafana/grafana/pkg/infra/tracing"
 	_ "github.com/grafana/grafana/pkg/infra/usagestats"
@@ -31,12 +33,15 @@ import (
 	_ "github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/registry"
 	_ "github.com/grafana/grafana/pkg/services/alerting"
+	_ "github.com/grafana/grafana/pkg/services/annotations/external"
 	_ "github.com/grafana/grafana/pkg/services/auth"
 	_ "github.com/grafana/grafana/pkg/services/cleanup"
 	_ "github.com/grafana/grafana/pkg/services/notifications"
 	_ "github.com/grafana/grafana/pkg/services/provisioning"
+	_ "github.com/grafana/grafana/pkg/services/queryrules"
 	_ "github.com/grafana/grafana/pkg/services/rendering"
 	_ "github.com/grafana/grafana/pkg/services/search"
+	_ "github.com/grafana/grafana/pkg/services/secrets"
 	_ "github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
@@ -165,6 +170,9 @@ func (s *Server) Shutdown(reason string) {
 	s.shutdownReason = reason
 	s.shutdownInProgress = true
 
+	// let the services finish their work in progress before stopping them
+	s.drainServices()
+
 	// call cancel func on root context
 	s.shutdownFn()
 
@@ -174,6 +182,41 @@ func (s *Server) Shutdown(reason string) {
 	}
 }
 
+// drainServices drains the services stage after stage, within the shutdown timeout. The services
+// still busy past the timeout are stopped with their work in progress.
+func (s *Server) drainServices() {
+	stages := map[registry.DrainStage][]*registry.Descriptor{}
+	for _, descriptor := range registry.GetServices() {
+		service, ok := descriptor.Instance.(registry.DrainableService)
+		if !ok || registry.IsDisabled(descriptor.Instance) {
+			continue
+		}
+		stages[service.DrainStage()] = append(stages[service.DrainStage()], descriptor)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+
+	for _, stage := range []registry.DrainStage{registry.DrainRequests, registry.DrainJobs, registry.DrainQueues} {
+		var wg sync.WaitGroup
+		for _, descriptor := range stages[stage] {
+			wg.Add(1)
+			go func(descriptor *registry.Descriptor) {
+				defer wg.Done()
+				s.log.Debug("Draining " + descriptor.Name)
+				if err := descriptor.Instance.(registry.DrainableService).Drain(ctx); err != nil {
+					s.log.Warn("Failed to drain "+descriptor.Name, "err", err)
+				}
+			}(descriptor)
+		}
+		wg.Wait()
+	}
+
+	if ctx.Err() != nil {
+		s.log.Warn("Shutdown timeout exceeded, stopping services with work in progress", "timeout", s.cfg.ShutdownTimeout)
+	}
+}
+
 // ExitCode returns an exit code for a given error.
 func (s *Server) ExitCode(reason error) int {
 	code := 1
@@ -0,0 +1,63 @@
+package dataexport
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// csvTimeFormat keeps milliseconds and the offset of the time zone
+const csvTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// writeCsv writes the frames one after the other, separated by an empty line
+func writeCsv(w io.Writer, tables []*table, location *time.Location) error {
+	writer := csv.NewWriter(w)
+
+	for i, t := range tables {
+		if i > 0 {
+			writer.Flush()
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+
+		record := make([]string, len(t.columns))
+		for j, c := range t.columns {
+			record[j] = c.name
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+
+		for row := 0; row < t.rows; row++ {
+			for j, c := range t.columns {
+				record[j] = csvValue(c.value(row), location)
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func csvValue(value interface{}, location *time.Location) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.In(location).Format(csvTimeFormat)
+	}
+	return ""
+}
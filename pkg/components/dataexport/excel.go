@@ -0,0 +1,207 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	excelMaxRows       = 1048576
+	excelMaxSheetName  = 31
+	excelDateStyle     = 1
+	excelHeaderStyle   = 2
+	spreadsheetMLSpace = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	relationshipsSpace = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+)
+
+// excelEpoch is day 0 of the 1900 date system, taking the 1900 leap year bug into account
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+var excelSheetNameReplacer = strings.NewReplacer("[", "(", "]", ")", ":", "-", "*", "-", "?", "-", "/", "-", "\\", "-")
+
+const excelStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="` + spreadsheetMLSpace + `">
+<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>
+</styleSheet>`
+
+const excelRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="` + relationshipsSpace + `/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+// writeExcel writes an Office Open XML workbook with a sheet per frame
+func writeExcel(w io.Writer, tables []*table, location *time.Location) error {
+	archive := zip.NewWriter(w)
+
+	names := excelSheetNames(tables)
+
+	var contentTypes, workbook, workbookRels strings.Builder
+	contentTypes.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+`)
+	workbook.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="` + spreadsheetMLSpace + `" xmlns:r="` + relationshipsSpace + `"><sheets>`)
+	workbookRels.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+`)
+
+	for i, name := range names {
+		id := i + 1
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`+"\n", id)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(name), id, id)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="%s/worksheet" Target="worksheets/sheet%d.xml"/>`+"\n", id, relationshipsSpace, id)
+	}
+
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="%s/styles" Target="styles.xml"/>`+"\n</Relationships>", len(names)+1, relationshipsSpace)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", excelRootRels},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		{"xl/styles.xml", excelStyles},
+	}
+
+	for _, file := range files {
+		fw, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, file.content); err != nil {
+			return err
+		}
+	}
+
+	for i, t := range tables {
+		fw, err := archive.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return err
+		}
+		if err := writeExcelSheet(fw, t, location); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+func writeExcelSheet(w io.Writer, t *table, location *time.Location) error {
+	buf := bufio.NewWriter(w)
+
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	buf.WriteString(`<worksheet xmlns="` + spreadsheetMLSpace + `"><sheetData>`)
+
+	buf.WriteString(`<row r="1">`)
+	for col, c := range t.columns {
+		fmt.Fprintf(buf, `<c r="%s1" s="%d" t="inlineStr"><is><t>%s</t></is></c>`, excelColumn(col), excelHeaderStyle, xmlEscape(c.name))
+	}
+	buf.WriteString(`</row>`)
+
+	for row := 0; row < t.rows; row++ {
+		r := strconv.Itoa(row + 2)
+		fmt.Fprintf(buf, `<row r="%s">`, r)
+
+		for col, c := range t.columns {
+			ref := excelColumn(col) + r
+
+			switch v := c.value(row).(type) {
+			case string:
+				fmt.Fprintf(buf, `<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, xmlEscape(v))
+			case float64:
+				// Excel has no representation for NaN and infinity
+				if !math.IsNaN(v) && !math.IsInf(v, 0) {
+					fmt.Fprintf(buf, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'g', -1, 64))
+				}
+			case int64:
+				fmt.Fprintf(buf, `<c r="%s"><v>%d</v></c>`, ref, v)
+			case bool:
+				value := 0
+				if v {
+					value = 1
+				}
+				fmt.Fprintf(buf, `<c r="%s" t="b"><v>%d</v></c>`, ref, value)
+			case time.Time:
+				fmt.Fprintf(buf, `<c r="%s" s="%d"><v>%s</v></c>`, ref, excelDateStyle, strconv.FormatFloat(excelTime(v, location), 'f', -1, 64))
+			}
+		}
+
+		buf.WriteString(`</row>`)
+	}
+
+	buf.WriteString(`</sheetData></worksheet>`)
+	return buf.Flush()
+}
+
+// excelTime returns the serial date of the time in location. Excel dates have no
+// time zone, so the wall clock time in location is used.
+func excelTime(t time.Time, location *time.Location) float64 {
+	local := t.In(location)
+	wall := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), time.UTC)
+	return float64(wall.Sub(excelEpoch)/time.Millisecond) / float64(24*time.Hour/time.Millisecond)
+}
+
+// excelColumn returns the letters of the column, A to Z, AA to ZZ and so on
+func excelColumn(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// excelSheetNames returns unique sheet names of at most 31 characters without the
+// characters Excel does not allow
+func excelSheetNames(tables []*table) []string {
+	names := make([]string, len(tables))
+	used := make(map[string]bool)
+
+	for i, t := range tables {
+		base := []rune(excelSheetNameReplacer.Replace(t.name))
+		if len(base) > excelMaxSheetName {
+			base = base[:excelMaxSheetName]
+		}
+
+		name := string(base)
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			suffix := []rune(fmt.Sprintf(" (%d)", n))
+			if len(base)+len(suffix) > excelMaxSheetName {
+				name = string(base[:excelMaxSheetName-len(suffix)]) + string(suffix)
+			} else {
+				name = string(base) + string(suffix)
+			}
+		}
+
+		used[strings.ToLower(name)] = true
+		names[i] = name
+	}
+
+	return names
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,212 @@
+// Package dataexport writes data frames as CSV, Excel or Parquet files.
+package dataexport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	FormatCsv     = "csv"
+	FormatExcel   = "xlsx"
+	FormatParquet = "parquet"
+)
+
+var (
+	ErrUnknownFormat  = errors.New("Unknown export format")
+	ErrMultipleFrames = errors.New("Parquet exports can only contain a single frame")
+	ErrTooManyRows    = errors.New("Too many rows for an Excel sheet")
+)
+
+var contentTypes = map[string]string{
+	FormatCsv:     "text/csv; charset=utf-8",
+	FormatExcel:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FormatParquet: "application/vnd.apache.parquet",
+}
+
+// ContentType returns the content type of the format
+func ContentType(format string) (string, error) {
+	contentType, ok := contentTypes[format]
+	if !ok {
+		return "", ErrUnknownFormat
+	}
+	return contentType, nil
+}
+
+// Validate checks that the frames can be exported in the format before anything is written
+func Validate(format string, frames data.Frames) error {
+	if _, err := ContentType(format); err != nil {
+		return err
+	}
+
+	if format == FormatParquet && len(frames) > 1 {
+		return ErrMultipleFrames
+	}
+
+	if format == FormatExcel {
+		for _, frame := range frames {
+			if frameRows(frame) >= excelMaxRows {
+				return ErrTooManyRows
+			}
+		}
+	}
+
+	return nil
+}
+
+// Write writes the frames to w in the format. Times are written in location, except for
+// Parquet where timestamps are always in UTC.
+func Write(w io.Writer, format string, frames data.Frames, location *time.Location) error {
+	if err := Validate(format, frames); err != nil {
+		return err
+	}
+
+	if location == nil {
+		location = time.UTC
+	}
+
+	tables := make([]*table, len(frames))
+	for i, frame := range frames {
+		tables[i] = newTable(frame, i)
+	}
+
+	switch format {
+	case FormatCsv:
+		return writeCsv(w, tables, location)
+	case FormatExcel:
+		return writeExcel(w, tables, location)
+	default:
+		return writeParquet(w, tables)
+	}
+}
+
+type columnType int
+
+const (
+	columnString columnType = iota
+	columnFloat
+	columnInt
+	columnBool
+	columnTime
+)
+
+type column struct {
+	name  string
+	typ   columnType
+	field *data.Field
+}
+
+// value returns the value of the row as string, float64, int64, bool, time.Time or nil
+func (c *column) value(row int) interface{} {
+	if row >= c.field.Len() {
+		return nil
+	}
+
+	v := reflect.ValueOf(c.field.At(row))
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.String:
+		return v.String()
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		return t
+	}
+
+	return fmt.Sprint(v.Interface())
+}
+
+type table struct {
+	name    string
+	columns []*column
+	rows    int
+}
+
+func newTable(frame *data.Frame, index int) *table {
+	t := &table{
+		name: frame.Name,
+		rows: frameRows(frame),
+	}
+
+	if t.name == "" {
+		t.name = frame.RefID
+	}
+	if t.name == "" {
+		t.name = fmt.Sprintf("Frame %d", index+1)
+	}
+
+	names := make(map[string]int)
+	for i, field := range frame.Fields {
+		name := field.Name
+		if name == "" {
+			name = fmt.Sprintf("Field %d", i+1)
+		}
+		if len(field.Labels) > 0 {
+			name = name + " " + field.Labels.String()
+		}
+
+		// column names have to be unique in Parquet files
+		names[name]++
+		if names[name] > 1 {
+			name = fmt.Sprintf("%s %d", name, names[name])
+		}
+
+		t.columns = append(t.columns, &column{name: name, typ: fieldColumnType(field), field: field})
+	}
+
+	return t
+}
+
+func frameRows(frame *data.Frame) int {
+	rows := 0
+	for _, field := range frame.Fields {
+		if field.Len() > rows {
+			rows = field.Len()
+		}
+	}
+	return rows
+}
+
+func fieldColumnType(field *data.Field) columnType {
+	switch field.Type() {
+	case data.FieldTypeTime, data.FieldTypeNullableTime:
+		return columnTime
+	case data.FieldTypeBool, data.FieldTypeNullableBool:
+		return columnBool
+	case data.FieldTypeString, data.FieldTypeNullableString:
+		return columnString
+	case data.FieldTypeFloat32, data.FieldTypeNullableFloat32,
+		data.FieldTypeFloat64, data.FieldTypeNullableFloat64:
+		return columnFloat
+	case data.FieldTypeInt8, data.FieldTypeNullableInt8,
+		data.FieldTypeInt16, data.FieldTypeNullableInt16,
+		data.FieldTypeInt32, data.FieldTypeNullableInt32,
+		data.FieldTypeInt64, data.FieldTypeNullableInt64,
+		data.FieldTypeUint8, data.FieldTypeNullableUint8,
+		data.FieldTypeUint16, data.FieldTypeNullableUint16,
+		data.FieldTypeUint32, data.FieldTypeNullableUint32,
+		data.FieldTypeUint64, data.FieldTypeNullableUint64:
+		return columnInt
+	}
+
+	return columnString
+}
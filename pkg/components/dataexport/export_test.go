@@ -0,0 +1,179 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+func testFrames() data.Frames {
+	t1 := time.Date(2020, 9, 13, 12, 26, 40, 0, time.UTC)
+	t2 := t1.Add(time.Minute)
+	v1 := 1.5
+	host := "web,1"
+
+	return data.Frames{
+		data.NewFrame("requests",
+			data.NewField("time", nil, []*time.Time{&t1, &t2}),
+			data.NewField("value", nil, []*float64{&v1, nil}),
+			data.NewField("host", nil, []*string{&host, nil}),
+			data.NewField("count", nil, []int64{3, 4}),
+		),
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Run("Writes csv with times in the location", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := Write(&buf, FormatCsv, testFrames(), time.FixedZone("CET", 3600))
+		require.NoError(t, err)
+		require.Equal(t, `time,value,host,count
+2020-09-13T13:26:40.000+01:00,1.5,"web,1",3
+2020-09-13T13:27:40.000+01:00,,,4
+`, buf.String())
+	})
+
+	t.Run("Separates frames in csv with an empty line", func(t *testing.T) {
+		frames := append(testFrames(), data.NewFrame("B", data.NewField("value", nil, []int64{1})))
+
+		var buf bytes.Buffer
+		err := Write(&buf, FormatCsv, frames, nil)
+		require.NoError(t, err)
+		require.Contains(t, buf.String(), "\n\nvalue\n1\n")
+	})
+
+	t.Run("Writes an Excel workbook with a sheet per frame", func(t *testing.T) {
+		frames := append(testFrames(), data.NewFrame("requests", data.NewField("value", nil, []int64{1})))
+
+		var buf bytes.Buffer
+		err := Write(&buf, FormatExcel, frames, time.UTC)
+		require.NoError(t, err)
+
+		archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+
+		files := make(map[string]string)
+		for _, file := range archive.File {
+			r, err := file.Open()
+			require.NoError(t, err)
+			content, err := ioutil.ReadAll(r)
+			require.NoError(t, err)
+			files[file.Name] = string(content)
+		}
+
+		require.Contains(t, files["xl/workbook.xml"], `<sheet name="requests" sheetId="1" r:id="rId1"/>`)
+		require.Contains(t, files["xl/workbook.xml"], `<sheet name="requests (2)" sheetId="2" r:id="rId2"/>`)
+		require.Contains(t, files["xl/worksheets/sheet1.xml"], `<c r="A2" s="1"><v>44087.51851851852</v></c>`)
+		require.Contains(t, files["xl/worksheets/sheet1.xml"], `<c r="C2" t="inlineStr"><is><t>web,1</t></is></c>`)
+		require.Contains(t, files["xl/worksheets/sheet2.xml"], `<c r="A2"><v>1</v></c>`)
+	})
+
+	t.Run("Writes a Parquet file", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := Write(&buf, FormatParquet, testFrames(), nil)
+		require.NoError(t, err)
+
+		b := buf.Bytes()
+		require.Equal(t, parquetMagic, string(b[:4]))
+		require.Equal(t, parquetMagic, string(b[len(b)-4:]))
+
+		footerLength := int(binary.LittleEndian.Uint32(b[len(b)-8 : len(b)-4]))
+		footer := b[len(b)-8-footerLength : len(b)-8]
+		require.Contains(t, string(footer), "host")
+		require.Contains(t, string(footer), "grafana")
+	})
+
+	t.Run("Parquet files can only contain a single frame", func(t *testing.T) {
+		frames := append(testFrames(), testFrames()...)
+		err := Write(&bytes.Buffer{}, FormatParquet, frames, nil)
+		require.Equal(t, ErrMultipleFrames, err)
+	})
+
+	t.Run("Unknown formats are rejected", func(t *testing.T) {
+		err := Write(&bytes.Buffer{}, "pdf", testFrames(), nil)
+		require.Equal(t, ErrUnknownFormat, err)
+	})
+}
+
+// TestParquetRoundTrip reads the Parquet files back with another implementation of the format
+func TestParquetRoundTrip(t *testing.T) {
+	frames := testFrames()
+	frames[0].Fields = append(frames[0].Fields, data.NewField("up", nil, []*bool{nil, boolPtr(true)}))
+
+	var buf bytes.Buffer
+	err := Write(&buf, FormatParquet, frames, nil)
+	require.NoError(t, err)
+
+	file, err := buffer.NewBufferFile(buf.Bytes())
+	require.NoError(t, err)
+	pr, err := reader.NewParquetColumnReader(file, 1)
+	require.NoError(t, err)
+	defer pr.ReadStop()
+
+	require.Equal(t, int64(2), pr.GetNumRows())
+
+	// the reader renames the elements of the schema, their names in the file are kept apart
+	schema := pr.Footer.Schema
+	require.Len(t, schema, 6)
+	require.Equal(t, "schema", pr.SchemaHandler.Infos[0].ExName)
+	expected := []struct {
+		name      string
+		physical  parquet.Type
+		converted *parquet.ConvertedType
+	}{
+		{"time", parquet.Type_INT64, parquet.ConvertedTypePtr(parquet.ConvertedType_TIMESTAMP_MILLIS)},
+		{"value", parquet.Type_DOUBLE, nil},
+		{"host", parquet.Type_BYTE_ARRAY, parquet.ConvertedTypePtr(parquet.ConvertedType_UTF8)},
+		{"count", parquet.Type_INT64, nil},
+		{"up", parquet.Type_BOOLEAN, nil},
+	}
+	for i, e := range expected {
+		element := schema[i+1]
+		require.Equal(t, e.name, pr.SchemaHandler.Infos[i+1].ExName)
+		require.Equal(t, e.physical, element.GetType())
+		require.Equal(t, parquet.FieldRepetitionType_OPTIONAL, element.GetRepetitionType())
+		require.Equal(t, e.converted, element.ConvertedType)
+	}
+
+	columns := make([][]interface{}, len(expected))
+	for i := range expected {
+		values, _, _, err := pr.ReadColumnByIndex(int64(i), 2)
+		require.NoError(t, err)
+		columns[i] = values
+	}
+
+	t1 := time.Date(2020, 9, 13, 12, 26, 40, 0, time.UTC)
+	require.Equal(t, []interface{}{t1.UnixNano() / int64(time.Millisecond), t1.Add(time.Minute).UnixNano() / int64(time.Millisecond)}, columns[0])
+	require.Equal(t, []interface{}{1.5, nil}, columns[1])
+	require.Equal(t, []interface{}{"web,1", nil}, columns[2])
+	require.Equal(t, []interface{}{int64(3), int64(4)}, columns[3])
+	require.Equal(t, []interface{}{nil, true}, columns[4])
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestParquetLevels(t *testing.T) {
+	levels := parquetLevels([]bool{true, true, true, false, true})
+	require.Equal(t, []byte{3 << 1, 1, 1 << 1, 0, 1 << 1, 1}, levels)
+}
+
+func TestExcelHelpers(t *testing.T) {
+	require.Equal(t, "A", excelColumn(0))
+	require.Equal(t, "Z", excelColumn(25))
+	require.Equal(t, "AA", excelColumn(26))
+	require.Equal(t, "BA", excelColumn(52))
+
+	names := excelSheetNames([]*table{{name: "a/b: [c]"}, {name: "A/B: [C]"}})
+	require.Equal(t, []string{"a-b- (c)", "A-B- (C) (2)"}, names)
+}
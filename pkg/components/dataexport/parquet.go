@@ -0,0 +1,270 @@
+package dataexport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+)
+
+// See https://github.com/apache/parquet-format/blob/master/src/main/thrift/parquet.thrift
+const (
+	parquetMagic = "PAR1"
+
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetConvertedUtf8            = 0
+	parquetConvertedTimestampMillis = 9
+
+	parquetOptional = 1
+
+	parquetEncodingPlain = 0
+	parquetEncodingRle   = 3
+
+	parquetUncompressed = 0
+	parquetDataPage     = 0
+)
+
+type parquetColumnChunk struct {
+	offset int64
+	size   int64
+}
+
+// countingWriter keeps track of the offsets in the file
+type countingWriter struct {
+	w     io.Writer
+	count int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.count += int64(n)
+	return n, err
+}
+
+// writeParquet writes an uncompressed Parquet file with a single row group. Every column
+// is optional and has a single page with plain encoded values.
+func writeParquet(w io.Writer, tables []*table) error {
+	t := &table{}
+	if len(tables) > 0 {
+		t = tables[0]
+	}
+
+	out := &countingWriter{w: w}
+	if _, err := io.WriteString(out, parquetMagic); err != nil {
+		return err
+	}
+
+	chunks := make([]parquetColumnChunk, len(t.columns))
+	for i, c := range t.columns {
+		page := parquetPage(c, t.rows)
+
+		header := &thriftWriter{}
+		header.structValue(func() {
+			header.i32(1, parquetDataPage)
+			header.i32(2, int32(len(page)))
+			header.i32(3, int32(len(page)))
+			header.structField(5, func() {
+				header.i32(1, int32(t.rows))
+				header.i32(2, parquetEncodingPlain)
+				header.i32(3, parquetEncodingRle)
+				header.i32(4, parquetEncodingRle)
+			})
+		})
+
+		chunks[i].offset = out.count
+		if _, err := out.Write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if _, err := out.Write(page); err != nil {
+			return err
+		}
+		chunks[i].size = out.count - chunks[i].offset
+	}
+
+	footer := parquetFooter(t, chunks)
+	if _, err := out.Write(footer); err != nil {
+		return err
+	}
+
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+	if _, err := out.Write(length[:]); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(out, parquetMagic)
+	return err
+}
+
+// parquetPage returns the definition levels followed by the values that are not null
+func parquetPage(c *column, rows int) []byte {
+	levels := make([]bool, rows)
+	var values bytes.Buffer
+	var bits []bool
+
+	for row := 0; row < rows; row++ {
+		value := c.value(row)
+		if value == nil {
+			continue
+		}
+
+		switch c.typ {
+		case columnString:
+			s := csvValue(value, time.UTC)
+			_ = binary.Write(&values, binary.LittleEndian, uint32(len(s)))
+			values.WriteString(s)
+		case columnFloat:
+			f, ok := value.(float64)
+			if !ok {
+				continue
+			}
+			_ = binary.Write(&values, binary.LittleEndian, math.Float64bits(f))
+		case columnInt:
+			n, ok := value.(int64)
+			if !ok {
+				continue
+			}
+			_ = binary.Write(&values, binary.LittleEndian, n)
+		case columnBool:
+			b, ok := value.(bool)
+			if !ok {
+				continue
+			}
+			bits = append(bits, b)
+		case columnTime:
+			ts, ok := value.(time.Time)
+			if !ok {
+				continue
+			}
+			_ = binary.Write(&values, binary.LittleEndian, ts.UnixNano()/int64(time.Millisecond))
+		}
+
+		levels[row] = true
+	}
+
+	// booleans are bit packed, least significant bit first
+	if c.typ == columnBool {
+		packed := make([]byte, (len(bits)+7)/8)
+		for i, b := range bits {
+			if b {
+				packed[i/8] |= 1 << uint(i%8)
+			}
+		}
+		values.Write(packed)
+	}
+
+	encoded := parquetLevels(levels)
+
+	var page bytes.Buffer
+	_ = binary.Write(&page, binary.LittleEndian, uint32(len(encoded)))
+	page.Write(encoded)
+	page.Write(values.Bytes())
+	return page.Bytes()
+}
+
+// parquetLevels encodes the definition levels as RLE runs with a bit width of 1
+func parquetLevels(levels []bool) []byte {
+	var buf bytes.Buffer
+	var varint [binary.MaxVarintLen64]byte
+
+	for start := 0; start < len(levels); {
+		end := start
+		for end < len(levels) && levels[end] == levels[start] {
+			end++
+		}
+
+		n := binary.PutUvarint(varint[:], uint64(end-start)<<1)
+		buf.Write(varint[:n])
+		if levels[start] {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+
+		start = end
+	}
+
+	return buf.Bytes()
+}
+
+func parquetType(typ columnType) (int32, int32) {
+	switch typ {
+	case columnFloat:
+		return parquetDouble, -1
+	case columnInt:
+		return parquetInt64, -1
+	case columnBool:
+		return parquetBoolean, -1
+	case columnTime:
+		return parquetInt64, parquetConvertedTimestampMillis
+	}
+	return parquetByteArray, parquetConvertedUtf8
+}
+
+func parquetFooter(t *table, chunks []parquetColumnChunk) []byte {
+	w := &thriftWriter{}
+
+	var totalSize int64
+	for _, chunk := range chunks {
+		totalSize += chunk.size
+	}
+
+	w.structValue(func() {
+		w.i32(1, 1)
+
+		w.list(2, thriftStruct, len(t.columns)+1)
+		w.structValue(func() {
+			w.binary(4, "schema")
+			w.i32(5, int32(len(t.columns)))
+		})
+		for _, c := range t.columns {
+			physical, converted := parquetType(c.typ)
+			w.structValue(func() {
+				w.i32(1, physical)
+				w.i32(3, parquetOptional)
+				w.binary(4, c.name)
+				if converted >= 0 {
+					w.i32(6, converted)
+				}
+			})
+		}
+
+		w.i64(3, int64(t.rows))
+
+		w.list(4, thriftStruct, 1)
+		w.structValue(func() {
+			w.list(1, thriftStruct, len(t.columns))
+			for i, c := range t.columns {
+				physical, _ := parquetType(c.typ)
+				chunk := chunks[i]
+				w.structValue(func() {
+					w.i64(2, chunk.offset)
+					w.structField(3, func() {
+						w.i32(1, physical)
+						w.list(2, thriftI32, 2)
+						w.varint(parquetEncodingPlain)
+						w.varint(parquetEncodingRle)
+						w.list(3, thriftBinary, 1)
+						w.string(c.name)
+						w.i32(4, parquetUncompressed)
+						w.i64(5, int64(t.rows))
+						w.i64(6, chunk.size)
+						w.i64(7, chunk.size)
+						w.i64(9, chunk.offset)
+					})
+				})
+			}
+			w.i64(2, totalSize)
+			w.i64(3, int64(t.rows))
+		})
+
+		w.binary(6, "grafana")
+	})
+
+	return w.buf.Bytes()
+}
@@ -0,0 +1,87 @@
+package encryption
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// envelopePrefix starts the payloads encrypted with a data key, followed by the name of the data
+// key and another envelopePrefix. Payloads encrypted with the secret_key start with an
+// alphanumeric salt instead.
+const envelopePrefix = '#'
+
+var (
+	// ErrEnvelopeUnavailable error returned when decrypting a payload encrypted with a data key
+	// while no envelope is set.
+	ErrEnvelopeUnavailable = errors.New("Envelope encryption is not available")
+	// ErrInvalidEnvelope error returned when a payload encrypted with a data key is malformed.
+	ErrInvalidEnvelope = errors.New("Invalid envelope encrypted payload")
+)
+
+// Envelope encrypts and decrypts the secrets stored in the database with data keys.
+type Envelope interface {
+	Encrypt(payload []byte) ([]byte, error)
+	Decrypt(payload []byte) ([]byte, error)
+}
+
+var envelope Envelope
+
+// SetEnvelope sets the envelope used to encrypt and decrypt the secrets stored in the database.
+func SetEnvelope(e Envelope) {
+	envelope = e
+}
+
+// Encrypt encrypts a secret stored in the database, with a data key when an envelope is set and
+// with the secret_key otherwise.
+func Encrypt(payload []byte) ([]byte, error) {
+	if envelope != nil {
+		return envelope.Encrypt(payload)
+	}
+
+	return util.Encrypt(payload, setting.SecretKey)
+}
+
+// Decrypt decrypts a secret stored in the database, whether it's encrypted with a data key or
+// with the secret_key.
+func Decrypt(payload []byte) ([]byte, error) {
+	if !IsEnvelope(payload) {
+		return util.Decrypt(payload, setting.SecretKey)
+	}
+
+	if envelope == nil {
+		return nil, ErrEnvelopeUnavailable
+	}
+	return envelope.Decrypt(payload)
+}
+
+// IsEnvelope returns true if a payload is encrypted with a data key.
+func IsEnvelope(payload []byte) bool {
+	return len(payload) > 0 && payload[0] == envelopePrefix
+}
+
+// WrapEnvelope returns the payload of a ciphertext encrypted with the data key named keyName.
+func WrapEnvelope(keyName string, ciphertext []byte) []byte {
+	payload := make([]byte, 0, len(keyName)+2+len(ciphertext))
+	payload = append(payload, envelopePrefix)
+	payload = append(payload, keyName...)
+	payload = append(payload, envelopePrefix)
+	return append(payload, ciphertext...)
+}
+
+// UnwrapEnvelope returns the name of the data key and the ciphertext of a payload encrypted with
+// a data key.
+func UnwrapEnvelope(payload []byte) (string, []byte, error) {
+	if !IsEnvelope(payload) {
+		return "", nil, ErrInvalidEnvelope
+	}
+
+	end := bytes.IndexByte(payload[1:], envelopePrefix)
+	if end <= 0 {
+		return "", nil, ErrInvalidEnvelope
+	}
+
+	return string(payload[1 : end+1]), payload[end+2:], nil
+}
@@ -0,0 +1,41 @@
+package encryption
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvelope(t *testing.T) {
+	t.Run("Should wrap and unwrap envelopes", func(t *testing.T) {
+		payload := WrapEnvelope("key1", []byte("ciphertext"))
+		require.True(t, IsEnvelope(payload))
+
+		keyName, ciphertext, err := UnwrapEnvelope(payload)
+		require.NoError(t, err)
+		require.Equal(t, "key1", keyName)
+		require.Equal(t, "ciphertext", string(ciphertext))
+
+		for _, invalid := range []string{"", "key1", "#key1", "##ciphertext"} {
+			_, _, err := UnwrapEnvelope([]byte(invalid))
+			require.Equal(t, ErrInvalidEnvelope, err, invalid)
+		}
+	})
+
+	t.Run("Should decrypt secrets encrypted with the secret_key", func(t *testing.T) {
+		encrypted, err := util.Encrypt([]byte("secret"), setting.SecretKey)
+		require.NoError(t, err)
+		require.False(t, IsEnvelope(encrypted))
+
+		decrypted, err := Decrypt(encrypted)
+		require.NoError(t, err)
+		require.Equal(t, "secret", string(decrypted))
+	})
+
+	t.Run("Should not decrypt envelopes without an envelope", func(t *testing.T) {
+		_, err := Decrypt(WrapEnvelope("key1", []byte("ciphertext")))
+		require.Equal(t, ErrEnvelopeUnavailable, err)
+	})
+}
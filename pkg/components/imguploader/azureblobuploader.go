@@ -29,6 +29,10 @@ type AzureBlobUploader struct {
 	account_key    string
 	container_name string
 	log            log.Logger
+
+	// signedURLExpiry is how long the shared access signatures of the URLs of the images are valid,
+	// 0 for public containers
+	signedURLExpiry time.Duration
 }
 
 func NewAzureBlobUploader(account_name string, account_key string, container_name string) *AzureBlobUploader {
@@ -78,13 +82,56 @@ func (az *AzureBlobUploader) Upload(ctx context.Context, imageDiskPath string) (
 	}
 
 	url := fmt.Sprintf("https://%s.blob.core.windows.net/%s/%s", az.account_name, az.container_name, randomFileName)
+	if az.signedURLExpiry > 0 {
+		sas, err := blob.Auth.BlobSAS(az.container_name, randomFileName, time.Now().Add(az.signedURLExpiry))
+		if err != nil {
+			return "", err
+		}
+		url += "?" + sas
+	}
 	return url, nil
 }
 
+// DeleteImagesBefore deletes the images uploaded to the container before a time.
+func (az *AzureBlobUploader) DeleteImagesBefore(ctx context.Context, before time.Time) (int, error) {
+	blob := NewStorageClient(az.account_name, az.account_key)
+
+	var expired []string
+	marker := ""
+	for {
+		blobs, err := blob.ListBlobs(ctx, az.container_name, marker)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, item := range blobs.Items {
+			lastModified, err := time.Parse(ms_date_layout, item.Property.LastModified)
+			if err != nil {
+				continue
+			}
+			if isUploadedImage("", item.Name) && lastModified.Before(before) {
+				expired = append(expired, item.Name)
+			}
+		}
+		if blobs.NextMarker == "" {
+			break
+		}
+		marker = blobs.NextMarker
+	}
+
+	for i, name := range expired {
+		if err := blob.DeleteBlob(ctx, az.container_name, name); err != nil {
+			return i, err
+		}
+	}
+	return len(expired), nil
+}
+
 // --- AZURE LIBRARY
 type Blobs struct {
-	XMLName xml.Name `xml:"EnumerationResults"`
-	Items   []Blob   `xml:"Blobs>Blob"`
+	XMLName    xml.Name `xml:"EnumerationResults"`
+	Items      []Blob   `xml:"Blobs>Blob"`
+	NextMarker string   `xml:"NextMarker"`
 }
 
 type Blob struct {
@@ -198,6 +245,71 @@ func (c *StorageClient) FileUpload(ctx context.Context, container, blobName stri
 	return c.transport().RoundTrip(req)
 }
 
+// ListBlobs returns a page of the blobs of a container, starting at marker
+func (c *StorageClient) ListBlobs(ctx context.Context, container, marker string) (*Blobs, error) {
+	params := url.Values{}
+	params.Set("restype", "container")
+	params.Set("comp", "list")
+	if marker != "" {
+		params.Set("marker", marker)
+	}
+
+	resp, err := c.do(ctx, "GET", c.absUrl("%s?%s", container, params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	blobs := &Blobs{}
+	if err := xml.NewDecoder(resp.Body).Decode(blobs); err != nil {
+		return nil, err
+	}
+	return blobs, nil
+}
+
+// DeleteBlob deletes a blob of a container
+func (c *StorageClient) DeleteBlob(ctx context.Context, container, blobName string) error {
+	resp, err := c.do(ctx, "DELETE", c.absUrl("%s/%s", container, escape(blobName)))
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// do sends a signed request without body, returning an *Error for the failed requests
+func (c *StorageClient) do(ctx context.Context, method, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	copyHeadersToRequest(req, map[string]string{
+		"x-ms-date":    time.Now().UTC().Format(ms_date_layout),
+		"x-ms-version": version,
+	})
+	if err := c.Auth.SignRequest(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.transport().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+		aerr := &Error{
+			Code:   resp.StatusCode,
+			Status: resp.Status,
+			Body:   body,
+			Header: resp.Header,
+		}
+		aerr.parseXML()
+		return nil, aerr
+	}
+	return resp, nil
+}
+
 func escape(content string) string {
 	content = url.QueryEscape(content)
 	// the Azure's behavior uses %20 to represent whitespace instead of + (plus)
@@ -246,6 +358,48 @@ func (a *Auth) SignRequest(req *http.Request) error {
 	return nil
 }
 
+// BlobSAS returns the query of a service shared access signature reading a blob over HTTPS until
+// expiry. See https://docs.microsoft.com/en-us/rest/api/storageservices/create-service-sas
+func (a *Auth) BlobSAS(container, blobName string, expiry time.Time) (string, error) {
+	const permissions = "r"
+	const protocol = "https"
+	signedExpiry := expiry.UTC().Format(time.RFC3339)
+
+	strToSign := strings.Join([]string{
+		permissions,
+		"", // signed start
+		signedExpiry,
+		fmt.Sprintf("/blob/%s/%s/%s", a.Account, container, blobName),
+		"", // signed identifier
+		"", // signed IP
+		protocol,
+		version,
+		"", // cache control
+		"", // content disposition
+		"", // content encoding
+		"", // content language
+		"", // content type
+	}, "\n")
+
+	decodedKey, err := base64.StdEncoding.DecodeString(a.Key)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, decodedKey)
+	if _, err := mac.Write([]byte(strToSign)); err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("sv", version)
+	params.Set("sr", "b")
+	params.Set("sp", permissions)
+	params.Set("se", signedExpiry)
+	params.Set("spr", protocol)
+	params.Set("sig", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	return params.Encode(), nil
+}
+
 func tryget(headers map[string][]string, key string) string {
 	// We default to empty string for "0" values to match server side behavior when generating signatures.
 	if len(headers[key]) > 0 { // && headers[key][0] != "0" { //&& key != "Content-Length" {
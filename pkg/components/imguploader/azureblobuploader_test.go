@@ -2,7 +2,12 @@ package imguploader
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/setting"
 	. "github.com/smartystreets/goconvey/convey"
@@ -24,3 +29,26 @@ func TestUploadToAzureBlob(t *testing.T) {
 		So(path, ShouldNotEqual, "")
 	})
 }
+
+func TestBlobSAS(t *testing.T) {
+	Convey("Signing the URL of a blob", t, func() {
+		auth := &Auth{Account: "account", Key: base64.StdEncoding.EncodeToString([]byte("key"))}
+		expiry := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+		sas, err := auth.BlobSAS("container", "abc.png", expiry)
+		So(err, ShouldBeNil)
+
+		query, err := url.ParseQuery(sas)
+		So(err, ShouldBeNil)
+		So(query.Get("sv"), ShouldEqual, "2017-04-17")
+		So(query.Get("sr"), ShouldEqual, "b")
+		So(query.Get("sp"), ShouldEqual, "r")
+		So(query.Get("se"), ShouldEqual, "2020-10-01T12:00:00Z")
+		So(query.Get("spr"), ShouldEqual, "https")
+
+		mac := hmac.New(sha256.New, []byte("key"))
+		_, err = mac.Write([]byte("r\n\n2020-10-01T12:00:00Z\n/blob/account/container/abc.png\n\n\nhttps\n2017-04-17\n\n\n\n\n"))
+		So(err, ShouldBeNil)
+		So(query.Get("sig"), ShouldEqual, base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	})
+}
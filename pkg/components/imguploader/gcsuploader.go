@@ -2,20 +2,38 @@ package imguploader
 
 import (
 	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/util"
 	"golang.org/x/oauth2/google"
+	"golang.org/x/oauth2/jwt"
 )
 
 const (
 	tokenUrl  string = "https://www.googleapis.com/auth/devstorage.read_write" // #nosec
 	uploadUrl string = "https://www.googleapis.com/upload/storage/v1/b/%s/o?uploadType=media&name=%s&predefinedAcl=publicRead"
+	// privateUploadUrl uploads the images with signed URLs, which stay private
+	privateUploadUrl string = "https://www.googleapis.com/upload/storage/v1/b/%s/o?uploadType=media&name=%s"
+	objectsUrl       string = "https://storage.googleapis.com/storage/v1/b/%s/o"
 )
 
 type GCSUploader struct {
@@ -23,6 +41,9 @@ type GCSUploader struct {
 	bucket  string
 	path    string
 	log     log.Logger
+
+	// signedURLExpiry is how long the URLs of private images are valid, 0 for public images
+	signedURLExpiry time.Duration
 }
 
 func NewGCSUploader(keyFile, bucket, path string) *GCSUploader {
@@ -34,6 +55,17 @@ func NewGCSUploader(keyFile, bucket, path string) *GCSUploader {
 	}
 }
 
+func (u *GCSUploader) jwtConfig() (*jwt.Config, error) {
+	u.log.Debug("Opening key file ", u.keyFile)
+	data, err := ioutil.ReadFile(u.keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	u.log.Debug("Creating JWT conf")
+	return google.JWTConfigFromJSON(data, tokenUrl)
+}
+
 func (u *GCSUploader) Upload(ctx context.Context, imageDiskPath string) (string, error) {
 	fileName, err := util.GetRandomString(20)
 	if err != nil {
@@ -43,14 +75,7 @@ func (u *GCSUploader) Upload(ctx context.Context, imageDiskPath string) (string,
 	fileName += pngExt
 	key := path.Join(u.path, fileName)
 
-	u.log.Debug("Opening key file ", u.keyFile)
-	data, err := ioutil.ReadFile(u.keyFile)
-	if err != nil {
-		return "", err
-	}
-
-	u.log.Debug("Creating JWT conf")
-	conf, err := google.JWTConfigFromJSON(data, tokenUrl)
+	conf, err := u.jwtConfig()
 	if err != nil {
 		return "", err
 	}
@@ -62,6 +87,9 @@ func (u *GCSUploader) Upload(ctx context.Context, imageDiskPath string) (string,
 		return "", err
 	}
 
+	if u.signedURLExpiry > 0 {
+		return signGCSURL(conf, u.bucket, key, time.Now(), u.signedURLExpiry)
+	}
 	return fmt.Sprintf("https://storage.googleapis.com/%s/%s", u.bucket, key), nil
 }
 
@@ -75,6 +103,9 @@ func (u *GCSUploader) uploadFile(client *http.Client, imageDiskPath, key string)
 	defer fileReader.Close()
 
 	reqUrl := fmt.Sprintf(uploadUrl, u.bucket, key)
+	if u.signedURLExpiry > 0 {
+		reqUrl = fmt.Sprintf(privateUploadUrl, u.bucket, key)
+	}
 	u.log.Debug("Request URL: ", reqUrl)
 
 	req, err := http.NewRequest("POST", reqUrl, fileReader)
@@ -97,3 +128,155 @@ func (u *GCSUploader) uploadFile(client *http.Client, imageDiskPath, key string)
 
 	return nil
 }
+
+// DeleteImagesBefore deletes the images uploaded to the path of the bucket before a time.
+func (u *GCSUploader) DeleteImagesBefore(ctx context.Context, before time.Time) (int, error) {
+	conf, err := u.jwtConfig()
+	if err != nil {
+		return 0, err
+	}
+	client := conf.Client(ctx)
+
+	var expired []string
+	pageToken := ""
+	for {
+		params := url.Values{}
+		params.Set("prefix", u.path)
+		params.Set("fields", "items(name,timeCreated),nextPageToken")
+		if pageToken != "" {
+			params.Set("pageToken", pageToken)
+		}
+
+		var page struct {
+			Items []struct {
+				Name        string    `json:"name"`
+				TimeCreated time.Time `json:"timeCreated"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := u.do(ctx, client, "GET", fmt.Sprintf(objectsUrl, u.bucket)+"?"+params.Encode(), &page); err != nil {
+			return 0, err
+		}
+
+		for _, item := range page.Items {
+			if isUploadedImage(u.path, item.Name) && item.TimeCreated.Before(before) {
+				expired = append(expired, item.Name)
+			}
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+
+	for i, name := range expired {
+		if err := u.do(ctx, client, "DELETE", fmt.Sprintf(objectsUrl, u.bucket)+"/"+url.PathEscape(name), nil); err != nil {
+			return i, err
+		}
+	}
+	return len(expired), nil
+}
+
+func (u *GCSUploader) do(ctx context.Context, client *http.Client, method, reqUrl string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, reqUrl, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("GCS response status code %d", resp.StatusCode)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// signGCSURL returns a V4 signed URL reading an object, signed with the key of the service account.
+// See https://cloud.google.com/storage/docs/access-control/signing-urls-manually
+func signGCSURL(conf *jwt.Config, bucket, key string, now time.Time, expiry time.Duration) (string, error) {
+	privateKey, err := parseRSAPrivateKey(conf.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	now = now.UTC()
+	datetime := now.Format("20060102T150405Z")
+	scope := now.Format("20060102") + "/auto/storage/goog4_request"
+	canonicalURI := "/" + bucket + "/" + escapeGCSPath(key)
+
+	query := map[string]string{
+		"X-Goog-Algorithm":     "GOOG4-RSA-SHA256",
+		"X-Goog-Credential":    conf.Email + "/" + scope,
+		"X-Goog-Date":          datetime,
+		"X-Goog-Expires":       strconv.Itoa(int(expiry.Seconds())),
+		"X-Goog-SignedHeaders": "host",
+	}
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	params := make([]string, 0, len(names))
+	for _, name := range names {
+		params = append(params, url.QueryEscape(name)+"="+url.QueryEscape(query[name]))
+	}
+	canonicalQuery := strings.Join(params, "&")
+
+	canonicalRequest := strings.Join([]string{
+		"GET",
+		canonicalURI,
+		canonicalQuery,
+		"host:storage.googleapis.com\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	hashedRequest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"GOOG4-RSA-SHA256",
+		datetime,
+		scope,
+		hex.EncodeToString(hashedRequest[:]),
+	}, "\n")
+
+	hashed := sha256.Sum256([]byte(stringToSign))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("https://storage.googleapis.com%s?%s&X-Goog-Signature=%s", canonicalURI, canonicalQuery,
+		hex.EncodeToString(signature)), nil
+}
+
+// escapeGCSPath escapes the segments of the name of an object
+func escapeGCSPath(name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block != nil {
+		data = block.Bytes
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(data)
+	if err != nil {
+		return x509.ParsePKCS1PrivateKey(data)
+	}
+	privateKey, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the private key of the service account isn't an RSA key")
+	}
+	return privateKey, nil
+}
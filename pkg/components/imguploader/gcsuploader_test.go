@@ -2,10 +2,21 @@ package imguploader
 
 import (
 	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/setting"
 	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/oauth2/jwt"
 )
 
 func TestUploadToGCS(t *testing.T) {
@@ -24,3 +35,37 @@ func TestUploadToGCS(t *testing.T) {
 		So(path, ShouldNotEqual, "")
 	})
 }
+
+func TestSignGCSURL(t *testing.T) {
+	Convey("Signing a GCS URL", t, func() {
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		So(err, ShouldBeNil)
+		conf := &jwt.Config{
+			Email:      "grafana@project.iam.gserviceaccount.com",
+			PrivateKey: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}),
+		}
+		now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+		signed, err := signGCSURL(conf, "bucket", "images/abc.png", now, time.Hour)
+		So(err, ShouldBeNil)
+
+		u, err := url.Parse(signed)
+		So(err, ShouldBeNil)
+		So(u.Host, ShouldEqual, "storage.googleapis.com")
+		So(u.Path, ShouldEqual, "/bucket/images/abc.png")
+		query := u.Query()
+		So(query.Get("X-Goog-Algorithm"), ShouldEqual, "GOOG4-RSA-SHA256")
+		So(query.Get("X-Goog-Credential"), ShouldEqual, "grafana@project.iam.gserviceaccount.com/20201001/auto/storage/goog4_request")
+		So(query.Get("X-Goog-Date"), ShouldEqual, "20201001T120000Z")
+		So(query.Get("X-Goog-Expires"), ShouldEqual, "3600")
+
+		signature, err := hex.DecodeString(query.Get("X-Goog-Signature"))
+		So(err, ShouldBeNil)
+		canonicalQuery := signed[strings.Index(signed, "?")+1 : strings.Index(signed, "&X-Goog-Signature")]
+		canonicalRequest := "GET\n/bucket/images/abc.png\n" + canonicalQuery + "\nhost:storage.googleapis.com\n\nhost\nUNSIGNED-PAYLOAD"
+		hashedRequest := sha256.Sum256([]byte(canonicalRequest))
+		stringToSign := "GOOG4-RSA-SHA256\n20201001T120000Z\n20201001/auto/storage/goog4_request\n" + hex.EncodeToString(hashedRequest[:])
+		hashed := sha256.Sum256([]byte(stringToSign))
+		So(rsa.VerifyPKCS1v15(&privateKey.PublicKey, crypto.SHA256, hashed[:], signature), ShouldBeNil)
+	})
+}
@@ -4,17 +4,55 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/setting"
+	"gopkg.in/ini.v1"
 )
 
 const pngExt = ".png"
 
+// maxSignedURLExpiry is the longest validity of the signed URLs of Amazon S3 and Google Cloud Storage
+const maxSignedURLExpiry = 7 * 24 * time.Hour
+
 type ImageUploader interface {
 	Upload(ctx context.Context, path string) (string, error)
 }
 
+// ImageDeleter is implemented by the uploaders which can delete the images they uploaded, to keep
+// them for the retention of the external image storage only.
+type ImageDeleter interface {
+	// DeleteImagesBefore deletes the images uploaded before a time and returns how many were deleted.
+	DeleteImagesBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// uploadedImageName matches the random names given to the uploaded images
+var uploadedImageName = regexp.MustCompile(`^[a-zA-Z0-9]+\.png$`)
+
+// isUploadedImage returns whether an object of a storage is an image uploaded to a path, so that
+// the other objects aren't deleted with the expired images
+func isUploadedImage(path, name string) bool {
+	if !strings.HasPrefix(name, path) {
+		return false
+	}
+	return uploadedImageName.MatchString(strings.TrimPrefix(strings.TrimPrefix(name, path), "/"))
+}
+
+// signedURLExpiry reads the signed_url_expiry setting of a provider
+func signedURLExpiry(sec *ini.Section, max time.Duration) (time.Duration, error) {
+	provider := strings.TrimPrefix(sec.Name(), "external_image_storage.")
+	expiry, err := time.ParseDuration(sec.Key("signed_url_expiry").MustString("0s"))
+	if err != nil || expiry < 0 {
+		return 0, fmt.Errorf("invalid signed_url_expiry for image.uploader.%s, expected a duration like 24h", provider)
+	}
+	if max > 0 && expiry > max {
+		return 0, fmt.Errorf("signed_url_expiry for image.uploader.%s can't be longer than %s", provider, max)
+	}
+	return expiry, nil
+}
+
 type NopImageUploader struct {
 }
 
@@ -57,7 +95,18 @@ func NewImageUploader() (ImageUploader, error) {
 			region = info.region
 		}
 
-		return NewS3Uploader(endpoint, region, bucket, path, "public-read", accessKey, secretKey, pathStyleAccess), nil
+		expiry, err := signedURLExpiry(s3sec, maxSignedURLExpiry)
+		if err != nil {
+			return nil, err
+		}
+
+		uploader := NewS3Uploader(endpoint, region, bucket, path, "public-read", accessKey, secretKey, pathStyleAccess)
+		uploader.profile = s3sec.Key("profile").MustString("")
+		uploader.assumeRoleArn = s3sec.Key("assume_role_arn").MustString("")
+		uploader.externalID = s3sec.Key("external_id").MustString("")
+		uploader.sseKMSKeyID = s3sec.Key("sse_kms_key_id").MustString("")
+		uploader.signedURLExpiry = expiry
+		return uploader, nil
 	case "webdav":
 		webdavSec, err := setting.Raw.GetSection("external_image_storage.webdav")
 		if err != nil {
@@ -84,7 +133,14 @@ func NewImageUploader() (ImageUploader, error) {
 		bucketName := gcssec.Key("bucket").MustString("")
 		path := gcssec.Key("path").MustString("")
 
-		return NewGCSUploader(keyFile, bucketName, path), nil
+		expiry, err := signedURLExpiry(gcssec, maxSignedURLExpiry)
+		if err != nil {
+			return nil, err
+		}
+
+		uploader := NewGCSUploader(keyFile, bucketName, path)
+		uploader.signedURLExpiry = expiry
+		return uploader, nil
 	case "azure_blob":
 		azureBlobSec, err := setting.Raw.GetSection("external_image_storage.azure_blob")
 		if err != nil {
@@ -95,7 +151,14 @@ func NewImageUploader() (ImageUploader, error) {
 		account_key := azureBlobSec.Key("account_key").MustString("")
 		container_name := azureBlobSec.Key("container_name").MustString("")
 
-		return NewAzureBlobUploader(account_name, account_key, container_name), nil
+		expiry, err := signedURLExpiry(azureBlobSec, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		uploader := NewAzureBlobUploader(account_name, account_key, container_name)
+		uploader.signedURLExpiry = expiry
+		return uploader, nil
 	case "local":
 		return NewLocalImageUploader()
 	}
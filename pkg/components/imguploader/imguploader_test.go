@@ -2,6 +2,7 @@ package imguploader
 
 import (
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/setting"
 
@@ -81,6 +82,36 @@ func TestImageUploaderFactory(t *testing.T) {
 				So(original.accessKey, ShouldEqual, "access_key")
 				So(original.secretKey, ShouldEqual, "secret_key")
 			})
+
+			Convey("with encryption and signed urls", func() {
+				s3sec, err := setting.Raw.GetSection("external_image_storage.s3")
+				So(err, ShouldBeNil)
+				_, err = s3sec.NewKey("bucket", "bucket")
+				So(err, ShouldBeNil)
+				_, err = s3sec.NewKey("region", "eu-west-1")
+				So(err, ShouldBeNil)
+				_, err = s3sec.NewKey("sse_kms_key_id", "alias/grafana")
+				So(err, ShouldBeNil)
+				_, err = s3sec.NewKey("assume_role_arn", "arn:aws:iam::123456789012:role/grafana")
+				So(err, ShouldBeNil)
+				_, err = s3sec.NewKey("signed_url_expiry", "24h")
+				So(err, ShouldBeNil)
+
+				uploader, err := NewImageUploader()
+				So(err, ShouldBeNil)
+
+				original, ok := uploader.(*S3Uploader)
+				So(ok, ShouldBeTrue)
+				So(original.sseKMSKeyID, ShouldEqual, "alias/grafana")
+				So(original.assumeRoleArn, ShouldEqual, "arn:aws:iam::123456789012:role/grafana")
+				So(original.signedURLExpiry, ShouldEqual, 24*time.Hour)
+
+				Convey("should return an error if the signed urls are valid longer than a week", func() {
+					s3sec.Key("signed_url_expiry").SetValue("200h")
+					_, err := NewImageUploader()
+					So(err, ShouldNotBeNil)
+				})
+			})
 		})
 
 		Convey("Webdav uploader", func() {
@@ -184,3 +215,14 @@ func TestImageUploaderFactory(t *testing.T) {
 		})
 	})
 }
+
+func TestIsUploadedImage(t *testing.T) {
+	Convey("Only the uploaded images are deleted", t, func() {
+		So(isUploadedImage("grafana/", "grafana/abcdefghij0123456789.png"), ShouldBeTrue)
+		So(isUploadedImage("grafana", "grafana/abcdefghij0123456789.png"), ShouldBeTrue)
+		So(isUploadedImage("", "abcdefghij0123456789.png"), ShouldBeTrue)
+		So(isUploadedImage("grafana/", "other/abcdefghij0123456789.png"), ShouldBeFalse)
+		So(isUploadedImage("", "grafana/abcdefghij0123456789.png"), ShouldBeFalse)
+		So(isUploadedImage("grafana/", "grafana/report.pdf"), ShouldBeFalse)
+	})
+}
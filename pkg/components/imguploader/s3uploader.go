@@ -2,21 +2,15 @@ package imguploader
 
 import (
 	"context"
-	"fmt"
 	"os"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
-	"github.com/aws/aws-sdk-go/aws/credentials/endpointcreds"
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
-	"github.com/aws/aws-sdk-go/aws/defaults"
-	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
-	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch"
 	"github.com/grafana/grafana/pkg/util"
 )
 
@@ -30,6 +24,15 @@ type S3Uploader struct {
 	accessKey       string
 	pathStyleAccess bool
 	log             log.Logger
+
+	// profile, assumeRoleArn and externalID are used like in the CloudWatch data source
+	profile       string
+	assumeRoleArn string
+	externalID    string
+	// sseKMSKeyID is the KMS key the images are encrypted with on the server side, if any
+	sseKMSKeyID string
+	// signedURLExpiry is how long the URLs of private images are valid, 0 for public images
+	signedURLExpiry time.Duration
 }
 
 func NewS3Uploader(endpoint, region, bucket, path, acl, accessKey, secretKey string, pathStyleAccess bool) *S3Uploader {
@@ -46,28 +49,36 @@ func NewS3Uploader(endpoint, region, bucket, path, acl, accessKey, secretKey str
 	}
 }
 
-func (u *S3Uploader) Upload(ctx context.Context, imageDiskPath string) (string, error) {
-	sess, err := session.NewSession()
-	if err != nil {
-		return "", err
+// session returns a session with the same credentials chain as the CloudWatch data source, which
+// includes the IAM roles for service accounts on EKS
+func (u *S3Uploader) session() (*session.Session, error) {
+	dsInfo := &cloudwatch.DatasourceInfo{
+		Region:        u.region,
+		AuthType:      "default",
+		Profile:       u.profile,
+		AssumeRoleArn: u.assumeRoleArn,
+		ExternalID:    u.externalID,
+		AccessKey:     u.accessKey,
+		SecretKey:     u.secretKey,
 	}
-	creds := credentials.NewChainCredentials(
-		[]credentials.Provider{
-			&credentials.StaticProvider{Value: credentials.Value{
-				AccessKeyID:     u.accessKey,
-				SecretAccessKey: u.secretKey,
-			}},
-			&credentials.EnvProvider{},
-			webIdentityProvider(sess),
-			remoteCredProvider(sess),
-		})
-	cfg := &aws.Config{
-		Region:           aws.String(u.region),
-		Endpoint:         aws.String(u.endpoint),
-		S3ForcePathStyle: aws.Bool(u.pathStyleAccess),
-		Credentials:      creds,
+	if u.accessKey != "" {
+		dsInfo.AuthType = "keys"
+	}
+	if u.assumeRoleArn != "" {
+		dsInfo.AuthType = "arn"
+	}
+
+	cfg, err := cloudwatch.GetAwsConfig(dsInfo)
+	if err != nil {
+		return nil, err
 	}
+	cfg.Endpoint = aws.String(u.endpoint)
+	cfg.S3ForcePathStyle = aws.Bool(u.pathStyleAccess)
 
+	return session.NewSession(cfg)
+}
+
+func (u *S3Uploader) Upload(ctx context.Context, imageDiskPath string) (string, error) {
 	rand, err := util.GetRandomString(20)
 	if err != nil {
 		return "", err
@@ -81,53 +92,79 @@ func (u *S3Uploader) Upload(ctx context.Context, imageDiskPath string) (string,
 	}
 	defer file.Close()
 
-	sess, err = session.NewSession(cfg)
+	sess, err := u.session()
 	if err != nil {
 		return "", err
 	}
-	uploader := s3manager.NewUploader(sess)
-	result, err := uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+
+	input := &s3manager.UploadInput{
 		Bucket:      aws.String(u.bucket),
 		Key:         aws.String(key),
-		ACL:         aws.String(u.acl),
 		Body:        file,
 		ContentType: aws.String("image/png"),
-	})
+	}
+	// the images with signed URLs stay private
+	if u.signedURLExpiry == 0 {
+		input.ACL = aws.String(u.acl)
+	}
+	if u.sseKMSKeyID != "" {
+		input.ServerSideEncryption = aws.String(s3.ServerSideEncryptionAwsKms)
+		input.SSEKMSKeyId = aws.String(u.sseKMSKeyID)
+	}
+
+	uploader := s3manager.NewUploader(sess)
+	result, err := uploader.UploadWithContext(ctx, input)
 	if err != nil {
 		return "", err
 	}
-	return result.Location, nil
-}
-
-func webIdentityProvider(sess *session.Session) credentials.Provider {
-	svc := sts.New(sess)
+	if u.signedURLExpiry == 0 {
+		return result.Location, nil
+	}
 
-	roleARN := os.Getenv("AWS_ROLE_ARN")
-	tokenFilepath := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
-	roleSessionName := os.Getenv("AWS_ROLE_SESSION_NAME")
-	return stscreds.NewWebIdentityRoleProvider(svc, roleARN, roleSessionName, tokenFilepath)
+	req, _ := s3.New(sess).GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(u.bucket),
+		Key:    aws.String(key),
+	})
+	return req.Presign(u.signedURLExpiry)
 }
 
-func remoteCredProvider(sess *session.Session) credentials.Provider {
-	ecsCredURI := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI")
-
-	if len(ecsCredURI) > 0 {
-		return ecsCredProvider(sess, ecsCredURI)
+// DeleteImagesBefore deletes the images uploaded to the path of the bucket before a time.
+func (u *S3Uploader) DeleteImagesBefore(ctx context.Context, before time.Time) (int, error) {
+	sess, err := u.session()
+	if err != nil {
+		return 0, err
 	}
-	return ec2RoleProvider(sess)
-}
-
-func ecsCredProvider(sess *session.Session, uri string) credentials.Provider {
-	const host = `169.254.170.2`
+	svc := s3.New(sess)
 
-	d := defaults.Get()
-	return endpointcreds.NewProviderClient(
-		*d.Config,
-		d.Handlers,
-		fmt.Sprintf("http://%s%s", host, uri),
-		func(p *endpointcreds.Provider) { p.ExpiryWindow = 5 * time.Minute })
-}
+	expired := make([]*s3.ObjectIdentifier, 0)
+	input := &s3.ListObjectsV2Input{Bucket: aws.String(u.bucket), Prefix: aws.String(u.path)}
+	err = svc.ListObjectsV2PagesWithContext(ctx, input, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range page.Contents {
+			if isUploadedImage(u.path, aws.StringValue(object.Key)) && object.LastModified != nil && object.LastModified.Before(before) {
+				expired = append(expired, &s3.ObjectIdentifier{Key: object.Key})
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
 
-func ec2RoleProvider(sess *session.Session) credentials.Provider {
-	return &ec2rolecreds.EC2RoleProvider{Client: ec2metadata.New(sess), ExpiryWindow: 5 * time.Minute}
+	deleted := 0
+	// DeleteObjects deletes up to 1000 objects
+	for start := 0; start < len(expired); start += 1000 {
+		end := start + 1000
+		if end > len(expired) {
+			end = len(expired)
+		}
+		_, err := svc.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(u.bucket),
+			Delete: &s3.Delete{Objects: expired[start:end], Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return deleted, err
+		}
+		deleted += end - start
+	}
+	return deleted, nil
 }
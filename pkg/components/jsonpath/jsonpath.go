@@ -0,0 +1,90 @@
+// Package jsonpath reads values from decoded JSON documents with simple JSON paths of object keys
+// and array indexes, like $.sensor.readings[0].value.
+package jsonpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// segment is an object key or an array index of a path
+type segment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// Path is the path of a value in a JSON document. The empty path, $, is the document itself.
+type Path []segment
+
+// Parse parses a path of keys and array indexes, like $.a.b[0]['c.d']. The leading $. is optional.
+func Parse(path string) (Path, error) {
+	p := strings.TrimSpace(path)
+	if p == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+	p = strings.TrimPrefix(p, "$")
+	if p != "" && p[0] != '.' && p[0] != '[' {
+		p = "." + p
+	}
+
+	segments := Path{}
+	for i := 0; i < len(p); {
+		switch p[i] {
+		case '.':
+			j := i + 1
+			for j < len(p) && p[j] != '.' && p[j] != '[' {
+				j++
+			}
+			if j == i+1 {
+				return nil, fmt.Errorf("invalid path %q", path)
+			}
+			segments = append(segments, segment{key: p[i+1 : j]})
+			i = j
+		case '[':
+			end := strings.IndexByte(p[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q", path)
+			}
+			inner := p[i+1 : i+end]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				segments = append(segments, segment{key: inner[1 : len(inner)-1]})
+			} else {
+				index, err := strconv.Atoi(inner)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("invalid index %q in path %q", inner, path)
+				}
+				segments = append(segments, segment{index: index, isIndex: true})
+			}
+			i += end + 1
+		default:
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+	}
+	return segments, nil
+}
+
+// Lookup returns the value at the path of a document decoded by encoding/json into an
+// interface{}, and false if there's none.
+func (p Path) Lookup(value interface{}) (interface{}, bool) {
+	for _, s := range p {
+		if s.isIndex {
+			items, ok := value.([]interface{})
+			if !ok || s.index >= len(items) {
+				return nil, false
+			}
+			value = items[s.index]
+			continue
+		}
+
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[s.key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
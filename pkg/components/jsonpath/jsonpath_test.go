@@ -0,0 +1,45 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPath(t *testing.T) {
+	var doc interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"sensor": {"id": 12, "a.b": true}, "readings": [{"value": 21.5}, {"value": null}]}`), &doc))
+
+	for path, expected := range map[string]interface{}{
+		"$":                    doc,
+		"$.sensor.id":          12.0,
+		"sensor.id":            12.0,
+		"$['sensor']['a.b']":   true,
+		`sensor["a.b"]`:        true,
+		"readings[0].value":    21.5,
+		"$.readings[1].value":  nil,
+		"$.readings[1]":        map[string]interface{}{"value": nil},
+		"$[\"readings\"][0]":   map[string]interface{}{"value": 21.5},
+		"$.readings[0].value ": 21.5,
+	} {
+		p, err := Parse(path)
+		require.NoError(t, err, path)
+		value, ok := p.Lookup(doc)
+		assert.True(t, ok, path)
+		assert.Equal(t, expected, value, path)
+	}
+
+	for _, path := range []string{"$.sensor.name", "readings[2]", "sensor[0]", "readings.value", "sensor.id.x"} {
+		p, err := Parse(path)
+		require.NoError(t, err, path)
+		_, ok := p.Lookup(doc)
+		assert.False(t, ok, path)
+	}
+
+	for _, path := range []string{"", " ", "a..b", "a[x]", "a[-1]", "a[0", "$a.", "a b[0]x"} {
+		_, err := Parse(path)
+		assert.Error(t, err, path)
+	}
+}
@@ -1,20 +1,83 @@
 package securejsondata
 
 import (
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/components/encryption"
 	"github.com/grafana/grafana/pkg/infra/log"
-	"github.com/grafana/grafana/pkg/setting"
-	"github.com/grafana/grafana/pkg/util"
 )
 
 // SecureJsonData is used to store encrypted data (for example in data_source table). Only values are separately
 // encrypted.
 type SecureJsonData map[string][]byte
 
+// ReferenceResolver returns the secret referenced by a decrypted value of a setting of an org, and false if the
+// value isn't a reference to a secret. Provisioned settings can reference any secret, the others only the secrets
+// allowed for their org.
+type ReferenceResolver func(orgID int64, provisioned bool, value string) (string, bool, error)
+
+var referenceResolver ReferenceResolver
+
+// SetReferenceResolver sets the resolver of the values referencing secrets stored outside of the database.
+func SetReferenceResolver(resolver ReferenceResolver) {
+	referenceResolver = resolver
+}
+
+// ResolveReference returns the secret referenced by a decrypted value of an org, or the value as is when it isn't
+// a reference.
+func ResolveReference(orgID int64, provisioned bool, value string) (string, error) {
+	if referenceResolver == nil {
+		return value, nil
+	}
+
+	secret, isReference, err := referenceResolver(orgID, provisioned, value)
+	if err != nil {
+		return "", err
+	}
+	if !isReference {
+		return value, nil
+	}
+	return secret, nil
+}
+
+// ResolveReferences returns the decrypted values of an org with the references to secrets replaced by the
+// secrets. The values are returned as is when none of them is a reference.
+func ResolveReferences(orgID int64, provisioned bool, values map[string]string) (map[string]string, error) {
+	if referenceResolver == nil {
+		return values, nil
+	}
+
+	var resolved map[string]string
+	for key, value := range values {
+		secret, isReference, err := referenceResolver(orgID, provisioned, value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", key, err)
+		}
+		if !isReference {
+			continue
+		}
+
+		if resolved == nil {
+			resolved = make(map[string]string, len(values))
+			for k, v := range values {
+				resolved[k] = v
+			}
+		}
+		resolved[key] = secret
+	}
+
+	if resolved == nil {
+		return values, nil
+	}
+	return resolved, nil
+}
+
 // DecryptedValue returns single decrypted value from SecureJsonData. Similar to normal map access second return value
-// is true if the key exists and false if not.
+// is true if the key exists and false if not. References to secrets are returned as they're stored, see
+// ResolveReference.
 func (s SecureJsonData) DecryptedValue(key string) (string, bool) {
 	if value, ok := s[key]; ok {
-		decryptedData, err := util.Decrypt(value, setting.SecretKey)
+		decryptedData, err := encryption.Decrypt(value)
 		if err != nil {
 			log.Fatal(4, err.Error())
 		}
@@ -24,11 +87,11 @@ func (s SecureJsonData) DecryptedValue(key string) (string, bool) {
 }
 
 // Decrypt returns map of the same type but where the all the values are decrypted. Opposite of what
-// GetEncryptedJsonData is doing.
+// GetEncryptedJsonData is doing. References to secrets are returned as they're stored, see ResolveReferences.
 func (s SecureJsonData) Decrypt() map[string]string {
 	decrypted := make(map[string]string)
 	for key, data := range s {
-		decryptedData, err := util.Decrypt(data, setting.SecretKey)
+		decryptedData, err := encryption.Decrypt(data)
 		if err != nil {
 			log.Fatal(4, err.Error())
 		}
@@ -42,7 +105,7 @@ func (s SecureJsonData) Decrypt() map[string]string {
 func GetEncryptedJsonData(sjd map[string]string) SecureJsonData {
 	encrypted := make(SecureJsonData)
 	for key, data := range sjd {
-		encryptedData, err := util.Encrypt([]byte(data), setting.SecretKey)
+		encryptedData, err := encryption.Encrypt([]byte(data))
 		if err != nil {
 			log.Fatal(4, err.Error())
 		}
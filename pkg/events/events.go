@@ -89,3 +89,43 @@ type UserUpdated struct {
 	Login     string    `json:"login"`
 	Email     string    `json:"email"`
 }
+
+type DashboardSaved struct {
+	Timestamp time.Time `json:"timestamp"`
+	Id        int64     `json:"id"`
+	Uid       string    `json:"uid"`
+	OrgId     int64     `json:"orgId"`
+	Title     string    `json:"title"`
+	Version   int       `json:"version"`
+	UpdatedBy int64     `json:"updatedBy"`
+}
+
+type DataSourceUpdated struct {
+	Timestamp time.Time `json:"timestamp"`
+	Id        int64     `json:"id"`
+	Uid       string    `json:"uid"`
+	OrgId     int64     `json:"orgId"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Version   int       `json:"version"`
+}
+
+type DataSourceDeleted struct {
+	Timestamp time.Time `json:"timestamp"`
+	Id        int64     `json:"id"`
+	Uid       string    `json:"uid"`
+	OrgId     int64     `json:"orgId"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+}
+
+type AlertStateChanged struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Id          int64     `json:"id"`
+	OrgId       int64     `json:"orgId"`
+	DashboardId int64     `json:"dashboardId"`
+	PanelId     int64     `json:"panelId"`
+	Name        string    `json:"name"`
+	State       string    `json:"state"`
+	PrevState   string    `json:"prevState"`
+}
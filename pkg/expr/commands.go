@@ -0,0 +1,239 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/gtime"
+	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// command is an operation of an expression query
+type command interface {
+	execute(ev *evaluator) (value, error)
+}
+
+// parseCommand returns the command of the model of an expression query
+func parseCommand(model *simplejson.Json) (command, error) {
+	switch kind := model.Get("type").MustString(); kind {
+	case "math":
+		node, err := parseMath(model.Get("expression").MustString())
+		if err != nil {
+			return nil, err
+		}
+		return &mathCommand{node: node}, nil
+	case "reduce":
+		refId, err := parseRefId(model.Get("expression").MustString())
+		if err != nil {
+			return nil, err
+		}
+		reducer := model.Get("reducer").MustString()
+		if _, ok := reducers[reducer]; !ok {
+			return nil, fmt.Errorf("unknown reducer %q", reducer)
+		}
+		return &reduceCommand{refId: refId, reducer: reducer}, nil
+	case "resample":
+		refId, err := parseRefId(model.Get("expression").MustString())
+		if err != nil {
+			return nil, err
+		}
+		window, err := gtime.ParseInterval(model.Get("rule").MustString())
+		if err != nil {
+			return nil, fmt.Errorf("invalid resample rule: %w", err)
+		}
+		if window <= 0 {
+			return nil, fmt.Errorf("invalid resample rule %q", model.Get("rule").MustString())
+		}
+		downsampler := model.Get("downsampler").MustString("mean")
+		if _, ok := reducers[downsampler]; !ok {
+			return nil, fmt.Errorf("unknown downsampler %q", downsampler)
+		}
+		upsampler := model.Get("upsampler").MustString("fillna")
+		if upsampler != "pad" && upsampler != "backfilling" && upsampler != "fillna" {
+			return nil, fmt.Errorf("unknown upsampler %q", upsampler)
+		}
+		return &resampleCommand{refId: refId, window: window, downsampler: downsampler, upsampler: upsampler}, nil
+	default:
+		return nil, fmt.Errorf("unknown expression type %q", kind)
+	}
+}
+
+// parseRefId returns the ref id of the query a reduce or resample operation applies to, like A
+// or $A
+func parseRefId(expression string) (string, error) {
+	refId := strings.TrimSpace(expression)
+	refId = strings.TrimPrefix(refId, "$")
+	if strings.HasPrefix(refId, "{") && strings.HasSuffix(refId, "}") {
+		refId = refId[1 : len(refId)-1]
+	}
+	if refId == "" {
+		return "", fmt.Errorf("missing query")
+	}
+	return refId, nil
+}
+
+type mathCommand struct {
+	node mathNode
+}
+
+func (c *mathCommand) execute(ev *evaluator) (value, error) {
+	return c.node.eval(ev)
+}
+
+// reducers reduce the values of a series to a number. The values are never null.
+var reducers = map[string]func(values []float64) null.Float{
+	"mean": func(values []float64) null.Float {
+		if len(values) == 0 {
+			return null.FloatFromPtr(nil)
+		}
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		return null.FloatFrom(sum / float64(len(values)))
+	},
+	"min": func(values []float64) null.Float {
+		if len(values) == 0 {
+			return null.FloatFromPtr(nil)
+		}
+		min := math.Inf(1)
+		for _, v := range values {
+			min = math.Min(min, v)
+		}
+		return null.FloatFrom(min)
+	},
+	"max": func(values []float64) null.Float {
+		if len(values) == 0 {
+			return null.FloatFromPtr(nil)
+		}
+		max := math.Inf(-1)
+		for _, v := range values {
+			max = math.Max(max, v)
+		}
+		return null.FloatFrom(max)
+	},
+	"sum": func(values []float64) null.Float {
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		return null.FloatFrom(sum)
+	},
+	"count": func(values []float64) null.Float {
+		return null.FloatFrom(float64(len(values)))
+	},
+	"last": func(values []float64) null.Float {
+		if len(values) == 0 {
+			return null.FloatFromPtr(nil)
+		}
+		return null.FloatFrom(values[len(values)-1])
+	},
+}
+
+func validValues(points tsdb.TimeSeriesPoints) []float64 {
+	values := make([]float64, 0, len(points))
+	for _, p := range points {
+		if p[0].Valid {
+			values = append(values, p[0].Float64)
+		}
+	}
+	return values
+}
+
+// reduceCommand reduces every series of a query to a number
+type reduceCommand struct {
+	refId   string
+	reducer string
+}
+
+func (c *reduceCommand) execute(ev *evaluator) (value, error) {
+	v, err := ev.evaluate(c.refId)
+	if err != nil {
+		return value{}, err
+	}
+
+	reduce := reducers[c.reducer]
+	res := value{items: make([]*item, 0, len(v.items))}
+	for _, it := range v.items {
+		reduced := &item{name: it.name, tags: it.tags, number: true, value: it.value}
+		if !it.number {
+			reduced.value = reduce(validValues(it.points))
+		}
+		res.items = append(res.items, reduced)
+	}
+	return res, nil
+}
+
+// resampleCommand changes the interval of the series of a query to a fixed window, from the
+// start to the end of the time range. A point is the result of the downsampler for the values of
+// its window, (t - window, t], or the result of the upsampler for the empty windows.
+type resampleCommand struct {
+	refId       string
+	window      time.Duration
+	downsampler string
+	upsampler   string
+}
+
+func (c *resampleCommand) execute(ev *evaluator) (value, error) {
+	v, err := ev.evaluate(c.refId)
+	if err != nil {
+		return value{}, err
+	}
+
+	from := float64(ev.timeRange.GetFromAsMsEpoch())
+	to := float64(ev.timeRange.GetToAsMsEpoch())
+	window := float64(c.window / time.Millisecond)
+	downsample := reducers[c.downsampler]
+
+	res := value{items: make([]*item, 0, len(v.items))}
+	for _, it := range v.items {
+		if it.number {
+			res.items = append(res.items, it)
+			continue
+		}
+
+		resampled := &item{name: it.name, tags: it.tags}
+		for t := from; t <= to; t += window {
+			var values []float64
+			for _, p := range it.points {
+				if p[1].Float64 > t-window && p[1].Float64 <= t && p[0].Valid {
+					values = append(values, p[0].Float64)
+				}
+			}
+
+			var f null.Float
+			if len(values) > 0 {
+				f = downsample(values)
+			} else {
+				f = c.upsample(it.points, t)
+			}
+			resampled.points = append(resampled.points, tsdb.NewTimePoint(f, t))
+		}
+		res.items = append(res.items, resampled)
+	}
+	return res, nil
+}
+
+func (c *resampleCommand) upsample(points tsdb.TimeSeriesPoints, t float64) null.Float {
+	switch c.upsampler {
+	case "pad":
+		f := null.FloatFromPtr(nil)
+		for _, p := range points {
+			if p[1].Float64 <= t && p[0].Valid {
+				f = p[0]
+			}
+		}
+		return f
+	case "backfilling":
+		for _, p := range points {
+			if p[1].Float64 > t && p[0].Valid {
+				return p[0]
+			}
+		}
+	}
+	return null.FloatFromPtr(nil)
+}
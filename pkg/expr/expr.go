@@ -0,0 +1,183 @@
+// Package expr runs the expression queries of a request, math, reduce and resample operations
+// over the results of the other queries of the request, possibly of different data sources.
+package expr
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+const (
+	// DatasourceName is the name and type of the data source of the expression queries
+	DatasourceName = "__expr__"
+	// DatasourceID is the id of the data source of the expression queries
+	DatasourceID int64 = -100
+)
+
+var logger = log.New("expr")
+
+func init() {
+	tsdb.RegisterTsdbQueryEndpoint(DatasourceName, func(dsInfo *models.DataSource) (tsdb.TsdbQueryEndpoint, error) {
+		return &ExpressionExecutor{HandleRequest: tsdb.HandleRequest}, nil
+	})
+}
+
+// DataSource returns the data source the requests with expression queries are sent to.
+func DataSource(orgID int64) *models.DataSource {
+	return &models.DataSource{
+		Id:    DatasourceID,
+		OrgId: orgID,
+		Name:  DatasourceName,
+		Type:  DatasourceName,
+	}
+}
+
+// IsExpressionQuery returns whether the model of a query is an expression.
+func IsExpressionQuery(model *simplejson.Json) bool {
+	return model.Get("datasource").MustString() == DatasourceName || model.Get("datasourceId").MustInt64() == DatasourceID
+}
+
+// ExpressionExecutor runs the data source queries of a request with the data sources of their
+// datasourceId, then evaluates its expression queries. The result of every query is returned.
+type ExpressionExecutor struct {
+	HandleRequest tsdb.HandleRequestFunc
+}
+
+func (e *ExpressionExecutor) Query(ctx context.Context, dsInfo *models.DataSource, req *tsdb.TsdbQuery) (*tsdb.Response, error) {
+	commands := make(map[string]command)
+	requests := make(map[int64]*tsdb.TsdbQuery)
+	dataSources := make(map[int64]*models.DataSource)
+
+	for _, query := range req.Queries {
+		if IsExpressionQuery(query.Model) {
+			cmd, err := parseCommand(query.Model)
+			if err != nil {
+				return nil, errutil.Wrapf(err, "invalid expression %s", query.RefId)
+			}
+			commands[query.RefId] = cmd
+			continue
+		}
+
+		ds := query.DataSource
+		if ds == nil || ds.Id == DatasourceID {
+			var err error
+			if ds, err = getDataSource(query.Model.Get("datasourceId").MustInt64(), dsInfo.OrgId); err != nil {
+				return nil, errutil.Wrapf(err, "failed to get the data source of query %s", query.RefId)
+			}
+		}
+
+		dsReq, ok := requests[ds.Id]
+		if !ok {
+			dsReq = &tsdb.TsdbQuery{
+				TimeRange: req.TimeRange,
+				Headers:   req.Headers,
+				Debug:     req.Debug,
+				User:      req.User,
+			}
+			requests[ds.Id] = dsReq
+			dataSources[ds.Id] = ds
+		}
+		query.DataSource = ds
+		dsReq.Queries = append(dsReq.Queries, query)
+	}
+
+	resp := &tsdb.Response{Results: make(map[string]*tsdb.QueryResult)}
+	for id, dsReq := range requests {
+		dsResp, err := e.HandleRequest(ctx, dataSources[id], dsReq)
+		if err != nil {
+			return nil, err
+		}
+		for refId, result := range dsResp.Results {
+			resp.Results[refId] = result
+		}
+	}
+
+	ev := &evaluator{
+		commands:  commands,
+		results:   resp.Results,
+		timeRange: req.TimeRange,
+		values:    make(map[string]value),
+		visiting:  make(map[string]bool),
+	}
+
+	refIds := make([]string, 0, len(commands))
+	for refId := range commands {
+		refIds = append(refIds, refId)
+	}
+	sort.Strings(refIds)
+
+	to := float64(req.TimeRange.GetToAsMsEpoch())
+	for _, refId := range refIds {
+		v, err := ev.evaluate(refId)
+		if err != nil {
+			logger.Debug("Expression failed", "refId", refId, "error", err)
+			resp.Results[refId] = &tsdb.QueryResult{RefId: refId, Error: err, ErrorString: err.Error()}
+			continue
+		}
+		resp.Results[refId] = &tsdb.QueryResult{RefId: refId, Series: v.toSeries(to)}
+	}
+	return resp, nil
+}
+
+func getDataSource(id int64, orgID int64) (*models.DataSource, error) {
+	query := &models.GetDataSourceByIdQuery{Id: id, OrgId: orgID}
+	if err := bus.Dispatch(query); err != nil {
+		return nil, err
+	}
+	return query.Result, nil
+}
+
+// evaluator evaluates the expressions of a request, once each, in the order they depend on
+// each other
+type evaluator struct {
+	commands  map[string]command
+	results   map[string]*tsdb.QueryResult
+	timeRange *tsdb.TimeRange
+	values    map[string]value
+	visiting  map[string]bool
+}
+
+// evaluate returns the value of a query, the result of a data source query or an expression
+func (ev *evaluator) evaluate(refId string) (value, error) {
+	if v, ok := ev.values[refId]; ok {
+		return v, nil
+	}
+
+	cmd, ok := ev.commands[refId]
+	if !ok {
+		result, ok := ev.results[refId]
+		if !ok {
+			return value{}, fmt.Errorf("query %s not found", refId)
+		}
+		if result.Error != nil {
+			return value{}, fmt.Errorf("query %s failed: %w", refId, result.Error)
+		}
+		v, err := valueFromResult(result)
+		if err != nil {
+			return value{}, err
+		}
+		ev.values[refId] = v
+		return v, nil
+	}
+
+	if ev.visiting[refId] {
+		return value{}, fmt.Errorf("expression %s depends on itself", refId)
+	}
+	ev.visiting[refId] = true
+	defer delete(ev.visiting, refId)
+
+	v, err := cmd.execute(ev)
+	if err != nil {
+		return value{}, err
+	}
+	ev.values[refId] = v
+	return v, nil
+}
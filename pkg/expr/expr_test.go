@@ -0,0 +1,158 @@
+package expr
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	prometheus = &models.DataSource{Id: 1, OrgId: 1, Type: "prometheus"}
+	graphite   = &models.DataSource{Id: 2, OrgId: 1, Type: "graphite"}
+)
+
+// fakeHandleRequest returns the series of the queries of the data sources
+func fakeHandleRequest(series map[string]tsdb.TimeSeriesSlice) tsdb.HandleRequestFunc {
+	return func(ctx context.Context, dsInfo *models.DataSource, req *tsdb.TsdbQuery) (*tsdb.Response, error) {
+		resp := &tsdb.Response{Results: make(map[string]*tsdb.QueryResult)}
+		for _, query := range req.Queries {
+			if query.DataSource.Id != dsInfo.Id {
+				return nil, fmt.Errorf("query %s sent to data source %d", query.RefId, dsInfo.Id)
+			}
+			resp.Results[query.RefId] = &tsdb.QueryResult{RefId: query.RefId, Series: series[query.RefId]}
+		}
+		return resp, nil
+	}
+}
+
+func newExpressionQuery(refId string, model map[string]interface{}) *tsdb.Query {
+	model["datasource"] = DatasourceName
+	return &tsdb.Query{RefId: refId, Model: simplejson.NewFromAny(model)}
+}
+
+func runExpressions(t *testing.T, series map[string]tsdb.TimeSeriesSlice, queries ...*tsdb.Query) *tsdb.Response {
+	t.Helper()
+
+	req := &tsdb.TsdbQuery{
+		TimeRange: tsdb.NewTimeRange("1000", "4000"),
+		Queries: append([]*tsdb.Query{
+			{RefId: "A", DataSource: prometheus, Model: simplejson.New()},
+			{RefId: "B", DataSource: graphite, Model: simplejson.New()},
+		}, queries...),
+	}
+	executor := &ExpressionExecutor{HandleRequest: fakeHandleRequest(series)}
+	resp, err := executor.Query(context.Background(), DataSource(1), req)
+	require.NoError(t, err)
+	return resp
+}
+
+// points returns the points of value and time pairs, nil values being null
+func points(values ...interface{}) tsdb.TimeSeriesPoints {
+	res := make(tsdb.TimeSeriesPoints, 0, len(values)/2)
+	for i := 0; i < len(values); i += 2 {
+		v := null.FloatFromPtr(nil)
+		if values[i] != nil {
+			v = null.FloatFrom(float64(values[i].(int)))
+		}
+		res = append(res, tsdb.NewTimePoint(v, float64(values[i+1].(int))))
+	}
+	return res
+}
+
+func TestExpressionExecutor(t *testing.T) {
+	series := map[string]tsdb.TimeSeriesSlice{
+		"A": {
+			{Name: "errors", Tags: map[string]string{"host": "a"}, Points: points(1, 1000, 4, 2000, 6, 3000)},
+			{Name: "errors", Tags: map[string]string{"host": "b"}, Points: points(2, 1000, 2, 2000, 2, 3000)},
+		},
+		"B": {
+			{Name: "requests", Tags: map[string]string{"host": "a"}, Points: points(10, 1000, 0, 2000)},
+			{Name: "requests", Tags: map[string]string{"host": "b"}, Points: points(20, 1000, 40, 2000)},
+		},
+	}
+
+	t.Run("Should return the results of the data source queries", func(t *testing.T) {
+		resp := runExpressions(t, series)
+		require.Equal(t, series["A"], resp.Results["A"].Series)
+		require.Equal(t, series["B"], resp.Results["B"].Series)
+	})
+
+	t.Run("Should combine the series of two data sources with the same tags", func(t *testing.T) {
+		resp := runExpressions(t, series, newExpressionQuery("C", map[string]interface{}{
+			"type":       "math",
+			"expression": "$A / $B * 100",
+		}))
+
+		result := resp.Results["C"]
+		require.NoError(t, result.Error)
+		require.Len(t, result.Series, 2)
+		require.Equal(t, map[string]string{"host": "a"}, result.Series[0].Tags)
+		// the points without a matching time are dropped, a division by zero is null
+		require.Equal(t, points(10, 1000, nil, 2000), result.Series[0].Points)
+		require.Equal(t, points(10, 1000, 5, 2000), result.Series[1].Points)
+	})
+
+	t.Run("Should reduce the series to numbers", func(t *testing.T) {
+		resp := runExpressions(t, series,
+			newExpressionQuery("C", map[string]interface{}{"type": "reduce", "expression": "$A", "reducer": "max"}),
+			newExpressionQuery("D", map[string]interface{}{"type": "math", "expression": "$C > 5"}),
+		)
+
+		require.Equal(t, points(6, 4000), resp.Results["C"].Series[0].Points)
+		require.Equal(t, points(2, 4000), resp.Results["C"].Series[1].Points)
+		require.Equal(t, points(1, 4000), resp.Results["D"].Series[0].Points)
+		require.Equal(t, points(0, 4000), resp.Results["D"].Series[1].Points)
+	})
+
+	t.Run("Should resample the series", func(t *testing.T) {
+		resp := runExpressions(t, series, newExpressionQuery("C", map[string]interface{}{
+			"type":        "resample",
+			"expression":  "B",
+			"rule":        "500ms",
+			"downsampler": "sum",
+			"upsampler":   "pad",
+		}))
+
+		result := resp.Results["C"]
+		require.NoError(t, result.Error)
+		require.Equal(t, points(10, 1000, 10, 1500, 0, 2000, 0, 2500, 0, 3000, 0, 3500, 0, 4000), result.Series[0].Points)
+	})
+
+	t.Run("Should evaluate functions and numbers", func(t *testing.T) {
+		resp := runExpressions(t, series, newExpressionQuery("C", map[string]interface{}{
+			"type":       "math",
+			"expression": "abs(-2 - 2) ** 2 % 5",
+		}))
+		require.Equal(t, points(1, 4000), resp.Results["C"].Series[0].Points)
+	})
+
+	t.Run("Should return the errors of the expressions", func(t *testing.T) {
+		resp := runExpressions(t, series,
+			newExpressionQuery("C", map[string]interface{}{"type": "math", "expression": "$D + 1"}),
+			newExpressionQuery("D", map[string]interface{}{"type": "math", "expression": "$C + 1"}),
+			newExpressionQuery("E", map[string]interface{}{"type": "math", "expression": "$F"}),
+		)
+		require.Error(t, resp.Results["C"].Error)
+		require.Error(t, resp.Results["D"].Error)
+		require.Error(t, resp.Results["E"].Error)
+	})
+
+	t.Run("Should fail with invalid expressions", func(t *testing.T) {
+		for _, model := range []map[string]interface{}{
+			{"type": "math", "expression": "$A +"},
+			{"type": "math", "expression": "unknown($A)"},
+			{"type": "reduce", "expression": "$A", "reducer": "median"},
+			{"type": "resample", "expression": "$A", "rule": "1x"},
+			{"type": "unknown"},
+		} {
+			_, err := parseCommand(simplejson.NewFromAny(model))
+			require.Error(t, err, "%v", model)
+		}
+	})
+}
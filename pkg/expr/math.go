@@ -0,0 +1,284 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/grafana/grafana/pkg/components/null"
+)
+
+// mathNode is a node of the syntax tree of a math expression
+type mathNode interface {
+	eval(ev *evaluator) (value, error)
+}
+
+type numberNode float64
+
+func (n numberNode) eval(ev *evaluator) (value, error) {
+	return scalarValue(null.FloatFrom(float64(n))), nil
+}
+
+// varNode refers to the value of a query, like $A
+type varNode string
+
+func (n varNode) eval(ev *evaluator) (value, error) {
+	return ev.evaluate(string(n))
+}
+
+type binaryNode struct {
+	op          func(a, b float64) float64
+	left, right mathNode
+}
+
+func (n *binaryNode) eval(ev *evaluator) (value, error) {
+	left, err := n.left.eval(ev)
+	if err != nil {
+		return value{}, err
+	}
+	right, err := n.right.eval(ev)
+	if err != nil {
+		return value{}, err
+	}
+	return binaryValue(n.op, left, right), nil
+}
+
+type funcNode struct {
+	fn  func(f float64) float64
+	arg mathNode
+}
+
+func (n *funcNode) eval(ev *evaluator) (value, error) {
+	arg, err := n.arg.eval(ev)
+	if err != nil {
+		return value{}, err
+	}
+	return mapValue(arg, n.fn), nil
+}
+
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// operators are the binary operators by precedence, from the lowest
+var operators = [][]struct {
+	token string
+	op    func(a, b float64) float64
+}{
+	{
+		{"||", func(a, b float64) float64 { return boolFloat(a != 0 || b != 0) }},
+	},
+	{
+		{"&&", func(a, b float64) float64 { return boolFloat(a != 0 && b != 0) }},
+	},
+	{
+		{"==", func(a, b float64) float64 { return boolFloat(a == b) }},
+		{"!=", func(a, b float64) float64 { return boolFloat(a != b) }},
+		{">=", func(a, b float64) float64 { return boolFloat(a >= b) }},
+		{"<=", func(a, b float64) float64 { return boolFloat(a <= b) }},
+		{">", func(a, b float64) float64 { return boolFloat(a > b) }},
+		{"<", func(a, b float64) float64 { return boolFloat(a < b) }},
+	},
+	{
+		{"+", func(a, b float64) float64 { return a + b }},
+		{"-", func(a, b float64) float64 { return a - b }},
+	},
+	{
+		{"*", func(a, b float64) float64 { return a * b }},
+		{"/", func(a, b float64) float64 { return a / b }},
+		{"%", math.Mod},
+	},
+	{
+		{"**", math.Pow},
+	},
+}
+
+var functions = map[string]func(f float64) float64{
+	"abs":   math.Abs,
+	"ceil":  math.Ceil,
+	"floor": math.Floor,
+	"round": math.Round,
+	"log":   math.Log,
+	"exp":   math.Exp,
+	"sqrt":  math.Sqrt,
+}
+
+// parseMath parses a math expression, like ($A + $B) / 2. Queries are referred to by $ and their
+// ref id, or ${ref id} for ref ids with spaces.
+func parseMath(s string) (mathNode, error) {
+	p := &mathParser{input: s}
+	node, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.input[p.pos:], p.pos)
+	}
+	return node, nil
+}
+
+type mathParser struct {
+	input string
+	pos   int
+}
+
+func (p *mathParser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *mathParser) parseBinary(level int) (mathNode, error) {
+	if level == len(operators) {
+		return p.parseUnary()
+	}
+
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		p.skipSpaces()
+		matched := false
+		for _, operator := range operators[level] {
+			if !strings.HasPrefix(p.input[p.pos:], operator.token) {
+				continue
+			}
+			// ** is the power operator, not a multiplication
+			if operator.token == "*" && strings.HasPrefix(p.input[p.pos:], "**") {
+				continue
+			}
+
+			p.pos += len(operator.token)
+			right, err := p.parseBinary(level + 1)
+			if err != nil {
+				return nil, err
+			}
+			left = &binaryNode{op: operator.op, left: left, right: right}
+			matched = true
+			break
+		}
+		if !matched {
+			return left, nil
+		}
+	}
+}
+
+func (p *mathParser) parseUnary() (mathNode, error) {
+	p.skipSpaces()
+	if p.pos < len(p.input) && p.input[p.pos] == '-' {
+		p.pos++
+		arg, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &funcNode{fn: func(f float64) float64 { return -f }, arg: arg}, nil
+	}
+	if p.pos < len(p.input) && p.input[p.pos] == '!' {
+		p.pos++
+		arg, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &funcNode{fn: func(f float64) float64 { return boolFloat(f == 0) }, arg: arg}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *mathParser) parsePrimary() (mathNode, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	c := p.input[p.pos]
+	switch {
+	case c == '(':
+		p.pos++
+		node, err := p.parseBinary(0)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case c == '$':
+		p.pos++
+		return p.parseVar()
+	case c == '.' || (c >= '0' && c <= '9'):
+		start := p.pos
+		for p.pos < len(p.input) && (p.input[p.pos] == '.' || p.input[p.pos] == 'e' ||
+			(p.input[p.pos] >= '0' && p.input[p.pos] <= '9')) {
+			p.pos++
+		}
+		f, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", p.input[start:p.pos])
+		}
+		return numberNode(f), nil
+	case unicode.IsLetter(rune(c)):
+		start := p.pos
+		for p.pos < len(p.input) && unicode.IsLetter(rune(p.input[p.pos])) {
+			p.pos++
+		}
+		name := p.input[start:p.pos]
+		fn, ok := functions[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown function %s", name)
+		}
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		arg, err := p.parseBinary(0)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return &funcNode{fn: fn, arg: arg}, nil
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", c, p.pos)
+}
+
+func (p *mathParser) parseVar() (mathNode, error) {
+	if p.pos < len(p.input) && p.input[p.pos] == '{' {
+		end := strings.IndexByte(p.input[p.pos:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed ${ at position %d", p.pos)
+		}
+		name := p.input[p.pos+1 : p.pos+end]
+		p.pos += end + 1
+		return varNode(name), nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) {
+		c := rune(p.input[p.pos])
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' {
+			break
+		}
+		p.pos++
+	}
+	if start == p.pos {
+		return nil, fmt.Errorf("missing ref id after $ at position %d", start)
+	}
+	return varNode(p.input[start:p.pos]), nil
+}
+
+func (p *mathParser) expect(c byte) error {
+	p.skipSpaces()
+	if p.pos >= len(p.input) || p.input[p.pos] != c {
+		return fmt.Errorf("expected %q at position %d", c, p.pos)
+	}
+	p.pos++
+	return nil
+}
@@ -0,0 +1,188 @@
+package expr
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// item is a series, or a number like the result of a reduce operation
+type item struct {
+	name   string
+	tags   map[string]string
+	points tsdb.TimeSeriesPoints
+	number bool
+	value  null.Float
+}
+
+// value is the value of a query or an expression. A scalar, like a number in an expression, is a
+// single number without tags.
+type value struct {
+	items []*item
+}
+
+func scalarValue(f null.Float) value {
+	return value{items: []*item{{number: true, value: f}}}
+}
+
+func valueFromResult(result *tsdb.QueryResult) (value, error) {
+	series := result.Series
+	if result.Dataframes != nil && len(series) == 0 {
+		frames, err := result.Dataframes.Decoded()
+		if err != nil {
+			return value{}, err
+		}
+		for _, frame := range frames {
+			frameSeries, err := tsdb.FrameToSeriesSlice(frame)
+			if err != nil {
+				return value{}, err
+			}
+			series = append(series, frameSeries...)
+		}
+	}
+
+	v := value{items: make([]*item, 0, len(series))}
+	for _, s := range series {
+		v.items = append(v.items, &item{name: s.Name, tags: s.Tags, points: s.Points})
+	}
+	return v, nil
+}
+
+// toSeries returns the series of the value, numbers being series with a single point at the end
+// of the time range
+func (v value) toSeries(to float64) tsdb.TimeSeriesSlice {
+	series := make(tsdb.TimeSeriesSlice, 0, len(v.items))
+	for _, it := range v.items {
+		s := &tsdb.TimeSeries{Name: it.name, Tags: it.tags, Points: it.points}
+		if it.number {
+			s.Points = tsdb.TimeSeriesPoints{{it.value, null.FloatFrom(to)}}
+		}
+		series = append(series, s)
+	}
+	return series
+}
+
+// mapValue applies a function to every number and point of the value
+func mapValue(v value, fn func(f float64) float64) value {
+	res := value{items: make([]*item, 0, len(v.items))}
+	for _, it := range v.items {
+		mapped := &item{name: it.name, tags: it.tags, number: it.number, value: applyFloat(fn, it.value)}
+		if !it.number {
+			mapped.points = make(tsdb.TimeSeriesPoints, 0, len(it.points))
+			for _, p := range it.points {
+				mapped.points = append(mapped.points, tsdb.TimePoint{applyFloat(fn, p[0]), p[1]})
+			}
+		}
+		res.items = append(res.items, mapped)
+	}
+	return res
+}
+
+func applyFloat(fn func(f float64) float64, f null.Float) null.Float {
+	if !f.Valid {
+		return f
+	}
+	return validFloat(fn(f.Float64))
+}
+
+// validFloat returns null for the results which aren't numbers, like a division by zero
+func validFloat(f float64) null.Float {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return null.FloatFromPtr(nil)
+	}
+	return null.FloatFrom(f)
+}
+
+// binaryValue applies an operator to two values. A value with a single item is combined with
+// every item of the other value, else the items with the same tags are combined.
+func binaryValue(op func(a, b float64) float64, left, right value) value {
+	res := value{}
+	switch {
+	case len(left.items) == 1:
+		for _, r := range right.items {
+			res.items = append(res.items, binaryItem(op, left.items[0], r))
+		}
+	case len(right.items) == 1:
+		for _, l := range left.items {
+			res.items = append(res.items, binaryItem(op, l, right.items[0]))
+		}
+	default:
+		for _, l := range left.items {
+			for _, r := range right.items {
+				if tagsKey(l.tags) == tagsKey(r.tags) {
+					res.items = append(res.items, binaryItem(op, l, r))
+				}
+			}
+		}
+	}
+	return res
+}
+
+func binaryItem(op func(a, b float64) float64, l, r *item) *item {
+	res := &item{name: l.name, tags: mergeTags(l.tags, r.tags)}
+	if l.name == "" || l.number && !r.number {
+		res.name = r.name
+	}
+
+	apply := func(a, b null.Float) null.Float {
+		if !a.Valid || !b.Valid {
+			return null.FloatFromPtr(nil)
+		}
+		return validFloat(op(a.Float64, b.Float64))
+	}
+
+	switch {
+	case l.number && r.number:
+		res.number = true
+		res.value = apply(l.value, r.value)
+	case l.number:
+		for _, p := range r.points {
+			res.points = append(res.points, tsdb.TimePoint{apply(l.value, p[0]), p[1]})
+		}
+	case r.number:
+		for _, p := range l.points {
+			res.points = append(res.points, tsdb.TimePoint{apply(p[0], r.value), p[1]})
+		}
+	default:
+		// the points of two series are combined when they have the same time
+		rightPoints := make(map[float64]null.Float, len(r.points))
+		for _, p := range r.points {
+			rightPoints[p[1].Float64] = p[0]
+		}
+		for _, p := range l.points {
+			if rv, ok := rightPoints[p[1].Float64]; ok {
+				res.points = append(res.points, tsdb.TimePoint{apply(p[0], rv), p[1]})
+			}
+		}
+	}
+	return res
+}
+
+func mergeTags(a, b map[string]string) map[string]string {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	merged := make(map[string]string, len(a)+len(b))
+	for k, v := range a {
+		merged[k] = v
+	}
+	for k, v := range b {
+		merged[k] = v
+	}
+	return merged
+}
+
+func tagsKey(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k, v := range tags {
+		keys = append(keys, k+"="+v)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
@@ -0,0 +1,420 @@
+// Package awsauth builds the credentials of the data sources calling AWS, with the credentials
+// providers and the config loaders of aws-sdk-go-v2. The data sources describe their credentials
+// with Settings, and ChainBuilder returns the provider chain resolving them: the keys, the role or
+// the web identity of the data source, falling back to the identity of Grafana itself from the
+// environment, the shared credentials file, ECS or EC2. SigV4Transport signs the HTTP requests of the
+// data sources calling an API behind an AWS service with these credentials.
+package awsauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/endpointcreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+)
+
+// AuthTypeARN is the auth type of the data sources assuming a role
+const AuthTypeARN = "arn"
+
+// roleSessionName is the session name of the roles assumed by the data sources
+const roleSessionName = "GrafanaSession"
+
+// maxChainedRoleDuration is the longest session of a role assumed with the credentials of another
+// assumed role, which AWS limits to one hour
+const maxChainedRoleDuration = time.Hour
+
+// remoteExpiryWindow is how long before they expire the credentials of ECS and EC2 are refreshed
+const remoteExpiryWindow = 5 * time.Minute
+
+// ecsCredentialsHost is the host of the container credentials endpoint of ECS
+const ecsCredentialsHost = "169.254.170.2"
+
+// Settings are the credentials of a data source
+type Settings struct {
+	// AuthType is arn when the data source assumes AssumeRoleArn
+	AuthType string
+	// Profile is the profile of the shared credentials file, the default one when empty
+	Profile   string
+	AccessKey string
+	SecretKey string
+
+	AssumeRoleArn      string
+	ExternalID         string
+	AssumeRoleDuration time.Duration
+
+	// IntermediateRoleArn is a role assumed before AssumeRoleArn, when it can only be assumed
+	// from another account
+	IntermediateRoleArn        string
+	IntermediateRoleExternalID string
+	IntermediateRoleDuration   time.Duration
+
+	// WebIdentityRoleArn and WebIdentityTokenFile are the web identity of the data source, which
+	// is then the only identity it uses. They default to AWS_ROLE_ARN and
+	// AWS_WEB_IDENTITY_TOKEN_FILE for the identity of Grafana.
+	WebIdentityRoleArn   string
+	WebIdentityTokenFile string
+
+	// Region is the region of the STS calls, whose endpoint is global when it's empty
+	Region string
+	// STSEndpoint is the endpoint of the STS calls, like a VPC or FIPS endpoint, the endpoint of
+	// the region when it's empty
+	STSEndpoint string
+}
+
+// STSClient calls STS to assume the roles and the web identities of the data sources
+type STSClient interface {
+	stscreds.AssumeRoleAPIClient
+	stscreds.AssumeRoleWithWebIdentityAPIClient
+}
+
+// ChainBuilder builds the credentials provider chains of the data sources. Its clients can be
+// replaced, like by tests.
+type ChainBuilder struct {
+	// NewSTSClient returns the STS client of the settings, signing its calls with creds
+	NewSTSClient func(s *Settings, creds aws.CredentialsProvider) STSClient
+	// IMDSClient gets the credentials of the instance profile of EC2
+	IMDSClient ec2rolecreds.GetMetadataAPIClient
+	// HTTPClient gets the credentials of the task role of ECS
+	HTTPClient *http.Client
+	// STSHTTPClient calls STS, the default client of the SDK when nil
+	STSHTTPClient *http.Client
+}
+
+// NewChainBuilder returns a builder calling AWS
+func NewChainBuilder() *ChainBuilder {
+	b := &ChainBuilder{
+		IMDSClient: imds.New(imds.Options{}),
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+	b.NewSTSClient = b.newSTSClient
+	return b
+}
+
+// NewHTTPClient returns an HTTP client for the calls to AWS whose connections are checked by control
+// before they're made, like the networks the data sources are blocked from
+func NewHTTPClient(control func(network, address string, c syscall.RawConn) error) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   control,
+	}).DialContext
+	return &http.Client{Transport: transport}
+}
+
+// IsAWSEndpoint returns whether an endpoint is an HTTPS endpoint of AWS, including the VPC and
+// FIPS endpoints, which the data sources configured by org admins are limited to
+func IsAWSEndpoint(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.User != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return strings.HasSuffix(host, ".amazonaws.com") || strings.HasSuffix(host, ".amazonaws.com.cn")
+}
+
+// Build returns the credentials provider of a data source, with when the credentials of the role
+// it assumes expire. The role is assumed right away, so that the data sources fail early when they
+// can't assume it.
+func (b *ChainBuilder) Build(ctx context.Context, s *Settings) (aws.CredentialsProvider, *time.Time, error) {
+	var providers ChainProvider
+	var expiration *time.Time
+	if s.AuthType == AuthTypeARN {
+		assumed, err := b.assumeRoles(ctx, s)
+		if err != nil {
+			return nil, nil, err
+		}
+		if assumed != nil {
+			providers = append(providers, credentials.NewStaticCredentialsProvider(aws.ToString(assumed.AccessKeyId),
+				aws.ToString(assumed.SecretAccessKey), aws.ToString(assumed.SessionToken)))
+			expiration = assumed.Expiration
+		}
+	}
+
+	providers = append(providers, b.providers(s)...)
+	return aws.NewCredentialsCache(providers), expiration, nil
+}
+
+// Provider returns the credentials provider of a data source like Build, for the clients keeping
+// it rather than building it again: its role is assumed when the credentials are first retrieved,
+// and again shortly before they expire.
+func (b *ChainBuilder) Provider(s *Settings) aws.CredentialsProvider {
+	if s.AuthType == AuthTypeARN {
+		return aws.NewCredentialsCache(roleProvider{builder: b, settings: s},
+			func(o *aws.CredentialsCacheOptions) { o.ExpiryWindow = remoteExpiryWindow })
+	}
+	return aws.NewCredentialsCache(ChainProvider(b.providers(s)),
+		func(o *aws.CredentialsCacheOptions) { o.ExpiryWindow = remoteExpiryWindow })
+}
+
+// providers returns the providers of the credentials of a data source other than its role: the
+// environment and its keys, unless it has a web identity of its own, then the identity of Grafana
+func (b *ChainBuilder) providers(s *Settings) []aws.CredentialsProvider {
+	var providers []aws.CredentialsProvider
+	if s.WebIdentityRoleArn == "" {
+		providers = append(providers, EnvProvider{},
+			credentials.NewStaticCredentialsProvider(s.AccessKey, s.SecretKey, ""))
+	}
+	return append(providers, b.DefaultProviders(s)...)
+}
+
+// assumeRoles assumes the role of a data source with the identity of Grafana, through its
+// intermediate role if it has one
+func (b *ChainBuilder) assumeRoles(ctx context.Context, s *Settings) (*types.Credentials, error) {
+	var creds aws.CredentialsProvider = aws.NewCredentialsCache(ChainProvider(b.DefaultProviders(s)))
+	input := &sts.AssumeRoleInput{
+		RoleArn:         aws.String(s.AssumeRoleArn),
+		RoleSessionName: aws.String(roleSessionName),
+		DurationSeconds: aws.Int32(int32(s.AssumeRoleDuration.Seconds())),
+	}
+	if s.ExternalID != "" {
+		input.ExternalId = aws.String(s.ExternalID)
+	}
+
+	if s.IntermediateRoleArn != "" {
+		intermediateInput := &sts.AssumeRoleInput{
+			RoleArn:         aws.String(s.IntermediateRoleArn),
+			RoleSessionName: aws.String(roleSessionName),
+			DurationSeconds: aws.Int32(int32(s.IntermediateRoleDuration.Seconds())),
+		}
+		if s.IntermediateRoleExternalID != "" {
+			intermediateInput.ExternalId = aws.String(s.IntermediateRoleExternalID)
+		}
+
+		intermediate, err := b.AssumeRole(ctx, s, creds, intermediateInput)
+		if err != nil {
+			return nil, fmt.Errorf("failed to assume intermediate role %s: %w", s.IntermediateRoleArn, err)
+		}
+		if intermediate == nil {
+			return nil, fmt.Errorf("failed to assume intermediate role %s: no credentials returned", s.IntermediateRoleArn)
+		}
+		creds = credentials.NewStaticCredentialsProvider(aws.ToString(intermediate.AccessKeyId),
+			aws.ToString(intermediate.SecretAccessKey), aws.ToString(intermediate.SessionToken))
+
+		if s.AssumeRoleDuration > maxChainedRoleDuration {
+			input.DurationSeconds = aws.Int32(int32(maxChainedRoleDuration.Seconds()))
+		}
+	}
+
+	return b.AssumeRole(ctx, s, creds, input)
+}
+
+// AssumeRole assumes a role with the given credentials, returning the credentials of the role
+func (b *ChainBuilder) AssumeRole(ctx context.Context, s *Settings, creds aws.CredentialsProvider, input *sts.AssumeRoleInput) (*types.Credentials, error) {
+	resp, err := b.NewSTSClient(s, creds).AssumeRole(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Credentials, nil
+}
+
+// DefaultProviders returns the providers of the credentials of Grafana itself. A data source with
+// a web identity of its own only uses it, so that it never falls back to the identity of Grafana.
+func (b *ChainBuilder) DefaultProviders(s *Settings) []aws.CredentialsProvider {
+	if s.WebIdentityRoleArn != "" {
+		return []aws.CredentialsProvider{b.WebIdentityProvider(s)}
+	}
+
+	return []aws.CredentialsProvider{
+		EnvProvider{},
+		SharedCredentialsProvider{Profile: s.Profile},
+		b.WebIdentityProvider(s),
+		b.RemoteProvider(),
+	}
+}
+
+// WebIdentityProvider returns the provider of the web identity of the data source, defaulting to
+// the role and token file of the environment
+func (b *ChainBuilder) WebIdentityProvider(s *Settings) aws.CredentialsProvider {
+	roleARN := os.Getenv("AWS_ROLE_ARN")
+	if s.WebIdentityRoleArn != "" {
+		roleARN = s.WebIdentityRoleArn
+	}
+	tokenFile := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+	if s.WebIdentityTokenFile != "" {
+		tokenFile = s.WebIdentityTokenFile
+	}
+	if roleARN == "" || tokenFile == "" {
+		return unavailableProvider("no web identity role ARN or token file")
+	}
+
+	return stscreds.NewWebIdentityRoleProvider(b.NewSTSClient(s, aws.AnonymousCredentials{}), roleARN,
+		stscreds.IdentityTokenFile(tokenFile), func(o *stscreds.WebIdentityRoleOptions) {
+			o.RoleSessionName = os.Getenv("AWS_ROLE_SESSION_NAME")
+		})
+}
+
+// RemoteProvider returns the provider of the task role of ECS when Grafana runs in a container of
+// ECS, and of the instance profile of EC2 otherwise
+func (b *ChainBuilder) RemoteProvider() aws.CredentialsProvider {
+	if uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); uri != "" {
+		return aws.NewCredentialsCache(endpointcreds.New(fmt.Sprintf("http://%s%s", ecsCredentialsHost, uri),
+			func(o *endpointcreds.Options) { o.HTTPClient = b.HTTPClient }),
+			func(o *aws.CredentialsCacheOptions) { o.ExpiryWindow = remoteExpiryWindow })
+	}
+	return aws.NewCredentialsCache(ec2rolecreds.New(func(o *ec2rolecreds.Options) { o.Client = b.IMDSClient }),
+		func(o *aws.CredentialsCacheOptions) { o.ExpiryWindow = remoteExpiryWindow })
+}
+
+// newSTSClient returns an STS client calling the endpoint of the settings
+func (b *ChainBuilder) newSTSClient(s *Settings, creds aws.CredentialsProvider) STSClient {
+	options := sts.Options{
+		Region:      STSRegion(s),
+		Credentials: creds,
+	}
+	if b.STSHTTPClient != nil {
+		options.HTTPClient = b.STSHTTPClient
+	}
+	if s.STSEndpoint != "" {
+		options.EndpointResolver = sts.EndpointResolverFromURL(s.STSEndpoint)
+	}
+	return sts.New(options)
+}
+
+// legacyGlobalSTSRegions are the regions whose STS calls go to the global endpoint unless
+// AWS_STS_REGIONAL_ENDPOINTS is regional, like with the SDKs which predate the regional endpoints
+var legacyGlobalSTSRegions = map[string]bool{
+	"ap-northeast-1": true,
+	"ap-south-1":     true,
+	"ap-southeast-1": true,
+	"ap-southeast-2": true,
+	"ca-central-1":   true,
+	"eu-central-1":   true,
+	"eu-north-1":     true,
+	"eu-west-1":      true,
+	"eu-west-2":      true,
+	"eu-west-3":      true,
+	"sa-east-1":      true,
+	"us-east-1":      true,
+	"us-east-2":      true,
+	"us-west-1":      true,
+	"us-west-2":      true,
+}
+
+// STSRegion returns the region of the STS calls of the settings, aws-global for the global
+// endpoint. The STS endpoint of the settings is signed for their region, us-east-1 by default.
+func STSRegion(s *Settings) string {
+	if s.STSEndpoint != "" {
+		if s.Region == "" {
+			return "us-east-1"
+		}
+		return s.Region
+	}
+	if s.Region == "" {
+		return "aws-global"
+	}
+	if legacyGlobalSTSRegions[s.Region] && !strings.EqualFold(os.Getenv("AWS_STS_REGIONAL_ENDPOINTS"), "regional") {
+		return "aws-global"
+	}
+	return s.Region
+}
+
+// ChainProvider returns the credentials of the first of its providers which has some
+type ChainProvider []aws.CredentialsProvider
+
+// Retrieve returns the credentials of the first provider which has some, or the errors of all of
+// them
+func (c ChainProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	errs := make([]string, 0, len(c))
+	for _, provider := range c {
+		creds, err := provider.Retrieve(ctx)
+		if err == nil {
+			return creds, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	return aws.Credentials{}, fmt.Errorf("no valid credentials: %s", strings.Join(errs, "; "))
+}
+
+// EnvProvider returns the credentials of the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN environment variables
+type EnvProvider struct{}
+
+// Retrieve returns the credentials of the environment
+func (EnvProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	env, err := config.NewEnvConfig()
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+	if !env.Credentials.HasKeys() {
+		return aws.Credentials{}, errors.New("no AWS credentials in the environment")
+	}
+	return env.Credentials, nil
+}
+
+// SharedCredentialsProvider returns the credentials of a profile of the shared credentials and
+// config files, the default profile when Profile is empty
+type SharedCredentialsProvider struct {
+	Profile string
+}
+
+// Retrieve returns the credentials of the profile
+func (p SharedCredentialsProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	profile := p.Profile
+	if profile == "" {
+		profile = os.Getenv("AWS_PROFILE")
+	}
+	if profile == "" {
+		profile = "default"
+	}
+
+	shared, err := config.LoadSharedConfigProfile(ctx, profile)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+	if !shared.Credentials.HasKeys() {
+		return aws.Credentials{}, fmt.Errorf("no AWS credentials in profile %q", profile)
+	}
+	return shared.Credentials, nil
+}
+
+// roleProvider returns the credentials of the role of a data source, assuming it on each call
+type roleProvider struct {
+	builder  *ChainBuilder
+	settings *Settings
+}
+
+// Retrieve assumes the role of the data source
+func (p roleProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	assumed, err := p.builder.assumeRoles(ctx, p.settings)
+	if err != nil {
+		return aws.Credentials{}, err
+	}
+	if assumed == nil {
+		return aws.Credentials{}, fmt.Errorf("failed to assume role %s: no credentials returned", p.settings.AssumeRoleArn)
+	}
+	creds := aws.Credentials{
+		AccessKeyID:     aws.ToString(assumed.AccessKeyId),
+		SecretAccessKey: aws.ToString(assumed.SecretAccessKey),
+		SessionToken:    aws.ToString(assumed.SessionToken),
+		Source:          "AssumeRoleProvider",
+	}
+	if assumed.Expiration != nil {
+		creds.CanExpire = true
+		creds.Expires = *assumed.Expiration
+	}
+	return creds, nil
+}
+
+// unavailableProvider is a provider which has no credentials
+type unavailableProvider string
+
+func (p unavailableProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	return aws.Credentials{}, errors.New(string(p))
+}
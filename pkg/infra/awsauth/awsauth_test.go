@@ -0,0 +1,339 @@
+package awsauth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSTSClient struct {
+	inputs []*sts.AssumeRoleInput
+	err    error
+}
+
+func (c *fakeSTSClient) AssumeRole(ctx context.Context, input *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	c.inputs = append(c.inputs, input)
+	if c.err != nil {
+		return nil, c.err
+	}
+	expiration := time.Now().Add(time.Hour)
+	return &sts.AssumeRoleOutput{Credentials: &types.Credentials{
+		AccessKeyId:     aws.String("assumed"),
+		SecretAccessKey: aws.String("assumed-secret"),
+		SessionToken:    aws.String("assumed-token"),
+		Expiration:      &expiration,
+	}}, nil
+}
+
+func (c *fakeSTSClient) AssumeRoleWithWebIdentity(ctx context.Context, input *sts.AssumeRoleWithWebIdentityInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleWithWebIdentityOutput, error) {
+	return nil, errors.New("web identity not supported")
+}
+
+// fakeIMDSClient returns the credentials of an instance profile
+type fakeIMDSClient struct {
+	paths []string
+}
+
+func (c *fakeIMDSClient) GetMetadata(ctx context.Context, input *imds.GetMetadataInput, optFns ...func(*imds.Options)) (*imds.GetMetadataOutput, error) {
+	c.paths = append(c.paths, input.Path)
+	content := "grafana"
+	if input.Path != "/iam/security-credentials/" {
+		content = `{"Code": "Success", "AccessKeyId": "ec2", "SecretAccessKey": "ec2-secret", "Token": "ec2-token", "Expiration": "2100-01-01T00:00:00Z"}`
+	}
+	return &imds.GetMetadataOutput{Content: ioutil.NopCloser(bytes.NewBufferString(content))}, nil
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+// setEnv sets environment variables for the duration of a test
+func setEnv(t *testing.T, env map[string]string) {
+	for name, value := range env {
+		orig, ok := os.LookupEnv(name)
+		require.NoError(t, os.Setenv(name, value))
+		name := name
+		t.Cleanup(func() {
+			if ok {
+				os.Setenv(name, orig)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+	}
+}
+
+// noEnvCredentials clears the credentials of the environment for the duration of a test
+func noEnvCredentials(t *testing.T) {
+	setEnv(t, map[string]string{
+		"AWS_ACCESS_KEY_ID":     "",
+		"AWS_SECRET_ACCESS_KEY": "",
+		"AWS_ACCESS_KEY":        "",
+		"AWS_SECRET_KEY":        "",
+		"AWS_SESSION_TOKEN":     "",
+	})
+}
+
+func newTestBuilder(client *fakeSTSClient) *ChainBuilder {
+	return &ChainBuilder{
+		NewSTSClient: func(*Settings, aws.CredentialsProvider) STSClient { return client },
+		IMDSClient:   &fakeIMDSClient{},
+	}
+}
+
+func TestChainBuilder_Build(t *testing.T) {
+	noEnvCredentials(t)
+
+	t.Run("Should assume the role of the data source", func(t *testing.T) {
+		client := &fakeSTSClient{}
+		provider, expiration, err := newTestBuilder(client).Build(context.Background(), &Settings{
+			AuthType:           AuthTypeARN,
+			AssumeRoleArn:      "arn:aws:iam::123456789012:role/monitoring",
+			AssumeRoleDuration: 30 * time.Minute,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, expiration)
+		require.Len(t, client.inputs, 1)
+		assert.Equal(t, int32(1800), *client.inputs[0].DurationSeconds)
+
+		creds, err := provider.Retrieve(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "assumed", creds.AccessKeyID)
+		assert.Equal(t, "assumed-token", creds.SessionToken)
+	})
+
+	t.Run("Should cap the session of a role assumed through an intermediate role", func(t *testing.T) {
+		client := &fakeSTSClient{}
+		_, _, err := newTestBuilder(client).Build(context.Background(), &Settings{
+			AuthType:                 AuthTypeARN,
+			AssumeRoleArn:            "arn:aws:iam::222222222222:role/monitoring",
+			AssumeRoleDuration:       4 * time.Hour,
+			IntermediateRoleArn:      "arn:aws:iam::111111111111:role/bastion",
+			IntermediateRoleDuration: 15 * time.Minute,
+		})
+		require.NoError(t, err)
+		require.Len(t, client.inputs, 2)
+		assert.Equal(t, "arn:aws:iam::111111111111:role/bastion", *client.inputs[0].RoleArn)
+		assert.Equal(t, int32(900), *client.inputs[0].DurationSeconds)
+		assert.Equal(t, int32(3600), *client.inputs[1].DurationSeconds)
+	})
+
+	t.Run("Should fail when the role can't be assumed", func(t *testing.T) {
+		client := &fakeSTSClient{err: errors.New("access denied")}
+		_, _, err := newTestBuilder(client).Build(context.Background(), &Settings{AuthType: AuthTypeARN})
+		require.EqualError(t, err, "access denied")
+	})
+
+	t.Run("Should use the keys of the data source", func(t *testing.T) {
+		provider, expiration, err := newTestBuilder(&fakeSTSClient{}).Build(context.Background(), &Settings{
+			AccessKey: "key",
+			SecretKey: "secret",
+		})
+		require.NoError(t, err)
+		assert.Nil(t, expiration)
+
+		creds, err := provider.Retrieve(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "key", creds.AccessKeyID)
+		assert.Equal(t, "secret", creds.SecretAccessKey)
+	})
+
+	t.Run("Should prefer the credentials of the environment", func(t *testing.T) {
+		setEnv(t, map[string]string{"AWS_ACCESS_KEY_ID": "env", "AWS_SECRET_ACCESS_KEY": "env-secret"})
+
+		provider, _, err := newTestBuilder(&fakeSTSClient{}).Build(context.Background(), &Settings{
+			AccessKey: "key",
+			SecretKey: "secret",
+		})
+		require.NoError(t, err)
+		creds, err := provider.Retrieve(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "env", creds.AccessKeyID)
+	})
+}
+
+func TestChainBuilder_Provider(t *testing.T) {
+	noEnvCredentials(t)
+
+	t.Run("Should assume the role of the data source once retrieved", func(t *testing.T) {
+		client := &fakeSTSClient{}
+		provider := newTestBuilder(client).Provider(&Settings{
+			AuthType:           AuthTypeARN,
+			AssumeRoleArn:      "arn:aws:iam::123456789012:role/monitoring",
+			AssumeRoleDuration: 15 * time.Minute,
+		})
+		assert.Empty(t, client.inputs)
+
+		creds, err := provider.Retrieve(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "assumed", creds.AccessKeyID)
+		assert.True(t, creds.CanExpire)
+		_, err = provider.Retrieve(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, client.inputs, 1)
+	})
+
+	t.Run("Should fail when the role can't be assumed", func(t *testing.T) {
+		provider := newTestBuilder(&fakeSTSClient{err: errors.New("access denied")}).Provider(&Settings{AuthType: AuthTypeARN})
+		_, err := provider.Retrieve(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "access denied")
+	})
+
+	t.Run("Should use the keys of the data source", func(t *testing.T) {
+		provider := newTestBuilder(&fakeSTSClient{}).Provider(&Settings{AccessKey: "key", SecretKey: "secret"})
+		creds, err := provider.Retrieve(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "key", creds.AccessKeyID)
+	})
+}
+
+func TestChainBuilder_DefaultProviders(t *testing.T) {
+	b := newTestBuilder(&fakeSTSClient{})
+
+	t.Run("Data sources with a web identity only use it", func(t *testing.T) {
+		providers := b.DefaultProviders(&Settings{
+			WebIdentityRoleArn:   "arn:aws:iam::123456789012:role/tenant-a",
+			WebIdentityTokenFile: "/var/run/secrets/tenant-a/token",
+		})
+		require.Len(t, providers, 1)
+	})
+
+	t.Run("Data sources without a web identity use the identity of Grafana", func(t *testing.T) {
+		providers := b.DefaultProviders(&Settings{})
+		require.Len(t, providers, 4)
+	})
+}
+
+func TestChainBuilder_RemoteProvider(t *testing.T) {
+	t.Run("Should get the credentials of the ECS task role", func(t *testing.T) {
+		setEnv(t, map[string]string{"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI": "/abc/123"})
+
+		var endpoint string
+		b := &ChainBuilder{HTTPClient: &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			endpoint = req.URL.String()
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body: ioutil.NopCloser(bytes.NewBufferString(
+					`{"AccessKeyId": "ecs", "SecretAccessKey": "ecs-secret", "Token": "ecs-token", "Expiration": "2100-01-01T00:00:00Z"}`)),
+			}, nil
+		})}}
+
+		creds, err := b.RemoteProvider().Retrieve(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "http://169.254.170.2/abc/123", endpoint)
+		assert.Equal(t, "ecs", creds.AccessKeyID)
+	})
+
+	t.Run("Should get the credentials of the EC2 instance profile", func(t *testing.T) {
+		setEnv(t, map[string]string{"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI": ""})
+
+		client := &fakeIMDSClient{}
+		b := &ChainBuilder{IMDSClient: client}
+
+		creds, err := b.RemoteProvider().Retrieve(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "ec2", creds.AccessKeyID)
+		assert.Equal(t, []string{"/iam/security-credentials/", "/iam/security-credentials/grafana"}, client.paths)
+		// the credentials are refreshed before they expire
+		assert.True(t, creds.Expires.Before(time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)))
+	})
+}
+
+func TestSTSRegion(t *testing.T) {
+	setEnv(t, map[string]string{"AWS_STS_REGIONAL_ENDPOINTS": ""})
+
+	t.Run("Should use the global endpoint for the legacy regions", func(t *testing.T) {
+		assert.Equal(t, "aws-global", STSRegion(&Settings{}))
+		assert.Equal(t, "aws-global", STSRegion(&Settings{Region: "eu-west-2"}))
+		assert.Equal(t, "cn-north-1", STSRegion(&Settings{Region: "cn-north-1"}))
+		assert.Equal(t, "af-south-1", STSRegion(&Settings{Region: "af-south-1"}))
+	})
+
+	t.Run("Should honor AWS_STS_REGIONAL_ENDPOINTS", func(t *testing.T) {
+		setEnv(t, map[string]string{"AWS_STS_REGIONAL_ENDPOINTS": "regional"})
+		assert.Equal(t, "eu-west-2", STSRegion(&Settings{Region: "eu-west-2"}))
+	})
+
+	t.Run("Should sign the calls to the STS endpoint for its region", func(t *testing.T) {
+		assert.Equal(t, "us-gov-west-1", STSRegion(&Settings{
+			Region:      "us-gov-west-1",
+			STSEndpoint: "https://vpce-0123456789abcdef-abcdefgh.sts.us-gov-west-1.vpce.amazonaws.com",
+		}))
+		assert.Equal(t, "us-east-1", STSRegion(&Settings{STSEndpoint: "https://sts-fips.us-east-1.amazonaws.com"}))
+	})
+}
+
+func TestIsAWSEndpoint(t *testing.T) {
+	for _, endpoint := range []string{
+		"https://sts.amazonaws.com",
+		"https://sts-fips.us-east-1.amazonaws.com",
+		"https://vpce-0123456789abcdef-abcdefgh.sts.eu-west-2.vpce.amazonaws.com",
+		"https://sts.cn-north-1.amazonaws.com.cn",
+	} {
+		assert.True(t, IsAWSEndpoint(endpoint), endpoint)
+	}
+
+	for _, endpoint := range []string{
+		"http://sts.amazonaws.com",
+		"https://169.254.169.254",
+		"https://sts.amazonaws.com.example.com",
+		"https://user@sts.amazonaws.com",
+		"sts.amazonaws.com",
+	} {
+		assert.False(t, IsAWSEndpoint(endpoint), endpoint)
+	}
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	blocked := errors.New("blocked")
+	client := NewHTTPClient(func(network, address string, c syscall.RawConn) error {
+		return blocked
+	})
+	_, err := client.Get(server.URL)
+	require.True(t, errors.Is(err, blocked))
+}
+
+func TestNewV1Credentials(t *testing.T) {
+	expires := time.Now().Add(-time.Minute)
+	creds := NewV1Credentials(aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		return aws.Credentials{
+			AccessKeyID:     "key",
+			SecretAccessKey: "secret",
+			SessionToken:    "token",
+			CanExpire:       true,
+			Expires:         expires,
+		}, nil
+	}))
+
+	value, err := creds.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "key", value.AccessKeyID)
+	assert.Equal(t, "token", value.SessionToken)
+	assert.True(t, creds.IsExpired())
+
+	expires = time.Now().Add(time.Hour)
+	_, err = creds.Get()
+	require.NoError(t, err)
+	assert.False(t, creds.IsExpired())
+}
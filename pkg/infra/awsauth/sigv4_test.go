@@ -0,0 +1,70 @@
+package awsauth
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigV4Transport(t *testing.T) {
+	var sent []*http.Request
+	var sentBodies []string
+	next := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body := ""
+		if req.Body != nil {
+			data, err := ioutil.ReadAll(req.Body)
+			require.NoError(t, err)
+			body = string(data)
+		}
+		sent = append(sent, req)
+		sentBodies = append(sentBodies, body)
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	})
+	creds := aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"}, nil
+	})
+	transport := NewSigV4Transport(next, creds, "aps", "eu-west-1")
+
+	t.Run("Should sign the requests and keep their body", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "https://aps-workspaces.eu-west-1.amazonaws.com/workspaces/ws-1/api/v1/query",
+			strings.NewReader("query=up"))
+		require.NoError(t, err)
+		req.SetBasicAuth("user", "pass")
+
+		_, err = transport.RoundTrip(req)
+		require.NoError(t, err)
+		require.Len(t, sent, 1)
+		auth := sent[0].Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/"), auth)
+		assert.Contains(t, auth, "/eu-west-1/aps/aws4_request")
+		assert.Equal(t, "token", sent[0].Header.Get("X-Amz-Security-Token"))
+		assert.Equal(t, "query=up", sentBodies[0])
+		// the request of the caller isn't modified
+		assert.Empty(t, req.Header.Get("X-Amz-Date"))
+	})
+
+	t.Run("Should sign the requests without body", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "https://search-logs.eu-west-1.es.amazonaws.com/_search", nil)
+		require.NoError(t, err)
+		_, err = transport.RoundTrip(req)
+		require.NoError(t, err)
+		assert.NotEmpty(t, sent[len(sent)-1].Header.Get("X-Amz-Date"))
+	})
+
+	t.Run("Should fail without credentials", func(t *testing.T) {
+		failing := NewSigV4Transport(next, aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{}, errors.New("no valid credentials")
+		}), "es", "eu-west-1")
+		req, err := http.NewRequest(http.MethodGet, "https://search-logs.eu-west-1.es.amazonaws.com/_search", nil)
+		require.NoError(t, err)
+		_, err = failing.RoundTrip(req)
+		require.EqualError(t, err, "failed to get the AWS credentials: no valid credentials")
+	})
+}
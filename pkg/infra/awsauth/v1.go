@@ -0,0 +1,47 @@
+package awsauth
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// v1ProviderName is the provider name of the credentials returned to aws-sdk-go
+const v1ProviderName = "GrafanaAWSAuth"
+
+// v1Provider is an aws-sdk-go credentials provider returning the credentials of an aws-sdk-go-v2
+// provider, for the clients which weren't migrated yet
+type v1Provider struct {
+	provider aws.CredentialsProvider
+	creds    aws.Credentials
+}
+
+// NewV1Credentials returns the aws-sdk-go credentials of an aws-sdk-go-v2 credentials provider
+func NewV1Credentials(provider aws.CredentialsProvider) *credentials.Credentials {
+	return credentials.NewCredentials(&v1Provider{provider: provider})
+}
+
+// Retrieve returns the credentials of the provider
+func (p *v1Provider) Retrieve() (credentials.Value, error) {
+	creds, err := p.provider.Retrieve(context.Background())
+	if err != nil {
+		return credentials.Value{ProviderName: v1ProviderName}, err
+	}
+	p.creds = creds
+	return credentials.Value{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		ProviderName:    v1ProviderName,
+	}, nil
+}
+
+// IsExpired returns whether the credentials returned last expired
+func (p *v1Provider) IsExpired() bool {
+	if p.creds.AccessKeyID == "" {
+		return true
+	}
+	return p.creds.CanExpire && !p.creds.Expires.After(time.Now())
+}
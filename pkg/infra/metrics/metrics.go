@@ -24,6 +24,42 @@ var (
 	// MProxyStatus is a metric proxy http response status
 	MProxyStatus *prometheus.CounterVec
 
+	// MDataSourceProxyStatus is a metric counter of dataproxy http response status, labeled by datasource type and uid
+	MDataSourceProxyStatus *prometheus.CounterVec
+
+	// MDataSourceQueryErrors is a metric counter of failed datasource queries, labeled by datasource type and uid
+	MDataSourceQueryErrors *prometheus.CounterVec
+
+	// MDataSourceQueryTruncated is a metric counter of datasource query results truncated to the query limits, labeled by datasource type and uid
+	MDataSourceQueryTruncated *prometheus.CounterVec
+
+	// MDataSourcePanelSlowQueries is a metric counter of dashboard panel queries slower than the slow query threshold, labeled by datasource type and uid
+	MDataSourcePanelSlowQueries *prometheus.CounterVec
+
+	// MDataSourcePanelBudgetExceeded is a metric counter of dashboard panel queries cancelled when exceeding the panel execution budget, labeled by datasource type and uid
+	MDataSourcePanelBudgetExceeded *prometheus.CounterVec
+
+	// MDataSourceQueriesDenied is a metric counter of datasource queries denied by a query rule, labeled by rule and datasource type
+	MDataSourceQueriesDenied *prometheus.CounterVec
+
+	// MApiRateLimited is a metric counter of api requests throttled by a rate limit, labeled by limit
+	MApiRateLimited *prometheus.CounterVec
+
+	// MUsageEventsExported is a metric counter of usage events exported, labeled by sink and status
+	MUsageEventsExported *prometheus.CounterVec
+
+	// MRetentionDeletedRows is a metric counter of rows deleted by the retention cleanup, labeled by kind
+	MRetentionDeletedRows *prometheus.CounterVec
+
+	// MRetentionPendingRows is a metric of rows beyond retention left for the next cleanup, labeled by kind
+	MRetentionPendingRows *prometheus.GaugeVec
+
+	// MRetentionCleanupDuration is a metric histogram of the duration of the retention cleanups, labeled by kind
+	MRetentionCleanupDuration *prometheus.HistogramVec
+
+	// MUsageEventsDropped is a metric counter of usage events dropped as the buffer was full
+	MUsageEventsDropped prometheus.Counter
+
 	// MHttpRequestTotal is a metric http request counter
 	MHttpRequestTotal *prometheus.CounterVec
 
@@ -93,6 +129,9 @@ var (
 	// MAwsCloudWatchGetMetricData is a metric counter for getting metric data time series from aws
 	MAwsCloudWatchGetMetricData prometheus.Counter
 
+	// MAwsCloudWatchGetMetricDataCached is a metric counter for metric data time series served from the cache instead of aws
+	MAwsCloudWatchGetMetricDataCached prometheus.Counter
+
 	// MDBDataSourceQueryByID is a metric counter for getting datasource by id
 	MDBDataSourceQueryByID prometheus.Counter
 
@@ -116,6 +155,18 @@ var (
 
 	// MRenderingSummary is a metric summary for image rendering request duration
 	MRenderingSummary *prometheus.SummaryVec
+
+	// MDataSourceProxyDuration is a metric histogram of dataproxy request duration, labeled by datasource type and uid
+	MDataSourceProxyDuration *prometheus.HistogramVec
+
+	// MDataSourceQueryDuration is a metric histogram of datasource query duration, labeled by datasource type and uid
+	MDataSourceQueryDuration *prometheus.HistogramVec
+
+	// MDataSourceQuerySeries is a metric histogram of the number of series returned by datasource queries, labeled by datasource type and uid
+	MDataSourceQuerySeries *prometheus.HistogramVec
+
+	// MDataSourceQueryResponseBytes is a metric histogram of the size of datasource query responses, labeled by datasource type and uid
+	MDataSourceQueryResponseBytes *prometheus.HistogramVec
 )
 
 // StatTotals
@@ -202,6 +253,45 @@ func init() {
 			Namespace: ExporterName,
 		}, []string{"code"}, httpStatusCodes...)
 
+	MApiRateLimited = newCounterVecStartingAtZero(
+		prometheus.CounterOpts{
+			Name:      "api_rate_limited_total",
+			Help:      "counter of api requests throttled by the rate limit of an org, a user or an api key",
+			Namespace: ExporterName,
+		}, []string{"limit"}, "org", "user", "api_key")
+
+	MUsageEventsExported = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "usage_events_exported_total",
+			Help:      "counter of usage events exported to a sink, by status",
+			Namespace: ExporterName,
+		}, []string{"sink", "status"})
+
+	MRetentionDeletedRows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "retention_deleted_rows_total",
+		Help:      "counter of rows deleted by the retention cleanup, labeled by kind",
+		Namespace: ExporterName,
+	}, []string{"kind"})
+
+	MRetentionPendingRows = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "retention_pending_rows",
+		Help:      "number of rows beyond retention left for the next cleanup, labeled by kind",
+		Namespace: ExporterName,
+	}, []string{"kind"})
+
+	MRetentionCleanupDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:      "retention_cleanup_duration_seconds",
+		Help:      "histogram of the duration of the retention cleanups, labeled by kind",
+		Namespace: ExporterName,
+		Buckets:   prometheus.ExponentialBuckets(0.01, 4, 8),
+	}, []string{"kind"})
+
+	MUsageEventsDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:      "usage_events_dropped_total",
+		Help:      "counter of usage events dropped because the buffer was full",
+		Namespace: ExporterName,
+	})
+
 	MHttpRequestTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "http_request_total",
@@ -348,6 +438,12 @@ func init() {
 		Namespace: ExporterName,
 	})
 
+	MAwsCloudWatchGetMetricDataCached = newCounterStartingAtZero(prometheus.CounterOpts{
+		Name:      "aws_cloudwatch_get_metric_data_cached_total",
+		Help:      "counter for metric data time series served from the cache instead of aws",
+		Namespace: ExporterName,
+	})
+
 	MDBDataSourceQueryByID = newCounterStartingAtZero(prometheus.CounterOpts{
 		Name:      "db_datasource_query_by_id_total",
 		Help:      "counter for getting datasource by id",
@@ -393,6 +489,72 @@ func init() {
 		Namespace:  ExporterName,
 	})
 
+	datasourceLabels := []string{"datasource_type", "datasource_uid"}
+
+	MDataSourceProxyStatus = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "datasource_proxy_response_status_total",
+		Help:      "dataproxy http response status, labeled by datasource",
+		Namespace: ExporterName,
+	}, append(datasourceLabels, "code"))
+
+	MDataSourceProxyDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:      "datasource_proxy_request_duration_seconds",
+		Help:      "histogram of dataproxy request duration, labeled by datasource",
+		Buckets:   prometheus.DefBuckets,
+		Namespace: ExporterName,
+	}, datasourceLabels)
+
+	MDataSourceQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:      "datasource_query_duration_seconds",
+		Help:      "histogram of datasource query duration, labeled by datasource",
+		Buckets:   prometheus.DefBuckets,
+		Namespace: ExporterName,
+	}, datasourceLabels)
+
+	MDataSourceQueryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "datasource_query_errors_total",
+		Help:      "counter of failed datasource queries, labeled by datasource",
+		Namespace: ExporterName,
+	}, datasourceLabels)
+
+	MDataSourceQueryTruncated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "datasource_query_truncated_total",
+		Help:      "counter of datasource query results truncated to the query limits, labeled by datasource",
+		Namespace: ExporterName,
+	}, datasourceLabels)
+
+	MDataSourcePanelSlowQueries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "datasource_panel_slow_queries_total",
+		Help:      "counter of dashboard panel queries slower than the slow query threshold, labeled by datasource",
+		Namespace: ExporterName,
+	}, datasourceLabels)
+
+	MDataSourcePanelBudgetExceeded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "datasource_panel_budget_exceeded_total",
+		Help:      "counter of dashboard panel queries cancelled when exceeding the panel execution budget, labeled by datasource",
+		Namespace: ExporterName,
+	}, datasourceLabels)
+
+	MDataSourceQueriesDenied = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "datasource_queries_denied_total",
+		Help:      "counter of datasource queries denied by a query rule, labeled by rule and datasource type",
+		Namespace: ExporterName,
+	}, []string{"rule", "datasource_type"})
+
+	MDataSourceQuerySeries = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:      "datasource_query_series",
+		Help:      "histogram of the number of series returned by datasource queries, labeled by datasource",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+		Namespace: ExporterName,
+	}, datasourceLabels)
+
+	MDataSourceQueryResponseBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:      "datasource_query_response_bytes",
+		Help:      "histogram of the size of datasource query responses, labeled by datasource",
+		Buckets:   prometheus.ExponentialBuckets(1024, 4, 8),
+		Namespace: ExporterName,
+	}, datasourceLabels)
+
 	MAlertingExecutionTime = prometheus.NewSummary(prometheus.SummaryOpts{
 		Name:       "alerting_execution_time_milliseconds",
 		Help:       "summary of alert execution duration",
@@ -523,6 +685,12 @@ func initMetricVars() {
 		MPageStatus,
 		MApiStatus,
 		MProxyStatus,
+		MApiRateLimited,
+		MUsageEventsExported,
+		MRetentionDeletedRows,
+		MRetentionPendingRows,
+		MRetentionCleanupDuration,
+		MUsageEventsDropped,
 		MHttpRequestTotal,
 		MHttpRequestSummary,
 		MApiUserSignUpStarted,
@@ -532,6 +700,16 @@ func initMetricVars() {
 		MApiDashboardGet,
 		MApiDashboardSearch,
 		MDataSourceProxyReqTimer,
+		MDataSourceProxyStatus,
+		MDataSourceProxyDuration,
+		MDataSourceQueryDuration,
+		MDataSourceQueryErrors,
+		MDataSourceQueryTruncated,
+		MDataSourcePanelSlowQueries,
+		MDataSourcePanelBudgetExceeded,
+		MDataSourceQueriesDenied,
+		MDataSourceQuerySeries,
+		MDataSourceQueryResponseBytes,
 		MAlertingExecutionTime,
 		MApiAdminUserCreate,
 		MApiLoginPost,
@@ -548,6 +726,7 @@ func initMetricVars() {
 		MAwsCloudWatchGetMetricStatistics,
 		MAwsCloudWatchListMetrics,
 		MAwsCloudWatchGetMetricData,
+		MAwsCloudWatchGetMetricDataCached,
 		MDBDataSourceQueryByID,
 		LDAPUsersSyncExecutionTime,
 		MRenderingRequestTotal,
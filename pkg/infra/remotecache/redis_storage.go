@@ -1,32 +1,88 @@
 package remotecache
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-redis/redis/v8"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
-	redis "gopkg.in/redis.v5"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 const redisCacheType = "redis"
 
+// Modes of the redis remote cache
+const (
+	redisModeStandalone = "standalone"
+	redisModeCluster    = "cluster"
+	redisModeSentinel   = "sentinel"
+)
+
+// redisHealthCheckInterval is how often the connection to redis is checked and its metrics updated
+const redisHealthCheckInterval = 15 * time.Second
+
+var (
+	redisUp              prometheus.Gauge
+	redisPoolConnections *prometheus.GaugeVec
+	redisPoolTimeouts    prometheus.Counter
+)
+
+func init() {
+	redisUp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "remote_cache_redis_up",
+		Help:      "Whether the last health check of the redis remote cache succeeded, 1 when it did and 0 otherwise",
+	})
+	redisPoolConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "remote_cache_redis_pool_connections",
+		Help:      "Number of connections in the pool of the redis remote cache, by state",
+	}, []string{"state"})
+	redisPoolTimeouts = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "remote_cache_redis_pool_timeouts_total",
+		Help:      "Number of times a connection to the redis remote cache couldn't be taken from the pool in time",
+	})
+
+	prometheus.MustRegister(redisUp, redisPoolConnections, redisPoolTimeouts)
+}
+
+// redisClient is implemented by the clients of every redis mode
+type redisClient interface {
+	redis.Cmdable
+	PoolStats() *redis.PoolStats
+}
+
 type redisStorage struct {
-	c *redis.Client
+	c   redisClient
+	log log.Logger
+
+	poolTimeouts uint32
 }
 
-// parseRedisConnStr parses k=v pairs in csv and builds a redis Options object
-func parseRedisConnStr(connStr string) (*redis.Options, error) {
+// redisConnOptions are the options of the redis connection string. Addrs are the addresses of the
+// redis server in standalone mode, of the cluster nodes in cluster mode and of the sentinels in
+// sentinel mode.
+type redisConnOptions struct {
+	Mode string
+	redis.UniversalOptions
+}
+
+// parseRedisConnStr parses k=v pairs in csv and builds the redis options
+func parseRedisConnStr(connStr string) (*redisConnOptions, error) {
 	keyValueCSV := strings.Split(connStr, ",")
-	options := &redis.Options{Network: "tcp"}
+	options := &redisConnOptions{Mode: redisModeStandalone}
 	setTLSIsTrue := false
 	for _, rawKeyValue := range keyValueCSV {
 		keyValueTuple := strings.SplitN(rawKeyValue, "=", 2)
 		if len(keyValueTuple) != 2 {
-			if strings.HasPrefix(rawKeyValue, "password") {
+			if strings.HasPrefix(rawKeyValue, "password") || strings.HasPrefix(rawKeyValue, "sentinel_password") {
 				// don't log the password
 				rawKeyValue = "password******"
 			}
@@ -35,10 +91,22 @@ func parseRedisConnStr(connStr string) (*redis.Options, error) {
 		connKey := keyValueTuple[0]
 		connVal := keyValueTuple[1]
 		switch connKey {
+		case "mode":
+			if connVal != redisModeStandalone && connVal != redisModeCluster && connVal != redisModeSentinel {
+				return nil, fmt.Errorf("mode must be set to 'standalone', 'cluster' or 'sentinel' when present")
+			}
+			options.Mode = connVal
 		case "addr":
-			options.Addr = connVal
+			// addr is repeated for the nodes of a cluster and the sentinels
+			options.Addrs = append(options.Addrs, connVal)
+		case "master_name":
+			options.MasterName = connVal
+		case "username":
+			options.Username = connVal
 		case "password":
 			options.Password = connVal
+		case "sentinel_password":
+			options.SentinelPassword = connVal
 		case "db":
 			i, err := strconv.Atoi(connVal)
 			if err != nil {
@@ -65,23 +133,80 @@ func parseRedisConnStr(connStr string) (*redis.Options, error) {
 			return nil, fmt.Errorf("unrecognized option '%v' in redis connection string", connKey)
 		}
 	}
+
+	if len(options.Addrs) == 0 {
+		return nil, fmt.Errorf("addr is required in redis connection string")
+	}
+	switch options.Mode {
+	case redisModeStandalone:
+		if len(options.Addrs) > 1 {
+			return nil, fmt.Errorf("addr can only be repeated in cluster or sentinel mode")
+		}
+	case redisModeCluster:
+		if options.DB != 0 {
+			return nil, fmt.Errorf("db cannot be set in cluster mode")
+		}
+	case redisModeSentinel:
+		if options.MasterName == "" {
+			return nil, fmt.Errorf("master_name is required in sentinel mode")
+		}
+	}
+
 	if setTLSIsTrue {
-		// Get hostname from the Addr property and set it on the configuration for TLS
-		sp := strings.Split(options.Addr, ":")
-		if len(sp) < 1 {
-			return nil, fmt.Errorf("unable to get hostname from the addr field, expected host:port, got '%v'", options.Addr)
+		options.TLSConfig = &tls.Config{}
+		// The hostname of each node is verified in cluster and sentinel modes
+		if options.Mode == redisModeStandalone {
+			// Get hostname from the Addr property and set it on the configuration for TLS
+			sp := strings.Split(options.Addrs[0], ":")
+			if len(sp) < 1 {
+				return nil, fmt.Errorf("unable to get hostname from the addr field, expected host:port, got '%v'", options.Addrs[0])
+			}
+			options.TLSConfig.ServerName = sp[0]
 		}
-		options.TLSConfig = &tls.Config{ServerName: sp[0]}
 	}
 	return options, nil
 }
 
+func newRedisClient(opts *redisConnOptions) redisClient {
+	switch opts.Mode {
+	case redisModeCluster:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:     opts.Addrs,
+			Username:  opts.Username,
+			Password:  opts.Password,
+			PoolSize:  opts.PoolSize,
+			TLSConfig: opts.TLSConfig,
+		})
+	case redisModeSentinel:
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       opts.MasterName,
+			SentinelAddrs:    opts.Addrs,
+			SentinelPassword: opts.SentinelPassword,
+			Username:         opts.Username,
+			Password:         opts.Password,
+			DB:               opts.DB,
+			PoolSize:         opts.PoolSize,
+			TLSConfig:        opts.TLSConfig,
+		})
+	default:
+		return redis.NewClient(&redis.Options{
+			Network:   "tcp",
+			Addr:      opts.Addrs[0],
+			Username:  opts.Username,
+			Password:  opts.Password,
+			DB:        opts.DB,
+			PoolSize:  opts.PoolSize,
+			TLSConfig: opts.TLSConfig,
+		})
+	}
+}
+
 func newRedisStorage(opts *setting.RemoteCacheOptions) (*redisStorage, error) {
 	opt, err := parseRedisConnStr(opts.ConnStr)
 	if err != nil {
 		return nil, err
 	}
-	return &redisStorage{c: redis.NewClient(opt)}, nil
+	return &redisStorage{c: newRedisClient(opt), log: log.New("cache.remote.redis")}, nil
 }
 
 // Set sets value to given key in session.
@@ -91,16 +216,22 @@ func (s *redisStorage) Set(key string, val interface{}, expires time.Duration) e
 	if err != nil {
 		return err
 	}
-	status := s.c.Set(key, string(value), expires)
+	status := s.c.Set(context.Background(), key, string(value), expires)
 	return status.Err()
 }
 
 // Get gets value by given key in session.
 func (s *redisStorage) Get(key string) (interface{}, error) {
-	v := s.c.Get(key)
+	v, err := s.c.Get(context.Background(), key).Result()
+	if err == redis.Nil {
+		return nil, ErrCacheItemNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
 
 	item := &cachedItem{}
-	err := decodeGob([]byte(v.Val()), item)
+	err = decodeGob([]byte(v), item)
 
 	if err == nil {
 		return item.Val, nil
@@ -113,6 +244,43 @@ func (s *redisStorage) Get(key string) (interface{}, error) {
 
 // Delete delete a key from session.
 func (s *redisStorage) Delete(key string) error {
-	cmd := s.c.Del(key)
+	cmd := s.c.Del(context.Background(), key)
 	return cmd.Err()
 }
+
+// Run checks the connection to redis periodically and updates its metrics
+func (s *redisStorage) Run(ctx context.Context) error {
+	ticker := time.NewTicker(redisHealthCheckInterval)
+	defer ticker.Stop()
+
+	s.checkHealth(ctx)
+	for {
+		select {
+		case <-ticker.C:
+			s.checkHealth(ctx)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *redisStorage) checkHealth(ctx context.Context) {
+	pingCtx, cancel := context.WithTimeout(ctx, redisHealthCheckInterval)
+	defer cancel()
+
+	if err := s.c.Ping(pingCtx).Err(); err != nil {
+		s.log.Warn("Redis health check failed", "error", err)
+		redisUp.Set(0)
+	} else {
+		redisUp.Set(1)
+	}
+
+	stats := s.c.PoolStats()
+	redisPoolConnections.WithLabelValues("total").Set(float64(stats.TotalConns))
+	redisPoolConnections.WithLabelValues("idle").Set(float64(stats.IdleConns))
+	redisPoolConnections.WithLabelValues("stale").Set(float64(stats.StaleConns))
+	if stats.Timeouts > s.poolTimeouts {
+		redisPoolTimeouts.Add(float64(stats.Timeouts - s.poolTimeouts))
+	}
+	s.poolTimeouts = stats.Timeouts
+}
@@ -5,55 +5,121 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
-	redis "gopkg.in/redis.v5"
 )
 
 func Test_parseRedisConnStr(t *testing.T) {
 	cases := map[string]struct {
 		InputConnStr  string
-		OutputOptions *redis.Options
+		OutputOptions *redisConnOptions
 		ShouldErr     bool
 	}{
 		"all redis options should parse": {
 			"addr=127.0.0.1:6379,pool_size=100,db=1,password=grafanaRocks,ssl=false",
-			&redis.Options{
-				Addr:      "127.0.0.1:6379",
-				PoolSize:  100,
-				DB:        1,
-				Password:  "grafanaRocks",
-				Network:   "tcp",
-				TLSConfig: nil,
+			&redisConnOptions{
+				Mode: redisModeStandalone,
+				UniversalOptions: redis.UniversalOptions{
+					Addrs:     []string{"127.0.0.1:6379"},
+					PoolSize:  100,
+					DB:        1,
+					Password:  "grafanaRocks",
+					TLSConfig: nil,
+				},
 			},
 			false,
 		},
 		"subset of redis options should parse": {
 			"addr=127.0.0.1:6379,pool_size=100",
-			&redis.Options{
-				Addr:     "127.0.0.1:6379",
-				PoolSize: 100,
-				Network:  "tcp",
+			&redisConnOptions{
+				Mode: redisModeStandalone,
+				UniversalOptions: redis.UniversalOptions{
+					Addrs:    []string{"127.0.0.1:6379"},
+					PoolSize: 100,
+				},
 			},
 			false,
 		},
 		"ssl set to true should result in default TLS configuration with tls set to addr's host": {
 			"addr=grafana.com:6379,ssl=true",
-			&redis.Options{
-				Addr:      "grafana.com:6379",
-				Network:   "tcp",
-				TLSConfig: &tls.Config{ServerName: "grafana.com"},
+			&redisConnOptions{
+				Mode: redisModeStandalone,
+				UniversalOptions: redis.UniversalOptions{
+					Addrs:     []string{"grafana.com:6379"},
+					TLSConfig: &tls.Config{ServerName: "grafana.com"},
+				},
 			},
 			false,
 		},
 		"ssl to insecure should result in TLS configuration with InsecureSkipVerify": {
 			"addr=127.0.0.1:6379,ssl=insecure",
-			&redis.Options{
-				Addr:      "127.0.0.1:6379",
-				Network:   "tcp",
-				TLSConfig: &tls.Config{InsecureSkipVerify: true},
+			&redisConnOptions{
+				Mode: redisModeStandalone,
+				UniversalOptions: redis.UniversalOptions{
+					Addrs:     []string{"127.0.0.1:6379"},
+					TLSConfig: &tls.Config{InsecureSkipVerify: true},
+				},
 			},
 			false,
 		},
+		"ACL user should parse": {
+			"addr=127.0.0.1:6379,username=grafana,password=grafanaRocks",
+			&redisConnOptions{
+				Mode: redisModeStandalone,
+				UniversalOptions: redis.UniversalOptions{
+					Addrs:    []string{"127.0.0.1:6379"},
+					Username: "grafana",
+					Password: "grafanaRocks",
+				},
+			},
+			false,
+		},
+		"cluster mode should parse every node with TLS verifying their hostname": {
+			"mode=cluster,addr=node1:7000,addr=node2:7000,pool_size=10,ssl=true",
+			&redisConnOptions{
+				Mode: redisModeCluster,
+				UniversalOptions: redis.UniversalOptions{
+					Addrs:     []string{"node1:7000", "node2:7000"},
+					PoolSize:  10,
+					TLSConfig: &tls.Config{},
+				},
+			},
+			false,
+		},
+		"sentinel mode should parse": {
+			"mode=sentinel,master_name=mymaster,addr=sentinel1:26379,addr=sentinel2:26379,sentinel_password=s3cret,password=grafanaRocks,db=2",
+			&redisConnOptions{
+				Mode: redisModeSentinel,
+				UniversalOptions: redis.UniversalOptions{
+					Addrs:            []string{"sentinel1:26379", "sentinel2:26379"},
+					MasterName:       "mymaster",
+					SentinelPassword: "s3cret",
+					Password:         "grafanaRocks",
+					DB:               2,
+				},
+			},
+			false,
+		},
+		"sentinel mode without master_name should err": {
+			"mode=sentinel,addr=sentinel1:26379",
+			nil,
+			true,
+		},
+		"cluster mode with db should err": {
+			"mode=cluster,addr=node1:7000,db=1",
+			nil,
+			true,
+		},
+		"repeated addr in standalone mode should err": {
+			"addr=127.0.0.1:6379,addr=127.0.0.1:6380",
+			nil,
+			true,
+		},
+		"invalid mode should err": {
+			"mode=dragons,addr=127.0.0.1:6379",
+			nil,
+			true,
+		},
 		"invalid SSL option should err": {
 			"addr=127.0.0.1:6379,ssl=dragons",
 			nil,
@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// leaderElection is the name of the row of the scheduler_leader table held by the leader.
+const leaderElection = "scheduler"
+
+type schedulerLeader struct {
+	Id      int64
+	Name    string
+	Holder  string
+	Expires int64
+}
+
+// elect takes or renews the leadership for the lease and returns whether this instance is the leader.
+// The leadership is taken when it's free or when the lease of the previous leader expired.
+func (srv *SchedulerService) elect(ctx context.Context, now time.Time) (bool, error) {
+	var leader bool
+
+	err := srv.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		rows := []*schedulerLeader{}
+		if err := sess.Where("name = ?", leaderElection).Find(&rows); err != nil {
+			return err
+		}
+
+		expires := now.Add(leaseDuration).Unix()
+		if len(rows) == 0 {
+			_, err := sess.Insert(&schedulerLeader{Name: leaderElection, Holder: srv.instanceID, Expires: expires})
+			leader = err == nil
+			return err
+		}
+
+		sql := `UPDATE scheduler_leader SET holder = ?, expires = ?
+			WHERE name = ? AND (holder = ? OR expires < ?)`
+		res, err := sess.Exec(sql, srv.instanceID, expires, leaderElection, srv.instanceID, now.Unix())
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		leader = affected == 1 || (rows[0].Holder == srv.instanceID && rows[0].Expires >= now.Unix())
+		return err
+	})
+
+	return leader, err
+}
+
+// resign gives up the leadership so that another instance takes it without waiting for the lease to expire.
+func (srv *SchedulerService) resign(ctx context.Context) error {
+	return srv.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Exec("UPDATE scheduler_leader SET expires = 0 WHERE name = ? AND holder = ?", leaderElection, srv.instanceID)
+		return err
+	})
+}
@@ -0,0 +1,201 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// tickInterval is how often the leadership is renewed and the due jobs are run.
+	tickInterval = 10 * time.Second
+	// leaseDuration is how long the leader stays the leader without renewing its leadership.
+	leaseDuration = 30 * time.Second
+)
+
+var leaderGauge prometheus.Gauge
+
+func init() {
+	registry.RegisterService(&SchedulerService{})
+
+	leaderGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "scheduler_leader",
+		Help:      "Whether this instance is the leader running the background jobs, 1 when it is and 0 otherwise",
+	})
+
+	prometheus.MustRegister(leaderGauge)
+}
+
+// JobFunc is the work of a background job.
+type JobFunc func(ctx context.Context) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	fn       JobFunc
+
+	mu      sync.Mutex
+	running bool
+	lastRun time.Time
+}
+
+// SchedulerService runs background jobs on their interval. The instances of a HA cluster elect a
+// leader through the database, and only the leader runs the jobs, so that each of them runs once
+// across the cluster.
+type SchedulerService struct {
+	SQLStore          *sqlstore.SqlStore            `inject:""`
+	ServerLockService *serverlock.ServerLockService `inject:""`
+	log               log.Logger
+
+	instanceID string
+
+	jobsMu sync.Mutex
+	jobs   []*job
+
+	leaderMu sync.RWMutex
+	leader   bool
+}
+
+func (srv *SchedulerService) Init() error {
+	srv.log = log.New("scheduler")
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	srv.instanceID = fmt.Sprintf("%s-%s", hostname, util.GenerateShortUID())
+	return nil
+}
+
+// Schedule adds a job running every interval on the leader. Jobs are usually scheduled by the
+// services running them when they are initialized.
+func (srv *SchedulerService) Schedule(name string, interval time.Duration, fn JobFunc) {
+	srv.jobsMu.Lock()
+	defer srv.jobsMu.Unlock()
+
+	srv.jobs = append(srv.jobs, &job{name: name, interval: interval, fn: fn})
+}
+
+// IsLeader returns whether this instance is the leader running the jobs.
+func (srv *SchedulerService) IsLeader() bool {
+	srv.leaderMu.RLock()
+	defer srv.leaderMu.RUnlock()
+	return srv.leader
+}
+
+func (srv *SchedulerService) setLeader(leader bool) {
+	srv.leaderMu.Lock()
+	defer srv.leaderMu.Unlock()
+
+	if leader != srv.leader {
+		if leader {
+			srv.log.Info("Became the leader running the background jobs", "instance", srv.instanceID)
+		} else {
+			srv.log.Info("Stopped being the leader running the background jobs", "instance", srv.instanceID)
+		}
+	}
+
+	srv.leader = leader
+	if leader {
+		leaderGauge.Set(1)
+	} else {
+		leaderGauge.Set(0)
+	}
+}
+
+func (srv *SchedulerService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	var wg sync.WaitGroup
+	srv.tick(ctx, &wg, time.Now())
+	for {
+		select {
+		case <-ticker.C:
+			srv.tick(ctx, &wg, time.Now())
+		case <-ctx.Done():
+			wg.Wait()
+			if srv.IsLeader() {
+				// the context is done already
+				if err := srv.resign(context.Background()); err != nil {
+					srv.log.Warn("Failed to resign the leadership", "error", err)
+				}
+				srv.setLeader(false)
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// tick renews the leadership and starts the due jobs when this instance is the leader.
+func (srv *SchedulerService) tick(ctx context.Context, wg *sync.WaitGroup, now time.Time) {
+	leader, err := srv.elect(ctx, now)
+	if err != nil {
+		srv.log.Error("Failed to elect the leader running the background jobs", "error", err)
+		leader = false
+	}
+	srv.setLeader(leader)
+	if !leader {
+		return
+	}
+
+	srv.jobsMu.Lock()
+	jobs := append([]*job{}, srv.jobs...)
+	srv.jobsMu.Unlock()
+
+	for _, j := range jobs {
+		if !j.start(now) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(j *job) {
+			defer wg.Done()
+			defer j.finish()
+			srv.runJob(ctx, j)
+		}(j)
+	}
+}
+
+// runJob runs a job unless it already ran within its interval, which happens when the previous
+// leader ran it shortly before losing the leadership.
+func (srv *SchedulerService) runJob(ctx context.Context, j *job) {
+	err := srv.ServerLockService.LockAndExecute(ctx, "scheduler "+j.name, j.interval-tickInterval/2, func() {
+		srv.log.Debug("Running job", "job", j.name)
+		if err := j.fn(ctx); err != nil {
+			srv.log.Error("Job failed", "job", j.name, "error", err)
+		}
+	})
+	if err != nil {
+		srv.log.Error("Failed to lock and run job", "job", j.name, "error", err)
+	}
+}
+
+// start marks the job as running when it's due and isn't running yet.
+func (j *job) start(now time.Time) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.running || now.Sub(j.lastRun) < j.interval-tickInterval/2 {
+		return false
+	}
+	j.running = true
+	j.lastRun = now
+	return true
+}
+
+func (j *job) finish() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.running = false
+}
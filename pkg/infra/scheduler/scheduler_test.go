@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestScheduler(t *testing.T, sqlStore *sqlstore.SqlStore, instanceID string) *SchedulerService {
+	t.Helper()
+
+	lockService := &serverlock.ServerLockService{SQLStore: sqlStore}
+	require.NoError(t, lockService.Init())
+
+	return &SchedulerService{
+		SQLStore:          sqlStore,
+		ServerLockService: lockService,
+		log:               log.New("test-logger"),
+		instanceID:        instanceID,
+	}
+}
+
+func TestLeaderElection(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+	first := newTestScheduler(t, sqlStore, "first")
+	second := newTestScheduler(t, sqlStore, "second")
+	ctx := context.Background()
+	now := time.Now()
+
+	leader, err := first.elect(ctx, now)
+	require.NoError(t, err)
+	require.True(t, leader)
+
+	leader, err = second.elect(ctx, now)
+	require.NoError(t, err)
+	require.False(t, leader)
+
+	t.Run("The leader renews its leadership", func(t *testing.T) {
+		leader, err := first.elect(ctx, now.Add(tickInterval))
+		require.NoError(t, err)
+		require.True(t, leader)
+	})
+
+	t.Run("The leadership is taken over when the lease expires", func(t *testing.T) {
+		later := now.Add(tickInterval + leaseDuration + time.Second)
+		leader, err := second.elect(ctx, later)
+		require.NoError(t, err)
+		require.True(t, leader)
+
+		leader, err = first.elect(ctx, later)
+		require.NoError(t, err)
+		require.False(t, leader)
+	})
+
+	t.Run("The leadership is taken over when the leader resigns", func(t *testing.T) {
+		require.NoError(t, second.resign(ctx))
+
+		leader, err := first.elect(ctx, now.Add(tickInterval+leaseDuration+2*time.Second))
+		require.NoError(t, err)
+		require.True(t, leader)
+	})
+}
+
+func TestRunJobs(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+	first := newTestScheduler(t, sqlStore, "first")
+	second := newTestScheduler(t, sqlStore, "second")
+
+	var mu sync.Mutex
+	runs := map[string]int{}
+	for _, srv := range []*SchedulerService{first, second} {
+		srv := srv
+		srv.Schedule("test job", time.Minute, func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			runs[srv.instanceID]++
+			return nil
+		})
+	}
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	now := time.Now()
+	for _, at := range []time.Time{now, now.Add(tickInterval), now.Add(time.Minute)} {
+		first.tick(ctx, &wg, at)
+		second.tick(ctx, &wg, at)
+		wg.Wait()
+	}
+
+	require.True(t, first.IsLeader())
+	require.False(t, second.IsLeader())
+	require.Equal(t, map[string]int{"first": 1}, runs)
+}
@@ -33,6 +33,7 @@ type SocialConnector interface {
 	Exchange(ctx context.Context, code string, authOptions ...oauth2.AuthCodeOption) (*oauth2.Token, error)
 	Client(ctx context.Context, t *oauth2.Token) *http.Client
 	TokenSource(ctx context.Context, t *oauth2.Token) oauth2.TokenSource
+	ExchangeToken(ctx context.Context, t *oauth2.Token, req TokenExchangeRequest) (*oauth2.Token, error)
 }
 
 type SocialBase struct {
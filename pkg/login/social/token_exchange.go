@@ -0,0 +1,159 @@
+package social
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	tokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+	accessTokenType        = "urn:ietf:params:oauth:token-type:access_token"
+
+	// exchangedTokenExpiryDelta is how long before they expire the exchanged tokens are exchanged again
+	exchangedTokenExpiryDelta = 10 * time.Second
+	// maxExchangedTokens is the most exchanged tokens cached, the expired ones being dropped first
+	maxExchangedTokens = 10000
+)
+
+// TokenExchangeRequest is the audience and the scopes of the token a user's access token is exchanged
+// for, like the ones expected by a data source
+type TokenExchangeRequest struct {
+	Audience string
+	Scopes   []string
+}
+
+// IsZero returns whether the request has neither an audience nor scopes, the access token being
+// used as is then
+func (r TokenExchangeRequest) IsZero() bool {
+	return r.Audience == "" && len(r.Scopes) == 0
+}
+
+var (
+	exchangedTokens     = make(map[string]*oauth2.Token)
+	exchangedTokensLock sync.Mutex
+)
+
+// ExchangeToken exchanges an access token of the provider for a token with another audience or other
+// scopes, with the token exchange of RFC 8693 on the token endpoint of the provider. The exchanged
+// tokens are cached until shortly before they expire.
+func (s *SocialBase) ExchangeToken(ctx context.Context, token *oauth2.Token, req TokenExchangeRequest) (*oauth2.Token, error) {
+	key := exchangedTokenKey(token.AccessToken, req)
+	exchangedTokensLock.Lock()
+	exchanged, ok := exchangedTokens[key]
+	exchangedTokensLock.Unlock()
+	if ok && (exchanged.Expiry.IsZero() || time.Until(exchanged.Expiry) > exchangedTokenExpiryDelta) {
+		return exchanged, nil
+	}
+
+	exchanged, err := s.exchangeToken(ctx, token, req)
+	if err != nil {
+		return nil, err
+	}
+
+	exchangedTokensLock.Lock()
+	defer exchangedTokensLock.Unlock()
+	if len(exchangedTokens) >= maxExchangedTokens {
+		pruneExchangedTokens()
+	}
+	exchangedTokens[key] = exchanged
+	return exchanged, nil
+}
+
+func (s *SocialBase) exchangeToken(ctx context.Context, token *oauth2.Token, req TokenExchangeRequest) (*oauth2.Token, error) {
+	params := url.Values{
+		"grant_type":           {tokenExchangeGrantType},
+		"subject_token":        {token.AccessToken},
+		"subject_token_type":   {accessTokenType},
+		"requested_token_type": {accessTokenType},
+	}
+	if req.Audience != "" {
+		params.Set("audience", req.Audience)
+	}
+	if len(req.Scopes) > 0 {
+		params.Set("scope", strings.Join(req.Scopes, " "))
+	}
+	if s.Endpoint.AuthStyle == oauth2.AuthStyleInParams {
+		params.Set("client_id", s.ClientID)
+		if s.ClientSecret != "" {
+			params.Set("client_secret", s.ClientSecret)
+		}
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, s.Endpoint.TokenURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if s.Endpoint.AuthStyle != oauth2.AuthStyleInParams {
+		httpReq.SetBasicAuth(url.QueryEscape(s.ClientID), url.QueryEscape(s.ClientSecret))
+	}
+
+	resp, err := oauth2.NewClient(ctx, nil).Do(httpReq.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("token exchange failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("token exchange failed: %w", err)
+	}
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil && resp.StatusCode < 300 {
+		return nil, fmt.Errorf("token exchange failed: invalid response: %w", err)
+	}
+	if resp.StatusCode >= 300 || result.Error != "" {
+		if result.Error == "" {
+			return nil, fmt.Errorf("token exchange failed: %s", resp.Status)
+		}
+		return nil, fmt.Errorf("token exchange failed: %s: %s", result.Error, result.ErrorDescription)
+	}
+	if result.AccessToken == "" {
+		return nil, fmt.Errorf("token exchange failed: no access token returned")
+	}
+
+	exchanged := &oauth2.Token{AccessToken: result.AccessToken, TokenType: result.TokenType}
+	if result.ExpiresIn > 0 {
+		exchanged.Expiry = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	} else if !token.Expiry.IsZero() {
+		// the exchanged token isn't used longer than the token it was exchanged for
+		exchanged.Expiry = token.Expiry
+	}
+	return exchanged, nil
+}
+
+// exchangedTokenKey is the key of an exchanged token, which doesn't keep the access token it was
+// exchanged for in memory
+func exchangedTokenKey(accessToken string, req TokenExchangeRequest) string {
+	hash := sha256.Sum256([]byte(accessToken))
+	return fmt.Sprintf("%s:%s:%s", hex.EncodeToString(hash[:]), req.Audience, strings.Join(req.Scopes, " "))
+}
+
+// pruneExchangedTokens drops the expired exchanged tokens, or all of them if none expired
+func pruneExchangedTokens() {
+	for key, token := range exchangedTokens {
+		if !token.Expiry.IsZero() && time.Until(token.Expiry) <= exchangedTokenExpiryDelta {
+			delete(exchangedTokens, key)
+		}
+	}
+	if len(exchangedTokens) >= maxExchangedTokens {
+		exchangedTokens = make(map[string]*oauth2.Token)
+	}
+}
@@ -0,0 +1,80 @@
+package social
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestExchangeToken(t *testing.T) {
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		requests = append(requests, r)
+		w.Header().Set("Content-Type", "application/json")
+		if r.Form.Get("audience") == "denied" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, err := w.Write([]byte(`{"error": "invalid_target", "error_description": "audience not allowed"}`))
+			require.NoError(t, err)
+			return
+		}
+		_, err := w.Write([]byte(`{"access_token": "exchanged-` + r.Form.Get("audience") + `", "token_type": "Bearer", "expires_in": 300}`))
+		require.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	provider := &SocialGenericOAuth{SocialBase: &SocialBase{Config: &oauth2.Config{
+		ClientID:     "grafana",
+		ClientSecret: "secret",
+		Endpoint:     oauth2.Endpoint{TokenURL: server.URL},
+	}}}
+	token := &oauth2.Token{AccessToken: "user-token", TokenType: "Bearer", Expiry: time.Now().Add(time.Hour)}
+
+	t.Run("Should exchange the token for the audience and scopes of the request", func(t *testing.T) {
+		exchanged, err := provider.ExchangeToken(context.Background(), token, TokenExchangeRequest{
+			Audience: "prometheus",
+			Scopes:   []string{"metrics:read", "alerts:read"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "exchanged-prometheus", exchanged.AccessToken)
+		assert.WithinDuration(t, time.Now().Add(5*time.Minute), exchanged.Expiry, time.Minute)
+
+		require.Len(t, requests, 1)
+		form := requests[0].Form
+		assert.Equal(t, tokenExchangeGrantType, form.Get("grant_type"))
+		assert.Equal(t, "user-token", form.Get("subject_token"))
+		assert.Equal(t, accessTokenType, form.Get("subject_token_type"))
+		assert.Equal(t, "metrics:read alerts:read", form.Get("scope"))
+		clientID, clientSecret, ok := requests[0].BasicAuth()
+		require.True(t, ok)
+		assert.Equal(t, "grafana", clientID)
+		assert.Equal(t, "secret", clientSecret)
+	})
+
+	t.Run("Should cache the exchanged tokens per audience", func(t *testing.T) {
+		requests = nil
+		exchanged, err := provider.ExchangeToken(context.Background(), token, TokenExchangeRequest{
+			Audience: "prometheus",
+			Scopes:   []string{"metrics:read", "alerts:read"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "exchanged-prometheus", exchanged.AccessToken)
+		assert.Empty(t, requests)
+
+		exchanged, err = provider.ExchangeToken(context.Background(), token, TokenExchangeRequest{Audience: "loki"})
+		require.NoError(t, err)
+		assert.Equal(t, "exchanged-loki", exchanged.AccessToken)
+		assert.Len(t, requests, 1)
+	})
+
+	t.Run("Should return the error of the provider", func(t *testing.T) {
+		_, err := provider.ExchangeToken(context.Background(), token, TokenExchangeRequest{Audience: "denied"})
+		require.EqualError(t, err, "token exchange failed: invalid_target: audience not allowed")
+	})
+}
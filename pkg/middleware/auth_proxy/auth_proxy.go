@@ -93,7 +93,7 @@ func New(options *Options) *AuthProxy {
 		enabled:             setting.AuthProxyEnabled,
 		headerType:          setting.AuthProxyHeaderProperty,
 		headers:             setting.AuthProxyHeaders,
-		whitelistIP:         setting.AuthProxyWhitelist,
+		whitelistIP:         setting.GetAuthProxyWhitelist(),
 		cacheTTL:            setting.AuthProxySyncTtl,
 		LDAPAllowSignup:     setting.LDAPAllowSignup,
 		AuthProxyAutoSignUp: setting.AuthProxyAutoSignUp,
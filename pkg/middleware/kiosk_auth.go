@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// kioskQueryPaths are the routes kiosks query the data of their dashboards with
+var kioskQueryPaths = map[string]bool{
+	"/api/ds/query":   true,
+	"/api/tsdb/query": true,
+}
+
+// kioskPaths are the routes of the kiosks themselves, under which they load their dashboards
+var kioskPaths = []string{"/api/playlist-kiosk", "/api/kiosk-device"}
+
+func isKioskPath(path string) bool {
+	for _, kioskPath := range kioskPaths {
+		if path == kioskPath || strings.HasPrefix(path, kioskPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// initContextWithKioskToken reads the kiosk token of a playlist or of a kiosk device in the
+// X-Grafana-Kiosk-Token header. The kiosk routes get the token, and the data queries are
+// authenticated as the user the kiosks act as. The token can't be used with the other routes, and
+// is never read from the URL, so that it doesn't end up in access logs.
+func initContextWithKioskToken(ctx *models.ReqContext) bool {
+	token := ctx.Req.Header.Get("X-Grafana-Kiosk-Token")
+	if token == "" {
+		return false
+	}
+
+	isQuery := ctx.Req.Method == http.MethodPost && kioskQueryPaths[ctx.Req.URL.Path]
+	if !isQuery && !isKioskPath(ctx.Req.URL.Path) {
+		ctx.JsonApiErr(401, "Kiosk tokens can only be used by kiosks and to query data", nil)
+		return true
+	}
+
+	query := models.GetKioskTokenQuery{Token: token}
+	if err := bus.Dispatch(&query); err != nil {
+		ctx.JsonApiErr(401, "Invalid kiosk token", err)
+		return true
+	}
+
+	ctx.KioskToken = query.Result
+	if isQuery {
+		ctx.IsSignedIn = true
+		ctx.SignedInUser = query.Result.SignedInUser()
+	}
+	return true
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/opentracing/opentracing-go"
 	macaron "gopkg.in/macaron.v1"
 
 	"github.com/grafana/grafana/pkg/bus"
@@ -52,6 +53,11 @@ func GetContextHandler(
 			Logger:         log.New("context"),
 		}
 
+		// the lookups of the signed in user are traced in their own span
+		requestCtx := c.Req.Context()
+		span, spanCtx := opentracing.StartSpanFromContext(requestCtx, "context handler")
+		c.Req.Request = c.Req.WithContext(spanCtx)
+
 		orgId := int64(0)
 		orgIdHeader := ctx.Req.Header.Get("X-Grafana-Org-Id")
 		if orgIdHeader != "" {
@@ -65,6 +71,7 @@ func GetContextHandler(
 		// then test if anonymous access is enabled
 		switch {
 		case initContextWithRenderAuth(ctx, renderService):
+		case initContextWithKioskToken(ctx):
 		case initContextWithApiKey(ctx):
 		case initContextWithBasicAuth(ctx, orgId):
 		case initContextWithAuthProxy(remoteCache, ctx, orgId):
@@ -72,6 +79,15 @@ func GetContextHandler(
 		case initContextWithAnonymousUser(ctx):
 		}
 
+		c.Req.Request = c.Req.WithContext(requestCtx)
+		span.SetTag("user_id", ctx.UserId)
+		span.SetTag("org_id", ctx.OrgId)
+		span.Finish()
+		if requestSpan := opentracing.SpanFromContext(requestCtx); requestSpan != nil {
+			requestSpan.SetTag("user_id", ctx.UserId)
+			requestSpan.SetTag("org_id", ctx.OrgId)
+		}
+
 		ctx.Logger = log.New("context", "userId", ctx.UserId, "orgId", ctx.OrgId, "uname", ctx.Login)
 		ctx.Data["ctx"] = ctx
 
@@ -166,6 +166,64 @@ func TestMiddlewareContext(t *testing.T) {
 			})
 		})
 
+		middlewareScenario(t, "Valid kiosk token", func(sc *scenarioContext) {
+			bus.AddHandler("test", func(query *models.GetKioskTokenQuery) error {
+				if query.Token != "kiosk-token" {
+					return models.ErrKioskTokenNotFound
+				}
+				query.Result = &models.KioskToken{Id: 1, OrgId: 12, DeviceId: 3}
+				return nil
+			})
+			sc.m.Post("/api/ds/query", sc.defaultHandler)
+			sc.m.Get("/api/kiosk-device", sc.defaultHandler)
+
+			Convey("Should authenticate the data queries as the kiosk user", func() {
+				sc.fakeReq("POST", "/api/ds/query")
+				sc.req.Header.Set("X-Grafana-Kiosk-Token", "kiosk-token")
+				sc.exec()
+
+				So(sc.resp.Code, ShouldEqual, 200)
+				So(sc.context.IsSignedIn, ShouldBeTrue)
+				So(sc.context.OrgId, ShouldEqual, 12)
+				So(sc.context.OrgRole, ShouldEqual, models.ROLE_VIEWER)
+				So(sc.context.Login, ShouldEqual, "kiosk-device-3")
+				So(sc.context.KioskToken.Id, ShouldEqual, 1)
+			})
+
+			Convey("Should give the token to the kiosk routes without signing in", func() {
+				sc.fakeReq("GET", "/api/kiosk-device")
+				sc.req.Header.Set("X-Grafana-Kiosk-Token", "kiosk-token")
+				sc.exec()
+
+				So(sc.resp.Code, ShouldEqual, 200)
+				So(sc.context.IsSignedIn, ShouldBeFalse)
+				So(sc.context.KioskToken.Id, ShouldEqual, 1)
+			})
+
+			Convey("Should reject the other routes", func() {
+				sc.fakeReq("GET", "/")
+				sc.req.Header.Set("X-Grafana-Kiosk-Token", "kiosk-token")
+				sc.exec()
+
+				So(sc.resp.Code, ShouldEqual, 401)
+
+				sc.fakeReq("GET", "/api/kiosk-devices")
+				sc.req.Header.Set("X-Grafana-Kiosk-Token", "kiosk-token")
+				sc.exec()
+
+				So(sc.resp.Code, ShouldEqual, 401)
+			})
+
+			Convey("Should reject invalid tokens", func() {
+				sc.fakeReq("POST", "/api/ds/query")
+				sc.req.Header.Set("X-Grafana-Kiosk-Token", "invalid")
+				sc.exec()
+
+				So(sc.resp.Code, ShouldEqual, 401)
+				So(sc.respJson["message"], ShouldEqual, "Invalid kiosk token")
+			})
+		})
+
 		middlewareScenario(t, "Valid api key, but does not match db hash", func(sc *scenarioContext) {
 			keyhash := "Something_not_matching"
 
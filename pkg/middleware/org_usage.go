@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"strings"
+
+	"gopkg.in/macaron.v1"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/orgusage"
+)
+
+// OrgApiUsage counts the API calls made in the organization of the request
+func OrgApiUsage(orgUsageService *orgusage.OrgUsageService) macaron.Handler {
+	return func(c *models.ReqContext) {
+		if c.OrgId == 0 || !strings.HasPrefix(c.Req.URL.Path, "/api/") {
+			return
+		}
+
+		orgUsageService.RecordApiCall(c.OrgId)
+	}
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/macaron.v1"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// rateLimitCleanupInterval is how often the buckets of the idle orgs, users and API keys are removed
+const rateLimitCleanupInterval = time.Minute
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is a token bucket per org, user or API key. The buckets hold up to burst tokens and
+// are refilled with rate tokens per second, every request taking one.
+type rateLimiter struct {
+	name  string
+	rate  float64
+	burst float64
+
+	mu          sync.Mutex
+	buckets     map[int64]*tokenBucket
+	lastCleanup time.Time
+}
+
+// newRateLimiter returns a rate limiter, or nil when there's no limit
+func newRateLimiter(name string, limit setting.RateLimit) *rateLimiter {
+	if limit.RequestsPerSecond <= 0 {
+		return nil
+	}
+
+	return &rateLimiter{
+		name:    name,
+		rate:    limit.RequestsPerSecond,
+		burst:   float64(limit.Burst),
+		buckets: make(map[int64]*tokenBucket),
+	}
+}
+
+// allow takes a token from the bucket of the key, and returns false with how long until a token is
+// available when the bucket is empty.
+func (l *rateLimiter) allow(key int64, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastCleanup) >= rateLimitCleanupInterval {
+		l.cleanup(now)
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+
+	return false, time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+}
+
+// cleanup removes the buckets refilled since they were last used, as they're the same as new ones
+func (l *rateLimiter) cleanup(now time.Time) {
+	for key, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastCleanup = now
+}
+
+// RateLimit throttles the API requests of the orgs, users and API keys exceeding their rate limit
+// with 429 Too Many Requests.
+func RateLimit(limits setting.RateLimitSettings) macaron.Handler {
+	orgLimiter := newRateLimiter("org", limits.Org)
+	userLimiter := newRateLimiter("user", limits.User)
+	apiKeyLimiter := newRateLimiter("api_key", limits.APIKey)
+
+	return func(c *models.ReqContext) {
+		if !strings.HasPrefix(c.Req.URL.Path, "/api/") {
+			return
+		}
+
+		// the limit of the user or API key is checked first, so that the requests they
+		// make past their limit don't count towards the limit of their org
+		now := time.Now()
+		if c.ApiKeyId > 0 {
+			if !checkRateLimit(c, apiKeyLimiter, c.ApiKeyId, now) {
+				return
+			}
+		} else if c.IsSignedIn && c.UserId > 0 {
+			if !checkRateLimit(c, userLimiter, c.UserId, now) {
+				return
+			}
+		}
+
+		if c.OrgId > 0 {
+			checkRateLimit(c, orgLimiter, c.OrgId, now)
+		}
+	}
+}
+
+func checkRateLimit(c *models.ReqContext, limiter *rateLimiter, key int64, now time.Time) bool {
+	if limiter == nil {
+		return true
+	}
+
+	allowed, retryAfter := limiter.allow(key, now)
+	if allowed {
+		return true
+	}
+
+	metrics.MApiRateLimited.WithLabelValues(limiter.name).Inc()
+	c.Resp.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	c.JsonApiErr(429, "Too many requests, rate limit exceeded", nil)
+	return false
+}
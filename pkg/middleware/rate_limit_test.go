@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/macaron.v1"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter("user", setting.RateLimit{RequestsPerSecond: 2, Burst: 3})
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		allowed, _ := limiter.allow(1, now)
+		require.True(t, allowed)
+	}
+	allowed, retryAfter := limiter.allow(1, now)
+	require.False(t, allowed)
+	require.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// keys have their own bucket
+	allowed, _ = limiter.allow(2, now)
+	require.True(t, allowed)
+
+	// buckets are refilled over time
+	allowed, _ = limiter.allow(1, now.Add(500*time.Millisecond))
+	require.True(t, allowed)
+
+	// refilled buckets are removed
+	limiter.allow(3, now.Add(time.Hour))
+	require.Len(t, limiter.buckets, 1)
+
+	require.Nil(t, newRateLimiter("org", setting.RateLimit{}))
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	m := macaron.New()
+	m.Use(macaron.Renderer())
+	m.Use(func(c *macaron.Context) {
+		userID, _ := strconv.ParseInt(c.Req.Header.Get("X-User-Id"), 10, 64)
+		c.Map(&models.ReqContext{
+			Context:      c,
+			SignedInUser: &models.SignedInUser{OrgId: 1, UserId: userID},
+			IsSignedIn:   true,
+			Logger:       log.New("test"),
+		})
+	})
+	m.Use(RateLimit(setting.RateLimitSettings{
+		Org:  setting.RateLimit{RequestsPerSecond: 0.001, Burst: 3},
+		User: setting.RateLimit{RequestsPerSecond: 0.001, Burst: 2},
+	}))
+	m.Get("/api/test", func(c *models.ReqContext) {
+		c.JsonOK("OK")
+	})
+
+	request := func(userID int) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "/api/test", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-User-Id", strconv.Itoa(userID))
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, req)
+		return rec
+	}
+
+	require.Equal(t, 200, request(1).Code)
+	require.Equal(t, 200, request(1).Code)
+
+	rec := request(1)
+	require.Equal(t, 429, rec.Code)
+	require.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	// the throttled requests of a user don't count towards the limit of the org
+	require.Equal(t, 200, request(2).Code)
+	require.Equal(t, 429, request(2).Code)
+	require.Equal(t, 429, request(3).Code)
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
@@ -10,27 +11,56 @@ import (
 	"gopkg.in/macaron.v1"
 )
 
+// Tracing starts the span of a request before the other middlewares, so that their spans are
+// part of the trace of the request.
+func Tracing() macaron.Handler {
+	return func(res http.ResponseWriter, req *http.Request, c *macaron.Context) {
+		span, ctx := startRequestSpan(req, fmt.Sprintf("HTTP %s", req.Method))
+		defer span.Finish()
+
+		c.Req.Request = req.WithContext(ctx)
+
+		c.Next()
+
+		finishRequestSpan(span, req, res.(macaron.ResponseWriter))
+	}
+}
+
+// RequestTracing names the span of a request after the route handling it, starting the span
+// when Tracing isn't used.
 func RequestTracing(handler string) macaron.Handler {
 	return func(res http.ResponseWriter, req *http.Request, c *macaron.Context) {
-		rw := res.(macaron.ResponseWriter)
+		if span := opentracing.SpanFromContext(c.Req.Context()); span != nil {
+			span.SetOperationName(fmt.Sprintf("HTTP %s", handler))
+			return
+		}
 
-		tracer := opentracing.GlobalTracer()
-		wireContext, _ := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
-		span := tracer.StartSpan(fmt.Sprintf("HTTP %s", handler), ext.RPCServerOption(wireContext))
+		span, ctx := startRequestSpan(req, fmt.Sprintf("HTTP %s", handler))
 		defer span.Finish()
 
-		ctx := opentracing.ContextWithSpan(req.Context(), span)
 		c.Req.Request = req.WithContext(ctx)
 
 		c.Next()
 
-		status := rw.Status()
+		finishRequestSpan(span, req, res.(macaron.ResponseWriter))
+	}
+}
 
-		ext.HTTPStatusCode.Set(span, uint16(status))
-		ext.HTTPUrl.Set(span, req.RequestURI)
-		ext.HTTPMethod.Set(span, req.Method)
-		if status >= 400 {
-			ext.Error.Set(span, true)
-		}
+func startRequestSpan(req *http.Request, operationName string) (opentracing.Span, context.Context) {
+	tracer := opentracing.GlobalTracer()
+	wireContext, _ := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
+	span := tracer.StartSpan(operationName, ext.RPCServerOption(wireContext))
+
+	return span, opentracing.ContextWithSpan(req.Context(), span)
+}
+
+func finishRequestSpan(span opentracing.Span, req *http.Request, rw macaron.ResponseWriter) {
+	status := rw.Status()
+
+	ext.HTTPStatusCode.Set(span, uint16(status))
+	ext.HTTPUrl.Set(span, req.RequestURI)
+	ext.HTTPMethod.Set(span, req.Method)
+	if status >= 400 {
+		ext.Error.Set(span, true)
 	}
 }
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/require"
+	macaron "gopkg.in/macaron.v1"
+)
+
+func TestRequestTracing(t *testing.T) {
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	t.Cleanup(func() {
+		opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+	})
+
+	m := macaron.New()
+	m.Use(Tracing())
+	m.Use(func(c *macaron.Context) {
+		span, _ := opentracing.StartSpanFromContext(c.Req.Context(), "middleware")
+		span.Finish()
+	})
+	m.Get("/api/dashboards/uid/:uid", RequestTracing("GET /api/dashboards/uid/:uid"), func(c *macaron.Context) {
+		span, _ := opentracing.StartSpanFromContext(c.Req.Context(), "handler")
+		span.Finish()
+		c.Resp.WriteHeader(404)
+	})
+
+	req, err := http.NewRequest("GET", "/api/dashboards/uid/abc", nil)
+	require.NoError(t, err)
+	m.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 3)
+	require.Equal(t, "middleware", spans[0].OperationName)
+	require.Equal(t, "handler", spans[1].OperationName)
+
+	requestSpan := spans[2]
+	require.Equal(t, "HTTP GET /api/dashboards/uid/:uid", requestSpan.OperationName)
+	require.Equal(t, uint16(404), requestSpan.Tag("http.status_code"))
+	require.Equal(t, true, requestSpan.Tag("error"))
+	require.Equal(t, requestSpan.SpanContext.SpanID, spans[0].ParentID)
+	require.Equal(t, requestSpan.SpanContext.SpanID, spans[1].ParentID)
+}
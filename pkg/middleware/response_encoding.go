@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"gopkg.in/macaron.v1"
+)
+
+var encodingLogger = log.New("response.encoding")
+
+// ResponseEncodingOptions are the optimizations applied to the responses of a route group.
+type ResponseEncodingOptions struct {
+	// Compress compresses the responses with brotli or gzip, whichever the client prefers
+	Compress bool
+	// CompressMinSize is the size in bytes from which responses are compressed
+	CompressMinSize int
+	// ETag tags the responses of GET requests, answering the requests for an unchanged response
+	// with 304 Not Modified
+	ETag bool
+}
+
+// EncodeResponse compresses and tags the responses of the routes it's added to. Responses are held
+// until the handlers return, so it's not meant for streamed responses.
+func EncodeResponse(opts ResponseEncodingOptions) macaron.Handler {
+	return func(ctx *macaron.Context) {
+		if !opts.Compress && !opts.ETag {
+			return
+		}
+
+		resp := ctx.Resp
+		buffer := &responseBuffer{header: resp.Header()}
+		setResponseWriter(ctx, macaron.NewResponseWriter(ctx.Req.Method, buffer))
+
+		ctx.Next()
+
+		setResponseWriter(ctx, resp)
+		if buffer.status == 0 {
+			return
+		}
+		writeEncodedResponse(ctx, buffer, opts)
+	}
+}
+
+func setResponseWriter(ctx *macaron.Context, w macaron.ResponseWriter) {
+	ctx.Resp = w
+	ctx.MapTo(w, (*http.ResponseWriter)(nil))
+	if _, ok := ctx.Render.(*macaron.DummyRender); !ok && ctx.Render != nil {
+		ctx.Render.SetResponseWriter(w)
+	}
+}
+
+func writeEncodedResponse(ctx *macaron.Context, buffer *responseBuffer, opts ResponseEncodingOptions) {
+	header := ctx.Resp.Header()
+	body := buffer.body.Bytes()
+
+	isGet := ctx.Req.Method == http.MethodGet || ctx.Req.Method == http.MethodHead
+	if opts.ETag && isGet && buffer.status == http.StatusOK && header.Get("ETag") == "" {
+		// weak, as the compressed and uncompressed responses share the same tag
+		etag := fmt.Sprintf(`W/"%x"`, sha1.Sum(body))
+		header.Set("ETag", etag)
+
+		if etagMatches(ctx.Req.Header.Get("If-None-Match"), etag) {
+			header.Del("Content-Type")
+			header.Del("Content-Length")
+			ctx.Resp.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	// the responses compressed by the gzip middleware already have a Content-Encoding, and their
+	// length is only known once compressed
+	if header.Get("Content-Encoding") == "" {
+		if opts.Compress && len(body) >= opts.CompressMinSize {
+			header.Add("Vary", "Accept-Encoding")
+			if encoding := negotiateEncoding(ctx.Req.Header.Get("Accept-Encoding")); encoding != "" {
+				if compressed, err := compress(encoding, body); err == nil {
+					header.Set("Content-Encoding", encoding)
+					body = compressed
+				}
+			}
+		}
+		header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+
+	ctx.Resp.WriteHeader(buffer.status)
+	if _, err := ctx.Resp.Write(body); err != nil {
+		encodingLogger.Debug("Failed to write response", "path", ctx.Req.URL.Path, "error", err)
+	}
+}
+
+// responseBuffer holds a response until it's encoded. It shares its header with the actual response.
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(p)
+}
+
+// etagMatches returns whether an If-None-Match header matches the etag, using the weak comparison.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding returns the encoding of the Accept-Encoding header with the highest quality
+// among brotli and gzip, preferring brotli, or an empty string when the client accepts neither.
+func negotiateEncoding(acceptEncoding string) string {
+	best, bestQuality := "", 0.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(part, ";")
+		encoding := strings.ToLower(strings.TrimSpace(params[0]))
+		quality := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		candidates := []string{encoding}
+		if encoding == "*" {
+			candidates = []string{"br", "gzip"}
+		} else if encoding != "br" && encoding != "gzip" {
+			continue
+		}
+
+		for _, candidate := range candidates {
+			if quality > bestQuality || (quality == bestQuality && quality > 0 && candidate == "br") {
+				best, bestQuality = candidate, quality
+			}
+		}
+	}
+	return best
+}
+
+func compress(encoding string, body []byte) ([]byte, error) {
+	var compressed bytes.Buffer
+	var w io.WriteCloser
+	if encoding == "br" {
+		w = brotli.NewWriterLevel(&compressed, brotli.DefaultCompression)
+	} else {
+		w = gzip.NewWriter(&compressed)
+	}
+
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
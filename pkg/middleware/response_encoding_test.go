@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/macaron.v1"
+)
+
+func TestEncodeResponse(t *testing.T) {
+	body := strings.Repeat(`{"panels":[]}`, 100)
+
+	newMacaron := func(opts ResponseEncodingOptions) *macaron.Macaron {
+		m := macaron.New()
+		m.Use(macaron.Renderer())
+		m.Get("/api/dashboard", EncodeResponse(opts), func(ctx *macaron.Context) {
+			ctx.JSON(200, body)
+		})
+		m.Get("/api/small", EncodeResponse(opts), func(ctx *macaron.Context) {
+			ctx.PlainText(200, []byte("small"))
+		})
+		return m
+	}
+	request := func(m *macaron.Macaron, path string, header map[string]string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		require.NoError(t, err)
+		for name, value := range header {
+			req.Header.Set(name, value)
+		}
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Compresses responses with the encoding preferred by the client", func(t *testing.T) {
+		m := newMacaron(ResponseEncodingOptions{Compress: true, CompressMinSize: 100})
+
+		rec := request(m, "/api/dashboard", map[string]string{"Accept-Encoding": "gzip, deflate, br"})
+		require.Equal(t, 200, rec.Code)
+		require.Equal(t, "br", rec.Header().Get("Content-Encoding"))
+		decoded, err := ioutil.ReadAll(brotli.NewReader(rec.Body))
+		require.NoError(t, err)
+		require.Contains(t, string(decoded), `{\"panels\":[]}`)
+
+		rec = request(m, "/api/dashboard", map[string]string{"Accept-Encoding": "br;q=0.5, gzip"})
+		require.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+		reader, err := gzip.NewReader(rec.Body)
+		require.NoError(t, err)
+		decoded, err = ioutil.ReadAll(reader)
+		require.NoError(t, err)
+		require.Contains(t, string(decoded), `{\"panels\":[]}`)
+
+		rec = request(m, "/api/dashboard", nil)
+		require.Empty(t, rec.Header().Get("Content-Encoding"))
+		require.Contains(t, rec.Body.String(), `{\"panels\":[]}`)
+	})
+
+	t.Run("Doesn't compress small responses", func(t *testing.T) {
+		m := newMacaron(ResponseEncodingOptions{Compress: true, CompressMinSize: 100})
+
+		rec := request(m, "/api/small", map[string]string{"Accept-Encoding": "gzip"})
+		require.Empty(t, rec.Header().Get("Content-Encoding"))
+		require.Equal(t, "small", rec.Body.String())
+	})
+
+	t.Run("Answers requests for unchanged responses with 304 Not Modified", func(t *testing.T) {
+		m := newMacaron(ResponseEncodingOptions{ETag: true})
+
+		rec := request(m, "/api/dashboard", nil)
+		require.Equal(t, 200, rec.Code)
+		etag := rec.Header().Get("ETag")
+		require.True(t, strings.HasPrefix(etag, `W/"`))
+
+		rec = request(m, "/api/dashboard", map[string]string{"If-None-Match": etag})
+		require.Equal(t, 304, rec.Code)
+		require.Empty(t, rec.Body.String())
+
+		rec = request(m, "/api/dashboard", map[string]string{"If-None-Match": `W/"other"`})
+		require.Equal(t, 200, rec.Code)
+		require.Equal(t, etag, rec.Header().Get("ETag"))
+	})
+}
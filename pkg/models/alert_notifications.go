@@ -2,8 +2,10 @@ package models
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/grafana/grafana/pkg/components/securejsondata"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 )
 
@@ -13,6 +15,9 @@ var (
 	ErrAlertNotificationStateVersionConflict    = errors.New("alert notification state update version conflict")
 	ErrAlertNotificationStateAlreadyExist       = errors.New("alert notification state already exists")
 	ErrAlertNotificationFailedGenerateUniqueUid = errors.New("Failed to generate unique alert notification uid")
+	ErrAlertNotificationWithSameNameExists      = errors.New("An alert notification with the same name already exists")
+	ErrAlertNotificationWithSameUIDExists       = errors.New("An alert notification with the same uid already exists")
+	ErrAlertNotificationVersionMismatch         = errors.New("The alert notification has been changed by someone else")
 )
 
 type AlertNotificationStateType string
@@ -36,50 +41,82 @@ type AlertNotification struct {
 	Settings              *simplejson.Json `json:"settings"`
 	Created               time.Time        `json:"created"`
 	Updated               time.Time        `json:"updated"`
+	// Version is incremented by each update of the notification
+	Version int `json:"version"`
+
+	// SecureSettings are the settings of the notifier that are encrypted at rest, like tokens and passwords
+	SecureSettings securejsondata.SecureJsonData `json:"-"`
+	// Provisioned is true for notifications that are created or updated from provisioning files
+	Provisioned bool `json:"provisioned"`
 }
 
-type CreateAlertNotificationCommand struct {
-	Uid                   string           `json:"uid"`
-	Name                  string           `json:"name"  binding:"Required"`
-	Type                  string           `json:"type"  binding:"Required"`
-	SendReminder          bool             `json:"sendReminder"`
-	DisableResolveMessage bool             `json:"disableResolveMessage"`
-	Frequency             string           `json:"frequency"`
-	IsDefault             bool             `json:"isDefault"`
-	Settings              *simplejson.Json `json:"settings"`
+// DecryptedValue returns the decrypted secure setting field, with the reference to a secret resolved,
+// or fallback for notifications that store the field in their settings
+func (an *AlertNotification) DecryptedValue(field string, fallback string) (string, error) {
+	value, ok := an.SecureSettings.DecryptedValue(field)
+	if !ok {
+		return fallback, nil
+	}
+
+	value, err := securejsondata.ResolveReference(an.OrgId, an.Provisioned, value)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", field, err)
+	}
+	return value, nil
+}
 
-	OrgId  int64 `json:"-"`
-	Result *AlertNotification
+type CreateAlertNotificationCommand struct {
+	Uid                   string            `json:"uid"`
+	Name                  string            `json:"name"  binding:"Required"`
+	Type                  string            `json:"type"  binding:"Required"`
+	SendReminder          bool              `json:"sendReminder"`
+	DisableResolveMessage bool              `json:"disableResolveMessage"`
+	Frequency             string            `json:"frequency"`
+	IsDefault             bool              `json:"isDefault"`
+	Settings              *simplejson.Json  `json:"settings"`
+	SecureSettings        map[string]string `json:"secureSettings"`
+
+	OrgId       int64 `json:"-"`
+	Provisioned bool  `json:"-"`
+	Result      *AlertNotification
 }
 
 type UpdateAlertNotificationCommand struct {
-	Id                    int64            `json:"id"  binding:"Required"`
-	Uid                   string           `json:"uid"`
-	Name                  string           `json:"name"  binding:"Required"`
-	Type                  string           `json:"type"  binding:"Required"`
-	SendReminder          bool             `json:"sendReminder"`
-	DisableResolveMessage bool             `json:"disableResolveMessage"`
-	Frequency             string           `json:"frequency"`
-	IsDefault             bool             `json:"isDefault"`
-	Settings              *simplejson.Json `json:"settings"  binding:"Required"`
-
-	OrgId  int64 `json:"-"`
-	Result *AlertNotification
+	Id                    int64             `json:"id"  binding:"Required"`
+	Uid                   string            `json:"uid"`
+	Name                  string            `json:"name"  binding:"Required"`
+	Type                  string            `json:"type"  binding:"Required"`
+	SendReminder          bool              `json:"sendReminder"`
+	DisableResolveMessage bool              `json:"disableResolveMessage"`
+	Frequency             string            `json:"frequency"`
+	IsDefault             bool              `json:"isDefault"`
+	Settings              *simplejson.Json  `json:"settings"  binding:"Required"`
+	SecureSettings        map[string]string `json:"secureSettings"`
+
+	OrgId       int64 `json:"-"`
+	Provisioned bool  `json:"-"`
+	// Version is the version the notification is expected to have, if set
+	Version int `json:"-"`
+	Result  *AlertNotification
 }
 
 type UpdateAlertNotificationWithUidCommand struct {
-	Uid                   string           `json:"-"`
-	NewUid                string           `json:"uid"`
-	Name                  string           `json:"name"  binding:"Required"`
-	Type                  string           `json:"type"  binding:"Required"`
-	SendReminder          bool             `json:"sendReminder"`
-	DisableResolveMessage bool             `json:"disableResolveMessage"`
-	Frequency             string           `json:"frequency"`
-	IsDefault             bool             `json:"isDefault"`
-	Settings              *simplejson.Json `json:"settings"  binding:"Required"`
-
-	OrgId  int64
-	Result *AlertNotification
+	Uid                   string            `json:"-"`
+	NewUid                string            `json:"uid"`
+	Name                  string            `json:"name"  binding:"Required"`
+	Type                  string            `json:"type"  binding:"Required"`
+	SendReminder          bool              `json:"sendReminder"`
+	DisableResolveMessage bool              `json:"disableResolveMessage"`
+	Frequency             string            `json:"frequency"`
+	IsDefault             bool              `json:"isDefault"`
+	Settings              *simplejson.Json  `json:"settings"  binding:"Required"`
+	SecureSettings        map[string]string `json:"secureSettings"`
+	// Version is the version the notification is expected to have, if set
+	Version int `json:"version"`
+
+	OrgId       int64
+	Provisioned bool `json:"-"`
+	Result      *AlertNotification
 }
 
 type DeleteAlertNotificationCommand struct {
@@ -113,6 +150,11 @@ type GetAlertNotificationsWithUidQuery struct {
 	Result *AlertNotification
 }
 
+// GetProvisionedAlertNotificationsQuery returns the provisioned notifications of all organizations
+type GetProvisionedAlertNotificationsQuery struct {
+	Result []*AlertNotification
+}
+
 type GetAlertNotificationsWithUidToSendQuery struct {
 	Uids  []string
 	OrgId int64
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrAnnotationStoreInvalidDataSource = errors.New("Annotation store must be a Loki or Elasticsearch data source")
+)
+
+// AnnotationStore is the data source annotations of an organization are written to and
+// queried from instead of the Grafana database
+type AnnotationStore struct {
+	Id           int64
+	OrgId        int64
+	DatasourceId int64
+	Updated      time.Time
+}
+
+// IsAnnotationStoreType returns true if annotations can be stored in data sources of the type
+func IsAnnotationStoreType(dsType string) bool {
+	return dsType == DS_LOKI || dsType == DS_ES
+}
+
+// ---------------------
+// COMMANDS
+
+// SetAnnotationStoreCommand sets the annotation store of an organization, a DatasourceId
+// of 0 stores annotations in the Grafana database
+type SetAnnotationStoreCommand struct {
+	DatasourceId int64 `json:"datasourceId"`
+	OrgId        int64 `json:"-"`
+}
+
+// ---------------------
+// QUERIES
+
+// GetAnnotationStoreQuery returns the annotation store of an organization, Result is nil if
+// annotations are stored in the Grafana database
+type GetAnnotationStoreQuery struct {
+	OrgId  int64
+	Result *AnnotationStore
+}
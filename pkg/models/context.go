@@ -13,6 +13,8 @@ type ReqContext struct {
 	*macaron.Context
 	*SignedInUser
 	UserToken *UserToken
+	// KioskToken is set when the request is authenticated with a kiosk token
+	KioskToken *KioskToken
 
 	IsSignedIn     bool
 	IsRenderCall   bool
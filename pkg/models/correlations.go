@@ -0,0 +1,103 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// Typed errors
+var (
+	ErrCorrelationNotFound                = errors.New("Correlation not found")
+	ErrCorrelationLabelEmpty              = errors.New("Correlation label cannot be empty")
+	ErrCorrelationFieldEmpty              = errors.New("Correlation field cannot be empty")
+	ErrCorrelationTargetEmpty             = errors.New("Correlation target query cannot be empty")
+	ErrCorrelationTargetDataSourceMissing = errors.New("Correlation target data source not found")
+	ErrCorrelationFailedGenerateUniqueUid = errors.New("Failed to generate unique correlation uid")
+)
+
+// Correlation links a field in the results of the source data source to a
+// query in the target data source. The target query can reference the value
+// of the field with ${__value.raw}.
+type Correlation struct {
+	Id                 int64
+	OrgId              int64
+	Uid                string
+	SourceDatasourceId int64
+	TargetDatasourceId int64
+	Label              string
+	Description        string
+	Field              string
+	Target             *simplejson.Json
+
+	Created time.Time
+	Updated time.Time
+}
+
+// CorrelationDTO is the frontend model of a correlation
+type CorrelationDTO struct {
+	Uid                 string           `json:"uid"`
+	SourceDatasourceId  int64            `json:"sourceDatasourceId"`
+	TargetDatasourceId  int64            `json:"targetDatasourceId"`
+	TargetDatasourceUid string           `json:"targetDatasourceUid,omitempty"`
+	Label               string           `json:"label"`
+	Description         string           `json:"description"`
+	Field               string           `json:"field"`
+	Target              *simplejson.Json `json:"target"`
+}
+
+//
+// COMMANDS
+//
+
+type CreateCorrelationCommand struct {
+	TargetDatasourceId int64            `json:"targetDatasourceId" binding:"Required"`
+	Label              string           `json:"label"`
+	Description        string           `json:"description"`
+	Field              string           `json:"field"`
+	Target             *simplejson.Json `json:"target"`
+
+	SourceDatasourceId int64 `json:"-"`
+	OrgId              int64 `json:"-"`
+
+	Result *Correlation
+}
+
+type UpdateCorrelationCommand struct {
+	Label       string           `json:"label"`
+	Description string           `json:"description"`
+	Field       string           `json:"field"`
+	Target      *simplejson.Json `json:"target"`
+
+	Uid                string `json:"-"`
+	SourceDatasourceId int64  `json:"-"`
+	OrgId              int64  `json:"-"`
+
+	Result *Correlation
+}
+
+type DeleteCorrelationCommand struct {
+	Uid                string
+	SourceDatasourceId int64
+	OrgId              int64
+}
+
+//
+// QUERIES
+//
+
+type GetCorrelationQuery struct {
+	Uid                string
+	SourceDatasourceId int64
+	OrgId              int64
+
+	Result *Correlation
+}
+
+type GetCorrelationsBySourceQuery struct {
+	SourceDatasourceId int64
+	OrgId              int64
+
+	Result []*CorrelationDTO
+}
@@ -93,6 +93,40 @@ func (dto *DashboardAclInfoDTO) IsDuplicateOf(other *DashboardAclInfoDTO) bool {
 	return dto.hasSameRoleAs(other) || dto.hasSameUserAs(other) || dto.hasSameTeamAs(other)
 }
 
+// Sources of the effective permission of a user for a dashboard or folder
+const (
+	PermissionSourceOrgAdmin = "org_admin"
+	PermissionSourceUser     = "user"
+	PermissionSourceTeam     = "team"
+	PermissionSourceRole     = "role"
+	PermissionSourceNone     = "none"
+)
+
+// DashboardPermissionPreview is the effective permission of a user for a dashboard or
+// folder together with the permission rule that grants it
+type DashboardPermissionPreview struct {
+	UserId         int64          `json:"userId"`
+	UserLogin      string         `json:"userLogin"`
+	OrgRole        RoleType       `json:"orgRole"`
+	DashboardId    int64          `json:"dashboardId"`
+	Uid            string         `json:"uid"`
+	Title          string         `json:"title"`
+	IsFolder       bool           `json:"isFolder"`
+	Permission     PermissionType `json:"permission"`
+	PermissionName string         `json:"permissionName"`
+	CanView        bool           `json:"canView"`
+	CanEdit        bool           `json:"canEdit"`
+	CanSave        bool           `json:"canSave"`
+	CanAdmin       bool           `json:"canAdmin"`
+	// Source is one of the PermissionSource constants
+	Source string `json:"source"`
+	// Rule is the permission rule that grants the effective permission, it is not set
+	// for org admins and users without access
+	Rule *DashboardAclInfoDTO `json:"rule,omitempty"`
+	// MatchingRules are all the permission rules that apply to the user
+	MatchingRules []*DashboardAclInfoDTO `json:"matchingRules"`
+}
+
 //
 // COMMANDS
 //
@@ -0,0 +1,257 @@
+package models
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/encryption"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+const (
+	// sensitiveFieldsKey lists the paths of the sensitive options of a panel, e.g. options.content
+	// or targets.*.token. Path elements are separated by dots, and index arrays when they're
+	// numbers, or match all their items when they're *.
+	sensitiveFieldsKey = "sensitiveFields"
+	// encryptedFieldKey holds the encrypted value of a sensitive field in the stored dashboards
+	encryptedFieldKey = "$__encrypted"
+)
+
+// EncryptSensitiveFields returns a copy of the dashboard JSON in which the sensitive fields of the
+// panels are encrypted, to be stored.
+func EncryptSensitiveFields(dashboard *simplejson.Json) (*simplejson.Json, error) {
+	encoded, err := dashboard.Encode()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := simplejson.NewJson(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	err = visitSensitiveFields(encrypted, func(value interface{}) (interface{}, error) {
+		if isEncryptedField(value) {
+			return value, nil
+		}
+
+		plain, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		ciphertext, err := encryption.Encrypt(plain)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{encryptedFieldKey: base64.StdEncoding.EncodeToString(ciphertext)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return encrypted, nil
+}
+
+// DecryptSensitiveFields decrypts the sensitive fields of a stored dashboard JSON. The fields which
+// can't be decrypted are removed, and the first error is returned once the others are decrypted.
+func DecryptSensitiveFields(dashboard *simplejson.Json) error {
+	var firstErr error
+	err := visitSensitiveFields(dashboard, func(value interface{}) (interface{}, error) {
+		if !isEncryptedField(value) {
+			// stored before the field was marked as sensitive
+			return value, nil
+		}
+
+		decrypted, err := decryptField(value.(map[string]interface{})[encryptedFieldKey])
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return nil, nil
+		}
+		return decrypted, nil
+	})
+	if err != nil {
+		return err
+	}
+	return firstErr
+}
+
+// ReEncryptSensitiveFields encrypts the encrypted sensitive fields of a stored dashboard JSON again,
+// and returns whether the dashboard has any.
+func ReEncryptSensitiveFields(dashboard *simplejson.Json) (bool, error) {
+	reEncrypted := false
+	err := visitSensitiveFields(dashboard, func(value interface{}) (interface{}, error) {
+		if !isEncryptedField(value) {
+			return value, nil
+		}
+
+		ciphertext, err := base64.StdEncoding.DecodeString(value.(map[string]interface{})[encryptedFieldKey].(string))
+		if err != nil {
+			return nil, err
+		}
+		plain, err := encryption.Decrypt(ciphertext)
+		if err != nil {
+			return nil, err
+		}
+		ciphertext, err = encryption.Encrypt(plain)
+		if err != nil {
+			return nil, err
+		}
+
+		reEncrypted = true
+		return map[string]interface{}{encryptedFieldKey: base64.StdEncoding.EncodeToString(ciphertext)}, nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return reEncrypted, nil
+}
+
+// RedactSensitiveFields removes the sensitive fields from a dashboard JSON, for the users who
+// can't edit the dashboard.
+func RedactSensitiveFields(dashboard *simplejson.Json) {
+	// the callback never fails
+	_ = visitSensitiveFields(dashboard, func(value interface{}) (interface{}, error) {
+		return nil, nil
+	})
+}
+
+// EncryptPanelSensitiveFields returns a copy of a panel JSON, like the model of a library panel, in
+// which its sensitive fields are encrypted, to be stored.
+func EncryptPanelSensitiveFields(panel *simplejson.Json) (*simplejson.Json, error) {
+	encrypted, err := EncryptSensitiveFields(dashboardOfPanel(panel))
+	if err != nil {
+		return nil, err
+	}
+	return encrypted.Get("panels").GetIndex(0), nil
+}
+
+// DecryptPanelSensitiveFields decrypts the sensitive fields of a stored panel JSON, like
+// DecryptSensitiveFields.
+func DecryptPanelSensitiveFields(panel *simplejson.Json) error {
+	return DecryptSensitiveFields(dashboardOfPanel(panel))
+}
+
+// ReEncryptPanelSensitiveFields encrypts the encrypted sensitive fields of a stored panel JSON again,
+// and returns whether the panel has any.
+func ReEncryptPanelSensitiveFields(panel *simplejson.Json) (bool, error) {
+	return ReEncryptSensitiveFields(dashboardOfPanel(panel))
+}
+
+// RedactPanelSensitiveFields removes the sensitive fields from a panel JSON, for the users who can't
+// change it.
+func RedactPanelSensitiveFields(panel *simplejson.Json) {
+	RedactSensitiveFields(dashboardOfPanel(panel))
+}
+
+// dashboardOfPanel returns a dashboard JSON holding a panel JSON, whose fields are updated in place
+// by the functions of the dashboards.
+func dashboardOfPanel(panel *simplejson.Json) *simplejson.Json {
+	return simplejson.NewFromAny(map[string]interface{}{"panels": []interface{}{panel.Interface()}})
+}
+
+func isEncryptedField(value interface{}) bool {
+	field, ok := value.(map[string]interface{})
+	if !ok || len(field) != 1 {
+		return false
+	}
+	_, ok = field[encryptedFieldKey].(string)
+	return ok
+}
+
+func decryptField(encoded interface{}) (interface{}, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded.(string))
+	if err != nil {
+		return nil, err
+	}
+	plain, err := encryption.Decrypt(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	// numbers are decoded the same way as in the rest of the dashboard JSON
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(plain))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// visitSensitiveFields calls fn with the value of every sensitive field of the panels, including
+// the panels of collapsed rows, and replaces the value with the one returned. The field is removed
+// when fn returns nil.
+func visitSensitiveFields(dashboard *simplejson.Json, fn func(value interface{}) (interface{}, error)) error {
+	var walk func(panels []interface{}) error
+	walk = func(panels []interface{}) error {
+		for _, p := range panels {
+			panel, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			for _, path := range simplejson.NewFromAny(panel).Get(sensitiveFieldsKey).MustStringArray() {
+				if path == "" {
+					continue
+				}
+				if err := visitField(panel, strings.Split(path, "."), fn); err != nil {
+					return err
+				}
+			}
+
+			if err := walk(simplejson.NewFromAny(panel).Get("panels").MustArray()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return walk(dashboard.Get("panels").MustArray())
+}
+
+func visitField(container interface{}, path []string, fn func(value interface{}) (interface{}, error)) error {
+	key, rest := path[0], path[1:]
+
+	switch c := container.(type) {
+	case map[string]interface{}:
+		value, exists := c[key]
+		if !exists {
+			return nil
+		}
+		if len(rest) > 0 {
+			return visitField(value, rest, fn)
+		}
+
+		updated, err := fn(value)
+		if err != nil {
+			return err
+		}
+		if updated == nil {
+			delete(c, key)
+		} else {
+			c[key] = updated
+		}
+	case []interface{}:
+		for i := range c {
+			if key != "*" && key != strconv.Itoa(i) {
+				continue
+			}
+			if len(rest) > 0 {
+				if err := visitField(c[i], rest, fn); err != nil {
+					return err
+				}
+				continue
+			}
+
+			updated, err := fn(c[i])
+			if err != nil {
+				return err
+			}
+			c[i] = updated
+		}
+	}
+	return nil
+}
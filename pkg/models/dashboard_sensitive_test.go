@@ -0,0 +1,120 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/stretchr/testify/require"
+)
+
+func newSensitiveDashboard(t *testing.T) *simplejson.Json {
+	dashboard, err := simplejson.NewJson([]byte(`{
+		"title": "Sensitive",
+		"panels": [
+			{
+				"id": 1,
+				"type": "text",
+				"sensitiveFields": ["options.content", "missing.field"],
+				"options": {"content": "<iframe src=\"https://example.com/?token=secret\"></iframe>", "mode": "html"}
+			},
+			{
+				"id": 2,
+				"type": "row",
+				"collapsed": true,
+				"panels": [
+					{
+						"id": 3,
+						"sensitiveFields": ["targets.*.apiKey", "links.0"],
+						"targets": [{"refId": "A", "apiKey": "key-a"}, {"refId": "B", "apiKey": 42}],
+						"links": [{"url": "https://example.com/?token=secret"}, {"url": "https://example.com"}]
+					}
+				]
+			}
+		]
+	}`))
+	require.NoError(t, err)
+	return dashboard
+}
+
+func TestSensitiveFields(t *testing.T) {
+	t.Run("Should encrypt a copy of the sensitive fields and decrypt them", func(t *testing.T) {
+		dashboard := newSensitiveDashboard(t)
+
+		encrypted, err := EncryptSensitiveFields(dashboard)
+		require.NoError(t, err)
+
+		// the dashboard itself is left unchanged
+		require.Equal(t, newSensitiveDashboard(t), dashboard)
+
+		text := encrypted.Get("panels").GetIndex(0)
+		require.Equal(t, "html", text.GetPath("options", "mode").MustString())
+		require.True(t, isEncryptedField(text.GetPath("options", "content").Interface()))
+
+		nested := encrypted.Get("panels").GetIndex(1).Get("panels").GetIndex(0)
+		for i := 0; i < 2; i++ {
+			target := nested.Get("targets").GetIndex(i)
+			require.NotEmpty(t, target.Get("refId").MustString())
+			require.True(t, isEncryptedField(target.Get("apiKey").Interface()))
+		}
+		require.True(t, isEncryptedField(nested.Get("links").GetIndex(0).Interface()))
+		require.Equal(t, "https://example.com", nested.Get("links").GetIndex(1).Get("url").MustString())
+
+		// encrypted fields aren't encrypted twice
+		reEncrypted, err := EncryptSensitiveFields(encrypted)
+		require.NoError(t, err)
+		require.Equal(t, encrypted, reEncrypted)
+
+		err = DecryptSensitiveFields(encrypted)
+		require.NoError(t, err)
+		require.Equal(t, newSensitiveDashboard(t), encrypted)
+	})
+
+	t.Run("Should leave the fields stored before they were marked as sensitive", func(t *testing.T) {
+		dashboard := newSensitiveDashboard(t)
+
+		err := DecryptSensitiveFields(dashboard)
+		require.NoError(t, err)
+		require.Equal(t, newSensitiveDashboard(t), dashboard)
+	})
+
+	t.Run("Should remove the fields which can't be decrypted", func(t *testing.T) {
+		dashboard := simplejson.NewFromAny(map[string]interface{}{
+			"panels": []interface{}{
+				map[string]interface{}{
+					"sensitiveFields": []interface{}{"options.content"},
+					"options": map[string]interface{}{
+						"content": map[string]interface{}{encryptedFieldKey: "not encrypted"},
+						"mode":    "markdown",
+					},
+				},
+			},
+		})
+
+		err := DecryptSensitiveFields(dashboard)
+		require.Error(t, err)
+
+		options := dashboard.Get("panels").GetIndex(0).Get("options")
+		_, exists := options.CheckGet("content")
+		require.False(t, exists)
+		require.Equal(t, "markdown", options.Get("mode").MustString())
+	})
+
+	t.Run("Should redact the sensitive fields", func(t *testing.T) {
+		dashboard := newSensitiveDashboard(t)
+
+		RedactSensitiveFields(dashboard)
+
+		text := dashboard.Get("panels").GetIndex(0)
+		_, exists := text.Get("options").CheckGet("content")
+		require.False(t, exists)
+		require.Equal(t, "html", text.GetPath("options", "mode").MustString())
+
+		nested := dashboard.Get("panels").GetIndex(1).Get("panels").GetIndex(0)
+		for i := 0; i < 2; i++ {
+			_, exists := nested.Get("targets").GetIndex(i).CheckGet("apiKey")
+			require.False(t, exists)
+		}
+		require.Nil(t, nested.Get("links").GetIndex(0).Interface())
+		require.Equal(t, "https://example.com", nested.Get("links").GetIndex(1).Get("url").MustString())
+	})
+}
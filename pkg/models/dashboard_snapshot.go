@@ -40,6 +40,25 @@ type DashboardSnapshotDTO struct {
 	Updated time.Time `json:"updated"`
 }
 
+// AdminDashboardSnapshotDTO is a snapshot as listed to server admins,
+// including who created it and the size of the stored dashboard in bytes
+type AdminDashboardSnapshotDTO struct {
+	Id             int64  `json:"id"`
+	Name           string `json:"name"`
+	Key            string `json:"key"`
+	OrgId          int64  `json:"orgId"`
+	UserId         int64  `json:"userId"`
+	CreatedBy      string `json:"createdBy"`
+	CreatedByEmail string `json:"createdByEmail"`
+	External       bool   `json:"external"`
+	ExternalUrl    string `json:"externalUrl"`
+	Size           int64  `json:"size"`
+
+	Expires time.Time `json:"expires"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
 // -----------------
 // COMMANDS
 
@@ -88,3 +107,22 @@ type GetDashboardSnapshotsQuery struct {
 
 	Result DashboardSnapshotsList
 }
+
+// SearchAllDashboardSnapshotsQuery searches snapshots across all organizations
+type SearchAllDashboardSnapshotsQuery struct {
+	Query   string
+	OrgId   int64
+	UserId  int64
+	Expired *bool
+	Page    int
+	Limit   int
+
+	Result SearchAllDashboardSnapshotsQueryResult
+}
+
+type SearchAllDashboardSnapshotsQueryResult struct {
+	TotalCount int64                        `json:"totalCount"`
+	Snapshots  []*AdminDashboardSnapshotDTO `json:"snapshots"`
+	Page       int                          `json:"page"`
+	PerPage    int                          `json:"perPage"`
+}
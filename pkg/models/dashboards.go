@@ -21,7 +21,8 @@ var (
 	ErrDashboardWithSameNameInFolderExists       = errors.New("A dashboard with the same name in the folder already exists")
 	ErrDashboardVersionMismatch                  = errors.New("The dashboard has been changed by someone else")
 	ErrDashboardTitleEmpty                       = errors.New("Dashboard title cannot be empty")
-	ErrDashboardFolderCannotHaveParent           = errors.New("A Dashboard Folder cannot be added to another folder")
+	ErrDashboardFolderCannotHaveParent           = errors.New("A Dashboard Folder cannot be moved into itself or one of its subfolders")
+	ErrDashboardFolderNestingTooDeep             = errors.New("Dashboard Folders cannot be nested that deep")
 	ErrDashboardsWithSameSlugExists              = errors.New("Multiple dashboards with the same slug exists")
 	ErrDashboardFailedGenerateUniqueUid          = errors.New("Failed to generate unique dashboard id")
 	ErrDashboardTypeMismatch                     = errors.New("Dashboard cannot be changed to a folder")
@@ -38,6 +39,9 @@ var (
 	RootFolderName                               = "General"
 )
 
+// MaxFolderNestingDepth is the maximum number of folders a dashboard can be nested in
+const MaxFolderNestingDepth = 8
+
 type UpdatePluginDashboardError struct {
 	PluginId string
 }
@@ -315,7 +319,10 @@ type GetDashboardTagsQuery struct {
 
 type GetDashboardsQuery struct {
 	DashboardIds []int64
-	Result       []*Dashboard
+	// DashboardUIds are looked up in OrgId when no DashboardIds are given
+	DashboardUIds []string
+	OrgId         int64
+	Result        []*Dashboard
 }
 
 type GetDashboardPermissionsForUserQuery struct {
@@ -342,6 +349,8 @@ type GetProvisionedDashboardDataByIdQuery struct {
 	Result      *DashboardProvisioning
 }
 
+// GetProvisionedDashboardDataQuery returns the provisioning data of the dashboards of a
+// provisioner, or of all provisioners when Name is empty
 type GetProvisionedDashboardDataQuery struct {
 	Name   string
 	Result []*DashboardProvisioning
@@ -373,3 +382,8 @@ type GetDashboardRefByIdQuery struct {
 type UnprovisionDashboardCommand struct {
 	Id int64
 }
+
+// ResetDashboardProvisioningCommand makes the next provisioning save the dashboard from its file again
+type ResetDashboardProvisioningCommand struct {
+	DashboardId int64
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Typed errors
+var (
+	ErrDataKeyNotFound = errors.New("Data key not found")
+)
+
+// DataKey encrypts the secrets stored in the database. Data keys are stored encrypted with a key
+// encryption key of a provider, e.g. the secret_key or a key of AWS KMS.
+type DataKey struct {
+	Id            int64
+	Name          string
+	Provider      string
+	EncryptedData []byte
+	// Active is true for the data key encrypting new secrets, the other data keys only decrypt
+	// the secrets encrypted before they were rotated.
+	Active  bool
+	Created time.Time
+	Updated time.Time
+}
+
+// ----------------------
+// COMMANDS
+
+// CreateDataKeyCommand creates the active data key, deactivating the previous one
+type CreateDataKeyCommand struct {
+	Name          string
+	Provider      string
+	EncryptedData []byte
+
+	Result *DataKey
+}
+
+// ReEncryptSecretsCommand encrypts the secrets stored in the database again, with the active
+// data key or with the secret_key when envelope encryption is disabled
+type ReEncryptSecretsCommand struct {
+	Result *ReEncryptSecretsResult
+}
+
+// ReEncryptSecretsResult is the number of rows whose secrets were encrypted again per table
+type ReEncryptSecretsResult struct {
+	DataSources        int `json:"dataSources"`
+	PluginSettings     int `json:"pluginSettings"`
+	AlertNotifications int `json:"alertNotifications"`
+	UserAuths          int `json:"userAuths"`
+	Dashboards         int `json:"dashboards"`
+	DashboardVersions  int `json:"dashboardVersions"`
+	LibraryPanels      int `json:"libraryPanels"`
+}
+
+// ---------------------
+// QUERIES
+
+type GetDataKeyQuery struct {
+	Name string
+
+	Result *DataKey
+}
+
+type GetDataKeysQuery struct {
+	Result []*DataKey
+}
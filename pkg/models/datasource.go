@@ -69,22 +69,26 @@ type DataSource struct {
 
 // DecryptedBasicAuthPassword returns data source basic auth password in plain text. It uses either deprecated
 // basic_auth_password field or encrypted secure_json_data[basicAuthPassword] variable.
-func (ds *DataSource) DecryptedBasicAuthPassword() string {
+func (ds *DataSource) DecryptedBasicAuthPassword() (string, error) {
 	return ds.decryptedValue("basicAuthPassword", ds.BasicAuthPassword)
 }
 
 // DecryptedPassword returns data source password in plain text. It uses either deprecated password field
 // or encrypted secure_json_data[password] variable.
-func (ds *DataSource) DecryptedPassword() string {
+func (ds *DataSource) DecryptedPassword() (string, error) {
 	return ds.decryptedValue("password", ds.Password)
 }
 
 // decryptedValue returns decrypted value from secureJsonData
-func (ds *DataSource) decryptedValue(field string, fallback string) string {
-	if value, ok := ds.DecryptedValue(field); ok {
-		return value
+func (ds *DataSource) decryptedValue(field string, fallback string) (string, error) {
+	value, ok, err := ds.DecryptedValue(field)
+	if err != nil {
+		return "", err
 	}
-	return fallback
+	if ok {
+		return value, nil
+	}
+	return fallback, nil
 }
 
 var knownDatasourcePlugins = map[string]bool{
@@ -197,6 +201,13 @@ type DeleteDataSourceByNameCommand struct {
 	DeletedDatasourcesCount int64
 }
 
+// FlushAWSCredentialsCacheCommand drops the AWS credentials cached for the CloudWatch data
+// sources, which are fetched again by the next queries
+type FlushAWSCredentialsCacheCommand struct {
+	// Result is the number of credentials dropped
+	Result int
+}
+
 // ---------------------
 // QUERIES
 
@@ -222,6 +233,12 @@ type GetDataSourceByNameQuery struct {
 	Result *DataSource
 }
 
+type GetDataSourceByUidQuery struct {
+	Uid    string
+	OrgId  int64
+	Result *DataSource
+}
+
 // ---------------------
 //  Permissions
 // ---------------------
@@ -10,7 +10,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/grafana/grafana/pkg/components/securejsondata"
+	"github.com/grafana/grafana/pkg/infra/awsauth"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/opentracing/opentracing-go"
 )
 
 type proxyTransportCache struct {
@@ -22,6 +25,9 @@ type proxyTransportCache struct {
 type dataSourceTransport struct {
 	headers   map[string]string
 	transport *http.Transport
+	settings  DataSourceHTTPSettings
+	// sigV4 signs the requests before sending them with transport, when the data source signs them
+	sigV4 *awsauth.SigV4Transport
 }
 
 // RoundTrip executes a single HTTP transaction, returning a Response for the provided Request.
@@ -30,7 +36,30 @@ func (d *dataSourceTransport) RoundTrip(req *http.Request) (*http.Response, erro
 		req.Header.Set(key, value)
 	}
 
-	return d.transport.RoundTrip(req)
+	// propagate the trace of the request to the data source, a failure only breaks the trace
+	if span := opentracing.SpanFromContext(req.Context()); span != nil {
+		_ = opentracing.GlobalTracer().Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
+	}
+
+	// the attempts are counted to record the retries of the request
+	attempts := 0
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if d.sigV4 != nil {
+			return d.sigV4.RoundTrip(req)
+		}
+		return d.transport.RoundTrip(req)
+	})
+
+	start := time.Now()
+	res, err := roundTripWithRetries(transport, req, d.settings.MaxRetries)
+	if recorder := dataSourceRequestRecorderFrom(req.Context()); recorder != nil {
+		res = recorder.record(req, res, err, time.Since(start), attempts-1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return limitResponse(res, d.settings.ResponseLimit)
 }
 
 type cachedTransport struct {
@@ -43,6 +72,24 @@ var ptc = proxyTransportCache{
 	cache: make(map[int64]cachedTransport),
 }
 
+func init() {
+	// the transports are built with the data proxy limits, they're built again once reloaded
+	setting.OnReload(func(cfg *setting.Cfg) {
+		ptc.clear()
+	})
+}
+
+// clear drops the cached transports, closing their idle connections
+func (c *proxyTransportCache) clear() {
+	c.Lock()
+	defer c.Unlock()
+
+	for _, t := range c.cache {
+		t.transport.CloseIdleConnections()
+	}
+	c.cache = make(map[int64]cachedTransport)
+}
+
 func (ds *DataSource) GetHttpClient() (*http.Client, error) {
 	transport, err := ds.GetHttpTransport()
 
@@ -51,19 +98,42 @@ func (ds *DataSource) GetHttpClient() (*http.Client, error) {
 	}
 
 	return &http.Client{
-		Timeout:   time.Duration(setting.DataProxyTimeout) * time.Second,
+		Timeout:   transport.settings.Timeout,
 		Transport: transport,
 	}, nil
 }
 
 func (ds *DataSource) GetHttpTransport() (*dataSourceTransport, error) {
+	ptc.Lock()
+	t, present := ptc.cache[ds.Id]
+	ptc.Unlock()
+	if present && ds.Updated.Equal(t.updated) {
+		return t.dataSourceTransport, nil
+	}
+
+	// built out of the lock, as resolving the references to secrets of the data source calls the secrets backends
+	dsTransport, err := ds.newHttpTransport()
+	if err != nil {
+		return nil, err
+	}
+
 	ptc.Lock()
 	defer ptc.Unlock()
 
 	if t, present := ptc.cache[ds.Id]; present && ds.Updated.Equal(t.updated) {
+		// built meanwhile by another request
 		return t.dataSourceTransport, nil
 	}
+	ptc.cache[ds.Id] = cachedTransport{
+		dataSourceTransport: dsTransport,
+		updated:             ds.Updated,
+	}
 
+	return dsTransport, nil
+}
+
+// newHttpTransport builds the transport of the data source
+func (ds *DataSource) newHttpTransport() (*dataSourceTransport, error) {
 	tlsConfig, err := ds.GetTLSConfig()
 	if err != nil {
 		return nil, err
@@ -72,31 +142,38 @@ func (ds *DataSource) GetHttpTransport() (*dataSourceTransport, error) {
 	tlsConfig.Renegotiation = tls.RenegotiateFreelyAsClient
 
 	// Create transport which adds all
-	customHeaders := ds.getCustomHeaders()
+	customHeaders, err := ds.getCustomHeaders()
+	if err != nil {
+		return nil, err
+	}
+	settings := ds.HTTPSettings()
 	transport := &http.Transport{
 		TLSClientConfig: tlsConfig,
 		Proxy:           http.ProxyFromEnvironment,
 		Dial: (&net.Dialer{
-			Timeout:   time.Duration(setting.DataProxyTimeout) * time.Second,
+			Timeout:   settings.Timeout,
 			KeepAlive: 30 * time.Second,
+			Control:   ds.networkPolicy().control,
 		}).Dial,
 		TLSHandshakeTimeout:   10 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
-		MaxIdleConns:          100,
-		IdleConnTimeout:       90 * time.Second,
-	}
-
-	dsTransport := &dataSourceTransport{
-		headers:   customHeaders,
-		transport: transport,
+		ResponseHeaderTimeout: settings.Timeout,
+		MaxIdleConns:          settings.MaxIdleConns,
+		MaxIdleConnsPerHost:   settings.MaxIdleConns,
+		IdleConnTimeout:       settings.IdleConnTimeout,
 	}
 
-	ptc.cache[ds.Id] = cachedTransport{
-		dataSourceTransport: dsTransport,
-		updated:             ds.Updated,
+	sigV4, err := ds.sigV4Transport(transport)
+	if err != nil {
+		return nil, err
 	}
 
-	return dsTransport, nil
+	return &dataSourceTransport{
+		headers:   customHeaders,
+		transport: transport,
+		settings:  settings,
+		sigV4:     sigV4,
+	}, nil
 }
 
 func (ds *DataSource) GetTLSConfig() (*tls.Config, error) {
@@ -112,7 +189,10 @@ func (ds *DataSource) GetTLSConfig() (*tls.Config, error) {
 	}
 
 	if tlsClientAuth || tlsAuthWithCACert {
-		decrypted := ds.SecureJsonData.Decrypt()
+		decrypted, err := securejsondata.ResolveReferences(ds.OrgId, ds.ReadOnly, ds.SecureJsonData.Decrypt())
+		if err != nil {
+			return nil, err
+		}
 		if tlsAuthWithCACert && len(decrypted["tlsCACert"]) > 0 {
 			caPool := x509.NewCertPool()
 			ok := caPool.AppendCertsFromPEM([]byte(decrypted["tlsCACert"]))
@@ -136,13 +216,16 @@ func (ds *DataSource) GetTLSConfig() (*tls.Config, error) {
 
 // getCustomHeaders returns a map with all the to be set headers
 // The map key represents the HeaderName and the value represents this header's value
-func (ds *DataSource) getCustomHeaders() map[string]string {
+func (ds *DataSource) getCustomHeaders() (map[string]string, error) {
 	headers := make(map[string]string)
 	if ds.JsonData == nil {
-		return headers
+		return headers, nil
 	}
 
-	decrypted := ds.SecureJsonData.Decrypt()
+	decrypted, err := securejsondata.ResolveReferences(ds.OrgId, ds.ReadOnly, ds.SecureJsonData.Decrypt())
+	if err != nil {
+		return nil, err
+	}
 	index := 1
 	for {
 		headerNameSuffix := fmt.Sprintf("httpHeaderName%d", index)
@@ -160,7 +243,7 @@ func (ds *DataSource) getCustomHeaders() map[string]string {
 		index++
 	}
 
-	return headers
+	return headers, nil
 }
 
 type cachedDecryptedJSON struct {
@@ -177,8 +260,16 @@ var dsDecryptionCache = secureJSONDecryptionCache{
 	cache: make(map[int64]cachedDecryptedJSON),
 }
 
-// DecryptedValues returns cached decrypted values from secureJsonData.
-func (ds *DataSource) DecryptedValues() map[string]string {
+// DecryptedValues returns cached decrypted values from secureJsonData, with the references to secrets resolved.
+// The references are resolved on every call, out of the lock of the cache, as they're cached by the secrets
+// service for a limited time to handle their rotation.
+func (ds *DataSource) DecryptedValues() (map[string]string, error) {
+	return securejsondata.ResolveReferences(ds.OrgId, ds.ReadOnly, ds.decryptedValuesUnresolved())
+}
+
+// decryptedValuesUnresolved returns cached decrypted values from secureJsonData, with the references to secrets
+// as they're stored.
+func (ds *DataSource) decryptedValuesUnresolved() map[string]string {
 	dsDecryptionCache.Lock()
 	defer dsDecryptionCache.Unlock()
 
@@ -195,10 +286,19 @@ func (ds *DataSource) DecryptedValues() map[string]string {
 	return json
 }
 
-// DecryptedValue returns cached decrypted value from cached secureJsonData.
-func (ds *DataSource) DecryptedValue(key string) (string, bool) {
-	value, exists := ds.DecryptedValues()[key]
-	return value, exists
+// DecryptedValue returns cached decrypted value from cached secureJsonData, with the reference to a secret
+// resolved.
+func (ds *DataSource) DecryptedValue(key string) (string, bool, error) {
+	value, exists := ds.decryptedValuesUnresolved()[key]
+	if !exists {
+		return "", false, nil
+	}
+
+	value, err := securejsondata.ResolveReference(ds.OrgId, ds.ReadOnly, value)
+	if err != nil {
+		return "", true, fmt.Errorf("failed to resolve %s: %w", key, err)
+	}
+	return value, true, nil
 }
 
 // ClearDSDecryptionCache clears the datasource decryption cache.
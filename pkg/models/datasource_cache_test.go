@@ -193,7 +193,8 @@ func TestDataSourceProxyCache(t *testing.T) {
 		}
 
 		Convey("Should match header value after decryption", func() {
-			headers := ds.getCustomHeaders()
+			headers, err := ds.getCustomHeaders()
+			So(err, ShouldBeNil)
 			So(headers["Authorization"], ShouldEqual, "Bearer xf5yhfkpsnmgo")
 		})
 
@@ -249,7 +250,8 @@ func TestDataSourceDecryptionCache(t *testing.T) {
 		}
 
 		// Populate cache
-		password, ok := ds.DecryptedValue("password")
+		password, ok, err := ds.DecryptedValue("password")
+		So(err, ShouldBeNil)
 		So(password, ShouldEqual, "password")
 		So(ok, ShouldBeTrue)
 
@@ -257,7 +259,8 @@ func TestDataSourceDecryptionCache(t *testing.T) {
 			"password": "",
 		})
 
-		password, ok = ds.DecryptedValue("password")
+		password, ok, err = ds.DecryptedValue("password")
+		So(err, ShouldBeNil)
 		So(password, ShouldEqual, "password")
 		So(ok, ShouldBeTrue)
 	})
@@ -276,7 +279,8 @@ func TestDataSourceDecryptionCache(t *testing.T) {
 		}
 
 		// Populate cache
-		password, ok := ds.DecryptedValue("password")
+		password, ok, err := ds.DecryptedValue("password")
+		So(err, ShouldBeNil)
 		So(password, ShouldEqual, "password")
 		So(ok, ShouldBeTrue)
 
@@ -285,7 +289,8 @@ func TestDataSourceDecryptionCache(t *testing.T) {
 		})
 		ds.Updated = time.Now()
 
-		password, ok = ds.DecryptedValue("password")
+		password, ok, err = ds.DecryptedValue("password")
+		So(err, ShouldBeNil)
 		So(password, ShouldEqual, "")
 		So(ok, ShouldBeTrue)
 	})
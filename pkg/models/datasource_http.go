@@ -0,0 +1,162 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// retryBackoff is the delay before the first retry of a request to a data source, growing with every retry.
+const retryBackoff = 100 * time.Millisecond
+
+// DataSourceHTTPSettings are the limits of the HTTP requests to a data source. They're set in the
+// JsonData of the data source, or in the [dataproxy] section of the configuration.
+type DataSourceHTTPSettings struct {
+	Timeout         time.Duration
+	MaxIdleConns    int
+	IdleConnTimeout time.Duration
+	MaxRetries      int
+	ResponseLimit   int64
+}
+
+// HTTPSettings returns the limits of the HTTP requests to the data source.
+func (ds *DataSource) HTTPSettings() DataSourceHTTPSettings {
+	dataProxy := setting.GetDataProxySettings()
+	settings := DataSourceHTTPSettings{
+		Timeout:         time.Duration(dataProxy.Timeout) * time.Second,
+		MaxIdleConns:    dataProxy.MaxIdleConns,
+		IdleConnTimeout: time.Duration(dataProxy.IdleConnTimeout) * time.Second,
+		MaxRetries:      dataProxy.MaxRetries,
+		ResponseLimit:   dataProxy.ResponseLimit,
+	}
+	if ds.JsonData == nil {
+		return settings
+	}
+
+	if timeout := ds.JsonData.Get("timeout").MustInt(0); timeout > 0 {
+		settings.Timeout = time.Duration(timeout) * time.Second
+	}
+	if maxIdleConns := ds.JsonData.Get("maxIdleConns").MustInt(0); maxIdleConns > 0 {
+		settings.MaxIdleConns = maxIdleConns
+	}
+	if idleConnTimeout := ds.JsonData.Get("idleConnTimeout").MustInt(0); idleConnTimeout > 0 {
+		settings.IdleConnTimeout = time.Duration(idleConnTimeout) * time.Second
+	}
+	if maxRetries, err := ds.JsonData.Get("maxRetries").Int(); err == nil && maxRetries >= 0 {
+		settings.MaxRetries = maxRetries
+	}
+	if responseLimit, err := ds.JsonData.Get("responseLimit").Int64(); err == nil && responseLimit >= 0 {
+		settings.ResponseLimit = responseLimit
+	}
+	return settings
+}
+
+// ResponseLimitError is returned when the response of a data source exceeds its response limit.
+type ResponseLimitError struct {
+	Limit int64
+}
+
+func (e ResponseLimitError) Error() string {
+	return fmt.Sprintf("the response of the data source exceeds the limit of %d bytes", e.Limit)
+}
+
+// limitResponse fails the response when its body exceeds the limit, upfront when its length is known.
+func limitResponse(res *http.Response, limit int64) (*http.Response, error) {
+	if limit <= 0 {
+		return res, nil
+	}
+
+	if res.ContentLength > limit {
+		res.Body.Close()
+		return nil, ResponseLimitError{Limit: limit}
+	}
+
+	res.Body = &limitedBody{body: res.Body, remaining: limit, limit: limit}
+	return res, nil
+}
+
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+	limit     int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// read one more byte to tell whether the body ends right at the limit
+		var extra [1]byte
+		if n, _ := b.body.Read(extra[:]); n > 0 {
+			return 0, ResponseLimitError{Limit: b.limit}
+		}
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
+
+// isRetryable returns whether a request to a data source can be sent again after it failed. Only
+// requests without a body, or whose body can be read again, and with an idempotent method are.
+func isRetryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func shouldRetry(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch res.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// roundTripWithRetries sends the request, retrying it when it's retryable and the data source couldn't
+// be reached or was unavailable.
+func roundTripWithRetries(transport http.RoundTripper, req *http.Request, maxRetries int) (*http.Response, error) {
+	res, err := transport.RoundTrip(req)
+	if maxRetries <= 0 || !isRetryable(req) {
+		return res, err
+	}
+
+	for attempt := 1; attempt <= maxRetries && shouldRetry(res, err); attempt++ {
+		if res != nil {
+			res.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(retryBackoff * time.Duration(attempt)):
+		}
+
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			req.Body = body
+		}
+		res, err = transport.RoundTrip(req)
+	}
+
+	return res, err
+}
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataSourceHTTPSettings(t *testing.T) {
+	setting.DataProxyTimeout = 30
+	setting.DataProxyMaxIdleConns = 100
+	setting.DataProxyIdleConnTimeout = 90
+	setting.DataProxyMaxRetries = 0
+	setting.DataProxyResponseLimit = 0
+
+	t.Run("Defaults to the global settings", func(t *testing.T) {
+		ds := &DataSource{JsonData: simplejson.New()}
+		require.Equal(t, DataSourceHTTPSettings{
+			Timeout:         30 * time.Second,
+			MaxIdleConns:    100,
+			IdleConnTimeout: 90 * time.Second,
+		}, ds.HTTPSettings())
+	})
+
+	t.Run("Data sources override the global settings", func(t *testing.T) {
+		ds := &DataSource{JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"timeout":         60,
+			"maxIdleConns":    10,
+			"idleConnTimeout": 30,
+			"maxRetries":      2,
+			"responseLimit":   1024,
+		})}
+		require.Equal(t, DataSourceHTTPSettings{
+			Timeout:         60 * time.Second,
+			MaxIdleConns:    10,
+			IdleConnTimeout: 30 * time.Second,
+			MaxRetries:      2,
+			ResponseLimit:   1024,
+		}, ds.HTTPSettings())
+	})
+}
+
+func TestDataSourceTransportLimits(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/unavailable":
+			attempts++
+			if attempts < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte("ok"))
+		case "/large":
+			_, _ = w.Write([]byte(strings.Repeat("a", 100)))
+		case "/streamed":
+			w.(http.Flusher).Flush()
+			_, _ = w.Write([]byte(strings.Repeat("a", 100)))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	newTransport := func(settings DataSourceHTTPSettings) *dataSourceTransport {
+		return &dataSourceTransport{transport: &http.Transport{}, settings: settings}
+	}
+
+	t.Run("Retries idempotent requests to unavailable data sources", func(t *testing.T) {
+		attempts = 0
+		client := &http.Client{Transport: newTransport(DataSourceHTTPSettings{MaxRetries: 2})}
+
+		res, err := client.Get(server.URL + "/unavailable")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Equal(t, 3, attempts)
+
+		attempts = 0
+		res, err = client.Post(server.URL+"/unavailable", "text/plain", strings.NewReader("body"))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		require.Equal(t, 1, attempts)
+	})
+
+	t.Run("Fails responses exceeding the response limit", func(t *testing.T) {
+		client := &http.Client{Transport: newTransport(DataSourceHTTPSettings{ResponseLimit: 50})}
+
+		_, err := client.Get(server.URL + "/large")
+		var limitErr ResponseLimitError
+		require.True(t, errors.As(err, &limitErr))
+		require.Equal(t, int64(50), limitErr.Limit)
+
+		res, err := client.Get(server.URL + "/streamed")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		_, err = ioutil.ReadAll(res.Body)
+		require.True(t, errors.As(err, &limitErr))
+	})
+
+	t.Run("Accepts responses within the response limit", func(t *testing.T) {
+		client := &http.Client{Transport: newTransport(DataSourceHTTPSettings{ResponseLimit: 100})}
+
+		res, err := client.Get(server.URL + "/streamed")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Len(t, body, 100)
+	})
+}
+
+func TestDataSourceNetworkPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() {
+		setting.DataSourceBlockPrivateNetworks = false
+		setting.DataSourceBlockedNetworks = nil
+		setting.DataSourceAllowedNetworks = nil
+	})
+	setting.DataSourceBlockPrivateNetworks = true
+
+	get := func(ds *DataSource) error {
+		ds.Url = server.URL
+		ds.Updated = time.Now()
+		client, err := ds.GetHttpClient()
+		require.NoError(t, err)
+		res, err := client.Get(server.URL)
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+
+	t.Run("Blocks requests to private networks", func(t *testing.T) {
+		err := get(&DataSource{Id: 1, JsonData: simplejson.New()})
+		var blockedErr NetworkBlockedError
+		require.True(t, errors.As(err, &blockedErr))
+		require.Equal(t, "127.0.0.1", blockedErr.IP.String())
+	})
+
+	t.Run("Honors the allowed networks of provisioned data sources only", func(t *testing.T) {
+		jsonData := simplejson.NewFromAny(map[string]interface{}{
+			"allowedNetworks": []interface{}{"127.0.0.0/8"},
+		})
+		require.NoError(t, get(&DataSource{Id: 2, ReadOnly: true, JsonData: jsonData}))
+
+		var blockedErr NetworkBlockedError
+		require.True(t, errors.As(get(&DataSource{Id: 3, JsonData: jsonData}), &blockedErr))
+	})
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// privateNetworks are the loopback, private, link-local and shared address ranges blocked when
+// data_source_block_private_networks is enabled.
+var privateNetworks = mustParseNetworks(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+)
+
+func mustParseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// NetworkBlockedError is returned when a request to a data source targets a blocked address.
+type NetworkBlockedError struct {
+	IP net.IP
+}
+
+func (e NetworkBlockedError) Error() string {
+	return fmt.Sprintf("requests to %s are not allowed", e.IP)
+}
+
+// dataSourceNetworkPolicy decides which addresses a data source may send requests to.
+type dataSourceNetworkPolicy struct {
+	blocked []*net.IPNet
+	allowed []*net.IPNet
+}
+
+// networkPolicy returns the network policy of the data source. The networks allowed in its JsonData
+// are only honored for provisioned data sources, since organization admins can edit the others.
+func (ds *DataSource) networkPolicy() *dataSourceNetworkPolicy {
+	policy := serverNetworkPolicy()
+	if ds.ReadOnly && ds.JsonData != nil {
+		for _, cidr := range ds.JsonData.Get("allowedNetworks").MustStringArray() {
+			_, network, err := net.ParseCIDR(cidr)
+			if err != nil {
+				// provisioning doesn't validate JsonData, an invalid network allows nothing
+				continue
+			}
+			policy.allowed = append(policy.allowed, network)
+		}
+	}
+	return policy
+}
+
+// serverNetworkPolicy returns the network policy of the server settings
+func serverNetworkPolicy() *dataSourceNetworkPolicy {
+	policy := &dataSourceNetworkPolicy{
+		blocked: append([]*net.IPNet{}, setting.DataSourceBlockedNetworks...),
+		allowed: append([]*net.IPNet{}, setting.DataSourceAllowedNetworks...),
+	}
+	if setting.DataSourceBlockPrivateNetworks {
+		policy.blocked = append(policy.blocked, privateNetworks...)
+	}
+	return policy
+}
+
+// NetworkPolicyControl checks the address a connection is about to be made to against the network
+// policy of the data sources, for the other requests to URLs organization admins configure, like the
+// webhooks of the notification channels. Use it as the Control of a net.Dialer.
+func NetworkPolicyControl(network, address string, c syscall.RawConn) error {
+	return serverNetworkPolicy().control(network, address, c)
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBlocked returns whether requests to the address are blocked. Allowed networks take precedence.
+func (p *dataSourceNetworkPolicy) isBlocked(ip net.IP) bool {
+	return containsIP(p.blocked, ip) && !containsIP(p.allowed, ip)
+}
+
+// control checks the address a connection is about to be made to. It runs after the host name was
+// resolved, so a host name resolving to a blocked address later on is blocked as well.
+func (p *dataSourceNetworkPolicy) control(network, address string, _ syscall.RawConn) error {
+	if len(p.blocked) == 0 {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("invalid address %q", address)
+	}
+
+	if p.isBlocked(ip) {
+		return NetworkBlockedError{IP: ip}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DataSourceRequest summarizes an HTTP request sent to a data source while running queries, for
+// the query inspector. The URL has neither credentials nor query string, as they can hold secrets.
+type DataSourceRequest struct {
+	Method     string  `json:"method"`
+	URL        string  `json:"url"`
+	Status     int     `json:"status,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"durationMs"`
+	Bytes      int64   `json:"bytes"`
+	Retries    int     `json:"retries,omitempty"`
+}
+
+// DataSourceRequestRecorder records the HTTP requests sent to the data sources with a context it
+// was added to, see WithDataSourceRequestRecorder.
+type DataSourceRequestRecorder struct {
+	mu       sync.Mutex
+	requests []*DataSourceRequest
+}
+
+type dataSourceRequestRecorderKey struct{}
+
+// WithDataSourceRequestRecorder returns a context recording the requests sent to the data sources
+// with it.
+func WithDataSourceRequestRecorder(ctx context.Context, recorder *DataSourceRequestRecorder) context.Context {
+	return context.WithValue(ctx, dataSourceRequestRecorderKey{}, recorder)
+}
+
+func dataSourceRequestRecorderFrom(ctx context.Context) *DataSourceRequestRecorder {
+	recorder, _ := ctx.Value(dataSourceRequestRecorderKey{}).(*DataSourceRequestRecorder)
+	return recorder
+}
+
+// Requests returns the requests recorded so far. The bytes of a response are counted as its
+// body is read.
+func (r *DataSourceRequestRecorder) Requests() []DataSourceRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	requests := make([]DataSourceRequest, 0, len(r.requests))
+	for _, req := range r.requests {
+		requests = append(requests, *req)
+	}
+	return requests
+}
+
+// record adds a request, and returns the response counting the bytes of its body
+func (r *DataSourceRequestRecorder) record(req *http.Request, res *http.Response, err error, duration time.Duration, retries int) *http.Response {
+	u := url.URL{Scheme: req.URL.Scheme, Host: req.URL.Host, Path: req.URL.Path}
+	recorded := &DataSourceRequest{
+		Method:     req.Method,
+		URL:        u.String(),
+		DurationMs: float64(duration) / float64(time.Millisecond),
+		Retries:    retries,
+	}
+	if err != nil {
+		recorded.Error = err.Error()
+	}
+	if res != nil {
+		recorded.Status = res.StatusCode
+		res.Body = &countedBody{body: res.Body, recorder: r, request: recorded}
+	}
+
+	r.mu.Lock()
+	r.requests = append(r.requests, recorded)
+	r.mu.Unlock()
+	return res
+}
+
+// countedBody counts the bytes read from the body of a recorded response
+type countedBody struct {
+	body     io.ReadCloser
+	recorder *DataSourceRequestRecorder
+	request  *DataSourceRequest
+}
+
+func (b *countedBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+
+	b.recorder.mu.Lock()
+	b.request.Bytes += int64(n)
+	b.recorder.mu.Unlock()
+	return n, err
+}
+
+func (b *countedBody) Close() error {
+	return b.body.Close()
+}
+
+// roundTripperFunc is a function sending HTTP requests
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataSourceRequestRecorder(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/unavailable":
+			attempts++
+			if attempts < 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte("ok"))
+		case "/query":
+			_, _ = w.Write([]byte(strings.Repeat("a", 100)))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := &http.Client{Transport: &dataSourceTransport{
+		transport: &http.Transport{},
+		settings:  DataSourceHTTPSettings{MaxRetries: 2},
+	}}
+	get := func(ctx context.Context, path string) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		require.NoError(t, err)
+		res, err := client.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		_, err = ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+	}
+
+	t.Run("Records the requests sent with the context of a recorder", func(t *testing.T) {
+		recorder := &DataSourceRequestRecorder{}
+		ctx := WithDataSourceRequestRecorder(context.Background(), recorder)
+
+		get(ctx, "/query?apiKey=secret")
+		get(ctx, "/unavailable")
+
+		requests := recorder.Requests()
+		require.Len(t, requests, 2)
+
+		require.Equal(t, http.MethodGet, requests[0].Method)
+		require.Equal(t, server.URL+"/query", requests[0].URL)
+		require.Equal(t, http.StatusOK, requests[0].Status)
+		require.Equal(t, int64(100), requests[0].Bytes)
+		require.Equal(t, 0, requests[0].Retries)
+		require.Greater(t, requests[0].DurationMs, float64(0))
+
+		require.Equal(t, http.StatusOK, requests[1].Status)
+		require.Equal(t, int64(2), requests[1].Bytes)
+		require.Equal(t, 1, requests[1].Retries)
+	})
+
+	t.Run("Records nothing without a recorder", func(t *testing.T) {
+		recorder := &DataSourceRequestRecorder{}
+
+		get(context.Background(), "/query")
+
+		require.Empty(t, recorder.Requests())
+	})
+}
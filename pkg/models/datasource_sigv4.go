@@ -0,0 +1,121 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+
+	"github.com/grafana/grafana/pkg/infra/awsauth"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// sigV4CredentialsBuilder builds the credentials of the data sources signing their requests with SigV4.
+// Stubbable by tests.
+var sigV4CredentialsBuilder = newSigV4CredentialsBuilder()
+
+// newSigV4CredentialsBuilder returns the builder of the SigV4 credentials, calling STS from the networks
+// the data sources are allowed to call
+func newSigV4CredentialsBuilder() *awsauth.ChainBuilder {
+	b := awsauth.NewChainBuilder()
+	b.STSHTTPClient = awsauth.NewHTTPClient(NetworkPolicyControl)
+	return b
+}
+
+// sigV4DefaultServices are the services the requests are signed for when the data source doesn't
+// set one, Amazon OpenSearch Service and Amazon Managed Service for Prometheus. The other data
+// sources sign them for API Gateway.
+var sigV4DefaultServices = map[string]string{
+	DS_ES:         "es",
+	DS_PROMETHEUS: "aps",
+}
+
+// sigV4AssumeRoleDuration is the session duration of the roles assumed to sign the requests
+const sigV4AssumeRoleDuration = 15 * time.Minute
+
+// sigV4Transport returns the transport signing the requests of the data source with SigV4 before
+// sending them with next, nil when the sigV4Auth option of its jsonData isn't enabled. The server
+// must enable SigV4 and allow the service and the auth provider of the data source. Its credentials
+// are those of its sigV4AuthType, like with the CloudWatch data source: the keys of its
+// secureJsonData, a profile of the shared credentials file, or, when the server allows them, a role
+// to assume and the identity of Grafana itself, including the web identity of IAM roles for service
+// accounts on EKS.
+func (ds *DataSource) sigV4Transport(next http.RoundTripper) (*awsauth.SigV4Transport, error) {
+	if ds.JsonData == nil || !ds.JsonData.Get("sigV4Auth").MustBool(false) {
+		return nil, nil
+	}
+	if !setting.AWSSigV4AuthEnabled {
+		return nil, errors.New("SigV4 auth isn't enabled on this server")
+	}
+
+	region := ds.JsonData.Get("sigV4Region").MustString()
+	if region == "" {
+		return nil, errors.New("SigV4 auth needs a region")
+	}
+	service := ds.JsonData.Get("sigV4Service").MustString()
+	if service == "" {
+		service = sigV4DefaultServices[ds.Type]
+	}
+	if service == "" {
+		service = "execute-api"
+	}
+	if !sigV4Allowed(setting.AWSSigV4AllowedServices, service) {
+		return nil, fmt.Errorf("SigV4 auth isn't allowed for the service %q", service)
+	}
+
+	authType := ds.JsonData.Get("sigV4AuthType").MustString("default")
+	if !sigV4Allowed(setting.AWSSigV4AllowedAuthProviders, authType) {
+		return nil, fmt.Errorf("SigV4 auth provider %q isn't allowed", authType)
+	}
+
+	creds, err := ds.sigV4Credentials(authType, region)
+	if err != nil {
+		return nil, err
+	}
+	return awsauth.NewSigV4Transport(next, creds, service, region), nil
+}
+
+// sigV4Credentials returns the credentials of an auth type. The keys and the profiles of the
+// shared credentials file never fall back to the identity of Grafana.
+func (ds *DataSource) sigV4Credentials(authType, region string) (aws.CredentialsProvider, error) {
+	decrypted, err := ds.DecryptedValues()
+	if err != nil {
+		return nil, err
+	}
+	switch authType {
+	case "keys":
+		if decrypted["sigV4AccessKey"] == "" || decrypted["sigV4SecretKey"] == "" {
+			return nil, errors.New("SigV4 auth needs an access key and a secret key")
+		}
+		return credentials.NewStaticCredentialsProvider(decrypted["sigV4AccessKey"], decrypted["sigV4SecretKey"], ""), nil
+	case "credentials":
+		return aws.NewCredentialsCache(awsauth.SharedCredentialsProvider{Profile: ds.JsonData.Get("sigV4Profile").MustString()}), nil
+	case "default", awsauth.AuthTypeARN:
+		duration := sigV4AssumeRoleDuration
+		if duration < setting.AWSAssumeRoleMinDuration {
+			duration = setting.AWSAssumeRoleMinDuration
+		}
+		return sigV4CredentialsBuilder.Provider(&awsauth.Settings{
+			AuthType:           authType,
+			AssumeRoleArn:      ds.JsonData.Get("sigV4AssumeRoleArn").MustString(),
+			ExternalID:         ds.JsonData.Get("sigV4ExternalId").MustString(),
+			AssumeRoleDuration: duration,
+			Region:             region,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown SigV4 auth provider %q", authType)
+	}
+}
+
+// sigV4Allowed returns whether value is in the allowed values of a server setting
+func sigV4Allowed(allowed []string, value string) bool {
+	for _, v := range allowed {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/securejsondata"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestDataSourceSigV4Transport(t *testing.T) {
+	origEnabled, origProviders, origServices := setting.AWSSigV4AuthEnabled, setting.AWSSigV4AllowedAuthProviders, setting.AWSSigV4AllowedServices
+	t.Cleanup(func() {
+		setting.AWSSigV4AuthEnabled = origEnabled
+		setting.AWSSigV4AllowedAuthProviders = origProviders
+		setting.AWSSigV4AllowedServices = origServices
+	})
+	setting.AWSSigV4AuthEnabled = true
+	setting.AWSSigV4AllowedAuthProviders = []string{"keys"}
+	setting.AWSSigV4AllowedServices = []string{"es", "aps", "execute-api"}
+
+	newDataSource := func(jsonData map[string]interface{}) *DataSource {
+		data := map[string]interface{}{
+			"sigV4Auth":     true,
+			"sigV4AuthType": "keys",
+			"sigV4Region":   "eu-west-1",
+		}
+		for k, v := range jsonData {
+			data[k] = v
+		}
+		return &DataSource{
+			Id:       1,
+			Type:     DS_PROMETHEUS,
+			JsonData: simplejson.NewFromAny(data),
+			SecureJsonData: securejsondata.GetEncryptedJsonData(map[string]string{
+				"sigV4AccessKey": "AKID",
+				"sigV4SecretKey": "secret",
+			}),
+		}
+	}
+
+	t.Run("Signs the requests with the keys of the data source", func(t *testing.T) {
+		transport, err := newDataSource(nil).sigV4Transport(http.DefaultTransport)
+		require.NoError(t, err)
+		assert.NotNil(t, transport)
+	})
+
+	t.Run("Doesn't sign the requests when the data source doesn't enable it", func(t *testing.T) {
+		transport, err := newDataSource(map[string]interface{}{"sigV4Auth": false}).sigV4Transport(http.DefaultTransport)
+		require.NoError(t, err)
+		assert.Nil(t, transport)
+	})
+
+	t.Run("Refuses SigV4 auth when the server doesn't enable it", func(t *testing.T) {
+		setting.AWSSigV4AuthEnabled = false
+		defer func() { setting.AWSSigV4AuthEnabled = true }()
+
+		_, err := newDataSource(nil).sigV4Transport(http.DefaultTransport)
+		assert.EqualError(t, err, "SigV4 auth isn't enabled on this server")
+	})
+
+	t.Run("Refuses the services the server doesn't allow", func(t *testing.T) {
+		_, err := newDataSource(map[string]interface{}{"sigV4Service": "s3"}).sigV4Transport(http.DefaultTransport)
+		assert.EqualError(t, err, `SigV4 auth isn't allowed for the service "s3"`)
+	})
+
+	t.Run("Refuses the identity of Grafana unless the server allows it", func(t *testing.T) {
+		for _, authType := range []string{"default", "credentials", "arn"} {
+			_, err := newDataSource(map[string]interface{}{"sigV4AuthType": authType}).sigV4Transport(http.DefaultTransport)
+			assert.EqualError(t, err, `SigV4 auth provider "`+authType+`" isn't allowed`)
+		}
+
+		ds := newDataSource(nil)
+		ds.JsonData.Del("sigV4AuthType")
+		_, err := ds.sigV4Transport(http.DefaultTransport)
+		assert.EqualError(t, err, `SigV4 auth provider "default" isn't allowed`)
+
+		setting.AWSSigV4AllowedAuthProviders = []string{"default"}
+		defer func() { setting.AWSSigV4AllowedAuthProviders = []string{"keys"} }()
+		transport, err := ds.sigV4Transport(http.DefaultTransport)
+		require.NoError(t, err)
+		assert.NotNil(t, transport)
+	})
+
+	t.Run("Doesn't fall back to the identity of Grafana without keys", func(t *testing.T) {
+		ds := newDataSource(nil)
+		ds.Id = 2
+		ds.SecureJsonData = securejsondata.GetEncryptedJsonData(map[string]string{})
+		_, err := ds.sigV4Transport(http.DefaultTransport)
+		assert.EqualError(t, err, "SigV4 auth needs an access key and a secret key")
+	})
+}
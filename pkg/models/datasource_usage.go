@@ -0,0 +1,154 @@
+package models
+
+import (
+	"errors"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+var ErrDataSourceInUse = errors.New("Data source is used by dashboards or alert rules")
+
+const mixedDataSourceName = "-- Mixed --"
+
+// DataSourceUsage lists what depends on a data source
+type DataSourceUsage struct {
+	Dashboards  []*DataSourceUsageDashboard  `json:"dashboards"`
+	AlertRules  []*DataSourceUsageAlertRule  `json:"alertRules"`
+	Annotations []*DataSourceUsageAnnotation `json:"annotations"`
+}
+
+// InUse tells whether anything depends on the data source
+func (u *DataSourceUsage) InUse() bool {
+	return len(u.Dashboards) > 0 || len(u.AlertRules) > 0 || len(u.Annotations) > 0
+}
+
+type DataSourceUsageDashboard struct {
+	Id        int64    `json:"id"`
+	Uid       string   `json:"uid"`
+	Title     string   `json:"title"`
+	Url       string   `json:"url"`
+	PanelIds  []int64  `json:"panelIds"`
+	Variables []string `json:"variables"`
+}
+
+type DataSourceUsageAlertRule struct {
+	Id           int64  `json:"id"`
+	Name         string `json:"name"`
+	DashboardId  int64  `json:"dashboardId"`
+	DashboardUid string `json:"dashboardUid"`
+	PanelId      int64  `json:"panelId"`
+}
+
+type DataSourceUsageAnnotation struct {
+	Name         string `json:"name"`
+	DashboardId  int64  `json:"dashboardId"`
+	DashboardUid string `json:"dashboardUid"`
+}
+
+// DashboardDataSourceRefs holds the references of a dashboard to a data source
+type DashboardDataSourceRefs struct {
+	PanelIds    []int64
+	Variables   []string
+	Annotations []string
+}
+
+// GetDashboardDataSourceRefs returns the panels, including the ones nested in rows, the template
+// variables and the annotation queries of a dashboard using a data source. A data source is
+// referenced by its name, or by an object with its uid, and a missing reference is the default data
+// source. The panels of the mixed data source reference the data sources of their queries.
+func GetDashboardDataSourceRefs(dashboard *simplejson.Json, ds *DataSource) *DashboardDataSourceRefs {
+	refs := &DashboardDataSourceRefs{
+		PanelIds:    make([]int64, 0),
+		Variables:   make([]string, 0),
+		Annotations: make([]string, 0),
+	}
+
+	var walk func(panels []interface{})
+	walk = func(panels []interface{}) {
+		for _, p := range panels {
+			panel := simplejson.NewFromAny(p)
+			if panelUsesDataSource(panel, ds) {
+				refs.PanelIds = append(refs.PanelIds, panel.Get("id").MustInt64())
+			}
+
+			walk(panel.Get("panels").MustArray())
+		}
+	}
+
+	walk(dashboard.Get("panels").MustArray())
+	for _, row := range dashboard.Get("rows").MustArray() {
+		walk(simplejson.NewFromAny(row).Get("panels").MustArray())
+	}
+
+	for _, v := range dashboard.GetPath("templating", "list").MustArray() {
+		variable := simplejson.NewFromAny(v)
+		if variable.Get("type").MustString() == "query" && refersToDataSource(variable, ds) {
+			refs.Variables = append(refs.Variables, variable.Get("name").MustString())
+		}
+	}
+
+	for _, a := range dashboard.GetPath("annotations", "list").MustArray() {
+		annotation := simplejson.NewFromAny(a)
+		if refersToDataSource(annotation, ds) {
+			refs.Annotations = append(refs.Annotations, annotation.Get("name").MustString())
+		}
+	}
+
+	return refs
+}
+
+func panelUsesDataSource(panel *simplejson.Json, ds *DataSource) bool {
+	// rows and text panels have no queries
+	if _, ok := panel.CheckGet("targets"); !ok {
+		return false
+	}
+
+	if panel.Get("datasource").MustString() != mixedDataSourceName {
+		return refersToDataSource(panel, ds)
+	}
+
+	for _, t := range panel.Get("targets").MustArray() {
+		if refersToDataSource(simplejson.NewFromAny(t), ds) {
+			return true
+		}
+	}
+	return false
+}
+
+func refersToDataSource(obj *simplejson.Json, ds *DataSource) bool {
+	ref, ok := obj.CheckGet("datasource")
+	if !ok || ref.Interface() == nil {
+		return ds.IsDefault
+	}
+
+	if name, err := ref.String(); err == nil {
+		if name == "" || name == "default" {
+			return ds.IsDefault
+		}
+		return name == ds.Name
+	}
+
+	return ds.Uid != "" && ref.Get("uid").MustString() == ds.Uid
+}
+
+// AlertUsesDataSource tells whether a query of the conditions of an alert rule is about a data source
+func AlertUsesDataSource(settings *simplejson.Json, ds *DataSource) bool {
+	for _, c := range settings.Get("conditions").MustArray() {
+		condition := simplejson.NewFromAny(c)
+		if condition.GetPath("query", "datasourceId").MustInt64() == ds.Id {
+			return true
+		}
+	}
+	return false
+}
+
+//
+// QUERIES
+//
+
+type GetDataSourceUsageQuery struct {
+	OrgId      int64
+	DataSource *DataSource
+
+	Result *DataSourceUsage
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDashboardDataSourceRefs(t *testing.T) {
+	dashboard, err := simplejson.NewJson([]byte(`{
+		"panels": [
+			{"id": 1, "datasource": "prom", "targets": [{}]},
+			{"id": 2, "datasource": null, "targets": [{}]},
+			{"id": 3, "type": "text"},
+			{"id": 4, "type": "row", "panels": [
+				{"id": 5, "datasource": {"uid": "prom-uid"}, "targets": [{}]},
+				{"id": 6, "datasource": "-- Mixed --", "targets": [{"datasource": "loki"}, {"datasource": "prom"}]},
+				{"id": 7, "datasource": "-- Mixed --", "targets": [{"datasource": "loki"}]}
+			]}
+		],
+		"rows": [{"panels": [{"id": 8, "datasource": "prom", "targets": [{}]}]}],
+		"templating": {"list": [
+			{"name": "job", "type": "query", "datasource": "prom"},
+			{"name": "ds", "type": "datasource", "query": "prometheus"},
+			{"name": "level", "type": "query", "datasource": "loki"}
+		]},
+		"annotations": {"list": [
+			{"name": "Annotations & Alerts", "datasource": "-- Grafana --"},
+			{"name": "Deploys", "datasource": "prom"}
+		]}
+	}`))
+	require.NoError(t, err)
+
+	refs := GetDashboardDataSourceRefs(dashboard, &DataSource{Name: "prom", Uid: "prom-uid"})
+	require.Equal(t, []int64{1, 5, 6, 8}, refs.PanelIds)
+	require.Equal(t, []string{"job"}, refs.Variables)
+	require.Equal(t, []string{"Deploys"}, refs.Annotations)
+
+	refs = GetDashboardDataSourceRefs(dashboard, &DataSource{Name: "default", Uid: "default-uid", IsDefault: true})
+	require.Equal(t, []int64{2}, refs.PanelIds)
+	require.Empty(t, refs.Variables)
+	require.Empty(t, refs.Annotations)
+}
+
+func TestAlertUsesDataSource(t *testing.T) {
+	settings, err := simplejson.NewJson([]byte(`{"conditions": [
+		{"query": {"datasourceId": 2, "params": ["A", "5m", "now"]}},
+		{"query": {"datasourceId": 3, "params": ["B", "5m", "now"]}}
+	]}`))
+	require.NoError(t, err)
+
+	require.True(t, AlertUsesDataSource(settings, &DataSource{Id: 3}))
+	require.False(t, AlertUsesDataSource(settings, &DataSource{Id: 4}))
+}
@@ -8,13 +8,15 @@ import (
 
 // Typed errors
 var (
-	ErrFolderNotFound                = errors.New("Folder not found")
-	ErrFolderVersionMismatch         = errors.New("The folder has been changed by someone else")
-	ErrFolderTitleEmpty              = errors.New("Folder title cannot be empty")
-	ErrFolderWithSameUIDExists       = errors.New("A folder/dashboard with the same uid already exists")
-	ErrFolderSameNameExists          = errors.New("A folder or dashboard in the general folder with the same name already exists")
-	ErrFolderFailedGenerateUniqueUid = errors.New("Failed to generate unique folder id")
-	ErrFolderAccessDenied            = errors.New("Access denied to folder")
+	ErrFolderNotFound                  = errors.New("Folder not found")
+	ErrFolderVersionMismatch           = errors.New("The folder has been changed by someone else")
+	ErrFolderTitleEmpty                = errors.New("Folder title cannot be empty")
+	ErrFolderWithSameUIDExists         = errors.New("A folder/dashboard with the same uid already exists")
+	ErrFolderSameNameExists            = errors.New("A folder or dashboard in the general folder with the same name already exists")
+	ErrFolderFailedGenerateUniqueUid   = errors.New("Failed to generate unique folder id")
+	ErrFolderAccessDenied              = errors.New("Access denied to folder")
+	ErrFolderParentNotFound            = errors.New("Parent folder not found")
+	ErrFolderHasConnectedLibraryPanels = errors.New("Folder contains library panels used by dashboards in other folders")
 )
 
 type Folder struct {
@@ -24,6 +26,9 @@ type Folder struct {
 	Url     string
 	Version int
 
+	ParentId  int64
+	ParentUid string
+
 	Created time.Time
 	Updated time.Time
 
@@ -75,8 +80,9 @@ func (cmd *UpdateFolderCommand) UpdateDashboardModel(dashFolder *Dashboard, orgI
 //
 
 type CreateFolderCommand struct {
-	Uid   string `json:"uid"`
-	Title string `json:"title"`
+	Uid       string `json:"uid"`
+	Title     string `json:"title"`
+	ParentUid string `json:"parentUid"`
 
 	Result *Folder
 }
@@ -87,6 +93,9 @@ type UpdateFolderCommand struct {
 	Version   int    `json:"version"`
 	Overwrite bool   `json:"overwrite"`
 
+	// ParentUid moves the folder when set, an empty uid moves it to the General folder
+	ParentUid *string `json:"parentUid"`
+
 	Result *Folder
 }
 
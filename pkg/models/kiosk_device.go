@@ -0,0 +1,110 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Typed errors
+var (
+	ErrKioskDeviceNotFound  = errors.New("Kiosk device not found")
+	ErrKioskDeviceNameEmpty = errors.New("Kiosk device name cannot be empty")
+	ErrKioskDeviceNameTaken = errors.New("Kiosk device with the same name already exists")
+)
+
+// KioskDevice is a wallboard registered in an organization. Its token gives read-only access to
+// its dashboards without signing in, see KioskToken.
+type KioskDevice struct {
+	Id    int64
+	OrgId int64
+	Name  string
+
+	Created  time.Time
+	Updated  time.Time
+	LastSeen time.Time
+}
+
+// KioskDeviceDashboard binds a dashboard to a kiosk device
+type KioskDeviceDashboard struct {
+	Id           int64
+	DeviceId     int64
+	DashboardUid string
+}
+
+// KioskDeviceDTO is the frontend model of a kiosk device
+type KioskDeviceDTO struct {
+	Id            int64     `json:"id"`
+	OrgId         int64     `json:"orgId"`
+	Name          string    `json:"name"`
+	DashboardUids []string  `json:"dashboardUids"`
+	Created       time.Time `json:"created"`
+	Updated       time.Time `json:"updated"`
+	LastSeen      time.Time `json:"lastSeen"`
+}
+
+//
+// COMMANDS
+//
+
+// CreateKioskDeviceCommand registers a kiosk device. Token is the only copy of its token.
+type CreateKioskDeviceCommand struct {
+	Name          string   `json:"name" binding:"Required"`
+	DashboardUids []string `json:"dashboardUids"`
+
+	OrgId int64 `json:"-"`
+
+	Result *KioskDeviceDTO
+	Token  string
+}
+
+type UpdateKioskDeviceCommand struct {
+	Name          string   `json:"name" binding:"Required"`
+	DashboardUids []string `json:"dashboardUids"`
+
+	Id    int64 `json:"-"`
+	OrgId int64 `json:"-"`
+
+	Result *KioskDeviceDTO
+}
+
+// RegenerateKioskDeviceTokenCommand replaces the token of a kiosk device, revoking the previous one
+type RegenerateKioskDeviceTokenCommand struct {
+	Id    int64
+	OrgId int64
+
+	Result string
+}
+
+type DeleteKioskDeviceCommand struct {
+	Id    int64
+	OrgId int64
+}
+
+type UpdateKioskDeviceLastSeenCommand struct {
+	Id int64
+}
+
+//
+// QUERIES
+//
+
+type GetKioskDevicesQuery struct {
+	OrgId int64
+
+	Result []*KioskDeviceDTO
+}
+
+type GetKioskDeviceByIdQuery struct {
+	Id    int64
+	OrgId int64
+
+	Result *KioskDeviceDTO
+}
+
+// GetKioskDevicesByDashboardQuery returns the ids of the kiosk devices showing a dashboard
+type GetKioskDevicesByDashboardQuery struct {
+	OrgId        int64
+	DashboardUid string
+
+	Result []int64
+}
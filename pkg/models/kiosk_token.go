@@ -0,0 +1,50 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Typed errors
+var (
+	ErrKioskTokenNotFound = errors.New("Kiosk token not found")
+)
+
+// KioskToken gives read-only access to the dashboards of a playlist or of a kiosk device without
+// signing in, and to the data queries of these dashboards. Only the hash of the token is stored.
+type KioskToken struct {
+	Id         int64
+	OrgId      int64
+	TokenHash  string
+	PlaylistId int64
+	DeviceId   int64
+	Created    time.Time
+}
+
+// KioskDeviceLogin is the login of the user a kiosk device acts as, which tells its live channel apart
+func KioskDeviceLogin(deviceID int64) string {
+	return "kiosk-device-" + strconv.FormatInt(deviceID, 10)
+}
+
+// SignedInUser returns the user the kiosks using the token act as. Kiosks can only see the
+// dashboards of their playlist or device that a viewer of the organization can see.
+func (t *KioskToken) SignedInUser() *SignedInUser {
+	user := &SignedInUser{IsAnonymous: true, OrgId: t.OrgId, OrgRole: ROLE_VIEWER}
+	if t.DeviceId != 0 {
+		user.Login = KioskDeviceLogin(t.DeviceId)
+	} else {
+		user.Login = "kiosk-playlist-" + strconv.FormatInt(t.PlaylistId, 10)
+	}
+	return user
+}
+
+//
+// QUERIES
+//
+
+type GetKioskTokenQuery struct {
+	Token string
+
+	Result *KioskToken
+}
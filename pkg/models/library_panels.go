@@ -0,0 +1,241 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// Typed errors
+var (
+	ErrLibraryPanelNotFound                = errors.New("Library panel not found")
+	ErrLibraryPanelWithSameNameExists      = errors.New("A library panel with the same name already exists in the folder")
+	ErrLibraryPanelWithSameUIDExists       = errors.New("A library panel with the same uid already exists")
+	ErrLibraryPanelFailedGenerateUniqueUid = errors.New("Failed to generate unique library panel uid")
+	ErrLibraryPanelHasConnectedDashboards  = errors.New("Library panel is still used by one or more dashboards")
+	ErrLibraryPanelVersionMismatch         = errors.New("The library panel has been changed by someone else")
+	ErrLibraryPanelInvalidUid              = errors.New("uid contains illegal characters")
+	ErrLibraryPanelUidTooLong              = errors.New("uid too long. max 40 characters")
+	ErrLibraryPanelNameEmpty               = errors.New("Library panel name cannot be empty")
+	ErrLibraryPanelModelEmpty              = errors.New("Library panel model cannot be empty")
+)
+
+// LibraryPanel is a panel definition stored on its own so that it can be
+// reused by several dashboards and updated in one place.
+type LibraryPanel struct {
+	Id       int64
+	OrgId    int64
+	FolderId int64
+	Uid      string
+	Name     string
+	Model    *simplejson.Json
+	Version  int64
+
+	Created time.Time
+	Updated time.Time
+
+	CreatedBy int64
+	UpdatedBy int64
+}
+
+// LibraryPanelDashboard is the connection between a library panel and a dashboard using it
+type LibraryPanelDashboard struct {
+	Id             int64
+	LibraryPanelId int64
+	DashboardId    int64
+
+	Created   time.Time
+	CreatedBy int64
+}
+
+// LibraryPanelConnectedDashboard is a dashboard using a library panel
+type LibraryPanelConnectedDashboard struct {
+	Id       int64  `json:"id"`
+	Uid      string `json:"uid"`
+	Title    string `json:"title"`
+	Slug     string `json:"-"`
+	FolderId int64  `json:"folderId"`
+	Url      string `json:"url" xorm:"-"`
+}
+
+// LibraryPanelDTO is the frontend model of a library panel
+type LibraryPanelDTO struct {
+	Id       int64            `json:"id"`
+	OrgId    int64            `json:"orgId"`
+	FolderId int64            `json:"folderId"`
+	Uid      string           `json:"uid"`
+	Name     string           `json:"name"`
+	Model    *simplejson.Json `json:"model"`
+	Version  int64            `json:"version"`
+	Meta     LibraryPanelMeta `json:"meta"`
+}
+
+// LibraryPanelMeta holds the metadata of a library panel
+type LibraryPanelMeta struct {
+	CanEdit             bool      `json:"canEdit"`
+	ConnectedDashboards int64     `json:"connectedDashboards"`
+	Created             time.Time `json:"created"`
+	Updated             time.Time `json:"updated"`
+	CreatedBy           string    `json:"createdBy"`
+	UpdatedBy           string    `json:"updatedBy"`
+}
+
+// LibraryPanelWithMeta is a library panel together with the information
+// needed to build a LibraryPanelDTO
+type LibraryPanelWithMeta struct {
+	Id       int64
+	OrgId    int64
+	FolderId int64
+	Uid      string
+	Name     string
+	Model    *simplejson.Json
+	Version  int64
+
+	Created time.Time
+	Updated time.Time
+
+	CreatedBy int64
+	UpdatedBy int64
+
+	ConnectedDashboards int64
+	CreatedByLogin      string
+	UpdatedByLogin      string
+}
+
+// ToDTO converts the library panel into its frontend model
+func (lp *LibraryPanelWithMeta) ToDTO() *LibraryPanelDTO {
+	return &LibraryPanelDTO{
+		Id:       lp.Id,
+		OrgId:    lp.OrgId,
+		FolderId: lp.FolderId,
+		Uid:      lp.Uid,
+		Name:     lp.Name,
+		Model:    lp.Model,
+		Version:  lp.Version,
+		Meta: LibraryPanelMeta{
+			ConnectedDashboards: lp.ConnectedDashboards,
+			Created:             lp.Created,
+			Updated:             lp.Updated,
+			CreatedBy:           lp.CreatedByLogin,
+			UpdatedBy:           lp.UpdatedByLogin,
+		},
+	}
+}
+
+// GetLibraryPanelUIDs returns the uids of all library panels referenced
+// by the panels of a dashboard, including panels nested in rows.
+func GetLibraryPanelUIDs(dashboard *simplejson.Json) []string {
+	uids := make([]string, 0)
+	seen := make(map[string]bool)
+
+	var walk func(panels []interface{})
+	walk = func(panels []interface{}) {
+		for _, p := range panels {
+			panel := simplejson.NewFromAny(p)
+			uid := panel.GetPath("libraryPanel", "uid").MustString()
+			if uid != "" && !seen[uid] {
+				seen[uid] = true
+				uids = append(uids, uid)
+			}
+
+			walk(panel.Get("panels").MustArray())
+		}
+	}
+
+	walk(dashboard.Get("panels").MustArray())
+	return uids
+}
+
+//
+// COMMANDS
+//
+
+type CreateLibraryPanelCommand struct {
+	FolderId int64            `json:"folderId"`
+	Uid      string           `json:"uid"`
+	Name     string           `json:"name"`
+	Model    *simplejson.Json `json:"model"`
+
+	OrgId  int64 `json:"-"`
+	UserId int64 `json:"-"`
+
+	Result *LibraryPanel
+}
+
+// PatchLibraryPanelCommand updates the fields of a library panel
+// that are set in the command
+type PatchLibraryPanelCommand struct {
+	Uid      string           `json:"-"`
+	FolderId *int64           `json:"folderId"`
+	Name     string           `json:"name"`
+	Model    *simplejson.Json `json:"model"`
+	Version  int64            `json:"version"`
+
+	OrgId  int64 `json:"-"`
+	UserId int64 `json:"-"`
+
+	Result *LibraryPanel
+}
+
+type DeleteLibraryPanelCommand struct {
+	Uid   string
+	OrgId int64
+}
+
+type ConnectLibraryPanelCommand struct {
+	Uid         string
+	DashboardId int64
+	OrgId       int64
+	UserId      int64
+}
+
+type DisconnectLibraryPanelCommand struct {
+	Uid         string
+	DashboardId int64
+	OrgId       int64
+}
+
+// SyncLibraryPanelConnectionsCommand replaces the connections of a dashboard
+// with connections to the library panels with the given uids
+type SyncLibraryPanelConnectionsCommand struct {
+	DashboardId int64
+	OrgId       int64
+	UserId      int64
+	Uids        []string
+}
+
+//
+// QUERIES
+//
+
+type GetLibraryPanelQuery struct {
+	Uid   string
+	OrgId int64
+
+	Result *LibraryPanelWithMeta
+}
+
+type GetLibraryPanelsByUIDsQuery struct {
+	Uids  []string
+	OrgId int64
+
+	Result []*LibraryPanel
+}
+
+type SearchLibraryPanelsQuery struct {
+	OrgId     int64
+	Name      string
+	FolderIds []int64
+	Limit     int
+	Page      int
+
+	Result []*LibraryPanelWithMeta
+}
+
+type GetLibraryPanelDashboardsQuery struct {
+	Uid   string
+	OrgId int64
+
+	Result []*LibraryPanelConnectedDashboard
+}
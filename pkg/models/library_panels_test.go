@@ -0,0 +1,27 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLibraryPanelUIDs(t *testing.T) {
+	dashboard := simplejson.NewFromAny(map[string]interface{}{
+		"panels": []interface{}{
+			map[string]interface{}{"id": 1, "type": "graph"},
+			map[string]interface{}{"id": 2, "libraryPanel": map[string]interface{}{"uid": "a"}},
+			map[string]interface{}{
+				"id":   3,
+				"type": "row",
+				"panels": []interface{}{
+					map[string]interface{}{"id": 4, "libraryPanel": map[string]interface{}{"uid": "b"}},
+					map[string]interface{}{"id": 5, "libraryPanel": map[string]interface{}{"uid": "a"}},
+				},
+			},
+		},
+	})
+
+	require.Equal(t, []string{"a", "b"}, GetLibraryPanelUIDs(dashboard))
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Types of the notification deliveries
+const (
+	NotificationDeliveryWebhook = "webhook"
+	NotificationDeliveryEmail   = "email"
+)
+
+// MaxNotificationDeliveryResponse is the most bytes of the response of a webhook recorded
+const MaxNotificationDeliveryResponse = 4096
+
+// NotificationDelivery summarizes a webhook or an email sent by a notification channel, for the
+// tests of the channels. The URL of a webhook only has its scheme and host, as the paths of many
+// webhooks hold their secret.
+type NotificationDelivery struct {
+	Type       string   `json:"type"`
+	Method     string   `json:"method,omitempty"`
+	URL        string   `json:"url,omitempty"`
+	Recipients []string `json:"recipients,omitempty"`
+	Status     int      `json:"status,omitempty"`
+	Response   string   `json:"response,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	DurationMs float64  `json:"durationMs"`
+}
+
+// NotificationDeliveryRecorder records the webhooks and the emails sent with a context it was added
+// to, see WithNotificationDeliveryRecorder.
+type NotificationDeliveryRecorder struct {
+	mu         sync.Mutex
+	deliveries []NotificationDelivery
+}
+
+type notificationDeliveryRecorderKey struct{}
+
+// WithNotificationDeliveryRecorder returns a context recording the webhooks and the emails sent with
+// it.
+func WithNotificationDeliveryRecorder(ctx context.Context, recorder *NotificationDeliveryRecorder) context.Context {
+	return context.WithValue(ctx, notificationDeliveryRecorderKey{}, recorder)
+}
+
+// NotificationDeliveryRecorderFrom returns the recorder of a context, nil when it has none.
+func NotificationDeliveryRecorderFrom(ctx context.Context) *NotificationDeliveryRecorder {
+	recorder, _ := ctx.Value(notificationDeliveryRecorderKey{}).(*NotificationDeliveryRecorder)
+	return recorder
+}
+
+// Deliveries returns the deliveries recorded so far.
+func (r *NotificationDeliveryRecorder) Deliveries() []NotificationDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]NotificationDelivery{}, r.deliveries...)
+}
+
+// RecordWebhook adds a webhook, with the status and the start of the body of its response
+func (r *NotificationDeliveryRecorder) RecordWebhook(method string, u *url.URL, status int, body []byte, err error, duration time.Duration) {
+	delivery := NotificationDelivery{
+		Type:       NotificationDeliveryWebhook,
+		Method:     method,
+		URL:        (&url.URL{Scheme: u.Scheme, Host: u.Host}).String(),
+		Status:     status,
+		DurationMs: float64(duration) / float64(time.Millisecond),
+	}
+	if len(body) > MaxNotificationDeliveryResponse {
+		body = body[:MaxNotificationDeliveryResponse]
+	}
+	delivery.Response = string(body)
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	r.record(delivery)
+}
+
+// RecordEmail adds an email
+func (r *NotificationDeliveryRecorder) RecordEmail(recipients []string, err error, duration time.Duration) {
+	delivery := NotificationDelivery{
+		Type:       NotificationDeliveryEmail,
+		Recipients: recipients,
+		DurationMs: float64(duration) / float64(time.Millisecond),
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	r.record(delivery)
+}
+
+func (r *NotificationDeliveryRecorder) record(delivery NotificationDelivery) {
+	r.mu.Lock()
+	r.deliveries = append(r.deliveries, delivery)
+	r.mu.Unlock()
+}
@@ -6,8 +6,16 @@ import (
 
 // Typed errors
 var (
-	ErrPlaylistNotFound           = errors.New("Playlist not found")
-	ErrPlaylistWithSameNameExists = errors.New("A playlist with the same name already exists")
+	ErrPlaylistNotFound            = errors.New("Playlist not found")
+	ErrPlaylistWithSameNameExists  = errors.New("A playlist with the same name already exists")
+	ErrPlaylistItemInvalidInterval = errors.New("Playlist item has an invalid interval")
+)
+
+// Playlist item types
+const (
+	PlaylistItemTypeDashboardById  = "dashboard_by_id"
+	PlaylistItemTypeDashboardByUid = "dashboard_by_uid"
+	PlaylistItemTypeDashboardByTag = "dashboard_by_tag"
 )
 
 // Playlist model
@@ -16,14 +24,17 @@ type Playlist struct {
 	Name     string `json:"name"`
 	Interval string `json:"interval"`
 	OrgId    int64  `json:"-"`
+	// HasKioskToken is set when the playlist has a kiosk token, see KioskToken
+	HasKioskToken bool `xorm:"-" json:"-"`
 }
 
 type PlaylistDTO struct {
-	Id       int64             `json:"id"`
-	Name     string            `json:"name"`
-	Interval string            `json:"interval"`
-	OrgId    int64             `json:"-"`
-	Items    []PlaylistItemDTO `json:"items"`
+	Id            int64             `json:"id"`
+	Name          string            `json:"name"`
+	Interval      string            `json:"interval"`
+	OrgId         int64             `json:"-"`
+	HasKioskToken bool              `json:"hasKioskToken"`
+	Items         []PlaylistItemDTO `json:"items"`
 }
 
 type PlaylistItemDTO struct {
@@ -33,6 +44,8 @@ type PlaylistItemDTO struct {
 	Title      string `json:"title"`
 	Value      string `json:"value"`
 	Order      int    `json:"order"`
+	// Interval overrides the interval of the playlist for this item
+	Interval string `json:"interval"`
 }
 
 type PlaylistDashboard struct {
@@ -48,6 +61,7 @@ type PlaylistItem struct {
 	Value      string
 	Order      int
 	Title      string
+	Interval   string
 }
 
 func (this PlaylistDashboard) TableName() string {
@@ -85,6 +99,20 @@ type DeletePlaylistCommand struct {
 	OrgId int64
 }
 
+// CreatePlaylistKioskTokenCommand creates a new kiosk token for the playlist,
+// replacing the previous one
+type CreatePlaylistKioskTokenCommand struct {
+	Id    int64
+	OrgId int64
+
+	Result string
+}
+
+type DeletePlaylistKioskTokenCommand struct {
+	Id    int64
+	OrgId int64
+}
+
 //
 // QUERIES
 //
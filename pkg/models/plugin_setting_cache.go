@@ -1,11 +1,25 @@
 package models
 
+import (
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/components/securejsondata"
+)
+
 var pluginSettingDecryptionCache = secureJSONDecryptionCache{
 	cache: make(map[int64]cachedDecryptedJSON),
 }
 
-// DecryptedValues returns cached decrypted values from secureJsonData.
-func (ps *PluginSetting) DecryptedValues() map[string]string {
+// DecryptedValues returns cached decrypted values from secureJsonData, with the references to secrets resolved.
+// The references are resolved on every call, out of the lock of the cache, as they're cached by the secrets
+// service for a limited time to handle their rotation.
+func (ps *PluginSetting) DecryptedValues() (map[string]string, error) {
+	return securejsondata.ResolveReferences(ps.OrgId, false, ps.decryptedValuesUnresolved())
+}
+
+// decryptedValuesUnresolved returns cached decrypted values from secureJsonData, with the references to secrets
+// as they're stored.
+func (ps *PluginSetting) decryptedValuesUnresolved() map[string]string {
 	pluginSettingDecryptionCache.Lock()
 	defer pluginSettingDecryptionCache.Unlock()
 
@@ -22,10 +36,19 @@ func (ps *PluginSetting) DecryptedValues() map[string]string {
 	return json
 }
 
-// DecryptedValue returns cached decrypted value from cached secureJsonData.
-func (ps *PluginSetting) DecryptedValue(key string) (string, bool) {
-	value, exists := ps.DecryptedValues()[key]
-	return value, exists
+// DecryptedValue returns cached decrypted value from cached secureJsonData, with the reference to a secret
+// resolved.
+func (ps *PluginSetting) DecryptedValue(key string) (string, bool, error) {
+	value, exists := ps.decryptedValuesUnresolved()[key]
+	if !exists {
+		return "", false, nil
+	}
+
+	value, err := securejsondata.ResolveReference(ps.OrgId, false, value)
+	if err != nil {
+		return "", true, fmt.Errorf("failed to resolve %s: %w", key, err)
+	}
+	return value, true, nil
 }
 
 // ClearPluginSettingDecryptionCache clears the datasource decryption cache.
@@ -22,7 +22,8 @@ func TestPluginSettingDecryptionCache(t *testing.T) {
 		}
 
 		// Populate cache
-		password, ok := ps.DecryptedValue("password")
+		password, ok, err := ps.DecryptedValue("password")
+		require.NoError(t, err)
 		require.Equal(t, "password", password)
 		require.True(t, ok)
 
@@ -46,7 +47,8 @@ func TestPluginSettingDecryptionCache(t *testing.T) {
 		}
 
 		// Populate cache
-		password, ok := ps.DecryptedValue("password")
+		password, ok, err := ps.DecryptedValue("password")
+		require.NoError(t, err)
 		require.Equal(t, "password", password)
 		require.True(t, ok)
 
@@ -55,7 +57,8 @@ func TestPluginSettingDecryptionCache(t *testing.T) {
 		})
 		ps.Updated = time.Now()
 
-		password, ok = ps.DecryptedValue("password")
+		password, ok, err = ps.DecryptedValue("password")
+		require.NoError(t, err)
 		require.Empty(t, password)
 		require.True(t, ok)
 	})
@@ -18,11 +18,22 @@ type Preferences struct {
 	Version         int
 	HomeDashboardId int64
 	Timezone        string
+	WeekStart       string
 	Theme           string
 	Created         time.Time
 	Updated         time.Time
 }
 
+// IsValidWeekStart tells whether a week start of preferences is a day of the week, or empty to
+// inherit the week start of the level above
+func IsValidWeekStart(weekStart string) bool {
+	switch weekStart {
+	case "", "sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday":
+		return true
+	}
+	return false
+}
+
 // ---------------------
 // QUERIES
 
@@ -35,6 +46,8 @@ type GetPreferencesQuery struct {
 	Result *Preferences
 }
 
+// GetPreferencesWithDefaultsQuery resolves the preferences of a user, the preferences of the
+// instance, its organization, teams and its own overriding each other in that order
 type GetPreferencesWithDefaultsQuery struct {
 	User *SignedInUser
 
@@ -50,5 +63,6 @@ type SavePreferencesCommand struct {
 
 	HomeDashboardId int64  `json:"homeDashboardId"`
 	Timezone        string `json:"timezone"`
+	WeekStart       string `json:"weekStart"`
 	Theme           string `json:"theme"`
 }
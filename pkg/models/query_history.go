@@ -0,0 +1,168 @@
+package models
+
+import (
+	"errors"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// Typed errors
+var (
+	ErrQueryHistoryNotFound                = errors.New("Query in query history not found")
+	ErrQueryHistoryAccessDenied            = errors.New("Access denied to query in query history")
+	ErrQueryHistoryQueriesEmpty            = errors.New("Query history entry needs at least one query")
+	ErrQueryHistoryDatasourceNotFound      = errors.New("Data source of query history entry not found")
+	ErrQueryHistoryAlreadyStarred          = errors.New("Query is already starred")
+	ErrQueryHistoryTeamNotFound            = errors.New("Team to share the query with not found")
+	ErrQueryHistoryFailedGenerateUniqueUid = errors.New("Failed to generate unique query history uid")
+)
+
+// QueryHistory is a query run in Explore, stored for the user that ran it
+type QueryHistory struct {
+	Id            int64
+	Uid           string
+	OrgId         int64
+	DatasourceUid string
+	CreatedBy     int64
+	// CreatedAt is a unix timestamp in seconds
+	CreatedAt int64
+	Comment   string
+	Queries   *simplejson.Json
+}
+
+// QueryHistoryStar marks a query in the query history as starred by a user
+type QueryHistoryStar struct {
+	Id       int64
+	QueryUid string
+	UserId   int64
+}
+
+// QueryHistoryShare makes a query in the query history visible to the members of a team
+type QueryHistoryShare struct {
+	Id       int64
+	QueryUid string
+	OrgId    int64
+	TeamId   int64
+	SharedBy int64
+	Created  int64
+}
+
+// QueryHistoryDTO is the frontend model of a query in the query history
+type QueryHistoryDTO struct {
+	Uid            string           `json:"uid"`
+	DatasourceUid  string           `json:"datasourceUid"`
+	CreatedBy      int64            `json:"createdBy"`
+	CreatedByLogin string           `json:"createdByLogin"`
+	CreatedAt      int64            `json:"createdAt"`
+	Comment        string           `json:"comment"`
+	Queries        *simplejson.Json `json:"queries"`
+	Starred        bool             `json:"starred"`
+	SharedWith     []int64          `json:"sharedWithTeamIds"`
+}
+
+// QueryHistorySearchResult is a page of queries from the query history
+type QueryHistorySearchResult struct {
+	TotalCount   int64              `json:"totalCount"`
+	QueryHistory []*QueryHistoryDTO `json:"queryHistory"`
+	Page         int                `json:"page"`
+	PerPage      int                `json:"perPage"`
+}
+
+//
+// COMMANDS
+//
+
+type CreateQueryHistoryCommand struct {
+	DatasourceUid string           `json:"datasourceUid" binding:"Required"`
+	Queries       *simplejson.Json `json:"queries" binding:"Required"`
+
+	OrgId  int64 `json:"-"`
+	UserId int64 `json:"-"`
+
+	Result *QueryHistory
+}
+
+type PatchQueryHistoryCommentCommand struct {
+	Comment string `json:"comment"`
+
+	Uid    string `json:"-"`
+	OrgId  int64  `json:"-"`
+	UserId int64  `json:"-"`
+
+	Result *QueryHistory
+}
+
+type DeleteQueryHistoryCommand struct {
+	Uid    string
+	OrgId  int64
+	UserId int64
+}
+
+type StarQueryHistoryCommand struct {
+	Uid    string
+	OrgId  int64
+	UserId int64
+}
+
+type UnstarQueryHistoryCommand struct {
+	Uid    string
+	OrgId  int64
+	UserId int64
+}
+
+type ShareQueryHistoryCommand struct {
+	TeamId int64 `json:"teamId" binding:"Required"`
+
+	Uid     string `json:"-"`
+	OrgId   int64  `json:"-"`
+	UserId  int64  `json:"-"`
+	IsAdmin bool   `json:"-"`
+}
+
+type UnshareQueryHistoryCommand struct {
+	Uid    string
+	TeamId int64
+	OrgId  int64
+	UserId int64
+}
+
+// MigrateQueryHistoryItem is a query history entry as stored in the local storage of the browser
+type MigrateQueryHistoryItem struct {
+	DatasourceName string           `json:"datasourceName"`
+	DatasourceId   int64            `json:"datasourceId"`
+	Queries        *simplejson.Json `json:"queries"`
+	CreatedAt      int64            `json:"createdAt"`
+	Comment        string           `json:"comment"`
+	Starred        bool             `json:"starred"`
+}
+
+// MigrateQueryHistoryCommand imports the query history kept in the browser, resolving
+// the data sources the queries were run against to their uids
+type MigrateQueryHistoryCommand struct {
+	Queries []MigrateQueryHistoryItem `json:"queries"`
+
+	OrgId  int64 `json:"-"`
+	UserId int64 `json:"-"`
+
+	TotalCount   int `json:"-"`
+	StarredCount int `json:"-"`
+	SkippedCount int `json:"-"`
+}
+
+//
+// QUERIES
+//
+
+type SearchQueryHistoryQuery struct {
+	OrgId          int64
+	UserId         int64
+	DatasourceUids []string
+	SearchString   string
+	OnlyStarred    bool
+	// Sort is either time-desc (the default) or time-asc
+	Sort  string
+	Page  int
+	Limit int
+
+	Result *QueryHistorySearchResult
+}
@@ -120,6 +120,24 @@ func GetQuotaScopes(target string) ([]QuotaScope, error) {
 			QuotaScope{Name: "org", Target: target, DefaultLimit: setting.Quota.Org.ApiKey},
 		)
 		return scopes, nil
+	case "snapshot":
+		scopes = append(scopes,
+			QuotaScope{Name: "global", Target: "dashboard_snapshot", DefaultLimit: setting.Quota.Global.Snapshot},
+			QuotaScope{Name: "org", Target: "dashboard_snapshot", DefaultLimit: setting.Quota.Org.Snapshot},
+		)
+		return scopes, nil
+	case "team":
+		scopes = append(scopes,
+			QuotaScope{Name: "global", Target: target, DefaultLimit: setting.Quota.Global.Team},
+			QuotaScope{Name: "org", Target: target, DefaultLimit: setting.Quota.Org.Team},
+		)
+		return scopes, nil
+	case "playlist":
+		scopes = append(scopes,
+			QuotaScope{Name: "global", Target: target, DefaultLimit: setting.Quota.Global.Playlist},
+			QuotaScope{Name: "org", Target: target, DefaultLimit: setting.Quota.Org.Playlist},
+		)
+		return scopes, nil
 	case "session":
 		scopes = append(scopes,
 			QuotaScope{Name: "global", Target: target, DefaultLimit: setting.Quota.Global.Session},
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Typed errors
+var (
+	ErrReportNotFound           = errors.New("Report not found")
+	ErrReportWithSameNameExists = errors.New("A report with the same name already exists")
+)
+
+// Report formats
+const (
+	ReportFormatPdf = "pdf"
+	ReportFormatPng = "png"
+)
+
+// Report orientations of the pages and images
+const (
+	ReportOrientationLandscape = "landscape"
+	ReportOrientationPortrait  = "portrait"
+)
+
+// Report renders dashboards on a schedule and emails them to the recipients
+type Report struct {
+	Id    int64 `json:"id"`
+	OrgId int64 `json:"orgId"`
+	// UserId is the owner of the report, the dashboards are rendered as this user
+	UserId int64  `json:"userId"`
+	Name   string `json:"name"`
+	// Schedule is a cron expression, evaluated in Timezone
+	Schedule    string `json:"schedule"`
+	Timezone    string `json:"timezone"`
+	TimeFrom    string `json:"timeFrom"`
+	TimeTo      string `json:"timeTo"`
+	Recipients  string `json:"recipients"`
+	ReplyTo     string `json:"replyTo"`
+	Message     string `json:"message"`
+	Format      string `json:"format"`
+	Orientation string `json:"orientation"`
+	Enabled     bool   `json:"enabled"`
+	// NextRunAt and LastSentAt are unix timestamps in seconds
+	NextRunAt  int64     `json:"nextRunAt"`
+	LastSentAt int64     `json:"lastSentAt"`
+	LastError  string    `json:"lastError"`
+	Created    time.Time `json:"created"`
+	Updated    time.Time `json:"updated"`
+
+	Dashboards []string `json:"dashboards" xorm:"-"`
+}
+
+// ReportDashboard is a dashboard of a report, in the order of the report
+type ReportDashboard struct {
+	Id           int64
+	ReportId     int64
+	DashboardUid string
+	Order        int
+}
+
+//
+// COMMANDS
+//
+
+// ReportSettings are the settings of a report that are set through the API
+type ReportSettings struct {
+	Name       string   `json:"name" binding:"Required"`
+	Dashboards []string `json:"dashboards" binding:"Required"`
+	Schedule   string   `json:"schedule" binding:"Required"`
+	Timezone   string   `json:"timezone"`
+	// TimeFrom and TimeTo default to the time range saved in each dashboard
+	TimeFrom    string `json:"timeFrom"`
+	TimeTo      string `json:"timeTo"`
+	Recipients  string `json:"recipients" binding:"Required"`
+	ReplyTo     string `json:"replyTo"`
+	Message     string `json:"message"`
+	Format      string `json:"format"`
+	Orientation string `json:"orientation"`
+	Enabled     bool   `json:"enabled"`
+}
+
+type CreateReportCommand struct {
+	ReportSettings
+
+	OrgId     int64 `json:"-"`
+	UserId    int64 `json:"-"`
+	NextRunAt int64 `json:"-"`
+
+	Result *Report `json:"-"`
+}
+
+type UpdateReportCommand struct {
+	ReportSettings
+
+	Id    int64 `json:"-"`
+	OrgId int64 `json:"-"`
+	// UserId is the user that updates the report, who becomes its owner
+	UserId    int64 `json:"-"`
+	NextRunAt int64 `json:"-"`
+
+	Result *Report `json:"-"`
+}
+
+type DeleteReportCommand struct {
+	Id    int64
+	OrgId int64
+}
+
+// UpdateReportRunCommand sets when a report runs next. When RunAt is set it also stores
+// the outcome of sending the report at that time.
+type UpdateReportRunCommand struct {
+	Id        int64
+	NextRunAt int64
+	RunAt     int64
+	Error     string
+}
+
+//
+// QUERIES
+//
+
+type GetReportByIdQuery struct {
+	Id     int64
+	OrgId  int64
+	Result *Report
+}
+
+type GetReportsQuery struct {
+	OrgId  int64
+	Result []*Report
+}
+
+// GetDueReportsQuery returns the enabled reports of all orgs that should have run before Now
+type GetDueReportsQuery struct {
+	Now    int64
+	Result []*Report
+}
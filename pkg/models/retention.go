@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// The kinds of data with a retention policy
+const (
+	RetentionAnnotations       = "annotations"
+	RetentionAlertExecutions   = "alert_executions"
+	RetentionDashboardVersions = "dashboard_versions"
+	RetentionLoginAttempts     = "login_attempts"
+)
+
+// ---------------------
+// COMMANDS
+
+// DeleteRowsBeyondRetentionCommand deletes the rows of a kind of data created before OlderThan,
+// then the oldest rows beyond MaxRows. The alert executions are the state changes of the alert
+// rules, the annotations with an alert id. The current version of a dashboard is never deleted.
+type DeleteRowsBeyondRetentionCommand struct {
+	Kind       string
+	OlderThan  time.Time
+	MaxRows    int64
+	BatchSize  int
+	MaxBatches int
+
+	DeletedRows int64
+	// PendingRows are the rows beyond retention left for the next cleanup, as the number of
+	// batches is limited
+	PendingRows int64
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type SystemStats struct {
 	Dashboards            int64
 	Datasources           int64
@@ -84,6 +86,51 @@ type GetAdminStatsQuery struct {
 	Result *AdminStats
 }
 
+// OrgApiUsage is the number of API calls made in an organization on a day
+type OrgApiUsage struct {
+	Id    int64
+	OrgId int64
+	// Day is formatted as 2006-01-02 in UTC
+	Day      string
+	ApiCalls int64
+}
+
+type OrgApiUsageDTO struct {
+	Day      string `json:"day"`
+	ApiCalls int64  `json:"apiCalls"`
+}
+
+type OrgUsage struct {
+	OrgId       int64             `json:"orgId"`
+	Dashboards  int64             `json:"dashboards"`
+	Folders     int64             `json:"folders"`
+	Datasources int64             `json:"datasources"`
+	Users       int64             `json:"users"`
+	Teams       int64             `json:"teams"`
+	Playlists   int64             `json:"playlists"`
+	ApiKeys     int64             `json:"apiKeys"`
+	Alerts      int64             `json:"alerts"`
+	Annotations int64             `json:"annotations"`
+	ApiCalls    []*OrgApiUsageDTO `json:"apiCalls" xorm:"-"`
+	// Quotas are only set when quotas are enabled
+	Quotas []*OrgQuotaDTO `json:"quotas,omitempty" xorm:"-"`
+}
+
+type GetOrgUsageQuery struct {
+	OrgId int64
+	// Days is the number of days of API calls to return, including today
+	Days int
+
+	Result *OrgUsage
+}
+
+// IncrementOrgApiUsageCommand adds the API calls counted since the last
+// increment to the API usage of the organizations on Day
+type IncrementOrgApiUsageCommand struct {
+	Day      time.Time
+	ApiCalls map[int64]int64
+}
+
 type SystemUserCountStats struct {
 	Count int64
 }
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// Types of the usage events
+const (
+	UsageEventDashboardView = "dashboard-view"
+	UsageEventDataRequest   = "data-request"
+	UsageEventLogin         = "login"
+)
+
+// UsageEvent records a user viewing a dashboard, querying a data source or logging in.
+type UsageEvent struct {
+	Id             int64     `json:"-"`
+	EventType      string    `json:"eventType"`
+	Created        time.Time `json:"timestamp"`
+	OrgId          int64     `json:"orgId"`
+	UserId         int64     `json:"userId"`
+	UserLogin      string    `json:"userLogin"`
+	DashboardId    int64     `json:"dashboardId,omitempty"`
+	DashboardUid   string    `json:"dashboardUid,omitempty"`
+	DatasourceId   int64     `json:"datasourceId,omitempty"`
+	DatasourceType string    `json:"datasourceType,omitempty"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// ---------------------
+// COMMANDS
+
+type SaveUsageEventsCommand struct {
+	Events []*UsageEvent
+}
+
+type DeleteOldUsageEventsCommand struct {
+	OlderThan   time.Time
+	DeletedRows int64
+}
@@ -0,0 +1,127 @@
+package backendplugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const collectTimeout = 10 * time.Second
+
+var pluginHealthStatus *prometheus.GaugeVec
+
+func init() {
+	pluginHealthStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "grafana",
+		Name:      "plugin_health_status",
+		Help:      "Health of backend plugins from the last health check, 1 when healthy and 0 otherwise",
+	}, []string{"plugin_id"})
+
+	prometheus.MustRegister(pluginHealthStatus)
+}
+
+// collect collects the metrics and the health of every running backend plugin.
+func (m *manager) collect(ctx context.Context) {
+	m.pluginsMu.RLock()
+	plugins := make([]Plugin, 0, len(m.plugins))
+	for _, p := range m.plugins {
+		plugins = append(plugins, p)
+	}
+	m.pluginsMu.RUnlock()
+
+	for _, p := range plugins {
+		if p.Exited() {
+			continue
+		}
+
+		collectCtx, cancel := context.WithTimeout(ctx, collectTimeout)
+		m.collectMetrics(collectCtx, p.PluginID())
+		m.checkHealth(collectCtx, p.PluginID())
+		cancel()
+	}
+}
+
+func (m *manager) collectMetrics(ctx context.Context, pluginID string) {
+	var families map[string]*dto.MetricFamily
+
+	resp, err := m.CollectMetrics(ctx, pluginID)
+	if err == nil && resp != nil {
+		var parser expfmt.TextParser
+		families, err = parser.TextToMetricFamilies(bytes.NewReader(resp.PrometheusMetrics))
+	}
+	if err != nil && !errors.Is(err, ErrMethodNotImplemented) {
+		m.logger.Warn("Failed to collect plugin metrics", "pluginId", pluginID, "error", err)
+	}
+
+	labelName := "plugin_id"
+	for _, family := range families {
+		for _, metric := range family.Metric {
+			metric.Label = append(metric.Label, &dto.LabelPair{Name: &labelName, Value: &pluginID})
+		}
+	}
+
+	m.collectedMu.Lock()
+	defer m.collectedMu.Unlock()
+	if len(families) == 0 {
+		delete(m.metrics, pluginID)
+		return
+	}
+	m.metrics[pluginID] = families
+}
+
+func (m *manager) checkHealth(ctx context.Context, pluginID string) {
+	resp, err := m.CheckHealth(ctx, backend.PluginContext{PluginID: pluginID})
+	if errors.Is(err, ErrMethodNotImplemented) {
+		return
+	}
+
+	if err != nil {
+		m.logger.Warn("Plugin health check failed", "pluginId", pluginID, "error", err)
+	}
+
+	healthy := 0.0
+	if err == nil && resp != nil && resp.Status == backend.HealthStatusOk {
+		healthy = 1
+	}
+	pluginHealthStatus.WithLabelValues(pluginID).Set(healthy)
+}
+
+// Gather returns the metrics last collected from the backend plugins, labeled with their plugin id.
+func (m *manager) Gather() ([]*dto.MetricFamily, error) {
+	m.collectedMu.RLock()
+	defer m.collectedMu.RUnlock()
+
+	merged := make(map[string]*dto.MetricFamily)
+	for _, families := range m.metrics {
+		for name, family := range families {
+			existing, exists := merged[name]
+			if !exists {
+				merged[name] = &dto.MetricFamily{
+					Name:   family.Name,
+					Help:   family.Help,
+					Type:   family.Type,
+					Metric: append([]*dto.Metric{}, family.Metric...),
+				}
+				continue
+			}
+			existing.Metric = append(existing.Metric, family.Metric...)
+		}
+	}
+
+	result := make([]*dto.MetricFamily, 0, len(merged))
+	for _, family := range merged {
+		result = append(result, family)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].GetName() < result[j].GetName()
+	})
+
+	return result, nil
+}
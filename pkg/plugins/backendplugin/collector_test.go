@@ -0,0 +1,61 @@
+package backendplugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagerCollect(t *testing.T) {
+	newManagerScenario(t, true, func(t *testing.T, ctx *managerScenarioCtx) {
+		err := ctx.manager.Register(testPluginID, ctx.factory)
+		require.NoError(t, err)
+
+		t.Run("Plugins without metrics and health checks", func(t *testing.T) {
+			ctx.manager.collect(context.Background())
+
+			families, err := ctx.manager.Gather()
+			require.NoError(t, err)
+			require.Empty(t, families)
+		})
+
+		t.Run("Collects the metrics of plugins labeled with their id", func(t *testing.T) {
+			ctx.plugin.CollectMetricsHandlerFunc = backend.CollectMetricsHandlerFunc(func(ctx context.Context) (*backend.CollectMetricsResult, error) {
+				return &backend.CollectMetricsResult{
+					PrometheusMetrics: []byte("# TYPE test_requests_total counter\ntest_requests_total{status=\"ok\"} 3\n"),
+				}, nil
+			})
+			ctx.manager.collect(context.Background())
+
+			families, err := ctx.manager.Gather()
+			require.NoError(t, err)
+			require.Len(t, families, 1)
+			require.Equal(t, "test_requests_total", families[0].GetName())
+			require.Len(t, families[0].Metric, 1)
+
+			labels := map[string]string{}
+			for _, label := range families[0].Metric[0].Label {
+				labels[label.GetName()] = label.GetValue()
+			}
+			require.Equal(t, map[string]string{"status": "ok", "plugin_id": testPluginID}, labels)
+			require.Equal(t, float64(3), families[0].Metric[0].GetCounter().GetValue())
+		})
+
+		t.Run("Reports the health of plugins", func(t *testing.T) {
+			status := backend.HealthStatusOk
+			ctx.plugin.CheckHealthHandlerFunc = backend.CheckHealthHandlerFunc(func(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+				return &backend.CheckHealthResult{Status: status}, nil
+			})
+
+			ctx.manager.collect(context.Background())
+			require.Equal(t, float64(1), testutil.ToFloat64(pluginHealthStatus.WithLabelValues(testPluginID)))
+
+			status = backend.HealthStatusError
+			ctx.manager.collect(context.Background())
+			require.Equal(t, float64(0), testutil.ToFloat64(pluginHealthStatus.WithLabelValues(testPluginID)))
+		})
+	})
+}
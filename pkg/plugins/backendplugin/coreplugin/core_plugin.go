@@ -16,10 +16,17 @@ type corePlugin struct {
 	backend.CheckHealthHandler
 	backend.CallResourceHandler
 	backend.QueryDataHandler
+	streamHandler backendplugin.StreamHandler
 }
 
 // New returns a new backendplugin.PluginFactoryFunc for creating a core (built-in) backendplugin.Plugin.
 func New(opts backend.ServeOpts) backendplugin.PluginFactoryFunc {
+	return NewWithStreamHandler(opts, nil)
+}
+
+// NewWithStreamHandler returns a new backendplugin.PluginFactoryFunc for creating a core (built-in)
+// backendplugin.Plugin that streams data with streamHandler.
+func NewWithStreamHandler(opts backend.ServeOpts, streamHandler backendplugin.StreamHandler) backendplugin.PluginFactoryFunc {
 	return backendplugin.PluginFactoryFunc(func(pluginID string, logger log.Logger, env []string) (backendplugin.Plugin, error) {
 		return &corePlugin{
 			pluginID:            pluginID,
@@ -27,6 +34,7 @@ func New(opts backend.ServeOpts) backendplugin.PluginFactoryFunc {
 			CheckHealthHandler:  opts.CheckHealthHandler,
 			CallResourceHandler: opts.CallResourceHandler,
 			QueryDataHandler:    opts.QueryDataHandler,
+			streamHandler:       streamHandler,
 		}, nil
 	})
 }
@@ -79,3 +87,19 @@ func (cp *corePlugin) CallResource(ctx context.Context, req *backend.CallResourc
 
 	return backendplugin.ErrMethodNotImplemented
 }
+
+func (cp *corePlugin) SubscribeStream(ctx context.Context, req *backendplugin.SubscribeStreamRequest) (*backendplugin.SubscribeStreamResponse, error) {
+	if cp.streamHandler != nil {
+		return cp.streamHandler.SubscribeStream(ctx, req)
+	}
+
+	return nil, backendplugin.ErrMethodNotImplemented
+}
+
+func (cp *corePlugin) RunStream(ctx context.Context, req *backendplugin.RunStreamRequest, sender backendplugin.StreamPacketSender) error {
+	if cp.streamHandler != nil {
+		return cp.streamHandler.RunStream(ctx, req, sender)
+	}
+
+	return backendplugin.ErrMethodNotImplemented
+}
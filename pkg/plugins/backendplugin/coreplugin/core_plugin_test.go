@@ -30,6 +30,9 @@ func TestCorePlugin(t *testing.T) {
 
 		err = p.CallResource(context.Background(), nil, nil)
 		require.Equal(t, backendplugin.ErrMethodNotImplemented, err)
+
+		_, err = p.(backendplugin.StreamHandler).SubscribeStream(context.Background(), nil)
+		require.Equal(t, backendplugin.ErrMethodNotImplemented, err)
 	})
 
 	t.Run("New core plugin with handlers set in opts should return expected values", func(t *testing.T) {
@@ -64,4 +67,35 @@ func TestCorePlugin(t *testing.T) {
 		require.NoError(t, err)
 		require.True(t, callResourceCalled)
 	})
+	t.Run("New core plugin with stream handler should stream", func(t *testing.T) {
+		factory := coreplugin.NewWithStreamHandler(backend.ServeOpts{}, &testStreamHandler{})
+		p, err := factory("plugin", log.New("test"), nil)
+		require.NoError(t, err)
+
+		handler, ok := p.(backendplugin.StreamHandler)
+		require.True(t, ok)
+
+		res, err := handler.SubscribeStream(context.Background(), &backendplugin.SubscribeStreamRequest{Path: "test"})
+		require.NoError(t, err)
+		require.Equal(t, backendplugin.SubscribeStreamStatusOK, res.Status)
+
+		var packets []*backendplugin.StreamPacket
+		err = handler.RunStream(context.Background(), &backendplugin.RunStreamRequest{Path: "test"},
+			backendplugin.StreamPacketSenderFunc(func(packet *backendplugin.StreamPacket) error {
+				packets = append(packets, packet)
+				return nil
+			}))
+		require.NoError(t, err)
+		require.Equal(t, []*backendplugin.StreamPacket{{Data: []byte(`"test"`)}}, packets)
+	})
+}
+
+type testStreamHandler struct{}
+
+func (h *testStreamHandler) SubscribeStream(ctx context.Context, req *backendplugin.SubscribeStreamRequest) (*backendplugin.SubscribeStreamResponse, error) {
+	return &backendplugin.SubscribeStreamResponse{Status: backendplugin.SubscribeStreamStatusOK}, nil
+}
+
+func (h *testStreamHandler) RunStream(ctx context.Context, req *backendplugin.RunStreamRequest, sender backendplugin.StreamPacketSender) error {
+	return sender.Send(&backendplugin.StreamPacket{Data: []byte(`"` + req.Path + `"`)})
 }
@@ -31,6 +31,11 @@ func modelToInstanceSettings(ds *models.DataSource) (*backend.DataSourceInstance
 		return nil, err
 	}
 
+	decryptedValues, err := ds.DecryptedValues()
+	if err != nil {
+		return nil, err
+	}
+
 	return &backend.DataSourceInstanceSettings{
 		ID:                      ds.Id,
 		Name:                    ds.Name,
@@ -40,7 +45,7 @@ func modelToInstanceSettings(ds *models.DataSource) (*backend.DataSourceInstance
 		BasicAuthEnabled:        ds.BasicAuth,
 		BasicAuthUser:           ds.BasicAuthUser,
 		JSONData:                jsonDataBytes,
-		DecryptedSecureJSONData: ds.DecryptedValues(),
+		DecryptedSecureJSONData: decryptedValues,
 		Updated:                 ds.Updated,
 	}, nil
 }
@@ -75,7 +75,7 @@ func instrumentDatasourcePluginV1(plugin datasourceV1.DatasourcePlugin) datasour
 
 	return datasourceV1QueryFunc(func(ctx context.Context, req *datasourceV1.DatasourceRequest) (*datasourceV1.DatasourceResponse, error) {
 		var resp *datasourceV1.DatasourceResponse
-		err := backendplugin.InstrumentQueryDataRequest(req.Datasource.Type, func() (innerErr error) {
+		err := backendplugin.InstrumentQueryDataRequest(ctx, req.Datasource.Type, func(ctx context.Context) (innerErr error) {
 			resp, innerErr = plugin.Query(ctx, req)
 			return
 		})
@@ -182,7 +182,7 @@ func instrumentDataClient(plugin grpcplugin.DataClient) grpcplugin.DataClient {
 
 	return dataClientQueryDataFunc(func(ctx context.Context, req *pluginv2.QueryDataRequest, opts ...grpc.CallOption) (*pluginv2.QueryDataResponse, error) {
 		var resp *pluginv2.QueryDataResponse
-		err := backendplugin.InstrumentQueryDataRequest(req.PluginContext.PluginId, func() (innerErr error) {
+		err := backendplugin.InstrumentQueryDataRequest(ctx, req.PluginContext.PluginId, func(ctx context.Context) (innerErr error) {
 			resp, innerErr = plugin.QueryData(ctx, req)
 			return
 		})
@@ -203,7 +203,7 @@ func instrumentTransformPlugin(plugin grpcplugin.TransformClient) grpcplugin.Tra
 
 	return transformPluginTransformDataFunc(func(ctx context.Context, req *pluginv2.QueryDataRequest, callback grpcplugin.TransformDataCallBack) (*pluginv2.QueryDataResponse, error) {
 		var resp *pluginv2.QueryDataResponse
-		err := backendplugin.InstrumentTransformDataRequest(req.PluginContext.PluginId, func() (innerErr error) {
+		err := backendplugin.InstrumentTransformDataRequest(ctx, req.PluginContext.PluginId, func(ctx context.Context) (innerErr error) {
 			resp, innerErr = plugin.TransformData(ctx, req, callback)
 			return
 		})
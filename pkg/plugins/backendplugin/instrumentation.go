@@ -5,7 +5,10 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/metadata"
 )
 
 var (
@@ -30,15 +33,23 @@ func init() {
 	prometheus.MustRegister(pluginRequestCounter, pluginRequestDuration)
 }
 
-// instrumentPluginRequest instruments success rate and latency of `fn`
-func instrumentPluginRequest(pluginID string, endpoint string, fn func() error) error {
+// instrumentPluginRequest instruments success rate and latency of `fn`, and traces it in a span
+// propagated to the plugin in the gRPC metadata of the request.
+func instrumentPluginRequest(ctx context.Context, pluginID string, endpoint string, fn func(ctx context.Context) error) error {
 	status := "ok"
 
+	span, ctx := opentracing.StartSpanFromContext(ctx, "plugin "+endpoint)
+	defer span.Finish()
+	span.SetTag("plugin_id", pluginID)
+	ctx = injectSpanContext(ctx, span)
+
 	start := time.Now()
 
-	err := fn()
+	err := fn(ctx)
 	if err != nil {
 		status = "error"
+		ext.Error.Set(span, true)
+		span.LogKV("error", err.Error())
 	}
 
 	elapsed := time.Since(start) / time.Millisecond
@@ -48,26 +59,40 @@ func instrumentPluginRequest(pluginID string, endpoint string, fn func() error)
 	return err
 }
 
-func instrumentCollectMetrics(pluginID string, fn func() error) error {
-	return instrumentPluginRequest(pluginID, "collectMetrics", fn)
+// injectSpanContext adds the span context to the gRPC metadata of the requests made with ctx.
+func injectSpanContext(ctx context.Context, span opentracing.Span) context.Context {
+	carrier := opentracing.TextMapCarrier{}
+	if err := opentracing.GlobalTracer().Inject(span.Context(), opentracing.TextMap, carrier); err != nil {
+		return ctx
+	}
+
+	pairs := make([]string, 0, len(carrier)*2)
+	for key, value := range carrier {
+		pairs = append(pairs, key, value)
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+func instrumentCollectMetrics(ctx context.Context, pluginID string, fn func(ctx context.Context) error) error {
+	return instrumentPluginRequest(ctx, pluginID, "collectMetrics", fn)
 }
 
-func instrumentCheckHealthRequest(pluginID string, fn func() error) error {
-	return instrumentPluginRequest(pluginID, "checkHealth", fn)
+func instrumentCheckHealthRequest(ctx context.Context, pluginID string, fn func(ctx context.Context) error) error {
+	return instrumentPluginRequest(ctx, pluginID, "checkHealth", fn)
 }
 
-func instrumentCallResourceRequest(pluginID string, fn func() error) error {
-	return instrumentPluginRequest(pluginID, "callResource", fn)
+func instrumentCallResourceRequest(ctx context.Context, pluginID string, fn func(ctx context.Context) error) error {
+	return instrumentPluginRequest(ctx, pluginID, "callResource", fn)
 }
 
 // InstrumentQueryDataRequest instruments success rate and latency of query data request.
-func InstrumentQueryDataRequest(pluginID string, fn func() error) error {
-	return instrumentPluginRequest(pluginID, "queryData", fn)
+func InstrumentQueryDataRequest(ctx context.Context, pluginID string, fn func(ctx context.Context) error) error {
+	return instrumentPluginRequest(ctx, pluginID, "queryData", fn)
 }
 
 // InstrumentTransformDataRequest instruments success rate and latency of transform data request.
-func InstrumentTransformDataRequest(pluginID string, fn func() error) error {
-	return instrumentPluginRequest(pluginID, "transformData", fn)
+func InstrumentTransformDataRequest(ctx context.Context, pluginID string, fn func(ctx context.Context) error) error {
+	return instrumentPluginRequest(ctx, pluginID, "transformData", fn)
 }
 
 // InstrumentQueryDataHandler wraps a backend.QueryDataHandler with instrumentation of success rate and latency.
@@ -78,7 +103,7 @@ func InstrumentQueryDataHandler(handler backend.QueryDataHandler) backend.QueryD
 
 	return backend.QueryDataHandlerFunc(func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 		var resp *backend.QueryDataResponse
-		err := InstrumentQueryDataRequest(req.PluginContext.PluginID, func() (innerErr error) {
+		err := InstrumentQueryDataRequest(ctx, req.PluginContext.PluginID, func(ctx context.Context) (innerErr error) {
 			resp, innerErr = handler.QueryData(ctx, req)
 			return
 		})
@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
@@ -19,7 +20,8 @@ import (
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
 	"github.com/grafana/grafana/pkg/util/proxyutil"
-	"golang.org/x/xerrors"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 )
 
 var (
@@ -53,6 +55,18 @@ type Manager interface {
 	CheckHealth(ctx context.Context, pCtx backend.PluginContext) (*backend.CheckHealthResult, error)
 	// CallResource calls a plugin resource.
 	CallResource(pluginConfig backend.PluginContext, ctx *models.ReqContext, path string)
+	// SubscribeStream asks a plugin whether a user can subscribe to one of its streams.
+	SubscribeStream(ctx context.Context, req *SubscribeStreamRequest) (*SubscribeStreamResponse, error)
+	// RunStream runs a stream of a plugin until ctx is done.
+	RunStream(ctx context.Context, req *RunStreamRequest, sender StreamPacketSender) error
+	// RestartPlugin stops and starts the process of a backend plugin.
+	RestartPlugin(ctx context.Context, pluginID string) error
+	// UnregisterPlugin stops a backend plugin and removes it, e.g. before its files are removed.
+	UnregisterPlugin(ctx context.Context, pluginID string) error
+	// ExitedPlugins returns the ids of the started backend plugins whose process exited.
+	ExitedPlugins() []string
+	// Gatherer gathers the metrics last collected from the backend plugins.
+	prometheus.Gatherer
 }
 
 type manager struct {
@@ -62,21 +76,51 @@ type manager struct {
 	plugins        map[string]Plugin
 	logger         log.Logger
 	pluginSettings map[string]pluginSettings
+
+	processesMu     sync.Mutex
+	processes       map[string]*pluginProcess
+	processSettings map[string]processSettings
+
+	collectedMu sync.RWMutex
+	metrics     map[string]map[string]*dto.MetricFamily
 }
 
 func (m *manager) Init() error {
 	m.plugins = make(map[string]Plugin)
 	m.logger = log.New("plugins.backend")
 	m.pluginSettings = extractPluginSettings(m.Cfg)
+	m.processes = make(map[string]*pluginProcess)
+	m.metrics = make(map[string]map[string]*dto.MetricFamily)
+
+	processSettings, err := extractProcessSettings(m.Cfg)
+	if err != nil {
+		return err
+	}
+	m.processSettings = processSettings
 
 	return nil
 }
 
 func (m *manager) Run(ctx context.Context) error {
 	m.start(ctx)
-	<-ctx.Done()
-	m.stop(ctx)
-	return ctx.Err()
+
+	// the metrics and the health of the plugins are collected periodically when an interval is configured
+	var collect <-chan time.Time
+	if m.Cfg.PluginsCollectInterval > 0 {
+		ticker := time.NewTicker(m.Cfg.PluginsCollectInterval)
+		defer ticker.Stop()
+		collect = ticker.C
+	}
+
+	for {
+		select {
+		case <-collect:
+			m.collect(ctx)
+		case <-ctx.Done():
+			m.stop(ctx)
+			return ctx.Err()
+		}
+	}
 }
 
 // Register registers a backend plugin
@@ -129,7 +173,7 @@ func (m *manager) start(ctx context.Context) {
 			continue
 		}
 
-		if err := startPluginAndRestartKilledProcesses(ctx, p); err != nil {
+		if err := m.startPluginAndRestartKilledProcesses(ctx, p); err != nil {
 			p.Logger().Error("Failed to start plugin", "error", err)
 			continue
 		}
@@ -149,7 +193,7 @@ func (m *manager) StartPlugin(ctx context.Context, pluginID string) error {
 		return errors.New("Backend plugin is managed and cannot be manually started")
 	}
 
-	return startPluginAndRestartKilledProcesses(ctx, p)
+	return m.startPluginAndRestartKilledProcesses(ctx, p)
 }
 
 // stop stops all managed backend plugins
@@ -182,7 +226,7 @@ func (m *manager) CollectMetrics(ctx context.Context, pluginID string) (*backend
 	}
 
 	var resp *backend.CollectMetricsResult
-	err := instrumentCollectMetrics(p.PluginID(), func() (innerErr error) {
+	err := instrumentCollectMetrics(ctx, p.PluginID(), func(ctx context.Context) (innerErr error) {
 		resp, innerErr = p.CollectMetrics(ctx)
 		return
 	})
@@ -204,7 +248,7 @@ func (m *manager) CheckHealth(ctx context.Context, pluginContext backend.PluginC
 	}
 
 	var resp *backend.CheckHealthResult
-	err := instrumentCheckHealthRequest(p.PluginID(), func() (innerErr error) {
+	err := instrumentCheckHealthRequest(ctx, p.PluginID(), func(ctx context.Context) (innerErr error) {
 		resp, innerErr = p.CheckHealth(ctx, &backend.CheckHealthRequest{PluginContext: pluginContext})
 		return
 	})
@@ -220,6 +264,43 @@ func (m *manager) CheckHealth(ctx context.Context, pluginContext backend.PluginC
 	return resp, nil
 }
 
+// SubscribeStream asks a plugin whether a user can subscribe to one of its streams.
+func (m *manager) SubscribeStream(ctx context.Context, req *SubscribeStreamRequest) (*SubscribeStreamResponse, error) {
+	handler, err := m.getStreamHandler(req.PluginContext.PluginID)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler.SubscribeStream(ctx, req)
+}
+
+// RunStream runs a stream of a plugin until ctx is done.
+func (m *manager) RunStream(ctx context.Context, req *RunStreamRequest, sender StreamPacketSender) error {
+	handler, err := m.getStreamHandler(req.PluginContext.PluginID)
+	if err != nil {
+		return err
+	}
+
+	return handler.RunStream(ctx, req, sender)
+}
+
+func (m *manager) getStreamHandler(pluginID string) (StreamHandler, error) {
+	m.pluginsMu.RLock()
+	p, registered := m.plugins[pluginID]
+	m.pluginsMu.RUnlock()
+
+	if !registered {
+		return nil, ErrPluginNotRegistered
+	}
+
+	handler, ok := p.(StreamHandler)
+	if !ok {
+		return nil, ErrMethodNotImplemented
+	}
+
+	return handler, nil
+}
+
 type keepCookiesJSONModel struct {
 	KeepCookies []string `json:"keepCookies"`
 }
@@ -243,6 +324,7 @@ func (m *manager) callResourceInternal(w http.ResponseWriter, req *http.Request,
 
 	proxyutil.ClearCookieHeader(req, keepCookieModel.KeepCookies)
 	proxyutil.PrepareProxyRequest(req)
+	m.applyContextHeaders(req, pCtx)
 
 	body, err := ioutil.ReadAll(req.Body)
 	if err != nil {
@@ -258,8 +340,8 @@ func (m *manager) callResourceInternal(w http.ResponseWriter, req *http.Request,
 		Body:          body,
 	}
 
-	return instrumentCallResourceRequest(p.PluginID(), func() error {
-		childCtx, cancel := context.WithCancel(req.Context())
+	return instrumentCallResourceRequest(req.Context(), p.PluginID(), func(ctx context.Context) error {
+		childCtx, cancel := context.WithCancel(ctx)
 		defer cancel()
 		stream := newCallResourceResponseStream(childCtx)
 		var wg sync.WaitGroup
@@ -270,7 +352,7 @@ func (m *manager) callResourceInternal(w http.ResponseWriter, req *http.Request,
 			wg.Done()
 		}()
 
-		innerErr := p.CallResource(req.Context(), crReq, stream)
+		innerErr := p.CallResource(ctx, crReq, stream)
 		stream.Close()
 		if innerErr != nil {
 			return innerErr
@@ -280,6 +362,19 @@ func (m *manager) callResourceInternal(w http.ResponseWriter, req *http.Request,
 	})
 }
 
+// applyContextHeaders sets the org and, like the data source proxy does when send_user_header is
+// enabled, the login of the user calling a resource, replacing the headers of the client.
+func (m *manager) applyContextHeaders(req *http.Request, pCtx backend.PluginContext) {
+	req.Header.Del("X-Grafana-Org-Id")
+	req.Header.Del("X-Grafana-User")
+	if pCtx.OrgID != 0 {
+		req.Header.Set("X-Grafana-Org-Id", strconv.FormatInt(pCtx.OrgID, 10))
+	}
+	if m.Cfg.SendUserHeader && pCtx.User != nil && pCtx.User.Login != "" {
+		req.Header.Set("X-Grafana-User", pCtx.User.Login)
+	}
+}
+
 // CallResource calls a plugin resource.
 func (m *manager) CallResource(pCtx backend.PluginContext, reqCtx *models.ReqContext, path string) {
 	clonedReq := reqCtx.Req.Clone(reqCtx.Req.Context())
@@ -310,6 +405,11 @@ func handleCallResourceError(err error, reqCtx *models.ReqContext) {
 		return
 	}
 
+	if errors.Is(err, ErrPluginNotRegistered) {
+		reqCtx.JsonApiErr(404, "Plugin not registered", err)
+		return
+	}
+
 	reqCtx.JsonApiErr(500, "Failed to call resource", err)
 }
 
@@ -365,42 +465,3 @@ func flushStream(plugin Plugin, stream CallResourceClientResponseStream, w http.
 		processedStreams++
 	}
 }
-
-func startPluginAndRestartKilledProcesses(ctx context.Context, p Plugin) error {
-	if err := p.Start(ctx); err != nil {
-		return err
-	}
-
-	go func(ctx context.Context, p Plugin) {
-		if err := restartKilledProcess(ctx, p); err != nil {
-			p.Logger().Error("Attempt to restart killed plugin process failed", "error", err)
-		}
-	}(ctx, p)
-
-	return nil
-}
-
-func restartKilledProcess(ctx context.Context, p Plugin) error {
-	ticker := time.NewTicker(time.Second * 1)
-
-	for {
-		select {
-		case <-ctx.Done():
-			if err := ctx.Err(); err != nil && !xerrors.Is(err, context.Canceled) {
-				return err
-			}
-			return nil
-		case <-ticker.C:
-			if !p.Exited() {
-				continue
-			}
-
-			p.Logger().Debug("Restarting plugin")
-			if err := p.Start(ctx); err != nil {
-				p.Logger().Error("Failed to restart plugin", "error", err)
-				continue
-			}
-			p.Logger().Debug("Plugin restarted")
-		}
-	}
-}
@@ -35,6 +35,9 @@ func TestManager(t *testing.T) {
 			w := httptest.NewRecorder()
 			err = ctx.manager.callResourceInternal(w, req, backend.PluginContext{PluginID: testPluginID})
 			require.Equal(t, ErrPluginNotRegistered, err)
+
+			_, err = ctx.manager.SubscribeStream(context.Background(), &SubscribeStreamRequest{PluginContext: backend.PluginContext{PluginID: testPluginID}})
+			require.Equal(t, ErrPluginNotRegistered, err)
 		})
 	})
 
@@ -136,6 +139,14 @@ func TestManager(t *testing.T) {
 						err = ctx.manager.callResourceInternal(w, req, backend.PluginContext{PluginID: testPluginID})
 						require.Equal(t, ErrMethodNotImplemented, err)
 					})
+
+					t.Run("Subscribe and run stream should return method not implemented error", func(t *testing.T) {
+						_, err = ctx.manager.SubscribeStream(context.Background(), &SubscribeStreamRequest{PluginContext: backend.PluginContext{PluginID: testPluginID}})
+						require.Equal(t, ErrMethodNotImplemented, err)
+
+						err = ctx.manager.RunStream(context.Background(), &RunStreamRequest{PluginContext: backend.PluginContext{PluginID: testPluginID}}, nil)
+						require.Equal(t, ErrMethodNotImplemented, err)
+					})
 				})
 
 				t.Run("Implemented handlers", func(t *testing.T) {
@@ -186,6 +197,43 @@ func TestManager(t *testing.T) {
 						require.NoError(t, err)
 						require.Equal(t, http.StatusOK, w.Code)
 					})
+
+					t.Run("Call resource should forward the org and the user", func(t *testing.T) {
+						var received *backend.CallResourceRequest
+						ctx.plugin.CallResourceHandlerFunc = backend.CallResourceHandlerFunc(func(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+							received = req
+							return sender.Send(&backend.CallResourceResponse{
+								Status: http.StatusOK,
+							})
+						})
+
+						ctx.cfg.SendUserHeader = true
+						defer func() { ctx.cfg.SendUserHeader = false }()
+
+						req, err := http.NewRequest(http.MethodGet, "/test", bytes.NewReader([]byte{}))
+						require.NoError(t, err)
+						req.Header.Set("X-Grafana-Org-Id", "3")
+						req.Header.Set("X-Grafana-User", "admin")
+						pCtx := backend.PluginContext{
+							PluginID: testPluginID,
+							OrgID:    2,
+							User:     &backend.User{Login: "editor", Role: "Editor"},
+						}
+						err = ctx.manager.callResourceInternal(httptest.NewRecorder(), req, pCtx)
+						require.NoError(t, err)
+						require.NotNil(t, received)
+						require.Equal(t, pCtx, received.PluginContext)
+						require.Equal(t, []string{"2"}, received.Headers["X-Grafana-Org-Id"])
+						require.Equal(t, []string{"editor"}, received.Headers["X-Grafana-User"])
+
+						ctx.cfg.SendUserHeader = false
+						req, err = http.NewRequest(http.MethodGet, "/test", bytes.NewReader([]byte{}))
+						require.NoError(t, err)
+						req.Header.Set("X-Grafana-User", "admin")
+						err = ctx.manager.callResourceInternal(httptest.NewRecorder(), req, pCtx)
+						require.NoError(t, err)
+						require.NotContains(t, received.Headers, "X-Grafana-User")
+					})
 				})
 			})
 		})
@@ -20,6 +20,12 @@ type Plugin interface {
 	backend.CallResourceHandler
 }
 
+// ProcessPlugin is a backend plugin running in its own process.
+type ProcessPlugin interface {
+	// Pid returns the process id of the running plugin, or 0 if it's not running.
+	Pid() int
+}
+
 // PluginFactoryFunc factory for creating a Plugin.
 type PluginFactoryFunc func(pluginID string, logger log.Logger, env []string) (Plugin, error)
 
@@ -0,0 +1,321 @@
+package backendplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRestartBackoff caps the delay between two attempts to restart a killed plugin process.
+	maxRestartBackoff = 5 * time.Minute
+	// restartResetInterval is how long a restarted plugin process has to keep running before
+	// its failed restart attempts are forgotten.
+	restartResetInterval = 10 * time.Minute
+)
+
+// Keys of the plugin settings configuring the process of a backend plugin. They aren't passed
+// to the plugin as environment variables.
+const (
+	settingMaxRestarts    = "max_restarts"
+	settingRestartBackoff = "restart_backoff"
+	settingMemoryLimit    = "memory_limit"
+	settingCPULimit       = "cpu_limit"
+)
+
+func isProcessSetting(key string) bool {
+	switch key {
+	case settingMaxRestarts, settingRestartBackoff, settingMemoryLimit, settingCPULimit:
+		return true
+	}
+	return false
+}
+
+// processSettings configures how the process of a backend plugin is run and restarted.
+type processSettings struct {
+	// maxRestarts is the number of attempts to restart a killed process, 0 means no limit.
+	maxRestarts int
+	// restartBackoff is the delay after the first restart attempt, doubled on every further attempt.
+	restartBackoff time.Duration
+	// memoryLimit is the maximum size in bytes of the virtual memory of the process, 0 means no limit.
+	memoryLimit uint64
+	// cpuLimit is the maximum CPU time in seconds of the process, 0 means no limit.
+	cpuLimit uint64
+}
+
+func (ps processSettings) hasLimits() bool {
+	return ps.memoryLimit > 0 || ps.cpuLimit > 0
+}
+
+func parseProcessSettings(settings map[string]string) (processSettings, error) {
+	ps := processSettings{}
+	for key, value := range settings {
+		if value == "" {
+			continue
+		}
+
+		var err error
+		switch key {
+		case settingMaxRestarts:
+			ps.maxRestarts, err = strconv.Atoi(value)
+			if err == nil && ps.maxRestarts < 0 {
+				err = errors.New("must not be negative")
+			}
+		case settingRestartBackoff:
+			ps.restartBackoff, err = time.ParseDuration(value)
+		case settingMemoryLimit:
+			var mb uint64
+			mb, err = strconv.ParseUint(value, 10, 64)
+			ps.memoryLimit = mb * 1024 * 1024
+		case settingCPULimit:
+			ps.cpuLimit, err = strconv.ParseUint(value, 10, 64)
+		}
+		if err != nil {
+			return ps, fmt.Errorf("invalid %s %q: %w", key, value, err)
+		}
+	}
+
+	return ps, nil
+}
+
+// pluginProcess tracks the restarts of the process of a backend plugin.
+type pluginProcess struct {
+	settings processSettings
+
+	mu          sync.Mutex
+	restarts    int
+	startedAt   time.Time
+	nextRestart time.Time
+	gaveUp      bool
+	// unregistered is set when the plugin is removed, its process isn't restarted anymore
+	unregistered bool
+}
+
+// started records that the plugin process was started, either initially or by an admin,
+// forgetting the previous restart attempts.
+func (proc *pluginProcess) started() {
+	proc.restarts = 0
+	proc.startedAt = time.Now()
+	proc.nextRestart = time.Time{}
+	proc.gaveUp = false
+}
+
+// backoff returns the delay before the next restart attempt after the given number of attempts.
+func (proc *pluginProcess) backoff(attempts int) time.Duration {
+	backoff := proc.settings.restartBackoff
+	for i := 1; i < attempts && backoff < maxRestartBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRestartBackoff {
+		backoff = maxRestartBackoff
+	}
+	return backoff
+}
+
+// startPlugin starts a plugin and applies the limits of its process.
+func (m *manager) startPlugin(ctx context.Context, p Plugin) error {
+	if err := p.Start(ctx); err != nil {
+		return err
+	}
+
+	m.applyProcessLimits(p)
+	return nil
+}
+
+func (m *manager) applyProcessLimits(p Plugin) {
+	proc := m.process(p.PluginID())
+	if !proc.settings.hasLimits() {
+		return
+	}
+
+	pp, ok := p.(ProcessPlugin)
+	if !ok {
+		p.Logger().Warn("Process limits only apply to plugins running in their own process")
+		return
+	}
+
+	pid := pp.Pid()
+	if pid == 0 {
+		return
+	}
+
+	if err := setProcessLimits(pid, proc.settings); err != nil {
+		p.Logger().Warn("Failed to apply process limits", "pid", pid, "error", err)
+	}
+}
+
+// process returns the process tracking of a plugin, created with its settings on first use.
+func (m *manager) process(pluginID string) *pluginProcess {
+	m.processesMu.Lock()
+	defer m.processesMu.Unlock()
+
+	proc, exists := m.processes[pluginID]
+	if !exists {
+		proc = &pluginProcess{settings: m.processSettings[pluginID]}
+		m.processes[pluginID] = proc
+	}
+	return proc
+}
+
+func (m *manager) startPluginAndRestartKilledProcesses(ctx context.Context, p Plugin) error {
+	proc := m.process(p.PluginID())
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+
+	if err := m.startPlugin(ctx, p); err != nil {
+		return err
+	}
+	proc.started()
+
+	go func(ctx context.Context, p Plugin) {
+		if err := m.restartKilledProcess(ctx, p); err != nil {
+			p.Logger().Error("Attempt to restart killed plugin process failed", "error", err)
+		}
+	}(ctx, p)
+
+	return nil
+}
+
+func (m *manager) restartKilledProcess(ctx context.Context, p Plugin) error {
+	ticker := time.NewTicker(time.Second * 1)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := ctx.Err(); err != nil && !errors.Is(err, context.Canceled) {
+				return err
+			}
+			return nil
+		case <-ticker.C:
+			if !m.restartIfExited(ctx, p, time.Now()) {
+				return nil
+			}
+		}
+	}
+}
+
+// restartIfExited restarts the process of a plugin if it exited, as long as it hasn't reached
+// the maximum number of restart attempts and the backoff since the last attempt has passed.
+// It returns false once the plugin is unregistered.
+func (m *manager) restartIfExited(ctx context.Context, p Plugin, now time.Time) bool {
+	proc := m.process(p.PluginID())
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+
+	if proc.unregistered {
+		return false
+	}
+
+	// the plugins are stopped on shutdown, they aren't restarted
+	if ctx.Err() != nil {
+		return true
+	}
+
+	if !p.Exited() {
+		if proc.restarts > 0 && now.Sub(proc.startedAt) >= restartResetInterval {
+			proc.restarts = 0
+		}
+		return true
+	}
+
+	if proc.settings.maxRestarts > 0 && proc.restarts >= proc.settings.maxRestarts {
+		if !proc.gaveUp {
+			p.Logger().Error("Plugin process reached the maximum number of restarts, giving up", "restarts", proc.restarts)
+			proc.gaveUp = true
+		}
+		return true
+	}
+
+	if now.Before(proc.nextRestart) {
+		return true
+	}
+
+	proc.restarts++
+	proc.nextRestart = now.Add(proc.backoff(proc.restarts))
+	p.Logger().Debug("Restarting plugin", "attempt", proc.restarts)
+	if err := m.startPlugin(ctx, p); err != nil {
+		p.Logger().Error("Failed to restart plugin", "attempt", proc.restarts, "error", err)
+		return true
+	}
+	proc.startedAt = now
+	p.Logger().Debug("Plugin restarted")
+	return true
+}
+
+// RestartPlugin stops and starts the process of a backend plugin, e.g. when it's wedged.
+func (m *manager) RestartPlugin(ctx context.Context, pluginID string) error {
+	m.pluginsMu.RLock()
+	p, registered := m.plugins[pluginID]
+	m.pluginsMu.RUnlock()
+	if !registered {
+		return ErrPluginNotRegistered
+	}
+
+	proc := m.process(pluginID)
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+
+	p.Logger().Info("Restarting plugin")
+	if err := p.Stop(ctx); err != nil {
+		return err
+	}
+
+	if err := m.startPlugin(ctx, p); err != nil {
+		return err
+	}
+	proc.started()
+
+	return nil
+}
+
+// UnregisterPlugin stops a backend plugin and removes it, so that it isn't restarted and its
+// files can be removed.
+func (m *manager) UnregisterPlugin(ctx context.Context, pluginID string) error {
+	m.pluginsMu.Lock()
+	p, registered := m.plugins[pluginID]
+	delete(m.plugins, pluginID)
+	m.pluginsMu.Unlock()
+	if !registered {
+		return ErrPluginNotRegistered
+	}
+
+	// the process tracking is kept so that the restart loop of the plugin sees it's unregistered
+	proc := m.process(pluginID)
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+	proc.unregistered = true
+
+	m.collectedMu.Lock()
+	delete(m.metrics, pluginID)
+	m.collectedMu.Unlock()
+
+	p.Logger().Info("Unregistering plugin")
+	return p.Stop(ctx)
+}
+
+// ExitedPlugins returns the ids of the started backend plugins whose process exited, whether
+// it's about to be restarted or not.
+func (m *manager) ExitedPlugins() []string {
+	m.processesMu.Lock()
+	pluginIDs := make([]string, 0, len(m.processes))
+	for pluginID := range m.processes {
+		pluginIDs = append(pluginIDs, pluginID)
+	}
+	m.processesMu.Unlock()
+
+	m.pluginsMu.RLock()
+	defer m.pluginsMu.RUnlock()
+	exited := []string{}
+	for _, pluginID := range pluginIDs {
+		if p, registered := m.plugins[pluginID]; registered && p.Exited() {
+			exited = append(exited, pluginID)
+		}
+	}
+	sort.Strings(exited)
+	return exited
+}
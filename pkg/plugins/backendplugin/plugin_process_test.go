@@ -0,0 +1,114 @@
+package backendplugin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessSettings(t *testing.T) {
+	t.Run("Should parse process settings", func(t *testing.T) {
+		ps, err := parseProcessSettings(map[string]string{
+			"max_restarts":    "3",
+			"restart_backoff": "2s",
+			"memory_limit":    "512",
+			"cpu_limit":       "60",
+			"key1":            "value1",
+		})
+		require.NoError(t, err)
+		require.Equal(t, processSettings{
+			maxRestarts:    3,
+			restartBackoff: 2 * time.Second,
+			memoryLimit:    512 * 1024 * 1024,
+			cpuLimit:       60,
+		}, ps)
+	})
+
+	t.Run("Should fail on invalid process settings", func(t *testing.T) {
+		for key, value := range map[string]string{"max_restarts": "-1", "restart_backoff": "2", "memory_limit": "1GB", "cpu_limit": "-1"} {
+			_, err := parseProcessSettings(map[string]string{key: value})
+			require.Error(t, err, key)
+		}
+	})
+
+	t.Run("Should double the restart backoff up to a maximum", func(t *testing.T) {
+		proc := &pluginProcess{settings: processSettings{restartBackoff: time.Second}}
+		require.Equal(t, time.Second, proc.backoff(1))
+		require.Equal(t, 2*time.Second, proc.backoff(2))
+		require.Equal(t, 8*time.Second, proc.backoff(4))
+		require.Equal(t, maxRestartBackoff, proc.backoff(20))
+	})
+}
+
+func TestManagerRestartPolicy(t *testing.T) {
+	newManagerScenario(t, true, func(t *testing.T, ctx *managerScenarioCtx) {
+		ctx.manager.processSettings[testPluginID] = processSettings{maxRestarts: 2, restartBackoff: time.Minute}
+		err := ctx.manager.Register(testPluginID, ctx.factory)
+		require.NoError(t, err)
+
+		// restarts are triggered by the test rather than by the restart loop
+		pCtx := context.Background()
+		cCtx, cancel := context.WithCancel(pCtx)
+		err = ctx.manager.startPluginAndRestartKilledProcesses(cCtx, ctx.plugin)
+		cancel()
+		require.NoError(t, err)
+		require.Equal(t, 1, ctx.plugin.startCount)
+
+		now := time.Now()
+
+		t.Run("Should restart killed plugin process with backoff", func(t *testing.T) {
+			ctx.plugin.kill()
+			ctx.manager.restartIfExited(pCtx, ctx.plugin, now)
+			require.Equal(t, 2, ctx.plugin.startCount)
+
+			ctx.plugin.kill()
+			ctx.manager.restartIfExited(pCtx, ctx.plugin, now.Add(30*time.Second))
+			require.Equal(t, 2, ctx.plugin.startCount)
+
+			ctx.manager.restartIfExited(pCtx, ctx.plugin, now.Add(time.Minute))
+			require.Equal(t, 3, ctx.plugin.startCount)
+		})
+
+		t.Run("Should give up restarting after max restarts", func(t *testing.T) {
+			ctx.plugin.kill()
+			ctx.manager.restartIfExited(pCtx, ctx.plugin, now.Add(time.Hour))
+			require.Equal(t, 3, ctx.plugin.startCount)
+			require.True(t, ctx.plugin.Exited())
+			require.Equal(t, []string{testPluginID}, ctx.manager.ExitedPlugins())
+		})
+
+		t.Run("Should restart plugin on demand and reset restart attempts", func(t *testing.T) {
+			err := ctx.manager.RestartPlugin(pCtx, testPluginID)
+			require.NoError(t, err)
+			require.Equal(t, 1, ctx.plugin.stopCount)
+			require.Equal(t, 4, ctx.plugin.startCount)
+			require.False(t, ctx.plugin.Exited())
+			require.Empty(t, ctx.manager.ExitedPlugins())
+
+			ctx.plugin.kill()
+			ctx.manager.restartIfExited(pCtx, ctx.plugin, time.Now())
+			require.Equal(t, 5, ctx.plugin.startCount)
+		})
+
+		t.Run("Should not restart plugin that is not registered", func(t *testing.T) {
+			err := ctx.manager.RestartPlugin(pCtx, "not-registered")
+			require.Equal(t, ErrPluginNotRegistered, err)
+		})
+
+		t.Run("Should stop unregistered plugin and not restart it", func(t *testing.T) {
+			err := ctx.manager.UnregisterPlugin(pCtx, testPluginID)
+			require.NoError(t, err)
+			require.Equal(t, 2, ctx.plugin.stopCount)
+
+			ctx.plugin.kill()
+			require.False(t, ctx.manager.restartIfExited(pCtx, ctx.plugin, time.Now().Add(time.Hour)))
+			require.Equal(t, 5, ctx.plugin.startCount)
+			require.Empty(t, ctx.manager.ExitedPlugins())
+
+			err = ctx.manager.UnregisterPlugin(pCtx, testPluginID)
+			require.Equal(t, ErrPluginNotRegistered, err)
+		})
+	})
+}
@@ -25,7 +25,7 @@ func extractPluginSettings(cfg *setting.Cfg) map[string]pluginSettings {
 	for pluginID, settings := range cfg.PluginSettings {
 		ps := pluginSettings{}
 		for k, v := range settings {
-			if k == "path" || strings.ToLower(k) == "id" {
+			if k == "path" || strings.ToLower(k) == "id" || isProcessSetting(k) {
 				continue
 			}
 
@@ -37,3 +37,17 @@ func extractPluginSettings(cfg *setting.Cfg) map[string]pluginSettings {
 
 	return psMap
 }
+
+func extractProcessSettings(cfg *setting.Cfg) (map[string]processSettings, error) {
+	psMap := map[string]processSettings{}
+	for pluginID, settings := range cfg.PluginSettings {
+		ps, err := parseProcessSettings(settings)
+		if err != nil {
+			return nil, fmt.Errorf("plugin.%s: %w", pluginID, err)
+		}
+
+		psMap[pluginID] = ps
+	}
+
+	return psMap, nil
+}
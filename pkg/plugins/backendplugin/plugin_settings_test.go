@@ -35,6 +35,13 @@ func TestPluginSettings(t *testing.T) {
 			require.Len(t, ps["plugin"], 2)
 		})
 
+		t.Run("Should skip process settings", func(t *testing.T) {
+			cfg.PluginSettings["plugin"]["max_restarts"] = "3"
+			cfg.PluginSettings["plugin"]["memory_limit"] = "512"
+			ps := extractPluginSettings(cfg)
+			require.Len(t, ps["plugin"], 2)
+		})
+
 		t.Run("Should return expected environment variables from plugin settings ", func(t *testing.T) {
 			ps := extractPluginSettings(cfg)
 			env := ps["plugin"].ToEnv("GF_PLUGIN", []string{"GF_VERSION=6.7.0"})
@@ -0,0 +1,13 @@
+// +build !linux
+
+package backendplugin
+
+import "errors"
+
+// errProcessLimitsUnsupported error returned when process limits cannot be applied on the OS.
+var errProcessLimitsUnsupported = errors.New("process limits are not supported on this platform")
+
+// setProcessLimits isn't supported on other platforms than Linux.
+func setProcessLimits(pid int, ps processSettings) error {
+	return errProcessLimitsUnsupported
+}
@@ -0,0 +1,31 @@
+package backendplugin
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// setProcessLimits sets the resource limits of a running process with prlimit.
+func setProcessLimits(pid int, ps processSettings) error {
+	if ps.memoryLimit > 0 {
+		if err := prlimit(pid, syscall.RLIMIT_AS, &syscall.Rlimit{Cur: ps.memoryLimit, Max: ps.memoryLimit}); err != nil {
+			return err
+		}
+	}
+
+	if ps.cpuLimit > 0 {
+		if err := prlimit(pid, syscall.RLIMIT_CPU, &syscall.Rlimit{Cur: ps.cpuLimit, Max: ps.cpuLimit}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func prlimit(pid int, resource int, limit *syscall.Rlimit) error {
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), uintptr(resource), uintptr(unsafe.Pointer(limit)), 0, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package backendplugin
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// SubscribeStreamStatus is the answer of a plugin to a stream subscription.
+type SubscribeStreamStatus int
+
+const (
+	// SubscribeStreamStatusOK the user can subscribe to the stream.
+	SubscribeStreamStatusOK SubscribeStreamStatus = iota
+	// SubscribeStreamStatusNotFound the plugin doesn't have the stream.
+	SubscribeStreamStatusNotFound
+	// SubscribeStreamStatusPermissionDenied the user can't subscribe to the stream.
+	SubscribeStreamStatusPermissionDenied
+)
+
+// SubscribeStreamRequest is a request to subscribe to a stream of a plugin.
+type SubscribeStreamRequest struct {
+	PluginContext backend.PluginContext
+	Path          string
+}
+
+// SubscribeStreamResponse is the response to a SubscribeStreamRequest.
+type SubscribeStreamResponse struct {
+	Status SubscribeStreamStatus
+}
+
+// RunStreamRequest is a request to run a stream of a plugin.
+type RunStreamRequest struct {
+	PluginContext backend.PluginContext
+	Path          string
+}
+
+// StreamPacket is a message sent by a plugin to the subscribers of a stream, usually
+// a JSON encoded data frame.
+type StreamPacket struct {
+	Data json.RawMessage
+}
+
+// StreamPacketSender is used for sending stream packets.
+type StreamPacketSender interface {
+	Send(*StreamPacket) error
+}
+
+// StreamPacketSenderFunc is an adapter to allow the use of
+// ordinary functions as StreamPacketSender.
+type StreamPacketSenderFunc func(packet *StreamPacket) error
+
+// Send calls fn(packet).
+func (fn StreamPacketSenderFunc) Send(packet *StreamPacket) error {
+	return fn(packet)
+}
+
+// StreamHandler is implemented by backend plugins that stream data.
+// SubscribeStream is called for every subscriber of a stream, and RunStream
+// runs a stream for as long as it has subscribers.
+type StreamHandler interface {
+	SubscribeStream(ctx context.Context, req *SubscribeStreamRequest) (*SubscribeStreamResponse, error)
+	RunStream(ctx context.Context, req *RunStreamRequest, sender StreamPacketSender) error
+}
@@ -28,6 +28,11 @@ func (tw *DatasourcePluginWrapper) Query(ctx context.Context, ds *models.DataSou
 		return nil, err
 	}
 
+	decryptedValues, err := ds.DecryptedValues()
+	if err != nil {
+		return nil, err
+	}
+
 	pbQuery := &datasource.DatasourceRequest{
 		Datasource: &datasource.DatasourceInfo{
 			Name:                    ds.Name,
@@ -36,7 +41,7 @@ func (tw *DatasourcePluginWrapper) Query(ctx context.Context, ds *models.DataSou
 			Id:                      ds.Id,
 			OrgId:                   ds.OrgId,
 			JsonData:                string(jsonData),
-			DecryptedSecureJsonData: ds.SecureJsonData.Decrypt(),
+			DecryptedSecureJsonData: decryptedValues,
 		},
 		TimeRange: &datasource.TimeRange{
 			FromRaw:     query.TimeRange.From,
@@ -31,6 +31,11 @@ func ModelToInstanceSettings(ds *models.DataSource) (*backend.DataSourceInstance
 		return nil, err
 	}
 
+	decryptedValues, err := ds.DecryptedValues()
+	if err != nil {
+		return nil, err
+	}
+
 	return &backend.DataSourceInstanceSettings{
 		ID:                      ds.Id,
 		Name:                    ds.Name,
@@ -40,7 +45,7 @@ func ModelToInstanceSettings(ds *models.DataSource) (*backend.DataSourceInstance
 		BasicAuthEnabled:        ds.BasicAuth,
 		BasicAuthUser:           ds.BasicAuthUser,
 		JSONData:                jsonDataBytes,
-		DecryptedSecureJSONData: ds.DecryptedValues(),
+		DecryptedSecureJSONData: decryptedValues,
 		Updated:                 ds.Updated,
 	}, nil
 }
@@ -115,7 +120,7 @@ func BackendUserFromSignedInUser(su *models.SignedInUser) *backend.User {
 	return &backend.User{
 		Login: su.Login,
 		Name:  su.Name,
-		Email: su.Name,
+		Email: su.Email,
 		Role:  string(su.OrgRole),
 	}
 }
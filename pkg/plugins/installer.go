@@ -0,0 +1,516 @@
+package plugins
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var (
+	ErrPluginAlreadyInstalled   = errors.New("Plugin is already installed")
+	ErrPluginNotInstalled       = errors.New("Plugin is not installed")
+	ErrPluginNotManaged         = errors.New("Plugin is not installed in the plugins directory and cannot be managed")
+	ErrPluginVersionNotFound    = errors.New("Plugin version not found")
+	ErrPluginVersionUnsupported = errors.New("Plugin version is not supported on this OS and architecture")
+	ErrInvalidPluginId          = errors.New("Invalid plugin id")
+
+	installHttpClient = http.Client{Timeout: 5 * time.Minute}
+	pluginIdRegex     = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+)
+
+// InstallResult is the outcome of installing, updating or uninstalling a plugin. Front-end plugins
+// are loaded and unloaded right away, while the changes to back-end plugins and to apps with proxy
+// routes take effect once Grafana is restarted.
+type InstallResult struct {
+	PluginId        string `json:"pluginId"`
+	Version         string `json:"version,omitempty"`
+	RestartRequired bool   `json:"restartRequired"`
+}
+
+type repoPlugin struct {
+	Id       string        `json:"slug"`
+	Versions []repoVersion `json:"versions"`
+}
+
+type repoVersion struct {
+	Version string `json:"version"`
+	// os-arch to md5 checksum to check when downloading the archive
+	Arch map[string]struct {
+		Md5 string `json:"md5"`
+	} `json:"arch"`
+}
+
+// InstallPlugin downloads a plugin from the grafana.com catalog and installs it in the plugins
+// directory. The latest version supported on this OS and architecture is installed when no
+// version is given.
+func (pm *PluginManager) InstallPlugin(pluginID, version string) (*InstallResult, error) {
+	pm.installMutex.Lock()
+	defer pm.installMutex.Unlock()
+
+	if isInstalled(pluginID) {
+		return nil, ErrPluginAlreadyInstalled
+	}
+
+	return pm.install(pluginID, version)
+}
+
+// UpdatePlugin replaces an installed plugin by another version from the grafana.com catalog
+func (pm *PluginManager) UpdatePlugin(pluginID, version string) (*InstallResult, error) {
+	pm.installMutex.Lock()
+	defer pm.installMutex.Unlock()
+
+	if _, err := managedPlugin(pluginID); err != nil {
+		return nil, err
+	}
+
+	return pm.install(pluginID, version)
+}
+
+// UninstallPlugin removes a plugin installed in the plugins directory. A running back-end plugin is
+// stopped first and stays registered until Grafana is restarted, front-end plugins are unloaded.
+func (pm *PluginManager) UninstallPlugin(ctx context.Context, pluginID string) (*InstallResult, error) {
+	pm.installMutex.Lock()
+	defer pm.installMutex.Unlock()
+
+	plugin, err := managedPlugin(pluginID)
+	if err != nil {
+		return nil, err
+	}
+
+	restartRequired := requiresRestart(plugin.PluginDir)
+
+	if plugin.Backend {
+		err := pm.BackendPluginManager.UnregisterPlugin(ctx, pluginID)
+		if err != nil && !errors.Is(err, backendplugin.ErrPluginNotRegistered) {
+			return nil, errutil.Wrap("failed to stop plugin", err)
+		}
+	}
+
+	pm.log.Info("Uninstalling plugin", "pluginId", pluginID, "pluginDir", plugin.PluginDir)
+	if err := os.RemoveAll(plugin.PluginDir); err != nil {
+		return nil, errutil.Wrap("failed to remove plugin directory", err)
+	}
+
+	if !restartRequired {
+		registryMutex.Lock()
+		unregisterPlugins(plugin.PluginDir)
+		registryMutex.Unlock()
+	}
+
+	return &InstallResult{PluginId: pluginID, RestartRequired: restartRequired}, nil
+}
+
+func (pm *PluginManager) install(pluginID, version string) (*InstallResult, error) {
+	if !pluginIdRegex.MatchString(pluginID) {
+		return nil, ErrInvalidPluginId
+	}
+
+	repoURL := strings.TrimSuffix(setting.GrafanaComUrl, "/") + "/api/plugins"
+	plugin, err := getRepoPlugin(repoURL, pluginID)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := selectRepoVersion(plugin, version)
+	if err != nil {
+		return nil, err
+	}
+
+	var checksum string
+	if arch, ok := v.Arch[osAndArchString()]; ok {
+		checksum = arch.Md5
+	}
+
+	downloadURL := fmt.Sprintf("%s/%s/versions/%s/download", repoURL, pluginID, v.Version)
+	pm.log.Info("Installing plugin", "pluginId", pluginID, "version", v.Version, "url", downloadURL)
+
+	archive, err := ioutil.TempFile("", "*.zip")
+	if err != nil {
+		return nil, errutil.Wrap("failed to create temporary file", err)
+	}
+	defer os.Remove(archive.Name())
+
+	err = downloadArchive(archive, downloadURL, checksum)
+	if closeErr := archive.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, errutil.Wrap("failed to download plugin archive", err)
+	}
+
+	if err := os.MkdirAll(setting.PluginsPath, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	// the plugin is extracted next to the plugins directory so that a failed install doesn't leave
+	// a broken plugin behind
+	staging, err := ioutil.TempDir(filepath.Dir(setting.PluginsPath), ".plugin-install-")
+	if err != nil {
+		return nil, errutil.Wrap("failed to create staging directory", err)
+	}
+	defer os.RemoveAll(staging)
+
+	if err := extractArchive(archive.Name(), staging); err != nil {
+		return nil, errutil.Wrap("failed to extract plugin archive", err)
+	}
+	if err := markExecutables(staging); err != nil {
+		return nil, errutil.Wrap("failed to make plugin executable", err)
+	}
+
+	pluginBase, err := readPluginBase(staging)
+	if err != nil {
+		return nil, err
+	}
+	if pluginBase.Id != pluginID {
+		return nil, fmt.Errorf("plugin archive contains plugin %q instead of %q", pluginBase.Id, pluginID)
+	}
+
+	scanner := pm.newScanner(setting.PluginsPath, true)
+	if err := scanner.validateSignature(pluginBase); err != nil {
+		return nil, err
+	}
+
+	pluginDir := filepath.Join(setting.PluginsPath, pluginID)
+	if existing, exists := GetPlugin(pluginID); exists {
+		pluginDir = existing.PluginDir
+	}
+
+	// both the previous version, which may be running, and the new one have to be front-end only to
+	// be swapped without a restart
+	restartRequired := requiresRestart(staging)
+	if _, err := os.Stat(pluginDir); err == nil && !restartRequired {
+		restartRequired = requiresRestart(pluginDir)
+	}
+
+	if err := os.RemoveAll(pluginDir); err != nil {
+		return nil, errutil.Wrap("failed to remove previous plugin version", err)
+	}
+	if err := os.Rename(staging, pluginDir); err != nil {
+		return nil, errutil.Wrap("failed to move plugin into the plugins directory", err)
+	}
+
+	for _, dep := range pluginBase.Dependencies.Plugins {
+		if isInstalled(dep.Id) {
+			continue
+		}
+
+		depResult, err := pm.install(dep.Id, "")
+		if err != nil {
+			return nil, errutil.Wrapf(err, "failed to install dependency '%s'", dep.Id)
+		}
+		restartRequired = restartRequired || depResult.RestartRequired
+	}
+
+	if restartRequired {
+		pm.log.Info("Plugin installed, restart Grafana to load it", "pluginId", pluginID, "version", v.Version)
+		return &InstallResult{PluginId: pluginID, Version: v.Version, RestartRequired: true}, nil
+	}
+
+	if err := pm.registerPlugins(pluginDir); err != nil {
+		return nil, errutil.Wrap("failed to load plugin", err)
+	}
+
+	pm.log.Info("Plugin installed", "pluginId", pluginID, "version", v.Version)
+	return &InstallResult{PluginId: pluginID, Version: v.Version}, nil
+}
+
+// requiresRestart checks if the plugins in a directory can only be loaded or unloaded by restarting
+// Grafana, since back-end plugins are started and the proxy routes of apps are mapped on startup
+func requiresRestart(pluginDir string) bool {
+	restartRequired := false
+	err := filepath.Walk(pluginDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || info.Name() != "plugin.json" {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var plugin struct {
+			Type    string            `json:"type"`
+			Backend bool              `json:"backend"`
+			Routes  []json.RawMessage `json:"routes"`
+		}
+		if err := json.Unmarshal(data, &plugin); err != nil {
+			return err
+		}
+
+		if plugin.Backend || plugin.Type == "renderer" || plugin.Type == "transform" ||
+			(plugin.Type == PluginTypeApp && len(plugin.Routes) > 0) {
+			restartRequired = true
+		}
+		return nil
+	})
+	return restartRequired || err != nil
+}
+
+// isInstalled checks if a plugin is bundled with Grafana or is in the plugins directory, including
+// the plugins installed or uninstalled since startup
+func isInstalled(pluginID string) bool {
+	pluginDir := filepath.Join(setting.PluginsPath, pluginID)
+	if plugin, exists := GetPlugin(pluginID); exists {
+		if plugin.IsCorePlugin || !isInDirectory(plugin.PluginDir, setting.PluginsPath) {
+			return true
+		}
+		pluginDir = plugin.PluginDir
+	}
+
+	_, err := os.Stat(filepath.Join(pluginDir, "plugin.json"))
+	return err == nil
+}
+
+// managedPlugin returns an installed plugin if it's in the plugins directory. The plugins installed
+// since startup are read from their directory, the ones uninstalled since startup aren't returned.
+func managedPlugin(pluginID string) (*PluginBase, error) {
+	plugin, exists := GetPlugin(pluginID)
+	if !exists {
+		installed, err := readPluginBase(filepath.Join(setting.PluginsPath, pluginID))
+		if err != nil || installed.Id != pluginID {
+			return nil, ErrPluginNotInstalled
+		}
+		return installed, nil
+	}
+
+	if plugin.IsCorePlugin || plugin.IncludedInAppId != "" || !isInDirectory(plugin.PluginDir, setting.PluginsPath) {
+		return nil, ErrPluginNotManaged
+	}
+
+	if _, err := os.Stat(plugin.PluginDir); os.IsNotExist(err) {
+		return nil, ErrPluginNotInstalled
+	}
+
+	return plugin, nil
+}
+
+func getRepoPlugin(repoURL, pluginID string) (*repoPlugin, error) {
+	resp, err := installHttpClient.Get(repoURL + "/" + pluginID)
+	if err != nil {
+		return nil, errutil.Wrap("failed to get plugin from the catalog", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, PluginNotFoundError{pluginID}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get plugin from the catalog, status code %d", resp.StatusCode)
+	}
+
+	plugin := &repoPlugin{}
+	if err := json.NewDecoder(resp.Body).Decode(plugin); err != nil {
+		return nil, errutil.Wrap("failed to parse plugin from the catalog", err)
+	}
+
+	return plugin, nil
+}
+
+func osAndArchString() string {
+	return strings.ToLower(runtime.GOOS) + "-" + runtime.GOARCH
+}
+
+func supportsCurrentArch(version repoVersion) bool {
+	if version.Arch == nil {
+		return true
+	}
+
+	for arch := range version.Arch {
+		if arch == osAndArchString() || arch == "any" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selectRepoVersion returns the given version, or the latest version supported on this OS and
+// architecture. The catalog lists the newest version first.
+func selectRepoVersion(plugin *repoPlugin, version string) (*repoVersion, error) {
+	for _, v := range plugin.Versions {
+		if version != "" && v.Version != version {
+			continue
+		}
+
+		if supportsCurrentArch(v) {
+			return &v, nil
+		}
+
+		if version != "" {
+			return nil, ErrPluginVersionUnsupported
+		}
+	}
+
+	if version == "" {
+		return nil, ErrPluginVersionUnsupported
+	}
+
+	return nil, ErrPluginVersionNotFound
+}
+
+func downloadArchive(w io.Writer, url, checksum string) error {
+	resp, err := installHttpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status code %d", resp.StatusCode)
+	}
+
+	h := md5.New()
+	if _, err := io.Copy(w, io.TeeReader(resp.Body, h)); err != nil {
+		return err
+	}
+
+	if checksum != "" && checksum != fmt.Sprintf("%x", h.Sum(nil)) {
+		return errors.New("expected MD5 checksum does not match the downloaded archive")
+	}
+
+	return nil
+}
+
+// extractArchive extracts a plugin archive into dest. Archives contain a single top directory, which is
+// replaced by dest. Symlinks are skipped.
+func extractArchive(archive, dest string) error {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, zf := range r.File {
+		parts := strings.SplitN(filepath.ToSlash(zf.Name), "/", 2)
+		if len(parts) < 2 || parts[1] == "" {
+			continue
+		}
+
+		target := filepath.Join(dest, parts[1])
+		if !isInDirectory(target, dest) {
+			return fmt.Errorf("file %q tries to write outside of the plugin directory", zf.Name)
+		}
+
+		if zf.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if zf.Mode()&os.ModeSymlink != 0 {
+			continue
+		}
+
+		if err := extractArchiveFile(zf, target); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func extractArchiveFile(zf *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	src, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(target, os.O_RDWR|os.O_CREATE|os.O_TRUNC, zf.Mode())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
+
+// markExecutables makes the executables of the back-end plugins in dir executable, since archives
+// don't always keep the file modes. They're named after the executable property of plugin.json,
+// followed by the OS and architecture, e.g. gpx_plugin_linux_arm64.
+func markExecutables(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || info.Name() != "plugin.json" {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var plugin struct {
+			Executable string `json:"executable"`
+		}
+		// invalid plugin.json files are reported when the plugin is read
+		if err := json.Unmarshal(data, &plugin); err != nil || plugin.Executable == "" {
+			return nil
+		}
+
+		executable := filepath.Join(filepath.Dir(path), plugin.Executable)
+		if !isInDirectory(executable, dir) {
+			return fmt.Errorf("executable %q is outside of the plugin directory", plugin.Executable)
+		}
+
+		files, err := ioutil.ReadDir(filepath.Dir(executable))
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if f.Mode().IsRegular() && strings.HasPrefix(f.Name(), filepath.Base(executable)+"_") {
+				if err := os.Chmod(filepath.Join(filepath.Dir(executable), f.Name()), 0755); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func readPluginBase(pluginDir string) (*PluginBase, error) {
+	data, err := ioutil.ReadFile(filepath.Join(pluginDir, "plugin.json"))
+	if err != nil {
+		return nil, errutil.Wrap("plugin archive has no plugin.json", err)
+	}
+
+	plugin := &PluginBase{}
+	if err := json.Unmarshal(data, plugin); err != nil {
+		return nil, errutil.Wrap("failed to parse plugin.json", err)
+	}
+
+	if plugin.Id == "" || plugin.Type == "" {
+		return nil, errors.New("did not find type or id properties in plugin.json")
+	}
+
+	plugin.PluginDir = pluginDir
+	return plugin, nil
+}
+
+// isInDirectory checks that path doesn't resolve outside of dir
+func isInDirectory(path, dir string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
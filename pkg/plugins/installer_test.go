@@ -0,0 +1,263 @@
+package plugins
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginManager_InstallPlugin(t *testing.T) {
+	origComUrl := setting.GrafanaComUrl
+	origPluginsPath := setting.PluginsPath
+	origEnv := setting.Env
+	origRootPath := setting.StaticRootPath
+	t.Cleanup(func() {
+		setting.GrafanaComUrl = origComUrl
+		setting.PluginsPath = origPluginsPath
+		setting.Env = origEnv
+		setting.StaticRootPath = origRootPath
+	})
+
+	dir, err := ioutil.TempDir("", "plugins")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	archives := map[string][]byte{
+		"test-panel/1.0.0": pluginArchive(t, `{"type": "panel", "id": "test-panel", "name": "Test", "info": {"version": "1.0.0"}}`),
+		"test-panel/2.0.0": pluginArchive(t, `{"type": "panel", "id": "test-panel", "name": "Test 2", "info": {"version": "2.0.0"}}`),
+		"test-backend/1.0.0": pluginArchive(t,
+			`{"type": "datasource", "id": "test-backend", "name": "Backend", "backend": true, "info": {"version": "1.0.0"}}`),
+		"test-app/1.0.0": pluginArchive(t,
+			`{"type": "app", "id": "test-app", "name": "App", "routes": [{"path": "api", "url": "https://example.com"}], "info": {"version": "1.0.0"}}`),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// /api/plugins/<id> or /api/plugins/<id>/versions/<version>/download
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/plugins/"), "/")
+		id := parts[0]
+		if len(parts) == 4 {
+			archive, exists := archives[id+"/"+parts[2]]
+			if !exists {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, err := w.Write(archive)
+			require.NoError(t, err)
+			return
+		}
+
+		plugin := repoPlugin{Id: id}
+		for _, v := range []string{"2.0.0", "1.0.0"} {
+			archive, exists := archives[id+"/"+v]
+			if !exists {
+				continue
+			}
+			version := repoVersion{Version: v}
+			version.Arch = map[string]struct {
+				Md5 string `json:"md5"`
+			}{osAndArchString(): {Md5: fmt.Sprintf("%x", md5.Sum(archive))}}
+			plugin.Versions = append(plugin.Versions, version)
+		}
+		if len(plugin.Versions) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		require.NoError(t, json.NewEncoder(w).Encode(plugin))
+	}))
+	t.Cleanup(server.Close)
+
+	setting.GrafanaComUrl = server.URL
+	setting.PluginsPath = filepath.Join(dir, "plugins")
+	setting.Env = setting.PROD
+	setting.StaticRootPath = filepath.Join(dir, "public")
+
+	Plugins = map[string]*PluginBase{}
+	Panels = map[string]*PanelPlugin{}
+	DataSources = map[string]*DataSourcePlugin{}
+	Apps = map[string]*AppPlugin{}
+	StaticRoutes = []*PluginStaticRoute{}
+	plog = log.New("plugins")
+
+	fm := &fakeBackendPluginManager{}
+	pm := &PluginManager{
+		Cfg:                  &setting.Cfg{},
+		BackendPluginManager: fm,
+		log:                  log.New("plugins"),
+	}
+
+	t.Run("Installs the given version and loads front-end plugins right away", func(t *testing.T) {
+		result, err := pm.InstallPlugin("test-panel", "1.0.0")
+		require.NoError(t, err)
+		assert.Equal(t, &InstallResult{PluginId: "test-panel", Version: "1.0.0"}, result)
+
+		assert.FileExists(t, filepath.Join(setting.PluginsPath, "test-panel", "plugin.json"))
+		panel, exists := GetPanel("test-panel")
+		require.True(t, exists)
+		assert.Equal(t, "1.0.0", panel.Info.Version)
+		assert.Equal(t, "plugins/test-panel/module", panel.Module)
+
+		route, exists := GetStaticRoute("test-panel")
+		require.True(t, exists)
+		assert.Equal(t, filepath.Join(setting.PluginsPath, "test-panel"), route.Directory)
+
+		_, err = pm.InstallPlugin("test-panel", "")
+		assert.Equal(t, ErrPluginAlreadyInstalled, err)
+	})
+
+	t.Run("Updates to the latest version", func(t *testing.T) {
+		result, err := pm.UpdatePlugin("test-panel", "")
+		require.NoError(t, err)
+		assert.Equal(t, "2.0.0", result.Version)
+		assert.False(t, result.RestartRequired)
+
+		panel, exists := GetPanel("test-panel")
+		require.True(t, exists)
+		assert.Equal(t, "Test 2", panel.Name)
+		assert.Len(t, StaticRoutes, 1)
+	})
+
+	t.Run("Uninstalls plugins", func(t *testing.T) {
+		result, err := pm.UninstallPlugin(context.Background(), "test-panel")
+		require.NoError(t, err)
+		assert.False(t, result.RestartRequired)
+		assert.NoDirExists(t, filepath.Join(setting.PluginsPath, "test-panel"))
+		assert.Empty(t, fm.unregisteredPlugins)
+		assert.Empty(t, Plugins)
+		assert.Empty(t, Panels)
+		assert.Empty(t, StaticRoutes)
+
+		_, err = pm.UninstallPlugin(context.Background(), "test-panel")
+		assert.Equal(t, ErrPluginNotInstalled, err)
+	})
+
+	t.Run("Requires a restart for apps with proxy routes", func(t *testing.T) {
+		result, err := pm.InstallPlugin("test-app", "")
+		require.NoError(t, err)
+		assert.True(t, result.RestartRequired)
+		assert.NotContains(t, Apps, "test-app")
+
+		result, err = pm.UninstallPlugin(context.Background(), "test-app")
+		require.NoError(t, err)
+		assert.True(t, result.RestartRequired)
+	})
+
+	t.Run("Refuses unsigned back-end plugins", func(t *testing.T) {
+		_, err := pm.InstallPlugin("test-backend", "")
+		require.EqualError(t, err, `plugin "test-backend" is unsigned`)
+		assert.NoDirExists(t, filepath.Join(setting.PluginsPath, "test-backend"))
+	})
+
+	t.Run("Stops back-end plugins before uninstalling them", func(t *testing.T) {
+		pm.Cfg.PluginsAllowUnsigned = []string{"test-backend"}
+
+		result, err := pm.InstallPlugin("test-backend", "")
+		require.NoError(t, err)
+		assert.True(t, result.RestartRequired)
+		assert.NotContains(t, DataSources, "test-backend")
+
+		_, err = pm.UninstallPlugin(context.Background(), "test-backend")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"test-backend"}, fm.unregisteredPlugins)
+		assert.NoDirExists(t, filepath.Join(setting.PluginsPath, "test-backend"))
+	})
+
+	t.Run("Returns an error for unknown plugins and versions", func(t *testing.T) {
+		_, err := pm.InstallPlugin("unknown", "")
+		assert.Equal(t, PluginNotFoundError{"unknown"}, err)
+
+		_, err = pm.InstallPlugin("test-panel", "1.0.0")
+		require.NoError(t, err)
+		_, err = pm.UpdatePlugin("test-panel", "3.0.0")
+		assert.Equal(t, ErrPluginVersionNotFound, err)
+
+		_, err = pm.InstallPlugin("../test-panel", "")
+		assert.Equal(t, ErrInvalidPluginId, err)
+	})
+}
+
+func TestExtractArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plugin")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	archive := filepath.Join(dir, "plugin.zip")
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	f, err := zw.Create("plugin/../../evil.txt")
+	require.NoError(t, err)
+	_, err = f.Write([]byte("evil"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, ioutil.WriteFile(archive, buf.Bytes(), 0600))
+
+	err = extractArchive(archive, filepath.Join(dir, "plugin"))
+	require.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(dir), "evil.txt"))
+}
+
+func TestMarkExecutables(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plugin")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	files := map[string]string{
+		"plugin.json":             `{"type": "datasource", "id": "test", "backend": true, "executable": "gpx_test"}`,
+		"gpx_test_linux_arm64":    "",
+		"gpx_test_darwin_amd64":   "",
+		"gpx_testing_linux_amd64": "",
+		"module.js":               "",
+	}
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	require.NoError(t, markExecutables(dir))
+
+	for name := range files {
+		info, err := os.Stat(filepath.Join(dir, name))
+		require.NoError(t, err)
+		if strings.HasPrefix(name, "gpx_test_") {
+			assert.Equal(t, os.FileMode(0755), info.Mode().Perm(), name)
+		} else {
+			assert.Equal(t, os.FileMode(0644), info.Mode().Perm(), name)
+		}
+	}
+}
+
+// pluginArchive returns a zip archive with a plugin.json in a top directory, like the catalog archives
+func pluginArchive(t *testing.T, pluginJSON string) []byte {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+
+	for name, content := range map[string]string{"plugin.json": pluginJSON, "module.js": ""} {
+		f, err := zw.Create("dist/" + name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
@@ -34,6 +34,20 @@ const (
 	PluginSignatureUnsigned PluginSignature = "unsigned" // no MANIFEST file
 )
 
+type PluginErrorCode string
+
+const (
+	signatureMissing  PluginErrorCode = "signatureMissing"
+	signatureModified PluginErrorCode = "signatureModified"
+	signatureInvalid  PluginErrorCode = "signatureInvalid"
+)
+
+// PluginError is a plugin that failed to load because of its signature
+type PluginError struct {
+	ErrorCode PluginErrorCode `json:"errorCode"`
+	PluginId  string          `json:"pluginId,omitempty"`
+}
+
 type PluginNotFoundError struct {
 	PluginId string
 }
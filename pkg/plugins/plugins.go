@@ -10,7 +10,9 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/grafana/grafana/pkg/infra/fs"
@@ -42,6 +44,7 @@ var (
 type PluginScanner struct {
 	pluginPath           string
 	errors               []error
+	pluginErrors         []PluginError
 	backendPluginManager backendplugin.Manager
 	cfg                  *setting.Cfg
 	requireSigned        bool
@@ -53,6 +56,11 @@ type PluginManager struct {
 	Cfg                  *setting.Cfg          `inject:""`
 	log                  log.Logger
 	scanningErrors       []error
+	installMutex         sync.Mutex
+
+	// pluginErrors are the signature errors of the last scan of each plugin directory
+	pluginErrors      map[string][]PluginError
+	pluginErrorsMutex sync.RWMutex
 }
 
 func init() {
@@ -182,15 +190,20 @@ func (pm *PluginManager) checkPluginPaths() error {
 	return nil
 }
 
-// scan a directory for plugins.
-func (pm *PluginManager) scan(pluginDir string, requireSigned bool) error {
-	scanner := &PluginScanner{
+func (pm *PluginManager) newScanner(pluginDir string, requireSigned bool) *PluginScanner {
+	return &PluginScanner{
 		pluginPath:           pluginDir,
 		backendPluginManager: pm.BackendPluginManager,
 		cfg:                  pm.Cfg,
 		requireSigned:        requireSigned,
 		log:                  pm.log,
 	}
+}
+
+// scan a directory for plugins.
+func (pm *PluginManager) scan(pluginDir string, requireSigned bool) error {
+	pm.setPluginErrors(pluginDir, nil)
+	scanner := pm.newScanner(pluginDir, requireSigned)
 
 	if err := util.Walk(pluginDir, true, true, scanner.walker); err != nil {
 		if xerrors.Is(err, os.ErrNotExist) {
@@ -209,19 +222,53 @@ func (pm *PluginManager) scan(pluginDir string, requireSigned bool) error {
 
 	if len(scanner.errors) > 0 {
 		pm.log.Warn("Some plugins failed to load", "errors", scanner.errors)
-		pm.scanningErrors = scanner.errors
+		pm.scanningErrors = append(pm.scanningErrors, scanner.errors...)
 	}
+	pm.setPluginErrors(pluginDir, scanner.pluginErrors)
 
 	return nil
 }
 
+// setPluginErrors replaces the signature errors of a plugin directory
+func (pm *PluginManager) setPluginErrors(pluginDir string, pluginErrors []PluginError) {
+	pm.pluginErrorsMutex.Lock()
+	defer pm.pluginErrorsMutex.Unlock()
+
+	if pm.pluginErrors == nil {
+		pm.pluginErrors = map[string][]PluginError{}
+	}
+	if len(pluginErrors) == 0 {
+		delete(pm.pluginErrors, pluginDir)
+		return
+	}
+	pm.pluginErrors[pluginDir] = pluginErrors
+}
+
 // GetDatasource returns a datasource based on passed pluginID if it exists
 //
 // This function fetches the datasource from the global variable DataSources in this package.
 // Rather then refactor all dependencies on the global variable we can use this as an transition.
 func (pm *PluginManager) GetDatasource(pluginID string) (*DataSourcePlugin, bool) {
-	ds, exist := DataSources[pluginID]
-	return ds, exist
+	return GetDataSource(pluginID)
+}
+
+// ScanningErrors returns the plugins that failed to load because of their signature, ordered by
+// plugin directory. The result is a copy, which later scans don't change.
+func (pm *PluginManager) ScanningErrors() []PluginError {
+	pm.pluginErrorsMutex.RLock()
+	defer pm.pluginErrorsMutex.RUnlock()
+
+	dirs := make([]string, 0, len(pm.pluginErrors))
+	for dir := range pm.pluginErrors {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	result := make([]PluginError, 0)
+	for _, dir := range dirs {
+		result = append(result, pm.pluginErrors[dir]...)
+	}
+	return result
 }
 
 func (scanner *PluginScanner) walker(currentPath string, f os.FileInfo, err error) error {
@@ -250,6 +297,13 @@ func (scanner *PluginScanner) walker(currentPath string, f os.FileInfo, err erro
 	return nil
 }
 
+func (scanner *PluginScanner) addPluginError(code PluginErrorCode, plugin *PluginBase) {
+	scanner.pluginErrors = append(scanner.pluginErrors, PluginError{
+		ErrorCode: code,
+		PluginId:  plugin.Id,
+	})
+}
+
 func (scanner *PluginScanner) loadPlugin(pluginJsonFilePath string) error {
 	currentDir := filepath.Dir(pluginJsonFilePath)
 	reader, err := os.Open(pluginJsonFilePath)
@@ -281,35 +335,8 @@ func (scanner *PluginScanner) loadPlugin(pluginJsonFilePath string) error {
 
 	pluginCommon.PluginDir = filepath.Dir(pluginJsonFilePath)
 
-	// For the time being, we choose to only require back-end plugins to be signed
-	// NOTE: the state is calculated again when setting metadata on the object
-	if pluginCommon.Backend && scanner.requireSigned {
-		sig := getPluginSignatureState(scanner.log, &pluginCommon)
-		if sig != PluginSignatureValid {
-			scanner.log.Debug("Invalid Plugin Signature", "pluginID", pluginCommon.Id, "pluginDir", pluginCommon.PluginDir, "state", sig)
-			if sig == PluginSignatureUnsigned {
-				allowUnsigned := false
-				for _, plug := range scanner.cfg.PluginsAllowUnsigned {
-					if plug == pluginCommon.Id {
-						allowUnsigned = true
-						break
-					}
-				}
-				if setting.Env != setting.DEV && !allowUnsigned {
-					return fmt.Errorf("plugin %q is unsigned", pluginCommon.Id)
-				}
-				scanner.log.Warn("Running an unsigned backend plugin", "pluginID", pluginCommon.Id, "pluginDir", pluginCommon.PluginDir)
-			} else {
-				switch sig {
-				case PluginSignatureInvalid:
-					return fmt.Errorf("plugin %q has an invalid signature", pluginCommon.Id)
-				case PluginSignatureModified:
-					return fmt.Errorf("plugin %q's signature has been modified", pluginCommon.Id)
-				default:
-					return fmt.Errorf("unrecognized plugin signature state %v", sig)
-				}
-			}
-		}
+	if err := scanner.validateSignature(&pluginCommon); err != nil {
+		return err
 	}
 
 	pluginGoType, exists := PluginTypes[pluginCommon.Type]
@@ -340,12 +367,53 @@ func (scanner *PluginScanner) loadPlugin(pluginJsonFilePath string) error {
 	return loader.Load(jsonParser, currentDir, scanner.backendPluginManager)
 }
 
+// validateSignature returns an error if the plugin has to be signed and isn't validly signed.
+// For the time being, we choose to only require back-end plugins to be signed
+// NOTE: the state is calculated again when setting metadata on the object
+func (scanner *PluginScanner) validateSignature(plugin *PluginBase) error {
+	if !plugin.Backend || !scanner.requireSigned {
+		return nil
+	}
+
+	sig := getPluginSignatureState(scanner.log, plugin)
+	if sig != PluginSignatureValid {
+		scanner.log.Debug("Invalid Plugin Signature", "pluginID", plugin.Id, "pluginDir", plugin.PluginDir, "state", sig)
+		if sig == PluginSignatureUnsigned {
+			allowUnsigned := false
+			for _, plug := range scanner.cfg.PluginsAllowUnsigned {
+				if plug == plugin.Id {
+					allowUnsigned = true
+					break
+				}
+			}
+			if setting.Env != setting.DEV && !allowUnsigned {
+				scanner.addPluginError(signatureMissing, plugin)
+				return fmt.Errorf("plugin %q is unsigned", plugin.Id)
+			}
+			scanner.log.Warn("Running an unsigned backend plugin", "pluginID", plugin.Id, "pluginDir", plugin.PluginDir)
+		} else {
+			switch sig {
+			case PluginSignatureInvalid:
+				scanner.addPluginError(signatureInvalid, plugin)
+				return fmt.Errorf("plugin %q has an invalid signature", plugin.Id)
+			case PluginSignatureModified:
+				scanner.addPluginError(signatureModified, plugin)
+				return fmt.Errorf("plugin %q's signature has been modified", plugin.Id)
+			default:
+				return fmt.Errorf("unrecognized plugin signature state %v", sig)
+			}
+		}
+	}
+
+	return nil
+}
+
 func (scanner *PluginScanner) IsBackendOnlyPlugin(pluginType string) bool {
 	return pluginType == "renderer" || pluginType == "transform"
 }
 
 func GetPluginMarkdown(pluginId string, name string) ([]byte, error) {
-	plug, exists := Plugins[pluginId]
+	plug, exists := GetPlugin(pluginId)
 	if !exists {
 		return nil, PluginNotFoundError{pluginId}
 	}
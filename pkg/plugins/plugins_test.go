@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/grafana/grafana/pkg/setting"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/ini.v1"
@@ -67,6 +68,13 @@ func TestPluginManager_Init(t *testing.T) {
 		require.NoError(t, err)
 
 		assert.Equal(t, []error{fmt.Errorf(`plugin "test" is unsigned`)}, pm.scanningErrors)
+		pluginErrors := pm.ScanningErrors()
+		assert.Equal(t, []PluginError{{ErrorCode: signatureMissing, PluginId: "test"}}, pluginErrors)
+
+		// scanning the directory again replaces its errors, and doesn't change the returned ones
+		pluginErrors[0].PluginId = "changed"
+		require.NoError(t, pm.scan(setting.PluginsPath, true))
+		assert.Equal(t, []PluginError{{ErrorCode: signatureMissing, PluginId: "test"}}, pm.ScanningErrors())
 	})
 
 	t.Run("With external unsigned back-end plugin and configuration disabling signature check of this plugin", func(t *testing.T) {
@@ -86,6 +94,7 @@ func TestPluginManager_Init(t *testing.T) {
 		require.NoError(t, err)
 
 		assert.Empty(t, pm.scanningErrors)
+		assert.Empty(t, pm.ScanningErrors())
 	})
 
 	t.Run("With external back-end plugin with invalid signature", func(t *testing.T) {
@@ -102,6 +111,7 @@ func TestPluginManager_Init(t *testing.T) {
 		require.NoError(t, err)
 
 		assert.Equal(t, []error{fmt.Errorf(`plugin "test" has an invalid signature`)}, pm.scanningErrors)
+		assert.Equal(t, []PluginError{{ErrorCode: signatureInvalid, PluginId: "test"}}, pm.ScanningErrors())
 	})
 
 	t.Run("With external back-end plugin lacking files listed in manifest", func(t *testing.T) {
@@ -120,6 +130,7 @@ func TestPluginManager_Init(t *testing.T) {
 		require.NoError(t, err)
 
 		assert.Equal(t, []error{fmt.Errorf(`plugin "test"'s signature has been modified`)}, pm.scanningErrors)
+		assert.Equal(t, []PluginError{{ErrorCode: signatureModified, PluginId: "test"}}, pm.ScanningErrors())
 	})
 
 	t.Run("Transform plugins should be ignored when expressions feature is off", func(t *testing.T) {
@@ -186,7 +197,8 @@ func TestPluginManager_IsBackendOnlyPlugin(t *testing.T) {
 }
 
 type fakeBackendPluginManager struct {
-	registeredPlugins []string
+	registeredPlugins   []string
+	unregisteredPlugins []string
 }
 
 func (f *fakeBackendPluginManager) Register(pluginID string, factory backendplugin.PluginFactoryFunc) error {
@@ -208,3 +220,28 @@ func (f *fakeBackendPluginManager) CheckHealth(ctx context.Context, pCtx backend
 
 func (f *fakeBackendPluginManager) CallResource(pluginConfig backend.PluginContext, ctx *models.ReqContext, path string) {
 }
+
+func (f *fakeBackendPluginManager) SubscribeStream(ctx context.Context, req *backendplugin.SubscribeStreamRequest) (*backendplugin.SubscribeStreamResponse, error) {
+	return nil, nil
+}
+
+func (f *fakeBackendPluginManager) RunStream(ctx context.Context, req *backendplugin.RunStreamRequest, sender backendplugin.StreamPacketSender) error {
+	return nil
+}
+
+func (f *fakeBackendPluginManager) RestartPlugin(ctx context.Context, pluginID string) error {
+	return nil
+}
+
+func (f *fakeBackendPluginManager) UnregisterPlugin(ctx context.Context, pluginID string) error {
+	f.unregisteredPlugins = append(f.unregisteredPlugins, pluginID)
+	return nil
+}
+
+func (f *fakeBackendPluginManager) ExitedPlugins() []string {
+	return nil
+}
+
+func (f *fakeBackendPluginManager) Gather() ([]*dto.MetricFamily, error) {
+	return nil, nil
+}
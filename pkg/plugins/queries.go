@@ -17,7 +17,7 @@ func GetPluginSettings(orgId int64) (map[string]*models.PluginSettingInfoDTO, er
 		pluginMap[plug.PluginId] = plug
 	}
 
-	for _, pluginDef := range Plugins {
+	for _, pluginDef := range GetPlugins() {
 		// ignore entries that exists
 		if _, ok := pluginMap[pluginDef.Id]; ok {
 			continue
@@ -63,21 +63,21 @@ func GetEnabledPlugins(orgId int64) (*EnabledPlugins, error) {
 		return ok
 	}
 
-	for pluginId, app := range Apps {
-		if b, ok := pluginSettingMap[pluginId]; ok {
+	for _, app := range GetApps() {
+		if b, ok := pluginSettingMap[app.Id]; ok {
 			app.Pinned = b.Pinned
 			enabledPlugins.Apps = append(enabledPlugins.Apps, app)
 		}
 	}
 
 	// add all plugins that are not part of an App.
-	for dsId, ds := range DataSources {
+	for _, ds := range GetDataSources() {
 		if isPluginEnabled(ds.Id) {
-			enabledPlugins.DataSources[dsId] = ds
+			enabledPlugins.DataSources[ds.Id] = ds
 		}
 	}
 
-	for _, panel := range Panels {
+	for _, panel := range GetPanels() {
 		if isPluginEnabled(panel.Id) {
 			enabledPlugins.Panels = append(enabledPlugins.Panels, panel)
 		}
@@ -88,6 +88,6 @@ func GetEnabledPlugins(orgId int64) (*EnabledPlugins, error) {
 
 // IsAppInstalled checks if an app plugin with provided plugin ID is installed.
 func IsAppInstalled(pluginID string) bool {
-	_, exists := Apps[pluginID]
+	_, exists := GetApp(pluginID)
 	return exists
 }
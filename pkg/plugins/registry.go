@@ -0,0 +1,174 @@
+package plugins
+
+import (
+	"sync"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// registryMutex guards DataSources, Panels, Apps, Plugins and StaticRoutes. Front-end plugins are
+// registered and unregistered at runtime when they're installed, updated or uninstalled, so the
+// registries are read through the functions below once Grafana has started.
+var registryMutex sync.RWMutex
+
+// GetPlugin returns a loaded plugin of any type
+func GetPlugin(pluginID string) (*PluginBase, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	plugin, exists := Plugins[pluginID]
+	return plugin, exists
+}
+
+// GetDataSource returns a loaded data source plugin
+func GetDataSource(pluginID string) (*DataSourcePlugin, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	ds, exists := DataSources[pluginID]
+	return ds, exists
+}
+
+// GetPanel returns a loaded panel plugin
+func GetPanel(pluginID string) (*PanelPlugin, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	panel, exists := Panels[pluginID]
+	return panel, exists
+}
+
+// GetApp returns a loaded app plugin
+func GetApp(pluginID string) (*AppPlugin, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	app, exists := Apps[pluginID]
+	return app, exists
+}
+
+// GetStaticRoute returns the route serving the files of an external plugin
+func GetStaticRoute(pluginID string) (*PluginStaticRoute, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	for _, route := range StaticRoutes {
+		if route.PluginId == pluginID {
+			return route, true
+		}
+	}
+	return nil, false
+}
+
+// GetPlugins returns all the loaded plugins
+func GetPlugins() []*PluginBase {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	result := make([]*PluginBase, 0, len(Plugins))
+	for _, plugin := range Plugins {
+		result = append(result, plugin)
+	}
+	return result
+}
+
+// GetDataSources returns all the loaded data source plugins
+func GetDataSources() []*DataSourcePlugin {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	result := make([]*DataSourcePlugin, 0, len(DataSources))
+	for _, ds := range DataSources {
+		result = append(result, ds)
+	}
+	return result
+}
+
+// GetPanels returns all the loaded panel plugins
+func GetPanels() []*PanelPlugin {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	result := make([]*PanelPlugin, 0, len(Panels))
+	for _, panel := range Panels {
+		result = append(result, panel)
+	}
+	return result
+}
+
+// GetApps returns all the loaded app plugins
+func GetApps() []*AppPlugin {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	result := make([]*AppPlugin, 0, len(Apps))
+	for _, app := range Apps {
+		result = append(result, app)
+	}
+	return result
+}
+
+// registerPlugins loads the plugins of a directory after startup and initializes them the same way
+// Init does. The plugins previously loaded from the directory are replaced.
+func (pm *PluginManager) registerPlugins(pluginDir string) error {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	unregisterPlugins(pluginDir)
+
+	scanner := pm.newScanner(pluginDir, true)
+	if err := util.Walk(pluginDir, true, true, scanner.walker); err != nil {
+		return err
+	}
+	if len(scanner.errors) > 0 {
+		unregisterPlugins(pluginDir)
+		return scanner.errors[0]
+	}
+
+	for _, panel := range Panels {
+		if isInDirectory(panel.PluginDir, pluginDir) {
+			panel.initFrontendPlugin()
+		}
+	}
+	for _, ds := range DataSources {
+		if isInDirectory(ds.PluginDir, pluginDir) {
+			ds.initFrontendPlugin()
+		}
+	}
+	for _, app := range Apps {
+		if isInDirectory(app.PluginDir, pluginDir) {
+			app.initApp()
+		}
+	}
+	for _, p := range Plugins {
+		if isInDirectory(p.PluginDir, pluginDir) {
+			p.Signature = getPluginSignatureState(pm.log, p)
+			metrics.SetPluginBuildInformation(p.Id, p.Type, p.Info.Version)
+		}
+	}
+
+	return nil
+}
+
+// unregisterPlugins removes the plugins loaded from a directory. The caller holds registryMutex.
+func unregisterPlugins(pluginDir string) {
+	for id, p := range Plugins {
+		if !isInDirectory(p.PluginDir, pluginDir) {
+			continue
+		}
+
+		delete(Plugins, id)
+		delete(Panels, id)
+		delete(DataSources, id)
+		delete(Apps, id)
+	}
+
+	routes := make([]*PluginStaticRoute, 0, len(StaticRoutes))
+	for _, route := range StaticRoutes {
+		if !isInDirectory(route.Directory, pluginDir) {
+			routes = append(routes, route)
+		}
+	}
+	StaticRoutes = routes
+}
@@ -96,6 +96,32 @@ type BackgroundService interface {
 	Run(ctx context.Context) error
 }
 
+// DrainStage orders the draining of the services when Grafana shuts down. The services of a stage
+// are drained together, once the services of the previous stages are drained.
+type DrainStage int
+
+const (
+	// DrainRequests is the stage of the services serving requests, drained first
+	DrainRequests DrainStage = iota
+	// DrainJobs is the stage of the services running background jobs, like alert evaluations
+	DrainJobs
+	// DrainQueues is the stage of the services with queued work, drained last as the services of
+	// the other stages may queue more work
+	DrainQueues
+)
+
+// DrainableService should be implemented by background services with work in
+// progress worth finishing when Grafana shuts down.
+type DrainableService interface {
+	// DrainStage returns the stage in which the service is drained.
+	DrainStage() DrainStage
+
+	// Drain stops the service from taking new work, and waits for the work in progress
+	// to finish or for ctx to be done. It's called before the context passed to `Run`
+	// is canceled.
+	Drain(ctx context.Context) error
+}
+
 // DatabaseMigrator allows the caller to add migrations to
 // the migrator passed as argument
 type DatabaseMigrator interface {
@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/null"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/alerting"
 	"github.com/grafana/grafana/pkg/tsdb"
@@ -40,6 +41,10 @@ type AlertQuery struct {
 	DatasourceID int64
 	From         string
 	To           string
+	// Transformations are the transformations of the panel changing its series
+	Transformations []*tsdb.Transformation
+	// Queries are the other queries of the panel, when the query is an expression
+	Queries []*simplejson.Json
 }
 
 // Eval evaluates the `QueryCondition`.
@@ -106,16 +111,22 @@ func (c *QueryCondition) Eval(context *alerting.EvalContext) (*alerting.Conditio
 }
 
 func (c *QueryCondition) executeQuery(context *alerting.EvalContext, timeRange *tsdb.TimeRange) (tsdb.TimeSeriesSlice, error) {
-	getDsInfo := &models.GetDataSourceByIdQuery{
-		Id:    c.Query.DatasourceID,
-		OrgId: context.Rule.OrgID,
-	}
+	var datasource *models.DataSource
+	if c.Query.DatasourceID == expr.DatasourceID {
+		datasource = expr.DataSource(context.Rule.OrgID)
+	} else {
+		getDsInfo := &models.GetDataSourceByIdQuery{
+			Id:    c.Query.DatasourceID,
+			OrgId: context.Rule.OrgID,
+		}
 
-	if err := bus.Dispatch(getDsInfo); err != nil {
-		return nil, fmt.Errorf("Could not find datasource %v", err)
+		if err := bus.Dispatch(getDsInfo); err != nil {
+			return nil, fmt.Errorf("Could not find datasource %v", err)
+		}
+		datasource = getDsInfo.Result
 	}
 
-	req := c.getRequestForAlertRule(getDsInfo.Result, timeRange, context.IsDebug)
+	req := c.getRequestForAlertRule(datasource, timeRange, context.IsDebug)
 	result := make(tsdb.TimeSeriesSlice, 0)
 
 	if context.IsDebug {
@@ -135,16 +146,20 @@ func (c *QueryCondition) executeQuery(context *alerting.EvalContext, timeRange *
 
 		queries := []*queryDto{}
 		for _, q := range req.Queries {
-			queries = append(queries, &queryDto{
-				RefID: q.RefId,
-				Model: q.Model,
-				Datasource: simplejson.NewFromAny(map[string]interface{}{
-					"id":   q.DataSource.Id,
-					"name": q.DataSource.Name,
-				}),
+			dto := &queryDto{
+				RefID:         q.RefId,
+				Model:         q.Model,
 				MaxDataPoints: q.MaxDataPoints,
 				IntervalMs:    q.IntervalMs,
-			})
+			}
+			// the data sources of the queries of an expression are loaded when it's evaluated
+			if q.DataSource != nil {
+				dto.Datasource = simplejson.NewFromAny(map[string]interface{}{
+					"id":   q.DataSource.Id,
+					"name": q.DataSource.Name,
+				})
+			}
+			queries = append(queries, dto)
 		}
 
 		data.Set("queries", queries)
@@ -155,7 +170,7 @@ func (c *QueryCondition) executeQuery(context *alerting.EvalContext, timeRange *
 		})
 	}
 
-	resp, err := c.HandleRequest(context.Ctx, getDsInfo.Result, req)
+	resp, err := c.HandleRequest(context.Ctx, datasource, req)
 	if err != nil {
 		if err == gocontext.DeadlineExceeded {
 			return nil, fmt.Errorf("Alert execution exceeded the timeout")
@@ -164,7 +179,12 @@ func (c *QueryCondition) executeQuery(context *alerting.EvalContext, timeRange *
 		return nil, fmt.Errorf("tsdb.HandleRequest() error %v", err)
 	}
 
-	for _, v := range resp.Results {
+	for refID, v := range resp.Results {
+		// only the series of the expression are evaluated, not the ones of its queries
+		if c.Query.DatasourceID == expr.DatasourceID && refID != c.Query.Model.Get("refId").MustString() {
+			continue
+		}
+
 		if v.Error != nil {
 			return nil, fmt.Errorf("tsdb.HandleRequest() response error %v", v)
 		}
@@ -214,6 +234,10 @@ func (c *QueryCondition) executeQuery(context *alerting.EvalContext, timeRange *
 }
 
 func (c *QueryCondition) getRequestForAlertRule(datasource *models.DataSource, timeRange *tsdb.TimeRange, debug bool) *tsdb.TsdbQuery {
+	if datasource.Id == expr.DatasourceID {
+		return c.getExpressionRequest(datasource, timeRange, debug)
+	}
+
 	req := &tsdb.TsdbQuery{
 		TimeRange: timeRange,
 		Queries: []*tsdb.Query{
@@ -226,12 +250,42 @@ func (c *QueryCondition) getRequestForAlertRule(datasource *models.DataSource, t
 		Headers: map[string]string{
 			"FromAlert": "true",
 		},
-		Debug: debug,
+		Debug:           debug,
+		Transformations: c.Query.Transformations,
 	}
 
 	return req
 }
 
+// getExpressionRequest returns the request of an expression, with the queries of the panel it's
+// evaluated with
+func (c *QueryCondition) getExpressionRequest(datasource *models.DataSource, timeRange *tsdb.TimeRange, debug bool) *tsdb.TsdbQuery {
+	req := &tsdb.TsdbQuery{
+		TimeRange: timeRange,
+		Headers: map[string]string{
+			"FromAlert": "true",
+		},
+		Debug:           debug,
+		Transformations: c.Query.Transformations,
+	}
+
+	for _, query := range c.Query.Queries {
+		req.Queries = append(req.Queries, &tsdb.Query{
+			RefId:         query.Get("refId").MustString(),
+			MaxDataPoints: query.Get("maxDataPoints").MustInt64(100),
+			IntervalMs:    query.Get("intervalMs").MustInt64(1000),
+			Model:         query,
+		})
+	}
+	req.Queries = append(req.Queries, &tsdb.Query{
+		RefId:      c.Query.Model.Get("refId").MustString(),
+		Model:      c.Query.Model,
+		DataSource: datasource,
+	})
+
+	return req
+}
+
 func newQueryCondition(model *simplejson.Json, index int) (*QueryCondition, error) {
 	condition := QueryCondition{}
 	condition.Index = index
@@ -252,6 +306,23 @@ func newQueryCondition(model *simplejson.Json, index int) (*QueryCondition, erro
 	}
 
 	condition.Query.DatasourceID = queryJSON.Get("datasourceId").MustInt64()
+	for _, query := range queryJSON.Get("queries").MustArray() {
+		condition.Query.Queries = append(condition.Query.Queries, simplejson.NewFromAny(query))
+	}
+
+	// only the transformations keeping the series are applied, as the alert rule queries only one
+	// query of the panel and evaluates its series
+	for _, t := range queryJSON.Get("transformations").MustArray() {
+		transformation := simplejson.NewFromAny(t)
+		id := transformation.Get("id").MustString()
+		if id != "organize" && id != "calculateField" {
+			continue
+		}
+		condition.Query.Transformations = append(condition.Query.Transformations, &tsdb.Transformation{
+			Id:      id,
+			Options: transformation.Get("options"),
+		})
+	}
 
 	reducerJSON := model.Get("reducer")
 	condition.Reducer = newSimpleReducer(reducerJSON.Get("type").MustString())
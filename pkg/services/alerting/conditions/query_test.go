@@ -9,6 +9,7 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/null"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/alerting"
 	"github.com/grafana/grafana/pkg/tsdb"
@@ -230,3 +231,52 @@ func queryConditionScenario(desc string, fn queryConditionScenarioFunc) {
 		fn(ctx)
 	})
 }
+
+func TestQueryConditionExpression(t *testing.T) {
+	Convey("Given a condition on an expression", t, func() {
+		jsonModel, err := simplejson.NewJson([]byte(`{
+            "type": "query",
+            "query":  {
+              "params": ["B", "5m", "now"],
+              "datasourceId": -100,
+              "model": {"refId": "B", "datasource": "__expr__", "type": "reduce", "expression": "$A", "reducer": "max"},
+              "queries": [{"refId": "A", "datasourceId": 1, "target": "statsd.fakesite.counters.session_start.mobile.count"}]
+            },
+            "reducer": {"type": "last"},
+            "evaluator": {"type": "gt", "params": [100]}
+          }`))
+		So(err, ShouldBeNil)
+
+		condition, err := newQueryCondition(jsonModel, 0)
+		So(err, ShouldBeNil)
+		So(condition.Query.Queries, ShouldHaveLength, 1)
+
+		var request *tsdb.TsdbQuery
+		var datasource *models.DataSource
+		condition.HandleRequest = func(context context.Context, dsInfo *models.DataSource, req *tsdb.TsdbQuery) (*tsdb.Response, error) {
+			request = req
+			datasource = dsInfo
+			return &tsdb.Response{
+				Results: map[string]*tsdb.QueryResult{
+					"A": {Series: tsdb.TimeSeriesSlice{tsdb.NewTimeSeries("test1", tsdb.NewTimeSeriesPointsFromArgs(50, 0, 150, 1))}},
+					"B": {Series: tsdb.TimeSeriesSlice{tsdb.NewTimeSeries("test1", tsdb.NewTimeSeriesPointsFromArgs(150, 1))}},
+				},
+			}, nil
+		}
+
+		cr, err := condition.Eval(&alerting.EvalContext{Rule: &alerting.Rule{OrgID: 1}})
+		So(err, ShouldBeNil)
+
+		Convey("Should send the expression with the queries of the panel", func() {
+			So(datasource.Id, ShouldEqual, expr.DatasourceID)
+			So(request.Queries, ShouldHaveLength, 2)
+			So(request.Queries[0].RefId, ShouldEqual, "A")
+			So(request.Queries[1].RefId, ShouldEqual, "B")
+		})
+
+		Convey("Should only evaluate the series of the expression", func() {
+			So(cr.Firing, ShouldBeTrue)
+			So(cr.EvalMatches, ShouldHaveLength, 1)
+		})
+	})
+}
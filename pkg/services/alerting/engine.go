@@ -3,6 +3,7 @@ package alerting
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
@@ -33,6 +34,13 @@ type AlertEngine struct {
 	ruleReader    ruleReader
 	log           log.Logger
 	resultHandler resultHandler
+
+	// draining is closed when the engine stops scheduling evaluations, and drained once the
+	// evaluations in progress are done
+	draining    chan struct{}
+	drained     chan struct{}
+	drainOnce   sync.Once
+	drainedOnce sync.Once
 }
 
 func init() {
@@ -53,6 +61,8 @@ func (e *AlertEngine) Init() error {
 	e.ruleReader = newRuleReader()
 	e.log = log.New("alerting.engine")
 	e.resultHandler = newResultHandler(e.RenderService)
+	e.draining = make(chan struct{})
+	e.drained = make(chan struct{})
 	return nil
 }
 
@@ -63,9 +73,28 @@ func (e *AlertEngine) Run(ctx context.Context) error {
 	alertGroup.Go(func() error { return e.runJobDispatcher(ctx) })
 
 	err := alertGroup.Wait()
+	e.drainedOnce.Do(func() { close(e.drained) })
 	return err
 }
 
+// DrainStage returns the stage in which the alerting engine is drained, once the HTTP server is.
+func (e *AlertEngine) DrainStage() registry.DrainStage {
+	return registry.DrainJobs
+}
+
+// Drain stops scheduling alert evaluations and waits for the evaluations in progress,
+// notifications included.
+func (e *AlertEngine) Drain(ctx context.Context) error {
+	e.drainOnce.Do(func() { close(e.draining) })
+
+	select {
+	case <-e.drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (e *AlertEngine) alertingTicker(grafanaCtx context.Context) error {
 	defer func() {
 		if err := recover(); err != nil {
@@ -79,6 +108,8 @@ func (e *AlertEngine) alertingTicker(grafanaCtx context.Context) error {
 		select {
 		case <-grafanaCtx.Done():
 			return grafanaCtx.Err()
+		case <-e.draining:
+			return nil
 		case tick := <-e.ticker.C:
 			// TEMP SOLUTION update rules ever tenth tick
 			if tickIndex%10 == 0 {
@@ -98,6 +129,12 @@ func (e *AlertEngine) runJobDispatcher(grafanaCtx context.Context) error {
 		select {
 		case <-grafanaCtx.Done():
 			return dispatcherGroup.Wait()
+		case <-e.draining:
+			e.log.Info("Waiting for the alert evaluations in progress", "skipped", len(e.execQueue))
+			err := dispatcherGroup.Wait()
+			e.drainedOnce.Do(func() { close(e.drained) })
+			<-grafanaCtx.Done()
+			return err
 		case job := <-e.execQueue:
 			dispatcherGroup.Go(func() error { return e.processJobWithRetry(alertCtx, job) })
 		}
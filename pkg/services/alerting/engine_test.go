@@ -127,3 +127,60 @@ func TestEngineProcessJob(t *testing.T) {
 		})
 	})
 }
+
+type slowEvalHandler struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (handler *slowEvalHandler) Eval(evalContext *EvalContext) {
+	close(handler.started)
+	<-handler.release
+}
+
+func TestEngineDrain(t *testing.T) {
+	Convey("Alerting engine draining", t, func() {
+		engine := &AlertEngine{}
+		err := engine.Init()
+		So(err, ShouldBeNil)
+		setting.AlertingEvaluationTimeout = 30 * time.Second
+		setting.AlertingMaxAttempts = 1
+		engine.resultHandler = &FakeResultHandler{}
+		evalHandler := &slowEvalHandler{started: make(chan struct{}), release: make(chan struct{})}
+		engine.evalHandler = evalHandler
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			_ = engine.runJobDispatcher(ctx)
+		}()
+
+		engine.execQueue <- &Job{Rule: &Rule{}}
+		<-evalHandler.started
+
+		Convey("Should wait for the evaluations in progress", func() {
+			drained := make(chan error)
+			go func() {
+				drained <- engine.Drain(context.Background())
+			}()
+
+			select {
+			case <-drained:
+				t.Fatal("drained before the evaluation finished")
+			case <-time.After(100 * time.Millisecond):
+			}
+
+			close(evalHandler.release)
+			So(<-drained, ShouldBeNil)
+		})
+
+		Convey("Should stop waiting when the shutdown timeout is exceeded", func() {
+			drainCtx, drainCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer drainCancel()
+
+			err := engine.Drain(drainCtx)
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+			close(evalHandler.release)
+		})
+	})
+}
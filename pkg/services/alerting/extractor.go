@@ -7,6 +7,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 )
@@ -53,6 +54,39 @@ func (e *DashAlertExtractor) lookupDatasourceID(dsName string) (*models.DataSour
 	return nil, errors.New("Could not find datasource id for " + dsName)
 }
 
+// getQueryDatasource returns the data source of a panel query, if the user can query it.
+func (e *DashAlertExtractor) getQueryDatasource(alert *models.Alert, panel *simplejson.Json, panelQuery *simplejson.Json) (*models.DataSource, error) {
+	dsName := ""
+	if panelQuery.Get("datasource").MustString() != "" {
+		dsName = panelQuery.Get("datasource").MustString()
+	} else if panel.Get("datasource").MustString() != "" {
+		dsName = panel.Get("datasource").MustString()
+	}
+
+	datasource, err := e.lookupDatasourceID(dsName)
+	if err != nil {
+		e.log.Debug("Error looking up datasource", "error", err)
+		return nil, ValidationError{Reason: fmt.Sprintf("Data source used by alert rule not found, alertName=%v, datasource=%s", alert.Name, dsName)}
+	}
+
+	dsFilterQuery := models.DatasourcesPermissionFilterQuery{
+		User:        e.User,
+		Datasources: []*models.DataSource{datasource},
+	}
+
+	if err := bus.Dispatch(&dsFilterQuery); err != nil {
+		if err != bus.ErrHandlerNotFound {
+			return nil, err
+		}
+	} else {
+		if len(dsFilterQuery.Result) == 0 {
+			return nil, models.ErrDataSourceAccessDenied
+		}
+	}
+
+	return datasource, nil
+}
+
 func findPanelQueryByRefID(panel *simplejson.Json, refID string) *simplejson.Json {
 	for _, targetsObj := range panel.Get("targets").MustArray() {
 		target := simplejson.NewFromAny(targetsObj)
@@ -148,41 +182,44 @@ func (e *DashAlertExtractor) getAlertFromPanels(jsonWithPanels *simplejson.Json,
 				return nil, ValidationError{Reason: reason}
 			}
 
-			dsName := ""
-			if panelQuery.Get("datasource").MustString() != "" {
-				dsName = panelQuery.Get("datasource").MustString()
-			} else if panel.Get("datasource").MustString() != "" {
-				dsName = panel.Get("datasource").MustString()
-			}
-
-			datasource, err := e.lookupDatasourceID(dsName)
-			if err != nil {
-				e.log.Debug("Error looking up datasource", "error", err)
-				return nil, ValidationError{Reason: fmt.Sprintf("Data source used by alert rule not found, alertName=%v, datasource=%s", alert.Name, dsName)}
-			}
-
-			dsFilterQuery := models.DatasourcesPermissionFilterQuery{
-				User:        e.User,
-				Datasources: []*models.DataSource{datasource},
-			}
-
-			if err := bus.Dispatch(&dsFilterQuery); err != nil {
-				if err != bus.ErrHandlerNotFound {
-					return nil, err
+			if expr.IsExpressionQuery(panelQuery) {
+				// the expression is evaluated with the other queries of the panel, which can be
+				// of any data source
+				queries := make([]interface{}, 0)
+				for _, target := range panel.Get("targets").MustArray() {
+					query := simplejson.NewFromAny(target)
+					if query.Get("refId").MustString() == queryRefID {
+						continue
+					}
+					if !expr.IsExpressionQuery(query) {
+						datasource, err := e.getQueryDatasource(alert, panel, query)
+						if err != nil {
+							return nil, err
+						}
+						query.Set("datasourceId", datasource.Id)
+					}
+					queries = append(queries, query.Interface())
 				}
+
+				jsonQuery.SetPath([]string{"datasourceId"}, expr.DatasourceID)
+				jsonQuery.Set("queries", queries)
 			} else {
-				if len(dsFilterQuery.Result) == 0 {
-					return nil, models.ErrDataSourceAccessDenied
+				datasource, err := e.getQueryDatasource(alert, panel, panelQuery)
+				if err != nil {
+					return nil, err
 				}
-			}
 
-			jsonQuery.SetPath([]string{"datasourceId"}, datasource.Id)
+				jsonQuery.SetPath([]string{"datasourceId"}, datasource.Id)
+			}
 
 			if interval, err := panel.Get("interval").String(); err == nil {
 				panelQuery.Set("interval", interval)
 			}
 
 			jsonQuery.Set("model", panelQuery.Interface())
+			if transformations, ok := panel.CheckGet("transformations"); ok {
+				jsonQuery.Set("transformations", transformations.Interface())
+			}
 		}
 
 		alert.Settings = jsonAlert
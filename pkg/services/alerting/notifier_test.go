@@ -335,6 +335,10 @@ func (s *testRenderService) GetRenderUser(key string) (*rendering.RenderUser, bo
 	return nil, false
 }
 
+func (s *testRenderService) CheckHealth(ctx context.Context) error {
+	return nil
+}
+
 var _ rendering.Service = &testRenderService{}
 
 type testImageUploader struct {
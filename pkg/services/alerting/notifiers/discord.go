@@ -63,7 +63,10 @@ func init() {
 
 func newDiscordNotifier(model *models.AlertNotification) (alerting.Notifier, error) {
 	content := model.Settings.Get("content").MustString()
-	url := model.Settings.Get("url").MustString()
+	url, err := model.DecryptedValue("url", model.Settings.Get("url").MustString())
+	if err != nil {
+		return nil, err
+	}
 	if url == "" {
 		return nil, alerting.ValidationError{Reason: "Could not find webhook url property in settings"}
 	}
@@ -40,7 +40,10 @@ func init() {
 }
 
 func newGoogleChatNotifier(model *models.AlertNotification) (alerting.Notifier, error) {
-	url := model.Settings.Get("url").MustString()
+	url, err := model.DecryptedValue("url", model.Settings.Get("url").MustString())
+	if err != nil {
+		return nil, err
+	}
 	if url == "" {
 		return nil, alerting.ValidationError{Reason: "Could not find url property in settings"}
 	}
@@ -44,7 +44,10 @@ const (
 
 // NewLINENotifier is the constructor for the LINE notifier
 func NewLINENotifier(model *models.AlertNotification) (alerting.Notifier, error) {
-	token := model.Settings.Get("token").MustString()
+	token, err := model.DecryptedValue("token", model.Settings.Get("token").MustString())
+	if err != nil {
+		return nil, err
+	}
 	if token == "" {
 		return nil, alerting.ValidationError{Reason: "Could not find token in settings"}
 	}
@@ -105,7 +105,10 @@ var (
 func NewPagerdutyNotifier(model *models.AlertNotification) (alerting.Notifier, error) {
 	severity := model.Settings.Get("severity").MustString("critical")
 	autoResolve := model.Settings.Get("autoResolve").MustBool(false)
-	key := model.Settings.Get("integrationKey").MustString()
+	key, err := model.DecryptedValue("integrationKey", model.Settings.Get("integrationKey").MustString())
+	if err != nil {
+		return nil, err
+	}
 	messageInDetails := model.Settings.Get("messageInDetails").MustBool(false)
 	if key == "" {
 		return nil, alerting.ValidationError{Reason: "Could not find integration key property in settings"}
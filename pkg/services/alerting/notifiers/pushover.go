@@ -258,8 +258,14 @@ func init() {
 
 // NewPushoverNotifier is the constructor for the Pushover Notifier
 func NewPushoverNotifier(model *models.AlertNotification) (alerting.Notifier, error) {
-	userKey := model.Settings.Get("userKey").MustString()
-	APIToken := model.Settings.Get("apiToken").MustString()
+	userKey, err := model.DecryptedValue("userKey", model.Settings.Get("userKey").MustString())
+	if err != nil {
+		return nil, err
+	}
+	APIToken, err := model.DecryptedValue("apiToken", model.Settings.Get("apiToken").MustString())
+	if err != nil {
+		return nil, err
+	}
 	device := model.Settings.Get("device").MustString()
 	priority, _ := strconv.Atoi(model.Settings.Get("priority").MustString())
 	retry, _ := strconv.Atoi(model.Settings.Get("retry").MustString())
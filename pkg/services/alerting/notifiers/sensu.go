@@ -88,12 +88,17 @@ func NewSensuNotifier(model *models.AlertNotification) (alerting.Notifier, error
 		return nil, alerting.ValidationError{Reason: "Could not find url property in settings"}
 	}
 
+	password, err := model.DecryptedValue("password", model.Settings.Get("password").MustString())
+	if err != nil {
+		return nil, err
+	}
+
 	return &SensuNotifier{
 		NotifierBase: NewNotifierBase(model),
 		URL:          url,
 		User:         model.Settings.Get("username").MustString(),
 		Source:       model.Settings.Get("source").MustString(),
-		Password:     model.Settings.Get("password").MustString(),
+		Password:     password,
 		Handler:      model.Settings.Get("handler").MustString(),
 		log:          log.New("alerting.notifier.sensu"),
 	}, nil
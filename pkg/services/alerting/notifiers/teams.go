@@ -38,7 +38,10 @@ func init() {
 
 // NewTeamsNotifier is the constructor for Teams notifier.
 func NewTeamsNotifier(model *models.AlertNotification) (alerting.Notifier, error) {
-	url := model.Settings.Get("url").MustString()
+	url, err := model.DecryptedValue("url", model.Settings.Get("url").MustString())
+	if err != nil {
+		return nil, err
+	}
 	if url == "" {
 		return nil, alerting.ValidationError{Reason: "Could not find url property in settings"}
 	}
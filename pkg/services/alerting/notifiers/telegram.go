@@ -87,7 +87,10 @@ func NewTelegramNotifier(model *models.AlertNotification) (alerting.Notifier, er
 		return nil, alerting.ValidationError{Reason: "No Settings Supplied"}
 	}
 
-	botToken := model.Settings.Get("bottoken").MustString()
+	botToken, err := model.DecryptedValue("bottoken", model.Settings.Get("bottoken").MustString())
+	if err != nil {
+		return nil, err
+	}
 	chatID := model.Settings.Get("chatid").MustString()
 	uploadImage := model.Settings.Get("uploadImage").MustBool()
 
@@ -119,7 +119,10 @@ func NewThreemaNotifier(model *models.AlertNotification) (alerting.Notifier, err
 
 	gatewayID := model.Settings.Get("gateway_id").MustString()
 	recipientID := model.Settings.Get("recipient_id").MustString()
-	apiSecret := model.Settings.Get("api_secret").MustString()
+	apiSecret, err := model.DecryptedValue("api_secret", model.Settings.Get("api_secret").MustString())
+	if err != nil {
+		return nil, err
+	}
 
 	// Validation
 	if gatewayID == "" {
@@ -2,10 +2,13 @@ package alerting
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/components/securejsondata"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
@@ -14,27 +17,68 @@ import (
 // NotificationTestCommand initiates an test
 // execution of an alert notification.
 type NotificationTestCommand struct {
-	State    models.AlertStateType
-	Name     string
-	Type     string
-	Settings *simplejson.Json
+	State          models.AlertStateType
+	OrgID          int64
+	Name           string
+	Type           string
+	Settings       *simplejson.Json
+	SecureSettings map[string]string
+	// Provisioned tells whether the notification tested is provisioned, which the secrets it
+	// references are resolved for without checking the paths allowed for its organization
+	Provisioned bool
+	// Alert is the synthetic alert sent, the default test alert when nil
+	Alert *NotificationTestAlert
+
+	// Result are the webhooks and emails sent by the notifier, with the responses of their upstreams
+	Result []models.NotificationDelivery
+}
+
+// NotificationTestAlert is a synthetic alert sent through a notifier to test it, rendered like the
+// alerts of the rules
+type NotificationTestAlert struct {
+	Title   string
+	Message string
+	// State is alerting when empty
+	State       models.AlertStateType
+	Tags        map[string]string
+	ImageURL    string
+	EvalMatches []*EvalMatch
+	Error       string
 }
 
 var (
 	logger = log.New("alerting.testnotification")
+
+	// ErrInvalidTestAlertState is returned when a synthetic alert has a state notifications aren't sent for
+	ErrInvalidTestAlertState = errors.New("invalid state of the test alert, expected alerting, ok, no_data or pending")
 )
 
+// testAlertStates are the states of the synthetic alerts
+var testAlertStates = map[models.AlertStateType]bool{
+	models.AlertStateAlerting: true,
+	models.AlertStateOK:       true,
+	models.AlertStateNoData:   true,
+	models.AlertStatePending:  true,
+}
+
 func init() {
-	bus.AddHandler("alerting", handleNotificationTestCommand)
+	bus.AddHandlerCtx("alerting", handleNotificationTestCommand)
 }
 
-func handleNotificationTestCommand(cmd *NotificationTestCommand) error {
+func handleNotificationTestCommand(ctx context.Context, cmd *NotificationTestCommand) error {
+	if cmd.Alert != nil && cmd.Alert.State != "" && !testAlertStates[cmd.Alert.State] {
+		return ErrInvalidTestAlertState
+	}
+
 	notifier := newNotificationService(nil)
 
 	model := &models.AlertNotification{
-		Name:     cmd.Name,
-		Type:     cmd.Type,
-		Settings: cmd.Settings,
+		OrgId:          cmd.OrgID,
+		Name:           cmd.Name,
+		Type:           cmd.Type,
+		Settings:       cmd.Settings,
+		SecureSettings: securejsondata.GetEncryptedJsonData(cmd.SecureSettings),
+		Provisioned:    cmd.Provisioned,
 	}
 
 	notifiers, err := InitNotifier(model)
@@ -44,10 +88,18 @@ func handleNotificationTestCommand(cmd *NotificationTestCommand) error {
 		return err
 	}
 
-	return notifier.sendNotifications(createTestEvalContext(cmd), notifierStateSlice{{notifier: notifiers}})
+	recorder := &models.NotificationDeliveryRecorder{}
+	evalContext := createTestEvalContext(models.WithNotificationDeliveryRecorder(ctx, recorder), cmd)
+	err = notifier.sendNotifications(evalContext, notifierStateSlice{{notifier: notifiers}})
+	cmd.Result = recorder.Deliveries()
+	return err
 }
 
-func createTestEvalContext(cmd *NotificationTestCommand) *EvalContext {
+func createTestEvalContext(ctx context.Context, cmd *NotificationTestCommand) *EvalContext {
+	if cmd.Alert != nil {
+		return createTestAlertEvalContext(ctx, cmd.Alert)
+	}
+
 	testRule := &Rule{
 		DashboardID: 1,
 		PanelID:     1,
@@ -56,16 +108,59 @@ func createTestEvalContext(cmd *NotificationTestCommand) *EvalContext {
 		State:       models.AlertStateAlerting,
 	}
 
-	ctx := NewEvalContext(context.Background(), testRule)
+	evalContext := NewEvalContext(ctx, testRule)
 	if cmd.Settings.Get("uploadImage").MustBool(true) {
-		ctx.ImagePublicURL = "https://grafana.com/assets/img/blog/mixed_styles.png"
+		evalContext.ImagePublicURL = "https://grafana.com/assets/img/blog/mixed_styles.png"
+	}
+	evalContext.IsTestRun = true
+	evalContext.Firing = true
+	evalContext.Error = fmt.Errorf("This is only a test")
+	evalContext.EvalMatches = evalMatchesBasedOnState()
+
+	return evalContext
+}
+
+// createTestAlertEvalContext returns the context of a synthetic alert, with its title, state, tags,
+// image and matches
+func createTestAlertEvalContext(ctx context.Context, alert *NotificationTestAlert) *EvalContext {
+	state := alert.State
+	if state == "" {
+		state = models.AlertStateAlerting
+	}
+	testRule := &Rule{
+		DashboardID: 1,
+		PanelID:     1,
+		Name:        alert.Title,
+		Message:     alert.Message,
+		State:       state,
+	}
+	if testRule.Name == "" {
+		testRule.Name = "Test notification"
+	}
+
+	names := make([]string, 0, len(alert.Tags))
+	for name := range alert.Tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		testRule.AlertRuleTags = append(testRule.AlertRuleTags, &models.Tag{Key: name, Value: alert.Tags[name]})
+	}
+
+	evalContext := NewEvalContext(ctx, testRule)
+	evalContext.IsTestRun = true
+	evalContext.Firing = state == models.AlertStateAlerting
+	evalContext.NoDataFound = state == models.AlertStateNoData
+	evalContext.ImagePublicURL = alert.ImageURL
+	evalContext.EvalMatches = alert.EvalMatches
+	if evalContext.EvalMatches == nil && evalContext.Firing {
+		evalContext.EvalMatches = evalMatchesBasedOnState()
+	}
+	if alert.Error != "" {
+		evalContext.Error = errors.New(alert.Error)
 	}
-	ctx.IsTestRun = true
-	ctx.Firing = true
-	ctx.Error = fmt.Errorf("This is only a test")
-	ctx.EvalMatches = evalMatchesBasedOnState()
 
-	return ctx
+	return evalContext
 }
 
 func evalMatchesBasedOnState() []*EvalMatch {
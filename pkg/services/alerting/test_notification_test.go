@@ -0,0 +1,109 @@
+package alerting
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookTestNotifier sends a webhook with the title of the alerts it's notified of
+type webhookTestNotifier struct {
+	testNotifier
+	notified *EvalContext
+}
+
+func (n *webhookTestNotifier) Notify(evalCtx *EvalContext) error {
+	n.notified = evalCtx
+	return bus.DispatchCtx(evalCtx.Ctx, &models.SendWebhookSync{Url: "https://hooks.example.com/secret", Body: evalCtx.GetNotificationTitle()})
+}
+
+func TestNotificationTestCommand(t *testing.T) {
+	notifier := &webhookTestNotifier{}
+	RegisterNotifier(&NotifierPlugin{
+		Type:    "webhook-test",
+		Name:    "Webhook test",
+		Factory: func(model *models.AlertNotification) (Notifier, error) { return notifier, nil },
+	})
+
+	bus.AddHandlerCtx("test", func(ctx context.Context, cmd *models.SendWebhookSync) error {
+		u, err := url.Parse(cmd.Url)
+		require.NoError(t, err)
+		models.NotificationDeliveryRecorderFrom(ctx).RecordWebhook("POST", u, 200, []byte(`{"ok": true}`), nil, time.Millisecond)
+		return nil
+	})
+
+	t.Run("Should send the synthetic alert and return the webhooks sent", func(t *testing.T) {
+		cmd := &NotificationTestCommand{
+			Type:     "webhook-test",
+			Settings: simplejson.New(),
+			Alert: &NotificationTestAlert{
+				Title:    "Disk full",
+				Message:  "The disk of db-1 is full",
+				Tags:     map[string]string{"severity": "critical", "host": "db-1"},
+				ImageURL: "https://example.com/panel.png",
+				EvalMatches: []*EvalMatch{
+					{Metric: "disk_used", Value: null.FloatFrom(99)},
+				},
+			},
+		}
+		require.NoError(t, handleNotificationTestCommand(context.Background(), cmd))
+
+		evalCtx := notifier.notified
+		assert.Equal(t, "[Alerting] Disk full", evalCtx.GetNotificationTitle())
+		assert.Equal(t, "The disk of db-1 is full", evalCtx.Rule.Message)
+		assert.True(t, evalCtx.Firing)
+		assert.True(t, evalCtx.IsTestRun)
+		assert.Equal(t, "https://example.com/panel.png", evalCtx.ImagePublicURL)
+		assert.Equal(t, []*models.Tag{{Key: "host", Value: "db-1"}, {Key: "severity", Value: "critical"}}, evalCtx.Rule.AlertRuleTags)
+		require.Len(t, evalCtx.EvalMatches, 1)
+		assert.Nil(t, evalCtx.Error)
+
+		require.Len(t, cmd.Result, 1)
+		assert.Equal(t, models.NotificationDeliveryWebhook, cmd.Result[0].Type)
+		assert.Equal(t, "https://hooks.example.com", cmd.Result[0].URL)
+		assert.Equal(t, 200, cmd.Result[0].Status)
+		assert.Equal(t, `{"ok": true}`, cmd.Result[0].Response)
+	})
+
+	t.Run("Should send a resolved synthetic alert", func(t *testing.T) {
+		cmd := &NotificationTestCommand{
+			Type:     "webhook-test",
+			Settings: simplejson.New(),
+			Alert:    &NotificationTestAlert{Title: "Disk full", State: models.AlertStateOK},
+		}
+		require.NoError(t, handleNotificationTestCommand(context.Background(), cmd))
+
+		evalCtx := notifier.notified
+		assert.Equal(t, "[OK] Disk full", evalCtx.GetNotificationTitle())
+		assert.False(t, evalCtx.Firing)
+		assert.Empty(t, evalCtx.EvalMatches)
+		assert.Empty(t, evalCtx.ImagePublicURL)
+	})
+
+	t.Run("Should reject the states without notifications", func(t *testing.T) {
+		cmd := &NotificationTestCommand{
+			Type:     "webhook-test",
+			Settings: simplejson.New(),
+			Alert:    &NotificationTestAlert{State: models.AlertStatePaused},
+		}
+		assert.Equal(t, ErrInvalidTestAlertState, handleNotificationTestCommand(context.Background(), cmd))
+	})
+
+	t.Run("Should send the default test alert", func(t *testing.T) {
+		cmd := &NotificationTestCommand{Type: "webhook-test", Settings: simplejson.New()}
+		require.NoError(t, handleNotificationTestCommand(context.Background(), cmd))
+
+		evalCtx := notifier.notified
+		assert.Equal(t, "[Alerting] Test notification", evalCtx.GetNotificationTitle())
+		assert.EqualError(t, evalCtx.Error, "This is only a test")
+		assert.Len(t, cmd.Result, 1)
+	})
+}
@@ -0,0 +1,97 @@
+package external
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// client sends requests to the url of a data source with its credentials
+type client struct {
+	ds *models.DataSource
+}
+
+func (c *client) do(method string, uriPath string, params url.Values, body interface{}, result interface{}) error {
+	u, err := url.Parse(c.ds.Url)
+	if err != nil {
+		return err
+	}
+	u.Path = path.Join(u.Path, uriPath)
+	u.RawQuery = params.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, u.String(), reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("User-Agent", "Grafana")
+	req.Header.Set("Content-Type", "application/json")
+
+	if c.ds.BasicAuth {
+		password, err := c.ds.DecryptedBasicAuthPassword()
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(c.ds.BasicAuthUser, password)
+	}
+
+	if !c.ds.BasicAuth && c.ds.User != "" {
+		password, err := c.ds.DecryptedPassword()
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(c.ds.User, password)
+	}
+
+	httpClient, err := c.ds.GetHttpClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode/100 != 2 {
+		if res.StatusCode == http.StatusNotFound {
+			return errNotFound
+		}
+		return fmt.Errorf("%s %s returned %s: %s", method, uriPath, res.Status, truncate(string(b), 200))
+	}
+
+	if result == nil || len(b) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(b, result)
+}
+
+func truncate(s string, length int) string {
+	if len(s) > length {
+		return s[:length] + "..."
+	}
+	return s
+}
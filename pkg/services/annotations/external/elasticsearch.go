@@ -0,0 +1,239 @@
+package external
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations"
+)
+
+// elasticsearchStore indexes annotations as documents in the index of an Elasticsearch
+// data source. The time field of the data source is set to the time of the annotation, so
+// annotations can also be queried with the data source.
+type elasticsearchStore struct {
+	client    *client
+	index     string
+	timeField string
+}
+
+type elasticsearchSearchResponse struct {
+	Hits struct {
+		Hits []struct {
+			Source annotations.Item `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+type elasticsearchGetResponse struct {
+	Found  bool             `json:"found"`
+	Source annotations.Item `json:"_source"`
+}
+
+func newElasticsearchStore(ds *models.DataSource) (*elasticsearchStore, error) {
+	jsonData := ds.JsonData
+	if jsonData == nil {
+		jsonData = simplejson.New()
+	}
+
+	if ds.Database == "" {
+		return nil, errors.New("Elasticsearch annotation stores need an index name")
+	}
+
+	if jsonData.Get("interval").MustString() != "" {
+		return nil, errors.New("Elasticsearch annotation stores need an index name without a pattern")
+	}
+
+	return &elasticsearchStore{
+		client:    &client{ds: ds},
+		index:     ds.Database,
+		timeField: jsonData.Get("timeField").MustString("@timestamp"),
+	}, nil
+}
+
+func (s *elasticsearchStore) Save(item *annotations.Item) error {
+	if err := prepareItem(item); err != nil {
+		return err
+	}
+
+	return s.write(item)
+}
+
+func (s *elasticsearchStore) Update(item *annotations.Item) error {
+	var res elasticsearchGetResponse
+	err := s.client.do("GET", s.docPath(item.Id), nil, nil, &res)
+	if err == errNotFound || (err == nil && (!res.Found || res.Source.OrgId != item.OrgId)) {
+		return ErrAnnotationNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	existing := res.Source
+	existing.Updated = time.Now().UnixNano() / int64(time.Millisecond)
+	existing.Text = item.Text
+
+	if item.Epoch != 0 {
+		existing.Epoch = item.Epoch
+	}
+	if item.EpochEnd != 0 {
+		existing.EpochEnd = item.EpochEnd
+	}
+	if item.Tags != nil {
+		existing.Tags = models.JoinTagPairs(models.ParseTagPairs(item.Tags))
+	}
+
+	if err := validateTimeRange(&existing); err != nil {
+		return err
+	}
+
+	return s.write(&existing)
+}
+
+func (s *elasticsearchStore) Find(query *annotations.ItemQuery) ([]*annotations.ItemDTO, error) {
+	limit := query.Limit
+	if limit == 0 {
+		limit = 100
+	}
+
+	search := map[string]interface{}{
+		"size": limit,
+		"sort": []interface{}{
+			map[string]interface{}{"epochEnd": "desc"},
+			map[string]interface{}{"epoch": "desc"},
+		},
+		"query": s.filter(query),
+	}
+
+	var res elasticsearchSearchResponse
+	err := s.client.do("POST", s.index+"/_search", nil, search, &res)
+	if err == errNotFound {
+		// nothing has been written to the index yet
+		return []*annotations.ItemDTO{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*annotations.ItemDTO, 0, len(res.Hits.Hits))
+	for i := range res.Hits.Hits {
+		items = append(items, toItemDTO(&res.Hits.Hits[i].Source))
+	}
+
+	return items, nil
+}
+
+func (s *elasticsearchStore) Delete(params *annotations.DeleteParams) error {
+	filters := []interface{}{term("orgId", params.OrgId)}
+	if params.Id != 0 {
+		filters = append(filters, term("id", params.Id))
+	} else {
+		filters = append(filters, term("dashboardId", params.DashboardId), term("panelId", params.PanelId))
+	}
+
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{"filter": filters},
+		},
+	}
+
+	urlParams := url.Values{}
+	urlParams.Set("refresh", "true")
+
+	err := s.client.do("POST", s.index+"/_delete_by_query", urlParams, query, nil)
+	if err == errNotFound {
+		return nil
+	}
+	return err
+}
+
+// write indexes the annotation and waits for it to be searchable, so that it is returned
+// when the dashboard reloads its annotations
+func (s *elasticsearchStore) write(item *annotations.Item) error {
+	doc := map[string]interface{}{
+		"id":          item.Id,
+		"orgId":       item.OrgId,
+		"userId":      item.UserId,
+		"dashboardId": item.DashboardId,
+		"panelId":     item.PanelId,
+		"alertId":     item.AlertId,
+		"prevState":   item.PrevState,
+		"newState":    item.NewState,
+		"text":        item.Text,
+		"tags":        item.Tags,
+		"data":        item.Data,
+		"epoch":       item.Epoch,
+		"epochEnd":    item.EpochEnd,
+		"created":     item.Created,
+		"updated":     item.Updated,
+	}
+	doc[s.timeField] = item.Epoch
+
+	params := url.Values{}
+	params.Set("refresh", "wait_for")
+
+	return s.client.do("PUT", s.docPath(item.Id), params, doc, nil)
+}
+
+func (s *elasticsearchStore) docPath(id int64) string {
+	return s.index + "/_doc/" + strconv.FormatInt(id, 10)
+}
+
+func (s *elasticsearchStore) filter(query *annotations.ItemQuery) map[string]interface{} {
+	filters := []interface{}{term("orgId", query.OrgId)}
+
+	if query.AnnotationId != 0 {
+		filters = append(filters, term("id", query.AnnotationId))
+	}
+	if query.AlertId != 0 {
+		filters = append(filters, term("alertId", query.AlertId))
+	}
+	if query.DashboardId != 0 {
+		filters = append(filters, term("dashboardId", query.DashboardId))
+	}
+	if query.PanelId != 0 {
+		filters = append(filters, term("panelId", query.PanelId))
+	}
+	if query.UserId != 0 {
+		filters = append(filters, term("userId", query.UserId))
+	}
+
+	if query.From > 0 && query.To > 0 {
+		filters = append(filters,
+			map[string]interface{}{"range": map[string]interface{}{"epoch": map[string]interface{}{"lte": query.To}}},
+			map[string]interface{}{"range": map[string]interface{}{"epochEnd": map[string]interface{}{"gte": query.From}}},
+		)
+	}
+
+	if query.Type == "alert" {
+		filters = append(filters, map[string]interface{}{"range": map[string]interface{}{"alertId": map[string]interface{}{"gt": 0}}})
+	} else if query.Type == "annotation" {
+		filters = append(filters, term("alertId", 0))
+	}
+
+	boolQuery := map[string]interface{}{"filter": filters}
+
+	if len(query.Tags) > 0 {
+		// match_phrase finds tags whether they are mapped as text or keyword
+		tagQueries := make([]interface{}, 0)
+		for _, tag := range models.JoinTagPairs(models.ParseTagPairs(query.Tags)) {
+			tagQueries = append(tagQueries, map[string]interface{}{"match_phrase": map[string]interface{}{"tags": tag}})
+		}
+
+		if query.MatchAny {
+			boolQuery["should"] = tagQueries
+			boolQuery["minimum_should_match"] = 1
+		} else {
+			boolQuery["must"] = tagQueries
+		}
+	}
+
+	return map[string]interface{}{"bool": boolQuery}
+}
+
+func term(field string, value interface{}) map[string]interface{} {
+	return map[string]interface{}{"term": map[string]interface{}{field: value}}
+}
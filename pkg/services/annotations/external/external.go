@@ -0,0 +1,241 @@
+package external
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/annotations"
+)
+
+var (
+	ErrAnnotationNotFound = errors.New("Annotation not found")
+	ErrReadOnly           = errors.New("Annotations stored in Loki cannot be updated or deleted")
+
+	errNotFound = errors.New("not found")
+)
+
+// storeCacheTTL is how long the annotation store of an organization is cached, changes to
+// the store or its data source take effect after at most this long
+const storeCacheTTL = 10 * time.Second
+
+func init() {
+	registry.RegisterService(&AnnotationStoreService{})
+}
+
+// AnnotationStoreService replaces the annotation repository with one that stores the
+// annotations of organizations with an annotation store in Loki or Elasticsearch, and
+// the annotations of all other organizations in the Grafana database.
+type AnnotationStoreService struct {
+	CacheService *localcache.CacheService `inject:""`
+
+	log log.Logger
+	sql annotations.Repository
+}
+
+func (s *AnnotationStoreService) Init() error {
+	s.log = log.New("annotations.external")
+	s.sql = annotations.GetRepository()
+
+	annotations.SetRepository(s)
+	return nil
+}
+
+func (s *AnnotationStoreService) Save(item *annotations.Item) error {
+	repo, err := s.repositoryFor(item.OrgId)
+	if err != nil {
+		return err
+	}
+
+	return repo.Save(item)
+}
+
+func (s *AnnotationStoreService) Update(item *annotations.Item) error {
+	repo, err := s.repositoryFor(item.OrgId)
+	if err != nil {
+		return err
+	}
+
+	return repo.Update(item)
+}
+
+func (s *AnnotationStoreService) Find(query *annotations.ItemQuery) ([]*annotations.ItemDTO, error) {
+	repo, err := s.repositoryFor(query.OrgId)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := repo.Find(query)
+	if err != nil || repo == s.sql {
+		return items, err
+	}
+
+	addUsersAndAlerts(items)
+	return items, nil
+}
+
+func (s *AnnotationStoreService) Delete(params *annotations.DeleteParams) error {
+	repo, err := s.repositoryFor(params.OrgId)
+	if err != nil {
+		return err
+	}
+
+	return repo.Delete(params)
+}
+
+func (s *AnnotationStoreService) repositoryFor(orgId int64) (annotations.Repository, error) {
+	cacheKey := fmt.Sprintf("annotation-store-%d", orgId)
+	if cached, found := s.CacheService.Get(cacheKey); found {
+		return cached.(annotations.Repository), nil
+	}
+
+	query := models.GetAnnotationStoreQuery{OrgId: orgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+
+	repo := s.sql
+	if query.Result != nil {
+		dsQuery := models.GetDataSourceByIdQuery{Id: query.Result.DatasourceId, OrgId: orgId}
+		if err := bus.Dispatch(&dsQuery); err != nil {
+			return nil, err
+		}
+
+		var err error
+		repo, err = newRepository(dsQuery.Result)
+		if err != nil {
+			s.log.Error("Invalid annotation store", "orgId", orgId, "datasource", dsQuery.Result.Name, "error", err)
+			return nil, err
+		}
+	}
+
+	s.CacheService.Set(cacheKey, repo, storeCacheTTL)
+	return repo, nil
+}
+
+func newRepository(ds *models.DataSource) (annotations.Repository, error) {
+	switch ds.Type {
+	case models.DS_LOKI:
+		return newLokiStore(ds), nil
+	case models.DS_ES:
+		return newElasticsearchStore(ds)
+	}
+
+	return nil, models.ErrAnnotationStoreInvalidDataSource
+}
+
+// prepareItem sets the fields of a new annotation the same way the Grafana database does
+func prepareItem(item *annotations.Item) error {
+	item.Id = newId()
+	item.Tags = models.JoinTagPairs(models.ParseTagPairs(item.Tags))
+	item.Created = time.Now().UnixNano() / int64(time.Millisecond)
+	item.Updated = item.Created
+
+	if item.Epoch == 0 {
+		item.Epoch = item.Created
+	}
+
+	return validateTimeRange(item)
+}
+
+// validateTimeRange updates the item so that EpochEnd >= Epoch
+func validateTimeRange(item *annotations.Item) error {
+	if item.EpochEnd == 0 {
+		if item.Epoch == 0 {
+			return errors.New("Missing Time Range")
+		}
+		item.EpochEnd = item.Epoch
+	}
+	if item.Epoch == 0 {
+		item.Epoch = item.EpochEnd
+	}
+	if item.EpochEnd < item.Epoch {
+		item.Epoch, item.EpochEnd = item.EpochEnd, item.Epoch
+	}
+	return nil
+}
+
+var (
+	idMutex sync.Mutex
+	lastId  int64
+)
+
+// newId returns a unique id for an annotation. Ids are the creation time in microseconds,
+// which keeps them within the integers JavaScript can represent.
+func newId() int64 {
+	idMutex.Lock()
+	defer idMutex.Unlock()
+
+	id := time.Now().UnixNano() / int64(time.Microsecond)
+	if id <= lastId {
+		id = lastId + 1
+	}
+	lastId = id
+
+	return id
+}
+
+func toItemDTO(item *annotations.Item) *annotations.ItemDTO {
+	return &annotations.ItemDTO{
+		Id:          item.Id,
+		AlertId:     item.AlertId,
+		DashboardId: item.DashboardId,
+		PanelId:     item.PanelId,
+		UserId:      item.UserId,
+		NewState:    item.NewState,
+		PrevState:   item.PrevState,
+		Created:     item.Created,
+		Updated:     item.Updated,
+		Time:        item.Epoch,
+		TimeEnd:     item.EpochEnd,
+		Text:        item.Text,
+		Tags:        item.Tags,
+		Data:        item.Data,
+	}
+}
+
+// addUsersAndAlerts adds the users and alert names that the Grafana database joins to
+// annotations
+func addUsersAndAlerts(items []*annotations.ItemDTO) {
+	users := make(map[int64]*models.User)
+	alerts := make(map[int64]*models.Alert)
+
+	for _, item := range items {
+		if item.UserId != 0 {
+			user, ok := users[item.UserId]
+			if !ok {
+				query := models.GetUserByIdQuery{Id: item.UserId}
+				if err := bus.Dispatch(&query); err == nil {
+					user = query.Result
+				}
+				users[item.UserId] = user
+			}
+
+			if user != nil {
+				item.Login = user.Login
+				item.Email = user.Email
+			}
+		}
+
+		if item.AlertId != 0 {
+			alert, ok := alerts[item.AlertId]
+			if !ok {
+				query := models.GetAlertByIdQuery{Id: item.AlertId}
+				if err := bus.Dispatch(&query); err == nil {
+					alert = query.Result
+				}
+				alerts[item.AlertId] = alert
+			}
+
+			if alert != nil {
+				item.AlertName = alert.Name
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package external
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations"
+)
+
+type request struct {
+	method string
+	path   string
+	query  string
+	body   map[string]interface{}
+}
+
+func newServer(t *testing.T, response string) (*httptest.Server, *[]request) {
+	requests := make([]request, 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := request{method: r.Method, path: r.URL.Path, query: r.URL.RawQuery}
+
+		b, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		if len(b) > 0 {
+			require.NoError(t, json.Unmarshal(b, &req.body))
+		}
+
+		requests = append(requests, req)
+		_, _ = w.Write([]byte(response))
+	}))
+
+	return server, &requests
+}
+
+func TestLokiStore(t *testing.T) {
+	t.Run("Writes annotations as log lines", func(t *testing.T) {
+		server, requests := newServer(t, "")
+		defer server.Close()
+
+		store := newLokiStore(&models.DataSource{Id: 1, Type: models.DS_LOKI, Url: server.URL})
+		item := &annotations.Item{OrgId: 1, DashboardId: 2, PanelId: 3, Epoch: 1600000000000, Text: "deploy", Tags: []string{"env:prod"}}
+		err := store.Save(item)
+		require.NoError(t, err)
+		require.NotZero(t, item.Id)
+		require.Equal(t, item.Epoch, item.EpochEnd)
+
+		require.Len(t, *requests, 1)
+		req := (*requests)[0]
+		require.Equal(t, "/loki/api/v1/push", req.path)
+
+		stream := req.body["streams"].([]interface{})[0].(map[string]interface{})
+		require.Equal(t, map[string]interface{}{"source": "grafana", "org_id": "1", "dashboard_id": "2", "type": "annotation"}, stream["stream"])
+
+		value := stream["values"].([]interface{})[0].([]interface{})
+		require.Equal(t, "1600000000000000000", value[0])
+		require.Contains(t, value[1], `"text":"deploy"`)
+
+		require.Equal(t, ErrReadOnly, store.Update(item))
+		require.Equal(t, ErrReadOnly, store.Delete(&annotations.DeleteParams{OrgId: 1, Id: item.Id}))
+	})
+
+	t.Run("Filters the queried log lines", func(t *testing.T) {
+		server, requests := newServer(t, `{"data":{"resultType":"streams","result":[{"stream":{},"values":[
+			["1600000000000000000","{\"id\":1,\"panelId\":3,\"epoch\":1600000000000,\"epochEnd\":1600000000000,\"tags\":[\"env:prod\"]}"],
+			["1600000001000000000","{\"id\":2,\"panelId\":4,\"epoch\":1600000001000,\"epochEnd\":1600000001000,\"tags\":[\"env:prod\"]}"],
+			["1600000002000000000","{\"id\":3,\"panelId\":3,\"epoch\":1600000002000,\"epochEnd\":1600000002000,\"tags\":[\"env:dev\"]}"],
+			["1600000003000000000","{\"id\":4,\"panelId\":3,\"epoch\":1600000003000,\"epochEnd\":1600000003000,\"tags\":[\"env:prod\",\"deploy\"]}"]
+		]}]}}`)
+		defer server.Close()
+
+		store := newLokiStore(&models.DataSource{Id: 2, Type: models.DS_LOKI, Url: server.URL})
+		items, err := store.Find(&annotations.ItemQuery{OrgId: 1, DashboardId: 2, PanelId: 3, Tags: []string{"env:prod"}, Type: "annotation"})
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		require.Equal(t, int64(4), items[0].Id)
+		require.Equal(t, int64(1), items[1].Id)
+
+		require.Equal(t, "/loki/api/v1/query_range", (*requests)[0].path)
+		require.Contains(t, (*requests)[0].query, "query=%7Bsource%3D%22grafana%22%2Corg_id%3D%221%22%2Cdashboard_id%3D%222%22%2Ctype%3D%22annotation%22%7D")
+	})
+}
+
+func TestElasticsearchStore(t *testing.T) {
+	ds := func(url string, jsonData map[string]interface{}) *models.DataSource {
+		return &models.DataSource{Id: 3, Type: models.DS_ES, Url: url, Database: "annotations", JsonData: simplejson.NewFromAny(jsonData)}
+	}
+
+	t.Run("Requires an index without a pattern", func(t *testing.T) {
+		_, err := newElasticsearchStore(ds("http://es", map[string]interface{}{"interval": "Daily"}))
+		require.Error(t, err)
+	})
+
+	t.Run("Indexes annotations with the time field of the data source", func(t *testing.T) {
+		server, requests := newServer(t, "{}")
+		defer server.Close()
+
+		store, err := newElasticsearchStore(ds(server.URL, map[string]interface{}{"timeField": "time"}))
+		require.NoError(t, err)
+
+		item := &annotations.Item{OrgId: 1, Epoch: 1600000000000, Text: "deploy"}
+		err = store.Save(item)
+		require.NoError(t, err)
+
+		req := (*requests)[0]
+		require.Equal(t, "PUT", req.method)
+		require.Equal(t, "/annotations/_doc/"+jsonNumber(item.Id), req.path)
+		require.Equal(t, "refresh=wait_for", req.query)
+		require.Equal(t, float64(1600000000000), req.body["time"])
+		require.Equal(t, "deploy", req.body["text"])
+	})
+
+	t.Run("Searches annotations with filters", func(t *testing.T) {
+		server, requests := newServer(t, `{"hits":{"hits":[{"_source":{"id":1,"orgId":1,"epoch":1600000000000,"epochEnd":1600000000000,"text":"deploy"}}]}}`)
+		defer server.Close()
+
+		store, err := newElasticsearchStore(ds(server.URL, nil))
+		require.NoError(t, err)
+
+		items, err := store.Find(&annotations.ItemQuery{OrgId: 1, DashboardId: 2, From: 1, To: 2, Tags: []string{"env:prod"}, MatchAny: true})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		require.Equal(t, "deploy", items[0].Text)
+		require.Equal(t, int64(1600000000000), items[0].Time)
+
+		req := (*requests)[0]
+		require.Equal(t, "/annotations/_search", req.path)
+		require.Equal(t, float64(100), req.body["size"])
+
+		query := req.body["query"].(map[string]interface{})["bool"].(map[string]interface{})
+		require.Len(t, query["filter"], 4)
+		require.Equal(t, []interface{}{map[string]interface{}{"match_phrase": map[string]interface{}{"tags": "env:prod"}}}, query["should"])
+	})
+
+	t.Run("Returns no annotations before the index exists", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(404)
+		}))
+		defer server.Close()
+
+		store, err := newElasticsearchStore(ds(server.URL, nil))
+		require.NoError(t, err)
+
+		items, err := store.Find(&annotations.ItemQuery{OrgId: 1})
+		require.NoError(t, err)
+		require.Len(t, items, 0)
+
+		err = store.Update(&annotations.Item{OrgId: 1, Id: 1})
+		require.Equal(t, ErrAnnotationNotFound, err)
+	})
+}
+
+func TestNewId(t *testing.T) {
+	first := newId()
+	second := newId()
+	require.Greater(t, second, first)
+	// ids must stay below Number.MAX_SAFE_INTEGER
+	require.Less(t, second, int64(1<<53))
+}
+
+func jsonNumber(n int64) string {
+	b, _ := json.Marshal(n)
+	return string(b)
+}
@@ -0,0 +1,210 @@
+package external
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations"
+)
+
+// lokiQueryRange is the time range annotations are looked up in when the query has none
+const lokiQueryRange = 7 * 24 * time.Hour
+
+// lokiStore writes annotations as JSON log lines to Loki. Loki is append only, so the
+// annotations can not be updated or deleted, and are filtered after they are queried.
+type lokiStore struct {
+	client *client
+}
+
+func newLokiStore(ds *models.DataSource) *lokiStore {
+	return &lokiStore{client: &client{ds: ds}}
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type lokiPushRequest struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiQueryResponse struct {
+	Data struct {
+		ResultType string       `json:"resultType"`
+		Result     []lokiStream `json:"result"`
+	} `json:"data"`
+}
+
+func (s *lokiStore) Save(item *annotations.Item) error {
+	if err := prepareItem(item); err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	ts := strconv.FormatInt(item.Epoch*int64(time.Millisecond), 10)
+	req := lokiPushRequest{
+		Streams: []lokiStream{{
+			Stream: lokiLabels(item.OrgId, item.DashboardId, item.AlertId != 0),
+			Values: [][2]string{{ts, string(line)}},
+		}},
+	}
+
+	return s.client.do("POST", "/loki/api/v1/push", nil, req, nil)
+}
+
+func (s *lokiStore) Update(item *annotations.Item) error {
+	return ErrReadOnly
+}
+
+func (s *lokiStore) Delete(params *annotations.DeleteParams) error {
+	return ErrReadOnly
+}
+
+func (s *lokiStore) Find(query *annotations.ItemQuery) ([]*annotations.ItemDTO, error) {
+	to := time.Now()
+	from := to.Add(-lokiQueryRange)
+	if query.From > 0 && query.To > 0 {
+		from = time.Unix(0, query.From*int64(time.Millisecond))
+		to = time.Unix(0, query.To*int64(time.Millisecond))
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = 100
+	}
+
+	params := url.Values{}
+	params.Set("query", lokiSelector(query))
+	params.Set("start", strconv.FormatInt(from.UnixNano(), 10))
+	params.Set("end", strconv.FormatInt(to.UnixNano(), 10))
+	params.Set("direction", "backward")
+	// filters that are not labels are applied after the query, so fetch more lines than needed
+	params.Set("limit", strconv.FormatInt(limit*10, 10))
+
+	var res lokiQueryResponse
+	if err := s.client.do("GET", "/loki/api/v1/query_range", params, nil, &res); err != nil {
+		return nil, err
+	}
+
+	items := make([]*annotations.ItemDTO, 0)
+	for _, stream := range res.Data.Result {
+		for _, value := range stream.Values {
+			item := annotations.Item{}
+			if err := json.Unmarshal([]byte(value[1]), &item); err != nil {
+				continue
+			}
+
+			if matches(query, &item) {
+				items = append(items, toItemDTO(&item))
+			}
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].TimeEnd != items[j].TimeEnd {
+			return items[i].TimeEnd > items[j].TimeEnd
+		}
+		return items[i].Time > items[j].Time
+	})
+
+	if int64(len(items)) > limit {
+		items = items[:limit]
+	}
+
+	return items, nil
+}
+
+// lokiLabels keeps the number of streams low, every other field is part of the log line
+func lokiLabels(orgId int64, dashboardId int64, alert bool) map[string]string {
+	labels := map[string]string{
+		"source":       "grafana",
+		"org_id":       strconv.FormatInt(orgId, 10),
+		"dashboard_id": strconv.FormatInt(dashboardId, 10),
+		"type":         "annotation",
+	}
+
+	if alert {
+		labels["type"] = "alert"
+	}
+
+	return labels
+}
+
+func lokiSelector(query *annotations.ItemQuery) string {
+	matchers := []string{
+		`source="grafana"`,
+		fmt.Sprintf(`org_id="%d"`, query.OrgId),
+	}
+
+	if query.DashboardId != 0 {
+		matchers = append(matchers, fmt.Sprintf(`dashboard_id="%d"`, query.DashboardId))
+	}
+
+	if query.Type == "alert" || query.Type == "annotation" {
+		matchers = append(matchers, fmt.Sprintf(`type="%s"`, query.Type))
+	}
+
+	selector := "{" + strings.Join(matchers, ",") + "}"
+	if query.AnnotationId != 0 {
+		selector += fmt.Sprintf(` |= "\"id\":%d,"`, query.AnnotationId)
+	}
+
+	return selector
+}
+
+// matches applies the filters of the query that Loki can not
+func matches(query *annotations.ItemQuery, item *annotations.Item) bool {
+	if query.AnnotationId != 0 && item.Id != query.AnnotationId {
+		return false
+	}
+	if query.AlertId != 0 && item.AlertId != query.AlertId {
+		return false
+	}
+	if query.PanelId != 0 && item.PanelId != query.PanelId {
+		return false
+	}
+	if query.UserId != 0 && item.UserId != query.UserId {
+		return false
+	}
+	if query.From > 0 && query.To > 0 && (item.Epoch > query.To || item.EpochEnd < query.From) {
+		return false
+	}
+
+	if len(query.Tags) == 0 {
+		return true
+	}
+
+	itemTags := make(map[string]bool)
+	for _, tag := range models.ParseTagPairs(item.Tags) {
+		itemTags[tag.Key] = true
+		itemTags[tag.Key+":"+tag.Value] = true
+	}
+
+	matched := 0
+	tags := models.ParseTagPairs(query.Tags)
+	for _, tag := range tags {
+		key := tag.Key
+		if tag.Value != "" {
+			key += ":" + tag.Value
+		}
+		if itemTags[key] {
+			matched++
+		}
+	}
+
+	if query.MatchAny {
+		return matched > 0
+	}
+	return matched == len(tags)
+}
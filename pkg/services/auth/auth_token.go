@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/serverlock"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
@@ -19,6 +20,7 @@ import (
 
 func init() {
 	registry.RegisterService(&UserAuthTokenService{})
+	remotecache.Register(userAuthToken{})
 }
 
 var getTime = time.Now
@@ -28,6 +30,7 @@ const urgentRotateTime = 1 * time.Minute
 type UserAuthTokenService struct {
 	SQLStore          *sqlstore.SqlStore            `inject:""`
 	ServerLockService *serverlock.ServerLockService `inject:""`
+	RemoteCache       *remotecache.RemoteCache      `inject:""`
 	Cfg               *setting.Cfg                  `inject:""`
 	log               log.Logger
 }
@@ -107,6 +110,14 @@ func (s *UserAuthTokenService) LookupToken(ctx context.Context, unhashedToken st
 		s.log.Debug("looking up token", "unhashed", unhashedToken, "hashed", hashedToken)
 	}
 
+	if model, cached := s.getCachedToken(hashedToken); cached {
+		model.UnhashedToken = unhashedToken
+
+		var userToken models.UserToken
+		err := model.toUserToken(&userToken)
+		return &userToken, err
+	}
+
 	var model userAuthToken
 	var exists bool
 	var err error
@@ -187,6 +198,11 @@ func (s *UserAuthTokenService) LookupToken(ctx context.Context, unhashedToken st
 		}
 	}
 
+	// only seen tokens are cached, the lookup of the other tokens updates them
+	if model.AuthTokenSeen && model.AuthToken == hashedToken {
+		s.cacheToken(hashedToken, model)
+	}
+
 	model.UnhashedToken = unhashedToken
 
 	var userToken models.UserToken
@@ -264,6 +280,7 @@ func (s *UserAuthTokenService) TryRotateToken(ctx context.Context, token *models
 
 	s.log.Debug("auth token rotated", "affected", affected, "auth_token_id", model.Id, "userId", model.UserId)
 	if affected > 0 {
+		s.evictCachedTokens(model.AuthToken, model.PrevAuthToken)
 		model.UnhashedToken = newToken
 		if err := model.toUserToken(token); err != nil {
 			return false, err
@@ -294,6 +311,8 @@ func (s *UserAuthTokenService) RevokeToken(ctx context.Context, token *models.Us
 		return err
 	}
 
+	s.evictCachedTokens(model.AuthToken, model.PrevAuthToken)
+
 	if rowsAffected == 0 {
 		s.log.Debug("user auth token not found/revoked", "tokenId", model.Id, "userId", model.UserId, "clientIP", model.ClientIp, "userAgent", model.UserAgent)
 		return models.ErrUserTokenNotFound
@@ -306,6 +325,10 @@ func (s *UserAuthTokenService) RevokeToken(ctx context.Context, token *models.Us
 
 func (s *UserAuthTokenService) RevokeAllUserTokens(ctx context.Context, userId int64) error {
 	return s.SQLStore.WithDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
+		if err := s.evictCachedUserTokens(dbSession, []int64{userId}); err != nil {
+			return err
+		}
+
 		sql := `DELETE from user_auth_token WHERE user_id = ?`
 		res, err := dbSession.Exec(sql, userId)
 		if err != nil {
@@ -329,6 +352,10 @@ func (s *UserAuthTokenService) BatchRevokeAllUserTokens(ctx context.Context, use
 			return nil
 		}
 
+		if err := s.evictCachedUserTokens(dbSession, userIds); err != nil {
+			return err
+		}
+
 		user_id_params := strings.Repeat(",?", len(userIds)-1)
 		sql := "DELETE from user_auth_token WHERE user_id IN (?" + user_id_params + ")"
 
@@ -401,6 +428,77 @@ func (s *UserAuthTokenService) GetUserTokens(ctx context.Context, userId int64)
 	return result, err
 }
 
+func (s *UserAuthTokenService) tokenCacheEnabled() bool {
+	return s.Cfg.TokenCacheEnabled && s.RemoteCache != nil
+}
+
+// getCachedToken returns a seen token from the remote cache, as long as it hasn't expired.
+func (s *UserAuthTokenService) getCachedToken(hashedToken string) (userAuthToken, bool) {
+	if !s.tokenCacheEnabled() {
+		return userAuthToken{}, false
+	}
+
+	item, err := s.RemoteCache.Get(tokenCacheKey(hashedToken))
+	if err != nil {
+		if err != remotecache.ErrCacheItemNotFound {
+			s.log.Warn("Failed to get auth token from the remote cache", "error", err)
+		}
+		return userAuthToken{}, false
+	}
+
+	model, ok := item.(userAuthToken)
+	if !ok || model.CreatedAt <= s.createdAfterParam() || model.RotatedAt <= s.rotatedAfterParam() {
+		return userAuthToken{}, false
+	}
+
+	return model, true
+}
+
+func (s *UserAuthTokenService) cacheToken(hashedToken string, model userAuthToken) {
+	if !s.tokenCacheEnabled() {
+		return
+	}
+
+	model.UnhashedToken = ""
+	if err := s.RemoteCache.Set(tokenCacheKey(hashedToken), model, s.Cfg.TokenCacheTTL); err != nil {
+		s.log.Warn("Failed to set auth token in the remote cache", "error", err)
+	}
+}
+
+// evictCachedTokens removes tokens from the remote cache after they were rotated or revoked, so
+// that every Grafana server looks them up in the database again.
+func (s *UserAuthTokenService) evictCachedTokens(hashedTokens ...string) {
+	if !s.tokenCacheEnabled() {
+		return
+	}
+
+	for _, hashedToken := range hashedTokens {
+		if err := s.RemoteCache.Delete(tokenCacheKey(hashedToken)); err != nil && err != remotecache.ErrCacheItemNotFound {
+			s.log.Warn("Failed to delete auth token from the remote cache", "error", err)
+		}
+	}
+}
+
+func (s *UserAuthTokenService) evictCachedUserTokens(dbSession *sqlstore.DBSession, userIds []int64) error {
+	if !s.tokenCacheEnabled() {
+		return nil
+	}
+
+	var tokens []*userAuthToken
+	if err := dbSession.In("user_id", userIds).Find(&tokens); err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		s.evictCachedTokens(token.AuthToken, token.PrevAuthToken)
+	}
+	return nil
+}
+
+func tokenCacheKey(hashedToken string) string {
+	return "auth-token-" + hashedToken
+}
+
 func (s *UserAuthTokenService) createdAfterParam() int64 {
 	tokenMaxLifetime := time.Duration(s.Cfg.LoginMaxLifetimeDays) * 24 * time.Hour
 	return getTime().Add(-tokenMaxLifetime).Unix()
@@ -10,9 +10,11 @@ import (
 	"github.com/grafana/grafana/pkg/setting"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUserAuthToken(t *testing.T) {
@@ -492,6 +494,76 @@ func TestUserAuthToken(t *testing.T) {
 	})
 }
 
+func TestUserAuthTokenCache(t *testing.T) {
+	ctx := createTestContext(t)
+	s := ctx.tokenService
+	s.Cfg.TokenCacheEnabled = true
+	s.Cfg.TokenCacheTTL = time.Minute
+	s.RemoteCache = &remotecache.RemoteCache{
+		SQLStore: ctx.sqlstore,
+		Cfg:      &setting.Cfg{RemoteCacheOptions: &setting.RemoteCacheOptions{Name: "database"}},
+	}
+	require.NoError(t, s.RemoteCache.Init())
+
+	now := time.Date(2018, 12, 13, 13, 45, 0, 0, time.UTC)
+	getTime = func() time.Time { return now }
+	t.Cleanup(func() { getTime = time.Now })
+
+	userToken, err := s.CreateToken(context.Background(), 10, "192.168.10.11:1234", "some user agent")
+	require.NoError(t, err)
+
+	t.Run("Unseen tokens aren't cached", func(t *testing.T) {
+		_, cached := s.getCachedToken(userToken.AuthToken)
+		require.False(t, cached)
+	})
+
+	t.Run("Seen tokens are looked up in the cache", func(t *testing.T) {
+		_, err := s.LookupToken(context.Background(), userToken.UnhashedToken)
+		require.NoError(t, err)
+		_, cached := s.getCachedToken(userToken.AuthToken)
+		require.True(t, cached)
+
+		_, err = ctx.sqlstore.NewSession().Exec("UPDATE user_auth_token SET user_agent = ? WHERE id = ?", "other user agent", userToken.Id)
+		require.NoError(t, err)
+		lookedUp, err := s.LookupToken(context.Background(), userToken.UnhashedToken)
+		require.NoError(t, err)
+		require.Equal(t, "some user agent", lookedUp.UserAgent)
+		require.Equal(t, userToken.UnhashedToken, lookedUp.UnhashedToken)
+	})
+
+	t.Run("Expired tokens aren't served from the cache", func(t *testing.T) {
+		now = now.Add(8 * 24 * time.Hour)
+		_, cached := s.getCachedToken(userToken.AuthToken)
+		require.False(t, cached)
+		now = now.Add(-8 * 24 * time.Hour)
+	})
+
+	t.Run("Rotated tokens are evicted from the cache", func(t *testing.T) {
+		lookedUp, err := s.LookupToken(context.Background(), userToken.UnhashedToken)
+		require.NoError(t, err)
+
+		now = now.Add(11 * time.Minute)
+		rotated, err := s.TryRotateToken(context.Background(), lookedUp, "192.168.10.11:1234", "some user agent")
+		require.NoError(t, err)
+		require.True(t, rotated)
+
+		_, cached := s.getCachedToken(userToken.AuthToken)
+		require.False(t, cached)
+		userToken = lookedUp
+	})
+
+	t.Run("Revoked tokens are evicted from the cache", func(t *testing.T) {
+		_, err := s.LookupToken(context.Background(), userToken.UnhashedToken)
+		require.NoError(t, err)
+		_, cached := s.getCachedToken(hashToken(userToken.UnhashedToken))
+		require.True(t, cached)
+
+		require.NoError(t, s.RevokeAllUserTokens(context.Background(), 10))
+		_, err = s.LookupToken(context.Background(), userToken.UnhashedToken)
+		require.Equal(t, models.ErrUserTokenNotFound, err)
+	})
+}
+
 func createTestContext(t *testing.T) *testContext {
 	t.Helper()
 
@@ -8,25 +8,52 @@ import (
 	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/imguploader"
 	"github.com/grafana/grafana/pkg/infra/log"
-	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/infra/scheduler"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
 type CleanUpService struct {
-	log               log.Logger
-	Cfg               *setting.Cfg                  `inject:""`
-	ServerLockService *serverlock.ServerLockService `inject:""`
+	log              log.Logger
+	Cfg              *setting.Cfg                `inject:""`
+	SchedulerService *scheduler.SchedulerService `inject:""`
 }
 
+// newImageUploader is stubbable by tests
+var newImageUploader = imguploader.NewImageUploader
+
 func init() {
 	registry.RegisterService(&CleanUpService{})
 }
 
 func (srv *CleanUpService) Init() error {
 	srv.log = log.New("cleanup")
+
+	// the database is cleaned up by a single instance, while each instance cleans up its own temp files
+	srv.SchedulerService.Schedule("delete expired dashboard versions", time.Minute*10, func(ctx context.Context) error {
+		srv.deleteExpiredDashboardVersions()
+		return nil
+	})
+	srv.SchedulerService.Schedule("delete expired snapshots", time.Minute*10, func(ctx context.Context) error {
+		srv.deleteExpiredSnapshots()
+		return nil
+	})
+	srv.SchedulerService.Schedule("delete rows beyond retention", srv.Cfg.Retention.Interval, func(ctx context.Context) error {
+		srv.deleteRowsBeyondRetention()
+		return nil
+	})
+	srv.SchedulerService.Schedule("delete old usage events", time.Hour, func(ctx context.Context) error {
+		srv.deleteOldUsageEvents()
+		return nil
+	})
+	srv.SchedulerService.Schedule("delete expired external images", time.Hour, func(ctx context.Context) error {
+		srv.deleteExpiredExternalImages(ctx)
+		return nil
+	})
 	return nil
 }
 
@@ -38,15 +65,6 @@ func (srv *CleanUpService) Run(ctx context.Context) error {
 		select {
 		case <-ticker.C:
 			srv.cleanUpTmpFiles()
-			srv.deleteExpiredSnapshots()
-			srv.deleteExpiredDashboardVersions()
-			err := srv.ServerLockService.LockAndExecute(ctx, "delete old login attempts",
-				time.Minute*10, func() {
-					srv.deleteOldLoginAttempts()
-				})
-			if err != nil {
-				srv.log.Error("failed to lock and execute cleanup of old login attempts", "error", err)
-			}
 		case <-ctx.Done():
 			return ctx.Err()
 		}
@@ -110,17 +128,86 @@ func (srv *CleanUpService) deleteExpiredDashboardVersions() {
 	}
 }
 
-func (srv *CleanUpService) deleteOldLoginAttempts() {
-	if srv.Cfg.DisableBruteForceLoginProtection {
+// retentionPolicies returns the retention policies of the kinds of data cleaned up
+func (srv *CleanUpService) retentionPolicies() map[string]setting.RetentionPolicy {
+	policies := map[string]setting.RetentionPolicy{
+		models.RetentionAnnotations:       srv.Cfg.Retention.Annotations,
+		models.RetentionAlertExecutions:   srv.Cfg.Retention.AlertExecutions,
+		models.RetentionDashboardVersions: srv.Cfg.Retention.DashboardVersions,
+	}
+	// login attempts are only recorded with the brute force login protection
+	if !srv.Cfg.DisableBruteForceLoginProtection {
+		policies[models.RetentionLoginAttempts] = srv.Cfg.Retention.LoginAttempts
+	}
+	return policies
+}
+
+func (srv *CleanUpService) deleteRowsBeyondRetention() {
+	for kind, policy := range srv.retentionPolicies() {
+		if policy.MaxAge == 0 && policy.MaxRows == 0 {
+			continue
+		}
+
+		cmd := models.DeleteRowsBeyondRetentionCommand{
+			Kind:       kind,
+			MaxRows:    policy.MaxRows,
+			BatchSize:  srv.Cfg.Retention.BatchSize,
+			MaxBatches: srv.Cfg.Retention.MaxBatches,
+		}
+		if policy.MaxAge > 0 {
+			cmd.OlderThan = time.Now().Add(-policy.MaxAge)
+		}
+
+		start := time.Now()
+		err := bus.Dispatch(&cmd)
+		metrics.MRetentionCleanupDuration.WithLabelValues(kind).Observe(time.Since(start).Seconds())
+		metrics.MRetentionDeletedRows.WithLabelValues(kind).Add(float64(cmd.DeletedRows))
+		if err != nil {
+			srv.log.Error("Problem deleting rows beyond retention", "kind", kind, "error", err.Error())
+			continue
+		}
+
+		metrics.MRetentionPendingRows.WithLabelValues(kind).Set(float64(cmd.PendingRows))
+		srv.log.Debug("Deleted rows beyond retention", "kind", kind, "rows affected", cmd.DeletedRows, "pending", cmd.PendingRows)
+	}
+}
+
+func (srv *CleanUpService) deleteOldUsageEvents() {
+	if srv.Cfg.UsageInsights.SQLRetentionDays <= 0 {
 		return
 	}
 
-	cmd := models.DeleteOldLoginAttemptsCommand{
-		OlderThan: time.Now().Add(time.Minute * -10),
+	cmd := models.DeleteOldUsageEventsCommand{
+		OlderThan: time.Now().AddDate(0, 0, -srv.Cfg.UsageInsights.SQLRetentionDays),
 	}
 	if err := bus.Dispatch(&cmd); err != nil {
-		srv.log.Error("Problem deleting expired login attempts", "error", err.Error())
+		srv.log.Error("Problem deleting old usage events", "error", err.Error())
 	} else {
-		srv.log.Debug("Deleted expired login attempts", "rows affected", cmd.DeletedRows)
+		srv.log.Debug("Deleted old usage events", "rows affected", cmd.DeletedRows)
+	}
+}
+
+// deleteExpiredExternalImages deletes the images uploaded to the external image storage beyond its
+// retention, for the storages whose uploader can delete them
+func (srv *CleanUpService) deleteExpiredExternalImages(ctx context.Context) {
+	if srv.Cfg.ImageUploadRetention <= 0 {
+		return
+	}
+
+	uploader, err := newImageUploader()
+	if err != nil {
+		srv.log.Error("Failed to create the image uploader", "error", err)
+		return
+	}
+	deleter, ok := uploader.(imguploader.ImageDeleter)
+	if !ok {
+		return
+	}
+
+	deleted, err := deleter.DeleteImagesBefore(ctx, time.Now().Add(-srv.Cfg.ImageUploadRetention))
+	if err != nil {
+		srv.log.Error("Problem deleting expired external images", "deleted", deleted, "error", err)
+		return
 	}
+	srv.log.Debug("Deleted expired external images", "deleted", deleted)
 }
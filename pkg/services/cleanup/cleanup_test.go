@@ -1,9 +1,13 @@
 package cleanup
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana/pkg/components/imguploader"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -40,3 +44,61 @@ func TestCleanUpTmpFiles(t *testing.T) {
 	})
 
 }
+
+func TestRetentionPolicies(t *testing.T) {
+	Convey("Retention policies", t, func() {
+		cfg := setting.Cfg{}
+		cfg.Retention.Annotations = setting.RetentionPolicy{MaxAge: time.Hour}
+		cfg.Retention.LoginAttempts = setting.RetentionPolicy{MaxAge: 10 * time.Minute}
+		service := CleanUpService{
+			Cfg: &cfg,
+		}
+
+		Convey("Should return the policy of every kind of data", func() {
+			policies := service.retentionPolicies()
+			So(policies, ShouldHaveLength, 4)
+			So(policies[models.RetentionAnnotations].MaxAge, ShouldEqual, time.Hour)
+		})
+
+		Convey("Should not clean up the login attempts without brute force login protection", func() {
+			cfg.DisableBruteForceLoginProtection = true
+			policies := service.retentionPolicies()
+			So(policies, ShouldNotContainKey, models.RetentionLoginAttempts)
+		})
+	})
+}
+
+type deletingImageUploader struct {
+	imguploader.NopImageUploader
+	before time.Time
+}
+
+func (u *deletingImageUploader) DeleteImagesBefore(ctx context.Context, before time.Time) (int, error) {
+	u.before = before
+	return 1, nil
+}
+
+func TestDeleteExpiredExternalImages(t *testing.T) {
+	Convey("Deleting expired external images", t, func() {
+		uploader := &deletingImageUploader{}
+		origNewImageUploader := newImageUploader
+		newImageUploader = func() (imguploader.ImageUploader, error) {
+			return uploader, nil
+		}
+		Reset(func() { newImageUploader = origNewImageUploader })
+
+		cfg := setting.Cfg{}
+		service := CleanUpService{Cfg: &cfg, log: log.New("cleanup")}
+
+		Convey("Should delete the images uploaded before the retention", func() {
+			cfg.ImageUploadRetention = 24 * time.Hour
+			service.deleteExpiredExternalImages(context.Background())
+			So(uploader.before, ShouldHappenWithin, time.Minute, time.Now().Add(-24*time.Hour))
+		})
+
+		Convey("Should keep the images without retention", func() {
+			service.deleteExpiredExternalImages(context.Background())
+			So(uploader.before.IsZero(), ShouldBeTrue)
+		})
+	})
+}
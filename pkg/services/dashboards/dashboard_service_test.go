@@ -37,8 +37,9 @@ func TestDashboardService(t *testing.T) {
 				}
 			})
 
-			Convey("Should return validation error if it's a folder and have a folder id", func() {
+			Convey("Should return validation error if it's a folder and its own parent", func() {
 				dto.Dashboard = models.NewDashboardFolder("Folder")
+				dto.Dashboard.Id = 1
 				dto.Dashboard.FolderId = 1
 				_, err := service.SaveDashboard(dto, false)
 				So(err, ShouldEqual, models.ErrDashboardFolderCannotHaveParent)
@@ -44,9 +44,11 @@ func (dr *dashboardServiceImpl) GetFolders(limit int64) ([]*models.Folder, error
 
 	for _, hit := range searchQuery.Result {
 		folders = append(folders, &models.Folder{
-			Id:    hit.Id,
-			Uid:   hit.Uid,
-			Title: hit.Title,
+			Id:        hit.Id,
+			Uid:       hit.Uid,
+			Title:     hit.Title,
+			ParentId:  hit.FolderId,
+			ParentUid: hit.FolderUid,
 		})
 	}
 
@@ -69,7 +71,7 @@ func (dr *dashboardServiceImpl) GetFolderByID(id int64) (*models.Folder, error)
 		return nil, models.ErrFolderAccessDenied
 	}
 
-	return dashToFolder(dashFolder), nil
+	return dashToFolderWithParent(dashFolder)
 }
 
 func (dr *dashboardServiceImpl) GetFolderByUID(uid string) (*models.Folder, error) {
@@ -88,12 +90,18 @@ func (dr *dashboardServiceImpl) GetFolderByUID(uid string) (*models.Folder, erro
 		return nil, models.ErrFolderAccessDenied
 	}
 
-	return dashToFolder(dashFolder), nil
+	return dashToFolderWithParent(dashFolder)
 }
 
 func (dr *dashboardServiceImpl) CreateFolder(cmd *models.CreateFolderCommand) error {
 	dashFolder := cmd.GetDashboardModel(dr.orgId, dr.user.UserId)
 
+	parentId, err := getParentFolderId(dr.orgId, cmd.ParentUid)
+	if err != nil {
+		return err
+	}
+	dashFolder.FolderId = parentId
+
 	dto := &SaveDashboardDTO{
 		Dashboard: dashFolder,
 		OrgId:     dr.orgId,
@@ -116,9 +124,9 @@ func (dr *dashboardServiceImpl) CreateFolder(cmd *models.CreateFolderCommand) er
 		return toFolderError(err)
 	}
 
-	cmd.Result = dashToFolder(dashFolder)
+	cmd.Result, err = dashToFolderWithParent(dashFolder)
 
-	return nil
+	return err
 }
 
 func (dr *dashboardServiceImpl) UpdateFolder(existingUid string, cmd *models.UpdateFolderCommand) error {
@@ -130,6 +138,14 @@ func (dr *dashboardServiceImpl) UpdateFolder(existingUid string, cmd *models.Upd
 
 	cmd.UpdateDashboardModel(dashFolder, dr.orgId, dr.user.UserId)
 
+	if cmd.ParentUid != nil {
+		parentId, err := getParentFolderId(dr.orgId, *cmd.ParentUid)
+		if err != nil {
+			return err
+		}
+		dashFolder.FolderId = parentId
+	}
+
 	dto := &SaveDashboardDTO{
 		Dashboard: dashFolder,
 		OrgId:     dr.orgId,
@@ -153,9 +169,9 @@ func (dr *dashboardServiceImpl) UpdateFolder(existingUid string, cmd *models.Upd
 		return toFolderError(err)
 	}
 
-	cmd.Result = dashToFolder(dashFolder)
+	cmd.Result, err = dashToFolderWithParent(dashFolder)
 
-	return nil
+	return err
 }
 
 func (dr *dashboardServiceImpl) DeleteFolder(uid string) (*models.Folder, error) {
@@ -193,11 +209,47 @@ func getFolder(query models.GetDashboardQuery) (*models.Dashboard, error) {
 	return query.Result, nil
 }
 
+// getParentFolderId returns the id of the folder with the given uid, or 0 for
+// the General folder when the uid is empty
+func getParentFolderId(orgId int64, parentUid string) (int64, error) {
+	if parentUid == "" {
+		return 0, nil
+	}
+
+	query := models.GetDashboardQuery{OrgId: orgId, Uid: parentUid}
+	parent, err := getFolder(query)
+	if err != nil {
+		if err == models.ErrFolderNotFound {
+			return 0, models.ErrFolderParentNotFound
+		}
+		return 0, err
+	}
+
+	return parent.Id, nil
+}
+
+// dashToFolderWithParent converts the dashboard to a folder and looks up the uid of its parent folder
+func dashToFolderWithParent(dash *models.Dashboard) (*models.Folder, error) {
+	folder := dashToFolder(dash)
+	if folder.ParentId == 0 {
+		return folder, nil
+	}
+
+	query := models.GetDashboardRefByIdQuery{Id: folder.ParentId}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, toFolderError(err)
+	}
+	folder.ParentUid = query.Result.Uid
+
+	return folder, nil
+}
+
 func dashToFolder(dash *models.Dashboard) *models.Folder {
 	return &models.Folder{
 		Id:        dash.Id,
 		Uid:       dash.Uid,
 		Title:     dash.Title,
+		ParentId:  dash.FolderId,
 		HasAcl:    dash.HasAcl,
 		Url:       dash.GetUrl(),
 		Version:   dash.Version,
@@ -233,6 +285,10 @@ func toFolderError(err error) error {
 		return models.ErrFolderNotFound
 	}
 
+	if err == models.ErrDashboardFolderNotFound {
+		return models.ErrFolderParentNotFound
+	}
+
 	if err == models.ErrDashboardFailedGenerateUniqueUid {
 		err = models.ErrFolderFailedGenerateUniqueUid
 	}
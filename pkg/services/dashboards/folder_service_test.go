@@ -172,6 +172,20 @@ func TestFolderService(t *testing.T) {
 				So(f.Title, ShouldEqual, dashFolder.Title)
 			})
 
+			Convey("When get folder nested in another folder should return parent uid", func() {
+				dashFolder.FolderId = 2
+
+				bus.AddHandler("test", func(query *models.GetDashboardRefByIdQuery) error {
+					query.Result = &models.DashboardRef{Uid: "parent-uid"}
+					return nil
+				})
+
+				f, err := service.GetFolderByUID("uid")
+				So(err, ShouldBeNil)
+				So(f.ParentId, ShouldEqual, 2)
+				So(f.ParentUid, ShouldEqual, "parent-uid")
+			})
+
 			Reset(func() {
 				guardian.New = origNewGuardian
 			})
@@ -189,6 +203,7 @@ func TestFolderService(t *testing.T) {
 				{ActualError: models.ErrDashboardVersionMismatch, ExpectedError: models.ErrFolderVersionMismatch},
 				{ActualError: models.ErrDashboardNotFound, ExpectedError: models.ErrFolderNotFound},
 				{ActualError: models.ErrDashboardFailedGenerateUniqueUid, ExpectedError: models.ErrFolderFailedGenerateUniqueUid},
+				{ActualError: models.ErrDashboardFolderNotFound, ExpectedError: models.ErrFolderParentNotFound},
 				{ActualError: models.ErrDashboardInvalidUid, ExpectedError: models.ErrDashboardInvalidUid},
 			}
 
@@ -0,0 +1,286 @@
+package datakeys
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/encryption"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+var (
+	// ErrEnvelopeEncryptionDisabled error returned when rotating the data keys while envelope
+	// encryption is disabled.
+	ErrEnvelopeEncryptionDisabled = errors.New("Envelope encryption is disabled")
+)
+
+const (
+	dataKeyLength     = 32
+	dataKeyNameLength = 16
+	// reloadInterval is how often the data keys are reloaded, to pick up the data keys rotated
+	// by other Grafana servers.
+	reloadInterval = 5 * time.Minute
+)
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:     "DataKeysService",
+		Instance: &DataKeysService{},
+		// the data keys are loaded after the database is migrated, and before other services
+		// store secrets
+		InitPriority: 50,
+	})
+}
+
+// DataKeysService encrypts the secrets stored in the database with data keys, themselves
+// encrypted with a key encryption key (KEK) of a provider.
+type DataKeysService struct {
+	Cfg *setting.Cfg `inject:""`
+
+	log       log.Logger
+	providers map[string]kekProvider
+
+	mu             sync.RWMutex
+	keys           map[string][]byte
+	activeKey      string
+	activeProvider string
+}
+
+func (s *DataKeysService) Init() error {
+	s.log = log.New("datakeys")
+	s.keys = make(map[string][]byte)
+	s.providers = map[string]kekProvider{
+		secretKeyProviderName: secretKeyProvider{},
+	}
+
+	settings := s.Cfg.Encryption
+	if settings.AWSKMSKeyId != "" {
+		provider, err := newAWSKMSProvider(settings.AWSKMSKeyId, settings.AWSKMSRegion)
+		if err != nil {
+			return err
+		}
+		s.providers[awsKMSProviderName] = provider
+	}
+
+	if settings.EnvelopeEncryption {
+		if _, exists := s.providers[settings.KEKProvider]; !exists {
+			return fmt.Errorf("unknown or unconfigured kek_provider %q", settings.KEKProvider)
+		}
+	}
+
+	if err := s.loadDataKeys(context.Background()); err != nil {
+		return err
+	}
+
+	// a new data key is created when there is none yet, or when the KEK provider changed
+	if settings.EnvelopeEncryption && s.activeProvider != settings.KEKProvider {
+		if _, err := s.RotateDataKeys(context.Background()); err != nil {
+			return err
+		}
+	}
+
+	// secrets encrypted with data keys can be decrypted even once envelope encryption is disabled
+	encryption.SetEnvelope(s)
+	return nil
+}
+
+func (s *DataKeysService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.loadDataKeys(ctx); err != nil {
+				s.log.Error("Failed to reload data keys", "error", err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// loadDataKeys loads the data keys that aren't loaded yet, and which data key is active.
+func (s *DataKeysService) loadDataKeys(ctx context.Context) error {
+	query := models.GetDataKeysQuery{}
+	if err := bus.Dispatch(&query); err != nil {
+		return err
+	}
+
+	for _, dataKey := range query.Result {
+		s.mu.RLock()
+		_, loaded := s.keys[dataKey.Name]
+		s.mu.RUnlock()
+
+		if !loaded {
+			key, err := s.decryptDataKey(ctx, dataKey)
+			if err != nil {
+				s.log.Warn("Failed to decrypt data key, the secrets it encrypted cannot be decrypted", "name", dataKey.Name, "provider", dataKey.Provider, "error", err)
+				continue
+			}
+
+			s.mu.Lock()
+			s.keys[dataKey.Name] = key
+			s.mu.Unlock()
+		}
+
+		if dataKey.Active {
+			s.mu.Lock()
+			s.activeKey = dataKey.Name
+			s.activeProvider = dataKey.Provider
+			s.mu.Unlock()
+		}
+	}
+
+	return nil
+}
+
+func (s *DataKeysService) decryptDataKey(ctx context.Context, dataKey *models.DataKey) ([]byte, error) {
+	provider, exists := s.providers[dataKey.Provider]
+	if !exists {
+		return nil, fmt.Errorf("kek provider %q is not configured", dataKey.Provider)
+	}
+
+	return provider.decrypt(ctx, dataKey.EncryptedData)
+}
+
+// RotateDataKeys creates a new active data key, encrypted with the configured KEK provider. The
+// previous data keys keep decrypting the secrets they encrypted, until the secrets are encrypted
+// again with the active data key.
+func (s *DataKeysService) RotateDataKeys(ctx context.Context) (*models.DataKey, error) {
+	if !s.Cfg.Encryption.EnvelopeEncryption {
+		return nil, ErrEnvelopeEncryptionDisabled
+	}
+
+	providerName := s.Cfg.Encryption.KEKProvider
+	key := make([]byte, dataKeyLength)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+
+	encryptedKey, err := s.providers[providerName].encrypt(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := util.GetRandomString(dataKeyNameLength)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := models.CreateDataKeyCommand{
+		Name:          name,
+		Provider:      providerName,
+		EncryptedData: encryptedKey,
+	}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.keys[name] = key
+	s.activeKey = name
+	s.activeProvider = providerName
+	s.mu.Unlock()
+
+	s.log.Info("Data keys rotated", "name", name, "provider", providerName)
+	return cmd.Result, nil
+}
+
+// Encrypt encrypts a secret with the active data key, or with the secret_key when envelope
+// encryption is disabled.
+func (s *DataKeysService) Encrypt(payload []byte) ([]byte, error) {
+	if !s.Cfg.Encryption.EnvelopeEncryption {
+		return util.Encrypt(payload, setting.SecretKey)
+	}
+
+	s.mu.RLock()
+	name := s.activeKey
+	key := s.keys[name]
+	s.mu.RUnlock()
+	if name == "" {
+		return nil, errors.New("No active data key")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return encryption.WrapEnvelope(name, gcm.Seal(nonce, nonce, payload, nil)), nil
+}
+
+// Decrypt decrypts a secret encrypted with a data key.
+func (s *DataKeysService) Decrypt(payload []byte) ([]byte, error) {
+	name, ciphertext, err := encryption.UnwrapEnvelope(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.dataKey(name)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, encryption.ErrInvalidEnvelope
+	}
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// dataKey returns a decrypted data key, loading it when it was created by another Grafana server
+// since the data keys were last loaded.
+func (s *DataKeysService) dataKey(name string) ([]byte, error) {
+	s.mu.RLock()
+	key, loaded := s.keys[name]
+	s.mu.RUnlock()
+	if loaded {
+		return key, nil
+	}
+
+	query := models.GetDataKeyQuery{Name: name}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+
+	key, err := s.decryptDataKey(context.Background(), query.Result)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.keys[name] = key
+	s.mu.Unlock()
+	return key, nil
+}
@@ -0,0 +1,109 @@
+package datakeys
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/encryption"
+	"github.com/grafana/grafana/pkg/components/securejsondata"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataKeysService(t *testing.T) {
+	sqlstore.InitTestDB(t)
+	t.Cleanup(func() {
+		encryption.SetEnvelope(nil)
+	})
+
+	newService := func(t *testing.T) *DataKeysService {
+		cfg := setting.NewCfg()
+		cfg.Encryption = setting.EncryptionSettings{EnvelopeEncryption: true, KEKProvider: secretKeyProviderName}
+		s := &DataKeysService{Cfg: cfg}
+		require.NoError(t, s.Init())
+		return s
+	}
+
+	legacy, err := util.Encrypt([]byte("legacy"), setting.SecretKey)
+	require.NoError(t, err)
+
+	s := newService(t)
+	firstKey := s.activeKey
+	require.NotEmpty(t, firstKey)
+
+	encrypted, err := encryption.Encrypt([]byte("secret"))
+	require.NoError(t, err)
+
+	t.Run("Secrets are encrypted with the active data key", func(t *testing.T) {
+		require.True(t, encryption.IsEnvelope(encrypted))
+		name, _, err := encryption.UnwrapEnvelope(encrypted)
+		require.NoError(t, err)
+		require.Equal(t, firstKey, name)
+
+		decrypted, err := encryption.Decrypt(encrypted)
+		require.NoError(t, err)
+		require.Equal(t, "secret", string(decrypted))
+	})
+
+	t.Run("Secrets encrypted with the secret_key are still decrypted", func(t *testing.T) {
+		decrypted, err := encryption.Decrypt(legacy)
+		require.NoError(t, err)
+		require.Equal(t, "legacy", string(decrypted))
+	})
+
+	t.Run("Secrets encrypted before a rotation are still decrypted", func(t *testing.T) {
+		dataKey, err := s.RotateDataKeys(context.Background())
+		require.NoError(t, err)
+		require.NotEqual(t, firstKey, dataKey.Name)
+
+		decrypted, err := encryption.Decrypt(encrypted)
+		require.NoError(t, err)
+		require.Equal(t, "secret", string(decrypted))
+
+		rotated, err := encryption.Encrypt([]byte("secret"))
+		require.NoError(t, err)
+		name, _, err := encryption.UnwrapEnvelope(rotated)
+		require.NoError(t, err)
+		require.Equal(t, dataKey.Name, name)
+	})
+
+	t.Run("Data keys are loaded from the database", func(t *testing.T) {
+		activeKey := s.activeKey
+		other := newService(t)
+		require.Equal(t, activeKey, other.activeKey)
+
+		decrypted, err := other.Decrypt(encrypted)
+		require.NoError(t, err)
+		require.Equal(t, "secret", string(decrypted))
+	})
+
+	t.Run("Secrets are re-encrypted with the active data key", func(t *testing.T) {
+		encryption.SetEnvelope(nil)
+		cmd := &models.AddDataSourceCommand{
+			OrgId:          1,
+			Name:           "test",
+			Type:           "test",
+			Access:         models.DS_ACCESS_PROXY,
+			SecureJsonData: map[string]string{"password": "pass"},
+		}
+		require.NoError(t, bus.Dispatch(cmd))
+		require.False(t, encryption.IsEnvelope(cmd.Result.SecureJsonData["password"]))
+
+		s := newService(t)
+		reEncrypt := &models.ReEncryptSecretsCommand{}
+		require.NoError(t, bus.Dispatch(reEncrypt))
+		require.Equal(t, 1, reEncrypt.Result.DataSources)
+
+		query := &models.GetDataSourceByIdQuery{Id: cmd.Result.Id, OrgId: 1}
+		require.NoError(t, bus.Dispatch(query))
+		password := query.Result.SecureJsonData["password"]
+		name, _, err := encryption.UnwrapEnvelope(password)
+		require.NoError(t, err)
+		require.Equal(t, s.activeKey, name)
+		require.Equal(t, map[string]string{"password": "pass"}, securejsondata.SecureJsonData(query.Result.SecureJsonData).Decrypt())
+	})
+}
@@ -0,0 +1,77 @@
+package datakeys
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	secretKeyProviderName = "secret_key"
+	awsKMSProviderName    = "awskms"
+)
+
+// kekProvider encrypts and decrypts the data keys with a key encryption key.
+type kekProvider interface {
+	encrypt(ctx context.Context, key []byte) ([]byte, error)
+	decrypt(ctx context.Context, encryptedKey []byte) ([]byte, error)
+}
+
+// secretKeyProvider encrypts the data keys with the secret_key of the configuration.
+type secretKeyProvider struct{}
+
+func (p secretKeyProvider) encrypt(ctx context.Context, key []byte) ([]byte, error) {
+	return util.Encrypt(key, setting.SecretKey)
+}
+
+func (p secretKeyProvider) decrypt(ctx context.Context, encryptedKey []byte) ([]byte, error) {
+	return util.Decrypt(encryptedKey, setting.SecretKey)
+}
+
+// awsKMSProvider encrypts the data keys with a key of AWS KMS, using the default AWS credentials
+// of the Grafana server.
+type awsKMSProvider struct {
+	keyId  string
+	client *kms.KMS
+}
+
+func newAWSKMSProvider(keyId string, region string) (*awsKMSProvider, error) {
+	cfg := &aws.Config{}
+	if region != "" {
+		cfg.Region = aws.String(region)
+	}
+
+	sess, err := session.NewSession(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &awsKMSProvider{keyId: keyId, client: kms.New(sess)}, nil
+}
+
+func (p *awsKMSProvider) encrypt(ctx context.Context, key []byte) ([]byte, error) {
+	output, err := p.client.EncryptWithContext(ctx, &kms.EncryptInput{
+		KeyId:     aws.String(p.keyId),
+		Plaintext: key,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return output.CiphertextBlob, nil
+}
+
+func (p *awsKMSProvider) decrypt(ctx context.Context, encryptedKey []byte) ([]byte, error) {
+	output, err := p.client.DecryptWithContext(ctx, &kms.DecryptInput{
+		CiphertextBlob: encryptedKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return output.Plaintext, nil
+}
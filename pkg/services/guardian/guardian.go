@@ -213,6 +213,107 @@ func (g *dashboardGuardianImpl) getTeams() ([]*models.TeamDTO, error) {
 	return query.Result, err
 }
 
+// GetPermissionPreview returns the effective permission of the user for the dashboard or folder
+// and the permission rule that grants it.
+func GetPermissionPreview(dash *models.Dashboard, user *models.SignedInUser) (*models.DashboardPermissionPreview, error) {
+	g := &dashboardGuardianImpl{
+		user:   user,
+		dashId: dash.Id,
+		orgId:  dash.OrgId,
+		log:    log.New("dashboard.permissions"),
+	}
+
+	preview := &models.DashboardPermissionPreview{
+		UserId:        user.UserId,
+		UserLogin:     user.Login,
+		OrgRole:       user.OrgRole,
+		DashboardId:   dash.Id,
+		Uid:           dash.Uid,
+		Title:         dash.Title,
+		IsFolder:      dash.IsFolder,
+		Source:        models.PermissionSourceNone,
+		MatchingRules: []*models.DashboardAclInfoDTO{},
+	}
+
+	matching, err := g.getMatchingAcl()
+	if err != nil {
+		return nil, err
+	}
+	preview.MatchingRules = matching
+
+	if user.OrgRole == models.ROLE_ADMIN {
+		preview.Permission = models.PERMISSION_ADMIN
+		preview.Source = models.PermissionSourceOrgAdmin
+	} else {
+		for _, p := range matching {
+			if p.Permission <= preview.Permission {
+				continue
+			}
+
+			preview.Permission = p.Permission
+			preview.Rule = p
+			switch {
+			case p.UserId > 0:
+				preview.Source = models.PermissionSourceUser
+			case p.TeamId > 0:
+				preview.Source = models.PermissionSourceTeam
+			default:
+				preview.Source = models.PermissionSourceRole
+			}
+		}
+	}
+
+	preview.PermissionName = preview.Permission.String()
+	preview.CanView = preview.Permission >= models.PERMISSION_VIEW
+	preview.CanSave = preview.Permission >= models.PERMISSION_EDIT
+	preview.CanEdit = preview.CanSave || (setting.ViewersCanEdit && preview.CanView)
+	preview.CanAdmin = preview.Permission >= models.PERMISSION_ADMIN
+
+	return preview, nil
+}
+
+// getMatchingAcl returns the acl items that apply to the user, using the same rules as checkAcl
+func (g *dashboardGuardianImpl) getMatchingAcl() ([]*models.DashboardAclInfoDTO, error) {
+	acl, err := g.GetAcl()
+	if err != nil {
+		return nil, err
+	}
+
+	var teamIds map[int64]bool
+	matching := []*models.DashboardAclInfoDTO{}
+
+	for _, p := range acl {
+		switch {
+		case p.UserId > 0:
+			if !g.user.IsAnonymous && p.UserId == g.user.UserId {
+				matching = append(matching, p)
+			}
+		case p.TeamId > 0:
+			if teamIds == nil {
+				teams, err := g.getTeams()
+				if err != nil {
+					return nil, err
+				}
+
+				teamIds = make(map[int64]bool)
+				for _, team := range teams {
+					teamIds[team.Id] = true
+				}
+			}
+
+			if teamIds[p.TeamId] {
+				matching = append(matching, p)
+			}
+		case p.Role != nil:
+			if *p.Role == g.user.OrgRole {
+				matching = append(matching, p)
+			}
+		}
+	}
+
+	return matching, nil
+}
+
 type FakeDashboardGuardian struct {
 	DashId                           int64
 	OrgId                            int64
@@ -5,6 +5,7 @@ import (
 	"runtime"
 	"testing"
 
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -676,3 +677,76 @@ func (sc *scenarioContext) verifyUpdateChildDashboardPermissionsWithOverrideShou
 		})
 	}
 }
+
+func TestGuardianPermissionPreview(t *testing.T) {
+	Convey("Guardian permission preview tests", t, func() {
+		dash := &models.Dashboard{Id: dashboardID, OrgId: orgID, Uid: "abc", Title: "Dash"}
+		acl := []*models.DashboardAclInfoDTO{
+			{OrgId: orgID, DashboardId: parentFolderID, Role: &viewerRole, Permission: models.PERMISSION_VIEW, Inherited: true},
+			{OrgId: orgID, DashboardId: dashboardID, TeamId: teamID, Permission: models.PERMISSION_EDIT},
+			{OrgId: orgID, DashboardId: dashboardID, UserId: otherUserID, Permission: models.PERMISSION_ADMIN},
+		}
+
+		bus.ClearBusHandlers()
+		bus.AddHandler("test", func(query *models.GetDashboardAclInfoListQuery) error {
+			query.Result = acl
+			return nil
+		})
+		bus.AddHandler("test", func(query *models.GetTeamsByUserQuery) error {
+			query.Result = []*models.TeamDTO{{Id: teamID}}
+			return nil
+		})
+
+		Convey("Given viewer in team with edit permission", func() {
+			user := &models.SignedInUser{UserId: userID, OrgId: orgID, OrgRole: models.ROLE_VIEWER}
+			preview, err := GetPermissionPreview(dash, user)
+			So(err, ShouldBeNil)
+
+			So(preview.Permission, ShouldEqual, models.PERMISSION_EDIT)
+			So(preview.PermissionName, ShouldEqual, "Edit")
+			So(preview.Source, ShouldEqual, models.PermissionSourceTeam)
+			So(preview.Rule, ShouldEqual, acl[1])
+			So(preview.MatchingRules, ShouldHaveLength, 2)
+			So(preview.CanView, ShouldBeTrue)
+			So(preview.CanSave, ShouldBeTrue)
+			So(preview.CanAdmin, ShouldBeFalse)
+		})
+
+		Convey("Given editor without matching rules", func() {
+			bus.AddHandler("test", func(query *models.GetTeamsByUserQuery) error {
+				query.Result = []*models.TeamDTO{}
+				return nil
+			})
+
+			user := &models.SignedInUser{UserId: userID, OrgId: orgID, OrgRole: models.ROLE_EDITOR}
+			preview, err := GetPermissionPreview(dash, user)
+			So(err, ShouldBeNil)
+
+			So(preview.Permission, ShouldEqual, models.PermissionType(0))
+			So(preview.Source, ShouldEqual, models.PermissionSourceNone)
+			So(preview.Rule, ShouldBeNil)
+			So(preview.MatchingRules, ShouldBeEmpty)
+			So(preview.CanView, ShouldBeFalse)
+		})
+
+		Convey("Given user with user permission", func() {
+			user := &models.SignedInUser{UserId: otherUserID, OrgId: orgID, OrgRole: models.ROLE_VIEWER}
+			preview, err := GetPermissionPreview(dash, user)
+			So(err, ShouldBeNil)
+
+			So(preview.Permission, ShouldEqual, models.PERMISSION_ADMIN)
+			So(preview.Source, ShouldEqual, models.PermissionSourceUser)
+			So(preview.Rule, ShouldEqual, acl[2])
+		})
+
+		Convey("Given org admin", func() {
+			user := &models.SignedInUser{UserId: userID, OrgId: orgID, OrgRole: models.ROLE_ADMIN}
+			preview, err := GetPermissionPreview(dash, user)
+			So(err, ShouldBeNil)
+
+			So(preview.Permission, ShouldEqual, models.PERMISSION_ADMIN)
+			So(preview.Source, ShouldEqual, models.PermissionSourceOrgAdmin)
+			So(preview.Rule, ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,170 @@
+package liveingest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/jsonpath"
+	"gopkg.in/yaml.v2"
+)
+
+// Bridge subscribes to the topics of an MQTT broker or of a Kafka cluster, and republishes their
+// messages on the Live channels of an organization.
+type Bridge struct {
+	Name     string       `yaml:"name"`
+	OrgID    int64        `yaml:"org_id"`
+	MQTT     *MQTTSource  `yaml:"mqtt"`
+	Kafka    *KafkaSource `yaml:"kafka"`
+	Channels []*Channel   `yaml:"channels"`
+}
+
+// MQTTSource is the MQTT broker of a bridge.
+type MQTTSource struct {
+	// URL of the broker, like tcp://mqtt:1883 or ssl://mqtt:8883
+	URL string `yaml:"url"`
+	// ClientID defaults to grafana-<bridge name>
+	ClientID string `yaml:"client_id"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// QoS is the quality of service of the subscriptions, 0 or 1
+	QoS byte `yaml:"qos"`
+}
+
+// KafkaSource is the Kafka cluster of a bridge.
+type KafkaSource struct {
+	Brokers []string `yaml:"brokers"`
+	// GroupID is the consumer group of the bridge, grafana-<hostname>-<bridge name> by default so
+	// that every Grafana instance gets all the messages
+	GroupID string `yaml:"group_id"`
+}
+
+// Channel republishes the messages of a topic on the Live channel grafana/ingest/<channel>, as a
+// frame whose fields are read from the JSON of the messages.
+type Channel struct {
+	// Topic is the topic of the messages, an MQTT topic filter with + and # wildcards or a Kafka topic
+	Topic   string          `yaml:"topic"`
+	Channel string          `yaml:"channel"`
+	Fields  []*FieldMapping `yaml:"fields"`
+}
+
+// FieldMapping reads a field of a frame from the JSON of a message.
+type FieldMapping struct {
+	Name string `yaml:"name"`
+	// Path of the value in the JSON of the message, like $.sensor.readings[0].value
+	Path string `yaml:"path"`
+	// Type is number, string, boolean or time, number by default
+	Type string `yaml:"type"`
+
+	path jsonpath.Path
+}
+
+// Field types of the field mappings
+const (
+	fieldTypeNumber  = "number"
+	fieldTypeString  = "string"
+	fieldTypeBoolean = "boolean"
+	fieldTypeTime    = "time"
+)
+
+type configFile struct {
+	Bridges []*Bridge `yaml:"bridges"`
+}
+
+func readConfigFile(path string) ([]*Bridge, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config configFile
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	if err := validateBridges(config.Bridges); err != nil {
+		return nil, err
+	}
+	return config.Bridges, nil
+}
+
+// validateBridges checks the bridges and sets their defaults
+func validateBridges(bridges []*Bridge) error {
+	names := make(map[string]bool)
+	channels := make(map[string]bool)
+	for i, bridge := range bridges {
+		if bridge.Name == "" {
+			return fmt.Errorf("bridge %d has no name", i+1)
+		}
+		if names[bridge.Name] {
+			return fmt.Errorf("bridge %q is defined twice", bridge.Name)
+		}
+		names[bridge.Name] = true
+		if bridge.OrgID < 1 {
+			bridge.OrgID = 1
+		}
+
+		switch {
+		case (bridge.MQTT == nil) == (bridge.Kafka == nil):
+			return fmt.Errorf("bridge %q needs either an mqtt or a kafka source", bridge.Name)
+		case bridge.MQTT != nil && bridge.MQTT.URL == "":
+			return fmt.Errorf("the mqtt source of bridge %q has no url", bridge.Name)
+		case bridge.MQTT != nil && bridge.MQTT.QoS > 1:
+			return fmt.Errorf("the mqtt source of bridge %q has an invalid qos %d, it must be 0 or 1", bridge.Name, bridge.MQTT.QoS)
+		case bridge.Kafka != nil && len(bridge.Kafka.Brokers) == 0:
+			return fmt.Errorf("the kafka source of bridge %q has no brokers", bridge.Name)
+		}
+
+		if len(bridge.Channels) == 0 {
+			return fmt.Errorf("bridge %q has no channels", bridge.Name)
+		}
+		for _, channel := range bridge.Channels {
+			if err := validateChannel(bridge, channel); err != nil {
+				return fmt.Errorf("channel %q of bridge %q: %w", channel.Channel, bridge.Name, err)
+			}
+			key := fmt.Sprintf("%d/%s", bridge.OrgID, channel.Channel)
+			if channels[key] {
+				return fmt.Errorf("channel %q of org %d is defined twice", channel.Channel, bridge.OrgID)
+			}
+			channels[key] = true
+		}
+	}
+	return nil
+}
+
+func validateChannel(bridge *Bridge, channel *Channel) error {
+	if channel.Channel == "" || strings.HasPrefix(channel.Channel, "/") {
+		return fmt.Errorf("invalid channel name")
+	}
+	if channel.Topic == "" {
+		return fmt.Errorf("no topic")
+	}
+	if bridge.Kafka != nil && strings.ContainsAny(channel.Topic, "+#") {
+		return fmt.Errorf("kafka topic %q has wildcards", channel.Topic)
+	}
+	if len(channel.Fields) == 0 {
+		return fmt.Errorf("no fields")
+	}
+
+	names := make(map[string]bool)
+	for _, field := range channel.Fields {
+		if field.Name == "" || names[field.Name] {
+			return fmt.Errorf("invalid or duplicate field name %q", field.Name)
+		}
+		names[field.Name] = true
+
+		switch field.Type {
+		case "":
+			field.Type = fieldTypeNumber
+		case fieldTypeNumber, fieldTypeString, fieldTypeBoolean, fieldTypeTime:
+		default:
+			return fmt.Errorf("field %q has an unknown type %q", field.Name, field.Type)
+		}
+
+		path, err := jsonpath.Parse(field.Path)
+		if err != nil {
+			return fmt.Errorf("field %q: %w", field.Name, err)
+		}
+		field.path = path
+	}
+	return nil
+}
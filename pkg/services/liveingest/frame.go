@@ -0,0 +1,142 @@
+package liveingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// frameMessage is a frame published on a Live channel, with its schema and the values of its
+// fields, the times in epoch milliseconds
+type frameMessage struct {
+	Schema frameSchema `json:"schema"`
+	Data   frameData   `json:"data"`
+}
+
+type frameSchema struct {
+	Name   string        `json:"name"`
+	Fields []fieldSchema `json:"fields"`
+}
+
+type fieldSchema struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type frameData struct {
+	Values [][]interface{} `json:"values"`
+}
+
+// channelFrame reads the frame of a message of a channel. A message whose JSON is an array is a
+// row per item. A frame without a time field gets one with the time the message was received, so
+// that it can be graphed.
+func channelFrame(channel *Channel, payload []byte, received time.Time) (*frameMessage, error) {
+	var root interface{}
+	if err := json.Unmarshal(payload, &root); err != nil {
+		return nil, fmt.Errorf("invalid JSON message: %w", err)
+	}
+	rows, ok := root.([]interface{})
+	if !ok {
+		rows = []interface{}{root}
+	}
+
+	frame := &frameMessage{Schema: frameSchema{Name: channel.Channel}}
+	hasTime := false
+	for _, field := range channel.Fields {
+		if field.Type == fieldTypeTime || field.Name == "time" {
+			hasTime = true
+		}
+	}
+	if !hasTime {
+		frame.Schema.Fields = append(frame.Schema.Fields, fieldSchema{Name: "time", Type: fieldTypeTime})
+		values := make([]interface{}, len(rows))
+		for i := range rows {
+			values[i] = received.UnixNano() / int64(time.Millisecond)
+		}
+		frame.Data.Values = append(frame.Data.Values, values)
+	}
+
+	for _, field := range channel.Fields {
+		frame.Schema.Fields = append(frame.Schema.Fields, fieldSchema{Name: field.Name, Type: field.Type})
+		values := make([]interface{}, len(rows))
+		for i, row := range rows {
+			if value, ok := field.path.Lookup(row); ok {
+				values[i] = convertValue(field.Type, value)
+			}
+		}
+		frame.Data.Values = append(frame.Data.Values, values)
+	}
+	return frame, nil
+}
+
+// convertValue converts a JSON value to a value of a field type, nil when it can't
+func convertValue(fieldType string, value interface{}) interface{} {
+	switch fieldType {
+	case fieldTypeNumber:
+		switch v := value.(type) {
+		case float64:
+			return v
+		case string:
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f
+			}
+		}
+	case fieldTypeString:
+		switch v := value.(type) {
+		case nil:
+			return nil
+		case string:
+			return v
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			return strconv.FormatBool(v)
+		default:
+			encoded, _ := json.Marshal(v)
+			return string(encoded)
+		}
+	case fieldTypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b
+			}
+		}
+	case fieldTypeTime:
+		switch v := value.(type) {
+		case float64:
+			// epoch seconds are told from epoch milliseconds by their magnitude, the milliseconds
+			// since 1970 having passed 1e11 in 1973
+			if v < 1e11 {
+				return int64(v * 1000)
+			}
+			return int64(v)
+		case string:
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				return t.UnixNano() / int64(time.Millisecond)
+			}
+		}
+	}
+	return nil
+}
+
+// topicMatches tells whether a topic matches an MQTT topic filter, where + matches a level of the
+// topic and a trailing # all the remaining levels. The Kafka topics, which can't have wildcards,
+// only match themselves.
+func topicMatches(filter, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, level := range filterLevels {
+		if level == "#" {
+			return i == len(filterLevels)-1
+		}
+		if i >= len(topicLevels) || (level != "+" && level != topicLevels[i]) {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
@@ -0,0 +1,63 @@
+package liveingest
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/segmentio/kafka-go"
+	"golang.org/x/sync/errgroup"
+)
+
+// kafkaMaxBytes bounds the size of the batches of messages fetched from the brokers
+const kafkaMaxBytes = 10e6
+
+type kafkaSubscriber struct {
+	source  *KafkaSource
+	groupID string
+	log     log.Logger
+}
+
+func newKafkaSubscriber(bridge *Bridge, logger log.Logger) subscriber {
+	groupID := bridge.Kafka.GroupID
+	if groupID == "" {
+		hostname, _ := os.Hostname()
+		groupID = fmt.Sprintf("grafana-%s-%s", hostname, bridge.Name)
+	}
+	return &kafkaSubscriber{source: bridge.Kafka, groupID: groupID, log: logger}
+}
+
+// run reads the topics with a reader each. A new consumer group starts with the latest messages,
+// the older ones being of no use to live dashboards.
+func (s *kafkaSubscriber) run(ctx context.Context, topics []string, handle func(topic string, payload []byte)) error {
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, topic := range topics {
+		reader := kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     s.source.Brokers,
+			GroupID:     s.groupID,
+			Topic:       topic,
+			MinBytes:    1,
+			MaxBytes:    kafkaMaxBytes,
+			StartOffset: kafka.LastOffset,
+		})
+		s.log.Info("Reading Kafka topic", "brokers", s.source.Brokers, "topic", topic, "groupId", s.groupID)
+
+		eg.Go(func() error {
+			defer func() {
+				if err := reader.Close(); err != nil {
+					s.log.Warn("Failed to close Kafka reader", "error", err)
+				}
+			}()
+
+			for {
+				msg, err := reader.ReadMessage(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to read Kafka topic %s: %w", reader.Config().Topic, err)
+				}
+				handle(msg.Topic, msg.Value)
+			}
+		})
+	}
+	return eg.Wait()
+}
@@ -0,0 +1,156 @@
+package liveingest
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/live"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+func init() {
+	registry.RegisterService(&LiveIngestService{})
+}
+
+// Feature is the Live feature of the channels of the bridges, named grafana/ingest/<channel>
+const Feature = "ingest"
+
+// Delays before a bridge whose source failed subscribes again, doubling up to the max
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+)
+
+// Publisher publishes to the Live channels of an org.
+type Publisher interface {
+	Publish(orgID int64, channel string, data interface{}) error
+}
+
+// subscriber subscribes to the topics of the source of a bridge.
+type subscriber interface {
+	// run calls handle with the messages of the topics until ctx is done or the source fails
+	run(ctx context.Context, topics []string, handle func(topic string, payload []byte)) error
+}
+
+// Subscriber factory.
+// Stubbable by tests.
+var newSubscriber = func(bridge *Bridge, logger log.Logger) subscriber {
+	if bridge.MQTT != nil {
+		return newMQTTSubscriber(bridge, logger)
+	}
+	return newKafkaSubscriber(bridge, logger)
+}
+
+// LiveIngestService runs the bridges of the configuration file of [live_ingest], which subscribe
+// to MQTT or Kafka topics and republish their messages as frames on Live channels, so that live
+// dashboards can show them without a data source.
+type LiveIngestService struct {
+	Cfg *setting.Cfg `inject:""`
+
+	log     log.Logger
+	bridges []*Bridge
+}
+
+func (s *LiveIngestService) Init() error {
+	s.log = log.New("live.ingest")
+
+	if !s.Cfg.LiveIngest.Enabled {
+		return nil
+	}
+
+	path := s.Cfg.LiveIngest.ConfigFile
+	bridges, err := readConfigFile(path)
+	if err != nil {
+		return errutil.Wrapf(err, "failed to read Live ingest bridges from %s", path)
+	}
+	s.bridges = bridges
+
+	s.log.Info("Live ingest bridges loaded", "file", path, "bridges", len(bridges))
+	return nil
+}
+
+// AuthorizeChannel allows the users of an org to subscribe to the channels of its bridges.
+func (s *LiveIngestService) AuthorizeChannel(user *models.SignedInUser, path string) error {
+	for _, bridge := range s.bridges {
+		if bridge.OrgID != user.OrgId {
+			continue
+		}
+		for _, channel := range bridge.Channels {
+			if channel.Channel == path {
+				return nil
+			}
+		}
+	}
+	return live.ErrStreamNotFound
+}
+
+// Start runs the bridges until ctx is done, publishing their frames with publisher.
+func (s *LiveIngestService) Start(ctx context.Context, publisher Publisher) {
+	for _, bridge := range s.bridges {
+		go s.runBridge(ctx, bridge, publisher)
+	}
+}
+
+// runBridge subscribes to the topics of a bridge, and subscribes again when its source fails
+func (s *LiveIngestService) runBridge(ctx context.Context, bridge *Bridge, publisher Publisher) {
+	logger := log.New("live.ingest", "bridge", bridge.Name)
+	topics := make([]string, 0, len(bridge.Channels))
+	seen := make(map[string]bool)
+	for _, channel := range bridge.Channels {
+		if !seen[channel.Topic] {
+			seen[channel.Topic] = true
+			topics = append(topics, channel.Topic)
+		}
+	}
+
+	handle := func(topic string, payload []byte) {
+		s.publish(logger, bridge, publisher, topic, payload, time.Now())
+	}
+
+	delay := minReconnectDelay
+	for {
+		started := time.Now()
+		err := newSubscriber(bridge, logger).run(ctx, topics, handle)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// a source which ran for a while failed for another reason than the last time
+		if time.Since(started) > maxReconnectDelay {
+			delay = minReconnectDelay
+		}
+		logger.Warn("Live ingest bridge failed, subscribing again", "error", err, "delay", delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// publish republishes a message on the channels of its topic
+func (s *LiveIngestService) publish(logger log.Logger, bridge *Bridge, publisher Publisher, topic string, payload []byte, received time.Time) {
+	for _, channel := range bridge.Channels {
+		if !topicMatches(channel.Topic, topic) {
+			continue
+		}
+
+		frame, err := channelFrame(channel, payload, received)
+		if err != nil {
+			logger.Debug("Failed to read Live ingest message", "topic", topic, "channel", channel.Channel, "error", err)
+			continue
+		}
+
+		name := live.ScopeGrafana + "/" + Feature + "/" + channel.Channel
+		if err := publisher.Publish(bridge.OrgID, name, frame); err != nil {
+			logger.Debug("Failed to publish Live ingest message", "channel", name, "error", err)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package liveingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/live"
+	"github.com/grafana/grafana/pkg/components/jsonpath"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadConfigFile(t *testing.T) {
+	bridges, err := readConfigFile("testdata/bridges.yaml")
+	require.NoError(t, err)
+	require.Len(t, bridges, 2)
+
+	plant := bridges[0]
+	assert.Equal(t, int64(1), plant.OrgID)
+	assert.Equal(t, "tcp://mqtt:1883", plant.MQTT.URL)
+	assert.Equal(t, byte(1), plant.MQTT.QoS)
+	require.Len(t, plant.Channels, 2)
+	assert.Equal(t, fieldTypeNumber, plant.Channels[0].Fields[2].Type)
+	expectedPath, err := jsonpath.Parse("readings[0].value")
+	require.NoError(t, err)
+	assert.Equal(t, expectedPath, plant.Channels[0].Fields[2].path)
+
+	orders := bridges[1]
+	assert.Equal(t, int64(2), orders.OrgID)
+	assert.Equal(t, []string{"kafka-1:9092", "kafka-2:9092"}, orders.Kafka.Brokers)
+	assert.Equal(t, "dashboards", orders.Kafka.GroupID)
+}
+
+func TestValidateBridges(t *testing.T) {
+	newBridge := func() *Bridge {
+		return &Bridge{
+			Name: "b",
+			MQTT: &MQTTSource{URL: "tcp://mqtt:1883"},
+			Channels: []*Channel{
+				{Topic: "t", Channel: "c", Fields: []*FieldMapping{{Name: "v", Path: "v"}}},
+			},
+		}
+	}
+	require.NoError(t, validateBridges([]*Bridge{newBridge()}))
+
+	for name, change := range map[string]func(b *Bridge){
+		"no name":         func(b *Bridge) { b.Name = "" },
+		"no source":       func(b *Bridge) { b.MQTT = nil },
+		"two sources":     func(b *Bridge) { b.Kafka = &KafkaSource{Brokers: []string{"kafka:9092"}} },
+		"invalid qos":     func(b *Bridge) { b.MQTT.QoS = 2 },
+		"no channels":     func(b *Bridge) { b.Channels = nil },
+		"no channel name": func(b *Bridge) { b.Channels[0].Channel = "" },
+		"no topic":        func(b *Bridge) { b.Channels[0].Topic = "" },
+		"no fields":       func(b *Bridge) { b.Channels[0].Fields = nil },
+		"unknown type":    func(b *Bridge) { b.Channels[0].Fields[0].Type = "date" },
+		"invalid path":    func(b *Bridge) { b.Channels[0].Fields[0].Path = "a[x]" },
+		"duplicate fields": func(b *Bridge) {
+			b.Channels[0].Fields = append(b.Channels[0].Fields, &FieldMapping{Name: "v", Path: "w"})
+		},
+		"duplicate channels": func(b *Bridge) {
+			b.Channels = append(b.Channels, &Channel{Topic: "u", Channel: "c", Fields: []*FieldMapping{{Name: "v", Path: "v"}}})
+		},
+		"kafka wildcards": func(b *Bridge) {
+			b.MQTT, b.Kafka = nil, &KafkaSource{Brokers: []string{"kafka:9092"}}
+			b.Channels[0].Topic = "orders/#"
+		},
+	} {
+		b := newBridge()
+		change(b)
+		assert.Error(t, validateBridges([]*Bridge{b}), name)
+	}
+}
+
+func newFieldMapping(t *testing.T, name, path, fieldType string) *FieldMapping {
+	parsed, err := jsonpath.Parse(path)
+	require.NoError(t, err)
+	return &FieldMapping{Name: name, Path: path, Type: fieldType, path: parsed}
+}
+
+func TestChannelFrame(t *testing.T) {
+	received := time.Unix(1600000000, 0)
+	channel := &Channel{Channel: "plant/temperature", Fields: []*FieldMapping{
+		newFieldMapping(t, "sensor", "$.sensor['id']", fieldTypeString),
+		newFieldMapping(t, "temperature", "readings[0].value", fieldTypeNumber),
+		newFieldMapping(t, "ok", "ok", fieldTypeBoolean),
+	}}
+
+	t.Run("A message is a row, with the time it was received", func(t *testing.T) {
+		frame, err := channelFrame(channel, []byte(`{"sensor": {"id": 12}, "readings": [{"value": "21.5"}], "ok": true}`), received)
+		require.NoError(t, err)
+		assert.Equal(t, "plant/temperature", frame.Schema.Name)
+		assert.Equal(t, []fieldSchema{{"time", "time"}, {"sensor", "string"}, {"temperature", "number"}, {"ok", "boolean"}}, frame.Schema.Fields)
+		assert.Equal(t, [][]interface{}{{int64(1600000000000)}, {"12"}, {21.5}, {true}}, frame.Data.Values)
+	})
+
+	t.Run("An array is a row per item, missing values are null", func(t *testing.T) {
+		frame, err := channelFrame(channel, []byte(`[{"sensor": {"id": "a"}, "readings": []}, {"ok": "false"}]`), received)
+		require.NoError(t, err)
+		assert.Equal(t, [][]interface{}{
+			{int64(1600000000000), int64(1600000000000)},
+			{"a", nil},
+			{nil, nil},
+			{nil, false},
+		}, frame.Data.Values)
+	})
+
+	t.Run("Times are read from the messages", func(t *testing.T) {
+		timed := &Channel{Channel: "c", Fields: []*FieldMapping{newFieldMapping(t, "ts", "ts", fieldTypeTime)}}
+		for payload, expected := range map[string]interface{}{
+			`{"ts": 1600000001}`:                 int64(1600000001000),
+			`{"ts": 1600000001234}`:              int64(1600000001234),
+			`{"ts": "2020-09-13T12:26:41.5Z"}`:   int64(1600000001500),
+			`{"ts": "yesterday"}`:                nil,
+			`{"ts": {"seconds": 1600000001234}}`: nil,
+		} {
+			frame, err := channelFrame(timed, []byte(payload), received)
+			require.NoError(t, err)
+			assert.Equal(t, [][]interface{}{{expected}}, frame.Data.Values, payload)
+		}
+	})
+
+	t.Run("Invalid JSON fails", func(t *testing.T) {
+		_, err := channelFrame(channel, []byte(`{"sensor"`), received)
+		assert.Error(t, err)
+	})
+}
+
+func TestTopicMatches(t *testing.T) {
+	for _, tc := range []struct {
+		filter, topic string
+		matches       bool
+	}{
+		{"plant/a/temperature", "plant/a/temperature", true},
+		{"plant/+/temperature", "plant/a/temperature", true},
+		{"plant/+/temperature", "plant/a/b/temperature", false},
+		{"plant/#", "plant", true},
+		{"plant/#", "plant/a/b", true},
+		{"#", "plant/a", true},
+		{"plant/+", "plant", false},
+		{"plant", "plant/a", false},
+		{"orders", "orders", true},
+		{"orders", "orders-eu", false},
+	} {
+		assert.Equal(t, tc.matches, topicMatches(tc.filter, tc.topic), "%s %s", tc.filter, tc.topic)
+	}
+}
+
+type fakePublisher struct {
+	mu        sync.Mutex
+	published map[string][]interface{}
+}
+
+func (p *fakePublisher) Publish(orgID int64, channel string, data interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	encoded, _ := json.Marshal(data)
+	p.published[channel] = append(p.published[channel], string(encoded))
+	return nil
+}
+
+func (p *fakePublisher) get(channel string) []interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.published[channel]
+}
+
+type fakeSubscriber struct {
+	runs  chan []string
+	onRun func(ctx context.Context, handle func(topic string, payload []byte)) error
+}
+
+func (s *fakeSubscriber) run(ctx context.Context, topics []string, handle func(topic string, payload []byte)) error {
+	s.runs <- topics
+	return s.onRun(ctx, handle)
+}
+
+func TestLiveIngestService(t *testing.T) {
+	s := &LiveIngestService{Cfg: setting.NewCfg()}
+	s.Cfg.LiveIngest = setting.LiveIngestSettings{Enabled: true, ConfigFile: "testdata/bridges.yaml"}
+	require.NoError(t, s.Init())
+	require.Len(t, s.bridges, 2)
+	s.bridges = s.bridges[:1]
+
+	t.Run("Users can subscribe to the channels of their org", func(t *testing.T) {
+		assert.NoError(t, s.AuthorizeChannel(&models.SignedInUser{OrgId: 1}, "plant/temperature"))
+		assert.Equal(t, live.ErrStreamNotFound, s.AuthorizeChannel(&models.SignedInUser{OrgId: 2}, "plant/temperature"))
+		assert.Equal(t, live.ErrStreamNotFound, s.AuthorizeChannel(&models.SignedInUser{OrgId: 1}, "orders"))
+	})
+
+	t.Run("Messages are published on the channels of their topic", func(t *testing.T) {
+		sub := &fakeSubscriber{runs: make(chan []string, 1), onRun: func(ctx context.Context, handle func(topic string, payload []byte)) error {
+			handle("plant/a/temperature", []byte(`{"ts": 1600000000000, "readings": [{"value": 20}], "value": 1}`))
+			handle("plant/b/humidity", []byte(`{"value": 2}`))
+			handle("plant/b/humidity", []byte(`not JSON`))
+			<-ctx.Done()
+			return ctx.Err()
+		}}
+		origNewSubscriber := newSubscriber
+		newSubscriber = func(*Bridge, log.Logger) subscriber { return sub }
+		t.Cleanup(func() { newSubscriber = origNewSubscriber })
+
+		publisher := &fakePublisher{published: make(map[string][]interface{})}
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		s.Start(ctx, publisher)
+
+		assert.Equal(t, []string{"plant/+/temperature", "plant/#"}, <-sub.runs)
+		require.Eventually(t, func() bool { return len(publisher.get("grafana/ingest/plant/all")) == 2 }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, []interface{}{
+			`{"schema":{"name":"plant/temperature","fields":[{"name":"time","type":"time"},{"name":"sensor","type":"string"},{"name":"temperature","type":"number"}]},"data":{"values":[[1600000000000],[null],[20]]}}`,
+		}, publisher.get("grafana/ingest/plant/temperature"))
+	})
+
+	t.Run("Bridges subscribe again when their source fails", func(t *testing.T) {
+		sub := &fakeSubscriber{runs: make(chan []string, 2), onRun: func(ctx context.Context, handle func(topic string, payload []byte)) error {
+			return errors.New("connection refused")
+		}}
+		origNewSubscriber := newSubscriber
+		newSubscriber = func(*Bridge, log.Logger) subscriber { return sub }
+		t.Cleanup(func() { newSubscriber = origNewSubscriber })
+
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		go s.runBridge(ctx, s.bridges[0], &fakePublisher{published: make(map[string][]interface{})})
+
+		<-sub.runs
+		select {
+		case <-sub.runs:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the bridge didn't subscribe again")
+		}
+	})
+
+	t.Run("Disabled service has no bridges", func(t *testing.T) {
+		s := &LiveIngestService{Cfg: setting.NewCfg()}
+		require.NoError(t, s.Init())
+		assert.Empty(t, s.bridges)
+	})
+}
@@ -0,0 +1,69 @@
+package liveingest
+
+import (
+	"context"
+	"fmt"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// mqttDisconnectQuiesce is how long, in milliseconds, the client waits for its pending work when
+// it disconnects
+const mqttDisconnectQuiesce = 250
+
+type mqttSubscriber struct {
+	source   *MQTTSource
+	clientID string
+	log      log.Logger
+}
+
+func newMQTTSubscriber(bridge *Bridge, logger log.Logger) subscriber {
+	clientID := bridge.MQTT.ClientID
+	if clientID == "" {
+		clientID = "grafana-" + bridge.Name
+	}
+	return &mqttSubscriber{source: bridge.MQTT, clientID: clientID, log: logger}
+}
+
+func (s *mqttSubscriber) run(ctx context.Context, topics []string, handle func(topic string, payload []byte)) error {
+	lost := make(chan error, 1)
+	opts := mqtt.NewClientOptions().
+		AddBroker(s.source.URL).
+		SetClientID(s.clientID).
+		SetUsername(s.source.Username).
+		SetPassword(s.source.Password).
+		// the bridge subscribes again itself, with backoff
+		SetAutoReconnect(false).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			select {
+			case lost <- err:
+			default:
+			}
+		})
+
+	client := mqtt.NewClient(opts)
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		return fmt.Errorf("failed to connect to MQTT broker %s: %w", s.source.URL, token.Error())
+	}
+	defer client.Disconnect(mqttDisconnectQuiesce)
+
+	filters := make(map[string]byte, len(topics))
+	for _, topic := range topics {
+		filters[topic] = s.source.QoS
+	}
+	token := client.SubscribeMultiple(filters, func(_ mqtt.Client, msg mqtt.Message) {
+		handle(msg.Topic(), msg.Payload())
+	})
+	if token.Wait() && token.Error() != nil {
+		return fmt.Errorf("failed to subscribe to MQTT topics: %w", token.Error())
+	}
+	s.log.Info("Subscribed to MQTT topics", "broker", s.source.URL, "topics", topics)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-lost:
+		return fmt.Errorf("lost connection to MQTT broker %s: %w", s.source.URL, err)
+	}
+}
@@ -87,7 +87,8 @@ func (ns *NotificationService) setFiles(
 }
 
 func (ns *NotificationService) createDialer() (*gomail.Dialer, error) {
-	host, port, err := net.SplitHostPort(ns.Cfg.Smtp.Host)
+	smtp := ns.Cfg.SmtpSettings()
+	host, port, err := net.SplitHostPort(smtp.Host)
 
 	if err != nil {
 		return nil, err
@@ -98,24 +99,24 @@ func (ns *NotificationService) createDialer() (*gomail.Dialer, error) {
 	}
 
 	tlsconfig := &tls.Config{
-		InsecureSkipVerify: ns.Cfg.Smtp.SkipVerify,
+		InsecureSkipVerify: smtp.SkipVerify,
 		ServerName:         host,
 	}
 
-	if ns.Cfg.Smtp.CertFile != "" {
-		cert, err := tls.LoadX509KeyPair(ns.Cfg.Smtp.CertFile, ns.Cfg.Smtp.KeyFile)
+	if smtp.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(smtp.CertFile, smtp.KeyFile)
 		if err != nil {
 			return nil, fmt.Errorf("Could not load cert or key file. error: %v", err)
 		}
 		tlsconfig.Certificates = []tls.Certificate{cert}
 	}
 
-	d := gomail.NewDialer(host, iPort, ns.Cfg.Smtp.User, ns.Cfg.Smtp.Password)
+	d := gomail.NewDialer(host, iPort, smtp.User, smtp.Password)
 	d.TLSConfig = tlsconfig
-	d.StartTLSPolicy = getStartTLSPolicy(ns.Cfg.Smtp.StartTLSPolicy)
+	d.StartTLSPolicy = getStartTLSPolicy(smtp.StartTLSPolicy)
 
-	if ns.Cfg.Smtp.EhloIdentity != "" {
-		d.LocalName = ns.Cfg.Smtp.EhloIdentity
+	if smtp.EhloIdentity != "" {
+		d.LocalName = smtp.EhloIdentity
 	} else {
 		d.LocalName = setting.InstanceName
 	}
@@ -134,7 +135,8 @@ func getStartTLSPolicy(policy string) gomail.StartTLSPolicy {
 }
 
 func (ns *NotificationService) buildEmailMessage(cmd *models.SendEmailCommand) (*Message, error) {
-	if !ns.Cfg.Smtp.Enabled {
+	smtp := ns.Cfg.SmtpSettings()
+	if !smtp.Enabled {
 		return nil, models.ErrSmtpNotEnabled
 	}
 
@@ -179,7 +181,7 @@ func (ns *NotificationService) buildEmailMessage(cmd *models.SendEmailCommand) (
 	return &Message{
 		To:            cmd.To,
 		SingleEmail:   cmd.SingleEmail,
-		From:          fmt.Sprintf("%s <%s>", ns.Cfg.Smtp.FromName, ns.Cfg.Smtp.FromAddress),
+		From:          fmt.Sprintf("%s <%s>", smtp.FromName, smtp.FromAddress),
 		Subject:       subject,
 		Body:          buffer.String(),
 		EmbeddedFiles: cmd.EmbeddedFiles,
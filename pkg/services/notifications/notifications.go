@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/events"
@@ -77,26 +78,59 @@ func (ns *NotificationService) Run(ctx context.Context) error {
 	for {
 		select {
 		case webhook := <-ns.webhookQueue:
-			err := ns.sendWebRequestSync(context.Background(), webhook)
+			ns.sendQueuedWebhook(webhook)
+		case msg := <-ns.mailQueue:
+			ns.sendQueuedEmail(msg)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
 
-			if err != nil {
-				ns.log.Error("Failed to send webrequest ", "error", err)
-			}
+// DrainStage returns the stage in which the notification queues are flushed, last.
+func (ns *NotificationService) DrainStage() registry.DrainStage {
+	return registry.DrainQueues
+}
+
+// Drain sends the queued webhooks and emails.
+func (ns *NotificationService) Drain(ctx context.Context) error {
+	if queued := len(ns.webhookQueue) + len(ns.mailQueue); queued > 0 {
+		ns.log.Info("Sending the queued notifications", "queued", queued)
+	}
+
+	for {
+		select {
+		case webhook := <-ns.webhookQueue:
+			ns.sendQueuedWebhook(webhook)
 		case msg := <-ns.mailQueue:
-			num, err := ns.send(msg)
-			tos := strings.Join(msg.To, "; ")
-			info := ""
-			if err != nil {
-				if len(msg.Info) > 0 {
-					info = ", info: " + msg.Info
-				}
-				ns.log.Error(fmt.Sprintf("Async sent email %d succeed, not send emails: %s%s err: %s", num, tos, info, err))
-			} else {
-				ns.log.Debug(fmt.Sprintf("Async sent email %d succeed, sent emails: %s%s", num, tos, info))
-			}
+			ns.sendQueuedEmail(msg)
 		case <-ctx.Done():
 			return ctx.Err()
+		default:
+			return nil
+		}
+	}
+}
+
+func (ns *NotificationService) sendQueuedWebhook(webhook *Webhook) {
+	err := ns.sendWebRequestSync(context.Background(), webhook)
+
+	if err != nil {
+		ns.log.Error("Failed to send webrequest ", "error", err)
+	}
+}
+
+func (ns *NotificationService) sendQueuedEmail(msg *Message) {
+	num, err := ns.send(msg)
+	tos := strings.Join(msg.To, "; ")
+	info := ""
+	if err != nil {
+		if len(msg.Info) > 0 {
+			info = ", info: " + msg.Info
 		}
+		ns.log.Error(fmt.Sprintf("Async sent email %d succeed, not send emails: %s%s err: %s", num, tos, info, err))
+	} else {
+		ns.log.Debug(fmt.Sprintf("Async sent email %d succeed, sent emails: %s%s", num, tos, info))
 	}
 }
 
@@ -127,13 +161,18 @@ func (ns *NotificationService) sendEmailCommandHandlerSync(ctx context.Context,
 		EmbeddedFiles: cmd.EmbeddedFiles,
 		Subject:       cmd.Subject,
 		ReplyTo:       cmd.ReplyTo,
+		AttachedFiles: cmd.AttachedFiles,
 	})
 
 	if err != nil {
 		return err
 	}
 
+	start := time.Now()
 	_, err = ns.send(message)
+	if recorder := models.NotificationDeliveryRecorderFrom(ctx); recorder != nil {
+		recorder.RecordEmail(message.To, err, time.Since(start))
+	}
 	return err
 }
 
@@ -216,7 +255,7 @@ func (ns *NotificationService) signUpStartedHandler(evt *events.SignUpStarted) e
 }
 
 func (ns *NotificationService) signUpCompletedHandler(evt *events.SignUpCompleted) error {
-	if evt.Email == "" || !ns.Cfg.Smtp.SendWelcomeEmailOnSignUp {
+	if evt.Email == "" || !ns.Cfg.SmtpSettings().SendWelcomeEmailOnSignUp {
 		return nil
 	}
 
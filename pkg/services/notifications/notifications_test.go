@@ -1,6 +1,11 @@
 package notifications
 
 import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 
 	"github.com/grafana/grafana/pkg/bus"
@@ -34,5 +39,55 @@ func TestNotifications(t *testing.T) {
 			So(sentMsg.Subject, ShouldEqual, "Reset your Grafana password - asd@asd.com")
 			So(sentMsg.Body, ShouldNotContainSubstring, "Subject")
 		})
+
+		Convey("When draining the queued notifications", func() {
+			var received int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&received, 1)
+			}))
+			defer server.Close()
+
+			ns.webhookQueue <- &Webhook{Url: server.URL, Body: "first"}
+			ns.webhookQueue <- &Webhook{Url: server.URL, Body: "second"}
+
+			err := ns.Drain(context.Background())
+			So(err, ShouldBeNil)
+			So(atomic.LoadInt32(&received), ShouldEqual, 2)
+			So(ns.webhookQueue, ShouldBeEmpty)
+		})
+
+		Convey("When sending a webhook to test a notification channel", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "invalid_token", http.StatusForbidden)
+			}))
+			defer server.Close()
+
+			recorder := &models.NotificationDeliveryRecorder{}
+			ctx := models.WithNotificationDeliveryRecorder(context.Background(), recorder)
+			err := ns.SendWebhookSync(ctx, &models.SendWebhookSync{Url: server.URL + "/services/secret", Body: "{}"})
+			So(err, ShouldNotBeNil)
+
+			deliveries := recorder.Deliveries()
+			So(deliveries, ShouldHaveLength, 1)
+			So(deliveries[0].URL, ShouldEqual, server.URL)
+			So(deliveries[0].Method, ShouldEqual, http.MethodPost)
+			So(deliveries[0].Status, ShouldEqual, http.StatusForbidden)
+			So(deliveries[0].Response, ShouldEqual, "invalid_token\n")
+			So(deliveries[0].Error, ShouldEqual, "Webhook response status 403 Forbidden")
+		})
+
+		Convey("When sending a webhook to a network blocked for the data sources", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			defer server.Close()
+
+			origBlocked := setting.DataSourceBlockedNetworks
+			defer func() { setting.DataSourceBlockedNetworks = origBlocked }()
+			_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+			setting.DataSourceBlockedNetworks = []*net.IPNet{loopback}
+
+			err := ns.SendWebhookSync(context.Background(), &models.SendWebhookSync{Url: server.URL, Body: "{}"})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "requests to 127.0.0.1 are not allowed")
+		})
 	})
 }
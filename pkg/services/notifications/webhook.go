@@ -13,6 +13,7 @@ import (
 
 	"golang.org/x/net/context/ctxhttp"
 
+	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/util"
 )
 
@@ -33,6 +34,8 @@ var netTransport = &http.Transport{
 	Proxy: http.ProxyFromEnvironment,
 	Dial: (&net.Dialer{
 		Timeout: 30 * time.Second,
+		// the webhooks are blocked from the networks the data sources are blocked from
+		Control: models.NetworkPolicyControl,
 	}).Dial,
 	TLSHandshakeTimeout: 5 * time.Second,
 }
@@ -68,8 +71,14 @@ func (ns *NotificationService) sendWebRequestSync(ctx context.Context, webhook *
 		request.Header.Set(k, v)
 	}
 
+	// the webhooks sent to test a notification channel are recorded with the responses of their upstreams
+	recorder := models.NotificationDeliveryRecorderFrom(ctx)
+	start := time.Now()
 	resp, err := ctxhttp.Do(ctx, netClient, request)
 	if err != nil {
+		if recorder != nil {
+			recorder.RecordWebhook(request.Method, request.URL, 0, nil, err, time.Since(start))
+		}
 		return err
 	}
 
@@ -77,6 +86,10 @@ func (ns *NotificationService) sendWebRequestSync(ctx context.Context, webhook *
 
 	if resp.StatusCode/100 == 2 {
 		ns.log.Debug("Webhook succeeded", "url", webhook.Url, "statuscode", resp.Status)
+		if recorder != nil {
+			body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, models.MaxNotificationDeliveryResponse))
+			recorder.RecordWebhook(request.Method, request.URL, resp.StatusCode, body, nil, time.Since(start))
+		}
 		// flushing the body enables the transport to reuse the same connection
 		if _, err := io.Copy(ioutil.Discard, resp.Body); err != nil {
 			ns.log.Error("Failed to copy resp.Body to ioutil.Discard", "err", err)
@@ -90,5 +103,9 @@ func (ns *NotificationService) sendWebRequestSync(ctx context.Context, webhook *
 	}
 
 	ns.log.Debug("Webhook failed", "url", webhook.Url, "statuscode", resp.Status, "body", string(body))
-	return fmt.Errorf("Webhook response status %v", resp.Status)
+	err = fmt.Errorf("Webhook response status %v", resp.Status)
+	if recorder != nil {
+		recorder.RecordWebhook(request.Method, request.URL, resp.StatusCode, body, err, time.Since(start))
+	}
+	return err
 }
@@ -0,0 +1,70 @@
+package orgusage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+)
+
+func init() {
+	registry.RegisterService(&OrgUsageService{})
+}
+
+// OrgUsageService counts the API calls per organization. The counts are kept
+// in memory and added to the org_api_usage table every minute.
+type OrgUsageService struct {
+	log log.Logger
+
+	mutex    sync.Mutex
+	apiCalls map[int64]int64
+}
+
+func (s *OrgUsageService) Init() error {
+	s.log = log.New("orgusage")
+	s.apiCalls = make(map[int64]int64)
+	return nil
+}
+
+func (s *OrgUsageService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-ctx.Done():
+			s.flush()
+			return ctx.Err()
+		}
+	}
+}
+
+// RecordApiCall counts an API call made in the organization
+func (s *OrgUsageService) RecordApiCall(orgId int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.apiCalls[orgId]++
+}
+
+func (s *OrgUsageService) flush() {
+	s.mutex.Lock()
+	apiCalls := s.apiCalls
+	s.apiCalls = make(map[int64]int64)
+	s.mutex.Unlock()
+
+	if len(apiCalls) == 0 {
+		return
+	}
+
+	cmd := models.IncrementOrgApiUsageCommand{Day: time.Now(), ApiCalls: apiCalls}
+	if err := bus.Dispatch(&cmd); err != nil {
+		s.log.Error("Failed to save org api usage", "error", err)
+	}
+}
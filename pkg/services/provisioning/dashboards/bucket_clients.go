@@ -0,0 +1,342 @@
+package dashboards
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/grafana/grafana/pkg/components/imguploader"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const gcsReadOnlyScope = "https://www.googleapis.com/auth/devstorage.read_only"
+
+var bucketHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// s3BucketClient reads the objects of an Amazon S3 bucket, or a bucket of a storage with an S3
+// compatible API. It uses the same credentials chain as the CloudWatch data source.
+type s3BucketClient struct {
+	bucket         string
+	endpoint       string
+	forcePathStyle bool
+	dsInfo         *cloudwatch.DatasourceInfo
+}
+
+func newS3BucketClient(cfg *config) (*s3BucketClient, error) {
+	c := &s3BucketClient{dsInfo: &cloudwatch.DatasourceInfo{}}
+
+	c.bucket, _ = cfg.Options["bucket"].(string)
+	if c.bucket == "" {
+		return nil, fmt.Errorf("Failed to load dashboards. bucket param is required for s3 providers")
+	}
+
+	c.endpoint, _ = cfg.Options["endpoint"].(string)
+	c.forcePathStyle, _ = cfg.Options["forcePathStyle"].(bool)
+
+	c.dsInfo.Region, _ = cfg.Options["region"].(string)
+	c.dsInfo.AuthType, _ = cfg.Options["authType"].(string)
+	c.dsInfo.Profile, _ = cfg.Options["profile"].(string)
+	c.dsInfo.AssumeRoleArn, _ = cfg.Options["assumeRoleArn"].(string)
+	c.dsInfo.ExternalID, _ = cfg.Options["externalId"].(string)
+	c.dsInfo.AccessKey, _ = cfg.Options["accessKey"].(string)
+	c.dsInfo.SecretKey, _ = cfg.Options["secretKey"].(string)
+
+	return c, nil
+}
+
+// service returns a new client for every fetch, as assumed role credentials expire
+func (c *s3BucketClient) service() (*s3.S3, error) {
+	cfg, err := cloudwatch.GetAwsConfig(c.dsInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.endpoint != "" {
+		cfg.Endpoint = aws.String(c.endpoint)
+	}
+	cfg.S3ForcePathStyle = aws.Bool(c.forcePathStyle)
+
+	sess, err := session.NewSession(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return s3.New(sess), nil
+}
+
+func (c *s3BucketClient) list(prefix string) ([]bucketObject, error) {
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []bucketObject
+	input := &s3.ListObjectsV2Input{Bucket: aws.String(c.bucket), Prefix: aws.String(prefix)}
+	err = svc.ListObjectsV2Pages(input, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range page.Contents {
+			objects = append(objects, bucketObject{Key: aws.StringValue(object.Key), ETag: aws.StringValue(object.ETag)})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (c *s3BucketClient) get(key string) (io.ReadCloser, error) {
+	svc, err := c.service()
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := svc.GetObject(&s3.GetObjectInput{Bucket: aws.String(c.bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, err
+	}
+
+	return output.Body, nil
+}
+
+func (c *s3BucketClient) location(prefix string) string {
+	return "s3://" + c.bucket + "/" + prefix
+}
+
+// gcsBucketClient reads the objects of a Google Cloud Storage bucket using the JSON API
+type gcsBucketClient struct {
+	bucket  string
+	baseURL string
+	client  *http.Client
+}
+
+func newGCSBucketClient(cfg *config) (*gcsBucketClient, error) {
+	c := &gcsBucketClient{baseURL: "https://storage.googleapis.com/storage/v1"}
+
+	c.bucket, _ = cfg.Options["bucket"].(string)
+	if c.bucket == "" {
+		return nil, fmt.Errorf("Failed to load dashboards. bucket param is required for gcs providers")
+	}
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, bucketHTTPClient)
+
+	// without a key file, use the application default credentials
+	keyFile, _ := cfg.Options["keyFile"].(string)
+	if keyFile == "" {
+		client, err := google.DefaultClient(ctx, gcsReadOnlyScope)
+		if err != nil {
+			return nil, err
+		}
+		c.client = client
+		return c, nil
+	}
+
+	data, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	conf, err := google.JWTConfigFromJSON(data, gcsReadOnlyScope)
+	if err != nil {
+		return nil, err
+	}
+	c.client = conf.Client(ctx)
+
+	return c, nil
+}
+
+func (c *gcsBucketClient) list(prefix string) ([]bucketObject, error) {
+	var objects []bucketObject
+	pageToken := ""
+
+	for {
+		params := url.Values{}
+		params.Set("prefix", prefix)
+		params.Set("fields", "items(name,etag),nextPageToken")
+		if pageToken != "" {
+			params.Set("pageToken", pageToken)
+		}
+
+		var page struct {
+			Items []struct {
+				Name string `json:"name"`
+				ETag string `json:"etag"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+
+		body, err := bucketRequest(c.client, c.baseURL+"/b/"+url.PathEscape(c.bucket)+"/o?"+params.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		err = json.NewDecoder(body).Decode(&page)
+		body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range page.Items {
+			objects = append(objects, bucketObject{Key: item.Name, ETag: item.ETag})
+		}
+
+		if page.NextPageToken == "" {
+			return objects, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+func (c *gcsBucketClient) get(key string) (io.ReadCloser, error) {
+	return bucketRequest(c.client, c.baseURL+"/b/"+url.PathEscape(c.bucket)+"/o/"+url.PathEscape(key)+"?alt=media", nil)
+}
+
+func (c *gcsBucketClient) location(prefix string) string {
+	return "gs://" + c.bucket + "/" + prefix
+}
+
+// azureBucketClient reads the blobs of an Azure Blob Storage container, authenticating with
+// either the key of the storage account or a shared access signature
+type azureBucketClient struct {
+	baseURL   string
+	container string
+	sasToken  string
+	auth      *imguploader.Auth
+}
+
+type azureBlobList struct {
+	Blobs      []imguploader.Blob `xml:"Blobs>Blob"`
+	NextMarker string             `xml:"NextMarker"`
+}
+
+func newAzureBucketClient(cfg *config) (*azureBucketClient, error) {
+	c := &azureBucketClient{}
+
+	accountName, _ := cfg.Options["accountName"].(string)
+	c.container, _ = cfg.Options["containerName"].(string)
+	if accountName == "" || c.container == "" {
+		return nil, fmt.Errorf("Failed to load dashboards. accountName and containerName params are required for azureblob providers")
+	}
+	c.baseURL = fmt.Sprintf("https://%s.blob.core.windows.net", accountName)
+
+	c.sasToken, _ = cfg.Options["sasToken"].(string)
+	c.sasToken = strings.TrimPrefix(c.sasToken, "?")
+
+	accountKey, _ := cfg.Options["accountKey"].(string)
+	if accountKey != "" {
+		c.auth = &imguploader.Auth{Account: accountName, Key: accountKey}
+	}
+
+	if c.auth == nil && c.sasToken == "" {
+		return nil, fmt.Errorf("Failed to load dashboards. accountKey or sasToken param is required for azureblob providers")
+	}
+
+	return c, nil
+}
+
+func (c *azureBucketClient) list(prefix string) ([]bucketObject, error) {
+	var objects []bucketObject
+	marker := ""
+
+	for {
+		params := url.Values{}
+		params.Set("restype", "container")
+		params.Set("comp", "list")
+		params.Set("prefix", prefix)
+		if marker != "" {
+			params.Set("marker", marker)
+		}
+
+		body, err := c.request(c.baseURL + "/" + url.PathEscape(c.container) + "?" + params.Encode())
+		if err != nil {
+			return nil, err
+		}
+
+		var page azureBlobList
+		err = xml.NewDecoder(body).Decode(&page)
+		body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, blob := range page.Blobs {
+			objects = append(objects, bucketObject{Key: blob.Name, ETag: blob.Property.Etag})
+		}
+
+		if page.NextMarker == "" {
+			return objects, nil
+		}
+		marker = page.NextMarker
+	}
+}
+
+func (c *azureBucketClient) get(key string) (io.ReadCloser, error) {
+	escaped := make([]string, 0)
+	for _, part := range strings.Split(key, "/") {
+		escaped = append(escaped, url.PathEscape(part))
+	}
+
+	return c.request(c.baseURL + "/" + url.PathEscape(c.container) + "/" + strings.Join(escaped, "/"))
+}
+
+func (c *azureBucketClient) request(u string) (io.ReadCloser, error) {
+	// requests signed with the account key must not have the signature of the SAS token
+	if c.auth == nil {
+		if strings.Contains(u, "?") {
+			u += "&" + c.sasToken
+		} else {
+			u += "?" + c.sasToken
+		}
+	}
+
+	return bucketRequest(bucketHTTPClient, u, func(req *http.Request) error {
+		req.Header.Set("x-ms-version", "2017-04-17")
+		req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+		if c.auth != nil {
+			return c.auth.SignRequest(req)
+		}
+		return nil
+	})
+}
+
+func (c *azureBucketClient) location(prefix string) string {
+	return c.baseURL + "/" + c.container + "/" + prefix
+}
+
+// bucketRequest sends a GET request and returns the body of successful responses
+func bucketRequest(client *http.Client, u string, prepare func(req *http.Request) error) (io.ReadCloser, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if prepare != nil {
+		if err := prepare(req); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("request to %s failed with status %d: %s", req.URL.Host, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return resp.Body, nil
+}
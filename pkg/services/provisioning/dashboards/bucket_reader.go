@@ -0,0 +1,235 @@
+package dashboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+)
+
+// etagsFile is the file in the directory of a bucket reader that keeps the ETags of the
+// objects that have been downloaded, so that unchanged objects are not downloaded again after a restart
+const etagsFile = ".etags"
+
+// bucketObject is an object in a bucket of an object storage
+type bucketObject struct {
+	Key  string
+	ETag string
+}
+
+// bucketClient lists and downloads the objects in a bucket of an object storage
+type bucketClient interface {
+	// list returns all objects with keys that start with prefix
+	list(prefix string) ([]bucketObject, error)
+	// get returns the content of the object with key
+	get(key string) (io.ReadCloser, error)
+	// location returns the URL of the objects with keys that start with prefix, like s3://bucket/prefix
+	location(prefix string) string
+}
+
+// bucketSource mirrors the dashboards in a bucket of an object storage to a local directory.
+// Key prefixes become directories, so that foldersFromFilesStructure creates a folder per prefix.
+type bucketSource struct {
+	client    bucketClient
+	prefix    string
+	directory string
+
+	// etags maps the paths of the downloaded files, relative to directory, to the ETag of their object
+	etags map[string]string
+}
+
+// NewDashboardBucketReader returns a new filereader that reads the dashboards from a local copy
+// of the objects in the bucket in `config`, and updates the objects that changed before every scan.
+func NewDashboardBucketReader(cfg *config, log log.Logger) (*FileReader, error) {
+	var client bucketClient
+	var err error
+
+	switch cfg.Type {
+	case "s3":
+		client, err = newS3BucketClient(cfg)
+	case "gcs":
+		client, err = newGCSBucketClient(cfg)
+	case "azureblob":
+		client, err = newAzureBucketClient(cfg)
+	default:
+		err = fmt.Errorf("type %s is not an object storage", cfg.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	foldersFromFilesStructure, _ := cfg.Options["foldersFromFilesStructure"].(bool)
+	if foldersFromFilesStructure && cfg.Folder != "" && cfg.FolderUID != "" {
+		return nil, fmt.Errorf("'folder' and 'folderUID' should be empty using 'foldersFromFilesStructure' option")
+	}
+
+	source := newBucketSource(cfg, client)
+
+	fr := &FileReader{
+		Cfg:                          cfg,
+		Path:                         source.directory,
+		log:                          log,
+		dashboardProvisioningService: dashboards.NewProvisioningService(),
+		FoldersFromFilesStructure:    foldersFromFilesStructure,
+		source:                       source,
+	}
+
+	return fr, nil
+}
+
+func newBucketSource(cfg *config, client bucketClient) *bucketSource {
+	s := &bucketSource{client: client}
+
+	s.prefix, _ = cfg.Options["prefix"].(string)
+	s.prefix = strings.TrimPrefix(s.prefix, "/")
+	if s.prefix != "" && !strings.HasSuffix(s.prefix, "/") {
+		s.prefix += "/"
+	}
+
+	s.directory, _ = cfg.Options["directory"].(string)
+	if s.directory == "" {
+		s.directory = filepath.Join(os.TempDir(), "grafana-provisioning", cfg.Type, models.SlugifyTitle(cfg.Name))
+	}
+
+	return s
+}
+
+// fetch downloads the dashboards with an ETag that changed since the last fetch, and
+// removes the files of dashboards that have been deleted from the bucket
+func (s *bucketSource) fetch() (string, error) {
+	if s.etags == nil {
+		if err := s.loadETags(); err != nil {
+			return "", err
+		}
+	}
+
+	objects, err := s.client.list(s.prefix)
+	if err != nil {
+		return "", err
+	}
+
+	found := make(map[string]bool)
+	for _, object := range objects {
+		path, ok := s.localPath(object.Key)
+		if !ok {
+			continue
+		}
+		found[path] = true
+
+		if s.etags[path] == object.ETag && fileExists(filepath.Join(s.directory, path)) {
+			continue
+		}
+
+		if err := s.download(object.Key, path); err != nil {
+			_ = s.saveETags()
+			return "", err
+		}
+		s.etags[path] = object.ETag
+	}
+
+	for path := range s.etags {
+		if found[path] {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(s.directory, path)); err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		delete(s.etags, path)
+	}
+
+	if err := s.saveETags(); err != nil {
+		return "", err
+	}
+
+	return "Provisioned from " + s.client.location(s.prefix), nil
+}
+
+// localPath returns the path of the file for the object with key, relative to the directory
+// of the source, and false for objects that are not dashboards
+func (s *bucketSource) localPath(key string) (string, bool) {
+	path := strings.TrimPrefix(key, s.prefix)
+	if path == "" || !strings.HasSuffix(path, ".json") {
+		return "", false
+	}
+
+	// keys are not paths, don't write outside of the directory
+	for _, part := range strings.Split(path, "/") {
+		if part == "" || part == "." || part == ".." {
+			return "", false
+		}
+	}
+
+	return filepath.FromSlash(path), true
+}
+
+func (s *bucketSource) download(key string, path string) error {
+	body, err := s.client.get(key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	filename := filepath.Join(s.directory, path)
+	if err := os.MkdirAll(filepath.Dir(filename), 0750); err != nil {
+		return err
+	}
+
+	// write to a temporary file first so that scans never read a partially downloaded dashboard
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), ".download-")
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to download %s: %w", key, err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filename)
+}
+
+func (s *bucketSource) loadETags() error {
+	s.etags = make(map[string]string)
+
+	data, err := ioutil.ReadFile(filepath.Join(s.directory, etagsFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &s.etags)
+}
+
+func (s *bucketSource) saveETags() error {
+	data, err := json.Marshal(s.etags)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.directory, 0750); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(s.directory, etagsFile), data, 0640)
+}
+
+func fileExists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
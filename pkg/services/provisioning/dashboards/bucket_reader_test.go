@@ -0,0 +1,180 @@
+package dashboards
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBucketClient struct {
+	objects   map[string]string
+	etags     map[string]string
+	downloads []string
+}
+
+func (c *fakeBucketClient) put(key, content, etag string) {
+	c.objects[key] = content
+	c.etags[key] = etag
+}
+
+func (c *fakeBucketClient) list(prefix string) ([]bucketObject, error) {
+	var objects []bucketObject
+	for key := range c.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, bucketObject{Key: key, ETag: c.etags[key]})
+		}
+	}
+	return objects, nil
+}
+
+func (c *fakeBucketClient) get(key string) (io.ReadCloser, error) {
+	c.downloads = append(c.downloads, key)
+	return ioutil.NopCloser(strings.NewReader(c.objects[key])), nil
+}
+
+func (c *fakeBucketClient) location(prefix string) string {
+	return "fake://bucket/" + prefix
+}
+
+func TestBucketSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bucket-provisioning")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client := &fakeBucketClient{objects: map[string]string{}, etags: map[string]string{}}
+	client.put("dashboards/root.json", `{"title": "root"}`, "1")
+	client.put("dashboards/team-a/nested.json", `{"title": "nested"}`, "2")
+	client.put("dashboards/readme.md", "not a dashboard", "3")
+	client.put("dashboards/../escape.json", `{"title": "escape"}`, "4")
+	client.put("other/outside.json", `{"title": "outside"}`, "5")
+
+	cfg := &config{Name: "bucket", Type: "s3", Options: map[string]interface{}{"prefix": "dashboards", "directory": dir}}
+	source := newBucketSource(cfg, client)
+
+	readFile := func(path string) string {
+		data, err := ioutil.ReadFile(filepath.Join(dir, path))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("Mirrors dashboards with key prefixes as directories", func(t *testing.T) {
+		version, err := source.fetch()
+		require.NoError(t, err)
+		require.Equal(t, "Provisioned from fake://bucket/dashboards/", version)
+
+		require.Equal(t, `{"title": "root"}`, readFile("root.json"))
+		require.Equal(t, `{"title": "nested"}`, readFile(filepath.Join("team-a", "nested.json")))
+		require.False(t, fileExists(filepath.Join(dir, "readme.md")))
+		require.False(t, fileExists(filepath.Join(filepath.Dir(dir), "escape.json")))
+		require.Len(t, client.downloads, 2)
+	})
+
+	t.Run("Only downloads objects with a changed ETag", func(t *testing.T) {
+		client.downloads = nil
+		client.put("dashboards/root.json", `{"title": "changed"}`, "6")
+
+		_, err := source.fetch()
+		require.NoError(t, err)
+		require.Equal(t, []string{"dashboards/root.json"}, client.downloads)
+		require.Equal(t, `{"title": "changed"}`, readFile("root.json"))
+	})
+
+	t.Run("Removes dashboards that are deleted from the bucket", func(t *testing.T) {
+		delete(client.objects, "dashboards/team-a/nested.json")
+
+		_, err := source.fetch()
+		require.NoError(t, err)
+		require.False(t, fileExists(filepath.Join(dir, "team-a", "nested.json")))
+	})
+
+	t.Run("Keeps the ETags across restarts", func(t *testing.T) {
+		client.downloads = nil
+
+		restarted := newBucketSource(cfg, client)
+		_, err := restarted.fetch()
+		require.NoError(t, err)
+		require.Empty(t, client.downloads)
+	})
+}
+
+func TestAzureBucketClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "2019", r.URL.Query().Get("sv"))
+		require.Equal(t, "secret", r.URL.Query().Get("sig"))
+
+		if r.URL.Query().Get("comp") == "list" {
+			if r.URL.Query().Get("marker") == "" {
+				fmt.Fprint(w, `<EnumerationResults><Blobs><Blob><Name>a/one.json</Name><Properties><Etag>0x1</Etag></Properties></Blob></Blobs><NextMarker>next</NextMarker></EnumerationResults>`)
+				return
+			}
+			fmt.Fprint(w, `<EnumerationResults><Blobs><Blob><Name>a/two words.json</Name><Properties><Etag>0x2</Etag></Properties></Blob></Blobs><NextMarker /></EnumerationResults>`)
+			return
+		}
+
+		require.Equal(t, "/dashboards/a/two words.json", r.URL.Path)
+		fmt.Fprint(w, `{"title": "two"}`)
+	}))
+	defer server.Close()
+
+	cfg := &config{Options: map[string]interface{}{"accountName": "account", "containerName": "dashboards", "sasToken": "?sv=2019&sig=secret"}}
+	client, err := newAzureBucketClient(cfg)
+	require.NoError(t, err)
+	client.baseURL = server.URL
+
+	objects, err := client.list("a/")
+	require.NoError(t, err)
+	require.Equal(t, []bucketObject{{Key: "a/one.json", ETag: "0x1"}, {Key: "a/two words.json", ETag: "0x2"}}, objects)
+
+	body, err := client.get("a/two words.json")
+	require.NoError(t, err)
+	defer body.Close()
+	data, err := ioutil.ReadAll(body)
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "two"}`, string(data))
+}
+
+func TestGCSBucketClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/b/bucket/o" {
+			require.Equal(t, "dashboards/", r.URL.Query().Get("prefix"))
+			if r.URL.Query().Get("pageToken") == "" {
+				fmt.Fprint(w, `{"items": [{"name": "dashboards/one.json", "etag": "e1"}], "nextPageToken": "next"}`)
+				return
+			}
+			fmt.Fprint(w, `{"items": [{"name": "dashboards/two.json", "etag": "e2"}]}`)
+			return
+		}
+
+		if r.URL.EscapedPath() == "/b/bucket/o/dashboards%2Fone.json" && r.URL.Query().Get("alt") == "media" {
+			fmt.Fprint(w, `{"title": "one"}`)
+			return
+		}
+
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	client := &gcsBucketClient{bucket: "bucket", baseURL: server.URL, client: http.DefaultClient}
+
+	objects, err := client.list("dashboards/")
+	require.NoError(t, err)
+	require.Equal(t, []bucketObject{{Key: "dashboards/one.json", ETag: "e1"}, {Key: "dashboards/two.json", ETag: "e2"}}, objects)
+
+	body, err := client.get("dashboards/one.json")
+	require.NoError(t, err)
+	defer body.Close()
+	data, err := ioutil.ReadAll(body)
+	require.NoError(t, err)
+	require.Equal(t, `{"title": "one"}`, string(data))
+
+	_, err = client.get("dashboards/missing.json")
+	require.Error(t, err)
+}
@@ -111,6 +111,18 @@ func getFileReaders(configs []*config, logger log.Logger) ([]*FileReader, error)
 				return nil, errutil.Wrapf(err, "Failed to create file reader for config %v", config.Name)
 			}
 			readers = append(readers, fileReader)
+		case "git":
+			gitReader, err := NewDashboardGitReader(config, logger.New("type", config.Type, "name", config.Name))
+			if err != nil {
+				return nil, errutil.Wrapf(err, "Failed to create git reader for config %v", config.Name)
+			}
+			readers = append(readers, gitReader)
+		case "s3", "gcs", "azureblob":
+			bucketReader, err := NewDashboardBucketReader(config, logger.New("type", config.Type, "name", config.Name))
+			if err != nil {
+				return nil, errutil.Wrapf(err, "Failed to create %s reader for config %v", config.Type, config.Name)
+			}
+			readers = append(readers, bucketReader)
 		default:
 			return nil, fmt.Errorf("type %s is not supported", config.Type)
 		}
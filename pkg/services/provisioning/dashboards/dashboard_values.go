@@ -0,0 +1,141 @@
+package dashboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+	"github.com/grafana/grafana/pkg/util"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// valuesPlaceholder matches the placeholders of the values in the dashboard templates of a provider,
+// like ${__values.datasourceUid} or ${__values.thresholds.cpu}
+var valuesPlaceholder = regexp.MustCompile(`\$\{__values\.([A-Za-z0-9_\-.]+)\}`)
+
+// dashboardValues are the values a provider fills its dashboard templates with, from the values
+// file and the values of its options. The values of the options take precedence.
+type dashboardValues struct {
+	values map[string]interface{}
+	// checkSum changes with the values, so that the dashboards are saved again when they change
+	checkSum string
+}
+
+// loadDashboardValues reads the values of a provider, nil when it has none. The values file is read
+// every time, so that changing it updates the dashboards. A relative path is relative to the path
+// of the dashboards, like the values files of a Git repository.
+func loadDashboardValues(cfg *config, dashboardsPath string) (*dashboardValues, error) {
+	merged := make(map[string]interface{})
+
+	if file, _ := cfg.Options["valuesFile"].(string); file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dashboardsPath, file)
+		}
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values file: %w", err)
+		}
+
+		var fileValues values.JSONValue
+		if err := yaml.Unmarshal(content, &fileValues); err != nil {
+			return nil, fmt.Errorf("failed to parse values file %q: %w", file, err)
+		}
+		for k, v := range fileValues.Value() {
+			merged[k] = v
+		}
+	}
+
+	if optionValues, ok := cfg.Options["values"].(map[string]interface{}); ok {
+		for k, v := range optionValues {
+			merged[k] = v
+		}
+	}
+
+	if len(merged) == 0 {
+		return nil, nil
+	}
+
+	// maps are marshalled with sorted keys
+	encoded, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	checkSum, err := util.Md5SumString(string(encoded))
+	if err != nil {
+		return nil, err
+	}
+
+	return &dashboardValues{values: merged, checkSum: checkSum}, nil
+}
+
+// lookup returns the value of a key, whose dots separate the keys of nested values
+func (dv *dashboardValues) lookup(key string) (interface{}, error) {
+	var current interface{} = dv.values
+	for _, part := range strings.Split(key, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("value %q not found", key)
+		}
+		if current, ok = m[part]; !ok {
+			return nil, fmt.Errorf("value %q not found", key)
+		}
+	}
+	return current, nil
+}
+
+// apply replaces the placeholders of the values in the strings of a dashboard. A string that is only
+// a placeholder is replaced with the value itself, so that numbers, booleans, lists and objects keep
+// their type, and the placeholders in longer strings with the text of their value.
+func (dv *dashboardValues) apply(data interface{}) (interface{}, error) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			applied, err := dv.apply(item)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = applied
+		}
+		return v, nil
+	case []interface{}:
+		for i, item := range v {
+			applied, err := dv.apply(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = applied
+		}
+		return v, nil
+	case string:
+		return dv.applyToString(v)
+	}
+	return data, nil
+}
+
+func (dv *dashboardValues) applyToString(s string) (interface{}, error) {
+	if match := valuesPlaceholder.FindStringSubmatch(s); match != nil && match[0] == s {
+		return dv.lookup(match[1])
+	}
+
+	var err error
+	result := valuesPlaceholder.ReplaceAllStringFunc(s, func(placeholder string) string {
+		value, lookupErr := dv.lookup(valuesPlaceholder.FindStringSubmatch(placeholder)[1])
+		if lookupErr != nil {
+			err = lookupErr
+			return placeholder
+		}
+		if text, ok := value.(string); ok {
+			return text
+		}
+		encoded, _ := json.Marshal(value)
+		return string(encoded)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,45 @@
+package dashboards
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardValues(t *testing.T) {
+	dv := &dashboardValues{values: map[string]interface{}{
+		"env":        "prod",
+		"replicas":   3,
+		"thresholds": map[string]interface{}{"cpu": 90},
+	}}
+
+	t.Run("replaces placeholders keeping the type of whole values", func(t *testing.T) {
+		data := map[string]interface{}{
+			"title":      "${__values.env} - ${__values.replicas} replicas",
+			"thresholds": []interface{}{"${__values.thresholds.cpu}", "${__values.thresholds}"},
+			"legend":     "{{instance}} ${instance}",
+		}
+
+		applied, err := dv.apply(data)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			"title":      "prod - 3 replicas",
+			"thresholds": []interface{}{90, map[string]interface{}{"cpu": 90}},
+			"legend":     "{{instance}} ${instance}",
+		}, applied)
+	})
+
+	t.Run("fails on missing values", func(t *testing.T) {
+		_, err := dv.apply(map[string]interface{}{"title": "${__values.region}"})
+		require.Error(t, err)
+
+		_, err = dv.apply([]interface{}{"${__values.env.name} dashboard"})
+		require.Error(t, err)
+	})
+
+	t.Run("loads no values when the provider has none", func(t *testing.T) {
+		values, err := loadDashboardValues(&config{Options: map[string]interface{}{}}, "")
+		require.NoError(t, err)
+		require.Nil(t, values)
+	})
+}
@@ -34,6 +34,21 @@ type FileReader struct {
 	log                          log.Logger
 	dashboardProvisioningService dashboards.DashboardProvisioningService
 	FoldersFromFilesStructure    bool
+
+	// source is the remote source that git and object storage readers fetch before every
+	// scan, and version the message that describes the version that was fetched last
+	source  remoteSource
+	version string
+
+	// values fill the dashboard templates, nil when the provider has none
+	values *dashboardValues
+}
+
+// remoteSource copies dashboards from a remote location to the path of a FileReader
+type remoteSource interface {
+	// fetch updates the local copy of the dashboards and returns the message that is
+	// stored with the versions of dashboards that change
+	fetch() (string, error)
 }
 
 // NewDashboardFileReader returns a new filereader based on `config`
@@ -63,6 +78,21 @@ func NewDashboardFileReader(cfg *config, log log.Logger) (*FileReader, error) {
 	}, nil
 }
 
+// fetchSource updates the local copy of the dashboards of readers with a remote source. When
+// fetching fails, the dashboards of the last version that was fetched stay provisioned.
+func (fr *FileReader) fetchSource() {
+	version, err := fr.source.fetch()
+	if err != nil {
+		fr.log.Error("Failed to fetch dashboards", "error", err)
+		return
+	}
+
+	if version != fr.version {
+		fr.log.Info("Fetched dashboards", "version", version)
+	}
+	fr.version = version
+}
+
 // pollChanges periodically runs startWalkingDisk based on interval specified in the config.
 func (fr *FileReader) pollChanges(ctx context.Context) {
 
@@ -82,12 +112,22 @@ func (fr *FileReader) pollChanges(ctx context.Context) {
 // startWalkingDisk traverses the file system for defined path, reads dashboard definition files and applies any change
 // to the database.
 func (fr *FileReader) startWalkingDisk() error {
+	if fr.source != nil {
+		fr.fetchSource()
+	}
+
 	fr.log.Debug("Start walking disk", "path", fr.Path)
 	resolvedPath := fr.resolvedPath()
 	if _, err := os.Stat(resolvedPath); err != nil {
 		return err
 	}
 
+	dashValues, err := loadDashboardValues(fr.Cfg, resolvedPath)
+	if err != nil {
+		return err
+	}
+	fr.values = dashValues
+
 	provisionedDashboardRefs, err := getProvisionedDashboardByPath(fr.dashboardProvisioningService, fr.Cfg.Name)
 	if err != nil {
 		return err
@@ -203,7 +243,8 @@ func (fr *FileReader) saveDashboard(path string, folderID int64, fileInfo os.Fil
 	}
 
 	provisionedData, alreadyProvisioned := provisionedDashboardRefs[path]
-	upToDate := alreadyProvisioned && provisionedData.Updated >= resolvedFileInfo.ModTime().Unix()
+	// the dashboards filled with values are compared by checksum only, as the values may have changed
+	upToDate := alreadyProvisioned && fr.values == nil && provisionedData.Updated >= resolvedFileInfo.ModTime().Unix()
 
 	jsonFile, err := fr.readDashboardFromFile(path, resolvedFileInfo.ModTime(), folderID)
 	if err != nil {
@@ -233,6 +274,10 @@ func (fr *FileReader) saveDashboard(path string, folderID int64, fileInfo os.Fil
 		dash.Dashboard.SetId(provisionedData.DashboardId)
 	}
 
+	if fr.version != "" {
+		dash.Message = fr.version
+	}
+
 	fr.log.Debug("saving new dashboard", "provisioner", fr.Cfg.Name, "file", path, "folderId", dash.Dashboard.FolderId)
 	dp := &models.DashboardProvisioning{
 		ExternalId: path,
@@ -358,7 +403,11 @@ func (fr *FileReader) readDashboardFromFile(path string, lastModified time.Time,
 		return nil, err
 	}
 
-	checkSum, err := util.Md5SumString(string(all))
+	content := string(all)
+	if fr.values != nil {
+		content += fr.values.checkSum
+	}
+	checkSum, err := util.Md5SumString(content)
 	if err != nil {
 		return nil, err
 	}
@@ -368,6 +417,14 @@ func (fr *FileReader) readDashboardFromFile(path string, lastModified time.Time,
 		return nil, err
 	}
 
+	if fr.values != nil {
+		applied, err := fr.values.apply(data.Interface())
+		if err != nil {
+			return nil, err
+		}
+		data = simplejson.NewFromAny(applied)
+	}
+
 	dash, err := createDashboardJSON(data, lastModified, fr.Cfg, folderID)
 	if err != nil {
 		return nil, err
@@ -25,6 +25,7 @@ var (
 	containingID              = "testdata/test-dashboards/containing-id"
 	unprovision               = "testdata/test-dashboards/unprovision"
 	foldersFromFilesStructure = "testdata/test-dashboards/folders-from-files-structure"
+	templated                 = "testdata/test-dashboards/templated"
 
 	fakeService *fakeDashboardProvisioningService
 )
@@ -149,6 +150,38 @@ func TestDashboardFileReader(t *testing.T) {
 				So(len(fakeService.inserted), ShouldEqual, 1)
 			})
 
+			Convey("Fills dashboard templates with values", func() {
+				cfg.Options["path"] = templated
+				cfg.Options["valuesFile"] = "values/prod.yaml"
+				cfg.Options["values"] = map[string]interface{}{"datasourceUid": "prometheus-eu"}
+
+				reader, err := NewDashboardFileReader(cfg, logger)
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk()
+				So(err, ShouldBeNil)
+
+				So(len(fakeService.inserted), ShouldEqual, 1)
+				dash := fakeService.inserted[0].Dashboard
+				So(dash.Title, ShouldEqual, "prod service")
+				So(dash.Uid, ShouldEqual, "service-prod")
+				panel := dash.Data.Get("panels").GetIndex(0)
+				So(panel.GetPath("datasource", "uid").MustString(), ShouldEqual, "prometheus-eu")
+				So(panel.Get("thresholds").GetIndex(0).Get("value").MustInt(), ShouldEqual, 90)
+			})
+
+			Convey("Fails to read dashboard templates without the values file", func() {
+				cfg.Options["path"] = templated
+				cfg.Options["valuesFile"] = "values/missing.yaml"
+
+				reader, err := NewDashboardFileReader(cfg, logger)
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk()
+				So(err, ShouldNotBeNil)
+				So(len(fakeService.inserted), ShouldEqual, 0)
+			})
+
 			Convey("Overrides id from dashboard.json files", func() {
 				cfg.Options["path"] = containingID
 
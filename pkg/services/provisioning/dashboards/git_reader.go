@@ -0,0 +1,176 @@
+package dashboards
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+)
+
+// gitRepository keeps a shallow clone of a branch of a Git repository up to date
+type gitRepository struct {
+	url        string
+	branch     string
+	directory  string
+	sshKeyFile string
+	username   string
+	token      string
+}
+
+// NewDashboardGitReader returns a new filereader that reads the dashboards from a clone of
+// the Git repository in `config`, and pulls the repository before every scan.
+func NewDashboardGitReader(cfg *config, log log.Logger) (*FileReader, error) {
+	repository, err := newGitRepository(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	foldersFromFilesStructure, _ := cfg.Options["foldersFromFilesStructure"].(bool)
+	if foldersFromFilesStructure && cfg.Folder != "" && cfg.FolderUID != "" {
+		return nil, fmt.Errorf("'folder' and 'folderUID' should be empty using 'foldersFromFilesStructure' option")
+	}
+
+	// path is relative to the root of the repository
+	path, _ := cfg.Options["path"].(string)
+
+	fr := &FileReader{
+		Cfg:                          cfg,
+		Path:                         filepath.Join(repository.directory, path),
+		log:                          log,
+		dashboardProvisioningService: dashboards.NewProvisioningService(),
+		FoldersFromFilesStructure:    foldersFromFilesStructure,
+		source:                       repository,
+	}
+
+	return fr, nil
+}
+
+func newGitRepository(cfg *config) (*gitRepository, error) {
+	url, _ := cfg.Options["url"].(string)
+	if url == "" {
+		return nil, fmt.Errorf("Failed to load dashboards. url param is required for git providers")
+	}
+
+	r := &gitRepository{url: url}
+	r.branch, _ = cfg.Options["branch"].(string)
+	r.sshKeyFile, _ = cfg.Options["sshKeyFile"].(string)
+	r.token, _ = cfg.Options["token"].(string)
+
+	r.username, _ = cfg.Options["username"].(string)
+	if r.username == "" {
+		r.username = "git"
+	}
+
+	r.directory, _ = cfg.Options["directory"].(string)
+	if r.directory == "" {
+		r.directory = filepath.Join(os.TempDir(), "grafana-provisioning", "git", models.SlugifyTitle(cfg.Name))
+	}
+
+	return r, nil
+}
+
+func (r *gitRepository) fetch() (string, error) {
+	commit, err := r.sync()
+	if err != nil {
+		return "", err
+	}
+
+	return "Provisioned from Git commit " + commit, nil
+}
+
+// sync clones the repository, or pulls the latest commit of the branch if it has been
+// cloned before, and returns the SHA of the checked out commit
+func (r *gitRepository) sync() (string, error) {
+	var err error
+	if _, statErr := os.Stat(filepath.Join(r.directory, ".git")); os.IsNotExist(statErr) {
+		err = r.clone()
+	} else {
+		err = r.pull()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return r.git(r.directory, "rev-parse", "HEAD")
+}
+
+func (r *gitRepository) clone() error {
+	if err := os.RemoveAll(r.directory); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.directory), 0750); err != nil {
+		return err
+	}
+
+	args := []string{"clone", "--depth", "1", "--single-branch"}
+	if r.branch != "" {
+		args = append(args, "--branch", r.branch)
+	}
+	args = append(args, "--", r.url, r.directory)
+
+	_, err := r.git("", args...)
+	return err
+}
+
+func (r *gitRepository) pull() error {
+	ref := "HEAD"
+	if r.branch != "" {
+		ref = r.branch
+	}
+
+	if _, err := r.git(r.directory, "fetch", "--depth", "1", "origin", ref); err != nil {
+		return err
+	}
+
+	if _, err := r.git(r.directory, "reset", "--hard", "FETCH_HEAD"); err != nil {
+		return err
+	}
+
+	_, err := r.git(r.directory, "clean", "-fdx")
+	return err
+}
+
+func (r *gitRepository) git(dir string, args ...string) (string, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), r.env()...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (r *gitRepository) env() []string {
+	// fail instead of waiting for credentials on a terminal that does not exist
+	env := []string{"GIT_TERMINAL_PROMPT=0"}
+
+	if r.sshKeyFile != "" {
+		env = append(env, "GIT_SSH_COMMAND=ssh -i "+shellQuote(r.sshKeyFile)+" -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new")
+	}
+
+	if r.token != "" {
+		// GIT_CONFIG_PARAMETERS sets config like `git -c` does, without showing the token in the process list
+		auth := base64.StdEncoding.EncodeToString([]byte(r.username + ":" + r.token))
+		env = append(env, "GIT_CONFIG_PARAMETERS="+shellQuote("http.extraHeader=Authorization: Basic "+auth))
+	}
+
+	return env
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
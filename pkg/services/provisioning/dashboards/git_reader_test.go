@@ -0,0 +1,101 @@
+package dashboards
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "git-provisioning")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the repository dashboards are provisioned from
+	origin := filepath.Join(dir, "origin")
+	require.NoError(t, os.Mkdir(origin, 0750))
+
+	git := func(args ...string) string {
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		out, err := exec.Command("git", append([]string{"-C", origin}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return string(out)
+	}
+	commit := func(file string, content string) string {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(origin, file), []byte(content), 0600))
+		git("add", ".")
+		git("commit", "-m", "update "+file)
+		return git("rev-parse", "HEAD")[:40]
+	}
+
+	git("init")
+	git("checkout", "-b", "dashboards")
+	first := commit("dashboard.json", `{"title": "first"}`)
+
+	repository, err := newGitRepository(&config{
+		Name: "Git dashboards",
+		Options: map[string]interface{}{
+			"url":       "file://" + origin,
+			"branch":    "dashboards",
+			"directory": filepath.Join(dir, "clone"),
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("Clones the branch", func(t *testing.T) {
+		sha, err := repository.sync()
+		require.NoError(t, err)
+		require.Equal(t, first, sha)
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, "clone", "dashboard.json"))
+		require.NoError(t, err)
+		require.Equal(t, `{"title": "first"}`, string(content))
+	})
+
+	t.Run("Pulls new commits", func(t *testing.T) {
+		second := commit("dashboard.json", `{"title": "second"}`)
+
+		// files that are not in the repository are removed
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "clone", "local.json"), []byte("{}"), 0600))
+
+		sha, err := repository.sync()
+		require.NoError(t, err)
+		require.Equal(t, second, sha)
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, "clone", "dashboard.json"))
+		require.NoError(t, err)
+		require.Equal(t, `{"title": "second"}`, string(content))
+
+		_, err = os.Stat(filepath.Join(dir, "clone", "local.json"))
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Returns errors of git", func(t *testing.T) {
+		repository, err := newGitRepository(&config{
+			Name:    "Missing",
+			Options: map[string]interface{}{"url": "file://" + filepath.Join(dir, "missing"), "directory": filepath.Join(dir, "missing-clone")},
+		})
+		require.NoError(t, err)
+
+		_, err = repository.sync()
+		require.Error(t, err)
+	})
+}
+
+func TestNewGitRepository(t *testing.T) {
+	_, err := newGitRepository(&config{Name: "no url", Options: map[string]interface{}{}})
+	require.Error(t, err)
+
+	repository, err := newGitRepository(&config{Name: "Git dashboards", Options: map[string]interface{}{"url": "https://example.com/dashboards.git", "token": "secret"}})
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(os.TempDir(), "grafana-provisioning", "git", "git-dashboards"), repository.directory)
+	require.Contains(t, repository.env(), "GIT_CONFIG_PARAMETERS='http.extraHeader=Authorization: Basic Z2l0OnNlY3JldA=='")
+}
@@ -0,0 +1,183 @@
+package datasources
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// Actions of a Change
+const (
+	ChangeCreate = "create"
+	ChangeUpdate = "update"
+	ChangeDelete = "delete"
+	ChangeNone   = "none"
+)
+
+// Change is a change that provisioning makes to a data source. Fields are the fields an
+// update changes.
+type Change struct {
+	Action string   `json:"action"`
+	OrgId  int64    `json:"orgId"`
+	Name   string   `json:"name"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+// DryRun scans a directory for provisioning config files and returns the changes that
+// provisioning the datasources in those files would make, without making them.
+func DryRun(configDirectory string) ([]Change, error) {
+	planned, err := Plan(configDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]Change, 0)
+	for _, change := range planned {
+		if change.Action != ChangeNone {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+// Plan is like DryRun, but also returns the data sources that provisioning leaves unchanged,
+// with the none action.
+func Plan(configDirectory string) ([]Change, error) {
+	dc := newDatasourceProvisioner(log.New("provisioning.datasources"))
+
+	configs, err := dc.cfgProvider.readConfig(configDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]Change, 0)
+	for _, cfg := range configs {
+		cfgChanges, err := planChanges(cfg)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, cfgChanges...)
+	}
+
+	return changes, nil
+}
+
+func planChanges(cfg *configs) ([]Change, error) {
+	type key struct {
+		orgId int64
+		name  string
+	}
+
+	changes := make([]Change, 0)
+	deleted := make(map[key]bool)
+
+	for _, ds := range cfg.DeleteDatasources {
+		query := &models.GetDataSourceByNameQuery{OrgId: ds.OrgID, Name: ds.Name}
+		err := bus.Dispatch(query)
+		if err == models.ErrDataSourceNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		deleted[key{ds.OrgID, ds.Name}] = true
+		changes = append(changes, Change{Action: ChangeDelete, OrgId: ds.OrgID, Name: ds.Name})
+	}
+
+	for _, ds := range cfg.Datasources {
+		query := &models.GetDataSourceByNameQuery{OrgId: ds.OrgID, Name: ds.Name}
+		err := bus.Dispatch(query)
+		if err != nil && err != models.ErrDataSourceNotFound {
+			return nil, err
+		}
+
+		if err == models.ErrDataSourceNotFound || deleted[key{ds.OrgID, ds.Name}] {
+			changes = append(changes, Change{Action: ChangeCreate, OrgId: ds.OrgID, Name: ds.Name})
+			continue
+		}
+
+		if fields := changedFields(query.Result, createUpdateCommand(ds, query.Result.Id)); len(fields) > 0 {
+			changes = append(changes, Change{Action: ChangeUpdate, OrgId: ds.OrgID, Name: ds.Name, Fields: fields})
+		} else {
+			changes = append(changes, Change{Action: ChangeNone, OrgId: ds.OrgID, Name: ds.Name})
+		}
+	}
+
+	return changes, nil
+}
+
+// changedFields returns the fields of the data source that the update command changes. Empty
+// strings in the command leave the field unchanged, except for the passwords.
+func changedFields(ds *models.DataSource, cmd *models.UpdateDataSourceCommand) []string {
+	fields := make([]string, 0)
+	add := func(name string, changed bool) {
+		if changed {
+			fields = append(fields, name)
+		}
+	}
+	addString := func(name string, value string, existing string) {
+		add(name, value != "" && value != existing)
+	}
+
+	addString("uid", cmd.Uid, ds.Uid)
+	addString("type", cmd.Type, ds.Type)
+	addString("access", string(cmd.Access), string(ds.Access))
+	addString("url", cmd.Url, ds.Url)
+	addString("user", cmd.User, ds.User)
+	addString("database", cmd.Database, ds.Database)
+	addString("basicAuthUser", cmd.BasicAuthUser, ds.BasicAuthUser)
+	add("basicAuth", cmd.BasicAuth != ds.BasicAuth)
+	add("withCredentials", cmd.WithCredentials != ds.WithCredentials)
+	add("isDefault", cmd.IsDefault != ds.IsDefault)
+	add("editable", cmd.ReadOnly != ds.ReadOnly)
+	add("jsonData", !jsonEqual(cmd.JsonData.MustMap(), jsonDataMap(ds)))
+	add("password", cmd.Password != ds.Password)
+	add("basicAuthPassword", cmd.BasicAuthPassword != ds.BasicAuthPassword)
+	add("secureJsonData", !secureJsonDataEqual(cmd.SecureJsonData, ds.SecureJsonData.Decrypt()))
+
+	return fields
+}
+
+func jsonDataMap(ds *models.DataSource) map[string]interface{} {
+	if ds.JsonData == nil {
+		return map[string]interface{}{}
+	}
+	return ds.JsonData.MustMap(map[string]interface{}{})
+}
+
+// jsonEqual compares values as JSON, so that numbers read from the database and from
+// provisioning files are equal
+func jsonEqual(a interface{}, b interface{}) bool {
+	var aValue, bValue interface{}
+
+	aJson, err := json.Marshal(a)
+	if err != nil || json.Unmarshal(aJson, &aValue) != nil {
+		return false
+	}
+
+	bJson, err := json.Marshal(b)
+	if err != nil || json.Unmarshal(bJson, &bValue) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(aValue, bValue)
+}
+
+func secureJsonDataEqual(provisioned map[string]string, existing map[string]string) bool {
+	if len(provisioned) != len(existing) {
+		return false
+	}
+
+	for k, v := range provisioned {
+		if existing[k] != v {
+			return false
+		}
+	}
+
+	return true
+}
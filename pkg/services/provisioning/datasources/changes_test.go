@@ -0,0 +1,78 @@
+package datasources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestDryRun(t *testing.T) {
+	fakeRepo = &fakeRepository{}
+	bus.ClearBusHandlers()
+	bus.AddHandler("test", mockDelete)
+	bus.AddHandler("test", mockInsert)
+	bus.AddHandler("test", mockUpdate)
+	bus.AddHandler("test", mockGet)
+
+	graphite := &models.DataSource{
+		OrgId:    1,
+		Name:     "Graphite",
+		Type:     "graphite",
+		Access:   models.DS_ACCESS_PROXY,
+		Url:      "http://localhost:8080",
+		ReadOnly: true,
+		JsonData: simplejson.New(),
+	}
+	fakeRepo.loadAll = []*models.DataSource{
+		graphite,
+		{OrgId: 1, Name: "old-graphite"},
+	}
+
+	t.Run("Returns the changes provisioning would make", func(t *testing.T) {
+		changes, err := DryRun(twoDatasourcesConfigPurgeOthers)
+		require.NoError(t, err)
+		require.Equal(t, []Change{
+			{Action: ChangeDelete, OrgId: 1, Name: "old-graphite"},
+			{Action: ChangeCreate, OrgId: 1, Name: "Prometheus"},
+		}, changes)
+	})
+
+	t.Run("Returns the fields that are updated", func(t *testing.T) {
+		graphite.Url = "http://localhost:8081"
+		graphite.JsonData = simplejson.NewFromAny(map[string]interface{}{"graphiteVersion": "1.1"})
+
+		changes, err := DryRun(twoDatasourcesConfigPurgeOthers)
+		require.NoError(t, err)
+		require.Len(t, changes, 3)
+		require.Equal(t, Change{Action: ChangeUpdate, OrgId: 1, Name: "Graphite", Fields: []string{"url", "jsonData"}}, changes[2])
+	})
+
+	t.Run("Plan returns the unchanged datasources", func(t *testing.T) {
+		graphite.Url = "http://localhost:8080"
+		graphite.JsonData = simplejson.New()
+
+		changes, err := Plan(twoDatasourcesConfigPurgeOthers)
+		require.NoError(t, err)
+		require.Len(t, changes, 3)
+		require.Equal(t, Change{Action: ChangeNone, OrgId: 1, Name: "Graphite"}, changes[2])
+	})
+
+	t.Run("Does not change the datasources", func(t *testing.T) {
+		require.Len(t, fakeRepo.inserted, 0)
+		require.Len(t, fakeRepo.updated, 0)
+		require.Len(t, fakeRepo.deleted, 0)
+	})
+}
+
+func TestJsonEqual(t *testing.T) {
+	fromFile := map[string]interface{}{"timeInterval": "10s", "maxConcurrentShardRequests": 5}
+	fromDatabase, err := simplejson.NewJson([]byte(`{"timeInterval":"10s","maxConcurrentShardRequests":5}`))
+	require.NoError(t, err)
+
+	require.True(t, jsonEqual(fromFile, fromDatabase.MustMap()))
+	require.False(t, jsonEqual(fromFile, map[string]interface{}{"timeInterval": "10s"}))
+}
@@ -1,7 +1,13 @@
 package notifiers
 
 import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"time"
+
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 )
@@ -87,6 +93,12 @@ func (dc *NotificationProvisioner) mergeNotifications(notificationToMerge []*not
 			return err
 		}
 
+		// secure settings that are removed from the file are removed from the notification
+		secureSettings := notification.SecureSettings
+		if secureSettings == nil {
+			secureSettings = map[string]string{}
+		}
+
 		if cmd.Result == nil {
 			dc.log.Debug("inserting alert notification from configuration", "name", notification.Name, "uid", notification.UID)
 			insertCmd := &models.CreateAlertNotificationCommand{
@@ -95,16 +107,18 @@ func (dc *NotificationProvisioner) mergeNotifications(notificationToMerge []*not
 				Type:                  notification.Type,
 				IsDefault:             notification.IsDefault,
 				Settings:              notification.SettingsToJSON(),
+				SecureSettings:        secureSettings,
 				OrgId:                 notification.OrgID,
 				DisableResolveMessage: notification.DisableResolveMessage,
 				Frequency:             notification.Frequency,
 				SendReminder:          notification.SendReminder,
+				Provisioned:           true,
 			}
 
 			if err := bus.Dispatch(insertCmd); err != nil {
 				return err
 			}
-		} else {
+		} else if notificationChanged(cmd.Result, notification) {
 			dc.log.Debug("updating alert notification from configuration", "name", notification.Name)
 			updateCmd := &models.UpdateAlertNotificationWithUidCommand{
 				Uid:                   notification.UID,
@@ -112,10 +126,12 @@ func (dc *NotificationProvisioner) mergeNotifications(notificationToMerge []*not
 				Type:                  notification.Type,
 				IsDefault:             notification.IsDefault,
 				Settings:              notification.SettingsToJSON(),
+				SecureSettings:        secureSettings,
 				OrgId:                 notification.OrgID,
 				DisableResolveMessage: notification.DisableResolveMessage,
 				Frequency:             notification.Frequency,
 				SendReminder:          notification.SendReminder,
+				Provisioned:           true,
 			}
 
 			if err := bus.Dispatch(updateCmd); err != nil {
@@ -127,6 +143,38 @@ func (dc *NotificationProvisioner) mergeNotifications(notificationToMerge []*not
 	return nil
 }
 
+// deleteRemovedNotifications deletes the provisioned notifications that are not in the config files anymore
+func (dc *NotificationProvisioner) deleteRemovedNotifications(configs []*notificationsAsConfig) error {
+	configured := make(map[int64]map[string]bool)
+	for _, cfg := range configs {
+		for _, notification := range cfg.Notifications {
+			if configured[notification.OrgID] == nil {
+				configured[notification.OrgID] = make(map[string]bool)
+			}
+			configured[notification.OrgID][notification.UID] = true
+		}
+	}
+
+	query := &models.GetProvisionedAlertNotificationsQuery{}
+	if err := bus.Dispatch(query); err != nil {
+		return err
+	}
+
+	for _, notification := range query.Result {
+		if configured[notification.OrgId][notification.Uid] {
+			continue
+		}
+
+		dc.log.Info("Deleting alert notification removed from configuration", "name", notification.Name, "uid", notification.Uid, "orgId", notification.OrgId)
+		cmd := &models.DeleteAlertNotificationCommand{Id: notification.Id, OrgId: notification.OrgId}
+		if err := bus.Dispatch(cmd); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (dc *NotificationProvisioner) applyChanges(configPath string) error {
 	configs, err := dc.cfgProvider.readConfig(configPath)
 	if err != nil {
@@ -139,5 +187,67 @@ func (dc *NotificationProvisioner) applyChanges(configPath string) error {
 		}
 	}
 
-	return nil
+	// don't delete all provisioned notifications when the config directory can't be read
+	if _, err := os.Stat(configPath); err != nil {
+		return nil
+	}
+
+	return dc.deleteRemovedNotifications(configs)
+}
+
+// notificationChanged returns true when the notification in the database differs from its configuration
+func notificationChanged(existing *models.AlertNotification, notification *notificationFromConfig) bool {
+	if !existing.Provisioned ||
+		existing.Name != notification.Name ||
+		existing.Type != notification.Type ||
+		existing.IsDefault != notification.IsDefault ||
+		existing.SendReminder != notification.SendReminder ||
+		existing.DisableResolveMessage != notification.DisableResolveMessage {
+		return true
+	}
+
+	if notification.SendReminder {
+		frequency, err := time.ParseDuration(notification.Frequency)
+		if err != nil || frequency != existing.Frequency {
+			return true
+		}
+	}
+
+	if !settingsEqual(existing.Settings, notification.SettingsToJSON()) {
+		return true
+	}
+
+	secureSettings := existing.SecureSettings.Decrypt()
+	if len(secureSettings) != len(notification.SecureSettings) {
+		return true
+	}
+	for key, value := range notification.SecureSettings {
+		if current, ok := secureSettings[key]; !ok || current != value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// settingsEqual compares settings by their JSON, as numbers from config files and the database have different types
+func settingsEqual(a *simplejson.Json, b *simplejson.Json) bool {
+	normalize := func(settings *simplejson.Json) interface{} {
+		if settings == nil {
+			settings = simplejson.New()
+		}
+
+		data, err := settings.MarshalJSON()
+		if err != nil {
+			return nil
+		}
+
+		var result interface{}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil
+		}
+		return result
+	}
+
+	return reflect.DeepEqual(normalize(a), normalize(b))
 }
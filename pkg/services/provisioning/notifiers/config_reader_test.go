@@ -23,6 +23,7 @@ var (
 	emptyFile                    = "./testdata/test-configs/empty"
 	twoNotificationsConfig       = "./testdata/test-configs/two-notifications"
 	unknownNotifier              = "./testdata/test-configs/unknown-notifier"
+	secureSettingsConfig         = "./testdata/test-configs/secure-settings"
 )
 
 func TestNotificationAsConfig(t *testing.T) {
@@ -260,6 +261,64 @@ func TestNotificationAsConfig(t *testing.T) {
 
 		})
 
+		Convey("Secure settings", func() {
+			dc := newNotificationProvisioner(logger)
+			err := dc.applyChanges(secureSettingsConfig)
+			So(err, ShouldBeNil)
+
+			query := models.GetAlertNotificationsWithUidQuery{OrgId: 1, Uid: "secure1"}
+			err = sqlstore.GetAlertNotificationsWithUid(&query)
+			So(err, ShouldBeNil)
+			So(query.Result, ShouldNotBeNil)
+
+			Convey("should be encrypted", func() {
+				So(query.Result.Provisioned, ShouldBeTrue)
+				So(query.Result.Settings.Get("url").MustString(), ShouldEqual, "")
+				So(string(query.Result.SecureSettings["url"]), ShouldNotContainSubstring, "secret")
+				url, err := query.Result.DecryptedValue("url", "")
+				So(err, ShouldBeNil)
+				So(url, ShouldEqual, "https://hooks.slack.com/services/secret")
+			})
+
+			Convey("should only update changed notifications", func() {
+				cfg, err := dc.cfgProvider.readConfig(secureSettingsConfig)
+				So(err, ShouldBeNil)
+
+				notification := cfg[0].Notifications[0]
+				So(notificationChanged(query.Result, notification), ShouldBeFalse)
+
+				notification.SecureSettings["url"] = "https://hooks.slack.com/services/other"
+				So(notificationChanged(query.Result, notification), ShouldBeTrue)
+			})
+		})
+
+		Convey("Notifications removed from the config", func() {
+			existingNotificationCmd := models.CreateAlertNotificationCommand{
+				Name:  "channel0",
+				OrgId: 1,
+				Uid:   "notifier0",
+				Type:  "slack",
+			}
+			err := sqlstore.CreateAlertNotificationCommand(&existingNotificationCmd)
+			So(err, ShouldBeNil)
+
+			dc := newNotificationProvisioner(logger)
+			err = dc.applyChanges(twoNotificationsConfig)
+			So(err, ShouldBeNil)
+
+			Convey("should be deleted if they were provisioned", func() {
+				err = dc.applyChanges(secureSettingsConfig)
+				So(err, ShouldBeNil)
+
+				notificationsQuery := models.GetAllAlertNotificationsQuery{OrgId: 1}
+				err = sqlstore.GetAllAlertNotifications(&notificationsQuery)
+				So(err, ShouldBeNil)
+				So(len(notificationsQuery.Result), ShouldEqual, 2)
+				So(notificationsQuery.Result[0].Uid, ShouldEqual, "notifier0")
+				So(notificationsQuery.Result[1].Uid, ShouldEqual, "secure1")
+			})
+		})
+
 		Convey("Config doesn't contain required field", func() {
 			dc := newNotificationProvisioner(logger)
 			err := dc.applyChanges(noRequiredFields)
@@ -0,0 +1,145 @@
+package orgs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"gopkg.in/yaml.v2"
+)
+
+type configReader struct {
+	log log.Logger
+}
+
+func (cr *configReader) readConfig(path string) ([]*orgsAsConfig, error) {
+	var orgs []*orgsAsConfig
+	cr.log.Debug("Looking for org provisioning files", "path", path)
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		cr.log.Error("Can't read org provisioning files from directory", "path", path, "error", err)
+		return orgs, nil
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml") {
+			cr.log.Debug("Parsing org provisioning file", "path", path, "file.Name", file.Name())
+			cfg, err := cr.parseOrgConfig(path, file)
+			if err != nil {
+				return nil, err
+			}
+
+			if cfg != nil {
+				orgs = append(orgs, cfg)
+			}
+		}
+	}
+
+	cr.log.Debug("Validating orgs, teams and users")
+	if err := validateRequiredField(orgs); err != nil {
+		return nil, err
+	}
+
+	checkOrgIDAndOrgName(orgs)
+
+	return orgs, nil
+}
+
+func (cr *configReader) parseOrgConfig(path string, file os.FileInfo) (*orgsAsConfig, error) {
+	filename, _ := filepath.Abs(filepath.Join(path, file.Name()))
+	yamlFile, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg *orgsAsConfigV0
+	err = yaml.Unmarshal(yamlFile, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.mapToOrgsFromConfig(), nil
+}
+
+func checkOrgIDAndOrgName(orgs []*orgsAsConfig) {
+	for i := range orgs {
+		for _, team := range orgs[i].Teams {
+			team.OrgID = normalizeOrgID(team.OrgID, team.OrgName)
+		}
+
+		for _, team := range orgs[i].DeleteTeams {
+			team.OrgID = normalizeOrgID(team.OrgID, team.OrgName)
+		}
+
+		for _, user := range orgs[i].Users {
+			user.OrgID = normalizeOrgID(user.OrgID, user.OrgName)
+		}
+	}
+}
+
+// normalizeOrgID returns the main org for items without an org, and 0 for items with an org name
+// that is resolved when the config is applied
+func normalizeOrgID(orgID int64, orgName string) int64 {
+	if orgID < 1 {
+		if orgName == "" {
+			return 1
+		}
+		return 0
+	}
+	return orgID
+}
+
+func validateRequiredField(orgs []*orgsAsConfig) error {
+	for i := range orgs {
+		var errStrings []string
+		for index, org := range orgs[i].Orgs {
+			if org.Name == "" {
+				errStrings = append(errStrings, fmt.Sprintf("Added org item %d in configuration doesn't contain required field name", index+1))
+			}
+		}
+
+		for index, org := range orgs[i].DeleteOrgs {
+			if org.Name == "" {
+				errStrings = append(errStrings, fmt.Sprintf("Deleted org item %d in configuration doesn't contain required field name", index+1))
+			}
+		}
+
+		for index, team := range orgs[i].Teams {
+			if team.Name == "" {
+				errStrings = append(errStrings, fmt.Sprintf("Added team item %d in configuration doesn't contain required field name", index+1))
+			}
+
+			for memberIndex, member := range team.Members {
+				if member.Login == "" {
+					errStrings = append(errStrings, fmt.Sprintf("Member %d of added team item %d in configuration doesn't contain required field login", memberIndex+1, index+1))
+				}
+			}
+		}
+
+		for index, team := range orgs[i].DeleteTeams {
+			if team.Name == "" {
+				errStrings = append(errStrings, fmt.Sprintf("Deleted team item %d in configuration doesn't contain required field name", index+1))
+			}
+		}
+
+		for index, user := range orgs[i].Users {
+			if user.Login == "" {
+				errStrings = append(errStrings, fmt.Sprintf("User item %d in configuration doesn't contain required field login", index+1))
+			}
+
+			if !user.Role.IsValid() {
+				errStrings = append(errStrings, fmt.Sprintf("User item %d in configuration has invalid role %q", index+1, user.Role))
+			}
+		}
+
+		if len(errStrings) != 0 {
+			return fmt.Errorf(strings.Join(errStrings, "\n"))
+		}
+	}
+
+	return nil
+}
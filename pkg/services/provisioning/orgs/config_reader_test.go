@@ -0,0 +1,74 @@
+package orgs
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	correctProperties = "./testdata/test-configs/correct-properties"
+	brokenYaml        = "./testdata/test-configs/broken-yaml"
+	noRequiredFields  = "./testdata/test-configs/no-required-fields"
+	invalidRole       = "./testdata/test-configs/invalid-role"
+	emptyFolder       = "./testdata/test-configs/empty_folder"
+)
+
+func TestConfigReader(t *testing.T) {
+	t.Run("Broken yaml should return error", func(t *testing.T) {
+		reader := &configReader{log: log.New("test logger")}
+		_, err := reader.readConfig(brokenYaml)
+		require.Error(t, err)
+	})
+
+	t.Run("Skip invalid directory", func(t *testing.T) {
+		reader := &configReader{log: log.New("test logger")}
+		cfg, err := reader.readConfig(emptyFolder)
+		require.NoError(t, err)
+		require.Len(t, cfg, 0)
+	})
+
+	t.Run("Missing required fields should return error", func(t *testing.T) {
+		reader := &configReader{log: log.New("test logger")}
+		_, err := reader.readConfig(noRequiredFields)
+		require.Error(t, err)
+		require.Equal(t, "Added org item 1 in configuration doesn't contain required field name\n"+
+			"Added team item 1 in configuration doesn't contain required field name\n"+
+			"Member 1 of added team item 1 in configuration doesn't contain required field login\n"+
+			"User item 1 in configuration doesn't contain required field login", err.Error())
+	})
+
+	t.Run("Invalid role should return error", func(t *testing.T) {
+		reader := &configReader{log: log.New("test logger")}
+		_, err := reader.readConfig(invalidRole)
+		require.Error(t, err)
+		require.Equal(t, `User item 1 in configuration has invalid role "Owner"`, err.Error())
+	})
+
+	t.Run("Can read correct properties", func(t *testing.T) {
+		reader := &configReader{log: log.New("test logger")}
+		cfg, err := reader.readConfig(correctProperties)
+		require.NoError(t, err)
+		require.Len(t, cfg, 1)
+
+		orgs := cfg[0]
+		require.Equal(t, []*orgFromConfig{{Name: "Engineering"}}, orgs.Orgs)
+		require.Equal(t, []*deleteOrgConfig{{Name: "Old Org"}}, orgs.DeleteOrgs)
+		require.Equal(t, []*deleteTeamConfig{{OrgName: "Engineering", Name: "Legacy"}}, orgs.DeleteTeams)
+
+		require.Len(t, orgs.Teams, 1)
+		team := orgs.Teams[0]
+		require.Equal(t, int64(0), team.OrgID)
+		require.Equal(t, "Engineering", team.OrgName)
+		require.Equal(t, "Backend", team.Name)
+		require.Equal(t, "backend@example.com", team.Email)
+		require.Equal(t, []*teamMemberFromConfig{{Login: "alice", Admin: true}, {Login: "bob"}}, team.Members)
+
+		require.Equal(t, []*userFromConfig{
+			{OrgName: "Engineering", Login: "alice", Role: models.ROLE_ADMIN},
+			{OrgID: 1, Login: "bob", Role: models.ROLE_EDITOR},
+		}, orgs.Users)
+	})
+}
@@ -0,0 +1,320 @@
+package orgs
+
+import (
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// Provision scans a directory for provisioning config files
+// and provisions the orgs, teams and user roles in those files.
+func Provision(configDirectory string) error {
+	op := newOrgProvisioner(log.New("provisioning.orgs"))
+	return op.applyChanges(configDirectory)
+}
+
+// OrgProvisioner is responsible for provisioning orgs, teams and user roles
+// based on configuration read by the `configReader`
+type OrgProvisioner struct {
+	log         log.Logger
+	cfgProvider *configReader
+}
+
+func newOrgProvisioner(log log.Logger) OrgProvisioner {
+	return OrgProvisioner{
+		log:         log,
+		cfgProvider: &configReader{log: log},
+	}
+}
+
+// applyChanges applies all config files together, so that teams and users can refer to
+// orgs in other files
+func (op *OrgProvisioner) applyChanges(configPath string) error {
+	configs, err := op.cfgProvider.readConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	for _, cfg := range configs {
+		if err := op.deleteOrgs(cfg.DeleteOrgs); err != nil {
+			return err
+		}
+	}
+
+	for _, cfg := range configs {
+		if err := op.createOrgs(cfg.Orgs); err != nil {
+			return err
+		}
+	}
+
+	for _, cfg := range configs {
+		if err := op.assignRoles(cfg.Users); err != nil {
+			return err
+		}
+	}
+
+	for _, cfg := range configs {
+		if err := op.deleteTeams(cfg.DeleteTeams); err != nil {
+			return err
+		}
+	}
+
+	for _, cfg := range configs {
+		if err := op.mergeTeams(cfg.Teams); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (op *OrgProvisioner) deleteOrgs(orgs []*deleteOrgConfig) error {
+	for _, org := range orgs {
+		query := &models.GetOrgByNameQuery{Name: org.Name}
+		if err := bus.Dispatch(query); err != nil {
+			if err == models.ErrOrgNotFound {
+				continue
+			}
+			return err
+		}
+
+		op.log.Info("Deleting org from configuration", "name", org.Name)
+		if err := bus.Dispatch(&models.DeleteOrgCommand{Id: query.Result.Id}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (op *OrgProvisioner) createOrgs(orgs []*orgFromConfig) error {
+	for _, org := range orgs {
+		query := &models.GetOrgByNameQuery{Name: org.Name}
+		err := bus.Dispatch(query)
+		if err == nil {
+			continue
+		}
+		if err != models.ErrOrgNotFound {
+			return err
+		}
+
+		op.log.Info("Creating org from configuration", "name", org.Name)
+		if err := bus.Dispatch(&models.CreateOrgCommand{Name: org.Name}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// assignRoles adds users to orgs, or updates their role. Users that don't exist yet are skipped,
+// so that roles of users that sign in with an external auth provider are assigned once they exist.
+func (op *OrgProvisioner) assignRoles(users []*userFromConfig) error {
+	for _, user := range users {
+		orgID, err := resolveOrgID(user.OrgID, user.OrgName)
+		if err != nil {
+			return err
+		}
+
+		userQuery := &models.GetUserByLoginQuery{LoginOrEmail: user.Login}
+		if err := bus.Dispatch(userQuery); err != nil {
+			if err == models.ErrUserNotFound {
+				op.log.Warn("Skipping role of user that doesn't exist", "login", user.Login, "orgId", orgID)
+				continue
+			}
+			return err
+		}
+
+		role, err := getOrgRole(userQuery.Result.Id, orgID)
+		if err != nil {
+			return err
+		}
+		if role == user.Role {
+			continue
+		}
+
+		op.log.Debug("Assigning role from configuration", "login", user.Login, "orgId", orgID, "role", user.Role)
+		if role == "" {
+			err = bus.Dispatch(&models.AddOrgUserCommand{OrgId: orgID, UserId: userQuery.Result.Id, Role: user.Role})
+		} else {
+			err = bus.Dispatch(&models.UpdateOrgUserCommand{OrgId: orgID, UserId: userQuery.Result.Id, Role: user.Role})
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (op *OrgProvisioner) deleteTeams(teams []*deleteTeamConfig) error {
+	for _, team := range teams {
+		orgID, err := resolveOrgID(team.OrgID, team.OrgName)
+		if err != nil {
+			return err
+		}
+
+		existing, err := getTeamByName(orgID, team.Name)
+		if err != nil {
+			return err
+		}
+
+		if existing == nil {
+			continue
+		}
+
+		op.log.Info("Deleting team from configuration", "name", team.Name, "orgId", orgID)
+		if err := bus.Dispatch(&models.DeleteTeamCommand{OrgId: orgID, Id: existing.Id}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (op *OrgProvisioner) mergeTeams(teams []*teamFromConfig) error {
+	for _, team := range teams {
+		orgID, err := resolveOrgID(team.OrgID, team.OrgName)
+		if err != nil {
+			return err
+		}
+
+		existing, err := getTeamByName(orgID, team.Name)
+		if err != nil {
+			return err
+		}
+
+		var teamID int64
+		if existing == nil {
+			op.log.Info("Creating team from configuration", "name", team.Name, "orgId", orgID)
+			cmd := &models.CreateTeamCommand{OrgId: orgID, Name: team.Name, Email: team.Email}
+			if err := bus.Dispatch(cmd); err != nil {
+				return err
+			}
+			teamID = cmd.Result.Id
+		} else {
+			teamID = existing.Id
+			if existing.Email != team.Email {
+				cmd := &models.UpdateTeamCommand{OrgId: orgID, Id: teamID, Name: team.Name, Email: team.Email}
+				if err := bus.Dispatch(cmd); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := op.syncTeamMembers(orgID, teamID, team); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncTeamMembers makes the members of a team match its configuration. Members that are synced from an
+// external auth provider are left alone.
+func (op *OrgProvisioner) syncTeamMembers(orgID int64, teamID int64, team *teamFromConfig) error {
+	membersQuery := &models.GetTeamMembersQuery{OrgId: orgID, TeamId: teamID}
+	if err := bus.Dispatch(membersQuery); err != nil {
+		return err
+	}
+
+	current := make(map[int64]*models.TeamMemberDTO)
+	for _, member := range membersQuery.Result {
+		current[member.UserId] = member
+	}
+
+	configured := make(map[int64]bool)
+	for _, member := range team.Members {
+		userQuery := &models.GetUserByLoginQuery{LoginOrEmail: member.Login}
+		if err := bus.Dispatch(userQuery); err != nil {
+			if err == models.ErrUserNotFound {
+				op.log.Warn("Skipping team member that doesn't exist", "login", member.Login, "team", team.Name)
+				continue
+			}
+			return err
+		}
+		userID := userQuery.Result.Id
+		configured[userID] = true
+
+		permission := models.PermissionType(0)
+		if member.Admin {
+			permission = models.PERMISSION_ADMIN
+		}
+
+		existing, ok := current[userID]
+		if ok {
+			if existing.Permission != permission {
+				cmd := &models.UpdateTeamMemberCommand{OrgId: orgID, TeamId: teamID, UserId: userID, Permission: permission}
+				if err := bus.Dispatch(cmd); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		// team members have to be members of the org of the team
+		err := bus.Dispatch(&models.AddOrgUserCommand{OrgId: orgID, UserId: userID, Role: models.ROLE_VIEWER})
+		if err != nil && err != models.ErrOrgUserAlreadyAdded {
+			return err
+		}
+
+		cmd := &models.AddTeamMemberCommand{OrgId: orgID, TeamId: teamID, UserId: userID, Permission: permission}
+		if err := bus.Dispatch(cmd); err != nil {
+			return err
+		}
+	}
+
+	for userID, member := range current {
+		if configured[userID] || member.External {
+			continue
+		}
+
+		op.log.Info("Removing team member that is not in the configuration", "login", member.Login, "team", team.Name)
+		if err := bus.Dispatch(&models.RemoveTeamMemberCommand{OrgId: orgID, TeamId: teamID, UserId: userID}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getOrgRole returns the role of a user in an org, or an empty role when the user isn't a member
+func getOrgRole(userID int64, orgID int64) (models.RoleType, error) {
+	query := &models.GetUserOrgListQuery{UserId: userID}
+	if err := bus.Dispatch(query); err != nil {
+		return "", err
+	}
+
+	for _, org := range query.Result {
+		if org.OrgId == orgID {
+			return org.Role, nil
+		}
+	}
+	return "", nil
+}
+
+func resolveOrgID(orgID int64, orgName string) (int64, error) {
+	if orgID != 0 {
+		return orgID, nil
+	}
+
+	query := &models.GetOrgByNameQuery{Name: orgName}
+	if err := bus.Dispatch(query); err != nil {
+		return 0, err
+	}
+
+	return query.Result.Id, nil
+}
+
+func getTeamByName(orgID int64, name string) (*models.TeamDTO, error) {
+	query := &models.SearchTeamsQuery{OrgId: orgID, Name: name, Limit: 1, Page: 1}
+	if err := bus.Dispatch(query); err != nil {
+		return nil, err
+	}
+
+	if len(query.Result.Teams) == 0 {
+		return nil, nil
+	}
+
+	return query.Result.Teams[0], nil
+}
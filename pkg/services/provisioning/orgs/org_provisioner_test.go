@@ -0,0 +1,103 @@
+package orgs
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrgProvisioner(t *testing.T) {
+	sqlstore.InitTestDB(t)
+
+	createUser := func(login string) int64 {
+		cmd := &models.CreateUserCommand{Login: login, Email: login + "@example.com", SkipOrgSetup: true}
+		require.NoError(t, bus.Dispatch(cmd))
+		return cmd.Result.Id
+	}
+
+	getOrgID := func(name string) int64 {
+		query := &models.GetOrgByNameQuery{Name: name}
+		require.NoError(t, bus.Dispatch(query))
+		return query.Result.Id
+	}
+
+	getRoles := func(orgID int64) map[string]models.RoleType {
+		query := &models.GetOrgUsersQuery{OrgId: orgID}
+		require.NoError(t, bus.Dispatch(query))
+		roles := map[string]models.RoleType{}
+		for _, user := range query.Result {
+			roles[user.Login] = models.RoleType(user.Role)
+		}
+		return roles
+	}
+
+	getMembers := func(orgID int64, teamName string) map[string]models.PermissionType {
+		team, err := getTeamByName(orgID, teamName)
+		require.NoError(t, err)
+		require.NotNil(t, team)
+
+		query := &models.GetTeamMembersQuery{OrgId: orgID, TeamId: team.Id}
+		require.NoError(t, bus.Dispatch(query))
+		members := map[string]models.PermissionType{}
+		for _, member := range query.Result {
+			members[member.Login] = member.Permission
+		}
+		return members
+	}
+
+	alice := createUser("alice")
+	createUser("bob")
+	carol := createUser("carol")
+	require.NoError(t, bus.Dispatch(&models.CreateOrgCommand{Name: "Main Org."}))
+	require.NoError(t, bus.Dispatch(&models.CreateOrgCommand{Name: "Old Org"}))
+
+	op := newOrgProvisioner(log.New("test logger"))
+
+	t.Run("Should provision orgs, teams and user roles", func(t *testing.T) {
+		require.NoError(t, op.applyChanges(correctProperties))
+
+		query := &models.GetOrgByNameQuery{Name: "Old Org"}
+		require.Equal(t, models.ErrOrgNotFound, bus.Dispatch(query))
+
+		orgID := getOrgID("Engineering")
+		require.Equal(t, map[string]models.RoleType{"alice": models.ROLE_ADMIN, "bob": models.ROLE_VIEWER}, getRoles(orgID))
+		require.Equal(t, models.ROLE_EDITOR, getRoles(1)["bob"])
+
+		team, err := getTeamByName(orgID, "Backend")
+		require.NoError(t, err)
+		require.Equal(t, "backend@example.com", team.Email)
+		require.Equal(t, map[string]models.PermissionType{"alice": models.PERMISSION_ADMIN, "bob": 0}, getMembers(orgID, "Backend"))
+	})
+
+	t.Run("Should sync team members with the configuration", func(t *testing.T) {
+		orgID := getOrgID("Engineering")
+		team, err := getTeamByName(orgID, "Backend")
+		require.NoError(t, err)
+
+		require.NoError(t, bus.Dispatch(&models.UpdateTeamMemberCommand{OrgId: orgID, TeamId: team.Id, UserId: alice, Permission: 0}))
+		require.NoError(t, bus.Dispatch(&models.AddOrgUserCommand{OrgId: orgID, UserId: carol, Role: models.ROLE_VIEWER}))
+		require.NoError(t, bus.Dispatch(&models.AddTeamMemberCommand{OrgId: orgID, TeamId: team.Id, UserId: carol}))
+
+		require.NoError(t, op.applyChanges(correctProperties))
+		require.Equal(t, map[string]models.PermissionType{"alice": models.PERMISSION_ADMIN, "bob": 0}, getMembers(orgID, "Backend"))
+	})
+
+	t.Run("Should keep members synced from external auth providers", func(t *testing.T) {
+		orgID := getOrgID("Engineering")
+		team, err := getTeamByName(orgID, "Backend")
+		require.NoError(t, err)
+
+		require.NoError(t, bus.Dispatch(&models.AddTeamMemberCommand{OrgId: orgID, TeamId: team.Id, UserId: carol, External: true}))
+
+		require.NoError(t, op.applyChanges(correctProperties))
+		require.Contains(t, getMembers(orgID, "Backend"), "carol")
+	})
+
+	t.Run("Should return error for broken yaml", func(t *testing.T) {
+		require.Error(t, op.applyChanges(brokenYaml))
+	})
+}
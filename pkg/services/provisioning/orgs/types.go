@@ -0,0 +1,147 @@
+package orgs
+
+import (
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+)
+
+// orgsAsConfig is a normalized data object for orgs, teams and users config data. Any config version should be
+// mappable to this type.
+type orgsAsConfig struct {
+	Orgs        []*orgFromConfig
+	DeleteOrgs  []*deleteOrgConfig
+	Teams       []*teamFromConfig
+	DeleteTeams []*deleteTeamConfig
+	Users       []*userFromConfig
+}
+
+type orgFromConfig struct {
+	Name string
+}
+
+type deleteOrgConfig struct {
+	Name string
+}
+
+type teamFromConfig struct {
+	OrgID   int64
+	OrgName string
+	Name    string
+	Email   string
+	Members []*teamMemberFromConfig
+}
+
+type teamMemberFromConfig struct {
+	Login string
+	Admin bool
+}
+
+type deleteTeamConfig struct {
+	OrgID   int64
+	OrgName string
+	Name    string
+}
+
+type userFromConfig struct {
+	OrgID   int64
+	OrgName string
+	Login   string
+	Role    models.RoleType
+}
+
+// orgsAsConfigV0 is a mapping for zero version configs. This is mapped to its normalised version.
+type orgsAsConfigV0 struct {
+	Orgs        []*orgFromConfigV0    `json:"orgs" yaml:"orgs"`
+	DeleteOrgs  []*deleteOrgConfigV0  `json:"delete_orgs" yaml:"delete_orgs"`
+	Teams       []*teamFromConfigV0   `json:"teams" yaml:"teams"`
+	DeleteTeams []*deleteTeamConfigV0 `json:"delete_teams" yaml:"delete_teams"`
+	Users       []*userFromConfigV0   `json:"users" yaml:"users"`
+}
+
+type orgFromConfigV0 struct {
+	Name values.StringValue `json:"name" yaml:"name"`
+}
+
+type deleteOrgConfigV0 struct {
+	Name values.StringValue `json:"name" yaml:"name"`
+}
+
+type teamFromConfigV0 struct {
+	OrgID   values.Int64Value         `json:"org_id" yaml:"org_id"`
+	OrgName values.StringValue        `json:"org_name" yaml:"org_name"`
+	Name    values.StringValue        `json:"name" yaml:"name"`
+	Email   values.StringValue        `json:"email" yaml:"email"`
+	Members []*teamMemberFromConfigV0 `json:"members" yaml:"members"`
+}
+
+type teamMemberFromConfigV0 struct {
+	Login values.StringValue `json:"login" yaml:"login"`
+	Admin values.BoolValue   `json:"admin" yaml:"admin"`
+}
+
+type deleteTeamConfigV0 struct {
+	OrgID   values.Int64Value  `json:"org_id" yaml:"org_id"`
+	OrgName values.StringValue `json:"org_name" yaml:"org_name"`
+	Name    values.StringValue `json:"name" yaml:"name"`
+}
+
+type userFromConfigV0 struct {
+	OrgID   values.Int64Value  `json:"org_id" yaml:"org_id"`
+	OrgName values.StringValue `json:"org_name" yaml:"org_name"`
+	Login   values.StringValue `json:"login" yaml:"login"`
+	Role    values.StringValue `json:"role" yaml:"role"`
+}
+
+// mapToOrgsFromConfig maps config syntax to a normalized orgsAsConfig object. Every version
+// of the config syntax should have this function.
+func (cfg *orgsAsConfigV0) mapToOrgsFromConfig() *orgsAsConfig {
+	r := &orgsAsConfig{}
+	if cfg == nil {
+		return r
+	}
+
+	for _, org := range cfg.Orgs {
+		r.Orgs = append(r.Orgs, &orgFromConfig{Name: org.Name.Value()})
+	}
+
+	for _, org := range cfg.DeleteOrgs {
+		r.DeleteOrgs = append(r.DeleteOrgs, &deleteOrgConfig{Name: org.Name.Value()})
+	}
+
+	for _, team := range cfg.Teams {
+		t := &teamFromConfig{
+			OrgID:   team.OrgID.Value(),
+			OrgName: team.OrgName.Value(),
+			Name:    team.Name.Value(),
+			Email:   team.Email.Value(),
+		}
+
+		for _, member := range team.Members {
+			t.Members = append(t.Members, &teamMemberFromConfig{
+				Login: member.Login.Value(),
+				Admin: member.Admin.Value(),
+			})
+		}
+
+		r.Teams = append(r.Teams, t)
+	}
+
+	for _, team := range cfg.DeleteTeams {
+		r.DeleteTeams = append(r.DeleteTeams, &deleteTeamConfig{
+			OrgID:   team.OrgID.Value(),
+			OrgName: team.OrgName.Value(),
+			Name:    team.Name.Value(),
+		})
+	}
+
+	for _, user := range cfg.Users {
+		r.Users = append(r.Users, &userFromConfig{
+			OrgID:   user.OrgID.Value(),
+			OrgName: user.OrgName.Value(),
+			Login:   user.Login.Value(),
+			Role:    models.RoleType(user.Role.Value()),
+		})
+	}
+
+	return r
+}
@@ -62,14 +62,17 @@ func TestConfigReader(t *testing.T) {
 		require.NoError(t, err)
 		require.Len(t, cfg, 1)
 
+		pinned := true
+		unpinned := false
 		testCases := []struct {
 			ExpectedPluginID string
 			ExpectedOrgID    int64
 			ExpectedOrgName  string
 			ExpectedEnabled  bool
+			ExpectedPinned   *bool
 		}{
-			{ExpectedPluginID: "test-plugin", ExpectedOrgID: 2, ExpectedOrgName: "", ExpectedEnabled: true},
-			{ExpectedPluginID: "test-plugin-2", ExpectedOrgID: 3, ExpectedOrgName: "", ExpectedEnabled: false},
+			{ExpectedPluginID: "test-plugin", ExpectedOrgID: 2, ExpectedOrgName: "", ExpectedEnabled: true, ExpectedPinned: &pinned},
+			{ExpectedPluginID: "test-plugin-2", ExpectedOrgID: 3, ExpectedOrgName: "", ExpectedEnabled: false, ExpectedPinned: &unpinned},
 			{ExpectedPluginID: "test-plugin", ExpectedOrgID: 0, ExpectedOrgName: "Org 3", ExpectedEnabled: true},
 			{ExpectedPluginID: "test-plugin-2", ExpectedOrgID: 1, ExpectedOrgName: "", ExpectedEnabled: true},
 		}
@@ -81,6 +84,7 @@ func TestConfigReader(t *testing.T) {
 			require.Equal(t, tc.ExpectedOrgID, app.OrgID)
 			require.Equal(t, tc.ExpectedOrgName, app.OrgName)
 			require.Equal(t, tc.ExpectedEnabled, app.Enabled)
+			require.Equal(t, tc.ExpectedPinned, app.Pinned)
 		}
 	})
 }
@@ -39,6 +39,8 @@ func (ap *PluginProvisioner) apply(cfg *pluginsAsConfig) error {
 			app.OrgID = 1
 		}
 
+		// new apps are pinned to the side menu, like apps enabled in the UI
+		pinned := true
 		query := &models.GetPluginSettingByIdQuery{OrgId: app.OrgID, PluginId: app.PluginID}
 		err := bus.Dispatch(query)
 		if err != nil {
@@ -47,15 +49,19 @@ func (ap *PluginProvisioner) apply(cfg *pluginsAsConfig) error {
 			}
 		} else {
 			app.PluginVersion = query.Result.PluginVersion
-			app.Pinned = query.Result.Pinned
+			pinned = query.Result.Pinned
 		}
 
-		ap.log.Info("Updating app from configuration ", "type", app.PluginID, "enabled", app.Enabled)
+		if app.Pinned != nil {
+			pinned = *app.Pinned
+		}
+
+		ap.log.Info("Updating app from configuration ", "type", app.PluginID, "enabled", app.Enabled, "pinned", pinned)
 		cmd := &models.UpdatePluginSettingCmd{
 			OrgId:          app.OrgID,
 			PluginId:       app.PluginID,
 			Enabled:        app.Enabled,
-			Pinned:         app.Pinned,
+			Pinned:         pinned,
 			JsonData:       app.JSONData,
 			SecureJsonData: app.SecureJSONData,
 			PluginVersion:  app.PluginVersion,
@@ -20,6 +20,9 @@ func TestPluginProvisioner(t *testing.T) {
 	})
 
 	t.Run("Should apply configurations", func(t *testing.T) {
+		pinned := true
+		unpinned := false
+
 		bus.AddHandler("test", func(query *models.GetOrgByNameQuery) error {
 			if query.Name == "Org 4" {
 				query.Result = &models.Org{Id: 4}
@@ -50,9 +53,9 @@ func TestPluginProvisioner(t *testing.T) {
 			{
 				Apps: []*appFromConfig{
 					{PluginID: "test-plugin", OrgID: 2, Enabled: true},
-					{PluginID: "test-plugin-2", OrgID: 3, Enabled: false},
+					{PluginID: "test-plugin-2", OrgID: 3, Enabled: false, Pinned: &unpinned},
 					{PluginID: "test-plugin", OrgName: "Org 4", Enabled: true},
-					{PluginID: "test-plugin-2", OrgID: 1, Enabled: true},
+					{PluginID: "test-plugin-2", OrgID: 1, Enabled: true, Pinned: &pinned},
 				},
 			},
 		}
@@ -67,11 +70,12 @@ func TestPluginProvisioner(t *testing.T) {
 			ExpectedOrgID         int64
 			ExpectedEnabled       bool
 			ExpectedPluginVersion string
+			ExpectedPinned        bool
 		}{
-			{ExpectedPluginID: "test-plugin", ExpectedOrgID: 2, ExpectedEnabled: true, ExpectedPluginVersion: "2.0.1"},
-			{ExpectedPluginID: "test-plugin-2", ExpectedOrgID: 3, ExpectedEnabled: false},
-			{ExpectedPluginID: "test-plugin", ExpectedOrgID: 4, ExpectedEnabled: true},
-			{ExpectedPluginID: "test-plugin-2", ExpectedOrgID: 1, ExpectedEnabled: true},
+			{ExpectedPluginID: "test-plugin", ExpectedOrgID: 2, ExpectedEnabled: true, ExpectedPluginVersion: "2.0.1", ExpectedPinned: false},
+			{ExpectedPluginID: "test-plugin-2", ExpectedOrgID: 3, ExpectedEnabled: false, ExpectedPinned: false},
+			{ExpectedPluginID: "test-plugin", ExpectedOrgID: 4, ExpectedEnabled: true, ExpectedPinned: true},
+			{ExpectedPluginID: "test-plugin-2", ExpectedOrgID: 1, ExpectedEnabled: true, ExpectedPinned: true},
 		}
 
 		for index, tc := range testCases {
@@ -81,6 +85,7 @@ func TestPluginProvisioner(t *testing.T) {
 			require.Equal(t, tc.ExpectedOrgID, cmd.OrgId)
 			require.Equal(t, tc.ExpectedEnabled, cmd.Enabled)
 			require.Equal(t, tc.ExpectedPluginVersion, cmd.PluginVersion)
+			require.Equal(t, tc.ExpectedPinned, cmd.Pinned)
 		}
 	})
 }
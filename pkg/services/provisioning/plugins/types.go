@@ -13,7 +13,7 @@ type appFromConfig struct {
 	OrgName        string
 	PluginID       string
 	Enabled        bool
-	Pinned         bool
+	Pinned         *bool
 	PluginVersion  string
 	JSONData       map[string]interface{}
 	SecureJSONData map[string]string
@@ -24,6 +24,7 @@ type appFromConfigV0 struct {
 	OrgName        values.StringValue    `json:"org_name" yaml:"org_name"`
 	Type           values.StringValue    `json:"type" yaml:"type"`
 	Disabled       values.BoolValue      `json:"disabled" yaml:"disabled"`
+	Pinned         *values.BoolValue     `json:"pinned" yaml:"pinned"`
 	JSONData       values.JSONValue      `json:"jsonData" yaml:"jsonData"`
 	SecureJSONData values.StringMapValue `json:"secureJsonData" yaml:"secureJsonData"`
 }
@@ -42,15 +43,22 @@ func (cfg *pluginsAsConfigV0) mapToPluginsFromConfig() *pluginsAsConfig {
 	}
 
 	for _, app := range cfg.Apps {
-		r.Apps = append(r.Apps, &appFromConfig{
+		a := &appFromConfig{
 			OrgID:          app.OrgID.Value(),
 			OrgName:        app.OrgName.Value(),
 			PluginID:       app.Type.Value(),
 			Enabled:        !app.Disabled.Value(),
-			Pinned:         true,
 			JSONData:       app.JSONData.Value(),
 			SecureJSONData: app.SecureJSONData.Value(),
-		})
+		}
+
+		// apps without a pinned state in the config keep the state set in the UI
+		if app.Pinned != nil {
+			pinned := app.Pinned.Value()
+			a.Pinned = &pinned
+		}
+
+		r.Apps = append(r.Apps, a)
 	}
 
 	return r
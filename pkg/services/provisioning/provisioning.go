@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
 	"github.com/grafana/grafana/pkg/services/provisioning/datasources"
 	"github.com/grafana/grafana/pkg/services/provisioning/notifiers"
+	"github.com/grafana/grafana/pkg/services/provisioning/orgs"
 	"github.com/grafana/grafana/pkg/services/provisioning/plugins"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
@@ -17,11 +18,15 @@ import (
 
 type ProvisioningService interface {
 	ProvisionDatasources() error
+	DryRunDatasources() ([]datasources.Change, error)
 	ProvisionPlugins() error
 	ProvisionNotifications() error
+	ProvisionOrgs() error
 	ProvisionDashboards() error
 	GetDashboardProvisionerResolvedPath(name string) string
 	GetAllowUIUpdatesFromConfig(name string) bool
+	GetStatus() ([]*SourceStatus, error)
+	RevertDrift() ([]*SourceStatus, error)
 }
 
 func init() {
@@ -31,7 +36,10 @@ func init() {
 		},
 		notifiers.Provision,
 		datasources.Provision,
+		datasources.DryRun,
 		plugins.Provision,
+		orgs.Provision,
+		datasources.Plan,
 	))
 }
 
@@ -39,14 +47,21 @@ func NewProvisioningServiceImpl(
 	newDashboardProvisioner dashboards.DashboardProvisionerFactory,
 	provisionNotifiers func(string) error,
 	provisionDatasources func(string) error,
+	dryRunDatasources func(string) ([]datasources.Change, error),
 	provisionPlugins func(string) error,
+	provisionOrgs func(string) error,
+	planDatasources func(string) ([]datasources.Change, error),
 ) *provisioningServiceImpl {
 	return &provisioningServiceImpl{
 		log:                     log.New("provisioning"),
 		newDashboardProvisioner: newDashboardProvisioner,
 		provisionNotifiers:      provisionNotifiers,
 		provisionDatasources:    provisionDatasources,
+		dryRunDatasources:       dryRunDatasources,
 		provisionPlugins:        provisionPlugins,
+		provisionOrgs:           provisionOrgs,
+		planDatasources:         planDatasources,
+		syncs:                   make(map[string]syncStatus),
 	}
 }
 
@@ -58,12 +73,24 @@ type provisioningServiceImpl struct {
 	dashboardProvisioner    dashboards.DashboardProvisioner
 	provisionNotifiers      func(string) error
 	provisionDatasources    func(string) error
+	dryRunDatasources       func(string) ([]datasources.Change, error)
 	provisionPlugins        func(string) error
+	provisionOrgs           func(string) error
+	planDatasources         func(string) ([]datasources.Change, error)
 	mutex                   sync.Mutex
+	datasourcesMutex        sync.Mutex
+	statusMutex             sync.Mutex
+	syncs                   map[string]syncStatus
 }
 
 func (ps *provisioningServiceImpl) Init() error {
-	err := ps.ProvisionDatasources()
+	// orgs go first, as the other provisioned resources can refer to them
+	err := ps.ProvisionOrgs()
+	if err != nil {
+		return err
+	}
+
+	err = ps.ProvisionDatasources()
 	if err != nil {
 		return err
 	}
@@ -88,6 +115,10 @@ func (ps *provisioningServiceImpl) Run(ctx context.Context) error {
 		return err
 	}
 
+	if ps.Cfg.ProvisioningWatchDatasources {
+		go ps.watchDatasources(ctx)
+	}
+
 	for {
 
 		// Wait for unlock. This is tied to new dashboardProvisioner to be instantiated before we start polling.
@@ -112,23 +143,45 @@ func (ps *provisioningServiceImpl) Run(ctx context.Context) error {
 }
 
 func (ps *provisioningServiceImpl) ProvisionDatasources() error {
-	datasourcePath := path.Join(ps.Cfg.ProvisioningPath, "datasources")
-	err := ps.provisionDatasources(datasourcePath)
+	// the datasources are provisioned on reload requests and file changes at the same time
+	ps.datasourcesMutex.Lock()
+	defer ps.datasourcesMutex.Unlock()
+
+	err := ps.recordSync(SourceDatasources, ps.provisionDatasources(ps.datasourcesPath()))
 	return errutil.Wrap("Datasource provisioning error", err)
 }
 
+// DryRunDatasources returns the changes that provisioning the datasources would make
+func (ps *provisioningServiceImpl) DryRunDatasources() ([]datasources.Change, error) {
+	ps.datasourcesMutex.Lock()
+	defer ps.datasourcesMutex.Unlock()
+
+	changes, err := ps.dryRunDatasources(ps.datasourcesPath())
+	return changes, errutil.Wrap("Datasource provisioning error", err)
+}
+
+func (ps *provisioningServiceImpl) datasourcesPath() string {
+	return path.Join(ps.Cfg.ProvisioningPath, "datasources")
+}
+
 func (ps *provisioningServiceImpl) ProvisionPlugins() error {
 	appPath := path.Join(ps.Cfg.ProvisioningPath, "plugins")
-	err := ps.provisionPlugins(appPath)
+	err := ps.recordSync(SourcePlugins, ps.provisionPlugins(appPath))
 	return errutil.Wrap("app provisioning error", err)
 }
 
 func (ps *provisioningServiceImpl) ProvisionNotifications() error {
 	alertNotificationsPath := path.Join(ps.Cfg.ProvisioningPath, "notifiers")
-	err := ps.provisionNotifiers(alertNotificationsPath)
+	err := ps.recordSync(SourceNotifications, ps.provisionNotifiers(alertNotificationsPath))
 	return errutil.Wrap("Alert notification provisioning error", err)
 }
 
+func (ps *provisioningServiceImpl) ProvisionOrgs() error {
+	orgsPath := path.Join(ps.Cfg.ProvisioningPath, "orgs")
+	err := ps.recordSync(SourceOrgs, ps.provisionOrgs(orgsPath))
+	return errutil.Wrap("Org provisioning error", err)
+}
+
 func (ps *provisioningServiceImpl) ProvisionDashboards() error {
 	dashboardPath := path.Join(ps.Cfg.ProvisioningPath, "dashboards")
 	dashProvisioner, err := ps.newDashboardProvisioner(dashboardPath)
@@ -141,7 +194,7 @@ func (ps *provisioningServiceImpl) ProvisionDashboards() error {
 
 	ps.cancelPolling()
 
-	if err := dashProvisioner.Provision(); err != nil {
+	if err := ps.recordSync(SourceDashboards, dashProvisioner.Provision()); err != nil {
 		// If we fail to provision with the new provisioner, mutex will unlock and the polling we restart with the
 		// old provisioner as we did not switch them yet.
 		return errutil.Wrap("Failed to provision dashboards", err)
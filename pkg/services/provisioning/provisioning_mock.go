@@ -1,22 +1,32 @@
 package provisioning
 
+import "github.com/grafana/grafana/pkg/services/provisioning/datasources"
+
 type Calls struct {
 	ProvisionDatasources                []interface{}
+	DryRunDatasources                   []interface{}
 	ProvisionPlugins                    []interface{}
 	ProvisionNotifications              []interface{}
+	ProvisionOrgs                       []interface{}
 	ProvisionDashboards                 []interface{}
 	GetDashboardProvisionerResolvedPath []interface{}
 	GetAllowUIUpdatesFromConfig         []interface{}
+	GetStatus                           []interface{}
+	RevertDrift                         []interface{}
 }
 
 type ProvisioningServiceMock struct {
 	Calls                                   *Calls
 	ProvisionDatasourcesFunc                func() error
+	DryRunDatasourcesFunc                   func() ([]datasources.Change, error)
 	ProvisionPluginsFunc                    func() error
 	ProvisionNotificationsFunc              func() error
+	ProvisionOrgsFunc                       func() error
 	ProvisionDashboardsFunc                 func() error
 	GetDashboardProvisionerResolvedPathFunc func(name string) string
 	GetAllowUIUpdatesFromConfigFunc         func(name string) bool
+	GetStatusFunc                           func() ([]*SourceStatus, error)
+	RevertDriftFunc                         func() ([]*SourceStatus, error)
 }
 
 func NewProvisioningServiceMock() *ProvisioningServiceMock {
@@ -33,6 +43,14 @@ func (mock *ProvisioningServiceMock) ProvisionDatasources() error {
 	return nil
 }
 
+func (mock *ProvisioningServiceMock) DryRunDatasources() ([]datasources.Change, error) {
+	mock.Calls.DryRunDatasources = append(mock.Calls.DryRunDatasources, nil)
+	if mock.DryRunDatasourcesFunc != nil {
+		return mock.DryRunDatasourcesFunc()
+	}
+	return nil, nil
+}
+
 func (mock *ProvisioningServiceMock) ProvisionPlugins() error {
 	mock.Calls.ProvisionPlugins = append(mock.Calls.ProvisionPlugins, nil)
 	if mock.ProvisionPluginsFunc != nil {
@@ -49,6 +67,14 @@ func (mock *ProvisioningServiceMock) ProvisionNotifications() error {
 	return nil
 }
 
+func (mock *ProvisioningServiceMock) ProvisionOrgs() error {
+	mock.Calls.ProvisionOrgs = append(mock.Calls.ProvisionOrgs, nil)
+	if mock.ProvisionOrgsFunc != nil {
+		return mock.ProvisionOrgsFunc()
+	}
+	return nil
+}
+
 func (mock *ProvisioningServiceMock) ProvisionDashboards() error {
 	mock.Calls.ProvisionDashboards = append(mock.Calls.ProvisionDashboards, nil)
 	if mock.ProvisionDashboardsFunc != nil {
@@ -72,3 +98,19 @@ func (mock *ProvisioningServiceMock) GetAllowUIUpdatesFromConfig(name string) bo
 	}
 	return false
 }
+
+func (mock *ProvisioningServiceMock) GetStatus() ([]*SourceStatus, error) {
+	mock.Calls.GetStatus = append(mock.Calls.GetStatus, nil)
+	if mock.GetStatusFunc != nil {
+		return mock.GetStatusFunc()
+	}
+	return nil, nil
+}
+
+func (mock *ProvisioningServiceMock) RevertDrift() ([]*SourceStatus, error) {
+	mock.Calls.RevertDrift = append(mock.Calls.RevertDrift, nil)
+	if mock.RevertDriftFunc != nil {
+		return mock.RevertDriftFunc()
+	}
+	return nil, nil
+}
@@ -3,12 +3,16 @@ package provisioning
 import (
 	"context"
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestProvisioningServiceImpl(t *testing.T) {
@@ -98,6 +102,9 @@ func setup() *serviceTestStruct {
 		nil,
 		nil,
 		nil,
+		nil,
+		nil,
+		nil,
 	)
 	serviceTest.service.Cfg = setting.NewCfg()
 
@@ -131,3 +138,36 @@ func setup() *serviceTestStruct {
 
 	return serviceTest
 }
+
+func TestWatchDatasources(t *testing.T) {
+	provisioningPath, err := ioutil.TempDir("", "provisioning")
+	require.NoError(t, err)
+	defer os.RemoveAll(provisioningPath)
+
+	datasourcesPath := filepath.Join(provisioningPath, "datasources")
+	require.NoError(t, os.Mkdir(datasourcesPath, 0750))
+
+	provisioned := make(chan string, 10)
+	service := NewProvisioningServiceImpl(nil, nil, func(path string) error {
+		provisioned <- path
+		return nil
+	}, nil, nil, nil, nil)
+	service.Cfg = setting.NewCfg()
+	service.Cfg.ProvisioningPath = provisioningPath
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go service.watchDatasources(ctx)
+
+	// give the watcher time to start watching the directory
+	time.Sleep(100 * time.Millisecond)
+	err = ioutil.WriteFile(filepath.Join(datasourcesPath, "datasources.yaml"), []byte("datasources: []"), 0600)
+	require.NoError(t, err)
+
+	select {
+	case path := <-provisioned:
+		assert.Equal(t, datasourcesPath, path)
+	case <-time.After(5 * time.Second):
+		t.Fatal("datasources were not provisioned after the file changed")
+	}
+}
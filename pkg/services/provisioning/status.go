@@ -0,0 +1,225 @@
+package provisioning
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/datasources"
+)
+
+// Provisioning sources, in the order they are provisioned
+const (
+	SourceOrgs          = "orgs"
+	SourceDatasources   = "datasources"
+	SourcePlugins       = "plugins"
+	SourceNotifications = "notifiers"
+	SourceDashboards    = "dashboards"
+)
+
+var sources = []string{SourceOrgs, SourceDatasources, SourcePlugins, SourceNotifications, SourceDashboards}
+
+// SourceStatus is the status of a provisioning source. Resources are only reported for the
+// sources that keep track of what they provision.
+type SourceStatus struct {
+	Source    string             `json:"source"`
+	LastSync  *time.Time         `json:"lastSync"`
+	Error     string             `json:"error,omitempty"`
+	Resources []*ManagedResource `json:"resources"`
+}
+
+// ManagedResource is a resource managed by a provisioning source. A drifted resource has been
+// changed since it was provisioned. For data sources, Fields are the fields that differ from the
+// provisioning files.
+type ManagedResource struct {
+	OrgId    int64    `json:"orgId"`
+	Uid      string   `json:"uid,omitempty"`
+	Name     string   `json:"name"`
+	Provider string   `json:"provider,omitempty"`
+	Drifted  bool     `json:"drifted"`
+	Fields   []string `json:"fields,omitempty"`
+
+	dashboardId int64
+}
+
+type syncStatus struct {
+	lastSync time.Time
+	err      error
+}
+
+// recordSync keeps the time and the result of the last sync of a source, and returns the error
+func (ps *provisioningServiceImpl) recordSync(source string, err error) error {
+	ps.statusMutex.Lock()
+	defer ps.statusMutex.Unlock()
+
+	ps.syncs[source] = syncStatus{lastSync: time.Now(), err: err}
+	return err
+}
+
+// GetStatus returns the status of every provisioning source
+func (ps *provisioningServiceImpl) GetStatus() ([]*SourceStatus, error) {
+	result := make([]*SourceStatus, 0, len(sources))
+
+	for _, source := range sources {
+		ps.statusMutex.Lock()
+		last, synced := ps.syncs[source]
+		ps.statusMutex.Unlock()
+
+		status := &SourceStatus{Source: source, Resources: make([]*ManagedResource, 0)}
+		if synced {
+			lastSync := last.lastSync
+			status.LastSync = &lastSync
+			if last.err != nil {
+				status.Error = last.err.Error()
+			}
+		}
+
+		var err error
+		switch source {
+		case SourceDatasources:
+			status.Resources, err = ps.datasourcesStatus()
+		case SourceNotifications:
+			status.Resources, err = notificationsStatus(last.lastSync)
+		case SourceDashboards:
+			status.Resources, err = dashboardsStatus()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, status)
+	}
+
+	return result, nil
+}
+
+// RevertDrift provisions the sources with drifted resources again, overwriting the changes made
+// since they were provisioned, and returns the new status
+func (ps *provisioningServiceImpl) RevertDrift() ([]*SourceStatus, error) {
+	statuses, err := ps.GetStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, status := range statuses {
+		drifted := make([]*ManagedResource, 0)
+		for _, resource := range status.Resources {
+			if resource.Drifted {
+				drifted = append(drifted, resource)
+			}
+		}
+
+		if len(drifted) == 0 {
+			continue
+		}
+
+		ps.log.Info("Reverting drifted resources", "source", status.Source, "count", len(drifted))
+		switch status.Source {
+		case SourceDatasources:
+			err = ps.ProvisionDatasources()
+		case SourceNotifications:
+			err = ps.ProvisionNotifications()
+		case SourceDashboards:
+			// dashboards are only saved again when their file changes
+			for _, resource := range drifted {
+				if err := bus.Dispatch(&models.ResetDashboardProvisioningCommand{DashboardId: resource.dashboardId}); err != nil {
+					return nil, err
+				}
+			}
+			err = ps.ProvisionDashboards()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ps.GetStatus()
+}
+
+// datasourcesStatus compares the data sources with the provisioning files, as provisioned data sources
+// can be edited in the UI when they are editable
+func (ps *provisioningServiceImpl) datasourcesStatus() ([]*ManagedResource, error) {
+	ps.datasourcesMutex.Lock()
+	defer ps.datasourcesMutex.Unlock()
+
+	changes, err := ps.planDatasources(ps.datasourcesPath())
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*ManagedResource, 0)
+	for _, change := range changes {
+		if change.Action == datasources.ChangeDelete {
+			continue
+		}
+
+		resources = append(resources, &ManagedResource{
+			OrgId:   change.OrgId,
+			Name:    change.Name,
+			Drifted: change.Action != datasources.ChangeNone,
+			Fields:  change.Fields,
+		})
+	}
+
+	return resources, nil
+}
+
+// notificationsStatus reports provisioned notifications that were updated after the last sync
+// as drifted, as provisioning updates all notifications that differ from the files
+func notificationsStatus(lastSync time.Time) ([]*ManagedResource, error) {
+	query := &models.GetProvisionedAlertNotificationsQuery{}
+	if err := bus.Dispatch(query); err != nil {
+		return nil, err
+	}
+
+	resources := make([]*ManagedResource, 0)
+	for _, notification := range query.Result {
+		resources = append(resources, &ManagedResource{
+			OrgId:   notification.OrgId,
+			Uid:     notification.Uid,
+			Name:    notification.Name,
+			Drifted: !lastSync.IsZero() && notification.Updated.After(lastSync),
+		})
+	}
+
+	return resources, nil
+}
+
+// dashboardsStatus reports provisioned dashboards that were last saved by a user as drifted,
+// as provisioning saves dashboards without a user
+func dashboardsStatus() ([]*ManagedResource, error) {
+	provisioned := &models.GetProvisionedDashboardDataQuery{}
+	if err := bus.Dispatch(provisioned); err != nil {
+		return nil, err
+	}
+
+	resources := make([]*ManagedResource, 0)
+	if len(provisioned.Result) == 0 {
+		return resources, nil
+	}
+
+	providers := make(map[int64]string)
+	ids := make([]int64, 0, len(provisioned.Result))
+	for _, data := range provisioned.Result {
+		providers[data.DashboardId] = data.Name
+		ids = append(ids, data.DashboardId)
+	}
+
+	query := &models.GetDashboardsQuery{DashboardIds: ids}
+	if err := bus.Dispatch(query); err != nil {
+		return nil, err
+	}
+
+	for _, dash := range query.Result {
+		resources = append(resources, &ManagedResource{
+			OrgId:       dash.OrgId,
+			Uid:         dash.Uid,
+			Name:        dash.Title,
+			Provider:    providers[dash.Id],
+			Drifted:     dash.UpdatedBy > 0,
+			dashboardId: dash.Id,
+		})
+	}
+
+	return resources, nil
+}
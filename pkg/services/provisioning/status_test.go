@@ -0,0 +1,128 @@
+package provisioning
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
+	"github.com/grafana/grafana/pkg/services/provisioning/datasources"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvisioningStatus(t *testing.T) {
+	bus.ClearBusHandlers()
+	t.Cleanup(bus.ClearBusHandlers)
+
+	bus.AddHandler("test", func(query *models.GetProvisionedAlertNotificationsQuery) error {
+		query.Result = []*models.AlertNotification{
+			{OrgId: 1, Uid: "unchanged", Name: "Unchanged", Updated: time.Now().Add(-time.Hour)},
+			{OrgId: 2, Uid: "changed", Name: "Changed", Updated: time.Now().Add(time.Hour)},
+		}
+		return nil
+	})
+
+	bus.AddHandler("test", func(query *models.GetProvisionedDashboardDataQuery) error {
+		require.Equal(t, "", query.Name)
+		query.Result = []*models.DashboardProvisioning{{DashboardId: 3, Name: "default"}, {DashboardId: 4, Name: "default"}}
+		return nil
+	})
+
+	bus.AddHandler("test", func(query *models.GetDashboardsQuery) error {
+		require.Equal(t, []int64{3, 4}, query.DashboardIds)
+		query.Result = []*models.Dashboard{
+			{Id: 3, OrgId: 1, Uid: "provisioned", Title: "Provisioned", UpdatedBy: -1},
+			{Id: 4, OrgId: 1, Uid: "edited", Title: "Edited", UpdatedBy: 2},
+		}
+		return nil
+	})
+
+	var reset []int64
+	bus.AddHandler("test", func(cmd *models.ResetDashboardProvisioningCommand) error {
+		reset = append(reset, cmd.DashboardId)
+		return nil
+	})
+
+	datasourcesProvisioned := 0
+	dashboardProvisioner := dashboards.NewDashboardProvisionerMock()
+	service := NewProvisioningServiceImpl(
+		func(path string) (dashboards.DashboardProvisioner, error) {
+			return dashboardProvisioner, nil
+		},
+		func(path string) error {
+			return nil
+		},
+		func(path string) error {
+			datasourcesProvisioned++
+			return errors.New("invalid datasource")
+		},
+		nil,
+		nil,
+		nil,
+		func(path string) ([]datasources.Change, error) {
+			return []datasources.Change{
+				{Action: datasources.ChangeDelete, OrgId: 1, Name: "old-graphite"},
+				{Action: datasources.ChangeNone, OrgId: 1, Name: "Graphite"},
+				{Action: datasources.ChangeUpdate, OrgId: 1, Name: "Prometheus", Fields: []string{"url"}},
+			}, nil
+		},
+	)
+	service.Cfg = setting.NewCfg()
+
+	require.Error(t, service.ProvisionDatasources())
+	require.NoError(t, service.ProvisionNotifications())
+	require.NoError(t, service.ProvisionDashboards())
+
+	t.Run("Reports the last sync and the drifted resources", func(t *testing.T) {
+		status, err := service.GetStatus()
+		require.NoError(t, err)
+		require.Len(t, status, 5)
+
+		require.Equal(t, SourceOrgs, status[0].Source)
+		require.Nil(t, status[0].LastSync)
+		require.Empty(t, status[0].Resources)
+
+		require.Equal(t, SourceDatasources, status[1].Source)
+		require.NotNil(t, status[1].LastSync)
+		require.Equal(t, "invalid datasource", status[1].Error)
+		require.Equal(t, []*ManagedResource{
+			{OrgId: 1, Name: "Graphite"},
+			{OrgId: 1, Name: "Prometheus", Drifted: true, Fields: []string{"url"}},
+		}, status[1].Resources)
+
+		require.Equal(t, SourceNotifications, status[3].Source)
+		require.Equal(t, []*ManagedResource{
+			{OrgId: 1, Uid: "unchanged", Name: "Unchanged"},
+			{OrgId: 2, Uid: "changed", Name: "Changed", Drifted: true},
+		}, status[3].Resources)
+
+		require.Equal(t, SourceDashboards, status[4].Source)
+		require.Equal(t, []*ManagedResource{
+			{OrgId: 1, Uid: "provisioned", Name: "Provisioned", Provider: "default", dashboardId: 3},
+			{OrgId: 1, Uid: "edited", Name: "Edited", Provider: "default", Drifted: true, dashboardId: 4},
+		}, status[4].Resources)
+	})
+
+	t.Run("Reverts the drifted resources", func(t *testing.T) {
+		datasourcesProvisioned = 0
+		dashboardProvisioner.Calls.Provision = nil
+
+		_, err := service.RevertDrift()
+		require.Error(t, err)
+		require.Equal(t, 1, datasourcesProvisioned)
+
+		service.provisionDatasources = func(path string) error {
+			datasourcesProvisioned++
+			return nil
+		}
+
+		_, err = service.RevertDrift()
+		require.NoError(t, err)
+		require.Equal(t, 2, datasourcesProvisioned)
+		require.Equal(t, []int64{4}, reset)
+		require.Len(t, dashboardProvisioner.Calls.Provision, 1)
+	})
+}
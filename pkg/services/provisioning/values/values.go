@@ -1,5 +1,5 @@
 // Package values is a set of value types to use in provisioning. They add custom unmarshaling logic that puts the string values
-// through os.ExpandEnv.
+// through environment variable and file interpolation.
 // Usage:
 // type Data struct {
 //   Field StringValue `yaml:"field"` // Instead of string
@@ -12,8 +12,8 @@ package values
 
 import (
 	"fmt"
-	"os"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -241,19 +241,36 @@ func transformMap(i map[interface{}]interface{}) (interface{}, interface{}, erro
 	return transformed, raw, nil
 }
 
+// varRegex matches the expanders available for the settings file, like $__file{/path}, and environment variables
+// in the $VAR and ${VAR} forms
+var varRegex = regexp.MustCompile(`\$(__\w+\{[^}]+\}|\{[^}]+\}|[A-Za-z_][A-Za-z0-9_]*)`)
+
 // interpolateValue returns the final value after interpolation. In addition to environment variable interpolation,
-// expanders available for the settings file are expanded here.
+// expanders available for the settings file are expanded here. Expanded values are not interpolated again, so
+// secrets read from environment variables and files can contain a '$'.
 // For a literal '$', '$$' can be used to avoid interpolation.
 func interpolateValue(val string) (string, string, error) {
 	parts := strings.Split(val, "$$")
 	interpolated := make([]string, len(parts))
 	for i, v := range parts {
-		expanded, err := setting.ExpandVar(v)
+		var err error
+		interpolated[i] = varRegex.ReplaceAllStringFunc(v, func(match string) string {
+			if err != nil {
+				return ""
+			}
+
+			// the expander of environment variables only matches the ${VAR} form
+			if !strings.HasPrefix(match, "$__") && !strings.HasPrefix(match, "${") {
+				match = "${" + match[1:] + "}"
+			}
+
+			var expanded string
+			expanded, err = setting.ExpandVar(match)
+			return expanded
+		})
 		if err != nil {
 			return val, val, fmt.Errorf("failed to interpolate value '%s': %w", val, err)
 		}
-		v = expanded
-		interpolated[i] = os.ExpandEnv(v)
 	}
 	return strings.Join(interpolated, "$"), val, nil
 }
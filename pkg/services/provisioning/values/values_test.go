@@ -279,6 +279,40 @@ func TestValues_readFile(t *testing.T) {
 	assert.Equal(t, expected, data.Val.Value())
 }
 
+func TestValues_expandedValuesAreNotInterpolated(t *testing.T) {
+	type Data struct {
+		Env  StringValue `yaml:"env"`
+		Var  StringValue `yaml:"var"`
+		File StringValue `yaml:"file"`
+	}
+
+	require.NoError(t, os.Setenv("PROVISIONING_SECRET", "pa$word${HOME}"))
+	defer func() {
+		require.NoError(t, os.Unsetenv("PROVISIONING_SECRET"))
+	}()
+
+	f, err := ioutil.TempFile(os.TempDir(), "file expansion *")
+	require.NoError(t, err)
+	file := f.Name()
+
+	defer func() {
+		require.NoError(t, os.Remove(file))
+	}()
+
+	_, err = f.WriteString("se$cret\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	data := &Data{}
+	document := fmt.Sprintf("env: $__env{PROVISIONING_SECRET}\nvar: $PROVISIONING_SECRET\nfile: $__file{%s}", file)
+	err = yaml.Unmarshal([]byte(document), data)
+	require.NoError(t, err)
+	assert.Equal(t, "pa$word${HOME}", data.Env.Value())
+	assert.Equal(t, "pa$word${HOME}", data.Var.Value())
+	assert.Equal(t, "se$cret", data.File.Value())
+	assert.Equal(t, "$__env{PROVISIONING_SECRET}", data.Env.Raw)
+}
+
 func TestValues_expanderError(t *testing.T) {
 	type Data struct {
 		Top JSONValue `yaml:"top"`
@@ -0,0 +1,61 @@
+package provisioning
+
+import (
+	"context"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// datasourcesWatchDelay is how long the watcher waits for more changes before provisioning,
+// as editors and Kubernetes config maps change several files at once
+const datasourcesWatchDelay = time.Second
+
+// watchDatasources provisions the datasources whenever a file in the datasources
+// provisioning directory changes, until the context is cancelled
+func (ps *provisioningServiceImpl) watchDatasources(ctx context.Context) {
+	datasourcesPath := ps.datasourcesPath()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		ps.log.Error("Failed to watch datasources provisioning directory", "error", err)
+		return
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(datasourcesPath); err != nil {
+		ps.log.Error("Failed to watch datasources provisioning directory", "path", datasourcesPath, "error", err)
+		return
+	}
+
+	ps.log.Info("Watching datasources provisioning directory for changes", "path", datasourcesPath)
+
+	var provision <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			ps.log.Debug("Datasources provisioning file changed", "file", event.Name, "op", event.Op.String())
+			provision = time.After(datasourcesWatchDelay)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			ps.log.Error("Error watching datasources provisioning directory", "error", err)
+		case <-provision:
+			provision = nil
+			if err := ps.ProvisionDatasources(); err != nil {
+				ps.log.Error("Failed to provision datasources after files changed", "error", err)
+				continue
+			}
+			ps.log.Info("Provisioned datasources after files changed")
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package querycache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/grafana/grafana/pkg/util/errutil"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Statuses of the query cache, reported in the X-Cache header of the responses
+const (
+	StatusHit    = "HIT"
+	StatusMiss   = "MISS"
+	StatusBypass = "BYPASS"
+)
+
+// defaultTTL is how long a response is cached when its data source doesn't set queryCacheTTL
+const defaultTTL = time.Minute
+
+var queryCacheRequests *prometheus.CounterVec
+
+func init() {
+	registry.RegisterService(&QueryCacheService{})
+
+	queryCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "query_cache_requests_total",
+		Help:      "Number of data source query requests looked up in the query cache, by status",
+	}, []string{"status"})
+
+	prometheus.MustRegister(queryCacheRequests)
+}
+
+// QueryCacheService caches the responses of the data sources enabling it with the
+// queryCacheEnabled option, so that identical query requests of any user are run once per TTL.
+// The dashboards of the configuration file of [query_cache_warming] are warmed: their query
+// requests are run again on their schedules, so that their first viewers get cached responses.
+type QueryCacheService struct {
+	Cfg          *setting.Cfg             `inject:""`
+	CacheService *localcache.CacheService `inject:""`
+
+	log    log.Logger
+	warmer *cacheWarmer
+}
+
+func (s *QueryCacheService) Init() error {
+	s.log = log.New("querycache")
+
+	if !s.Cfg.QueryCacheWarming.Enabled {
+		return nil
+	}
+
+	path := s.Cfg.QueryCacheWarming.ConfigFile
+	dashboards, err := readWarmingConfigFile(path)
+	if err != nil {
+		return errutil.Wrapf(err, "failed to read the warmed dashboards from %s", path)
+	}
+	s.warmer = newCacheWarmer(dashboards)
+
+	s.log.Info("Query cache warmed dashboards loaded", "file", path, "dashboards", len(dashboards))
+	return nil
+}
+
+// CacheStatus tells whether a response was served from the cache, and for how long it's cached.
+type CacheStatus struct {
+	Status string
+	MaxAge time.Duration
+}
+
+// HandleRequest runs the queries of a data source, or returns the cached response of an identical
+// request when the data source enables query caching.
+func (s *QueryCacheService) HandleRequest(ctx context.Context, ds *models.DataSource, req *tsdb.TsdbQuery, skipCache bool) (*tsdb.Response, CacheStatus, error) {
+	ttl, enabled := cacheTTL(ds)
+	if !enabled || skipCache || req.Debug {
+		resp, err := tsdb.HandleRequest(ctx, ds, req)
+		return withCacheStatus(resp, StatusBypass), CacheStatus{Status: StatusBypass}, err
+	}
+
+	// the rules are applied before looking up the cache, so that the queries they deny aren't
+	// served from the cache
+	if err := tsdb.ApplyQueryRules(ds, req); err != nil {
+		return nil, CacheStatus{}, err
+	}
+
+	key, err := cacheKey(ds, req, ttl)
+	if err != nil {
+		return nil, CacheStatus{}, err
+	}
+
+	if cached, expiration, found := s.CacheService.GetWithExpiration(key); found {
+		queryCacheRequests.WithLabelValues(StatusHit).Inc()
+		return withCacheStatus(copyResponse(cached.(*tsdb.Response)), StatusHit), CacheStatus{Status: StatusHit, MaxAge: time.Until(expiration)}, nil
+	}
+	queryCacheRequests.WithLabelValues(StatusMiss).Inc()
+
+	resp, err := tsdb.HandleRequest(ctx, ds, req)
+	if err != nil {
+		return nil, CacheStatus{}, err
+	}
+
+	withCacheStatus(resp, StatusMiss)
+	for _, res := range resp.Results {
+		if res.Error != nil || res.ErrorString != "" {
+			return resp, CacheStatus{Status: StatusMiss}, nil
+		}
+	}
+
+	s.CacheService.Set(key, copyResponse(resp), ttl)
+	return resp, CacheStatus{Status: StatusMiss, MaxAge: ttl}, nil
+}
+
+// withCacheStatus tells the query inspector whether the response is served from the cache. The
+// responses served from the cache sent no request to the data source.
+func withCacheStatus(resp *tsdb.Response, status string) *tsdb.Response {
+	if resp == nil {
+		return nil
+	}
+
+	if resp.Execution == nil {
+		resp.Execution = &tsdb.ExecutionMeta{Requests: []models.DataSourceRequest{}}
+	}
+	resp.Execution.Cache = status
+	return resp
+}
+
+// cacheTTL returns how long the responses of a data source are cached, if it enables query caching.
+func cacheTTL(ds *models.DataSource) (time.Duration, bool) {
+	if ds == nil || ds.JsonData == nil || !ds.JsonData.Get("queryCacheEnabled").MustBool(false) {
+		return 0, false
+	}
+
+	// the responses of data sources queried with the token of the user aren't shared
+	if ds.JsonData.Get("oauthPassThru").MustBool(false) {
+		return 0, false
+	}
+
+	ttl := time.Duration(ds.JsonData.Get("queryCacheTTL").MustInt(0)) * time.Second
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	return ttl, true
+}
+
+type cacheKeyQuery struct {
+	RefId         string          `json:"refId"`
+	MaxDataPoints int64           `json:"maxDataPoints"`
+	IntervalMs    int64           `json:"intervalMs"`
+	QueryType     string          `json:"queryType"`
+	Model         json.RawMessage `json:"model"`
+}
+
+// cacheKey identifies a request by its data source and its queries. The relative time ranges are
+// truncated to the TTL, so that the requests made within the same TTL share their response, while
+// the absolute time ranges are kept as they are.
+func cacheKey(ds *models.DataSource, req *tsdb.TsdbQuery, ttl time.Duration) (string, error) {
+	step := ttl.Milliseconds()
+	queries := make([]cacheKeyQuery, 0, len(req.Queries))
+	for _, query := range req.Queries {
+		model, err := query.Model.MarshalJSON()
+		if err != nil {
+			return "", err
+		}
+		queries = append(queries, cacheKeyQuery{
+			RefId:         query.RefId,
+			MaxDataPoints: query.MaxDataPoints,
+			IntervalMs:    query.IntervalMs,
+			QueryType:     query.QueryType,
+			Model:         model,
+		})
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"datasourceId": ds.Id,
+		"version":      ds.Version,
+		"from":         cacheKeyTime(req.TimeRange.From, req.TimeRange.GetFromAsMsEpoch(), step),
+		"to":           cacheKeyTime(req.TimeRange.To, req.TimeRange.GetToAsMsEpoch(), step),
+		"queries":      queries,
+		// the responses are cached once transformed
+		"transformations": req.Transformations,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(data)
+	return "query-cache-" + hex.EncodeToString(hash[:]), nil
+}
+
+// cacheKeyTime truncates a time relative to now to the given step in milliseconds
+func cacheKeyTime(value string, epochMs int64, step int64) int64 {
+	if !strings.Contains(value, "now") {
+		return epochMs
+	}
+	return epochMs - epochMs%step
+}
+
+// copyResponse copies a response, so that the cached response isn't changed by its callers.
+func copyResponse(resp *tsdb.Response) *tsdb.Response {
+	results := make(map[string]*tsdb.QueryResult, len(resp.Results))
+	for refID, res := range resp.Results {
+		copied := *res
+		results[refID] = &copied
+	}
+
+	return &tsdb.Response{
+		Results: results,
+		Message: resp.Message,
+	}
+}
@@ -0,0 +1,144 @@
+package querycache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEndpoint struct {
+	calls int
+	err   error
+}
+
+func (e *fakeEndpoint) Query(ctx context.Context, ds *models.DataSource, query *tsdb.TsdbQuery) (*tsdb.Response, error) {
+	e.calls++
+	result := tsdb.NewQueryResult()
+	result.RefId = "A"
+	result.Error = e.err
+	return &tsdb.Response{Results: map[string]*tsdb.QueryResult{"A": result}}, nil
+}
+
+func TestQueryCacheService(t *testing.T) {
+	endpoint := &fakeEndpoint{}
+	tsdb.RegisterTsdbQueryEndpoint("querycache-test", func(dsInfo *models.DataSource) (tsdb.TsdbQueryEndpoint, error) {
+		return endpoint, nil
+	})
+
+	s := &QueryCacheService{CacheService: localcache.New(5*time.Minute, 10*time.Minute)}
+	ds := &models.DataSource{
+		Id:       1,
+		Type:     "querycache-test",
+		JsonData: simplejson.NewFromAny(map[string]interface{}{"queryCacheEnabled": true, "queryCacheTTL": 30}),
+	}
+	newRequest := func(expr string) *tsdb.TsdbQuery {
+		return &tsdb.TsdbQuery{
+			TimeRange: tsdb.NewTimeRange("1600000000000", "1600000300000"),
+			Queries: []*tsdb.Query{
+				{RefId: "A", Model: simplejson.NewFromAny(map[string]interface{}{"expr": expr})},
+			},
+		}
+	}
+
+	t.Run("Identical requests are served from the cache", func(t *testing.T) {
+		resp, status, err := s.HandleRequest(context.Background(), ds, newRequest("up"), false)
+		require.NoError(t, err)
+		require.Equal(t, StatusMiss, status.Status)
+		require.Equal(t, 30*time.Second, status.MaxAge)
+		require.Equal(t, StatusMiss, resp.Execution.Cache)
+
+		resp, status, err = s.HandleRequest(context.Background(), ds, newRequest("up"), false)
+		require.NoError(t, err)
+		require.Equal(t, StatusHit, status.Status)
+		require.Equal(t, "A", resp.Results["A"].RefId)
+		require.Equal(t, StatusHit, resp.Execution.Cache)
+		require.Empty(t, resp.Execution.Requests)
+		require.Equal(t, 1, endpoint.calls)
+	})
+
+	t.Run("Different queries aren't served from the cache", func(t *testing.T) {
+		endpoint.calls = 0
+		_, status, err := s.HandleRequest(context.Background(), ds, newRequest("down"), false)
+		require.NoError(t, err)
+		require.Equal(t, StatusMiss, status.Status)
+		require.Equal(t, 1, endpoint.calls)
+	})
+
+	t.Run("Requests skipping the cache bypass it", func(t *testing.T) {
+		endpoint.calls = 0
+		_, status, err := s.HandleRequest(context.Background(), ds, newRequest("up"), true)
+		require.NoError(t, err)
+		require.Equal(t, StatusBypass, status.Status)
+		require.Equal(t, 1, endpoint.calls)
+	})
+
+	t.Run("Data sources without query caching bypass it", func(t *testing.T) {
+		endpoint.calls = 0
+		uncached := &models.DataSource{Id: 2, Type: "querycache-test", JsonData: simplejson.New()}
+		for i := 0; i < 2; i++ {
+			_, status, err := s.HandleRequest(context.Background(), uncached, newRequest("up"), false)
+			require.NoError(t, err)
+			require.Equal(t, StatusBypass, status.Status)
+		}
+		require.Equal(t, 2, endpoint.calls)
+	})
+
+	t.Run("Failed queries aren't cached", func(t *testing.T) {
+		endpoint.calls = 0
+		endpoint.err = errors.New("query failed")
+		defer func() { endpoint.err = nil }()
+
+		for i := 0; i < 2; i++ {
+			_, status, err := s.HandleRequest(context.Background(), ds, newRequest("failing"), false)
+			require.NoError(t, err)
+			require.Equal(t, StatusMiss, status.Status)
+		}
+		require.Equal(t, 2, endpoint.calls)
+	})
+
+	t.Run("Updating the data source invalidates its cached responses", func(t *testing.T) {
+		endpoint.calls = 0
+		updated := *ds
+		updated.Version++
+		_, status, err := s.HandleRequest(context.Background(), &updated, newRequest("up"), false)
+		require.NoError(t, err)
+		require.Equal(t, StatusMiss, status.Status)
+		require.Equal(t, 1, endpoint.calls)
+	})
+}
+
+func TestCacheKey(t *testing.T) {
+	ds := &models.DataSource{Id: 1}
+	now := time.Date(2020, 9, 13, 12, 0, 10, 0, time.UTC)
+	newRequest := func(from, to string, now time.Time) *tsdb.TsdbQuery {
+		return &tsdb.TsdbQuery{
+			TimeRange: tsdb.NewFakeTimeRange(from, to, now),
+			Queries: []*tsdb.Query{
+				{RefId: "A", Model: simplejson.NewFromAny(map[string]interface{}{"expr": "up"})},
+			},
+		}
+	}
+	key := func(req *tsdb.TsdbQuery) string {
+		key, err := cacheKey(ds, req, 30*time.Second)
+		require.NoError(t, err)
+		return key
+	}
+
+	t.Run("Relative time ranges within the same TTL share their key", func(t *testing.T) {
+		require.Equal(t, key(newRequest("now-1h", "now", now)), key(newRequest("now-1h", "now", now.Add(15*time.Second))))
+		require.NotEqual(t, key(newRequest("now-1h", "now", now)), key(newRequest("now-1h", "now", now.Add(30*time.Second))))
+	})
+
+	t.Run("Absolute time ranges are keyed on their exact range", func(t *testing.T) {
+		require.NotEqual(t, key(newRequest("1600000000000", "1600000300000", now)), key(newRequest("1600000000001", "1600000300000", now)))
+		require.NotEqual(t, key(newRequest("1600000000000", "1600000300000", now)), key(newRequest("1600000000000", "1600000300010", now)))
+		require.Equal(t, key(newRequest("1600000000000", "1600000300000", now)), key(newRequest("1600000000000", "1600000300000", now.Add(time.Hour))))
+	})
+}
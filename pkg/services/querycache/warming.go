@@ -0,0 +1,394 @@
+package querycache
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v2"
+)
+
+// Warming statuses of the requests of the warmed dashboards
+const (
+	warmingStatusHit   = "hit"
+	warmingStatusMiss  = "miss"
+	warmingStatusError = "error"
+)
+
+const (
+	// warmingInterval is how often the schedules of the warmed dashboards are checked
+	warmingInterval = time.Minute
+	// warmingRequestTimeout is how long a warmed request may run
+	warmingRequestTimeout = time.Minute
+	// maxWarmedRequests is the max number of requests warmed per dashboard
+	maxWarmedRequests = 200
+	// relativeRangeSlack is how close to now the end of a recorded time range must be for the
+	// range to be relative, like now-6h to now
+	relativeRangeSlack = time.Minute
+)
+
+var (
+	warmingRequests *prometheus.CounterVec
+	warmingDuration *prometheus.HistogramVec
+)
+
+func init() {
+	warmingRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "query_cache_warming_requests_total",
+		Help:      "Number of query requests run to warm the query cache, by dashboard and status",
+	}, []string{"dashboard", "status"})
+
+	warmingDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "grafana",
+		Name:      "query_cache_warming_duration_seconds",
+		Help:      "Time taken to warm the query cache with the requests of a dashboard",
+		Buckets:   []float64{.1, .5, 1, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"dashboard"})
+
+	prometheus.MustRegister(warmingRequests, warmingDuration)
+}
+
+// WarmedDashboard is a dashboard whose queries are run on a schedule to populate the query cache.
+type WarmedDashboard struct {
+	OrgID int64  `yaml:"org_id"`
+	UID   string `yaml:"uid"`
+	// Schedule is a cron expression with five fields or a descriptor like @daily
+	Schedule string `yaml:"schedule"`
+	// Timezone of the schedule, UTC by default
+	Timezone string `yaml:"timezone"`
+
+	schedule cron.Schedule
+}
+
+type warmingConfigFile struct {
+	Dashboards []*WarmedDashboard `yaml:"dashboards"`
+}
+
+func readWarmingConfigFile(path string) ([]*WarmedDashboard, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config warmingConfigFile
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	if err := validateWarmedDashboards(config.Dashboards); err != nil {
+		return nil, err
+	}
+	return config.Dashboards, nil
+}
+
+// validateWarmedDashboards checks the dashboards, parses their schedules and sets their defaults
+func validateWarmedDashboards(dashboards []*WarmedDashboard) error {
+	seen := make(map[string]bool)
+	for i, dashboard := range dashboards {
+		if dashboard.UID == "" {
+			return fmt.Errorf("dashboard %d has no uid", i+1)
+		}
+		if dashboard.OrgID < 1 {
+			dashboard.OrgID = 1
+		}
+		key := dashboardKey(dashboard.OrgID, dashboard.UID)
+		if seen[key] {
+			return fmt.Errorf("dashboard %q of org %d is defined twice", dashboard.UID, dashboard.OrgID)
+		}
+		seen[key] = true
+
+		if strings.HasPrefix(dashboard.Schedule, "TZ=") || strings.HasPrefix(dashboard.Schedule, "CRON_TZ=") {
+			return fmt.Errorf("dashboard %q has a timezone in its schedule, use its timezone instead", dashboard.UID)
+		}
+		schedule, err := cron.ParseStandard(dashboard.Schedule)
+		if err != nil {
+			return fmt.Errorf("dashboard %q has an invalid schedule %q: %w", dashboard.UID, dashboard.Schedule, err)
+		}
+
+		location := time.UTC
+		if dashboard.Timezone != "" && strings.ToLower(dashboard.Timezone) != "utc" {
+			if location, err = time.LoadLocation(dashboard.Timezone); err != nil {
+				return fmt.Errorf("dashboard %q has an invalid timezone %q", dashboard.UID, dashboard.Timezone)
+			}
+		}
+		dashboard.schedule = inLocation{schedule: schedule, location: location}
+	}
+	return nil
+}
+
+// inLocation evaluates a schedule in a timezone
+type inLocation struct {
+	schedule cron.Schedule
+	location *time.Location
+}
+
+func (s inLocation) Next(t time.Time) time.Time {
+	return s.schedule.Next(t.In(s.location))
+}
+
+func dashboardKey(orgID int64, uid string) string {
+	return fmt.Sprintf("%d/%s", orgID, uid)
+}
+
+// recordedRequest is the latest query request of a panel of a warmed dashboard to a data source.
+type recordedRequest struct {
+	orgID           int64
+	datasourceID    int64
+	user            *models.SignedInUser
+	queries         []*tsdb.Query
+	transformations []*tsdb.Transformation
+	headers         map[string]string
+	from, to        int64
+	// relative tells whether the time range ends now, and is shifted to the time of the warming
+	relative bool
+}
+
+// request returns the request to warm at a time, of the queries of a data source
+func (r *recordedRequest) request(ds *models.DataSource, now time.Time) *tsdb.TsdbQuery {
+	from, to := r.from, r.to
+	if r.relative {
+		to = now.UnixNano() / int64(time.Millisecond)
+		from = to - (r.to - r.from)
+	}
+
+	queries := make([]*tsdb.Query, 0, len(r.queries))
+	for _, query := range r.queries {
+		copied := *query
+		copied.Model = copyModel(query.Model)
+		copied.DataSource = ds
+		queries = append(queries, &copied)
+	}
+
+	return &tsdb.TsdbQuery{
+		TimeRange:       tsdb.NewTimeRange(fmt.Sprint(from), fmt.Sprint(to)),
+		Queries:         queries,
+		Headers:         r.headers,
+		User:            r.user,
+		Transformations: r.transformations,
+	}
+}
+
+// copyModel copies the model of a query, so that the rules rewriting queries don't change the
+// recorded one
+func copyModel(model *simplejson.Json) *simplejson.Json {
+	if model == nil {
+		return simplejson.New()
+	}
+	data, err := model.MarshalJSON()
+	if err != nil {
+		return simplejson.New()
+	}
+	copied, err := simplejson.NewJson(data)
+	if err != nil {
+		return simplejson.New()
+	}
+	return copied
+}
+
+// cacheWarmer records the query requests of the panels of the warmed dashboards, and runs them
+// again on the schedules of the dashboards.
+type cacheWarmer struct {
+	dashboards map[string]*WarmedDashboard
+
+	mu sync.Mutex
+	// uids of the dashboards by id, resolved once
+	uids map[int64]string
+	// requests of the dashboards by dashboard key, then by panel and data source
+	requests map[string]map[string]*recordedRequest
+}
+
+func newCacheWarmer(dashboards []*WarmedDashboard) *cacheWarmer {
+	w := &cacheWarmer{
+		dashboards: make(map[string]*WarmedDashboard, len(dashboards)),
+		uids:       make(map[int64]string),
+		requests:   make(map[string]map[string]*recordedRequest),
+	}
+	for _, dashboard := range dashboards {
+		w.dashboards[dashboardKey(dashboard.OrgID, dashboard.UID)] = dashboard
+	}
+	return w
+}
+
+// warmedDashboard returns the warmed dashboard of a dashboard id, nil when it isn't warmed
+func (w *cacheWarmer) warmedDashboard(orgID, dashboardID int64) *WarmedDashboard {
+	w.mu.Lock()
+	uid, ok := w.uids[dashboardID]
+	w.mu.Unlock()
+
+	if !ok {
+		query := models.GetDashboardQuery{Id: dashboardID, OrgId: orgID}
+		if err := bus.Dispatch(&query); err != nil {
+			return nil
+		}
+		uid = query.Result.Uid
+
+		w.mu.Lock()
+		w.uids[dashboardID] = uid
+		w.mu.Unlock()
+	}
+	return w.dashboards[dashboardKey(orgID, uid)]
+}
+
+func (w *cacheWarmer) record(dashboard *WarmedDashboard, panelID int64, ds *models.DataSource, req *tsdb.TsdbQuery, now time.Time) {
+	queries := make([]*tsdb.Query, 0, len(req.Queries))
+	for _, query := range req.Queries {
+		queries = append(queries, &tsdb.Query{
+			RefId:         query.RefId,
+			Model:         copyModel(query.Model),
+			MaxDataPoints: query.MaxDataPoints,
+			IntervalMs:    query.IntervalMs,
+			QueryType:     query.QueryType,
+		})
+	}
+
+	to := req.TimeRange.GetToAsMsEpoch()
+	sinceTo := now.Sub(time.Unix(0, to*int64(time.Millisecond)))
+	recorded := &recordedRequest{
+		orgID:           ds.OrgId,
+		datasourceID:    ds.Id,
+		user:            req.User,
+		queries:         queries,
+		transformations: req.Transformations,
+		headers:         req.Headers,
+		from:            req.TimeRange.GetFromAsMsEpoch(),
+		to:              to,
+		relative:        sinceTo > -relativeRangeSlack && sinceTo < relativeRangeSlack,
+	}
+
+	key := dashboardKey(dashboard.OrgID, dashboard.UID)
+	requestKey := fmt.Sprintf("%d/%d", panelID, ds.Id)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	requests, ok := w.requests[key]
+	if !ok {
+		requests = make(map[string]*recordedRequest)
+		w.requests[key] = requests
+	}
+	if _, ok := requests[requestKey]; !ok && len(requests) >= maxWarmedRequests {
+		return
+	}
+	requests[requestKey] = recorded
+}
+
+// recorded returns the recorded requests of a dashboard
+func (w *cacheWarmer) recorded(dashboard *WarmedDashboard) []*recordedRequest {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	requests := w.requests[dashboardKey(dashboard.OrgID, dashboard.UID)]
+	recorded := make([]*recordedRequest, 0, len(requests))
+	for _, req := range requests {
+		recorded = append(recorded, req)
+	}
+	return recorded
+}
+
+// RecordDashboardRequest records the query request of a panel of a dashboard, so that it's run
+// again to warm the query cache when the dashboard is warmed. The requests of the dashboards are
+// learned from their views, as their queries depend on the panels' widths and on the variables.
+func (s *QueryCacheService) RecordDashboardRequest(dashboardID, panelID int64, ds *models.DataSource, req *tsdb.TsdbQuery) {
+	if s.warmer == nil || ds == nil || dashboardID <= 0 {
+		return
+	}
+	if _, enabled := cacheTTL(ds); !enabled || req.Debug {
+		return
+	}
+
+	dashboard := s.warmer.warmedDashboard(ds.OrgId, dashboardID)
+	if dashboard == nil {
+		return
+	}
+	s.warmer.record(dashboard, panelID, ds, req, time.Now())
+}
+
+// Run warms the query cache with the requests of the warmed dashboards on their schedules. The
+// cache is local, so every Grafana instance warms its own.
+func (s *QueryCacheService) Run(ctx context.Context) error {
+	if s.warmer == nil {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	now := time.Now()
+	next := make(map[*WarmedDashboard]time.Time, len(s.warmer.dashboards))
+	for _, dashboard := range s.warmer.dashboards {
+		next[dashboard] = dashboard.schedule.Next(now)
+	}
+
+	ticker := time.NewTicker(warmingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case t := <-ticker.C:
+			for dashboard, at := range next {
+				if t.Before(at) {
+					continue
+				}
+				s.warmDashboard(ctx, dashboard, t)
+				next[dashboard] = dashboard.schedule.Next(time.Now())
+			}
+		}
+	}
+}
+
+// warmDashboard runs the recorded requests of a dashboard one after the other, so that warming
+// doesn't load the data sources more than a viewer of the dashboard
+func (s *QueryCacheService) warmDashboard(ctx context.Context, dashboard *WarmedDashboard, now time.Time) {
+	started := time.Now()
+	recorded := s.warmer.recorded(dashboard)
+	counts := make(map[string]int)
+	for _, req := range recorded {
+		if ctx.Err() != nil {
+			return
+		}
+		status := s.warmRequest(ctx, dashboard, req, now)
+		warmingRequests.WithLabelValues(dashboard.UID, status).Inc()
+		counts[status]++
+	}
+	warmingDuration.WithLabelValues(dashboard.UID).Observe(time.Since(started).Seconds())
+
+	s.log.Info("Query cache warmed", "orgId", dashboard.OrgID, "dashboard", dashboard.UID, "requests", len(recorded),
+		"hits", counts[warmingStatusHit], "misses", counts[warmingStatusMiss], "errors", counts[warmingStatusError],
+		"duration", time.Since(started))
+}
+
+func (s *QueryCacheService) warmRequest(ctx context.Context, dashboard *WarmedDashboard, recorded *recordedRequest, now time.Time) string {
+	// the data source is loaded again, as it may have been updated since the request was recorded
+	query := models.GetDataSourceByIdQuery{Id: recorded.datasourceID, OrgId: recorded.orgID}
+	if err := bus.Dispatch(&query); err != nil {
+		s.log.Warn("Failed to load the data source of a warmed request", "dashboard", dashboard.UID,
+			"datasourceId", recorded.datasourceID, "error", err)
+		return warmingStatusError
+	}
+	if _, enabled := cacheTTL(query.Result); !enabled {
+		return warmingStatusError
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, warmingRequestTimeout)
+	defer cancel()
+	resp, status, err := s.HandleRequest(ctx, query.Result, recorded.request(query.Result, now), false)
+	if err != nil {
+		s.log.Warn("Failed to warm a request", "dashboard", dashboard.UID, "datasourceId", recorded.datasourceID, "error", err)
+		return warmingStatusError
+	}
+	for _, res := range resp.Results {
+		if res.Error != nil || res.ErrorString != "" {
+			return warmingStatusError
+		}
+	}
+	if status.Status == StatusHit {
+		return warmingStatusHit
+	}
+	return warmingStatusMiss
+}
@@ -0,0 +1,147 @@
+package querycache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateWarmedDashboards(t *testing.T) {
+	dashboards := []*WarmedDashboard{
+		{UID: "ops", Schedule: "0 8 * * 1-5", Timezone: "Europe/Stockholm"},
+		{OrgID: 2, UID: "ops", Schedule: "@daily"},
+	}
+	require.NoError(t, validateWarmedDashboards(dashboards))
+	assert.Equal(t, int64(1), dashboards[0].OrgID)
+
+	// Monday 2020-09-14 05:00 UTC is 07:00 in Stockholm
+	now := time.Date(2020, 9, 14, 5, 0, 0, 0, time.UTC)
+	assert.True(t, dashboards[0].schedule.Next(now).Equal(time.Date(2020, 9, 14, 6, 0, 0, 0, time.UTC)))
+	assert.True(t, dashboards[1].schedule.Next(now).Equal(time.Date(2020, 9, 15, 0, 0, 0, 0, time.UTC)))
+
+	for name, dashboard := range map[string]*WarmedDashboard{
+		"no uid":               {Schedule: "@daily"},
+		"invalid schedule":     {UID: "a", Schedule: "0 8 * *"},
+		"timezone in schedule": {UID: "a", Schedule: "CRON_TZ=UTC 0 8 * * *"},
+		"invalid timezone":     {UID: "a", Schedule: "@daily", Timezone: "Mars/Olympus"},
+	} {
+		assert.Error(t, validateWarmedDashboards([]*WarmedDashboard{dashboard}), name)
+	}
+	assert.Error(t, validateWarmedDashboards([]*WarmedDashboard{
+		{UID: "a", Schedule: "@daily"}, {OrgID: 1, UID: "a", Schedule: "@hourly"},
+	}), "duplicate dashboards")
+}
+
+func TestQueryCacheWarming(t *testing.T) {
+	endpoint := &fakeEndpoint{}
+	tsdb.RegisterTsdbQueryEndpoint("querycache-warming-test", func(dsInfo *models.DataSource) (tsdb.TsdbQueryEndpoint, error) {
+		return endpoint, nil
+	})
+
+	ds := &models.DataSource{
+		Id:       1,
+		OrgId:    1,
+		Type:     "querycache-warming-test",
+		JsonData: simplejson.NewFromAny(map[string]interface{}{"queryCacheEnabled": true, "queryCacheTTL": 3600}),
+	}
+
+	bus.ClearBusHandlers()
+	t.Cleanup(bus.ClearBusHandlers)
+	bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+		if query.OrgId != 1 {
+			return models.ErrDashboardNotFound
+		}
+		query.Result = &models.Dashboard{Id: query.Id, OrgId: 1, Uid: fmt.Sprintf("dash-%d", query.Id)}
+		return nil
+	})
+	bus.AddHandler("test", func(query *models.GetDataSourceByIdQuery) error {
+		query.Result = ds
+		return nil
+	})
+
+	dashboard := &WarmedDashboard{OrgID: 1, UID: "dash-1", Schedule: "@daily"}
+	require.NoError(t, validateWarmedDashboards([]*WarmedDashboard{dashboard}))
+	s := &QueryCacheService{
+		CacheService: localcache.New(5*time.Minute, 10*time.Minute),
+		log:          log.New("querycache"),
+		warmer:       newCacheWarmer([]*WarmedDashboard{dashboard}),
+	}
+
+	newRequest := func(from, to time.Time) *tsdb.TsdbQuery {
+		return &tsdb.TsdbQuery{
+			TimeRange: tsdb.NewTimeRange(fmt.Sprint(from.UnixNano()/int64(time.Millisecond)), fmt.Sprint(to.UnixNano()/int64(time.Millisecond))),
+			Queries: []*tsdb.Query{
+				{RefId: "A", MaxDataPoints: 800, IntervalMs: 30000, Model: simplejson.NewFromAny(map[string]interface{}{"expr": "up"})},
+			},
+			User: &models.SignedInUser{OrgId: 1},
+		}
+	}
+
+	t.Run("The requests of the panels of warmed dashboards are recorded", func(t *testing.T) {
+		now := time.Now()
+		s.RecordDashboardRequest(1, 2, ds, newRequest(now.Add(-6*time.Hour), now))
+		s.RecordDashboardRequest(1, 3, ds, newRequest(now.Add(-6*time.Hour), now))
+		// a later request of a panel replaces its recorded request
+		s.RecordDashboardRequest(1, 3, ds, newRequest(now.Add(-12*time.Hour), now))
+		s.RecordDashboardRequest(2, 2, ds, newRequest(now.Add(-6*time.Hour), now))
+
+		recorded := s.warmer.recorded(dashboard)
+		require.Len(t, recorded, 2)
+		for _, req := range recorded {
+			assert.True(t, req.relative)
+		}
+	})
+
+	t.Run("Warming runs the recorded requests of a dashboard", func(t *testing.T) {
+		endpoint.calls = 0
+		warmedAt := time.Now().Add(time.Hour)
+		s.warmDashboard(context.Background(), dashboard, warmedAt)
+		require.Equal(t, 2, endpoint.calls)
+
+		// the relative time ranges are shifted to the time of the warming, so that the views
+		// of the dashboard which follow it are served from the cache
+		_, status, err := s.HandleRequest(context.Background(), ds, newRequest(warmedAt.Add(-6*time.Hour), warmedAt), false)
+		require.NoError(t, err)
+		assert.Equal(t, StatusHit, status.Status)
+		assert.Equal(t, 2, endpoint.calls)
+
+		// warming again within the TTL hits the cache
+		s.warmDashboard(context.Background(), dashboard, warmedAt)
+		assert.Equal(t, 2, endpoint.calls)
+	})
+
+	t.Run("Absolute time ranges are warmed as recorded", func(t *testing.T) {
+		from := time.Date(2020, 9, 14, 0, 0, 0, 0, time.UTC)
+		req := newRequest(from, from.Add(24*time.Hour))
+		s.RecordDashboardRequest(1, 4, ds, req)
+
+		var recorded *recordedRequest
+		for _, r := range s.warmer.recorded(dashboard) {
+			if !r.relative {
+				recorded = r
+			}
+		}
+		require.NotNil(t, recorded)
+		warmed := recorded.request(ds, time.Now())
+		assert.Equal(t, req.TimeRange.GetFromAsMsEpoch(), warmed.TimeRange.GetFromAsMsEpoch())
+		assert.Equal(t, req.TimeRange.GetToAsMsEpoch(), warmed.TimeRange.GetToAsMsEpoch())
+	})
+
+	t.Run("Requests to data sources without query caching aren't recorded", func(t *testing.T) {
+		uncached := &models.DataSource{Id: 2, OrgId: 1, Type: "querycache-warming-test", JsonData: simplejson.New()}
+		count := len(s.warmer.recorded(dashboard))
+		now := time.Now()
+		s.RecordDashboardRequest(1, 5, uncached, newRequest(now.Add(-time.Hour), now))
+		assert.Len(t, s.warmer.recorded(dashboard), count)
+	})
+}
@@ -0,0 +1,75 @@
+package queryrules
+
+import (
+	"io/ioutil"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/grafana/grafana/pkg/util/errutil"
+	"gopkg.in/yaml.v2"
+)
+
+func init() {
+	registry.RegisterService(&QueryRulesService{})
+}
+
+// QueryRulesService loads the rules allowing, denying or rewriting the queries of the data sources
+// from the configuration file of [query_rules], and loads them again when the settings are reloaded.
+type QueryRulesService struct {
+	Cfg *setting.Cfg `inject:""`
+
+	log log.Logger
+}
+
+type configFile struct {
+	Rules []*tsdb.QueryRule `yaml:"rules"`
+}
+
+func (s *QueryRulesService) Init() error {
+	s.log = log.New("queryrules")
+
+	if !s.Cfg.QueryRules.Enabled {
+		return nil
+	}
+
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	setting.OnReload(func(cfg *setting.Cfg) {
+		if err := s.load(); err != nil {
+			s.log.Error("Failed to reload the query rules, the previous rules are kept", "error", err)
+		}
+	})
+	return nil
+}
+
+func (s *QueryRulesService) load() error {
+	path := s.Cfg.QueryRules.ConfigFile
+	rules, err := readConfigFile(path)
+	if err != nil {
+		return errutil.Wrapf(err, "failed to read query rules from %s", path)
+	}
+
+	if err := tsdb.SetQueryRules(rules); err != nil {
+		return errutil.Wrapf(err, "failed to load query rules from %s", path)
+	}
+
+	s.log.Info("Query rules loaded", "file", path, "rules", len(rules))
+	return nil
+}
+
+func readConfigFile(path string) ([]*tsdb.QueryRule, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config configFile
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	return config.Rules, nil
+}
@@ -0,0 +1,56 @@
+package queryrules
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryRulesService(t *testing.T) {
+	t.Cleanup(func() {
+		err := tsdb.SetQueryRules(nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("Should read the rules of the configuration file", func(t *testing.T) {
+		rules, err := readConfigFile("testdata/rules.yaml")
+		require.NoError(t, err)
+		require.Len(t, rules, 2)
+
+		require.Equal(t, "deny-sql-writes", rules[0].Name)
+		require.Equal(t, []string{"mysql", "postgres", "mssql"}, rules[0].DatasourceTypes)
+		require.Equal(t, tsdb.QueryRuleDeny, rules[0].Action)
+		require.Equal(t, "rawSql", rules[0].Match[0].Field)
+		require.Equal(t, `(?i)\b(drop|delete|truncate|alter)\b`, rules[0].Match[0].Regex)
+
+		require.Equal(t, []int64{1, 2}, rules[1].OrgIds)
+		require.Equal(t, tsdb.QueryRuleRewrite, rules[1].Action)
+		require.Equal(t, "[1m]", rules[1].Rewrites[0].Replace)
+	})
+
+	t.Run("Should load the rules when enabled", func(t *testing.T) {
+		s := &QueryRulesService{Cfg: setting.NewCfg()}
+		s.Cfg.QueryRules = setting.QueryRulesSettings{Enabled: true, ConfigFile: "testdata/rules.yaml"}
+
+		err := s.Init()
+		require.NoError(t, err)
+	})
+
+	t.Run("Should fail with invalid rules", func(t *testing.T) {
+		s := &QueryRulesService{Cfg: setting.NewCfg()}
+		s.Cfg.QueryRules = setting.QueryRulesSettings{Enabled: true, ConfigFile: "testdata/invalid.yaml"}
+
+		err := s.Init()
+		require.Error(t, err)
+	})
+
+	t.Run("Should fail without configuration file", func(t *testing.T) {
+		s := &QueryRulesService{Cfg: setting.NewCfg()}
+		s.Cfg.QueryRules = setting.QueryRulesSettings{Enabled: true, ConfigFile: "testdata/missing.yaml"}
+
+		err := s.Init()
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,243 @@
+package querystats
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func init() {
+	registry.RegisterService(&QueryStatsService{})
+}
+
+const (
+	// maxPanelsPerOrg is the most panels whose query durations are tracked per organization
+	maxPanelsPerOrg = 10000
+	// statsRetention is how long the durations of a panel are kept after its last query
+	statsRetention = 24 * time.Hour
+)
+
+// QueryStatsService tracks the durations of the queries of the dashboard panels per organization,
+// to report the slowest panels and dashboards. The durations are kept in memory, by instance.
+type QueryStatsService struct {
+	log log.Logger
+
+	mutex  sync.Mutex
+	panels map[int64]map[panelKey]*panelStats
+}
+
+type panelKey struct {
+	dashboardID int64
+	panelID     int64
+}
+
+type panelStats struct {
+	queries        int64
+	total          time.Duration
+	max            time.Duration
+	slowQueries    int64
+	budgetExceeded int64
+	lastQueryAt    time.Time
+}
+
+// QueryDurations are the durations of the queries of a panel or of the panels of a dashboard
+type QueryDurations struct {
+	Queries        int64     `json:"queries"`
+	AvgDurationMs  int64     `json:"avgDurationMs"`
+	MaxDurationMs  int64     `json:"maxDurationMs"`
+	SlowQueries    int64     `json:"slowQueries"`
+	BudgetExceeded int64     `json:"budgetExceeded"`
+	LastQueryAt    time.Time `json:"lastQueryAt"`
+
+	total time.Duration
+}
+
+// slowerThan compares the durations by sortBy, max or slow, and by their average otherwise
+func (d *QueryDurations) slowerThan(other *QueryDurations, sortBy string) bool {
+	switch sortBy {
+	case "max":
+		if d.MaxDurationMs != other.MaxDurationMs {
+			return d.MaxDurationMs > other.MaxDurationMs
+		}
+	case "slow":
+		if d.SlowQueries != other.SlowQueries {
+			return d.SlowQueries > other.SlowQueries
+		}
+	}
+	return d.AvgDurationMs > other.AvgDurationMs
+}
+
+// PanelQueryStats are the query durations of a dashboard panel
+type PanelQueryStats struct {
+	DashboardID int64 `json:"dashboardId"`
+	PanelID     int64 `json:"panelId"`
+	QueryDurations
+}
+
+// DashboardQueryStats are the query durations of the panels of a dashboard
+type DashboardQueryStats struct {
+	DashboardID int64 `json:"dashboardId"`
+	Panels      int   `json:"panels"`
+	QueryDurations
+}
+
+func (s *QueryStatsService) Init() error {
+	s.log = log.New("querystats")
+	s.panels = make(map[int64]map[panelKey]*panelStats)
+	return nil
+}
+
+// Run drops the durations of the panels which weren't queried within statsRetention
+func (s *QueryStatsService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.prune(time.Now().Add(-statsRetention))
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// RecordPanelQuery records the duration of the queries of a dashboard panel, logging them when
+// they're slower than the slow query threshold
+func (s *QueryStatsService) RecordPanelQuery(orgID, dashboardID, panelID int64, ds *models.DataSource, duration time.Duration, budgetExceeded bool) {
+	slow := setting.QuerySlowThreshold > 0 && duration >= setting.QuerySlowThreshold
+	if slow {
+		s.log.Warn("Slow panel query", "orgId", orgID, "dashboardId", dashboardID, "panelId", panelID,
+			"datasource", ds.Name, "duration", duration)
+		metrics.MDataSourcePanelSlowQueries.WithLabelValues(ds.Type, ds.Uid).Inc()
+	}
+	if budgetExceeded {
+		metrics.MDataSourcePanelBudgetExceeded.WithLabelValues(ds.Type, ds.Uid).Inc()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	panels, ok := s.panels[orgID]
+	if !ok {
+		panels = make(map[panelKey]*panelStats)
+		s.panels[orgID] = panels
+	}
+	key := panelKey{dashboardID: dashboardID, panelID: panelID}
+	stats, ok := panels[key]
+	if !ok {
+		if len(panels) >= maxPanelsPerOrg {
+			return
+		}
+		stats = &panelStats{}
+		panels[key] = stats
+	}
+
+	stats.queries++
+	stats.total += duration
+	if duration > stats.max {
+		stats.max = duration
+	}
+	if slow {
+		stats.slowQueries++
+	}
+	if budgetExceeded {
+		stats.budgetExceeded++
+	}
+	stats.lastQueryAt = time.Now()
+}
+
+// SlowestPanels returns the panels of an organization with the longest query durations, sorted by
+// their average duration, or by their max duration or number of slow queries with sortBy max or slow
+func (s *QueryStatsService) SlowestPanels(orgID int64, sortBy string, limit int) []*PanelQueryStats {
+	s.mutex.Lock()
+	result := make([]*PanelQueryStats, 0, len(s.panels[orgID]))
+	for key, stats := range s.panels[orgID] {
+		result = append(result, &PanelQueryStats{
+			DashboardID: key.dashboardID,
+			PanelID:     key.panelID,
+			QueryDurations: QueryDurations{
+				Queries:        stats.queries,
+				AvgDurationMs:  milliseconds(stats.total / time.Duration(stats.queries)),
+				MaxDurationMs:  milliseconds(stats.max),
+				SlowQueries:    stats.slowQueries,
+				BudgetExceeded: stats.budgetExceeded,
+				LastQueryAt:    stats.lastQueryAt,
+				total:          stats.total,
+			},
+		})
+	}
+	s.mutex.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].slowerThan(&result[j].QueryDurations, sortBy)
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// SlowestDashboards returns the dashboards of an organization with the longest query durations of
+// their panels, sorted like SlowestPanels
+func (s *QueryStatsService) SlowestDashboards(orgID int64, sortBy string, limit int) []*DashboardQueryStats {
+	dashboards := make(map[int64]*DashboardQueryStats)
+	for _, panel := range s.SlowestPanels(orgID, sortBy, 0) {
+		dashboard, ok := dashboards[panel.DashboardID]
+		if !ok {
+			dashboard = &DashboardQueryStats{DashboardID: panel.DashboardID}
+			dashboards[panel.DashboardID] = dashboard
+		}
+		dashboard.Panels++
+		dashboard.Queries += panel.Queries
+		dashboard.total += panel.total
+		if panel.MaxDurationMs > dashboard.MaxDurationMs {
+			dashboard.MaxDurationMs = panel.MaxDurationMs
+		}
+		dashboard.SlowQueries += panel.SlowQueries
+		dashboard.BudgetExceeded += panel.BudgetExceeded
+		if panel.LastQueryAt.After(dashboard.LastQueryAt) {
+			dashboard.LastQueryAt = panel.LastQueryAt
+		}
+	}
+
+	result := make([]*DashboardQueryStats, 0, len(dashboards))
+	for _, dashboard := range dashboards {
+		dashboard.AvgDurationMs = milliseconds(dashboard.total / time.Duration(dashboard.Queries))
+		result = append(result, dashboard)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].slowerThan(&result[j].QueryDurations, sortBy)
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+func (s *QueryStatsService) prune(before time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for orgID, panels := range s.panels {
+		for key, stats := range panels {
+			if stats.lastQueryAt.Before(before) {
+				delete(panels, key)
+			}
+		}
+		if len(panels) == 0 {
+			delete(s.panels, orgID)
+		}
+	}
+}
+
+func milliseconds(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
@@ -0,0 +1,62 @@
+package querystats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryStatsService(t *testing.T) {
+	origThreshold := setting.QuerySlowThreshold
+	t.Cleanup(func() { setting.QuerySlowThreshold = origThreshold })
+	setting.QuerySlowThreshold = 5 * time.Second
+
+	s := &QueryStatsService{}
+	require.NoError(t, s.Init())
+	ds := &models.DataSource{Name: "prom", Type: "prometheus", Uid: "prom"}
+
+	s.RecordPanelQuery(1, 10, 1, ds, 1*time.Second, false)
+	s.RecordPanelQuery(1, 10, 1, ds, 3*time.Second, false)
+	s.RecordPanelQuery(1, 10, 2, ds, 6*time.Second, false)
+	s.RecordPanelQuery(1, 20, 1, ds, 4*time.Second, false)
+	s.RecordPanelQuery(1, 20, 1, ds, 12*time.Second, true)
+	s.RecordPanelQuery(2, 30, 1, ds, time.Minute, false)
+
+	t.Run("Should report the slowest panels of the organization", func(t *testing.T) {
+		panels := s.SlowestPanels(1, "", 0)
+		require.Len(t, panels, 3)
+		assert.Equal(t, int64(20), panels[0].DashboardID)
+		assert.Equal(t, int64(8000), panels[0].AvgDurationMs)
+		assert.Equal(t, int64(12000), panels[0].MaxDurationMs)
+		assert.Equal(t, int64(1), panels[0].SlowQueries)
+		assert.Equal(t, int64(1), panels[0].BudgetExceeded)
+		assert.Equal(t, int64(2), panels[2].Queries)
+		assert.Equal(t, int64(2000), panels[2].AvgDurationMs)
+
+		panels = s.SlowestPanels(1, "max", 1)
+		require.Len(t, panels, 1)
+		assert.Equal(t, int64(20), panels[0].DashboardID)
+	})
+
+	t.Run("Should report the slowest dashboards of the organization", func(t *testing.T) {
+		dashboards := s.SlowestDashboards(1, "", 0)
+		require.Len(t, dashboards, 2)
+		assert.Equal(t, int64(20), dashboards[0].DashboardID)
+		assert.Equal(t, int64(10), dashboards[1].DashboardID)
+		assert.Equal(t, 2, dashboards[1].Panels)
+		assert.Equal(t, int64(3), dashboards[1].Queries)
+		assert.Equal(t, int64(3333), dashboards[1].AvgDurationMs)
+		assert.Equal(t, int64(6000), dashboards[1].MaxDurationMs)
+		assert.Equal(t, int64(1), dashboards[1].SlowQueries)
+	})
+
+	t.Run("Should drop the panels which weren't queried lately", func(t *testing.T) {
+		s.prune(time.Now().Add(time.Minute))
+		assert.Empty(t, s.SlowestPanels(1, "", 0))
+		assert.Empty(t, s.SlowestDashboards(2, "", 0))
+	})
+}
@@ -2,11 +2,11 @@ package rendering
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
-	"net/url"
 	"os"
 	"strconv"
 	"time"
@@ -26,17 +26,61 @@ var netClient = &http.Client{
 	Transport: netTransport,
 }
 
+// errRendererUnreachable is returned when a remote rendering service cannot be reached, in
+// which case the next rendering service of the pool is tried.
+var errRendererUnreachable = errors.New("Failed to send request to remote rendering service")
+
+// checkRemoteHealth returns errRendererUnreachable when none of the remote rendering services
+// accepts connections.
+func (rs *RenderingService) checkRemoteHealth(ctx context.Context) error {
+	dialer := &net.Dialer{}
+	for _, instance := range rs.rendererPool.instances {
+		port := instance.url.Port()
+		if port == "" {
+			port = "80"
+			if instance.url.Scheme == "https" {
+				port = "443"
+			}
+		}
+
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(instance.url.Hostname(), port))
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		rs.log.Debug("Remote rendering service is unreachable", "url", instance.url.String(), "error", err)
+	}
+
+	return errRendererUnreachable
+}
+
 func (rs *RenderingService) renderViaHttp(ctx context.Context, renderKey string, opts Opts) (*RenderResult, error) {
-	filePath, err := rs.getFilePathForNewImage()
-	if err != nil {
-		return nil, err
+	// gives service some additional time to timeout and return possible errors.
+	reqContext, cancel := context.WithTimeout(ctx, opts.Timeout+time.Second*2)
+	defer cancel()
+
+	err := ErrNoRenderer
+	for _, instance := range rs.rendererPool.candidates() {
+		var result *RenderResult
+		result, err = rs.renderViaHttpInstance(reqContext, instance, renderKey, opts)
+		if !errors.Is(err, errRendererUnreachable) || reqContext.Err() != nil {
+			return result, err
+		}
 	}
 
-	rendererUrl, err := url.Parse(rs.Cfg.RendererUrl)
+	return nil, err
+}
+
+func (rs *RenderingService) renderViaHttpInstance(reqContext context.Context, instance *rendererInstance, renderKey string, opts Opts) (*RenderResult, error) {
+	rs.rendererPool.acquire(instance)
+	defer rs.rendererPool.release(instance)
+
+	filePath, err := rs.getFilePathForNewImage()
 	if err != nil {
 		return nil, err
 	}
 
+	rendererUrl := *instance.url
 	queryParams := rendererUrl.Query()
 	queryParams.Add("url", rs.getURL(opts.Path))
 	queryParams.Add("renderKey", renderKey)
@@ -60,19 +104,19 @@ func (rs *RenderingService) renderViaHttp(ctx context.Context, renderKey string,
 		req.Header[k] = v
 	}
 
-	// gives service some additional time to timeout and return possible errors.
-	reqContext, cancel := context.WithTimeout(ctx, opts.Timeout+time.Second*2)
-	defer cancel()
-
 	req = req.WithContext(reqContext)
 
-	rs.log.Debug("calling remote rendering service", "url", rendererUrl)
+	rs.log.Debug("calling remote rendering service", "url", instance.url)
 
 	// make request to renderer server
 	resp, err := netClient.Do(req)
 	if err != nil {
-		rs.log.Error("Failed to send request to remote rendering service.", "error", err)
-		return nil, fmt.Errorf("Failed to send request to remote rendering service. %s", err)
+		if reqContext.Err() == context.DeadlineExceeded {
+			rs.log.Info("Rendering timed out")
+			return nil, ErrTimeout
+		}
+		rs.log.Error("Failed to send request to remote rendering service.", "url", instance.url, "error", err)
+		return nil, fmt.Errorf("%w. %s", errRendererUnreachable, err)
 	}
 
 	// save response to file
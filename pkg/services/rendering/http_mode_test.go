@@ -0,0 +1,85 @@
+package rendering
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRendererPool(t *testing.T) {
+	t.Run("Should parse a comma-separated list of renderer URLs", func(t *testing.T) {
+		pool, err := newRendererPool("http://renderer1:8081/render, http://renderer2:8081/render,")
+		require.NoError(t, err)
+		require.Len(t, pool.instances, 2)
+		require.Equal(t, "renderer2:8081", pool.instances[1].url.Host)
+	})
+
+	t.Run("Should prefer the least busy instances and rotate idle ones", func(t *testing.T) {
+		pool, err := newRendererPool("http://renderer1,http://renderer2,http://renderer3")
+		require.NoError(t, err)
+
+		pool.acquire(pool.instances[0])
+		candidates := pool.candidates()
+		require.Equal(t, "renderer2", candidates[0].url.Host)
+		require.Equal(t, "renderer1", candidates[2].url.Host)
+
+		pool.release(pool.instances[0])
+		require.Equal(t, "renderer2", pool.candidates()[0].url.Host)
+		require.Equal(t, "renderer3", pool.candidates()[0].url.Host)
+		require.Equal(t, "renderer1", pool.candidates()[0].url.Host)
+	})
+}
+
+func TestRenderViaHttp(t *testing.T) {
+	renderer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "key", r.URL.Query().Get("renderKey"))
+		_, err := w.Write([]byte("image"))
+		require.NoError(t, err)
+	}))
+	defer renderer.Close()
+
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	imagesDir, err := ioutil.TempDir("", "rendering")
+	require.NoError(t, err)
+	defer os.RemoveAll(imagesDir)
+
+	cfg := setting.NewCfg()
+	cfg.ImagesDir = imagesDir
+
+	pool, err := newRendererPool(unreachable.URL + "/render," + renderer.URL + "/render")
+	require.NoError(t, err)
+	rs := &RenderingService{
+		Cfg:          cfg,
+		log:          log.New("test"),
+		rendererPool: pool,
+	}
+
+	t.Run("Should try the next renderer when a renderer cannot be reached", func(t *testing.T) {
+		result, err := rs.renderViaHttp(context.Background(), "key", Opts{Path: "d/test?orgId=1", Timeout: time.Second})
+		require.NoError(t, err)
+
+		image, err := ioutil.ReadFile(result.FilePath)
+		require.NoError(t, err)
+		require.Equal(t, "image", string(image))
+	})
+
+	t.Run("Should be healthy when a renderer can be reached", func(t *testing.T) {
+		cfg.RendererUrl = unreachable.URL + "/render," + renderer.URL + "/render"
+		require.NoError(t, rs.CheckHealth(context.Background()))
+
+		unreachablePool, err := newRendererPool(unreachable.URL + "/render")
+		require.NoError(t, err)
+		unhealthy := &RenderingService{Cfg: cfg, log: log.New("test"), rendererPool: unreachablePool}
+		require.Equal(t, errRendererUnreachable, unhealthy.CheckHealth(context.Background()))
+	})
+}
@@ -10,7 +10,6 @@ import (
 
 var ErrTimeout = errors.New("Timeout error. You can set timeout in seconds with &timeout url parameter")
 var ErrNoRenderer = errors.New("No renderer plugin found nor is an external render server configured")
-var ErrPhantomJSNotInstalled = errors.New("PhantomJS executable not found")
 
 type Opts struct {
 	Width             int
@@ -38,4 +37,6 @@ type Service interface {
 	Render(ctx context.Context, opts Opts) (*RenderResult, error)
 	RenderErrorImage(error error) (*RenderResult, error)
 	GetRenderUser(key string) (*RenderUser, bool)
+	// CheckHealth returns an error when rendering isn't possible, ErrNoRenderer when there's no renderer.
+	CheckHealth(ctx context.Context) error
 }
@@ -0,0 +1,67 @@
+package rendering
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
+)
+
+// renderLimiter limits the number of concurrent render requests. Requests over the limit
+// are queued until a render request completes or their queue timeout expires.
+type renderLimiter struct {
+	mu         sync.Mutex
+	inProgress int
+	released   chan struct{}
+}
+
+func newRenderLimiter() *renderLimiter {
+	return &renderLimiter{released: make(chan struct{})}
+}
+
+// acquire returns true once the render request can run without exceeding the limit, and
+// false if it couldn't run within the queue timeout.
+func (l *renderLimiter) acquire(ctx context.Context, limit int, queueTimeout time.Duration) bool {
+	var expired <-chan time.Time
+	if queueTimeout > 0 {
+		timer := time.NewTimer(queueTimeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		l.mu.Lock()
+		if l.inProgress < limit {
+			l.inProgress++
+			metrics.MRenderingQueue.Set(float64(l.inProgress))
+			l.mu.Unlock()
+			return true
+		}
+		released := l.released
+		l.mu.Unlock()
+
+		if queueTimeout <= 0 {
+			return false
+		}
+
+		select {
+		case <-released:
+		case <-expired:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// release completes a render request and wakes up the queued ones.
+func (l *renderLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inProgress--
+	metrics.MRenderingQueue.Set(float64(l.inProgress))
+	close(l.released)
+	l.released = make(chan struct{})
+}
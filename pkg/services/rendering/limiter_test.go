@@ -0,0 +1,57 @@
+package rendering
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should reject requests over the limit without queue timeout", func(t *testing.T) {
+		l := newRenderLimiter()
+		require.True(t, l.acquire(ctx, 2, 0))
+		require.True(t, l.acquire(ctx, 2, 0))
+		require.False(t, l.acquire(ctx, 2, 0))
+
+		l.release()
+		require.True(t, l.acquire(ctx, 2, 0))
+	})
+
+	t.Run("Should queue requests over the limit until a request completes", func(t *testing.T) {
+		l := newRenderLimiter()
+		require.True(t, l.acquire(ctx, 1, 0))
+
+		acquired := make(chan bool)
+		go func() {
+			acquired <- l.acquire(ctx, 1, time.Minute)
+		}()
+
+		select {
+		case <-acquired:
+			t.Fatal("queued request should wait for a request to complete")
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		l.release()
+		require.True(t, <-acquired)
+	})
+
+	t.Run("Should give up queued requests after the queue timeout", func(t *testing.T) {
+		l := newRenderLimiter()
+		require.True(t, l.acquire(ctx, 1, 0))
+		require.False(t, l.acquire(ctx, 1, 10*time.Millisecond))
+	})
+
+	t.Run("Should give up queued requests when their context is done", func(t *testing.T) {
+		l := newRenderLimiter()
+		require.True(t, l.acquire(ctx, 1, 0))
+
+		cCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		require.False(t, l.acquire(cCtx, 1, time.Minute))
+	})
+}
@@ -0,0 +1,75 @@
+package rendering
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// rendererInstance is a remote HTTP image renderer service.
+type rendererInstance struct {
+	url        *url.URL
+	inProgress int
+}
+
+// rendererPool spreads the render requests over the remote HTTP image renderer services,
+// preferring the instances with the fewest requests in progress.
+type rendererPool struct {
+	mu        sync.Mutex
+	instances []*rendererInstance
+	next      int
+}
+
+// newRendererPool returns the pool of a comma-separated list of renderer service URLs.
+func newRendererPool(serverURLs string) (*rendererPool, error) {
+	pool := &rendererPool{}
+	for _, serverURL := range strings.Split(serverURLs, ",") {
+		serverURL = strings.TrimSpace(serverURL)
+		if serverURL == "" {
+			continue
+		}
+
+		u, err := url.Parse(serverURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid renderer server_url %q: %w", serverURL, err)
+		}
+		pool.instances = append(pool.instances, &rendererInstance{url: u})
+	}
+
+	return pool, nil
+}
+
+// candidates returns the instances ordered from the least to the most busy, the first one
+// being tried first and the others being tried in turn when it cannot be reached.
+func (p *rendererPool) candidates() []*rendererInstance {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.instances) == 0 {
+		return nil
+	}
+
+	// rotate the instances so that idle instances take turns
+	candidates := append([]*rendererInstance{}, p.instances[p.next:]...)
+	candidates = append(candidates, p.instances[:p.next]...)
+	p.next = (p.next + 1) % len(p.instances)
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].inProgress < candidates[j].inProgress
+	})
+	return candidates
+}
+
+func (p *rendererPool) acquire(instance *rendererInstance) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	instance.inProgress++
+}
+
+func (p *rendererPool) release(instance *rendererInstance) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	instance.inProgress--
+}
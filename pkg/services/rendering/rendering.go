@@ -39,11 +39,12 @@ type RenderUser struct {
 }
 
 type RenderingService struct {
-	log             log.Logger
-	pluginInfo      *plugins.RendererPlugin
-	renderAction    renderFunc
-	domain          string
-	inProgressCount int
+	log          log.Logger
+	pluginInfo   *plugins.RendererPlugin
+	renderAction renderFunc
+	domain       string
+	limiter      *renderLimiter
+	rendererPool *rendererPool
 
 	Cfg                *setting.Cfg             `inject:""`
 	RemoteCacheService *remotecache.RemoteCache `inject:""`
@@ -58,8 +59,18 @@ func (rs *RenderingService) Init() error {
 		return err
 	}
 
+	rs.limiter = newRenderLimiter()
+
 	// set value used for domain attribute of renderKey cookie
 	if rs.Cfg.RendererUrl != "" {
+		rs.rendererPool, err = newRendererPool(rs.Cfg.RendererUrl)
+		if err != nil {
+			return err
+		}
+		if len(rs.rendererPool.instances) == 0 {
+			return fmt.Errorf("invalid renderer server_url %q", rs.Cfg.RendererUrl)
+		}
+
 		// RendererCallbackUrl has already been passed, it won't generate an error.
 		u, _ := url.Parse(rs.Cfg.RendererCallbackUrl)
 		rs.domain = u.Hostname()
@@ -75,7 +86,7 @@ func (rs *RenderingService) Init() error {
 func (rs *RenderingService) Run(ctx context.Context) error {
 	if rs.remoteAvailable() {
 		rs.log = rs.log.New("renderer", "http")
-		rs.log.Info("Backend rendering via external http server")
+		rs.log.Info("Backend rendering via external http server", "instances", len(rs.rendererPool.instances))
 		rs.renderAction = rs.renderViaHttp
 		<-ctx.Done()
 		return nil
@@ -114,6 +125,19 @@ func (rs *RenderingService) IsAvailable() bool {
 	return rs.remoteAvailable() || rs.pluginAvailable()
 }
 
+func (rs *RenderingService) CheckHealth(ctx context.Context) error {
+	if rs.remoteAvailable() {
+		return rs.checkRemoteHealth(ctx)
+	}
+
+	// the process of the renderer plugin is checked along with the other backend plugins
+	if rs.pluginAvailable() {
+		return nil
+	}
+
+	return ErrNoRenderer
+}
+
 func (rs *RenderingService) RenderErrorImage(err error) (*RenderResult, error) {
 	imgUrl := "public/img/rendering_error.png"
 
@@ -148,12 +172,6 @@ func (rs *RenderingService) Render(ctx context.Context, opts Opts) (*RenderResul
 }
 
 func (rs *RenderingService) render(ctx context.Context, opts Opts) (*RenderResult, error) {
-	if rs.inProgressCount > opts.ConcurrentLimit {
-		return &RenderResult{
-			FilePath: filepath.Join(setting.HomePath, "public/img/rendering_limit.png"),
-		}, nil
-	}
-
 	if !rs.IsAvailable() {
 		rs.log.Warn("Could not render image, no image renderer found/installed. " +
 			"For image rendering support please install the grafana-image-renderer plugin. " +
@@ -161,6 +179,14 @@ func (rs *RenderingService) render(ctx context.Context, opts Opts) (*RenderResul
 		return rs.renderUnavailableImage(), nil
 	}
 
+	if !rs.limiter.acquire(ctx, opts.ConcurrentLimit, rs.Cfg.RendererQueueTimeout) {
+		rs.log.Warn("Could not render image, too many concurrent render requests", "limit", opts.ConcurrentLimit)
+		return &RenderResult{
+			FilePath: filepath.Join(setting.HomePath, "public/img/rendering_limit.png"),
+		}, nil
+	}
+	defer rs.limiter.release()
+
 	rs.log.Info("Rendering", "path", opts.Path)
 	if math.IsInf(opts.DeviceScaleFactor, 0) || math.IsNaN(opts.DeviceScaleFactor) || opts.DeviceScaleFactor <= 0 {
 		opts.DeviceScaleFactor = 1
@@ -172,13 +198,6 @@ func (rs *RenderingService) render(ctx context.Context, opts Opts) (*RenderResul
 
 	defer rs.deleteRenderKey(renderKey)
 
-	defer func() {
-		rs.inProgressCount--
-		metrics.MRenderingQueue.Set(float64(rs.inProgressCount))
-	}()
-
-	rs.inProgressCount++
-	metrics.MRenderingQueue.Set(float64(rs.inProgressCount))
 	return rs.renderAction(ctx, renderKey, opts)
 }
 
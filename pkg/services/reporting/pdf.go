@@ -0,0 +1,74 @@
+package reporting
+
+import (
+	"bytes"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/jung-kurt/gofpdf"
+)
+
+const pdfMargin = 10.0
+
+// renderedDashboard is a rendered image of a dashboard of a report
+type renderedDashboard struct {
+	Title     string
+	Url       string
+	TimeRange string
+	FilePath  string
+}
+
+// buildPdf returns an A4 document with a page per dashboard. Every page has the title and
+// time range of the dashboard above the image, which links to the dashboard.
+func buildPdf(report *models.Report, dashboards []*renderedDashboard) ([]byte, error) {
+	orientation := "L"
+	if report.Orientation == models.ReportOrientationPortrait {
+		orientation = "P"
+	}
+
+	pdf := gofpdf.New(orientation, "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(false, pdfMargin)
+
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle(report.Name, true)
+	pdf.SetCreator("Grafana", false)
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	imageOptions := gofpdf.ImageOptions{ImageType: "PNG"}
+
+	for _, dashboard := range dashboards {
+		pdf.AddPage()
+
+		pdf.SetFont("Helvetica", "B", 14)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.CellFormat(0, 8, tr(dashboard.Title), "", 1, "L", false, 0, dashboard.Url)
+
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetTextColor(110, 110, 110)
+		pdf.CellFormat(0, 5, tr(dashboard.TimeRange), "", 1, "L", false, 0, "")
+
+		info := pdf.RegisterImageOptions(dashboard.FilePath, imageOptions)
+		if pdf.Err() {
+			break
+		}
+
+		// fit the image in the rest of the page, keeping its aspect ratio
+		top := pdf.GetY() + 2
+		maxWidth := pageWidth - 2*pdfMargin
+		maxHeight := pageHeight - top - pdfMargin
+
+		width, height := maxWidth, maxWidth*info.Height()/info.Width()
+		if height > maxHeight {
+			width, height = maxHeight*info.Width()/info.Height(), maxHeight
+		}
+
+		pdf.ImageOptions(dashboard.FilePath, pdfMargin+(maxWidth-width)/2, top, width, height, false, imageOptions, 0, dashboard.Url)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,322 @@
+package reporting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/scheduler"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+var (
+	ErrInvalidFormat      = errors.New("Invalid report format, use pdf or png")
+	ErrInvalidOrientation = errors.New("Invalid report orientation, use landscape or portrait")
+	ErrInvalidRecipients  = errors.New("Report recipients must be a list of email addresses")
+	ErrInvalidTimeRange   = errors.New("Invalid report time range")
+)
+
+const (
+	emailTemplate   = "report"
+	renderTimeout   = time.Minute
+	timeRangeFormat = "2006-01-02 15:04"
+)
+
+var attachmentNameReplacer = strings.NewReplacer("/", "-", "\\", "-", ":", "-", "*", "-", "?", "-", "\"", "'", "<", "(", ">", ")", "|", "-")
+
+func init() {
+	registry.RegisterService(&ReportingService{})
+}
+
+// ReportingService renders the dashboards of reports on their schedule and emails them
+type ReportingService struct {
+	log              log.Logger
+	Cfg              *setting.Cfg                `inject:""`
+	RenderService    rendering.Service           `inject:""`
+	SchedulerService *scheduler.SchedulerService `inject:""`
+}
+
+func (srv *ReportingService) Init() error {
+	srv.log = log.New("reporting")
+
+	srv.SchedulerService.Schedule("send scheduled reports", time.Minute, func(ctx context.Context) error {
+		srv.sendDueReports(ctx, time.Now())
+		return nil
+	})
+	return nil
+}
+
+// sendDueReports sends the reports that are due. The reports are claimed by setting when they
+// run next before they are sent, so other servers do not send them while they are rendered.
+func (srv *ReportingService) sendDueReports(ctx context.Context, now time.Time) {
+	query := models.GetDueReportsQuery{Now: now.Unix()}
+	if err := bus.Dispatch(&query); err != nil {
+		srv.log.Error("Failed to get scheduled reports", "error", err)
+		return
+	}
+
+	claimed := make([]*models.UpdateReportRunCommand, 0, len(query.Result))
+	reports := make([]*models.Report, 0, len(query.Result))
+
+	for _, report := range query.Result {
+		next, err := NextRun(report.Schedule, report.Timezone, now)
+		if err != nil {
+			srv.log.Error("Invalid report schedule", "reportId", report.Id, "error", err)
+			next = now.Add(24 * time.Hour)
+		}
+
+		cmd := &models.UpdateReportRunCommand{Id: report.Id, NextRunAt: next.Unix()}
+		if err := bus.Dispatch(cmd); err != nil {
+			srv.log.Error("Failed to claim report", "reportId", report.Id, "error", err)
+			continue
+		}
+
+		claimed = append(claimed, cmd)
+		reports = append(reports, report)
+	}
+
+	for i, report := range reports {
+		cmd := claimed[i]
+		cmd.RunAt = now.Unix()
+
+		srv.log.Debug("Sending scheduled report", "reportId", report.Id, "orgId", report.OrgId)
+		if err := srv.SendReport(ctx, report); err != nil {
+			srv.log.Error("Failed to send scheduled report", "reportId", report.Id, "orgId", report.OrgId, "error", err)
+			cmd.Error = err.Error()
+		}
+
+		if err := bus.Dispatch(cmd); err != nil {
+			srv.log.Error("Failed to save report run", "reportId", report.Id, "error", err)
+		}
+	}
+}
+
+// SendReport renders the dashboards of the report as the user that owns it and emails
+// them to the recipients
+func (srv *ReportingService) SendReport(ctx context.Context, report *models.Report) error {
+	userQuery := models.GetSignedInUserQuery{UserId: report.UserId, OrgId: report.OrgId}
+	if err := bus.Dispatch(&userQuery); err != nil {
+		return errutil.Wrap("Failed to get the owner of the report", err)
+	}
+
+	location, err := LoadLocation(report.Timezone)
+	if err != nil {
+		return err
+	}
+
+	dashboards := make([]*renderedDashboard, 0, len(report.Dashboards))
+	for _, uid := range report.Dashboards {
+		dashboard, err := srv.renderDashboard(ctx, report, userQuery.Result, uid, location)
+		if err != nil {
+			return err
+		}
+		dashboards = append(dashboards, dashboard)
+	}
+
+	attachments, err := buildAttachments(report, dashboards)
+	if err != nil {
+		return errutil.Wrap("Failed to build report", err)
+	}
+
+	links := make([]map[string]string, len(dashboards))
+	for i, dashboard := range dashboards {
+		links[i] = map[string]string{
+			"Title":     dashboard.Title,
+			"Url":       dashboard.Url,
+			"TimeRange": dashboard.TimeRange,
+		}
+	}
+
+	return bus.DispatchCtx(ctx, &models.SendEmailCommandSync{
+		SendEmailCommand: models.SendEmailCommand{
+			To:       splitEmails(report.Recipients),
+			ReplyTo:  splitEmails(report.ReplyTo),
+			Template: emailTemplate,
+			Data: map[string]interface{}{
+				"Name":       report.Name,
+				"Message":    report.Message,
+				"Dashboards": links,
+			},
+			AttachedFiles: attachments,
+		},
+	})
+}
+
+func (srv *ReportingService) renderDashboard(ctx context.Context, report *models.Report, user *models.SignedInUser, uid string, location *time.Location) (*renderedDashboard, error) {
+	query := models.GetDashboardQuery{Uid: uid, OrgId: report.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, errutil.Wrapf(err, "Failed to get dashboard %s", uid)
+	}
+	dash := query.Result
+
+	canView, err := guardian.New(dash.Id, report.OrgId, user).CanView()
+	if err != nil {
+		return nil, err
+	}
+	if !canView {
+		return nil, fmt.Errorf("The owner of the report cannot view dashboard %s", dash.Title)
+	}
+
+	from, to := report.TimeFrom, report.TimeTo
+	if from == "" {
+		from = dash.Data.GetPath("time", "from").MustString("now-6h")
+	}
+	if to == "" {
+		to = dash.Data.GetPath("time", "to").MustString("now")
+	}
+
+	fromTime, toTime, err := parseTimeRange(from, to, location)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("orgId", fmt.Sprint(report.OrgId))
+	params.Set("from", fmt.Sprint(fromTime.UnixNano()/int64(time.Millisecond)))
+	params.Set("to", fmt.Sprint(toTime.UnixNano()/int64(time.Millisecond)))
+	path := fmt.Sprintf("d/%s/%s?%s", dash.Uid, dash.Slug, params.Encode())
+
+	// A4 proportions
+	width, height := 1500, 1060
+	if report.Orientation == models.ReportOrientationPortrait {
+		width, height = height, width
+	}
+
+	result, err := srv.RenderService.Render(ctx, rendering.Opts{
+		Width:           width,
+		Height:          height,
+		Timeout:         renderTimeout,
+		OrgId:           report.OrgId,
+		UserId:          user.UserId,
+		OrgRole:         user.OrgRole,
+		Path:            path + "&kiosk",
+		Timezone:        location.String(),
+		ConcurrentLimit: srv.Cfg.RendererConcurrentRequestLimit,
+	})
+	if err != nil {
+		return nil, errutil.Wrapf(err, "Failed to render dashboard %s", dash.Title)
+	}
+
+	return &renderedDashboard{
+		Title:     dash.Title,
+		Url:       setting.ToAbsUrl(path),
+		TimeRange: fmt.Sprintf("%s to %s %s", fromTime.In(location).Format(timeRangeFormat), toTime.In(location).Format(timeRangeFormat), location),
+		FilePath:  result.FilePath,
+	}, nil
+}
+
+func buildAttachments(report *models.Report, dashboards []*renderedDashboard) ([]*models.SendEmailAttachFile, error) {
+	if report.Format == models.ReportFormatPng {
+		files := make([]*models.SendEmailAttachFile, 0, len(dashboards))
+		for i, dashboard := range dashboards {
+			content, err := ioutil.ReadFile(dashboard.FilePath)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, &models.SendEmailAttachFile{
+				Name:    fmt.Sprintf("%d - %s.png", i+1, attachmentNameReplacer.Replace(dashboard.Title)),
+				Content: content,
+			})
+		}
+		return files, nil
+	}
+
+	content, err := buildPdf(report, dashboards)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*models.SendEmailAttachFile{
+		{Name: attachmentNameReplacer.Replace(report.Name) + ".pdf", Content: content},
+	}, nil
+}
+
+// ValidateSettings sets the defaults of the report settings, checks them and returns
+// when the report runs next after now
+func ValidateSettings(settings *models.ReportSettings, now time.Time) (time.Time, error) {
+	if settings.Format == "" {
+		settings.Format = models.ReportFormatPdf
+	}
+	if settings.Format != models.ReportFormatPdf && settings.Format != models.ReportFormatPng {
+		return time.Time{}, ErrInvalidFormat
+	}
+
+	if settings.Orientation == "" {
+		settings.Orientation = models.ReportOrientationLandscape
+	}
+	if settings.Orientation != models.ReportOrientationLandscape && settings.Orientation != models.ReportOrientationPortrait {
+		return time.Time{}, ErrInvalidOrientation
+	}
+
+	for _, addresses := range []string{settings.Recipients, settings.ReplyTo} {
+		for _, address := range splitEmails(addresses) {
+			if _, err := mail.ParseAddress(address); err != nil {
+				return time.Time{}, ErrInvalidRecipients
+			}
+		}
+	}
+	if len(splitEmails(settings.Recipients)) == 0 {
+		return time.Time{}, ErrInvalidRecipients
+	}
+
+	next, err := NextRun(settings.Schedule, settings.Timezone, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if settings.TimeFrom != "" || settings.TimeTo != "" {
+		location, _ := LoadLocation(settings.Timezone)
+		if _, _, err := parseTimeRange(settings.TimeFrom, settings.TimeTo, location); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	return next, nil
+}
+
+func parseTimeRange(from string, to string, location *time.Location) (time.Time, time.Time, error) {
+	if from == "" {
+		from = "now-6h"
+	}
+	if to == "" {
+		to = "now"
+	}
+
+	timeRange := tsdb.NewTimeRange(from, to)
+
+	fromTime, err := timeRange.ParseFromWithLocation(location)
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrInvalidTimeRange
+	}
+
+	toTime, err := timeRange.ParseToWithLocation(location)
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrInvalidTimeRange
+	}
+
+	return fromTime, toTime, nil
+}
+
+func splitEmails(emails string) []string {
+	result := make([]string, 0)
+	for _, email := range util.SplitEmails(emails) {
+		if email = strings.TrimSpace(email); email != "" {
+			result = append(result, email)
+		}
+	}
+	return result
+}
@@ -0,0 +1,127 @@
+package reporting
+
+import (
+	"errors"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestNextRun(t *testing.T) {
+	now := time.Date(2020, 6, 1, 10, 30, 0, 0, time.UTC)
+
+	t.Run("Evaluates the schedule in the timezone", func(t *testing.T) {
+		next, err := NextRun("0 8 * * *", "Europe/Stockholm", now)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2020, 6, 2, 6, 0, 0, 0, time.UTC), next.UTC())
+
+		next, err = NextRun("0 8 * * *", "", now)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2020, 6, 2, 8, 0, 0, 0, time.UTC), next.UTC())
+	})
+
+	t.Run("Supports descriptors", func(t *testing.T) {
+		next, err := NextRun("@weekly", "UTC", now)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2020, 6, 7, 0, 0, 0, 0, time.UTC), next)
+	})
+
+	t.Run("Rejects invalid schedules and timezones", func(t *testing.T) {
+		_, err := NextRun("0 8 * *", "UTC", now)
+		require.True(t, errors.Is(err, ErrInvalidSchedule))
+
+		_, err = NextRun("CRON_TZ=Europe/Stockholm 0 8 * * *", "UTC", now)
+		require.True(t, errors.Is(err, ErrInvalidSchedule))
+
+		_, err = NextRun("0 8 * * *", "Mars/Olympus", now)
+		require.Equal(t, ErrInvalidTimezone, err)
+	})
+}
+
+func TestValidateSettings(t *testing.T) {
+	now := time.Date(2020, 6, 1, 10, 30, 0, 0, time.UTC)
+
+	valid := func() *models.ReportSettings {
+		return &models.ReportSettings{
+			Name:       "Weekly",
+			Dashboards: []string{"abc"},
+			Schedule:   "0 8 * * 1",
+			Recipients: "ops@example.com; dev@example.com",
+		}
+	}
+
+	t.Run("Sets defaults", func(t *testing.T) {
+		settings := valid()
+		next, err := ValidateSettings(settings, now)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2020, 6, 8, 8, 0, 0, 0, time.UTC), next)
+		require.Equal(t, models.ReportFormatPdf, settings.Format)
+		require.Equal(t, models.ReportOrientationLandscape, settings.Orientation)
+	})
+
+	t.Run("Rejects invalid settings", func(t *testing.T) {
+		tests := []struct {
+			update func(settings *models.ReportSettings)
+			err    error
+		}{
+			{func(s *models.ReportSettings) { s.Format = "docx" }, ErrInvalidFormat},
+			{func(s *models.ReportSettings) { s.Orientation = "square" }, ErrInvalidOrientation},
+			{func(s *models.ReportSettings) { s.Recipients = " ; " }, ErrInvalidRecipients},
+			{func(s *models.ReportSettings) { s.Recipients = "ops" }, ErrInvalidRecipients},
+			{func(s *models.ReportSettings) { s.ReplyTo = "not an address" }, ErrInvalidRecipients},
+			{func(s *models.ReportSettings) { s.TimeFrom = "yesterday-ish" }, ErrInvalidTimeRange},
+		}
+
+		for _, test := range tests {
+			settings := valid()
+			test.update(settings)
+			_, err := ValidateSettings(settings, now)
+			require.Equal(t, test.err, err)
+		}
+	})
+}
+
+func TestBuildAttachments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reporting")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	imagePath := filepath.Join(dir, "dashboard.png")
+	file, err := os.Create(imagePath)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(file, image.NewRGBA(image.Rect(0, 0, 150, 106))))
+	require.NoError(t, file.Close())
+
+	dashboards := []*renderedDashboard{
+		{Title: "Requests / Errors", Url: "http://localhost:3000/d/abc/requests", TimeRange: "2020-06-01 00:00 to 2020-06-02 00:00 UTC", FilePath: imagePath},
+		{Title: "Latency", Url: "http://localhost:3000/d/def/latency", TimeRange: "2020-06-01 00:00 to 2020-06-02 00:00 UTC", FilePath: imagePath},
+	}
+
+	t.Run("Builds a pdf with a page per dashboard", func(t *testing.T) {
+		for _, orientation := range []string{models.ReportOrientationLandscape, models.ReportOrientationPortrait} {
+			report := &models.Report{Name: "Daily: Ops", Format: models.ReportFormatPdf, Orientation: orientation}
+			files, err := buildAttachments(report, dashboards)
+			require.NoError(t, err)
+			require.Len(t, files, 1)
+			require.Equal(t, "Daily- Ops.pdf", files[0].Name)
+			require.Equal(t, "%PDF", string(files[0].Content[:4]))
+		}
+	})
+
+	t.Run("Attaches an image per dashboard", func(t *testing.T) {
+		report := &models.Report{Name: "Daily", Format: models.ReportFormatPng}
+		files, err := buildAttachments(report, dashboards)
+		require.NoError(t, err)
+		require.Len(t, files, 2)
+		require.Equal(t, "1 - Requests - Errors.png", files[0].Name)
+		require.Equal(t, "2 - Latency.png", files[1].Name)
+	})
+}
@@ -0,0 +1,53 @@
+package reporting
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	ErrInvalidSchedule = errors.New("Invalid report schedule")
+	ErrInvalidTimezone = errors.New("Invalid report timezone")
+)
+
+// LoadLocation returns the location of a report timezone, the empty timezone is UTC
+func LoadLocation(timezone string) (*time.Location, error) {
+	if timezone == "" || strings.ToLower(timezone) == "utc" {
+		return time.UTC, nil
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return location, nil
+}
+
+// NextRun returns when a report with the schedule runs next after t. The schedule is
+// a cron expression with five fields or a descriptor like @daily, evaluated in timezone.
+func NextRun(schedule string, timezone string, t time.Time) (time.Time, error) {
+	location, err := LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if strings.HasPrefix(schedule, "TZ=") || strings.HasPrefix(schedule, "CRON_TZ=") {
+		return time.Time{}, fmt.Errorf("%w: use the timezone of the report", ErrInvalidSchedule)
+	}
+
+	parsed, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidSchedule, err)
+	}
+
+	next := parsed.Next(t.In(location))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("%w: the schedule never runs", ErrInvalidSchedule)
+	}
+
+	return next, nil
+}
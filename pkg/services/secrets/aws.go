@@ -0,0 +1,54 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+// awsProvider reads secrets from AWS Secrets Manager with the default credentials of the
+// Grafana server. Paths are the id of a secret, followed by the key of the value for secrets
+// stored as JSON, e.g. prod/db#password.
+type awsProvider struct {
+	client *secretsmanager.SecretsManager
+}
+
+func newAWSProvider(region string) (*awsProvider, error) {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return nil, err
+	}
+
+	return &awsProvider{client: secretsmanager.New(sess)}, nil
+}
+
+func (p *awsProvider) GetSecret(ctx context.Context, path string) (string, error) {
+	parts := strings.SplitN(path, "#", 2)
+
+	output, err := p.client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(parts[0]),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	secret := aws.StringValue(output.SecretString)
+	if len(parts) == 1 {
+		return secret, nil
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal([]byte(secret), &values); err != nil {
+		return "", err
+	}
+
+	value, ok := values[parts[1]]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
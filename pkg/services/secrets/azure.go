@@ -0,0 +1,63 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const azureKeyVaultAPIVersion = "7.0"
+
+// azureProvider reads secrets from Azure Key Vault with the client credentials of an Azure AD
+// application. Paths are the name of a secret, optionally followed by its version, e.g. db-password.
+type azureProvider struct {
+	vaultUrl string
+	client   *http.Client
+}
+
+func newAzureProvider(vaultUrl string, tenantId string, clientId string, clientSecret string) *azureProvider {
+	credentials := clientcredentials.Config{
+		ClientID:     clientId,
+		ClientSecret: clientSecret,
+		TokenURL:     fmt.Sprintf("https://login.microsoftonline.com/%s/oauth2/v2.0/token", tenantId),
+		Scopes:       []string{"https://vault.azure.net/.default"},
+	}
+
+	return &azureProvider{
+		vaultUrl: strings.TrimSuffix(vaultUrl, "/"),
+		client:   credentials.Client(context.Background()),
+	}
+}
+
+func (p *azureProvider) GetSecret(ctx context.Context, path string) (string, error) {
+	secretUrl := fmt.Sprintf("%s/secrets/%s?api-version=%s", p.vaultUrl, strings.TrimPrefix(path, "/"), azureKeyVaultAPIVersion)
+	req, err := http.NewRequest(http.MethodGet, secretUrl, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", ErrSecretNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Azure Key Vault request failed: %s", resp.Status)
+	}
+
+	var secret struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", err
+	}
+	return secret.Value, nil
+}
@@ -0,0 +1,152 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/securejsondata"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var (
+	// ErrSecretNotFound error returned when a referenced secret doesn't exist.
+	ErrSecretNotFound = errors.New("Secret not found")
+	// ErrSecretNotAllowed error returned when an org references a secret outside of its allowed prefixes.
+	ErrSecretNotAllowed = errors.New("Secret not allowed for the organization")
+)
+
+const fetchTimeout = 10 * time.Second
+
+// referenceRegex matches the references to secrets, e.g. $__vault{secret/data/db#password}.
+var referenceRegex = regexp.MustCompile(`^\$__(\w+){([^}]+)}$`)
+
+func init() {
+	registry.Register(&registry.Descriptor{
+		Name:         "SecretsService",
+		Instance:     &SecretsService{},
+		InitPriority: registry.High,
+	})
+}
+
+// Provider is a backend storing secrets outside of the Grafana database.
+type Provider interface {
+	// GetSecret returns the secret stored at a path of the backend.
+	GetSecret(ctx context.Context, path string) (string, error)
+}
+
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+// SecretsService resolves the values of SecureJsonData referencing a secret stored in a
+// secrets backend. Secrets are cached for a limited time, so that rotated secrets are picked
+// up without restarting Grafana.
+type SecretsService struct {
+	Cfg *setting.Cfg `inject:""`
+
+	log       log.Logger
+	providers map[string]Provider
+
+	cacheMu sync.Mutex
+	cache   map[string]cachedSecret
+}
+
+func (s *SecretsService) Init() error {
+	s.log = log.New("secrets")
+	s.providers = make(map[string]Provider)
+	s.cache = make(map[string]cachedSecret)
+
+	if !s.Cfg.Secrets.Enabled {
+		return nil
+	}
+
+	settings := s.Cfg.Secrets
+	if settings.VaultUrl != "" {
+		s.providers["vault"] = newVaultProvider(settings.VaultUrl, settings.VaultToken)
+	}
+	if settings.AWSRegion != "" {
+		provider, err := newAWSProvider(settings.AWSRegion)
+		if err != nil {
+			return err
+		}
+		s.providers["aws"] = provider
+	}
+	if settings.AzureVaultUrl != "" {
+		s.providers["azure"] = newAzureProvider(settings.AzureVaultUrl, settings.AzureTenantId, settings.AzureClientId, settings.AzureClientSecret)
+	}
+
+	securejsondata.SetReferenceResolver(s.Resolve)
+	return nil
+}
+
+// Resolve returns the secret referenced by a value of an org, and false if the value isn't a reference to a
+// secret of a configured backend. Unless the setting is provisioned, the secret must be under one of the
+// prefixes allowed for the org. An error is returned when the secret cannot be fetched, unless a previous
+// value of the secret is cached.
+func (s *SecretsService) Resolve(orgID int64, provisioned bool, value string) (string, bool, error) {
+	match := referenceRegex.FindStringSubmatch(value)
+	if match == nil {
+		return "", false, nil
+	}
+
+	backend, path := match[1], match[2]
+	provider, exists := s.providers[backend]
+	if !exists {
+		return "", false, nil
+	}
+
+	if !provisioned && !s.isAllowed(orgID, backend, path) {
+		return "", true, fmt.Errorf("%w: %s", ErrSecretNotAllowed, value)
+	}
+
+	s.cacheMu.Lock()
+	cached, isCached := s.cache[value]
+	s.cacheMu.Unlock()
+	if isCached && time.Now().Before(cached.expires) {
+		return cached.value, true, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	secret, err := provider.GetSecret(ctx, path)
+	if err != nil {
+		if isCached {
+			s.log.Warn("Failed to refresh secret, using the previous value", "backend", backend, "path", path, "error", err)
+			return cached.value, true, nil
+		}
+
+		s.log.Error("Failed to get secret", "backend", backend, "path", path, "error", err)
+		return "", true, fmt.Errorf("failed to get secret %s: %w", value, err)
+	}
+
+	s.cacheMu.Lock()
+	s.cache[value] = cachedSecret{value: secret, expires: time.Now().Add(s.Cfg.Secrets.CacheTTL)}
+	s.cacheMu.Unlock()
+
+	return secret, true, nil
+}
+
+// isAllowed returns whether an org can reference the secret at a path of a backend. The paths that could
+// leave their prefix once in the URL of the backend, with dot segments, escapes or a query, are never
+// allowed.
+func (s *SecretsService) isAllowed(orgID int64, backend string, path string) bool {
+	path = strings.TrimPrefix(path, "/")
+	if strings.Contains(path, "..") || strings.ContainsAny(path, "%?\\") {
+		return false
+	}
+
+	for _, allowed := range s.Cfg.Secrets.AllowedPrefixes[orgID] {
+		if allowed.Backend == backend && strings.HasPrefix(path, strings.TrimPrefix(allowed.Prefix, "/")) {
+			return true
+		}
+	}
+	return false
+}
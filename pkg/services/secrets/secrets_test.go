@@ -0,0 +1,151 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretsService(t *testing.T) {
+	provider := &fakeProvider{secrets: map[string]string{"db#password": "secret", "other/db#password": "other"}}
+	cfg := setting.NewCfg()
+	cfg.Secrets.CacheTTL = time.Minute
+	cfg.Secrets.AllowedPrefixes = map[int64][]setting.SecretsAllowedPrefix{
+		1: {{Backend: "fake", Prefix: "db"}},
+	}
+	s := &SecretsService{
+		Cfg:       cfg,
+		log:       log.New("test"),
+		providers: map[string]Provider{"fake": provider},
+		cache:     make(map[string]cachedSecret),
+	}
+
+	t.Run("Should not resolve values that are not references", func(t *testing.T) {
+		for _, value := range []string{"password", "$__fake{db#password} ", "$__unknown{db#password}", "${db#password}"} {
+			_, isReference, err := s.Resolve(1, false, value)
+			require.NoError(t, err)
+			require.False(t, isReference, value)
+		}
+	})
+
+	t.Run("Should resolve and cache references", func(t *testing.T) {
+		secret, isReference, err := s.Resolve(1, false, "$__fake{db#password}")
+		require.NoError(t, err)
+		require.True(t, isReference)
+		require.Equal(t, "secret", secret)
+
+		provider.secrets["db#password"] = "rotated"
+		secret, _, err = s.Resolve(1, false, "$__fake{db#password}")
+		require.NoError(t, err)
+		require.Equal(t, "secret", secret)
+		require.Equal(t, 1, provider.calls)
+	})
+
+	t.Run("Should refuse references outside of the prefixes allowed for the org", func(t *testing.T) {
+		for _, tc := range []struct {
+			orgID int64
+			value string
+		}{
+			{orgID: 2, value: "$__fake{db#password}"},
+			{orgID: 1, value: "$__fake{other/db#password}"},
+			{orgID: 1, value: "$__fake{db/../other/db#password}"},
+			{orgID: 1, value: "$__fake{db%2F..%2Fother/db#password}"},
+		} {
+			_, isReference, err := s.Resolve(tc.orgID, false, tc.value)
+			require.True(t, isReference, tc.value)
+			require.True(t, errors.Is(err, ErrSecretNotAllowed), tc.value)
+		}
+	})
+
+	t.Run("Should resolve references of provisioned settings outside of the allowed prefixes", func(t *testing.T) {
+		secret, _, err := s.Resolve(2, true, "$__fake{other/db#password}")
+		require.NoError(t, err)
+		require.Equal(t, "other", secret)
+	})
+
+	t.Run("Should refresh rotated secrets once expired", func(t *testing.T) {
+		s.cache["$__fake{db#password}"] = cachedSecret{value: "secret", expires: time.Now().Add(-time.Second)}
+
+		secret, _, err := s.Resolve(1, false, "$__fake{db#password}")
+		require.NoError(t, err)
+		require.Equal(t, "rotated", secret)
+	})
+
+	t.Run("Should keep using expired secrets when they cannot be refreshed", func(t *testing.T) {
+		s.cache["$__fake{db#password}"] = cachedSecret{value: "rotated", expires: time.Now().Add(-time.Second)}
+		provider.err = errors.New("unavailable")
+
+		secret, _, err := s.Resolve(1, false, "$__fake{db#password}")
+		require.NoError(t, err)
+		require.Equal(t, "rotated", secret)
+	})
+
+	t.Run("Should return an error when secrets cannot be fetched", func(t *testing.T) {
+		secret, isReference, err := s.Resolve(1, false, "$__fake{db/other#password}")
+		require.True(t, isReference)
+		require.Error(t, err)
+		require.Empty(t, secret)
+	})
+}
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "token", r.Header.Get("X-Vault-Token"))
+
+		switch r.URL.Path {
+		case "/v1/secret/data/db":
+			_, _ = w.Write([]byte(`{"data": {"data": {"password": "kv2"}, "metadata": {"version": 1}}}`))
+		case "/v1/kv/db":
+			_, _ = w.Write([]byte(`{"data": {"password": "kv1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := newVaultProvider(server.URL+"/", "token")
+	ctx := context.Background()
+
+	secret, err := p.GetSecret(ctx, "secret/data/db#password")
+	require.NoError(t, err)
+	require.Equal(t, "kv2", secret)
+
+	secret, err = p.GetSecret(ctx, "kv/db#password")
+	require.NoError(t, err)
+	require.Equal(t, "kv1", secret)
+
+	_, err = p.GetSecret(ctx, "kv/db#user")
+	require.Equal(t, ErrSecretNotFound, err)
+
+	_, err = p.GetSecret(ctx, "kv/other#password")
+	require.Equal(t, ErrSecretNotFound, err)
+
+	_, err = p.GetSecret(ctx, "kv/db")
+	require.Error(t, err)
+}
+
+type fakeProvider struct {
+	secrets map[string]string
+	err     error
+	calls   int
+}
+
+func (p *fakeProvider) GetSecret(ctx context.Context, path string) (string, error) {
+	p.calls++
+	if p.err != nil {
+		return "", p.err
+	}
+
+	secret, exists := p.secrets[path]
+	if !exists {
+		return "", ErrSecretNotFound
+	}
+	return secret, nil
+}
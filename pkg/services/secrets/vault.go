@@ -0,0 +1,77 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// vaultProvider reads secrets from the HTTP API of HashiCorp Vault. Paths are the path of a
+// secret followed by the key of the value, e.g. secret/data/db#password.
+type vaultProvider struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func newVaultProvider(vaultUrl string, token string) *vaultProvider {
+	return &vaultProvider{
+		url:    strings.TrimSuffix(vaultUrl, "/"),
+		token:  token,
+		client: &http.Client{},
+	}
+}
+
+func (p *vaultProvider) GetSecret(ctx context.Context, path string) (string, error) {
+	parts := strings.SplitN(path, "#", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", fmt.Errorf("Vault secret path %q is missing the key of the value, e.g. secret/data/db#password", path)
+	}
+	secretPath, key := parts[0], parts[1]
+
+	secretUrl, err := url.Parse(fmt.Sprintf("%s/v1/%s", p.url, strings.TrimPrefix(secretPath, "/")))
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, secretUrl.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+
+	resp, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", ErrSecretNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Vault request failed: %s", resp.Status)
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", err
+	}
+
+	data := secret.Data
+	// the values of the KV secrets engine version 2 are nested in the data of the secret
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+
+	value, ok := data[key].(string)
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
@@ -0,0 +1,342 @@
+package shadowquery
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcomes of the comparison of the responses of a data source and of its shadow data source
+const (
+	OutcomeMatch          = "match"
+	OutcomeSeriesMismatch = "series_mismatch"
+	OutcomePrimaryError   = "primary_error"
+	OutcomeShadowError    = "shadow_error"
+	OutcomeBothError      = "both_error"
+	OutcomeSkipped        = "skipped"
+)
+
+const (
+	// shadowQueryTimeout is how long the queries of a shadow data source may run
+	shadowQueryTimeout = 30 * time.Second
+	// maxConcurrentShadowRequests is the most requests sent to shadow data sources at the same
+	// time, the next ones being skipped rather than queued
+	maxConcurrentShadowRequests = 20
+	// maxMismatches is the number of latest mismatches kept per data source
+	maxMismatches = 20
+)
+
+var shadowRequests *prometheus.CounterVec
+
+func init() {
+	registry.RegisterService(&ShadowQueryService{})
+
+	shadowRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grafana",
+		Name:      "datasource_shadow_requests_total",
+		Help:      "Number of data source query requests sent to a shadow data source, by data source and outcome of the comparison of their responses",
+	}, []string{"datasource_type", "datasource_uid", "outcome"})
+
+	prometheus.MustRegister(shadowRequests)
+}
+
+// ShadowQueryService sends the query requests of the data sources with a shadow data source, set by
+// the shadowDatasourceUid option of their jsonData, to the shadow data source too. The response of
+// the data source is returned while the shadow data source is queried in the background, and the
+// two responses are compared to validate a migration from one to the other: the requests failing
+// with only one of them, the queries returning different numbers of series and their durations
+// are recorded.
+type ShadowQueryService struct {
+	log log.Logger
+
+	running chan struct{}
+	wg      sync.WaitGroup
+
+	mutex sync.Mutex
+	stats map[int64]*ShadowStats
+}
+
+// ShadowStats compare the responses of a data source to the responses of its shadow data source
+type ShadowStats struct {
+	ShadowUID string `json:"shadowUid"`
+	// Requests is the number of requests sent to both data sources, by outcome of the comparison
+	Requests map[string]int64 `json:"requests"`
+	// Skipped is the number of requests not sent to the shadow data source, as too many were running
+	Skipped              int64      `json:"skipped"`
+	PrimaryAvgDurationMs int64      `json:"primaryAvgDurationMs"`
+	ShadowAvgDurationMs  int64      `json:"shadowAvgDurationMs"`
+	LastMismatches       []Mismatch `json:"lastMismatches"`
+
+	compared        int64
+	primaryDuration time.Duration
+	shadowDuration  time.Duration
+}
+
+// Mismatch is a query of a request whose responses differ
+type Mismatch struct {
+	Time          time.Time `json:"time"`
+	RefID         string    `json:"refId,omitempty"`
+	Outcome       string    `json:"outcome"`
+	PrimarySeries int       `json:"primarySeries"`
+	ShadowSeries  int       `json:"shadowSeries"`
+	PrimaryError  string    `json:"primaryError,omitempty"`
+	ShadowError   string    `json:"shadowError,omitempty"`
+}
+
+func (s *ShadowQueryService) Init() error {
+	s.log = log.New("shadowquery")
+	s.running = make(chan struct{}, maxConcurrentShadowRequests)
+	s.stats = make(map[int64]*ShadowStats)
+	return nil
+}
+
+// ShadowRequest sends a request of a data source to its shadow data source in the background, if it
+// has one and the request is sampled by its shadowSampleRate, and compares the responses. resp,
+// err and duration are the response of the data source, which isn't read after ShadowRequest
+// returns.
+func (s *ShadowQueryService) ShadowRequest(ds *models.DataSource, req *tsdb.TsdbQuery, resp *tsdb.Response, err error, duration time.Duration) {
+	shadowUID, ok := shadowDatasourceUID(ds)
+	if !ok {
+		return
+	}
+
+	select {
+	case s.running <- struct{}{}:
+	default:
+		shadowRequests.WithLabelValues(ds.Type, ds.Uid, OutcomeSkipped).Inc()
+		s.withStats(ds.Id, shadowUID, func(stats *ShadowStats) { stats.Skipped++ })
+		return
+	}
+
+	primary := summarize(resp, err, duration)
+	shadowReq := copyRequest(req)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() { <-s.running }()
+
+		s.compare(ds, shadowUID, shadowReq, primary)
+	}()
+}
+
+// compare queries the shadow data source of a data source and compares its response to the response
+// of the data source
+func (s *ShadowQueryService) compare(ds *models.DataSource, shadowUID string, req *tsdb.TsdbQuery, primary responseSummary) {
+	query := models.GetDataSourceByUidQuery{Uid: shadowUID, OrgId: ds.OrgId}
+	if err := bus.Dispatch(&query); err != nil {
+		s.log.Warn("Failed to get the shadow data source", "datasource", ds.Name, "shadowUid", shadowUID, "error", err)
+		return
+	}
+	for _, q := range req.Queries {
+		q.DataSource = query.Result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shadowQueryTimeout)
+	defer cancel()
+	start := time.Now()
+	resp, err := tsdb.HandleRequest(ctx, query.Result, req)
+	shadow := summarize(resp, err, time.Since(start))
+
+	outcome, mismatches := compareResponses(primary, shadow)
+	shadowRequests.WithLabelValues(ds.Type, ds.Uid, outcome).Inc()
+	if outcome != OutcomeMatch {
+		s.log.Debug("Shadow data source response differs", "datasource", ds.Name, "shadowUid", shadowUID, "outcome", outcome)
+	}
+
+	s.withStats(ds.Id, shadowUID, func(stats *ShadowStats) {
+		stats.Requests[outcome]++
+		stats.compared++
+		stats.primaryDuration += primary.duration
+		stats.shadowDuration += shadow.duration
+		stats.LastMismatches = append(stats.LastMismatches, mismatches...)
+		if len(stats.LastMismatches) > maxMismatches {
+			stats.LastMismatches = stats.LastMismatches[len(stats.LastMismatches)-maxMismatches:]
+		}
+	})
+}
+
+// withStats updates the stats of a data source, which are reset when its shadow data source changes
+func (s *ShadowQueryService) withStats(datasourceID int64, shadowUID string, fn func(stats *ShadowStats)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats, ok := s.stats[datasourceID]
+	if !ok || stats.ShadowUID != shadowUID {
+		stats = &ShadowStats{ShadowUID: shadowUID, Requests: make(map[string]int64)}
+		s.stats[datasourceID] = stats
+	}
+	fn(stats)
+}
+
+// Stats returns the comparison of the responses of a data source and of its shadow data source,
+// nil when none was sent to its shadow data source
+func (s *ShadowQueryService) Stats(datasourceID int64) *ShadowStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats, ok := s.stats[datasourceID]
+	if !ok {
+		return nil
+	}
+	result := *stats
+	result.Requests = make(map[string]int64, len(stats.Requests))
+	for outcome, count := range stats.Requests {
+		result.Requests[outcome] = count
+	}
+	result.LastMismatches = append([]Mismatch{}, stats.LastMismatches...)
+	if stats.compared > 0 {
+		result.PrimaryAvgDurationMs = milliseconds(stats.primaryDuration / time.Duration(stats.compared))
+		result.ShadowAvgDurationMs = milliseconds(stats.shadowDuration / time.Duration(stats.compared))
+	}
+	return &result
+}
+
+// shadowDatasourceUID returns the uid of the shadow data source of a data source, when the request
+// is sampled
+func shadowDatasourceUID(ds *models.DataSource) (string, bool) {
+	if ds == nil || ds.JsonData == nil {
+		return "", false
+	}
+	uid := ds.JsonData.Get("shadowDatasourceUid").MustString()
+	if uid == "" || uid == ds.Uid {
+		return "", false
+	}
+	rate := ds.JsonData.Get("shadowSampleRate").MustFloat64(100)
+	if rate < 100 && rand.Float64()*100 >= rate {
+		return "", false
+	}
+	return uid, true
+}
+
+// copyRequest copies the queries of a request, so that the queries of the data source and of its
+// shadow data source don't share their models
+func copyRequest(req *tsdb.TsdbQuery) *tsdb.TsdbQuery {
+	result := &tsdb.TsdbQuery{
+		TimeRange:       req.TimeRange,
+		Headers:         req.Headers,
+		User:            req.User,
+		Transformations: req.Transformations,
+	}
+	for _, q := range req.Queries {
+		query := *q
+		if q.Model != nil {
+			if data, err := q.Model.MarshalJSON(); err == nil {
+				if model, err := simplejson.NewJson(data); err == nil {
+					query.Model = model
+				}
+			}
+		}
+		result.Queries = append(result.Queries, &query)
+	}
+	return result
+}
+
+type responseSummary struct {
+	duration time.Duration
+	err      string
+	results  map[string]resultSummary
+}
+
+type resultSummary struct {
+	series int
+	err    string
+}
+
+// summarize returns the errors and the number of series of a response
+func summarize(resp *tsdb.Response, err error, duration time.Duration) responseSummary {
+	summary := responseSummary{duration: duration, results: make(map[string]resultSummary)}
+	if err != nil {
+		summary.err = err.Error()
+		return summary
+	}
+	if resp == nil {
+		return summary
+	}
+	for refID, res := range resp.Results {
+		result := resultSummary{series: tsdb.SeriesCount(res), err: res.ErrorString}
+		if res.Error != nil {
+			result.err = res.Error.Error()
+		}
+		summary.results[refID] = result
+	}
+	return summary
+}
+
+// compareResponses returns the outcome of the comparison of two responses, an error of either one
+// prevailing over different numbers of series, and the queries whose responses differ
+func compareResponses(primary, shadow responseSummary) (string, []Mismatch) {
+	now := time.Now()
+	if primary.err != "" || shadow.err != "" {
+		outcome := errorOutcome(primary.err, shadow.err)
+		if outcome == OutcomeBothError {
+			return outcome, nil
+		}
+		return outcome, []Mismatch{{Time: now, Outcome: outcome, PrimaryError: primary.err, ShadowError: shadow.err}}
+	}
+
+	refIDs := make([]string, 0, len(primary.results))
+	for refID := range primary.results {
+		refIDs = append(refIDs, refID)
+	}
+	for refID := range shadow.results {
+		if _, ok := primary.results[refID]; !ok {
+			refIDs = append(refIDs, refID)
+		}
+	}
+	sort.Strings(refIDs)
+
+	outcome := OutcomeMatch
+	var mismatches []Mismatch
+	for _, refID := range refIDs {
+		p, s := primary.results[refID], shadow.results[refID]
+		mismatch := Mismatch{
+			Time:          now,
+			RefID:         refID,
+			PrimarySeries: p.series,
+			ShadowSeries:  s.series,
+			PrimaryError:  p.err,
+			ShadowError:   s.err,
+		}
+		switch {
+		case p.err != "" || s.err != "":
+			mismatch.Outcome = errorOutcome(p.err, s.err)
+			if mismatch.Outcome == OutcomeBothError {
+				continue
+			}
+		case p.series != s.series:
+			mismatch.Outcome = OutcomeSeriesMismatch
+		default:
+			continue
+		}
+		mismatches = append(mismatches, mismatch)
+		if outcome == OutcomeMatch || outcome == OutcomeSeriesMismatch {
+			outcome = mismatch.Outcome
+		}
+	}
+	return outcome, mismatches
+}
+
+func errorOutcome(primaryErr, shadowErr string) string {
+	switch {
+	case primaryErr != "" && shadowErr != "":
+		return OutcomeBothError
+	case primaryErr != "":
+		return OutcomePrimaryError
+	default:
+		return OutcomeShadowError
+	}
+}
+
+func milliseconds(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
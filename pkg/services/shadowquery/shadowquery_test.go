@@ -0,0 +1,177 @@
+package shadowquery
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEndpoint returns series series per query, or fails with err
+type fakeEndpoint struct {
+	mu     sync.Mutex
+	series int
+	err    error
+	models []*simplejson.Json
+}
+
+func (e *fakeEndpoint) Query(ctx context.Context, ds *models.DataSource, query *tsdb.TsdbQuery) (*tsdb.Response, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err != nil {
+		return nil, e.err
+	}
+	resp := &tsdb.Response{Results: make(map[string]*tsdb.QueryResult)}
+	for _, q := range query.Queries {
+		e.models = append(e.models, q.Model)
+		result := tsdb.NewQueryResult()
+		result.RefId = q.RefId
+		for i := 0; i < e.series; i++ {
+			result.Series = append(result.Series, &tsdb.TimeSeries{Name: "series"})
+		}
+		resp.Results[q.RefId] = result
+	}
+	return resp, nil
+}
+
+func TestShadowQueryService(t *testing.T) {
+	shadowEndpoint := &fakeEndpoint{series: 2}
+	tsdb.RegisterTsdbQueryEndpoint("shadowquery-test", func(dsInfo *models.DataSource) (tsdb.TsdbQueryEndpoint, error) {
+		return shadowEndpoint, nil
+	})
+
+	bus.ClearBusHandlers()
+	t.Cleanup(bus.ClearBusHandlers)
+	bus.AddHandler("test", func(query *models.GetDataSourceByUidQuery) error {
+		if query.Uid != "shadow" {
+			return models.ErrDataSourceNotFound
+		}
+		query.Result = &models.DataSource{Id: 2, Uid: "shadow", OrgId: query.OrgId, Type: "shadowquery-test"}
+		return nil
+	})
+
+	s := &ShadowQueryService{}
+	require.NoError(t, s.Init())
+	ds := &models.DataSource{
+		Id:       1,
+		Uid:      "primary",
+		OrgId:    1,
+		Type:     "prometheus",
+		JsonData: simplejson.NewFromAny(map[string]interface{}{"shadowDatasourceUid": "shadow"}),
+	}
+	newRequest := func() *tsdb.TsdbQuery {
+		return &tsdb.TsdbQuery{
+			TimeRange: tsdb.NewTimeRange("1600000000000", "1600000300000"),
+			Queries: []*tsdb.Query{
+				{RefId: "A", DataSource: ds, Model: simplejson.NewFromAny(map[string]interface{}{"expr": "up"})},
+			},
+		}
+	}
+	newResponse := func(series int) *tsdb.Response {
+		result := tsdb.NewQueryResult()
+		result.RefId = "A"
+		for i := 0; i < series; i++ {
+			result.Series = append(result.Series, &tsdb.TimeSeries{Name: "series"})
+		}
+		return &tsdb.Response{Results: map[string]*tsdb.QueryResult{"A": result}}
+	}
+
+	t.Run("Should compare the responses of the data source and of its shadow", func(t *testing.T) {
+		req := newRequest()
+		s.ShadowRequest(ds, req, newResponse(2), nil, 100*time.Millisecond)
+		s.ShadowRequest(ds, req, newResponse(3), nil, 300*time.Millisecond)
+		s.wg.Wait()
+
+		stats := s.Stats(1)
+		require.NotNil(t, stats)
+		assert.Equal(t, "shadow", stats.ShadowUID)
+		assert.Equal(t, map[string]int64{OutcomeMatch: 1, OutcomeSeriesMismatch: 1}, stats.Requests)
+		assert.Equal(t, int64(200), stats.PrimaryAvgDurationMs)
+		require.Len(t, stats.LastMismatches, 1)
+		assert.Equal(t, "A", stats.LastMismatches[0].RefID)
+		assert.Equal(t, 3, stats.LastMismatches[0].PrimarySeries)
+		assert.Equal(t, 2, stats.LastMismatches[0].ShadowSeries)
+
+		// the shadow data source gets a copy of the queries
+		require.NotEmpty(t, shadowEndpoint.models)
+		assert.NotSame(t, req.Queries[0].Model, shadowEndpoint.models[0])
+		assert.Equal(t, "up", shadowEndpoint.models[0].Get("expr").MustString())
+		assert.Same(t, ds, req.Queries[0].DataSource)
+	})
+
+	t.Run("Should record the requests failing with only one data source", func(t *testing.T) {
+		shadowEndpoint.mu.Lock()
+		shadowEndpoint.err = errors.New("connection refused")
+		shadowEndpoint.mu.Unlock()
+		t.Cleanup(func() { shadowEndpoint.err = nil })
+
+		s.ShadowRequest(ds, newRequest(), newResponse(2), nil, time.Millisecond)
+		s.ShadowRequest(ds, newRequest(), nil, errors.New("timeout"), time.Millisecond)
+		s.wg.Wait()
+
+		stats := s.Stats(1)
+		assert.Equal(t, int64(1), stats.Requests[OutcomeShadowError])
+		assert.Equal(t, int64(1), stats.Requests[OutcomeBothError])
+		last := stats.LastMismatches[len(stats.LastMismatches)-1]
+		assert.Equal(t, OutcomeShadowError, last.Outcome)
+		assert.Equal(t, "connection refused", last.ShadowError)
+	})
+
+	t.Run("Should not shadow the data sources without a shadow", func(t *testing.T) {
+		other := &models.DataSource{Id: 3, Uid: "other", JsonData: simplejson.New()}
+		s.ShadowRequest(other, newRequest(), newResponse(1), nil, time.Millisecond)
+		s.wg.Wait()
+		assert.Nil(t, s.Stats(3))
+	})
+
+	t.Run("Should reset the stats when the shadow changes", func(t *testing.T) {
+		changed := &models.DataSource{
+			Id:       1,
+			Uid:      "primary",
+			OrgId:    1,
+			JsonData: simplejson.NewFromAny(map[string]interface{}{"shadowDatasourceUid": "missing"}),
+		}
+		s.ShadowRequest(changed, newRequest(), newResponse(1), nil, time.Millisecond)
+		s.wg.Wait()
+		// the shadow data source doesn't exist, nothing is compared
+		assert.Equal(t, "shadow", s.Stats(1).ShadowUID)
+
+		s.withStats(1, "missing", func(*ShadowStats) {})
+		assert.Empty(t, s.Stats(1).Requests)
+	})
+}
+
+func TestCompareResponses(t *testing.T) {
+	summary := func(results map[string]resultSummary) responseSummary {
+		return responseSummary{results: results}
+	}
+
+	outcome, mismatches := compareResponses(
+		summary(map[string]resultSummary{"A": {series: 2}, "B": {series: 1}}),
+		summary(map[string]resultSummary{"A": {series: 2}, "B": {series: 1}}))
+	assert.Equal(t, OutcomeMatch, outcome)
+	assert.Empty(t, mismatches)
+
+	outcome, mismatches = compareResponses(
+		summary(map[string]resultSummary{"A": {series: 2}, "B": {series: 1}}),
+		summary(map[string]resultSummary{"A": {series: 1}, "B": {err: "bad query"}}))
+	assert.Equal(t, OutcomeShadowError, outcome)
+	require.Len(t, mismatches, 2)
+	assert.Equal(t, OutcomeSeriesMismatch, mismatches[0].Outcome)
+	assert.Equal(t, "B", mismatches[1].RefID)
+
+	outcome, mismatches = compareResponses(
+		summary(map[string]resultSummary{"A": {series: 1}}),
+		summary(map[string]resultSummary{"A": {series: 1}, "B": {series: 1}}))
+	assert.Equal(t, OutcomeSeriesMismatch, outcome)
+	require.Len(t, mismatches, 1)
+	assert.Equal(t, 0, mismatches[0].PrimarySeries)
+}
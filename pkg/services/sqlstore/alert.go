@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/models"
 )
 
@@ -297,6 +298,7 @@ func SetAlertState(cmd *models.SetAlertStateCommand) error {
 			return models.ErrRequiresNewState
 		}
 
+		prevState := alert.State
 		alert.State = cmd.State
 		alert.StateChanges++
 		alert.NewStateDate = timeNow()
@@ -314,6 +316,17 @@ func SetAlertState(cmd *models.SetAlertStateCommand) error {
 		}
 
 		cmd.Result = alert
+
+		sess.publishAfterCommit(&events.AlertStateChanged{
+			Timestamp:   alert.NewStateDate,
+			Id:          alert.Id,
+			OrgId:       alert.OrgId,
+			DashboardId: alert.DashboardId,
+			PanelId:     alert.PanelId,
+			Name:        alert.Name,
+			State:       string(alert.State),
+			PrevState:   string(prevState),
+		})
 		return nil
 	})
 }
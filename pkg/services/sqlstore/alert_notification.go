@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/securejsondata"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -19,6 +20,7 @@ func init() {
 	bus.AddHandler("sql", UpdateAlertNotification)
 	bus.AddHandler("sql", DeleteAlertNotification)
 	bus.AddHandler("sql", GetAllAlertNotifications)
+	bus.AddHandler("sql", GetProvisionedAlertNotifications)
 	bus.AddHandlerCtx("sql", GetOrCreateAlertNotificationState)
 	bus.AddHandlerCtx("sql", SetAlertNotificationStateToCompleteCommand)
 	bus.AddHandlerCtx("sql", SetAlertNotificationStateToPendingCommand)
@@ -107,6 +109,16 @@ func GetAllAlertNotifications(query *models.GetAllAlertNotificationsQuery) error
 	return nil
 }
 
+func GetProvisionedAlertNotifications(query *models.GetProvisionedAlertNotificationsQuery) error {
+	results := make([]*models.AlertNotification, 0)
+	if err := x.Where("provisioned = ?", dialect.BooleanStr(true)).Find(&results); err != nil {
+		return err
+	}
+
+	query.Result = results
+	return nil
+}
+
 func GetAlertNotificationsWithUidToSend(query *models.GetAlertNotificationsWithUidToSendQuery) error {
 	var sql bytes.Buffer
 	params := make([]interface{}, 0)
@@ -123,7 +135,10 @@ func GetAlertNotificationsWithUidToSend(query *models.GetAlertNotificationsWithU
 										alert_notification.is_default,
 										alert_notification.disable_resolve_message,
 										alert_notification.send_reminder,
-										alert_notification.frequency
+										alert_notification.frequency,
+										alert_notification.secure_settings,
+										alert_notification.provisioned,
+										alert_notification.version
 										FROM alert_notification
 	  							`)
 
@@ -195,7 +210,10 @@ func getAlertNotificationInternal(query *models.GetAlertNotificationsQuery, sess
 										alert_notification.is_default,
 										alert_notification.disable_resolve_message,
 										alert_notification.send_reminder,
-										alert_notification.frequency
+										alert_notification.frequency,
+										alert_notification.secure_settings,
+										alert_notification.provisioned,
+										alert_notification.version
 										FROM alert_notification
 	  							`)
 
@@ -244,7 +262,10 @@ func getAlertNotificationWithUidInternal(query *models.GetAlertNotificationsWith
 										alert_notification.is_default,
 										alert_notification.disable_resolve_message,
 										alert_notification.send_reminder,
-										alert_notification.frequency
+										alert_notification.frequency,
+										alert_notification.secure_settings,
+										alert_notification.provisioned,
+										alert_notification.version
 										FROM alert_notification
 	  							`)
 
@@ -283,7 +304,7 @@ func CreateAlertNotificationCommand(cmd *models.CreateAlertNotificationCommand)
 		}
 
 		if existingQuery.Result != nil {
-			return fmt.Errorf("Alert notification uid %s already exists", cmd.Uid)
+			return models.ErrAlertNotificationWithSameUIDExists
 		}
 
 		// check if name exists
@@ -293,7 +314,7 @@ func CreateAlertNotificationCommand(cmd *models.CreateAlertNotificationCommand)
 		}
 
 		if sameNameQuery.Result != nil {
-			return fmt.Errorf("Alert notification name %s already exists", cmd.Name)
+			return models.ErrAlertNotificationWithSameNameExists
 		}
 
 		var frequency time.Duration
@@ -320,6 +341,9 @@ func CreateAlertNotificationCommand(cmd *models.CreateAlertNotificationCommand)
 			Created:               time.Now(),
 			Updated:               time.Now(),
 			IsDefault:             cmd.IsDefault,
+			SecureSettings:        securejsondata.GetEncryptedJsonData(cmd.SecureSettings),
+			Provisioned:           cmd.Provisioned,
+			Version:               1,
 		}
 
 		if _, err = sess.MustCols("send_reminder").Insert(alertNotification); err != nil {
@@ -362,9 +386,14 @@ func UpdateAlertNotification(cmd *models.UpdateAlertNotificationCommand) error {
 		}
 
 		if sameNameQuery.Result != nil && sameNameQuery.Result.Id != current.Id {
-			return fmt.Errorf("Alert notification name %s already exists", cmd.Name)
+			return models.ErrAlertNotificationWithSameNameExists
+		}
+
+		if cmd.Version != 0 && cmd.Version != current.Version {
+			return models.ErrAlertNotificationVersionMismatch
 		}
 
+		current.Version++
 		current.Updated = time.Now()
 		current.Settings = cmd.Settings
 		current.Name = cmd.Name
@@ -377,6 +406,17 @@ func UpdateAlertNotification(cmd *models.UpdateAlertNotificationCommand) error {
 			current.Uid = cmd.Uid
 		}
 
+		// secure settings are only replaced when they are part of the command
+		if cmd.SecureSettings != nil {
+			current.SecureSettings = securejsondata.GetEncryptedJsonData(cmd.SecureSettings)
+			sess.MustCols("secure_settings")
+		}
+
+		// updates from the UI don't change whether a notification is provisioned
+		if cmd.Provisioned {
+			current.Provisioned = true
+		}
+
 		if current.SendReminder {
 			if cmd.Frequency == "" {
 				return models.ErrNotificationFrequencyNotFound
@@ -430,8 +470,11 @@ func UpdateAlertNotificationWithUid(cmd *models.UpdateAlertNotificationWithUidCo
 		Frequency:             cmd.Frequency,
 		IsDefault:             cmd.IsDefault,
 		Settings:              cmd.Settings,
+		SecureSettings:        cmd.SecureSettings,
 
-		OrgId: cmd.OrgId,
+		OrgId:       cmd.OrgId,
+		Provisioned: cmd.Provisioned,
+		Version:     cmd.Version,
 	}
 
 	if err := bus.Dispatch(updateNotification); err != nil {
@@ -226,6 +226,9 @@ func TestAlertNotificationSQLAccess(t *testing.T) {
 				err = CreateAlertNotificationCommand(cmd)
 				So(err, ShouldNotBeNil)
 			})
+			Convey("Saved Alert Notification has the first version", func() {
+				So(cmd.Result.Version, ShouldEqual, 1)
+			})
 			Convey("Cannot save Alert Notification with the same name and another uid", func() {
 				anotherUidCmd := &models.CreateAlertNotificationCommand{
 					Name:         cmd.Name,
@@ -271,6 +274,25 @@ func TestAlertNotificationSQLAccess(t *testing.T) {
 				So(newCmd.Result.DisableResolveMessage, ShouldBeTrue)
 			})
 
+			Convey("Can update alert notification with its version", func() {
+				newCmd := &models.UpdateAlertNotificationWithUidCommand{
+					Uid:      cmd.Result.Uid,
+					Name:     "NewName",
+					Type:     "webhook",
+					OrgId:    cmd.Result.OrgId,
+					Settings: simplejson.New(),
+					Version:  1,
+				}
+				err := UpdateAlertNotificationWithUid(newCmd)
+				So(err, ShouldBeNil)
+				So(newCmd.Result.Version, ShouldEqual, 2)
+
+				Convey("Cannot update alert notification with an old version", func() {
+					err := UpdateAlertNotificationWithUid(newCmd)
+					So(err, ShouldEqual, models.ErrAlertNotificationVersionMismatch)
+				})
+			})
+
 			Convey("Can update alert notification to disable sending of reminders", func() {
 				newCmd := &models.UpdateAlertNotificationCommand{
 					Name:         "NewName",
@@ -286,6 +308,54 @@ func TestAlertNotificationSQLAccess(t *testing.T) {
 			})
 		})
 
+		Convey("Can save secure settings of Alert Notification", func() {
+			cmd := &models.CreateAlertNotificationCommand{
+				Name:           "secure",
+				Type:           "slack",
+				OrgId:          1,
+				Settings:       simplejson.New(),
+				SecureSettings: map[string]string{"url": "https://hooks.slack.com/secret"},
+				Provisioned:    true,
+			}
+			So(CreateAlertNotificationCommand(cmd), ShouldBeNil)
+
+			query := &models.GetAlertNotificationsWithUidQuery{OrgId: 1, Uid: cmd.Result.Uid}
+			So(GetAlertNotificationsWithUid(query), ShouldBeNil)
+			url, err := query.Result.DecryptedValue("url", "")
+			So(err, ShouldBeNil)
+			So(url, ShouldEqual, "https://hooks.slack.com/secret")
+			So(query.Result.Provisioned, ShouldBeTrue)
+
+			Convey("Update without secure settings keeps them", func() {
+				updateCmd := &models.UpdateAlertNotificationCommand{Id: cmd.Result.Id, OrgId: 1, Name: "secure", Type: "slack", Settings: simplejson.New()}
+				So(UpdateAlertNotification(updateCmd), ShouldBeNil)
+
+				So(GetAlertNotificationsWithUid(query), ShouldBeNil)
+				url, err := query.Result.DecryptedValue("url", "")
+				So(err, ShouldBeNil)
+				So(url, ShouldEqual, "https://hooks.slack.com/secret")
+				So(query.Result.Provisioned, ShouldBeTrue)
+			})
+
+			Convey("Update with secure settings replaces them", func() {
+				updateCmd := &models.UpdateAlertNotificationCommand{Id: cmd.Result.Id, OrgId: 1, Name: "secure", Type: "slack", Settings: simplejson.New(), SecureSettings: map[string]string{}}
+				So(UpdateAlertNotification(updateCmd), ShouldBeNil)
+
+				So(GetAlertNotificationsWithUid(query), ShouldBeNil)
+				So(query.Result.SecureSettings, ShouldBeEmpty)
+			})
+
+			Convey("Can get provisioned notifications", func() {
+				other := &models.CreateAlertNotificationCommand{Name: "other", Type: "email", OrgId: 2, Settings: simplejson.New()}
+				So(CreateAlertNotificationCommand(other), ShouldBeNil)
+
+				provisioned := &models.GetProvisionedAlertNotificationsQuery{}
+				So(GetProvisionedAlertNotifications(provisioned), ShouldBeNil)
+				So(len(provisioned.Result), ShouldEqual, 1)
+				So(provisioned.Result[0].Uid, ShouldEqual, cmd.Result.Uid)
+			})
+		})
+
 		Convey("Can search using an array of ids", func() {
 			cmd1 := models.CreateAlertNotificationCommand{Name: "nagios", Type: "webhook", OrgId: 1, SendReminder: true, Frequency: "10s", Settings: simplejson.New()}
 			cmd2 := models.CreateAlertNotificationCommand{Name: "slack", Type: "webhook", OrgId: 1, SendReminder: true, Frequency: "10s", Settings: simplejson.New()}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/annotations"
+	"xorm.io/xorm"
 )
 
 // Update the item so that EpochEnd >= Epoch
@@ -228,9 +229,12 @@ func (r *SqlAnnotationRepo) Find(query *annotations.ItemQuery) ([]*annotations.I
 	// order of ORDER BY arguments match the order of a sql index for performance
 	sql.WriteString(" ORDER BY a.org_id, a.epoch_end DESC, a.epoch DESC" + dialect.Limit(query.Limit) + " ) dt on dt.id = annotation.id")
 
-	items := make([]*annotations.ItemDTO, 0)
-
-	if err := x.SQL(sql.String(), params...).Find(&items); err != nil {
+	var items []*annotations.ItemDTO
+	err := withReadEngine(func(engine *xorm.Engine) error {
+		items = make([]*annotations.ItemDTO, 0)
+		return engine.SQL(sql.String(), params...).Find(&items)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,80 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", GetAnnotationStore)
+	bus.AddHandler("sql", SetAnnotationStore)
+}
+
+func GetAnnotationStore(query *models.GetAnnotationStoreQuery) error {
+	store := models.AnnotationStore{}
+	exists, err := x.Where("org_id = ?", query.OrgId).Get(&store)
+	if err != nil {
+		return err
+	}
+
+	query.Result = nil
+	if exists {
+		query.Result = &store
+	}
+
+	return nil
+}
+
+func SetAnnotationStore(cmd *models.SetAnnotationStoreCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		if cmd.DatasourceId == 0 {
+			_, err := sess.Exec("DELETE FROM annotation_store WHERE org_id = ?", cmd.OrgId)
+			return err
+		}
+
+		ds := models.DataSource{Id: cmd.DatasourceId, OrgId: cmd.OrgId}
+		exists, err := sess.Get(&ds)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return models.ErrDataSourceNotFound
+		}
+		if !models.IsAnnotationStoreType(ds.Type) {
+			return models.ErrAnnotationStoreInvalidDataSource
+		}
+
+		store := models.AnnotationStore{}
+		exists, err = sess.Where("org_id = ?", cmd.OrgId).Get(&store)
+		if err != nil {
+			return err
+		}
+
+		store.OrgId = cmd.OrgId
+		store.DatasourceId = cmd.DatasourceId
+		store.Updated = time.Now()
+
+		if exists {
+			_, err = sess.ID(store.Id).Cols("datasource_id", "updated").Update(&store)
+		} else {
+			_, err = sess.Insert(&store)
+		}
+
+		return err
+	})
+}
+
+// deleteDataSourceAnnotationStore stores annotations in the Grafana database again when the
+// data source they are stored in is deleted
+func deleteDataSourceAnnotationStore(sess *DBSession, orgId int64, dataSourceCondition string, args ...interface{}) error {
+	rawSQL := "DELETE FROM annotation_store WHERE org_id = ? AND " +
+		"datasource_id IN (SELECT id FROM data_source WHERE " + dataSourceCondition + ")"
+
+	sqlOrArgs := []interface{}{rawSQL, orgId}
+	sqlOrArgs = append(sqlOrArgs, args...)
+
+	_, err := sess.Exec(sqlOrArgs...)
+	return err
+}
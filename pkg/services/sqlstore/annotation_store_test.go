@@ -0,0 +1,57 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestAnnotationStoreDataAccess(t *testing.T) {
+	InitTestDB(t)
+
+	loki := models.AddDataSourceCommand{OrgId: 1, Name: "loki", Type: models.DS_LOKI, Access: models.DS_ACCESS_PROXY, Url: "http://loki"}
+	err := AddDataSource(&loki)
+	require.NoError(t, err)
+
+	graphite := models.AddDataSourceCommand{OrgId: 1, Name: "graphite", Type: models.DS_GRAPHITE, Access: models.DS_ACCESS_PROXY, Url: "http://graphite"}
+	err = AddDataSource(&graphite)
+	require.NoError(t, err)
+
+	getStore := func(t *testing.T) *models.AnnotationStore {
+		query := models.GetAnnotationStoreQuery{OrgId: 1}
+		err := GetAnnotationStore(&query)
+		require.NoError(t, err)
+		return query.Result
+	}
+
+	t.Run("Annotations are stored in the database by default", func(t *testing.T) {
+		require.Nil(t, getStore(t))
+	})
+
+	t.Run("Only Loki and Elasticsearch data sources can store annotations", func(t *testing.T) {
+		err := SetAnnotationStore(&models.SetAnnotationStoreCommand{OrgId: 1, DatasourceId: graphite.Result.Id})
+		require.Equal(t, models.ErrAnnotationStoreInvalidDataSource, err)
+
+		err = SetAnnotationStore(&models.SetAnnotationStoreCommand{OrgId: 2, DatasourceId: loki.Result.Id})
+		require.Equal(t, models.ErrDataSourceNotFound, err)
+
+		err = SetAnnotationStore(&models.SetAnnotationStoreCommand{OrgId: 1, DatasourceId: loki.Result.Id})
+		require.NoError(t, err)
+		require.Equal(t, loki.Result.Id, getStore(t).DatasourceId)
+
+		err = SetAnnotationStore(&models.SetAnnotationStoreCommand{OrgId: 1, DatasourceId: 0})
+		require.NoError(t, err)
+		require.Nil(t, getStore(t))
+	})
+
+	t.Run("Deleting the data source stores annotations in the database", func(t *testing.T) {
+		err := SetAnnotationStore(&models.SetAnnotationStoreCommand{OrgId: 1, DatasourceId: loki.Result.Id})
+		require.NoError(t, err)
+
+		err = DeleteDataSourceByName(&models.DeleteDataSourceByNameCommand{OrgId: 1, Name: "loki"})
+		require.NoError(t, err)
+		require.Nil(t, getStore(t))
+	})
+}
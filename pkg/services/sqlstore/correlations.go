@@ -0,0 +1,159 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", CreateCorrelation)
+	bus.AddHandler("sql", UpdateCorrelation)
+	bus.AddHandler("sql", DeleteCorrelation)
+	bus.AddHandler("sql", GetCorrelation)
+	bus.AddHandler("sql", GetCorrelationsBySource)
+}
+
+func CreateCorrelation(cmd *models.CreateCorrelationCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		targetExists, err := sess.Where("org_id=? AND id=?", cmd.OrgId, cmd.TargetDatasourceId).Get(&models.DataSource{})
+		if err != nil {
+			return err
+		}
+		if !targetExists {
+			return models.ErrCorrelationTargetDataSourceMissing
+		}
+
+		uid, err := generateNewCorrelationUid(sess, cmd.OrgId)
+		if err != nil {
+			return err
+		}
+
+		correlation := &models.Correlation{
+			OrgId:              cmd.OrgId,
+			Uid:                uid,
+			SourceDatasourceId: cmd.SourceDatasourceId,
+			TargetDatasourceId: cmd.TargetDatasourceId,
+			Label:              cmd.Label,
+			Description:        cmd.Description,
+			Field:              cmd.Field,
+			Target:             cmd.Target,
+			Created:            time.Now(),
+			Updated:            time.Now(),
+		}
+
+		if _, err := sess.Insert(correlation); err != nil {
+			return err
+		}
+
+		cmd.Result = correlation
+		return nil
+	})
+}
+
+func UpdateCorrelation(cmd *models.UpdateCorrelationCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		correlation := models.Correlation{}
+		has, err := sess.Where("org_id=? AND uid=? AND source_datasource_id=?", cmd.OrgId, cmd.Uid, cmd.SourceDatasourceId).Get(&correlation)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return models.ErrCorrelationNotFound
+		}
+
+		correlation.Label = cmd.Label
+		correlation.Description = cmd.Description
+		correlation.Field = cmd.Field
+		correlation.Target = cmd.Target
+		correlation.Updated = time.Now()
+
+		if _, err := sess.ID(correlation.Id).Cols("label", "description", "field", "target", "updated").Update(&correlation); err != nil {
+			return err
+		}
+
+		cmd.Result = &correlation
+		return nil
+	})
+}
+
+func DeleteCorrelation(cmd *models.DeleteCorrelationCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		result, err := sess.Exec("DELETE FROM correlation WHERE org_id=? AND uid=? AND source_datasource_id=?", cmd.OrgId, cmd.Uid, cmd.SourceDatasourceId)
+		if err != nil {
+			return err
+		}
+
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return models.ErrCorrelationNotFound
+		}
+
+		return nil
+	})
+}
+
+func GetCorrelation(query *models.GetCorrelationQuery) error {
+	correlation := models.Correlation{}
+	has, err := x.Where("org_id=? AND uid=? AND source_datasource_id=?", query.OrgId, query.Uid, query.SourceDatasourceId).Get(&correlation)
+	if err != nil {
+		return err
+	}
+	if !has {
+		return models.ErrCorrelationNotFound
+	}
+
+	query.Result = &correlation
+	return nil
+}
+
+func GetCorrelationsBySource(query *models.GetCorrelationsBySourceQuery) error {
+	rawSQL := `SELECT
+		c.uid,
+		c.source_datasource_id,
+		c.target_datasource_id,
+		ds.uid AS target_datasource_uid,
+		c.label,
+		c.description,
+		c.field,
+		c.target
+		FROM correlation AS c
+		INNER JOIN data_source AS ds ON ds.id = c.target_datasource_id
+		WHERE c.org_id = ? AND c.source_datasource_id = ?
+		ORDER BY c.label ASC`
+
+	query.Result = make([]*models.CorrelationDTO, 0)
+	return x.SQL(rawSQL, query.OrgId, query.SourceDatasourceId).Find(&query.Result)
+}
+
+// deleteDataSourceCorrelations removes the correlations from and to the data sources
+// matching the condition, it has to run before the data sources are deleted.
+func deleteDataSourceCorrelations(sess *DBSession, orgId int64, dataSourceCondition string, args ...interface{}) error {
+	rawSQL := "DELETE FROM correlation WHERE org_id = ? AND (" +
+		"source_datasource_id IN (SELECT id FROM data_source WHERE " + dataSourceCondition + ") OR " +
+		"target_datasource_id IN (SELECT id FROM data_source WHERE " + dataSourceCondition + "))"
+
+	sqlOrArgs := []interface{}{rawSQL, orgId}
+	sqlOrArgs = append(sqlOrArgs, args...)
+	sqlOrArgs = append(sqlOrArgs, args...)
+
+	_, err := sess.Exec(sqlOrArgs...)
+	return err
+}
+
+func generateNewCorrelationUid(sess *DBSession, orgId int64) (string, error) {
+	for i := 0; i < 3; i++ {
+		uid := generateNewUid()
+
+		exists, err := sess.Where("org_id=? AND uid=?", orgId, uid).Get(&models.Correlation{})
+		if err != nil {
+			return "", err
+		}
+
+		if !exists {
+			return uid, nil
+		}
+	}
+
+	return "", models.ErrCorrelationFailedGenerateUniqueUid
+}
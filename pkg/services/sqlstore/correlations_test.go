@@ -0,0 +1,124 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestCorrelationDataAccess(t *testing.T) {
+	addDataSource := func(t *testing.T, name string) *models.DataSource {
+		cmd := models.AddDataSourceCommand{
+			OrgId:  1,
+			Name:   name,
+			Type:   models.DS_ES,
+			Access: models.DS_ACCESS_PROXY,
+			Url:    "http://test",
+		}
+		err := AddDataSource(&cmd)
+		require.NoError(t, err)
+		return cmd.Result
+	}
+
+	createCorrelation := func(t *testing.T, source *models.DataSource, target *models.DataSource) *models.Correlation {
+		cmd := models.CreateCorrelationCommand{
+			SourceDatasourceId: source.Id,
+			TargetDatasourceId: target.Id,
+			OrgId:              1,
+			Label:              "Show trace",
+			Field:              "traceId",
+			Target:             simplejson.NewFromAny(map[string]interface{}{"query": "${__value.raw}"}),
+		}
+		err := CreateCorrelation(&cmd)
+		require.NoError(t, err)
+		return cmd.Result
+	}
+
+	t.Run("Can create and list correlations of a data source", func(t *testing.T) {
+		InitTestDB(t)
+		logs := addDataSource(t, "logs")
+		traces := addDataSource(t, "traces")
+
+		correlation := createCorrelation(t, logs, traces)
+		require.NotEmpty(t, correlation.Uid)
+
+		query := models.GetCorrelationsBySourceQuery{SourceDatasourceId: logs.Id, OrgId: 1}
+		err := GetCorrelationsBySource(&query)
+		require.NoError(t, err)
+		require.Len(t, query.Result, 1)
+		require.Equal(t, correlation.Uid, query.Result[0].Uid)
+		require.Equal(t, traces.Uid, query.Result[0].TargetDatasourceUid)
+		require.Equal(t, "${__value.raw}", query.Result[0].Target.Get("query").MustString())
+
+		query = models.GetCorrelationsBySourceQuery{SourceDatasourceId: traces.Id, OrgId: 1}
+		err = GetCorrelationsBySource(&query)
+		require.NoError(t, err)
+		require.Len(t, query.Result, 0)
+	})
+
+	t.Run("Cannot create a correlation to a missing data source", func(t *testing.T) {
+		InitTestDB(t)
+		logs := addDataSource(t, "logs")
+
+		err := CreateCorrelation(&models.CreateCorrelationCommand{
+			SourceDatasourceId: logs.Id,
+			TargetDatasourceId: 999,
+			OrgId:              1,
+			Label:              "Show trace",
+			Field:              "traceId",
+			Target:             simplejson.New(),
+		})
+		require.Equal(t, models.ErrCorrelationTargetDataSourceMissing, err)
+	})
+
+	t.Run("Can update and delete a correlation", func(t *testing.T) {
+		InitTestDB(t)
+		logs := addDataSource(t, "logs")
+		traces := addDataSource(t, "traces")
+		correlation := createCorrelation(t, logs, traces)
+
+		err := UpdateCorrelation(&models.UpdateCorrelationCommand{
+			Uid:                correlation.Uid,
+			SourceDatasourceId: logs.Id,
+			OrgId:              1,
+			Label:              "Open trace",
+			Field:              "trace_id",
+			Target:             simplejson.New(),
+		})
+		require.NoError(t, err)
+
+		query := models.GetCorrelationQuery{Uid: correlation.Uid, SourceDatasourceId: logs.Id, OrgId: 1}
+		err = GetCorrelation(&query)
+		require.NoError(t, err)
+		require.Equal(t, "Open trace", query.Result.Label)
+		require.Equal(t, "trace_id", query.Result.Field)
+
+		err = DeleteCorrelation(&models.DeleteCorrelationCommand{Uid: correlation.Uid, SourceDatasourceId: logs.Id, OrgId: 1})
+		require.NoError(t, err)
+
+		err = GetCorrelation(&query)
+		require.Equal(t, models.ErrCorrelationNotFound, err)
+	})
+
+	t.Run("Deleting a data source deletes its correlations", func(t *testing.T) {
+		InitTestDB(t)
+		logs := addDataSource(t, "logs")
+		traces := addDataSource(t, "traces")
+		createCorrelation(t, logs, traces)
+
+		err := DeleteDataSourceByName(&models.DeleteDataSourceByNameCommand{Name: "traces", OrgId: 1})
+		require.NoError(t, err)
+
+		query := models.GetCorrelationsBySourceQuery{SourceDatasourceId: logs.Id, OrgId: 1}
+		err = GetCorrelationsBySource(&query)
+		require.NoError(t, err)
+		require.Len(t, query.Result, 0)
+
+		count, err := x.Table("correlation").Count()
+		require.NoError(t, err)
+		require.Equal(t, int64(0), count)
+	})
+}
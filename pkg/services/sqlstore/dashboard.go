@@ -9,10 +9,12 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/search"
 	"github.com/grafana/grafana/pkg/util"
+	"xorm.io/xorm"
 )
 
 var shadowSearchCounter = prometheus.NewCounterVec(
@@ -96,14 +98,13 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 	var affectedRows int64
 	var err error
 
-	if dash.Id == 0 {
+	isNew := dash.Id == 0
+	if isNew {
 		dash.SetVersion(1)
 		dash.Created = time.Now()
 		dash.CreatedBy = userId
 		dash.Updated = time.Now()
 		dash.UpdatedBy = userId
-		metrics.MApiDashboardInsert.Inc()
-		affectedRows, err = sess.Insert(dash)
 	} else {
 		dash.SetVersion(dash.Version + 1)
 
@@ -114,9 +115,23 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 		}
 
 		dash.UpdatedBy = userId
+	}
 
+	// the sensitive fields are stored encrypted, while the saved dashboard keeps them decrypted
+	data := dash.Data
+	encryptedData, err := models.EncryptSensitiveFields(data)
+	if err != nil {
+		return err
+	}
+
+	dash.Data = encryptedData
+	if isNew {
+		metrics.MApiDashboardInsert.Inc()
+		affectedRows, err = sess.Insert(dash)
+	} else {
 		affectedRows, err = sess.MustCols("folder_id").ID(dash.Id).Update(dash)
 	}
+	dash.Data = data
 
 	if err != nil {
 		return err
@@ -134,7 +149,7 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 		Created:       time.Now(),
 		CreatedBy:     dash.UpdatedBy,
 		Message:       cmd.Message,
-		Data:          dash.Data,
+		Data:          encryptedData,
 	}
 
 	// insert version entry
@@ -162,6 +177,16 @@ func saveDashboard(sess *DBSession, cmd *models.SaveDashboardCommand) error {
 
 	cmd.Result = dash
 
+	sess.publishAfterCommit(&events.DashboardSaved{
+		Timestamp: dash.Updated,
+		Id:        dash.Id,
+		Uid:       dash.Uid,
+		OrgId:     dash.OrgId,
+		Title:     dash.Title,
+		Version:   dash.Version,
+		UpdatedBy: dash.UpdatedBy,
+	})
+
 	return err
 }
 
@@ -198,10 +223,21 @@ func GetDashboard(query *models.GetDashboardQuery) error {
 
 	dashboard.SetId(dashboard.Id)
 	dashboard.SetUid(dashboard.Uid)
+	decryptSensitiveFields(&dashboard)
 	query.Result = &dashboard
 	return nil
 }
 
+// decryptSensitiveFields decrypts the sensitive fields of the loaded dashboards. The fields which
+// can't be decrypted, e.g. because their data key is lost, are left out.
+func decryptSensitiveFields(dashboards ...*models.Dashboard) {
+	for _, dash := range dashboards {
+		if err := models.DecryptSensitiveFields(dash.Data); err != nil {
+			sqlog.Warn("Failed to decrypt sensitive fields of dashboard", "id", dash.Id, "uid", dash.Uid, "error", err)
+		}
+	}
+}
+
 type DashboardSearchProjection struct {
 	Id          int64
 	Uid         string
@@ -272,7 +308,10 @@ func findDashboards(query *search.FindPersistedDashboardsQuery) ([]DashboardSear
 	}
 
 	sql, params := sb.ToSql(limit, page)
-	err := x.SQL(sql, params...).Find(&res)
+	err := withReadEngine(func(engine *xorm.Engine) error {
+		res = nil
+		return engine.SQL(sql, params...).Find(&res)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -369,24 +408,59 @@ func DeleteDashboard(cmd *models.DeleteDashboardCommand) error {
 			"DELETE FROM dashboard_version WHERE dashboard_id = ?",
 			"DELETE FROM annotation WHERE dashboard_id = ?",
 			"DELETE FROM dashboard_provisioning WHERE dashboard_id = ?",
+			"DELETE FROM library_panel_dashboard WHERE dashboard_id = ?",
 		}
 
 		if dashboard.IsFolder {
-			deletes = append(deletes, "DELETE FROM dashboard_provisioning WHERE dashboard_id in (select id from dashboard where folder_id = ?)")
-			deletes = append(deletes, "DELETE FROM dashboard WHERE folder_id = ?")
+			folderDeletes := []string{
+				"DELETE FROM dashboard_provisioning WHERE dashboard_id in (select id from dashboard where folder_id = ?)",
+				"DELETE FROM library_panel_dashboard WHERE dashboard_id in (select id from dashboard where folder_id = ?)",
+				"DELETE FROM library_panel_dashboard WHERE library_panel_id in (select id from library_panel where folder_id = ?)",
+				"DELETE FROM library_panel WHERE folder_id = ?",
+				"DELETE FROM dashboard WHERE folder_id = ?",
+			}
+
+			// subfolders are deleted together with their content
+			folderIds, err := getFolderTreeIds(sess, dashboard.OrgId, dashboard.Id)
+			if err != nil {
+				return err
+			}
 
-			dashIds := []struct {
-				Id int64
-			}{}
-			err := sess.SQL("select id from dashboard where folder_id = ?", dashboard.Id).Find(&dashIds)
+			// the library panels of the folders are deleted with them, unless dashboards outside
+			// of the folders still use them
+			inUse, err := sess.Table("library_panel_dashboard").
+				Join("INNER", "library_panel", "library_panel.id = library_panel_dashboard.library_panel_id").
+				Join("INNER", "dashboard", "dashboard.id = library_panel_dashboard.dashboard_id").
+				In("library_panel.folder_id", folderIds).
+				NotIn("dashboard.folder_id", folderIds).
+				Count()
 			if err != nil {
 				return err
 			}
+			if inUse > 0 {
+				return models.ErrFolderHasConnectedLibraryPanels
+			}
 
-			for _, id := range dashIds {
-				if err := deleteAlertDefinition(id.Id, sess); err != nil {
+			for _, folderId := range folderIds {
+				dashIds := []struct {
+					Id int64
+				}{}
+				err := sess.SQL("select id from dashboard where folder_id = ?", folderId).Find(&dashIds)
+				if err != nil {
 					return err
 				}
+
+				for _, id := range dashIds {
+					if err := deleteAlertDefinition(id.Id, sess); err != nil {
+						return err
+					}
+				}
+
+				for _, sql := range folderDeletes {
+					if _, err := sess.Exec(sql, folderId); err != nil {
+						return err
+					}
+				}
 			}
 		}
 
@@ -407,15 +481,25 @@ func DeleteDashboard(cmd *models.DeleteDashboardCommand) error {
 }
 
 func GetDashboards(query *models.GetDashboardsQuery) error {
-	if len(query.DashboardIds) == 0 {
+	if len(query.DashboardIds) == 0 && len(query.DashboardUIds) == 0 {
 		return models.ErrCommandValidationFailed
 	}
 
 	var dashboards = make([]*models.Dashboard, 0)
 
-	err := x.In("id", query.DashboardIds).Find(&dashboards)
+	var err error
+	if len(query.DashboardIds) > 0 {
+		err = x.In("id", query.DashboardIds).Find(&dashboards)
+	} else {
+		err = x.Where("org_id = ?", query.OrgId).In("uid", query.DashboardUIds).Find(&dashboards)
+	}
+	if err != nil {
+		return err
+	}
+
+	decryptSensitiveFields(dashboards...)
 	query.Result = dashboards
-	return err
+	return nil
 }
 
 // GetDashboardPermissionsForUser returns the maximum permission the specified user has for a dashboard(s)
@@ -444,7 +528,8 @@ func GetDashboardPermissionsForUser(query *models.GetDashboardPermissionsForUser
 	// check dashboards that have ACLs via user id, team id or role
 	sql := `SELECT d.id AS dashboard_id, MAX(COALESCE(da.permission, pt.permission)) AS permission
 	FROM dashboard AS d
-		LEFT JOIN dashboard_acl as da on d.folder_id = da.dashboard_id or d.id = da.dashboard_id
+		` + permissions.FolderAncestorsJoin("d") + `
+		LEFT JOIN dashboard_acl as da on ` + permissions.DashboardOrAncestorsAclCondition("d", "da") + `
 		LEFT JOIN team_member as ugm on ugm.team_id =  da.team_id
 		LEFT JOIN org_user ou ON ou.role = da.role AND ou.user_id = ?
 	`
@@ -492,9 +577,13 @@ func GetDashboardsByPluginId(query *models.GetDashboardsByPluginIdQuery) error {
 	var dashboards = make([]*models.Dashboard, 0)
 	whereExpr := "org_id=? AND plugin_id=? AND is_folder=" + dialect.BooleanStr(false)
 
-	err := x.Where(whereExpr, query.OrgId, query.PluginId).Find(&dashboards)
+	if err := x.Where(whereExpr, query.OrgId, query.PluginId).Find(&dashboards); err != nil {
+		return err
+	}
+
+	decryptSensitiveFields(dashboards...)
 	query.Result = dashboards
-	return err
+	return nil
 }
 
 type DashboardSlugDTO struct {
@@ -524,6 +613,7 @@ func GetDashboardsBySlug(query *models.GetDashboardsBySlugQuery) error {
 		return err
 	}
 
+	decryptSensitiveFields(dashboards...)
 	query.Result = dashboards
 	return nil
 }
@@ -613,7 +703,7 @@ func getExistingDashboardByIdOrUidForUpdate(sess *DBSession, cmd *models.Validat
 		return models.ErrDashboardTypeMismatch
 	}
 
-	if !dash.IsFolder && dash.FolderId != existing.FolderId {
+	if dash.FolderId != existing.FolderId {
 		cmd.Result.IsParentFolderChanged = true
 	}
 
@@ -638,7 +728,14 @@ func getExistingDashboardByTitleAndFolder(sess *DBSession, cmd *models.ValidateD
 	dash := cmd.Dashboard
 	var existing models.Dashboard
 
-	exists, err := sess.Where("org_id=? AND slug=? AND (is_folder=? OR folder_id=?)", dash.OrgId, dash.Slug, dialect.BooleanStr(true), dash.FolderId).Get(&existing)
+	var exists bool
+	var err error
+	if dash.IsFolder {
+		// folder names only have to be unique among the content of the parent folder
+		exists, err = sess.Where("org_id=? AND slug=? AND folder_id=?", dash.OrgId, dash.Slug, dash.FolderId).Get(&existing)
+	} else {
+		exists, err = sess.Where("org_id=? AND slug=? AND ((is_folder=? AND folder_id=0) OR folder_id=?)", dash.OrgId, dash.Slug, dialect.BooleanStr(true), dash.FolderId).Get(&existing)
+	}
 	if err != nil {
 		return err
 	}
@@ -680,10 +777,87 @@ func ValidateDashboardBeforeSave(cmd *models.ValidateDashboardBeforeSaveCommand)
 			return err
 		}
 
+		if err = validateFolderNesting(sess, cmd.Dashboard); err != nil {
+			return err
+		}
+
 		return nil
 	})
 }
 
+// validateFolderNesting makes sure that a folder is not moved into itself or one of its
+// subfolders and that no dashboard ends up nested in more than models.MaxFolderNestingDepth folders.
+func validateFolderNesting(sess *DBSession, dash *models.Dashboard) error {
+	depth := 0
+	for parentId := dash.FolderId; parentId > 0; depth++ {
+		if dash.IsFolder && parentId == dash.Id {
+			return models.ErrDashboardFolderCannotHaveParent
+		}
+
+		if depth >= models.MaxFolderNestingDepth {
+			return models.ErrDashboardFolderNestingTooDeep
+		}
+
+		var parent models.Dashboard
+		exists, err := sess.Where("org_id=? AND id=?", dash.OrgId, parentId).Get(&parent)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return models.ErrDashboardFolderNotFound
+		}
+
+		parentId = parent.FolderId
+	}
+
+	if !dash.IsFolder {
+		return nil
+	}
+
+	// the folder itself and every level of subfolders below it add to the depth
+	folderIds := []int64{dash.Id}
+	for len(folderIds) > 0 {
+		depth++
+		if depth > models.MaxFolderNestingDepth {
+			return models.ErrDashboardFolderNestingTooDeep
+		}
+
+		if dash.Id == 0 {
+			break
+		}
+
+		childIds := make([]int64, 0)
+		err := sess.Table("dashboard").Cols("id").Where("org_id=? AND is_folder=?", dash.OrgId, dialect.BooleanStr(true)).In("folder_id", folderIds).Find(&childIds)
+		if err != nil {
+			return err
+		}
+
+		folderIds = childIds
+	}
+
+	return nil
+}
+
+// getFolderTreeIds returns the id of the folder together with the ids of all its subfolders
+func getFolderTreeIds(sess *DBSession, orgId int64, folderId int64) ([]int64, error) {
+	result := []int64{folderId}
+	folderIds := []int64{folderId}
+
+	for len(folderIds) > 0 {
+		childIds := make([]int64, 0)
+		err := sess.Table("dashboard").Cols("id").Where("org_id=? AND is_folder=?", orgId, dialect.BooleanStr(true)).In("folder_id", folderIds).Find(&childIds)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, childIds...)
+		folderIds = childIds
+	}
+
+	return result, nil
+}
+
 func HasEditPermissionInFolders(query *models.HasEditPermissionInFoldersQuery) error {
 	if query.SignedInUser.HasRole(models.ROLE_EDITOR) {
 		query.Result = true
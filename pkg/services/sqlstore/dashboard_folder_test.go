@@ -1,6 +1,7 @@
 package sqlstore
 
 import (
+	"fmt"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -468,5 +469,123 @@ func TestDashboardFolderDataAccess(t *testing.T) {
 				})
 			})
 		})
+
+		Convey("Given nested dashboard folders", func() {
+			parentFolder := insertTestDashboard("parent folder", 1, 0, true)
+			childFolder := insertTestDashboard("child folder", 1, parentFolder.Id, true)
+			dashInChildFolder := insertTestDashboard("dash in child folder", 1, childFolder.Id, false)
+
+			currentUser := createUser("viewer", "Viewer", false)
+			signedInUser := &models.SignedInUser{UserId: currentUser.Id, OrgId: 1, OrgRole: models.ROLE_VIEWER}
+
+			Convey("and acl is set for the parent folder", func() {
+				var otherUser int64 = 999
+				err := testHelperUpdateDashboardAcl(parentFolder.Id, models.DashboardAcl{
+					DashboardId: parentFolder.Id,
+					OrgId:       1,
+					UserId:      otherUser,
+					Permission:  models.PERMISSION_EDIT,
+				})
+				So(err, ShouldBeNil)
+
+				Convey("should not return the child folder or its dashboards", func() {
+					query := &search.FindPersistedDashboardsQuery{
+						SignedInUser: signedInUser,
+						OrgId:        1,
+						DashboardIds: []int64{childFolder.Id, dashInChildFolder.Id},
+					}
+					err := SearchDashboards(query)
+					So(err, ShouldBeNil)
+					So(len(query.Result), ShouldEqual, 0)
+				})
+
+				Convey("should inherit the parent folder acl in the child folder dashboards", func() {
+					query := models.GetDashboardAclInfoListQuery{DashboardId: dashInChildFolder.Id, OrgId: 1}
+					err := GetDashboardAclInfoList(&query)
+					So(err, ShouldBeNil)
+					So(len(query.Result), ShouldEqual, 1)
+					So(query.Result[0].DashboardId, ShouldEqual, parentFolder.Id)
+					So(query.Result[0].Inherited, ShouldBeTrue)
+				})
+
+				Convey("and the user is given view permission on the parent folder", func() {
+					err := testHelperUpdateDashboardAcl(parentFolder.Id, models.DashboardAcl{
+						DashboardId: parentFolder.Id,
+						OrgId:       1,
+						UserId:      currentUser.Id,
+						Permission:  models.PERMISSION_VIEW,
+					})
+					So(err, ShouldBeNil)
+
+					Convey("should return the child folder and its dashboards", func() {
+						query := &search.FindPersistedDashboardsQuery{
+							SignedInUser: signedInUser,
+							OrgId:        1,
+							DashboardIds: []int64{childFolder.Id, dashInChildFolder.Id},
+						}
+						err := SearchDashboards(query)
+						So(err, ShouldBeNil)
+						So(len(query.Result), ShouldEqual, 2)
+					})
+				})
+			})
+
+			Convey("should not be able to move the parent folder into its child folder", func() {
+				parentFolder.FolderId = childFolder.Id
+				err := ValidateDashboardBeforeSave(&models.ValidateDashboardBeforeSaveCommand{
+					OrgId:     1,
+					Dashboard: parentFolder,
+				})
+				So(err, ShouldEqual, models.ErrDashboardFolderCannotHaveParent)
+			})
+
+			Convey("should not be able to nest folders deeper than the max depth", func() {
+				folder := childFolder
+				for i := 2; i < models.MaxFolderNestingDepth; i++ {
+					folder = insertTestDashboard(fmt.Sprintf("nested folder %d", i), 1, folder.Id, true)
+				}
+
+				tooDeep := models.NewDashboardFolder("too deep")
+				tooDeep.OrgId = 1
+				tooDeep.FolderId = folder.Id
+				tooDeep.UpdateSlug()
+				err := ValidateDashboardBeforeSave(&models.ValidateDashboardBeforeSaveCommand{
+					OrgId:     1,
+					Dashboard: tooDeep,
+				})
+				So(err, ShouldEqual, models.ErrDashboardFolderNestingTooDeep)
+
+				dash := models.NewDashboard("deepest dashboard")
+				dash.OrgId = 1
+				dash.FolderId = folder.Id
+				dash.UpdateSlug()
+				err = ValidateDashboardBeforeSave(&models.ValidateDashboardBeforeSaveCommand{
+					OrgId:     1,
+					Dashboard: dash,
+				})
+				So(err, ShouldBeNil)
+			})
+
+			Convey("should allow folders with the same name in different parent folders", func() {
+				folder := models.NewDashboardFolder("child folder")
+				folder.OrgId = 1
+				folder.UpdateSlug()
+				err := ValidateDashboardBeforeSave(&models.ValidateDashboardBeforeSaveCommand{
+					OrgId:     1,
+					Dashboard: folder,
+				})
+				So(err, ShouldBeNil)
+			})
+
+			Convey("should delete subfolders and their dashboards when deleting the parent folder", func() {
+				err := DeleteDashboard(&models.DeleteDashboardCommand{Id: parentFolder.Id, OrgId: 1})
+				So(err, ShouldBeNil)
+
+				query := models.GetDashboardsQuery{DashboardIds: []int64{childFolder.Id, dashInChildFolder.Id}}
+				err = GetDashboards(&query)
+				So(err, ShouldBeNil)
+				So(len(query.Result), ShouldEqual, 0)
+			})
+		})
 	})
 }
@@ -10,6 +10,7 @@ func init() {
 	bus.AddHandler("sql", SaveProvisionedDashboard)
 	bus.AddHandler("sql", GetProvisionedDataByDashboardId)
 	bus.AddHandler("sql", UnprovisionDashboard)
+	bus.AddHandler("sql", ResetDashboardProvisioning)
 }
 
 type DashboardExtras struct {
@@ -72,7 +73,14 @@ func saveProvisionedData(sess *DBSession, cmd *models.DashboardProvisioning, das
 func GetProvisionedDashboardDataQuery(cmd *models.GetProvisionedDashboardDataQuery) error {
 	var result []*models.DashboardProvisioning
 
-	if err := x.Where("name = ?", cmd.Name).Find(&result); err != nil {
+	sess := x.NewSession()
+	defer sess.Close()
+
+	if cmd.Name != "" {
+		sess.Where("name = ?", cmd.Name)
+	}
+
+	if err := sess.Find(&result); err != nil {
 		return err
 	}
 
@@ -88,3 +96,10 @@ func UnprovisionDashboard(cmd *models.UnprovisionDashboardCommand) error {
 	}
 	return nil
 }
+
+// ResetDashboardProvisioning clears the checksum and the updated time of the provisioned file, so that the
+// dashboard is saved again even if the file didn't change.
+func ResetDashboardProvisioning(cmd *models.ResetDashboardProvisioningCommand) error {
+	_, err := x.Exec("UPDATE dashboard_provisioning SET check_sum = ?, updated = ? WHERE dashboard_id = ?", "", 0, cmd.DashboardId)
+	return err
+}
@@ -96,6 +96,23 @@ func TestDashboardProvisioningTest(t *testing.T) {
 				So(query.Result, ShouldBeNil)
 			})
 
+			Convey("Can query for the provisioned dashboards of all provisioners", func() {
+				query := &models.GetProvisionedDashboardDataQuery{}
+				err := GetProvisionedDashboardDataQuery(query)
+				So(err, ShouldBeNil)
+				So(len(query.Result), ShouldEqual, 1)
+			})
+
+			Convey("ResetDashboardProvisioning should clear the checksum and updated time", func() {
+				So(ResetDashboardProvisioning(&models.ResetDashboardProvisioningCommand{DashboardId: dashId}), ShouldBeNil)
+
+				query := &models.GetProvisionedDashboardDataByIdQuery{DashboardId: dashId}
+				err = GetProvisionedDataByDashboardId(query)
+				So(err, ShouldBeNil)
+				So(query.Result.Updated, ShouldEqual, 0)
+				So(query.Result.CheckSum, ShouldEqual, "")
+			})
+
 			Convey("UnprovisionDashboard should delete provisioning metadata", func() {
 				unprovisionCmd := &models.UnprovisionDashboardCommand{
 					Id: dashId,
@@ -1,6 +1,7 @@
 package sqlstore
 
 import (
+	"strings"
 	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
@@ -14,27 +15,64 @@ func init() {
 	bus.AddHandler("sql", DeleteDashboardSnapshot)
 	bus.AddHandler("sql", SearchDashboardSnapshots)
 	bus.AddHandler("sql", DeleteExpiredSnapshots)
+	bus.AddHandler("sql", SearchAllDashboardSnapshots)
 }
 
+const MAX_SNAPSHOTS_TO_DELETE_PER_BATCH = 100
+const MAX_SNAPSHOT_DELETION_BATCHES = 50
+
 // DeleteExpiredSnapshots removes snapshots with old expiry dates.
 // SnapShotRemoveExpired is deprecated and should be removed in the future.
 // Snapshot expiry is decided by the user when they share the snapshot.
 func DeleteExpiredSnapshots(cmd *models.DeleteExpiredSnapshotsCommand) error {
-	return inTransaction(func(sess *DBSession) error {
-		if !setting.SnapShotRemoveExpired {
-			sqlog.Warn("[Deprecated] The snapshot_remove_expired setting is outdated. Please remove from your config.")
-			return nil
-		}
+	if !setting.SnapShotRemoveExpired {
+		sqlog.Warn("[Deprecated] The snapshot_remove_expired setting is outdated. Please remove from your config.")
+		return nil
+	}
 
-		deleteExpiredSql := "DELETE FROM dashboard_snapshot WHERE expires < ?"
-		expiredResponse, err := sess.Exec(deleteExpiredSql, time.Now())
-		if err != nil {
+	return deleteExpiredSnapshots(cmd, time.Now(), MAX_SNAPSHOTS_TO_DELETE_PER_BATCH, MAX_SNAPSHOT_DELETION_BATCHES)
+}
+
+// deleteExpiredSnapshots removes snapshots that expired before `now` in batches
+// so that a large backlog of expired snapshots doesn't end up in one huge transaction.
+func deleteExpiredSnapshots(cmd *models.DeleteExpiredSnapshotsCommand, now time.Time, perBatch int, maxBatches int) error {
+	for batch := 0; batch < maxBatches; batch++ {
+		deleted := int64(0)
+
+		batchErr := inTransaction(func(sess *DBSession) error {
+			var snapshotIdsToDelete []interface{}
+			err := sess.SQL("SELECT id FROM dashboard_snapshot WHERE expires < ? ORDER BY expires ASC "+dialect.Limit(int64(perBatch)), now).Find(&snapshotIdsToDelete)
+			if err != nil {
+				return err
+			}
+
+			if len(snapshotIdsToDelete) < 1 {
+				return nil
+			}
+
+			deleteExpiredSql := `DELETE FROM dashboard_snapshot WHERE id IN (?` + strings.Repeat(",?", len(snapshotIdsToDelete)-1) + `)`
+			sqlOrArgs := append([]interface{}{deleteExpiredSql}, snapshotIdsToDelete...)
+			expiredResponse, err := sess.Exec(sqlOrArgs...)
+			if err != nil {
+				return err
+			}
+
+			deleted, err = expiredResponse.RowsAffected()
 			return err
+		})
+
+		if batchErr != nil {
+			return batchErr
 		}
-		cmd.DeletedRows, _ = expiredResponse.RowsAffected()
 
-		return nil
-	})
+		cmd.DeletedRows += deleted
+
+		if deleted < int64(perBatch) {
+			break
+		}
+	}
+
+	return nil
 }
 
 func CreateDashboardSnapshot(cmd *models.CreateDashboardSnapshotCommand) error {
@@ -116,3 +154,76 @@ func SearchDashboardSnapshots(query *models.GetDashboardSnapshotsQuery) error {
 	query.Result = snapshots
 	return err
 }
+
+// SearchAllDashboardSnapshots returns a page of snapshots across all organizations
+// together with their creator and the size of the stored dashboard. It is meant
+// for server admins managing snapshot storage.
+func SearchAllDashboardSnapshots(query *models.SearchAllDashboardSnapshotsQuery) error {
+	query.Result = models.SearchAllDashboardSnapshotsQueryResult{
+		Snapshots: make([]*models.AdminDashboardSnapshotDTO, 0),
+	}
+
+	whereConditions := make([]string, 0)
+	whereParams := make([]interface{}, 0)
+
+	if query.OrgId > 0 {
+		whereConditions = append(whereConditions, "dashboard_snapshot.org_id = ?")
+		whereParams = append(whereParams, query.OrgId)
+	}
+
+	if query.Query != "" {
+		queryWithWildcards := "%" + query.Query + "%"
+		whereConditions = append(whereConditions, "(dashboard_snapshot.name "+dialect.LikeStr()+" ? OR dashboard_snapshot.key "+dialect.LikeStr()+" ?)")
+		whereParams = append(whereParams, queryWithWildcards, queryWithWildcards)
+	}
+
+	if query.UserId > 0 {
+		whereConditions = append(whereConditions, "dashboard_snapshot.user_id = ?")
+		whereParams = append(whereParams, query.UserId)
+	}
+
+	if query.Expired != nil {
+		if *query.Expired {
+			whereConditions = append(whereConditions, "dashboard_snapshot.expires < ?")
+		} else {
+			whereConditions = append(whereConditions, "dashboard_snapshot.expires >= ?")
+		}
+		whereParams = append(whereParams, time.Now())
+	}
+
+	sess := x.Table("dashboard_snapshot").
+		Select(`dashboard_snapshot.id,
+				dashboard_snapshot.name,
+				dashboard_snapshot.`+dialect.Quote("key")+`,
+				dashboard_snapshot.org_id,
+				dashboard_snapshot.user_id,
+				dashboard_snapshot.external,
+				dashboard_snapshot.external_url,
+				dashboard_snapshot.expires,
+				dashboard_snapshot.created,
+				dashboard_snapshot.updated,
+				LENGTH(dashboard_snapshot.dashboard) AS size,`+
+			dialect.Quote("user")+`.login AS created_by,`+
+			dialect.Quote("user")+`.email AS created_by_email`).
+		Join("LEFT", dialect.Quote("user"), `dashboard_snapshot.user_id = `+dialect.Quote("user")+`.id`)
+
+	if len(whereConditions) > 0 {
+		sess.Where(strings.Join(whereConditions, " AND "), whereParams...)
+	}
+
+	offset := query.Limit * (query.Page - 1)
+	sess.Limit(query.Limit, offset)
+	sess.OrderBy("dashboard_snapshot.created DESC")
+	if err := sess.Find(&query.Result.Snapshots); err != nil {
+		return err
+	}
+
+	countSess := x.Table("dashboard_snapshot")
+	if len(whereConditions) > 0 {
+		countSess.Where(strings.Join(whereConditions, " AND "), whereParams...)
+	}
+
+	count, err := countSess.Count(&models.DashboardSnapshot{})
+	query.Result.TotalCount = count
+	return err
+}
@@ -1,6 +1,7 @@
 package sqlstore
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -146,6 +147,90 @@ func TestDeleteExpiredSnapshots(t *testing.T) {
 	})
 }
 
+func TestDeleteExpiredSnapshotsInBatches(t *testing.T) {
+	sqlstore := InitTestDB(t)
+
+	Convey("Testing dashboard snapshots clean up in batches", t, func() {
+		createTestSnapshot(sqlstore, "key1", 48000)
+		for i := 0; i < 5; i++ {
+			createTestSnapshot(sqlstore, fmt.Sprintf("expired%d", i), -1200)
+		}
+
+		Convey("Should stop after the max number of batches", func() {
+			cmd := &models.DeleteExpiredSnapshotsCommand{}
+			err := deleteExpiredSnapshots(cmd, time.Now(), 2, 2)
+			So(err, ShouldBeNil)
+			So(cmd.DeletedRows, ShouldEqual, 4)
+
+			cmd = &models.DeleteExpiredSnapshotsCommand{}
+			err = deleteExpiredSnapshots(cmd, time.Now(), 2, 2)
+			So(err, ShouldBeNil)
+			So(cmd.DeletedRows, ShouldEqual, 1)
+		})
+	})
+}
+
+func TestSearchAllDashboardSnapshots(t *testing.T) {
+	Convey("Testing admin snapshot search", t, func() {
+		sqlstore := InitTestDB(t)
+		createTestSnapshot(sqlstore, "key1", 48000)
+		createTestSnapshot(sqlstore, "key2", -1200)
+
+		cmd := models.CreateDashboardSnapshotCommand{
+			Name:      "other org",
+			Key:       "key3",
+			DeleteKey: "deletekey3",
+			Dashboard: simplejson.NewFromAny(map[string]interface{}{
+				"hello": "mupp",
+			}),
+			UserId: 1000,
+			OrgId:  2,
+		}
+		err := CreateDashboardSnapshot(&cmd)
+		So(err, ShouldBeNil)
+
+		Convey("Should return snapshots from all orgs with their size", func() {
+			query := models.SearchAllDashboardSnapshotsQuery{Page: 1, Limit: 10}
+			err := SearchAllDashboardSnapshots(&query)
+			So(err, ShouldBeNil)
+
+			So(query.Result.TotalCount, ShouldEqual, 3)
+			So(len(query.Result.Snapshots), ShouldEqual, 3)
+			for _, snapshot := range query.Result.Snapshots {
+				So(snapshot.Size, ShouldBeGreaterThan, 0)
+			}
+		})
+
+		Convey("Should filter by org", func() {
+			query := models.SearchAllDashboardSnapshotsQuery{OrgId: 2, Page: 1, Limit: 10}
+			err := SearchAllDashboardSnapshots(&query)
+			So(err, ShouldBeNil)
+
+			So(query.Result.TotalCount, ShouldEqual, 1)
+			So(query.Result.Snapshots[0].Key, ShouldEqual, "key3")
+		})
+
+		Convey("Should filter by expiry", func() {
+			expired := true
+			query := models.SearchAllDashboardSnapshotsQuery{Expired: &expired, Page: 1, Limit: 10}
+			err := SearchAllDashboardSnapshots(&query)
+			So(err, ShouldBeNil)
+
+			So(query.Result.TotalCount, ShouldEqual, 1)
+			So(query.Result.Snapshots[0].Key, ShouldEqual, "key2")
+		})
+
+		Convey("Should page results", func() {
+			query := models.SearchAllDashboardSnapshotsQuery{Page: 2, Limit: 2}
+			err := SearchAllDashboardSnapshots(&query)
+			So(err, ShouldBeNil)
+
+			So(query.Result.TotalCount, ShouldEqual, 3)
+			So(len(query.Result.Snapshots), ShouldEqual, 1)
+		})
+	})
+}
+
 func createTestSnapshot(sqlstore *SqlStore, key string, expires int64) *models.DashboardSnapshot {
 	cmd := models.CreateDashboardSnapshotCommand{
 		Key:       key,
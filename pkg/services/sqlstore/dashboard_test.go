@@ -501,3 +501,51 @@ func moveDashboard(orgId int64, dashboard *simplejson.Json, newFolderId int64) *
 
 	return cmd.Result
 }
+
+func TestDashboard_SensitiveFields(t *testing.T) {
+	InitTestDB(t)
+
+	cmd := models.SaveDashboardCommand{
+		OrgId: 1,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{
+			"id":    nil,
+			"title": "Sensitive",
+			"panels": []interface{}{
+				map[string]interface{}{
+					"id":              1,
+					"sensitiveFields": []interface{}{"options.content"},
+					"options":         map[string]interface{}{"content": "token=secret"},
+				},
+			},
+		}),
+	}
+	err := SaveDashboard(&cmd)
+	require.NoError(t, err)
+	require.Equal(t, "token=secret", cmd.Result.Data.Get("panels").GetIndex(0).GetPath("options", "content").MustString())
+
+	var stored models.Dashboard
+	has, err := x.ID(cmd.Result.Id).Get(&stored)
+	require.NoError(t, err)
+	require.True(t, has)
+	encoded, err := stored.Data.Encode()
+	require.NoError(t, err)
+	require.NotContains(t, string(encoded), "token=secret")
+
+	var storedVersion models.DashboardVersion
+	has, err = x.Where("dashboard_id=?", cmd.Result.Id).Get(&storedVersion)
+	require.NoError(t, err)
+	require.True(t, has)
+	encoded, err = storedVersion.Data.Encode()
+	require.NoError(t, err)
+	require.NotContains(t, string(encoded), "token=secret")
+
+	query := models.GetDashboardQuery{Id: cmd.Result.Id, OrgId: 1}
+	err = GetDashboard(&query)
+	require.NoError(t, err)
+	require.Equal(t, "token=secret", query.Result.Data.Get("panels").GetIndex(0).GetPath("options", "content").MustString())
+
+	versionQuery := models.GetDashboardVersionQuery{DashboardId: cmd.Result.Id, OrgId: 1, Version: 1}
+	err = GetDashboardVersion(&versionQuery)
+	require.NoError(t, err)
+	require.Equal(t, "token=secret", versionQuery.Result.Data.Get("panels").GetIndex(0).GetPath("options", "content").MustString())
+}
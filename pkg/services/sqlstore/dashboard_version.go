@@ -6,6 +6,7 @@ import (
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
+	"xorm.io/xorm"
 )
 
 func init() {
@@ -16,10 +17,16 @@ func init() {
 
 // GetDashboardVersion gets the dashboard version for the given dashboard ID and version number.
 func GetDashboardVersion(query *models.GetDashboardVersionQuery) error {
-	version := models.DashboardVersion{}
-	has, err := x.Where("dashboard_version.dashboard_id=? AND dashboard_version.version=? AND dashboard.org_id=?", query.DashboardId, query.Version, query.OrgId).
-		Join("LEFT", "dashboard", `dashboard.id = dashboard_version.dashboard_id`).
-		Get(&version)
+	var version models.DashboardVersion
+	var has bool
+	err := withReadEngine(func(engine *xorm.Engine) error {
+		version = models.DashboardVersion{}
+		var err error
+		has, err = engine.Where("dashboard_version.dashboard_id=? AND dashboard_version.version=? AND dashboard.org_id=?", query.DashboardId, query.Version, query.OrgId).
+			Join("LEFT", "dashboard", `dashboard.id = dashboard_version.dashboard_id`).
+			Get(&version)
+		return err
+	})
 
 	if err != nil {
 		return err
@@ -30,6 +37,9 @@ func GetDashboardVersion(query *models.GetDashboardVersionQuery) error {
 	}
 
 	version.Data.Set("id", version.DashboardId)
+	if err := models.DecryptSensitiveFields(version.Data); err != nil {
+		sqlog.Warn("Failed to decrypt sensitive fields of dashboard version", "dashboardId", version.DashboardId, "version", version.Version, "error", err)
+	}
 	query.Result = &version
 	return nil
 }
@@ -40,8 +50,10 @@ func GetDashboardVersions(query *models.GetDashboardVersionsQuery) error {
 		query.Limit = 1000
 	}
 
-	err := x.Table("dashboard_version").
-		Select(`dashboard_version.id,
+	err := withReadEngine(func(engine *xorm.Engine) error {
+		query.Result = nil
+		return engine.Table("dashboard_version").
+			Select(`dashboard_version.id,
 				dashboard_version.dashboard_id,
 				dashboard_version.parent_version,
 				dashboard_version.restored_from,
@@ -50,13 +62,14 @@ func GetDashboardVersions(query *models.GetDashboardVersionsQuery) error {
 				dashboard_version.created_by as created_by_id,
 				dashboard_version.message,
 				dashboard_version.data,`+
-			dialect.Quote("user")+`.login as created_by`).
-		Join("LEFT", dialect.Quote("user"), `dashboard_version.created_by = `+dialect.Quote("user")+`.id`).
-		Join("LEFT", "dashboard", `dashboard.id = dashboard_version.dashboard_id`).
-		Where("dashboard_version.dashboard_id=? AND dashboard.org_id=?", query.DashboardId, query.OrgId).
-		OrderBy("dashboard_version.version DESC").
-		Limit(query.Limit, query.Start).
-		Find(&query.Result)
+				dialect.Quote("user")+`.login as created_by`).
+			Join("LEFT", dialect.Quote("user"), `dashboard_version.created_by = `+dialect.Quote("user")+`.id`).
+			Join("LEFT", "dashboard", `dashboard.id = dashboard_version.dashboard_id`).
+			Where("dashboard_version.dashboard_id=? AND dashboard.org_id=?", query.DashboardId, query.OrgId).
+			OrderBy("dashboard_version.version DESC").
+			Limit(query.Limit, query.Start).
+			Find(&query.Result)
+	})
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,263 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/encryption"
+	"github.com/grafana/grafana/pkg/components/securejsondata"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// reEncryptBatchSize is the number of dashboards, dashboard versions or library panels loaded at once by ReEncryptSecrets
+const reEncryptBatchSize = 100
+
+func init() {
+	bus.AddHandler("sql", CreateDataKey)
+	bus.AddHandler("sql", GetDataKey)
+	bus.AddHandler("sql", GetDataKeys)
+	bus.AddHandler("sql", ReEncryptSecrets)
+}
+
+func CreateDataKey(cmd *models.CreateDataKeyCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		if _, err := sess.Exec("UPDATE data_key SET active = ?, updated = ? WHERE active = ?", dialect.BooleanStr(false), time.Now(), dialect.BooleanStr(true)); err != nil {
+			return err
+		}
+
+		dataKey := &models.DataKey{
+			Name:          cmd.Name,
+			Provider:      cmd.Provider,
+			EncryptedData: cmd.EncryptedData,
+			Active:        true,
+			Created:       time.Now(),
+			Updated:       time.Now(),
+		}
+		if _, err := sess.Insert(dataKey); err != nil {
+			return err
+		}
+
+		cmd.Result = dataKey
+		return nil
+	})
+}
+
+func GetDataKey(query *models.GetDataKeyQuery) error {
+	dataKey := &models.DataKey{}
+	has, err := x.Where("name = ?", query.Name).Get(dataKey)
+	if err != nil {
+		return err
+	}
+	if !has {
+		return models.ErrDataKeyNotFound
+	}
+
+	query.Result = dataKey
+	return nil
+}
+
+func GetDataKeys(query *models.GetDataKeysQuery) error {
+	query.Result = make([]*models.DataKey, 0)
+	return x.Asc("id").Find(&query.Result)
+}
+
+// ReEncryptSecrets encrypts the secrets stored in the database again, e.g. with the active data
+// key after the data keys are rotated.
+func ReEncryptSecrets(cmd *models.ReEncryptSecretsCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		result := &models.ReEncryptSecretsResult{}
+
+		dataSources := make([]*models.DataSource, 0)
+		if err := sess.Cols("id", "secure_json_data").Find(&dataSources); err != nil {
+			return err
+		}
+		for _, ds := range dataSources {
+			if len(ds.SecureJsonData) == 0 {
+				continue
+			}
+
+			secureJsonData, err := reEncryptSecureJsonData(ds.SecureJsonData)
+			if err != nil {
+				return err
+			}
+			if _, err := sess.ID(ds.Id).Cols("secure_json_data").Update(&models.DataSource{SecureJsonData: secureJsonData}); err != nil {
+				return err
+			}
+			result.DataSources++
+		}
+
+		pluginSettings := make([]*models.PluginSetting, 0)
+		if err := sess.Cols("id", "secure_json_data").Find(&pluginSettings); err != nil {
+			return err
+		}
+		for _, ps := range pluginSettings {
+			if len(ps.SecureJsonData) == 0 {
+				continue
+			}
+
+			secureJsonData, err := reEncryptSecureJsonData(ps.SecureJsonData)
+			if err != nil {
+				return err
+			}
+			if _, err := sess.ID(ps.Id).Cols("secure_json_data").Update(&models.PluginSetting{SecureJsonData: secureJsonData}); err != nil {
+				return err
+			}
+			result.PluginSettings++
+		}
+
+		notifications := make([]*models.AlertNotification, 0)
+		if err := sess.Cols("id", "secure_settings").Find(&notifications); err != nil {
+			return err
+		}
+		for _, an := range notifications {
+			if len(an.SecureSettings) == 0 {
+				continue
+			}
+
+			secureSettings, err := reEncryptSecureJsonData(an.SecureSettings)
+			if err != nil {
+				return err
+			}
+			if _, err := sess.ID(an.Id).Cols("secure_settings").Update(&models.AlertNotification{SecureSettings: secureSettings}); err != nil {
+				return err
+			}
+			result.AlertNotifications++
+		}
+
+		userAuths := make([]*models.UserAuth, 0)
+		if err := sess.Cols("id", "o_auth_access_token", "o_auth_refresh_token", "o_auth_token_type").Find(&userAuths); err != nil {
+			return err
+		}
+		for _, ua := range userAuths {
+			if ua.OAuthAccessToken == "" && ua.OAuthRefreshToken == "" && ua.OAuthTokenType == "" {
+				continue
+			}
+
+			var err error
+			updated := &models.UserAuth{}
+			if updated.OAuthAccessToken, err = reEncryptAndEncode(ua.OAuthAccessToken); err != nil {
+				return err
+			}
+			if updated.OAuthRefreshToken, err = reEncryptAndEncode(ua.OAuthRefreshToken); err != nil {
+				return err
+			}
+			if updated.OAuthTokenType, err = reEncryptAndEncode(ua.OAuthTokenType); err != nil {
+				return err
+			}
+			if _, err := sess.ID(ua.Id).Cols("o_auth_access_token", "o_auth_refresh_token", "o_auth_token_type").Update(updated); err != nil {
+				return err
+			}
+			result.UserAuths++
+		}
+
+		// the dashboards, their versions and the library panels are read in batches, since they can be large
+		var lastID int64
+		for {
+			dashboards := make([]*models.Dashboard, 0)
+			if err := sess.Cols("id", "data").Where("id > ?", lastID).Asc("id").Limit(reEncryptBatchSize).Find(&dashboards); err != nil {
+				return err
+			}
+			for _, dash := range dashboards {
+				lastID = dash.Id
+				reEncrypted, err := models.ReEncryptSensitiveFields(dash.Data)
+				if err != nil {
+					return err
+				}
+				if !reEncrypted {
+					continue
+				}
+
+				if _, err := sess.ID(dash.Id).Cols("data").Update(&models.Dashboard{Data: dash.Data}); err != nil {
+					return err
+				}
+				result.Dashboards++
+			}
+			if len(dashboards) < reEncryptBatchSize {
+				break
+			}
+		}
+
+		lastID = 0
+		for {
+			versions := make([]*models.DashboardVersion, 0)
+			if err := sess.Cols("id", "data").Where("id > ?", lastID).Asc("id").Limit(reEncryptBatchSize).Find(&versions); err != nil {
+				return err
+			}
+			for _, version := range versions {
+				lastID = version.Id
+				reEncrypted, err := models.ReEncryptSensitiveFields(version.Data)
+				if err != nil {
+					return err
+				}
+				if !reEncrypted {
+					continue
+				}
+
+				if _, err := sess.ID(version.Id).Cols("data").Update(&models.DashboardVersion{Data: version.Data}); err != nil {
+					return err
+				}
+				result.DashboardVersions++
+			}
+			if len(versions) < reEncryptBatchSize {
+				break
+			}
+		}
+
+		lastID = 0
+		for {
+			libraryPanels := make([]*models.LibraryPanel, 0)
+			if err := sess.Cols("id", "model").Where("id > ?", lastID).Asc("id").Limit(reEncryptBatchSize).Find(&libraryPanels); err != nil {
+				return err
+			}
+			for _, libraryPanel := range libraryPanels {
+				lastID = libraryPanel.Id
+				reEncrypted, err := models.ReEncryptPanelSensitiveFields(libraryPanel.Model)
+				if err != nil {
+					return err
+				}
+				if !reEncrypted {
+					continue
+				}
+
+				if _, err := sess.ID(libraryPanel.Id).Cols("model").Update(&models.LibraryPanel{Model: libraryPanel.Model}); err != nil {
+					return err
+				}
+				result.LibraryPanels++
+			}
+			if len(libraryPanels) < reEncryptBatchSize {
+				break
+			}
+		}
+
+		cmd.Result = result
+		return nil
+	})
+}
+
+func reEncryptSecureJsonData(secureJsonData securejsondata.SecureJsonData) (securejsondata.SecureJsonData, error) {
+	reEncrypted := make(securejsondata.SecureJsonData)
+	for key, data := range secureJsonData {
+		decrypted, err := encryption.Decrypt(data)
+		if err != nil {
+			return nil, err
+		}
+
+		reEncrypted[key], err = encryption.Encrypt(decrypted)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return reEncrypted, nil
+}
+
+func reEncryptAndEncode(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+
+	decrypted, err := decodeAndDecrypt(s)
+	if err != nil {
+		return "", err
+	}
+	return encryptAndEncode(decrypted)
+}
@@ -0,0 +1,97 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReEncryptSecrets(t *testing.T) {
+	InitTestDB(t)
+
+	storedField := func(t *testing.T, data *simplejson.Json) string {
+		field := data.Get("panels").GetIndex(0).GetPath("options", "content").Get("$__encrypted").MustString()
+		require.NotEmpty(t, field)
+		return field
+	}
+
+	sensitive := models.SaveDashboardCommand{
+		OrgId: 1,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{
+			"id":    nil,
+			"title": "Sensitive",
+			"panels": []interface{}{
+				map[string]interface{}{
+					"id":              1,
+					"sensitiveFields": []interface{}{"options.content"},
+					"options":         map[string]interface{}{"content": "token=secret"},
+				},
+			},
+		}),
+	}
+	require.NoError(t, SaveDashboard(&sensitive))
+
+	plain := models.SaveDashboardCommand{
+		OrgId:     1,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"id": nil, "title": "Plain"}),
+	}
+	require.NoError(t, SaveDashboard(&plain))
+
+	libraryPanel := models.CreateLibraryPanelCommand{
+		OrgId: 1,
+		Name:  "Sensitive",
+		Model: simplejson.NewFromAny(map[string]interface{}{
+			"sensitiveFields": []interface{}{"options.content"},
+			"options":         map[string]interface{}{"content": "token=secret"},
+		}),
+	}
+	require.NoError(t, CreateLibraryPanel(&libraryPanel))
+	require.Equal(t, "token=secret", libraryPanel.Result.Model.GetPath("options", "content").MustString())
+
+	var stored models.Dashboard
+	_, err := x.ID(sensitive.Result.Id).Get(&stored)
+	require.NoError(t, err)
+	var storedVersion models.DashboardVersion
+	_, err = x.Where("dashboard_id=?", sensitive.Result.Id).Get(&storedVersion)
+	require.NoError(t, err)
+	var storedLibraryPanel models.LibraryPanel
+	_, err = x.ID(libraryPanel.Result.Id).Get(&storedLibraryPanel)
+	require.NoError(t, err)
+	storedPanelField := storedLibraryPanel.Model.GetPath("options", "content").Get("$__encrypted").MustString()
+	require.NotEmpty(t, storedPanelField)
+
+	cmd := &models.ReEncryptSecretsCommand{}
+	require.NoError(t, ReEncryptSecrets(cmd))
+	require.Equal(t, 1, cmd.Result.Dashboards)
+	require.Equal(t, 1, cmd.Result.DashboardVersions)
+	require.Equal(t, 1, cmd.Result.LibraryPanels)
+
+	var reEncrypted models.Dashboard
+	_, err = x.ID(sensitive.Result.Id).Get(&reEncrypted)
+	require.NoError(t, err)
+	require.NotEqual(t, storedField(t, stored.Data), storedField(t, reEncrypted.Data))
+
+	var reEncryptedVersion models.DashboardVersion
+	_, err = x.ID(storedVersion.Id).Get(&reEncryptedVersion)
+	require.NoError(t, err)
+	require.NotEqual(t, storedField(t, storedVersion.Data), storedField(t, reEncryptedVersion.Data))
+
+	query := models.GetDashboardQuery{Id: sensitive.Result.Id, OrgId: 1}
+	require.NoError(t, GetDashboard(&query))
+	require.Equal(t, "token=secret", query.Result.Data.Get("panels").GetIndex(0).GetPath("options", "content").MustString())
+
+	versionQuery := models.GetDashboardVersionQuery{DashboardId: sensitive.Result.Id, OrgId: 1, Version: 1}
+	require.NoError(t, GetDashboardVersion(&versionQuery))
+	require.Equal(t, "token=secret", versionQuery.Result.Data.Get("panels").GetIndex(0).GetPath("options", "content").MustString())
+
+	var reEncryptedLibraryPanel models.LibraryPanel
+	_, err = x.ID(libraryPanel.Result.Id).Get(&reEncryptedLibraryPanel)
+	require.NoError(t, err)
+	require.NotEqual(t, storedPanelField, reEncryptedLibraryPanel.Model.GetPath("options", "content").Get("$__encrypted").MustString())
+
+	libraryPanelQuery := models.GetLibraryPanelQuery{Uid: libraryPanel.Result.Uid, OrgId: 1}
+	require.NoError(t, GetLibraryPanel(&libraryPanelQuery))
+	require.Equal(t, "token=secret", libraryPanelQuery.Result.Model.GetPath("options", "content").MustString())
+}
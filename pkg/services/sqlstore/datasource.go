@@ -13,6 +13,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/securejsondata"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 )
 
@@ -25,6 +26,7 @@ func init() {
 	bus.AddHandler("sql", UpdateDataSource)
 	bus.AddHandler("sql", GetDataSourceById)
 	bus.AddHandler("sql", GetDataSourceByName)
+	bus.AddHandler("sql", GetDataSourceByUid)
 }
 
 func GetDataSourceById(query *models.GetDataSourceByIdQuery) error {
@@ -57,6 +59,21 @@ func GetDataSourceByName(query *models.GetDataSourceByNameQuery) error {
 	return err
 }
 
+func GetDataSourceByUid(query *models.GetDataSourceByUidQuery) error {
+	datasource := models.DataSource{OrgId: query.OrgId, Uid: query.Uid}
+	has, err := x.Get(&datasource)
+	if err != nil {
+		return err
+	}
+
+	if !has {
+		return models.ErrDataSourceNotFound
+	}
+
+	query.Result = &datasource
+	return nil
+}
+
 func GetDataSources(query *models.GetDataSourcesQuery) error {
 	sess := x.Limit(5000, 0).Where("org_id=?", query.OrgId).Asc("name")
 
@@ -73,24 +90,71 @@ func GetAllDataSources(query *models.GetAllDataSourcesQuery) error {
 
 func DeleteDataSourceById(cmd *models.DeleteDataSourceByIdCommand) error {
 	return inTransaction(func(sess *DBSession) error {
+		if err := deleteDataSourceCorrelations(sess, cmd.OrgId, "id=? and org_id=?", cmd.Id, cmd.OrgId); err != nil {
+			return err
+		}
+
+		if err := deleteDataSourceAnnotationStore(sess, cmd.OrgId, "id=? and org_id=?", cmd.Id, cmd.OrgId); err != nil {
+			return err
+		}
+
+		ds := models.DataSource{}
+		has, err := sess.Where("id=? and org_id=?", cmd.Id, cmd.OrgId).Get(&ds)
+		if err != nil {
+			return err
+		}
+
 		var rawSql = "DELETE FROM data_source WHERE id=? and org_id=?"
 		result, err := sess.Exec(rawSql, cmd.Id, cmd.OrgId)
 		affected, _ := result.RowsAffected()
 		cmd.DeletedDatasourcesCount = affected
+		if has {
+			publishDataSourceDeleted(sess, &ds)
+		}
 		return err
 	})
 }
 
 func DeleteDataSourceByName(cmd *models.DeleteDataSourceByNameCommand) error {
 	return inTransaction(func(sess *DBSession) error {
+		if err := deleteDataSourceCorrelations(sess, cmd.OrgId, "name=? and org_id=?", cmd.Name, cmd.OrgId); err != nil {
+			return err
+		}
+
+		if err := deleteDataSourceAnnotationStore(sess, cmd.OrgId, "name=? and org_id=?", cmd.Name, cmd.OrgId); err != nil {
+			return err
+		}
+
+		ds := models.DataSource{}
+		has, err := sess.Where("name=? and org_id=?", cmd.Name, cmd.OrgId).Get(&ds)
+		if err != nil {
+			return err
+		}
+
 		var rawSql = "DELETE FROM data_source WHERE name=? and org_id=?"
 		result, err := sess.Exec(rawSql, cmd.Name, cmd.OrgId)
 		affected, _ := result.RowsAffected()
 		cmd.DeletedDatasourcesCount = affected
+		if has {
+			publishDataSourceDeleted(sess, &ds)
+		}
 		return err
 	})
 }
 
+// publishDataSourceDeleted tells the listeners, like the caches of the data sources, that a data
+// source is deleted once the transaction is committed
+func publishDataSourceDeleted(sess *DBSession, ds *models.DataSource) {
+	sess.publishAfterCommit(&events.DataSourceDeleted{
+		Timestamp: time.Now(),
+		Id:        ds.Id,
+		Uid:       ds.Uid,
+		OrgId:     ds.OrgId,
+		Name:      ds.Name,
+		Type:      ds.Type,
+	})
+}
+
 func AddDataSource(cmd *models.AddDataSourceCommand) error {
 	return inTransaction(func(sess *DBSession) error {
 		existing := models.DataSource{OrgId: cmd.OrgId, Name: cmd.Name}
@@ -220,9 +284,28 @@ func UpdateDataSource(cmd *models.UpdateDataSourceCommand) error {
 		}
 
 		err = updateIsDefaultFlag(ds, sess)
+		if err != nil {
+			return err
+		}
+
+		// the uid is kept when the command doesn't set one
+		if ds.Uid == "" {
+			if _, err := sess.Table("data_source").Where("id=?", ds.Id).Cols("uid").Get(&ds.Uid); err != nil {
+				return err
+			}
+		}
 
 		cmd.Result = ds
-		return err
+		sess.publishAfterCommit(&events.DataSourceUpdated{
+			Timestamp: ds.Updated,
+			Id:        ds.Id,
+			Uid:       ds.Uid,
+			OrgId:     ds.OrgId,
+			Name:      ds.Name,
+			Type:      ds.Type,
+			Version:   ds.Version,
+		})
+		return nil
 	})
 }
 
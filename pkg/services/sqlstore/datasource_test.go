@@ -3,6 +3,8 @@ package sqlstore
 import (
 	"testing"
 
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/stretchr/testify/require"
 )
@@ -84,6 +86,23 @@ func TestDataAccess(t *testing.T) {
 		})
 	})
 
+	t.Run("GetDataSourceByUid", func(t *testing.T) {
+		InitTestDB(t)
+		cmd := defaultAddDatasourceCommand
+		cmd.Uid = "test"
+		err := AddDataSource(&cmd)
+		require.NoError(t, err)
+
+		query := models.GetDataSourceByUidQuery{Uid: "test", OrgId: cmd.OrgId}
+		err = GetDataSourceByUid(&query)
+		require.NoError(t, err)
+		require.Equal(t, cmd.Result.Id, query.Result.Id)
+
+		query = models.GetDataSourceByUidQuery{Uid: "other", OrgId: cmd.OrgId}
+		err = GetDataSourceByUid(&query)
+		require.Equal(t, models.ErrDataSourceNotFound, err)
+	})
+
 	t.Run("UpdateDataSource", func(t *testing.T) {
 		t.Run("updates datasource with version", func(t *testing.T) {
 			InitTestDB(t)
@@ -151,6 +170,27 @@ func TestDataAccess(t *testing.T) {
 			require.NoError(t, err)
 		})
 
+		t.Run("publishes an event once updated", func(t *testing.T) {
+			InitTestDB(t)
+			ds := initDatasource()
+
+			var updated []*events.DataSourceUpdated
+			bus.AddEventListener(func(evt *events.DataSourceUpdated) error {
+				updated = append(updated, evt)
+				return nil
+			})
+
+			cmd := defaultUpdateDatasourceCommand
+			cmd.Id = ds.Id
+			err := UpdateDataSource(&cmd)
+			require.NoError(t, err)
+
+			require.Len(t, updated, 1)
+			require.Equal(t, ds.Id, updated[0].Id)
+			require.Equal(t, ds.Uid, updated[0].Uid)
+			require.Equal(t, "nisse_updated", updated[0].Name)
+		})
+
 		t.Run("updates ds without higher version", func(t *testing.T) {
 			InitTestDB(t)
 			ds := initDatasource()
@@ -185,6 +225,24 @@ func TestDataAccess(t *testing.T) {
 			require.Equal(t, 0, len(query.Result))
 		})
 
+		t.Run("publishes an event once deleted", func(t *testing.T) {
+			InitTestDB(t)
+			ds := initDatasource()
+
+			var deleted []*events.DataSourceDeleted
+			bus.AddEventListener(func(evt *events.DataSourceDeleted) error {
+				deleted = append(deleted, evt)
+				return nil
+			})
+
+			err := DeleteDataSourceById(&models.DeleteDataSourceByIdCommand{Id: ds.Id, OrgId: ds.OrgId})
+			require.NoError(t, err)
+
+			require.Len(t, deleted, 1)
+			require.Equal(t, ds.Id, deleted[0].Id)
+			require.Equal(t, models.DS_GRAPHITE, deleted[0].Type)
+		})
+
 		t.Run("Can not delete datasource with wrong orgId", func(t *testing.T) {
 			InitTestDB(t)
 			ds := initDatasource()
@@ -0,0 +1,75 @@
+package sqlstore
+
+import (
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", GetDataSourceUsage)
+}
+
+// GetDataSourceUsage finds the dashboards, annotation queries and alert rules of an organization
+// using a data source. The references aren't stored, the dashboards are read each time.
+func GetDataSourceUsage(query *models.GetDataSourceUsageQuery) error {
+	usage := &models.DataSourceUsage{
+		Dashboards:  make([]*models.DataSourceUsageDashboard, 0),
+		AlertRules:  make([]*models.DataSourceUsageAlertRule, 0),
+		Annotations: make([]*models.DataSourceUsageAnnotation, 0),
+	}
+
+	dashboards := make([]*models.Dashboard, 0)
+	if err := x.Where("org_id=? AND is_folder=?", query.OrgId, dialect.BooleanStr(false)).
+		Cols("id", "uid", "slug", "title", "data").
+		OrderBy("title ASC").
+		Find(&dashboards); err != nil {
+		return err
+	}
+
+	dashboardUids := make(map[int64]string, len(dashboards))
+	for _, dashboard := range dashboards {
+		dashboardUids[dashboard.Id] = dashboard.Uid
+
+		refs := models.GetDashboardDataSourceRefs(dashboard.Data, query.DataSource)
+		if len(refs.PanelIds) > 0 || len(refs.Variables) > 0 {
+			usage.Dashboards = append(usage.Dashboards, &models.DataSourceUsageDashboard{
+				Id:        dashboard.Id,
+				Uid:       dashboard.Uid,
+				Title:     dashboard.Title,
+				Url:       models.GetDashboardUrl(dashboard.Uid, dashboard.Slug),
+				PanelIds:  refs.PanelIds,
+				Variables: refs.Variables,
+			})
+		}
+		for _, name := range refs.Annotations {
+			usage.Annotations = append(usage.Annotations, &models.DataSourceUsageAnnotation{
+				Name:         name,
+				DashboardId:  dashboard.Id,
+				DashboardUid: dashboard.Uid,
+			})
+		}
+	}
+
+	alerts := make([]*models.Alert, 0)
+	if err := x.Where("org_id=?", query.OrgId).Cols("id", "name", "dashboard_id", "panel_id", "settings").
+		OrderBy("name ASC").
+		Find(&alerts); err != nil {
+		return err
+	}
+
+	for _, alert := range alerts {
+		if alert.Settings == nil || !models.AlertUsesDataSource(alert.Settings, query.DataSource) {
+			continue
+		}
+		usage.AlertRules = append(usage.AlertRules, &models.DataSourceUsageAlertRule{
+			Id:           alert.Id,
+			Name:         alert.Name,
+			DashboardId:  alert.DashboardId,
+			DashboardUid: dashboardUids[alert.DashboardId],
+			PanelId:      alert.PanelId,
+		})
+	}
+
+	query.Result = usage
+	return nil
+}
@@ -0,0 +1,72 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestGetDataSourceUsage(t *testing.T) {
+	InitTestDB(t)
+
+	addDS := models.AddDataSourceCommand{OrgId: 1, Name: "prom", Type: models.DS_PROMETHEUS, Access: models.DS_ACCESS_PROXY}
+	require.NoError(t, AddDataSource(&addDS))
+	ds := addDS.Result
+
+	saveDashboard := func(t *testing.T, orgID int64, data string) *models.Dashboard {
+		dashboard, err := simplejson.NewJson([]byte(data))
+		require.NoError(t, err)
+		cmd := models.SaveDashboardCommand{OrgId: orgID, Dashboard: dashboard}
+		require.NoError(t, SaveDashboard(&cmd))
+		return cmd.Result
+	}
+
+	used := saveDashboard(t, 1, `{
+		"title": "Used",
+		"panels": [{"id": 1, "datasource": "prom", "targets": [{}]}],
+		"annotations": {"list": [{"name": "Deploys", "datasource": "prom"}]}
+	}`)
+	saveDashboard(t, 1, `{"title": "Unused", "panels": [{"id": 1, "datasource": "loki", "targets": [{}]}]}`)
+	saveDashboard(t, 2, `{"title": "Other org", "panels": [{"id": 1, "datasource": "prom", "targets": [{}]}]}`)
+
+	settings := simplejson.NewFromAny(map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"query": map[string]interface{}{"datasourceId": ds.Id}},
+		},
+	})
+	err := SaveAlerts(&models.SaveAlertsCommand{
+		DashboardId: used.Id,
+		OrgId:       1,
+		UserId:      1,
+		Alerts: []*models.Alert{{
+			DashboardId: used.Id,
+			OrgId:       1,
+			PanelId:     1,
+			Name:        "High latency",
+			Settings:    settings,
+		}},
+	})
+	require.NoError(t, err)
+
+	query := models.GetDataSourceUsageQuery{OrgId: 1, DataSource: ds}
+	require.NoError(t, GetDataSourceUsage(&query))
+	require.True(t, query.Result.InUse())
+
+	require.Len(t, query.Result.Dashboards, 1)
+	require.Equal(t, used.Uid, query.Result.Dashboards[0].Uid)
+	require.Equal(t, []int64{1}, query.Result.Dashboards[0].PanelIds)
+
+	require.Len(t, query.Result.Annotations, 1)
+	require.Equal(t, "Deploys", query.Result.Annotations[0].Name)
+
+	require.Len(t, query.Result.AlertRules, 1)
+	require.Equal(t, "High latency", query.Result.AlertRules[0].Name)
+	require.Equal(t, used.Uid, query.Result.AlertRules[0].DashboardUid)
+
+	query = models.GetDataSourceUsageQuery{OrgId: 1, DataSource: &models.DataSource{Id: ds.Id + 1, Name: "other"}}
+	require.NoError(t, GetDataSourceUsage(&query))
+	require.False(t, query.Result.InUse())
+}
@@ -0,0 +1,242 @@
+package sqlstore
+
+import (
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", CreateKioskDevice)
+	bus.AddHandler("sql", UpdateKioskDevice)
+	bus.AddHandler("sql", RegenerateKioskDeviceToken)
+	bus.AddHandler("sql", DeleteKioskDevice)
+	bus.AddHandler("sql", UpdateKioskDeviceLastSeen)
+	bus.AddHandler("sql", GetKioskDevices)
+	bus.AddHandler("sql", GetKioskDeviceById)
+	bus.AddHandler("sql", GetKioskDevicesByDashboard)
+}
+
+func CreateKioskDevice(cmd *models.CreateKioskDeviceCommand) error {
+	name := strings.TrimSpace(cmd.Name)
+	if name == "" {
+		return models.ErrKioskDeviceNameEmpty
+	}
+
+	return inTransaction(func(sess *DBSession) error {
+		if err := checkKioskDeviceName(sess, cmd.OrgId, 0, name); err != nil {
+			return err
+		}
+
+		device := &models.KioskDevice{
+			OrgId:   cmd.OrgId,
+			Name:    name,
+			Created: time.Now(),
+			Updated: time.Now(),
+		}
+		if _, err := sess.Insert(device); err != nil {
+			return err
+		}
+
+		token, err := replaceKioskToken(sess, &models.KioskToken{OrgId: cmd.OrgId, DeviceId: device.Id})
+		if err != nil {
+			return err
+		}
+
+		uids, err := saveKioskDeviceDashboards(sess, device.Id, cmd.DashboardUids)
+		if err != nil {
+			return err
+		}
+
+		cmd.Result = kioskDeviceToDTO(device, uids)
+		cmd.Token = token
+		return nil
+	})
+}
+
+func UpdateKioskDevice(cmd *models.UpdateKioskDeviceCommand) error {
+	name := strings.TrimSpace(cmd.Name)
+	if name == "" {
+		return models.ErrKioskDeviceNameEmpty
+	}
+
+	return inTransaction(func(sess *DBSession) error {
+		device := models.KioskDevice{}
+		has, err := sess.Where("org_id=? AND id=?", cmd.OrgId, cmd.Id).Get(&device)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return models.ErrKioskDeviceNotFound
+		}
+
+		if err := checkKioskDeviceName(sess, cmd.OrgId, cmd.Id, name); err != nil {
+			return err
+		}
+
+		device.Name = name
+		device.Updated = time.Now()
+		if _, err := sess.ID(device.Id).Cols("name", "updated").Update(&device); err != nil {
+			return err
+		}
+
+		if _, err := sess.Exec("DELETE FROM kiosk_device_dashboard WHERE device_id=?", device.Id); err != nil {
+			return err
+		}
+		uids, err := saveKioskDeviceDashboards(sess, device.Id, cmd.DashboardUids)
+		if err != nil {
+			return err
+		}
+
+		cmd.Result = kioskDeviceToDTO(&device, uids)
+		return nil
+	})
+}
+
+func RegenerateKioskDeviceToken(cmd *models.RegenerateKioskDeviceTokenCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		res, err := sess.Exec("UPDATE kiosk_device SET updated=? WHERE org_id=? AND id=?", time.Now(), cmd.OrgId, cmd.Id)
+		if err != nil {
+			return err
+		}
+
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			return models.ErrKioskDeviceNotFound
+		}
+
+		token, err := replaceKioskToken(sess, &models.KioskToken{OrgId: cmd.OrgId, DeviceId: cmd.Id})
+		if err != nil {
+			return err
+		}
+
+		cmd.Result = token
+		return nil
+	})
+}
+
+func DeleteKioskDevice(cmd *models.DeleteKioskDeviceCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		res, err := sess.Exec("DELETE FROM kiosk_device WHERE org_id=? AND id=?", cmd.OrgId, cmd.Id)
+		if err != nil {
+			return err
+		}
+
+		if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+			return models.ErrKioskDeviceNotFound
+		}
+
+		if _, err := sess.Exec("DELETE FROM kiosk_device_dashboard WHERE device_id=?", cmd.Id); err != nil {
+			return err
+		}
+
+		return deleteKioskTokens(sess, 0, cmd.Id)
+	})
+}
+
+func UpdateKioskDeviceLastSeen(cmd *models.UpdateKioskDeviceLastSeenCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		_, err := sess.Exec("UPDATE kiosk_device SET last_seen=? WHERE id=?", time.Now(), cmd.Id)
+		return err
+	})
+}
+
+func GetKioskDevices(query *models.GetKioskDevicesQuery) error {
+	devices := make([]*models.KioskDevice, 0)
+	if err := x.Where("org_id=?", query.OrgId).Asc("name").Find(&devices); err != nil {
+		return err
+	}
+
+	query.Result = make([]*models.KioskDeviceDTO, 0, len(devices))
+	for _, device := range devices {
+		uids, err := getKioskDeviceDashboardUids(device.Id)
+		if err != nil {
+			return err
+		}
+		query.Result = append(query.Result, kioskDeviceToDTO(device, uids))
+	}
+	return nil
+}
+
+func GetKioskDeviceById(query *models.GetKioskDeviceByIdQuery) error {
+	device := models.KioskDevice{}
+	has, err := x.Where("org_id=? AND id=?", query.OrgId, query.Id).Get(&device)
+	if err != nil {
+		return err
+	}
+	if !has {
+		return models.ErrKioskDeviceNotFound
+	}
+
+	uids, err := getKioskDeviceDashboardUids(device.Id)
+	if err != nil {
+		return err
+	}
+
+	query.Result = kioskDeviceToDTO(&device, uids)
+	return nil
+}
+
+func GetKioskDevicesByDashboard(query *models.GetKioskDevicesByDashboardQuery) error {
+	query.Result = make([]int64, 0)
+	return x.SQL(`SELECT kiosk_device.id FROM kiosk_device
+		INNER JOIN kiosk_device_dashboard ON kiosk_device_dashboard.device_id = kiosk_device.id
+		WHERE kiosk_device.org_id=? AND kiosk_device_dashboard.dashboard_uid=?`, query.OrgId, query.DashboardUid).Find(&query.Result)
+}
+
+func checkKioskDeviceName(sess *DBSession, orgId int64, id int64, name string) error {
+	existing := models.KioskDevice{}
+	has, err := sess.Where("org_id=? AND name=?", orgId, name).Get(&existing)
+	if err != nil {
+		return err
+	}
+	if has && existing.Id != id {
+		return models.ErrKioskDeviceNameTaken
+	}
+	return nil
+}
+
+// saveKioskDeviceDashboards binds dashboards to a device, ignoring the duplicated uids, and returns
+// the bound uids
+func saveKioskDeviceDashboards(sess *DBSession, deviceId int64, dashboardUids []string) ([]string, error) {
+	uids := make([]string, 0, len(dashboardUids))
+	seen := make(map[string]bool)
+	for _, uid := range dashboardUids {
+		if uid == "" || seen[uid] {
+			continue
+		}
+		seen[uid] = true
+
+		if _, err := sess.Insert(&models.KioskDeviceDashboard{DeviceId: deviceId, DashboardUid: uid}); err != nil {
+			return nil, err
+		}
+		uids = append(uids, uid)
+	}
+	return uids, nil
+}
+
+func getKioskDeviceDashboardUids(deviceId int64) ([]string, error) {
+	dashboards := make([]*models.KioskDeviceDashboard, 0)
+	if err := x.Where("device_id=?", deviceId).Asc("id").Find(&dashboards); err != nil {
+		return nil, err
+	}
+
+	uids := make([]string, 0, len(dashboards))
+	for _, dashboard := range dashboards {
+		uids = append(uids, dashboard.DashboardUid)
+	}
+	return uids, nil
+}
+
+func kioskDeviceToDTO(device *models.KioskDevice, dashboardUids []string) *models.KioskDeviceDTO {
+	return &models.KioskDeviceDTO{
+		Id:            device.Id,
+		OrgId:         device.OrgId,
+		Name:          device.Name,
+		DashboardUids: dashboardUids,
+		Created:       device.Created,
+		Updated:       device.Updated,
+		LastSeen:      device.LastSeen,
+	}
+}
@@ -0,0 +1,125 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestKioskDeviceDataAccess(t *testing.T) {
+	createDevice := func(t *testing.T, name string, uids ...string) *models.CreateKioskDeviceCommand {
+		cmd := models.CreateKioskDeviceCommand{OrgId: 1, Name: name, DashboardUids: uids}
+		err := CreateKioskDevice(&cmd)
+		require.NoError(t, err)
+		return &cmd
+	}
+
+	t.Run("Can create a device and get it by its token", func(t *testing.T) {
+		InitTestDB(t)
+		cmd := createDevice(t, "lobby", "abc", "def", "abc")
+		require.NotEmpty(t, cmd.Token)
+		require.Equal(t, []string{"abc", "def"}, cmd.Result.DashboardUids)
+
+		tokenQuery := models.GetKioskTokenQuery{Token: cmd.Token}
+		err := GetKioskToken(&tokenQuery)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), tokenQuery.Result.OrgId)
+		require.Equal(t, cmd.Result.Id, tokenQuery.Result.DeviceId)
+		require.Equal(t, int64(0), tokenQuery.Result.PlaylistId)
+
+		query := models.GetKioskDeviceByIdQuery{Id: tokenQuery.Result.DeviceId, OrgId: 1}
+		err = GetKioskDeviceById(&query)
+		require.NoError(t, err)
+		require.Equal(t, "lobby", query.Result.Name)
+		require.Equal(t, []string{"abc", "def"}, query.Result.DashboardUids)
+
+		count, err := x.Where("token_hash=?", cmd.Token).Count(&models.KioskToken{})
+		require.NoError(t, err)
+		require.Equal(t, int64(0), count)
+
+		err = GetKioskToken(&models.GetKioskTokenQuery{Token: "invalid"})
+		require.Equal(t, models.ErrKioskTokenNotFound, err)
+	})
+
+	t.Run("Cannot create devices with the same name", func(t *testing.T) {
+		InitTestDB(t)
+		createDevice(t, "lobby")
+
+		err := CreateKioskDevice(&models.CreateKioskDeviceCommand{OrgId: 1, Name: "lobby"})
+		require.Equal(t, models.ErrKioskDeviceNameTaken, err)
+
+		err = CreateKioskDevice(&models.CreateKioskDeviceCommand{OrgId: 1, Name: " "})
+		require.Equal(t, models.ErrKioskDeviceNameEmpty, err)
+	})
+
+	t.Run("Can update the dashboards of a device and find the devices of a dashboard", func(t *testing.T) {
+		InitTestDB(t)
+		lobby := createDevice(t, "lobby", "abc")
+		office := createDevice(t, "office", "abc", "def")
+
+		err := UpdateKioskDevice(&models.UpdateKioskDeviceCommand{Id: lobby.Result.Id, OrgId: 1, Name: "hall", DashboardUids: []string{"def"}})
+		require.NoError(t, err)
+
+		query := models.GetKioskDevicesByDashboardQuery{OrgId: 1, DashboardUid: "def"}
+		err = GetKioskDevicesByDashboard(&query)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []int64{lobby.Result.Id, office.Result.Id}, query.Result)
+
+		query = models.GetKioskDevicesByDashboardQuery{OrgId: 1, DashboardUid: "abc"}
+		err = GetKioskDevicesByDashboard(&query)
+		require.NoError(t, err)
+		require.Equal(t, []int64{office.Result.Id}, query.Result)
+
+		devices := models.GetKioskDevicesQuery{OrgId: 1}
+		err = GetKioskDevices(&devices)
+		require.NoError(t, err)
+		require.Len(t, devices.Result, 2)
+		require.Equal(t, "hall", devices.Result[0].Name)
+		require.Equal(t, []string{"def"}, devices.Result[0].DashboardUids)
+
+		err = UpdateKioskDevice(&models.UpdateKioskDeviceCommand{Id: lobby.Result.Id, OrgId: 2, Name: "hall"})
+		require.Equal(t, models.ErrKioskDeviceNotFound, err)
+	})
+
+	t.Run("Regenerating the token of a device revokes the previous token", func(t *testing.T) {
+		InitTestDB(t)
+		cmd := createDevice(t, "lobby")
+
+		regenerate := models.RegenerateKioskDeviceTokenCommand{Id: cmd.Result.Id, OrgId: 1}
+		err := RegenerateKioskDeviceToken(&regenerate)
+		require.NoError(t, err)
+		require.NotEqual(t, cmd.Token, regenerate.Result)
+
+		err = GetKioskToken(&models.GetKioskTokenQuery{Token: cmd.Token})
+		require.Equal(t, models.ErrKioskTokenNotFound, err)
+
+		err = GetKioskToken(&models.GetKioskTokenQuery{Token: regenerate.Result})
+		require.NoError(t, err)
+
+		err = RegenerateKioskDeviceToken(&models.RegenerateKioskDeviceTokenCommand{Id: cmd.Result.Id, OrgId: 2})
+		require.Equal(t, models.ErrKioskDeviceNotFound, err)
+	})
+
+	t.Run("Can delete a device", func(t *testing.T) {
+		InitTestDB(t)
+		cmd := createDevice(t, "lobby", "abc")
+
+		err := DeleteKioskDevice(&models.DeleteKioskDeviceCommand{Id: cmd.Result.Id, OrgId: 1})
+		require.NoError(t, err)
+
+		err = GetKioskDeviceById(&models.GetKioskDeviceByIdQuery{Id: cmd.Result.Id, OrgId: 1})
+		require.Equal(t, models.ErrKioskDeviceNotFound, err)
+
+		count, err := x.Table("kiosk_device_dashboard").Count()
+		require.NoError(t, err)
+		require.Equal(t, int64(0), count)
+
+		err = GetKioskToken(&models.GetKioskTokenQuery{Token: cmd.Token})
+		require.Equal(t, models.ErrKioskTokenNotFound, err)
+
+		err = DeleteKioskDevice(&models.DeleteKioskDeviceCommand{Id: cmd.Result.Id, OrgId: 1})
+		require.Equal(t, models.ErrKioskDeviceNotFound, err)
+	})
+}
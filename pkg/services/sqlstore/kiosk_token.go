@@ -0,0 +1,70 @@
+package sqlstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func init() {
+	bus.AddHandler("sql", GetKioskToken)
+}
+
+// hashKioskToken returns the hash of a kiosk token stored in the database
+func hashKioskToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// replaceKioskToken creates a token for the playlist or the kiosk device of kioskToken, revoking
+// their previous token, and returns it
+func replaceKioskToken(sess *DBSession, kioskToken *models.KioskToken) (string, error) {
+	if err := deleteKioskTokens(sess, kioskToken.PlaylistId, kioskToken.DeviceId); err != nil {
+		return "", err
+	}
+
+	token, err := util.GetRandomString(32)
+	if err != nil {
+		return "", err
+	}
+
+	kioskToken.TokenHash = hashKioskToken(token)
+	kioskToken.Created = time.Now()
+	if _, err := sess.Insert(kioskToken); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// deleteKioskTokens revokes the tokens of a playlist or of a kiosk device
+func deleteKioskTokens(sess *DBSession, playlistId int64, deviceId int64) error {
+	var err error
+	if playlistId != 0 {
+		_, err = sess.Exec("DELETE FROM kiosk_token WHERE playlist_id=?", playlistId)
+	} else {
+		_, err = sess.Exec("DELETE FROM kiosk_token WHERE device_id=?", deviceId)
+	}
+	return err
+}
+
+func GetKioskToken(query *models.GetKioskTokenQuery) error {
+	if query.Token == "" {
+		return models.ErrKioskTokenNotFound
+	}
+
+	token := models.KioskToken{}
+	has, err := x.Where("token_hash=?", hashKioskToken(query.Token)).Get(&token)
+	if err != nil {
+		return err
+	}
+	if !has {
+		return models.ErrKioskTokenNotFound
+	}
+
+	query.Result = &token
+	return nil
+}
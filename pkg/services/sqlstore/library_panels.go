@@ -0,0 +1,406 @@
+package sqlstore
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func init() {
+	bus.AddHandler("sql", CreateLibraryPanel)
+	bus.AddHandler("sql", PatchLibraryPanel)
+	bus.AddHandler("sql", DeleteLibraryPanel)
+	bus.AddHandler("sql", GetLibraryPanel)
+	bus.AddHandler("sql", GetLibraryPanelsByUIDs)
+	bus.AddHandler("sql", SearchLibraryPanels)
+	bus.AddHandler("sql", ConnectLibraryPanel)
+	bus.AddHandler("sql", DisconnectLibraryPanel)
+	bus.AddHandler("sql", SyncLibraryPanelConnections)
+	bus.AddHandler("sql", GetLibraryPanelDashboards)
+}
+
+const libraryPanelWithMetaSelect = `SELECT
+	lp.id,
+	lp.org_id,
+	lp.folder_id,
+	lp.uid,
+	lp.name,
+	lp.model,
+	lp.version,
+	lp.created,
+	lp.created_by,
+	lp.updated,
+	lp.updated_by,
+	(SELECT COUNT(*) FROM library_panel_dashboard WHERE library_panel_id = lp.id) AS connected_dashboards,
+	u1.login AS created_by_login,
+	u2.login AS updated_by_login
+	FROM library_panel AS lp`
+
+func libraryPanelWithMetaJoins() string {
+	return `
+	LEFT OUTER JOIN ` + dialect.Quote("user") + ` AS u1 ON u1.id = lp.created_by
+	LEFT OUTER JOIN ` + dialect.Quote("user") + ` AS u2 ON u2.id = lp.updated_by`
+}
+
+func CreateLibraryPanel(cmd *models.CreateLibraryPanelCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		if err := validateLibraryPanelName(sess, cmd.OrgId, cmd.FolderId, cmd.Name, 0); err != nil {
+			return err
+		}
+
+		uid := cmd.Uid
+		if uid == "" {
+			var err error
+			uid, err = generateNewLibraryPanelUid(sess, cmd.OrgId)
+			if err != nil {
+				return err
+			}
+		} else {
+			exists, err := sess.Where("org_id=? AND uid=?", cmd.OrgId, uid).Get(&models.LibraryPanel{})
+			if err != nil {
+				return err
+			}
+			if exists {
+				return models.ErrLibraryPanelWithSameUIDExists
+			}
+		}
+
+		cmd.Model.Set("title", cmd.Name)
+		cmd.Model.Del("libraryPanel")
+
+		encryptedModel, err := models.EncryptPanelSensitiveFields(cmd.Model)
+		if err != nil {
+			return err
+		}
+
+		libraryPanel := &models.LibraryPanel{
+			OrgId:     cmd.OrgId,
+			FolderId:  cmd.FolderId,
+			Uid:       uid,
+			Name:      cmd.Name,
+			Model:     encryptedModel,
+			Version:   1,
+			Created:   time.Now(),
+			Updated:   time.Now(),
+			CreatedBy: cmd.UserId,
+			UpdatedBy: cmd.UserId,
+		}
+
+		if _, err := sess.Insert(libraryPanel); err != nil {
+			return err
+		}
+
+		libraryPanel.Model = cmd.Model
+		cmd.Result = libraryPanel
+		return nil
+	})
+}
+
+func PatchLibraryPanel(cmd *models.PatchLibraryPanelCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		libraryPanel := models.LibraryPanel{}
+		has, err := sess.Where("org_id=? AND uid=?", cmd.OrgId, cmd.Uid).Get(&libraryPanel)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return models.ErrLibraryPanelNotFound
+		}
+
+		if cmd.Version != 0 && libraryPanel.Version != cmd.Version {
+			return models.ErrLibraryPanelVersionMismatch
+		}
+
+		if cmd.FolderId != nil {
+			libraryPanel.FolderId = *cmd.FolderId
+		}
+		if cmd.Name != "" {
+			libraryPanel.Name = cmd.Name
+		}
+		if cmd.Model != nil {
+			libraryPanel.Model = cmd.Model
+			libraryPanel.Model.Del("libraryPanel")
+		} else {
+			decryptLibraryPanelSensitiveFields(libraryPanel.Id, libraryPanel.Uid, libraryPanel.Model)
+		}
+		libraryPanel.Model.Set("title", libraryPanel.Name)
+
+		if err := validateLibraryPanelName(sess, cmd.OrgId, libraryPanel.FolderId, libraryPanel.Name, libraryPanel.Id); err != nil {
+			return err
+		}
+
+		model := libraryPanel.Model
+		libraryPanel.Model, err = models.EncryptPanelSensitiveFields(model)
+		if err != nil {
+			return err
+		}
+
+		libraryPanel.Version++
+		libraryPanel.Updated = time.Now()
+		libraryPanel.UpdatedBy = cmd.UserId
+
+		affectedRows, err := sess.Where("id=? AND version=?", libraryPanel.Id, libraryPanel.Version-1).
+			Cols("folder_id", "name", "model", "version", "updated", "updated_by").
+			Update(&libraryPanel)
+		if err != nil {
+			return err
+		}
+		if affectedRows == 0 {
+			return models.ErrLibraryPanelVersionMismatch
+		}
+
+		libraryPanel.Model = model
+		cmd.Result = &libraryPanel
+		return nil
+	})
+}
+
+func DeleteLibraryPanel(cmd *models.DeleteLibraryPanelCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		libraryPanel := models.LibraryPanel{}
+		has, err := sess.Where("org_id=? AND uid=?", cmd.OrgId, cmd.Uid).Get(&libraryPanel)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return models.ErrLibraryPanelNotFound
+		}
+
+		connections, err := sess.Where("library_panel_id=?", libraryPanel.Id).Count(&models.LibraryPanelDashboard{})
+		if err != nil {
+			return err
+		}
+		if connections > 0 {
+			return models.ErrLibraryPanelHasConnectedDashboards
+		}
+
+		_, err = sess.Exec("DELETE FROM library_panel WHERE id=?", libraryPanel.Id)
+		return err
+	})
+}
+
+func GetLibraryPanel(query *models.GetLibraryPanelQuery) error {
+	libraryPanels := make([]*models.LibraryPanelWithMeta, 0)
+	sql := libraryPanelWithMetaSelect + libraryPanelWithMetaJoins() + ` WHERE lp.org_id=? AND lp.uid=?`
+	if err := x.SQL(sql, query.OrgId, query.Uid).Find(&libraryPanels); err != nil {
+		return err
+	}
+
+	if len(libraryPanels) == 0 {
+		return models.ErrLibraryPanelNotFound
+	}
+
+	decryptLibraryPanelSensitiveFields(libraryPanels[0].Id, libraryPanels[0].Uid, libraryPanels[0].Model)
+	query.Result = libraryPanels[0]
+	return nil
+}
+
+func GetLibraryPanelsByUIDs(query *models.GetLibraryPanelsByUIDsQuery) error {
+	query.Result = make([]*models.LibraryPanel, 0)
+	if len(query.Uids) == 0 {
+		return nil
+	}
+
+	if err := x.Where("org_id=?", query.OrgId).In("uid", query.Uids).Find(&query.Result); err != nil {
+		return err
+	}
+
+	for _, libraryPanel := range query.Result {
+		decryptLibraryPanelSensitiveFields(libraryPanel.Id, libraryPanel.Uid, libraryPanel.Model)
+	}
+	return nil
+}
+
+func SearchLibraryPanels(query *models.SearchLibraryPanelsQuery) error {
+	query.Result = make([]*models.LibraryPanelWithMeta, 0)
+
+	var sql bytes.Buffer
+	params := make([]interface{}, 0)
+
+	sql.WriteString(libraryPanelWithMetaSelect)
+	sql.WriteString(libraryPanelWithMetaJoins())
+	sql.WriteString(` WHERE lp.org_id=?`)
+	params = append(params, query.OrgId)
+
+	if query.Name != "" {
+		sql.WriteString(` AND lp.name ` + dialect.LikeStr() + ` ?`)
+		params = append(params, "%"+query.Name+"%")
+	}
+
+	if len(query.FolderIds) > 0 {
+		sql.WriteString(` AND lp.folder_id IN (?` + strings.Repeat(",?", len(query.FolderIds)-1) + `)`)
+		for _, folderId := range query.FolderIds {
+			params = append(params, folderId)
+		}
+	}
+
+	sql.WriteString(` ORDER BY lp.name ASC`)
+
+	if query.Limit > 0 {
+		page := query.Page
+		if page < 1 {
+			page = 1
+		}
+		sql.WriteString(` ` + dialect.LimitOffset(int64(query.Limit), int64((page-1)*query.Limit)))
+	}
+
+	if err := x.SQL(sql.String(), params...).Find(&query.Result); err != nil {
+		return err
+	}
+
+	for _, libraryPanel := range query.Result {
+		decryptLibraryPanelSensitiveFields(libraryPanel.Id, libraryPanel.Uid, libraryPanel.Model)
+	}
+	return nil
+}
+
+// decryptLibraryPanelSensitiveFields decrypts the sensitive fields of a loaded library panel model,
+// like decryptSensitiveFields does for the dashboards.
+func decryptLibraryPanelSensitiveFields(id int64, uid string, model *simplejson.Json) {
+	if err := models.DecryptPanelSensitiveFields(model); err != nil {
+		sqlog.Warn("Failed to decrypt sensitive fields of library panel", "id", id, "uid", uid, "error", err)
+	}
+}
+
+func ConnectLibraryPanel(cmd *models.ConnectLibraryPanelCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		libraryPanel, err := getLibraryPanelForOrg(sess, cmd.OrgId, cmd.Uid)
+		if err != nil {
+			return err
+		}
+
+		dashboard := models.Dashboard{Id: cmd.DashboardId, OrgId: cmd.OrgId}
+		has, err := sess.Get(&dashboard)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return models.ErrDashboardNotFound
+		}
+
+		return connectLibraryPanel(sess, libraryPanel.Id, cmd.DashboardId, cmd.UserId)
+	})
+}
+
+func DisconnectLibraryPanel(cmd *models.DisconnectLibraryPanelCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		libraryPanel, err := getLibraryPanelForOrg(sess, cmd.OrgId, cmd.Uid)
+		if err != nil {
+			return err
+		}
+
+		_, err = sess.Exec("DELETE FROM library_panel_dashboard WHERE library_panel_id=? AND dashboard_id=?", libraryPanel.Id, cmd.DashboardId)
+		return err
+	})
+}
+
+// SyncLibraryPanelConnections makes the connections of a dashboard match the library panels it uses.
+// Uids that don't belong to a library panel in the org are ignored.
+func SyncLibraryPanelConnections(cmd *models.SyncLibraryPanelConnectionsCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		if _, err := sess.Exec("DELETE FROM library_panel_dashboard WHERE dashboard_id=?", cmd.DashboardId); err != nil {
+			return err
+		}
+
+		if len(cmd.Uids) == 0 {
+			return nil
+		}
+
+		libraryPanels := make([]*models.LibraryPanel, 0)
+		if err := sess.Where("org_id=?", cmd.OrgId).In("uid", cmd.Uids).Find(&libraryPanels); err != nil {
+			return err
+		}
+
+		for _, libraryPanel := range libraryPanels {
+			if err := connectLibraryPanel(sess, libraryPanel.Id, cmd.DashboardId, cmd.UserId); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func GetLibraryPanelDashboards(query *models.GetLibraryPanelDashboardsQuery) error {
+	query.Result = make([]*models.LibraryPanelConnectedDashboard, 0)
+
+	sql := `SELECT dashboard.id, dashboard.uid, dashboard.title, dashboard.slug, dashboard.folder_id
+		FROM library_panel_dashboard
+		INNER JOIN library_panel ON library_panel.id = library_panel_dashboard.library_panel_id
+		INNER JOIN dashboard ON dashboard.id = library_panel_dashboard.dashboard_id
+		WHERE library_panel.org_id=? AND library_panel.uid=?
+		ORDER BY dashboard.title ASC`
+
+	if err := x.SQL(sql, query.OrgId, query.Uid).Find(&query.Result); err != nil {
+		return err
+	}
+
+	for _, dashboard := range query.Result {
+		dashboard.Url = models.GetDashboardUrl(dashboard.Uid, dashboard.Slug)
+	}
+
+	return nil
+}
+
+func getLibraryPanelForOrg(sess *DBSession, orgId int64, uid string) (*models.LibraryPanel, error) {
+	libraryPanel := models.LibraryPanel{}
+	has, err := sess.Where("org_id=? AND uid=?", orgId, uid).Get(&libraryPanel)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, models.ErrLibraryPanelNotFound
+	}
+
+	return &libraryPanel, nil
+}
+
+func connectLibraryPanel(sess *DBSession, libraryPanelId int64, dashboardId int64, userId int64) error {
+	exists, err := sess.Where("library_panel_id=? AND dashboard_id=?", libraryPanelId, dashboardId).Get(&models.LibraryPanelDashboard{})
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = sess.Insert(&models.LibraryPanelDashboard{
+		LibraryPanelId: libraryPanelId,
+		DashboardId:    dashboardId,
+		Created:        time.Now(),
+		CreatedBy:      userId,
+	})
+	return err
+}
+
+func validateLibraryPanelName(sess *DBSession, orgId int64, folderId int64, name string, id int64) error {
+	var existing models.LibraryPanel
+	exists, err := sess.Where("org_id=? AND folder_id=? AND name=?", orgId, folderId, name).Get(&existing)
+	if err != nil {
+		return err
+	}
+
+	if exists && existing.Id != id {
+		return models.ErrLibraryPanelWithSameNameExists
+	}
+
+	return nil
+}
+
+func generateNewLibraryPanelUid(sess *DBSession, orgId int64) (string, error) {
+	for i := 0; i < 3; i++ {
+		uid := util.GenerateShortUID()
+		exists, err := sess.Where("org_id=? AND uid=?", orgId, uid).Get(&models.LibraryPanel{})
+		if err != nil {
+			return "", err
+		}
+
+		if !exists {
+			return uid, nil
+		}
+	}
+
+	return "", models.ErrLibraryPanelFailedGenerateUniqueUid
+}
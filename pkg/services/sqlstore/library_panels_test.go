@@ -0,0 +1,181 @@
+package sqlstore
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestLibraryPanelDataAccess(t *testing.T) {
+	Convey("Testing library panel data access", t, func() {
+		InitTestDB(t)
+
+		createLibraryPanel := func(name string, folderId int64) *models.LibraryPanel {
+			cmd := models.CreateLibraryPanelCommand{
+				OrgId:    1,
+				FolderId: folderId,
+				Name:     name,
+				UserId:   1,
+				Model: simplejson.NewFromAny(map[string]interface{}{
+					"type":  "graph",
+					"title": "will be replaced",
+				}),
+			}
+			err := CreateLibraryPanel(&cmd)
+			So(err, ShouldBeNil)
+			return cmd.Result
+		}
+
+		Convey("Given a saved library panel", func() {
+			libraryPanel := createLibraryPanel("Requests", 0)
+
+			Convey("Should generate a uid and use the name as title", func() {
+				So(libraryPanel.Uid, ShouldNotBeEmpty)
+				So(libraryPanel.Version, ShouldEqual, 1)
+				So(libraryPanel.Model.Get("title").MustString(), ShouldEqual, "Requests")
+			})
+
+			Convey("Should be able to get it by uid", func() {
+				query := models.GetLibraryPanelQuery{Uid: libraryPanel.Uid, OrgId: 1}
+				err := GetLibraryPanel(&query)
+				So(err, ShouldBeNil)
+				So(query.Result.Name, ShouldEqual, "Requests")
+				So(query.Result.Model.Get("type").MustString(), ShouldEqual, "graph")
+				So(query.Result.ConnectedDashboards, ShouldEqual, 0)
+			})
+
+			Convey("Should not be able to get it from another org", func() {
+				query := models.GetLibraryPanelQuery{Uid: libraryPanel.Uid, OrgId: 2}
+				err := GetLibraryPanel(&query)
+				So(err, ShouldEqual, models.ErrLibraryPanelNotFound)
+			})
+
+			Convey("Should not be able to create another one with the same name in the folder", func() {
+				cmd := models.CreateLibraryPanelCommand{
+					OrgId: 1,
+					Name:  "Requests",
+					Model: simplejson.NewFromAny(map[string]interface{}{"type": "graph"}),
+				}
+				err := CreateLibraryPanel(&cmd)
+				So(err, ShouldEqual, models.ErrLibraryPanelWithSameNameExists)
+			})
+
+			Convey("Should be able to patch it", func() {
+				cmd := models.PatchLibraryPanelCommand{
+					Uid:     libraryPanel.Uid,
+					OrgId:   1,
+					Name:    "Errors",
+					Version: 1,
+				}
+				err := PatchLibraryPanel(&cmd)
+				So(err, ShouldBeNil)
+				So(cmd.Result.Version, ShouldEqual, 2)
+				So(cmd.Result.Model.Get("title").MustString(), ShouldEqual, "Errors")
+				So(cmd.Result.Model.Get("type").MustString(), ShouldEqual, "graph")
+
+				Convey("Should not be able to patch an outdated version", func() {
+					cmd := models.PatchLibraryPanelCommand{
+						Uid:     libraryPanel.Uid,
+						OrgId:   1,
+						Name:    "Latency",
+						Version: 1,
+					}
+					err := PatchLibraryPanel(&cmd)
+					So(err, ShouldEqual, models.ErrLibraryPanelVersionMismatch)
+				})
+			})
+
+			Convey("Should be able to search by name", func() {
+				createLibraryPanel("Latency", 0)
+
+				query := models.SearchLibraryPanelsQuery{OrgId: 1, Name: "lat"}
+				err := SearchLibraryPanels(&query)
+				So(err, ShouldBeNil)
+				So(len(query.Result), ShouldEqual, 1)
+				So(query.Result[0].Name, ShouldEqual, "Latency")
+			})
+
+			Convey("Given a dashboard using the library panel", func() {
+				dash := insertTestDashboard("Dashboard using library panel", 1, 0, false)
+
+				err := SyncLibraryPanelConnections(&models.SyncLibraryPanelConnectionsCommand{
+					DashboardId: dash.Id,
+					OrgId:       1,
+					Uids:        []string{libraryPanel.Uid, "unknown"},
+				})
+				So(err, ShouldBeNil)
+
+				Convey("Should list the connected dashboard", func() {
+					query := models.GetLibraryPanelDashboardsQuery{Uid: libraryPanel.Uid, OrgId: 1}
+					err := GetLibraryPanelDashboards(&query)
+					So(err, ShouldBeNil)
+					So(len(query.Result), ShouldEqual, 1)
+					So(query.Result[0].Id, ShouldEqual, dash.Id)
+					So(query.Result[0].Url, ShouldNotBeEmpty)
+				})
+
+				Convey("Should not be able to delete the library panel", func() {
+					err := DeleteLibraryPanel(&models.DeleteLibraryPanelCommand{Uid: libraryPanel.Uid, OrgId: 1})
+					So(err, ShouldEqual, models.ErrLibraryPanelHasConnectedDashboards)
+				})
+
+				Convey("Should be able to delete it once disconnected", func() {
+					err := DisconnectLibraryPanel(&models.DisconnectLibraryPanelCommand{Uid: libraryPanel.Uid, DashboardId: dash.Id, OrgId: 1})
+					So(err, ShouldBeNil)
+
+					err = DeleteLibraryPanel(&models.DeleteLibraryPanelCommand{Uid: libraryPanel.Uid, OrgId: 1})
+					So(err, ShouldBeNil)
+				})
+
+				Convey("Should remove the connection when the dashboard is deleted", func() {
+					err := DeleteDashboard(&models.DeleteDashboardCommand{Id: dash.Id, OrgId: 1})
+					So(err, ShouldBeNil)
+
+					query := models.GetLibraryPanelQuery{Uid: libraryPanel.Uid, OrgId: 1}
+					err = GetLibraryPanel(&query)
+					So(err, ShouldBeNil)
+					So(query.Result.ConnectedDashboards, ShouldEqual, 0)
+				})
+			})
+		})
+
+		Convey("Given a library panel in a folder", func() {
+			folder := insertTestDashboard("Library panels", 1, 0, true)
+			libraryPanel := createLibraryPanel("Errors", folder.Id)
+
+			connect := func(dash *models.Dashboard) {
+				err := SyncLibraryPanelConnections(&models.SyncLibraryPanelConnectionsCommand{
+					DashboardId: dash.Id,
+					OrgId:       1,
+					Uids:        []string{libraryPanel.Uid},
+				})
+				So(err, ShouldBeNil)
+			}
+
+			Convey("Should delete it with the folder when only dashboards of the folder use it", func() {
+				connect(insertTestDashboard("Dashboard in the folder", 1, folder.Id, false))
+
+				err := DeleteDashboard(&models.DeleteDashboardCommand{Id: folder.Id, OrgId: 1})
+				So(err, ShouldBeNil)
+
+				err = GetLibraryPanel(&models.GetLibraryPanelQuery{Uid: libraryPanel.Uid, OrgId: 1})
+				So(err, ShouldEqual, models.ErrLibraryPanelNotFound)
+			})
+
+			Convey("Should not delete the folder when a dashboard of another folder uses it", func() {
+				connect(insertTestDashboard("Dashboard in the general folder", 1, 0, false))
+
+				err := DeleteDashboard(&models.DeleteDashboardCommand{Id: folder.Id, OrgId: 1})
+				So(err, ShouldEqual, models.ErrFolderHasConnectedLibraryPanels)
+
+				query := models.GetLibraryPanelQuery{Uid: libraryPanel.Uid, OrgId: 1}
+				err = GetLibraryPanel(&query)
+				So(err, ShouldBeNil)
+				So(query.Result.ConnectedDashboards, ShouldEqual, 1)
+			})
+		})
+	})
+}
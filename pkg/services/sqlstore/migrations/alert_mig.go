@@ -168,4 +168,16 @@ func addAlertMigrations(mg *Migrator) {
 	mg.AddMigration("Remove unique index org_id_name", NewDropIndexMigration(alert_notification, &Index{
 		Cols: []string{"org_id", "name"}, Type: UniqueIndex,
 	}))
+
+	mg.AddMigration("Add column secure_settings in alert_notification", NewAddColumnMigration(alert_notification, &Column{
+		Name: "secure_settings", Type: DB_Text, Nullable: true,
+	}))
+
+	mg.AddMigration("Add column provisioned in alert_notification", NewAddColumnMigration(alert_notification, &Column{
+		Name: "provisioned", Type: DB_Bool, Nullable: false, Default: "0",
+	}))
+
+	mg.AddMigration("Add column version in alert_notification", NewAddColumnMigration(alert_notification, &Column{
+		Name: "version", Type: DB_Int, Nullable: false, Default: "0",
+	}))
 }
@@ -0,0 +1,30 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addCorrelationMigrations(mg *Migrator) {
+	correlationV1 := Table{
+		Name: "correlation",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "source_datasource_id", Type: DB_BigInt, Nullable: false},
+			{Name: "target_datasource_id", Type: DB_BigInt, Nullable: false},
+			{Name: "label", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "description", Type: DB_Text, Nullable: true},
+			{Name: "field", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "target", Type: DB_Text, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "uid"}, Type: UniqueIndex},
+			{Cols: []string{"org_id", "source_datasource_id"}},
+			{Cols: []string{"org_id", "target_datasource_id"}},
+		},
+	}
+
+	mg.AddMigration("create correlation table v1", NewAddTableMigration(correlationV1))
+	addTableIndicesMigrations(mg, "v1", correlationV1)
+}
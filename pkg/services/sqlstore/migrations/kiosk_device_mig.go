@@ -0,0 +1,39 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addKioskDeviceMigrations(mg *Migrator) {
+	kioskDeviceV1 := Table{
+		Name: "kiosk_device",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "name", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+			{Name: "last_seen", Type: DB_DateTime, Nullable: true},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "name"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create kiosk_device table v1", NewAddTableMigration(kioskDeviceV1))
+	addTableIndicesMigrations(mg, "v1", kioskDeviceV1)
+
+	kioskDeviceDashboardV1 := Table{
+		Name: "kiosk_device_dashboard",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "device_id", Type: DB_BigInt, Nullable: false},
+			{Name: "dashboard_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"device_id", "dashboard_uid"}, Type: UniqueIndex},
+			{Cols: []string{"dashboard_uid"}},
+		},
+	}
+
+	mg.AddMigration("create kiosk_device_dashboard table v1", NewAddTableMigration(kioskDeviceDashboardV1))
+	addTableIndicesMigrations(mg, "v1", kioskDeviceDashboardV1)
+}
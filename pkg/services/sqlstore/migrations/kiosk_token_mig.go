@@ -0,0 +1,27 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+// addKioskTokenMigrations creates the table of the kiosk tokens of the playlists and of the kiosk
+// devices. Only the hashes of the tokens are stored.
+func addKioskTokenMigrations(mg *Migrator) {
+	kioskTokenV1 := Table{
+		Name: "kiosk_token",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "token_hash", Type: DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "playlist_id", Type: DB_BigInt, Nullable: false},
+			{Name: "device_id", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"token_hash"}, Type: UniqueIndex},
+			{Cols: []string{"playlist_id"}},
+			{Cols: []string{"device_id"}},
+		},
+	}
+
+	mg.AddMigration("create kiosk_token table v1", NewAddTableMigration(kioskTokenV1))
+	addTableIndicesMigrations(mg, "v1", kioskTokenV1)
+}
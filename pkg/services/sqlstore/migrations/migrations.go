@@ -34,6 +34,17 @@ func AddMigrations(mg *Migrator) {
 	addServerlockMigrations(mg)
 	addUserAuthTokenMigrations(mg)
 	addCacheMigration(mg)
+	addLibraryPanelMigrations(mg)
+	addCorrelationMigrations(mg)
+	addQueryHistoryMigrations(mg)
+	addOrgApiUsageMigrations(mg)
+	addReportMigrations(mg)
+	addAnnotationStoreMigrations(mg)
+	addDataKeyMigrations(mg)
+	addSchedulerMigrations(mg)
+	addUsageEventMigrations(mg)
+	addKioskDeviceMigrations(mg)
+	addKioskTokenMigrations(mg)
 }
 
 func addMigrationLogMigrations(mg *Migrator) {
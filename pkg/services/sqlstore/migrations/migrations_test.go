@@ -1,10 +1,13 @@
 package migrations
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 	"github.com/grafana/grafana/pkg/services/sqlstore/sqlutil"
+	"github.com/stretchr/testify/require"
 	"xorm.io/xorm"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -56,3 +59,56 @@ func TestMigrations(t *testing.T) {
 		})
 	}
 }
+
+func TestMigrationLockAndStatus(t *testing.T) {
+	x, err := xorm.NewEngine(sqlutil.TestDB_Sqlite3.DriverName, sqlutil.TestDB_Sqlite3.ConnStr)
+	require.NoError(t, err)
+	require.NoError(t, NewDialect(x).CleanDB())
+
+	mg := NewMigrator(x)
+	mg.LockingEnabled = true
+	AddMigrations(mg)
+
+	t.Run("Every migration is pending on an empty database", func(t *testing.T) {
+		pending, err := mg.PendingMigrations()
+		require.NoError(t, err)
+		require.Len(t, pending, mg.MigrationsCount())
+
+		status, err := mg.Status()
+		require.NoError(t, err)
+		require.Equal(t, 0, status.Executed)
+		require.Len(t, status.Pending, mg.MigrationsCount())
+		require.Empty(t, status.LastMigration)
+		require.Nil(t, status.Lock)
+	})
+
+	t.Run("Migrations don't run while the lock is held", func(t *testing.T) {
+		require.NoError(t, mg.Lock("other-instance"))
+		require.True(t, errors.Is(mg.Lock("another-instance"), ErrMigrationLocked))
+
+		lock, err := mg.GetLock()
+		require.NoError(t, err)
+		require.Equal(t, "other-instance", lock.Holder)
+
+		err = mg.Start()
+		require.True(t, errors.Is(err, ErrMigrationLocked))
+
+		pending, err := mg.PendingMigrations()
+		require.NoError(t, err)
+		require.Len(t, pending, mg.MigrationsCount())
+	})
+
+	t.Run("Migrations run and release the lock once it's free", func(t *testing.T) {
+		require.NoError(t, mg.Unlock())
+		mg.LockTimeout = time.Minute
+
+		require.NoError(t, mg.Start())
+
+		status, err := mg.Status()
+		require.NoError(t, err)
+		require.Equal(t, mg.MigrationsCount(), status.Executed)
+		require.Empty(t, status.Pending)
+		require.NotEmpty(t, status.LastMigration)
+		require.Nil(t, status.Lock)
+	})
+}
@@ -0,0 +1,21 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addOrgApiUsageMigrations(mg *Migrator) {
+	orgApiUsageV1 := Table{
+		Name: "org_api_usage",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "day", Type: DB_NVarchar, Length: 10, Nullable: false},
+			{Name: "api_calls", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "day"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create org_api_usage table v1", NewAddTableMigration(orgApiUsageV1))
+	addTableIndicesMigrations(mg, "v1", orgApiUsageV1)
+}
@@ -43,4 +43,8 @@ func addPreferencesMigrations(mg *Migrator) {
 		Sqlite("UPDATE preferences SET team_id=0 WHERE team_id IS NULL;").
 		Postgres("UPDATE preferences SET team_id=0 WHERE team_id IS NULL;").
 		Mysql("UPDATE preferences SET team_id=0 WHERE team_id IS NULL;"))
+
+	mg.AddMigration("Add column week_start in preferences", NewAddColumnMigration(preferencesV2, &Column{
+		Name: "week_start", Type: DB_NVarchar, Length: 10, Nullable: true,
+	}))
 }
@@ -0,0 +1,21 @@
+package migrations
+
+import "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addSchedulerMigrations(mg *migrator.Migrator) {
+	schedulerLeader := migrator.Table{
+		Name: "scheduler_leader",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "name", Type: migrator.DB_NVarchar, Length: 100, Nullable: false},
+			{Name: "holder", Type: migrator.DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "expires", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"name"}, Type: migrator.UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create scheduler_leader table", migrator.NewAddTableMigration(schedulerLeader))
+	mg.AddMigration("add unique index scheduler_leader.name", migrator.NewAddIndexMigration(schedulerLeader, schedulerLeader.Indices[0]))
+}
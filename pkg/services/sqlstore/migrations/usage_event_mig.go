@@ -0,0 +1,29 @@
+package migrations
+
+import . "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addUsageEventMigrations(mg *Migrator) {
+	usageEventV1 := Table{
+		Name: "usage_event",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "event_type", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "user_login", Type: DB_NVarchar, Length: 190, Nullable: true},
+			{Name: "dashboard_id", Type: DB_BigInt, Nullable: true},
+			{Name: "dashboard_uid", Type: DB_NVarchar, Length: 40, Nullable: true},
+			{Name: "datasource_id", Type: DB_BigInt, Nullable: true},
+			{Name: "datasource_type", Type: DB_NVarchar, Length: 255, Nullable: true},
+			{Name: "error", Type: DB_Text, Nullable: true},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "created"}},
+			{Cols: []string{"created"}},
+		},
+	}
+
+	mg.AddMigration("create usage_event table v1", NewAddTableMigration(usageEventV1))
+	addTableIndicesMigrations(mg, "v1", usageEventV1)
+}
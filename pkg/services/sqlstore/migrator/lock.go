@@ -0,0 +1,131 @@
+package migrator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	migrationLockTable = "migration_lock"
+	migrationLockId    = 1
+	// lockRetryInterval is how often an instance waiting for the migration lock tries to take it.
+	lockRetryInterval = time.Second
+)
+
+// ErrMigrationLocked is returned when the migration lock is held by someone else.
+var ErrMigrationLocked = errors.New("migrations are locked")
+
+// MigrationLock is the row of the migration lock, present while migrations are locked.
+type MigrationLock struct {
+	Id       int64     `json:"-"`
+	Holder   string    `json:"holder"`
+	Acquired time.Time `json:"acquired"`
+}
+
+// LockHolder identifies the current process as the holder of the migration lock.
+func LockHolder() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", hostname, os.Getpid())
+}
+
+func (mg *Migrator) ensureLockTable() error {
+	table := &Table{
+		Name:        migrationLockTable,
+		PrimaryKeys: []string{"id"},
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true},
+			{Name: "holder", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "acquired", Type: DB_DateTime, Nullable: false},
+		},
+	}
+
+	_, err := mg.x.Exec(mg.Dialect.CreateTableSql(table))
+	return err
+}
+
+// GetLock returns the migration lock, or nil when migrations aren't locked.
+func (mg *Migrator) GetLock() (*MigrationLock, error) {
+	exists, err := mg.x.IsTableExist(migrationLockTable)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	lock := &MigrationLock{}
+	has, err := mg.x.Table(migrationLockTable).Where("id = ?", migrationLockId).Get(lock)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, nil
+	}
+	return lock, nil
+}
+
+// Lock prevents every other instance from running migrations until the lock is released,
+// returning ErrMigrationLocked when it's already held.
+func (mg *Migrator) Lock(holder string) error {
+	if err := mg.ensureLockTable(); err != nil {
+		return err
+	}
+
+	sql := "INSERT INTO " + mg.Dialect.Quote(migrationLockTable) + " (id, holder, acquired) VALUES (?, ?, ?)"
+	if _, err := mg.x.Exec(sql, migrationLockId, holder, time.Now()); err != nil {
+		// the insert fails on the primary key when the lock is held
+		lock, lockErr := mg.GetLock()
+		if lockErr == nil && lock != nil {
+			return ErrMigrationLocked
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Unlock releases the migration lock, whoever holds it.
+func (mg *Migrator) Unlock() error {
+	exists, err := mg.x.IsTableExist(migrationLockTable)
+	if err != nil || !exists {
+		return err
+	}
+
+	_, err = mg.x.Exec("DELETE FROM "+mg.Dialect.Quote(migrationLockTable)+" WHERE id = ?", migrationLockId)
+	return err
+}
+
+// acquireLock takes the migration lock, waiting for it up to the lock timeout of the migrator.
+func (mg *Migrator) acquireLock(holder string) error {
+	deadline := time.Now().Add(mg.LockTimeout)
+	waiting := false
+	for {
+		err := mg.Lock(holder)
+		if !errors.Is(err, ErrMigrationLocked) {
+			return err
+		}
+
+		lock, err := mg.GetLock()
+		if err != nil {
+			return err
+		}
+		if lock == nil {
+			continue
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w by %s since %s, release it with grafana-cli admin migrations unlock if its holder is gone",
+				ErrMigrationLocked, lock.Holder, lock.Acquired.Format(time.RFC3339))
+		}
+
+		if !waiting {
+			mg.Logger.Info("Waiting for migration lock", "holder", lock.Holder, "acquired", lock.Acquired)
+			waiting = true
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
@@ -16,6 +16,21 @@ type Migrator struct {
 	Dialect    Dialect
 	migrations []Migration
 	Logger     log.Logger
+
+	// LockingEnabled makes Start take the migration lock while running pending migrations.
+	LockingEnabled bool
+	// LockTimeout is how long Start waits for the migration lock held by another instance.
+	LockTimeout time.Duration
+}
+
+// MigrationStatus reports the migrations executed on the database and the pending ones.
+type MigrationStatus struct {
+	Total         int            `json:"total"`
+	Executed      int            `json:"executed"`
+	Pending       []string       `json:"pending"`
+	LastMigration string         `json:"lastMigration"`
+	LastExecuted  time.Time      `json:"lastExecuted"`
+	Lock          *MigrationLock `json:"lock"`
 }
 
 type MigrationLog struct {
@@ -72,7 +87,81 @@ func (mg *Migrator) GetMigrationLog() (map[string]MigrationLog, error) {
 	return logMap, nil
 }
 
+// PendingMigrations returns the migrations that haven't been executed yet, in the order they run.
+func (mg *Migrator) PendingMigrations() ([]Migration, error) {
+	logMap, err := mg.GetMigrationLog()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]Migration, 0)
+	for _, m := range mg.migrations {
+		if _, exists := logMap[m.Id()]; !exists {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Status returns the status of the migrations, the last executed migration being the current
+// version of the schema.
+func (mg *Migrator) Status() (*MigrationStatus, error) {
+	logMap, err := mg.GetMigrationLog()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MigrationStatus{Total: len(mg.migrations), Pending: make([]string, 0)}
+	var last MigrationLog
+	for _, m := range mg.migrations {
+		logItem, exists := logMap[m.Id()]
+		if !exists {
+			status.Pending = append(status.Pending, m.Id())
+			continue
+		}
+
+		status.Executed++
+		if logItem.Id > last.Id {
+			last = logItem
+		}
+	}
+	status.LastMigration = last.MigrationId
+	status.LastExecuted = last.Timestamp
+
+	status.Lock, err = mg.GetLock()
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Start runs the pending migrations, holding the migration lock while doing so when locking is enabled.
 func (mg *Migrator) Start() error {
+	if !mg.LockingEnabled {
+		return mg.run()
+	}
+
+	pending, err := mg.PendingMigrations()
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if err := mg.acquireLock(LockHolder()); err != nil {
+		return err
+	}
+	defer func() {
+		if err := mg.Unlock(); err != nil {
+			mg.Logger.Error("Failed to release migration lock", "error", err)
+		}
+	}()
+
+	return mg.run()
+}
+
+func (mg *Migrator) run() error {
 	mg.Logger.Info("Starting DB migration")
 
 	logMap, err := mg.GetMigrationLog()
@@ -108,15 +108,21 @@ func CreateOrg(cmd *models.CreateOrgCommand) error {
 			return err
 		}
 
-		user := models.OrgUser{
-			OrgId:   org.Id,
-			UserId:  cmd.UserId,
-			Role:    models.ROLE_ADMIN,
-			Created: time.Now(),
-			Updated: time.Now(),
+		// orgs created by provisioning don't have an initial admin
+		if cmd.UserId != 0 {
+			user := models.OrgUser{
+				OrgId:   org.Id,
+				UserId:  cmd.UserId,
+				Role:    models.ROLE_ADMIN,
+				Created: time.Now(),
+				Updated: time.Now(),
+			}
+
+			if _, err := sess.Insert(&user); err != nil {
+				return err
+			}
 		}
 
-		_, err := sess.Insert(&user)
 		cmd.Result = org
 
 		sess.publishAfterCommit(&events.OrgCreated{
@@ -125,7 +131,7 @@ func CreateOrg(cmd *models.CreateOrgCommand) error {
 			Name:      org.Name,
 		})
 
-		return err
+		return nil
 	})
 }
 
@@ -207,6 +213,16 @@ func DeleteOrg(cmd *models.DeleteOrgCommand) error {
 			"DELETE FROM org_user WHERE org_id = ?",
 			"DELETE FROM org WHERE id = ?",
 			"DELETE FROM temp_user WHERE org_id = ?",
+			"DELETE FROM query_history_star WHERE EXISTS (SELECT 1 FROM query_history WHERE org_id = ? AND query_history_star.query_uid = query_history.uid)",
+			"DELETE FROM query_history_share WHERE org_id = ?",
+			"DELETE FROM query_history WHERE org_id = ?",
+			"DELETE FROM org_api_usage WHERE org_id = ?",
+			"DELETE FROM report_dashboard WHERE EXISTS (SELECT 1 FROM report WHERE org_id = ? AND report_dashboard.report_id = report.id)",
+			"DELETE FROM report WHERE org_id = ?",
+			"DELETE FROM annotation_store WHERE org_id = ?",
+			"DELETE FROM kiosk_device_dashboard WHERE EXISTS (SELECT 1 FROM kiosk_device WHERE org_id = ? AND kiosk_device_dashboard.device_id = kiosk_device.id)",
+			"DELETE FROM kiosk_device WHERE org_id = ?",
+			"DELETE FROM kiosk_token WHERE org_id = ?",
 		}
 
 		for _, sql := range deletes {
@@ -1,6 +1,7 @@
 package permissions
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/grafana/grafana/pkg/models"
@@ -25,17 +26,13 @@ func (d DashboardPermissionFilter) Where() (string, []interface{}) {
 		okRoles = append(okRoles, models.ROLE_VIEWER)
 	}
 
-	falseStr := d.Dialect.BooleanStr(false)
-
 	sql := `(
 		dashboard.id IN (
 			SELECT distinct DashboardId from (
 				SELECT d.id AS DashboardId
 					FROM dashboard AS d
-					LEFT JOIN dashboard AS folder on folder.id = d.folder_id
-					LEFT JOIN dashboard_acl AS da ON
-						da.dashboard_id = d.id OR
-						da.dashboard_id = d.folder_id
+					` + FolderAncestorsJoin("d") + `
+					LEFT JOIN dashboard_acl AS da ON ` + DashboardOrAncestorsAclCondition("d", "da") + `
 					LEFT JOIN team_member as ugm on ugm.team_id = da.team_id
 					WHERE
 						d.org_id = ? AND
@@ -48,14 +45,11 @@ func (d DashboardPermissionFilter) Where() (string, []interface{}) {
 				UNION
 				SELECT d.id AS DashboardId
 					FROM dashboard AS d
-					LEFT JOIN dashboard AS folder on folder.id = d.folder_id
+					` + FolderAncestorsJoin("d") + `
 					LEFT JOIN dashboard_acl AS da ON
 						(
 							-- include default permissions -->
-							da.org_id = -1 AND (
-							  (folder.id IS NOT NULL AND folder.has_acl = ` + falseStr + `) OR
-							  (folder.id IS NULL AND d.has_acl = ` + falseStr + `)
-							)
+							da.org_id = -1 AND ` + DefaultPermissionsCondition("d", d.Dialect) + `
 						)
 					WHERE
 						d.org_id = ? AND
@@ -75,3 +69,45 @@ func (d DashboardPermissionFilter) Where() (string, []interface{}) {
 	params = append(params, okRoles...)
 	return sql, params
 }
+
+// FolderAncestorsJoin returns the joins that make the folders the dashboard aliased as dashAlias
+// is nested in available as folder1 (its parent folder), folder2 (the parent of folder1) and so on.
+// Joined folders that don't exist are NULL.
+func FolderAncestorsJoin(dashAlias string) string {
+	joins := make([]string, 0, models.MaxFolderNestingDepth)
+	child := dashAlias
+	for i := 1; i <= models.MaxFolderNestingDepth; i++ {
+		folder := fmt.Sprintf("folder%d", i)
+		joins = append(joins, fmt.Sprintf("LEFT JOIN dashboard AS %s ON %s.id = %s.folder_id", folder, folder, child))
+		child = folder
+	}
+
+	return strings.Join(joins, "\n")
+}
+
+// DashboardOrAncestorsAclCondition returns the condition matching the acl items, aliased as aclAlias,
+// of the dashboard aliased as dashAlias and of all the folders it is nested in.
+// It requires the joins returned by FolderAncestorsJoin.
+func DashboardOrAncestorsAclCondition(dashAlias string, aclAlias string) string {
+	conditions := []string{fmt.Sprintf("%s.dashboard_id = %s.id", aclAlias, dashAlias)}
+	for i := 1; i <= models.MaxFolderNestingDepth; i++ {
+		conditions = append(conditions, fmt.Sprintf("%s.dashboard_id = folder%d.id", aclAlias, i))
+	}
+
+	return "(" + strings.Join(conditions, " OR ") + ")"
+}
+
+// DefaultPermissionsCondition returns the condition that holds when the default permissions apply
+// to the dashboard aliased as dashAlias. That is the case when none of the folders it is nested in
+// have permissions set or, for dashboards in the General folder, when the dashboard has no permissions set.
+// It requires the joins returned by FolderAncestorsJoin.
+func DefaultPermissionsCondition(dashAlias string, dialect migrator.Dialect) string {
+	falseStr := dialect.BooleanStr(false)
+
+	folderConditions := []string{"folder1.id IS NOT NULL"}
+	for i := 1; i <= models.MaxFolderNestingDepth; i++ {
+		folderConditions = append(folderConditions, fmt.Sprintf("(folder%d.id IS NULL OR folder%d.has_acl = %s)", i, i, falseStr))
+	}
+
+	return fmt.Sprintf("((%s) OR (folder1.id IS NULL AND %s.has_acl = %s))", strings.Join(folderConditions, " AND "), dashAlias, falseStr)
+}
@@ -12,6 +12,8 @@ func init() {
 	bus.AddHandler("sql", SearchPlaylists)
 	bus.AddHandler("sql", GetPlaylist)
 	bus.AddHandler("sql", GetPlaylistItem)
+	bus.AddHandler("sql", CreatePlaylistKioskToken)
+	bus.AddHandler("sql", DeletePlaylistKioskToken)
 }
 
 func CreatePlaylist(cmd *models.CreatePlaylistCommand) error {
@@ -34,6 +36,7 @@ func CreatePlaylist(cmd *models.CreatePlaylistCommand) error {
 			Value:      item.Value,
 			Order:      item.Order,
 			Title:      item.Title,
+			Interval:   item.Interval,
 		})
 	}
 
@@ -86,6 +89,7 @@ func UpdatePlaylist(cmd *models.UpdatePlaylistCommand) error {
 			Value:      item.Value,
 			Order:      index + 1,
 			Title:      item.Title,
+			Interval:   item.Interval,
 		})
 	}
 
@@ -100,11 +104,18 @@ func GetPlaylist(query *models.GetPlaylistByIdQuery) error {
 	}
 
 	playlist := models.Playlist{}
-	_, err := x.ID(query.Id).Get(&playlist)
+	if _, err := x.ID(query.Id).Get(&playlist); err != nil {
+		return err
+	}
 
-	query.Result = &playlist
+	tokens, err := x.Where("playlist_id=?", query.Id).Count(&models.KioskToken{})
+	if err != nil {
+		return err
+	}
+	playlist.HasKioskToken = tokens > 0
 
-	return err
+	query.Result = &playlist
+	return nil
 }
 
 func DeletePlaylist(cmd *models.DeletePlaylistCommand) error {
@@ -122,8 +133,14 @@ func DeletePlaylist(cmd *models.DeletePlaylistCommand) error {
 
 		var rawItemSql = "DELETE FROM playlist_item WHERE playlist_id = ?"
 		_, err2 := sess.Exec(rawItemSql, cmd.Id)
+		if err2 != nil {
+			return err2
+		}
 
-		return err2
+		var rawKioskTokenSql = "DELETE FROM kiosk_token WHERE playlist_id = ? and org_id = ?"
+		_, err3 := sess.Exec(rawKioskTokenSql, cmd.Id, cmd.OrgId)
+
+		return err3
 	})
 }
 
@@ -155,3 +172,30 @@ func GetPlaylistItem(query *models.GetPlaylistItemsByIdQuery) error {
 
 	return err
 }
+
+func CreatePlaylistKioskToken(cmd *models.CreatePlaylistKioskTokenCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		exists, err := sess.Where("id = ? AND org_id = ?", cmd.Id, cmd.OrgId).Get(&models.Playlist{})
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return models.ErrPlaylistNotFound
+		}
+
+		token, err := replaceKioskToken(sess, &models.KioskToken{OrgId: cmd.OrgId, PlaylistId: cmd.Id})
+		if err != nil {
+			return err
+		}
+
+		cmd.Result = token
+		return nil
+	})
+}
+
+func DeletePlaylistKioskToken(cmd *models.DeletePlaylistKioskTokenCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		_, err := sess.Exec("DELETE FROM kiosk_token WHERE playlist_id = ? AND org_id = ?", cmd.Id, cmd.OrgId)
+		return err
+	})
+}
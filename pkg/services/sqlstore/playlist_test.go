@@ -22,6 +22,74 @@ func TestPlaylistDataAccess(t *testing.T) {
 			err := CreatePlaylist(&cmd)
 			So(err, ShouldBeNil)
 
+			Convey("can get playlist items with intervals", func() {
+				items := []models.PlaylistItemDTO{
+					{Title: "Backend response times", Value: "cIBgcSjkk", Type: "dashboard_by_uid", Interval: "1m"},
+				}
+				update := models.UpdatePlaylistCommand{Name: "NYC office", OrgId: 1, Id: cmd.Result.Id, Interval: "10m", Items: items}
+				err = UpdatePlaylist(&update)
+				So(err, ShouldBeNil)
+
+				query := models.GetPlaylistItemsByIdQuery{PlaylistId: cmd.Result.Id}
+				err = GetPlaylistItem(&query)
+				So(err, ShouldBeNil)
+				So(len(*query.Result), ShouldEqual, 1)
+				So((*query.Result)[0].Type, ShouldEqual, "dashboard_by_uid")
+				So((*query.Result)[0].Interval, ShouldEqual, "1m")
+			})
+
+			Convey("can create and delete kiosk token", func() {
+				tokenCmd := models.CreatePlaylistKioskTokenCommand{Id: cmd.Result.Id, OrgId: 1}
+				err = CreatePlaylistKioskToken(&tokenCmd)
+				So(err, ShouldBeNil)
+				So(tokenCmd.Result, ShouldNotBeEmpty)
+
+				query := models.GetKioskTokenQuery{Token: tokenCmd.Result}
+				err = GetKioskToken(&query)
+				So(err, ShouldBeNil)
+				So(query.Result.PlaylistId, ShouldEqual, cmd.Result.Id)
+				So(query.Result.DeviceId, ShouldEqual, 0)
+
+				playlistQuery := models.GetPlaylistByIdQuery{Id: cmd.Result.Id}
+				err = GetPlaylist(&playlistQuery)
+				So(err, ShouldBeNil)
+				So(playlistQuery.Result.HasKioskToken, ShouldBeTrue)
+
+				Convey("should not create kiosk token for playlist in other org", func() {
+					err = CreatePlaylistKioskToken(&models.CreatePlaylistKioskTokenCommand{Id: cmd.Result.Id, OrgId: 2})
+					So(err, ShouldEqual, models.ErrPlaylistNotFound)
+				})
+
+				Convey("should revoke the previous kiosk token when creating a new one", func() {
+					err = CreatePlaylistKioskToken(&models.CreatePlaylistKioskTokenCommand{Id: cmd.Result.Id, OrgId: 1})
+					So(err, ShouldBeNil)
+
+					err = GetKioskToken(&query)
+					So(err, ShouldEqual, models.ErrKioskTokenNotFound)
+				})
+
+				Convey("should not find empty kiosk token", func() {
+					err = GetKioskToken(&models.GetKioskTokenQuery{})
+					So(err, ShouldEqual, models.ErrKioskTokenNotFound)
+				})
+
+				Convey("should not find kiosk token after deleting it", func() {
+					err = DeletePlaylistKioskToken(&models.DeletePlaylistKioskTokenCommand{Id: cmd.Result.Id, OrgId: 1})
+					So(err, ShouldBeNil)
+
+					err = GetKioskToken(&query)
+					So(err, ShouldEqual, models.ErrKioskTokenNotFound)
+				})
+
+				Convey("should not find kiosk token after deleting the playlist", func() {
+					err = DeletePlaylist(&models.DeletePlaylistCommand{Id: cmd.Result.Id, OrgId: 1})
+					So(err, ShouldBeNil)
+
+					err = GetKioskToken(&query)
+					So(err, ShouldEqual, models.ErrKioskTokenNotFound)
+				})
+			})
+
 			Convey("can update playlist", func() {
 				items := []models.PlaylistItemDTO{
 					{Title: "influxdb", Value: "influxdb", Type: "dashboard_by_tag"},
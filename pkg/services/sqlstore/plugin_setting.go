@@ -4,9 +4,8 @@ import (
 	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/encryption"
 	"github.com/grafana/grafana/pkg/models"
-	"github.com/grafana/grafana/pkg/setting"
-	"github.com/grafana/grafana/pkg/util"
 )
 
 func init() {
@@ -76,8 +75,12 @@ func UpdatePluginSetting(cmd *models.UpdatePluginSettingCmd) error {
 			_, err = sess.Insert(&pluginSetting)
 			return err
 		}
+		if pluginSetting.SecureJsonData == nil && len(cmd.SecureJsonData) > 0 {
+			pluginSetting.SecureJsonData = make(map[string][]byte)
+		}
+
 		for key, data := range cmd.SecureJsonData {
-			encryptedData, err := util.Encrypt([]byte(data), setting.SecretKey)
+			encryptedData, err := encryption.Encrypt([]byte(data))
 			if err != nil {
 				return err
 			}
@@ -30,9 +30,11 @@ func GetPreferencesWithDefaults(query *models.GetPreferencesWithDefaultsQuery) e
 	params = append(params, query.User.OrgId)
 	params = append(params, query.User.UserId)
 	params = append(params, query.User.OrgId)
+	// the preferences of the instance have no org
+	filter += " OR (org_id=0 AND team_id=0 AND user_id=0)"
 	prefs := make([]*models.Preferences, 0)
 	err := x.Where(filter, params...).
-		OrderBy("user_id ASC, team_id ASC").
+		OrderBy("user_id ASC, team_id ASC, org_id ASC").
 		Find(&prefs)
 
 	if err != nil {
@@ -42,6 +44,7 @@ func GetPreferencesWithDefaults(query *models.GetPreferencesWithDefaultsQuery) e
 	res := &models.Preferences{
 		Theme:           setting.DefaultTheme,
 		Timezone:        "browser",
+		WeekStart:       strings.ToLower(setting.WeekStart.String()),
 		HomeDashboardId: 0,
 	}
 
@@ -52,6 +55,9 @@ func GetPreferencesWithDefaults(query *models.GetPreferencesWithDefaultsQuery) e
 		if p.Timezone != "" {
 			res.Timezone = p.Timezone
 		}
+		if p.WeekStart != "" {
+			res.WeekStart = p.WeekStart
+		}
 		if p.HomeDashboardId != 0 {
 			res.HomeDashboardId = p.HomeDashboardId
 		}
@@ -94,6 +100,7 @@ func SavePreferences(cmd *models.SavePreferencesCommand) error {
 				TeamId:          cmd.TeamId,
 				HomeDashboardId: cmd.HomeDashboardId,
 				Timezone:        cmd.Timezone,
+				WeekStart:       cmd.WeekStart,
 				Theme:           cmd.Theme,
 				Created:         time.Now(),
 				Updated:         time.Now(),
@@ -103,6 +110,7 @@ func SavePreferences(cmd *models.SavePreferencesCommand) error {
 		}
 		prefs.HomeDashboardId = cmd.HomeDashboardId
 		prefs.Timezone = cmd.Timezone
+		prefs.WeekStart = cmd.WeekStart
 		prefs.Theme = cmd.Theme
 		prefs.Updated = time.Now()
 		prefs.Version += 1
@@ -19,9 +19,37 @@ func TestPreferencesDataAccess(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(query.Result.Theme, ShouldEqual, setting.DefaultTheme)
 			So(query.Result.Timezone, ShouldEqual, "browser")
+			So(query.Result.WeekStart, ShouldEqual, "monday")
 			So(query.Result.HomeDashboardId, ShouldEqual, 0)
 		})
 
+		Convey("GetPreferencesWithDefaults should override the instance preferences with the org, team and user ones", func() {
+			err := SavePreferences(&models.SavePreferencesCommand{Theme: "light", Timezone: "utc", WeekStart: "sunday"})
+			So(err, ShouldBeNil)
+			err = SavePreferences(&models.SavePreferencesCommand{OrgId: 1, Timezone: "Europe/Paris"})
+			So(err, ShouldBeNil)
+			err = SavePreferences(&models.SavePreferencesCommand{OrgId: 1, TeamId: 2, WeekStart: "saturday"})
+			So(err, ShouldBeNil)
+			err = SavePreferences(&models.SavePreferencesCommand{OrgId: 1, UserId: 1, Theme: "dark"})
+			So(err, ShouldBeNil)
+
+			query := &models.GetPreferencesWithDefaultsQuery{
+				User: &models.SignedInUser{OrgId: 1, UserId: 1, Teams: []int64{2}},
+			}
+			err = GetPreferencesWithDefaults(query)
+			So(err, ShouldBeNil)
+			So(query.Result.Theme, ShouldEqual, "dark")
+			So(query.Result.Timezone, ShouldEqual, "Europe/Paris")
+			So(query.Result.WeekStart, ShouldEqual, "saturday")
+
+			query = &models.GetPreferencesWithDefaultsQuery{User: &models.SignedInUser{OrgId: 2, UserId: 3}}
+			err = GetPreferencesWithDefaults(query)
+			So(err, ShouldBeNil)
+			So(query.Result.Theme, ShouldEqual, "light")
+			So(query.Result.Timezone, ShouldEqual, "utc")
+			So(query.Result.WeekStart, ShouldEqual, "sunday")
+		})
+
 		Convey("GetPreferencesWithDefaults with saved org and user home dashboard should return user home dashboard", func() {
 			err := SavePreferences(&models.SavePreferencesCommand{OrgId: 1, HomeDashboardId: 1})
 			So(err, ShouldBeNil)
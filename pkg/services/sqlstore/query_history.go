@@ -0,0 +1,407 @@
+package sqlstore
+
+import (
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", CreateQueryHistory)
+	bus.AddHandler("sql", PatchQueryHistoryComment)
+	bus.AddHandler("sql", DeleteQueryHistory)
+	bus.AddHandler("sql", StarQueryHistory)
+	bus.AddHandler("sql", UnstarQueryHistory)
+	bus.AddHandler("sql", ShareQueryHistory)
+	bus.AddHandler("sql", UnshareQueryHistory)
+	bus.AddHandler("sql", SearchQueryHistory)
+	bus.AddHandler("sql", MigrateQueryHistory)
+}
+
+func CreateQueryHistory(cmd *models.CreateQueryHistoryCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		queryHistory, err := insertQueryHistory(sess, cmd.OrgId, cmd.UserId, cmd.DatasourceUid, cmd.Queries, time.Now().Unix(), "")
+		if err != nil {
+			return err
+		}
+
+		cmd.Result = queryHistory
+		return nil
+	})
+}
+
+func PatchQueryHistoryComment(cmd *models.PatchQueryHistoryCommentCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		queryHistory, err := getOwnQueryHistory(sess, cmd.OrgId, cmd.UserId, cmd.Uid)
+		if err != nil {
+			return err
+		}
+
+		queryHistory.Comment = cmd.Comment
+		if _, err := sess.ID(queryHistory.Id).Cols("comment").Update(queryHistory); err != nil {
+			return err
+		}
+
+		cmd.Result = queryHistory
+		return nil
+	})
+}
+
+func DeleteQueryHistory(cmd *models.DeleteQueryHistoryCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		queryHistory, err := getOwnQueryHistory(sess, cmd.OrgId, cmd.UserId, cmd.Uid)
+		if err != nil {
+			return err
+		}
+
+		deletes := []string{
+			"DELETE FROM query_history_star WHERE query_uid = ?",
+			"DELETE FROM query_history_share WHERE query_uid = ?",
+			"DELETE FROM query_history WHERE uid = ?",
+		}
+
+		for _, sql := range deletes {
+			if _, err := sess.Exec(sql, queryHistory.Uid); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func StarQueryHistory(cmd *models.StarQueryHistoryCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		if err := canSeeQueryHistory(sess, cmd.OrgId, cmd.UserId, cmd.Uid); err != nil {
+			return err
+		}
+
+		exists, err := sess.Where("user_id=? AND query_uid=?", cmd.UserId, cmd.Uid).Get(&models.QueryHistoryStar{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return models.ErrQueryHistoryAlreadyStarred
+		}
+
+		_, err = sess.Insert(&models.QueryHistoryStar{QueryUid: cmd.Uid, UserId: cmd.UserId})
+		return err
+	})
+}
+
+func UnstarQueryHistory(cmd *models.UnstarQueryHistoryCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		if err := canSeeQueryHistory(sess, cmd.OrgId, cmd.UserId, cmd.Uid); err != nil {
+			return err
+		}
+
+		_, err := sess.Exec("DELETE FROM query_history_star WHERE user_id = ? AND query_uid = ?", cmd.UserId, cmd.Uid)
+		return err
+	})
+}
+
+func ShareQueryHistory(cmd *models.ShareQueryHistoryCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		queryHistory, err := getOwnQueryHistory(sess, cmd.OrgId, cmd.UserId, cmd.Uid)
+		if err != nil {
+			return err
+		}
+
+		if _, err := teamExists(cmd.OrgId, cmd.TeamId, sess); err != nil {
+			if err == models.ErrTeamNotFound {
+				return models.ErrQueryHistoryTeamNotFound
+			}
+			return err
+		}
+
+		// only admins can share queries with teams they are not a member of
+		if !cmd.IsAdmin {
+			isMember, err := sess.Where("org_id=? AND team_id=? AND user_id=?", cmd.OrgId, cmd.TeamId, cmd.UserId).Get(&models.TeamMember{})
+			if err != nil {
+				return err
+			}
+			if !isMember {
+				return models.ErrQueryHistoryAccessDenied
+			}
+		}
+
+		exists, err := sess.Where("query_uid=? AND team_id=?", queryHistory.Uid, cmd.TeamId).Get(&models.QueryHistoryShare{})
+		if err != nil || exists {
+			return err
+		}
+
+		_, err = sess.Insert(&models.QueryHistoryShare{
+			QueryUid: queryHistory.Uid,
+			OrgId:    cmd.OrgId,
+			TeamId:   cmd.TeamId,
+			SharedBy: cmd.UserId,
+			Created:  time.Now().Unix(),
+		})
+		return err
+	})
+}
+
+func UnshareQueryHistory(cmd *models.UnshareQueryHistoryCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		queryHistory, err := getOwnQueryHistory(sess, cmd.OrgId, cmd.UserId, cmd.Uid)
+		if err != nil {
+			return err
+		}
+
+		_, err = sess.Exec("DELETE FROM query_history_share WHERE query_uid = ? AND team_id = ?", queryHistory.Uid, cmd.TeamId)
+		return err
+	})
+}
+
+type queryHistorySearchProjection struct {
+	Uid            string
+	DatasourceUid  string
+	CreatedBy      int64
+	CreatedByLogin string
+	CreatedAt      int64
+	Comment        string
+	Queries        *simplejson.Json
+	StarredBy      int64
+}
+
+func SearchQueryHistory(query *models.SearchQueryHistoryQuery) error {
+	if query.Limit <= 0 {
+		query.Limit = 100
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+
+	filter := SqlBuilder{}
+	filter.Write(` FROM query_history AS qh
+		LEFT OUTER JOIN query_history_star AS qhs ON qhs.query_uid = qh.uid AND qhs.user_id = ?
+		LEFT OUTER JOIN `+dialect.Quote("user")+` AS u ON u.id = qh.created_by
+		WHERE qh.org_id = ? AND (
+			qh.created_by = ? OR
+			qh.uid IN (
+				SELECT s.query_uid FROM query_history_share AS s
+				INNER JOIN team_member AS tm ON tm.team_id = s.team_id
+				WHERE s.org_id = ? AND tm.user_id = ?
+			)
+		)`, query.UserId, query.OrgId, query.UserId, query.OrgId, query.UserId)
+
+	if len(query.DatasourceUids) > 0 {
+		filter.Write(` AND qh.datasource_uid IN (?` + strings.Repeat(",?", len(query.DatasourceUids)-1) + `)`)
+		for _, uid := range query.DatasourceUids {
+			filter.AddParams(uid)
+		}
+	}
+
+	if query.SearchString != "" {
+		filter.Write(` AND (qh.queries `+dialect.LikeStr()+` ? OR qh.comment `+dialect.LikeStr()+` ?)`, "%"+query.SearchString+"%", "%"+query.SearchString+"%")
+	}
+
+	if query.OnlyStarred {
+		filter.Write(` AND qhs.id IS NOT NULL`)
+	}
+
+	var count int64
+	countSQL := "SELECT COUNT(*)" + filter.GetSqlString()
+	if _, err := x.SQL(countSQL, filter.params...).Get(&count); err != nil {
+		return err
+	}
+
+	sql := SqlBuilder{}
+	sql.Write(`SELECT
+		qh.uid,
+		qh.datasource_uid,
+		qh.created_by,
+		u.login AS created_by_login,
+		qh.created_at,
+		qh.comment,
+		qh.queries,
+		qhs.user_id AS starred_by`)
+	sql.Write(filter.GetSqlString(), filter.params...)
+
+	if query.Sort == "time-asc" {
+		sql.Write(` ORDER BY qh.created_at ASC, qh.id ASC`)
+	} else {
+		sql.Write(` ORDER BY qh.created_at DESC, qh.id DESC`)
+	}
+
+	offset := query.Limit * (query.Page - 1)
+	sql.Write(dialect.LimitOffset(int64(query.Limit), int64(offset)))
+
+	rows := make([]*queryHistorySearchProjection, 0)
+	if err := x.SQL(sql.GetSqlString(), sql.params...).Find(&rows); err != nil {
+		return err
+	}
+
+	result := make([]*models.QueryHistoryDTO, 0, len(rows))
+	for _, row := range rows {
+		sharedWith := make([]int64, 0)
+		if row.CreatedBy == query.UserId {
+			if err := x.Table("query_history_share").Where("query_uid = ?", row.Uid).Cols("team_id").Find(&sharedWith); err != nil {
+				return err
+			}
+		}
+
+		result = append(result, &models.QueryHistoryDTO{
+			Uid:            row.Uid,
+			DatasourceUid:  row.DatasourceUid,
+			CreatedBy:      row.CreatedBy,
+			CreatedByLogin: row.CreatedByLogin,
+			CreatedAt:      row.CreatedAt,
+			Comment:        row.Comment,
+			Queries:        row.Queries,
+			Starred:        row.StarredBy > 0,
+			SharedWith:     sharedWith,
+		})
+	}
+
+	query.Result = &models.QueryHistorySearchResult{
+		TotalCount:   count,
+		QueryHistory: result,
+		Page:         query.Page,
+		PerPage:      query.Limit,
+	}
+
+	return nil
+}
+
+// MigrateQueryHistory imports the query history kept in the local storage of the browser.
+// The local storage entries reference data sources by name, these are resolved to uids
+// and entries of data sources that no longer exist are skipped.
+func MigrateQueryHistory(cmd *models.MigrateQueryHistoryCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		dataSources := make([]*models.DataSource, 0)
+		if err := sess.Where("org_id=?", cmd.OrgId).Find(&dataSources); err != nil {
+			return err
+		}
+
+		uidsByName := make(map[string]string)
+		uidsById := make(map[int64]string)
+		for _, ds := range dataSources {
+			uidsByName[ds.Name] = ds.Uid
+			uidsById[ds.Id] = ds.Uid
+		}
+
+		for _, item := range cmd.Queries {
+			datasourceUid, ok := uidsByName[item.DatasourceName]
+			if !ok {
+				datasourceUid, ok = uidsById[item.DatasourceId]
+			}
+
+			if !ok || item.Queries == nil || len(item.Queries.MustArray()) == 0 {
+				cmd.SkippedCount++
+				continue
+			}
+
+			createdAt := item.CreatedAt
+			if createdAt <= 0 {
+				createdAt = time.Now().Unix()
+			} else if createdAt > 1e12 {
+				// the browser stores timestamps in milliseconds
+				createdAt /= 1000
+			}
+
+			queryHistory, err := insertQueryHistory(sess, cmd.OrgId, cmd.UserId, datasourceUid, item.Queries, createdAt, item.Comment)
+			if err != nil {
+				return err
+			}
+			cmd.TotalCount++
+
+			if item.Starred {
+				if _, err := sess.Insert(&models.QueryHistoryStar{QueryUid: queryHistory.Uid, UserId: cmd.UserId}); err != nil {
+					return err
+				}
+				cmd.StarredCount++
+			}
+		}
+
+		return nil
+	})
+}
+
+func insertQueryHistory(sess *DBSession, orgId int64, userId int64, datasourceUid string, queries *simplejson.Json, createdAt int64, comment string) (*models.QueryHistory, error) {
+	uid, err := generateNewQueryHistoryUid(sess, orgId)
+	if err != nil {
+		return nil, err
+	}
+
+	queryHistory := &models.QueryHistory{
+		Uid:           uid,
+		OrgId:         orgId,
+		DatasourceUid: datasourceUid,
+		CreatedBy:     userId,
+		CreatedAt:     createdAt,
+		Comment:       comment,
+		Queries:       queries,
+	}
+
+	if _, err := sess.Insert(queryHistory); err != nil {
+		return nil, err
+	}
+
+	return queryHistory, nil
+}
+
+func getOwnQueryHistory(sess *DBSession, orgId int64, userId int64, uid string) (*models.QueryHistory, error) {
+	queryHistory := models.QueryHistory{}
+	exists, err := sess.Where("org_id=? AND uid=?", orgId, uid).Get(&queryHistory)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, models.ErrQueryHistoryNotFound
+	}
+
+	if queryHistory.CreatedBy != userId {
+		return nil, models.ErrQueryHistoryAccessDenied
+	}
+
+	return &queryHistory, nil
+}
+
+// canSeeQueryHistory checks that the query was created by the user or shared with one of the teams of the user
+func canSeeQueryHistory(sess *DBSession, orgId int64, userId int64, uid string) error {
+	queryHistory := models.QueryHistory{}
+	exists, err := sess.Where("org_id=? AND uid=?", orgId, uid).Get(&queryHistory)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return models.ErrQueryHistoryNotFound
+	}
+
+	if queryHistory.CreatedBy == userId {
+		return nil
+	}
+
+	res, err := sess.Query(`SELECT 1 FROM query_history_share AS s
+		INNER JOIN team_member AS tm ON tm.team_id = s.team_id
+		WHERE s.query_uid = ? AND s.org_id = ? AND tm.user_id = ?`, uid, orgId, userId)
+	if err != nil {
+		return err
+	}
+	if len(res) == 0 {
+		return models.ErrQueryHistoryNotFound
+	}
+
+	return nil
+}
+
+func generateNewQueryHistoryUid(sess *DBSession, orgId int64) (string, error) {
+	for i := 0; i < 3; i++ {
+		uid := generateNewUid()
+
+		exists, err := sess.Where("org_id=? AND uid=?", orgId, uid).Get(&models.QueryHistory{})
+		if err != nil {
+			return "", err
+		}
+
+		if !exists {
+			return uid, nil
+		}
+	}
+
+	return "", models.ErrQueryHistoryFailedGenerateUniqueUid
+}
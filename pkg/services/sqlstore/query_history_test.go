@@ -0,0 +1,185 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestQueryHistoryDataAccess(t *testing.T) {
+	createUser := func(t *testing.T, login string) int64 {
+		cmd := &models.CreateUserCommand{
+			Email: fmt.Sprint(login, "@test.com"),
+			Name:  login,
+			Login: login,
+		}
+		err := CreateUser(context.Background(), cmd)
+		require.NoError(t, err)
+		return cmd.Result.Id
+	}
+
+	createQuery := func(t *testing.T, userId int64, datasourceUid string, expr string) *models.QueryHistory {
+		cmd := models.CreateQueryHistoryCommand{
+			DatasourceUid: datasourceUid,
+			Queries:       simplejson.NewFromAny([]interface{}{map[string]interface{}{"expr": expr}}),
+			OrgId:         1,
+			UserId:        userId,
+		}
+		err := CreateQueryHistory(&cmd)
+		require.NoError(t, err)
+		return cmd.Result
+	}
+
+	search := func(t *testing.T, query models.SearchQueryHistoryQuery) *models.QueryHistorySearchResult {
+		query.OrgId = 1
+		err := SearchQueryHistory(&query)
+		require.NoError(t, err)
+		return query.Result
+	}
+
+	t.Run("Can create and search queries", func(t *testing.T) {
+		InitTestDB(t)
+		userId := createUser(t, "alice")
+		otherId := createUser(t, "bob")
+
+		createQuery(t, userId, "loki", `{job="grafana"}`)
+		createQuery(t, userId, "prometheus", "up")
+		createQuery(t, otherId, "loki", `{job="other"}`)
+
+		result := search(t, models.SearchQueryHistoryQuery{UserId: userId})
+		require.Equal(t, int64(2), result.TotalCount)
+		require.Len(t, result.QueryHistory, 2)
+		require.Equal(t, "alice", result.QueryHistory[0].CreatedByLogin)
+
+		result = search(t, models.SearchQueryHistoryQuery{UserId: userId, DatasourceUids: []string{"loki"}})
+		require.Len(t, result.QueryHistory, 1)
+		require.Equal(t, "loki", result.QueryHistory[0].DatasourceUid)
+
+		result = search(t, models.SearchQueryHistoryQuery{UserId: userId, SearchString: "up"})
+		require.Len(t, result.QueryHistory, 1)
+		require.Equal(t, "prometheus", result.QueryHistory[0].DatasourceUid)
+
+		result = search(t, models.SearchQueryHistoryQuery{UserId: userId, Limit: 1, Page: 2})
+		require.Equal(t, int64(2), result.TotalCount)
+		require.Len(t, result.QueryHistory, 1)
+	})
+
+	t.Run("Can comment, star and delete own queries", func(t *testing.T) {
+		InitTestDB(t)
+		userId := createUser(t, "alice")
+		otherId := createUser(t, "bob")
+		query := createQuery(t, userId, "loki", `{job="grafana"}`)
+
+		err := PatchQueryHistoryComment(&models.PatchQueryHistoryCommentCommand{Uid: query.Uid, OrgId: 1, UserId: userId, Comment: "errors"})
+		require.NoError(t, err)
+
+		err = PatchQueryHistoryComment(&models.PatchQueryHistoryCommentCommand{Uid: query.Uid, OrgId: 1, UserId: otherId, Comment: "mine"})
+		require.Equal(t, models.ErrQueryHistoryAccessDenied, err)
+
+		err = StarQueryHistory(&models.StarQueryHistoryCommand{Uid: query.Uid, OrgId: 1, UserId: userId})
+		require.NoError(t, err)
+		err = StarQueryHistory(&models.StarQueryHistoryCommand{Uid: query.Uid, OrgId: 1, UserId: userId})
+		require.Equal(t, models.ErrQueryHistoryAlreadyStarred, err)
+
+		result := search(t, models.SearchQueryHistoryQuery{UserId: userId, OnlyStarred: true})
+		require.Len(t, result.QueryHistory, 1)
+		require.True(t, result.QueryHistory[0].Starred)
+		require.Equal(t, "errors", result.QueryHistory[0].Comment)
+
+		err = UnstarQueryHistory(&models.UnstarQueryHistoryCommand{Uid: query.Uid, OrgId: 1, UserId: userId})
+		require.NoError(t, err)
+		result = search(t, models.SearchQueryHistoryQuery{UserId: userId, OnlyStarred: true})
+		require.Len(t, result.QueryHistory, 0)
+
+		err = DeleteQueryHistory(&models.DeleteQueryHistoryCommand{Uid: query.Uid, OrgId: 1, UserId: userId})
+		require.NoError(t, err)
+		result = search(t, models.SearchQueryHistoryQuery{UserId: userId})
+		require.Len(t, result.QueryHistory, 0)
+	})
+
+	t.Run("Shared queries are visible to team members", func(t *testing.T) {
+		InitTestDB(t)
+		userId := createUser(t, "alice")
+		memberId := createUser(t, "bob")
+		outsiderId := createUser(t, "carol")
+
+		team := models.CreateTeamCommand{OrgId: 1, Name: "team"}
+		err := CreateTeam(&team)
+		require.NoError(t, err)
+		err = AddTeamMember(&models.AddTeamMemberCommand{OrgId: 1, TeamId: team.Result.Id, UserId: userId})
+		require.NoError(t, err)
+		err = AddTeamMember(&models.AddTeamMemberCommand{OrgId: 1, TeamId: team.Result.Id, UserId: memberId})
+		require.NoError(t, err)
+
+		query := createQuery(t, userId, "loki", `{job="grafana"}`)
+
+		err = ShareQueryHistory(&models.ShareQueryHistoryCommand{Uid: query.Uid, OrgId: 1, UserId: outsiderId, TeamId: team.Result.Id})
+		require.Equal(t, models.ErrQueryHistoryAccessDenied, err)
+
+		err = ShareQueryHistory(&models.ShareQueryHistoryCommand{Uid: query.Uid, OrgId: 1, UserId: userId, TeamId: 999})
+		require.Equal(t, models.ErrQueryHistoryTeamNotFound, err)
+
+		err = ShareQueryHistory(&models.ShareQueryHistoryCommand{Uid: query.Uid, OrgId: 1, UserId: userId, TeamId: team.Result.Id})
+		require.NoError(t, err)
+
+		result := search(t, models.SearchQueryHistoryQuery{UserId: userId})
+		require.Len(t, result.QueryHistory, 1)
+		require.Equal(t, []int64{team.Result.Id}, result.QueryHistory[0].SharedWith)
+
+		result = search(t, models.SearchQueryHistoryQuery{UserId: memberId})
+		require.Len(t, result.QueryHistory, 1)
+		require.Equal(t, query.Uid, result.QueryHistory[0].Uid)
+
+		err = StarQueryHistory(&models.StarQueryHistoryCommand{Uid: query.Uid, OrgId: 1, UserId: memberId})
+		require.NoError(t, err)
+
+		result = search(t, models.SearchQueryHistoryQuery{UserId: outsiderId})
+		require.Len(t, result.QueryHistory, 0)
+
+		err = StarQueryHistory(&models.StarQueryHistoryCommand{Uid: query.Uid, OrgId: 1, UserId: outsiderId})
+		require.Equal(t, models.ErrQueryHistoryNotFound, err)
+
+		err = UnshareQueryHistory(&models.UnshareQueryHistoryCommand{Uid: query.Uid, OrgId: 1, UserId: userId, TeamId: team.Result.Id})
+		require.NoError(t, err)
+
+		result = search(t, models.SearchQueryHistoryQuery{UserId: memberId})
+		require.Len(t, result.QueryHistory, 0)
+	})
+
+	t.Run("Can migrate query history from local storage", func(t *testing.T) {
+		InitTestDB(t)
+		userId := createUser(t, "alice")
+
+		ds := models.AddDataSourceCommand{OrgId: 1, Name: "loki", Type: models.DS_ES, Access: models.DS_ACCESS_PROXY, Url: "http://test"}
+		err := AddDataSource(&ds)
+		require.NoError(t, err)
+
+		queries := simplejson.NewFromAny([]interface{}{map[string]interface{}{"expr": "up"}})
+		cmd := models.MigrateQueryHistoryCommand{
+			OrgId:  1,
+			UserId: userId,
+			Queries: []models.MigrateQueryHistoryItem{
+				{DatasourceName: "loki", Queries: queries, CreatedAt: 1600000000000, Starred: true},
+				{DatasourceId: ds.Result.Id, Queries: queries, CreatedAt: 1600000001000, Comment: "by id"},
+				{DatasourceName: "deleted", Queries: queries, CreatedAt: 1600000002000},
+			},
+		}
+		err = MigrateQueryHistory(&cmd)
+		require.NoError(t, err)
+		require.Equal(t, 2, cmd.TotalCount)
+		require.Equal(t, 1, cmd.StarredCount)
+		require.Equal(t, 1, cmd.SkippedCount)
+
+		result := search(t, models.SearchQueryHistoryQuery{UserId: userId, Sort: "time-asc"})
+		require.Len(t, result.QueryHistory, 2)
+		require.Equal(t, int64(1600000000), result.QueryHistory[0].CreatedAt)
+		require.True(t, result.QueryHistory[0].Starred)
+		require.Equal(t, ds.Result.Uid, result.QueryHistory[1].DatasourceUid)
+		require.Equal(t, "by id", result.QueryHistory[1].Comment)
+	})
+}
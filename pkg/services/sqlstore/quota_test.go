@@ -23,6 +23,9 @@ func TestQuotaCommandsAndQueries(t *testing.T) {
 				Dashboard:  5,
 				DataSource: 5,
 				ApiKey:     5,
+				Snapshot:   5,
+				Team:       5,
+				Playlist:   5,
 			},
 			User: &setting.UserQuota{
 				Org: 5,
@@ -91,7 +94,7 @@ func TestQuotaCommandsAndQueries(t *testing.T) {
 				err = GetOrgQuotas(&query)
 
 				So(err, ShouldBeNil)
-				So(len(query.Result), ShouldEqual, 4)
+				So(len(query.Result), ShouldEqual, 7)
 				for _, res := range query.Result {
 					limit := 5 //default quota limit
 					used := 0
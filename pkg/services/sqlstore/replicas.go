@@ -0,0 +1,141 @@
+package sqlstore
+
+import (
+	"database/sql/driver"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"xorm.io/xorm"
+)
+
+// replicaRetryInterval is how long the reads skip a replica that couldn't be reached.
+const replicaRetryInterval = 30 * time.Second
+
+// replica is a read-only replica of the database.
+type replica struct {
+	engine *xorm.Engine
+	host   string
+
+	mu       sync.Mutex
+	failedAt time.Time
+}
+
+func (r *replica) available(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return now.Sub(r.failedAt) >= replicaRetryInterval
+}
+
+func (r *replica) failed(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failedAt = now
+}
+
+var (
+	replicas     []*replica
+	replicaIndex uint32
+)
+
+// initReplicas connects to the read-only replicas of the database, which share the
+// configuration of the primary database besides what their url sets.
+func (ss *SqlStore) initReplicas() error {
+	replicas = nil
+	if len(ss.dbCfg.ReplicaUrls) == 0 {
+		return nil
+	}
+
+	if ss.dbCfg.Type == migrator.SQLITE {
+		ss.log.Warn("Read replicas are not supported with sqlite3, reads are sent to the primary database")
+		return nil
+	}
+
+	for _, replicaURL := range ss.dbCfg.ReplicaUrls {
+		dbURL, err := url.Parse(replicaURL)
+		if err != nil {
+			return err
+		}
+
+		replicaCfg := ss.dbCfg
+		replicaCfg.ConnectionString = ""
+		readDatabaseURL(&replicaCfg, dbURL)
+
+		replicaStore := &SqlStore{Cfg: ss.Cfg, dbCfg: replicaCfg}
+		engine, err := replicaStore.getEngine()
+		if err != nil {
+			return err
+		}
+
+		ss.log.Info("Reading from database replica", "host", replicaCfg.Host)
+		replicas = append(replicas, &replica{engine: engine, host: replicaCfg.Host})
+	}
+
+	return nil
+}
+
+// readDatabaseURL sets the configuration of a replica from its url, overriding only what the url sets.
+// The type of the replica is always the type of the primary database.
+func readDatabaseURL(dbCfg *DatabaseConfig, dbURL *url.URL) {
+	dbCfg.Host = dbURL.Host
+
+	pathSplit := strings.Split(dbURL.Path, "/")
+	if len(pathSplit) > 1 && pathSplit[1] != "" {
+		dbCfg.Name = pathSplit[1]
+	}
+
+	if userInfo := dbURL.User; userInfo != nil {
+		dbCfg.User = userInfo.Username()
+		dbCfg.Pwd, _ = userInfo.Password()
+	}
+
+	if query := dbURL.Query(); len(query) > 0 {
+		dbCfg.UrlQueryParams = query
+	}
+}
+
+// nextReplica returns the next available replica in a round robin, or nil when none is available.
+func nextReplica() *replica {
+	now := time.Now()
+	for i := 0; i < len(replicas); i++ {
+		r := replicas[int(atomic.AddUint32(&replicaIndex, 1))%len(replicas)]
+		if r.available(now) {
+			return r
+		}
+	}
+	return nil
+}
+
+// withReadEngine runs a read-only query on a replica of the database when there's one, falling back
+// to the primary database when the replica can't be reached. Reads from replicas can lag behind
+// the latest writes, so only reads which can tolerate it should use it. query can be run twice, so it
+// resets the results it reads into rather than appending to the rows read from the replica.
+func withReadEngine(query func(engine *xorm.Engine) error) error {
+	r := nextReplica()
+	if r == nil {
+		return query(x)
+	}
+
+	err := query(r.engine)
+	if err == nil || !isConnectionError(err) {
+		return err
+	}
+
+	sqlog.Warn("Failed to reach database replica, reading from the primary", "host", r.host, "error", err)
+	r.failed(time.Now())
+	return query(x)
+}
+
+func isConnectionError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
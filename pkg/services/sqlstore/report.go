@@ -0,0 +1,252 @@
+package sqlstore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", CreateReport)
+	bus.AddHandler("sql", UpdateReport)
+	bus.AddHandler("sql", DeleteReport)
+	bus.AddHandler("sql", UpdateReportRun)
+	bus.AddHandler("sql", GetReportById)
+	bus.AddHandler("sql", GetReports)
+	bus.AddHandler("sql", GetDueReports)
+}
+
+func CreateReport(cmd *models.CreateReportCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		if err := checkReportName(sess, cmd.OrgId, 0, cmd.Name); err != nil {
+			return err
+		}
+
+		report := &models.Report{
+			OrgId:       cmd.OrgId,
+			UserId:      cmd.UserId,
+			Name:        cmd.Name,
+			Schedule:    cmd.Schedule,
+			Timezone:    cmd.Timezone,
+			TimeFrom:    cmd.TimeFrom,
+			TimeTo:      cmd.TimeTo,
+			Recipients:  cmd.Recipients,
+			ReplyTo:     cmd.ReplyTo,
+			Message:     cmd.Message,
+			Format:      cmd.Format,
+			Orientation: cmd.Orientation,
+			Enabled:     cmd.Enabled,
+			NextRunAt:   cmd.NextRunAt,
+			Created:     time.Now(),
+			Updated:     time.Now(),
+			Dashboards:  cmd.Dashboards,
+		}
+
+		if _, err := sess.Insert(report); err != nil {
+			return err
+		}
+
+		if err := insertReportDashboards(sess, report.Id, cmd.Dashboards); err != nil {
+			return err
+		}
+
+		cmd.Result = report
+		return nil
+	})
+}
+
+func UpdateReport(cmd *models.UpdateReportCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		report, err := getReport(sess, cmd.Id, cmd.OrgId)
+		if err != nil {
+			return err
+		}
+
+		if err := checkReportName(sess, cmd.OrgId, cmd.Id, cmd.Name); err != nil {
+			return err
+		}
+
+		report.UserId = cmd.UserId
+		report.Name = cmd.Name
+		report.Schedule = cmd.Schedule
+		report.Timezone = cmd.Timezone
+		report.TimeFrom = cmd.TimeFrom
+		report.TimeTo = cmd.TimeTo
+		report.Recipients = cmd.Recipients
+		report.ReplyTo = cmd.ReplyTo
+		report.Message = cmd.Message
+		report.Format = cmd.Format
+		report.Orientation = cmd.Orientation
+		report.Enabled = cmd.Enabled
+		report.NextRunAt = cmd.NextRunAt
+		report.Updated = time.Now()
+		report.Dashboards = cmd.Dashboards
+
+		cols := []string{"user_id", "name", "schedule", "timezone", "time_from", "time_to", "recipients", "reply_to",
+			"message", "format", "orientation", "enabled", "next_run_at", "updated"}
+		if _, err := sess.ID(report.Id).Cols(cols...).Update(report); err != nil {
+			return err
+		}
+
+		if _, err := sess.Exec("DELETE FROM report_dashboard WHERE report_id = ?", report.Id); err != nil {
+			return err
+		}
+
+		if err := insertReportDashboards(sess, report.Id, cmd.Dashboards); err != nil {
+			return err
+		}
+
+		cmd.Result = report
+		return nil
+	})
+}
+
+func DeleteReport(cmd *models.DeleteReportCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		if _, err := getReport(sess, cmd.Id, cmd.OrgId); err != nil {
+			return err
+		}
+
+		deletes := []string{
+			"DELETE FROM report_dashboard WHERE report_id = ?",
+			"DELETE FROM report WHERE id = ?",
+		}
+
+		for _, sql := range deletes {
+			if _, err := sess.Exec(sql, cmd.Id); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func UpdateReportRun(cmd *models.UpdateReportRunCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		report := &models.Report{
+			NextRunAt:  cmd.NextRunAt,
+			LastSentAt: cmd.RunAt,
+			LastError:  cmd.Error,
+		}
+
+		cols := []string{"next_run_at"}
+		if cmd.RunAt > 0 {
+			cols = append(cols, "last_error")
+			if cmd.Error == "" {
+				cols = append(cols, "last_sent_at")
+			}
+		}
+
+		_, err := sess.ID(cmd.Id).Cols(cols...).Update(report)
+		return err
+	})
+}
+
+func GetReportById(query *models.GetReportByIdQuery) error {
+	report, err := getReport(newSession(), query.Id, query.OrgId)
+	if err != nil {
+		return err
+	}
+
+	query.Result = report
+	return nil
+}
+
+func GetReports(query *models.GetReportsQuery) error {
+	reports := make([]*models.Report, 0)
+	if err := x.Where("org_id = ?", query.OrgId).Asc("name").Find(&reports); err != nil {
+		return err
+	}
+
+	if err := loadReportDashboards(newSession(), reports); err != nil {
+		return err
+	}
+
+	query.Result = reports
+	return nil
+}
+
+func GetDueReports(query *models.GetDueReportsQuery) error {
+	reports := make([]*models.Report, 0)
+	if err := x.Where("enabled = ? AND next_run_at <= ?", dialect.BooleanStr(true), query.Now).Asc("next_run_at").Find(&reports); err != nil {
+		return err
+	}
+
+	if err := loadReportDashboards(newSession(), reports); err != nil {
+		return err
+	}
+
+	query.Result = reports
+	return nil
+}
+
+func getReport(sess *DBSession, id int64, orgId int64) (*models.Report, error) {
+	report := &models.Report{}
+	has, err := sess.Where("id = ? AND org_id = ?", id, orgId).Get(report)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, models.ErrReportNotFound
+	}
+
+	if err := loadReportDashboards(sess, []*models.Report{report}); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func checkReportName(sess *DBSession, orgId int64, id int64, name string) error {
+	existing := &models.Report{}
+	has, err := sess.Where("org_id = ? AND name = ? AND id <> ?", orgId, name, id).Get(existing)
+	if err != nil {
+		return err
+	}
+	if has {
+		return models.ErrReportWithSameNameExists
+	}
+	return nil
+}
+
+func insertReportDashboards(sess *DBSession, reportId int64, uids []string) error {
+	for i, uid := range uids {
+		if _, err := sess.Insert(&models.ReportDashboard{ReportId: reportId, DashboardUid: uid, Order: i}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadReportDashboards(sess *DBSession, reports []*models.Report) error {
+	if len(reports) == 0 {
+		return nil
+	}
+
+	byId := make(map[int64]*models.Report, len(reports))
+	ids := make([]int64, 0, len(reports))
+	for _, report := range reports {
+		report.Dashboards = make([]string, 0)
+		byId[report.Id] = report
+		ids = append(ids, report.Id)
+	}
+
+	dashboards := make([]*models.ReportDashboard, 0)
+	if err := sess.In("report_id", ids).Find(&dashboards); err != nil {
+		return err
+	}
+
+	sort.Slice(dashboards, func(i, j int) bool {
+		return dashboards[i].Order < dashboards[j].Order
+	})
+
+	for _, dashboard := range dashboards {
+		report := byId[dashboard.ReportId]
+		report.Dashboards = append(report.Dashboards, dashboard.DashboardUid)
+	}
+
+	return nil
+}
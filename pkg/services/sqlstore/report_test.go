@@ -0,0 +1,132 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func TestReportDataAccess(t *testing.T) {
+	createReport := func(t *testing.T, orgId int64, name string, nextRunAt int64, enabled bool) *models.Report {
+		cmd := models.CreateReportCommand{
+			ReportSettings: models.ReportSettings{
+				Name:        name,
+				Dashboards:  []string{"b", "a"},
+				Schedule:    "0 8 * * *",
+				Recipients:  "ops@example.com",
+				Format:      models.ReportFormatPdf,
+				Orientation: models.ReportOrientationLandscape,
+				Enabled:     enabled,
+			},
+			OrgId:     orgId,
+			UserId:    1,
+			NextRunAt: nextRunAt,
+		}
+		err := CreateReport(&cmd)
+		require.NoError(t, err)
+		return cmd.Result
+	}
+
+	t.Run("Can create, get and delete reports", func(t *testing.T) {
+		InitTestDB(t)
+		report := createReport(t, 1, "daily", 100, true)
+		createReport(t, 2, "daily", 100, true)
+
+		err := CreateReport(&models.CreateReportCommand{ReportSettings: models.ReportSettings{Name: "daily"}, OrgId: 1})
+		require.Equal(t, models.ErrReportWithSameNameExists, err)
+
+		query := models.GetReportByIdQuery{Id: report.Id, OrgId: 1}
+		err = GetReportById(&query)
+		require.NoError(t, err)
+		require.Equal(t, "daily", query.Result.Name)
+		require.Equal(t, []string{"b", "a"}, query.Result.Dashboards)
+
+		err = GetReportById(&models.GetReportByIdQuery{Id: report.Id, OrgId: 2})
+		require.Equal(t, models.ErrReportNotFound, err)
+
+		reportsQuery := models.GetReportsQuery{OrgId: 1}
+		err = GetReports(&reportsQuery)
+		require.NoError(t, err)
+		require.Len(t, reportsQuery.Result, 1)
+
+		err = DeleteReport(&models.DeleteReportCommand{Id: report.Id, OrgId: 1})
+		require.NoError(t, err)
+
+		err = GetReportById(&models.GetReportByIdQuery{Id: report.Id, OrgId: 1})
+		require.Equal(t, models.ErrReportNotFound, err)
+	})
+
+	t.Run("Can update reports", func(t *testing.T) {
+		InitTestDB(t)
+		report := createReport(t, 1, "daily", 100, true)
+		createReport(t, 1, "weekly", 100, true)
+
+		cmd := models.UpdateReportCommand{
+			ReportSettings: models.ReportSettings{
+				Name:       "weekly",
+				Dashboards: []string{"c"},
+				Schedule:   "0 8 * * 1",
+				Recipients: "dev@example.com",
+			},
+			Id:     report.Id,
+			OrgId:  1,
+			UserId: 2,
+		}
+		err := UpdateReport(&cmd)
+		require.Equal(t, models.ErrReportWithSameNameExists, err)
+
+		cmd.Name = "daily ops"
+		err = UpdateReport(&cmd)
+		require.NoError(t, err)
+
+		query := models.GetReportByIdQuery{Id: report.Id, OrgId: 1}
+		err = GetReportById(&query)
+		require.NoError(t, err)
+		require.Equal(t, "daily ops", query.Result.Name)
+		require.Equal(t, "0 8 * * 1", query.Result.Schedule)
+		require.Equal(t, int64(2), query.Result.UserId)
+		require.Equal(t, []string{"c"}, query.Result.Dashboards)
+	})
+
+	t.Run("Returns the enabled reports that are due", func(t *testing.T) {
+		InitTestDB(t)
+		due := createReport(t, 1, "due", 100, true)
+		createReport(t, 1, "later", 300, true)
+		createReport(t, 2, "disabled", 100, false)
+
+		query := models.GetDueReportsQuery{Now: 200}
+		err := GetDueReports(&query)
+		require.NoError(t, err)
+		require.Len(t, query.Result, 1)
+		require.Equal(t, due.Id, query.Result[0].Id)
+		require.Equal(t, []string{"b", "a"}, query.Result[0].Dashboards)
+
+		err = UpdateReportRun(&models.UpdateReportRunCommand{Id: due.Id, NextRunAt: 400})
+		require.NoError(t, err)
+
+		err = UpdateReportRun(&models.UpdateReportRunCommand{Id: due.Id, NextRunAt: 400, RunAt: 200, Error: "render failed"})
+		require.NoError(t, err)
+
+		reportQuery := models.GetReportByIdQuery{Id: due.Id, OrgId: 1}
+		err = GetReportById(&reportQuery)
+		require.NoError(t, err)
+		require.Equal(t, int64(400), reportQuery.Result.NextRunAt)
+		require.Equal(t, int64(0), reportQuery.Result.LastSentAt)
+		require.Equal(t, "render failed", reportQuery.Result.LastError)
+
+		err = UpdateReportRun(&models.UpdateReportRunCommand{Id: due.Id, NextRunAt: 500, RunAt: 400})
+		require.NoError(t, err)
+
+		err = GetReportById(&reportQuery)
+		require.NoError(t, err)
+		require.Equal(t, int64(400), reportQuery.Result.LastSentAt)
+		require.Equal(t, "", reportQuery.Result.LastError)
+
+		query = models.GetDueReportsQuery{Now: 200}
+		err = GetDueReports(&query)
+		require.NoError(t, err)
+		require.Len(t, query.Result, 0)
+	})
+}
@@ -0,0 +1,169 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", DeleteRowsBeyondRetention)
+}
+
+// retentionTable is the table of a kind of data with a retention policy
+type retentionTable struct {
+	name string
+	// filter selects the rows of the kind which can be deleted
+	filter string
+	// created converts a time to the type of the created column
+	created func(t time.Time) interface{}
+	// dependents are the tables whose rows are deleted with the rows of the table, by the column
+	// referencing them
+	dependents map[string]string
+}
+
+var retentionTables = map[string]retentionTable{
+	models.RetentionAnnotations: {
+		name:       "annotation",
+		filter:     "alert_id = 0 OR alert_id IS NULL",
+		created:    func(t time.Time) interface{} { return t.UnixNano() / int64(time.Millisecond) },
+		dependents: map[string]string{"annotation_tag": "annotation_id"},
+	},
+	models.RetentionAlertExecutions: {
+		name:       "annotation",
+		filter:     "alert_id > 0",
+		created:    func(t time.Time) interface{} { return t.UnixNano() / int64(time.Millisecond) },
+		dependents: map[string]string{"annotation_tag": "annotation_id"},
+	},
+	models.RetentionDashboardVersions: {
+		name: "dashboard_version",
+		filter: `NOT EXISTS (SELECT 1 FROM dashboard WHERE dashboard.id = dashboard_version.dashboard_id
+			AND dashboard.version = dashboard_version.version)`,
+		created: func(t time.Time) interface{} { return t },
+	},
+	models.RetentionLoginAttempts: {
+		name:    "login_attempt",
+		filter:  "1 = 1",
+		created: func(t time.Time) interface{} { return t.Unix() },
+	},
+}
+
+// DeleteRowsBeyondRetention deletes the rows of a kind of data beyond its retention policy, by
+// batches so that a large backlog doesn't end up in one huge transaction.
+func DeleteRowsBeyondRetention(cmd *models.DeleteRowsBeyondRetentionCommand) error {
+	table, ok := retentionTables[cmd.Kind]
+	if !ok {
+		return fmt.Errorf("unknown kind of data %q", cmd.Kind)
+	}
+	if cmd.BatchSize < 1 {
+		cmd.BatchSize = 100
+	}
+
+	batches := 0
+	if !cmd.OlderThan.IsZero() {
+		where := "(" + table.filter + ") AND created < ?"
+		for ; batches < cmd.MaxBatches; batches++ {
+			deleted, err := deleteRetentionBatch(table, cmd.BatchSize, where, table.created(cmd.OlderThan))
+			if err != nil {
+				return err
+			}
+			cmd.DeletedRows += deleted
+			if deleted < int64(cmd.BatchSize) {
+				break
+			}
+		}
+	}
+
+	excess := int64(0)
+	if cmd.MaxRows > 0 {
+		count, err := countRetentionRows(table, table.filter)
+		if err != nil {
+			return err
+		}
+		excess = count - cmd.MaxRows
+
+		// the oldest rows are deleted first
+		for ; excess > 0 && batches < cmd.MaxBatches; batches++ {
+			limit := int64(cmd.BatchSize)
+			if excess < limit {
+				limit = excess
+			}
+			deleted, err := deleteRetentionBatch(table, int(limit), table.filter)
+			if err != nil {
+				return err
+			}
+			cmd.DeletedRows += deleted
+			excess -= deleted
+			if deleted < limit {
+				break
+			}
+		}
+	}
+
+	if batches < cmd.MaxBatches {
+		cmd.PendingRows = 0
+		return nil
+	}
+
+	// the rows over the maximum are mostly the old ones
+	cmd.PendingRows = excess
+	if !cmd.OlderThan.IsZero() {
+		older, err := countRetentionRows(table, "("+table.filter+") AND created < ?", table.created(cmd.OlderThan))
+		if err != nil {
+			return err
+		}
+		if older > cmd.PendingRows {
+			cmd.PendingRows = older
+		}
+	}
+	if cmd.PendingRows < 0 {
+		cmd.PendingRows = 0
+	}
+	return nil
+}
+
+func countRetentionRows(table retentionTable, where string, args ...interface{}) (int64, error) {
+	var count int64
+	err := withDbSession(context.Background(), func(sess *DBSession) error {
+		var err error
+		count, err = sess.Table(table.name).Where(where, args...).Count()
+		return err
+	})
+	return count, err
+}
+
+// deleteRetentionBatch deletes the oldest rows of the table matching where, up to limit
+func deleteRetentionBatch(table retentionTable, limit int, where string, args ...interface{}) (int64, error) {
+	deleted := int64(0)
+	err := inTransaction(func(sess *DBSession) error {
+		var ids []interface{}
+		query := "SELECT id FROM " + table.name + " WHERE " + where + " ORDER BY id ASC " + dialect.Limit(int64(limit))
+		if err := sess.SQL(query, args...).Find(&ids); err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		in := "(?" + strings.Repeat(",?", len(ids)-1) + ")"
+		for dependent, column := range table.dependents {
+			sqlOrArgs := append([]interface{}{"DELETE FROM " + dependent + " WHERE " + column + " IN " + in}, ids...)
+			if _, err := sess.Exec(sqlOrArgs...); err != nil {
+				return err
+			}
+		}
+
+		sqlOrArgs := append([]interface{}{"DELETE FROM " + table.name + " WHERE id IN " + in}, ids...)
+		result, err := sess.Exec(sqlOrArgs...)
+		if err != nil {
+			return err
+		}
+		deleted, err = result.RowsAffected()
+		return err
+	})
+	return deleted, err
+}
@@ -0,0 +1,98 @@
+package sqlstore
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations"
+)
+
+func TestDeleteRowsBeyondRetention(t *testing.T) {
+	Convey("Testing the retention cleanup", t, func() {
+		InitTestDB(t)
+		repo := SqlAnnotationRepo{}
+		now := time.Now()
+
+		// saveAnnotation saves an annotation created at the given time
+		saveAnnotation := func(alertID int64, created time.Time) int64 {
+			item := &annotations.Item{OrgId: 1, AlertId: alertID, Text: "annotation", Epoch: 10, Tags: []string{"outage"}}
+			err := repo.Save(item)
+			So(err, ShouldBeNil)
+			_, err = x.Exec("UPDATE annotation SET created = ? WHERE id = ?", created.UnixNano()/int64(time.Millisecond), item.Id)
+			So(err, ShouldBeNil)
+			return item.Id
+		}
+		countRows := func(table string, where string) int64 {
+			count, err := x.Table(table).Where(where).Count()
+			So(err, ShouldBeNil)
+			return count
+		}
+
+		saveAnnotation(0, now.Add(-48*time.Hour))
+		saveAnnotation(0, now.Add(-time.Hour))
+		newest := saveAnnotation(0, now)
+		saveAnnotation(1, now.Add(-48*time.Hour))
+		saveAnnotation(1, now.Add(-48*time.Hour))
+
+		Convey("Should delete the annotations older than the max age with their tags", func() {
+			cmd := models.DeleteRowsBeyondRetentionCommand{
+				Kind:       models.RetentionAnnotations,
+				OlderThan:  now.Add(-24 * time.Hour),
+				MaxBatches: 10,
+			}
+			err := DeleteRowsBeyondRetention(&cmd)
+			So(err, ShouldBeNil)
+			So(cmd.DeletedRows, ShouldEqual, 1)
+			So(cmd.PendingRows, ShouldEqual, 0)
+			So(countRows("annotation", "alert_id = 0"), ShouldEqual, 2)
+			So(countRows("annotation", "alert_id > 0"), ShouldEqual, 2)
+			So(countRows("annotation_tag", "1 = 1"), ShouldEqual, 4)
+		})
+
+		Convey("Should keep the newest annotations up to the max rows", func() {
+			cmd := models.DeleteRowsBeyondRetentionCommand{
+				Kind:       models.RetentionAnnotations,
+				MaxRows:    1,
+				MaxBatches: 10,
+			}
+			err := DeleteRowsBeyondRetention(&cmd)
+			So(err, ShouldBeNil)
+			So(cmd.DeletedRows, ShouldEqual, 2)
+			So(countRows("annotation", "alert_id = 0"), ShouldEqual, 1)
+			So(countRows("annotation", fmt.Sprintf("id = %d", newest)), ShouldEqual, 1)
+		})
+
+		Convey("Should leave the rows beyond the max batches for the next cleanup", func() {
+			cmd := models.DeleteRowsBeyondRetentionCommand{
+				Kind:       models.RetentionAlertExecutions,
+				OlderThan:  now.Add(-24 * time.Hour),
+				BatchSize:  1,
+				MaxBatches: 1,
+			}
+			err := DeleteRowsBeyondRetention(&cmd)
+			So(err, ShouldBeNil)
+			So(cmd.DeletedRows, ShouldEqual, 1)
+			So(cmd.PendingRows, ShouldEqual, 1)
+			So(countRows("annotation", "alert_id = 0"), ShouldEqual, 3)
+		})
+
+		Convey("Should keep the current version of the dashboards", func() {
+			dashboard := insertTestDashboard("retention", 1, 0, false)
+			updateTestDashboard(dashboard, map[string]interface{}{"tags": "updated"})
+
+			cmd := models.DeleteRowsBeyondRetentionCommand{
+				Kind:       models.RetentionDashboardVersions,
+				OlderThan:  now.Add(time.Hour),
+				MaxBatches: 10,
+			}
+			err := DeleteRowsBeyondRetention(&cmd)
+			So(err, ShouldBeNil)
+			So(cmd.DeletedRows, ShouldEqual, 1)
+			So(countRows("dashboard_version", "version = 2"), ShouldEqual, 1)
+		})
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"reflect"
 
+	"github.com/opentracing/opentracing-go"
 	"xorm.io/xorm"
 )
 
@@ -46,8 +47,21 @@ func startSession(ctx context.Context, engine *xorm.Engine, beginTran bool) (*DB
 	return newSess, nil
 }
 
+// startSpan starts a span of the database calls of a traced request, or a noop span otherwise.
+func startSpan(ctx context.Context, operationName string) opentracing.Span {
+	if opentracing.SpanFromContext(ctx) == nil {
+		return opentracing.NoopTracer{}.StartSpan(operationName)
+	}
+
+	span, _ := opentracing.StartSpanFromContext(ctx, operationName)
+	return span
+}
+
 // WithDbSession calls the callback with an session attached to the context.
 func (ss *SqlStore) WithDbSession(ctx context.Context, callback dbTransactionFunc) error {
+	span := startSpan(ctx, "sqlstore session")
+	defer span.Finish()
+
 	sess, err := startSession(ctx, ss.engine, false)
 	if err != nil {
 		return err
@@ -57,6 +71,9 @@ func (ss *SqlStore) WithDbSession(ctx context.Context, callback dbTransactionFun
 }
 
 func withDbSession(ctx context.Context, callback dbTransactionFunc) error {
+	span := startSpan(ctx, "sqlstore session")
+	defer span.Finish()
+
 	sess, err := startSession(ctx, x, false)
 	if err != nil {
 		return err
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/permissions"
 )
 
 type SqlBuilder struct {
@@ -40,18 +41,14 @@ func (sb *SqlBuilder) writeDashboardPermissionFilter(user *models.SignedInUser,
 		okRoles = append(okRoles, models.ROLE_VIEWER)
 	}
 
-	falseStr := dialect.BooleanStr(false)
-
 	sb.sql.WriteString(` AND
 	(
 		dashboard.id IN (
 			SELECT distinct DashboardId from (
 				SELECT d.id AS DashboardId
 					FROM dashboard AS d
-					LEFT JOIN dashboard AS folder on folder.id = d.folder_id
-					LEFT JOIN dashboard_acl AS da ON
-						da.dashboard_id = d.id OR
-						da.dashboard_id = d.folder_id
+					` + permissions.FolderAncestorsJoin("d") + `
+					LEFT JOIN dashboard_acl AS da ON ` + permissions.DashboardOrAncestorsAclCondition("d", "da") + `
 					LEFT JOIN team_member as ugm on ugm.team_id = da.team_id
 					WHERE
 						d.org_id = ? AND
@@ -64,14 +61,11 @@ func (sb *SqlBuilder) writeDashboardPermissionFilter(user *models.SignedInUser,
 				UNION
 				SELECT d.id AS DashboardId
 					FROM dashboard AS d
-					LEFT JOIN dashboard AS folder on folder.id = d.folder_id
+					` + permissions.FolderAncestorsJoin("d") + `
 					LEFT JOIN dashboard_acl AS da ON
 						(
 							-- include default permissions -->
-							da.org_id = -1 AND (
-							  (folder.id IS NOT NULL AND folder.has_acl = ` + falseStr + `) OR
-							  (folder.id IS NULL AND d.has_acl = ` + falseStr + `)
-							)
+							da.org_id = -1 AND ` + permissions.DefaultPermissionsCondition("d", dialect) + `
 						)
 					WHERE
 						d.org_id = ? AND
@@ -60,37 +60,22 @@ type SqlStore struct {
 	engine                      *xorm.Engine
 	log                         log.Logger
 	Dialect                     migrator.Dialect
+	migrator                    *migrator.Migrator
 	skipEnsureDefaultOrgAndUser bool
 }
 
 func (ss *SqlStore) Init() error {
-	ss.log = log.New("sqlstore")
-	ss.readConfig()
-
-	engine, err := ss.getEngine()
+	mg, err := ss.InitMigrator()
 	if err != nil {
-		return fmt.Errorf("Fail to connect to database: %v", err)
+		return err
 	}
 
-	ss.engine = engine
-	ss.Dialect = migrator.NewDialect(ss.engine)
-
-	// temporarily still set global var
-	x = engine
-	dialect = ss.Dialect
-
-	migrator := migrator.NewMigrator(x)
-	migrations.AddMigrations(migrator)
-
-	for _, descriptor := range registry.GetServices() {
-		sc, ok := descriptor.Instance.(registry.DatabaseMigrator)
-		if ok {
-			sc.AddMigration(migrator)
-		}
+	if err := mg.Start(); err != nil {
+		return fmt.Errorf("Migration failed err: %v", err)
 	}
 
-	if err := migrator.Start(); err != nil {
-		return fmt.Errorf("Migration failed err: %v", err)
+	if err := ss.initReplicas(); err != nil {
+		return fmt.Errorf("Fail to connect to database replica: %v", err)
 	}
 
 	// Init repo instances
@@ -113,6 +98,45 @@ func (ss *SqlStore) Init() error {
 	return ss.ensureMainOrgAndAdminUser()
 }
 
+// InitMigrator connects to the database and returns the migrator with every migration added,
+// without running them.
+func (ss *SqlStore) InitMigrator() (*migrator.Migrator, error) {
+	ss.log = log.New("sqlstore")
+	ss.readConfig()
+
+	engine, err := ss.getEngine()
+	if err != nil {
+		return nil, fmt.Errorf("Fail to connect to database: %v", err)
+	}
+
+	ss.engine = engine
+	ss.Dialect = migrator.NewDialect(ss.engine)
+
+	// temporarily still set global var
+	x = engine
+	dialect = ss.Dialect
+
+	mg := migrator.NewMigrator(x)
+	mg.LockingEnabled = ss.dbCfg.MigrationLocking
+	mg.LockTimeout = time.Duration(ss.dbCfg.MigrationLockTimeout) * time.Second
+	migrations.AddMigrations(mg)
+
+	for _, descriptor := range registry.GetServices() {
+		sc, ok := descriptor.Instance.(registry.DatabaseMigrator)
+		if ok {
+			sc.AddMigration(mg)
+		}
+	}
+
+	ss.migrator = mg
+	return mg, nil
+}
+
+// MigrationStatus returns the status of the database migrations.
+func (ss *SqlStore) MigrationStatus() (*migrator.MigrationStatus, error) {
+	return ss.migrator.Status()
+}
+
 func (ss *SqlStore) logOrgsNotice() error {
 	type targetCount struct {
 		Count int64
@@ -329,6 +353,10 @@ func (ss *SqlStore) readConfig() {
 	ss.dbCfg.Path = sec.Key("path").MustString("data/grafana.db")
 
 	ss.dbCfg.CacheMode = sec.Key("cache_mode").MustString("private")
+
+	ss.dbCfg.MigrationLocking = sec.Key("migration_locking").MustBool(true)
+	ss.dbCfg.MigrationLockTimeout = sec.Key("migration_lock_timeout").MustInt(300)
+	ss.dbCfg.ReplicaUrls = util.SplitString(sec.Key("replica_urls").String())
 }
 
 // Interface of arguments for testing db
@@ -436,4 +464,8 @@ type DatabaseConfig struct {
 	ConnMaxLifetime  int
 	CacheMode        string
 	UrlQueryParams   map[string][]string
+
+	MigrationLocking     bool
+	MigrationLockTimeout int
+	ReplicaUrls          []string
 }
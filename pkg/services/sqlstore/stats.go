@@ -13,6 +13,8 @@ func init() {
 	bus.AddHandler("sql", GetDataSourceStats)
 	bus.AddHandler("sql", GetDataSourceAccessStats)
 	bus.AddHandler("sql", GetAdminStats)
+	bus.AddHandler("sql", GetOrgUsage)
+	bus.AddHandler("sql", IncrementOrgApiUsage)
 	bus.AddHandler("sql", GetActiveUserStats)
 	bus.AddHandlerCtx("sql", GetAlertNotifiersUsageStats)
 	bus.AddHandlerCtx("sql", GetSystemUserCountStats)
@@ -177,6 +179,109 @@ func GetAdminStats(query *models.GetAdminStatsQuery) error {
 	return nil
 }
 
+const orgApiUsageDayFormat = "2006-01-02"
+
+func GetOrgUsage(query *models.GetOrgUsageQuery) error {
+	if query.Days <= 0 {
+		query.Days = 30
+	}
+
+	var rawSql = `SELECT
+		(
+			SELECT COUNT(*)
+			FROM ` + dialect.Quote("dashboard") + `
+			WHERE org_id = ? AND is_folder = ` + dialect.BooleanStr(false) + `
+		) AS dashboards,
+		(
+			SELECT COUNT(*)
+			FROM ` + dialect.Quote("dashboard") + `
+			WHERE org_id = ? AND is_folder = ` + dialect.BooleanStr(true) + `
+		) AS folders,
+		(
+			SELECT COUNT(*)
+			FROM ` + dialect.Quote("data_source") + `
+			WHERE org_id = ?
+		) AS datasources,
+		(
+			SELECT COUNT(*)
+			FROM ` + dialect.Quote("org_user") + `
+			WHERE org_id = ?
+		) AS users,
+		(
+			SELECT COUNT(*)
+			FROM ` + dialect.Quote("team") + `
+			WHERE org_id = ?
+		) AS teams,
+		(
+			SELECT COUNT(*)
+			FROM ` + dialect.Quote("playlist") + `
+			WHERE org_id = ?
+		) AS playlists,
+		(
+			SELECT COUNT(*)
+			FROM ` + dialect.Quote("api_key") + `
+			WHERE org_id = ?
+		) AS api_keys,
+		(
+			SELECT COUNT(*)
+			FROM ` + dialect.Quote("alert") + `
+			WHERE org_id = ?
+		) AS alerts,
+		(
+			SELECT COUNT(*)
+			FROM ` + dialect.Quote("annotation") + `
+			WHERE org_id = ?
+		) AS annotations`
+
+	params := make([]interface{}, 9)
+	for i := range params {
+		params[i] = query.OrgId
+	}
+
+	var usage models.OrgUsage
+	if _, err := x.SQL(rawSql, params...).Get(&usage); err != nil {
+		return err
+	}
+	usage.OrgId = query.OrgId
+
+	from := time.Now().UTC().AddDate(0, 0, -(query.Days - 1)).Format(orgApiUsageDayFormat)
+	rows := make([]*models.OrgApiUsage, 0)
+	if err := x.Where("org_id = ? AND day >= ?", query.OrgId, from).Asc("day").Find(&rows); err != nil {
+		return err
+	}
+
+	usage.ApiCalls = make([]*models.OrgApiUsageDTO, 0, len(rows))
+	for _, row := range rows {
+		usage.ApiCalls = append(usage.ApiCalls, &models.OrgApiUsageDTO{Day: row.Day, ApiCalls: row.ApiCalls})
+	}
+
+	query.Result = &usage
+	return nil
+}
+
+func IncrementOrgApiUsage(cmd *models.IncrementOrgApiUsageCommand) error {
+	day := cmd.Day.UTC().Format(orgApiUsageDayFormat)
+
+	return inTransaction(func(sess *DBSession) error {
+		for orgId, apiCalls := range cmd.ApiCalls {
+			res, err := sess.Exec("UPDATE org_api_usage SET api_calls = api_calls + ? WHERE org_id = ? AND day = ?", apiCalls, orgId, day)
+			if err != nil {
+				return err
+			}
+
+			if affected, _ := res.RowsAffected(); affected > 0 {
+				continue
+			}
+
+			if _, err := sess.Insert(&models.OrgApiUsage{OrgId: orgId, Day: day, ApiCalls: apiCalls}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
 func GetSystemUserCountStats(ctx context.Context, query *models.GetSystemUserCountStatsQuery) error {
 	return withDbSession(ctx, func(sess *DBSession) error {
 		var rawSql = `SELECT COUNT(id) AS Count FROM ` + dialect.Quote("user")
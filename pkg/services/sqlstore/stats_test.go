@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/stretchr/testify/assert"
@@ -65,6 +66,35 @@ func TestStatsDataAccess(t *testing.T) {
 			assert.Equal(t, int64(0), query.Result.ActiveEditors)
 			assert.Equal(t, int64(0), query.Result.ActiveViewers)
 		})
+
+		t.Run("Get org usage should count resources and api calls of the org", func(t *testing.T) {
+			getOrgQuery := models.GetOrgByNameQuery{Name: "Org #0"}
+			err := GetOrgByName(&getOrgQuery)
+			require.NoError(t, err)
+			orgId := getOrgQuery.Result.Id
+
+			err = IncrementOrgApiUsage(&models.IncrementOrgApiUsageCommand{Day: time.Now(), ApiCalls: map[int64]int64{orgId: 3, orgId + 1: 5}})
+			require.NoError(t, err)
+			err = IncrementOrgApiUsage(&models.IncrementOrgApiUsageCommand{Day: time.Now(), ApiCalls: map[int64]int64{orgId: 2}})
+			require.NoError(t, err)
+			err = IncrementOrgApiUsage(&models.IncrementOrgApiUsageCommand{Day: time.Now().AddDate(0, 0, -40), ApiCalls: map[int64]int64{orgId: 7}})
+			require.NoError(t, err)
+
+			query := models.GetOrgUsageQuery{OrgId: orgId}
+			err = GetOrgUsage(&query)
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), query.Result.Users)
+			assert.Equal(t, int64(0), query.Result.Dashboards)
+			require.Len(t, query.Result.ApiCalls, 1)
+			assert.Equal(t, time.Now().UTC().Format("2006-01-02"), query.Result.ApiCalls[0].Day)
+			assert.Equal(t, int64(5), query.Result.ApiCalls[0].ApiCalls)
+
+			query = models.GetOrgUsageQuery{OrgId: orgId, Days: 60}
+			err = GetOrgUsage(&query)
+			require.NoError(t, err)
+			require.Len(t, query.Result.ApiCalls, 2)
+			assert.Equal(t, int64(7), query.Result.ApiCalls[0].ApiCalls)
+		})
 	})
 }
 
@@ -32,6 +32,9 @@ func inTransactionWithRetry(callback dbTransactionFunc, retry int) error {
 }
 
 func inTransactionWithRetryCtx(ctx context.Context, engine *xorm.Engine, callback dbTransactionFunc, retry int) error {
+	span := startSpan(ctx, "sqlstore transaction")
+	defer span.Finish()
+
 	sess, err := startSession(ctx, engine, true)
 	if err != nil {
 		return err
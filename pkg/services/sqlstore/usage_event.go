@@ -0,0 +1,34 @@
+package sqlstore
+
+import (
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", SaveUsageEvents)
+	bus.AddHandler("sql", DeleteOldUsageEvents)
+}
+
+func SaveUsageEvents(cmd *models.SaveUsageEventsCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		for _, event := range cmd.Events {
+			if _, err := sess.Insert(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func DeleteOldUsageEvents(cmd *models.DeleteOldUsageEventsCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		result, err := sess.Exec("DELETE FROM usage_event WHERE created < ?", cmd.OlderThan)
+		if err != nil {
+			return err
+		}
+
+		cmd.DeletedRows, err = result.RowsAffected()
+		return err
+	})
+}
@@ -0,0 +1,37 @@
+package sqlstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageEventDataAccess(t *testing.T) {
+	InitTestDB(t)
+
+	now := time.Now()
+	err := SaveUsageEvents(&models.SaveUsageEventsCommand{Events: []*models.UsageEvent{
+		{EventType: models.UsageEventLogin, Created: now.Add(-48 * time.Hour), OrgId: 1, UserId: 1, UserLogin: "admin"},
+		{EventType: models.UsageEventDashboardView, Created: now, OrgId: 1, UserId: 1, UserLogin: "admin", DashboardId: 1, DashboardUid: "abc"},
+	}})
+	require.NoError(t, err)
+
+	count, err := x.Count(&models.UsageEvent{})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	t.Run("Should delete the events older than the retention", func(t *testing.T) {
+		cmd := models.DeleteOldUsageEventsCommand{OlderThan: now.Add(-24 * time.Hour)}
+		err := DeleteOldUsageEvents(&cmd)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), cmd.DeletedRows)
+
+		var events []*models.UsageEvent
+		err = x.Find(&events)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, models.UsageEventDashboardView, events[0].EventType)
+	})
+}
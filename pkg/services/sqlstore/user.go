@@ -576,6 +576,9 @@ func deleteUserInTransaction(sess *DBSession, cmd *models.DeleteUserCommand) err
 		"DELETE FROM user_auth WHERE user_id = ?",
 		"DELETE FROM user_auth_token WHERE user_id = ?",
 		"DELETE FROM quota WHERE user_id = ?",
+		"DELETE FROM query_history_star WHERE user_id = ?",
+		"DELETE FROM query_history_share WHERE query_uid IN (SELECT uid FROM query_history WHERE created_by = ?)",
+		"DELETE FROM query_history WHERE created_by = ?",
 	}
 
 	for _, sql := range deletes {
@@ -0,0 +1,139 @@
+package templating
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// variableRegex matches $var, [[var]], [[var:format]], ${var} and ${var:format}
+var variableRegex = regexp.MustCompile(`\$(\w+)|\[\[([\s\S]+?)(?::(\w+))?\]\]|\$\{(\w+)(?::(\w+))?\}`)
+
+// Formats of multi value variables, see https://grafana.com/docs/grafana/latest/variables/advanced-variable-format-options/
+const (
+	FormatRaw           = "raw"
+	FormatGlob          = "glob"
+	FormatRegex         = "regex"
+	FormatPipe          = "pipe"
+	FormatCsv           = "csv"
+	FormatJson          = "json"
+	FormatLucene        = "lucene"
+	FormatPercentEncode = "percentencode"
+	FormatSingleQuote   = "singlequote"
+	FormatDoubleQuote   = "doublequote"
+	FormatSqlString     = "sqlstring"
+)
+
+// Interpolate replaces the variables in text with their values. Variables without an
+// explicit format use the default format of the data source type.
+func Interpolate(text string, variables map[string]*Variable, dsType string) string {
+	return variableRegex.ReplaceAllStringFunc(text, func(match string) string {
+		groups := variableRegex.FindStringSubmatch(match)
+
+		name := groups[1] + groups[2] + groups[4]
+		format := groups[3] + groups[5]
+
+		variable, ok := variables[name]
+		if !ok || variable.Type == TypeAdhoc {
+			return match
+		}
+
+		// custom all values are not formatted
+		if variable.isAll && variable.allValue != "" {
+			return variable.allValue
+		}
+
+		if format == "" {
+			format = defaultFormat(dsType, variable)
+		}
+
+		return formatValue(variable.Value, format)
+	})
+}
+
+// defaultFormat mirrors the interpolation of variables in the query editors of the data sources
+func defaultFormat(dsType string, variable *Variable) string {
+	multi := variable.Multi || variable.IncludeAll
+
+	switch dsType {
+	case models.DS_MYSQL, models.DS_POSTGRES, models.DS_MSSQL:
+		if multi {
+			return FormatSqlString
+		}
+		return FormatRaw
+	case models.DS_PROMETHEUS, models.DS_LOKI:
+		if multi {
+			return FormatRegex
+		}
+		return FormatRaw
+	}
+
+	return FormatGlob
+}
+
+func formatValue(values []string, format string) string {
+	if len(values) == 1 {
+		value := values[0]
+
+		switch format {
+		case FormatRegex:
+			return regexp.QuoteMeta(value)
+		case FormatLucene:
+			return `"` + luceneEscape(value) + `"`
+		case FormatJson:
+			b, _ := json.Marshal(value)
+			return string(b)
+		case FormatPercentEncode:
+			return url.QueryEscape(value)
+		case FormatSingleQuote:
+			return "'" + value + "'"
+		case FormatDoubleQuote:
+			return `"` + value + `"`
+		case FormatSqlString:
+			return "'" + strings.Replace(value, "'", "''", -1) + "'"
+		}
+
+		return value
+	}
+
+	quoted := func(quote func(string) string) []string {
+		result := make([]string, len(values))
+		for i, v := range values {
+			result[i] = quote(v)
+		}
+		return result
+	}
+
+	switch format {
+	case FormatRaw, FormatCsv:
+		return strings.Join(values, ",")
+	case FormatPipe:
+		return strings.Join(values, "|")
+	case FormatRegex:
+		return "(" + strings.Join(quoted(regexp.QuoteMeta), "|") + ")"
+	case FormatLucene:
+		return "(" + strings.Join(quoted(func(v string) string { return `"` + luceneEscape(v) + `"` }), " OR ") + ")"
+	case FormatJson:
+		b, _ := json.Marshal(values)
+		return string(b)
+	case FormatPercentEncode:
+		return url.QueryEscape("{" + strings.Join(values, ",") + "}")
+	case FormatSingleQuote:
+		return strings.Join(quoted(func(v string) string { return "'" + v + "'" }), ",")
+	case FormatDoubleQuote:
+		return strings.Join(quoted(func(v string) string { return `"` + v + `"` }), ",")
+	case FormatSqlString:
+		return strings.Join(quoted(func(v string) string { return "'" + strings.Replace(v, "'", "''", -1) + "'" }), ",")
+	}
+
+	return "{" + strings.Join(values, ",") + "}"
+}
+
+var luceneSpecialChars = regexp.MustCompile(`([\!\*\+\-\=<>\s\&\|\(\)\[\]\{\}\^\~\?\:\\/"])`)
+
+func luceneEscape(value string) string {
+	return luceneSpecialChars.ReplaceAllString(value, `\$1`)
+}
@@ -0,0 +1,605 @@
+// Package templating resolves the template variables of dashboards server-side and
+// interpolates them into the queries of the panels.
+package templating
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// Variable types
+const (
+	TypeQuery      = "query"
+	TypeCustom     = "custom"
+	TypeConstant   = "constant"
+	TypeTextbox    = "textbox"
+	TypeInterval   = "interval"
+	TypeDatasource = "datasource"
+	TypeAdhoc      = "adhoc"
+)
+
+const allValue = "$__all"
+
+type Option struct {
+	Text  string `json:"text"`
+	Value string `json:"value"`
+}
+
+// Variable is a resolved template variable
+type Variable struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Multi      bool     `json:"multi"`
+	IncludeAll bool     `json:"includeAll"`
+	Text       []string `json:"text"`
+	Value      []string `json:"value"`
+	Options    []Option `json:"options"`
+
+	isAll    bool
+	allValue string
+}
+
+// Panel is a panel with its title, data source and queries interpolated
+type Panel struct {
+	Id         int64              `json:"id"`
+	Title      string             `json:"title"`
+	Datasource string             `json:"datasource"`
+	Targets    []*simplejson.Json `json:"targets"`
+}
+
+type Result struct {
+	Variables []*Variable `json:"variables"`
+	Panels    []*Panel    `json:"panels"`
+	Warnings  []string    `json:"warnings"`
+}
+
+type ResolveOptions struct {
+	Dashboard *models.Dashboard
+	User      *models.SignedInUser
+	TimeRange *tsdb.TimeRange
+	// Values override the current values of the variables saved in the dashboard
+	Values          map[string][]string
+	DatasourceCache datasources.CacheService
+}
+
+// sqlDatasources are the data sources that can run query variables server-side
+var sqlDatasources = map[string]bool{
+	models.DS_MYSQL:    true,
+	models.DS_POSTGRES: true,
+	models.DS_MSSQL:    true,
+}
+
+type resolver struct {
+	ctx         context.Context
+	opts        ResolveOptions
+	variables   map[string]*Variable
+	datasources map[string]*models.DataSource
+	result      *Result
+}
+
+// Resolve resolves the template variables of the dashboard in order, so variables can depend
+// on the variables before them, and interpolates the queries of the panels. Query variables of
+// data sources that cannot be queried server-side keep the value saved in the dashboard.
+func Resolve(ctx context.Context, opts ResolveOptions) (*Result, error) {
+	r := &resolver{
+		ctx:         ctx,
+		opts:        opts,
+		variables:   make(map[string]*Variable),
+		datasources: make(map[string]*models.DataSource),
+		result: &Result{
+			Variables: make([]*Variable, 0),
+			Panels:    make([]*Panel, 0),
+			Warnings:  make([]string, 0),
+		},
+	}
+
+	r.addBuiltinVariables()
+
+	for _, item := range opts.Dashboard.Data.Get("templating").Get("list").MustArray() {
+		variable, err := r.resolveVariable(simplejson.NewFromAny(item))
+		if err != nil {
+			return nil, err
+		}
+
+		r.variables[variable.Name] = variable
+		r.result.Variables = append(r.result.Variables, variable)
+	}
+
+	r.interpolatePanels(opts.Dashboard.Data.Get("panels").MustArray())
+
+	return r.result, nil
+}
+
+func (r *resolver) warn(format string, args ...interface{}) {
+	r.result.Warnings = append(r.result.Warnings, fmt.Sprintf(format, args...))
+}
+
+func (r *resolver) addBuiltinVariables() {
+	builtin := map[string]string{
+		"__from":      strconv.FormatInt(r.opts.TimeRange.GetFromAsMsEpoch(), 10),
+		"__to":        strconv.FormatInt(r.opts.TimeRange.GetToAsMsEpoch(), 10),
+		"__dashboard": r.opts.Dashboard.Title,
+		"__org":       strconv.FormatInt(r.opts.Dashboard.OrgId, 10),
+	}
+
+	for name, value := range builtin {
+		r.variables[name] = &Variable{Name: name, Type: TypeConstant, Text: []string{value}, Value: []string{value}}
+	}
+}
+
+func (r *resolver) resolveVariable(model *simplejson.Json) (*Variable, error) {
+	variable := &Variable{
+		Name:       model.Get("name").MustString(),
+		Type:       model.Get("type").MustString(),
+		Multi:      model.Get("multi").MustBool(),
+		IncludeAll: model.Get("includeAll").MustBool(),
+		Options:    make([]Option, 0),
+		allValue:   model.Get("allValue").MustString(),
+	}
+
+	query := model.Get("query").MustString()
+
+	switch variable.Type {
+	case TypeAdhoc:
+		return variable, nil
+	case TypeConstant:
+		variable.Options = []Option{{Text: query, Value: query}}
+		variable.Text = []string{query}
+		variable.Value = []string{query}
+		return variable, nil
+	case TypeTextbox:
+		value := query
+		if current := currentValues(model); len(current) > 0 {
+			value = current[0]
+		}
+		if override, ok := r.opts.Values[variable.Name]; ok && len(override) > 0 {
+			value = override[0]
+		}
+		variable.Text = []string{value}
+		variable.Value = []string{value}
+		return variable, nil
+	case TypeCustom, TypeInterval:
+		variable.Options = splitCustomQuery(query)
+	case TypeDatasource:
+		options, err := r.datasourceOptions(query, model.Get("regex").MustString())
+		if err != nil {
+			return nil, err
+		}
+		variable.Options = options
+	case TypeQuery:
+		options, ok, err := r.queryOptions(variable, model)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			variable.Options = savedOptions(model)
+		} else {
+			variable.Options = options
+		}
+	default:
+		r.warn("Variable %s has unsupported type %s", variable.Name, variable.Type)
+	}
+
+	r.selectValue(variable, model)
+	return variable, nil
+}
+
+// selectValue selects the value of the variable in the request, or the current value saved in
+// the dashboard, falling back to the first option when the value is not an option anymore
+func (r *resolver) selectValue(variable *Variable, model *simplejson.Json) {
+	values := currentValues(model)
+	if override, ok := r.opts.Values[variable.Name]; ok {
+		values = override
+	}
+
+	if variable.IncludeAll && len(values) == 1 && values[0] == allValue {
+		selectAll(variable)
+		return
+	}
+
+	if len(variable.Options) == 0 {
+		variable.Text = values
+		variable.Value = values
+		return
+	}
+
+	for _, value := range values {
+		for _, option := range variable.Options {
+			if option.Value == value {
+				variable.Text = append(variable.Text, option.Text)
+				variable.Value = append(variable.Value, option.Value)
+				break
+			}
+		}
+
+		if len(variable.Value) > 0 && !variable.Multi {
+			break
+		}
+	}
+
+	if len(variable.Value) > 0 {
+		return
+	}
+
+	if variable.IncludeAll {
+		selectAll(variable)
+		return
+	}
+
+	variable.Text = []string{variable.Options[0].Text}
+	variable.Value = []string{variable.Options[0].Value}
+}
+
+func selectAll(variable *Variable) {
+	variable.isAll = true
+	variable.Text = []string{"All"}
+	variable.Value = make([]string, 0, len(variable.Options))
+
+	if variable.allValue != "" {
+		variable.Value = append(variable.Value, variable.allValue)
+		return
+	}
+
+	for _, option := range variable.Options {
+		variable.Value = append(variable.Value, option.Value)
+	}
+}
+
+func (r *resolver) queryOptions(variable *Variable, model *simplejson.Json) ([]Option, bool, error) {
+	ds, err := r.getDatasource(model.Get("datasource").MustString())
+	if err != nil {
+		if err == models.ErrDataSourceNotFound || err == models.ErrDataSourceAccessDenied {
+			r.warn("Data source of variable %s cannot be used: %s, using the saved value", variable.Name, err)
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	if !sqlDatasources[ds.Type] {
+		r.warn("Variable %s queries %s data source %s which cannot be queried server-side, using the saved value", variable.Name, ds.Type, ds.Name)
+		return nil, false, nil
+	}
+
+	query := model.Get("query").MustString()
+	if query == "" {
+		query = model.GetPath("query", "query").MustString()
+	}
+	query = Interpolate(query, r.variables, ds.Type)
+
+	tsdbQuery := &tsdb.TsdbQuery{
+		TimeRange: r.opts.TimeRange,
+		User:      r.opts.User,
+		Queries: []*tsdb.Query{
+			{
+				RefId:         "tempvar",
+				MaxDataPoints: 100,
+				IntervalMs:    1000,
+				DataSource:    ds,
+				Model: simplejson.NewFromAny(map[string]interface{}{
+					"refId":  "tempvar",
+					"rawSql": query,
+					"format": "table",
+				}),
+			},
+		},
+	}
+
+	resp, err := tsdb.HandleRequest(r.ctx, ds, tsdbQuery)
+	if err != nil {
+		return nil, false, err
+	}
+
+	result, ok := resp.Results["tempvar"]
+	if !ok {
+		return []Option{}, true, nil
+	}
+	if result.Error != nil {
+		r.warn("Query of variable %s failed: %s", variable.Name, result.Error)
+		return nil, false, nil
+	}
+
+	options := optionsFromTables(result.Tables)
+	options, err = filterOptions(options, model.Get("regex").MustString())
+	if err != nil {
+		r.warn("Regex of variable %s is invalid: %s", variable.Name, err)
+	}
+	sortOptions(options, model.Get("sort").MustInt())
+
+	return options, true, nil
+}
+
+func (r *resolver) datasourceOptions(pluginId string, regex string) ([]Option, error) {
+	query := models.GetDataSourcesQuery{OrgId: r.opts.Dashboard.OrgId, User: r.opts.User}
+	if err := bus.Dispatch(&query); err != nil {
+		return nil, err
+	}
+
+	options := make([]Option, 0)
+	for _, ds := range query.Result {
+		if ds.Type == pluginId {
+			options = append(options, Option{Text: ds.Name, Value: ds.Name})
+		}
+	}
+
+	options, err := filterOptions(options, regex)
+	if err != nil {
+		r.warn("Regex %s is invalid: %s", regex, err)
+	}
+
+	return options, nil
+}
+
+// getDatasource returns the data source with the name or the default data source
+// for an empty name, checking that the user can query it
+func (r *resolver) getDatasource(name string) (*models.DataSource, error) {
+	if ds, ok := r.datasources[name]; ok {
+		return ds, nil
+	}
+
+	var id int64
+	if name == "" || name == "default" {
+		query := models.GetDataSourcesQuery{OrgId: r.opts.Dashboard.OrgId, User: r.opts.User}
+		if err := bus.Dispatch(&query); err != nil {
+			return nil, err
+		}
+		for _, ds := range query.Result {
+			if ds.IsDefault {
+				id = ds.Id
+			}
+		}
+		if id == 0 {
+			return nil, models.ErrDataSourceNotFound
+		}
+	} else {
+		query := models.GetDataSourceByNameQuery{Name: name, OrgId: r.opts.Dashboard.OrgId}
+		if err := bus.Dispatch(&query); err != nil {
+			return nil, err
+		}
+		id = query.Result.Id
+	}
+
+	ds, err := r.opts.DatasourceCache.GetDatasource(id, r.opts.User, false)
+	if err != nil {
+		return nil, err
+	}
+
+	r.datasources[name] = ds
+	return ds, nil
+}
+
+func (r *resolver) interpolatePanels(panels []interface{}) {
+	for _, item := range panels {
+		panel := simplejson.NewFromAny(item)
+
+		if panel.Get("type").MustString() == "row" {
+			r.interpolatePanels(panel.Get("panels").MustArray())
+			continue
+		}
+
+		targets := panel.Get("targets").MustArray()
+		if len(targets) == 0 {
+			continue
+		}
+
+		datasource := Interpolate(panel.Get("datasource").MustString(), r.variables, "")
+		result := &Panel{
+			Id:         panel.Get("id").MustInt64(),
+			Title:      Interpolate(panel.Get("title").MustString(), r.variables, ""),
+			Datasource: datasource,
+			Targets:    make([]*simplejson.Json, 0, len(targets)),
+		}
+
+		for _, target := range targets {
+			targetDatasource := datasource
+			if name, ok := target.(map[string]interface{})["datasource"].(string); ok {
+				targetDatasource = Interpolate(name, r.variables, "")
+			}
+
+			// built-in data sources like -- Grafana -- have no type specific formats
+			dsType := ""
+			if !strings.HasPrefix(targetDatasource, "-- ") {
+				if ds, err := r.getDatasource(targetDatasource); err == nil {
+					dsType = ds.Type
+				} else {
+					r.warn("Data source %s of panel %d cannot be used: %s", targetDatasource, result.Id, err)
+				}
+			}
+
+			result.Targets = append(result.Targets, simplejson.NewFromAny(interpolateValue(target, r.variables, dsType)))
+		}
+
+		r.result.Panels = append(r.result.Panels, result)
+	}
+}
+
+// interpolateValue interpolates all the strings of a target
+func interpolateValue(value interface{}, variables map[string]*Variable, dsType string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return Interpolate(v, variables, dsType)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = interpolateValue(item, variables, dsType)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = interpolateValue(item, variables, dsType)
+		}
+		return result
+	}
+
+	return value
+}
+
+// currentValues returns the current value saved in the dashboard, which is a string or
+// an array of strings for multi value variables
+func currentValues(model *simplejson.Json) []string {
+	current := model.GetPath("current", "value")
+
+	if values, err := current.StringArray(); err == nil {
+		return values
+	}
+
+	if value, err := current.String(); err == nil {
+		return []string{value}
+	}
+
+	return []string{}
+}
+
+func savedOptions(model *simplejson.Json) []Option {
+	options := make([]Option, 0)
+	for _, item := range model.Get("options").MustArray() {
+		option := simplejson.NewFromAny(item)
+		value, err := option.Get("value").String()
+		if err != nil || value == allValue {
+			continue
+		}
+		options = append(options, Option{Text: option.Get("text").MustString(value), Value: value})
+	}
+	return options
+}
+
+var customQuerySplit = regexp.MustCompile(`(?:\\,|[^,])+`)
+
+// splitCustomQuery splits the comma separated values of custom and interval variables,
+// commas can be escaped with a backslash
+func splitCustomQuery(query string) []Option {
+	options := make([]Option, 0)
+	for _, match := range customQuerySplit.FindAllString(query, -1) {
+		value := strings.TrimSpace(strings.Replace(match, `\,`, ",", -1))
+		if value != "" {
+			options = append(options, Option{Text: value, Value: value})
+		}
+	}
+	return options
+}
+
+// optionsFromTables returns the options of a query variable. Tables with __text and __value
+// columns have an option per row, otherwise every value in the table is an option.
+func optionsFromTables(tables []*tsdb.Table) []Option {
+	options := make([]Option, 0)
+	seen := make(map[string]bool)
+
+	add := func(text string, value string) {
+		if seen[value] {
+			return
+		}
+		seen[value] = true
+		options = append(options, Option{Text: text, Value: value})
+	}
+
+	for _, table := range tables {
+		textIndex, valueIndex := -1, -1
+		for i, column := range table.Columns {
+			switch column.Text {
+			case "__text":
+				textIndex = i
+			case "__value":
+				valueIndex = i
+			}
+		}
+
+		for _, row := range table.Rows {
+			if textIndex >= 0 && valueIndex >= 0 {
+				add(fmt.Sprint(row[textIndex]), fmt.Sprint(row[valueIndex]))
+				continue
+			}
+
+			for _, cell := range row {
+				value := fmt.Sprint(cell)
+				add(value, value)
+			}
+		}
+	}
+
+	return options
+}
+
+// filterOptions applies the regex of a variable in the /pattern/flags form. When the regex has
+// a capture group the captured text is used as option.
+func filterOptions(options []Option, regex string) ([]Option, error) {
+	if regex == "" {
+		return options, nil
+	}
+
+	pattern := regex
+	if strings.HasPrefix(regex, "/") && strings.LastIndex(regex, "/") > 0 {
+		end := strings.LastIndex(regex, "/")
+		pattern = regex[1:end]
+		if strings.Contains(regex[end+1:], "i") {
+			pattern = "(?i)" + pattern
+		}
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return options, err
+	}
+
+	result := make([]Option, 0)
+	for _, option := range options {
+		matches := re.FindStringSubmatch(option.Text)
+		if matches == nil {
+			continue
+		}
+
+		if len(matches) > 1 {
+			result = append(result, Option{Text: matches[1], Value: matches[1]})
+			continue
+		}
+
+		result = append(result, option)
+	}
+
+	return result, nil
+}
+
+var numberRegex = regexp.MustCompile(`\d+`)
+
+// sortOptions sorts the options like the variable editor: 1 and 2 sort alphabetically, 3 and 4
+// numerically and 5 and 6 alphabetically case-insensitive, ascending and descending respectively
+func sortOptions(options []Option, order int) {
+	if order <= 0 || order > 6 {
+		return
+	}
+
+	number := func(text string) int {
+		n, err := strconv.Atoi(numberRegex.FindString(text))
+		if err != nil {
+			return -1
+		}
+		return n
+	}
+
+	sort.SliceStable(options, func(i, j int) bool {
+		a, b := options[i].Text, options[j].Text
+		switch order {
+		case 1:
+			return a < b
+		case 2:
+			return a > b
+		case 3:
+			return number(a) < number(b)
+		case 4:
+			return number(a) > number(b)
+		case 5:
+			return strings.ToLower(a) < strings.ToLower(b)
+		default:
+			return strings.ToLower(a) > strings.ToLower(b)
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package templating
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+func TestInterpolate(t *testing.T) {
+	variables := map[string]*Variable{
+		"host":   {Name: "host", Type: TypeCustom, Multi: true, Value: []string{"web-1", "web'2"}},
+		"single": {Name: "single", Type: TypeCustom, Value: []string{"a.b"}},
+		"all":    {Name: "all", Type: TypeQuery, IncludeAll: true, Value: []string{"*"}, isAll: true, allValue: "*"},
+		"filter": {Name: "filter", Type: TypeAdhoc},
+	}
+
+	t.Run("Uses the explicit format", func(t *testing.T) {
+		tests := map[string]string{
+			"${host:csv}":         "web-1,web'2",
+			"${host:pipe}":        "web-1|web'2",
+			"${host:regex}":       "(web-1|web'2)",
+			"${host:json}":        `["web-1","web'2"]`,
+			"${host:glob}":        "{web-1,web'2}",
+			"${host:singlequote}": "'web-1','web'2'",
+			"${host:doublequote}": `"web-1","web'2"`,
+			"${host:sqlstring}":   "'web-1','web''2'",
+			"${host:lucene}":      `("web\-1" OR "web'2")`,
+			"[[single:regex]]":    `a\.b`,
+			"${single:json}":      `"a.b"`,
+		}
+
+		for text, expected := range tests {
+			require.Equal(t, expected, Interpolate(text, variables, ""), text)
+		}
+	})
+
+	t.Run("Uses the default format of the data source", func(t *testing.T) {
+		require.Equal(t, "'web-1','web''2'", Interpolate("$host", variables, models.DS_MYSQL))
+		require.Equal(t, "(web-1|web'2)", Interpolate("$host", variables, models.DS_PROMETHEUS))
+		require.Equal(t, "{web-1,web'2}", Interpolate("$host", variables, models.DS_GRAPHITE))
+		require.Equal(t, "a.b", Interpolate("$single", variables, models.DS_PROMETHEUS))
+	})
+
+	t.Run("Keeps unknown and adhoc variables and custom all values", func(t *testing.T) {
+		require.Equal(t, "$unknown $filter", Interpolate("$unknown $filter", variables, ""))
+		require.Equal(t, "host=*", Interpolate("host=${all:regex}", variables, models.DS_PROMETHEUS))
+	})
+}
+
+func TestOptions(t *testing.T) {
+	t.Run("Splits custom queries on unescaped commas", func(t *testing.T) {
+		options := splitCustomQuery(`a, b\,c ,,d`)
+		require.Equal(t, []Option{{Text: "a", Value: "a"}, {Text: "b,c", Value: "b,c"}, {Text: "d", Value: "d"}}, options)
+	})
+
+	t.Run("Filters options with the regex of the variable", func(t *testing.T) {
+		options := []Option{{Text: "Server-1", Value: "Server-1"}, {Text: "server-2", Value: "server-2"}, {Text: "db-1", Value: "db-1"}}
+
+		filtered, err := filterOptions(options, "/server-(\\d)/i")
+		require.NoError(t, err)
+		require.Equal(t, []Option{{Text: "1", Value: "1"}, {Text: "2", Value: "2"}}, filtered)
+
+		_, err = filterOptions(options, "/server-(/")
+		require.Error(t, err)
+	})
+
+	t.Run("Sorts options", func(t *testing.T) {
+		options := []Option{{Text: "b10"}, {Text: "B2"}, {Text: "a1"}}
+
+		sortOptions(options, 3)
+		require.Equal(t, []Option{{Text: "a1"}, {Text: "B2"}, {Text: "b10"}}, options)
+
+		sortOptions(options, 1)
+		require.Equal(t, []Option{{Text: "B2"}, {Text: "a1"}, {Text: "b10"}}, options)
+
+		sortOptions(options, 6)
+		require.Equal(t, []Option{{Text: "B2"}, {Text: "b10"}, {Text: "a1"}}, options)
+	})
+
+	t.Run("Uses __text and __value columns", func(t *testing.T) {
+		tables := []*tsdb.Table{{
+			Columns: []tsdb.TableColumn{{Text: "__value"}, {Text: "__text"}},
+			Rows:    []tsdb.RowValues{{int64(1), "one"}, {int64(2), "two"}, {int64(1), "one"}},
+		}}
+		require.Equal(t, []Option{{Text: "one", Value: "1"}, {Text: "two", Value: "2"}}, optionsFromTables(tables))
+	})
+}
+
+func TestResolve(t *testing.T) {
+	resolve := func(t *testing.T, values map[string][]string) *Result {
+		dash := models.NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+			"title": "Servers",
+			"templating": map[string]interface{}{
+				"list": []interface{}{
+					map[string]interface{}{"name": "env", "type": "constant", "query": "prod"},
+					map[string]interface{}{
+						"name": "host", "type": "custom", "query": "web-1,web-2,web-3", "multi": true, "includeAll": true,
+						"current": map[string]interface{}{"value": []interface{}{"web-2"}},
+					},
+					map[string]interface{}{"name": "search", "type": "textbox", "query": "error"},
+				},
+			},
+			"panels": []interface{}{
+				map[string]interface{}{
+					"id":         1,
+					"title":      "$env requests",
+					"datasource": "-- Grafana --",
+					"targets": []interface{}{
+						map[string]interface{}{"refId": "A", "query": "${host:pipe} $search"},
+					},
+				},
+			},
+		}))
+		dash.OrgId = 1
+
+		result, err := Resolve(context.Background(), ResolveOptions{
+			Dashboard: dash,
+			User:      &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_VIEWER},
+			TimeRange: tsdb.NewTimeRange("now-1h", "now"),
+			Values:    values,
+		})
+		require.NoError(t, err)
+		require.Empty(t, result.Warnings)
+		require.Len(t, result.Variables, 3)
+		require.Len(t, result.Panels, 1)
+		return result
+	}
+
+	t.Run("Uses the current values saved in the dashboard", func(t *testing.T) {
+		result := resolve(t, nil)
+		require.Equal(t, []string{"web-2"}, result.Variables[1].Value)
+		require.Equal(t, "prod requests", result.Panels[0].Title)
+		require.Equal(t, "web-2 error", result.Panels[0].Targets[0].Get("query").MustString())
+	})
+
+	t.Run("Uses the values of the request", func(t *testing.T) {
+		result := resolve(t, map[string][]string{"host": {"web-1", "web-3", "web-9"}, "search": {"timeout"}})
+		require.Equal(t, []string{"web-1", "web-3"}, result.Variables[1].Value)
+		require.Equal(t, "web-1|web-3 timeout", result.Panels[0].Targets[0].Get("query").MustString())
+	})
+
+	t.Run("Selects all options", func(t *testing.T) {
+		result := resolve(t, map[string][]string{"host": {"$__all"}})
+		require.Equal(t, []string{"All"}, result.Variables[1].Text)
+		require.Equal(t, "web-1|web-2|web-3 error", result.Panels[0].Targets[0].Get("query").MustString())
+	})
+}
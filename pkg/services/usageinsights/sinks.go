@@ -0,0 +1,192 @@
+package usageinsights
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// Names of the sinks in the settings
+const (
+	sqlSinkName   = "sql"
+	lokiSinkName  = "loki"
+	httpSinkName  = "http"
+	kafkaSinkName = "kafka"
+)
+
+var exportClient = &http.Client{Timeout: 30 * time.Second}
+
+// sqlSink saves the events to the usage_event table of the Grafana database.
+type sqlSink struct{}
+
+func (s *sqlSink) Name() string {
+	return sqlSinkName
+}
+
+func (s *sqlSink) Write(ctx context.Context, events []*models.UsageEvent) error {
+	return bus.Dispatch(&models.SaveUsageEventsCommand{Events: events})
+}
+
+// lokiSink pushes the events to Loki as JSON log lines, in a stream per event type and org.
+type lokiSink struct {
+	url      string
+	username string
+	password string
+}
+
+func newLokiSink(cfg setting.UsageInsightsSettings) *lokiSink {
+	return &lokiSink{url: cfg.LokiURL, username: cfg.LokiUsername, password: cfg.LokiPassword}
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type lokiPushRequest struct {
+	Streams []*lokiStream `json:"streams"`
+}
+
+func (s *lokiSink) Name() string {
+	return lokiSinkName
+}
+
+func (s *lokiSink) Write(ctx context.Context, events []*models.UsageEvent) error {
+	streams := map[string]*lokiStream{}
+	req := lokiPushRequest{}
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		key := fmt.Sprintf("%s/%d", event.EventType, event.OrgId)
+		stream, ok := streams[key]
+		if !ok {
+			stream = &lokiStream{Stream: map[string]string{
+				"source":     "grafana",
+				"event_type": event.EventType,
+				"org_id":     strconv.FormatInt(event.OrgId, 10),
+			}}
+			streams[key] = stream
+			req.Streams = append(req.Streams, stream)
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(event.Created.UnixNano(), 10), string(line)})
+	}
+
+	u, err := url.Parse(s.url)
+	if err != nil {
+		return err
+	}
+	u.Path = path.Join(u.Path, "/loki/api/v1/push")
+
+	return postJSON(ctx, u.String(), "application/json", req, func(httpReq *http.Request) {
+		if s.username != "" {
+			httpReq.SetBasicAuth(s.username, s.password)
+		}
+	})
+}
+
+// httpSink posts the events as a JSON array to a url.
+type httpSink struct {
+	url           string
+	authorization string
+}
+
+func newHTTPSink(cfg setting.UsageInsightsSettings) *httpSink {
+	return &httpSink{url: cfg.HTTPURL, authorization: cfg.HTTPAuthorization}
+}
+
+func (s *httpSink) Name() string {
+	return httpSinkName
+}
+
+func (s *httpSink) Write(ctx context.Context, events []*models.UsageEvent) error {
+	return postJSON(ctx, s.url, "application/json", events, func(req *http.Request) {
+		if s.authorization != "" {
+			req.Header.Set("Authorization", s.authorization)
+		}
+	})
+}
+
+// kafkaSink produces the events to a Kafka topic through a Kafka REST proxy, keyed by org.
+type kafkaSink struct {
+	url   string
+	topic string
+}
+
+func newKafkaSink(cfg setting.UsageInsightsSettings) *kafkaSink {
+	return &kafkaSink{url: cfg.KafkaURL, topic: cfg.KafkaTopic}
+}
+
+type kafkaRecord struct {
+	Key   string             `json:"key"`
+	Value *models.UsageEvent `json:"value"`
+}
+
+type kafkaProduceRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+func (s *kafkaSink) Name() string {
+	return kafkaSinkName
+}
+
+func (s *kafkaSink) Write(ctx context.Context, events []*models.UsageEvent) error {
+	req := kafkaProduceRequest{Records: make([]kafkaRecord, 0, len(events))}
+	for _, event := range events {
+		req.Records = append(req.Records, kafkaRecord{Key: strconv.FormatInt(event.OrgId, 10), Value: event})
+	}
+
+	u, err := url.Parse(s.url)
+	if err != nil {
+		return err
+	}
+	u.Path = path.Join(u.Path, "topics", s.topic)
+
+	return postJSON(ctx, u.String(), "application/vnd.kafka.json.v2+json", req, nil)
+}
+
+func postJSON(ctx context.Context, url string, contentType string, body interface{}, prepare func(req *http.Request)) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("User-Agent", "Grafana")
+	req.Header.Set("Content-Type", contentType)
+	if prepare != nil {
+		prepare(req)
+	}
+
+	res, err := exportClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(res.Body)
+		if len(msg) > 200 {
+			msg = msg[:200]
+		}
+		return fmt.Errorf("POST %s returned %s: %s", url, res.Status, msg)
+	}
+	return nil
+}
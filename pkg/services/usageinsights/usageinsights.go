@@ -0,0 +1,156 @@
+package usageinsights
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func init() {
+	registry.RegisterService(&UsageInsightsService{})
+}
+
+// flushTimeout bounds the export of the events still buffered on shutdown
+const flushTimeout = 10 * time.Second
+
+// Sink is where the usage events are exported to.
+type Sink interface {
+	// Name is the name of the sink in the settings and the metrics
+	Name() string
+	// Write exports a batch of events
+	Write(ctx context.Context, events []*models.UsageEvent) error
+}
+
+// UsageInsightsService records the usage events: dashboard views, data requests and logins.
+// The events are buffered in memory and exported in batches to the configured sinks, so that
+// recording an event never waits for a sink. Events are dropped when the buffer is full.
+type UsageInsightsService struct {
+	Cfg *setting.Cfg `inject:""`
+
+	log    log.Logger
+	sinks  []Sink
+	events chan *models.UsageEvent
+}
+
+func (s *UsageInsightsService) Init() error {
+	s.log = log.New("usageinsights")
+
+	if !s.Cfg.UsageInsights.Enabled {
+		return nil
+	}
+
+	for _, name := range s.Cfg.UsageInsights.Sinks {
+		sink, err := newSink(name, s.Cfg.UsageInsights)
+		if err != nil {
+			return err
+		}
+		s.sinks = append(s.sinks, sink)
+	}
+
+	s.events = make(chan *models.UsageEvent, s.Cfg.UsageInsights.BufferSize)
+	return nil
+}
+
+func (s *UsageInsightsService) IsDisabled() bool {
+	return !s.Cfg.UsageInsights.Enabled
+}
+
+func newSink(name string, cfg setting.UsageInsightsSettings) (Sink, error) {
+	switch name {
+	case sqlSinkName:
+		return &sqlSink{}, nil
+	case lokiSinkName:
+		if cfg.LokiURL == "" {
+			return nil, fmt.Errorf("usage insights loki sink requires loki_url")
+		}
+		return newLokiSink(cfg), nil
+	case httpSinkName:
+		if cfg.HTTPURL == "" {
+			return nil, fmt.Errorf("usage insights http sink requires http_url")
+		}
+		return newHTTPSink(cfg), nil
+	case kafkaSinkName:
+		if cfg.KafkaURL == "" {
+			return nil, fmt.Errorf("usage insights kafka sink requires kafka_url")
+		}
+		return newKafkaSink(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown usage insights sink %q", name)
+	}
+}
+
+// Record queues an event for export. It never blocks, the event is dropped when the buffer is
+// full or when usage insights are disabled.
+func (s *UsageInsightsService) Record(event *models.UsageEvent) {
+	if s == nil || s.events == nil {
+		return
+	}
+
+	if event.Created.IsZero() {
+		event.Created = time.Now()
+	}
+
+	select {
+	case s.events <- event:
+	default:
+		metrics.MUsageEventsDropped.Inc()
+	}
+}
+
+func (s *UsageInsightsService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.Cfg.UsageInsights.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*models.UsageEvent, 0, s.Cfg.UsageInsights.BatchSize)
+	for {
+		select {
+		case event := <-s.events:
+			batch = append(batch, event)
+			if len(batch) >= s.Cfg.UsageInsights.BatchSize {
+				s.flush(ctx, batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				s.flush(ctx, batch)
+				batch = batch[:0]
+			}
+		case <-ctx.Done():
+			// export the buffered events before shutting down
+			flushCtx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+			defer cancel()
+		drain:
+			for {
+				select {
+				case event := <-s.events:
+					batch = append(batch, event)
+				default:
+					break drain
+				}
+			}
+			if len(batch) > 0 {
+				s.flush(flushCtx, batch)
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// flush exports a batch of events to every sink. A sink failing doesn't prevent the others from
+// receiving the events, and the events it failed to export aren't retried.
+func (s *UsageInsightsService) flush(ctx context.Context, events []*models.UsageEvent) {
+	for _, sink := range s.sinks {
+		status := "success"
+		if err := sink.Write(ctx, events); err != nil {
+			s.log.Error("Failed to export usage events", "sink", sink.Name(), "events", len(events), "error", err)
+			status = "failure"
+		}
+		metrics.MUsageEventsExported.WithLabelValues(sink.Name(), status).Add(float64(len(events)))
+	}
+}
@@ -0,0 +1,151 @@
+package usageinsights
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSink struct {
+	mu     sync.Mutex
+	events []*models.UsageEvent
+}
+
+func (s *fakeSink) Name() string {
+	return "fake"
+}
+
+func (s *fakeSink) Write(ctx context.Context, events []*models.UsageEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func (s *fakeSink) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.events)
+}
+
+func newTestService(bufferSize int, sink Sink) *UsageInsightsService {
+	cfg := setting.NewCfg()
+	cfg.UsageInsights = setting.UsageInsightsSettings{
+		Enabled:       true,
+		FlushInterval: time.Hour,
+		BatchSize:     2,
+		BufferSize:    bufferSize,
+	}
+	return &UsageInsightsService{
+		Cfg:    cfg,
+		log:    log.New("test"),
+		sinks:  []Sink{sink},
+		events: make(chan *models.UsageEvent, bufferSize),
+	}
+}
+
+func TestUsageInsightsService(t *testing.T) {
+	t.Run("Should export full batches and the buffered events on shutdown", func(t *testing.T) {
+		sink := &fakeSink{}
+		s := newTestService(10, sink)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			_ = s.Run(ctx)
+			close(done)
+		}()
+
+		s.Record(&models.UsageEvent{EventType: models.UsageEventLogin, UserId: 1})
+		s.Record(&models.UsageEvent{EventType: models.UsageEventLogin, UserId: 2})
+		require.Eventually(t, func() bool { return sink.count() == 2 }, time.Second, 10*time.Millisecond)
+
+		s.Record(&models.UsageEvent{EventType: models.UsageEventDashboardView, UserId: 3})
+		cancel()
+		<-done
+		require.Equal(t, 3, sink.count())
+		require.False(t, sink.events[2].Created.IsZero())
+	})
+
+	t.Run("Should drop the events when the buffer is full", func(t *testing.T) {
+		s := newTestService(1, &fakeSink{})
+		s.Record(&models.UsageEvent{EventType: models.UsageEventLogin})
+		s.Record(&models.UsageEvent{EventType: models.UsageEventLogin})
+		require.Len(t, s.events, 1)
+	})
+
+	t.Run("Should not record events when disabled", func(t *testing.T) {
+		s := &UsageInsightsService{}
+		s.Record(&models.UsageEvent{EventType: models.UsageEventLogin})
+	})
+
+	t.Run("Should fail on unknown or unconfigured sinks", func(t *testing.T) {
+		_, err := newSink("unknown", setting.UsageInsightsSettings{})
+		require.Error(t, err)
+		_, err = newSink(kafkaSinkName, setting.UsageInsightsSettings{})
+		require.Error(t, err)
+	})
+}
+
+func TestUsageInsightsSinks(t *testing.T) {
+	events := []*models.UsageEvent{
+		{EventType: models.UsageEventDataRequest, Created: time.Unix(10, 0), OrgId: 1, DatasourceId: 2},
+		{EventType: models.UsageEventDataRequest, Created: time.Unix(20, 0), OrgId: 2, DatasourceId: 3},
+	}
+
+	var path, contentType, auth string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, contentType, auth = r.URL.Path, r.Header.Get("Content-Type"), r.Header.Get("Authorization")
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	t.Run("Should push a stream per event type and org to Loki", func(t *testing.T) {
+		sink := newLokiSink(setting.UsageInsightsSettings{LokiURL: server.URL, LokiUsername: "user", LokiPassword: "pass"})
+		require.NoError(t, sink.Write(context.Background(), events))
+		require.Equal(t, "/loki/api/v1/push", path)
+		require.NotEmpty(t, auth)
+
+		var req lokiPushRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+		require.Len(t, req.Streams, 2)
+		require.Equal(t, "1", req.Streams[0].Stream["org_id"])
+		require.Equal(t, "10000000000", req.Streams[0].Values[0][0])
+	})
+
+	t.Run("Should post the events as a JSON array", func(t *testing.T) {
+		sink := newHTTPSink(setting.UsageInsightsSettings{HTTPURL: server.URL + "/events", HTTPAuthorization: "Bearer token"})
+		require.NoError(t, sink.Write(context.Background(), events))
+		require.Equal(t, "/events", path)
+		require.Equal(t, "Bearer token", auth)
+
+		var posted []*models.UsageEvent
+		require.NoError(t, json.Unmarshal(body, &posted))
+		require.Len(t, posted, 2)
+		require.Equal(t, int64(3), posted[1].DatasourceId)
+	})
+
+	t.Run("Should produce the events to a Kafka topic", func(t *testing.T) {
+		sink := newKafkaSink(setting.UsageInsightsSettings{KafkaURL: server.URL, KafkaTopic: "usage"})
+		require.NoError(t, sink.Write(context.Background(), events))
+		require.Equal(t, "/topics/usage", path)
+		require.Equal(t, "application/vnd.kafka.json.v2+json", contentType)
+
+		var req kafkaProduceRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+		require.Len(t, req.Records, 2)
+		require.Equal(t, "2", req.Records[1].Key)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -19,6 +20,7 @@ import (
 	"github.com/go-macaron/session"
 	ini "gopkg.in/ini.v1"
 
+	"github.com/grafana/grafana/pkg/components/gtime"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -88,15 +90,27 @@ var (
 	RouterLogging      bool
 	DataProxyLogging   bool
 	DataProxyTimeout   int
-	StaticRootPath     string
-	EnableGzip         bool
-	EnforceDomain      bool
+
+	// Data proxy limits, which data sources can override in their settings.
+	DataProxyMaxIdleConns    int
+	DataProxyIdleConnTimeout int
+	DataProxyMaxRetries      int
+	DataProxyResponseLimit   int64
+	StaticRootPath           string
+	EnableGzip               bool
+	EnforceDomain            bool
+
+	// Data sources
+	BlockDeletingUsedDataSources bool
 
 	// Security settings.
 	SecretKey                         string
 	DisableGravatar                   bool
 	EmailCodeValidMinutes             int
 	DataProxyWhiteList                map[string]bool
+	DataSourceBlockPrivateNetworks    bool
+	DataSourceBlockedNetworks         []*net.IPNet
+	DataSourceAllowedNetworks         []*net.IPNet
 	DisableBruteForceLoginProtection  bool
 	CookieSecure                      bool
 	CookieSameSiteDisabled            bool
@@ -223,12 +237,32 @@ type Cfg struct {
 	Raw    *ini.File
 	Logger log.Logger
 
+	// args are the command line arguments the configuration was loaded with, to reload it
+	args *CommandLineArgs
+
 	// HTTP Server Settings
 	AppUrl           string
 	AppSubUrl        string
 	ServeFromSubPath bool
 	StaticRootPath   string
 
+	// ShutdownTimeout is how long the work in progress is waited for when shutting down
+	ShutdownTimeout time.Duration
+
+	// AdditionalListenAddresses are the addresses the HTTP server listens on besides http_addr
+	// and http_port, or socket: host:port for TCP, unix:<path> for a Unix socket
+	AdditionalListenAddresses []string
+	// SocketActivation makes the HTTP server listen on the sockets passed by systemd instead
+	SocketActivation bool
+
+	// Compression and ETags of the API route groups
+	CompressedRoutes map[string]bool
+	CompressMinSize  int
+	ETagRoutes       map[string]bool
+
+	// ReadinessChecks are the dependencies failing /api/health/ready when they're unhealthy
+	ReadinessChecks map[string]bool
+
 	// build
 	BuildVersion string
 	BuildCommit  string
@@ -245,14 +279,43 @@ type Cfg struct {
 	LogsPath           string
 	BundledPluginsPath string
 
+	// Provisioning
+	ProvisioningWatchDatasources bool
+
 	// SMTP email settings
 	Smtp SmtpSettings
 
+	// API rate limits
+	RateLimit RateLimitSettings
+
+	// Usage events
+	UsageInsights UsageInsightsSettings
+
+	// Retention of the annotations, alert executions, dashboard versions and login attempts
+	Retention RetentionSettings
+
+	// Secrets
+	Secrets    SecretsSettings
+	Encryption EncryptionSettings
+
+	// Rules allowing, denying or rewriting data source queries
+	QueryRules QueryRulesSettings
+
+	// Bridges republishing MQTT and Kafka messages on Live channels
+	LiveIngest LiveIngestSettings
+
+	// Dashboards whose queries are run on a schedule to populate the query cache
+	QueryCacheWarming QueryCacheWarmingSettings
+
 	// Rendering
 	ImagesDir                      string
 	RendererUrl                    string
 	RendererCallbackUrl            string
 	RendererConcurrentRequestLimit int
+	RendererQueueTimeout           time.Duration
+
+	// ImageUploadRetention is how long the images uploaded to the external image storage are kept, 0 for ever
+	ImageUploadRetention time.Duration
 
 	// Security
 	DisableInitAdminCreation         bool
@@ -270,6 +333,8 @@ type Cfg struct {
 	PluginsAppsSkipVerifyTLS         bool
 	PluginSettings                   PluginSettings
 	PluginsAllowUnsigned             []string
+	PluginAdminEnabled               bool
+	PluginsCollectInterval           time.Duration
 	DisableSanitizeHtml              bool
 	EnterpriseLicensePath            string
 
@@ -281,6 +346,8 @@ type Cfg struct {
 	LoginMaxInactiveLifetimeDays int
 	LoginMaxLifetimeDays         int
 	TokenRotationIntervalMinutes int
+	TokenCacheEnabled            bool
+	TokenCacheTTL                time.Duration
 
 	// OAuth
 	OAuthCookieMaxAge int
@@ -612,6 +679,7 @@ func (cfg *Cfg) validateStaticRootPath() error {
 
 func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	setHomePath(args)
+	cfg.args = args
 
 	iniFile, err := cfg.loadConfiguration(args)
 	if err != nil {
@@ -651,6 +719,7 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 		return err
 	}
 	cfg.ProvisioningPath = makeAbsolute(provisioning, HomePath)
+	cfg.ProvisioningWatchDatasources = iniFile.Section("provisioning").Key("watch_datasources").MustBool(false)
 	server := iniFile.Section("server")
 	AppUrl, AppSubUrl, err = parseAppUrlAndSubUrl(server)
 	if err != nil {
@@ -694,9 +763,25 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	if err != nil {
 		return err
 	}
+	cfg.AdditionalListenAddresses = util.SplitString(server.Key("additional_listen_addresses").String())
+	cfg.SocketActivation = server.Key("socket_activation").MustBool(false)
 	RouterLogging = server.Key("router_logging").MustBool(false)
 
 	EnableGzip = server.Key("enable_gzip").MustBool(false)
+	cfg.ShutdownTimeout = server.Key("shutdown_timeout").MustDuration(30 * time.Second)
+	cfg.CompressedRoutes = make(map[string]bool)
+	for _, group := range util.SplitString(server.Key("compressed_routes").String()) {
+		cfg.CompressedRoutes[group] = true
+	}
+	cfg.CompressMinSize = server.Key("compress_min_size").MustInt(1024)
+	cfg.ETagRoutes = make(map[string]bool)
+	for _, group := range util.SplitString(server.Key("etag_routes").String()) {
+		cfg.ETagRoutes[group] = true
+	}
+	cfg.ReadinessChecks = make(map[string]bool)
+	for _, check := range util.SplitString(server.Key("readiness_checks").MustString("database remote_cache")) {
+		cfg.ReadinessChecks[check] = true
+	}
 	EnforceDomain = server.Key("enforce_domain").MustBool(false)
 	staticRoot, err := valueAsString(server, "static_root_path", "")
 	if err != nil {
@@ -711,10 +796,11 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 
 	// read data proxy settings
 	dataproxy := iniFile.Section("dataproxy")
-	DataProxyLogging = dataproxy.Key("logging").MustBool(false)
-	DataProxyTimeout = dataproxy.Key("timeout").MustInt(30)
+	setDataProxySettings(parseDataProxySettings(iniFile))
 	cfg.SendUserHeader = dataproxy.Key("send_user_header").MustBool(false)
 
+	BlockDeletingUsedDataSources = iniFile.Section("datasources").Key("block_deleting_used").MustBool(false)
+
 	// read security settings
 	security := iniFile.Section("security")
 	SecretKey, err = valueAsString(security, "secret_key", "")
@@ -795,6 +881,17 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 		DataProxyWhiteList[hostAndIp] = true
 	}
 
+	// network policy of the requests to data sources
+	DataSourceBlockPrivateNetworks = security.Key("data_source_block_private_networks").MustBool(false)
+	DataSourceBlockedNetworks, err = parseNetworks(security, "data_source_blocked_networks", "169.254.169.254/32 fd00:ec2::254/128")
+	if err != nil {
+		return err
+	}
+	DataSourceAllowedNetworks, err = parseNetworks(security, "data_source_allowed_networks", "")
+	if err != nil {
+		return err
+	}
+
 	// admin
 	cfg.DisableInitAdminCreation = security.Key("disable_initial_admin_creation").MustBool(false)
 	AdminUser, err = valueAsString(security, "admin_user", "")
@@ -859,6 +956,8 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	if cfg.TokenRotationIntervalMinutes < 2 {
 		cfg.TokenRotationIntervalMinutes = 2
 	}
+	cfg.TokenCacheEnabled = auth.Key("token_cache_enabled").MustBool(false)
+	cfg.TokenCacheTTL = time.Duration(auth.Key("token_cache_ttl_seconds").MustInt(60)) * time.Second
 
 	DisableLoginForm = auth.Key("disable_login_form").MustBool(false)
 	DisableSignoutMenu = auth.Key("disable_signout_menu").MustBool(false)
@@ -952,6 +1051,7 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 		}
 	}
 	cfg.RendererConcurrentRequestLimit = renderSec.Key("concurrent_render_request_limit").MustInt(30)
+	cfg.RendererQueueTimeout = renderSec.Key("queue_timeout").MustDuration(0)
 
 	cfg.ImagesDir = filepath.Join(cfg.DataPath, "png")
 	cfg.TempDataLifetime = iniFile.Section("paths").Key("temp_data_lifetime").MustDuration(time.Second * 3600 * 24)
@@ -1001,6 +1101,8 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	pluginsSection := iniFile.Section("plugins")
 	cfg.PluginsEnableAlpha = pluginsSection.Key("enable_alpha").MustBool(false)
 	cfg.PluginsAppsSkipVerifyTLS = pluginsSection.Key("app_tls_skip_verify_insecure").MustBool(false)
+	cfg.PluginAdminEnabled = pluginsSection.Key("plugin_admin_enabled").MustBool(false)
+	cfg.PluginsCollectInterval = pluginsSection.Key("collect_interval").MustDuration(time.Minute)
 	cfg.PluginSettings = extractPluginSettings(iniFile.Sections())
 	pluginsAllowUnsigned := pluginsSection.Key("allow_loading_unsigned_plugins").MustString("")
 	for _, plug := range strings.Split(pluginsAllowUnsigned, ",") {
@@ -1028,7 +1130,18 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	cfg.readLDAPConfig()
 	cfg.readSessionConfig()
 	cfg.readSmtpSettings()
+	cfg.readRateLimitSettings()
+	cfg.readUsageInsightsSettings()
+	cfg.readSecretsSettings()
+	cfg.readAWSSettings()
+	cfg.readQueryRulesSettings()
+	cfg.readLiveIngestSettings()
+	cfg.readQueryCacheWarmingSettings()
+	cfg.readQueryLimitsSettings()
+	cfg.readEncryptionSettings()
+	cfg.readDateFormatsSettings()
 	cfg.readQuotaSettings()
+	cfg.readRetentionSettings()
 
 	if VerifyEmailEnabled && !cfg.Smtp.Enabled {
 		log.Warn("require_email_validation is enabled but smtp is disabled")
@@ -1051,6 +1164,13 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	if err != nil {
 		return err
 	}
+	// the images are kept for days usually, like 30d
+	if value := imageUploadingSection.Key("retention").String(); value != "" {
+		cfg.ImageUploadRetention, err = gtime.ParseInterval(value)
+		if err != nil {
+			return fmt.Errorf("invalid retention for external_image_storage: %w", err)
+		}
+	}
 
 	enterprise := iniFile.Section("enterprise")
 	cfg.EnterpriseLicensePath, err = valueAsString(enterprise, "license_path", filepath.Join(cfg.DataPath, "license.jwt"))
@@ -1075,6 +1195,32 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 	return nil
 }
 
+// parseNetworks parses a list of networks in CIDR notation, an IP address being a network of its own.
+func parseNetworks(section *ini.Section, keyName string, defaultValue string) ([]*net.IPNet, error) {
+	value, err := valueAsString(section, keyName, defaultValue)
+	if err != nil {
+		return nil, err
+	}
+
+	networks := []*net.IPNet{}
+	for _, cidr := range util.SplitString(value) {
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q in %s: %w", cidr, keyName, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
 func valueAsString(section *ini.Section, keyName string, defaultValue string) (value string, err error) {
 	defer func() {
 		if err_ := recover(); err_ != nil {
@@ -0,0 +1,56 @@
+package setting
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	// AWSAssumeRoleMinDuration is the shortest session duration accepted by AWS STS when assuming a role
+	AWSAssumeRoleMinDuration = 15 * time.Minute
+	// AWSAssumeRoleMaxDuration is the longest session duration accepted by AWS STS when assuming a role
+	AWSAssumeRoleMaxDuration = 12 * time.Hour
+)
+
+// AWSAssumeRoleMaxSessionDuration is the longest session duration the data sources can request when
+// assuming an AWS role
+var AWSAssumeRoleMaxSessionDuration time.Duration
+
+// AWSUseFIPSEndpoint is whether the AWS data sources use the FIPS endpoints of AWS when they don't
+// configure it themselves
+var AWSUseFIPSEndpoint bool
+
+// AWSMaxConcurrentCalls is the most AWS API calls in flight at once of each AWS data source which
+// doesn't set its own limit, 0 for no limit
+var AWSMaxConcurrentCalls int
+
+// AWSSigV4AuthEnabled is whether the data sources can sign their requests with SigV4
+var AWSSigV4AuthEnabled bool
+
+// AWSSigV4AllowedAuthProviders are the auth types the data sources can sign their requests with.
+// default, credentials and arn use the identity of Grafana itself.
+var AWSSigV4AllowedAuthProviders []string
+
+// AWSSigV4AllowedServices are the AWS services the data sources can sign their requests for
+var AWSSigV4AllowedServices []string
+
+func (cfg *Cfg) readAWSSettings() {
+	aws := cfg.Raw.Section("aws")
+	maxDuration := aws.Key("assume_role_max_duration").MustDuration(time.Hour)
+	if maxDuration < AWSAssumeRoleMinDuration {
+		maxDuration = AWSAssumeRoleMinDuration
+	}
+	if maxDuration > AWSAssumeRoleMaxDuration {
+		maxDuration = AWSAssumeRoleMaxDuration
+	}
+	AWSAssumeRoleMaxSessionDuration = maxDuration
+	AWSUseFIPSEndpoint = aws.Key("use_fips_endpoint").MustBool(false)
+	AWSMaxConcurrentCalls = aws.Key("max_concurrent_calls").MustInt(20)
+	if AWSMaxConcurrentCalls < 0 {
+		AWSMaxConcurrentCalls = 0
+	}
+	AWSSigV4AuthEnabled = aws.Key("sigv4_auth_enabled").MustBool(false)
+	AWSSigV4AllowedAuthProviders = util.SplitString(aws.Key("sigv4_allowed_auth_providers").MustString("keys"))
+	AWSSigV4AllowedServices = util.SplitString(aws.Key("sigv4_allowed_services").MustString("es aps execute-api"))
+}
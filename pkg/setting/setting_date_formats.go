@@ -0,0 +1,42 @@
+package setting
+
+import (
+	"strings"
+	"time"
+)
+
+var (
+	// WeekStart is the first day of the weeks, when rounding time ranges like now/w
+	WeekStart = time.Monday
+	// FiscalYearStartMonth is the first month of the fiscal years, for time ranges like now/fy or now/fQ
+	FiscalYearStartMonth = time.January
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+func (cfg *Cfg) readDateFormatsSettings() {
+	dateFormats := cfg.Raw.Section("date_formats")
+
+	weekStart := strings.ToLower(dateFormats.Key("week_start").MustString("monday"))
+	if day, ok := weekdays[weekStart]; ok {
+		WeekStart = day
+	} else {
+		cfg.Logger.Warn("Invalid week_start in [date_formats], using monday", "week_start", weekStart)
+		WeekStart = time.Monday
+	}
+
+	month := dateFormats.Key("fiscal_year_start_month").MustInt(1)
+	if month < 1 || month > 12 {
+		cfg.Logger.Warn("Invalid fiscal_year_start_month in [date_formats], using 1", "fiscal_year_start_month", month)
+		month = 1
+	}
+	FiscalYearStartMonth = time.Month(month)
+}
@@ -0,0 +1,19 @@
+package setting
+
+type EncryptionSettings struct {
+	EnvelopeEncryption bool
+	KEKProvider        string
+
+	AWSKMSKeyId  string
+	AWSKMSRegion string
+}
+
+func (cfg *Cfg) readEncryptionSettings() {
+	sec := cfg.Raw.Section("security.encryption")
+	cfg.Encryption.EnvelopeEncryption = sec.Key("envelope_encryption").MustBool(false)
+	cfg.Encryption.KEKProvider = sec.Key("kek_provider").MustString("secret_key")
+
+	kms := cfg.Raw.Section("security.encryption.awskms")
+	cfg.Encryption.AWSKMSKeyId = kms.Key("key_id").String()
+	cfg.Encryption.AWSKMSRegion = kms.Key("region").String()
+}
@@ -0,0 +1,12 @@
+package setting
+
+type LiveIngestSettings struct {
+	Enabled    bool
+	ConfigFile string
+}
+
+func (cfg *Cfg) readLiveIngestSettings() {
+	sec := cfg.Raw.Section("live_ingest")
+	cfg.LiveIngest.Enabled = sec.Key("enabled").MustBool(false)
+	cfg.LiveIngest.ConfigFile = makeAbsolute(sec.Key("config_file").MustString("conf/live_ingest.yaml"), HomePath)
+}
@@ -0,0 +1,12 @@
+package setting
+
+type QueryCacheWarmingSettings struct {
+	Enabled    bool
+	ConfigFile string
+}
+
+func (cfg *Cfg) readQueryCacheWarmingSettings() {
+	sec := cfg.Raw.Section("query_cache_warming")
+	cfg.QueryCacheWarming.Enabled = sec.Key("enabled").MustBool(false)
+	cfg.QueryCacheWarming.ConfigFile = makeAbsolute(sec.Key("config_file").MustString("conf/query_cache_warming.yaml"), HomePath)
+}
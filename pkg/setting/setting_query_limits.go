@@ -0,0 +1,39 @@
+package setting
+
+import "time"
+
+var (
+	// QueryMaxSeries is the maximum number of series returned by a query to a data source, 0
+	// meaning no limit. Data sources can override it in their settings.
+	QueryMaxSeries = 0
+	// QueryMaxDataPoints is the maximum number of data points returned by a query to a data
+	// source, 0 meaning no limit. Data sources can override it in their settings.
+	QueryMaxDataPoints = 0
+	// QueryPanelBudget is how long the queries of a dashboard panel may run before they're
+	// cancelled, 0 meaning no limit. Data sources can override it in their settings.
+	QueryPanelBudget time.Duration
+	// QuerySlowThreshold is how long the queries of a dashboard panel may run before they're
+	// logged and reported as slow, 0 meaning never.
+	QuerySlowThreshold = 10 * time.Second
+)
+
+func (cfg *Cfg) readQueryLimitsSettings() {
+	sec := cfg.Raw.Section("query_limits")
+
+	QueryMaxSeries = sec.Key("max_series").MustInt(0)
+	if QueryMaxSeries < 0 {
+		QueryMaxSeries = 0
+	}
+	QueryMaxDataPoints = sec.Key("max_data_points").MustInt(0)
+	if QueryMaxDataPoints < 0 {
+		QueryMaxDataPoints = 0
+	}
+	QueryPanelBudget = sec.Key("panel_execution_budget").MustDuration(0)
+	if QueryPanelBudget < 0 {
+		QueryPanelBudget = 0
+	}
+	QuerySlowThreshold = sec.Key("slow_query_threshold").MustDuration(10 * time.Second)
+	if QuerySlowThreshold < 0 {
+		QuerySlowThreshold = 0
+	}
+}
@@ -0,0 +1,12 @@
+package setting
+
+type QueryRulesSettings struct {
+	Enabled    bool
+	ConfigFile string
+}
+
+func (cfg *Cfg) readQueryRulesSettings() {
+	sec := cfg.Raw.Section("query_rules")
+	cfg.QueryRules.Enabled = sec.Key("enabled").MustBool(false)
+	cfg.QueryRules.ConfigFile = makeAbsolute(sec.Key("config_file").MustString("conf/query_rules.yaml"), HomePath)
+}
@@ -9,6 +9,9 @@ type OrgQuota struct {
 	DataSource int64 `target:"data_source"`
 	Dashboard  int64 `target:"dashboard"`
 	ApiKey     int64 `target:"api_key"`
+	Snapshot   int64 `target:"dashboard_snapshot"`
+	Team       int64 `target:"team"`
+	Playlist   int64 `target:"playlist"`
 }
 
 type UserQuota struct {
@@ -21,6 +24,9 @@ type GlobalQuota struct {
 	DataSource int64 `target:"data_source"`
 	Dashboard  int64 `target:"dashboard"`
 	ApiKey     int64 `target:"api_key"`
+	Snapshot   int64 `target:"dashboard_snapshot"`
+	Team       int64 `target:"team"`
+	Playlist   int64 `target:"playlist"`
 	Session    int64 `target:"-"`
 }
 
@@ -74,6 +80,9 @@ func (cfg *Cfg) readQuotaSettings() {
 		DataSource: quota.Key("org_data_source").MustInt64(10),
 		Dashboard:  quota.Key("org_dashboard").MustInt64(10),
 		ApiKey:     quota.Key("org_api_key").MustInt64(10),
+		Snapshot:   quota.Key("org_snapshot").MustInt64(-1),
+		Team:       quota.Key("org_team").MustInt64(-1),
+		Playlist:   quota.Key("org_playlist").MustInt64(-1),
 	}
 
 	// per User limits
@@ -88,6 +97,9 @@ func (cfg *Cfg) readQuotaSettings() {
 		DataSource: quota.Key("global_data_source").MustInt64(-1),
 		Dashboard:  quota.Key("global_dashboard").MustInt64(-1),
 		ApiKey:     quota.Key("global_api_key").MustInt64(-1),
+		Snapshot:   quota.Key("global_snapshot").MustInt64(-1),
+		Team:       quota.Key("global_team").MustInt64(-1),
+		Playlist:   quota.Key("global_playlist").MustInt64(-1),
 		Session:    quota.Key("global_session").MustInt64(-1),
 	}
 
@@ -0,0 +1,35 @@
+package setting
+
+import "math"
+
+// RateLimit is a number of requests per second, with a burst of requests allowed at once
+type RateLimit struct {
+	RequestsPerSecond float64
+	Burst             int
+}
+
+type RateLimitSettings struct {
+	Org    RateLimit
+	User   RateLimit
+	APIKey RateLimit
+}
+
+func (cfg *Cfg) readRateLimitSettings() {
+	sec := cfg.Raw.Section("rate_limiting")
+
+	readRateLimit := func(prefix string) RateLimit {
+		limit := RateLimit{
+			RequestsPerSecond: sec.Key(prefix + "_requests_per_second").MustFloat64(0),
+			Burst:             sec.Key(prefix + "_burst").MustInt(0),
+		}
+		// allow a second worth of requests at once by default
+		if limit.Burst <= 0 {
+			limit.Burst = int(math.Max(1, math.Ceil(limit.RequestsPerSecond)))
+		}
+		return limit
+	}
+
+	cfg.RateLimit.Org = readRateLimit("org")
+	cfg.RateLimit.User = readRateLimit("user")
+	cfg.RateLimit.APIKey = readRateLimit("api_key")
+}
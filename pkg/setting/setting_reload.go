@@ -0,0 +1,180 @@
+package setting
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"gopkg.in/ini.v1"
+)
+
+var (
+	// reloadMu guards the settings that can be reloaded without restarting Grafana, so that they're
+	// all replaced at once and never read while being replaced
+	reloadMu sync.RWMutex
+	// reloadCallMu prevents concurrent reloads
+	reloadCallMu sync.Mutex
+
+	reloadHandlers []ReloadHandler
+)
+
+// ReloadHandler is called after the settings were reloaded, e.g. to drop what was built with
+// the previous settings.
+type ReloadHandler func(cfg *Cfg)
+
+// OnReload registers a handler called after the settings were reloaded.
+func OnReload(handler ReloadHandler) {
+	reloadCallMu.Lock()
+	defer reloadCallMu.Unlock()
+	reloadHandlers = append(reloadHandlers, handler)
+}
+
+// DataProxySettings are the settings of the [dataproxy] section that can be reloaded.
+type DataProxySettings struct {
+	Logging         bool
+	Timeout         int
+	MaxIdleConns    int
+	IdleConnTimeout int
+	MaxRetries      int
+	ResponseLimit   int64
+}
+
+func parseDataProxySettings(file *ini.File) DataProxySettings {
+	dataproxy := file.Section("dataproxy")
+	return DataProxySettings{
+		Logging:         dataproxy.Key("logging").MustBool(false),
+		Timeout:         dataproxy.Key("timeout").MustInt(30),
+		MaxIdleConns:    dataproxy.Key("max_idle_connections").MustInt(100),
+		IdleConnTimeout: dataproxy.Key("idle_conn_timeout_seconds").MustInt(90),
+		MaxRetries:      dataproxy.Key("max_retries").MustInt(0),
+		ResponseLimit:   dataproxy.Key("response_limit").MustInt64(0),
+	}
+}
+
+func setDataProxySettings(settings DataProxySettings) {
+	DataProxyLogging = settings.Logging
+	DataProxyTimeout = settings.Timeout
+	DataProxyMaxIdleConns = settings.MaxIdleConns
+	DataProxyIdleConnTimeout = settings.IdleConnTimeout
+	DataProxyMaxRetries = settings.MaxRetries
+	DataProxyResponseLimit = settings.ResponseLimit
+}
+
+// GetDataProxySettings returns the data proxy settings, which can change when the settings are reloaded.
+func GetDataProxySettings() DataProxySettings {
+	reloadMu.RLock()
+	defer reloadMu.RUnlock()
+	return DataProxySettings{
+		Logging:         DataProxyLogging,
+		Timeout:         DataProxyTimeout,
+		MaxIdleConns:    DataProxyMaxIdleConns,
+		IdleConnTimeout: DataProxyIdleConnTimeout,
+		MaxRetries:      DataProxyMaxRetries,
+		ResponseLimit:   DataProxyResponseLimit,
+	}
+}
+
+// GetAuthProxyWhitelist returns the addresses allowed to authenticate users with the auth proxy,
+// which can change when the settings are reloaded.
+func GetAuthProxyWhitelist() string {
+	reloadMu.RLock()
+	defer reloadMu.RUnlock()
+	return AuthProxyWhitelist
+}
+
+func validateAuthProxyWhitelist(whitelist string) error {
+	if strings.TrimSpace(whitelist) == "" {
+		return nil
+	}
+
+	for _, proxy := range strings.Split(whitelist, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			proxy += "/32"
+		}
+		if _, _, err := net.ParseCIDR(proxy); err != nil {
+			return fmt.Errorf("invalid auth.proxy whitelist: %w", err)
+		}
+	}
+	return nil
+}
+
+// Reload reads the configuration files again and applies the settings that can change without
+// restarting Grafana: the log levels and outputs, the SMTP settings, the auth proxy whitelist and
+// the data proxy limits. The other settings keep their value until Grafana is restarted. The
+// settings are only applied when they're all valid, and replaced at once.
+func (cfg *Cfg) Reload() error {
+	reloadCallMu.Lock()
+	defer reloadCallMu.Unlock()
+
+	if cfg.args == nil {
+		return errors.New("the configuration wasn't loaded from the configuration files")
+	}
+
+	file, err := readConfigFiles(cfg.args)
+	if err != nil {
+		return err
+	}
+
+	smtp := parseSmtpSettings(file)
+	dataProxy := parseDataProxySettings(file)
+	whitelist := file.Section("auth.proxy").Key("whitelist").String()
+	if err := validateAuthProxyWhitelist(whitelist); err != nil {
+		return err
+	}
+
+	if err := cfg.initLogging(file); err != nil {
+		return err
+	}
+
+	reloadMu.Lock()
+	cfg.Smtp = smtp
+	setDataProxySettings(dataProxy)
+	AuthProxyWhitelist = whitelist
+	reloadMu.Unlock()
+
+	for _, handler := range reloadHandlers {
+		handler(cfg)
+	}
+
+	cfg.Logger.Info("Settings reloaded")
+	return nil
+}
+
+// readConfigFiles reads defaults.ini and the configuration file, with the command line and
+// environment overrides, failing instead of exiting like when Grafana starts.
+func readConfigFiles(args *CommandLineArgs) (*ini.File, error) {
+	defaultConfigFile := path.Join(HomePath, "conf/defaults.ini")
+	if _, err := os.Stat(defaultConfigFile); err != nil {
+		return nil, err
+	}
+
+	parsedFile, err := ini.Load(defaultConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse defaults.ini: %w", err)
+	}
+	parsedFile.BlockMode = false
+
+	configFiles = []string{defaultConfigFile}
+	commandLineProps := getCommandLineProperties(args.Args)
+	applyCommandLineDefaultProperties(commandLineProps, parsedFile)
+
+	if err := loadSpecifiedConfigFile(args.Config, parsedFile); err != nil {
+		return nil, err
+	}
+
+	if err := applyEnvVariableOverrides(parsedFile); err != nil {
+		return nil, err
+	}
+
+	applyCommandLineProperties(commandLineProps, parsedFile)
+
+	if err := expandConfig(parsedFile); err != nil {
+		return nil, err
+	}
+	return parsedFile, nil
+}
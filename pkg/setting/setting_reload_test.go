@@ -0,0 +1,58 @@
+package setting
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadSettings(t *testing.T) {
+	skipStaticRootValidation = true
+
+	dir, err := ioutil.TempDir("", "grafana-reload")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	configFile := filepath.Join(dir, "custom.ini")
+	writeConfig := func(smtpHost string, timeout string, whitelist string) {
+		content := "[smtp]\nhost = " + smtpHost + "\n[dataproxy]\ntimeout = " + timeout + "\n[auth.proxy]\nwhitelist = " + whitelist + "\n"
+		require.NoError(t, ioutil.WriteFile(configFile, []byte(content), 0600))
+	}
+
+	writeConfig("smtp1:25", "10", "10.0.0.1")
+	cfg := NewCfg()
+	err = cfg.Load(&CommandLineArgs{HomePath: "../../", Config: configFile})
+	require.NoError(t, err)
+	require.Equal(t, "smtp1:25", cfg.SmtpSettings().Host)
+	require.Equal(t, 10, GetDataProxySettings().Timeout)
+
+	reloaded := 0
+	OnReload(func(cfg *Cfg) {
+		reloaded++
+	})
+
+	t.Run("Should not apply any setting when one is invalid", func(t *testing.T) {
+		writeConfig("smtp2:25", "20", "not-an-ip")
+		require.Error(t, cfg.Reload())
+		require.Equal(t, "smtp1:25", cfg.SmtpSettings().Host)
+		require.Equal(t, 10, GetDataProxySettings().Timeout)
+		require.Equal(t, "10.0.0.1", GetAuthProxyWhitelist())
+		require.Equal(t, 0, reloaded)
+	})
+
+	t.Run("Should apply the reloadable settings", func(t *testing.T) {
+		writeConfig("smtp2:25", "20", "10.0.0.0/8")
+		require.NoError(t, cfg.Reload())
+		require.Equal(t, "smtp2:25", cfg.SmtpSettings().Host)
+		require.Equal(t, 20, GetDataProxySettings().Timeout)
+		require.Equal(t, "10.0.0.0/8", GetAuthProxyWhitelist())
+		require.Equal(t, 1, reloaded)
+	})
+
+	t.Run("Should fail when the settings weren't loaded from files", func(t *testing.T) {
+		require.Error(t, NewCfg().Reload())
+	})
+}
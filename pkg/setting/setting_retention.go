@@ -0,0 +1,74 @@
+package setting
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/gtime"
+)
+
+// RetentionPolicy is how long and how many rows of a kind of data are kept in the database. A zero
+// MaxAge or MaxRows doesn't limit them.
+type RetentionPolicy struct {
+	MaxAge  time.Duration
+	MaxRows int64
+}
+
+// RetentionSettings configures the cleanup job deleting the rows beyond their retention policy.
+type RetentionSettings struct {
+	// Interval is how often the cleanup job runs
+	Interval time.Duration
+	// BatchSize is the number of rows deleted by a statement
+	BatchSize int
+	// MaxBatches is the number of batches of a kind of data deleted by a run of the cleanup job,
+	// the rows left are deleted by the next runs
+	MaxBatches int
+
+	Annotations       RetentionPolicy
+	AlertExecutions   RetentionPolicy
+	DashboardVersions RetentionPolicy
+	LoginAttempts     RetentionPolicy
+}
+
+func (cfg *Cfg) readRetentionSettings() {
+	sec := cfg.Raw.Section("retention")
+
+	cfg.Retention.Interval = sec.Key("cleanup_interval").MustDuration(10 * time.Minute)
+	cfg.Retention.BatchSize = sec.Key("batch_size").MustInt(100)
+	if cfg.Retention.BatchSize < 1 {
+		cfg.Retention.BatchSize = 1
+	}
+	cfg.Retention.MaxBatches = sec.Key("max_batches").MustInt(50)
+	if cfg.Retention.MaxBatches < 1 {
+		cfg.Retention.MaxBatches = 1
+	}
+
+	cfg.Retention.Annotations = cfg.readRetentionPolicy("annotations", 0)
+	cfg.Retention.AlertExecutions = cfg.readRetentionPolicy("alert_executions", 0)
+	cfg.Retention.DashboardVersions = cfg.readRetentionPolicy("dashboard_versions", 0)
+	cfg.Retention.LoginAttempts = cfg.readRetentionPolicy("login_attempts", 10*time.Minute)
+}
+
+func (cfg *Cfg) readRetentionPolicy(kind string, defaultMaxAge time.Duration) RetentionPolicy {
+	sec := cfg.Raw.Section("retention")
+
+	policy := RetentionPolicy{
+		MaxAge:  defaultMaxAge,
+		MaxRows: sec.Key(kind + "_max_rows").MustInt64(0),
+	}
+	// the max ages can be days, weeks, months or years, like 90d
+	if value := sec.Key(kind + "_max_age").String(); value != "" {
+		maxAge, err := gtime.ParseInterval(value)
+		if err != nil {
+			cfg.Logger.Warn("Invalid retention max age, using the default", "key", kind+"_max_age", "value", value)
+		} else {
+			policy.MaxAge = maxAge
+		}
+	}
+	if policy.MaxAge < 0 {
+		policy.MaxAge = 0
+	}
+	if policy.MaxRows < 0 {
+		policy.MaxRows = 0
+	}
+	return policy
+}
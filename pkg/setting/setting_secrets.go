@@ -0,0 +1,70 @@
+package setting
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// SecretsAllowedPrefix is a prefix of the paths of the secrets of a backend an org can reference
+type SecretsAllowedPrefix struct {
+	Backend string
+	Prefix  string
+}
+
+type SecretsSettings struct {
+	Enabled  bool
+	CacheTTL time.Duration
+	// AllowedPrefixes are the prefixes of the secrets each org can reference, by org id. The provisioned
+	// settings can reference any secret.
+	AllowedPrefixes map[int64][]SecretsAllowedPrefix
+
+	VaultUrl   string
+	VaultToken string
+
+	AWSRegion string
+
+	AzureVaultUrl     string
+	AzureTenantId     string
+	AzureClientId     string
+	AzureClientSecret string
+}
+
+func (cfg *Cfg) readSecretsSettings() {
+	sec := cfg.Raw.Section("secrets")
+	cfg.Secrets.Enabled = sec.Key("enabled").MustBool(false)
+	cfg.Secrets.CacheTTL = sec.Key("cache_ttl").MustDuration(5 * time.Minute)
+	cfg.Secrets.AllowedPrefixes = make(map[int64][]SecretsAllowedPrefix)
+	for _, entry := range util.SplitString(sec.Key("allowed_prefixes").String()) {
+		// <org id>:<backend>:<prefix>, the prefix can hold colons like the ARNs of AWS
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			cfg.Logger.Warn("Ignoring invalid entry of [secrets] allowed_prefixes, expected <org id>:<backend>:<prefix>", "entry", entry)
+			continue
+		}
+		orgID, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			cfg.Logger.Warn("Ignoring invalid entry of [secrets] allowed_prefixes, expected <org id>:<backend>:<prefix>", "entry", entry)
+			continue
+		}
+		cfg.Secrets.AllowedPrefixes[orgID] = append(cfg.Secrets.AllowedPrefixes[orgID], SecretsAllowedPrefix{
+			Backend: parts[1],
+			Prefix:  parts[2],
+		})
+	}
+
+	vault := cfg.Raw.Section("secrets.vault")
+	cfg.Secrets.VaultUrl = vault.Key("url").String()
+	cfg.Secrets.VaultToken = vault.Key("token").String()
+
+	aws := cfg.Raw.Section("secrets.aws")
+	cfg.Secrets.AWSRegion = aws.Key("region").String()
+
+	azure := cfg.Raw.Section("secrets.azure")
+	cfg.Secrets.AzureVaultUrl = azure.Key("vault_url").String()
+	cfg.Secrets.AzureTenantId = azure.Key("tenant_id").String()
+	cfg.Secrets.AzureClientId = azure.Key("client_id").String()
+	cfg.Secrets.AzureClientSecret = azure.Key("client_secret").String()
+}
@@ -1,5 +1,7 @@
 package setting
 
+import "gopkg.in/ini.v1"
+
 type SmtpSettings struct {
 	Enabled        bool
 	Host           string
@@ -18,20 +20,33 @@ type SmtpSettings struct {
 }
 
 func (cfg *Cfg) readSmtpSettings() {
-	sec := cfg.Raw.Section("smtp")
-	cfg.Smtp.Enabled = sec.Key("enabled").MustBool(false)
-	cfg.Smtp.Host = sec.Key("host").String()
-	cfg.Smtp.User = sec.Key("user").String()
-	cfg.Smtp.Password = sec.Key("password").String()
-	cfg.Smtp.CertFile = sec.Key("cert_file").String()
-	cfg.Smtp.KeyFile = sec.Key("key_file").String()
-	cfg.Smtp.FromAddress = sec.Key("from_address").String()
-	cfg.Smtp.FromName = sec.Key("from_name").String()
-	cfg.Smtp.EhloIdentity = sec.Key("ehlo_identity").String()
-	cfg.Smtp.StartTLSPolicy = sec.Key("startTLS_policy").String()
-	cfg.Smtp.SkipVerify = sec.Key("skip_verify").MustBool(false)
+	cfg.Smtp = parseSmtpSettings(cfg.Raw)
+}
+
+// SmtpSettings returns the SMTP settings, which can change when the settings are reloaded.
+func (cfg *Cfg) SmtpSettings() SmtpSettings {
+	reloadMu.RLock()
+	defer reloadMu.RUnlock()
+	return cfg.Smtp
+}
+
+func parseSmtpSettings(file *ini.File) SmtpSettings {
+	smtp := SmtpSettings{}
+	sec := file.Section("smtp")
+	smtp.Enabled = sec.Key("enabled").MustBool(false)
+	smtp.Host = sec.Key("host").String()
+	smtp.User = sec.Key("user").String()
+	smtp.Password = sec.Key("password").String()
+	smtp.CertFile = sec.Key("cert_file").String()
+	smtp.KeyFile = sec.Key("key_file").String()
+	smtp.FromAddress = sec.Key("from_address").String()
+	smtp.FromName = sec.Key("from_name").String()
+	smtp.EhloIdentity = sec.Key("ehlo_identity").String()
+	smtp.StartTLSPolicy = sec.Key("startTLS_policy").String()
+	smtp.SkipVerify = sec.Key("skip_verify").MustBool(false)
 
-	emails := cfg.Raw.Section("emails")
-	cfg.Smtp.SendWelcomeEmailOnSignUp = emails.Key("welcome_email_on_sign_up").MustBool(false)
-	cfg.Smtp.TemplatesPattern = emails.Key("templates_pattern").MustString("emails/*.html")
+	emails := file.Section("emails")
+	smtp.SendWelcomeEmailOnSignUp = emails.Key("welcome_email_on_sign_up").MustBool(false)
+	smtp.TemplatesPattern = emails.Key("templates_pattern").MustString("emails/*.html")
+	return smtp
 }
@@ -0,0 +1,58 @@
+package setting
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// UsageInsightsSettings configures the recording of the usage events and the sinks they're
+// exported to.
+type UsageInsightsSettings struct {
+	Enabled bool
+	// Sinks are the names of the sinks the events are exported to: sql, loki, http or kafka
+	Sinks []string
+	// FlushInterval is how often the buffered events are exported
+	FlushInterval time.Duration
+	// BatchSize is the number of buffered events exported at once before the flush interval
+	BatchSize int
+	// BufferSize is the number of events buffered before new events are dropped
+	BufferSize int
+
+	// SQLRetentionDays is how long the events are kept in the usage_event table, 0 keeps them forever
+	SQLRetentionDays int
+
+	LokiURL      string
+	LokiUsername string
+	LokiPassword string
+
+	// HTTPURL is the url the events are posted to as a JSON array
+	HTTPURL string
+	// HTTPAuthorization is the Authorization header sent to HTTPURL
+	HTTPAuthorization string
+
+	// KafkaURL is the url of a Kafka REST proxy, the events are produced to KafkaTopic
+	KafkaURL   string
+	KafkaTopic string
+}
+
+func (cfg *Cfg) readUsageInsightsSettings() {
+	sec := cfg.Raw.Section("usage_insights")
+
+	cfg.UsageInsights.Enabled = sec.Key("enabled").MustBool(false)
+	cfg.UsageInsights.Sinks = util.SplitString(sec.Key("sinks").MustString("sql"))
+	cfg.UsageInsights.FlushInterval = sec.Key("flush_interval").MustDuration(10 * time.Second)
+	cfg.UsageInsights.BatchSize = sec.Key("batch_size").MustInt(500)
+	cfg.UsageInsights.BufferSize = sec.Key("buffer_size").MustInt(10000)
+	cfg.UsageInsights.SQLRetentionDays = sec.Key("sql_retention_days").MustInt(90)
+
+	cfg.UsageInsights.LokiURL = sec.Key("loki_url").String()
+	cfg.UsageInsights.LokiUsername = sec.Key("loki_username").String()
+	cfg.UsageInsights.LokiPassword = sec.Key("loki_password").String()
+
+	cfg.UsageInsights.HTTPURL = sec.Key("http_url").String()
+	cfg.UsageInsights.HTTPAuthorization = sec.Key("http_authorization").String()
+
+	cfg.UsageInsights.KafkaURL = sec.Key("kafka_url").String()
+	cfg.UsageInsights.KafkaTopic = sec.Key("kafka_topic").MustString("grafana-usage-events")
+}
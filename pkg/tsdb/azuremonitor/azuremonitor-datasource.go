@@ -218,7 +218,7 @@ func (e *AzureMonitorDatasource) executeQuery(ctx context.Context, query *AzureM
 
 func (e *AzureMonitorDatasource) createRequest(ctx context.Context, dsInfo *models.DataSource) (*http.Request, error) {
 	// find plugin
-	plugin, ok := plugins.DataSources[dsInfo.Type]
+	plugin, ok := plugins.GetDataSource(dsInfo.Type)
 	if !ok {
 		return nil, errors.New("Unable to find datasource plugin Azure Monitor")
 	}
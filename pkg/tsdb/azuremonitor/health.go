@@ -0,0 +1,43 @@
+package azuremonitor
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// CheckHealth lists the subscriptions with the credentials of the Azure Monitor API. The other
+// services of the data source aren't checked, the status is unknown when only those are set up.
+func (e *AzureMonitorExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) (*tsdb.HealthCheckResult, error) {
+	if dsInfo.JsonData.Get("subscriptionId").MustString() == "" {
+		return &tsdb.HealthCheckResult{
+			Status:  tsdb.HealthStatusUnknown,
+			Message: "Azure Monitor API is not configured, the other services aren't checked",
+		}, nil
+	}
+
+	azDatasource := &AzureMonitorDatasource{
+		httpClient: e.httpClient,
+		dsInfo:     dsInfo,
+	}
+	req, err := azDatasource.createRequest(ctx, dsInfo)
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = "api-version=2019-03-01"
+
+	res, err := ctxhttp.Do(ctx, e.httpClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("Request failed status: %v", res.Status)
+	}
+
+	return tsdb.HealthOk("Successfully queried the Azure Monitor service."), nil
+}
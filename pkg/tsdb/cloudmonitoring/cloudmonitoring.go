@@ -94,6 +94,12 @@ func (e *CloudMonitoringExecutor) Query(ctx context.Context, dsInfo *models.Data
 	return result, err
 }
 
+// ConcurrentQueries tells that the queries of a request are independent, each sent with its own
+// HTTP request.
+func (e *CloudMonitoringExecutor) ConcurrentQueries() bool {
+	return true
+}
+
 func (e *CloudMonitoringExecutor) getGCEDefaultProject(ctx context.Context, tsdbQuery *tsdb.TsdbQuery) (*tsdb.Response, error) {
 	result := &tsdb.Response{
 		Results: make(map[string]*tsdb.QueryResult),
@@ -761,7 +767,7 @@ func (e *CloudMonitoringExecutor) createRequest(ctx context.Context, dsInfo *mod
 	req.Header.Set("User-Agent", fmt.Sprintf("Grafana/%s", setting.BuildVersion))
 
 	// find plugin
-	plugin, ok := plugins.DataSources[dsInfo.Type]
+	plugin, ok := plugins.GetDataSource(dsInfo.Type)
 	if !ok {
 		return nil, errors.New("Unable to find datasource plugin CloudMonitoring")
 	}
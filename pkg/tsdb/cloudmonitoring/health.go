@@ -0,0 +1,41 @@
+package cloudmonitoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// CheckHealth lists a metric descriptor of the default project, like the test of the data
+// source in the frontend.
+func (e *CloudMonitoringExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) (*tsdb.HealthCheckResult, error) {
+	projectName, err := e.getDefaultProject(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if projectName == "" {
+		return nil, errors.New("no default project is set")
+	}
+
+	req, err := e.createRequest(ctx, dsInfo, nil, "cloudmonitoringv3/projects/"+projectName+"/metricDescriptors")
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = "pageSize=1"
+
+	res, err := ctxhttp.Do(ctx, e.httpClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("Request failed status: %v", res.Status)
+	}
+
+	return tsdb.HealthOk("Successfully queried the Google Cloud Monitoring API."), nil
+}
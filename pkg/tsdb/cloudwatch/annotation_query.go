@@ -3,15 +3,22 @@ package cloudwatch
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/tsdb"
 	"github.com/grafana/grafana/pkg/util/errutil"
 )
 
+const (
+	metricAlarmType    = "metric"
+	compositeAlarmType = "composite"
+)
+
 func (e *CloudWatchExecutor) executeAnnotationQuery(ctx context.Context, queryContext *tsdb.TsdbQuery) (*tsdb.Response, error) {
 	result := &tsdb.Response{
 		Results: make(map[string]*tsdb.QueryResult),
@@ -35,30 +42,25 @@ func (e *CloudWatchExecutor) executeAnnotationQuery(ctx context.Context, queryCo
 	}
 	actionPrefix := parameters.Get("actionPrefix").MustString("")
 	alarmNamePrefix := parameters.Get("alarmNamePrefix").MustString("")
+	alarmType := parameters.Get("alarmType").MustString(metricAlarmType)
+	if alarmType != metricAlarmType && alarmType != compositeAlarmType {
+		return nil, fmt.Errorf("invalid alarm type %q", alarmType)
+	}
 
-	svc, err := e.getClient(region)
+	// several regions are queried at once when the query has regions, the region is then added to the
+	// tags of the annotations
+	regions, err := e.expandRegions(queryRegions(region, parameters.Get("regions").MustStringArray()))
 	if err != nil {
 		return nil, err
 	}
+	multiRegion := len(regions) > 1
 
-	var alarmNames []*string
-	if usePrefixMatch {
-		params := &cloudwatch.DescribeAlarmsInput{
-			MaxRecords:      aws.Int64(100),
-			ActionPrefix:    aws.String(actionPrefix),
-			AlarmNamePrefix: aws.String(alarmNamePrefix),
-		}
-		resp, err := svc.DescribeAlarms(params)
-		if err != nil {
-			return nil, errutil.Wrap("failed to call cloudwatch:DescribeAlarms", err)
-		}
-		alarmNames = filterAlarms(resp, namespace, metricName, dimensions, statistics, period)
-	} else {
-		if region == "" || namespace == "" || metricName == "" || len(statistics) == 0 {
+	var qd []*cloudwatch.Dimension
+	if alarmType == metricAlarmType && !usePrefixMatch {
+		if len(regions) == 0 || namespace == "" || metricName == "" || len(statistics) == 0 {
 			return result, errors.New("invalid annotations query")
 		}
 
-		var qd []*cloudwatch.Dimension
 		for k, v := range dimensions {
 			if vv, ok := v.([]interface{}); ok {
 				for _, vvv := range vv {
@@ -71,22 +73,6 @@ func (e *CloudWatchExecutor) executeAnnotationQuery(ctx context.Context, queryCo
 				}
 			}
 		}
-		for _, s := range statistics {
-			params := &cloudwatch.DescribeAlarmsForMetricInput{
-				Namespace:  aws.String(namespace),
-				MetricName: aws.String(metricName),
-				Dimensions: qd,
-				Statistic:  aws.String(s),
-				Period:     aws.Int64(period),
-			}
-			resp, err := svc.DescribeAlarmsForMetric(params)
-			if err != nil {
-				return nil, errutil.Wrap("failed to call cloudwatch:DescribeAlarmsForMetric", err)
-			}
-			for _, alarm := range resp.MetricAlarms {
-				alarmNames = append(alarmNames, alarm.AlarmName)
-			}
-		}
 	}
 
 	startTime, err := queryContext.TimeRange.ParseFrom()
@@ -98,6 +84,96 @@ func (e *CloudWatchExecutor) executeAnnotationQuery(ctx context.Context, queryCo
 		return nil, err
 	}
 
+	annotations := make([]map[string]string, 0)
+	for _, region := range regions {
+		svc, err := e.getClient(region)
+		if err != nil {
+			return nil, err
+		}
+
+		var alarmNames []*string
+		switch {
+		case alarmType == compositeAlarmType:
+			alarmNames, err = describeCompositeAlarmNames(svc, actionPrefix, alarmNamePrefix)
+			if err != nil {
+				return nil, err
+			}
+		case usePrefixMatch:
+			params := &cloudwatch.DescribeAlarmsInput{
+				MaxRecords:      aws.Int64(100),
+				ActionPrefix:    aws.String(actionPrefix),
+				AlarmNamePrefix: aws.String(alarmNamePrefix),
+			}
+			resp, err := svc.DescribeAlarms(params)
+			if err != nil {
+				return nil, errutil.Wrap("failed to call cloudwatch:DescribeAlarms", err)
+			}
+			alarmNames = filterAlarms(resp, namespace, metricName, dimensions, statistics, period)
+		default:
+			for _, s := range statistics {
+				params := &cloudwatch.DescribeAlarmsForMetricInput{
+					Namespace:  aws.String(namespace),
+					MetricName: aws.String(metricName),
+					Dimensions: qd,
+					Statistic:  aws.String(s),
+					Period:     aws.Int64(period),
+				}
+				resp, err := svc.DescribeAlarmsForMetric(params)
+				if err != nil {
+					return nil, errutil.Wrap("failed to call cloudwatch:DescribeAlarmsForMetric", err)
+				}
+				for _, alarm := range resp.MetricAlarms {
+					alarmNames = append(alarmNames, alarm.AlarmName)
+				}
+			}
+		}
+
+		regionTag := ""
+		if multiRegion {
+			regionTag = region
+		}
+		regionAnnotations, err := alarmHistoryAnnotations(svc, alarmNames, alarmType, startTime, endTime, regionTag)
+		if err != nil {
+			return nil, err
+		}
+		annotations = append(annotations, regionAnnotations...)
+	}
+
+	transformAnnotationToTable(annotations, queryResult)
+	result.Results[firstQuery.RefId] = queryResult
+	return result, err
+}
+
+// describeCompositeAlarmNames returns the names of the composite alarms matching the action and alarm name
+// prefixes. Composite alarms don't have metrics, so the metric of the query doesn't filter them.
+func describeCompositeAlarmNames(svc cloudwatchiface.CloudWatchAPI, actionPrefix string, alarmNamePrefix string) ([]*string, error) {
+	params := &cloudwatch.DescribeAlarmsInput{
+		AlarmTypes: aws.StringSlice([]string{cloudwatch.AlarmTypeCompositeAlarm}),
+		MaxRecords: aws.Int64(100),
+	}
+	if actionPrefix != "" {
+		params.ActionPrefix = aws.String(actionPrefix)
+	}
+	if alarmNamePrefix != "" {
+		params.AlarmNamePrefix = aws.String(alarmNamePrefix)
+	}
+
+	var alarmNames []*string
+	err := svc.DescribeAlarmsPages(params, func(page *cloudwatch.DescribeAlarmsOutput, lastPage bool) bool {
+		for _, alarm := range page.CompositeAlarms {
+			alarmNames = append(alarmNames, alarm.AlarmName)
+		}
+		return !lastPage
+	})
+	if err != nil {
+		return nil, errutil.Wrap("failed to call cloudwatch:DescribeAlarms", err)
+	}
+	return alarmNames, nil
+}
+
+// alarmHistoryAnnotations returns the history of alarms as annotations. The history of composite alarms
+// is only returned when asked for.
+func alarmHistoryAnnotations(svc cloudwatchiface.CloudWatchAPI, alarmNames []*string, alarmType string, startTime time.Time, endTime time.Time, region string) ([]map[string]string, error) {
 	annotations := make([]map[string]string, 0)
 	for _, alarmName := range alarmNames {
 		params := &cloudwatch.DescribeAlarmHistoryInput{
@@ -106,6 +182,9 @@ func (e *CloudWatchExecutor) executeAnnotationQuery(ctx context.Context, queryCo
 			EndDate:    aws.Time(endTime),
 			MaxRecords: aws.Int64(100),
 		}
+		if alarmType == compositeAlarmType {
+			params.AlarmTypes = aws.StringSlice([]string{cloudwatch.AlarmTypeCompositeAlarm})
+		}
 		resp, err := svc.DescribeAlarmHistory(params)
 		if err != nil {
 			return nil, errutil.Wrap("failed to call cloudwatch:DescribeAlarmHistory", err)
@@ -116,31 +195,31 @@ func (e *CloudWatchExecutor) executeAnnotationQuery(ctx context.Context, queryCo
 			annotation["title"] = *history.AlarmName
 			annotation["tags"] = *history.HistoryItemType
 			annotation["text"] = *history.HistorySummary
+			annotation["region"] = region
 			annotations = append(annotations, annotation)
 		}
 	}
-
-	transformAnnotationToTable(annotations, queryResult)
-	result.Results[firstQuery.RefId] = queryResult
-	return result, err
+	return annotations, nil
 }
 
 func transformAnnotationToTable(data []map[string]string, result *tsdb.QueryResult) {
 	table := &tsdb.Table{
-		Columns: make([]tsdb.TableColumn, 4),
+		Columns: make([]tsdb.TableColumn, 5),
 		Rows:    make([]tsdb.RowValues, 0),
 	}
 	table.Columns[0].Text = "time"
 	table.Columns[1].Text = "title"
 	table.Columns[2].Text = "tags"
 	table.Columns[3].Text = "text"
+	table.Columns[4].Text = "region"
 
 	for _, r := range data {
-		values := make([]interface{}, 4)
+		values := make([]interface{}, 5)
 		values[0] = r["time"]
 		values[1] = r["title"]
 		values[2] = r["tags"]
 		values[3] = r["text"]
+		values[4] = r["region"]
 		table.Rows = append(table.Rows, values)
 	}
 	result.Tables = append(result.Tables, table)
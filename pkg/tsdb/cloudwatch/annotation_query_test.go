@@ -0,0 +1,98 @@
+package cloudwatch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAlarmsClient struct {
+	cloudwatchiface.CloudWatchAPI
+
+	compositeAlarmPages [][]*cloudwatch.CompositeAlarm
+	history             map[string][]*cloudwatch.AlarmHistoryItem
+
+	describeAlarmsInputs []*cloudwatch.DescribeAlarmsInput
+	historyInputs        []*cloudwatch.DescribeAlarmHistoryInput
+}
+
+func (c *fakeAlarmsClient) DescribeAlarmsPages(input *cloudwatch.DescribeAlarmsInput, fn func(*cloudwatch.DescribeAlarmsOutput, bool) bool) error {
+	c.describeAlarmsInputs = append(c.describeAlarmsInputs, input)
+	for i, alarms := range c.compositeAlarmPages {
+		if !fn(&cloudwatch.DescribeAlarmsOutput{CompositeAlarms: alarms}, i == len(c.compositeAlarmPages)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (c *fakeAlarmsClient) DescribeAlarmHistory(input *cloudwatch.DescribeAlarmHistoryInput) (*cloudwatch.DescribeAlarmHistoryOutput, error) {
+	c.historyInputs = append(c.historyInputs, input)
+	return &cloudwatch.DescribeAlarmHistoryOutput{AlarmHistoryItems: c.history[*input.AlarmName]}, nil
+}
+
+func TestAnnotationQuery(t *testing.T) {
+	t.Run("Composite alarms are described across pages", func(t *testing.T) {
+		client := &fakeAlarmsClient{
+			compositeAlarmPages: [][]*cloudwatch.CompositeAlarm{
+				{{AlarmName: aws.String("service-down")}},
+				{{AlarmName: aws.String("service-degraded")}},
+			},
+		}
+
+		alarmNames, err := describeCompositeAlarmNames(client, "", "service-")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"service-down", "service-degraded"}, aws.StringValueSlice(alarmNames))
+
+		require.Len(t, client.describeAlarmsInputs, 1)
+		input := client.describeAlarmsInputs[0]
+		assert.Equal(t, []string{cloudwatch.AlarmTypeCompositeAlarm}, aws.StringValueSlice(input.AlarmTypes))
+		assert.Equal(t, "service-", aws.StringValue(input.AlarmNamePrefix))
+		assert.Nil(t, input.ActionPrefix)
+	})
+
+	t.Run("History of composite alarms is tagged with the region", func(t *testing.T) {
+		timestamp := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+		client := &fakeAlarmsClient{
+			history: map[string][]*cloudwatch.AlarmHistoryItem{
+				"service-down": {{
+					AlarmName:       aws.String("service-down"),
+					HistoryItemType: aws.String("StateUpdate"),
+					HistorySummary:  aws.String("Alarm updated from OK to ALARM"),
+					Timestamp:       aws.Time(timestamp),
+				}},
+			},
+		}
+
+		annotations, err := alarmHistoryAnnotations(client, aws.StringSlice([]string{"service-down"}), compositeAlarmType,
+			timestamp.Add(-time.Hour), timestamp.Add(time.Hour), "eu-west-1")
+		require.NoError(t, err)
+		assert.Equal(t, []map[string]string{{
+			"time":   "2020-06-01T10:00:00Z",
+			"title":  "service-down",
+			"tags":   "StateUpdate",
+			"text":   "Alarm updated from OK to ALARM",
+			"region": "eu-west-1",
+		}}, annotations)
+
+		require.Len(t, client.historyInputs, 1)
+		assert.Equal(t, []string{cloudwatch.AlarmTypeCompositeAlarm}, aws.StringValueSlice(client.historyInputs[0].AlarmTypes))
+	})
+
+	t.Run("History of metric alarms is described without alarm types", func(t *testing.T) {
+		client := &fakeAlarmsClient{}
+
+		annotations, err := alarmHistoryAnnotations(client, aws.StringSlice([]string{"cpu-high"}), metricAlarmType,
+			time.Now().Add(-time.Hour), time.Now(), "")
+		require.NoError(t, err)
+		assert.Empty(t, annotations)
+
+		require.Len(t, client.historyInputs, 1)
+		assert.Nil(t, client.historyInputs[0].AlarmTypes)
+	})
+}
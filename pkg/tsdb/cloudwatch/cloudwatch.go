@@ -2,17 +2,14 @@ package cloudwatch
 
 import (
 	"context"
-	"fmt"
 	"regexp"
 	"sync"
 	"time"
 
-	"github.com/grafana/grafana-plugin-sdk-go/data"
-
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
-	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/tsdb"
@@ -28,12 +25,42 @@ type CloudWatchExecutor struct {
 }
 
 type DatasourceInfo struct {
-	Profile       string
-	Region        string
-	AuthType      string
-	AssumeRoleArn string
-	ExternalID    string
-	Namespace     string
+	// DatasourceID is the id of the data source, whose cached credentials are dropped when it's
+	// updated or deleted
+	DatasourceID int64
+
+	Profile            string
+	Region             string
+	AuthType           string
+	AssumeRoleArn      string
+	AssumeRoleDuration time.Duration
+	ExternalID         string
+	Namespace          string
+
+	// IntermediateRoleArn is a role assumed before AssumeRoleArn, for the target role to trust
+	// it instead of the credentials of Grafana
+	IntermediateRoleArn        string
+	IntermediateRoleExternalID string
+
+	// WebIdentityRoleArn and WebIdentityTokenFile give the data source its own web identity, such
+	// as an IAM role for a service account on EKS, instead of the one of the Grafana process
+	WebIdentityRoleArn   string
+	WebIdentityTokenFile string
+
+	// STSEndpoint is the endpoint of AWS STS, like a VPC endpoint, when not the one of the region
+	STSEndpoint string
+
+	// UseFIPSEndpoint makes the data source call the FIPS endpoints of STS, CloudWatch and
+	// CloudWatch Logs
+	UseFIPSEndpoint bool
+
+	// Endpoint replaces the endpoints of the region for the CloudWatch, CloudWatch Logs, EC2 and
+	// Resource Groups Tagging API calls, like a proxy or localstack
+	Endpoint string
+
+	// MaxConcurrentCalls is the most AWS API calls in flight at once of the data source, 0 for no
+	// limit
+	MaxConcurrentCalls int
 
 	AccessKey string
 	SecretKey string
@@ -53,7 +80,10 @@ func (e *CloudWatchExecutor) getLogsClient(region string) (*cloudwatchlogs.Cloud
 		return logsClient, nil
 	}
 
-	dsInfo := retrieveDsInfo(e.DataSource, region)
+	dsInfo, err := retrieveDsInfo(e.DataSource, region)
+	if err != nil {
+		return nil, err
+	}
 	newLogsClient, err := retrieveLogsClient(dsInfo)
 
 	if err != nil {
@@ -66,7 +96,10 @@ func (e *CloudWatchExecutor) getLogsClient(region string) (*cloudwatchlogs.Cloud
 }
 
 func NewCloudWatchExecutor(datasource *models.DataSource) (tsdb.TsdbQueryEndpoint, error) {
-	dsInfo := retrieveDsInfo(datasource, "default")
+	dsInfo, err := retrieveDsInfo(datasource, "default")
+	if err != nil {
+		return nil, err
+	}
 	defaultLogsClient, err := retrieveLogsClient(dsInfo)
 
 	if err != nil {
@@ -87,60 +120,19 @@ var aliasFormat = regexp.MustCompile(`\{\{\s*(.+?)\s*\}\}`)
 
 func init() {
 	tsdb.RegisterTsdbQueryEndpoint("cloudwatch", NewCloudWatchExecutor)
-}
-
-func (e *CloudWatchExecutor) alertQuery(ctx context.Context, logsClient *cloudwatchlogs.CloudWatchLogs, queryContext *tsdb.TsdbQuery) (*cloudwatchlogs.GetQueryResultsOutput, error) {
-	const maxAttempts = 8
-	const pollPeriod = 1000 * time.Millisecond
-
-	queryParams := queryContext.Queries[0].Model
-	startQueryOutput, err := e.executeStartQuery(ctx, logsClient, queryParams, queryContext.TimeRange)
-	if err != nil {
-		return nil, err
-	}
-
-	requestParams := simplejson.NewFromAny(map[string]interface{}{
-		"region":  queryParams.Get("region").MustString(""),
-		"queryId": *startQueryOutput.QueryId,
-	})
-
-	ticker := time.NewTicker(pollPeriod)
-	defer ticker.Stop()
-
-	attemptCount := 1
-	for range ticker.C {
-		res, err := e.executeGetQueryResults(ctx, logsClient, requestParams)
-		if err != nil {
-			return nil, err
-		}
-		if isTerminated(*res.Status) {
-			return res, err
-		}
-		if attemptCount >= maxAttempts {
-			return res, fmt.Errorf("fetching of query results exceeded max number of attempts")
-		}
-
-		attemptCount++
-	}
-
-	return nil, nil
+	bus.AddEventListener(handleDataSourceUpdated)
+	bus.AddEventListener(handleDataSourceDeleted)
+	bus.AddHandler("cloudwatch", flushCredentialCache)
 }
 
 func (e *CloudWatchExecutor) Query(ctx context.Context, dsInfo *models.DataSource, queryContext *tsdb.TsdbQuery) (*tsdb.Response, error) {
 	e.DataSource = dsInfo
 
-	/*
-		Unlike many other data sources,	with Cloudwatch Logs query requests don't receive the results as the response to the query, but rather
-		an ID is first returned. Following this, a client is expected to send requests along with the ID until the status of the query is complete,
-		receiving (possibly partial) results each time. For queries made via dashboards and Explore, the logic of making these repeated queries is handled on
-		the frontend, but because alerts are executed on the backend the logic needs to be reimplemented here.
-	*/
+	// The frontend runs the Logs Insights queries of dashboards and Explore step by step with log
+	// actions, the other ones, like the queries of alerts, are run here until they're done
 	queryParams := queryContext.Queries[0].Model
-	_, fromAlert := queryContext.Headers["FromAlert"]
-	isLogAlertQuery := fromAlert && queryParams.Get("queryMode").MustString("") == "Logs"
-
-	if isLogAlertQuery {
-		return e.executeLogAlertQuery(ctx, queryContext)
+	if isLogsQuery(queryParams) {
+		return e.executeLogsQuery(ctx, queryContext)
 	}
 
 	queryType := queryParams.Get("type").MustString("")
@@ -163,70 +155,6 @@ func (e *CloudWatchExecutor) Query(ctx context.Context, dsInfo *models.DataSourc
 	return result, err
 }
 
-func (e *CloudWatchExecutor) executeLogAlertQuery(ctx context.Context, queryContext *tsdb.TsdbQuery) (*tsdb.Response, error) {
-	queryParams := queryContext.Queries[0].Model
-	queryParams.Set("subtype", "StartQuery")
-	queryParams.Set("queryString", queryParams.Get("expression").MustString(""))
-
-	region := queryParams.Get("region").MustString("default")
-	if region == "default" {
-		region = e.DataSource.JsonData.Get("defaultRegion").MustString()
-		queryParams.Set("region", region)
-	}
-
-	logsClient, err := e.getLogsClient(region)
-	if err != nil {
-		return nil, err
-	}
-
-	result, err := e.executeStartQuery(ctx, logsClient, queryParams, queryContext.TimeRange)
-	if err != nil {
-		return nil, err
-	}
-
-	queryParams.Set("queryId", *result.QueryId)
-
-	// Get query results
-	getQueryResultsOutput, err := e.alertQuery(ctx, logsClient, queryContext)
-	if err != nil {
-		return nil, err
-	}
-
-	dataframe, err := logsResultsToDataframes(getQueryResultsOutput)
-	if err != nil {
-		return nil, err
-	}
-
-	statsGroups := queryParams.Get("statsGroups").MustStringArray()
-	if len(statsGroups) > 0 && len(dataframe.Fields) > 0 {
-		groupedFrames, err := groupResults(dataframe, statsGroups)
-		if err != nil {
-			return nil, err
-		}
-
-		response := &tsdb.Response{
-			Results: make(map[string]*tsdb.QueryResult),
-		}
-
-		response.Results["A"] = &tsdb.QueryResult{
-			RefId:      "A",
-			Dataframes: tsdb.NewDecodedDataFrames(groupedFrames),
-		}
-
-		return response, nil
-	}
-
-	response := &tsdb.Response{
-		Results: map[string]*tsdb.QueryResult{
-			"A": {
-				RefId:      "A",
-				Dataframes: tsdb.NewDecodedDataFrames(data.Frames{dataframe}),
-			},
-		},
-	}
-	return response, nil
-}
-
 func isTerminated(queryStatus string) bool {
 	return queryStatus == "Complete" || queryStatus == "Cancelled" || queryStatus == "Failed" || queryStatus == "Timeout"
 }
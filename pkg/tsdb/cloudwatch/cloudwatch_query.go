@@ -1,9 +1,17 @@
 package cloudwatch
 
 import (
+	"regexp"
 	"strings"
 )
 
+var (
+	// expressionStrings matches the strings of a math expression, like the label of METRICS("label")
+	expressionStrings = regexp.MustCompile(`"[^"]*"|'[^']*'`)
+	// expressionIDs matches the query IDs referenced by a math expression, as the functions are in upper case
+	expressionIDs = regexp.MustCompile(`\b[a-z][a-zA-Z0-9_]*\b`)
+)
+
 type cloudWatchQuery struct {
 	RefId                   string
 	Region                  string
@@ -19,12 +27,36 @@ type cloudWatchQuery struct {
 	MatchExact              bool
 	UsedExpression          string
 	RequestExceededMaxLimit bool
+	// AccountId is the source account of the metrics, when they're queried from a monitoring account
+	AccountId string
+	// MultiRegion tells whether the query row is sent to several regions
+	MultiRegion bool
 }
 
 func (q *cloudWatchQuery) isMathExpression() bool {
 	return q.Expression != "" && !q.isUserDefinedSearchExpression()
 }
 
+// isAnomalyDetectionBandExpression tells whether a math expression returns the upper and lower
+// series of an anomaly detection band
+func (q *cloudWatchQuery) isAnomalyDetectionBandExpression() bool {
+	return q.isMathExpression() && strings.Contains(expressionStrings.ReplaceAllString(q.Expression, ""), "ANOMALY_DETECTION_BAND(")
+}
+
+// referencedIDs returns the IDs of the queries referenced by a math expression
+func (q *cloudWatchQuery) referencedIDs() []string {
+	expression := expressionStrings.ReplaceAllString(q.Expression, "")
+	ids := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, id := range expressionIDs.FindAllString(expression, -1) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
 func (q *cloudWatchQuery) isSearchExpression() bool {
 	return q.isUserDefinedSearchExpression() || q.isInferredSearchExpression()
 }
@@ -0,0 +1,93 @@
+package cloudwatch
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// callLimiter caps the AWS API calls in flight of a data source, so that a heavy dashboard can't
+// use all the API quota of the account, which the alerts and the other dashboards share
+type callLimiter struct {
+	slots chan struct{}
+}
+
+var (
+	callLimitersLock sync.Mutex
+	// callLimiters are the limiters of the data sources by id, shared by their executors as
+	// there's one per query
+	callLimiters = make(map[int64]*callLimiter)
+)
+
+// getCallLimiter returns the limiter of a data source, a new one when its limit changed
+func getCallLimiter(datasourceID int64, limit int) *callLimiter {
+	callLimitersLock.Lock()
+	defer callLimitersLock.Unlock()
+
+	if limiter, ok := callLimiters[datasourceID]; ok && cap(limiter.slots) == limit {
+		return limiter
+	}
+	limiter := &callLimiter{slots: make(chan struct{}, limit)}
+	callLimiters[datasourceID] = limiter
+	return limiter
+}
+
+func evictCallLimiter(datasourceID int64) {
+	callLimitersLock.Lock()
+	defer callLimitersLock.Unlock()
+
+	delete(callLimiters, datasourceID)
+}
+
+// limitedHTTPClient returns the HTTP client of the AWS clients of a data source, whose calls wait
+// for a slot of the limiter of the data source
+func limitedHTTPClient(dsInfo *DatasourceInfo) *http.Client {
+	return &http.Client{
+		Transport: &limitedTransport{
+			next:    awsHTTPClient.Transport,
+			limiter: getCallLimiter(dsInfo.DatasourceID, dsInfo.MaxConcurrentCalls),
+		},
+	}
+}
+
+// limitedTransport holds a slot of its limiter from the start of a call until the body of its
+// response is closed. The retries of the throttled calls release their slot while they wait.
+type limitedTransport struct {
+	next    http.RoundTripper
+	limiter *callLimiter
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	select {
+	case t.limiter.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	release := t.limiter.release()
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	res.Body = &releasingBody{ReadCloser: res.Body, release: release}
+	return res, nil
+}
+
+// release returns a function releasing a slot once
+func (l *callLimiter) release() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-l.slots })
+	}
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,90 @@
+package cloudwatch
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallLimiter(t *testing.T) {
+	t.Run("Calls of a data source wait for a slot", func(t *testing.T) {
+		var mu sync.Mutex
+		inFlight, maxInFlight := 0, 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			_, _ = w.Write([]byte("ok"))
+		}))
+		t.Cleanup(server.Close)
+		t.Cleanup(func() { evictCallLimiter(1) })
+
+		client := limitedHTTPClient(&DatasourceInfo{DatasourceID: 1, MaxConcurrentCalls: 2})
+		var wg sync.WaitGroup
+		for i := 0; i < 6; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res, err := client.Get(server.URL)
+				if assert.NoError(t, err) {
+					_, _ = ioutil.ReadAll(res.Body)
+					res.Body.Close()
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 2, maxInFlight)
+	})
+
+	t.Run("Waiting calls are canceled with their context", func(t *testing.T) {
+		t.Cleanup(func() { evictCallLimiter(2) })
+		limiter := getCallLimiter(2, 1)
+		limiter.slots <- struct{}{}
+
+		client := limitedHTTPClient(&DatasourceInfo{DatasourceID: 2, MaxConcurrentCalls: 1})
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost", nil)
+		require.NoError(t, err)
+		_, err = client.Do(req)
+		assert.Error(t, err)
+		assert.True(t, ctx.Err() != nil)
+	})
+
+	t.Run("Data sources share their limiter until their limit changes", func(t *testing.T) {
+		t.Cleanup(func() { evictCallLimiter(3) })
+		limiter := getCallLimiter(3, 5)
+		assert.Same(t, limiter, getCallLimiter(3, 5))
+		assert.NotSame(t, limiter, getCallLimiter(3, 10))
+		assert.NotSame(t, limiter, getCallLimiter(4, 5))
+		evictCallLimiter(4)
+	})
+
+	t.Run("The limit of a data source defaults to the one of the settings", func(t *testing.T) {
+		origMaxConcurrentCalls := setting.AWSMaxConcurrentCalls
+		setting.AWSMaxConcurrentCalls = 20
+		t.Cleanup(func() { setting.AWSMaxConcurrentCalls = origMaxConcurrentCalls })
+
+		assert.Equal(t, 5, parseMaxConcurrentCalls("5"))
+		assert.Equal(t, 0, parseMaxConcurrentCalls(0.0))
+		assert.Equal(t, 8, parseMaxConcurrentCalls(8))
+		for _, value := range []interface{}{nil, "", "many", -1} {
+			assert.Equal(t, 20, parseMaxConcurrentCalls(value))
+		}
+	})
+}
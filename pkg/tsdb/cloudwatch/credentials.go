@@ -1,214 +1,430 @@
 package cloudwatch
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
-	"os"
+	"net/url"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
-	"github.com/aws/aws-sdk-go/aws/credentials/endpointcreds"
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
-	"github.com/aws/aws-sdk-go/aws/defaults"
-	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go/service/sts"
-	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/awsauth"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
+	"golang.org/x/sync/singleflight"
 )
 
 type cache struct {
 	credential *credentials.Credentials
 	expiration *time.Time
+	// used is whether the credentials have been used since they were cached
+	used bool
 }
 
 var awsCredentialCache = make(map[string]cache)
 var credentialCacheLock sync.RWMutex
 
-// Session factory.
+// credentialCacheKeys are the keys of the cached credentials used by each data source, which
+// several data sources with the same settings share
+var credentialCacheKeys = make(map[int64]map[string]bool)
+
+// credentialCacheGeneration is incremented when cached credentials are dropped, so that the
+// credentials fetched with the old settings in the meantime aren't cached
+var credentialCacheGeneration int
+
+// Session factory. The clients of the sessions retry their throttled calls with the throttling
+// retryer, and fail with ErrThrottled once they are exhausted.
 // Stubbable by tests.
 var newSession = func(cfgs ...*aws.Config) (*session.Session, error) {
-	return session.NewSession(cfgs...)
+	sess, err := session.NewSession(append([]*aws.Config{withThrottlingRetries(&aws.Config{})}, cfgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	addThrottlingHandlers(&sess.Handlers)
+	return sess, nil
 }
 
-// STS service factory.
+// credentialsBuilder builds the credentials provider chains of the data sources.
 // Stubbable by tests.
-var newSTSService = func(p client.ConfigProvider, cfgs ...*aws.Config) stsiface.STSAPI {
-	return sts.New(p, cfgs...)
+var credentialsBuilder = newCredentialsBuilder()
+
+// awsHTTPClient is the HTTP client of the calls to AWS, blocked from the networks the data sources
+// are blocked from
+var awsHTTPClient = awsauth.NewHTTPClient(models.NetworkPolicyControl)
+
+// newCredentialsBuilder returns the builder of the credentials of the data sources, calling STS with
+// awsHTTPClient
+func newCredentialsBuilder() *awsauth.ChainBuilder {
+	b := awsauth.NewChainBuilder()
+	b.STSHTTPClient = awsHTTPClient
+	return b
 }
 
-// EC2Metadata service factory.
-// Stubbable by tests.
-var newEC2Metadata = func(p client.ConfigProvider, cfgs ...*aws.Config) *ec2metadata.EC2Metadata {
-	return ec2metadata.New(p, cfgs...)
+// defaultAssumeRoleDuration is the session duration of the assumed roles when the data source
+// doesn't set one
+const defaultAssumeRoleDuration = 15 * time.Minute
+
+// assumeRoleDuration returns the session duration of the role assumed by a data source, bounded
+// by what STS accepts and assume_role_max_duration.
+func assumeRoleDuration(requested time.Duration) time.Duration {
+	if requested <= 0 {
+		return defaultAssumeRoleDuration
+	}
+	if max := setting.AWSAssumeRoleMaxSessionDuration; max > 0 && requested > max {
+		requested = max
+	}
+	if requested < setting.AWSAssumeRoleMinDuration {
+		requested = setting.AWSAssumeRoleMinDuration
+	}
+	return requested
 }
 
+// credentialRefreshWindow is how long before they expire the cached credentials are refreshed in
+// the background
+const credentialRefreshWindow = time.Minute
+
+// credentialRefreshes makes the concurrent refreshes of the same credentials share a single call
+// to STS
+var credentialRefreshes singleflight.Group
+
 func getCredentials(dsInfo *DatasourceInfo) (*credentials.Credentials, error) {
-	cacheKey := fmt.Sprintf("%s:%s:%s:%s", dsInfo.AuthType, dsInfo.AccessKey, dsInfo.Profile, dsInfo.AssumeRoleArn)
-	credentialCacheLock.RLock()
-	if _, ok := awsCredentialCache[cacheKey]; ok {
-		if awsCredentialCache[cacheKey].expiration != nil &&
-			awsCredentialCache[cacheKey].expiration.After(time.Now().UTC()) {
-			result := awsCredentialCache[cacheKey].credential
-			credentialCacheLock.RUnlock()
-			return result, nil
-		}
+	if err := checkFIPSEndpoints(dsInfo); err != nil {
+		return nil, err
 	}
-	credentialCacheLock.RUnlock()
-
-	accessKeyID := ""
-	secretAccessKey := ""
-	sessionToken := ""
-	var expiration *time.Time = nil
-	if dsInfo.AuthType == "arn" {
-		params := &sts.AssumeRoleInput{
-			RoleArn:         aws.String(dsInfo.AssumeRoleArn),
-			RoleSessionName: aws.String("GrafanaSession"),
-			DurationSeconds: aws.Int64(900),
-		}
-		if dsInfo.ExternalID != "" {
-			params.ExternalId = aws.String(dsInfo.ExternalID)
-		}
 
-		stsSess, err := newSession()
-		if err != nil {
-			return nil, err
+	cacheKey := credentialCacheKey(dsInfo)
+	credentialCacheLock.Lock()
+	if dsInfo.DatasourceID != 0 {
+		if credentialCacheKeys[dsInfo.DatasourceID] == nil {
+			credentialCacheKeys[dsInfo.DatasourceID] = make(map[string]bool)
 		}
-		stsCreds := credentials.NewChainCredentials(
-			[]credentials.Provider{
-				&credentials.EnvProvider{},
-				&credentials.SharedCredentialsProvider{Filename: "", Profile: dsInfo.Profile},
-				webIdentityProvider(stsSess),
-				remoteCredProvider(stsSess),
-			})
-		stsConfig := &aws.Config{
-			Region:      aws.String(dsInfo.Region),
-			Credentials: stsCreds,
+		credentialCacheKeys[dsInfo.DatasourceID][cacheKey] = true
+	}
+	if entry, ok := awsCredentialCache[cacheKey]; ok {
+		if entry.expiration != nil && entry.expiration.After(time.Now().UTC()) {
+			entry.used = true
+			awsCredentialCache[cacheKey] = entry
+			credentialCacheLock.Unlock()
+			return entry.credential, nil
 		}
+	}
+	credentialCacheLock.Unlock()
 
-		sess, err := newSession(stsConfig)
+	return refreshCredentials(cacheKey, *dsInfo)
+}
+
+// credentialCacheKey identifies the credentials of a data source by all the settings they depend
+// on. The secret key is hashed, so that it isn't kept in the keys of the cache.
+func credentialCacheKey(dsInfo *DatasourceInfo) string {
+	secretKeyHash := sha256.Sum256([]byte(dsInfo.SecretKey))
+	return fmt.Sprintf("%s:%s:%x:%s:%s:%s:%s:%s:%s:%s:%s:%s:%t", dsInfo.AuthType, dsInfo.AccessKey, secretKeyHash,
+		dsInfo.Profile, dsInfo.WebIdentityRoleArn, dsInfo.WebIdentityTokenFile, dsInfo.IntermediateRoleArn,
+		dsInfo.IntermediateRoleExternalID, dsInfo.AssumeRoleArn, dsInfo.ExternalID,
+		assumeRoleDuration(dsInfo.AssumeRoleDuration), dsInfo.STSEndpoint, dsInfo.UseFIPSEndpoint)
+}
+
+// refreshCredentials gets new credentials for a data source and caches them
+func refreshCredentials(cacheKey string, dsInfo DatasourceInfo) (*credentials.Credentials, error) {
+	result, err, _ := credentialRefreshes.Do(cacheKey, func() (interface{}, error) {
+		credentialCacheLock.RLock()
+		generation := credentialCacheGeneration
+		credentialCacheLock.RUnlock()
+
+		creds, expiration, err := newCredentials(&dsInfo)
 		if err != nil {
 			return nil, err
 		}
-		svc := newSTSService(sess, stsConfig)
-		resp, err := svc.AssumeRole(params)
-		if err != nil {
-			return nil, err
+
+		credentialCacheLock.Lock()
+		if generation != credentialCacheGeneration {
+			credentialCacheLock.Unlock()
+			return creds, nil
 		}
-		if resp.Credentials != nil {
-			accessKeyID = *resp.Credentials.AccessKeyId
-			secretAccessKey = *resp.Credentials.SecretAccessKey
-			sessionToken = *resp.Credentials.SessionToken
-			expiration = resp.Credentials.Expiration
+		awsCredentialCache[cacheKey] = cache{
+			credential: creds,
+			expiration: expiration,
 		}
-	} else {
-		now := time.Now()
-		e := now.Add(5 * time.Minute)
-		expiration = &e
-	}
+		credentialCacheLock.Unlock()
 
-	sess, err := newSession()
+		scheduleCredentialRefresh(cacheKey, dsInfo, expiration)
+		return creds, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	creds := credentials.NewChainCredentials(
-		[]credentials.Provider{
-			&credentials.StaticProvider{Value: credentials.Value{
-				AccessKeyID:     accessKeyID,
-				SecretAccessKey: secretAccessKey,
-				SessionToken:    sessionToken,
-			}},
-			&credentials.EnvProvider{},
-			&credentials.StaticProvider{Value: credentials.Value{
-				AccessKeyID:     dsInfo.AccessKey,
-				SecretAccessKey: dsInfo.SecretKey,
-			}},
-			&credentials.SharedCredentialsProvider{Filename: "", Profile: dsInfo.Profile},
-			webIdentityProvider(sess),
-			remoteCredProvider(sess),
-		})
+	return result.(*credentials.Credentials), nil
+}
 
-	credentialCacheLock.Lock()
-	awsCredentialCache[cacheKey] = cache{
-		credential: creds,
-		expiration: expiration,
+// scheduleCredentialRefresh refreshes cached credentials in the background shortly before they
+// expire, so that the queries don't wait for STS. Only the credentials used since they were cached
+// are refreshed, the ones of the data sources no longer queried are dropped from the cache.
+func scheduleCredentialRefresh(cacheKey string, dsInfo DatasourceInfo, expiration *time.Time) {
+	if expiration == nil {
+		return
+	}
+	delay := time.Until(*expiration) - credentialRefreshWindow
+	if delay <= 0 {
+		return
 	}
-	credentialCacheLock.Unlock()
 
-	return creds, nil
+	time.AfterFunc(delay, func() {
+		credentialCacheLock.Lock()
+		entry, ok := awsCredentialCache[cacheKey]
+		if !ok || entry.expiration == nil || !entry.expiration.Equal(*expiration) {
+			// the credentials have been replaced in the meantime
+			credentialCacheLock.Unlock()
+			return
+		}
+		if !entry.used {
+			delete(awsCredentialCache, cacheKey)
+			credentialCacheLock.Unlock()
+			return
+		}
+		credentialCacheLock.Unlock()
+
+		if _, err := refreshCredentials(cacheKey, dsInfo); err != nil {
+			plog.Warn("Failed to refresh AWS credentials", "error", err)
+		}
+	})
 }
 
-func webIdentityProvider(sess *session.Session) credentials.Provider {
-	svc := newSTSService(sess)
+// evictDatasourceCredentials drops the cached credentials used by a data source, so that its new
+// settings are used by the next queries rather than the credentials of the old ones, and returns
+// how many were dropped
+func evictDatasourceCredentials(datasourceID int64) int {
+	credentialCacheLock.Lock()
+	defer credentialCacheLock.Unlock()
 
-	roleARN := os.Getenv("AWS_ROLE_ARN")
-	tokenFilepath := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
-	roleSessionName := os.Getenv("AWS_ROLE_SESSION_NAME")
-	return stscreds.NewWebIdentityRoleProvider(svc, roleARN, roleSessionName, tokenFilepath)
+	evicted := 0
+	for cacheKey := range credentialCacheKeys[datasourceID] {
+		if _, ok := awsCredentialCache[cacheKey]; ok {
+			delete(awsCredentialCache, cacheKey)
+			evicted++
+		}
+		// the next queries don't wait for a refresh in flight, done with the old settings
+		credentialRefreshes.Forget(cacheKey)
+	}
+	delete(credentialCacheKeys, datasourceID)
+	credentialCacheGeneration++
+	return evicted
 }
 
-func remoteCredProvider(sess *session.Session) credentials.Provider {
-	ecsCredURI := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI")
+// flushCredentialCache drops all the cached credentials
+func flushCredentialCache(cmd *models.FlushAWSCredentialsCacheCommand) error {
+	credentialCacheLock.Lock()
+	defer credentialCacheLock.Unlock()
 
-	if len(ecsCredURI) > 0 {
-		return ecsCredProvider(sess, ecsCredURI)
+	cmd.Result = len(awsCredentialCache)
+	for cacheKey := range awsCredentialCache {
+		credentialRefreshes.Forget(cacheKey)
 	}
-	return ec2RoleProvider(sess)
+	awsCredentialCache = make(map[string]cache)
+	credentialCacheKeys = make(map[int64]map[string]bool)
+	credentialCacheGeneration++
+	return nil
 }
 
-func ecsCredProvider(sess *session.Session, uri string) credentials.Provider {
-	const host = `169.254.170.2`
+func handleDataSourceUpdated(evt *events.DataSourceUpdated) error {
+	if evt.Type == models.DS_CLOUDWATCH {
+		evictDatasourceCredentials(evt.Id)
+		evictMetricsIndexes(evt.Id)
+	}
+	return nil
+}
 
-	d := defaults.Get()
-	return endpointcreds.NewProviderClient(
-		*d.Config,
-		d.Handlers,
-		fmt.Sprintf("http://%s%s", host, uri),
-		func(p *endpointcreds.Provider) { p.ExpiryWindow = 5 * time.Minute })
+func handleDataSourceDeleted(evt *events.DataSourceDeleted) error {
+	if evt.Type == models.DS_CLOUDWATCH {
+		evictDatasourceCredentials(evt.Id)
+		evictCallLimiter(evt.Id)
+		evictMetricsIndexes(evt.Id)
+	}
+	return nil
 }
 
-func ec2RoleProvider(sess *session.Session) credentials.Provider {
-	return &ec2rolecreds.EC2RoleProvider{Client: newEC2Metadata(sess), ExpiryWindow: 5 * time.Minute}
+// newCredentials returns new credentials for a data source, with the time they expire. The
+// credentials of the data sources which don't assume a role are cached for five minutes.
+func newCredentials(dsInfo *DatasourceInfo) (*credentials.Credentials, *time.Time, error) {
+	provider, expiration, err := credentialsBuilder.Build(context.Background(), authSettings(dsInfo))
+	if err != nil {
+		return nil, nil, err
+	}
+	if dsInfo.AuthType != awsauth.AuthTypeARN {
+		e := time.Now().Add(5 * time.Minute)
+		expiration = &e
+	}
+	return awsauth.NewV1Credentials(provider), expiration, nil
+}
+
+// authSettings returns the credentials settings of a data source. STS is called on the endpoint of
+// the data source if it has one, like a VPC endpoint, else on the FIPS endpoint of its region if it
+// uses FIPS endpoints, else on the endpoint of its region.
+func authSettings(dsInfo *DatasourceInfo) *awsauth.Settings {
+	s := &awsauth.Settings{
+		AuthType:                   dsInfo.AuthType,
+		Profile:                    dsInfo.Profile,
+		AccessKey:                  dsInfo.AccessKey,
+		SecretKey:                  dsInfo.SecretKey,
+		AssumeRoleArn:              dsInfo.AssumeRoleArn,
+		ExternalID:                 dsInfo.ExternalID,
+		AssumeRoleDuration:         assumeRoleDuration(dsInfo.AssumeRoleDuration),
+		IntermediateRoleArn:        dsInfo.IntermediateRoleArn,
+		IntermediateRoleExternalID: dsInfo.IntermediateRoleExternalID,
+		IntermediateRoleDuration:   setting.AWSAssumeRoleMinDuration,
+		WebIdentityRoleArn:         dsInfo.WebIdentityRoleArn,
+		WebIdentityTokenFile:       dsInfo.WebIdentityTokenFile,
+		Region:                     dsInfo.Region,
+		STSEndpoint:                dsInfo.STSEndpoint,
+	}
+	if s.STSEndpoint == "" && dsInfo.UseFIPSEndpoint {
+		endpoint, err := fipsEndpoint(sts.EndpointsID, fipsRegion(dsInfo))
+		if err != nil {
+			// getCredentials fails before STS is called in the regions without FIPS endpoints
+			plog.Warn("No FIPS endpoint for AWS STS", "region", dsInfo.Region, "error", err)
+		} else {
+			s.Region = fipsRegion(dsInfo)
+			s.STSEndpoint = endpoint
+		}
+	}
+	return s
 }
 
-func (e *CloudWatchExecutor) getDsInfo(region string) *DatasourceInfo {
+func (e *CloudWatchExecutor) getDsInfo(region string) (*DatasourceInfo, error) {
 	return retrieveDsInfo(e.DataSource, region)
 }
 
-func retrieveDsInfo(datasource *models.DataSource, region string) *DatasourceInfo {
-	defaultRegion := datasource.JsonData.Get("defaultRegion").MustString()
+// getRegion returns the region of the data source for a region of a query, which can be default
+func (e *CloudWatchExecutor) getRegion(region string) string {
+	return retrieveRegion(e.DataSource, region)
+}
+
+func retrieveRegion(datasource *models.DataSource, region string) string {
 	if region == "default" {
-		region = defaultRegion
+		return datasource.JsonData.Get("defaultRegion").MustString()
 	}
+	return region
+}
+
+func retrieveDsInfo(datasource *models.DataSource, region string) (*DatasourceInfo, error) {
+	region = retrieveRegion(datasource, region)
 
 	authType := datasource.JsonData.Get("authType").MustString()
 	assumeRoleArn := datasource.JsonData.Get("assumeRoleArn").MustString()
+	assumeRoleDuration := parseDuration(datasource.JsonData.Get("assumeRoleDuration").Interface())
 	externalID := datasource.JsonData.Get("externalId").MustString()
-	decrypted := datasource.DecryptedValues()
+	intermediateRoleArn := datasource.JsonData.Get("intermediateRoleArn").MustString()
+	intermediateRoleExternalID := datasource.JsonData.Get("intermediateRoleExternalId").MustString()
+	// the token file is read on the Grafana server, so only the provisioned data sources can have a web
+	// identity of their own, the others use the web identity of the server
+	var webIdentityRoleArn, webIdentityTokenFile string
+	if datasource.ReadOnly {
+		webIdentityRoleArn = datasource.JsonData.Get("webIdentityRoleArn").MustString()
+		webIdentityTokenFile = datasource.JsonData.Get("webIdentityTokenFile").MustString()
+	}
+	stsEndpoint := datasource.JsonData.Get("stsEndpoint").MustString()
+	if stsEndpoint != "" && !datasource.ReadOnly && !awsauth.IsAWSEndpoint(stsEndpoint) {
+		return nil, fmt.Errorf("invalid STS endpoint %q, only provisioned data sources can use endpoints other than the HTTPS endpoints of AWS", stsEndpoint)
+	}
+	useFIPSEndpoint := datasource.JsonData.Get("useFipsEndpoint").MustBool(setting.AWSUseFIPSEndpoint)
+	endpoint := datasource.JsonData.Get("endpoint").MustString()
+	if endpoint != "" && !datasource.ReadOnly && !awsauth.IsAWSEndpoint(endpoint) {
+		return nil, fmt.Errorf("invalid endpoint %q, only provisioned data sources can use endpoints other than the HTTPS endpoints of AWS", endpoint)
+	}
+	maxConcurrentCalls := parseMaxConcurrentCalls(datasource.JsonData.Get("maxConcurrentCalls").Interface())
+	decrypted, err := datasource.DecryptedValues()
+	if err != nil {
+		return nil, err
+	}
 	accessKey := decrypted["accessKey"]
 	secretKey := decrypted["secretKey"]
 
 	datasourceInfo := &DatasourceInfo{
-		Region:        region,
-		Profile:       datasource.Database,
-		AuthType:      authType,
-		AssumeRoleArn: assumeRoleArn,
-		ExternalID:    externalID,
-		AccessKey:     accessKey,
-		SecretKey:     secretKey,
+		DatasourceID:       datasource.Id,
+		Region:             region,
+		Profile:            datasource.Database,
+		AuthType:           authType,
+		AssumeRoleArn:      assumeRoleArn,
+		AssumeRoleDuration: assumeRoleDuration,
+		ExternalID:         externalID,
+		AccessKey:          accessKey,
+		SecretKey:          secretKey,
+
+		IntermediateRoleArn:        intermediateRoleArn,
+		IntermediateRoleExternalID: intermediateRoleExternalID,
+		WebIdentityRoleArn:         webIdentityRoleArn,
+		WebIdentityTokenFile:       webIdentityTokenFile,
+		STSEndpoint:                stsEndpoint,
+		UseFIPSEndpoint:            useFIPSEndpoint,
+		Endpoint:                   endpoint,
+		MaxConcurrentCalls:         maxConcurrentCalls,
+	}
+
+	return datasourceInfo, nil
+}
+
+// parseMaxConcurrentCalls parses the maxConcurrentCalls of a data source, a number or a string of
+// the config page. The limit of [aws] is used when it's not set or invalid.
+func parseMaxConcurrentCalls(value interface{}) int {
+	var limit int64 = -1
+	switch v := value.(type) {
+	case string:
+		if n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+			limit = n
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			limit = n
+		}
+	case float64:
+		limit = int64(v)
+	case int:
+		limit = int64(v)
+	}
+	if limit < 0 {
+		return setting.AWSMaxConcurrentCalls
 	}
+	return int(limit)
+}
 
-	return datasourceInfo
+// parseDuration parses a duration of the jsonData of a data source, like assumeRoleDuration, either a
+// duration like 1h or a number of seconds. Invalid durations are 0, the default being used instead.
+func parseDuration(value interface{}) time.Duration {
+	switch v := value.(type) {
+	case string:
+		if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+		if duration, err := time.ParseDuration(v); err == nil {
+			return duration
+		}
+	case json.Number:
+		if seconds, err := v.Int64(); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+	case float64:
+		return time.Duration(v) * time.Second
+	case int:
+		return time.Duration(v) * time.Second
+	case int64:
+		return time.Duration(v) * time.Second
+	}
+	return 0
 }
 
-func getAwsConfig(dsInfo *DatasourceInfo) (*aws.Config, error) {
+// GetAwsConfig returns the AWS config for the region and credentials in dsInfo,
+// using the same credential chain as the CloudWatch data source. The calls of the
+// clients of a data source with a limit of concurrent calls share its limit.
+func GetAwsConfig(dsInfo *DatasourceInfo) (*aws.Config, error) {
 	creds, err := getCredentials(dsInfo)
 	if err != nil {
 		return nil, err
@@ -217,18 +433,49 @@ func getAwsConfig(dsInfo *DatasourceInfo) (*aws.Config, error) {
 	cfg := &aws.Config{
 		Region:      aws.String(dsInfo.Region),
 		Credentials: creds,
+		HTTPClient:  awsHTTPClient,
+	}
+	if dsInfo.DatasourceID != 0 && dsInfo.MaxConcurrentCalls > 0 {
+		cfg.HTTPClient = limitedHTTPClient(dsInfo)
 	}
 
 	return cfg, nil
 }
 
+// setEndpoint sets the endpoint of a service in the config of its client, the endpoint of the data
+// source if it has one, otherwise the FIPS endpoint of the service if the data source uses them
+func setEndpoint(cfg *aws.Config, dsInfo *DatasourceInfo, service string) error {
+	if dsInfo.Endpoint == "" {
+		for _, fipsService := range fipsServices {
+			if service == fipsService {
+				return setFIPSEndpoint(cfg, dsInfo, service)
+			}
+		}
+		return nil
+	}
+
+	endpoint, err := url.Parse(dsInfo.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return fmt.Errorf("invalid endpoint %q, expected an http or https URL", dsInfo.Endpoint)
+	}
+	cfg.Endpoint = aws.String(dsInfo.Endpoint)
+	return nil
+}
+
 func (e *CloudWatchExecutor) getClient(region string) (*cloudwatch.CloudWatch, error) {
-	datasourceInfo := e.getDsInfo(region)
-	cfg, err := getAwsConfig(datasourceInfo)
+	datasourceInfo, err := e.getDsInfo(region)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := GetAwsConfig(datasourceInfo)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := setEndpoint(cfg, datasourceInfo, cloudwatch.EndpointsID); err != nil {
+		return nil, err
+	}
+
 	sess, err := newSession(cfg)
 	if err != nil {
 		return nil, err
@@ -244,11 +491,15 @@ func (e *CloudWatchExecutor) getClient(region string) (*cloudwatch.CloudWatch, e
 }
 
 func retrieveLogsClient(datasourceInfo *DatasourceInfo) (*cloudwatchlogs.CloudWatchLogs, error) {
-	cfg, err := getAwsConfig(datasourceInfo)
+	cfg, err := GetAwsConfig(datasourceInfo)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := setEndpoint(cfg, datasourceInfo, cloudwatchlogs.EndpointsID); err != nil {
+		return nil, err
+	}
+
 	sess, err := newSession(cfg)
 	if err != nil {
 		return nil, err
@@ -1,117 +1,100 @@
 package cloudwatch
 
 import (
-	"os"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/client"
-	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
-	"github.com/aws/aws-sdk-go/aws/credentials/endpointcreds"
-	"github.com/aws/aws-sdk-go/aws/ec2metadata"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/sts"
-	"github.com/aws/aws-sdk-go/service/sts/stsiface"
-	"github.com/golang/mock/gomock"
-	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mock_stsiface"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/awsauth"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestECSCredProvider(t *testing.T) {
-	os.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "/abc/123")
-	t.Cleanup(func() {
-		os.Unsetenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI")
-	})
-
-	sess, err := session.NewSession()
-	require.NoError(t, err)
-	provider := remoteCredProvider(sess)
-	require.NotNil(t, provider)
-
-	ecsProvider, ok := provider.(*endpointcreds.Provider)
-	require.NotNil(t, ecsProvider)
-	require.True(t, ok)
-
-	assert.Equal(t, "http://169.254.170.2/abc/123", ecsProvider.Client.Endpoint)
+// fakeSTSClient records the roles assumed by the data sources and returns the credentials of
+// assumeRole
+type fakeSTSClient struct {
+	mu         sync.Mutex
+	inputs     []*sts.AssumeRoleInput
+	assumeRole func(input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error)
 }
 
-func TestDefaultEC2RoleProvider(t *testing.T) {
-	sess, err := session.NewSession()
-	require.NoError(t, err)
-	provider := remoteCredProvider(sess)
-	require.NotNil(t, provider)
+func (c *fakeSTSClient) AssumeRole(ctx context.Context, input *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	c.mu.Lock()
+	c.inputs = append(c.inputs, input)
+	c.mu.Unlock()
+	return c.assumeRole(input)
+}
 
-	ec2Provider, ok := provider.(*ec2rolecreds.EC2RoleProvider)
-	require.NotNil(t, ec2Provider)
-	require.True(t, ok)
+func (c *fakeSTSClient) AssumeRoleWithWebIdentity(ctx context.Context, input *sts.AssumeRoleWithWebIdentityInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleWithWebIdentityOutput, error) {
+	return nil, errors.New("web identity not supported")
 }
 
-func TestGetCredentials_ARNAuthType(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	var stsMock *mock_stsiface.MockSTSAPI
+func (c *fakeSTSClient) calls() []*sts.AssumeRoleInput {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.inputs
+}
 
-	origNewSession := newSession
-	origNewSTSService := newSTSService
-	origNewEC2Metadata := newEC2Metadata
+// stubSTS makes the data sources call client rather than STS
+func stubSTS(t *testing.T, client *fakeSTSClient) {
+	orig := credentialsBuilder
 	t.Cleanup(func() {
-		newSession = origNewSession
-		newSTSService = origNewSTSService
-		newEC2Metadata = origNewEC2Metadata
+		credentialsBuilder = orig
 	})
-	newSession = func(cfgs ...*aws.Config) (*session.Session, error) {
-		return &session.Session{}, nil
+	credentialsBuilder = &awsauth.ChainBuilder{
+		NewSTSClient: func(*awsauth.Settings, awsv2.CredentialsProvider) awsauth.STSClient {
+			return client
+		},
 	}
-	newSTSService = func(p client.ConfigProvider, cfgs ...*aws.Config) stsiface.STSAPI {
-		return stsMock
-	}
-	newEC2Metadata = func(p client.ConfigProvider, cfgs ...*aws.Config) *ec2metadata.EC2Metadata {
-		return nil
+}
+
+func assumed(id string, expiration *time.Time) *sts.AssumeRoleOutput {
+	return &sts.AssumeRoleOutput{
+		Credentials: &types.Credentials{
+			AccessKeyId:     awsv2.String(id),
+			SecretAccessKey: awsv2.String(id + "-secret"),
+			SessionToken:    awsv2.String(id + "-token"),
+			Expiration:      expiration,
+		},
 	}
+}
 
+func TestGetCredentials_ARNAuthType(t *testing.T) {
 	t.Run("Without external ID", func(t *testing.T) {
-		stsMock = mock_stsiface.NewMockSTSAPI(ctrl)
-		stsMock.
-			EXPECT().
-			AssumeRole(gomock.Eq(&sts.AssumeRoleInput{
-				RoleArn:         aws.String(""),
-				DurationSeconds: aws.Int64(900),
-				RoleSessionName: aws.String("GrafanaSession"),
-			})).
-			Return(&sts.AssumeRoleOutput{
-				Credentials: &sts.Credentials{
-					AccessKeyId:     aws.String("id"),
-					SecretAccessKey: aws.String("secret"),
-					SessionToken:    aws.String("token"),
-				},
-			}, nil).
-			Times(1)
+		client := &fakeSTSClient{assumeRole: func(*sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+			return assumed("id", nil), nil
+		}}
+		stubSTS(t, client)
 
 		creds, err := getCredentials(&DatasourceInfo{
 			AuthType: "arn",
 		})
 		require.NoError(t, err)
 		require.NotNil(t, creds)
+		assert.Equal(t, []*sts.AssumeRoleInput{{
+			RoleArn:         awsv2.String(""),
+			DurationSeconds: awsv2.Int32(900),
+			RoleSessionName: awsv2.String("GrafanaSession"),
+		}}, client.calls())
 	})
 
 	t.Run("With external ID", func(t *testing.T) {
-		stsMock = mock_stsiface.NewMockSTSAPI(ctrl)
-		stsMock.
-			EXPECT().
-			AssumeRole(gomock.Eq(&sts.AssumeRoleInput{
-				RoleArn:         aws.String(""),
-				DurationSeconds: aws.Int64(900),
-				RoleSessionName: aws.String("GrafanaSession"),
-				ExternalId:      aws.String("external-id"),
-			})).
-			Return(&sts.AssumeRoleOutput{
-				Credentials: &sts.Credentials{
-					AccessKeyId:     aws.String("id"),
-					SecretAccessKey: aws.String("secret"),
-					SessionToken:    aws.String("token"),
-				},
-			}, nil).
-			Times(1)
+		client := &fakeSTSClient{assumeRole: func(*sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+			return assumed("id", nil), nil
+		}}
+		stubSTS(t, client)
 
 		creds, err := getCredentials(&DatasourceInfo{
 			AuthType:   "arn",
@@ -119,5 +102,446 @@ func TestGetCredentials_ARNAuthType(t *testing.T) {
 		})
 		require.NoError(t, err)
 		require.NotNil(t, creds)
+		assert.Equal(t, []*sts.AssumeRoleInput{{
+			RoleArn:         awsv2.String(""),
+			DurationSeconds: awsv2.Int32(900),
+			RoleSessionName: awsv2.String("GrafanaSession"),
+			ExternalId:      awsv2.String("external-id"),
+		}}, client.calls())
+	})
+
+	t.Run("Data sources differing only by external ID don't share credentials", func(t *testing.T) {
+		expiration := time.Now().Add(time.Hour)
+		client := &fakeSTSClient{assumeRole: func(input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+			return assumed(*input.ExternalId, &expiration), nil
+		}}
+		stubSTS(t, client)
+
+		tenantA, err := getCredentials(&DatasourceInfo{
+			AuthType:      "arn",
+			AssumeRoleArn: "arn:aws:iam::123456789012:role/shared",
+			ExternalID:    "tenant-a",
+		})
+		require.NoError(t, err)
+		tenantB, err := getCredentials(&DatasourceInfo{
+			AuthType:      "arn",
+			AssumeRoleArn: "arn:aws:iam::123456789012:role/shared",
+			ExternalID:    "tenant-b",
+		})
+		require.NoError(t, err)
+
+		require.Len(t, client.calls(), 2)
+		valueA, err := tenantA.Get()
+		require.NoError(t, err)
+		valueB, err := tenantB.Get()
+		require.NoError(t, err)
+		assert.Equal(t, "tenant-a", valueA.AccessKeyID)
+		assert.Equal(t, "tenant-b", valueB.AccessKeyID)
+	})
+
+	t.Run("With assume role duration", func(t *testing.T) {
+		origMaxDuration := setting.AWSAssumeRoleMaxSessionDuration
+		t.Cleanup(func() {
+			setting.AWSAssumeRoleMaxSessionDuration = origMaxDuration
+		})
+		setting.AWSAssumeRoleMaxSessionDuration = 2 * time.Hour
+
+		client := &fakeSTSClient{assumeRole: func(*sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+			return assumed("id", nil), nil
+		}}
+		stubSTS(t, client)
+
+		creds, err := getCredentials(&DatasourceInfo{
+			AuthType:           "arn",
+			AssumeRoleDuration: time.Hour,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, creds)
+		assert.Equal(t, []*sts.AssumeRoleInput{{
+			RoleArn:         awsv2.String(""),
+			DurationSeconds: awsv2.Int32(3600),
+			RoleSessionName: awsv2.String("GrafanaSession"),
+		}}, client.calls())
+	})
+
+	t.Run("With intermediate role", func(t *testing.T) {
+		origMaxDuration := setting.AWSAssumeRoleMaxSessionDuration
+		t.Cleanup(func() {
+			setting.AWSAssumeRoleMaxSessionDuration = origMaxDuration
+		})
+		setting.AWSAssumeRoleMaxSessionDuration = 4 * time.Hour
+
+		client := &fakeSTSClient{assumeRole: func(input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+			if strings.HasSuffix(*input.RoleArn, "bastion") {
+				return assumed("bastion", nil), nil
+			}
+			return assumed("id", nil), nil
+		}}
+		stubSTS(t, client)
+
+		creds, err := getCredentials(&DatasourceInfo{
+			AuthType:                   "arn",
+			AssumeRoleArn:              "arn:aws:iam::222222222222:role/monitoring",
+			AssumeRoleDuration:         2 * time.Hour,
+			ExternalID:                 "external-id",
+			IntermediateRoleArn:        "arn:aws:iam::111111111111:role/bastion",
+			IntermediateRoleExternalID: "bastion-id",
+		})
+		require.NoError(t, err)
+		require.NotNil(t, creds)
+		assert.Equal(t, []*sts.AssumeRoleInput{
+			{
+				RoleArn:         awsv2.String("arn:aws:iam::111111111111:role/bastion"),
+				DurationSeconds: awsv2.Int32(900),
+				RoleSessionName: awsv2.String("GrafanaSession"),
+				ExternalId:      awsv2.String("bastion-id"),
+			},
+			{
+				RoleArn:         awsv2.String("arn:aws:iam::222222222222:role/monitoring"),
+				DurationSeconds: awsv2.Int32(3600),
+				RoleSessionName: awsv2.String("GrafanaSession"),
+				ExternalId:      awsv2.String("external-id"),
+			},
+		}, client.calls())
+
+		value, err := creds.Get()
+		require.NoError(t, err)
+		assert.Equal(t, "id", value.AccessKeyID)
+	})
+
+	t.Run("With failing intermediate role", func(t *testing.T) {
+		client := &fakeSTSClient{assumeRole: func(*sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+			return nil, errors.New("access denied")
+		}}
+		stubSTS(t, client)
+
+		_, err := getCredentials(&DatasourceInfo{
+			AuthType:            "arn",
+			AssumeRoleArn:       "arn:aws:iam::222222222222:role/monitoring",
+			IntermediateRoleArn: "arn:aws:iam::111111111111:role/denied",
+		})
+		require.EqualError(t, err, "failed to assume intermediate role arn:aws:iam::111111111111:role/denied: access denied")
+		assert.Len(t, client.calls(), 1)
+	})
+}
+
+func TestGetCredentials_Refresh(t *testing.T) {
+	// the credentials returned by the next calls to STS
+	var (
+		mu          sync.Mutex
+		expirations []*time.Time
+		delay       time.Duration
+	)
+	client := &fakeSTSClient{assumeRole: func(*sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		time.Sleep(delay)
+		var expiration *time.Time
+		if len(expirations) > 0 {
+			expiration, expirations = expirations[0], expirations[1:]
+		}
+		return assumed("id", expiration), nil
+	}}
+	stubSTS(t, client)
+	expect := func(d time.Duration, e ...*time.Time) int {
+		mu.Lock()
+		defer mu.Unlock()
+		delay, expirations = d, e
+		return len(client.calls())
+	}
+
+	cached := func(dsInfo *DatasourceInfo) (cache, bool) {
+		credentialCacheLock.RLock()
+		defer credentialCacheLock.RUnlock()
+		for key, entry := range awsCredentialCache {
+			if strings.Contains(key, dsInfo.AssumeRoleArn) {
+				return entry, true
+			}
+		}
+		return cache{}, false
+	}
+
+	t.Run("Concurrent queries should assume the role once", func(t *testing.T) {
+		calls := expect(100 * time.Millisecond)
+
+		dsInfo := &DatasourceInfo{AuthType: "arn", AssumeRoleArn: "arn:aws:iam::123456789012:role/concurrent"}
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				creds, err := getCredentials(dsInfo)
+				assert.NoError(t, err)
+				assert.NotNil(t, creds)
+			}()
+		}
+		wg.Wait()
+		assert.Len(t, client.calls(), calls+1)
+	})
+
+	t.Run("Should refresh the used credentials before they expire", func(t *testing.T) {
+		expiration := time.Now().Add(credentialRefreshWindow + 100*time.Millisecond)
+		renewed := time.Now().Add(time.Hour)
+		calls := expect(0, &expiration, &renewed)
+
+		dsInfo := &DatasourceInfo{AuthType: "arn", AssumeRoleArn: "arn:aws:iam::123456789012:role/used"}
+		_, err := getCredentials(dsInfo)
+		require.NoError(t, err)
+		_, err = getCredentials(dsInfo)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			entry, ok := cached(dsInfo)
+			return ok && entry.expiration.Equal(renewed)
+		}, 2*time.Second, 10*time.Millisecond)
+		assert.Len(t, client.calls(), calls+2)
+	})
+
+	t.Run("Should drop the unused credentials before they expire", func(t *testing.T) {
+		expiration := time.Now().Add(credentialRefreshWindow + 100*time.Millisecond)
+		calls := expect(0, &expiration)
+
+		dsInfo := &DatasourceInfo{AuthType: "arn", AssumeRoleArn: "arn:aws:iam::123456789012:role/unused"}
+		_, err := getCredentials(dsInfo)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			_, ok := cached(dsInfo)
+			return !ok
+		}, 2*time.Second, 10*time.Millisecond)
+		assert.Len(t, client.calls(), calls+1)
+	})
+
+	t.Run("Should drop the credentials of an updated data source", func(t *testing.T) {
+		expiration := time.Now().Add(time.Hour)
+		calls := expect(0, &expiration, &expiration)
+
+		dsInfo := &DatasourceInfo{DatasourceID: 42, AuthType: "arn", AssumeRoleArn: "arn:aws:iam::123456789012:role/updated"}
+		_, err := getCredentials(dsInfo)
+		require.NoError(t, err)
+
+		require.NoError(t, handleDataSourceUpdated(&events.DataSourceUpdated{Id: 41, Type: models.DS_CLOUDWATCH}))
+		_, ok := cached(dsInfo)
+		require.True(t, ok)
+
+		require.NoError(t, handleDataSourceUpdated(&events.DataSourceUpdated{Id: 42, Type: models.DS_CLOUDWATCH}))
+		_, ok = cached(dsInfo)
+		require.False(t, ok)
+
+		_, err = getCredentials(dsInfo)
+		require.NoError(t, err)
+		assert.Len(t, client.calls(), calls+2)
+	})
+
+	t.Run("Should flush the whole cache", func(t *testing.T) {
+		expiration := time.Now().Add(time.Hour)
+		calls := expect(0, &expiration)
+
+		dsInfo := &DatasourceInfo{DatasourceID: 43, AuthType: "arn", AssumeRoleArn: "arn:aws:iam::123456789012:role/flushed"}
+		_, err := getCredentials(dsInfo)
+		require.NoError(t, err)
+
+		cmd := models.FlushAWSCredentialsCacheCommand{}
+		require.NoError(t, flushCredentialCache(&cmd))
+		require.GreaterOrEqual(t, cmd.Result, 1)
+		_, ok := cached(dsInfo)
+		require.False(t, ok)
+		assert.Len(t, client.calls(), calls+1)
+	})
+}
+
+func TestGetCredentials_WebIdentity(t *testing.T) {
+	stubSTS(t, &fakeSTSClient{})
+
+	t.Run("Data sources with different web identities don't share credentials", func(t *testing.T) {
+		tenantA, err := getCredentials(&DatasourceInfo{
+			AuthType:           "webIdentity",
+			WebIdentityRoleArn: "arn:aws:iam::123456789012:role/tenant-a",
+		})
+		require.NoError(t, err)
+		tenantB, err := getCredentials(&DatasourceInfo{
+			AuthType:           "webIdentity",
+			WebIdentityRoleArn: "arn:aws:iam::123456789012:role/tenant-b",
+		})
+		require.NoError(t, err)
+		require.NotSame(t, tenantA, tenantB)
+	})
+
+	t.Run("Only provisioned data sources have a web identity of their own", func(t *testing.T) {
+		jsonData := simplejson.NewFromAny(map[string]interface{}{
+			"authType":             "webIdentity",
+			"webIdentityRoleArn":   "arn:aws:iam::123456789012:role/tenant-a",
+			"webIdentityTokenFile": "/var/run/secrets/tenant-a/token",
+		})
+
+		dsInfo, err := retrieveDsInfo(&models.DataSource{JsonData: jsonData, ReadOnly: true}, "default")
+		require.NoError(t, err)
+		assert.Equal(t, "arn:aws:iam::123456789012:role/tenant-a", dsInfo.WebIdentityRoleArn)
+		assert.Equal(t, "/var/run/secrets/tenant-a/token", dsInfo.WebIdentityTokenFile)
+
+		dsInfo, err = retrieveDsInfo(&models.DataSource{JsonData: jsonData}, "default")
+		require.NoError(t, err)
+		assert.Empty(t, dsInfo.WebIdentityRoleArn)
+		assert.Empty(t, dsInfo.WebIdentityTokenFile)
+	})
+}
+
+func TestRetrieveDsInfo_STSEndpoint(t *testing.T) {
+	jsonData := simplejson.NewFromAny(map[string]interface{}{"stsEndpoint": "https://sts.internal:8443"})
+
+	t.Run("Should only use endpoints of AWS for the data sources which aren't provisioned", func(t *testing.T) {
+		_, err := retrieveDsInfo(&models.DataSource{JsonData: jsonData}, "default")
+		require.Error(t, err)
+
+		dsInfo, err := retrieveDsInfo(&models.DataSource{JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"stsEndpoint": "https://vpce-0123456789abcdef-abcdefgh.sts.eu-west-2.vpce.amazonaws.com",
+		})}, "default")
+		require.NoError(t, err)
+		assert.Equal(t, "https://vpce-0123456789abcdef-abcdefgh.sts.eu-west-2.vpce.amazonaws.com", dsInfo.STSEndpoint)
+	})
+
+	t.Run("Should use any endpoint of the provisioned data sources", func(t *testing.T) {
+		dsInfo, err := retrieveDsInfo(&models.DataSource{JsonData: jsonData, ReadOnly: true}, "default")
+		require.NoError(t, err)
+		assert.Equal(t, "https://sts.internal:8443", dsInfo.STSEndpoint)
+	})
+}
+
+func TestRetrieveDsInfo_Endpoint(t *testing.T) {
+	jsonData := simplejson.NewFromAny(map[string]interface{}{"endpoint": "http://localhost:4566"})
+
+	t.Run("Should only use endpoints of AWS for the data sources which aren't provisioned", func(t *testing.T) {
+		_, err := retrieveDsInfo(&models.DataSource{JsonData: jsonData}, "default")
+		require.Error(t, err)
+
+		dsInfo, err := retrieveDsInfo(&models.DataSource{JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"endpoint": "https://vpce-0123456789abcdef-abcdefgh.monitoring.eu-west-2.vpce.amazonaws.com",
+		})}, "default")
+		require.NoError(t, err)
+		assert.Equal(t, "https://vpce-0123456789abcdef-abcdefgh.monitoring.eu-west-2.vpce.amazonaws.com", dsInfo.Endpoint)
+	})
+
+	t.Run("Should use any endpoint of the provisioned data sources", func(t *testing.T) {
+		dsInfo, err := retrieveDsInfo(&models.DataSource{JsonData: jsonData, ReadOnly: true}, "default")
+		require.NoError(t, err)
+		assert.Equal(t, "http://localhost:4566", dsInfo.Endpoint)
+	})
+}
+
+func TestAuthSettings(t *testing.T) {
+	t.Run("Should use the STS endpoint of the data source", func(t *testing.T) {
+		s := authSettings(&DatasourceInfo{
+			Region:      "us-gov-west-1",
+			STSEndpoint: "https://vpce-0123456789abcdef-abcdefgh.sts.us-gov-west-1.vpce.amazonaws.com",
+		})
+		assert.Equal(t, "us-gov-west-1", s.Region)
+		assert.Equal(t, "https://vpce-0123456789abcdef-abcdefgh.sts.us-gov-west-1.vpce.amazonaws.com", s.STSEndpoint)
+	})
+
+	t.Run("Should use the endpoint of the region by default", func(t *testing.T) {
+		s := authSettings(&DatasourceInfo{Region: "cn-north-1"})
+		assert.Equal(t, "cn-north-1", s.Region)
+		assert.Empty(t, s.STSEndpoint)
+	})
+
+	t.Run("Should use the FIPS endpoint of the region", func(t *testing.T) {
+		s := authSettings(&DatasourceInfo{Region: "us-east-2", UseFIPSEndpoint: true})
+		assert.Equal(t, "https://sts-fips.us-east-2.amazonaws.com", s.STSEndpoint)
+
+		s = authSettings(&DatasourceInfo{UseFIPSEndpoint: true})
+		assert.Equal(t, "us-east-1", s.Region)
+		assert.Equal(t, "https://sts-fips.us-east-1.amazonaws.com", s.STSEndpoint)
+
+		s = authSettings(&DatasourceInfo{
+			Region:          "us-east-2",
+			UseFIPSEndpoint: true,
+			STSEndpoint:     "https://vpce-0123456789abcdef-abcdefgh.sts.us-east-2.vpce.amazonaws.com",
+		})
+		assert.Equal(t, "https://vpce-0123456789abcdef-abcdefgh.sts.us-east-2.vpce.amazonaws.com", s.STSEndpoint)
+	})
+
+	t.Run("Should bound the assume role duration", func(t *testing.T) {
+		s := authSettings(&DatasourceInfo{AuthType: "arn"})
+		assert.Equal(t, defaultAssumeRoleDuration, s.AssumeRoleDuration)
+		assert.Equal(t, setting.AWSAssumeRoleMinDuration, s.IntermediateRoleDuration)
+	})
+}
+
+func TestFIPSEndpoints(t *testing.T) {
+	t.Run("Should return the FIPS endpoints of the regions", func(t *testing.T) {
+		endpoint, err := fipsEndpoint("monitoring", "us-west-2")
+		require.NoError(t, err)
+		assert.Equal(t, "https://monitoring-fips.us-west-2.amazonaws.com", endpoint)
+
+		endpoint, err = fipsEndpoint("logs", "us-gov-west-1")
+		require.NoError(t, err)
+		assert.Equal(t, "https://logs.us-gov-west-1.amazonaws.com", endpoint)
+	})
+
+	t.Run("Should set the endpoint of the clients of FIPS data sources", func(t *testing.T) {
+		cfg := &aws.Config{}
+		require.NoError(t, setFIPSEndpoint(cfg, &DatasourceInfo{Region: "us-east-1"}, "logs"))
+		assert.Nil(t, cfg.Endpoint)
+
+		require.NoError(t, setFIPSEndpoint(cfg, &DatasourceInfo{Region: "us-east-1", UseFIPSEndpoint: true}, "logs"))
+		assert.Equal(t, "https://logs-fips.us-east-1.amazonaws.com", *cfg.Endpoint)
+	})
+
+	t.Run("Should fail in the regions without FIPS endpoints", func(t *testing.T) {
+		_, err := getCredentials(&DatasourceInfo{Region: "eu-west-1", UseFIPSEndpoint: true})
+		require.Error(t, err)
+	})
+}
+
+func TestSetEndpoint(t *testing.T) {
+	t.Run("Should use the endpoint of the data source for all the services", func(t *testing.T) {
+		dsInfo := &DatasourceInfo{Region: "us-east-1", UseFIPSEndpoint: true, Endpoint: "http://localhost:4566"}
+		for _, service := range []string{"monitoring", "logs", "ec2", "tagging"} {
+			cfg := &aws.Config{}
+			require.NoError(t, setEndpoint(cfg, dsInfo, service))
+			assert.Equal(t, "http://localhost:4566", *cfg.Endpoint)
+		}
+	})
+
+	t.Run("Should only use the FIPS endpoints of the FIPS services", func(t *testing.T) {
+		dsInfo := &DatasourceInfo{Region: "us-east-1", UseFIPSEndpoint: true}
+		cfg := &aws.Config{}
+		require.NoError(t, setEndpoint(cfg, dsInfo, "monitoring"))
+		assert.Equal(t, "https://monitoring-fips.us-east-1.amazonaws.com", *cfg.Endpoint)
+
+		cfg = &aws.Config{}
+		require.NoError(t, setEndpoint(cfg, dsInfo, "ec2"))
+		assert.Nil(t, cfg.Endpoint)
+	})
+
+	t.Run("Should fail with an invalid endpoint", func(t *testing.T) {
+		for _, endpoint := range []string{"localhost:4566", "ftp://localhost", "http://"} {
+			err := setEndpoint(&aws.Config{}, &DatasourceInfo{Endpoint: endpoint}, "monitoring")
+			assert.Error(t, err, endpoint)
+		}
+	})
+}
+
+func TestAssumeRoleDuration(t *testing.T) {
+	origMaxDuration := setting.AWSAssumeRoleMaxSessionDuration
+	t.Cleanup(func() {
+		setting.AWSAssumeRoleMaxSessionDuration = origMaxDuration
+	})
+	setting.AWSAssumeRoleMaxSessionDuration = time.Hour
+
+	t.Run("Should parse durations and numbers of seconds", func(t *testing.T) {
+		assert.Equal(t, time.Hour, parseDuration("1h"))
+		assert.Equal(t, 30*time.Minute, parseDuration("1800"))
+		assert.Equal(t, 30*time.Minute, parseDuration(json.Number("1800")))
+		assert.Equal(t, 30*time.Minute, parseDuration(float64(1800)))
+		assert.Equal(t, time.Duration(0), parseDuration("invalid"))
+		assert.Equal(t, time.Duration(0), parseDuration(nil))
+	})
+
+	t.Run("Should bound the duration", func(t *testing.T) {
+		assert.Equal(t, defaultAssumeRoleDuration, assumeRoleDuration(0))
+		assert.Equal(t, 30*time.Minute, assumeRoleDuration(30*time.Minute))
+		assert.Equal(t, setting.AWSAssumeRoleMinDuration, assumeRoleDuration(time.Minute))
+		assert.Equal(t, time.Hour, assumeRoleDuration(4*time.Hour))
 	})
 }
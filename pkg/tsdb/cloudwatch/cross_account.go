@@ -0,0 +1,281 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/private/protocol/restjson"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// CloudWatch cross-account observability lets a monitoring account query the metrics of the source
+// accounts linked to it with Observability Access Manager (OAM). aws-sdk-go v1.29 predates it, so
+// the AccountId of the metric data queries and the IncludeLinkedAccounts and OwningAccount of
+// ListMetrics are added to the query protocol parameters of the requests, and OAM is called with
+// a client of its own.
+
+// validAccountID matches the IDs of AWS accounts
+var validAccountID = regexp.MustCompile(`^\d{12}$`)
+
+// withQueryParams adds parameters to a request of the query protocol, after the ones of its input
+func withQueryParams(params url.Values) request.Option {
+	return func(r *request.Request) {
+		r.Handlers.Build.PushBackNamed(request.NamedHandler{
+			Name: "grafana.cloudwatch.QueryParams",
+			Fn: func(r *request.Request) {
+				if r.Error != nil || r.Body == nil {
+					return
+				}
+				body, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					r.Error = err
+					return
+				}
+				values, err := url.ParseQuery(string(body))
+				if err != nil {
+					r.Error = err
+					return
+				}
+				for name, v := range params {
+					values[name] = v
+				}
+				r.SetBufferBody([]byte(values.Encode()))
+			},
+		})
+	}
+}
+
+// crossAccountParams returns the AccountId parameters of the metric data queries of an input, nil
+// when no query is about a source account. Math and search expressions have no account ID, the
+// latter search the account with :aws.AccountId.
+func crossAccountParams(input *cloudwatch.GetMetricDataInput, queries map[string]*cloudWatchQuery) url.Values {
+	var params url.Values
+	for i, mdq := range input.MetricDataQueries {
+		query, ok := queries[aws.StringValue(mdq.Id)]
+		if !ok || query.AccountId == "" || mdq.MetricStat == nil {
+			continue
+		}
+		if params == nil {
+			params = url.Values{}
+		}
+		params.Set("MetricDataQueries.member."+strconv.Itoa(i+1)+".AccountId", query.AccountId)
+	}
+	return params
+}
+
+// listMetricsAccountParams returns the parameters of ListMetrics listing the metrics of a source
+// account from a monitoring account
+func listMetricsAccountParams(accountID string) url.Values {
+	return url.Values{
+		"IncludeLinkedAccounts": {"true"},
+		"OwningAccount":         {accountID},
+	}
+}
+
+const (
+	oamEndpointsID = "oam"
+	oamAPIVersion  = "2022-06-10"
+)
+
+// oamClient calls the Observability Access Manager API, with the REST JSON protocol
+type oamClient struct {
+	*client.Client
+}
+
+func newOAMClient(p client.ConfigProvider, cfgs ...*aws.Config) *oamClient {
+	c := p.ClientConfig(oamEndpointsID, cfgs...)
+	svc := &oamClient{
+		Client: client.New(
+			*c.Config,
+			metadata.ClientInfo{
+				ServiceName:   oamEndpointsID,
+				ServiceID:     "OAM",
+				SigningName:   c.SigningName,
+				SigningRegion: c.SigningRegion,
+				PartitionID:   c.PartitionID,
+				Endpoint:      c.Endpoint,
+				APIVersion:    oamAPIVersion,
+			},
+			c.Handlers,
+		),
+	}
+	svc.Handlers.Sign.PushBackNamed(v4.SignRequestHandler)
+	svc.Handlers.Build.PushBackNamed(restjson.BuildHandler)
+	svc.Handlers.Unmarshal.PushBackNamed(restjson.UnmarshalHandler)
+	svc.Handlers.UnmarshalMeta.PushBackNamed(restjson.UnmarshalMetaHandler)
+	svc.Handlers.UnmarshalError.PushBackNamed(restjson.UnmarshalErrorHandler)
+	return svc
+}
+
+type oamListSinksInput struct {
+	_         struct{} `type:"structure"`
+	NextToken *string  `type:"string"`
+}
+
+type oamListSinksOutput struct {
+	_         struct{}            `type:"structure"`
+	Items     []*oamListSinksItem `type:"list"`
+	NextToken *string             `type:"string"`
+}
+
+type oamListSinksItem struct {
+	_    struct{} `type:"structure"`
+	Arn  *string  `type:"string"`
+	Id   *string  `type:"string"`
+	Name *string  `type:"string"`
+}
+
+type oamListAttachedLinksInput struct {
+	_              struct{} `type:"structure"`
+	NextToken      *string  `type:"string"`
+	SinkIdentifier *string  `type:"string"`
+}
+
+type oamListAttachedLinksOutput struct {
+	_         struct{}                    `type:"structure"`
+	Items     []*oamListAttachedLinksItem `type:"list"`
+	NextToken *string                     `type:"string"`
+}
+
+type oamListAttachedLinksItem struct {
+	_             struct{}  `type:"structure"`
+	Label         *string   `type:"string"`
+	LinkArn       *string   `type:"string"`
+	ResourceTypes []*string `type:"list"`
+}
+
+func (c *oamClient) send(ctx context.Context, name string, input, output interface{}) error {
+	op := &request.Operation{
+		Name:       name,
+		HTTPMethod: "POST",
+		HTTPPath:   "/" + name,
+	}
+	req := c.NewRequest(op, input, output)
+	req.SetContext(ctx)
+	return req.Send()
+}
+
+func (c *oamClient) listSinks(ctx context.Context) ([]*oamListSinksItem, error) {
+	items := make([]*oamListSinksItem, 0)
+	input := &oamListSinksInput{}
+	for {
+		output := &oamListSinksOutput{}
+		if err := c.send(ctx, "ListSinks", input, output); err != nil {
+			return nil, fmt.Errorf("failed to call oam:ListSinks: %w", err)
+		}
+		items = append(items, output.Items...)
+		if aws.StringValue(output.NextToken) == "" {
+			return items, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+func (c *oamClient) listAttachedLinks(ctx context.Context, sinkIdentifier string) ([]*oamListAttachedLinksItem, error) {
+	items := make([]*oamListAttachedLinksItem, 0)
+	input := &oamListAttachedLinksInput{SinkIdentifier: aws.String(sinkIdentifier)}
+	for {
+		output := &oamListAttachedLinksOutput{}
+		if err := c.send(ctx, "ListAttachedLinks", input, output); err != nil {
+			return nil, fmt.Errorf("failed to call oam:ListAttachedLinks: %w", err)
+		}
+		items = append(items, output.Items...)
+		if aws.StringValue(output.NextToken) == "" {
+			return items, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+func (e *CloudWatchExecutor) getOAMClient(region string) (*oamClient, error) {
+	dsInfo, err := e.getDsInfo(region)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := GetAwsConfig(dsInfo)
+	if err != nil {
+		return nil, err
+	}
+	if err := setEndpoint(cfg, dsInfo, oamEndpointsID); err != nil {
+		return nil, err
+	}
+	sess, err := newSession(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newOAMClient(sess, cfg), nil
+}
+
+// handleGetAccounts lists the source accounts linked to the sinks of the monitoring account, for
+// the account template variables. The label of a link is the account name by default. The accounts
+// are cached, so that accounts linked later show up once the entry expires.
+func (e *CloudWatchExecutor) handleGetAccounts(ctx context.Context, parameters *simplejson.Json, queryContext *tsdb.TsdbQuery) ([]suggestData, error) {
+	region := parameters.Get("region").MustString()
+	key := e.metadataCacheKey("accounts", region)
+	if cached, ok := metadataCache.Get(key); ok {
+		return cached.([]suggestData), nil
+	}
+
+	svc, err := e.getOAMClient(region)
+	if err != nil {
+		return nil, err
+	}
+	result, err := listLinkedAccounts(ctx, svc)
+	if err != nil {
+		return nil, err
+	}
+
+	metadataCache.Set(key, result, metadataCacheTTL)
+	return result, nil
+}
+
+func listLinkedAccounts(ctx context.Context, svc *oamClient) ([]suggestData, error) {
+	sinks, err := svc.listSinks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]suggestData, 0)
+	seen := make(map[string]bool)
+	for _, sink := range sinks {
+		links, err := svc.listAttachedLinks(ctx, aws.StringValue(sink.Arn))
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			linkArn, err := arn.Parse(aws.StringValue(link.LinkArn))
+			if err != nil {
+				plog.Warn("Skipping link with an invalid ARN", "arn", aws.StringValue(link.LinkArn), "error", err)
+				continue
+			}
+			if seen[linkArn.AccountID] {
+				continue
+			}
+			seen[linkArn.AccountID] = true
+
+			text := aws.StringValue(link.Label)
+			if text == "" {
+				text = linkArn.AccountID
+			}
+			result = append(result, suggestData{Text: text, Value: linkArn.AccountID})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Text < result[j].Text
+	})
+	return result, nil
+}
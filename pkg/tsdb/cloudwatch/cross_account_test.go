@@ -0,0 +1,100 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSession(t *testing.T, endpoint string) *session.Session {
+	sess, err := session.NewSession(&aws.Config{
+		Endpoint:    aws.String(endpoint),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("key", "secret", ""),
+	})
+	require.NoError(t, err)
+	return sess
+}
+
+func TestCrossAccountParams(t *testing.T) {
+	queries := map[string]*cloudWatchQuery{
+		"a": {Id: "a", AccountId: "123456789012"},
+		"b": {Id: "b"},
+		"c": {Id: "c", AccountId: "123456789012", Expression: "SEARCH('{AWS/EC2}', 'Average', 300)"},
+	}
+	input := &cloudwatch.GetMetricDataInput{MetricDataQueries: []*cloudwatch.MetricDataQuery{
+		{Id: aws.String("b"), MetricStat: &cloudwatch.MetricStat{}},
+		{Id: aws.String("c"), Expression: aws.String("SEARCH('{AWS/EC2}', 'Average', 300)")},
+		{Id: aws.String("a"), MetricStat: &cloudwatch.MetricStat{}},
+	}}
+
+	assert.Equal(t, url.Values{"MetricDataQueries.member.3.AccountId": {"123456789012"}}, crossAccountParams(input, queries))
+
+	delete(queries, "a")
+	assert.Nil(t, crossAccountParams(input, queries))
+}
+
+func TestWithQueryParams(t *testing.T) {
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		form, err = url.ParseQuery(string(body))
+		require.NoError(t, err)
+		_, _ = w.Write([]byte(`<ListMetricsResponse><ListMetricsResult><Metrics></Metrics></ListMetricsResult></ListMetricsResponse>`))
+	}))
+	t.Cleanup(server.Close)
+
+	svc := cloudwatch.New(newTestSession(t, server.URL))
+	_, err := svc.ListMetricsWithContext(context.Background(), &cloudwatch.ListMetricsInput{Namespace: aws.String("AWS/EC2")},
+		withQueryParams(listMetricsAccountParams("123456789012")))
+	require.NoError(t, err)
+
+	assert.Equal(t, "ListMetrics", form.Get("Action"))
+	assert.Equal(t, "AWS/EC2", form.Get("Namespace"))
+	assert.Equal(t, "true", form.Get("IncludeLinkedAccounts"))
+	assert.Equal(t, "123456789012", form.Get("OwningAccount"))
+}
+
+func TestListLinkedAccounts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/ListSinks":
+			_, _ = w.Write([]byte(`{"Items": [{"Arn": "arn:aws:oam:us-east-1:111111111111:sink/s1", "Id": "s1", "Name": "sink"}]}`))
+		case "/ListAttachedLinks":
+			var input struct {
+				NextToken      string
+				SinkIdentifier string
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&input))
+			assert.Equal(t, "arn:aws:oam:us-east-1:111111111111:sink/s1", input.SinkIdentifier)
+			if input.NextToken == "" {
+				_, _ = w.Write([]byte(`{"Items": [{"Label": "production", "LinkArn": "arn:aws:oam:us-east-1:222222222222:link/l1"}], "NextToken": "next"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"Items": [{"LinkArn": "arn:aws:oam:us-east-1:333333333333:link/l2"}, {"LinkArn": "invalid"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	accounts, err := listLinkedAccounts(context.Background(), newOAMClient(newTestSession(t, server.URL)))
+	require.NoError(t, err)
+	assert.Equal(t, []suggestData{
+		{Text: "333333333333", Value: "333333333333"},
+		{Text: "production", Value: "222222222222"},
+	}, accounts)
+}
@@ -0,0 +1,74 @@
+package cloudwatch
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+// fipsRegions are the regions with FIPS endpoints for STS, CloudWatch and CloudWatch Logs. The
+// standard endpoints of the GovCloud regions are FIPS endpoints, the other regions have separate
+// ones.
+var fipsRegions = map[string]bool{
+	"us-east-1":     false,
+	"us-east-2":     false,
+	"us-west-1":     false,
+	"us-west-2":     false,
+	"us-gov-east-1": true,
+	"us-gov-west-1": true,
+}
+
+// fipsServices are the services the data source calls on FIPS endpoints
+var fipsServices = []string{sts.EndpointsID, cloudwatch.EndpointsID, cloudwatchlogs.EndpointsID}
+
+// fipsEndpoint returns the FIPS endpoint of a service, like monitoring, in a region
+func fipsEndpoint(service string, region string) (string, error) {
+	standard, ok := fipsRegions[region]
+	if !ok {
+		return "", fmt.Errorf("AWS has no FIPS endpoint in region %q", region)
+	}
+	if standard {
+		return fmt.Sprintf("https://%s.%s.amazonaws.com", service, region), nil
+	}
+	return fmt.Sprintf("https://%s-fips.%s.amazonaws.com", service, region), nil
+}
+
+// fipsRegion returns the region of the FIPS endpoints of a data source. STS, whose endpoint is
+// global when the data source has no region, is called in us-east-1 then.
+func fipsRegion(dsInfo *DatasourceInfo) string {
+	if dsInfo.Region == "" {
+		return "us-east-1"
+	}
+	return dsInfo.Region
+}
+
+// checkFIPSEndpoints returns an error when the data source uses FIPS endpoints in a region
+// without, rather than calling the standard endpoints
+func checkFIPSEndpoints(dsInfo *DatasourceInfo) error {
+	if !dsInfo.UseFIPSEndpoint {
+		return nil
+	}
+	for _, service := range fipsServices {
+		if _, err := fipsEndpoint(service, fipsRegion(dsInfo)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setFIPSEndpoint sets the FIPS endpoint of a service in the config of its client, if the data
+// source uses FIPS endpoints
+func setFIPSEndpoint(cfg *aws.Config, dsInfo *DatasourceInfo, service string) error {
+	if !dsInfo.UseFIPSEndpoint {
+		return nil
+	}
+	endpoint, err := fipsEndpoint(service, fipsRegion(dsInfo))
+	if err != nil {
+		return err
+	}
+	cfg.Endpoint = aws.String(endpoint)
+	return nil
+}
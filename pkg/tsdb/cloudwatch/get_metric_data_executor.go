@@ -4,11 +4,12 @@ import (
 	"context"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 )
 
-func (e *CloudWatchExecutor) executeRequest(ctx context.Context, client cloudWatchClient, metricDataInput *cloudwatch.GetMetricDataInput) ([]*cloudwatch.GetMetricDataOutput, error) {
+func (e *CloudWatchExecutor) executeRequest(ctx context.Context, client cloudWatchClient, metricDataInput *cloudwatch.GetMetricDataInput, opts ...request.Option) ([]*cloudwatch.GetMetricDataOutput, error) {
 	mdo := make([]*cloudwatch.GetMetricDataOutput, 0)
 
 	nextToken := ""
@@ -16,7 +17,7 @@ func (e *CloudWatchExecutor) executeRequest(ctx context.Context, client cloudWat
 		if nextToken != "" {
 			metricDataInput.NextToken = aws.String(nextToken)
 		}
-		resp, err := client.GetMetricDataWithContext(ctx, metricDataInput)
+		resp, err := client.GetMetricDataWithContext(ctx, metricDataInput, opts...)
 		if err != nil {
 			return mdo, err
 		}
@@ -0,0 +1,41 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// CheckHealth lists the billing metrics with the CloudWatch API, like the test of the data source
+// in the frontend, and a log group with the CloudWatch Logs API, in the default region.
+func (e *CloudWatchExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) (*tsdb.HealthCheckResult, error) {
+	e.DataSource = dsInfo
+
+	client, err := e.getClient("default")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := client.ListMetricsWithContext(ctx, &cloudwatch.ListMetricsInput{
+		Namespace:  aws.String("AWS/Billing"),
+		MetricName: aws.String("EstimatedCharges"),
+	}); err != nil {
+		return nil, fmt.Errorf("CloudWatch metrics query failed: %w", err)
+	}
+
+	logsClient, err := e.getLogsClient("default")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := logsClient.DescribeLogGroupsWithContext(ctx, &cloudwatchlogs.DescribeLogGroupsInput{
+		Limit: aws.Int64(1),
+	}); err != nil {
+		return nil, fmt.Errorf("CloudWatch logs query failed: %w", err)
+	}
+
+	return tsdb.HealthOk("Successfully queried the CloudWatch metrics and logs APIs."), nil
+}
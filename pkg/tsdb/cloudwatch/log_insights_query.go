@@ -0,0 +1,261 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	// logsQueryPollPeriod is how often the results of a running Logs Insights query are fetched
+	logsQueryPollPeriod = time.Second
+	// logsQueryTimeout is how long a Logs Insights query may run before it's stopped
+	logsQueryTimeout = 30 * time.Second
+)
+
+// isLogsQuery returns whether a query is a Logs Insights query to run in the backend, rather than
+// one of the log actions with which the frontend runs its queries step by step.
+func isLogsQuery(model *simplejson.Json) bool {
+	queryType := model.Get("type").MustString("")
+	return model.Get("queryMode").MustString("") == "Logs" && (queryType == "" || queryType == "timeSeriesQuery")
+}
+
+// executeLogsQuery runs Logs Insights queries in the backend. Unlike many other data sources, with
+// CloudWatch Logs the response to a query is the ID of the query, which is then polled until the
+// query is complete, receiving (possibly partial) results each time.
+func (e *CloudWatchExecutor) executeLogsQuery(ctx context.Context, queryContext *tsdb.TsdbQuery) (*tsdb.Response, error) {
+	resultChan := make(chan *tsdb.QueryResult, len(queryContext.Queries))
+	eg, ectx := errgroup.WithContext(ctx)
+
+	for _, query := range queryContext.Queries {
+		query := query
+		eg.Go(func() error {
+			queryResult := &tsdb.QueryResult{RefId: query.RefId}
+			frames, err := e.executeLogsInsightsQuery(ectx, query.Model, queryContext.TimeRange)
+			if err != nil {
+				// the queries of the request are independent, only a cancelled request fails them all
+				if ectx.Err() != nil {
+					return ectx.Err()
+				}
+				queryResult.Error = err
+			} else {
+				queryResult.Dataframes = tsdb.NewDecodedDataFrames(frames)
+			}
+
+			resultChan <- queryResult
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	close(resultChan)
+
+	response := &tsdb.Response{
+		Results: make(map[string]*tsdb.QueryResult),
+	}
+	for result := range resultChan {
+		response.Results[result.RefId] = result
+	}
+
+	return response, nil
+}
+
+func (e *CloudWatchExecutor) executeLogsInsightsQuery(ctx context.Context, model *simplejson.Json, timeRange *tsdb.TimeRange) (data.Frames, error) {
+	parameters := simplejson.NewFromAny(map[string]interface{}{
+		"queryString":   model.Get("expression").MustString(""),
+		"logGroupNames": model.Get("logGroupNames").MustStringArray(),
+	})
+	if limit, err := model.Get("limit").Int64(); err == nil {
+		parameters.Set("limit", limit)
+	}
+
+	region := model.Get("region").MustString("default")
+	if region == "default" {
+		region = e.DataSource.JsonData.Get("defaultRegion").MustString()
+	}
+
+	logsClient, err := e.getLogsClient(region)
+	if err != nil {
+		return nil, err
+	}
+
+	queryResults, err := e.runLogsQuery(ctx, logsClient, parameters, timeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	frame, err := logsResultsToDataframes(queryResults)
+	if err != nil {
+		return nil, err
+	}
+
+	return logsFramesFor(frame, model.Get("statsGroups").MustStringArray())
+}
+
+// runLogsQuery starts a Logs Insights query and polls its results until it's terminated. The query
+// is stopped when the context is cancelled or it runs longer than logsQueryTimeout.
+func (e *CloudWatchExecutor) runLogsQuery(ctx context.Context, logsClient cloudwatchlogsiface.CloudWatchLogsAPI, parameters *simplejson.Json, timeRange *tsdb.TimeRange) (*cloudwatchlogs.GetQueryResultsOutput, error) {
+	if len(parameters.Get("logGroupNames").MustStringArray()) == 0 {
+		return nil, fmt.Errorf("at least one log group must be selected")
+	}
+
+	startQueryOutput, err := e.executeStartQuery(ctx, logsClient, parameters, timeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	requestParams := simplejson.NewFromAny(map[string]interface{}{
+		"queryId": *startQueryOutput.QueryId,
+	})
+
+	timeout := time.NewTimer(logsQueryTimeout)
+	defer timeout.Stop()
+	ticker := time.NewTicker(logsQueryPollPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			e.stopLogsQuery(logsClient, requestParams)
+			return nil, ctx.Err()
+		case <-timeout.C:
+			e.stopLogsQuery(logsClient, requestParams)
+			return nil, fmt.Errorf("logs query didn't complete within %s", logsQueryTimeout)
+		case <-ticker.C:
+			res, err := e.executeGetQueryResults(ctx, logsClient, requestParams)
+			if err != nil {
+				return nil, err
+			}
+			if !isTerminated(*res.Status) {
+				continue
+			}
+			if *res.Status != "Complete" {
+				return nil, fmt.Errorf("logs query %s: %s", strings.ToLower(*res.Status), *startQueryOutput.QueryId)
+			}
+			return res, nil
+		}
+	}
+}
+
+// stopLogsQuery stops a query which isn't needed anymore, so that it doesn't keep scanning the log
+// groups. The context of the query may be done already, so the query is stopped with its own.
+func (e *CloudWatchExecutor) stopLogsQuery(logsClient cloudwatchlogsiface.CloudWatchLogsAPI, parameters *simplejson.Json) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := e.executeStopQuery(ctx, logsClient, parameters); err != nil {
+		plog.Warn("Failed to stop logs query", "queryId", parameters.Get("queryId").MustString(), "error", err)
+	}
+}
+
+// logsFramesFor returns the frames of the results of a logs query. The results of a "stats ... by
+// bin(...)" query are time series, with one frame per group defined in the query, and the other
+// results are returned as a table.
+func logsFramesFor(frame *data.Frame, statsGroups []string) (data.Frames, error) {
+	frames := data.Frames{frame}
+	if len(statsGroups) > 0 && len(frame.Fields) > 0 {
+		groupedFrames, err := groupResults(frame, statsGroups)
+		if err != nil {
+			return nil, err
+		}
+		// groups are returned in a consistent order
+		sort.Slice(groupedFrames, func(i, j int) bool {
+			return groupedFrames[i].Name < groupedFrames[j].Name
+		})
+		frames = groupedFrames
+	}
+
+	for i, f := range frames {
+		if series := logsFrameToTimeSeries(f, statsGroups); series != nil {
+			frames[i] = series
+		}
+	}
+
+	return frames, nil
+}
+
+// logsFrameToTimeSeries converts the results of a stats query to a wide time series frame, whose
+// values are labelled with the groups of the query. It returns nil when the results aren't a time
+// series: they need a time field other than the @timestamp of the log events, and numeric fields.
+func logsFrameToTimeSeries(frame *data.Frame, statsGroups []string) *data.Frame {
+	var timeField *data.Field
+	valueFields := make([]*data.Field, 0)
+	labels := data.Labels{}
+
+	for _, field := range frame.Fields {
+		switch field.Type() {
+		case data.FieldTypeNullableTime:
+			if field.Name == "@timestamp" || timeField != nil {
+				return nil
+			}
+			timeField = field
+		case data.FieldTypeNullableFloat64:
+			valueFields = append(valueFields, field)
+		case data.FieldTypeNullableString:
+			if field.Len() > 0 && isStatsGroup(field.Name, statsGroups) {
+				if value, ok := field.At(0).(*string); ok && value != nil {
+					labels[field.Name] = *value
+				}
+			}
+		}
+	}
+	if timeField == nil || len(valueFields) == 0 {
+		return nil
+	}
+
+	// the rows without a time can't be part of a time series
+	rows := make([]int, 0, timeField.Len())
+	for i := 0; i < timeField.Len(); i++ {
+		if timeField.At(i).(*time.Time) != nil {
+			rows = append(rows, i)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return timeField.At(rows[i]).(*time.Time).Before(*timeField.At(rows[j]).(*time.Time))
+	})
+
+	times := make([]time.Time, len(rows))
+	for i, row := range rows {
+		times[i] = *timeField.At(row).(*time.Time)
+	}
+	fields := []*data.Field{data.NewField(timeField.Name, nil, times)}
+
+	for _, valueField := range valueFields {
+		var fieldLabels data.Labels
+		if len(labels) > 0 {
+			fieldLabels = labels.Copy()
+		}
+		values := make([]*float64, len(rows))
+		for i, row := range rows {
+			if value := valueField.At(row).(*float64); value != nil {
+				values[i] = aws.Float64(*value)
+			}
+		}
+		fields = append(fields, data.NewField(valueField.Name, fieldLabels, values))
+	}
+
+	series := data.NewFrame(frame.Name, fields...)
+	series.Meta = frame.Meta
+	return series
+}
+
+func isStatsGroup(name string, statsGroups []string) bool {
+	for _, group := range statsGroups {
+		if group == name {
+			return true
+		}
+	}
+	return false
+}
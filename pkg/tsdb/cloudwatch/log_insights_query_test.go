@@ -0,0 +1,159 @@
+package cloudwatch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runningLogsClient is a logs client whose queries are running for a number of polls
+type runningLogsClient struct {
+	FakeLogsClient
+	runningPolls int
+	polls        int
+	stopped      bool
+	startInput   *cloudwatchlogs.StartQueryInput
+}
+
+func (c *runningLogsClient) StartQueryWithContext(ctx context.Context, input *cloudwatchlogs.StartQueryInput, option ...request.Option) (*cloudwatchlogs.StartQueryOutput, error) {
+	c.startInput = input
+	return c.FakeLogsClient.StartQueryWithContext(ctx, input, option...)
+}
+
+func (c *runningLogsClient) GetQueryResultsWithContext(ctx context.Context, input *cloudwatchlogs.GetQueryResultsInput, option ...request.Option) (*cloudwatchlogs.GetQueryResultsOutput, error) {
+	c.polls++
+	if c.polls <= c.runningPolls {
+		return &cloudwatchlogs.GetQueryResultsOutput{
+			Status:     aws.String("Running"),
+			Statistics: &cloudwatchlogs.QueryStatistics{},
+		}, nil
+	}
+	return c.FakeLogsClient.GetQueryResultsWithContext(ctx, input, option...)
+}
+
+func (c *runningLogsClient) StopQueryWithContext(ctx context.Context, input *cloudwatchlogs.StopQueryInput, option ...request.Option) (*cloudwatchlogs.StopQueryOutput, error) {
+	c.stopped = true
+	return c.FakeLogsClient.StopQueryWithContext(ctx, input, option...)
+}
+
+func setLogsQueryPolling(t *testing.T, pollPeriod time.Duration, timeout time.Duration) {
+	origPollPeriod, origTimeout := logsQueryPollPeriod, logsQueryTimeout
+	logsQueryPollPeriod, logsQueryTimeout = pollPeriod, timeout
+	t.Cleanup(func() {
+		logsQueryPollPeriod, logsQueryTimeout = origPollPeriod, origTimeout
+	})
+}
+
+func TestRunLogsQuery(t *testing.T) {
+	executor := &CloudWatchExecutor{}
+	timeRange := tsdb.NewTimeRange("1584700643000", "1584873443000")
+
+	t.Run("Should poll the results until the query is complete", func(t *testing.T) {
+		setLogsQueryPolling(t, time.Millisecond, time.Minute)
+		logsClient := &runningLogsClient{runningPolls: 2}
+		params := simplejson.NewFromAny(map[string]interface{}{
+			"queryString":   "fields @message",
+			"logGroupNames": []interface{}{"group_a", "group_b"},
+		})
+
+		res, err := executor.runLogsQuery(context.Background(), logsClient, params, timeRange)
+		require.NoError(t, err)
+		assert.Equal(t, "Complete", *res.Status)
+		assert.Len(t, res.Results, 2)
+		assert.Equal(t, 3, logsClient.polls)
+		assert.Equal(t, []string{"group_a", "group_b"}, aws.StringValueSlice(logsClient.startInput.LogGroupNames))
+		assert.False(t, logsClient.stopped)
+	})
+
+	t.Run("Should require a log group", func(t *testing.T) {
+		logsClient := &runningLogsClient{}
+		params := simplejson.NewFromAny(map[string]interface{}{
+			"queryString": "fields @message",
+		})
+
+		_, err := executor.runLogsQuery(context.Background(), logsClient, params, timeRange)
+		require.EqualError(t, err, "at least one log group must be selected")
+		assert.Nil(t, logsClient.startInput)
+	})
+
+	t.Run("Should stop the query when the context is cancelled", func(t *testing.T) {
+		setLogsQueryPolling(t, time.Millisecond, time.Minute)
+		logsClient := &runningLogsClient{runningPolls: 1000000}
+		params := simplejson.NewFromAny(map[string]interface{}{
+			"queryString":   "fields @message",
+			"logGroupNames": []interface{}{"group_a"},
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err := executor.runLogsQuery(ctx, logsClient, params, timeRange)
+		require.Equal(t, context.DeadlineExceeded, err)
+		assert.True(t, logsClient.stopped)
+	})
+
+	t.Run("Should stop the query when it runs too long", func(t *testing.T) {
+		setLogsQueryPolling(t, time.Millisecond, 20*time.Millisecond)
+		logsClient := &runningLogsClient{runningPolls: 1000000}
+		params := simplejson.NewFromAny(map[string]interface{}{
+			"queryString":   "fields @message",
+			"logGroupNames": []interface{}{"group_a"},
+		})
+
+		_, err := executor.runLogsQuery(context.Background(), logsClient, params, timeRange)
+		require.EqualError(t, err, "logs query didn't complete within 20ms")
+		assert.True(t, logsClient.stopped)
+	})
+}
+
+func TestLogsFramesFor(t *testing.T) {
+	timeA := time.Date(2020, 3, 20, 10, 0, 0, 0, time.UTC)
+	timeB := time.Date(2020, 3, 20, 10, 5, 0, 0, time.UTC)
+
+	t.Run("Should return the log events as a table", func(t *testing.T) {
+		frame := data.NewFrame("CloudWatchLogsResponse",
+			data.NewField("@timestamp", nil, []*time.Time{&timeA, &timeB}),
+			data.NewField("@message", nil, []*string{aws.String("a"), aws.String("b")}),
+			data.NewField("bytes", nil, []*float64{aws.Float64(1), aws.Float64(2)}),
+		)
+
+		frames, err := logsFramesFor(frame, nil)
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		assert.Equal(t, frame, frames[0])
+	})
+
+	t.Run("Should return one time series per group of a stats query", func(t *testing.T) {
+		frame := data.NewFrame("CloudWatchLogsResponse",
+			data.NewField("host", nil, []*string{aws.String("b"), aws.String("a"), aws.String("a")}),
+			data.NewField("bin(5m)", nil, []*time.Time{&timeA, &timeB, &timeA}),
+			data.NewField("count(*)", nil, []*float64{aws.Float64(1), aws.Float64(2), aws.Float64(3)}),
+		)
+
+		frames, err := logsFramesFor(frame, []string{"host", "bin(5m)"})
+		require.NoError(t, err)
+		require.Len(t, frames, 2)
+
+		assert.Equal(t, "a", frames[0].Name)
+		require.Len(t, frames[0].Fields, 2)
+		assert.Equal(t, "bin(5m)", frames[0].Fields[0].Name)
+		assert.Equal(t, timeA, frames[0].Fields[0].At(0))
+		assert.Equal(t, timeB, frames[0].Fields[0].At(1))
+		assert.Equal(t, "count(*)", frames[0].Fields[1].Name)
+		assert.Equal(t, data.Labels{"host": "a"}, frames[0].Fields[1].Labels)
+		assert.Equal(t, 3.0, *frames[0].Fields[1].At(0).(*float64))
+		assert.Equal(t, 2.0, *frames[0].Fields[1].At(1).(*float64))
+		assert.Equal(t, data.TimeSeriesTypeWide, frames[0].TimeSeriesSchema().Type)
+
+		assert.Equal(t, "b", frames[1].Name)
+		assert.Equal(t, data.Labels{"host": "b"}, frames[1].Fields[1].Labels)
+	})
+}
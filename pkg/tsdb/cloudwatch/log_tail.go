@@ -0,0 +1,211 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+const (
+	// logsTailMaxLogGroups is the maximum number of log groups followed by a live tail, like the
+	// maximum of a Logs Insights query
+	logsTailMaxLogGroups = 20
+	// logsTailMaxEvents is the maximum number of events of a log group sent by a poll, the events
+	// beyond it are skipped so that a busy log group doesn't hold back the tail
+	logsTailMaxEvents = 1000
+)
+
+// logsTailPollPeriod is how often the log groups of a live tail are polled for new events
+var logsTailPollPeriod = 2 * time.Second
+
+// logsTailRequest is what a live tail follows, parsed from the path of its stream:
+// tail?region=<region>&logGroupName=<name>&logGroupName=<name>&filterPattern=<pattern>
+type logsTailRequest struct {
+	Region        string
+	LogGroupNames []string
+	FilterPattern string
+}
+
+func parseLogsTailPath(path string) (*logsTailRequest, error) {
+	parts := strings.SplitN(path, "?", 2)
+	if parts[0] != "tail" || len(parts) != 2 {
+		return nil, fmt.Errorf("unknown stream %q", path)
+	}
+
+	values, err := url.ParseQuery(parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	req := &logsTailRequest{
+		Region:        values.Get("region"),
+		LogGroupNames: values["logGroupName"],
+		FilterPattern: values.Get("filterPattern"),
+	}
+	if req.Region == "" {
+		req.Region = "default"
+	}
+	if len(req.LogGroupNames) == 0 {
+		return nil, fmt.Errorf("at least one log group must be selected")
+	}
+	if len(req.LogGroupNames) > logsTailMaxLogGroups {
+		return nil, fmt.Errorf("a live tail can follow at most %d log groups", logsTailMaxLogGroups)
+	}
+	return req, nil
+}
+
+// logsTailMessage is a message of a live tail, with the new events of a log group sorted by time
+type logsTailMessage struct {
+	LogGroupName string          `json:"logGroupName"`
+	Events       []logsTailEvent `json:"events"`
+}
+
+type logsTailEvent struct {
+	// Timestamp is the time of the event in milliseconds
+	Timestamp     int64  `json:"timestamp"`
+	Message       string `json:"message"`
+	LogStreamName string `json:"logStreamName"`
+	EventID       string `json:"eventId"`
+}
+
+// SubscribeStream checks that the path of a stream is a live tail of log groups.
+func (e *CloudWatchExecutor) SubscribeStream(ctx context.Context, dsInfo *models.DataSource, path string) error {
+	if _, err := parseLogsTailPath(path); err != nil {
+		return tsdb.ErrStreamNotFound
+	}
+	return nil
+}
+
+// RunStream follows the log groups of a live tail, like `aws logs tail --follow`, sending their
+// new events until ctx is done.
+func (e *CloudWatchExecutor) RunStream(ctx context.Context, dsInfo *models.DataSource, path string, send func(message []byte) error) error {
+	e.DataSource = dsInfo
+
+	req, err := parseLogsTailPath(path)
+	if err != nil {
+		return tsdb.ErrStreamNotFound
+	}
+
+	region := req.Region
+	if region == "default" {
+		region = dsInfo.JsonData.Get("defaultRegion").MustString()
+	}
+	logsClient, err := e.getLogsClient(region)
+	if err != nil {
+		return err
+	}
+
+	return e.tailLogs(ctx, logsClient, req, send)
+}
+
+func (e *CloudWatchExecutor) tailLogs(ctx context.Context, logsClient cloudwatchlogsiface.CloudWatchLogsAPI, req *logsTailRequest, send func(message []byte) error) error {
+	// the tail starts with the events from now on
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	tails := make([]*logGroupTail, 0, len(req.LogGroupNames))
+	for _, name := range req.LogGroupNames {
+		tails = append(tails, &logGroupTail{logGroupName: name, filterPattern: req.FilterPattern, startTime: now})
+	}
+
+	ticker := time.NewTicker(logsTailPollPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		for _, tail := range tails {
+			events, err := tail.poll(ctx, logsClient)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				// the next poll tries again
+				plog.Warn("Failed to poll log group of live tail", "logGroupName", tail.logGroupName, "error", err)
+				continue
+			}
+			if len(events) == 0 {
+				continue
+			}
+
+			message, err := json.Marshal(logsTailMessage{LogGroupName: tail.logGroupName, Events: events})
+			if err != nil {
+				return err
+			}
+			if err := send(message); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// logGroupTail follows the events of a log group. As FilterLogEvents returns the events from a
+// time in milliseconds, the ids of the events at that time were sent already are remembered.
+type logGroupTail struct {
+	logGroupName  string
+	filterPattern string
+	startTime     int64
+	sentAtStart   map[string]bool
+}
+
+func (t *logGroupTail) poll(ctx context.Context, logsClient cloudwatchlogsiface.CloudWatchLogsAPI) ([]logsTailEvent, error) {
+	input := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName: aws.String(t.logGroupName),
+		StartTime:    aws.Int64(t.startTime),
+	}
+	if t.filterPattern != "" {
+		input.FilterPattern = aws.String(t.filterPattern)
+	}
+
+	events := make([]logsTailEvent, 0)
+	err := logsClient.FilterLogEventsPagesWithContext(ctx, input, func(page *cloudwatchlogs.FilterLogEventsOutput, lastPage bool) bool {
+		for _, event := range page.Events {
+			if event.EventId == nil || event.Timestamp == nil || t.sentAtStart[*event.EventId] {
+				continue
+			}
+			events = append(events, logsTailEvent{
+				Timestamp:     *event.Timestamp,
+				Message:       aws.StringValue(event.Message),
+				LogStreamName: aws.StringValue(event.LogStreamName),
+				EventID:       *event.EventId,
+			})
+		}
+		return len(events) < logsTailMaxEvents
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp < events[j].Timestamp
+	})
+	if len(events) > logsTailMaxEvents {
+		events = events[:logsTailMaxEvents]
+	}
+
+	for _, event := range events {
+		if event.Timestamp > t.startTime {
+			t.startTime = event.Timestamp
+			t.sentAtStart = make(map[string]bool)
+		}
+		if event.Timestamp == t.startTime {
+			if t.sentAtStart == nil {
+				t.sentAtStart = make(map[string]bool)
+			}
+			t.sentAtStart[event.EventID] = true
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,121 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tailLogsClient returns the events of its log groups from the start time of the request
+type tailLogsClient struct {
+	FakeLogsClient
+	events map[string][]*cloudwatchlogs.FilteredLogEvent
+	inputs []*cloudwatchlogs.FilterLogEventsInput
+}
+
+func (c *tailLogsClient) FilterLogEventsPagesWithContext(ctx context.Context, input *cloudwatchlogs.FilterLogEventsInput, fn func(*cloudwatchlogs.FilterLogEventsOutput, bool) bool, option ...request.Option) error {
+	c.inputs = append(c.inputs, input)
+	page := &cloudwatchlogs.FilterLogEventsOutput{}
+	for _, event := range c.events[*input.LogGroupName] {
+		if *event.Timestamp >= *input.StartTime {
+			page.Events = append(page.Events, event)
+		}
+	}
+	fn(page, true)
+	return nil
+}
+
+func tailEvent(id string, timestamp int64, message string) *cloudwatchlogs.FilteredLogEvent {
+	return &cloudwatchlogs.FilteredLogEvent{
+		EventId:       aws.String(id),
+		Timestamp:     aws.Int64(timestamp),
+		Message:       aws.String(message),
+		LogStreamName: aws.String("stream"),
+	}
+}
+
+func TestParseLogsTailPath(t *testing.T) {
+	req, err := parseLogsTailPath("tail?region=us-east-1&logGroupName=%2Faws%2Flambda%2Fa&logGroupName=b&filterPattern=ERROR")
+	require.NoError(t, err)
+	assert.Equal(t, &logsTailRequest{
+		Region:        "us-east-1",
+		LogGroupNames: []string{"/aws/lambda/a", "b"},
+		FilterPattern: "ERROR",
+	}, req)
+
+	req, err = parseLogsTailPath("tail?logGroupName=a")
+	require.NoError(t, err)
+	assert.Equal(t, "default", req.Region)
+
+	_, err = parseLogsTailPath("tail?region=us-east-1")
+	require.EqualError(t, err, "at least one log group must be selected")
+
+	_, err = parseLogsTailPath("metrics?logGroupName=a")
+	require.Error(t, err)
+}
+
+func TestLogGroupTail(t *testing.T) {
+	t.Run("Should send the new events once", func(t *testing.T) {
+		logsClient := &tailLogsClient{events: map[string][]*cloudwatchlogs.FilteredLogEvent{
+			"a": {tailEvent("2", 1010, "second"), tailEvent("1", 1000, "first")},
+		}}
+		tail := &logGroupTail{logGroupName: "a", filterPattern: "ERROR", startTime: 1000}
+
+		events, err := tail.poll(context.Background(), logsClient)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, "first", events[0].Message)
+		assert.Equal(t, "second", events[1].Message)
+		assert.Equal(t, "ERROR", *logsClient.inputs[0].FilterPattern)
+
+		logsClient.events["a"] = append(logsClient.events["a"], tailEvent("3", 1010, "third"), tailEvent("4", 1020, "fourth"))
+		events, err = tail.poll(context.Background(), logsClient)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, "third", events[0].Message)
+		assert.Equal(t, "fourth", events[1].Message)
+		assert.Equal(t, int64(1010), *logsClient.inputs[1].StartTime)
+
+		events, err = tail.poll(context.Background(), logsClient)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("Should send the events of the log groups until the context is done", func(t *testing.T) {
+		origPollPeriod := logsTailPollPeriod
+		logsTailPollPeriod = time.Millisecond
+		t.Cleanup(func() { logsTailPollPeriod = origPollPeriod })
+
+		future := time.Now().Add(time.Hour).UnixNano() / int64(time.Millisecond)
+		logsClient := &tailLogsClient{events: map[string][]*cloudwatchlogs.FilteredLogEvent{
+			"a": {tailEvent("1", future, "from a")},
+			"b": {tailEvent("2", future, "from b")},
+		}}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		messages := make([]logsTailMessage, 0)
+		executor := &CloudWatchExecutor{}
+		err := executor.tailLogs(ctx, logsClient, &logsTailRequest{LogGroupNames: []string{"a", "b"}}, func(message []byte) error {
+			var decoded logsTailMessage
+			require.NoError(t, json.Unmarshal(message, &decoded))
+			messages = append(messages, decoded)
+			if len(messages) == 2 {
+				cancel()
+			}
+			return nil
+		})
+		require.Equal(t, context.Canceled, err)
+		require.Len(t, messages, 2)
+		assert.Equal(t, "a", messages[0].LogGroupName)
+		assert.Equal(t, "from a", messages[0].Events[0].Message)
+		assert.Equal(t, "b", messages[1].LogGroupName)
+	})
+}
@@ -0,0 +1,75 @@
+package cloudwatch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+)
+
+// metricDataCache holds the GetMetricData responses of the data sources with a metricDataCacheTTL,
+// by data source id and version so that the entries of a data source are dropped when it's updated.
+// The executors are created by request, so the cache is shared by the package.
+var metricDataCache = localcache.New(5*time.Minute, 10*time.Minute)
+
+// metricDataCacheTTL returns how long the GetMetricData responses of the data source are cached,
+// either a duration like 5m or a number of seconds. They aren't cached when it's 0.
+func (e *CloudWatchExecutor) metricDataCacheTTL() time.Duration {
+	if e.DataSource == nil || e.DataSource.JsonData == nil {
+		return 0
+	}
+	return parseDuration(e.DataSource.JsonData.Get("metricDataCacheTTL").Interface())
+}
+
+// metricDataCacheKey identifies the GetMetricData requests of a batch by its queries, its region and
+// its time range truncated to the TTL, so that the refreshes of a panel within the same TTL share
+// their responses
+func (e *CloudWatchExecutor) metricDataCacheKey(region string, startTime, endTime time.Time, input *cloudwatch.GetMetricDataInput,
+	params url.Values, ttl time.Duration) (string, error) {
+	// the time range and the pagination of the input are left out of its queries
+	queries := *input
+	queries.StartTime, queries.EndTime, queries.NextToken = nil, nil, nil
+
+	step := ttl.Milliseconds()
+	data, err := json.Marshal(map[string]interface{}{
+		"region": region,
+		"from":   startTime.UnixNano() / int64(time.Millisecond) / step,
+		"to":     endTime.UnixNano() / int64(time.Millisecond) / step,
+		"input":  queries,
+		"params": params,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(data)
+	return fmt.Sprintf("%d/%d/metric-data/%s", e.DataSource.Id, e.DataSource.Version, hex.EncodeToString(hash[:])), nil
+}
+
+// cacheableMetricData tells whether the GetMetricData responses of a batch can be cached, which
+// those with partial data can't
+func cacheableMetricData(outputs []*cloudwatch.GetMetricDataOutput) bool {
+	for _, output := range outputs {
+		for _, result := range output.MetricDataResults {
+			if result.StatusCode != nil && *result.StatusCode != cloudwatch.StatusCodeComplete {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// copyMetricData copies GetMetricData responses, which are changed when they're parsed
+func copyMetricData(outputs []*cloudwatch.GetMetricDataOutput) []*cloudwatch.GetMetricDataOutput {
+	copied := make([]*cloudwatch.GetMetricDataOutput, 0, len(outputs))
+	for _, output := range outputs {
+		copied = append(copied, awsutil.CopyOf(output).(*cloudwatch.GetMetricDataOutput))
+	}
+	return copied
+}
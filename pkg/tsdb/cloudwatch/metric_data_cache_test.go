@@ -0,0 +1,103 @@
+package cloudwatch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingCloudWatchClient struct {
+	calls      int
+	statusCode string
+}
+
+func (c *countingCloudWatchClient) GetMetricDataWithContext(ctx aws.Context, input *cloudwatch.GetMetricDataInput, opts ...request.Option) (*cloudwatch.GetMetricDataOutput, error) {
+	c.calls++
+	return &cloudwatch.GetMetricDataOutput{
+		MetricDataResults: []*cloudwatch.MetricDataResult{{
+			Id:         aws.String("queryA"),
+			Label:      aws.String("cpu"),
+			StatusCode: aws.String(c.statusCode),
+			Timestamps: []*time.Time{aws.Time(time.Unix(1600000000, 0))},
+			Values:     []*float64{aws.Float64(12.5)},
+		}},
+	}, nil
+}
+
+func TestMetricDataCache(t *testing.T) {
+	newExecutor := func(id int64, ttl interface{}) *CloudWatchExecutor {
+		return &CloudWatchExecutor{DataSource: &models.DataSource{
+			Id:       id,
+			JsonData: simplejson.NewFromAny(map[string]interface{}{"defaultRegion": "us-east-1", "metricDataCacheTTL": ttl}),
+		}}
+	}
+	newQueries := func() map[string]*cloudWatchQuery {
+		return map[string]*cloudWatchQuery{"queryA": {
+			Id: "queryA", RefId: "A", Region: "default", Namespace: "AWS/EC2", MetricName: "CPUUtilization",
+			Stats: "Average", Period: 300, Dimensions: map[string][]string{}, ReturnData: true, MatchExact: true,
+		}}
+	}
+	startTime := time.Unix(1600000000, 0)
+	endTime := startTime.Add(time.Hour)
+
+	execute := func(t *testing.T, e *CloudWatchExecutor, client cloudWatchClient, region string, startTime, endTime time.Time) *tsdb.QueryResult {
+		results := make(chan *tsdb.QueryResult, 1)
+		require.NoError(t, e.executeQueryBatch(context.Background(), client, region, startTime, endTime, newQueries(), results))
+		result := <-results
+		require.NoError(t, result.Error)
+		return result
+	}
+
+	t.Run("Identical batches within the TTL are served from the cache", func(t *testing.T) {
+		e := newExecutor(1, "5m")
+		client := &countingCloudWatchClient{statusCode: cloudwatch.StatusCodeComplete}
+		first := execute(t, e, client, "default", startTime, endTime)
+		second := execute(t, e, client, "default", startTime.Add(time.Second), endTime.Add(time.Second))
+		assert.Equal(t, 1, client.calls)
+		assert.Equal(t, first.Series, second.Series)
+
+		// the region is part of the key, the default region being the one of the data source
+		execute(t, e, client, "us-east-1", startTime, endTime)
+		assert.Equal(t, 1, client.calls)
+		execute(t, e, client, "eu-west-1", startTime, endTime)
+		assert.Equal(t, 2, client.calls)
+
+		// the time range is truncated to the TTL
+		execute(t, e, client, "default", startTime.Add(10*time.Minute), endTime.Add(10*time.Minute))
+		assert.Equal(t, 3, client.calls)
+	})
+
+	t.Run("Updating the data source invalidates its cached responses", func(t *testing.T) {
+		e := newExecutor(2, "300")
+		client := &countingCloudWatchClient{statusCode: cloudwatch.StatusCodeComplete}
+		execute(t, e, client, "default", startTime, endTime)
+		e.DataSource.Version++
+		execute(t, e, client, "default", startTime, endTime)
+		assert.Equal(t, 2, client.calls)
+	})
+
+	t.Run("Partial responses aren't cached", func(t *testing.T) {
+		e := newExecutor(3, "5m")
+		client := &countingCloudWatchClient{statusCode: cloudwatch.StatusCodePartialData}
+		execute(t, e, client, "default", startTime, endTime)
+		execute(t, e, client, "default", startTime, endTime)
+		assert.Equal(t, 2, client.calls)
+	})
+
+	t.Run("Data sources without a TTL aren't cached", func(t *testing.T) {
+		e := newExecutor(4, nil)
+		client := &countingCloudWatchClient{statusCode: cloudwatch.StatusCodeComplete}
+		execute(t, e, client, "default", startTime, endTime)
+		execute(t, e, client, "default", startTime, endTime)
+		assert.Equal(t, 2, client.calls)
+	})
+}
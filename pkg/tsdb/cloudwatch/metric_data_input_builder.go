@@ -1,12 +1,17 @@
 package cloudwatch
 
 import (
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 )
 
+// maxMetricDataQueries is the maximum number of metric data queries of a GetMetricData request
+const maxMetricDataQueries = 500
+
 func (e *CloudWatchExecutor) buildMetricDataInput(startTime time.Time, endTime time.Time, queries map[string]*cloudWatchQuery) (*cloudwatch.GetMetricDataInput, error) {
 	metricDataInput := &cloudwatch.GetMetricDataInput{
 		StartTime: aws.Time(startTime),
@@ -23,3 +28,90 @@ func (e *CloudWatchExecutor) buildMetricDataInput(startTime time.Time, endTime t
 
 	return metricDataInput, nil
 }
+
+// batchQueries splits the queries of a region into batches of at most maxMetricDataQueries, each
+// sent with its own GetMetricData requests. The queries of a query row, and the math expressions
+// with the queries they reference, are kept in the same batch.
+func batchQueries(queries map[string]*cloudWatchQuery) ([]map[string]*cloudWatchQuery, error) {
+	parents := make(map[string]string, len(queries))
+	var find func(id string) string
+	find = func(id string) string {
+		parent, ok := parents[id]
+		if !ok || parent == id {
+			return id
+		}
+		root := find(parent)
+		parents[id] = root
+		return root
+	}
+	union := func(a, b string) {
+		rootA, rootB := find(a), find(b)
+		if rootA == rootB {
+			return
+		}
+		// the smallest id is the root, so that the batches don't depend on the order of the queries
+		if rootB < rootA {
+			rootA, rootB = rootB, rootA
+		}
+		parents[rootB] = rootA
+	}
+
+	ids := make([]string, 0, len(queries))
+	for id := range queries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	firstIDOfRow := make(map[string]string)
+	for _, id := range ids {
+		query := queries[id]
+		if first, ok := firstIDOfRow[query.RefId]; ok {
+			union(first, id)
+		} else {
+			firstIDOfRow[query.RefId] = id
+		}
+		if query.isMathExpression() {
+			for _, referenced := range query.referencedIDs() {
+				if _, ok := queries[referenced]; ok {
+					union(id, referenced)
+				}
+			}
+		}
+	}
+
+	groups := make(map[string][]string)
+	roots := make([]string, 0)
+	for _, id := range ids {
+		root := find(id)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], id)
+	}
+
+	batches := make([]map[string]*cloudWatchQuery, 0)
+	for _, root := range roots {
+		group := groups[root]
+		if len(group) > maxMetricDataQueries {
+			return nil, fmt.Errorf("error in query %q - it's sent with %d metric queries referencing each other, more than the %d of a GetMetricData request",
+				queries[root].RefId, len(group), maxMetricDataQueries)
+		}
+
+		// the group goes into the first batch with room for it
+		var batch map[string]*cloudWatchQuery
+		for _, b := range batches {
+			if len(b)+len(group) <= maxMetricDataQueries {
+				batch = b
+				break
+			}
+		}
+		if batch == nil {
+			batch = make(map[string]*cloudWatchQuery)
+			batches = append(batches, batch)
+		}
+		for _, id := range group {
+			batch[id] = queries[id]
+		}
+	}
+	return batches, nil
+}
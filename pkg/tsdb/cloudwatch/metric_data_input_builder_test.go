@@ -0,0 +1,65 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchQueries(t *testing.T) {
+	metricQueries := func(refID string, n int) map[string]*cloudWatchQuery {
+		queries := make(map[string]*cloudWatchQuery)
+		for i := 0; i < n; i++ {
+			id := fmt.Sprintf("query%s_%d", refID, i)
+			queries[id] = &cloudWatchQuery{Id: id, RefId: refID, Namespace: "AWS/EC2", MetricName: "CPUUtilization"}
+		}
+		return queries
+	}
+	merge := func(all ...map[string]*cloudWatchQuery) map[string]*cloudWatchQuery {
+		merged := make(map[string]*cloudWatchQuery)
+		for _, queries := range all {
+			for id, query := range queries {
+				merged[id] = query
+			}
+		}
+		return merged
+	}
+
+	t.Run("Should send the queries of a region in one batch up to the maximum", func(t *testing.T) {
+		batches, err := batchQueries(merge(metricQueries("A", 300), metricQueries("B", 200)))
+		require.NoError(t, err)
+		require.Len(t, batches, 1)
+		assert.Len(t, batches[0], 500)
+	})
+
+	t.Run("Should keep the queries of a row in the same batch", func(t *testing.T) {
+		batches, err := batchQueries(merge(metricQueries("A", 300), metricQueries("B", 300), metricQueries("C", 200)))
+		require.NoError(t, err)
+		require.Len(t, batches, 2)
+		assert.Len(t, batches[0], 500)
+		assert.Contains(t, batches[0], "queryA_0")
+		assert.Contains(t, batches[0], "queryC_0")
+		assert.Len(t, batches[1], 300)
+		assert.Contains(t, batches[1], "queryB_299")
+	})
+
+	t.Run("Should keep a math expression with the queries it references", func(t *testing.T) {
+		queries := merge(metricQueries("A", 300), metricQueries("B", 300))
+		queries["e1"] = &cloudWatchQuery{Id: "e1", RefId: "C", Expression: "queryA_0 + queryB_0"}
+
+		_, err := batchQueries(queries)
+		require.EqualError(t, err, `error in query "C" - it's sent with 601 metric queries referencing each other, more than the 500 of a GetMetricData request`)
+
+		queries = merge(metricQueries("A", 200), metricQueries("B", 200), metricQueries("D", 200))
+		queries["e1"] = &cloudWatchQuery{Id: "e1", RefId: "C", Expression: "queryA_0 + queryD_0"}
+		batches, err := batchQueries(queries)
+		require.NoError(t, err)
+		require.Len(t, batches, 2)
+		assert.Len(t, batches[0], 401)
+		assert.Contains(t, batches[0], "e1")
+		assert.Contains(t, batches[0], "queryD_0")
+		assert.Contains(t, batches[1], "queryB_0")
+	})
+}
@@ -72,6 +72,9 @@ func buildSearchExpression(query *cloudWatchQuery, stat string) string {
 	}
 
 	searchTerm := fmt.Sprintf(`MetricName="%s"`, query.MetricName)
+	if query.AccountId != "" {
+		searchTerm = appendSearch(searchTerm, fmt.Sprintf(`:aws.AccountId="%s"`, query.AccountId))
+	}
 	keys := []string{}
 	for k := range knownDimensions {
 		keys = append(keys, k)
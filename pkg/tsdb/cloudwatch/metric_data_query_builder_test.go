@@ -109,6 +109,22 @@ func TestMetricDataQueryBuilder(t *testing.T) {
 					res := buildSearchExpression(query, "Average")
 					So(res, ShouldEqual, `REMOVE_EMPTY(SEARCH('{AWS/Kafka,"Cluster Name"} MetricName="CpuUser" "Cluster Name"="dev-cluster"', 'Average', 300))`)
 				})
+
+				Convey("and query is about a source account", func() {
+					query := &cloudWatchQuery{
+						Namespace:  "AWS/EC2",
+						MetricName: "CPUUtilization",
+						Dimensions: map[string][]string{
+							"InstanceId": {"*"},
+						},
+						Period:     300,
+						MatchExact: matchExact,
+						AccountId:  "123456789012",
+					}
+
+					res := buildSearchExpression(query, "Average")
+					So(res, ShouldEqual, `REMOVE_EMPTY(SEARCH('{AWS/EC2,"InstanceId"} MetricName="CPUUtilization" :aws.AccountId="123456789012"', 'Average', 300))`)
+				})
 			})
 
 			Convey("and query should not be matched exact", func() {
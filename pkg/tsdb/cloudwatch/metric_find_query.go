@@ -7,32 +7,25 @@ import (
 	"reflect"
 	"sort"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/aws/aws-sdk-go/aws/awsutil"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/tsdb"
 )
 
 type suggestData struct {
-	Text  string
-	Value string
+	Text  string `json:"text"`
+	Value string `json:"value"`
 }
 
-type CustomMetricsCache struct {
-	Expire time.Time
-	Cache  []string
-}
-
-var customMetricsMetricsMap = make(map[string]map[string]map[string]*CustomMetricsCache)
-var customMetricsDimensionsMap = make(map[string]map[string]map[string]*CustomMetricsCache)
 var metricsMap = map[string][]string{
 	"AWS/ACMPrivateCA":      {"CRLGenerated", "Failure", "MisconfiguredCRLBucket", "Success", "Time"},
 	"AWS/AmazonMQ":          {"ConsumerCount", "CpuCreditBalance", "CpuUtilization", "CurrentConnectionsCount", "DequeueCount", "DispatchCount", "EnqueueCount", "EnqueueTime", "ExpiredCount", "HeapUsage", "InflightCount", "JournalFilesForFastRecovery", "JournalFilesForFullRecovery", "MemoryUsage", "NetworkIn", "NetworkOut", "OpenTransactionsCount", "ProducerCount", "QueueSize", "StorePercentUsage", "TotalConsumerCount", "TotalMessageCount", "TotalProducerCount"},
@@ -220,7 +213,21 @@ var dimensionsMap = map[string][]string{
 	"AWS/Cassandra":         {"Keyspace", "Operation", "TableName"},
 }
 
-var regionCache sync.Map
+// metadataCacheTTL is how long the regions, namespaces and accounts of the data sources are cached,
+// new regions, custom namespaces and linked accounts show up once they expire
+const metadataCacheTTL = 5 * time.Minute
+
+// metadataCache holds the regions, namespaces and accounts of the data sources, by data source id
+// and version so that the entries of a data source are dropped when it's updated
+var metadataCache = localcache.New(metadataCacheTTL, 2*metadataCacheTTL)
+
+// fallbackRegions are the regions listed when DescribeRegions fails, like when the credentials
+// aren't allowed to call it
+var fallbackRegions = []string{
+	"ap-east-1", "ap-northeast-1", "ap-northeast-2", "ap-northeast-3", "ap-south-1", "ap-southeast-1", "ap-southeast-2", "ca-central-1",
+	"eu-central-1", "eu-north-1", "eu-west-1", "eu-west-2", "eu-west-3", "me-south-1", "sa-east-1", "us-east-1", "us-east-2", "us-west-1", "us-west-2",
+	"cn-north-1", "cn-northwest-1", "us-gov-east-1", "us-gov-west-1", "us-isob-east-1", "us-iso-east-1",
+}
 
 func (e *CloudWatchExecutor) executeMetricFindQuery(ctx context.Context, queryContext *tsdb.TsdbQuery) (*tsdb.Response, error) {
 	firstQuery := queryContext.Queries[0]
@@ -246,6 +253,10 @@ func (e *CloudWatchExecutor) executeMetricFindQuery(ctx context.Context, queryCo
 		data, err = e.handleGetEc2InstanceAttribute(ctx, parameters, queryContext)
 	case "resource_arns":
 		data, err = e.handleGetResourceArns(ctx, parameters, queryContext)
+	case "resource_ids":
+		data, err = e.handleGetResourceIds(ctx, parameters, queryContext)
+	case "accounts":
+		data, err = e.handleGetAccounts(ctx, parameters, queryContext)
 	}
 	if err != nil {
 		return nil, err
@@ -299,44 +310,34 @@ func parseMultiSelectValue(input string) []string {
 	return []string{trimmedInput}
 }
 
-// Whenever this list is updated, the frontend list should also be updated.
-// Please update the region list in public/app/plugins/datasource/cloudwatch/partials/config.html
+// metadataCacheKey is the key of a metadata entry of the data source of the executor
+func (e *CloudWatchExecutor) metadataCacheKey(kind string, args ...string) string {
+	return fmt.Sprintf("%d/%d/%s/%s", e.DataSource.Id, e.DataSource.Version, kind, strings.Join(args, "/"))
+}
+
+// handleGetRegions lists the regions enabled for the account of the data source with
+// DescribeRegions, so that new regions show up without a release. The fallback regions are
+// listed when it fails, without being cached.
 func (e *CloudWatchExecutor) handleGetRegions(ctx context.Context, parameters *simplejson.Json, queryContext *tsdb.TsdbQuery) ([]suggestData, error) {
-	dsInfo := e.getDsInfo("default")
-	profile := dsInfo.Profile
-	if cache, ok := regionCache.Load(profile); ok {
-		if cache2, ok2 := cache.([]suggestData); ok2 {
-			return cache2, nil
-		}
+	key := e.metadataCacheKey("regions")
+	if cached, ok := metadataCache.Get(key); ok {
+		return cached.([]suggestData), nil
 	}
 
-	regions := []string{
-		"ap-east-1", "ap-northeast-1", "ap-northeast-2", "ap-northeast-3", "ap-south-1", "ap-southeast-1", "ap-southeast-2", "ca-central-1",
-		"eu-central-1", "eu-north-1", "eu-west-1", "eu-west-2", "eu-west-3", "me-south-1", "sa-east-1", "us-east-1", "us-east-2", "us-west-1", "us-west-2",
-		"cn-north-1", "cn-northwest-1", "us-gov-east-1", "us-gov-west-1", "us-isob-east-1", "us-iso-east-1",
-	}
 	err := e.ensureClientSession("default")
 	if err != nil {
 		return nil, err
 	}
+
+	var regions []string
 	r, err := e.ec2Svc.DescribeRegions(&ec2.DescribeRegionsInput{})
 	if err != nil {
 		// ignore error for backward compatibility
 		plog.Error("Failed to get regions", "error", err)
+		regions = append(regions, fallbackRegions...)
 	} else {
 		for _, region := range r.Regions {
-			exists := false
-
-			for _, existingRegion := range regions {
-				if existingRegion == *region.RegionName {
-					exists = true
-					break
-				}
-			}
-
-			if !exists {
-				regions = append(regions, *region.RegionName)
-			}
+			regions = append(regions, aws.StringValue(region.RegionName))
 		}
 	}
 	sort.Strings(regions)
@@ -345,23 +346,73 @@ func (e *CloudWatchExecutor) handleGetRegions(ctx context.Context, parameters *s
 	for _, region := range regions {
 		result = append(result, suggestData{Text: region, Value: region})
 	}
-	regionCache.Store(profile, result)
+	if err == nil {
+		metadataCache.Set(key, result, metadataCacheTTL)
+	}
 
 	return result, nil
 }
 
+// handleGetNamespaces lists the namespaces of the AWS services, the custom namespaces of the data
+// source and the namespaces of the metrics of the region, of a source account when the accountId
+// parameter is set, so that the custom namespaces published by applications show up.
 func (e *CloudWatchExecutor) handleGetNamespaces(ctx context.Context, parameters *simplejson.Json, queryContext *tsdb.TsdbQuery) ([]suggestData, error) {
+	region := parameters.Get("region").MustString("default")
+	accountID := parameters.Get("accountId").MustString()
+	if accountID == "all" {
+		accountID = ""
+	}
+	if accountID != "" && !validAccountID.MatchString(accountID) {
+		return nil, fmt.Errorf("invalid account ID %q", accountID)
+	}
+
+	key := e.metadataCacheKey("namespaces", region, accountID)
+	if cached, ok := metadataCache.Get(key); ok {
+		return cached.([]suggestData), nil
+	}
+
+	customNamespaces := e.DataSource.JsonData.Get("customMetricsNamespaces").MustString()
+	result, err := listNamespaces(ctx, region, accountID, customNamespaces, e.cloudwatchListMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	metadataCache.Set(key, result, metadataCacheTTL)
+	return result, nil
+}
+
+func listNamespaces(ctx context.Context, region string, accountID string, customNamespaces string, listMetrics listMetricsFunc) ([]suggestData, error) {
+	seen := make(map[string]bool)
 	keys := []string{}
+	add := func(namespace string) {
+		namespace = strings.TrimSpace(namespace)
+		if namespace != "" && !seen[namespace] {
+			seen[namespace] = true
+			keys = append(keys, namespace)
+		}
+	}
+
 	for key := range metricsMap {
-		keys = append(keys, key)
+		add(key)
 	}
-	customNamespaces := e.DataSource.JsonData.Get("customMetricsNamespaces").MustString()
 	if customNamespaces != "" {
-		keys = append(keys, strings.Split(customNamespaces, ",")...)
+		for _, namespace := range strings.Split(customNamespaces, ",") {
+			add(namespace)
+		}
+	}
+
+	metrics, err := listMetrics(ctx, region, "", "", nil, accountID)
+	if err != nil {
+		// the known namespaces are still listed when the metrics can't be
+		plog.Warn("Failed to list the namespaces of the metrics", "region", region, "error", err)
+	} else {
+		for _, metric := range metrics.Metrics {
+			add(aws.StringValue(metric.Namespace))
+		}
 	}
 	sort.Strings(keys)
 
-	result := make([]suggestData, 0)
+	result := make([]suggestData, 0, len(keys))
 	for _, key := range keys {
 		result = append(result, suggestData{Text: key, Value: key})
 	}
@@ -380,13 +431,11 @@ func (e *CloudWatchExecutor) handleGetMetrics(ctx context.Context, parameters *s
 			return nil, fmt.Errorf("unable to find namespace %q", namespace)
 		}
 	} else {
-		var err error
-		dsInfo := e.getDsInfo(region)
-		dsInfo.Namespace = namespace
-
-		if namespaceMetrics, err = getMetricsForCustomMetrics(dsInfo, getAllMetrics); err != nil {
+		metrics, _, err := e.indexedMetrics(ctx, region, namespace, "")
+		if err != nil {
 			return nil, errors.New("Unable to call AWS API")
 		}
+		namespaceMetrics = uniqueMetricNames(metrics)
 	}
 	sort.Strings(namespaceMetrics)
 
@@ -409,13 +458,11 @@ func (e *CloudWatchExecutor) handleGetDimensions(ctx context.Context, parameters
 			return nil, fmt.Errorf("unable to find dimension %q", namespace)
 		}
 	} else {
-		var err error
-		dsInfo := e.getDsInfo(region)
-		dsInfo.Namespace = namespace
-
-		if dimensionValues, err = getDimensionsForCustomMetrics(dsInfo, getAllMetrics); err != nil {
+		metrics, _, err := e.indexedMetrics(ctx, region, namespace, "")
+		if err != nil {
 			return nil, errors.New("Unable to call AWS API")
 		}
+		dimensionValues = uniqueDimensionKeys(metrics)
 	}
 	sort.Strings(dimensionValues)
 
@@ -433,7 +480,22 @@ func (e *CloudWatchExecutor) handleGetDimensionValues(ctx context.Context, param
 	metricName := parameters.Get("metricName").MustString()
 	dimensionKey := parameters.Get("dimensionKey").MustString()
 	dimensionsJson := parameters.Get("dimensions").MustMap()
+	accountID := parameters.Get("accountId").MustString()
+	if accountID == "all" {
+		accountID = ""
+	}
 
+	metrics, err := e.listMetrics(ctx, region, namespace, metricName, dimensionFilters(dimensionsJson), accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return dimensionValuesOf(metrics.Metrics, dimensionKey), nil
+}
+
+// dimensionFilters returns the ListMetrics filters of dimensions given as a JSON object whose
+// values are either a value or a list of values
+func dimensionFilters(dimensionsJson map[string]interface{}) []*cloudwatch.DimensionFilter {
 	var dimensions []*cloudwatch.DimensionFilter
 	for k, v := range dimensionsJson {
 		if vv, ok := v.(string); ok {
@@ -450,15 +512,14 @@ func (e *CloudWatchExecutor) handleGetDimensionValues(ctx context.Context, param
 			}
 		}
 	}
+	return dimensions
+}
 
-	metrics, err := e.cloudwatchListMetrics(region, namespace, metricName, dimensions)
-	if err != nil {
-		return nil, err
-	}
-
+// dimensionValuesOf returns the sorted values of a dimension of metrics
+func dimensionValuesOf(metrics []*cloudwatch.Metric, dimensionKey string) []suggestData {
 	result := make([]suggestData, 0)
 	dupCheck := make(map[string]bool)
-	for _, metric := range metrics.Metrics {
+	for _, metric := range metrics {
 		for _, dim := range metric.Dimensions {
 			if *dim.Name == dimensionKey {
 				if _, exists := dupCheck[*dim.Value]; exists {
@@ -474,18 +535,23 @@ func (e *CloudWatchExecutor) handleGetDimensionValues(ctx context.Context, param
 	sort.Slice(result, func(i, j int) bool {
 		return result[i].Text < result[j].Text
 	})
-
-	return result, nil
+	return result
 }
 
 func (e *CloudWatchExecutor) ensureClientSession(region string) error {
 	if e.ec2Svc == nil {
-		dsInfo := e.getDsInfo(region)
-		cfg, err := getAwsConfig(dsInfo)
+		dsInfo, err := e.getDsInfo(region)
 		if err != nil {
-			return fmt.Errorf("Failed to call ec2:getAwsConfig, %w", err)
+			return err
 		}
-		sess, err := session.NewSession(cfg)
+		cfg, err := GetAwsConfig(dsInfo)
+		if err != nil {
+			return fmt.Errorf("Failed to call ec2:GetAwsConfig, %w", err)
+		}
+		if err := setEndpoint(cfg, dsInfo, ec2.EndpointsID); err != nil {
+			return err
+		}
+		sess, err := newSession(cfg)
 		if err != nil {
 			return fmt.Errorf("Failed to call ec2:NewSession, %w", err)
 		}
@@ -607,12 +673,18 @@ func (e *CloudWatchExecutor) handleGetEc2InstanceAttribute(ctx context.Context,
 
 func (e *CloudWatchExecutor) ensureRGTAClientSession(region string) error {
 	if e.rgtaSvc == nil {
-		dsInfo := e.getDsInfo(region)
-		cfg, err := getAwsConfig(dsInfo)
+		dsInfo, err := e.getDsInfo(region)
+		if err != nil {
+			return err
+		}
+		cfg, err := GetAwsConfig(dsInfo)
 		if err != nil {
-			return fmt.Errorf("Failed to call ec2:getAwsConfig, %w", err)
+			return fmt.Errorf("Failed to call ec2:GetAwsConfig, %w", err)
 		}
-		sess, err := session.NewSession(cfg)
+		if err := setEndpoint(cfg, dsInfo, resourcegroupstaggingapi.EndpointsID); err != nil {
+			return err
+		}
+		sess, err := newSession(cfg)
 		if err != nil {
 			return fmt.Errorf("Failed to call ec2:NewSession, %w", err)
 		}
@@ -621,42 +693,62 @@ func (e *CloudWatchExecutor) ensureRGTAClientSession(region string) error {
 	return nil
 }
 
-func (e *CloudWatchExecutor) handleGetResourceArns(ctx context.Context, parameters *simplejson.Json, queryContext *tsdb.TsdbQuery) ([]suggestData, error) {
+// tagFilters returns the filters of the tags parameter of resource_arns and resource_ids, which maps
+// the tag keys to a value or a list of values. A tag without values matches any of its values.
+func tagFilters(tags map[string]interface{}) []*resourcegroupstaggingapi.TagFilter {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	filters := make([]*resourcegroupstaggingapi.TagFilter, 0, len(keys))
+	for _, k := range keys {
+		var values []*string
+		switch v := tags[k].(type) {
+		case string:
+			values = append(values, aws.String(v))
+		case []string:
+			values = aws.StringSlice(v)
+		case []interface{}:
+			for _, vv := range v {
+				if value, ok := vv.(string); ok {
+					values = append(values, aws.String(value))
+				}
+			}
+		}
+		filters = append(filters, &resourcegroupstaggingapi.TagFilter{
+			Key:    aws.String(k),
+			Values: values,
+		})
+	}
+	return filters
+}
+
+func (e *CloudWatchExecutor) getTaggedResources(parameters *simplejson.Json) ([]*resourcegroupstaggingapi.ResourceTagMapping, error) {
 	region := parameters.Get("region").MustString()
 	resourceType := parameters.Get("resourceType").MustString()
-	filterJson := parameters.Get("tags").MustMap()
 
 	err := e.ensureRGTAClientSession(region)
 	if err != nil {
 		return nil, err
 	}
 
-	var filters []*resourcegroupstaggingapi.TagFilter
-	for k, v := range filterJson {
-		if vv, ok := v.([]interface{}); ok {
-			var vvvvv []*string
-			for _, vvv := range vv {
-				if vvvv, ok := vvv.(string); ok {
-					vvvvv = append(vvvvv, &vvvv)
-				}
-			}
-			filters = append(filters, &resourcegroupstaggingapi.TagFilter{
-				Key:    aws.String(k),
-				Values: vvvvv,
-			})
-		}
+	resources, err := e.resourceGroupsGetResources(region, tagFilters(parameters.Get("tags").MustMap()), []*string{aws.String(resourceType)})
+	if err != nil {
+		return nil, err
 	}
+	return resources.ResourceTagMappingList, nil
+}
 
-	var resourceTypes []*string
-	resourceTypes = append(resourceTypes, &resourceType)
-
-	resources, err := e.resourceGroupsGetResources(region, filters, resourceTypes)
+func (e *CloudWatchExecutor) handleGetResourceArns(ctx context.Context, parameters *simplejson.Json, queryContext *tsdb.TsdbQuery) ([]suggestData, error) {
+	resources, err := e.getTaggedResources(parameters)
 	if err != nil {
 		return nil, err
 	}
 
 	result := make([]suggestData, 0)
-	for _, resource := range resources.ResourceTagMappingList {
+	for _, resource := range resources {
 		data := *resource.ResourceARN
 		result = append(result, suggestData{Text: data, Value: data})
 	}
@@ -664,23 +756,68 @@ func (e *CloudWatchExecutor) handleGetResourceArns(ctx context.Context, paramete
 	return result, nil
 }
 
-func (e *CloudWatchExecutor) cloudwatchListMetrics(region string, namespace string, metricName string, dimensions []*cloudwatch.DimensionFilter) (*cloudwatch.ListMetricsOutput, error) {
+// handleGetResourceIds returns the ids of the resources with tags, like the ids of the EC2 instances,
+// the identifiers of the RDS clusters or the names of the Lambda functions, which are the values of
+// their dimensions
+func (e *CloudWatchExecutor) handleGetResourceIds(ctx context.Context, parameters *simplejson.Json, queryContext *tsdb.TsdbQuery) ([]suggestData, error) {
+	resources, err := e.getTaggedResources(parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]suggestData, 0)
+	for _, resource := range resources {
+		id, err := resourceIDFromARN(aws.StringValue(resource.ResourceARN))
+		if err != nil {
+			plog.Warn("Skipping resource with an invalid ARN", "arn", aws.StringValue(resource.ResourceARN), "error", err)
+			continue
+		}
+		result = append(result, suggestData{Text: id, Value: id})
+	}
+
+	return result, nil
+}
+
+// resourceIDFromARN returns the id of a resource, the part of its ARN after its resource type. The
+// target groups of load balancers keep their type, like in their dimension.
+func resourceIDFromARN(resourceARN string) (string, error) {
+	parsed, err := arn.Parse(resourceARN)
+	if err != nil {
+		return "", err
+	}
+
+	if parsed.Service == "elasticloadbalancing" && strings.HasPrefix(parsed.Resource, "targetgroup/") {
+		return parsed.Resource, nil
+	}
+	if i := strings.IndexAny(parsed.Resource, "/:"); i >= 0 {
+		return parsed.Resource[i+1:], nil
+	}
+	return parsed.Resource, nil
+}
+
+// cloudwatchListMetrics lists the metrics of a namespace, or of all the namespaces, of a source
+// account when accountID is set
+func (e *CloudWatchExecutor) cloudwatchListMetrics(ctx context.Context, region string, namespace string, metricName string, dimensions []*cloudwatch.DimensionFilter, accountID string) (*cloudwatch.ListMetricsOutput, error) {
 	svc, err := e.getClient(region)
 	if err != nil {
 		return nil, err
 	}
 
 	params := &cloudwatch.ListMetricsInput{
-		Namespace:  aws.String(namespace),
 		Dimensions: dimensions,
 	}
 
+	// the metrics of all the namespaces are listed without one
+	if namespace != "" {
+		params.Namespace = aws.String(namespace)
+	}
+
 	if metricName != "" {
 		params.MetricName = aws.String(metricName)
 	}
 
 	var resp cloudwatch.ListMetricsOutput
-	if err := svc.ListMetricsPages(params,
+	if err := svc.ListMetricsPagesWithContext(ctx, params,
 		func(page *cloudwatch.ListMetricsOutput, lastPage bool) bool {
 			metrics.MAwsCloudWatchListMetrics.Inc()
 			metrics, _ := awsutil.ValuesAtPath(page, "Metrics")
@@ -688,7 +825,7 @@ func (e *CloudWatchExecutor) cloudwatchListMetrics(region string, namespace stri
 				resp.Metrics = append(resp.Metrics, metric.(*cloudwatch.Metric))
 			}
 			return !lastPage
-		}); err != nil {
+		}, e.listMetricsOptions(accountID)...); err != nil {
 		return nil, fmt.Errorf("failed to call cloudwatch:ListMetrics: %w", err)
 	}
 
@@ -739,126 +876,6 @@ func (e *CloudWatchExecutor) resourceGroupsGetResources(region string, filters [
 	return &resp, nil
 }
 
-func getAllMetrics(cwData *DatasourceInfo) (cloudwatch.ListMetricsOutput, error) {
-	creds, err := getCredentials(cwData)
-	if err != nil {
-		return cloudwatch.ListMetricsOutput{}, err
-	}
-	cfg := &aws.Config{
-		Region:      aws.String(cwData.Region),
-		Credentials: creds,
-	}
-	sess, err := session.NewSession(cfg)
-	if err != nil {
-		return cloudwatch.ListMetricsOutput{}, err
-	}
-	svc := cloudwatch.New(sess, cfg)
-
-	params := &cloudwatch.ListMetricsInput{
-		Namespace: aws.String(cwData.Namespace),
-	}
-
-	var resp cloudwatch.ListMetricsOutput
-	err = svc.ListMetricsPages(params, func(page *cloudwatch.ListMetricsOutput, lastPage bool) bool {
-		metrics.MAwsCloudWatchListMetrics.Inc()
-		metrics, err := awsutil.ValuesAtPath(page, "Metrics")
-		if err != nil {
-			return !lastPage
-		}
-
-		for _, metric := range metrics {
-			resp.Metrics = append(resp.Metrics, metric.(*cloudwatch.Metric))
-		}
-		return !lastPage
-	})
-	return resp, err
-}
-
-var metricsCacheLock sync.Mutex
-
-func getMetricsForCustomMetrics(dsInfo *DatasourceInfo, getAllMetrics func(*DatasourceInfo) (cloudwatch.ListMetricsOutput, error)) ([]string, error) {
-	metricsCacheLock.Lock()
-	defer metricsCacheLock.Unlock()
-
-	if _, ok := customMetricsMetricsMap[dsInfo.Profile]; !ok {
-		customMetricsMetricsMap[dsInfo.Profile] = make(map[string]map[string]*CustomMetricsCache)
-	}
-	if _, ok := customMetricsMetricsMap[dsInfo.Profile][dsInfo.Region]; !ok {
-		customMetricsMetricsMap[dsInfo.Profile][dsInfo.Region] = make(map[string]*CustomMetricsCache)
-	}
-	if _, ok := customMetricsMetricsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace]; !ok {
-		customMetricsMetricsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace] = &CustomMetricsCache{}
-		customMetricsMetricsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace].Cache = make([]string, 0)
-	}
-
-	if customMetricsMetricsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace].Expire.After(time.Now()) {
-		return customMetricsMetricsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace].Cache, nil
-	}
-	result, err := getAllMetrics(dsInfo)
-	if err != nil {
-		return []string{}, err
-	}
-	customMetricsMetricsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace].Cache = make([]string, 0)
-	customMetricsMetricsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace].Expire = time.Now().Add(5 * time.Minute)
-
-	for _, metric := range result.Metrics {
-		if isDuplicate(customMetricsMetricsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace].Cache, *metric.MetricName) {
-			continue
-		}
-		customMetricsMetricsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace].Cache = append(customMetricsMetricsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace].Cache, *metric.MetricName)
-	}
-
-	return customMetricsMetricsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace].Cache, nil
-}
-
-var dimensionsCacheLock sync.Mutex
-
-func getDimensionsForCustomMetrics(dsInfo *DatasourceInfo, getAllMetrics func(*DatasourceInfo) (cloudwatch.ListMetricsOutput, error)) ([]string, error) {
-	dimensionsCacheLock.Lock()
-	defer dimensionsCacheLock.Unlock()
-
-	if _, ok := customMetricsDimensionsMap[dsInfo.Profile]; !ok {
-		customMetricsDimensionsMap[dsInfo.Profile] = make(map[string]map[string]*CustomMetricsCache)
-	}
-	if _, ok := customMetricsDimensionsMap[dsInfo.Profile][dsInfo.Region]; !ok {
-		customMetricsDimensionsMap[dsInfo.Profile][dsInfo.Region] = make(map[string]*CustomMetricsCache)
-	}
-	if _, ok := customMetricsDimensionsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace]; !ok {
-		customMetricsDimensionsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace] = &CustomMetricsCache{}
-		customMetricsDimensionsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace].Cache = make([]string, 0)
-	}
-
-	if customMetricsDimensionsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace].Expire.After(time.Now()) {
-		return customMetricsDimensionsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace].Cache, nil
-	}
-	result, err := getAllMetrics(dsInfo)
-	if err != nil {
-		return []string{}, err
-	}
-	customMetricsDimensionsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace].Cache = make([]string, 0)
-	customMetricsDimensionsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace].Expire = time.Now().Add(5 * time.Minute)
-
-	for _, metric := range result.Metrics {
-		for _, dimension := range metric.Dimensions {
-			if isDuplicate(customMetricsDimensionsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace].Cache, *dimension.Name) {
-				continue
-			}
-			customMetricsDimensionsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace].Cache = append(customMetricsDimensionsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace].Cache, *dimension.Name)
-		}
-	}
-
-	return customMetricsDimensionsMap[dsInfo.Profile][dsInfo.Region][dsInfo.Namespace].Cache, nil
-}
-
-func isDuplicate(nameList []string, target string) bool {
-	for _, name := range nameList {
-		if name == target {
-			return true
-		}
-	}
-	return false
-}
-
 func isCustomMetrics(namespace string) bool {
 	return strings.Index(namespace, "AWS/") != 0
 }
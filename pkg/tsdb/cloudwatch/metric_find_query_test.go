@@ -2,6 +2,7 @@ package cloudwatch
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -44,58 +45,34 @@ func (m mockedRGTA) GetResourcesPages(in *resourcegroupstaggingapi.GetResourcesI
 
 func TestCloudWatchMetrics(t *testing.T) {
 
-	t.Run("When calling getMetricsForCustomMetrics", func(t *testing.T) {
-		dsInfo := &DatasourceInfo{
-			Region:        "us-east-1",
-			Namespace:     "Foo",
-			Profile:       "default",
-			AssumeRoleArn: "",
-		}
-		f := func(dsInfo *DatasourceInfo) (cloudwatch.ListMetricsOutput, error) {
-			return cloudwatch.ListMetricsOutput{
-				Metrics: []*cloudwatch.Metric{
+	listCustomMetrics := func(ctx context.Context, fn func(page []*cloudwatch.Metric)) error {
+		fn([]*cloudwatch.Metric{
+			{
+				MetricName: aws.String("Test_MetricName"),
+				Dimensions: []*cloudwatch.Dimension{
 					{
-						MetricName: aws.String("Test_MetricName"),
-						Dimensions: []*cloudwatch.Dimension{
-							{
-								Name: aws.String("Test_DimensionName"),
-							},
-						},
+						Name: aws.String("Test_DimensionName"),
 					},
 				},
-			}, nil
-		}
-		metrics, err := getMetricsForCustomMetrics(dsInfo, f)
+			},
+		})
+		return nil
+	}
+
+	t.Run("When listing the metric names of a custom namespace", func(t *testing.T) {
+		metrics, complete, err := (&metricsIndex{}).get(context.Background(), listCustomMetrics)
 		require.NoError(t, err)
+		assert.True(t, complete)
 
-		assert.Contains(t, metrics, "Test_MetricName")
+		assert.Contains(t, uniqueMetricNames(metrics), "Test_MetricName")
 	})
 
-	t.Run("When calling getDimensionsForCustomMetrics", func(t *testing.T) {
-		dsInfo := &DatasourceInfo{
-			Region:        "us-east-1",
-			Namespace:     "Foo",
-			Profile:       "default",
-			AssumeRoleArn: "",
-		}
-		f := func(dsInfo *DatasourceInfo) (cloudwatch.ListMetricsOutput, error) {
-			return cloudwatch.ListMetricsOutput{
-				Metrics: []*cloudwatch.Metric{
-					{
-						MetricName: aws.String("Test_MetricName"),
-						Dimensions: []*cloudwatch.Dimension{
-							{
-								Name: aws.String("Test_DimensionName"),
-							},
-						},
-					},
-				},
-			}, nil
-		}
-		dimensionKeys, err := getDimensionsForCustomMetrics(dsInfo, f)
+	t.Run("When listing the dimension keys of a custom namespace", func(t *testing.T) {
+		metrics, complete, err := (&metricsIndex{}).get(context.Background(), listCustomMetrics)
 		require.NoError(t, err)
+		assert.True(t, complete)
 
-		assert.Contains(t, dimensionKeys, "Test_DimensionName")
+		assert.Contains(t, uniqueDimensionKeys(metrics), "Test_DimensionName")
 	})
 
 	t.Run("When calling handleGetRegions", func(t *testing.T) {
@@ -111,6 +88,7 @@ func TestCloudWatchMetrics(t *testing.T) {
 		jsonData := simplejson.New()
 		jsonData.Set("defaultRegion", "default")
 		executor.DataSource = &models.DataSource{
+			Id:             101,
 			JsonData:       jsonData,
 			SecureJsonData: securejsondata.SecureJsonData{},
 		}
@@ -118,9 +96,35 @@ func TestCloudWatchMetrics(t *testing.T) {
 		result, err := executor.handleGetRegions(context.Background(), simplejson.New(), &tsdb.TsdbQuery{})
 		require.NoError(t, err)
 
-		assert.Equal(t, "ap-east-1", result[0].Text)
-		assert.Equal(t, "ap-northeast-1", result[1].Text)
-		assert.Equal(t, "ap-northeast-2", result[2].Text)
+		assert.Equal(t, []suggestData{{Text: "ap-northeast-2", Value: "ap-northeast-2"}}, result)
+	})
+
+	t.Run("When calling listNamespaces", func(t *testing.T) {
+		var listedNamespace *string
+		listMetrics := func(ctx context.Context, region string, namespace string, metricName string, dimensions []*cloudwatch.DimensionFilter, accountID string) (*cloudwatch.ListMetricsOutput, error) {
+			listedNamespace = &namespace
+			return &cloudwatch.ListMetricsOutput{Metrics: []*cloudwatch.Metric{
+				{Namespace: aws.String("MyApp"), MetricName: aws.String("Requests")},
+				{Namespace: aws.String("AWS/EC2"), MetricName: aws.String("CPUUtilization")},
+				{Namespace: aws.String("MyApp"), MetricName: aws.String("Errors")},
+			}}, nil
+		}
+
+		result, err := listNamespaces(context.Background(), "us-east-1", "", "Custom, MyApp", listMetrics)
+		require.NoError(t, err)
+		require.NotNil(t, listedNamespace)
+		assert.Empty(t, *listedNamespace)
+		assert.Contains(t, result, suggestData{Text: "MyApp", Value: "MyApp"})
+		assert.Contains(t, result, suggestData{Text: "Custom", Value: "Custom"})
+		assert.Contains(t, result, suggestData{Text: "AWS/EC2", Value: "AWS/EC2"})
+		assert.Len(t, result, len(metricsMap)+2)
+
+		failing := func(ctx context.Context, region string, namespace string, metricName string, dimensions []*cloudwatch.DimensionFilter, accountID string) (*cloudwatch.ListMetricsOutput, error) {
+			return nil, errors.New("access denied")
+		}
+		result, err = listNamespaces(context.Background(), "us-east-1", "", "", failing)
+		require.NoError(t, err)
+		assert.Len(t, result, len(metricsMap))
 	})
 
 	t.Run("When calling handleGetEc2InstanceAttribute", func(t *testing.T) {
@@ -259,6 +263,65 @@ func TestCloudWatchMetrics(t *testing.T) {
 		assert.Equal(t, "arn:aws:ec2:us-east-1:123456789012:instance/i-76543210987654321", result[1].Text)
 		assert.Equal(t, "arn:aws:ec2:us-east-1:123456789012:instance/i-76543210987654321", result[1].Value)
 	})
+
+	t.Run("When calling handleGetResourceIds", func(t *testing.T) {
+		executor := &CloudWatchExecutor{
+			rgtaSvc: mockedRGTA{
+				Resp: resourcegroupstaggingapi.GetResourcesOutput{
+					ResourceTagMappingList: []*resourcegroupstaggingapi.ResourceTagMapping{
+						{ResourceARN: aws.String("arn:aws:rds:us-east-1:123456789012:cluster:orders")},
+						{ResourceARN: aws.String("arn:aws:lambda:us-east-1:123456789012:function:checkout")},
+						{ResourceARN: aws.String("invalid")},
+					},
+				},
+			},
+		}
+
+		json := simplejson.New()
+		json.Set("region", "us-east-1")
+		json.Set("resourceType", "rds:cluster")
+		json.Set("tags", map[string]interface{}{"Environment": "production"})
+		result, err := executor.handleGetResourceIds(context.Background(), json, &tsdb.TsdbQuery{})
+		require.NoError(t, err)
+
+		assert.Equal(t, []suggestData{
+			{Text: "orders", Value: "orders"},
+			{Text: "checkout", Value: "checkout"},
+		}, result)
+	})
+}
+
+func TestTagFilters(t *testing.T) {
+	filters := tagFilters(map[string]interface{}{
+		"Team":        []interface{}{"sysops", "dev"},
+		"Environment": "production",
+		"Service":     []string{},
+	})
+
+	assert.Equal(t, []*resourcegroupstaggingapi.TagFilter{
+		{Key: aws.String("Environment"), Values: aws.StringSlice([]string{"production"})},
+		{Key: aws.String("Service"), Values: aws.StringSlice([]string{})},
+		{Key: aws.String("Team"), Values: aws.StringSlice([]string{"sysops", "dev"})},
+	}, filters)
+}
+
+func TestResourceIDFromARN(t *testing.T) {
+	testCases := map[string]string{
+		"arn:aws:ec2:us-east-1:123456789012:instance/i-12345678901234567":                     "i-12345678901234567",
+		"arn:aws:rds:us-east-1:123456789012:db:orders-1":                                      "orders-1",
+		"arn:aws:lambda:us-east-1:123456789012:function:checkout":                             "checkout",
+		"arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/web/50dc6c495c": "app/web/50dc6c495c",
+		"arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/web/73e2d6bc24d8a06": "targetgroup/web/73e2d6bc24d8a06",
+		"arn:aws:sqs:us-east-1:123456789012:orders":                                           "orders",
+	}
+	for resourceARN, expected := range testCases {
+		id, err := resourceIDFromARN(resourceARN)
+		require.NoError(t, err)
+		assert.Equal(t, expected, id, resourceARN)
+	}
+
+	_, err := resourceIDFromARN("i-12345678901234567")
+	require.Error(t, err)
 }
 
 func TestParseMultiSelectValue(t *testing.T) {
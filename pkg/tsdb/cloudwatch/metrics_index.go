@@ -0,0 +1,279 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+)
+
+// The metrics of the custom namespaces, which can have tens of thousands of them, are listed in the
+// background into an index, page after page, instead of with a ListMetrics call per request of
+// the query editor. The requests get the metrics listed so far when the listing takes longer than
+// metricsIndexWait, and the next requests get more.
+var (
+	// metricsIndexTTL is how long an index is used before the namespace is listed again. The
+	// expired index is used while it's listed again.
+	metricsIndexTTL = 5 * time.Minute
+	// metricsIndexWait is how long a request waits for a listing in progress
+	metricsIndexWait = 10 * time.Second
+	// metricsIndexListTimeout is how long a namespace may take to be listed
+	metricsIndexListTimeout = 10 * time.Minute
+)
+
+// recentlyActiveParams are the parameters of ListMetrics listing only the metrics published in the
+// past three hours, the only period of RecentlyActive. aws-sdk-go v1.29 predates it, so it's added
+// to the query protocol parameters of the requests.
+var recentlyActiveParams = url.Values{"RecentlyActive": {"PT3H"}}
+
+// metricsIndex holds the metrics of a namespace of a data source.
+type metricsIndex struct {
+	mu sync.Mutex
+	// metrics of the last complete listing
+	metrics  []*cloudwatch.Metric
+	listedAt time.Time
+	complete bool
+	// listing in progress, and the metrics it listed so far
+	listing bool
+	pending []*cloudwatch.Metric
+	done    chan struct{}
+	err     error
+}
+
+var (
+	metricsIndexesLock sync.Mutex
+	// metricsIndexes by data source id and version, region, account and namespace
+	metricsIndexes = make(map[string]*metricsIndex)
+)
+
+// getMetricsIndex returns the index of a namespace, dropping the indexes of the previous versions of
+// the data source
+func getMetricsIndex(datasourceID int64, version int, region, accountID, namespace string) *metricsIndex {
+	key := fmt.Sprintf("%d/%d/%s/%s/%s", datasourceID, version, region, accountID, namespace)
+	current := fmt.Sprintf("%d/%d/", datasourceID, version)
+
+	metricsIndexesLock.Lock()
+	defer metricsIndexesLock.Unlock()
+	if index, ok := metricsIndexes[key]; ok {
+		return index
+	}
+	for k := range metricsIndexes {
+		if strings.HasPrefix(k, fmt.Sprintf("%d/", datasourceID)) && !strings.HasPrefix(k, current) {
+			delete(metricsIndexes, k)
+		}
+	}
+	index := &metricsIndex{}
+	metricsIndexes[key] = index
+	return index
+}
+
+// evictMetricsIndexes drops the indexes of a data source
+func evictMetricsIndexes(datasourceID int64) {
+	metricsIndexesLock.Lock()
+	defer metricsIndexesLock.Unlock()
+	for k := range metricsIndexes {
+		if strings.HasPrefix(k, fmt.Sprintf("%d/", datasourceID)) {
+			delete(metricsIndexes, k)
+		}
+	}
+}
+
+// listPagesFunc lists the metrics of a namespace, calling fn with each page
+type listPagesFunc func(ctx context.Context, fn func(page []*cloudwatch.Metric)) error
+
+// get returns the metrics of the index, listing them again when it expired. It waits for the
+// first listing of the namespace until ctx is done or for metricsIndexWait, and then returns the
+// metrics listed so far, telling they're partial.
+func (index *metricsIndex) get(ctx context.Context, list listPagesFunc) ([]*cloudwatch.Metric, bool, error) {
+	index.mu.Lock()
+	if !index.listing && (!index.complete || time.Since(index.listedAt) > metricsIndexTTL) {
+		index.startListing(list)
+	}
+	if index.complete {
+		defer index.mu.Unlock()
+		return index.metrics, true, nil
+	}
+	done := index.done
+	index.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	case <-time.After(metricsIndexWait):
+	}
+
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	if index.complete {
+		return index.metrics, true, nil
+	}
+	if index.err != nil && len(index.pending) == 0 {
+		return nil, false, index.err
+	}
+	return index.pending, false, nil
+}
+
+// startListing lists the namespace in the background, the metrics being added to the pending ones
+// page after page. The caller holds the lock of the index.
+func (index *metricsIndex) startListing(list listPagesFunc) {
+	index.listing = true
+	index.pending = nil
+	index.err = nil
+	index.done = make(chan struct{})
+
+	go func(done chan struct{}) {
+		defer close(done)
+		ctx, cancel := context.WithTimeout(context.Background(), metricsIndexListTimeout)
+		defer cancel()
+
+		err := list(ctx, func(page []*cloudwatch.Metric) {
+			index.mu.Lock()
+			defer index.mu.Unlock()
+			// a new slice is made when the pending metrics are added to, so that the slices
+			// returned by get aren't changed
+			pending := make([]*cloudwatch.Metric, len(index.pending), len(index.pending)+len(page))
+			copy(pending, index.pending)
+			index.pending = append(pending, page...)
+		})
+
+		index.mu.Lock()
+		defer index.mu.Unlock()
+		index.listing = false
+		if err != nil {
+			plog.Warn("Failed to list the metrics of a namespace", "error", err)
+			index.err = err
+			return
+		}
+		index.metrics, index.pending = index.pending, nil
+		index.listedAt = time.Now()
+		index.complete = true
+	}(index.done)
+}
+
+// listMetrics lists the metrics of a namespace matching a metric name and dimension filters. The
+// metrics of the custom namespaces are filtered from their index.
+func (e *CloudWatchExecutor) listMetrics(ctx context.Context, region string, namespace string, metricName string, dimensions []*cloudwatch.DimensionFilter, accountID string) (*cloudwatch.ListMetricsOutput, error) {
+	if namespace == "" || !isCustomMetrics(namespace) {
+		return e.cloudwatchListMetrics(ctx, region, namespace, metricName, dimensions, accountID)
+	}
+
+	namespaceMetrics, _, err := e.indexedMetrics(ctx, region, namespace, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp cloudwatch.ListMetricsOutput
+	for _, metric := range namespaceMetrics {
+		if metricName != "" && aws.StringValue(metric.MetricName) != metricName {
+			continue
+		}
+		if matchesDimensionFilters(metric, dimensions) {
+			resp.Metrics = append(resp.Metrics, metric)
+		}
+	}
+	return &resp, nil
+}
+
+// matchesDimensionFilters tells whether a metric has the dimensions of filters, like ListMetrics
+func matchesDimensionFilters(metric *cloudwatch.Metric, filters []*cloudwatch.DimensionFilter) bool {
+	for _, filter := range filters {
+		found := false
+		for _, dim := range metric.Dimensions {
+			if aws.StringValue(dim.Name) == aws.StringValue(filter.Name) &&
+				(filter.Value == nil || aws.StringValue(dim.Value) == aws.StringValue(filter.Value)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// indexedMetrics returns the metrics of a custom namespace from its index, and whether they're all
+// of its metrics
+func (e *CloudWatchExecutor) indexedMetrics(ctx context.Context, region string, namespace string, accountID string) ([]*cloudwatch.Metric, bool, error) {
+	index := getMetricsIndex(e.DataSource.Id, e.DataSource.Version, e.getRegion(region), accountID, namespace)
+	return index.get(ctx, func(ctx context.Context, fn func(page []*cloudwatch.Metric)) error {
+		return e.listMetricsPages(ctx, region, namespace, accountID, fn)
+	})
+}
+
+// listMetricsPages lists all the metrics of a namespace with ListMetrics, calling fn with each page
+func (e *CloudWatchExecutor) listMetricsPages(ctx context.Context, region string, namespace string, accountID string, fn func(page []*cloudwatch.Metric)) error {
+	svc, err := e.getClient(region)
+	if err != nil {
+		return err
+	}
+
+	params := &cloudwatch.ListMetricsInput{Namespace: aws.String(namespace)}
+	if err := svc.ListMetricsPagesWithContext(ctx, params, func(page *cloudwatch.ListMetricsOutput, lastPage bool) bool {
+		metrics.MAwsCloudWatchListMetrics.Inc()
+		fn(page.Metrics)
+		return !lastPage
+	}, e.listMetricsOptions(accountID)...); err != nil {
+		return fmt.Errorf("failed to call cloudwatch:ListMetrics: %w", err)
+	}
+	return nil
+}
+
+// listMetricsOptions returns the options of the ListMetrics requests of a source account when
+// accountID is set, listing only the recently active metrics when the data source sets
+// listMetricsRecentlyActive
+func (e *CloudWatchExecutor) listMetricsOptions(accountID string) []request.Option {
+	params := url.Values{}
+	if accountID != "" {
+		for name, values := range listMetricsAccountParams(accountID) {
+			params[name] = values
+		}
+	}
+	if e.DataSource != nil && e.DataSource.JsonData != nil && e.DataSource.JsonData.Get("listMetricsRecentlyActive").MustBool(false) {
+		for name, values := range recentlyActiveParams {
+			params[name] = values
+		}
+	}
+
+	if len(params) == 0 {
+		return nil
+	}
+	return []request.Option{withQueryParams(params)}
+}
+
+// uniqueMetricNames returns the names of metrics, without duplicates
+func uniqueMetricNames(metrics []*cloudwatch.Metric) []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, metric := range metrics {
+		name := aws.StringValue(metric.MetricName)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// uniqueDimensionKeys returns the dimension keys of metrics, without duplicates
+func uniqueDimensionKeys(metrics []*cloudwatch.Metric) []string {
+	seen := make(map[string]bool)
+	keys := make([]string, 0)
+	for _, metric := range metrics {
+		for _, dim := range metric.Dimensions {
+			key := aws.StringValue(dim.Name)
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,156 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsIndex(t *testing.T) {
+	newPage := func(page int) []*cloudwatch.Metric {
+		return []*cloudwatch.Metric{{MetricName: aws.String(fmt.Sprintf("metric%d", page))}}
+	}
+
+	t.Run("The metrics listed so far are returned while the first listing is in progress", func(t *testing.T) {
+		wait := metricsIndexWait
+		metricsIndexWait = 10 * time.Millisecond
+		t.Cleanup(func() { metricsIndexWait = wait })
+
+		next, listed := make(chan bool), make(chan struct{})
+		list := func(ctx context.Context, fn func(page []*cloudwatch.Metric)) error {
+			for page := 0; <-next; page++ {
+				fn(newPage(page))
+				listed <- struct{}{}
+			}
+			return nil
+		}
+		listPage := func() {
+			next <- true
+			<-listed
+		}
+
+		index := &metricsIndex{}
+		metrics, complete, err := index.get(context.Background(), list)
+		require.NoError(t, err)
+		assert.False(t, complete)
+		assert.Empty(t, metrics)
+		index.mu.Lock()
+		done := index.done
+		index.mu.Unlock()
+
+		listPage()
+		listPage()
+		partial, complete, err := index.get(context.Background(), list)
+		require.NoError(t, err)
+		assert.False(t, complete)
+		assert.Equal(t, []string{"metric0", "metric1"}, uniqueMetricNames(partial))
+
+		listPage()
+		next <- false
+		<-done
+		metrics, complete, err = index.get(context.Background(), list)
+		require.NoError(t, err)
+		assert.True(t, complete)
+		assert.Equal(t, []string{"metric0", "metric1", "metric2"}, uniqueMetricNames(metrics))
+		// the partial metrics returned before aren't changed by the next pages
+		assert.Len(t, partial, 2)
+	})
+
+	t.Run("An expired index is returned while the namespace is listed again", func(t *testing.T) {
+		var calls int
+		list := func(ctx context.Context, fn func(page []*cloudwatch.Metric)) error {
+			calls++
+			fn(newPage(calls))
+			return nil
+		}
+
+		index := &metricsIndex{}
+		_, _, err := index.get(context.Background(), list)
+		require.NoError(t, err)
+		index.mu.Lock()
+		index.listedAt = index.listedAt.Add(-metricsIndexTTL - time.Second)
+		done := index.done
+		index.mu.Unlock()
+
+		metrics, complete, err := index.get(context.Background(), list)
+		require.NoError(t, err)
+		assert.True(t, complete)
+		assert.Equal(t, []string{"metric1"}, uniqueMetricNames(metrics))
+
+		index.mu.Lock()
+		assert.NotEqual(t, done, index.done)
+		done = index.done
+		index.mu.Unlock()
+		<-done
+		metrics, _, err = index.get(context.Background(), list)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"metric2"}, uniqueMetricNames(metrics))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("The error of a failed listing is returned", func(t *testing.T) {
+		list := func(ctx context.Context, fn func(page []*cloudwatch.Metric)) error {
+			return fmt.Errorf("access denied")
+		}
+		_, _, err := (&metricsIndex{}).get(context.Background(), list)
+		assert.Error(t, err)
+	})
+
+	t.Run("The indexes of the previous versions of a data source are dropped", func(t *testing.T) {
+		index := getMetricsIndex(42, 1, "us-east-1", "", "MyApp")
+		assert.Same(t, index, getMetricsIndex(42, 1, "us-east-1", "", "MyApp"))
+		assert.NotSame(t, index, getMetricsIndex(42, 2, "us-east-1", "", "MyApp"))
+
+		metricsIndexesLock.Lock()
+		_, ok := metricsIndexes["42/1/us-east-1//MyApp"]
+		metricsIndexesLock.Unlock()
+		assert.False(t, ok)
+
+		evictMetricsIndexes(42)
+		metricsIndexesLock.Lock()
+		_, ok = metricsIndexes["42/2/us-east-1//MyApp"]
+		metricsIndexesLock.Unlock()
+		assert.False(t, ok)
+	})
+}
+
+func TestMatchesDimensionFilters(t *testing.T) {
+	metric := &cloudwatch.Metric{Dimensions: []*cloudwatch.Dimension{
+		{Name: aws.String("Service"), Value: aws.String("checkout")},
+		{Name: aws.String("Environment"), Value: aws.String("prod")},
+	}}
+
+	assert.True(t, matchesDimensionFilters(metric, nil))
+	assert.True(t, matchesDimensionFilters(metric, []*cloudwatch.DimensionFilter{
+		{Name: aws.String("Service")},
+		{Name: aws.String("Environment"), Value: aws.String("prod")},
+	}))
+	assert.False(t, matchesDimensionFilters(metric, []*cloudwatch.DimensionFilter{
+		{Name: aws.String("Environment"), Value: aws.String("dev")},
+	}))
+	assert.False(t, matchesDimensionFilters(metric, []*cloudwatch.DimensionFilter{
+		{Name: aws.String("InstanceId")},
+	}))
+}
+
+func TestListMetricsOptions(t *testing.T) {
+	newExecutor := func(jsonData map[string]interface{}) *CloudWatchExecutor {
+		return &CloudWatchExecutor{DataSource: &models.DataSource{JsonData: simplejson.NewFromAny(jsonData)}}
+	}
+
+	assert.Empty(t, newExecutor(map[string]interface{}{}).listMetricsOptions(""))
+
+	e := newExecutor(map[string]interface{}{"listMetricsRecentlyActive": true})
+	require.Len(t, e.listMetricsOptions(""), 1)
+	assert.Equal(t, url.Values{"RecentlyActive": {"PT3H"}}, recentlyActiveParams)
+	require.Len(t, e.listMetricsOptions("123456789012"), 1)
+}
@@ -5,6 +5,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/tsdb"
 )
@@ -12,16 +13,26 @@ import (
 // returns a map of queries with query id as key. In the case a q request query
 // has more than one statistic defined, one cloudwatchQuery will be created for each statistic.
 // If the query doesn't have an Id defined by the user, we'll give it an with format `query[RefId]`. In the case
-// the incoming query had more than one stat, it will ge an id like `query[RefId]_[StatName]`, eg queryC_Average
+// the incoming query had more than one stat, it will ge an id like `query[RefId]_[StatName]`, eg queryC_Average.
+// A query with an expression is sent once whatever its statistics, as they're part of the expression.
 func (e *CloudWatchExecutor) transformRequestQueriesToCloudWatchQueries(requestQueries []*requestQuery) (map[string]*cloudWatchQuery, error) {
 	cloudwatchQueries := make(map[string]*cloudWatchQuery)
 	for _, requestQuery := range requestQueries {
-		for _, stat := range requestQuery.Statistics {
+		statistics := requestQuery.Statistics
+		if requestQuery.Expression != "" {
+			stat := aws.String("")
+			if len(statistics) > 0 {
+				stat = statistics[0]
+			}
+			statistics = []*string{stat}
+		}
+
+		for _, stat := range statistics {
 			id := requestQuery.Id
 			if id == "" {
 				id = fmt.Sprintf("query%s", requestQuery.RefId)
 			}
-			if len(requestQuery.Statistics) > 1 {
+			if len(statistics) > 1 {
 				id = fmt.Sprintf("%s_%v", id, strings.ReplaceAll(*stat, ".", "_"))
 			}
 
@@ -30,26 +41,68 @@ func (e *CloudWatchExecutor) transformRequestQueriesToCloudWatchQueries(requestQ
 			}
 
 			query := &cloudWatchQuery{
-				Id:         id,
-				RefId:      requestQuery.RefId,
-				Region:     requestQuery.Region,
-				Namespace:  requestQuery.Namespace,
-				MetricName: requestQuery.MetricName,
-				Dimensions: requestQuery.Dimensions,
-				Stats:      *stat,
-				Period:     requestQuery.Period,
-				Alias:      requestQuery.Alias,
-				Expression: requestQuery.Expression,
-				ReturnData: requestQuery.ReturnData,
-				MatchExact: requestQuery.MatchExact,
+				Id:          id,
+				RefId:       requestQuery.RefId,
+				Region:      requestQuery.Region,
+				Namespace:   requestQuery.Namespace,
+				MetricName:  requestQuery.MetricName,
+				Dimensions:  requestQuery.Dimensions,
+				Stats:       *stat,
+				Period:      requestQuery.Period,
+				Alias:       requestQuery.Alias,
+				Expression:  requestQuery.Expression,
+				ReturnData:  requestQuery.ReturnData,
+				MatchExact:  requestQuery.MatchExact,
+				AccountId:   requestQuery.AccountId,
+				MultiRegion: requestQuery.MultiRegion,
 			}
 			cloudwatchQueries[id] = query
 		}
 	}
 
+	for _, query := range cloudwatchQueries {
+		if !query.isMathExpression() {
+			continue
+		}
+		for _, id := range query.referencedIDs() {
+			if _, ok := cloudwatchQueries[id]; !ok {
+				return nil, fmt.Errorf("error in query %q - expression references unknown query ID %q", query.RefId, id)
+			}
+		}
+	}
+	for _, query := range cloudwatchQueries {
+		if query.isMathExpression() && len(query.Dimensions) == 0 {
+			query.Dimensions = referencedDimensions(query, cloudwatchQueries, map[string]bool{})
+		}
+	}
+
 	return cloudwatchQueries, nil
 }
 
+// referencedDimensions returns the dimensions of the queries referenced by a math expression, through
+// the math expressions it references, so that the series of the expression get their tags.
+func referencedDimensions(query *cloudWatchQuery, queries map[string]*cloudWatchQuery, visited map[string]bool) map[string][]string {
+	visited[query.Id] = true
+	dimensions := make(map[string][]string)
+	for _, id := range query.referencedIDs() {
+		if visited[id] {
+			continue
+		}
+		referenced := queries[id]
+
+		referencedDims := referenced.Dimensions
+		if referenced.isMathExpression() && len(referenced.Dimensions) == 0 {
+			referencedDims = referencedDimensions(referenced, queries, visited)
+		}
+		for key, values := range referencedDims {
+			if _, exists := dimensions[key]; !exists {
+				dimensions[key] = values
+			}
+		}
+	}
+	return dimensions
+}
+
 func (e *CloudWatchExecutor) transformQueryResponseToQueryResult(cloudwatchResponses []*cloudwatchResponse) map[string]*tsdb.QueryResult {
 	responsesByRefID := make(map[string][]*cloudwatchResponse)
 	for _, res := range cloudwatchResponses {
@@ -66,23 +119,21 @@ func (e *CloudWatchExecutor) transformQueryResponseToQueryResult(cloudwatchRespo
 
 		requestExceededMaxLimit := false
 		partialData := false
-		queryMeta := []struct {
-			Expression, ID string
-			Period         int
-		}{}
+		queryMeta := []getMetricDataMeta{}
+		bands := make([]*anomalyDetectionBand, 0)
 
 		for _, response := range responses {
 			timeSeries = append(timeSeries, *response.series...)
 			requestExceededMaxLimit = requestExceededMaxLimit || response.RequestExceededMaxLimit
 			partialData = partialData || response.PartialData
-			queryMeta = append(queryMeta, struct {
-				Expression, ID string
-				Period         int
-			}{
+			queryMeta = append(queryMeta, getMetricDataMeta{
 				Expression: response.Expression,
 				ID:         response.Id,
 				Period:     response.Period,
 			})
+			if response.AnomalyDetectionBand != nil {
+				bands = append(bands, response.AnomalyDetectionBand)
+			}
 		}
 
 		sort.Slice(timeSeries, func(i, j int) bool {
@@ -98,6 +149,9 @@ func (e *CloudWatchExecutor) transformQueryResponseToQueryResult(cloudwatchRespo
 
 		queryResult.Series = append(queryResult.Series, timeSeries...)
 		queryResult.Meta.Set("gmdMeta", queryMeta)
+		if len(bands) > 0 {
+			queryResult.Meta.Set("anomalyDetectionBands", bands)
+		}
 		results[refID] = queryResult
 	}
 
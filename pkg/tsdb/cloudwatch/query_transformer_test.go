@@ -155,5 +155,83 @@ func TestQueryTransformer(t *testing.T) {
 				So(err, ShouldNotBeNil)
 			})
 		})
+
+		Convey("when transforming math expressions", func() {
+			executor := &CloudWatchExecutor{}
+			metricQuery := &requestQuery{
+				RefId:      "A",
+				Region:     "us-east-1",
+				Namespace:  "AWS/EC2",
+				MetricName: "CPUUtilization",
+				Dimensions: map[string][]string{"InstanceId": {"i-123", "i-456"}},
+				Statistics: aws.StringSlice([]string{"Average", "Maximum"}),
+				Period:     300,
+				Id:         "m1",
+			}
+
+			Convey("one cloudwatchQuery is generated whatever the stats of the expression", func() {
+				requestQueries := []*requestQuery{
+					{
+						RefId:      "B",
+						Region:     "us-east-1",
+						Statistics: aws.StringSlice([]string{"Average", "Sum"}),
+						Id:         "e1",
+						Expression: `SUM(METRICS("m1"))`,
+					},
+					{
+						RefId:      "C",
+						Region:     "us-east-1",
+						Expression: "m1_Average / m1_Maximum * 100",
+					},
+					metricQuery,
+				}
+
+				res, err := executor.transformRequestQueriesToCloudWatchQueries(requestQueries)
+				So(err, ShouldBeNil)
+				So(len(res), ShouldEqual, 4)
+				So(res, ShouldContainKey, "e1")
+				So(res["e1"].Stats, ShouldEqual, "Average")
+				So(res, ShouldContainKey, "queryC")
+				So(res["queryC"].Stats, ShouldEqual, "")
+			})
+
+			Convey("the dimensions of the referenced queries are propagated to the expression", func() {
+				requestQueries := []*requestQuery{
+					{
+						RefId:      "B",
+						Region:     "us-east-1",
+						Id:         "e1",
+						Expression: "m1_Average * 2",
+					},
+					{
+						RefId:      "C",
+						Region:     "us-east-1",
+						Id:         "e2",
+						Expression: "e1 + 1",
+					},
+					metricQuery,
+				}
+
+				res, err := executor.transformRequestQueriesToCloudWatchQueries(requestQueries)
+				So(err, ShouldBeNil)
+				So(res["e1"].Dimensions["InstanceId"], ShouldResemble, []string{"i-123", "i-456"})
+				So(res["e2"].Dimensions["InstanceId"], ShouldResemble, []string{"i-123", "i-456"})
+			})
+
+			Convey("should return an error if an expression references an unknown query id", func() {
+				requestQueries := []*requestQuery{
+					{
+						RefId:      "B",
+						Region:     "us-east-1",
+						Expression: "m1 * 2",
+					},
+					metricQuery,
+				}
+
+				res, err := executor.transformRequestQueriesToCloudWatchQueries(requestQueries)
+				So(res, ShouldBeNil)
+				So(err.Error(), ShouldEqual, `error in query "B" - expression references unknown query ID "m1"`)
+			})
+		})
 	})
 }
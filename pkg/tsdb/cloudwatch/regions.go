@@ -0,0 +1,52 @@
+package cloudwatch
+
+import (
+	"context"
+)
+
+// allRegions is the wildcard of the regions of a query selecting all the regions
+const allRegions = "*"
+
+// queryRegions returns the regions of a query without duplicates, the region of the query when it
+// has no regions
+func queryRegions(region string, regions []string) []string {
+	if len(regions) == 0 {
+		if region == "" {
+			return nil
+		}
+		return []string{region}
+	}
+
+	result := make([]string, 0, len(regions))
+	seen := make(map[string]bool)
+	for _, r := range regions {
+		if r == "" || seen[r] {
+			continue
+		}
+		seen[r] = true
+		result = append(result, r)
+	}
+	return result
+}
+
+// expandRegions replaces the wildcard of the regions of a query by the regions of the regions()
+// query of the data source
+func (e *CloudWatchExecutor) expandRegions(regions []string) ([]string, error) {
+	wildcard := false
+	for _, region := range regions {
+		wildcard = wildcard || region == allRegions
+	}
+	if !wildcard {
+		return regions, nil
+	}
+
+	suggestions, err := e.handleGetRegions(context.Background(), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	expanded := make([]string, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		expanded = append(expanded, suggestion.Value)
+	}
+	return expanded, nil
+}
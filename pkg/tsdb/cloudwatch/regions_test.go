@@ -0,0 +1,117 @@
+package cloudwatch
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegions(t *testing.T) {
+	executor := &CloudWatchExecutor{
+		ec2Svc: mockedEc2{RespRegions: ec2.DescribeRegionsOutput{
+			Regions: []*ec2.Region{{RegionName: aws.String("xx-west-9")}},
+		}},
+		DataSource: &models.DataSource{Id: 102, JsonData: simplejson.New()},
+	}
+
+	t.Run("Regions of the query", func(t *testing.T) {
+		assert.Equal(t, []string{"us-east-1"}, queryRegions("us-east-1", nil))
+		assert.Empty(t, queryRegions("", nil))
+		assert.Equal(t, []string{"us-east-1", "eu-west-1"}, queryRegions("us-east-1", []string{"us-east-1", "", "eu-west-1", "us-east-1"}))
+	})
+
+	t.Run("Wildcard is expanded to all the regions", func(t *testing.T) {
+		regions, err := executor.expandRegions([]string{"us-east-1"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"us-east-1"}, regions)
+
+		regions, err = executor.expandRegions([]string{"us-east-1", "*"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"xx-west-9"}, regions)
+	})
+
+	t.Run("Query rows of several regions are sent to each region", func(t *testing.T) {
+		queryContext := &tsdb.TsdbQuery{
+			Queries: []*tsdb.Query{
+				{RefId: "A", Model: simplejson.NewFromAny(map[string]interface{}{
+					"type":       "timeSeriesQuery",
+					"region":     "us-east-1",
+					"regions":    []interface{}{"us-east-1", "eu-west-1"},
+					"namespace":  "AWS/Route53",
+					"metricName": "HealthCheckStatus",
+					"statistics": []interface{}{"Average"},
+					"period":     "60",
+				})},
+				{RefId: "B", Model: simplejson.NewFromAny(map[string]interface{}{
+					"type":       "timeSeriesQuery",
+					"region":     "us-east-1",
+					"namespace":  "AWS/EC2",
+					"metricName": "CPUUtilization",
+					"statistics": []interface{}{"Average"},
+					"period":     "60",
+				})},
+			},
+		}
+
+		queries, err := executor.parseQueries(queryContext, time.Now().Add(-time.Hour), time.Now())
+		require.NoError(t, err)
+		require.Len(t, queries["us-east-1"], 2)
+		require.Len(t, queries["eu-west-1"], 1)
+		assert.Equal(t, "eu-west-1", queries["eu-west-1"][0].Region)
+		assert.True(t, queries["eu-west-1"][0].MultiRegion)
+		assert.Equal(t, "us-east-1", queries["us-east-1"][0].Region)
+		assert.True(t, queries["us-east-1"][0].MultiRegion)
+		assert.False(t, queries["us-east-1"][1].MultiRegion)
+	})
+
+	t.Run("Series of several regions are tagged and named with their region", func(t *testing.T) {
+		query := &cloudWatchQuery{
+			Region:      "eu-west-1",
+			Namespace:   "AWS/Route53",
+			MetricName:  "HealthCheckStatus",
+			Stats:       "Average",
+			Period:      60,
+			MultiRegion: true,
+		}
+		tags := map[string]string{}
+		assert.Equal(t, "HealthCheckStatus eu-west-1", formatSeriesName(query, tags, "HealthCheckStatus"))
+		assert.Equal(t, "eu-west-1", tags["region"])
+
+		query.Alias = "{{ region }} {{metric}}"
+		assert.Equal(t, "eu-west-1 HealthCheckStatus", formatSeriesName(query, map[string]string{}, "label"))
+
+		query.MultiRegion = false
+		tags = map[string]string{}
+		assert.Equal(t, "eu-west-1 HealthCheckStatus", formatSeriesName(query, tags, "label"))
+		assert.NotContains(t, tags, "region")
+	})
+
+	t.Run("Results of the regions of a query row are merged", func(t *testing.T) {
+		result := &tsdb.QueryResult{
+			RefId:  "A",
+			Series: tsdb.TimeSeriesSlice{{Name: "b us-east-1", Points: tsdb.TimeSeriesPoints{tsdb.NewTimePoint(null.FloatFrom(1), 1000)}}},
+			Meta:   simplejson.NewFromAny(map[string]interface{}{"gmdMeta": []getMetricDataMeta{{ID: "queryA", Period: 60}}}),
+		}
+		mergeQueryResults(result, &tsdb.QueryResult{
+			RefId:  "A",
+			Series: tsdb.TimeSeriesSlice{{Name: "a eu-west-1"}},
+			Meta:   simplejson.NewFromAny(map[string]interface{}{"gmdMeta": []getMetricDataMeta{{ID: "queryA", Period: 300}}}),
+		})
+		mergeQueryResults(result, &tsdb.QueryResult{RefId: "A", Error: errors.New("throttled")})
+
+		require.Len(t, result.Series, 2)
+		assert.Equal(t, "a eu-west-1", result.Series[0].Name)
+		assert.Equal(t, "b us-east-1", result.Series[1].Name)
+		assert.Equal(t, []getMetricDataMeta{{ID: "queryA", Period: 60}, {ID: "queryA", Period: 300}}, result.Meta.Get("gmdMeta").Interface())
+		assert.EqualError(t, result.Error, "throttled")
+	})
+}
@@ -2,6 +2,7 @@ package cloudwatch
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"regexp"
 	"sort"
@@ -14,6 +15,9 @@ import (
 	"github.com/grafana/grafana/pkg/tsdb"
 )
 
+// validQueryID matches the IDs of the GetMetricData queries, which math expressions reference
+var validQueryID = regexp.MustCompile(`^[a-z][a-zA-Z0-9_]*$`)
+
 // Parses the json queries and returns a requestQuery. The requestQuery has a 1 to 1 mapping to a query editor row
 func (e *CloudWatchExecutor) parseQueries(queryContext *tsdb.TsdbQuery, startTime time.Time, endTime time.Time) (map[string][]*requestQuery, error) {
 	requestQueries := make(map[string][]*requestQuery)
@@ -29,10 +33,17 @@ func (e *CloudWatchExecutor) parseQueries(queryContext *tsdb.TsdbQuery, startTim
 			return nil, &queryError{err: err, RefID: refID}
 		}
 
-		if _, exist := requestQueries[query.Region]; !exist {
-			requestQueries[query.Region] = make([]*requestQuery, 0)
+		// a query of several regions is sent to each of them, the results of the regions are merged
+		regions, err := e.expandRegions(queryRegions(query.Region, query.Regions))
+		if err != nil {
+			return nil, err
+		}
+		for _, region := range regions {
+			regionQuery := *query
+			regionQuery.Region = region
+			regionQuery.MultiRegion = len(regions) > 1
+			requestQueries[region] = append(requestQueries[region], &regionQuery)
 		}
-		requestQueries[query.Region] = append(requestQueries[query.Region], query)
 	}
 
 	return requestQueries, nil
@@ -44,12 +55,14 @@ func parseRequestQuery(model *simplejson.Json, refId string, startTime time.Time
 	if err != nil {
 		return nil, err
 	}
+	// the namespace and the metric of a math expression are those of the queries it references
+	expression := model.Get("expression").MustString("")
 	namespace, err := model.Get("namespace").String()
-	if err != nil {
+	if err != nil && expression == "" {
 		return nil, err
 	}
 	metricName, err := model.Get("metricName").String()
-	if err != nil {
+	if err != nil && expression == "" {
 		return nil, err
 	}
 	dimensions, err := parseDimensions(model)
@@ -90,7 +103,9 @@ func parseRequestQuery(model *simplejson.Json, refId string, startTime time.Time
 	}
 
 	id := model.Get("id").MustString("")
-	expression := model.Get("expression").MustString("")
+	if id != "" && !validQueryID.MatchString(id) {
+		return nil, fmt.Errorf("invalid query ID %q - it must start with a lowercase letter and contain only letters, numbers and underscores", id)
+	}
 	alias := model.Get("alias").MustString()
 	returnData := !model.Get("hide").MustBool(false)
 	queryType := model.Get("type").MustString()
@@ -103,6 +118,14 @@ func parseRequestQuery(model *simplejson.Json, refId string, startTime time.Time
 
 	matchExact := model.Get("matchExact").MustBool(true)
 
+	accountID := model.Get("accountId").MustString()
+	if accountID == "all" {
+		accountID = ""
+	}
+	if accountID != "" && !validAccountID.MatchString(accountID) {
+		return nil, fmt.Errorf("invalid account ID %q - it must be the 12 digits of an AWS account", accountID)
+	}
+
 	return &requestQuery{
 		RefId:      refId,
 		Region:     region,
@@ -116,6 +139,8 @@ func parseRequestQuery(model *simplejson.Json, refId string, startTime time.Time
 		Expression: expression,
 		ReturnData: returnData,
 		MatchExact: matchExact,
+		AccountId:  accountID,
+		Regions:    model.Get("regions").MustStringArray(),
 	}, nil
 }
 
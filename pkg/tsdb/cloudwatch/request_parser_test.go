@@ -212,6 +212,52 @@ func TestRequestParser(t *testing.T) {
 				})
 			})
 
+			Convey("with a math expression", func() {
+				query := simplejson.NewFromAny(map[string]interface{}{
+					"refId":      "ref1",
+					"region":     "us-east-1",
+					"id":         "e1",
+					"expression": "SUM(METRICS())",
+					"period":     "600",
+					"hide":       false,
+				})
+
+				res, err := parseRequestQuery(query, "ref1", from, to)
+				So(err, ShouldBeNil)
+				So(res.Namespace, ShouldEqual, "")
+				So(res.MetricName, ShouldEqual, "")
+				So(res.Expression, ShouldEqual, "SUM(METRICS())")
+
+				Convey("should return an error if the id isn't valid", func() {
+					query.Set("id", "E1")
+					_, err := parseRequestQuery(query, "ref1", from, to)
+					So(err, ShouldNotBeNil)
+				})
+			})
+
+			Convey("with the account ID of a source account", func() {
+				query := simplejson.NewFromAny(map[string]interface{}{
+					"refId":      "ref1",
+					"region":     "us-east-1",
+					"namespace":  "ec2",
+					"metricName": "CPUUtilization",
+					"statistics": []interface{}{"Average"},
+					"accountId":  "123456789012",
+				})
+
+				res, err := parseRequestQuery(query, "ref1", from, to)
+				So(err, ShouldBeNil)
+				So(res.AccountId, ShouldEqual, "123456789012")
+
+				query.Set("accountId", "all")
+				res, err = parseRequestQuery(query, "ref1", from, to)
+				So(err, ShouldBeNil)
+				So(res.AccountId, ShouldEqual, "")
+
+				query.Set("accountId", "prod")
+				_, err = parseRequestQuery(query, "ref1", from, to)
+				So(err, ShouldNotBeNil)
+			})
 		})
 	})
 }
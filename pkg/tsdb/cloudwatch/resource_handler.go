@@ -0,0 +1,176 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// dimensionsCacheTTL is how long the dimension keys and values listed with ListMetrics are
+// cached, like the dimensions of the custom metrics
+const dimensionsCacheTTL = 5 * time.Minute
+
+// dimensionsCache holds the dimension keys and values of the data sources, by data source id and
+// version so that the entries of a data source are dropped when it's updated
+var dimensionsCache = localcache.New(dimensionsCacheTTL, 2*dimensionsCacheTTL)
+
+type listMetricsFunc func(ctx context.Context, region string, namespace string, metricName string, dimensions []*cloudwatch.DimensionFilter, accountID string) (*cloudwatch.ListMetricsOutput, error)
+
+// CallResource serves the regions, namespaces and accounts of the query editor and of the
+// template variables, like their regions(), namespaces() and accounts() queries, and the
+// dimension keys and values of the query editor, listed with ListMetrics. The parameters are the
+// region, namespace, metricName, dimensionKey, the dimensions filtering the metrics as a JSON
+// object and the accountId.
+func (e *CloudWatchExecutor) CallResource(ctx context.Context, dsInfo *models.DataSource, path string, params url.Values) (interface{}, error) {
+	e.DataSource = dsInfo
+
+	parameters := simplejson.New()
+	for key := range params {
+		parameters.Set(key, params.Get(key))
+	}
+	switch path {
+	case "regions":
+		return e.handleGetRegions(ctx, parameters, nil)
+	case "namespaces":
+		if accountID := params.Get("accountId"); accountID != "" && accountID != "all" && !validAccountID.MatchString(accountID) {
+			return nil, fmt.Errorf("%w: invalid account ID %q", tsdb.ErrInvalidResourceRequest, accountID)
+		}
+		return e.handleGetNamespaces(ctx, parameters, nil)
+	case "accounts":
+		return e.handleGetAccounts(ctx, parameters, nil)
+	}
+
+	return callResource(ctx, dsInfo, path, params, e.listMetrics)
+}
+
+func callResource(ctx context.Context, dsInfo *models.DataSource, path string, params url.Values, listMetrics listMetricsFunc) (interface{}, error) {
+	var handler func(context.Context, url.Values, listMetricsFunc) ([]suggestData, error)
+	switch path {
+	case "dimension-keys":
+		handler = getDimensionKeys
+	case "dimension-values":
+		handler = getDimensionValues
+	default:
+		return nil, tsdb.ErrResourceNotFound
+	}
+
+	key := fmt.Sprintf("%d/%d/%s?%s", dsInfo.Id, dsInfo.Version, path, params.Encode())
+	if cached, ok := dimensionsCache.Get(key); ok {
+		return cached, nil
+	}
+
+	result, err := handler(ctx, params, listMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	dimensionsCache.Set(key, result, dimensionsCacheTTL)
+	return result, nil
+}
+
+type dimensionsParams struct {
+	region     string
+	namespace  string
+	metricName string
+	dimensions []*cloudwatch.DimensionFilter
+	accountID  string
+}
+
+func parseDimensionsParams(params url.Values) (*dimensionsParams, error) {
+	p := &dimensionsParams{
+		region:     params.Get("region"),
+		namespace:  params.Get("namespace"),
+		metricName: params.Get("metricName"),
+		accountID:  params.Get("accountId"),
+	}
+	if p.region == "" {
+		p.region = "default"
+	}
+	if p.namespace == "" {
+		return nil, fmt.Errorf("%w: missing namespace", tsdb.ErrInvalidResourceRequest)
+	}
+	if p.accountID == "all" {
+		p.accountID = ""
+	}
+	if p.accountID != "" && !validAccountID.MatchString(p.accountID) {
+		return nil, fmt.Errorf("%w: invalid account ID %q", tsdb.ErrInvalidResourceRequest, p.accountID)
+	}
+
+	if raw := params.Get("dimensions"); raw != "" {
+		var dimensionsJson map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &dimensionsJson); err != nil {
+			return nil, fmt.Errorf("%w: invalid dimensions: %s", tsdb.ErrInvalidResourceRequest, err)
+		}
+		p.dimensions = dimensionFilters(dimensionsJson)
+	}
+
+	return p, nil
+}
+
+// getDimensionKeys returns the dimension keys of the metrics of a namespace. The keys of the AWS
+// namespaces are known, the ones of a metric or of the custom namespaces are listed.
+func getDimensionKeys(ctx context.Context, params url.Values, listMetrics listMetricsFunc) ([]suggestData, error) {
+	p, err := parseDimensionsParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	if known, exists := dimensionsMap[p.namespace]; exists && p.metricName == "" && len(p.dimensions) == 0 {
+		keys = append(keys, known...)
+	} else {
+		metrics, err := listMetrics(ctx, p.region, p.namespace, p.metricName, p.dimensions, p.accountID)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		for _, metric := range metrics.Metrics {
+			for _, dim := range metric.Dimensions {
+				name := aws.StringValue(dim.Name)
+				if !seen[name] {
+					seen[name] = true
+					keys = append(keys, name)
+				}
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	result := make([]suggestData, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, suggestData{Text: key, Value: key})
+	}
+	return result, nil
+}
+
+// getDimensionValues returns the values of a dimension of the metrics of a namespace
+func getDimensionValues(ctx context.Context, params url.Values, listMetrics listMetricsFunc) ([]suggestData, error) {
+	p, err := parseDimensionsParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	dimensionKey := params.Get("dimensionKey")
+	if dimensionKey == "" {
+		return nil, fmt.Errorf("%w: missing dimensionKey", tsdb.ErrInvalidResourceRequest)
+	}
+
+	// only the metrics with the dimension are listed
+	dimensions := append(p.dimensions, &cloudwatch.DimensionFilter{Name: aws.String(dimensionKey)})
+	metrics, err := listMetrics(ctx, p.region, p.namespace, p.metricName, dimensions, p.accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return dimensionValuesOf(metrics.Metrics, dimensionKey), nil
+}
@@ -0,0 +1,110 @@
+package cloudwatch
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallResource(t *testing.T) {
+	var calls int
+	var lastInput []*cloudwatch.DimensionFilter
+	listMetrics := func(ctx context.Context, region string, namespace string, metricName string, dimensions []*cloudwatch.DimensionFilter, accountID string) (*cloudwatch.ListMetricsOutput, error) {
+		calls++
+		lastInput = dimensions
+		return &cloudwatch.ListMetricsOutput{Metrics: []*cloudwatch.Metric{
+			{Dimensions: []*cloudwatch.Dimension{
+				{Name: aws.String("Service"), Value: aws.String("checkout")},
+				{Name: aws.String("Environment"), Value: aws.String("prod")},
+			}},
+			{Dimensions: []*cloudwatch.Dimension{
+				{Name: aws.String("Service"), Value: aws.String("cart")},
+			}},
+		}}, nil
+	}
+
+	t.Run("Should list the dimension keys of a custom namespace, cached by data source version", func(t *testing.T) {
+		calls = 0
+		ds := &models.DataSource{Id: 1, Version: 1}
+		params := url.Values{"region": {"us-east-1"}, "namespace": {"MyApp"}}
+
+		result, err := callResource(context.Background(), ds, "dimension-keys", params, listMetrics)
+		require.NoError(t, err)
+		assert.Equal(t, []suggestData{{Text: "Environment", Value: "Environment"}, {Text: "Service", Value: "Service"}}, result)
+
+		_, err = callResource(context.Background(), ds, "dimension-keys", params, listMetrics)
+		require.NoError(t, err)
+		assert.Equal(t, 1, calls)
+
+		ds.Version = 2
+		_, err = callResource(context.Background(), ds, "dimension-keys", params, listMetrics)
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("Should return the known dimension keys of an AWS namespace", func(t *testing.T) {
+		calls = 0
+		result, err := callResource(context.Background(), &models.DataSource{Id: 2}, "dimension-keys", url.Values{"namespace": {"AWS/SQS"}}, listMetrics)
+		require.NoError(t, err)
+		assert.Equal(t, []suggestData{{Text: "QueueName", Value: "QueueName"}}, result)
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("Should list the values of a dimension of the metrics with it", func(t *testing.T) {
+		params := url.Values{
+			"namespace":    {"MyApp"},
+			"metricName":   {"Latency"},
+			"dimensionKey": {"Service"},
+			"dimensions":   {`{"Environment": "prod"}`},
+		}
+		result, err := callResource(context.Background(), &models.DataSource{Id: 3}, "dimension-values", params, listMetrics)
+		require.NoError(t, err)
+		assert.Equal(t, []suggestData{{Text: "cart", Value: "cart"}, {Text: "checkout", Value: "checkout"}}, result)
+		assert.Equal(t, []*cloudwatch.DimensionFilter{
+			{Name: aws.String("Environment"), Value: aws.String("prod")},
+			{Name: aws.String("Service")},
+		}, lastInput)
+	})
+
+	t.Run("Should reject invalid requests", func(t *testing.T) {
+		_, err := callResource(context.Background(), &models.DataSource{}, "dimension-keys", url.Values{}, listMetrics)
+		assert.True(t, errors.Is(err, tsdb.ErrInvalidResourceRequest))
+
+		_, err = callResource(context.Background(), &models.DataSource{}, "dimension-values", url.Values{"namespace": {"MyApp"}}, listMetrics)
+		assert.True(t, errors.Is(err, tsdb.ErrInvalidResourceRequest))
+
+		_, err = callResource(context.Background(), &models.DataSource{}, "dimension-keys", url.Values{"namespace": {"MyApp"}, "dimensions": {"{"}}, listMetrics)
+		assert.True(t, errors.Is(err, tsdb.ErrInvalidResourceRequest))
+
+		_, err = callResource(context.Background(), &models.DataSource{}, "unknown", url.Values{}, listMetrics)
+		assert.Equal(t, tsdb.ErrResourceNotFound, err)
+
+		_, err = (&CloudWatchExecutor{}).CallResource(context.Background(), &models.DataSource{}, "namespaces", url.Values{"accountId": {"me"}})
+		assert.True(t, errors.Is(err, tsdb.ErrInvalidResourceRequest))
+	})
+
+	t.Run("Should list the regions of the account, cached by data source version", func(t *testing.T) {
+		ds := &models.DataSource{Id: 4, Version: 1, JsonData: simplejson.New()}
+		executor := &CloudWatchExecutor{ec2Svc: mockedEc2{RespRegions: ec2.DescribeRegionsOutput{
+			Regions: []*ec2.Region{{RegionName: aws.String("us-east-1")}, {RegionName: aws.String("af-south-1")}},
+		}}}
+		result, err := executor.CallResource(context.Background(), ds, "regions", url.Values{})
+		require.NoError(t, err)
+		assert.Equal(t, []suggestData{{Text: "af-south-1", Value: "af-south-1"}, {Text: "us-east-1", Value: "us-east-1"}}, result)
+
+		executor.ec2Svc = mockedEc2{}
+		result, err = executor.CallResource(context.Background(), ds, "regions", url.Values{})
+		require.NoError(t, err)
+		assert.Len(t, result, 2)
+	})
+}
@@ -54,6 +54,7 @@ func (e *CloudWatchExecutor) parseResponse(metricDataOutputs []*cloudwatch.GetMe
 			Id:                      queries[id].Id,
 			RequestExceededMaxLimit: queries[id].RequestExceededMaxLimit,
 			PartialData:             partialData,
+			AnomalyDetectionBand:    anomalyDetectionBandOf(*series),
 		}
 		cloudWatchResponses = append(cloudWatchResponses, response)
 	}
@@ -70,6 +71,7 @@ func parseGetMetricDataTimeSeries(metricDataResults map[string]*cloudwatch.Metri
 
 	partialData := false
 	result := tsdb.TimeSeriesSlice{}
+	labeledSeries := make(map[string]*tsdb.TimeSeries)
 	for _, label := range metricDataResultLabels {
 		metricDataResult := metricDataResults[label]
 		if *metricDataResult.StatusCode != "Complete" {
@@ -105,7 +107,7 @@ func parseGetMetricDataTimeSeries(metricDataResults map[string]*cloudwatch.Metri
 					}
 				}
 
-				emptySeries.Name = formatAlias(query, query.Stats, emptySeries.Tags, label)
+				emptySeries.Name = formatSeriesName(query, emptySeries.Tags, label)
 				result = append(result, &emptySeries)
 			}
 		} else {
@@ -135,7 +137,7 @@ func parseGetMetricDataTimeSeries(metricDataResults map[string]*cloudwatch.Metri
 				}
 			}
 
-			series.Name = formatAlias(query, query.Stats, series.Tags, label)
+			series.Name = formatSeriesName(query, series.Tags, label)
 
 			for j, t := range metricDataResult.Timestamps {
 				if j > 0 {
@@ -148,11 +150,106 @@ func parseGetMetricDataTimeSeries(metricDataResults map[string]*cloudwatch.Metri
 					float64(t.Unix())*1000))
 			}
 			result = append(result, &series)
+			labeledSeries[label] = &series
 		}
 	}
+
+	if query.isAnomalyDetectionBandExpression() {
+		nameAnomalyDetectionBand(labeledSeries)
+	}
 	return &result, partialData, nil
 }
 
+// Tag of the series of an anomaly detection band telling whether it's the upper or lower one
+const (
+	anomalyDetectionBandTag   = "band"
+	anomalyDetectionBandUpper = "upper"
+	anomalyDetectionBandLower = "lower"
+)
+
+// nameAnomalyDetectionBand tags the upper and lower series of an anomaly detection band, which
+// are labeled with the label of the expression followed by "Upper" and "Lower", and suffixes
+// their names the same way when their alias doesn't tell them apart. When the labels don't tell
+// them apart, the upper series is the one with the greatest values.
+func nameAnomalyDetectionBand(labeledSeries map[string]*tsdb.TimeSeries) {
+	if len(labeledSeries) != 2 {
+		return
+	}
+
+	var upper, lower *tsdb.TimeSeries
+	for label, series := range labeledSeries {
+		switch l := strings.ToLower(strings.TrimSpace(label)); {
+		case strings.HasSuffix(l, anomalyDetectionBandUpper):
+			upper = series
+		case strings.HasSuffix(l, anomalyDetectionBandLower):
+			lower = series
+		}
+	}
+	if upper == nil || lower == nil || upper == lower {
+		labels := make([]string, 0, 2)
+		for label := range labeledSeries {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		upper, lower = labeledSeries[labels[0]], labeledSeries[labels[1]]
+		if sumOfPoints(lower) > sumOfPoints(upper) {
+			upper, lower = lower, upper
+		}
+	}
+
+	upper.Tags[anomalyDetectionBandTag] = anomalyDetectionBandUpper
+	lower.Tags[anomalyDetectionBandTag] = anomalyDetectionBandLower
+	if upper.Name == lower.Name {
+		upper.Name += " Upper"
+		lower.Name += " Lower"
+	}
+}
+
+func sumOfPoints(series *tsdb.TimeSeries) float64 {
+	sum := 0.0
+	for _, point := range series.Points {
+		if point[0].Valid {
+			sum += point[0].Float64
+		}
+	}
+	return sum
+}
+
+// anomalyDetectionBandOf returns the band of the series tagged by nameAnomalyDetectionBand, nil
+// if they aren't a band
+func anomalyDetectionBandOf(series tsdb.TimeSeriesSlice) *anomalyDetectionBand {
+	band := &anomalyDetectionBand{}
+	for _, s := range series {
+		switch s.Tags[anomalyDetectionBandTag] {
+		case anomalyDetectionBandUpper:
+			band.Upper = s.Name
+		case anomalyDetectionBandLower:
+			band.Lower = s.Name
+		}
+	}
+	if band.Upper == "" || band.Lower == "" {
+		return nil
+	}
+	return band
+}
+
+// formatSeriesName names a series with the alias of its query. The series of a query of several
+// regions are tagged with their region, which is added to their name unless the alias has it.
+func formatSeriesName(query *cloudWatchQuery, tags map[string]string, label string) string {
+	name := formatAlias(query, query.Stats, tags, label)
+	if !query.MultiRegion {
+		return name
+	}
+
+	tags["region"] = query.Region
+	for _, match := range aliasFormat.FindAllStringSubmatch(query.Alias, -1) {
+		if match[1] == "region" {
+			return name
+		}
+	}
+	return fmt.Sprintf("%s %s", name, query.Region)
+}
+
 func formatAlias(query *cloudWatchQuery, stat string, dimensions map[string]string, label string) string {
 	region := query.Region
 	namespace := query.Namespace
@@ -167,6 +264,10 @@ func formatAlias(query *cloudWatchQuery, stat string, dimensions map[string]stri
 	}
 
 	if len(query.Alias) == 0 && query.isMathExpression() {
+		// an expression returning a series per metric, like METRICS()*2, labels them with their metrics
+		if len(label) != 0 && label != query.Id {
+			return label
+		}
 		return query.Id
 	}
 	if len(query.Alias) == 0 && query.isInferredSearchExpression() && !query.isMultiValuedDimensionExpression() {
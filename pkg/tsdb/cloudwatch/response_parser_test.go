@@ -310,5 +310,104 @@ func TestCloudWatchResponseParser(t *testing.T) {
 			So(timeSeries.Points[2][0].String(), ShouldEqual, null.FloatFromPtr(nil).String())
 			So(timeSeries.Points[3][0].String(), ShouldEqual, null.FloatFrom(30.0).String())
 		})
+
+		Convey("can label the series of a math expression", func() {
+			timestamp := time.Unix(0, 0)
+			resp := map[string]*cloudwatch.MetricDataResult{
+				"e1": {
+					Id:         aws.String("e1"),
+					Label:      aws.String("e1"),
+					Timestamps: []*time.Time{aws.Time(timestamp)},
+					Values:     []*float64{aws.Float64(10)},
+					StatusCode: aws.String("Complete"),
+				},
+				"i-123": {
+					Id:         aws.String("e1"),
+					Label:      aws.String("i-123"),
+					Timestamps: []*time.Time{aws.Time(timestamp)},
+					Values:     []*float64{aws.Float64(20)},
+					StatusCode: aws.String("Complete"),
+				},
+			}
+
+			query := &cloudWatchQuery{
+				RefId:      "refId1",
+				Region:     "us-east-1",
+				Id:         "e1",
+				Expression: "m1 * 2",
+				Dimensions: map[string][]string{
+					"InstanceId": {"i-123", "i-456"},
+				},
+				Period: 60,
+			}
+			series, _, err := parseGetMetricDataTimeSeries(resp, query)
+
+			So(err, ShouldBeNil)
+			So((*series)[0].Name, ShouldEqual, "e1")
+			So((*series)[1].Name, ShouldEqual, "i-123")
+			So((*series)[1].Tags["InstanceId"], ShouldEqual, "i-123")
+		})
+
+		Convey("can tell the upper and lower series of an anomaly detection band", func() {
+			timestamp := time.Unix(0, 0)
+			resp := map[string]*cloudwatch.MetricDataResult{
+				"CPUUtilization Lower": {
+					Id:         aws.String("ad1"),
+					Label:      aws.String("CPUUtilization Lower"),
+					Timestamps: []*time.Time{aws.Time(timestamp)},
+					Values:     []*float64{aws.Float64(10)},
+					StatusCode: aws.String("Complete"),
+				},
+				"CPUUtilization Upper": {
+					Id:         aws.String("ad1"),
+					Label:      aws.String("CPUUtilization Upper"),
+					Timestamps: []*time.Time{aws.Time(timestamp)},
+					Values:     []*float64{aws.Float64(30)},
+					StatusCode: aws.String("Complete"),
+				},
+			}
+
+			query := &cloudWatchQuery{
+				RefId:      "refId1",
+				Region:     "us-east-1",
+				Id:         "ad1",
+				Expression: "ANOMALY_DETECTION_BAND(m1, 2)",
+				Alias:      "expected",
+				Period:     60,
+			}
+			series, _, err := parseGetMetricDataTimeSeries(resp, query)
+
+			So(err, ShouldBeNil)
+			So((*series)[0].Name, ShouldEqual, "expected Lower")
+			So((*series)[0].Tags["band"], ShouldEqual, "lower")
+			So((*series)[1].Name, ShouldEqual, "expected Upper")
+			So((*series)[1].Tags["band"], ShouldEqual, "upper")
+			So(anomalyDetectionBandOf(*series), ShouldResemble, &anomalyDetectionBand{Upper: "expected Upper", Lower: "expected Lower"})
+
+			Convey("by their values when their labels don't", func() {
+				query.Alias = ""
+				series, _, err := parseGetMetricDataTimeSeries(map[string]*cloudwatch.MetricDataResult{
+					"a": {
+						Id:         aws.String("ad1"),
+						Label:      aws.String("a"),
+						Timestamps: []*time.Time{aws.Time(timestamp)},
+						Values:     []*float64{aws.Float64(30)},
+						StatusCode: aws.String("Complete"),
+					},
+					"b": {
+						Id:         aws.String("ad1"),
+						Label:      aws.String("b"),
+						Timestamps: []*time.Time{aws.Time(timestamp)},
+						Values:     []*float64{aws.Float64(10)},
+						StatusCode: aws.String("Complete"),
+					},
+				}, query)
+
+				So(err, ShouldBeNil)
+				So((*series)[0].Name, ShouldEqual, "a")
+				So((*series)[0].Tags["band"], ShouldEqual, "upper")
+				So((*series)[1].Tags["band"], ShouldEqual, "lower")
+			})
+		})
 	})
 }
@@ -0,0 +1,122 @@
+package cloudwatch
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// ErrThrottled is the error of the AWS calls still throttled once their retries are exhausted.
+var ErrThrottled = errors.New("throttled by AWS")
+
+// The retry budget of the throttled AWS calls: the delay before the nth retry is a random duration
+// up to throttlingBaseDelay * 2^n, bounded by throttlingMaxDelay.
+// Stubbable by tests.
+var (
+	throttlingMaxRetries = 5
+	throttlingBaseDelay  = 300 * time.Millisecond
+	throttlingMaxDelay   = 10 * time.Second
+)
+
+// throttledError is the last error of a throttled AWS call, returned once its retries are exhausted.
+type throttledError struct {
+	err     error
+	retries int
+}
+
+func (e *throttledError) Error() string {
+	return fmt.Sprintf("%s after %d retries, reduce the number of queries or raise the AWS limits: %s",
+		ErrThrottled, e.retries, e.err)
+}
+
+func (e *throttledError) Unwrap() error {
+	return e.err
+}
+
+func (e *throttledError) Is(target error) bool {
+	return target == ErrThrottled
+}
+
+// isThrottlingError tells whether an AWS error is a throttling error. Besides the throttling codes
+// known to the SDK, some APIs only tell it with their message.
+func isThrottlingError(err error) bool {
+	if request.IsErrorThrottle(err) {
+		return true
+	}
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
+		return strings.Contains(strings.ToLower(awsErr.Message()), "rate exceeded")
+	}
+	return false
+}
+
+// throttlingRetryer retries the throttled AWS calls with jittered exponential backoff, and the
+// other calls like the default retryer of the SDK.
+type throttlingRetryer struct {
+	client.DefaultRetryer
+
+	randLock sync.Mutex
+	rand     *rand.Rand
+}
+
+func newThrottlingRetryer() *throttlingRetryer {
+	return &throttlingRetryer{
+		DefaultRetryer: client.DefaultRetryer{NumMaxRetries: throttlingMaxRetries},
+		rand:           rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (r *throttlingRetryer) ShouldRetry(req *request.Request) bool {
+	if isThrottlingError(req.Error) {
+		return true
+	}
+	return r.DefaultRetryer.ShouldRetry(req)
+}
+
+func (r *throttlingRetryer) RetryRules(req *request.Request) time.Duration {
+	if !isThrottlingError(req.Error) {
+		return r.DefaultRetryer.RetryRules(req)
+	}
+	return r.backoff(req.RetryCount)
+}
+
+// backoff returns the delay before a retry of a throttled call, with full jitter so that the calls
+// throttled together don't retry together
+func (r *throttlingRetryer) backoff(retryCount int) time.Duration {
+	max := throttlingMaxDelay
+	// the shift is bounded so that the delay doesn't overflow
+	if retryCount < 16 {
+		if d := throttlingBaseDelay << uint(retryCount); d < max {
+			max = d
+		}
+	}
+
+	r.randLock.Lock()
+	defer r.randLock.Unlock()
+	return time.Duration(r.rand.Int63n(int64(max) + 1))
+}
+
+// withThrottlingRetries sets the retryer of an AWS config to the throttling retryer.
+func withThrottlingRetries(cfg *aws.Config) *aws.Config {
+	return request.WithRetryer(cfg, newThrottlingRetryer())
+}
+
+// addThrottlingHandlers replaces the error of the AWS calls still throttled once their retries are
+// exhausted by a throttledError. It runs after the retry handlers of the SDK, which clear the error
+// of the calls they retry.
+func addThrottlingHandlers(handlers *request.Handlers) {
+	handlers.AfterRetry.PushBack(func(req *request.Request) {
+		if req.Error != nil && isThrottlingError(req.Error) {
+			plog.Warn("AWS call throttled", "operation", req.Operation.Name, "retries", req.RetryCount)
+			req.Error = &throttledError{err: req.Error, retries: req.RetryCount}
+		}
+	})
+}
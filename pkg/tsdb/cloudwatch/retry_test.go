@@ -0,0 +1,98 @@
+package cloudwatch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottlingRetries(t *testing.T) {
+	origBaseDelay, origMaxDelay := throttlingBaseDelay, throttlingMaxDelay
+	throttlingBaseDelay, throttlingMaxDelay = time.Millisecond, 5*time.Millisecond
+	t.Cleanup(func() {
+		throttlingBaseDelay, throttlingMaxDelay = origBaseDelay, origMaxDelay
+	})
+
+	// newClient returns a client of a server failing its first calls with an error
+	newClient := func(t *testing.T, failures int, code, message string) (*cloudwatch.CloudWatch, *int) {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls <= failures {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`<ErrorResponse><Error><Type>Sender</Type><Code>` + code + `</Code><Message>` +
+					message + `</Message></Error></ErrorResponse>`))
+				return
+			}
+			_, _ = w.Write([]byte(`<ListMetricsResponse><ListMetricsResult><Metrics></Metrics></ListMetricsResult></ListMetricsResponse>`))
+		}))
+		t.Cleanup(server.Close)
+
+		sess, err := newSession(&aws.Config{
+			Endpoint:    aws.String(server.URL),
+			Region:      aws.String("us-east-1"),
+			Credentials: credentials.NewStaticCredentials("key", "secret", ""),
+		})
+		require.NoError(t, err)
+		return cloudwatch.New(sess), &calls
+	}
+
+	t.Run("Throttled calls are retried", func(t *testing.T) {
+		svc, calls := newClient(t, 3, "Throttling", "Rate exceeded")
+		_, err := svc.ListMetricsWithContext(context.Background(), &cloudwatch.ListMetricsInput{})
+		require.NoError(t, err)
+		assert.Equal(t, 4, *calls)
+	})
+
+	t.Run("Calls rate exceeded with another code are retried", func(t *testing.T) {
+		svc, calls := newClient(t, 1, "LimitExceeded", "Rate exceeded for ListMetrics")
+		_, err := svc.ListMetricsWithContext(context.Background(), &cloudwatch.ListMetricsInput{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("Calls still throttled once the retries are exhausted fail", func(t *testing.T) {
+		svc, calls := newClient(t, 100, "Throttling", "Rate exceeded")
+		_, err := svc.ListMetricsWithContext(context.Background(), &cloudwatch.ListMetricsInput{})
+		require.Error(t, err)
+		assert.Equal(t, throttlingMaxRetries+1, *calls)
+		assert.True(t, errors.Is(err, ErrThrottled))
+		assert.Contains(t, err.Error(), "throttled by AWS after 5 retries")
+
+		var awsErr awserr.Error
+		require.True(t, errors.As(err, &awsErr))
+		assert.Equal(t, "Throttling", awsErr.Code())
+	})
+
+	t.Run("Other errors aren't retried", func(t *testing.T) {
+		svc, calls := newClient(t, 1, "AccessDenied", "User is not authorized")
+		_, err := svc.ListMetricsWithContext(context.Background(), &cloudwatch.ListMetricsInput{})
+		require.Error(t, err)
+		assert.Equal(t, 1, *calls)
+		assert.False(t, errors.Is(err, ErrThrottled))
+	})
+}
+
+func TestThrottlingBackoff(t *testing.T) {
+	retryer := newThrottlingRetryer()
+	for retryCount := 0; retryCount < 40; retryCount++ {
+		max := throttlingMaxDelay
+		if retryCount < 16 && throttlingBaseDelay<<uint(retryCount) < max {
+			max = throttlingBaseDelay << uint(retryCount)
+		}
+		for i := 0; i < 20; i++ {
+			delay := retryer.backoff(retryCount)
+			assert.True(t, delay >= 0 && delay <= max, "retry %d: %s", retryCount, delay)
+		}
+	}
+}
@@ -3,8 +3,13 @@ package cloudwatch
 import (
 	"context"
 	"fmt"
+	"sort"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/tsdb"
 	"golang.org/x/sync/errgroup"
 )
@@ -33,7 +38,15 @@ func (e *CloudWatchExecutor) executeTimeSeriesQuery(ctx context.Context, queryCo
 		}, nil
 	}
 
-	resultChan := make(chan *tsdb.QueryResult, len(queryContext.Queries))
+	// a query row has at most a result by statistic in each of its regions, as its statistics can be
+	// sent in different batches
+	results := 0
+	for _, requestQueries := range requestQueriesByRegion {
+		for _, query := range requestQueries {
+			results += len(query.Statistics) + 1
+		}
+	}
+	resultChan := make(chan *tsdb.QueryResult, results)
 	eg, ectx := errgroup.WithContext(ctx)
 	for r, q := range requestQueriesByRegion {
 		requestQueries := q
@@ -66,24 +79,7 @@ func (e *CloudWatchExecutor) executeTimeSeriesQuery(ctx context.Context, queryCo
 				return nil
 			}
 
-			metricDataInput, err := e.buildMetricDataInput(startTime, endTime, queries)
-			if err != nil {
-				return err
-			}
-
-			cloudwatchResponses := make([]*cloudwatchResponse, 0)
-			mdo, err := e.executeRequest(ectx, client, metricDataInput)
-			if err != nil {
-				for _, query := range requestQueries {
-					resultChan <- &tsdb.QueryResult{
-						RefId: query.RefId,
-						Error: err,
-					}
-				}
-				return nil
-			}
-
-			responses, err := e.parseResponse(mdo, queries)
+			batches, err := batchQueries(queries)
 			if err != nil {
 				for _, query := range requestQueries {
 					resultChan <- &tsdb.QueryResult{
@@ -94,10 +90,12 @@ func (e *CloudWatchExecutor) executeTimeSeriesQuery(ctx context.Context, queryCo
 				return nil
 			}
 
-			cloudwatchResponses = append(cloudwatchResponses, responses...)
-			res := e.transformQueryResponseToQueryResult(cloudwatchResponses)
-			for _, queryRes := range res {
-				resultChan <- queryRes
+			// the batches of metrics of a region are queried at the same time
+			for _, b := range batches {
+				batch := b
+				eg.Go(func() error {
+					return e.executeQueryBatch(ectx, client, region, startTime, endTime, batch, resultChan)
+				})
 			}
 			return nil
 		})
@@ -107,11 +105,124 @@ func (e *CloudWatchExecutor) executeTimeSeriesQuery(ctx context.Context, queryCo
 	}
 	close(resultChan)
 
-	results := &tsdb.Response{
+	response := &tsdb.Response{
 		Results: make(map[string]*tsdb.QueryResult),
 	}
 	for result := range resultChan {
-		results.Results[result.RefId] = result
+		// the query rows of several regions have a result by region
+		if existing, ok := response.Results[result.RefId]; ok {
+			mergeQueryResults(existing, result)
+			continue
+		}
+		response.Results[result.RefId] = result
+	}
+	return response, nil
+}
+
+// mergeQueryResults adds the series and the meta of the result of a query row in a region to its
+// result in the other regions. The query row fails when it fails in a region.
+func mergeQueryResults(result *tsdb.QueryResult, other *tsdb.QueryResult) {
+	if result.Error == nil {
+		result.Error = other.Error
+	}
+	if result.ErrorString == "" {
+		result.ErrorString = other.ErrorString
+	}
+
+	result.Series = append(result.Series, other.Series...)
+	sort.Slice(result.Series, func(i, j int) bool {
+		return result.Series[i].Name < result.Series[j].Name
+	})
+
+	if other.Meta == nil {
+		return
+	}
+	if result.Meta == nil {
+		result.Meta = other.Meta
+		return
+	}
+	if queryMeta, ok := other.Meta.Get("gmdMeta").Interface().([]getMetricDataMeta); ok {
+		existing, _ := result.Meta.Get("gmdMeta").Interface().([]getMetricDataMeta)
+		result.Meta.Set("gmdMeta", append(existing, queryMeta...))
+	}
+	if bands, ok := other.Meta.Get("anomalyDetectionBands").Interface().([]*anomalyDetectionBand); ok {
+		existing, _ := result.Meta.Get("anomalyDetectionBands").Interface().([]*anomalyDetectionBand)
+		result.Meta.Set("anomalyDetectionBands", append(existing, bands...))
+	}
+}
+
+// executeQueryBatch sends the GetMetricData requests of a batch of queries, sending the results of
+// their query rows to resultChan. The responses are served from the metric data cache when the data
+// source has a metricDataCacheTTL.
+func (e *CloudWatchExecutor) executeQueryBatch(ctx context.Context, client cloudWatchClient, region string, startTime time.Time, endTime time.Time,
+	queries map[string]*cloudWatchQuery, resultChan chan<- *tsdb.QueryResult) error {
+	defer func() {
+		if err := recover(); err != nil {
+			plog.Error("Execute Get Metric Data Query Panic", "error", err, "stack", log.Stack(1))
+			if theErr, ok := err.(error); ok {
+				resultChan <- &tsdb.QueryResult{
+					Error: theErr,
+				}
+			}
+		}
+	}()
+
+	failBatch := func(err error) {
+		refIDs := make(map[string]bool)
+		for _, query := range queries {
+			if !refIDs[query.RefId] {
+				refIDs[query.RefId] = true
+				resultChan <- &tsdb.QueryResult{
+					RefId: query.RefId,
+					Error: err,
+				}
+			}
+		}
+	}
+
+	metricDataInput, err := e.buildMetricDataInput(startTime, endTime, queries)
+	if err != nil {
+		return err
+	}
+
+	var opts []request.Option
+	params := crossAccountParams(metricDataInput, queries)
+	if params != nil {
+		opts = append(opts, withQueryParams(params))
+	}
+
+	var cacheKey string
+	ttl := e.metricDataCacheTTL()
+	if ttl > 0 {
+		if cacheKey, err = e.metricDataCacheKey(e.getRegion(region), startTime, endTime, metricDataInput, params, ttl); err != nil {
+			return err
+		}
+	}
+
+	var mdo []*cloudwatch.GetMetricDataOutput
+	if cached, ok := metricDataCache.Get(cacheKey); cacheKey != "" && ok {
+		mdo = copyMetricData(cached.([]*cloudwatch.GetMetricDataOutput))
+		metrics.MAwsCloudWatchGetMetricDataCached.Add(float64(len(metricDataInput.MetricDataQueries)))
+	} else {
+		mdo, err = e.executeRequest(ctx, client, metricDataInput, opts...)
+		if err != nil {
+			failBatch(err)
+			return nil
+		}
+		if cacheKey != "" && cacheableMetricData(mdo) {
+			metricDataCache.Set(cacheKey, copyMetricData(mdo), ttl)
+		}
+	}
+
+	responses, err := e.parseResponse(mdo, queries)
+	if err != nil {
+		failBatch(err)
+		return nil
+	}
+
+	res := e.transformQueryResponseToQueryResult(responses)
+	for _, queryRes := range res {
+		resultChan <- queryRes
 	}
-	return results, nil
+	return nil
 }
@@ -28,6 +28,13 @@ type requestQuery struct {
 	Period             int
 	Alias              string
 	MatchExact         bool
+	AccountId          string
+	// Regions are the regions the query is sent to, the region of the query when it's empty. A
+	// wildcard selects all the regions.
+	Regions []string
+	// MultiRegion tells whether the query is sent to several regions, whose series are then tagged
+	// with their region
+	MultiRegion bool
 }
 
 type cloudwatchResponse struct {
@@ -38,6 +45,22 @@ type cloudwatchResponse struct {
 	RequestExceededMaxLimit bool
 	PartialData             bool
 	Period                  int
+	// AnomalyDetectionBand is the band returned by an ANOMALY_DETECTION_BAND expression
+	AnomalyDetectionBand *anomalyDetectionBand
+}
+
+// getMetricDataMeta is the meta of a GetMetricData query of a query row, for the links of the
+// frontend to the console
+type getMetricDataMeta struct {
+	Expression, ID string
+	Period         int
+}
+
+// anomalyDetectionBand holds the names of the upper and lower series of an anomaly detection band,
+// for the frontend to fill the area between them
+type anomalyDetectionBand struct {
+	Upper string `json:"upper"`
+	Lower string `json:"lower"`
 }
 
 type queryError struct {
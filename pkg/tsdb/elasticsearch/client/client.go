@@ -38,6 +38,7 @@ type Client interface {
 	GetMinInterval(queryInterval string) (time.Duration, error)
 	ExecuteMultisearch(r *MultiSearchRequest) (*MultiSearchResponse, error)
 	MultiSearch() *MultiSearchRequestBuilder
+	GetMapping() (*simplejson.Json, error)
 	EnableDebug()
 }
 
@@ -187,12 +188,20 @@ func (c *baseClientImpl) executeRequest(method, uriPath, uriQuery string, body [
 
 	if c.ds.BasicAuth {
 		clientLog.Debug("Request configured to use basic authentication")
-		req.SetBasicAuth(c.ds.BasicAuthUser, c.ds.DecryptedBasicAuthPassword())
+		password, err := c.ds.DecryptedBasicAuthPassword()
+		if err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth(c.ds.BasicAuthUser, password)
 	}
 
 	if !c.ds.BasicAuth && c.ds.User != "" {
 		clientLog.Debug("Request configured to use basic authentication")
-		req.SetBasicAuth(c.ds.User, c.ds.DecryptedPassword())
+		password, err := c.ds.DecryptedPassword()
+		if err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth(c.ds.User, password)
 	}
 
 	httpClient, err := newDatasourceHttpClient(c.ds)
@@ -278,6 +287,25 @@ func (c *baseClientImpl) ExecuteMultisearch(r *MultiSearchRequest) (*MultiSearch
 	return &msr, nil
 }
 
+// GetMapping returns the mappings of the indices of the time range, by index.
+func (c *baseClientImpl) GetMapping() (*simplejson.Json, error) {
+	uriPath := strings.Join(c.indices, ",") + "/_mapping"
+	clientRes, err := c.executeRequest(http.MethodGet, uriPath, "ignore_unavailable=true", nil)
+	if err != nil {
+		return nil, err
+	}
+	res := clientRes.httpResponse
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(res.Body)
+		clientLog.Debug("Failed to get the mapping", "status", res.Status, "body", string(body))
+		return nil, fmt.Errorf("failed to get the mapping of the indices %s: %s", strings.Join(c.indices, ","), res.Status)
+	}
+
+	return simplejson.NewFromReader(res.Body)
+}
+
 func (c *baseClientImpl) createMultiSearchRequests(searchRequests []*SearchRequest) []*multiRequest {
 	multiRequests := []*multiRequest{}
 
@@ -84,6 +84,21 @@ func (b *SearchRequestBuilder) SortDesc(field, unmappedType string) *SearchReque
 	return b
 }
 
+// SortAsc adds an ascending sort to the search request
+func (b *SearchRequestBuilder) SortAsc(field, unmappedType string) *SearchRequestBuilder {
+	props := map[string]string{
+		"order": "asc",
+	}
+
+	if unmappedType != "" {
+		props["unmapped_type"] = unmappedType
+	}
+
+	b.sort[field] = props
+
+	return b
+}
+
 // AddDocValueField adds a doc value field to the search request
 func (b *SearchRequestBuilder) AddDocValueField(field string) *SearchRequestBuilder {
 	// fields field not supported on version >= 5
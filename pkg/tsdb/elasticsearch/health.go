@@ -0,0 +1,74 @@
+package elasticsearch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+)
+
+// CheckHealth gets the mapping of the indices of the last hour and checks that the time field
+// is mapped, like the test of the data source in the frontend.
+func (e *ElasticsearchExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) (*tsdb.HealthCheckResult, error) {
+	client, err := es.NewClient(ctx, dsInfo, tsdb.NewTimeRange("now-1h", "now"))
+	if err != nil {
+		return nil, err
+	}
+
+	mapping, err := client.GetMapping()
+	if err != nil {
+		return nil, err
+	}
+
+	timeField := client.GetTimeField()
+	if !hasMappedField(mapping, client.GetVersion(), timeField) {
+		return nil, fmt.Errorf("no date field named %s found", timeField)
+	}
+
+	return tsdb.HealthOk("Index OK. Time field name OK."), nil
+}
+
+// hasMappedField returns whether a field, whose path can be dotted, is mapped by one of the indices.
+// The mappings have a level of types before Elasticsearch 7.
+func hasMappedField(mapping *simplejson.Json, version int, field string) bool {
+	indices, err := mapping.Map()
+	if err != nil {
+		return false
+	}
+
+	for index := range indices {
+		mappings := mapping.GetPath(index, "mappings")
+		if version >= 70 {
+			if hasProperty(mappings, field) {
+				return true
+			}
+			continue
+		}
+
+		types, err := mappings.Map()
+		if err != nil {
+			continue
+		}
+		for typ := range types {
+			if hasProperty(mappings.Get(typ), field) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasProperty(mapping *simplejson.Json, field string) bool {
+	for _, name := range strings.Split(field, ".") {
+		var ok bool
+		mapping, ok = mapping.Get("properties").CheckGet(name)
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package elasticsearch
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckHealth(t *testing.T) {
+	client := newFakeClient(70)
+	origNewClient := es.NewClient
+	es.NewClient = func(ctx context.Context, ds *models.DataSource, timeRange *tsdb.TimeRange) (es.Client, error) {
+		return client, nil
+	}
+	defer func() {
+		es.NewClient = origNewClient
+	}()
+
+	newMapping := func(body string) *simplejson.Json {
+		mapping, err := simplejson.NewJson([]byte(body))
+		require.NoError(t, err)
+		return mapping
+	}
+	executor := &ElasticsearchExecutor{}
+
+	t.Run("Should pass when the time field is mapped", func(t *testing.T) {
+		client.version = 70
+		client.mapping = newMapping(`{"logs": {"mappings": {"properties": {"@timestamp": {"type": "date"}}}}}`)
+		result, err := executor.CheckHealth(context.Background(), &models.DataSource{})
+		require.NoError(t, err)
+		require.Equal(t, tsdb.HealthStatusOk, result.Status)
+	})
+
+	t.Run("Should find the time field in the types of the mappings before Elasticsearch 7", func(t *testing.T) {
+		client.version = 60
+		client.mapping = newMapping(`{"logs": {"mappings": {"doc": {"properties": {"@timestamp": {"type": "date"}}}}}}`)
+		_, err := executor.CheckHealth(context.Background(), &models.DataSource{})
+		require.NoError(t, err)
+	})
+
+	t.Run("Should find a nested time field", func(t *testing.T) {
+		client.version = 70
+		client.timeField = "event.created"
+		defer func() { client.timeField = "@timestamp" }()
+		client.mapping = newMapping(`{"logs": {"mappings": {"properties": {"event": {"properties": {"created": {"type": "date"}}}}}}}`)
+		_, err := executor.CheckHealth(context.Background(), &models.DataSource{})
+		require.NoError(t, err)
+	})
+
+	t.Run("Should fail when the time field isn't mapped", func(t *testing.T) {
+		client.version = 70
+		client.mapping = newMapping(`{"logs": {"mappings": {"properties": {"message": {"type": "text"}}}}}`)
+		_, err := executor.CheckHealth(context.Background(), &models.DataSource{})
+		require.EqualError(t, err, "no date field named @timestamp found")
+	})
+
+	t.Run("Should fail when the mapping can't be read", func(t *testing.T) {
+		client.mappingError = errors.New("connection refused")
+		defer func() { client.mappingError = nil }()
+		_, err := executor.CheckHealth(context.Background(), &models.DataSource{})
+		require.EqualError(t, err, "connection refused")
+	})
+}
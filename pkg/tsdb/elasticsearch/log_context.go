@@ -0,0 +1,125 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+)
+
+// LogContext searches the documents matching the query of the row around the position, sorted
+// by time. The time of the documents is the one of their sort value, in milliseconds.
+func (e *ElasticsearchExecutor) LogContext(ctx context.Context, dsInfo *models.DataSource, query *tsdb.LogContextQuery) ([]*tsdb.LogRow, error) {
+	positionMs := query.Position.TimeEpochNs / int64(time.Millisecond)
+	windowMs := tsdb.LogContextWindow.Milliseconds()
+	from, to := positionMs, positionMs+windowMs
+	if query.Direction == tsdb.LogContextBefore {
+		from, to = positionMs-windowMs, positionMs
+	}
+
+	client, err := es.NewClient(ctx, dsInfo, tsdb.NewTimeRange(strconv.FormatInt(from, 10), strconv.FormatInt(to, 10)))
+	if err != nil {
+		return nil, err
+	}
+	timeField := client.GetTimeField()
+
+	ms := client.MultiSearch()
+	b := ms.Search(tsdb.Interval{})
+	b.Size(query.Limit + len(query.Position.SkipIDs))
+	if query.Direction == tsdb.LogContextBefore {
+		b.SortDesc(timeField, "boolean")
+	} else {
+		b.SortAsc(timeField, "boolean")
+	}
+	filters := b.Query().Bool().Filter()
+	filters.AddDateRangeFilter(timeField, strconv.FormatInt(to, 10), strconv.FormatInt(from, 10), es.DateFormatEpochMS)
+	filters.AddQueryStringFilter(query.Model.Get("query").MustString(), true)
+
+	req, err := ms.Build()
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.ExecuteMultisearch(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Responses) != 1 {
+		return nil, fmt.Errorf("unexpected number of responses: %d", len(res.Responses))
+	}
+	if res.Responses[0].Error != nil {
+		return nil, fmt.Errorf("failed to search the log context: %s", getErrorFromElasticResponse(res.Responses[0]).ErrorString)
+	}
+	if res.Responses[0].Hits == nil {
+		return []*tsdb.LogRow{}, nil
+	}
+
+	messageField := dsInfo.JsonData.Get("logMessageField").MustString()
+	rows := make([]*tsdb.LogRow, 0, len(res.Responses[0].Hits.Hits))
+	for _, hit := range res.Responses[0].Hits.Hits {
+		row, err := hitToLogRow(hit, timeField, messageField)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+
+	// the documents of the same millisecond are in no particular order, so they're sorted by id
+	// to get the same pages every time
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].TimeEpochNs != rows[j].TimeEpochNs {
+			if query.Direction == tsdb.LogContextBefore {
+				return rows[i].TimeEpochNs > rows[j].TimeEpochNs
+			}
+			return rows[i].TimeEpochNs < rows[j].TimeEpochNs
+		}
+		return rows[i].ID < rows[j].ID
+	})
+	return tsdb.SkipLogRows(query.Position, rows), nil
+}
+
+// hitToLogRow converts a document to a log row. The line is the message field of the data
+// source, or the whole document when it has none, and the labels the other string fields.
+func hitToLogRow(hit map[string]interface{}, timeField, messageField string) (*tsdb.LogRow, error) {
+	id, _ := hit["_id"].(string)
+	sortValues, _ := hit["sort"].([]interface{})
+	if len(sortValues) == 0 {
+		return nil, fmt.Errorf("document %q has no sort value", id)
+	}
+	timeMs, ok := sortValues[0].(float64)
+	if !ok {
+		return nil, fmt.Errorf("document %q has no time in the field %s", id, timeField)
+	}
+
+	row := &tsdb.LogRow{
+		TimeEpochNs: int64(timeMs) * int64(time.Millisecond),
+		ID:          id,
+		Labels:      map[string]string{},
+	}
+
+	source, _ := hit["_source"].(map[string]interface{})
+	for name, value := range source {
+		if name == timeField || name == messageField {
+			continue
+		}
+		if s, ok := value.(string); ok {
+			row.Labels[name] = s
+		}
+	}
+
+	if message, ok := source[messageField].(string); ok && messageField != "" {
+		row.Line = message
+		return row, nil
+	}
+	line, err := json.Marshal(source)
+	if err != nil {
+		return nil, err
+	}
+	row.Line = string(line)
+	return row, nil
+}
@@ -0,0 +1,59 @@
+package elasticsearch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogContext(t *testing.T) {
+	client := newFakeClient(70)
+	origNewClient := es.NewClient
+	es.NewClient = func(ctx context.Context, ds *models.DataSource, timeRange *tsdb.TimeRange) (es.Client, error) {
+		return client, nil
+	}
+	defer func() {
+		es.NewClient = origNewClient
+	}()
+
+	hit := func(id string, timeMs float64, message string) map[string]interface{} {
+		return map[string]interface{}{
+			"_id":     id,
+			"sort":    []interface{}{timeMs},
+			"_source": map[string]interface{}{"@timestamp": "ignored", "msg": message, "host": "a", "bytes": 10.0},
+		}
+	}
+	client.multiSearchResponse = &es.MultiSearchResponse{Responses: []*es.SearchResponse{{
+		Hits: &es.SearchResponseHits{Hits: []map[string]interface{}{
+			hit("c", 2000, "third"), hit("b", 1000, "second"), hit("a", 1000, "row"),
+		}},
+	}}}
+
+	ds := &models.DataSource{JsonData: simplejson.NewFromAny(map[string]interface{}{"logMessageField": "msg"})}
+	query := &tsdb.LogContextQuery{
+		Model:     simplejson.NewFromAny(map[string]interface{}{"query": "level:error"}),
+		Direction: tsdb.LogContextAfter,
+		Limit:     2,
+		Position:  tsdb.LogPosition{TimeEpochNs: 1000 * 1000000, SkipIDs: []string{"a"}},
+	}
+	rows, err := (&ElasticsearchExecutor{}).LogContext(context.Background(), ds, query)
+	require.NoError(t, err)
+
+	require.Len(t, rows, 2)
+	assert.Equal(t, &tsdb.LogRow{TimeEpochNs: 1000 * 1000000, ID: "b", Line: "second", Labels: map[string]string{"host": "a"}}, rows[0])
+	assert.Equal(t, "c", rows[1].ID)
+
+	sr := client.multisearchRequests[0].Requests[0]
+	assert.Equal(t, 3, sr.Size)
+	assert.Equal(t, "asc", sr.Sort["@timestamp"].(map[string]string)["order"])
+	rangeFilter := sr.Query.Bool.Filters[0].(*es.RangeFilter)
+	assert.Equal(t, "1000", rangeFilter.Gte)
+	assert.Equal(t, "7201000", rangeFilter.Lte)
+	assert.Equal(t, "level:error", sr.Query.Bool.Filters[1].(*es.QueryStringFilter).Query)
+}
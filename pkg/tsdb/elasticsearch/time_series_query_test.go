@@ -728,6 +728,8 @@ type fakeClient struct {
 	timeField           string
 	multiSearchResponse *es.MultiSearchResponse
 	multiSearchError    error
+	mapping             *simplejson.Json
+	mappingError        error
 	builder             *es.MultiSearchRequestBuilder
 	multisearchRequests []*es.MultiSearchRequest
 }
@@ -760,6 +762,10 @@ func (c *fakeClient) ExecuteMultisearch(r *es.MultiSearchRequest) (*es.MultiSear
 	return c.multiSearchResponse, c.multiSearchError
 }
 
+func (c *fakeClient) GetMapping() (*simplejson.Json, error) {
+	return c.mapping, c.mappingError
+}
+
 func (c *fakeClient) MultiSearch() *es.MultiSearchRequestBuilder {
 	c.builder = es.NewMultiSearchRequestBuilder(c.version)
 	return c.builder
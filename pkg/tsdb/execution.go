@@ -0,0 +1,38 @@
+package tsdb
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// ExecutionMeta tells what the backend did to run the queries of a request, for the query
+// inspector: how long it took, whether the response is cached, and the HTTP requests sent to the
+// data source.
+type ExecutionMeta struct {
+	// DurationMs is how long the data source took to run the queries
+	DurationMs float64 `json:"durationMs"`
+	// UpstreamDurationMs is how long the HTTP requests to the data source took, until their
+	// response headers, which is more than DurationMs when they're sent concurrently
+	UpstreamDurationMs float64 `json:"upstreamDurationMs"`
+	// Bytes is the size of the response bodies of the data source
+	Bytes int64 `json:"bytes"`
+	// Retries is how many times the HTTP requests to the data source were retried
+	Retries int `json:"retries"`
+	// Cache is whether the response is served from the query cache: HIT, MISS or BYPASS
+	Cache    string                     `json:"cache,omitempty"`
+	Requests []models.DataSourceRequest `json:"requests"`
+}
+
+func newExecutionMeta(duration time.Duration, requests []models.DataSourceRequest) *ExecutionMeta {
+	meta := &ExecutionMeta{
+		DurationMs: float64(duration) / float64(time.Millisecond),
+		Requests:   requests,
+	}
+	for _, req := range requests {
+		meta.UpstreamDurationMs += req.DurationMs
+		meta.Bytes += req.Bytes
+		meta.Retries += req.Retries
+	}
+	return meta
+}
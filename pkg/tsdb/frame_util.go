@@ -2,6 +2,8 @@ package tsdb
 
 import (
 	"fmt"
+	"reflect"
+	"strconv"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
@@ -94,3 +96,114 @@ func FrameToSeriesSlice(frame *data.Frame) (TimeSeriesSlice, error) {
 	return seriesSlice, nil
 
 }
+
+// TableToFrame converts a Table to a sdk Frame. The type of a column is the type of its
+// first value that is not null, values of a different type are converted or become null.
+func TableToFrame(table *Table) *data.Frame {
+	fields := make([]*data.Field, len(table.Columns))
+
+	for colIdx, column := range table.Columns {
+		var first interface{}
+		for _, row := range table.Rows {
+			if colIdx < len(row) && row[colIdx] != nil {
+				first = row[colIdx]
+				break
+			}
+		}
+
+		var values interface{}
+		switch first.(type) {
+		case time.Time, *time.Time:
+			vec := make([]*time.Time, len(table.Rows))
+			for rowIdx, row := range table.Rows {
+				vec[rowIdx] = tableTimeAt(row, colIdx)
+			}
+			values = vec
+		case bool, *bool:
+			vec := make([]*bool, len(table.Rows))
+			for rowIdx, row := range table.Rows {
+				if v, ok := tableValueAt(row, colIdx).(bool); ok {
+					vec[rowIdx] = &v
+				}
+			}
+			values = vec
+		case string, []byte, nil:
+			vec := make([]*string, len(table.Rows))
+			for rowIdx, row := range table.Rows {
+				if v := tableValueAt(row, colIdx); v != nil {
+					s := tableString(v)
+					vec[rowIdx] = &s
+				}
+			}
+			values = vec
+		default:
+			vec := make([]*float64, len(table.Rows))
+			for rowIdx, row := range table.Rows {
+				if v, ok := tableFloat(tableValueAt(row, colIdx)); ok {
+					vec[rowIdx] = &v
+				}
+			}
+			values = vec
+		}
+
+		fields[colIdx] = data.NewField(column.Text, nil, values)
+	}
+
+	return data.NewFrame("", fields...)
+}
+
+// tableValueAt returns the value of the column in the row, dereferencing pointers
+func tableValueAt(row RowValues, colIdx int) interface{} {
+	if colIdx >= len(row) || row[colIdx] == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(row[colIdx])
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	return v.Interface()
+}
+
+func tableTimeAt(row RowValues, colIdx int) *time.Time {
+	if t, ok := tableValueAt(row, colIdx).(time.Time); ok {
+		return &t
+	}
+	return nil
+}
+
+func tableString(value interface{}) string {
+	if b, ok := value.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(value)
+}
+
+func tableFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case null.Float:
+		return v.Float64, v.Valid
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	case []byte:
+		f, err := strconv.ParseFloat(string(v), 64)
+		return f, err == nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	}
+
+	return 0, false
+}
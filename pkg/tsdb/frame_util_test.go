@@ -137,3 +137,28 @@ func TestFrameToSeriesSlice(t *testing.T) {
 		})
 	}
 }
+
+func TestTableToFrame(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 0, 0, time.UTC)
+	table := &Table{
+		Columns: []TableColumn{{Text: "time"}, {Text: "host"}, {Text: "value"}, {Text: "up"}, {Text: "empty"}},
+		Rows: []RowValues{
+			{ts, "web-1", int64(3), true, nil},
+			{nil, []byte("web-2"), "4.5", nil, nil},
+		},
+	}
+
+	frame := TableToFrame(table)
+
+	expected := data.NewFrame("",
+		data.NewField("time", nil, []*time.Time{&ts, nil}),
+		data.NewField("host", nil, []*string{pointer.String("web-1"), pointer.String("web-2")}),
+		data.NewField("value", nil, []*float64{pointer.Float64(3), pointer.Float64(4.5)}),
+		data.NewField("up", nil, []*bool{pointer.Bool(true), nil}),
+		data.NewField("empty", nil, []*string{nil, nil}),
+	)
+
+	if diff := cmp.Diff(expected, frame, data.FrameTestCompareOptions()...); diff != "" {
+		t.Errorf("Result mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -168,7 +168,11 @@ func (e *GraphiteExecutor) createRequest(dsInfo *models.DataSource, data url.Val
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	if dsInfo.BasicAuth {
-		req.SetBasicAuth(dsInfo.BasicAuthUser, dsInfo.DecryptedBasicAuthPassword())
+		password, err := dsInfo.DecryptedBasicAuthPassword()
+		if err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth(dsInfo.BasicAuthUser, password)
 	}
 
 	return req, err
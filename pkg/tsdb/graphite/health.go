@@ -0,0 +1,39 @@
+package graphite
+
+import (
+	"context"
+	"net/url"
+
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// CheckHealth renders a constant line over the last minute, which only needs graphite-web to respond.
+func (e *GraphiteExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) (*tsdb.HealthCheckResult, error) {
+	req, err := e.createRequest(dsInfo, url.Values{
+		"target": []string{"constantLine(1)"},
+		"from":   []string{"-1min"},
+		"until":  []string{"now"},
+		"format": []string{"json"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient, err := dsInfo.GetHttpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := ctxhttp.Do(ctx, httpClient, req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := e.parseResponse(res); err != nil {
+		return nil, err
+	}
+
+	return tsdb.HealthOk("Data source is working"), nil
+}
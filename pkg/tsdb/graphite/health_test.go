@@ -0,0 +1,43 @@
+package graphite
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckHealth(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/render", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "constantLine(1)", r.Form.Get("target"))
+
+		w.WriteHeader(status)
+		_, err := w.Write([]byte(`[{"target": "1", "datapoints": [[1, 1600000000]]}]`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	dsInfo := &models.DataSource{Id: 1, Url: server.URL, JsonData: simplejson.New()}
+	executor := &GraphiteExecutor{}
+
+	t.Run("Should pass when graphite renders the constant line", func(t *testing.T) {
+		status = http.StatusOK
+		result, err := executor.CheckHealth(context.Background(), dsInfo)
+		require.NoError(t, err)
+		require.Equal(t, tsdb.HealthStatusOk, result.Status)
+	})
+
+	t.Run("Should fail when graphite returns an error", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		_, err := executor.CheckHealth(context.Background(), dsInfo)
+		require.Error(t, err)
+	})
+}
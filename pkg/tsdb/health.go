@@ -0,0 +1,64 @@
+package tsdb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// HealthStatus is the status of a data source, as reported by its health check.
+type HealthStatus string
+
+const (
+	HealthStatusOk      HealthStatus = "OK"
+	HealthStatusError   HealthStatus = "ERROR"
+	HealthStatusUnknown HealthStatus = "UNKNOWN"
+)
+
+// ErrHealthCheckNotSupported is returned when the query endpoint of a data source can't check its health.
+var ErrHealthCheckNotSupported = errors.New("health check not supported by the data source")
+
+// HealthCheckResult is the result of the health check of a data source.
+type HealthCheckResult struct {
+	Status  HealthStatus
+	Message string
+	Details map[string]interface{}
+}
+
+// HealthChecker is implemented by the query endpoints which can check that their data source
+// works, with a cheap request like the test queries of the frontend. An error returned by
+// CheckHealth is the data source failing the check.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context, dsInfo *models.DataSource) (*HealthCheckResult, error)
+}
+
+// CheckHealth checks the health of a data source with the query endpoint of its type.
+func CheckHealth(ctx context.Context, dsInfo *models.DataSource) (*HealthCheckResult, error) {
+	endpoint, err := getTsdbQueryEndpointFor(dsInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	checker, ok := endpoint.(HealthChecker)
+	if !ok {
+		return nil, ErrHealthCheckNotSupported
+	}
+
+	start := time.Now()
+	result, err := checker.CheckHealth(ctx, dsInfo)
+	if err != nil {
+		result = &HealthCheckResult{Status: HealthStatusError, Message: err.Error()}
+	}
+	if result.Details == nil {
+		result.Details = map[string]interface{}{}
+	}
+	result.Details["durationMs"] = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// HealthOk returns the result of a passing health check with a message.
+func HealthOk(message string) *HealthCheckResult {
+	return &HealthCheckResult{Status: HealthStatusOk, Message: message}
+}
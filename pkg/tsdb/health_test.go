@@ -0,0 +1,52 @@
+package tsdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeHealthChecker struct {
+	FakeExecutor
+	err error
+}
+
+func (e *fakeHealthChecker) CheckHealth(ctx context.Context, dsInfo *models.DataSource) (*HealthCheckResult, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return HealthOk("Data source is working"), nil
+}
+
+func TestCheckHealth(t *testing.T) {
+	checker := &fakeHealthChecker{}
+	RegisterTsdbQueryEndpoint("test-health", func(dsInfo *models.DataSource) (TsdbQueryEndpoint, error) {
+		return checker, nil
+	})
+
+	t.Run("Should return the result of the health check", func(t *testing.T) {
+		checker.err = nil
+		result, err := CheckHealth(context.Background(), &models.DataSource{Type: "test-health"})
+		require.NoError(t, err)
+		require.Equal(t, HealthStatusOk, result.Status)
+		require.Equal(t, "Data source is working", result.Message)
+		require.Contains(t, result.Details, "durationMs")
+	})
+
+	t.Run("Should return an error status when the health check fails", func(t *testing.T) {
+		checker.err = errors.New("connection refused")
+		result, err := CheckHealth(context.Background(), &models.DataSource{Type: "test-health"})
+		require.NoError(t, err)
+		require.Equal(t, HealthStatusError, result.Status)
+		require.Equal(t, "connection refused", result.Message)
+	})
+
+	t.Run("Should return an error when the query endpoint can't check the health", func(t *testing.T) {
+		registerFakeExecutor()
+		_, err := CheckHealth(context.Background(), &models.DataSource{Type: "test"})
+		require.Equal(t, ErrHealthCheckNotSupported, err)
+	})
+}
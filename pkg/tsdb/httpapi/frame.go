@@ -0,0 +1,308 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// column is the values of a field read from the rows of a response, before they're converted to
+// the type of the field
+type column struct {
+	name      string
+	fieldType string
+	values    []interface{}
+	// fromCSV tells that the values are the strings of CSV, whose empty and null values are null
+	// unless the field is a string field
+	fromCSV bool
+}
+
+// responseFrame reads the frame of the CSV or JSON response of a query
+func responseFrame(format string, body []byte, model *queryModel) (*data.Frame, error) {
+	var columns []*column
+	var err error
+	if format == formatCSV {
+		columns, err = csvColumns(body, model.Fields)
+	} else {
+		columns, err = jsonColumns(body, model)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	frame := data.NewFrame("")
+	for _, c := range columns {
+		frame.Fields = append(frame.Fields, c.field())
+	}
+	return frame, nil
+}
+
+// jsonColumns reads the columns of the fields from the rows of a JSON response, the items of the
+// array at the root path or the object at the root path. Without configured fields, every key of
+// the rows with a scalar value is a field, the fields sorted by name.
+func jsonColumns(body []byte, model *queryModel) ([]*column, error) {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON response: %w", err)
+	}
+	root, ok := model.rootPath.Lookup(doc)
+	if !ok {
+		return nil, fmt.Errorf("root path %q not found in the response", model.RootPath)
+	}
+
+	var rows []interface{}
+	switch v := root.(type) {
+	case []interface{}:
+		rows = v
+	case map[string]interface{}:
+		rows = []interface{}{v}
+	default:
+		return nil, fmt.Errorf("the rows of the response must be an array or an object")
+	}
+
+	if len(model.Fields) == 0 {
+		return inferredJSONColumns(rows), nil
+	}
+
+	columns := make([]*column, 0, len(model.Fields))
+	for _, field := range model.Fields {
+		if field.path == nil {
+			return nil, fmt.Errorf("field %q has an invalid path %q", field.Name, field.Path)
+		}
+		c := &column{name: field.Name, fieldType: field.Type, values: make([]interface{}, len(rows))}
+		for i, row := range rows {
+			if value, ok := field.path.Lookup(row); ok {
+				c.values[i] = value
+			}
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+func inferredJSONColumns(rows []interface{}) []*column {
+	keys := []string{}
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		object, _ := row.(map[string]interface{})
+		for key, value := range object {
+			switch value.(type) {
+			case map[string]interface{}, []interface{}:
+				continue
+			}
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	columns := make([]*column, 0, len(keys))
+	for _, key := range keys {
+		c := &column{name: key, values: make([]interface{}, len(rows))}
+		for i, row := range rows {
+			if object, ok := row.(map[string]interface{}); ok {
+				c.values[i] = object[key]
+			}
+		}
+		columns = append(columns, c)
+	}
+	return columns
+}
+
+// csvColumns reads the columns of the fields from a CSV response, whose first line names the
+// columns. Without configured fields, every column is a field.
+func csvColumns(body []byte, fields []*fieldConfig) ([]*column, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimSpace(body)))
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV response: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header, rows := records[0], records[1:]
+	indexes := make(map[string]int, len(header))
+	for i, name := range header {
+		header[i] = strings.TrimSpace(name)
+		indexes[header[i]] = i
+	}
+	if len(fields) == 0 {
+		for _, name := range header {
+			fields = append(fields, &fieldConfig{Name: name, Path: name})
+		}
+	}
+
+	columns := make([]*column, 0, len(fields))
+	for _, field := range fields {
+		index, ok := indexes[field.Path]
+		if !ok {
+			return nil, fmt.Errorf("column %q of field %q not found in the response", field.Path, field.Name)
+		}
+		c := &column{name: field.Name, fieldType: field.Type, values: make([]interface{}, len(rows)), fromCSV: true}
+		for i, row := range rows {
+			c.values[i] = strings.TrimSpace(row[index])
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+// field converts the values of a column to a field of its type, the values which can't be
+// converted being null
+func (c *column) field() *data.Field {
+	fieldType := c.fieldType
+	if fieldType == "" {
+		fieldType = c.inferType()
+	}
+
+	switch fieldType {
+	case fieldTypeNumber:
+		values := make([]*float64, len(c.values))
+		for i, v := range c.values {
+			values[i] = toNumber(v)
+		}
+		return data.NewField(c.name, nil, values)
+	case fieldTypeBoolean:
+		values := make([]*bool, len(c.values))
+		for i, v := range c.values {
+			values[i] = toBoolean(v)
+		}
+		return data.NewField(c.name, nil, values)
+	case fieldTypeTime:
+		values := make([]*time.Time, len(c.values))
+		for i, v := range c.values {
+			values[i] = toTime(v)
+		}
+		return data.NewField(c.name, nil, values)
+	default:
+		values := make([]*string, len(c.values))
+		for i, v := range c.values {
+			values[i] = toString(v)
+		}
+		return data.NewField(c.name, nil, values)
+	}
+}
+
+// inferType returns the type of a column from all its values that aren't null, so that a panel gets
+// the same frame on every refresh. A number field named time is a time field.
+func (c *column) inferType() string {
+	isNumber, isBoolean, isTime, found := true, true, true, false
+	for _, v := range c.values {
+		if c.isNull(v) {
+			continue
+		}
+		found = true
+		switch v := v.(type) {
+		case float64:
+			isBoolean, isTime = false, false
+		case bool:
+			isNumber, isTime = false, false
+		case string:
+			if !c.fromCSV || toNumber(v) == nil {
+				isNumber = false
+			}
+			if !c.fromCSV || toBoolean(v) == nil {
+				isBoolean = false
+			}
+			if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+				isTime = false
+			}
+		default:
+			return fieldTypeString
+		}
+	}
+
+	switch {
+	case !found:
+		return fieldTypeString
+	case isNumber && strings.EqualFold(c.name, "time"):
+		return fieldTypeTime
+	case isNumber:
+		return fieldTypeNumber
+	case isBoolean:
+		return fieldTypeBoolean
+	case isTime:
+		return fieldTypeTime
+	}
+	return fieldTypeString
+}
+
+func (c *column) isNull(v interface{}) bool {
+	if c.fromCSV {
+		return v == "" || v == "null"
+	}
+	return v == nil
+}
+
+func toNumber(value interface{}) *float64 {
+	switch v := value.(type) {
+	case float64:
+		return &v
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return &f
+		}
+	}
+	return nil
+}
+
+func toBoolean(value interface{}) *bool {
+	switch v := value.(type) {
+	case bool:
+		return &v
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return &b
+		}
+	}
+	return nil
+}
+
+// toTime converts epoch seconds or milliseconds, told apart by their magnitude as the milliseconds
+// since 1970 passed 1e11 in 1973, and RFC 3339 times
+func toTime(value interface{}) *time.Time {
+	if s, ok := value.(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return &t
+		}
+	}
+	f := toNumber(value)
+	if f == nil {
+		return nil
+	}
+	ms := *f
+	if ms < 1e11 {
+		ms *= 1000
+	}
+	t := time.Unix(0, int64(ms)*int64(time.Millisecond)).UTC()
+	return &t
+}
+
+func toString(value interface{}) *string {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		s = v
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		s = strconv.FormatBool(v)
+	default:
+		encoded, _ := json.Marshal(v)
+		s = string(encoded)
+	}
+	return &s
+}
@@ -0,0 +1,22 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// CheckHealth calls the URL of the data source, with its auth.
+func (e *HTTPAPIExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) (*tsdb.HealthCheckResult, error) {
+	req, err := newRequest(dsInfo, &queryModel{Method: http.MethodGet})
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := e.do(ctx, dsInfo, req); err != nil {
+		return nil, err
+	}
+
+	return tsdb.HealthOk("Data source is working"), nil
+}
@@ -0,0 +1,216 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// HTTPAPIExecutor queries the CSV or JSON of the endpoints of an HTTP API, whose values are read
+// into typed frames, so that the small internal APIs can be graphed without a plugin of their own.
+type HTTPAPIExecutor struct {
+}
+
+func NewHTTPAPIExecutor(datasource *models.DataSource) (tsdb.TsdbQueryEndpoint, error) {
+	return &HTTPAPIExecutor{}, nil
+}
+
+var (
+	plog log.Logger
+)
+
+func init() {
+	plog = log.New("tsdb.httpapi")
+	tsdb.RegisterTsdbQueryEndpoint("httpapi", NewHTTPAPIExecutor)
+}
+
+// maxResponseSize is the size of the largest response read, the frames being held in memory
+const maxResponseSize = 10 << 20
+
+// Query calls the endpoint of each query and returns the frame of its response.
+func (e *HTTPAPIExecutor) Query(ctx context.Context, dsInfo *models.DataSource, tsdbQuery *tsdb.TsdbQuery) (*tsdb.Response, error) {
+	result := &tsdb.Response{
+		Results: map[string]*tsdb.QueryResult{},
+	}
+
+	for _, query := range tsdbQuery.Queries {
+		queryResult := &tsdb.QueryResult{RefId: query.RefId}
+		frame, err := e.executeQuery(ctx, dsInfo, query, tsdbQuery.TimeRange)
+		if err != nil {
+			queryResult.Error = err
+		} else {
+			queryResult.Dataframes = tsdb.NewDecodedDataFrames(data.Frames{frame})
+		}
+		result.Results[query.RefId] = queryResult
+	}
+
+	return result, nil
+}
+
+func (e *HTTPAPIExecutor) executeQuery(ctx context.Context, dsInfo *models.DataSource, query *tsdb.Query, timeRange *tsdb.TimeRange) (*data.Frame, error) {
+	model, err := parseQueryModel(query.Model)
+	if err != nil {
+		return nil, err
+	}
+	model.interpolate(timeRange)
+
+	req, err := newRequest(dsInfo, model)
+	if err != nil {
+		return nil, err
+	}
+	body, contentType, err := e.do(ctx, dsInfo, req)
+	if err != nil {
+		return nil, err
+	}
+
+	format := model.Format
+	if format == "" {
+		format = detectFormat(contentType, body)
+	}
+	frame, err := responseFrame(format, body, model)
+	if err != nil {
+		return nil, err
+	}
+	frame.Name = query.RefId
+	frame.RefID = query.RefId
+	frame.Meta = &data.FrameMeta{ExecutedQueryString: req.Method + " " + req.URL.String()}
+	return frame, nil
+}
+
+// newRequest returns the request of a query, to the path of the query relative to the URL of the
+// data source
+func newRequest(dsInfo *models.DataSource, model *queryModel) (*http.Request, error) {
+	u, err := url.Parse(dsInfo.Url)
+	if err != nil {
+		return nil, err
+	}
+	if model.Path != "" {
+		ref, err := url.Parse(model.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path: %w", err)
+		}
+		if ref.IsAbs() || ref.Host != "" {
+			return nil, fmt.Errorf("invalid path %q, it must be relative to the URL of the data source", model.Path)
+		}
+		u.Path = path.Join(u.Path, ref.Path)
+		if strings.HasSuffix(ref.Path, "/") {
+			u.Path += "/"
+		}
+		u.RawQuery = ref.RawQuery
+	}
+	params := u.Query()
+	for _, param := range model.Params {
+		if param.Key != "" {
+			params.Add(param.Key, param.Value)
+		}
+	}
+	u.RawQuery = params.Encode()
+
+	var body io.Reader
+	if model.Method == http.MethodPost {
+		body = strings.NewReader(model.Body)
+	}
+	req, err := http.NewRequest(model.Method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for _, header := range model.Headers {
+		if header.Key != "" {
+			req.Header.Set(header.Key, header.Value)
+		}
+	}
+	if model.Method == http.MethodPost && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if dsInfo.BasicAuth {
+		password, err := dsInfo.DecryptedBasicAuthPassword()
+		if err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth(dsInfo.BasicAuthUser, password)
+	}
+	return req, nil
+}
+
+// do sends a request and returns the body and the content type of its response. The errors of the
+// API are plain text.
+func (e *HTTPAPIExecutor) do(ctx context.Context, dsInfo *models.DataSource, req *http.Request) ([]byte, string, error) {
+	httpClient, err := dsInfo.GetHttpClient()
+	if err != nil {
+		return nil, "", err
+	}
+
+	res, err := ctxhttp.Do(ctx, httpClient, req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		message, _ := ioutil.ReadAll(io.LimitReader(res.Body, 512))
+		plog.Info("Request failed", "status", res.Status, "body", string(message))
+		if len(message) > 0 {
+			return nil, "", fmt.Errorf("request failed status: %v: %s", res.Status, strings.TrimSpace(string(message)))
+		}
+		return nil, "", fmt.Errorf("request failed status: %v", res.Status)
+	}
+
+	content, err := ioutil.ReadAll(io.LimitReader(res.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(content) > maxResponseSize {
+		return nil, "", fmt.Errorf("the response is larger than %d MB", maxResponseSize>>20)
+	}
+	return content, res.Header.Get("Content-Type"), nil
+}
+
+// detectFormat tells whether a response is CSV or JSON from its content type, or from its content
+// when its content type is neither
+func detectFormat(contentType string, body []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "text/csv" || mediaType == "application/csv":
+		return formatCSV
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return formatJSON
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return formatJSON
+	}
+	return formatCSV
+}
+
+// interpolate replaces $__from and $__to with the time range of the query in epoch milliseconds,
+// in the path, the parameters and the body of the query
+func (m *queryModel) interpolate(timeRange *tsdb.TimeRange) {
+	if timeRange == nil {
+		return
+	}
+	replacer := strings.NewReplacer(
+		"$__from", strconv.FormatInt(timeRange.GetFromAsMsEpoch(), 10),
+		"$__to", strconv.FormatInt(timeRange.GetToAsMsEpoch(), 10),
+	)
+	m.Path = replacer.Replace(m.Path)
+	m.Body = replacer.Replace(m.Body)
+	for i := range m.Params {
+		m.Params[i].Value = replacer.Replace(m.Params[i].Value)
+	}
+}
@@ -0,0 +1,222 @@
+package httpapi
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/securejsondata"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPAPI(t *testing.T) {
+	var request *http.Request
+	var requestBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		request, requestBody = r, string(body)
+		switch r.URL.Path {
+		case "/api/sensors":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"data": {"sensors": [
+				{"id": "a", "time": 1600000000000, "reading": {"value": 21.5}, "ok": true, "tags": ["x"]},
+				{"id": "b", "time": 1600000060000, "reading": {"value": null}, "ok": false, "since": "2020-09-13T12:26:40Z"}
+			]}}`))
+		case "/api/status":
+			_, _ = w.Write([]byte(`{"version": "1.2", "uptime": 3600}`))
+		case "/export.csv":
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			_, _ = w.Write([]byte("time,host,load,up\n1600000000000,a,0.5,true\n1600000060000,b,,false\n"))
+		default:
+			http.Error(w, "Not found", http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	dsInfo := &models.DataSource{Id: 1, Type: "httpapi", Url: server.URL, JsonData: simplejson.New()}
+	executor := &HTTPAPIExecutor{}
+	timeRange := tsdb.NewTimeRange("1600000000000", "1600003600000")
+
+	query := func(t *testing.T, model map[string]interface{}) (*data.Frame, error) {
+		result, err := executor.Query(context.Background(), dsInfo, &tsdb.TsdbQuery{
+			TimeRange: timeRange,
+			Queries:   []*tsdb.Query{{RefId: "A", Model: simplejson.NewFromAny(model)}},
+		})
+		require.NoError(t, err)
+		if err := result.Results["A"].Error; err != nil {
+			return nil, err
+		}
+		frames, err := result.Results["A"].Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		return frames[0], nil
+	}
+
+	t.Run("Reads the fields of the rows of a JSON response", func(t *testing.T) {
+		frame, err := query(t, map[string]interface{}{
+			"path":     "api/sensors",
+			"rootPath": "$.data.sensors",
+			"fields": []map[string]interface{}{
+				{"name": "time", "type": "time"},
+				{"name": "sensor", "path": "id"},
+				{"name": "value", "path": "reading.value", "type": "number"},
+				{"name": "ok"},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, frame.Rows())
+		assert.Equal(t, "GET "+server.URL+"/api/sensors", frame.Meta.ExecutedQueryString)
+
+		t1, t2 := time.Unix(1600000000, 0).UTC(), time.Unix(1600000060, 0).UTC()
+		value, a, b, ok1, ok2 := 21.5, "a", "b", true, false
+		assert.Equal(t, data.NewField("time", nil, []*time.Time{&t1, &t2}), frame.Fields[0])
+		assert.Equal(t, data.NewField("sensor", nil, []*string{&a, &b}), frame.Fields[1])
+		assert.Equal(t, data.NewField("value", nil, []*float64{&value, nil}), frame.Fields[2])
+		assert.Equal(t, data.NewField("ok", nil, []*bool{&ok1, &ok2}), frame.Fields[3])
+	})
+
+	t.Run("Infers the fields of the scalar values of the rows", func(t *testing.T) {
+		frame, err := query(t, map[string]interface{}{"path": "api/sensors", "rootPath": "data.sensors"})
+		require.NoError(t, err)
+		names := []string{}
+		for _, field := range frame.Fields {
+			names = append(names, field.Name)
+		}
+		assert.Equal(t, []string{"id", "ok", "since", "time"}, names)
+		assert.Equal(t, data.FieldTypeNullableBool, frame.Fields[1].Type())
+		assert.Equal(t, data.FieldTypeNullableTime, frame.Fields[2].Type())
+		assert.Nil(t, frame.Fields[2].At(0))
+		assert.Equal(t, data.FieldTypeNullableTime, frame.Fields[3].Type())
+	})
+
+	t.Run("An object is a single row", func(t *testing.T) {
+		frame, err := query(t, map[string]interface{}{"path": "/api/status"})
+		require.NoError(t, err)
+		require.Equal(t, 1, frame.Rows())
+		uptime, version := 3600.0, "1.2"
+		assert.Equal(t, data.NewField("uptime", nil, []*float64{&uptime}), frame.Fields[0])
+		assert.Equal(t, data.NewField("version", nil, []*string{&version}), frame.Fields[1])
+	})
+
+	t.Run("Reads the columns of a CSV response", func(t *testing.T) {
+		frame, err := query(t, map[string]interface{}{"path": "export.csv"})
+		require.NoError(t, err)
+		require.Len(t, frame.Fields, 4)
+		assert.Equal(t, data.FieldTypeNullableTime, frame.Fields[0].Type())
+		assert.Equal(t, data.FieldTypeNullableString, frame.Fields[1].Type())
+		load := 0.5
+		assert.Equal(t, data.NewField("load", nil, []*float64{&load, nil}), frame.Fields[2])
+		assert.Equal(t, data.FieldTypeNullableBool, frame.Fields[3].Type())
+
+		frame, err = query(t, map[string]interface{}{"path": "export.csv", "format": "csv", "fields": []map[string]interface{}{
+			{"name": "load average", "path": "load", "type": "string"},
+		}})
+		require.NoError(t, err)
+		loadText, empty := "0.5", ""
+		assert.Equal(t, data.NewField("load average", nil, []*string{&loadText, &empty}), frame.Fields[0])
+
+		_, err = query(t, map[string]interface{}{"path": "export.csv", "fields": []map[string]interface{}{{"name": "cpu"}}})
+		assert.EqualError(t, err, `column "cpu" of field "cpu" not found in the response`)
+	})
+
+	t.Run("Sends the parameters, headers and body of the query", func(t *testing.T) {
+		_, err := query(t, map[string]interface{}{
+			"path":    "api/status?verbose=1",
+			"method":  "post",
+			"params":  []map[string]interface{}{{"key": "from", "value": "$__from"}},
+			"headers": []map[string]interface{}{{"key": "X-Tenant", "value": "ops"}},
+			"body":    `{"to": $__to}`,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.MethodPost, request.Method)
+		assert.Equal(t, "1600000000000", request.URL.Query().Get("from"))
+		assert.Equal(t, "1", request.URL.Query().Get("verbose"))
+		assert.Equal(t, "ops", request.Header.Get("X-Tenant"))
+		assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+		assert.Equal(t, `{"to": 1600003600000}`, requestBody)
+	})
+
+	t.Run("Invalid queries and failed requests are errors", func(t *testing.T) {
+		for model, expected := range map[string]string{
+			`{"path": "missing"}`:                                     "request failed status: 404 Not Found: Not found",
+			`{"path": "http://example.com/api"}`:                      `invalid path "http://example.com/api", it must be relative to the URL of the data source`,
+			`{"method": "DELETE"}`:                                    `unsupported method "DELETE", it must be GET or POST`,
+			`{"format": "xml"}`:                                       `unknown format "xml", it must be json or csv`,
+			`{"path": "api/status", "rootPath": "$.data"}`:            `root path "$.data" not found in the response`,
+			`{"path": "api/status", "rootPath": "$.version"}`:         "the rows of the response must be an array or an object",
+			`{"path": "api/status", "fields": [{"name": "a b[0]x"}]}`: `field "a b[0]x" has an invalid path "a b[0]x"`,
+			`{"fields": [{"name": "a", "type": "date"}]}`:             `field "a" has an unknown type "date"`,
+		} {
+			m, err := simplejson.NewJson([]byte(model))
+			require.NoError(t, err)
+			_, err = query(t, m.MustMap())
+			assert.EqualError(t, err, expected, model)
+		}
+	})
+
+	t.Run("Uses the basic auth of the data source", func(t *testing.T) {
+		dsInfo := &models.DataSource{Id: 2, Url: server.URL, JsonData: simplejson.New(), BasicAuth: true, BasicAuthUser: "user",
+			SecureJsonData: securejsondata.GetEncryptedJsonData(map[string]string{"basicAuthPassword": "pass"})}
+		_, err := executor.CheckHealth(context.Background(), dsInfo)
+		assert.EqualError(t, err, "request failed status: 404 Not Found: Not found")
+		user, password, ok := request.BasicAuth()
+		require.True(t, ok)
+		assert.Equal(t, "user", user)
+		assert.Equal(t, "pass", password)
+	})
+
+	t.Run("Signs the requests with SigV4", func(t *testing.T) {
+		origEnabled, origProviders, origServices := setting.AWSSigV4AuthEnabled, setting.AWSSigV4AllowedAuthProviders, setting.AWSSigV4AllowedServices
+		defer func() {
+			setting.AWSSigV4AuthEnabled = origEnabled
+			setting.AWSSigV4AllowedAuthProviders = origProviders
+			setting.AWSSigV4AllowedServices = origServices
+		}()
+		setting.AWSSigV4AuthEnabled = true
+		setting.AWSSigV4AllowedAuthProviders = []string{"keys"}
+		setting.AWSSigV4AllowedServices = []string{"execute-api"}
+
+		signed := &models.DataSource{
+			Id:        3,
+			Url:       server.URL,
+			BasicAuth: true,
+			JsonData: simplejson.NewFromAny(map[string]interface{}{
+				"sigV4Auth":     true,
+				"sigV4AuthType": "keys",
+				"sigV4Region":   "eu-west-1",
+			}),
+			SecureJsonData: securejsondata.GetEncryptedJsonData(map[string]string{
+				"basicAuthPassword": "pass",
+				"sigV4AccessKey":    "AKID",
+				"sigV4SecretKey":    "secret",
+			}),
+		}
+		_, err := executor.Query(context.Background(), signed, &tsdb.TsdbQuery{
+			TimeRange: timeRange,
+			Queries: []*tsdb.Query{{RefId: "A", Model: simplejson.NewFromAny(map[string]interface{}{
+				"path": "api/status", "method": "POST", "body": "{}",
+			})}},
+		})
+		require.NoError(t, err)
+
+		auth := request.Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/"), auth)
+		assert.Contains(t, auth, "/eu-west-1/execute-api/aws4_request")
+		assert.Equal(t, "{}", requestBody)
+
+		signed.Id = 4
+		signed.JsonData.Del("sigV4Region")
+		_, err = executor.CheckHealth(context.Background(), signed)
+		assert.EqualError(t, err, "SigV4 auth needs a region")
+	})
+}
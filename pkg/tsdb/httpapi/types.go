@@ -0,0 +1,123 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/jsonpath"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// Formats of the responses
+const (
+	formatJSON = "json"
+	formatCSV  = "csv"
+)
+
+// Types of the fields of the frames
+const (
+	fieldTypeNumber  = "number"
+	fieldTypeString  = "string"
+	fieldTypeBoolean = "boolean"
+	fieldTypeTime    = "time"
+)
+
+type keyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// queryModel is a query of an endpoint of the API
+type queryModel struct {
+	// Path of the endpoint relative to the URL of the data source, which can have a query string
+	Path    string     `json:"path"`
+	Method  string     `json:"method"`
+	Params  []keyValue `json:"params"`
+	Headers []keyValue `json:"headers"`
+	// Body of the POST requests
+	Body string `json:"body"`
+	// Format of the response, json or csv, detected from the response when empty
+	Format string `json:"format"`
+	// RootPath is the path of the rows in the JSON of the response, an array of rows or an object
+	// which is a single row, the whole document when empty
+	RootPath string `json:"rootPath"`
+	// Fields of the frame, all the scalar values of the rows or the columns of the CSV when empty
+	Fields []*fieldConfig `json:"fields"`
+
+	rootPath jsonpath.Path
+}
+
+// fieldConfig reads a field of the frame from the rows of the response
+type fieldConfig struct {
+	Name string `json:"name"`
+	// Path of the value in a JSON row, like $.sensor.readings[0].value, or the column of the CSV,
+	// the name of the field when empty
+	Path string `json:"path"`
+	// Type is number, string, boolean or time, inferred from the values when empty
+	Type string `json:"type"`
+
+	path jsonpath.Path
+}
+
+func parseQueryModel(model *simplejson.Json) (*queryModel, error) {
+	encoded, err := model.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	m := &queryModel{}
+	if err := json.Unmarshal(encoded, m); err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+
+	m.Method = strings.ToUpper(m.Method)
+	switch m.Method {
+	case "":
+		m.Method = http.MethodGet
+	case http.MethodGet, http.MethodPost:
+	default:
+		return nil, fmt.Errorf("unsupported method %q, it must be GET or POST", m.Method)
+	}
+
+	switch m.Format {
+	case "", formatJSON, formatCSV:
+	default:
+		return nil, fmt.Errorf("unknown format %q, it must be json or csv", m.Format)
+	}
+
+	if strings.TrimSpace(m.RootPath) != "" {
+		if m.rootPath, err = jsonpath.Parse(m.RootPath); err != nil {
+			return nil, fmt.Errorf("root path: %w", err)
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, field := range m.Fields {
+		if field.Path == "" {
+			field.Path = field.Name
+		}
+		if field.Name == "" {
+			field.Name = field.Path
+		}
+		if field.Name == "" || names[field.Name] {
+			return nil, fmt.Errorf("invalid or duplicate field name %q", field.Name)
+		}
+		names[field.Name] = true
+
+		switch field.Type {
+		case "", fieldTypeNumber, fieldTypeString, fieldTypeBoolean, fieldTypeTime:
+		default:
+			return nil, fmt.Errorf("field %q has an unknown type %q", field.Name, field.Type)
+		}
+
+		// the paths of the fields of CSV are column names, which needn't be valid JSON paths
+		if field.path, err = jsonpath.Parse(field.Path); err != nil {
+			if m.Format == formatJSON {
+				return nil, fmt.Errorf("field %q: %w", field.Name, err)
+			}
+			field.path = nil
+		}
+	}
+	return m, nil
+}
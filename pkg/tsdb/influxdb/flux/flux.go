@@ -73,7 +73,10 @@ func RunnerFromDataSource(dsInfo *models.DataSource) (*Runner, error) {
 	if url == "" {
 		return nil, fmt.Errorf("missing url from datasource configuration")
 	}
-	token, found := dsInfo.SecureJsonData.DecryptedValue("token")
+	token, found, err := dsInfo.DecryptedValue("token")
+	if err != nil {
+		return nil, err
+	}
 	if !found {
 		return nil, fmt.Errorf("token is missing from datasource configuration and is needed to use Flux")
 	}
@@ -98,3 +101,19 @@ func backendDataResponseToTSDBResponse(dr *backend.DataResponse, refID string) *
 	}
 	return qr
 }
+
+// CheckHealth lists a bucket of the organization of the data source.
+func CheckHealth(ctx context.Context, dsInfo *models.DataSource) error {
+	runner, err := RunnerFromDataSource(dsInfo)
+	if err != nil {
+		return err
+	}
+
+	result, err := runner.runQuery(ctx, "buckets() |> limit(n: 1)")
+	if err != nil {
+		return err
+	}
+	// reading the first table is enough to get the error of the query
+	result.Next()
+	return result.Err()
+}
@@ -0,0 +1,65 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/grafana/grafana/pkg/tsdb/influxdb/flux"
+)
+
+// CheckHealth lists a measurement of the database, or a bucket with Flux, so that the
+// credentials and the database are checked too.
+func (e *InfluxDBExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) (*tsdb.HealthCheckResult, error) {
+	if dsInfo.JsonData.Get("version").MustString("") == "Flux" {
+		if err := flux.CheckHealth(ctx, dsInfo); err != nil {
+			return nil, err
+		}
+		return tsdb.HealthOk("Data source is working"), nil
+	}
+
+	req, err := e.createRequest(dsInfo, "SHOW MEASUREMENTS LIMIT 1")
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient, err := dsInfo.GetHttpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ctxhttp.Do(ctx, httpClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("Influxdb returned statuscode invalid status code: %v", resp.Status)
+	}
+
+	// the errors of the statements, like an unknown database, come with a 200
+	var response struct {
+		Error   string `json:"error"`
+		Results []struct {
+			Error string `json:"error"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	if response.Error != "" {
+		return nil, errors.New(response.Error)
+	}
+	for _, result := range response.Results {
+		if result.Error != "" {
+			return nil, errors.New(result.Error)
+		}
+	}
+
+	return tsdb.HealthOk("Data source is working"), nil
+}
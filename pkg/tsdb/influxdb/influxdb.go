@@ -164,11 +164,19 @@ func (e *InfluxDBExecutor) createRequest(dsInfo *models.DataSource, query string
 	req.URL.RawQuery = params.Encode()
 
 	if dsInfo.BasicAuth {
-		req.SetBasicAuth(dsInfo.BasicAuthUser, dsInfo.DecryptedBasicAuthPassword())
+		password, err := dsInfo.DecryptedBasicAuthPassword()
+		if err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth(dsInfo.BasicAuthUser, password)
 	}
 
 	if !dsInfo.BasicAuth && dsInfo.User != "" {
-		req.SetBasicAuth(dsInfo.User, dsInfo.DecryptedPassword())
+		password, err := dsInfo.DecryptedPassword()
+		if err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth(dsInfo.User, password)
 	}
 
 	glog.Debug("Influxdb request", "url", req.URL.String())
@@ -0,0 +1,17 @@
+package jaeger
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// CheckHealth lists the services of Jaeger, the query service answers it even without traces.
+func (e *JaegerExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) (*tsdb.HealthCheckResult, error) {
+	if err := e.get(ctx, dsInfo, "api/services", nil, nil); err != nil {
+		return nil, err
+	}
+
+	return tsdb.HealthOk("Data source is working"), nil
+}
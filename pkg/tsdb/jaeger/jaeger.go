@@ -0,0 +1,202 @@
+package jaeger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+type JaegerExecutor struct {
+}
+
+func NewJaegerExecutor(datasource *models.DataSource) (tsdb.TsdbQueryEndpoint, error) {
+	return &JaegerExecutor{}, nil
+}
+
+var (
+	plog log.Logger
+)
+
+func init() {
+	plog = log.New("tsdb.jaeger")
+	tsdb.RegisterTsdbQueryEndpoint("jaeger", NewJaegerExecutor)
+}
+
+const (
+	queryTypeTrace  = "trace"
+	queryTypeSearch = "search"
+)
+
+// Query returns a trace frame for the queries of a trace ID, and a frame of the found traces for the
+// searches, of a service and an operation, tags, durations and the time range of the query.
+func (e *JaegerExecutor) Query(ctx context.Context, dsInfo *models.DataSource, tsdbQuery *tsdb.TsdbQuery) (*tsdb.Response, error) {
+	result := &tsdb.Response{
+		Results: map[string]*tsdb.QueryResult{},
+	}
+
+	for _, query := range tsdbQuery.Queries {
+		queryResult := &tsdb.QueryResult{RefId: query.RefId}
+		frames, err := e.executeQuery(ctx, dsInfo, query, tsdbQuery.TimeRange)
+		if err != nil {
+			queryResult.Error = err
+		} else {
+			queryResult.Dataframes = tsdb.NewDecodedDataFrames(frames)
+		}
+		result.Results[query.RefId] = queryResult
+	}
+
+	return result, nil
+}
+
+func (e *JaegerExecutor) executeQuery(ctx context.Context, dsInfo *models.DataSource, query *tsdb.Query, timeRange *tsdb.TimeRange) (data.Frames, error) {
+	queryType := query.Model.Get("queryType").MustString(queryTypeTrace)
+	switch queryType {
+	case queryTypeTrace:
+		traceID := query.Model.Get("query").MustString("")
+		if traceID == "" {
+			return data.Frames{}, nil
+		}
+
+		var traces []jaegerTrace
+		if err := e.get(ctx, dsInfo, "api/traces/"+url.PathEscape(traceID), nil, &traces); err != nil {
+			return nil, err
+		}
+		if len(traces) == 0 {
+			return nil, fmt.Errorf("trace %s not found", traceID)
+		}
+
+		frame, err := tsdb.NewTraceFrame(traceID, traces[0].spans())
+		if err != nil {
+			return nil, err
+		}
+		return data.Frames{frame}, nil
+	case queryTypeSearch:
+		params, err := searchParams(query, timeRange)
+		if err != nil {
+			return nil, err
+		}
+
+		var traces []jaegerTrace
+		if err := e.get(ctx, dsInfo, "api/traces", params, &traces); err != nil {
+			return nil, err
+		}
+
+		summaries := make([]*tsdb.TraceSummary, 0, len(traces))
+		for _, trace := range traces {
+			summaries = append(summaries, tsdb.SummarizeTrace(trace.TraceID, trace.spans()))
+		}
+		return data.Frames{tsdb.NewTraceSearchFrame(summaries)}, nil
+	default:
+		return nil, fmt.Errorf("unknown query type %q", queryType)
+	}
+}
+
+// searchParams returns the parameters of the search of traces of the Jaeger API, whose times are
+// in microseconds
+func searchParams(query *tsdb.Query, timeRange *tsdb.TimeRange) (url.Values, error) {
+	service := query.Model.Get("service").MustString("")
+	if service == "" {
+		return nil, fmt.Errorf("the service is required to search traces")
+	}
+
+	params := url.Values{}
+	params.Set("service", service)
+	if operation := query.Model.Get("operation").MustString(""); operation != "" {
+		params.Set("operation", operation)
+	}
+	if tags := query.Model.Get("tags").MustString(""); tags != "" {
+		// the tags are a JSON object of the tag values, like the search form of the Jaeger UI
+		var parsed map[string]string
+		if err := json.Unmarshal([]byte(tags), &parsed); err != nil {
+			return nil, fmt.Errorf("invalid tags, expected a JSON object: %w", err)
+		}
+		params.Set("tags", tags)
+	}
+	for _, key := range []string{"minDuration", "maxDuration"} {
+		if value := query.Model.Get(key).MustString(""); value != "" {
+			if _, err := time.ParseDuration(value); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			params.Set(key, value)
+		}
+	}
+	params.Set("limit", strconv.Itoa(query.Model.Get("limit").MustInt(20)))
+
+	if timeRange != nil {
+		params.Set("start", strconv.FormatInt(timeRange.GetFromAsMsEpoch()*1000, 10))
+		params.Set("end", strconv.FormatInt(timeRange.GetToAsMsEpoch()*1000, 10))
+	}
+	return params, nil
+}
+
+// get calls the HTTP API of the Jaeger query service, the one of the Jaeger UI, and decodes the data
+// of its response
+func (e *JaegerExecutor) get(ctx context.Context, dsInfo *models.DataSource, apiPath string, params url.Values, result interface{}) error {
+	u, err := url.Parse(dsInfo.Url)
+	if err != nil {
+		return err
+	}
+	u.Path = path.Join(u.Path, apiPath)
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	if dsInfo.BasicAuth {
+		password, err := dsInfo.DecryptedBasicAuthPassword()
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(dsInfo.BasicAuthUser, password)
+	}
+
+	httpClient, err := dsInfo.GetHttpClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := ctxhttp.Do(ctx, httpClient, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	var response jaegerResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		if res.StatusCode/100 != 2 {
+			return fmt.Errorf("request failed status: %v", res.Status)
+		}
+		return err
+	}
+	if len(response.Errors) > 0 {
+		return fmt.Errorf("jaeger error: %s", response.Errors[0].Msg)
+	}
+	if res.StatusCode/100 != 2 {
+		plog.Info("Request failed", "status", res.Status, "body", string(body))
+		return fmt.Errorf("request failed status: %v", res.Status)
+	}
+
+	if result == nil || response.Data == nil {
+		return nil
+	}
+	return json.Unmarshal(response.Data, result)
+}
@@ -0,0 +1,144 @@
+package jaeger
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const traceResponse = `{"data": [{
+	"traceID": "abc",
+	"spans": [
+		{"traceID": "abc", "spanID": "1", "operationName": "GET /api", "references": [], "startTime": 1600000000000000, "duration": 2500,
+			"tags": [{"key": "http.status_code", "type": "int64", "value": 200}], "logs": [], "processID": "p1"},
+		{"traceID": "abc", "spanID": "2", "operationName": "SELECT", "references": [{"refType": "CHILD_OF", "traceID": "abc", "spanID": "1"}],
+			"startTime": 1600000000001000, "duration": 1000, "tags": [],
+			"logs": [{"timestamp": 1600000000001500, "fields": [{"key": "event", "type": "string", "value": "query"}]}], "processID": "p2"}
+	],
+	"processes": {
+		"p1": {"serviceName": "api", "tags": [{"key": "hostname", "type": "string", "value": "api-1"}]},
+		"p2": {"serviceName": "db", "tags": []}
+	}
+}], "errors": null}`
+
+func TestJaeger(t *testing.T) {
+	var requestPath string
+	var params url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestPath = r.URL.Path
+		params = r.URL.Query()
+		switch r.URL.Path {
+		case "/api/traces/abc", "/api/traces":
+			_, _ = w.Write([]byte(traceResponse))
+		case "/api/services":
+			_, _ = w.Write([]byte(`{"data": ["db", "api"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"data": null, "errors": [{"code": 404, "msg": "trace not found"}]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	dsInfo := &models.DataSource{Id: 1, Type: "jaeger", Url: server.URL, JsonData: simplejson.New()}
+	executor := &JaegerExecutor{}
+	timeRange := tsdb.NewTimeRange("1600000000000", "1600003600000")
+
+	t.Run("Returns the spans of a trace", func(t *testing.T) {
+		query := &tsdb.Query{RefId: "A", Model: simplejson.NewFromAny(map[string]interface{}{"query": "abc"})}
+		result, err := executor.Query(context.Background(), dsInfo, &tsdb.TsdbQuery{TimeRange: timeRange, Queries: []*tsdb.Query{query}})
+		require.NoError(t, err)
+		require.NoError(t, result.Results["A"].Error)
+		assert.Equal(t, "/api/traces/abc", requestPath)
+
+		frames, err := result.Results["A"].Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		frame := frames[0]
+		assert.Equal(t, tsdb.TraceResultType, frame.Meta.Custom.(map[string]interface{})["resultType"])
+		require.Equal(t, 2, frame.Rows())
+
+		row := frame.RowCopy(1)
+		assert.Equal(t, []interface{}{
+			"abc", "2", "1", "SELECT", "db", "[]", 1600000000001.0, 1.0,
+			`[{"timestamp":1600000000001.5,"fields":[{"key":"event","value":"query"}]}]`, "[]",
+		}, row)
+		assert.Equal(t, `[{"key":"hostname","value":"api-1"}]`, frame.Fields[5].At(0))
+		assert.Equal(t, `[{"key":"http.status_code","value":200}]`, frame.Fields[9].At(0))
+	})
+
+	t.Run("Fails with the error of Jaeger", func(t *testing.T) {
+		query := &tsdb.Query{RefId: "A", Model: simplejson.NewFromAny(map[string]interface{}{"query": "missing"})}
+		result, err := executor.Query(context.Background(), dsInfo, &tsdb.TsdbQuery{TimeRange: timeRange, Queries: []*tsdb.Query{query}})
+		require.NoError(t, err)
+		assert.EqualError(t, result.Results["A"].Error, "jaeger error: trace not found")
+	})
+
+	t.Run("Searches traces", func(t *testing.T) {
+		query := &tsdb.Query{RefId: "A", Model: simplejson.NewFromAny(map[string]interface{}{
+			"queryType":   "search",
+			"service":     "api",
+			"operation":   "GET /api",
+			"tags":        `{"http.status_code":"500"}`,
+			"minDuration": "1.5ms",
+			"limit":       5,
+		})}
+		result, err := executor.Query(context.Background(), dsInfo, &tsdb.TsdbQuery{TimeRange: timeRange, Queries: []*tsdb.Query{query}})
+		require.NoError(t, err)
+		require.NoError(t, result.Results["A"].Error)
+		assert.Equal(t, "/api/traces", requestPath)
+		assert.Equal(t, url.Values{
+			"service":     {"api"},
+			"operation":   {"GET /api"},
+			"tags":        {`{"http.status_code":"500"}`},
+			"minDuration": {"1.5ms"},
+			"limit":       {"5"},
+			"start":       {"1600000000000000"},
+			"end":         {"1600003600000000"},
+		}, params)
+
+		frames, err := result.Results["A"].Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		assert.Equal(t, []interface{}{"abc", "api: GET /api", time.Unix(1600000000, 0), 2.5, int64(2)}, frames[0].RowCopy(0))
+	})
+
+	t.Run("Requires the service of a search", func(t *testing.T) {
+		query := &tsdb.Query{RefId: "A", Model: simplejson.NewFromAny(map[string]interface{}{"queryType": "search"})}
+		result, err := executor.Query(context.Background(), dsInfo, &tsdb.TsdbQuery{TimeRange: timeRange, Queries: []*tsdb.Query{query}})
+		require.NoError(t, err)
+		assert.Error(t, result.Results["A"].Error)
+	})
+
+	t.Run("Lists and caches the services", func(t *testing.T) {
+		result, err := executor.CallResource(context.Background(), dsInfo, "services", url.Values{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"api", "db"}, result)
+
+		requestPath = ""
+		_, err = executor.CallResource(context.Background(), dsInfo, "services", url.Values{})
+		require.NoError(t, err)
+		assert.Empty(t, requestPath)
+
+		_, err = executor.CallResource(context.Background(), dsInfo, "operations", url.Values{})
+		assert.True(t, errors.Is(err, tsdb.ErrInvalidResourceRequest))
+		_, err = executor.CallResource(context.Background(), dsInfo, "dependencies", url.Values{})
+		assert.Equal(t, tsdb.ErrResourceNotFound, err)
+	})
+
+	t.Run("Checks the health with the services", func(t *testing.T) {
+		result, err := executor.CheckHealth(context.Background(), dsInfo)
+		require.NoError(t, err)
+		assert.Equal(t, tsdb.HealthStatusOk, result.Status)
+		assert.Equal(t, "/api/services", requestPath)
+	})
+}
@@ -0,0 +1,53 @@
+package jaeger
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// servicesCacheTTL is how long the services and operations are cached, they only change when
+// services are deployed
+const servicesCacheTTL = 5 * time.Minute
+
+// servicesCache holds the services and operations of the data sources, by data source id and
+// version so that the entries of a data source are dropped when it's updated
+var servicesCache = localcache.New(servicesCacheTTL, 2*servicesCacheTTL)
+
+// CallResource serves the services and the operations of a service of the query editor, the
+// parameter of the operations is the service.
+func (e *JaegerExecutor) CallResource(ctx context.Context, dsInfo *models.DataSource, resourcePath string, params url.Values) (interface{}, error) {
+	var apiPath string
+	switch resourcePath {
+	case "services":
+		apiPath = "api/services"
+	case "operations":
+		service := params.Get("service")
+		if service == "" {
+			return nil, fmt.Errorf("%w: missing service", tsdb.ErrInvalidResourceRequest)
+		}
+		apiPath = "api/services/" + url.PathEscape(service) + "/operations"
+	default:
+		return nil, tsdb.ErrResourceNotFound
+	}
+
+	key := fmt.Sprintf("%d/%d/%s?%s", dsInfo.Id, dsInfo.Version, resourcePath, params.Encode())
+	if cached, ok := servicesCache.Get(key); ok {
+		return cached, nil
+	}
+
+	result := make([]string, 0)
+	if err := e.get(ctx, dsInfo, apiPath, nil, &result); err != nil {
+		return nil, err
+	}
+	sort.Strings(result)
+
+	servicesCache.Set(key, result, servicesCacheTTL)
+	return result, nil
+}
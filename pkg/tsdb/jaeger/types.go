@@ -0,0 +1,99 @@
+package jaeger
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+type jaegerResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []jaegerError   `json:"errors"`
+}
+
+type jaegerError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+type jaegerTrace struct {
+	TraceID   string                   `json:"traceID"`
+	Spans     []jaegerSpan             `json:"spans"`
+	Processes map[string]jaegerProcess `json:"processes"`
+}
+
+type jaegerSpan struct {
+	TraceID       string            `json:"traceID"`
+	SpanID        string            `json:"spanID"`
+	OperationName string            `json:"operationName"`
+	References    []jaegerReference `json:"references"`
+	StartTime     int64             `json:"startTime"`
+	Duration      int64             `json:"duration"`
+	Tags          []jaegerKeyValue  `json:"tags"`
+	Logs          []jaegerLog       `json:"logs"`
+	ProcessID     string            `json:"processID"`
+}
+
+type jaegerReference struct {
+	RefType string `json:"refType"`
+	TraceID string `json:"traceID"`
+	SpanID  string `json:"spanID"`
+}
+
+type jaegerKeyValue struct {
+	Key   string      `json:"key"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+type jaegerLog struct {
+	Timestamp int64            `json:"timestamp"`
+	Fields    []jaegerKeyValue `json:"fields"`
+}
+
+type jaegerProcess struct {
+	ServiceName string           `json:"serviceName"`
+	Tags        []jaegerKeyValue `json:"tags"`
+}
+
+// spans converts the spans of a trace, whose times are in microseconds, to the spans of the trace
+// frame. The parent of a span is its CHILD_OF reference, or its first reference.
+func (t jaegerTrace) spans() []*tsdb.TraceSpan {
+	spans := make([]*tsdb.TraceSpan, 0, len(t.Spans))
+	for _, s := range t.Spans {
+		process := t.Processes[s.ProcessID]
+		span := &tsdb.TraceSpan{
+			TraceID:       s.TraceID,
+			SpanID:        s.SpanID,
+			OperationName: s.OperationName,
+			ServiceName:   process.ServiceName,
+			ServiceTags:   keyValues(process.Tags),
+			StartTime:     time.Unix(0, s.StartTime*int64(time.Microsecond)),
+			Duration:      time.Duration(s.Duration) * time.Microsecond,
+			Logs:          make([]tsdb.TraceLog, 0, len(s.Logs)),
+			Tags:          keyValues(s.Tags),
+		}
+		for _, ref := range s.References {
+			if ref.RefType == "CHILD_OF" || span.ParentSpanID == "" {
+				span.ParentSpanID = ref.SpanID
+			}
+		}
+		for _, l := range s.Logs {
+			span.Logs = append(span.Logs, tsdb.TraceLog{
+				Timestamp: float64(l.Timestamp) / 1000,
+				Fields:    keyValues(l.Fields),
+			})
+		}
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+func keyValues(kvs []jaegerKeyValue) []tsdb.TraceKeyValue {
+	result := make([]tsdb.TraceKeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		result = append(result, tsdb.TraceKeyValue{Key: kv.Key, Value: kv.Value})
+	}
+	return result
+}
@@ -0,0 +1,216 @@
+package tsdb
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+const (
+	LogContextBefore LogContextDirection = "before"
+	LogContextAfter  LogContextDirection = "after"
+
+	defaultLogContextLimit = 10
+	maxLogContextLimit     = 1000
+
+	// LogContextWindow is how far from their position the context rows are looked up, the pages
+	// stop at a longer gap between two rows
+	LogContextWindow = 2 * time.Hour
+)
+
+var (
+	// ErrLogContextNotSupported is returned when the query endpoint of a data source can't fetch
+	// the context of log rows.
+	ErrLogContextNotSupported = errors.New("log context not supported by the data source")
+	// ErrInvalidLogContextQuery is wrapped by the errors of the queries without row, with an
+	// unknown direction, or a cursor which wasn't returned by a previous page.
+	ErrInvalidLogContextQuery = errors.New("invalid log context query")
+)
+
+// LogContextDirection is whether the context rows are before or after a log row.
+type LogContextDirection string
+
+// LogRow is a log row returned by a data source. The rows without id of their data source, like
+// the ones of Loki, are identified by their labels and line.
+type LogRow struct {
+	TimeEpochNs int64             `json:"timeEpochNs,string"`
+	ID          string            `json:"id"`
+	Line        string            `json:"line"`
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
+// LogPosition is where a page of context rows starts: the rows at TimeEpochNs with the ids in
+// SkipIDs were returned already.
+type LogPosition struct {
+	TimeEpochNs int64    `json:"t"`
+	SkipIDs     []string `json:"s,omitempty"`
+}
+
+// LogContextQuery asks for the rows of the stream of a log row next to a position.
+type LogContextQuery struct {
+	// Model is the query which returned the row, in the model of the data source
+	Model     *simplejson.Json
+	Row       *LogRow
+	Direction LogContextDirection
+	Limit     int
+	Position  LogPosition
+}
+
+// LogContextResult is a page of context rows, sorted from the closest to the log row. Cursor
+// fetches the next page, and is empty after the last one.
+type LogContextResult struct {
+	Rows   []*LogRow `json:"rows"`
+	Cursor string    `json:"cursor,omitempty"`
+}
+
+// LogContextProvider is implemented by the query endpoints which can fetch the rows before and
+// after a log row, for the "show context" of Explore. LogContext returns up to query.Limit rows
+// at or beyond query.Position in query.Direction, without the skipped ones, sorted from the
+// closest to the position.
+type LogContextProvider interface {
+	LogContext(ctx context.Context, dsInfo *models.DataSource, query *LogContextQuery) ([]*LogRow, error)
+}
+
+// LogContext fetches a page of the context of a log row with the query endpoint of the type of
+// its data source. The first page starts at the row, and the next ones at their cursor.
+func LogContext(ctx context.Context, dsInfo *models.DataSource, query *LogContextQuery, cursor string) (*LogContextResult, error) {
+	endpoint, err := getTsdbQueryEndpointFor(dsInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, ok := endpoint.(LogContextProvider)
+	if !ok {
+		return nil, ErrLogContextNotSupported
+	}
+
+	if query.Row == nil {
+		return nil, fmt.Errorf("%w: the log row is required", ErrInvalidLogContextQuery)
+	}
+	if query.Direction != LogContextBefore && query.Direction != LogContextAfter {
+		return nil, fmt.Errorf("%w: the direction must be %q or %q", ErrInvalidLogContextQuery, LogContextBefore, LogContextAfter)
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultLogContextLimit
+	}
+	if query.Limit > maxLogContextLimit {
+		query.Limit = maxLogContextLimit
+	}
+	if query.Model == nil {
+		query.Model = simplejson.New()
+	}
+
+	if cursor != "" {
+		position, err := decodeLogCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		query.Position = position
+	} else {
+		query.Position = LogPosition{TimeEpochNs: query.Row.TimeEpochNs, SkipIDs: []string{LogRowID(query.Row)}}
+	}
+
+	rows, err := provider.LogContext(ctx, dsInfo, query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &LogContextResult{Rows: rows}
+	if len(rows) < query.Limit {
+		return result, nil
+	}
+	if len(rows) > query.Limit {
+		result.Rows = rows[:query.Limit]
+	}
+	result.Cursor, err = encodeLogCursor(nextLogPosition(query.Position, result.Rows))
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// LogRowID returns the id of a row, or a hash of its labels and line when it has none.
+func LogRowID(row *LogRow) string {
+	if row.ID != "" {
+		return row.ID
+	}
+
+	names := make([]string, 0, len(row.Labels))
+	for name := range row.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := fnv.New64a()
+	for _, name := range names {
+		_, _ = h.Write([]byte(name + "=" + row.Labels[name] + "\n"))
+	}
+	_, _ = h.Write([]byte(row.Line))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// nextLogPosition is the position after a page of rows: the time of the last row, skipping the
+// rows at that time which were returned already.
+func nextLogPosition(position LogPosition, rows []*LogRow) LogPosition {
+	last := rows[len(rows)-1].TimeEpochNs
+	next := LogPosition{TimeEpochNs: last}
+	if last == position.TimeEpochNs {
+		next.SkipIDs = append(next.SkipIDs, position.SkipIDs...)
+	}
+	for _, row := range rows {
+		if row.TimeEpochNs == last {
+			next.SkipIDs = append(next.SkipIDs, LogRowID(row))
+		}
+	}
+	return next
+}
+
+func encodeLogCursor(position LogPosition) (string, error) {
+	data, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeLogCursor(cursor string) (LogPosition, error) {
+	var position LogPosition
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		err = json.Unmarshal(data, &position)
+	}
+	if err != nil {
+		return position, fmt.Errorf("%w: the cursor wasn't returned by a previous page", ErrInvalidLogContextQuery)
+	}
+	return position, nil
+}
+
+// SkipLogRows returns the rows which aren't skipped at a position.
+func SkipLogRows(position LogPosition, rows []*LogRow) []*LogRow {
+	if len(position.SkipIDs) == 0 {
+		return rows
+	}
+
+	skipped := make(map[string]bool, len(position.SkipIDs))
+	for _, id := range position.SkipIDs {
+		skipped[id] = true
+	}
+
+	kept := make([]*LogRow, 0, len(rows))
+	for _, row := range rows {
+		if row.TimeEpochNs == position.TimeEpochNs && skipped[LogRowID(row)] {
+			continue
+		}
+		kept = append(kept, row)
+	}
+	return kept
+}
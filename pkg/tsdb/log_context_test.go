@@ -0,0 +1,110 @@
+package tsdb
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLogContextProvider returns the context of its rows, like a data source would
+type fakeLogContextProvider struct {
+	FakeExecutor
+	rows []*LogRow
+}
+
+func (e *fakeLogContextProvider) LogContext(ctx context.Context, dsInfo *models.DataSource, query *LogContextQuery) ([]*LogRow, error) {
+	rows := make([]*LogRow, 0)
+	for _, row := range e.rows {
+		if query.Direction == LogContextBefore && row.TimeEpochNs <= query.Position.TimeEpochNs ||
+			query.Direction == LogContextAfter && row.TimeEpochNs >= query.Position.TimeEpochNs {
+			rows = append(rows, row)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if query.Direction == LogContextBefore {
+			return rows[i].TimeEpochNs > rows[j].TimeEpochNs
+		}
+		return rows[i].TimeEpochNs < rows[j].TimeEpochNs
+	})
+	if limit := query.Limit + len(query.Position.SkipIDs); len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return SkipLogRows(query.Position, rows), nil
+}
+
+func TestLogContext(t *testing.T) {
+	provider := &fakeLogContextProvider{rows: []*LogRow{
+		{TimeEpochNs: 10, ID: "a"},
+		{TimeEpochNs: 20, ID: "b"},
+		{TimeEpochNs: 20, ID: "c"},
+		{TimeEpochNs: 20, ID: "d"},
+		{TimeEpochNs: 30, ID: "e"},
+		{TimeEpochNs: 40, ID: "f"},
+	}}
+	RegisterTsdbQueryEndpoint("test-log-context", func(dsInfo *models.DataSource) (TsdbQueryEndpoint, error) {
+		return provider, nil
+	})
+	ds := &models.DataSource{Type: "test-log-context"}
+
+	ids := func(rows []*LogRow) []string {
+		result := make([]string, 0, len(rows))
+		for _, row := range rows {
+			result = append(result, row.ID)
+		}
+		return result
+	}
+
+	t.Run("Should page through the rows after a row, skipping the rows at the same time already returned", func(t *testing.T) {
+		query := &LogContextQuery{Row: &LogRow{TimeEpochNs: 20, ID: "b"}, Direction: LogContextAfter, Limit: 2}
+		result, err := LogContext(context.Background(), ds, query, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c", "d"}, ids(result.Rows))
+		require.NotEmpty(t, result.Cursor)
+
+		result, err = LogContext(context.Background(), ds, query, result.Cursor)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"e", "f"}, ids(result.Rows))
+		require.NotEmpty(t, result.Cursor)
+
+		result, err = LogContext(context.Background(), ds, query, result.Cursor)
+		require.NoError(t, err)
+		assert.Empty(t, result.Rows)
+		assert.Empty(t, result.Cursor)
+	})
+
+	t.Run("Should return no cursor after the last page", func(t *testing.T) {
+		query := &LogContextQuery{Row: &LogRow{TimeEpochNs: 20, ID: "b"}, Direction: LogContextBefore, Limit: 5}
+		result, err := LogContext(context.Background(), ds, query, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c", "d", "a"}, ids(result.Rows))
+		assert.Empty(t, result.Cursor)
+	})
+
+	t.Run("Should reject invalid queries", func(t *testing.T) {
+		_, err := LogContext(context.Background(), ds, &LogContextQuery{Row: &LogRow{}, Direction: "up"}, "")
+		require.True(t, errors.Is(err, ErrInvalidLogContextQuery))
+
+		_, err = LogContext(context.Background(), ds, &LogContextQuery{Row: &LogRow{}, Direction: LogContextAfter}, "not a cursor")
+		require.True(t, errors.Is(err, ErrInvalidLogContextQuery))
+	})
+
+	t.Run("Should return an error when the query endpoint can't fetch the log context", func(t *testing.T) {
+		registerFakeExecutor()
+		_, err := LogContext(context.Background(), &models.DataSource{Type: "test"}, &LogContextQuery{}, "")
+		require.Equal(t, ErrLogContextNotSupported, err)
+	})
+}
+
+func TestLogRowID(t *testing.T) {
+	assert.Equal(t, "abc", LogRowID(&LogRow{ID: "abc", Line: "line"}))
+
+	row := &LogRow{Line: "line", Labels: map[string]string{"job": "app", "level": "error"}}
+	assert.Len(t, LogRowID(row), 16)
+	assert.Equal(t, LogRowID(row), LogRowID(&LogRow{Line: "line", Labels: map[string]string{"level": "error", "job": "app"}}))
+	assert.NotEqual(t, LogRowID(row), LogRowID(&LogRow{Line: "other line", Labels: row.Labels}))
+}
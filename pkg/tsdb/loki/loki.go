@@ -0,0 +1,164 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+var plog = log.New("tsdb.loki")
+
+// LokiExecutor fetches the log context of Loki on the backend. The queries of Loki are still
+// run by the frontend, through the data source proxy.
+type LokiExecutor struct{}
+
+// NewLokiExecutor creates a new Loki executor
+func NewLokiExecutor(dsInfo *models.DataSource) (tsdb.TsdbQueryEndpoint, error) {
+	return &LokiExecutor{}, nil
+}
+
+func init() {
+	tsdb.RegisterTsdbQueryEndpoint("loki", NewLokiExecutor)
+}
+
+// Query isn't supported, the queries of Loki are run by the frontend.
+func (e *LokiExecutor) Query(ctx context.Context, dsInfo *models.DataSource, tsdbQuery *tsdb.TsdbQuery) (*tsdb.Response, error) {
+	return nil, errors.New("the queries of Loki are run by the frontend")
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type lokiQueryResponse struct {
+	Data struct {
+		ResultType string       `json:"resultType"`
+		Result     []lokiStream `json:"result"`
+	} `json:"data"`
+}
+
+// LogContext queries the stream of the row, selected by its labels, around the position. The
+// rows of Loki have no id, they're identified by their labels and line.
+func (e *LokiExecutor) LogContext(ctx context.Context, dsInfo *models.DataSource, query *tsdb.LogContextQuery) ([]*tsdb.LogRow, error) {
+	if len(query.Row.Labels) == 0 {
+		return nil, errors.New("the labels of the log row are required to select its stream")
+	}
+
+	// the start of a query range is inclusive and the end exclusive
+	window := tsdb.LogContextWindow.Nanoseconds()
+	position := query.Position.TimeEpochNs
+	start, end, direction := position, position+window, "forward"
+	if query.Direction == tsdb.LogContextBefore {
+		start, end, direction = position-window, position+1, "backward"
+	}
+
+	params := url.Values{}
+	params.Set("query", streamSelector(query.Row.Labels))
+	params.Set("start", strconv.FormatInt(start, 10))
+	params.Set("end", strconv.FormatInt(end, 10))
+	params.Set("direction", direction)
+	params.Set("limit", strconv.Itoa(query.Limit+len(query.Position.SkipIDs)))
+
+	var res lokiQueryResponse
+	if err := e.get(ctx, dsInfo, "/loki/api/v1/query_range", params, &res); err != nil {
+		return nil, err
+	}
+	if res.Data.ResultType != "streams" {
+		return nil, fmt.Errorf("unexpected result type %q", res.Data.ResultType)
+	}
+
+	rows := make([]*tsdb.LogRow, 0)
+	for _, stream := range res.Data.Result {
+		for _, value := range stream.Values {
+			ts, err := strconv.ParseInt(value[0], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp %q: %w", value[0], err)
+			}
+			rows = append(rows, &tsdb.LogRow{TimeEpochNs: ts, Line: value[1], Labels: stream.Stream})
+		}
+	}
+
+	// the rows of several streams, or of the same nanosecond, are sorted by id to get the same
+	// pages every time
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].TimeEpochNs != rows[j].TimeEpochNs {
+			if query.Direction == tsdb.LogContextBefore {
+				return rows[i].TimeEpochNs > rows[j].TimeEpochNs
+			}
+			return rows[i].TimeEpochNs < rows[j].TimeEpochNs
+		}
+		return tsdb.LogRowID(rows[i]) < tsdb.LogRowID(rows[j])
+	})
+	return tsdb.SkipLogRows(query.Position, rows), nil
+}
+
+// streamSelector returns the selector of the streams with the labels, like {job="app",level="error"}
+func streamSelector(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	matchers := make([]string, 0, len(names))
+	for _, name := range names {
+		matchers = append(matchers, fmt.Sprintf("%s=%s", name, strconv.Quote(labels[name])))
+	}
+	return "{" + strings.Join(matchers, ",") + "}"
+}
+
+func (e *LokiExecutor) get(ctx context.Context, dsInfo *models.DataSource, uriPath string, params url.Values, result interface{}) error {
+	u, err := url.Parse(dsInfo.Url)
+	if err != nil {
+		return err
+	}
+	u.Path = path.Join(u.Path, uriPath)
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "Grafana")
+
+	if dsInfo.BasicAuth {
+		password, err := dsInfo.DecryptedBasicAuthPassword()
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(dsInfo.BasicAuthUser, password)
+	}
+
+	httpClient, err := dsInfo.GetHttpClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := ctxhttp.Do(ctx, httpClient, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(res.Body)
+		plog.Debug("Loki request failed", "url", u.String(), "status", res.Status, "body", string(body))
+		return fmt.Errorf("request to Loki failed with %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+
+	return json.NewDecoder(res.Body).Decode(result)
+}
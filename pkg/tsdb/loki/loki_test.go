@@ -0,0 +1,54 @@
+package loki
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamSelector(t *testing.T) {
+	assert.Equal(t, `{job="app",level="error \"x\""}`, streamSelector(map[string]string{"level": `error "x"`, "job": "app"}))
+}
+
+func TestLogContext(t *testing.T) {
+	var params url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/loki/api/v1/query_range", r.URL.Path)
+		params = r.URL.Query()
+		_, _ = w.Write([]byte(`{"data": {"resultType": "streams", "result": [
+			{"stream": {"job": "app"}, "values": [["3000", "third"], ["2000", "second"], ["2000", "row"]]},
+			{"stream": {"job": "app", "pod": "b"}, "values": [["2500", "other pod"]]}
+		]}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	ds := &models.DataSource{Type: "loki", Url: server.URL}
+	row := &tsdb.LogRow{TimeEpochNs: 2000, Line: "row", Labels: map[string]string{"job": "app"}}
+	query := &tsdb.LogContextQuery{Row: row, Direction: tsdb.LogContextAfter, Limit: 2}
+
+	result, err := tsdb.LogContext(context.Background(), ds, query, "")
+	require.NoError(t, err)
+	assert.Equal(t, `{job="app"}`, params.Get("query"))
+	assert.Equal(t, "forward", params.Get("direction"))
+	assert.Equal(t, "2000", params.Get("start"))
+	assert.Equal(t, "3", params.Get("limit"))
+
+	require.Len(t, result.Rows, 2)
+	assert.Equal(t, "second", result.Rows[0].Line)
+	assert.Equal(t, "other pod", result.Rows[1].Line)
+	assert.Equal(t, "b", result.Rows[1].Labels["pod"])
+	assert.NotEmpty(t, result.Cursor)
+
+	query.Direction = tsdb.LogContextBefore
+	_, err = tsdb.LogContext(context.Background(), ds, query, "")
+	require.NoError(t, err)
+	assert.Equal(t, "backward", params.Get("direction"))
+	assert.Equal(t, "2001", params.Get("end"))
+}
@@ -16,6 +16,10 @@ type TsdbQuery struct {
 	Headers   map[string]string
 	Debug     bool
 	User      *models.SignedInUser
+	// Transformations are applied to the results of the queries, see ApplyTransformations
+	Transformations []*Transformation
+
+	queryRulesApplied bool
 }
 
 type Query struct {
@@ -28,8 +32,10 @@ type Query struct {
 }
 
 type Response struct {
-	Results map[string]*QueryResult `json:"results"`
-	Message string                  `json:"message,omitempty"`
+	Results      map[string]*QueryResult  `json:"results"`
+	Message      string                   `json:"message,omitempty"`
+	Correlations []*models.CorrelationDTO `json:"correlations,omitempty"`
+	Execution    *ExecutionMeta           `json:"execution,omitempty"`
 }
 
 type QueryResult struct {
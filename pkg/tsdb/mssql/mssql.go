@@ -88,12 +88,16 @@ func generateConnectionString(datasource *models.DataSource) (string, error) {
 
 	logger.Debug("Generating connection string", "url", datasource.Url, "host", addr.Host, "port", addr.Port)
 	encrypt := datasource.JsonData.Get("encrypt").MustString("false")
+	password, err := datasource.DecryptedPassword()
+	if err != nil {
+		return "", err
+	}
 	connStr := fmt.Sprintf("server=%s;port=%s;database=%s;user id=%s;password=%s;",
 		addr.Host,
 		addr.Port,
 		datasource.Database,
 		datasource.User,
-		datasource.DecryptedPassword(),
+		password,
 	)
 	if encrypt != "false" {
 		connStr += fmt.Sprintf("encrypt=%s;", encrypt)
@@ -36,9 +36,14 @@ func newMysqlQueryEndpoint(datasource *models.DataSource) (tsdb.TsdbQueryEndpoin
 		protocol = "unix"
 	}
 
+	password, err := datasource.DecryptedPassword()
+	if err != nil {
+		return nil, err
+	}
+
 	cnnstr := fmt.Sprintf("%s:%s@%s(%s)/%s?collation=utf8mb4_unicode_ci&parseTime=true&loc=UTC&allowNativePasswords=true",
 		characterEscape(datasource.User, ":"),
-		datasource.DecryptedPassword(),
+		password,
 		protocol,
 		characterEscape(datasource.Url, ")"),
 		characterEscape(datasource.Database, "?"),
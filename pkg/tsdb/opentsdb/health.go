@@ -0,0 +1,52 @@
+package opentsdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// CheckHealth asks OpenTSDB for a metric suggestion, like the test of the data source in the frontend.
+func (e *OpenTsdbExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) (*tsdb.HealthCheckResult, error) {
+	u, err := url.Parse(dsInfo.Url)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, "api/suggest")
+	u.RawQuery = url.Values{"type": []string{"metrics"}, "q": []string{"cpu"}, "max": []string{"1"}}.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if dsInfo.BasicAuth {
+		password, err := dsInfo.DecryptedBasicAuthPassword()
+		if err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth(dsInfo.BasicAuthUser, password)
+	}
+
+	httpClient, err := dsInfo.GetHttpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := ctxhttp.Do(ctx, httpClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("Request failed status: %v", res.Status)
+	}
+
+	return tsdb.HealthOk("Data source is working"), nil
+}
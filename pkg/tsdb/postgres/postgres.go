@@ -83,9 +83,14 @@ func generateConnectionString(datasource *models.DataSource, logger log.Logger)
 		}
 	}
 
+	password, err := datasource.DecryptedPassword()
+	if err != nil {
+		return "", err
+	}
+
 	u := &url.URL{
 		Scheme: "postgres",
-		User:   url.UserPassword(datasource.User, datasource.DecryptedPassword()),
+		User:   url.UserPassword(datasource.User, password),
 		Host:   datasource.Url, Path: datasource.Database,
 		RawQuery: sslOpts,
 	}
@@ -0,0 +1,42 @@
+package prometheus
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/remoteread"
+)
+
+// CheckHealth evaluates 1+1 at the current time, the query of the test of the data source in the frontend.
+// The stores queried with remote read are checked with a read of the up series of the last minute.
+func (e *PrometheusExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) (*tsdb.HealthCheckResult, error) {
+	if remoteReadEnabled(dsInfo) {
+		client, err := e.getRemoteReadClient(dsInfo)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		_, err = client.Read(ctx, []remoteread.Query{{
+			Start:    now.Add(-time.Minute),
+			End:      now,
+			Matchers: []remoteread.Matcher{{Type: remoteread.MatchEqual, Name: "__name__", Value: "up"}},
+		}})
+		if err != nil {
+			return nil, err
+		}
+		return tsdb.HealthOk("Data source is working"), nil
+	}
+
+	client, err := e.getClient(dsInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, err := client.Query(ctx, "1+1", time.Now()); err != nil {
+		return nil, err
+	}
+
+	return tsdb.HealthOk("Data source is working"), nil
+}
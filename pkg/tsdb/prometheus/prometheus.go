@@ -60,18 +60,29 @@ func init() {
 	intervalCalculator = tsdb.NewIntervalCalculator(&tsdb.IntervalOptions{MinInterval: time.Second * 1})
 }
 
-func (e *PrometheusExecutor) getClient(dsInfo *models.DataSource) (apiv1.API, error) {
-	cfg := api.Config{
-		Address:      dsInfo.Url,
-		RoundTripper: e.Transport,
-	}
-
+func (e *PrometheusExecutor) getRoundTripper(dsInfo *models.DataSource) (http.RoundTripper, error) {
 	if dsInfo.BasicAuth {
-		cfg.RoundTripper = basicAuthTransport{
+		password, err := dsInfo.DecryptedBasicAuthPassword()
+		if err != nil {
+			return nil, err
+		}
+		return basicAuthTransport{
 			Transport: e.Transport,
 			username:  dsInfo.BasicAuthUser,
-			password:  dsInfo.DecryptedBasicAuthPassword(),
-		}
+			password:  password,
+		}, nil
+	}
+	return e.Transport, nil
+}
+
+func (e *PrometheusExecutor) getClient(dsInfo *models.DataSource) (apiv1.API, error) {
+	roundTripper, err := e.getRoundTripper(dsInfo)
+	if err != nil {
+		return nil, err
+	}
+	cfg := api.Config{
+		Address:      dsInfo.Url,
+		RoundTripper: roundTripper,
 	}
 
 	client, err := api.NewClient(cfg)
@@ -87,6 +98,10 @@ func (e *PrometheusExecutor) Query(ctx context.Context, dsInfo *models.DataSourc
 		Results: map[string]*tsdb.QueryResult{},
 	}
 
+	if remoteReadEnabled(dsInfo) {
+		return e.remoteReadQuery(ctx, dsInfo, tsdbQuery)
+	}
+
 	client, err := e.getClient(dsInfo)
 	if err != nil {
 		return nil, err
@@ -128,6 +143,12 @@ func (e *PrometheusExecutor) Query(ctx context.Context, dsInfo *models.DataSourc
 	return result, nil
 }
 
+// ConcurrentQueries tells that the queries of a request are independent, each sent with its own
+// HTTP request.
+func (e *PrometheusExecutor) ConcurrentQueries() bool {
+	return true
+}
+
 func formatLegend(metric model.Metric, query *PrometheusQuery) string {
 	if query.LegendFormat == "" {
 		return metric.String()
@@ -1,11 +1,14 @@
 package prometheus
 
 import (
+	"math"
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/remoteread"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	p "github.com/prometheus/common/model"
@@ -156,5 +159,57 @@ func TestPrometheus(t *testing.T) {
 			})
 		})
 
+		Convey("converting remote read series to frames", func() {
+			start := time.Unix(1600000000, 0)
+			ms := func(d time.Duration) int64 {
+				return start.Add(d).UnixNano() / int64(time.Millisecond)
+			}
+			series := []*remoteread.Series{
+				{
+					Labels: map[string]string{"__name__": "up", "job": "web"},
+					Samples: []remoteread.Sample{
+						{TimestampMs: ms(-time.Minute), Value: 1},
+						{TimestampMs: ms(10 * time.Second), Value: 0},
+						{TimestampMs: ms(50 * time.Second), Value: 1},
+						{TimestampMs: ms(2 * time.Minute), Value: math.Float64frombits(staleNaN)},
+					},
+				},
+				{
+					Labels:  map[string]string{"__name__": "up", "job": "api"},
+					Samples: []remoteread.Sample{{TimestampMs: ms(-10 * time.Minute), Value: 1}},
+				},
+			}
+			query := &PrometheusQuery{
+				LegendFormat: "{{job}}",
+				Start:        start,
+				End:          start.Add(3 * time.Minute),
+				Step:         30 * time.Second,
+			}
+
+			frames := remoteReadFrames(series, query)
+
+			So(frames, ShouldHaveLength, 2)
+			So(frames[0].Name, ShouldEqual, "api")
+			So(frames[0].Fields[0].Len(), ShouldEqual, 0)
+
+			web := frames[1]
+			So(web.Name, ShouldEqual, "web")
+			So(web.Fields[1].Labels, ShouldResemble, data.Labels{"__name__": "up", "job": "web"})
+			So(web.Fields[1].Config.DisplayName, ShouldEqual, "web")
+
+			times := make([]time.Time, 0)
+			values := make([]float64, 0)
+			for i := 0; i < web.Fields[0].Len(); i++ {
+				times = append(times, web.Fields[0].At(i).(time.Time))
+				values = append(values, web.Fields[1].At(i).(float64))
+			}
+			So(times, ShouldResemble, []time.Time{
+				start,
+				start.Add(30 * time.Second),
+				start.Add(time.Minute),
+				start.Add(90 * time.Second),
+			})
+			So(values, ShouldResemble, []float64{1, 0, 1, 1})
+		})
 	})
 }
@@ -0,0 +1,143 @@
+package prometheus
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/remoteread"
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/common/model"
+)
+
+// lookbackDelta is how far back a step looks for the last sample of a series, like the lookback of
+// the instant vector selectors of Prometheus
+const lookbackDelta = 5 * time.Minute
+
+// staleNaN is the value of the samples marking the end of a series
+const staleNaN uint64 = 0x7ff0000000000002
+
+// remoteReadEnabled tells whether the queries of a data source are sent with the remote read protocol,
+// for the long-term stores which don't evaluate PromQL
+func remoteReadEnabled(dsInfo *models.DataSource) bool {
+	return dsInfo.JsonData != nil && dsInfo.JsonData.Get("remoteRead").MustBool(false)
+}
+
+func (e *PrometheusExecutor) getRemoteReadClient(dsInfo *models.DataSource) (*remoteread.Client, error) {
+	url := dsInfo.JsonData.Get("remoteReadUrl").MustString("")
+	if url == "" {
+		url = strings.TrimSuffix(dsInfo.Url, "/") + "/api/v1/read"
+	}
+
+	roundTripper, err := e.getRoundTripper(dsInfo)
+	if err != nil {
+		return nil, err
+	}
+	return &remoteread.Client{
+		URL:          url,
+		HTTPClient:   &http.Client{Transport: roundTripper},
+		ResponseType: remoteread.ResponseType(dsInfo.JsonData.Get("remoteReadResponseType").MustString(string(remoteread.ResponseTypeAuto))),
+	}, nil
+}
+
+// remoteReadQuery reads the series of the selectors of the queries in one remote read request, and
+// returns their samples at the steps of the queries like a range query of the selectors
+func (e *PrometheusExecutor) remoteReadQuery(ctx context.Context, dsInfo *models.DataSource, tsdbQuery *tsdb.TsdbQuery) (*tsdb.Response, error) {
+	queries, err := parseQuery(dsInfo, tsdbQuery.Queries, tsdbQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	readQueries := make([]remoteread.Query, 0, len(queries))
+	for _, query := range queries {
+		matchers, err := remoteread.ParseSelector(query.Expr)
+		if err != nil {
+			return nil, err
+		}
+		readQueries = append(readQueries, remoteread.Query{
+			Start:    query.Start.Add(-lookbackDelta),
+			End:      query.End,
+			Step:     query.Step,
+			Matchers: matchers,
+		})
+	}
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "prometheus.remoteread")
+	span.SetTag("queries", len(readQueries))
+	defer span.Finish()
+
+	client, err := e.getRemoteReadClient(dsInfo)
+	if err != nil {
+		return nil, err
+	}
+	results, err := client.Read(ctx, readQueries)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &tsdb.Response{
+		Results: map[string]*tsdb.QueryResult{},
+	}
+	for i, query := range queries {
+		result.Results[query.RefId] = &tsdb.QueryResult{
+			RefId:      query.RefId,
+			Dataframes: tsdb.NewDecodedDataFrames(remoteReadFrames(results[i], query)),
+		}
+	}
+	return result, nil
+}
+
+// remoteReadFrames converts the series of a query to frames, sorted by name
+func remoteReadFrames(series []*remoteread.Series, query *PrometheusQuery) data.Frames {
+	frames := make(data.Frames, 0, len(series))
+	for _, s := range series {
+		metric := make(model.Metric, len(s.Labels))
+		for k, v := range s.Labels {
+			metric[model.LabelName(k)] = model.LabelValue(v)
+		}
+		name := formatLegend(metric, query)
+
+		times, values := alignSamples(s.Samples, query.Start, query.End, query.Step)
+		valueField := data.NewField("Value", data.Labels(s.Labels), values)
+		valueField.SetConfig(&data.FieldConfig{DisplayName: name})
+		frames = append(frames, data.NewFrame(name, data.NewField("Time", nil, times), valueField))
+	}
+
+	sort.SliceStable(frames, func(i, j int) bool {
+		return frames[i].Name < frames[j].Name
+	})
+	return frames
+}
+
+// alignSamples returns the last sample of the lookback of each step, the raw samples of remote read
+// can be many more than the steps of the query. Steps without samples are skipped.
+func alignSamples(samples []remoteread.Sample, start time.Time, end time.Time, step time.Duration) ([]time.Time, []float64) {
+	times := make([]time.Time, 0)
+	values := make([]float64, 0)
+	if step <= 0 {
+		return times, values
+	}
+
+	i := 0
+	for ts := start; !ts.After(end); ts = ts.Add(step) {
+		stepMs := ts.UnixNano() / int64(time.Millisecond)
+		for i < len(samples) && samples[i].TimestampMs <= stepMs {
+			i++
+		}
+		if i == 0 || samples[i-1].TimestampMs <= stepMs-lookbackDelta.Milliseconds() {
+			continue
+		}
+		if math.Float64bits(samples[i-1].Value) == staleNaN {
+			continue
+		}
+		times = append(times, ts)
+		values = append(values, samples[i-1].Value)
+	}
+	return times, values
+}
@@ -0,0 +1,179 @@
+package remoteread
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+var errInvalidChunk = errors.New("invalid XOR chunk")
+
+// decodeXORChunk decodes the samples of a Gorilla XOR chunk of the Prometheus TSDB, whose first two
+// bytes are the number of samples. Timestamps are encoded as delta of deltas, and values XORed with
+// the previous value.
+func decodeXORChunk(data []byte) ([]Sample, error) {
+	if len(data) < 2 {
+		return nil, errInvalidChunk
+	}
+	count := int(binary.BigEndian.Uint16(data))
+	samples := make([]Sample, 0, count)
+	r := &bitReader{data: data[2:]}
+
+	var t, tDelta int64
+	var v uint64
+	var leading, trailing uint8
+	for i := 0; i < count; i++ {
+		switch i {
+		case 0:
+			ts, err := binary.ReadVarint(r)
+			if err != nil {
+				return nil, errInvalidChunk
+			}
+			if v, err = r.readBits(64); err != nil {
+				return nil, err
+			}
+			t = ts
+		case 1:
+			delta, err := binary.ReadUvarint(r)
+			if err != nil {
+				return nil, errInvalidChunk
+			}
+			tDelta = int64(delta)
+			t += tDelta
+			if v, leading, trailing, err = readXORValue(r, v, leading, trailing); err != nil {
+				return nil, err
+			}
+		default:
+			dod, err := readDeltaOfDelta(r)
+			if err != nil {
+				return nil, err
+			}
+			tDelta += dod
+			t += tDelta
+			if v, leading, trailing, err = readXORValue(r, v, leading, trailing); err != nil {
+				return nil, err
+			}
+		}
+		samples = append(samples, Sample{TimestampMs: t, Value: math.Float64frombits(v)})
+	}
+	return samples, nil
+}
+
+// readDeltaOfDelta reads a delta of deltas of timestamps, whose prefix of up to four bits tells its
+// size of 0, 14, 17, 20 or 64 bits
+func readDeltaOfDelta(r *bitReader) (int64, error) {
+	var prefix uint8
+	for i := 0; i < 4; i++ {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		if !bit {
+			break
+		}
+		prefix++
+	}
+
+	var size uint8
+	switch prefix {
+	case 0:
+		return 0, nil
+	case 1:
+		size = 14
+	case 2:
+		size = 17
+	case 3:
+		size = 20
+	case 4:
+		bits, err := r.readBits(64)
+		return int64(bits), err
+	}
+
+	bits, err := r.readBits(size)
+	if err != nil {
+		return 0, err
+	}
+	// negative numbers are read as high unsigned numbers
+	if bits > 1<<(size-1) {
+		bits -= 1 << size
+	}
+	return int64(bits), nil
+}
+
+// readXORValue reads a value XORed with the previous one. Its meaningful bits are in the window of
+// leading and trailing zeros of the previous value, unless a new window is given.
+func readXORValue(r *bitReader, previous uint64, leading uint8, trailing uint8) (uint64, uint8, uint8, error) {
+	changed, err := r.readBit()
+	if err != nil || !changed {
+		return previous, leading, trailing, err
+	}
+
+	newWindow, err := r.readBit()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if newWindow {
+		bits, err := r.readBits(5)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		leading = uint8(bits)
+
+		bits, err = r.readBits(6)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		// 64 meaningful bits are written as 0
+		significant := uint8(bits)
+		if significant == 0 {
+			significant = 64
+		}
+		trailing = 64 - leading - significant
+	}
+
+	bits, err := r.readBits(64 - leading - trailing)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return previous ^ bits<<trailing, leading, trailing, nil
+}
+
+// bitReader reads the bits of a chunk, the most significant bit of a byte first
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) readBit() (bool, error) {
+	if r.pos >= len(r.data)*8 {
+		return false, errInvalidChunk
+	}
+	bit := r.data[r.pos/8]&(0x80>>(r.pos%8)) != 0
+	r.pos++
+	return bit, nil
+}
+
+func (r *bitReader) readBits(n uint8) (uint64, error) {
+	var bits uint64
+	for i := uint8(0); i < n; i++ {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		bits <<= 1
+		if bit {
+			bits |= 1
+		}
+	}
+	return bits, nil
+}
+
+// ReadByte reads 8 bits, the varints of the chunks aren't aligned to bytes
+func (r *bitReader) ReadByte() (byte, error) {
+	bits, err := r.readBits(8)
+	if err == errInvalidChunk {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return byte(bits), err
+}
@@ -0,0 +1,214 @@
+// Package remoteread is a client of the remote read protocol of Prometheus, which long-term stores
+// like Thanos, Cortex or VictoriaMetrics also serve.
+package remoteread
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+var plog = log.New("tsdb.prometheus.remoteread")
+
+// MatchType is the type of a label matcher
+type MatchType int32
+
+const (
+	MatchEqual     MatchType = 0
+	MatchNotEqual  MatchType = 1
+	MatchRegexp    MatchType = 2
+	MatchNotRegexp MatchType = 3
+)
+
+// Matcher matches the series whose label matches a value
+type Matcher struct {
+	Type  MatchType
+	Name  string
+	Value string
+}
+
+// Query asks for the samples of the matching series between two times. Step is a hint of the
+// resolution of the result, stores can ignore it.
+type Query struct {
+	Start    time.Time
+	End      time.Time
+	Step     time.Duration
+	Matchers []Matcher
+}
+
+type Sample struct {
+	TimestampMs int64
+	Value       float64
+}
+
+type Series struct {
+	Labels  map[string]string
+	Samples []Sample
+}
+
+// ResponseType tells which responses are accepted from the store
+type ResponseType string
+
+const (
+	// ResponseTypeAuto accepts streamed chunks, and samples from the stores which don't stream
+	ResponseTypeAuto ResponseType = "auto"
+	// ResponseTypeSamples only accepts samples, for the stores whose streaming is broken
+	ResponseTypeSamples ResponseType = "samples"
+)
+
+const (
+	remoteReadVersion = "0.1.0"
+	// maxFrameSize is the maximum size of a frame of a streamed response, Prometheus sends frames of 1MB
+	maxFrameSize = 50 * 1024 * 1024
+)
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Client sends remote read requests to a store, URL is usually the /api/v1/read endpoint.
+type Client struct {
+	URL          string
+	HTTPClient   *http.Client
+	ResponseType ResponseType
+}
+
+// Read sends the queries in one request, and returns the series of each query. The samples of a
+// series are sorted by time.
+func (c *Client) Read(ctx context.Context, queries []Query) ([][]*Series, error) {
+	accepted := []responseType{responseTypeStreamedXORChunks, responseTypeSamples}
+	if c.ResponseType == ResponseTypeSamples {
+		accepted = []responseType{responseTypeSamples}
+	}
+
+	body := snappy.Encode(nil, marshalReadRequest(queries, accepted))
+	req, err := http.NewRequest(http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Accept-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Read-Version", remoteReadVersion)
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			plog.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if resp.StatusCode/100 != 2 {
+		message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("remote read failed with status %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	// stores which can't stream answer with samples
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/x-streamed-protobuf") {
+		return readStreamedResponse(resp.Body, len(queries))
+	}
+	return readSampledResponse(resp.Body, len(queries))
+}
+
+func readSampledResponse(r io.Reader, queries int) ([][]*Series, error) {
+	compressed, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress remote read response: %w", err)
+	}
+
+	results, err := unmarshalReadResponse(decoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) != queries {
+		return nil, fmt.Errorf("remote read returned %d results for %d queries", len(results), queries)
+	}
+	return results, nil
+}
+
+// readStreamedResponse reads the frames of a streamed response. A frame is its size as varint, the
+// CRC32 checksum of its data and a ChunkedReadResponse. The chunks of a series can span several
+// frames, which are merged.
+func readStreamedResponse(r io.Reader, queries int) ([][]*Series, error) {
+	results := make([][]*Series, queries)
+	for i := range results {
+		results[i] = make([]*Series, 0)
+	}
+
+	br := bufio.NewReader(r)
+	for {
+		size, err := binary.ReadUvarint(br)
+		if errors.Is(err, io.EOF) {
+			return results, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if size > maxFrameSize {
+			return nil, fmt.Errorf("remote read frame of %d bytes exceeds the limit of %d bytes", size, maxFrameSize)
+		}
+
+		var checksum [4]byte
+		if _, err := io.ReadFull(br, checksum[:]); err != nil {
+			return nil, err
+		}
+		frame := make([]byte, size)
+		if _, err := io.ReadFull(br, frame); err != nil {
+			return nil, err
+		}
+		if crc32.Checksum(frame, castagnoliTable) != binary.BigEndian.Uint32(checksum[:]) {
+			return nil, errors.New("remote read frame checksum mismatch")
+		}
+
+		queryIndex, series, err := unmarshalChunkedReadResponse(frame)
+		if err != nil {
+			return nil, err
+		}
+		if queryIndex < 0 || queryIndex >= queries {
+			return nil, fmt.Errorf("remote read returned series of unknown query %d", queryIndex)
+		}
+
+		for _, s := range series {
+			result := results[queryIndex]
+			if last := len(result) - 1; last >= 0 && sameLabels(result[last].Labels, s.Labels) {
+				result[last].Samples = append(result[last].Samples, s.Samples...)
+				continue
+			}
+			results[queryIndex] = append(result, s)
+		}
+	}
+}
+
+func sameLabels(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if other, ok := b[k]; !ok || other != v {
+			return false
+		}
+	}
+	return true
+}
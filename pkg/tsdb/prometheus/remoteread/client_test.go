@@ -0,0 +1,327 @@
+package remoteread
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io/ioutil"
+	"math"
+	"math/bits"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestRemoteRead(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	query := Query{
+		Start: start,
+		End:   start.Add(time.Hour),
+		Step:  time.Minute,
+		Matchers: []Matcher{
+			{Type: MatchEqual, Name: "__name__", Value: "up"},
+			{Type: MatchRegexp, Name: "job", Value: "api.*"},
+		},
+	}
+	labels := map[string]string{"__name__": "up", "job": "api"}
+	samples := []Sample{
+		{TimestampMs: 1600000000000, Value: 1},
+		{TimestampMs: 1600000015000, Value: 1},
+		{TimestampMs: 1600000030000, Value: 0.5},
+		{TimestampMs: 1600000045001, Value: 0.5},
+		{TimestampMs: 1600000060000, Value: -2.25},
+		{TimestampMs: 1600003600000, Value: math.MaxFloat64},
+		{TimestampMs: 1600003600001, Value: 0},
+	}
+
+	t.Run("Decodes XOR chunks", func(t *testing.T) {
+		decoded, err := decodeXORChunk(encodeXORChunk(samples))
+		require.NoError(t, err)
+		assert.Equal(t, samples, decoded)
+
+		_, err = decodeXORChunk(encodeXORChunk(samples)[:10])
+		assert.Error(t, err)
+	})
+
+	t.Run("Reads samples from stores which don't stream", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+			assert.Equal(t, "0.1.0", r.Header.Get("X-Prometheus-Remote-Read-Version"))
+
+			queries, accepted := decodeReadRequest(t, r)
+			assert.Equal(t, []responseType{responseTypeSamples}, accepted)
+			require.Len(t, queries, 1)
+			assert.Equal(t, query, queries[0])
+
+			w.Header().Set("Content-Type", "application/x-protobuf")
+			_, err := w.Write(snappy.Encode(nil, marshalReadResponse(labels, samples)))
+			require.NoError(t, err)
+		}))
+		defer server.Close()
+
+		client := &Client{URL: server.URL, ResponseType: ResponseTypeSamples}
+		results, err := client.Read(context.Background(), []Query{query})
+		require.NoError(t, err)
+		assert.Equal(t, [][]*Series{{{Labels: labels, Samples: samples}}}, results)
+	})
+
+	t.Run("Reads streamed chunks and merges the chunks of a series", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, accepted := decodeReadRequest(t, r)
+			assert.Equal(t, []responseType{responseTypeStreamedXORChunks, responseTypeSamples}, accepted)
+
+			w.Header().Set("Content-Type", "application/x-streamed-protobuf; proto=prometheus.ChunkedReadResponse")
+			_, err := w.Write(streamedFrame(0, labels, samples[:3]))
+			require.NoError(t, err)
+			_, err = w.Write(streamedFrame(0, labels, samples[3:]))
+			require.NoError(t, err)
+			_, err = w.Write(streamedFrame(1, map[string]string{"__name__": "down"}, samples[:1]))
+			require.NoError(t, err)
+		}))
+		defer server.Close()
+
+		client := &Client{URL: server.URL, ResponseType: ResponseTypeAuto}
+		results, err := client.Read(context.Background(), []Query{query, query})
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Equal(t, []*Series{{Labels: labels, Samples: samples}}, results[0])
+		assert.Equal(t, []*Series{{Labels: map[string]string{"__name__": "down"}, Samples: samples[:1]}}, results[1])
+	})
+
+	t.Run("Fails on corrupted frames", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			frame := streamedFrame(0, labels, samples)
+			frame[len(frame)-1]++
+			w.Header().Set("Content-Type", "application/x-streamed-protobuf; proto=prometheus.ChunkedReadResponse")
+			_, err := w.Write(frame)
+			require.NoError(t, err)
+		}))
+		defer server.Close()
+
+		client := &Client{URL: server.URL}
+		_, err := client.Read(context.Background(), []Query{query})
+		require.EqualError(t, err, "remote read frame checksum mismatch")
+	})
+
+	t.Run("Fails with the message of the store", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "remote read is disabled", http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		client := &Client{URL: server.URL}
+		_, err := client.Read(context.Background(), []Query{query})
+		require.EqualError(t, err, "remote read failed with status 400 Bad Request: remote read is disabled")
+	})
+}
+
+func decodeReadRequest(t *testing.T, r *http.Request) ([]Query, []responseType) {
+	compressed, err := ioutil.ReadAll(r.Body)
+	require.NoError(t, err)
+	body, err := snappy.Decode(nil, compressed)
+	require.NoError(t, err)
+
+	var queries []Query
+	var accepted []responseType
+	err = walkFields(body, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) error {
+		switch num {
+		case 1:
+			query := Query{}
+			return walkFields(v, func(num protowire.Number, _ protowire.Type, v []byte, x uint64) error {
+				switch num {
+				case 1:
+					query.Start = time.Unix(0, int64(x)*1e6)
+				case 2:
+					query.End = time.Unix(0, int64(x)*1e6)
+				case 3:
+					matcher := Matcher{}
+					err := walkFields(v, func(num protowire.Number, _ protowire.Type, v []byte, x uint64) error {
+						switch num {
+						case 1:
+							matcher.Type = MatchType(x)
+						case 2:
+							matcher.Name = string(v)
+						case 3:
+							matcher.Value = string(v)
+						}
+						return nil
+					})
+					query.Matchers = append(query.Matchers, matcher)
+					return err
+				case 4:
+					err := walkFields(v, func(num protowire.Number, _ protowire.Type, _ []byte, x uint64) error {
+						if num == 1 {
+							query.Step = time.Duration(x) * time.Millisecond
+						}
+						return nil
+					})
+					queries = append(queries, query)
+					return err
+				}
+				return nil
+			})
+		case 2:
+			for len(v) > 0 {
+				x, n := protowire.ConsumeVarint(v)
+				accepted = append(accepted, responseType(x))
+				v = v[n:]
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return queries, accepted
+}
+
+func marshalLabels(b []byte, labels map[string]string) []byte {
+	for name, value := range labels {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, appendString(appendString(nil, 1, name), 2, value))
+	}
+	return b
+}
+
+func marshalReadResponse(labels map[string]string, samples []Sample) []byte {
+	series := marshalLabels(nil, labels)
+	for _, sample := range samples {
+		var s []byte
+		s = protowire.AppendTag(s, 1, protowire.Fixed64Type)
+		s = protowire.AppendFixed64(s, math.Float64bits(sample.Value))
+		s = appendInt64(s, 2, sample.TimestampMs)
+		series = protowire.AppendTag(series, 2, protowire.BytesType)
+		series = protowire.AppendBytes(series, s)
+	}
+
+	var result []byte
+	result = protowire.AppendTag(result, 1, protowire.BytesType)
+	result = protowire.AppendBytes(result, series)
+
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	return protowire.AppendBytes(b, result)
+}
+
+func streamedFrame(queryIndex int64, labels map[string]string, samples []Sample) []byte {
+	var c []byte
+	c = appendInt64(c, 1, samples[0].TimestampMs)
+	c = appendInt64(c, 2, samples[len(samples)-1].TimestampMs)
+	c = appendInt64(c, 3, chunkEncodingXOR)
+	c = protowire.AppendTag(c, 4, protowire.BytesType)
+	c = protowire.AppendBytes(c, encodeXORChunk(samples))
+
+	series := marshalLabels(nil, labels)
+	series = protowire.AppendTag(series, 2, protowire.BytesType)
+	series = protowire.AppendBytes(series, c)
+
+	var message []byte
+	message = protowire.AppendTag(message, 1, protowire.BytesType)
+	message = protowire.AppendBytes(message, series)
+	message = appendInt64(message, 2, queryIndex)
+
+	frame := protowire.AppendVarint(nil, uint64(len(message)))
+	frame = append(frame, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(frame[len(frame)-4:], crc32.Checksum(message, castagnoliTable))
+	return append(frame, message...)
+}
+
+// encodeXORChunk encodes samples like the XOR appender of the Prometheus TSDB
+func encodeXORChunk(samples []Sample) []byte {
+	w := &bitWriter{}
+	var t, tDelta int64
+	var v uint64
+	leading, trailing := uint8(0xff), uint8(0)
+
+	writeValue := func(value float64) {
+		delta := math.Float64bits(value) ^ v
+		v = math.Float64bits(value)
+		if delta == 0 {
+			w.writeBits(0, 1)
+			return
+		}
+		w.writeBits(1, 1)
+
+		l, tr := uint8(bits.LeadingZeros64(delta)), uint8(bits.TrailingZeros64(delta))
+		if l >= 32 {
+			l = 31
+		}
+		if leading != 0xff && l >= leading && tr >= trailing {
+			w.writeBits(0, 1)
+			w.writeBits(delta>>trailing, 64-int(leading)-int(trailing))
+			return
+		}
+		leading, trailing = l, tr
+		w.writeBits(1, 1)
+		w.writeBits(uint64(l), 5)
+		significant := 64 - l - tr
+		w.writeBits(uint64(significant), 6)
+		w.writeBits(delta>>tr, int(significant))
+	}
+
+	for i, sample := range samples {
+		switch i {
+		case 0:
+			buf := make([]byte, binary.MaxVarintLen64)
+			for _, b := range buf[:binary.PutVarint(buf, sample.TimestampMs)] {
+				w.writeBits(uint64(b), 8)
+			}
+			v = math.Float64bits(sample.Value)
+			w.writeBits(v, 64)
+		case 1:
+			tDelta = sample.TimestampMs - t
+			buf := make([]byte, binary.MaxVarintLen64)
+			for _, b := range buf[:binary.PutUvarint(buf, uint64(tDelta))] {
+				w.writeBits(uint64(b), 8)
+			}
+			writeValue(sample.Value)
+		default:
+			delta := sample.TimestampMs - t
+			dod := delta - tDelta
+			tDelta = delta
+			switch {
+			case dod == 0:
+				w.writeBits(0, 1)
+			case -8191 <= dod && dod <= 8192:
+				w.writeBits(0x02, 2)
+				w.writeBits(uint64(dod), 14)
+			case -65535 <= dod && dod <= 65536:
+				w.writeBits(0x06, 3)
+				w.writeBits(uint64(dod), 17)
+			case -524287 <= dod && dod <= 524288:
+				w.writeBits(0x0e, 4)
+				w.writeBits(uint64(dod), 20)
+			default:
+				w.writeBits(0x0f, 4)
+				w.writeBits(uint64(dod), 64)
+			}
+			writeValue(sample.Value)
+		}
+		t = sample.TimestampMs
+	}
+
+	data := make([]byte, 2, 2+len(w.data))
+	binary.BigEndian.PutUint16(data, uint16(len(samples)))
+	return append(data, w.data...)
+}
+
+type bitWriter struct {
+	data []byte
+	pos  int
+}
+
+func (w *bitWriter) writeBits(v uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if w.pos%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		if v&(1<<uint(i)) != 0 {
+			w.data[len(w.data)-1] |= 0x80 >> uint(w.pos%8)
+		}
+		w.pos++
+	}
+}
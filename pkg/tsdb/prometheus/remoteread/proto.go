@@ -0,0 +1,271 @@
+package remoteread
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The messages of the remote read protocol, prometheus/prompb/remote.proto and types.proto, are
+// encoded by hand to not depend on the Prometheus server module.
+
+// responseType is the ReadRequest.ResponseType enum
+type responseType int32
+
+const (
+	responseTypeSamples           responseType = 0
+	responseTypeStreamedXORChunks responseType = 1
+)
+
+// chunkEncodingXOR is the Chunk.Encoding enum value of the Gorilla XOR chunks
+const chunkEncodingXOR = 1
+
+type chunk struct {
+	minTimeMs int64
+	maxTimeMs int64
+	encoding  int32
+	data      []byte
+}
+
+func marshalReadRequest(queries []Query, acceptedResponseTypes []responseType) []byte {
+	var b []byte
+	for _, query := range queries {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, marshalQuery(query))
+	}
+
+	if len(acceptedResponseTypes) > 0 {
+		var packed []byte
+		for _, t := range acceptedResponseTypes {
+			packed = protowire.AppendVarint(packed, uint64(t))
+		}
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, packed)
+	}
+	return b
+}
+
+func marshalQuery(query Query) []byte {
+	var b []byte
+	b = appendInt64(b, 1, query.Start.UnixNano()/1e6)
+	b = appendInt64(b, 2, query.End.UnixNano()/1e6)
+
+	for _, matcher := range query.Matchers {
+		var m []byte
+		m = appendInt64(m, 1, int64(matcher.Type))
+		m = appendString(m, 2, matcher.Name)
+		m = appendString(m, 3, matcher.Value)
+
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendBytes(b, m)
+	}
+
+	var hints []byte
+	hints = appendInt64(hints, 1, query.Step.Milliseconds())
+	hints = appendInt64(hints, 3, query.Start.UnixNano()/1e6)
+	hints = appendInt64(hints, 4, query.End.UnixNano()/1e6)
+	b = protowire.AppendTag(b, 4, protowire.BytesType)
+	b = protowire.AppendBytes(b, hints)
+	return b
+}
+
+func appendInt64(b []byte, num protowire.Number, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+// unmarshalReadResponse decodes a ReadResponse, the series of every query of the request
+func unmarshalReadResponse(b []byte) ([][]*Series, error) {
+	var results [][]*Series
+	err := walkFields(b, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+
+		series := make([]*Series, 0)
+		err := walkFields(v, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) error {
+			if num != 1 || typ != protowire.BytesType {
+				return nil
+			}
+			s, err := unmarshalTimeSeries(v)
+			if err != nil {
+				return err
+			}
+			series = append(series, s)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		results = append(results, series)
+		return nil
+	})
+	return results, err
+}
+
+func unmarshalTimeSeries(b []byte) (*Series, error) {
+	series := &Series{Labels: map[string]string{}}
+	err := walkFields(b, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			return unmarshalLabel(v, series.Labels)
+		case 2:
+			sample := Sample{}
+			err := walkFields(v, func(num protowire.Number, typ protowire.Type, _ []byte, x uint64) error {
+				switch {
+				case num == 1 && typ == protowire.Fixed64Type:
+					sample.Value = math.Float64frombits(x)
+				case num == 2 && typ == protowire.VarintType:
+					sample.TimestampMs = int64(x)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			series.Samples = append(series.Samples, sample)
+		}
+		return nil
+	})
+	return series, err
+}
+
+// unmarshalChunkedReadResponse decodes a ChunkedReadResponse, the series of a frame of a streamed
+// response and the index of their query
+func unmarshalChunkedReadResponse(b []byte) (int, []*Series, error) {
+	queryIndex := 0
+	series := make([]*Series, 0)
+	err := walkFields(b, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			s, err := unmarshalChunkedSeries(v)
+			if err != nil {
+				return err
+			}
+			series = append(series, s)
+		case num == 2 && typ == protowire.VarintType:
+			queryIndex = int(x)
+		}
+		return nil
+	})
+	return queryIndex, series, err
+}
+
+func unmarshalChunkedSeries(b []byte) (*Series, error) {
+	series := &Series{Labels: map[string]string{}}
+	err := walkFields(b, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			return unmarshalLabel(v, series.Labels)
+		case 2:
+			c := chunk{}
+			err := walkFields(v, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+				switch {
+				case num == 1 && typ == protowire.VarintType:
+					c.minTimeMs = int64(x)
+				case num == 2 && typ == protowire.VarintType:
+					c.maxTimeMs = int64(x)
+				case num == 3 && typ == protowire.VarintType:
+					c.encoding = int32(x)
+				case num == 4 && typ == protowire.BytesType:
+					c.data = v
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if c.encoding != chunkEncodingXOR {
+				return fmt.Errorf("unsupported chunk encoding %d", c.encoding)
+			}
+
+			samples, err := decodeXORChunk(c.data)
+			if err != nil {
+				return err
+			}
+			series.Samples = append(series.Samples, samples...)
+		}
+		return nil
+	})
+	return series, err
+}
+
+func unmarshalLabel(b []byte, labels map[string]string) error {
+	var name, value string
+	err := walkFields(b, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			name = string(v)
+		case 2:
+			value = string(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	labels[name] = value
+	return nil
+}
+
+var errInvalidMessage = errors.New("invalid protobuf message")
+
+// walkFields calls fn with the fields of a message, v is the value of the length-delimited fields and
+// x the value of the varint and fixed fields
+func walkFields(b []byte, fn func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return errInvalidMessage
+		}
+		b = b[n:]
+
+		var v []byte
+		var x uint64
+		switch typ {
+		case protowire.VarintType:
+			x, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			x, n = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var x32 uint32
+			x32, n = protowire.ConsumeFixed32(b)
+			x = uint64(x32)
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return errInvalidMessage
+		}
+		b = b[n:]
+
+		if err := fn(num, typ, v, x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
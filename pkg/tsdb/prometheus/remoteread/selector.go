@@ -0,0 +1,156 @@
+package remoteread
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseSelector parses a PromQL series selector, like up{job="api", instance=~"10\\..*"}, into the
+// matchers of a remote read query. Remote read returns raw series, so the other expressions of PromQL
+// aren't supported.
+func ParseSelector(expr string) ([]Matcher, error) {
+	p := &selectorParser{input: strings.TrimSpace(expr)}
+	matchers := make([]Matcher, 0)
+
+	if name := p.identifier(true); name != "" {
+		matchers = append(matchers, Matcher{Type: MatchEqual, Name: "__name__", Value: name})
+	}
+
+	p.skipSpaces()
+	if p.consume("{") {
+		for {
+			p.skipSpaces()
+			if p.consume("}") {
+				break
+			}
+
+			matcher, err := p.matcher()
+			if err != nil {
+				return nil, err
+			}
+			matchers = append(matchers, matcher)
+
+			p.skipSpaces()
+			if p.consume(",") {
+				continue
+			}
+			if !p.consume("}") {
+				return nil, p.errorf("expected , or }")
+			}
+			break
+		}
+	}
+
+	p.skipSpaces()
+	if p.pos != len(p.input) {
+		return nil, fmt.Errorf("remote read only supports series selectors, %q isn't one", expr)
+	}
+	if len(matchers) == 0 {
+		return nil, fmt.Errorf("series selector %q has no matchers", expr)
+	}
+	return matchers, nil
+}
+
+type selectorParser struct {
+	input string
+	pos   int
+}
+
+func (p *selectorParser) matcher() (Matcher, error) {
+	name := p.identifier(false)
+	if name == "" {
+		return Matcher{}, p.errorf("expected a label name")
+	}
+
+	p.skipSpaces()
+	var matchType MatchType
+	switch {
+	case p.consume("=~"):
+		matchType = MatchRegexp
+	case p.consume("!~"):
+		matchType = MatchNotRegexp
+	case p.consume("!="):
+		matchType = MatchNotEqual
+	case p.consume("="):
+		matchType = MatchEqual
+	default:
+		return Matcher{}, p.errorf("expected a label matching operator")
+	}
+
+	p.skipSpaces()
+	value, err := p.quoted()
+	if err != nil {
+		return Matcher{}, err
+	}
+	return Matcher{Type: matchType, Name: name, Value: value}, nil
+}
+
+// identifier consumes a label name, or a metric name whose names can also have colons
+func (p *selectorParser) identifier(metricName bool) string {
+	start := p.pos
+	for p.pos < len(p.input) {
+		c := rune(p.input[p.pos])
+		if c == '_' || unicode.IsLetter(c) || (p.pos > start && unicode.IsDigit(c)) || (metricName && c == ':') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.input[start:p.pos]
+}
+
+// quoted consumes a string in double quotes, single quotes or backticks
+func (p *selectorParser) quoted() (string, error) {
+	if p.pos >= len(p.input) {
+		return "", p.errorf("expected a quoted string")
+	}
+
+	quote := p.input[p.pos]
+	if quote != '"' && quote != '\'' && quote != '`' {
+		return "", p.errorf("expected a quoted string")
+	}
+
+	for end := p.pos + 1; end < len(p.input); end++ {
+		switch p.input[end] {
+		case '\\':
+			if quote != '`' {
+				end++
+			}
+		case quote:
+			raw := p.input[p.pos+1 : end]
+			p.pos = end + 1
+			if quote == '`' {
+				return raw, nil
+			}
+			if quote == '\'' {
+				raw = strings.ReplaceAll(strings.ReplaceAll(raw, `\'`, `'`), `"`, `\"`)
+			}
+			value, err := strconv.Unquote(`"` + raw + `"`)
+			if err != nil {
+				return "", p.errorf("invalid quoted string")
+			}
+			return value, nil
+		}
+	}
+	return "", p.errorf("unterminated quoted string")
+}
+
+func (p *selectorParser) consume(s string) bool {
+	if strings.HasPrefix(p.input[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *selectorParser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *selectorParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid series selector at position %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
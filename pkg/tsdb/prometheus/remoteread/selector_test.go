@@ -0,0 +1,51 @@
+package remoteread
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		expr     string
+		matchers []Matcher
+	}{
+		{
+			expr:     "up",
+			matchers: []Matcher{{Type: MatchEqual, Name: "__name__", Value: "up"}},
+		},
+		{
+			expr: ` node:cpu_seconds:rate5m { job = "node", mode!~'idle|iowait' , instance=~"10\\.0\\..*",} `,
+			matchers: []Matcher{
+				{Type: MatchEqual, Name: "__name__", Value: "node:cpu_seconds:rate5m"},
+				{Type: MatchEqual, Name: "job", Value: "node"},
+				{Type: MatchNotRegexp, Name: "mode", Value: "idle|iowait"},
+				{Type: MatchRegexp, Name: "instance", Value: `10\.0\..*`},
+			},
+		},
+		{
+			expr: "{__name__=~`http_.*`, code!=\"200\"}",
+			matchers: []Matcher{
+				{Type: MatchRegexp, Name: "__name__", Value: "http_.*"},
+				{Type: MatchNotEqual, Name: "code", Value: "200"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			matchers, err := ParseSelector(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.matchers, matchers)
+		})
+	}
+
+	for _, expr := range []string{"", "{}", "rate(up[5m])", "up[5m]", `up{job="api"} + 1`, `up{job=api}`, `up{job="api"`, `up{="api"}`} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := ParseSelector(expr)
+			assert.Error(t, err)
+		})
+	}
+}
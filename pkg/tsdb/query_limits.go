@@ -0,0 +1,215 @@
+package tsdb
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// QueryLimits are the maximum number of series and data points returned by a query to a data
+// source, and how long the queries of a dashboard panel may run, 0 meaning no limit.
+type QueryLimits struct {
+	MaxSeries     int
+	MaxDataPoints int
+	PanelBudget   time.Duration
+}
+
+// QueryLimitsFor returns the query limits of a data source. They're set in the JsonData of the
+// data source, or in the [query_limits] section of the configuration.
+func QueryLimitsFor(dsInfo *models.DataSource) QueryLimits {
+	limits := QueryLimits{
+		MaxSeries:     setting.QueryMaxSeries,
+		MaxDataPoints: setting.QueryMaxDataPoints,
+		PanelBudget:   setting.QueryPanelBudget,
+	}
+	if dsInfo.JsonData == nil {
+		return limits
+	}
+
+	if maxSeries, err := dsInfo.JsonData.Get("maxSeries").Int(); err == nil && maxSeries >= 0 {
+		limits.MaxSeries = maxSeries
+	}
+	if maxDataPoints, err := dsInfo.JsonData.Get("maxDataPoints").Int(); err == nil && maxDataPoints >= 0 {
+		limits.MaxDataPoints = maxDataPoints
+	}
+	// the panel execution budget is a duration like 30s or a number of seconds
+	budget := dsInfo.JsonData.Get("panelExecutionBudget")
+	if seconds, err := budget.Int(); err == nil && seconds >= 0 {
+		limits.PanelBudget = time.Duration(seconds) * time.Second
+	} else if duration, err := time.ParseDuration(budget.MustString()); err == nil && duration >= 0 {
+		limits.PanelBudget = duration
+	}
+	return limits
+}
+
+// PanelBudgetExceededError is returned when the queries of a dashboard panel ran longer than the
+// panel execution budget of the data source
+type PanelBudgetExceededError struct {
+	Budget time.Duration
+}
+
+func (e *PanelBudgetExceededError) Error() string {
+	return fmt.Sprintf("The queries of the panel ran longer than the limit of %s of the data source, try a shorter time range or fewer series", e.Budget)
+}
+
+// ApplyQueryLimits drops the series and data points of the results beyond the query limits of
+// the data source, adding a notice telling what was dropped to the metadata of the results.
+func ApplyQueryLimits(dsInfo *models.DataSource, resp *Response) error {
+	limits := QueryLimitsFor(dsInfo)
+	if limits.MaxSeries == 0 && limits.MaxDataPoints == 0 {
+		return nil
+	}
+
+	for _, result := range resp.Results {
+		truncated, err := limits.apply(result)
+		if err != nil {
+			return err
+		}
+		if truncated {
+			metrics.MDataSourceQueryTruncated.WithLabelValues(dsInfo.Type, dsInfo.Uid).Inc()
+		}
+	}
+	return nil
+}
+
+// truncationNotice tells which limits of a query were exceeded
+func (l QueryLimits) truncationNotice(budget *limitBudget) string {
+	exceeded := make([]string, 0, 2)
+	if budget.seriesExceeded {
+		exceeded = append(exceeded, fmt.Sprintf("%d series", l.MaxSeries))
+	}
+	if budget.pointsExceeded {
+		exceeded = append(exceeded, fmt.Sprintf("%d data points", l.MaxDataPoints))
+	}
+	return fmt.Sprintf("The query returned more than the limit of %s of the data source, the others were dropped",
+		strings.Join(exceeded, " and "))
+}
+
+// limitBudget counts the series and data points of a query result against the query limits
+type limitBudget struct {
+	limits         QueryLimits
+	series         int
+	points         int
+	seriesExceeded bool
+	pointsExceeded bool
+}
+
+// takeSeries returns whether another series fits in the limit
+func (b *limitBudget) takeSeries() bool {
+	if b.limits.MaxSeries > 0 && b.series >= b.limits.MaxSeries {
+		b.seriesExceeded = true
+		return false
+	}
+	b.series++
+	return true
+}
+
+// takePoints returns how many of n data points fit in the limit
+func (b *limitBudget) takePoints(n int) int {
+	if b.limits.MaxDataPoints > 0 && b.points+n > b.limits.MaxDataPoints {
+		n = b.limits.MaxDataPoints - b.points
+		b.pointsExceeded = true
+	}
+	b.points += n
+	return n
+}
+
+func (b *limitBudget) truncated() bool {
+	return b.seriesExceeded || b.pointsExceeded
+}
+
+// apply truncates a query result to the limits, and returns whether it was truncated. The time
+// series and the value fields of the data frames count as series, and the tables as one series.
+func (l QueryLimits) apply(result *QueryResult) (bool, error) {
+	budget := &limitBudget{limits: l}
+
+	for i, series := range result.Series {
+		if !budget.takeSeries() {
+			result.Series = result.Series[:i]
+			break
+		}
+		series.Points = series.Points[:budget.takePoints(len(series.Points))]
+	}
+
+	for i, table := range result.Tables {
+		if !budget.takeSeries() {
+			result.Tables = result.Tables[:i]
+			break
+		}
+		table.Rows = table.Rows[:budget.takePoints(len(table.Rows))]
+	}
+
+	if result.Dataframes != nil {
+		frames, err := result.Dataframes.Decoded()
+		if err != nil {
+			return false, err
+		}
+		if frames = limitFrames(frames, budget); budget.truncated() {
+			if len(frames) > 0 {
+				frames[0].AppendNotices(data.Notice{
+					Severity: data.NoticeSeverityWarning,
+					Text:     l.truncationNotice(budget),
+				})
+			}
+			result.Dataframes = NewDecodedDataFrames(frames)
+		}
+	}
+
+	if !budget.truncated() {
+		return false, nil
+	}
+	if result.Meta == nil {
+		result.Meta = simplejson.New()
+	}
+	result.Meta.Set("notices", []interface{}{
+		map[string]interface{}{"severity": "warning", "text": l.truncationNotice(budget)},
+	})
+	return true, nil
+}
+
+// limitFrames drops the value fields and rows of the frames beyond the limits. The frames whose
+// value fields are all beyond the series limit are dropped.
+func limitFrames(frames data.Frames, budget *limitBudget) data.Frames {
+	for i, frame := range frames {
+		fields := make([]*data.Field, 0, len(frame.Fields))
+		values := 0
+		for _, field := range frame.Fields {
+			if isTimeField(field) {
+				fields = append(fields, field)
+				continue
+			}
+			if !budget.takeSeries() {
+				continue
+			}
+			fields = append(fields, field)
+			values++
+		}
+		if values == 0 && len(fields) < len(frame.Fields) {
+			return frames[:i]
+		}
+		frame.Fields = fields
+
+		rows, err := frame.RowLen()
+		if err != nil || values == 0 {
+			continue
+		}
+		allowed := budget.takePoints(rows * values)
+		keep := allowed / values
+		// a row is kept with all its values, the points left are given back
+		budget.points -= allowed - keep*values
+		for row := rows - 1; row >= keep; row-- {
+			frame.DeleteRow(row)
+		}
+	}
+	return frames
+}
+
+func isTimeField(field *data.Field) bool {
+	return field.Type() == data.FieldTypeTime || field.Type() == data.FieldTypeNullableTime
+}
@@ -0,0 +1,117 @@
+package tsdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryLimits(t *testing.T) {
+	origMaxSeries, origMaxDataPoints := setting.QueryMaxSeries, setting.QueryMaxDataPoints
+	t.Cleanup(func() {
+		setting.QueryMaxSeries, setting.QueryMaxDataPoints = origMaxSeries, origMaxDataPoints
+	})
+	setting.QueryMaxSeries, setting.QueryMaxDataPoints = 2, 0
+
+	points := func(n int) TimeSeriesPoints {
+		result := make(TimeSeriesPoints, n)
+		for i := range result {
+			result[i] = NewTimePoint(null.FloatFrom(float64(i)), float64(i*1000))
+		}
+		return result
+	}
+
+	t.Run("Should use the limits of the data source", func(t *testing.T) {
+		require.Equal(t, QueryLimits{MaxSeries: 2}, QueryLimitsFor(&models.DataSource{}))
+
+		ds := &models.DataSource{JsonData: simplejson.NewFromAny(map[string]interface{}{
+			"maxSeries":     0,
+			"maxDataPoints": 100,
+		})}
+		require.Equal(t, QueryLimits{MaxDataPoints: 100}, QueryLimitsFor(ds))
+	})
+
+	t.Run("Should read the panel execution budget of the data source", func(t *testing.T) {
+		for budget, expected := range map[interface{}]time.Duration{30: 30 * time.Second, "1m": time.Minute, "invalid": 0} {
+			ds := &models.DataSource{JsonData: simplejson.NewFromAny(map[string]interface{}{"panelExecutionBudget": budget})}
+			require.Equal(t, expected, QueryLimitsFor(ds).PanelBudget, budget)
+		}
+	})
+
+	t.Run("Should drop the series beyond the limits", func(t *testing.T) {
+		resp := &Response{Results: map[string]*QueryResult{
+			"A": {RefId: "A", Series: TimeSeriesSlice{
+				{Name: "a", Points: points(3)},
+				{Name: "b", Points: points(3)},
+				{Name: "c", Points: points(3)},
+			}},
+			"B": {RefId: "B", Series: TimeSeriesSlice{{Name: "d", Points: points(3)}}},
+		}}
+		ds := &models.DataSource{JsonData: simplejson.NewFromAny(map[string]interface{}{"maxDataPoints": 5})}
+
+		err := ApplyQueryLimits(ds, resp)
+		require.NoError(t, err)
+
+		series := resp.Results["A"].Series
+		require.Len(t, series, 2)
+		require.Len(t, series[0].Points, 3)
+		require.Len(t, series[1].Points, 2)
+		require.Equal(t,
+			"The query returned more than the limit of 2 series and 5 data points of the data source, the others were dropped",
+			resp.Results["A"].Meta.Get("notices").GetIndex(0).Get("text").MustString())
+
+		require.Len(t, resp.Results["B"].Series[0].Points, 3)
+		require.Nil(t, resp.Results["B"].Meta)
+	})
+
+	t.Run("Should drop the fields and rows of the frames beyond the limits", func(t *testing.T) {
+		now := time.Now()
+		frames := data.Frames{
+			data.NewFrame("a",
+				data.NewField("time", nil, []time.Time{now, now.Add(time.Second), now.Add(2 * time.Second)}),
+				data.NewField("a", nil, []float64{1, 2, 3}),
+			),
+			data.NewFrame("b",
+				data.NewField("time", nil, []time.Time{now, now.Add(time.Second)}),
+				data.NewField("b", nil, []float64{1, 2}),
+				data.NewField("c", nil, []float64{1, 2}),
+			),
+			data.NewFrame("d",
+				data.NewField("time", nil, []time.Time{now}),
+				data.NewField("d", nil, []float64{1}),
+			),
+		}
+		resp := &Response{Results: map[string]*QueryResult{
+			"A": {RefId: "A", Dataframes: NewDecodedDataFrames(frames)},
+		}}
+		ds := &models.DataSource{JsonData: simplejson.NewFromAny(map[string]interface{}{"maxDataPoints": 4})}
+
+		err := ApplyQueryLimits(ds, resp)
+		require.NoError(t, err)
+
+		limited, err := resp.Results["A"].Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, limited, 2)
+		require.Equal(t, 3, limited[0].Fields[0].Len())
+		require.Len(t, limited[1].Fields, 2)
+		require.Equal(t, 1, limited[1].Fields[1].Len())
+		require.Len(t, limited[0].Meta.Notices, 1)
+		require.Equal(t, data.NoticeSeverityWarning, limited[0].Meta.Notices[0].Severity)
+	})
+
+	t.Run("Should not change the results within the limits", func(t *testing.T) {
+		resp := &Response{Results: map[string]*QueryResult{
+			"A": {RefId: "A", Series: TimeSeriesSlice{{Name: "a", Points: points(3)}}},
+		}}
+		err := ApplyQueryLimits(&models.DataSource{}, resp)
+		require.NoError(t, err)
+		require.Len(t, resp.Results["A"].Series[0].Points, 3)
+		require.Nil(t, resp.Results["A"].Meta)
+	})
+}
@@ -0,0 +1,305 @@
+package tsdb
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// QueryRuleAction is what a query rule does with the queries it matches.
+type QueryRuleAction string
+
+const (
+	// QueryRuleAllow runs the query without checking the next rules
+	QueryRuleAllow QueryRuleAction = "allow"
+	// QueryRuleDeny fails the request of the query
+	QueryRuleDeny QueryRuleAction = "deny"
+	// QueryRuleRewrite changes fields of the query, then checks the next rules
+	QueryRuleRewrite QueryRuleAction = "rewrite"
+)
+
+var queryRulesLogger = log.New("tsdb.queryrules")
+
+// QueryRule allows, denies or rewrites the queries of the data sources it applies to which match
+// all of its matchers. The rules are checked in order, for every query of a request.
+type QueryRule struct {
+	Name            string               `yaml:"name"`
+	DatasourceTypes []string             `yaml:"datasource_types"`
+	DatasourceUids  []string             `yaml:"datasource_uids"`
+	OrgIds          []int64              `yaml:"org_ids"`
+	Match           []*QueryFieldMatcher `yaml:"match"`
+	Action          QueryRuleAction      `yaml:"action"`
+	// Message is the error of the denied queries
+	Message  string               `yaml:"message"`
+	Rewrites []*QueryFieldRewrite `yaml:"rewrites"`
+}
+
+// QueryFieldMatcher matches a field of the query model, like rawSql or logGroupNames, by a regex
+// or a list of values. Fields holding arrays match when one of their items does.
+type QueryFieldMatcher struct {
+	// Field is the path of the field, with dots separating nested fields
+	Field  string   `yaml:"field"`
+	Regex  string   `yaml:"regex"`
+	Values []string `yaml:"values"`
+
+	regex *regexp.Regexp
+}
+
+// QueryFieldRewrite replaces the matches of a regex in a field of the query model. The
+// replacement can refer to the groups of the regex, e.g. $1.
+type QueryFieldRewrite struct {
+	Field   string `yaml:"field"`
+	Regex   string `yaml:"regex"`
+	Replace string `yaml:"replace"`
+
+	regex *regexp.Regexp
+}
+
+// QueryDeniedError is returned when a query of a request is denied by a query rule.
+type QueryDeniedError struct {
+	Rule    string
+	RefId   string
+	Message string
+}
+
+func (e *QueryDeniedError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("query %s denied: %s", e.RefId, e.Message)
+	}
+	return fmt.Sprintf("query %s denied by rule %s", e.RefId, e.Rule)
+}
+
+var (
+	queryRules   []*QueryRule
+	queryRulesMu sync.RWMutex
+)
+
+// SetQueryRules validates the query rules and replaces the current ones.
+func SetQueryRules(rules []*QueryRule) error {
+	for i, rule := range rules {
+		if err := rule.compile(); err != nil {
+			name := rule.Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i+1)
+			}
+			return fmt.Errorf("invalid query rule %s: %w", name, err)
+		}
+	}
+
+	queryRulesMu.Lock()
+	defer queryRulesMu.Unlock()
+	queryRules = rules
+	return nil
+}
+
+func getQueryRules() []*QueryRule {
+	queryRulesMu.RLock()
+	defer queryRulesMu.RUnlock()
+	return queryRules
+}
+
+func (r *QueryRule) compile() error {
+	switch r.Action {
+	case QueryRuleAllow, QueryRuleDeny:
+	case QueryRuleRewrite:
+		if len(r.Rewrites) == 0 {
+			return fmt.Errorf("rewrite rules need rewrites")
+		}
+	default:
+		return fmt.Errorf("unknown action %q", r.Action)
+	}
+
+	for _, matcher := range r.Match {
+		if matcher.Field == "" {
+			return fmt.Errorf("matchers need a field")
+		}
+		if (matcher.Regex == "") == (len(matcher.Values) == 0) {
+			return fmt.Errorf("matcher of field %s needs either a regex or values", matcher.Field)
+		}
+		if matcher.Regex != "" {
+			regex, err := regexp.Compile(matcher.Regex)
+			if err != nil {
+				return err
+			}
+			matcher.regex = regex
+		}
+	}
+
+	for _, rewrite := range r.Rewrites {
+		if rewrite.Field == "" || rewrite.Regex == "" {
+			return fmt.Errorf("rewrites need a field and a regex")
+		}
+		regex, err := regexp.Compile(rewrite.Regex)
+		if err != nil {
+			return err
+		}
+		rewrite.regex = regex
+	}
+	return nil
+}
+
+// appliesTo returns whether the rule applies to the queries of a data source
+func (r *QueryRule) appliesTo(dsInfo *models.DataSource) bool {
+	return (len(r.DatasourceTypes) == 0 || containsString(r.DatasourceTypes, dsInfo.Type)) &&
+		(len(r.DatasourceUids) == 0 || containsString(r.DatasourceUids, dsInfo.Uid)) &&
+		(len(r.OrgIds) == 0 || containsInt64(r.OrgIds, dsInfo.OrgId))
+}
+
+func (r *QueryRule) matches(model *simplejson.Json) bool {
+	for _, matcher := range r.Match {
+		if !matcher.matches(model) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *QueryFieldMatcher) matches(model *simplejson.Json) bool {
+	field, exists := getField(model, strings.Split(m.Field, "."))
+	if !exists {
+		return false
+	}
+
+	for _, value := range fieldValues(field.Interface()) {
+		if m.regex != nil && m.regex.MatchString(value) {
+			return true
+		}
+		if m.regex == nil && containsString(m.Values, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rw *QueryFieldRewrite) apply(model *simplejson.Json) {
+	path := strings.Split(rw.Field, ".")
+	field, exists := getField(model, path)
+	if !exists {
+		return
+	}
+
+	switch value := field.Interface().(type) {
+	case string:
+		model.SetPath(path, rw.regex.ReplaceAllString(value, rw.Replace))
+	case []interface{}:
+		for i, item := range value {
+			if s, ok := item.(string); ok {
+				value[i] = rw.regex.ReplaceAllString(s, rw.Replace)
+			}
+		}
+	}
+}
+
+func getField(model *simplejson.Json, path []string) (*simplejson.Json, bool) {
+	field := model
+	for _, key := range path {
+		var exists bool
+		if field, exists = field.CheckGet(key); !exists {
+			return nil, false
+		}
+	}
+	return field, true
+}
+
+// fieldValues returns the values of a field as strings, the items of an array being values of
+// their own
+func fieldValues(field interface{}) []string {
+	switch value := field.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			values = append(values, fieldValues(item)...)
+		}
+		return values
+	case map[string]interface{}:
+		return nil
+	default:
+		return []string{fmt.Sprint(value)}
+	}
+}
+
+// ApplyQueryRules checks the queries of a request against the query rules, rewriting them, or
+// returning a QueryDeniedError when one of them is denied. The rules are applied once per request.
+func ApplyQueryRules(dsInfo *models.DataSource, req *TsdbQuery) error {
+	if req.queryRulesApplied {
+		return nil
+	}
+	req.queryRulesApplied = true
+
+	// the queries of the expressions of pkg/expr get the rules of their own data source when
+	// they're run
+	if dsInfo.Type == "__expr__" {
+		return nil
+	}
+
+	rules := getQueryRules()
+	if len(rules) == 0 {
+		return nil
+	}
+
+	for _, query := range req.Queries {
+		if query.Model == nil {
+			continue
+		}
+		if err := applyQueryRulesToQuery(rules, dsInfo, req.User, query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyQueryRulesToQuery(rules []*QueryRule, dsInfo *models.DataSource, user *models.SignedInUser, query *Query) error {
+	for _, rule := range rules {
+		if !rule.appliesTo(dsInfo) || !rule.matches(query.Model) {
+			continue
+		}
+
+		switch rule.Action {
+		case QueryRuleAllow:
+			return nil
+		case QueryRuleDeny:
+			metrics.MDataSourceQueriesDenied.WithLabelValues(rule.Name, dsInfo.Type).Inc()
+			logCtx := []interface{}{"rule", rule.Name, "orgId", dsInfo.OrgId, "datasource", dsInfo.Uid,
+				"datasourceType", dsInfo.Type, "refId", query.RefId}
+			if user != nil {
+				logCtx = append(logCtx, "userId", user.UserId, "login", user.Login)
+			}
+			queryRulesLogger.Warn("Query denied", logCtx...)
+			return &QueryDeniedError{Rule: rule.Name, RefId: query.RefId, Message: rule.Message}
+		case QueryRuleRewrite:
+			for _, rewrite := range rule.Rewrites {
+				rewrite.apply(query.Model)
+			}
+			queryRulesLogger.Debug("Query rewritten", "rule", rule.Name, "datasource", dsInfo.Uid, "refId", query.RefId)
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt64(values []int64, value int64) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
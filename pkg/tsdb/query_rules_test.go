@@ -0,0 +1,157 @@
+package tsdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func newRulesQuery(model map[string]interface{}) *TsdbQuery {
+	return &TsdbQuery{
+		Queries: []*Query{{RefId: "A", Model: simplejson.NewFromAny(model)}},
+		User:    &models.SignedInUser{UserId: 1, Login: "viewer"},
+	}
+}
+
+func TestQueryRules(t *testing.T) {
+	t.Cleanup(func() {
+		err := SetQueryRules(nil)
+		require.NoError(t, err)
+	})
+
+	err := SetQueryRules([]*QueryRule{
+		{
+			Name:            "allow-admin-db",
+			DatasourceTypes: []string{"mysql"},
+			DatasourceUids:  []string{"admin-db"},
+			Action:          QueryRuleAllow,
+		},
+		{
+			Name:            "deny-sql-writes",
+			DatasourceTypes: []string{"mysql", "postgres"},
+			Match:           []*QueryFieldMatcher{{Field: "rawSql", Regex: `(?i)\b(drop|delete)\b`}},
+			Action:          QueryRuleDeny,
+			Message:         "Statements changing the database are not allowed",
+		},
+		{
+			Name:            "deny-audit-log-group",
+			DatasourceTypes: []string{"cloudwatch"},
+			Match:           []*QueryFieldMatcher{{Field: "logGroupNames", Values: []string{"/aws/audit"}}},
+			Action:          QueryRuleDeny,
+		},
+		{
+			Name:            "prometheus-min-rate-range",
+			DatasourceTypes: []string{"prometheus"},
+			Action:          QueryRuleRewrite,
+			Rewrites:        []*QueryFieldRewrite{{Field: "expr", Regex: `\[1s\]`, Replace: "[1m]"}},
+		},
+		{
+			Name:            "deny-nested-field",
+			DatasourceTypes: []string{"prometheus"},
+			Match:           []*QueryFieldMatcher{{Field: "options.job", Values: []string{"secret"}}},
+			Action:          QueryRuleDeny,
+		},
+	})
+	require.NoError(t, err)
+
+	mysql := &models.DataSource{Uid: "mysql", Type: "mysql", OrgId: 1}
+	cloudwatch := &models.DataSource{Uid: "cloudwatch", Type: "cloudwatch", OrgId: 1}
+	prometheus := &models.DataSource{Uid: "prometheus", Type: "prometheus", OrgId: 1}
+
+	t.Run("Should deny the queries matching a deny rule", func(t *testing.T) {
+		err := ApplyQueryRules(mysql, newRulesQuery(map[string]interface{}{"rawSql": "DROP TABLE users"}))
+		require.Error(t, err)
+
+		var deniedErr *QueryDeniedError
+		require.True(t, errors.As(err, &deniedErr))
+		require.Equal(t, "deny-sql-writes", deniedErr.Rule)
+		require.Equal(t, "A", deniedErr.RefId)
+		require.Equal(t, "query A denied: Statements changing the database are not allowed", err.Error())
+	})
+
+	t.Run("Should run the queries not matching a deny rule", func(t *testing.T) {
+		err := ApplyQueryRules(mysql, newRulesQuery(map[string]interface{}{"rawSql": "SELECT * FROM users"}))
+		require.NoError(t, err)
+
+		err = ApplyQueryRules(&models.DataSource{Type: "mssql"}, newRulesQuery(map[string]interface{}{"rawSql": "DROP TABLE users"}))
+		require.NoError(t, err)
+	})
+
+	t.Run("Should run the queries allowed before a deny rule", func(t *testing.T) {
+		adminDB := &models.DataSource{Uid: "admin-db", Type: "mysql", OrgId: 1}
+		err := ApplyQueryRules(adminDB, newRulesQuery(map[string]interface{}{"rawSql": "DELETE FROM sessions"}))
+		require.NoError(t, err)
+	})
+
+	t.Run("Should match the items of array fields", func(t *testing.T) {
+		err := ApplyQueryRules(cloudwatch, newRulesQuery(map[string]interface{}{
+			"logGroupNames": []interface{}{"/aws/lambda", "/aws/audit"},
+		}))
+		require.EqualError(t, err, "query A denied by rule deny-audit-log-group")
+
+		err = ApplyQueryRules(cloudwatch, newRulesQuery(map[string]interface{}{
+			"logGroupNames": []interface{}{"/aws/lambda"},
+		}))
+		require.NoError(t, err)
+	})
+
+	t.Run("Should rewrite the queries and check the next rules", func(t *testing.T) {
+		req := newRulesQuery(map[string]interface{}{"expr": "rate(http_requests_total[1s])"})
+		err := ApplyQueryRules(prometheus, req)
+		require.NoError(t, err)
+		require.Equal(t, "rate(http_requests_total[1m])", req.Queries[0].Model.Get("expr").MustString())
+
+		err = ApplyQueryRules(prometheus, newRulesQuery(map[string]interface{}{
+			"expr":    "up",
+			"options": map[string]interface{}{"job": "secret"},
+		}))
+		require.Error(t, err)
+	})
+
+	t.Run("Should apply the rules once per request", func(t *testing.T) {
+		req := newRulesQuery(map[string]interface{}{"rawSql": "DROP TABLE users"})
+		err := ApplyQueryRules(mysql, req)
+		require.Error(t, err)
+
+		err = ApplyQueryRules(mysql, req)
+		require.NoError(t, err)
+	})
+
+	t.Run("Should fail the requests with denied queries", func(t *testing.T) {
+		executor, _ := NewFakeExecutor(nil)
+		RegisterTsdbQueryEndpoint("mysql", func(dsInfo *models.DataSource) (TsdbQueryEndpoint, error) {
+			return executor, nil
+		})
+		ds := &models.DataSource{Id: 1, Type: "mysql"}
+		req := newRulesQuery(map[string]interface{}{"rawSql": "DROP TABLE users"})
+		req.Queries[0].DataSource = ds
+
+		_, err := HandleRequest(context.Background(), ds, req)
+		var deniedErr *QueryDeniedError
+		require.True(t, errors.As(err, &deniedErr))
+	})
+}
+
+func TestSetQueryRules(t *testing.T) {
+	t.Cleanup(func() {
+		err := SetQueryRules(nil)
+		require.NoError(t, err)
+	})
+
+	invalid := map[string]*QueryRule{
+		"unknown action":     {Name: "rule", Action: "block"},
+		"rewrite no rewrite": {Name: "rule", Action: QueryRuleRewrite},
+		"matcher no field":   {Name: "rule", Action: QueryRuleDeny, Match: []*QueryFieldMatcher{{Regex: "drop"}}},
+		"matcher no value":   {Name: "rule", Action: QueryRuleDeny, Match: []*QueryFieldMatcher{{Field: "rawSql"}}},
+		"matcher both":       {Name: "rule", Action: QueryRuleDeny, Match: []*QueryFieldMatcher{{Field: "rawSql", Regex: "drop", Values: []string{"drop"}}}},
+		"invalid regex":      {Name: "rule", Action: QueryRuleDeny, Match: []*QueryFieldMatcher{{Field: "rawSql", Regex: "("}}},
+	}
+	for name, rule := range invalid {
+		err := SetQueryRules([]*QueryRule{rule})
+		require.Error(t, err, name)
+	}
+}
@@ -2,17 +2,162 @@ package tsdb
 
 import (
 	"context"
+	"sync"
+	"time"
 
+	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 )
 
 type HandleRequestFunc func(ctx context.Context, dsInfo *models.DataSource, req *TsdbQuery) (*Response, error)
 
+// maxConcurrentQueries is the maximum number of queries of a request running at the same time
+const maxConcurrentQueries = 10
+
+// ConcurrentQuerier is implemented by the query endpoints whose queries don't depend on each other,
+// unlike e.g. the queries referring to the results of the other queries of the request. The
+// queries of their requests are run concurrently, one request by query.
+type ConcurrentQuerier interface {
+	ConcurrentQueries() bool
+}
+
 func HandleRequest(ctx context.Context, dsInfo *models.DataSource, req *TsdbQuery) (*Response, error) {
 	endpoint, err := getTsdbQueryEndpointFor(dsInfo)
 	if err != nil {
 		return nil, err
 	}
 
-	return endpoint.Query(ctx, dsInfo, req)
+	if err := ApplyQueryRules(dsInfo, req); err != nil {
+		return nil, err
+	}
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "tsdb query")
+	defer span.Finish()
+	span.SetTag("datasource_id", dsInfo.Id)
+	span.SetTag("datasource_type", dsInfo.Type)
+	span.SetTag("org_id", dsInfo.OrgId)
+	span.SetTag("queries", len(req.Queries))
+
+	// the HTTP requests sent to the data source are recorded for the query inspector
+	recorder := &models.DataSourceRequestRecorder{}
+	ctx = models.WithDataSourceRequestRecorder(ctx, recorder)
+
+	start := time.Now()
+	resp, err := runQueries(ctx, endpoint, dsInfo, req)
+	duration := time.Since(start)
+	observeQuery(dsInfo, resp, err, duration)
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogKV("error", err.Error())
+	}
+	if resp != nil {
+		resp.Execution = newExecutionMeta(duration, recorder.Requests())
+	}
+	if err == nil && resp != nil {
+		if err := ApplyQueryLimits(dsInfo, resp); err != nil {
+			return nil, err
+		}
+		if err := ApplyTransformations(resp, req.Transformations); err != nil {
+			return nil, err
+		}
+	}
+	return resp, err
+}
+
+// runQueries runs the queries of a request with the query endpoint of the data source. When the
+// endpoint runs them concurrently, the failure of a query is the error of its result rather than
+// of the whole request, unless all the queries failed or the request was cancelled.
+func runQueries(ctx context.Context, endpoint TsdbQueryEndpoint, dsInfo *models.DataSource, req *TsdbQuery) (*Response, error) {
+	querier, ok := endpoint.(ConcurrentQuerier)
+	if !ok || !querier.ConcurrentQueries() || len(req.Queries) < 2 {
+		return endpoint.Query(ctx, dsInfo, req)
+	}
+
+	responses := make([]*Response, len(req.Queries))
+	errs := make([]error, len(req.Queries))
+	limit := make(chan struct{}, maxConcurrentQueries)
+	var wg sync.WaitGroup
+	for i, query := range req.Queries {
+		queryReq := *req
+		queryReq.Queries = []*Query{query}
+
+		wg.Add(1)
+		go func(i int, queryReq *TsdbQuery) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			responses[i], errs[i] = endpoint.Query(ctx, dsInfo, queryReq)
+		}(i, &queryReq)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	resp := &Response{Results: make(map[string]*QueryResult)}
+	failed := 0
+	for i, query := range req.Queries {
+		if errs[i] != nil {
+			failed++
+			resp.Results[query.RefId] = &QueryResult{RefId: query.RefId, Error: errs[i]}
+			continue
+		}
+		if responses[i] == nil {
+			continue
+		}
+		for refID, result := range responses[i].Results {
+			resp.Results[refID] = result
+		}
+		if resp.Message == "" {
+			resp.Message = responses[i].Message
+		}
+		resp.Correlations = append(resp.Correlations, responses[i].Correlations...)
+	}
+	if failed == len(req.Queries) {
+		return nil, errs[0]
+	}
+	return resp, nil
+}
+
+// observeQuery updates the metrics of the queries of a data source.
+func observeQuery(dsInfo *models.DataSource, resp *Response, err error, duration time.Duration) {
+	metrics.MDataSourceQueryDuration.WithLabelValues(dsInfo.Type, dsInfo.Uid).Observe(duration.Seconds())
+
+	if err != nil {
+		metrics.MDataSourceQueryErrors.WithLabelValues(dsInfo.Type, dsInfo.Uid).Inc()
+		return
+	}
+	if resp == nil {
+		return
+	}
+
+	series := 0
+	for _, res := range resp.Results {
+		if res.Error != nil || res.ErrorString != "" {
+			metrics.MDataSourceQueryErrors.WithLabelValues(dsInfo.Type, dsInfo.Uid).Inc()
+		}
+		series += SeriesCount(res)
+	}
+	metrics.MDataSourceQuerySeries.WithLabelValues(dsInfo.Type, dsInfo.Uid).Observe(float64(series))
+}
+
+// SeriesCount returns the number of series, tables and data frames of a query result.
+func SeriesCount(res *QueryResult) int {
+	return len(res.Series) + len(res.Tables) + frameCount(res.Dataframes)
+}
+
+// frameCount returns the number of data frames without encoding nor decoding them.
+func frameCount(frames DataFrames) int {
+	df, ok := frames.(*dataFrames)
+	if !ok || df == nil {
+		return 0
+	}
+	if df.decoded != nil {
+		return len(df.decoded)
+	}
+	return len(df.encoded)
 }
@@ -0,0 +1,46 @@
+package tsdb
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+var (
+	// ErrResourcesNotSupported is returned when the query endpoint of a data source has no resources.
+	ErrResourcesNotSupported = errors.New("resources not supported by the data source")
+	// ErrResourceNotFound is returned by the resource handlers for the paths they don't handle.
+	ErrResourceNotFound = errors.New("resource not found")
+	// ErrInvalidResourceRequest is wrapped by the errors of the resource handlers about requests
+	// missing or having invalid parameters.
+	ErrInvalidResourceRequest = errors.New("invalid resource request")
+)
+
+// ResourceHandler is implemented by the query endpoints serving resources to the frontend of
+// their data source, like the suggestions of the query editor, at
+// /api/datasources/:id/resources/*. The result is marshalled to JSON.
+type ResourceHandler interface {
+	CallResource(ctx context.Context, dsInfo *models.DataSource, path string, params url.Values) (interface{}, error)
+}
+
+// CallResource gets a resource of a data source with the query endpoint of its type. It returns
+// ErrResourcesNotSupported for the data sources without query endpoint, like the backend plugins.
+func CallResource(ctx context.Context, dsInfo *models.DataSource, path string, params url.Values) (interface{}, error) {
+	if _, exists := registry[dsInfo.Type]; !exists {
+		return nil, ErrResourcesNotSupported
+	}
+
+	endpoint, err := getTsdbQueryEndpointFor(dsInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	handler, ok := endpoint.(ResourceHandler)
+	if !ok {
+		return nil, ErrResourcesNotSupported
+	}
+
+	return handler.CallResource(ctx, dsInfo, path, params)
+}
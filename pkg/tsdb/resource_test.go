@@ -0,0 +1,42 @@
+package tsdb
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeResourceHandler struct {
+	FakeExecutor
+}
+
+func (e *fakeResourceHandler) CallResource(ctx context.Context, dsInfo *models.DataSource, path string, params url.Values) (interface{}, error) {
+	if path != "echo" {
+		return nil, ErrResourceNotFound
+	}
+	return params.Get("value"), nil
+}
+
+func TestCallResource(t *testing.T) {
+	RegisterTsdbQueryEndpoint("test-resources", func(dsInfo *models.DataSource) (TsdbQueryEndpoint, error) {
+		return &fakeResourceHandler{}, nil
+	})
+	ds := &models.DataSource{Type: "test-resources"}
+
+	result, err := CallResource(context.Background(), ds, "echo", url.Values{"value": {"a"}})
+	require.NoError(t, err)
+	require.Equal(t, "a", result)
+
+	_, err = CallResource(context.Background(), ds, "other", url.Values{})
+	require.Equal(t, ErrResourceNotFound, err)
+
+	registerFakeExecutor()
+	_, err = CallResource(context.Background(), &models.DataSource{Type: "test"}, "echo", url.Values{})
+	require.Equal(t, ErrResourcesNotSupported, err)
+
+	_, err = CallResource(context.Background(), &models.DataSource{Type: "backend-plugin"}, "echo", url.Values{})
+	require.Equal(t, ErrResourcesNotSupported, err)
+}
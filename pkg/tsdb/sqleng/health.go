@@ -0,0 +1,22 @@
+package sqleng
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// CheckHealth runs SELECT 1 on a connection of the pool of the data source, like the test of
+// the SQL data sources in the frontend.
+func (e *sqlQueryEndpoint) CheckHealth(ctx context.Context, dsInfo *models.DataSource) (*tsdb.HealthCheckResult, error) {
+	rows, err := e.engine.DB().QueryContext(ctx, "SELECT 1")
+	if err != nil {
+		return nil, e.queryResultTransformer.TransformQueryError(err)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	return tsdb.HealthOk("Database Connection OK"), nil
+}
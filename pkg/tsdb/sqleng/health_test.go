@@ -0,0 +1,42 @@
+package sqleng
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/tsdb"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"xorm.io/core"
+	"xorm.io/xorm"
+)
+
+type testQueryResultTransformer struct{}
+
+func (t *testQueryResultTransformer) TransformQueryResult(columnTypes []*sql.ColumnType, rows *core.Rows) (tsdb.RowValues, error) {
+	return nil, nil
+}
+
+func (t *testQueryResultTransformer) TransformQueryError(err error) error {
+	return errors.New("transformed: " + err.Error())
+}
+
+func TestCheckHealth(t *testing.T) {
+	engine, err := xorm.NewEngine("sqlite3", ":memory:")
+	require.NoError(t, err)
+	endpoint := &sqlQueryEndpoint{engine: engine, queryResultTransformer: &testQueryResultTransformer{}}
+
+	t.Run("Should pass when the database answers", func(t *testing.T) {
+		result, err := endpoint.CheckHealth(context.Background(), nil)
+		require.NoError(t, err)
+		require.Equal(t, tsdb.HealthStatusOk, result.Status)
+	})
+
+	t.Run("Should return the transformed error when the database fails", func(t *testing.T) {
+		require.NoError(t, engine.Close())
+		_, err := endpoint.CheckHealth(context.Background(), nil)
+		require.EqualError(t, err, "transformed: sql: database is closed")
+	})
+}
@@ -0,0 +1,55 @@
+package tsdb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+var (
+	// ErrStreamingNotSupported is returned when the query endpoint of a data source doesn't stream data.
+	ErrStreamingNotSupported = errors.New("streaming not supported by the data source")
+	// ErrStreamNotFound is returned when a data source has no stream at a path.
+	ErrStreamNotFound = errors.New("stream not found")
+)
+
+// Streamer is implemented by the query endpoints which stream data over Grafana Live, on the
+// ds/<datasourceId>/<path> channels of their data sources.
+type Streamer interface {
+	// SubscribeStream returns ErrStreamNotFound when the data source has no stream at path.
+	SubscribeStream(ctx context.Context, dsInfo *models.DataSource, path string) error
+	// RunStream sends the messages of the stream at path, usually JSON, until ctx is done.
+	RunStream(ctx context.Context, dsInfo *models.DataSource, path string, send func(message []byte) error) error
+}
+
+// SubscribeStream checks that a data source has a stream at path.
+func SubscribeStream(ctx context.Context, dsInfo *models.DataSource, path string) error {
+	streamer, err := getStreamerFor(dsInfo)
+	if err != nil {
+		return err
+	}
+	return streamer.SubscribeStream(ctx, dsInfo, path)
+}
+
+// RunStream runs the stream of a data source at path until ctx is done.
+func RunStream(ctx context.Context, dsInfo *models.DataSource, path string, send func(message []byte) error) error {
+	streamer, err := getStreamerFor(dsInfo)
+	if err != nil {
+		return err
+	}
+	return streamer.RunStream(ctx, dsInfo, path, send)
+}
+
+func getStreamerFor(dsInfo *models.DataSource) (Streamer, error) {
+	endpoint, err := getTsdbQueryEndpointFor(dsInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	streamer, ok := endpoint.(Streamer)
+	if !ok {
+		return nil, ErrStreamingNotSupported
+	}
+	return streamer, nil
+}
@@ -0,0 +1,52 @@
+package tsdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStreamer struct {
+	FakeExecutor
+}
+
+func (e *fakeStreamer) SubscribeStream(ctx context.Context, dsInfo *models.DataSource, path string) error {
+	if path != "tail" {
+		return ErrStreamNotFound
+	}
+	return nil
+}
+
+func (e *fakeStreamer) RunStream(ctx context.Context, dsInfo *models.DataSource, path string, send func(message []byte) error) error {
+	return send([]byte(`{"path":"` + path + `"}`))
+}
+
+func TestStreams(t *testing.T) {
+	RegisterTsdbQueryEndpoint("test-stream", func(dsInfo *models.DataSource) (TsdbQueryEndpoint, error) {
+		return &fakeStreamer{}, nil
+	})
+	ds := &models.DataSource{Type: "test-stream"}
+
+	t.Run("Should check the path of the stream", func(t *testing.T) {
+		require.NoError(t, SubscribeStream(context.Background(), ds, "tail"))
+		require.Equal(t, ErrStreamNotFound, SubscribeStream(context.Background(), ds, "other"))
+	})
+
+	t.Run("Should run the stream", func(t *testing.T) {
+		var messages []string
+		err := RunStream(context.Background(), ds, "tail", func(message []byte) error {
+			messages = append(messages, string(message))
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{`{"path":"tail"}`}, messages)
+	})
+
+	t.Run("Should return an error when the query endpoint doesn't stream", func(t *testing.T) {
+		registerFakeExecutor()
+		err := SubscribeStream(context.Background(), &models.DataSource{Type: "test"}, "tail")
+		require.Equal(t, ErrStreamingNotSupported, err)
+	})
+}
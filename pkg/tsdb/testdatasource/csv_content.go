@@ -0,0 +1,131 @@
+package testdatasource
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// CSVContentDesc is the description for the CSV Content scenario.
+const CSVContentDesc = `CSV Content returns the CSV of csvContent as a data frame, its first line naming the fields.
+A field whose values are all numbers is a number field, a time field when it's named time and its values are epoch milliseconds.
+Fields of RFC 3339 times or of true/false values are time and boolean fields, the others string fields.
+Empty and null values are null, except in string fields.`
+
+func getCSVContent(query *tsdb.Query, context *tsdb.TsdbQuery) *tsdb.QueryResult {
+	queryRes := tsdb.NewQueryResult()
+
+	frame, err := csvContentFrame(query.Model.Get("csvContent").MustString())
+	if err != nil {
+		queryRes.Error = err
+		return queryRes
+	}
+	frame.Name = query.Model.Get("alias").MustString()
+	frame.RefID = query.RefId
+
+	queryRes.Dataframes = tsdb.NewDecodedDataFrames(data.Frames{frame})
+	return queryRes
+}
+
+// csvContentFrame parses CSV content into a frame, the type of a field is inferred from all its
+// values so that a dashboard gets the same frame on every refresh.
+func csvContentFrame(content string) (*data.Frame, error) {
+	reader := csv.NewReader(strings.NewReader(strings.TrimSpace(content)))
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV content: %w", err)
+	}
+	if len(records) == 0 {
+		return data.NewFrame(""), nil
+	}
+
+	header, rows := records[0], records[1:]
+	frame := data.NewFrame("")
+	for i, name := range header {
+		values := make([]string, len(rows))
+		for j, row := range rows {
+			values[j] = strings.TrimSpace(row[i])
+		}
+		frame.Fields = append(frame.Fields, csvField(strings.TrimSpace(name), values))
+	}
+	return frame, nil
+}
+
+func csvField(name string, values []string) *data.Field {
+	switch {
+	case allParse(values, isFloat):
+		floats := make([]*float64, len(values))
+		times := make([]*time.Time, len(values))
+		for i, v := range values {
+			if isCSVNull(v) {
+				continue
+			}
+			f, _ := strconv.ParseFloat(v, 64)
+			t := time.Unix(0, int64(f)*int64(time.Millisecond)).UTC()
+			floats[i], times[i] = &f, &t
+		}
+		if strings.EqualFold(name, "time") {
+			return data.NewField(name, nil, times)
+		}
+		return data.NewField(name, nil, floats)
+	case allParse(values, isTime):
+		times := make([]*time.Time, len(values))
+		for i, v := range values {
+			if !isCSVNull(v) {
+				t, _ := time.Parse(time.RFC3339, v)
+				times[i] = &t
+			}
+		}
+		return data.NewField(name, nil, times)
+	case allParse(values, isBool):
+		bools := make([]*bool, len(values))
+		for i, v := range values {
+			if !isCSVNull(v) {
+				b := strings.EqualFold(v, "true")
+				bools[i] = &b
+			}
+		}
+		return data.NewField(name, nil, bools)
+	}
+	return data.NewField(name, nil, values)
+}
+
+func isCSVNull(v string) bool {
+	return v == "" || v == "null"
+}
+
+// allParse tells whether all the values of a field that aren't null can be parsed, and that
+// there is at least one
+func allParse(values []string, parses func(string) bool) bool {
+	found := false
+	for _, v := range values {
+		if isCSVNull(v) {
+			continue
+		}
+		if !parses(v) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+func isFloat(v string) bool {
+	_, err := strconv.ParseFloat(v, 64)
+	return err == nil
+}
+
+func isTime(v string) bool {
+	_, err := time.Parse(time.RFC3339, v)
+	return err == nil
+}
+
+func isBool(v string) bool {
+	return strings.EqualFold(v, "true") || strings.EqualFold(v, "false")
+}
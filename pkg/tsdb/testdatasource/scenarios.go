@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
-	"math/rand"
 	"strconv"
 	"strings"
 	"time"
@@ -50,9 +49,10 @@ func init() {
 				ts := &tsdb.TimeSeries{Name: strconv.Itoa(start)}
 				start *= factor
 
+				r := newRandFor(query, i)
 				points := make(tsdb.TimeSeriesPoints, 0)
 				for j := int64(0); j < 100 && timeWalkerMs < to; j++ {
-					v := float64(rand.Int63n(100))
+					v := float64(r.Int63n(100))
 					points = append(points, tsdb.NewTimePoint(null.FloatFrom(v), float64(timeWalkerMs)))
 					timeWalkerMs += query.IntervalMs * 50
 				}
@@ -79,9 +79,10 @@ func init() {
 				timeWalkerMs := context.TimeRange.GetFromAsMsEpoch()
 				ts := &tsdb.TimeSeries{Name: strconv.Itoa(i * 10)}
 
+				r := newRandFor(query, i)
 				points := make(tsdb.TimeSeriesPoints, 0)
 				for j := int64(0); j < 100 && timeWalkerMs < to; j++ {
-					v := float64(rand.Int63n(100))
+					v := float64(r.Int63n(100))
 					points = append(points, tsdb.NewTimePoint(null.FloatFrom(v), float64(timeWalkerMs)))
 					timeWalkerMs += query.IntervalMs * 50
 				}
@@ -130,6 +131,25 @@ func init() {
 		},
 	})
 
+	registerScenario(&Scenario{
+		Id:          "scripted_waveform",
+		Name:        "Scripted Waveform",
+		StringInput: "50 + 10*sin(1h) + 2*noise()",
+		Handler: func(query *tsdb.Query, context *tsdb.TsdbQuery) *tsdb.QueryResult {
+			return getScriptedWaveform(query, context)
+		},
+		Description: ScriptedWaveformDesc,
+	})
+
+	registerScenario(&Scenario{
+		Id:   "csv_content",
+		Name: "CSV Content",
+		Handler: func(query *tsdb.Query, context *tsdb.TsdbQuery) *tsdb.QueryResult {
+			return getCSVContent(query, context)
+		},
+		Description: CSVContentDesc,
+	})
+
 	registerScenario(&Scenario{
 		Id:   "random_walk_table",
 		Name: "Random Walk Table",
@@ -556,8 +576,9 @@ func getRandomWalk(query *tsdb.Query, tsdbQuery *tsdb.TsdbQuery, index int) *tsd
 	timeWalkerMs := tsdbQuery.TimeRange.GetFromAsMsEpoch()
 	to := tsdbQuery.TimeRange.GetToAsMsEpoch()
 	series := newSeriesForQuery(query, index)
+	r := newRandFor(query, index)
 
-	startValue := query.Model.Get("startValue").MustFloat64(rand.Float64() * 100)
+	startValue := query.Model.Get("startValue").MustFloat64(r.Float64() * 100)
 	spread := query.Model.Get("spread").MustFloat64(1)
 	noise := query.Model.Get("noise").MustFloat64(0)
 
@@ -570,7 +591,7 @@ func getRandomWalk(query *tsdb.Query, tsdbQuery *tsdb.TsdbQuery, index int) *tsd
 	walker := startValue
 
 	for i := int64(0); i < 10000 && timeWalkerMs < to; i++ {
-		nextValue := walker + (rand.Float64() * noise)
+		nextValue := walker + (r.Float64() * noise)
 
 		if hasMin && nextValue < min {
 			nextValue = min
@@ -584,7 +605,7 @@ func getRandomWalk(query *tsdb.Query, tsdbQuery *tsdb.TsdbQuery, index int) *tsd
 
 		points = append(points, tsdb.NewTimePoint(null.FloatFrom(nextValue), float64(timeWalkerMs)))
 
-		walker += (rand.Float64() - 0.5) * spread
+		walker += (r.Float64() - 0.5) * spread
 		timeWalkerMs += query.IntervalMs
 	}
 
@@ -639,13 +660,14 @@ func getRandomWalkTable(query *tsdb.Query, tsdbQuery *tsdb.TsdbQuery) *tsdb.Quer
 		Rows: []tsdb.RowValues{},
 	}
 
+	r := newRandFor(query, 0)
 	withNil := query.Model.Get("withNil").MustBool(false)
-	walker := query.Model.Get("startValue").MustFloat64(rand.Float64() * 100)
+	walker := query.Model.Get("startValue").MustFloat64(r.Float64() * 100)
 	spread := 2.5
 	var info strings.Builder
 
 	for i := int64(0); i < query.MaxDataPoints && timeWalkerMs < to; i++ {
-		delta := rand.Float64() - 0.5
+		delta := r.Float64() - 0.5
 		walker += delta
 
 		info.Reset()
@@ -660,15 +682,15 @@ func getRandomWalkTable(query *tsdb.Query, tsdbQuery *tsdb.TsdbQuery) *tsdb.Quer
 		row := tsdb.RowValues{
 			float64(timeWalkerMs),
 			walker,
-			walker - ((rand.Float64() * spread) + 0.01), // Min
-			walker + ((rand.Float64() * spread) + 0.01), // Max
+			walker - ((r.Float64() * spread) + 0.01), // Min
+			walker + ((r.Float64() * spread) + 0.01), // Max
 			info.String(),
 		}
 
 		// Add some random null values
-		if withNil && rand.Float64() > 0.8 {
+		if withNil && r.Float64() > 0.8 {
 			for i := 1; i < 4; i++ {
-				if rand.Float64() > .2 {
+				if r.Float64() > .2 {
 					row[i] = nil
 				}
 			}
@@ -0,0 +1,86 @@
+package testdatasource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// maxSimulatedLatency bounds the latency of a query, so that a typo can't hold a request forever
+const maxSimulatedLatency = time.Minute
+
+// newRandFor returns the random numbers of a series of a query. They are drawn from the seed of
+// the query when it has one, so that the query returns the same data on every refresh.
+func newRandFor(query *tsdb.Query, index int) *rand.Rand {
+	if seed, err := query.Model.Get("seed").Int64(); err == nil {
+		return rand.New(rand.NewSource(seed + int64(index)))
+	}
+	return rand.New(rand.NewSource(time.Now().UnixNano() + int64(index)))
+}
+
+// seededNoise returns a random number between -1 and 1 drawn from a seed, a term and a time, the
+// same whatever the other times drawn so that the noise of a point doesn't depend on the time range
+func seededNoise(seed, term, t int64) float64 {
+	x := uint64(seed)*0x9e3779b97f4a7c15 ^ uint64(term)*0xbf58476d1ce4e5b9 ^ uint64(t)
+	// splitmix64 finalizer
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return float64(x>>11)/float64(1<<53)*2 - 1
+}
+
+// parseLatency parses the latency of a query, a duration like 500ms or a range like 100ms-2s
+func parseLatency(latency string) (min, max time.Duration, err error) {
+	parts := strings.SplitN(latency, "-", 2)
+	if min, err = time.ParseDuration(strings.TrimSpace(parts[0])); err != nil {
+		return 0, 0, fmt.Errorf("invalid latency %q", latency)
+	}
+	max = min
+	if len(parts) == 2 {
+		if max, err = time.ParseDuration(strings.TrimSpace(parts[1])); err != nil {
+			return 0, 0, fmt.Errorf("invalid latency %q", latency)
+		}
+	}
+	if min < 0 || max < min || max > maxSimulatedLatency {
+		return 0, 0, fmt.Errorf("invalid latency %q, it must be a duration or a range of durations up to %s", latency, maxSimulatedLatency)
+	}
+	return min, max, nil
+}
+
+// simulateFaults delays a query by its latency and fails it at its error rate, with its error
+// message, before its scenario runs. With a seed, the latency and whether the query fails are
+// the same on every refresh.
+func simulateFaults(ctx context.Context, query *tsdb.Query) error {
+	latency := query.Model.Get("latency").MustString()
+	errorRate := query.Model.Get("errorRate").MustFloat64(0)
+	if latency == "" && errorRate <= 0 {
+		return nil
+	}
+
+	r := newRandFor(query, 0)
+	if latency != "" {
+		min, max, err := parseLatency(latency)
+		if err != nil {
+			return err
+		}
+		delay := min + time.Duration(r.Int63n(int64(max-min)+1))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	if errorRate > 0 && r.Float64() < errorRate {
+		message := query.Model.Get("errorMessage").MustString("simulated error")
+		return errors.New(message)
+	}
+	return nil
+}
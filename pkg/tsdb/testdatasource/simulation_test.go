@@ -0,0 +1,178 @@
+package testdatasource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSimulationQuery(model map[string]interface{}) (*tsdb.Query, *tsdb.TsdbQuery) {
+	query := &tsdb.Query{RefId: "A", IntervalMs: 60000, MaxDataPoints: 100, Model: simplejson.NewFromAny(model)}
+	return query, &tsdb.TsdbQuery{
+		TimeRange: tsdb.NewTimeRange("1599999960000", "1600003560000"),
+		Queries:   []*tsdb.Query{query},
+	}
+}
+
+func TestCSVContent(t *testing.T) {
+	t.Run("Fields are typed from their values", func(t *testing.T) {
+		query, tsdbQuery := newSimulationQuery(map[string]interface{}{
+			"scenarioId": "csv_content",
+			"alias":      "hosts",
+			"csvContent": "time, host, load, up, since\n" +
+				"1600000000000, srv-1, 1.5, true, 2020-09-13T12:26:40Z\n" +
+				"1600000060000, srv-2, null, false,\n",
+		})
+		result := ScenarioRegistry["csv_content"].Handler(query, tsdbQuery)
+		require.NoError(t, result.Error)
+
+		frames, err := result.Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		frame := frames[0]
+		assert.Equal(t, "hosts", frame.Name)
+		require.Equal(t, 2, frame.Rows())
+
+		load := 1.5
+		up := true
+		since := time.Date(2020, 9, 13, 12, 26, 40, 0, time.UTC)
+		first := time.Unix(1600000000, 0).UTC()
+		assert.Equal(t, []interface{}{&first, "srv-1", &load, &up, &since}, frame.RowCopy(0))
+
+		second := frame.RowCopy(1)
+		assert.Nil(t, second[2])
+		assert.Nil(t, second[4])
+	})
+
+	t.Run("Invalid CSV fails", func(t *testing.T) {
+		_, err := csvContentFrame("a,b\n1")
+		assert.Error(t, err)
+	})
+}
+
+func TestScriptedWaveform(t *testing.T) {
+	t.Run("Terms are summed", func(t *testing.T) {
+		w, err := parseWaveform("50 - 10*sin(1h, 15m) + 2*square(30m, 0.25) + -1*sawtooth(1h) + triangle(1h) - 1e-3")
+		require.NoError(t, err)
+		require.Len(t, w, 6)
+
+		// at the start of the hour, sin is at its peak with the phase, square is up, sawtooth and
+		// triangle at their lowest
+		assert.InDelta(t, 50-10+2+1-1-0.001, w.valueAt(0, 0), 1e-9)
+		assert.InDelta(t, 50+0-2+0.5+0-0.001, w.valueAt(int64(15*time.Minute/time.Millisecond), 0), 1e-9)
+	})
+
+	t.Run("Noise depends on the seed and the time only", func(t *testing.T) {
+		w, err := parseWaveform("noise()")
+		require.NoError(t, err)
+		assert.Equal(t, w.valueAt(1000, 42), w.valueAt(1000, 42))
+		assert.NotEqual(t, w.valueAt(1000, 42), w.valueAt(1000, 43))
+		for i := int64(0); i < 1000; i++ {
+			assert.True(t, math.Abs(w.valueAt(i, 42)) <= 1)
+		}
+	})
+
+	t.Run("Invalid scripts fail", func(t *testing.T) {
+		for _, script := range []string{"", "sin()", "cos(1h)", "2*", "square(1h, 2)", "noise(1)", "sin(1h"} {
+			_, err := parseWaveform(script)
+			assert.Error(t, err, script)
+		}
+	})
+
+	t.Run("Points are the same whatever the time range", func(t *testing.T) {
+		query, tsdbQuery := newSimulationQuery(map[string]interface{}{"stringInput": "10*sin(1h) + noise()", "seed": 7})
+		result := ScenarioRegistry["scripted_waveform"].Handler(query, tsdbQuery)
+		require.NoError(t, result.Error)
+		points := result.Series[0].Points
+		require.Len(t, points, 60)
+
+		tsdbQuery.TimeRange = tsdb.NewTimeRange("1600000020000", "1600003560000")
+		shifted := ScenarioRegistry["scripted_waveform"].Handler(query, tsdbQuery).Series[0].Points
+		assert.Equal(t, points[1:], shifted)
+	})
+}
+
+func TestSeededScenarios(t *testing.T) {
+	query, tsdbQuery := newSimulationQuery(map[string]interface{}{"seed": 3})
+	for _, id := range []string{"random_walk", "random_walk_table", "linear_heatmap_bucket_data"} {
+		first := ScenarioRegistry[id].Handler(query, tsdbQuery)
+		second := ScenarioRegistry[id].Handler(query, tsdbQuery)
+		assert.Equal(t, first, second, id)
+	}
+}
+
+func TestSimulatedFaults(t *testing.T) {
+	executor := &TestDataExecutor{}
+
+	t.Run("Queries fail at their error rate", func(t *testing.T) {
+		query, tsdbQuery := newSimulationQuery(map[string]interface{}{"errorRate": 1, "errorMessage": "boom"})
+		result, err := executor.Query(context.Background(), &models.DataSource{}, tsdbQuery)
+		require.NoError(t, err)
+		assert.EqualError(t, result.Results["A"].Error, "boom")
+
+		query.Model.Set("errorRate", 0)
+		result, err = executor.Query(context.Background(), &models.DataSource{}, tsdbQuery)
+		require.NoError(t, err)
+		assert.NoError(t, result.Results["A"].Error)
+	})
+
+	t.Run("Queries are delayed by their latency", func(t *testing.T) {
+		_, tsdbQuery := newSimulationQuery(map[string]interface{}{"latency": "20ms-30ms"})
+		start := time.Now()
+		result, err := executor.Query(context.Background(), &models.DataSource{}, tsdbQuery)
+		require.NoError(t, err)
+		assert.NoError(t, result.Results["A"].Error)
+		assert.True(t, time.Since(start) >= 20*time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		tsdbQuery.Queries[0].Model.Set("latency", "1m")
+		result, err = executor.Query(ctx, &models.DataSource{}, tsdbQuery)
+		require.NoError(t, err)
+		assert.True(t, errors.Is(result.Results["A"].Error, context.Canceled))
+	})
+
+	t.Run("Invalid latencies fail", func(t *testing.T) {
+		for _, latency := range []string{"soon", "2s-1s", "1h", "1s-"} {
+			_, _, err := parseLatency(latency)
+			assert.Error(t, err, latency)
+		}
+	})
+}
+
+func TestSignalStream(t *testing.T) {
+	executor := &TestDataExecutor{}
+	assert.NoError(t, executor.SubscribeStream(context.Background(), &models.DataSource{}, "signal?speed=1s"))
+	assert.Equal(t, tsdb.ErrStreamNotFound, executor.SubscribeStream(context.Background(), &models.DataSource{}, "signal?speed=1ms"))
+	assert.Equal(t, tsdb.ErrStreamNotFound, executor.SubscribeStream(context.Background(), &models.DataSource{}, "logs"))
+
+	req, err := parseSignalPath("signal?seed=5&waveform=10*square(2s)&failAfter=3&noise=0")
+	require.NoError(t, err)
+
+	ticks := make(chan time.Time, 4)
+	for i := 0; i < 4; i++ {
+		ticks <- time.Unix(1600000000+int64(i), 0)
+	}
+	var messages []signalMessage
+	err = runSignal(context.Background(), req, ticks, func(message []byte) error {
+		var m signalMessage
+		require.NoError(t, json.Unmarshal(message, &m))
+		messages = append(messages, m)
+		return nil
+	})
+	assert.EqualError(t, err, "simulated stream error after 3 values")
+	assert.Equal(t, []signalMessage{
+		{Time: 1600000000000, Value: 10, Min: 10, Max: 10},
+		{Time: 1600000001000, Value: -10, Min: -10, Max: -10},
+		{Time: 1600000002000, Value: 10, Min: 10, Max: 10},
+	}, messages)
+}
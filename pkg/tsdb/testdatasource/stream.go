@@ -0,0 +1,155 @@
+package testdatasource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// minSignalSpeed bounds the speed of a signal, so that a stream can't flood its subscribers
+const minSignalSpeed = 10 * time.Millisecond
+
+// signalRequest is what a simulated signal streams, parsed from the path of its stream:
+// signal?speed=<duration>&seed=<seed>&spread=<spread>&noise=<noise>&waveform=<script>&failAfter=<count>
+type signalRequest struct {
+	// Speed is the period of the values
+	Speed time.Duration
+	// Seed is the seed of the random walk and of the noise, the current time when it's 0
+	Seed int64
+	// Spread is the maximum change of the value of the random walk between values
+	Spread float64
+	// Noise is the spread of the min and max around the value
+	Noise float64
+	// Waveform is the script of the values, instead of the random walk
+	Waveform waveform
+	// FailAfter is the number of values after which the stream fails, to simulate errors
+	FailAfter int
+}
+
+// signalMessage is a value of a simulated signal, with its time in milliseconds
+type signalMessage struct {
+	Time  int64   `json:"time"`
+	Value float64 `json:"value"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+}
+
+func parseSignalPath(path string) (*signalRequest, error) {
+	parts := strings.SplitN(path, "?", 2)
+	if parts[0] != "signal" {
+		return nil, fmt.Errorf("unknown stream %q", path)
+	}
+
+	values := url.Values{}
+	if len(parts) == 2 {
+		var err error
+		if values, err = url.ParseQuery(parts[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	req := &signalRequest{Speed: 250 * time.Millisecond, Spread: 3.5, Noise: 2.2}
+	var err error
+	if v := values.Get("speed"); v != "" {
+		if req.Speed, err = time.ParseDuration(v); err != nil || req.Speed < minSignalSpeed {
+			return nil, fmt.Errorf("invalid speed %q, it must be at least %s", v, minSignalSpeed)
+		}
+	}
+	if v := values.Get("seed"); v != "" {
+		if req.Seed, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid seed %q", v)
+		}
+	}
+	if v := values.Get("spread"); v != "" {
+		if req.Spread, err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, fmt.Errorf("invalid spread %q", v)
+		}
+	}
+	if v := values.Get("noise"); v != "" {
+		if req.Noise, err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, fmt.Errorf("invalid noise %q", v)
+		}
+	}
+	if v := values.Get("waveform"); v != "" {
+		if req.Waveform, err = parseWaveform(v); err != nil {
+			return nil, err
+		}
+	}
+	if v := values.Get("failAfter"); v != "" {
+		if req.FailAfter, err = strconv.Atoi(v); err != nil || req.FailAfter < 1 {
+			return nil, fmt.Errorf("invalid failAfter %q", v)
+		}
+	}
+	return req, nil
+}
+
+// SubscribeStream checks that the path of a stream is a simulated signal.
+func (e *TestDataExecutor) SubscribeStream(ctx context.Context, dsInfo *models.DataSource, path string) error {
+	if _, err := parseSignalPath(path); err != nil {
+		return tsdb.ErrStreamNotFound
+	}
+	return nil
+}
+
+// RunStream sends the values of a simulated signal, like the signal of the Streaming Client
+// scenario, until ctx is done or the signal fails after its failAfter values.
+func (e *TestDataExecutor) RunStream(ctx context.Context, dsInfo *models.DataSource, path string, send func(message []byte) error) error {
+	req, err := parseSignalPath(path)
+	if err != nil {
+		return tsdb.ErrStreamNotFound
+	}
+
+	ticker := time.NewTicker(req.Speed)
+	defer ticker.Stop()
+
+	return runSignal(ctx, req, ticker.C, send)
+}
+
+func runSignal(ctx context.Context, req *signalRequest, ticks <-chan time.Time, send func(message []byte) error) error {
+	seed := req.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	walk := rand.New(rand.NewSource(seed))
+	value := walk.Float64() * 100
+
+	for count := 0; ; count++ {
+		var now time.Time
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now = <-ticks:
+		}
+
+		if req.FailAfter > 0 && count >= req.FailAfter {
+			return fmt.Errorf("simulated stream error after %d values", req.FailAfter)
+		}
+
+		t := now.UnixNano() / int64(time.Millisecond)
+		if req.Waveform != nil {
+			value = req.Waveform.valueAt(t, seed)
+		} else {
+			value += (walk.Float64() - 0.5) * req.Spread
+		}
+		message, err := json.Marshal(signalMessage{
+			Time:  t,
+			Value: value,
+			Min:   value - walk.Float64()*req.Noise,
+			Max:   value + walk.Float64()*req.Noise,
+		})
+		if err != nil {
+			return err
+		}
+		if err := send(message); err != nil {
+			return err
+		}
+	}
+}
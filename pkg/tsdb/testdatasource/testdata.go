@@ -31,6 +31,10 @@ func (e *TestDataExecutor) Query(ctx context.Context, dsInfo *models.DataSource,
 	for _, query := range tsdbQuery.Queries {
 		scenarioId := query.Model.Get("scenarioId").MustString("random_walk")
 		if scenario, exist := ScenarioRegistry[scenarioId]; exist {
+			if err := simulateFaults(ctx, query); err != nil {
+				result.Results[query.RefId] = &tsdb.QueryResult{RefId: query.RefId, Error: err}
+				continue
+			}
 			result.Results[query.RefId] = scenario.Handler(query, tsdbQuery)
 			result.Results[query.RefId].RefId = query.RefId
 		} else {
@@ -40,3 +44,13 @@ func (e *TestDataExecutor) Query(ctx context.Context, dsInfo *models.DataSource,
 
 	return result, nil
 }
+
+// ConcurrentQueries tells that the scenarios of a request are independent.
+func (e *TestDataExecutor) ConcurrentQueries() bool {
+	return true
+}
+
+// CheckHealth always passes, the scenarios don't depend on anything.
+func (e *TestDataExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) (*tsdb.HealthCheckResult, error) {
+	return tsdb.HealthOk("Data source is working"), nil
+}
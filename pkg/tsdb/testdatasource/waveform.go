@@ -0,0 +1,234 @@
+package testdatasource
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// ScriptedWaveformDesc is the description for the Scripted Waveform scenario.
+const ScriptedWaveformDesc = `Scripted Waveform returns the sum of the terms of its script, like 50 + 10*sin(1h) + 2*noise().
+A term is a number or a wave multiplied by an optional amplitude: sin(period, [phase]), square(period, [duty cycle]),
+triangle(period, [phase]), sawtooth(period, [phase]) or noise(), a random value between -1 and 1.
+The waves are based off of absolute time like the predictable scenarios, and the noise off of the seed of the query,
+so that a script returns the same values for the same seed, whatever the time range.`
+
+// waveTerm is a term of a waveform script, its value at a time in milliseconds is amplitude times
+// the value of its wave, or of the noise of the seed when it's noisy
+type waveTerm struct {
+	amplitude float64
+	wave      func(t int64) float64
+	noisy     bool
+}
+
+// waveform is a parsed waveform script
+type waveform []waveTerm
+
+// valueAt returns the value of a waveform at a time in milliseconds, the noise of its terms being
+// drawn from seed
+func (w waveform) valueAt(t int64, seed int64) float64 {
+	value := 0.0
+	for i, term := range w {
+		if term.noisy {
+			value += term.amplitude * seededNoise(seed, int64(i), t)
+		} else {
+			value += term.amplitude * term.wave(t)
+		}
+	}
+	return value
+}
+
+// parseWaveform parses a waveform script, a sum of terms like 10*sin(1h, 15m)
+func parseWaveform(script string) (waveform, error) {
+	script = strings.TrimSpace(script)
+	if script == "" {
+		return nil, fmt.Errorf("empty waveform script")
+	}
+
+	var w waveform
+	for _, raw := range splitTerms(script) {
+		term, err := parseWaveTerm(raw)
+		if err != nil {
+			return nil, err
+		}
+		w = append(w, term)
+	}
+	return w, nil
+}
+
+// splitTerms splits a script on the + and - outside of parentheses, keeping the sign of the
+// terms, so that 50 - 2*sin(1h) are the terms 50 and -2*sin(1h)
+func splitTerms(script string) []string {
+	var terms []string
+	depth, start := 0, 0
+	for i, c := range script {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '+', '-':
+			// the sign of a number, like 1e-3, or of the first term isn't an operator
+			prev := strings.TrimSpace(script[start:i])
+			if depth > 0 || prev == "" || strings.HasSuffix(prev, "e") || strings.HasSuffix(prev, "*") {
+				continue
+			}
+			terms = append(terms, prev)
+			start = i
+			if c == '+' {
+				start = i + 1
+			}
+		}
+	}
+	return append(terms, strings.TrimSpace(script[start:]))
+}
+
+func parseWaveTerm(raw string) (waveTerm, error) {
+	term := waveTerm{amplitude: 1}
+	expr := strings.TrimSpace(raw)
+	negate := func() {
+		if strings.HasPrefix(expr, "-") {
+			term.amplitude = -term.amplitude
+			expr = strings.TrimSpace(expr[1:])
+		}
+	}
+
+	negate()
+	if i := strings.Index(expr, "*"); i >= 0 {
+		amplitude, err := strconv.ParseFloat(strings.TrimSpace(expr[:i]), 64)
+		if err != nil {
+			return term, fmt.Errorf("invalid amplitude in %q", raw)
+		}
+		term.amplitude *= amplitude
+		expr = strings.TrimSpace(expr[i+1:])
+		negate()
+	}
+
+	open := strings.Index(expr, "(")
+	if open < 0 {
+		constant, err := strconv.ParseFloat(expr, 64)
+		if err != nil {
+			return term, fmt.Errorf("invalid term %q", raw)
+		}
+		term.amplitude *= constant
+		term.wave = func(int64) float64 { return 1 }
+		return term, nil
+	}
+	if !strings.HasSuffix(expr, ")") {
+		return term, fmt.Errorf("invalid term %q", raw)
+	}
+
+	name := strings.TrimSpace(expr[:open])
+	var args []string
+	if inner := strings.TrimSpace(expr[open+1 : len(expr)-1]); inner != "" {
+		for _, arg := range strings.Split(inner, ",") {
+			args = append(args, strings.TrimSpace(arg))
+		}
+	}
+
+	if name == "noise" {
+		if len(args) != 0 {
+			return term, fmt.Errorf("noise() takes no arguments")
+		}
+		term.noisy = true
+		return term, nil
+	}
+
+	if len(args) < 1 || len(args) > 2 {
+		return term, fmt.Errorf("%s() takes a period and an optional second argument", name)
+	}
+	period, err := time.ParseDuration(args[0])
+	if err != nil || period < time.Millisecond {
+		return term, fmt.Errorf("invalid period %q of %s()", args[0], name)
+	}
+	periodMs := float64(period / time.Millisecond)
+
+	// position returns the position of a time within the period, between 0 and 1
+	var offsetMs float64
+	position := func(t int64) float64 {
+		p := math.Mod(float64(t)+offsetMs, periodMs) / periodMs
+		if p < 0 {
+			p++
+		}
+		return p
+	}
+
+	if name == "square" {
+		duty := 0.5
+		if len(args) == 2 {
+			if duty, err = strconv.ParseFloat(args[1], 64); err != nil || duty < 0 || duty > 1 {
+				return term, fmt.Errorf("invalid duty cycle %q of square(), it must be between 0 and 1", args[1])
+			}
+		}
+		term.wave = func(t int64) float64 {
+			if position(t) < duty {
+				return 1
+			}
+			return -1
+		}
+		return term, nil
+	}
+
+	if len(args) == 2 {
+		phase, err := time.ParseDuration(args[1])
+		if err != nil {
+			return term, fmt.Errorf("invalid phase %q of %s()", args[1], name)
+		}
+		offsetMs = float64(phase / time.Millisecond)
+	}
+
+	switch name {
+	case "sin":
+		term.wave = func(t int64) float64 {
+			return math.Sin(2 * math.Pi * position(t))
+		}
+	case "triangle":
+		term.wave = func(t int64) float64 {
+			return 1 - 4*math.Abs(position(t)-0.5)
+		}
+	case "sawtooth":
+		term.wave = func(t int64) float64 {
+			return 2*position(t) - 1
+		}
+	default:
+		return term, fmt.Errorf("unknown wave %q", name)
+	}
+	return term, nil
+}
+
+func getScriptedWaveform(query *tsdb.Query, context *tsdb.TsdbQuery) *tsdb.QueryResult {
+	queryRes := tsdb.NewQueryResult()
+
+	script := query.Model.Get("stringInput").MustString()
+	w, err := parseWaveform(script)
+	if err != nil {
+		queryRes.Error = err
+		return queryRes
+	}
+
+	seed := query.Model.Get("seed").MustInt64(0)
+	step := query.IntervalMs
+	if step <= 0 {
+		step = 1000
+	}
+
+	from := context.TimeRange.GetFromAsMsEpoch()
+	to := context.TimeRange.GetToAsMsEpoch()
+	points := make(tsdb.TimeSeriesPoints, 0)
+	// the points line up on the step so that they have the same values whatever the time range
+	for t, i := from-(from%step), 0; t < to && i < 10000; t, i = t+step, i+1 {
+		points = append(points, tsdb.NewTimePoint(null.FloatFrom(w.valueAt(t, seed)), float64(t)))
+	}
+
+	series := newSeriesForQuery(query, 0)
+	series.Points = points
+	series.Tags = parseLabels(query)
+
+	queryRes.Series = append(queryRes.Series, series)
+	return queryRes
+}
@@ -0,0 +1,142 @@
+package tsdb
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeMathOptions are the settings evaluating relative times depend on
+type timeMathOptions struct {
+	weekStart            time.Weekday
+	fiscalYearStartMonth time.Month
+	roundUp              bool
+}
+
+// timeUnits are the units of relative times, the fiscal ones being checked first as they are
+// longer than the others
+var timeUnits = []string{"fy", "fQ", "y", "Q", "M", "w", "d", "h", "m", "s"}
+
+// evaluateTimeMath evaluates relative times anchored at now, like now-1fy/fy or now/fQ. On top of
+// the units of the datemath package, it supports quarters, fiscal years and fiscal quarters. It
+// returns false for the expressions it doesn't support, like business days or absolute anchors,
+// which are left to the datemath package.
+func evaluateTimeMath(s string, now time.Time, opts timeMathOptions) (time.Time, bool) {
+	if !strings.HasPrefix(s, "now") {
+		return time.Time{}, false
+	}
+
+	t := now
+	rest := s[len("now"):]
+	for rest != "" {
+		op := rest[0]
+		rest = rest[1:]
+		if op != '+' && op != '-' && op != '/' {
+			return time.Time{}, false
+		}
+
+		digits := 0
+		for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+			digits++
+		}
+		factor := 1
+		if digits > 0 {
+			var err error
+			if factor, err = strconv.Atoi(rest[:digits]); err != nil {
+				return time.Time{}, false
+			}
+			rest = rest[digits:]
+		}
+
+		unit, ok := parseTimeUnit(rest)
+		if !ok {
+			return time.Time{}, false
+		}
+		rest = rest[len(unit):]
+
+		switch op {
+		case '+':
+			t = addTimeUnits(t, factor, unit)
+		case '-':
+			t = addTimeUnits(t, -factor, unit)
+		case '/':
+			// only whole units can be rounded to, like in the frontend
+			if digits > 0 && factor != 1 {
+				return time.Time{}, false
+			}
+			t = roundTime(t, unit, opts)
+		}
+	}
+	return t, true
+}
+
+func parseTimeUnit(s string) (string, bool) {
+	for _, unit := range timeUnits {
+		if strings.HasPrefix(s, unit) {
+			return unit, true
+		}
+	}
+	return "", false
+}
+
+func addTimeUnits(t time.Time, factor int, unit string) time.Time {
+	switch unit {
+	case "y", "fy":
+		return t.AddDate(factor, 0, 0)
+	case "Q", "fQ":
+		return t.AddDate(0, 3*factor, 0)
+	case "M":
+		return t.AddDate(0, factor, 0)
+	case "w":
+		return t.AddDate(0, 0, 7*factor)
+	case "d":
+		return t.AddDate(0, 0, factor)
+	case "h":
+		return t.Add(time.Duration(factor) * time.Hour)
+	case "m":
+		return t.Add(time.Duration(factor) * time.Minute)
+	default:
+		return t.Add(time.Duration(factor) * time.Second)
+	}
+}
+
+// roundTime rounds down to the start of the unit, or up to its last millisecond
+func roundTime(t time.Time, unit string, opts timeMathOptions) time.Time {
+	start := startOfTimeUnit(t, unit, opts)
+	if opts.roundUp {
+		return addTimeUnits(start, 1, unit).Add(-time.Millisecond)
+	}
+	return start
+}
+
+func startOfTimeUnit(t time.Time, unit string, opts timeMathOptions) time.Time {
+	year, month, day := t.Date()
+	switch unit {
+	case "y":
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, t.Location())
+	case "fy":
+		return time.Date(year, month-monthsSince(month, opts.fiscalYearStartMonth), 1, 0, 0, 0, 0, t.Location())
+	case "Q":
+		return time.Date(year, month-monthsSince(month, time.January)%3, 1, 0, 0, 0, 0, t.Location())
+	case "fQ":
+		return time.Date(year, month-monthsSince(month, opts.fiscalYearStartMonth)%3, 1, 0, 0, 0, 0, t.Location())
+	case "M":
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	case "w":
+		days := (int(t.Weekday()) - int(opts.weekStart) + 7) % 7
+		return time.Date(year, month, day-days, 0, 0, 0, 0, t.Location())
+	case "d":
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	case "h":
+		return time.Date(year, month, day, t.Hour(), 0, 0, 0, t.Location())
+	case "m":
+		return time.Date(year, month, day, t.Hour(), t.Minute(), 0, 0, t.Location())
+	default:
+		return time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	}
+}
+
+// monthsSince returns how many months passed since the last time it was the start month
+func monthsSince(month, start time.Month) time.Month {
+	return (month - start + 12) % 12
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/timberio/go-datemath"
 )
 
@@ -102,12 +103,22 @@ func parse(s string, now time.Time, withRoundUp bool, location *time.Location) (
 
 	diff, err := time.ParseDuration("-" + s)
 	if err != nil {
+		if location == nil {
+			location = time.UTC
+		}
+		if res, ok := evaluateTimeMath(s, now.In(location), timeMathOptions{
+			weekStart:            setting.WeekStart,
+			fiscalYearStartMonth: setting.FiscalYearStartMonth,
+			roundUp:              withRoundUp,
+		}); ok {
+			return res, nil
+		}
+
 		options := []func(*datemath.Options){
 			datemath.WithNow(now),
 			datemath.WithRoundUp(withRoundUp),
-		}
-		if location != nil {
-			options = append(options, datemath.WithLocation(location))
+			datemath.WithStartOfWeek(setting.WeekStart),
+			datemath.WithLocation(location),
 		}
 
 		return datemath.ParseAndEvaluate(s, options...)
@@ -6,6 +6,7 @@ import (
 
 	"time"
 
+	"github.com/grafana/grafana/pkg/setting"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -251,3 +252,76 @@ func TestTimeRange(t *testing.T) {
 		})
 	})
 }
+
+func TestTimeRangeFiscalPeriods(t *testing.T) {
+	Convey("Time range with fiscal periods", t, func() {
+		origWeekStart, origFiscalYearStartMonth := setting.WeekStart, setting.FiscalYearStartMonth
+		Reset(func() {
+			setting.WeekStart, setting.FiscalYearStartMonth = origWeekStart, origFiscalYearStartMonth
+		})
+		setting.FiscalYearStartMonth = time.October
+
+		// a Sunday
+		now, err := time.Parse(time.RFC3339Nano, "2020-07-26T15:12:56.000Z")
+		So(err, ShouldBeNil)
+
+		parseRange := func(from, to string) (time.Time, time.Time) {
+			tr := NewFakeTimeRange(from, to, now)
+			fromTime, err := tr.ParseFrom()
+			So(err, ShouldBeNil)
+			toTime, err := tr.ParseTo()
+			So(err, ShouldBeNil)
+			return fromTime.UTC(), toTime.UTC()
+		}
+
+		Convey("Can parse now/fy", func() {
+			from, to := parseRange("now/fy", "now/fy")
+			So(from, ShouldEqual, time.Date(2019, time.October, 1, 0, 0, 0, 0, time.UTC))
+			So(to, ShouldEqual, time.Date(2020, time.September, 30, 23, 59, 59, 999000000, time.UTC))
+		})
+
+		Convey("Can parse now-1fy/fy", func() {
+			from, to := parseRange("now-1fy/fy", "now-1fy/fy")
+			So(from, ShouldEqual, time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC))
+			So(to, ShouldEqual, time.Date(2019, time.September, 30, 23, 59, 59, 999000000, time.UTC))
+		})
+
+		Convey("Can parse now/fQ", func() {
+			setting.FiscalYearStartMonth = time.February
+			from, to := parseRange("now/fQ", "now/fQ")
+			So(from, ShouldEqual, time.Date(2020, time.May, 1, 0, 0, 0, 0, time.UTC))
+			So(to, ShouldEqual, time.Date(2020, time.July, 31, 23, 59, 59, 999000000, time.UTC))
+		})
+
+		Convey("Can parse now-1Q/Q", func() {
+			from, to := parseRange("now-1Q/Q", "now-1Q/Q")
+			So(from, ShouldEqual, time.Date(2020, time.April, 1, 0, 0, 0, 0, time.UTC))
+			So(to, ShouldEqual, time.Date(2020, time.June, 30, 23, 59, 59, 999000000, time.UTC))
+		})
+
+		Convey("Can parse now/w with the week start", func() {
+			from, to := parseRange("now/w", "now/w")
+			So(from, ShouldEqual, time.Date(2020, time.July, 20, 0, 0, 0, 0, time.UTC))
+			So(to, ShouldEqual, time.Date(2020, time.July, 26, 23, 59, 59, 999000000, time.UTC))
+
+			setting.WeekStart = time.Sunday
+			from, to = parseRange("now/w", "now/w")
+			So(from, ShouldEqual, time.Date(2020, time.July, 26, 0, 0, 0, 0, time.UTC))
+			So(to, ShouldEqual, time.Date(2020, time.August, 1, 23, 59, 59, 999000000, time.UTC))
+		})
+
+		Convey("Can parse now/fy with America/Chicago timezone", func() {
+			location, err := time.LoadLocation("America/Chicago")
+			So(err, ShouldBeNil)
+
+			res, err := NewFakeTimeRange("now/fy", "now", now).ParseFromWithLocation(location)
+			So(err, ShouldBeNil)
+			So(res, ShouldEqual, time.Date(2019, time.October, 1, 0, 0, 0, 0, location))
+		})
+
+		Convey("Can still parse business days", func() {
+			from, _ := parseRange("now-1b", "now")
+			So(from, ShouldEqual, time.Date(2020, time.July, 24, 15, 12, 56, 0, time.UTC))
+		})
+	})
+}
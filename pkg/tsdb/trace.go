@@ -0,0 +1,156 @@
+package tsdb
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// TraceResultType is the result type of the custom meta of the frames of traces and trace searches,
+// which the frontend shows with the trace view and the trace list
+const (
+	TraceResultType       = "trace"
+	TraceSearchResultType = "traceSearch"
+)
+
+// TraceKeyValue is a tag of a span or service, or a field of a span log
+type TraceKeyValue struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// TraceLog is an event logged during a span, at a time in milliseconds
+type TraceLog struct {
+	Timestamp float64         `json:"timestamp"`
+	Fields    []TraceKeyValue `json:"fields"`
+}
+
+// TraceSpan is a span of the traces of the tracing data sources. Whatever the tracing system, the
+// spans are returned in the same frame so that the traces of all of them can be linked and shown.
+type TraceSpan struct {
+	TraceID       string
+	SpanID        string
+	ParentSpanID  string
+	OperationName string
+	ServiceName   string
+	ServiceTags   []TraceKeyValue
+	StartTime     time.Time
+	Duration      time.Duration
+	Logs          []TraceLog
+	Tags          []TraceKeyValue
+}
+
+// TraceSummary is a trace found by a trace search
+type TraceSummary struct {
+	TraceID   string
+	TraceName string
+	StartTime time.Time
+	Duration  time.Duration
+	Spans     int64
+}
+
+// NewTraceFrame returns the frame of a trace, a row by span. The times are in milliseconds and the
+// tags and logs are JSON.
+func NewTraceFrame(traceID string, spans []*TraceSpan) (*data.Frame, error) {
+	frame := data.NewFrame(traceID,
+		data.NewField("traceID", nil, []string{}),
+		data.NewField("spanID", nil, []string{}),
+		data.NewField("parentSpanID", nil, []string{}),
+		data.NewField("operationName", nil, []string{}),
+		data.NewField("serviceName", nil, []string{}),
+		data.NewField("serviceTags", nil, []string{}),
+		data.NewField("startTime", nil, []float64{}),
+		data.NewField("duration", nil, []float64{}),
+		data.NewField("logs", nil, []string{}),
+		data.NewField("tags", nil, []string{}),
+	)
+	frame.Meta = &data.FrameMeta{Custom: map[string]interface{}{"resultType": TraceResultType}}
+
+	for _, span := range spans {
+		serviceTags, err := marshalTraceJSON(span.ServiceTags)
+		if err != nil {
+			return nil, err
+		}
+		logs, err := marshalTraceJSON(span.Logs)
+		if err != nil {
+			return nil, err
+		}
+		tags, err := marshalTraceJSON(span.Tags)
+		if err != nil {
+			return nil, err
+		}
+
+		frame.AppendRow(
+			span.TraceID,
+			span.SpanID,
+			span.ParentSpanID,
+			span.OperationName,
+			span.ServiceName,
+			serviceTags,
+			float64(span.StartTime.UnixNano())/float64(time.Millisecond),
+			float64(span.Duration)/float64(time.Millisecond),
+			logs,
+			tags,
+		)
+	}
+	return frame, nil
+}
+
+// NewTraceSearchFrame returns the frame of the traces found by a trace search, whose trace IDs
+// link to their traces
+func NewTraceSearchFrame(traces []*TraceSummary) *data.Frame {
+	frame := data.NewFrame("Traces",
+		data.NewField("traceID", nil, []string{}),
+		data.NewField("traceName", nil, []string{}),
+		data.NewField("startTime", nil, []time.Time{}),
+		data.NewField("duration", nil, []float64{}),
+		data.NewField("spans", nil, []int64{}),
+	)
+	frame.Fields[3].SetConfig(&data.FieldConfig{Unit: "ms"})
+	frame.Meta = &data.FrameMeta{Custom: map[string]interface{}{"resultType": TraceSearchResultType}}
+
+	for _, trace := range traces {
+		frame.AppendRow(trace.TraceID, trace.TraceName, trace.StartTime, float64(trace.Duration)/float64(time.Millisecond), trace.Spans)
+	}
+	return frame
+}
+
+// SummarizeTrace returns the summary of the spans of a trace, named after the service and operation of
+// its root span
+func SummarizeTrace(traceID string, spans []*TraceSpan) *TraceSummary {
+	summary := &TraceSummary{TraceID: traceID, Spans: int64(len(spans))}
+	if len(spans) == 0 {
+		return summary
+	}
+
+	var root *TraceSpan
+	start, end := spans[0].StartTime, spans[0].StartTime.Add(spans[0].Duration)
+	for _, span := range spans {
+		if span.StartTime.Before(start) {
+			start = span.StartTime
+		}
+		if spanEnd := span.StartTime.Add(span.Duration); spanEnd.After(end) {
+			end = spanEnd
+		}
+		if span.ParentSpanID == "" && (root == nil || span.StartTime.Before(root.StartTime)) {
+			root = span
+		}
+	}
+	if root == nil {
+		root = spans[0]
+	}
+
+	summary.TraceName = root.ServiceName + ": " + root.OperationName
+	summary.StartTime = start
+	summary.Duration = end.Sub(start)
+	return summary
+}
+
+func marshalTraceJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,423 @@
+package tsdb
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// Transformation changes the results of the queries of a request, the same way as the panel
+// transformation with the same id, so that alerting and API consumers get the data of the panels.
+type Transformation struct {
+	Id      string           `json:"id"`
+	Options *simplejson.Json `json:"options"`
+}
+
+type transformFunc func(resp *Response, options *simplejson.Json) error
+
+var transformations = map[string]transformFunc{
+	"organize":       organizeTransformation,
+	"merge":          mergeTransformation,
+	"reduce":         reduceTransformation,
+	"calculateField": calculateFieldTransformation,
+}
+
+// TransformationError is returned when a transformation can't be applied to the results.
+type TransformationError struct {
+	Id     string
+	Reason string
+}
+
+func (e *TransformationError) Error() string {
+	return fmt.Sprintf("transformation %s failed: %s", e.Id, e.Reason)
+}
+
+// ApplyTransformations applies the transformations to the results in order. The results returned
+// as data frames are converted to time series first, or left as they are if they aren't time series.
+func ApplyTransformations(resp *Response, list []*Transformation) error {
+	if len(list) == 0 {
+		return nil
+	}
+
+	for _, result := range resp.Results {
+		if err := framesToSeries(result); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range list {
+		transform, ok := transformations[t.Id]
+		if !ok {
+			return &TransformationError{Id: t.Id, Reason: "unknown transformation"}
+		}
+
+		options := t.Options
+		if options == nil {
+			options = simplejson.New()
+		}
+		if err := transform(resp, options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func framesToSeries(result *QueryResult) error {
+	if result.Dataframes == nil {
+		return nil
+	}
+
+	frames, err := result.Dataframes.Decoded()
+	if err != nil {
+		return err
+	}
+
+	var series TimeSeriesSlice
+	for _, frame := range frames {
+		frameSeries, err := FrameToSeriesSlice(frame)
+		if err != nil {
+			// not a time series, the frames are left to the frontend
+			return nil
+		}
+		for _, s := range frameSeries {
+			if s.Name == "" {
+				s.Name = frame.Name
+			}
+		}
+		series = append(series, frameSeries...)
+	}
+
+	result.Series = append(result.Series, series...)
+	result.Dataframes = nil
+	return nil
+}
+
+// sortedResults returns the results ordered by ref id
+func sortedResults(resp *Response) []*QueryResult {
+	refIds := make([]string, 0, len(resp.Results))
+	for refId := range resp.Results {
+		refIds = append(refIds, refId)
+	}
+	sort.Strings(refIds)
+
+	results := make([]*QueryResult, 0, len(refIds))
+	for _, refId := range refIds {
+		results = append(results, resp.Results[refId])
+	}
+	return results
+}
+
+// organizeTransformation renames, with renameByName, and removes, with excludeByName, the series
+// and table columns of the results
+func organizeTransformation(resp *Response, options *simplejson.Json) error {
+	rename := options.Get("renameByName").MustMap()
+	exclude := options.Get("excludeByName").MustMap()
+
+	newName := func(name string) string {
+		if renamed, ok := rename[name].(string); ok && renamed != "" {
+			return renamed
+		}
+		return name
+	}
+	excluded := func(name string) bool {
+		v, _ := exclude[name].(bool)
+		return v
+	}
+
+	for _, result := range resp.Results {
+		series := make(TimeSeriesSlice, 0, len(result.Series))
+		for _, s := range result.Series {
+			if excluded(s.Name) {
+				continue
+			}
+			s.Name = newName(s.Name)
+			series = append(series, s)
+		}
+		result.Series = series
+
+		for _, table := range result.Tables {
+			var kept []int
+			columns := make([]TableColumn, 0, len(table.Columns))
+			for i, column := range table.Columns {
+				if excluded(column.Text) {
+					continue
+				}
+				kept = append(kept, i)
+				columns = append(columns, TableColumn{Text: newName(column.Text)})
+			}
+			if len(columns) == len(table.Columns) {
+				table.Columns = columns
+				continue
+			}
+
+			for rowIdx, row := range table.Rows {
+				values := make(RowValues, 0, len(kept))
+				for _, i := range kept {
+					if i < len(row) {
+						values = append(values, row[i])
+					}
+				}
+				table.Rows[rowIdx] = values
+			}
+			table.Columns = columns
+		}
+	}
+	return nil
+}
+
+// mergeTransformation merges the results of all the queries into the result of the first one.
+// Tables with the same columns are merged into a single table.
+func mergeTransformation(resp *Response, options *simplejson.Json) error {
+	results := sortedResults(resp)
+	if len(results) < 2 {
+		return nil
+	}
+
+	merged := results[0]
+	for _, result := range results[1:] {
+		merged.Series = append(merged.Series, result.Series...)
+		for _, table := range result.Tables {
+			if same := tableWithColumns(merged.Tables, table.Columns); same != nil {
+				same.Rows = append(same.Rows, table.Rows...)
+				continue
+			}
+			merged.Tables = append(merged.Tables, table)
+		}
+		delete(resp.Results, result.RefId)
+	}
+	return nil
+}
+
+func tableWithColumns(tables []*Table, columns []TableColumn) *Table {
+	for _, table := range tables {
+		if len(table.Columns) != len(columns) {
+			continue
+		}
+		same := true
+		for i := range columns {
+			if table.Columns[i].Text != columns[i].Text {
+				same = false
+				break
+			}
+		}
+		if same {
+			return table
+		}
+	}
+	return nil
+}
+
+// seriesReducer reduces the points of a series to a single value
+type seriesReducer struct {
+	name   string
+	reduce func(points TimeSeriesPoints) null.Float
+}
+
+var seriesReducers = map[string]seriesReducer{
+	"mean": {"Mean", func(points TimeSeriesPoints) null.Float {
+		sum, count := 0.0, 0
+		for _, p := range points {
+			if p[0].Valid {
+				sum += p[0].Float64
+				count++
+			}
+		}
+		if count == 0 {
+			return null.FloatFromPtr(nil)
+		}
+		return null.FloatFrom(sum / float64(count))
+	}},
+	"min": {"Min", func(points TimeSeriesPoints) null.Float {
+		return foldPoints(points, math.Min)
+	}},
+	"max": {"Max", func(points TimeSeriesPoints) null.Float {
+		return foldPoints(points, math.Max)
+	}},
+	"sum": {"Total", func(points TimeSeriesPoints) null.Float {
+		return foldPoints(points, func(a, b float64) float64 { return a + b })
+	}},
+	"count": {"Count", func(points TimeSeriesPoints) null.Float {
+		return null.FloatFrom(float64(len(points)))
+	}},
+	"first": {"First", func(points TimeSeriesPoints) null.Float {
+		if len(points) == 0 {
+			return null.FloatFromPtr(nil)
+		}
+		return points[0][0]
+	}},
+	"firstNotNull": {"First (not null)", func(points TimeSeriesPoints) null.Float {
+		for _, p := range points {
+			if p[0].Valid {
+				return p[0]
+			}
+		}
+		return null.FloatFromPtr(nil)
+	}},
+	"last": {"Last", func(points TimeSeriesPoints) null.Float {
+		if len(points) == 0 {
+			return null.FloatFromPtr(nil)
+		}
+		return points[len(points)-1][0]
+	}},
+	"lastNotNull": {"Last (not null)", func(points TimeSeriesPoints) null.Float {
+		for i := len(points) - 1; i >= 0; i-- {
+			if points[i][0].Valid {
+				return points[i][0]
+			}
+		}
+		return null.FloatFromPtr(nil)
+	}},
+}
+
+func foldPoints(points TimeSeriesPoints, fn func(a, b float64) float64) null.Float {
+	var res null.Float
+	for _, p := range points {
+		if !p[0].Valid {
+			continue
+		}
+		if !res.Valid {
+			res = p[0]
+			continue
+		}
+		res = null.FloatFrom(fn(res.Float64, p[0].Float64))
+	}
+	return res
+}
+
+// reduceTransformation replaces the series of every result by a table with a row per series, and
+// a column per reducer of the reducers option
+func reduceTransformation(resp *Response, options *simplejson.Json) error {
+	var reducers []seriesReducer
+	columns := []TableColumn{{Text: "Field"}}
+	for _, id := range options.Get("reducers").MustStringArray() {
+		reducer, ok := seriesReducers[id]
+		if !ok {
+			return &TransformationError{Id: "reduce", Reason: fmt.Sprintf("unknown reducer %s", id)}
+		}
+		reducers = append(reducers, reducer)
+		columns = append(columns, TableColumn{Text: reducer.name})
+	}
+	if len(reducers) == 0 {
+		return &TransformationError{Id: "reduce", Reason: "no reducers"}
+	}
+
+	for _, result := range resp.Results {
+		if len(result.Series) == 0 {
+			continue
+		}
+
+		table := &Table{Columns: columns}
+		for _, s := range result.Series {
+			row := RowValues{s.Name}
+			for _, reducer := range reducers {
+				row = append(row, reducer.reduce(s.Points))
+			}
+			table.Rows = append(table.Rows, row)
+		}
+		result.Series = make(TimeSeriesSlice, 0)
+		result.Tables = append(result.Tables, table)
+	}
+	return nil
+}
+
+// calculateFieldTransformation adds a series computed from two operands, series or numbers, with
+// the binary mode. Operands are series names, or the ref id of a query returning a single series.
+func calculateFieldTransformation(resp *Response, options *simplejson.Json) error {
+	if mode := options.Get("mode").MustString("binary"); mode != "binary" {
+		return &TransformationError{Id: "calculateField", Reason: fmt.Sprintf("unsupported mode %s", mode)}
+	}
+
+	binary := options.Get("binary")
+	leftOperand := binary.Get("left").MustString()
+	operator := binary.Get("operator").MustString()
+	rightOperand := binary.Get("right").MustString()
+
+	op, ok := binaryOperators[operator]
+	if !ok {
+		return &TransformationError{Id: "calculateField", Reason: fmt.Sprintf("unknown operator %q", operator)}
+	}
+
+	left, leftResult := findOperand(resp, leftOperand)
+	right, rightResult := findOperand(resp, rightOperand)
+	leftValue, leftErr := strconv.ParseFloat(leftOperand, 64)
+	rightValue, rightErr := strconv.ParseFloat(rightOperand, 64)
+	if (left == nil && leftErr != nil) || (right == nil && rightErr != nil) {
+		return &TransformationError{Id: "calculateField", Reason: fmt.Sprintf("operands %s and %s not found", leftOperand, rightOperand)}
+	}
+	if left == nil && right == nil {
+		return &TransformationError{Id: "calculateField", Reason: "one of the operands must be a series"}
+	}
+
+	alias := options.Get("alias").MustString(strings.Join([]string{leftOperand, operator, rightOperand}, " "))
+	calculated := &TimeSeries{Name: alias}
+	result := leftResult
+	switch {
+	case left != nil && right != nil:
+		// the points of the left series are matched to the points of the right series with the
+		// same time
+		rightPoints := make(map[float64]null.Float, len(right.Points))
+		for _, p := range right.Points {
+			rightPoints[p[1].Float64] = p[0]
+		}
+		for _, p := range left.Points {
+			calculated.Points = append(calculated.Points, TimePoint{applyOperator(op, p[0], rightPoints[p[1].Float64]), p[1]})
+		}
+		calculated.Tags = left.Tags
+	case left != nil:
+		for _, p := range left.Points {
+			calculated.Points = append(calculated.Points, TimePoint{applyOperator(op, p[0], null.FloatFrom(rightValue)), p[1]})
+		}
+		calculated.Tags = left.Tags
+	default:
+		result = rightResult
+		for _, p := range right.Points {
+			calculated.Points = append(calculated.Points, TimePoint{applyOperator(op, null.FloatFrom(leftValue), p[0]), p[1]})
+		}
+		calculated.Tags = right.Tags
+	}
+
+	if options.Get("replaceFields").MustBool(false) {
+		result.Series = TimeSeriesSlice{calculated}
+		return nil
+	}
+	result.Series = append(result.Series, calculated)
+	return nil
+}
+
+var binaryOperators = map[string]func(a, b float64) float64{
+	"+": func(a, b float64) float64 { return a + b },
+	"-": func(a, b float64) float64 { return a - b },
+	"*": func(a, b float64) float64 { return a * b },
+	"/": func(a, b float64) float64 { return a / b },
+}
+
+func applyOperator(op func(a, b float64) float64, a, b null.Float) null.Float {
+	if !a.Valid || !b.Valid {
+		return null.FloatFromPtr(nil)
+	}
+	res := op(a.Float64, b.Float64)
+	if math.IsNaN(res) || math.IsInf(res, 0) {
+		return null.FloatFromPtr(nil)
+	}
+	return null.FloatFrom(res)
+}
+
+// findOperand returns the series with the name, or the only series of the query with the ref id
+func findOperand(resp *Response, name string) (*TimeSeries, *QueryResult) {
+	for _, result := range sortedResults(resp) {
+		for _, s := range result.Series {
+			if s.Name == name {
+				return s, result
+			}
+		}
+	}
+	if result, ok := resp.Results[name]; ok && len(result.Series) == 1 {
+		return result.Series[0], result
+	}
+	return nil, nil
+}
@@ -0,0 +1,153 @@
+package tsdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/null"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func newTransformationsResponse() *Response {
+	return &Response{
+		Results: map[string]*QueryResult{
+			"A": {
+				RefId: "A",
+				Series: TimeSeriesSlice{
+					NewTimeSeries("errors", NewTimeSeriesPointsFromArgs(1, 1000, 4, 2000)),
+					NewTimeSeries("debug", NewTimeSeriesPointsFromArgs(10, 1000, 20, 2000)),
+				},
+			},
+			"B": {
+				RefId: "B",
+				Series: TimeSeriesSlice{
+					NewTimeSeries("requests", NewTimeSeriesPointsFromArgs(10, 1000, 0, 2000)),
+				},
+			},
+		},
+	}
+}
+
+func newTransformation(id string, options map[string]interface{}) *Transformation {
+	return &Transformation{Id: id, Options: simplejson.NewFromAny(options)}
+}
+
+func seriesNames(series TimeSeriesSlice) []string {
+	names := make([]string, 0, len(series))
+	for _, s := range series {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestApplyTransformations(t *testing.T) {
+	t.Run("Should rename and exclude series with organize", func(t *testing.T) {
+		resp := newTransformationsResponse()
+		err := ApplyTransformations(resp, []*Transformation{newTransformation("organize", map[string]interface{}{
+			"renameByName":  map[string]interface{}{"errors": "Errors"},
+			"excludeByName": map[string]interface{}{"debug": true},
+		})})
+		require.NoError(t, err)
+		require.Equal(t, []string{"Errors"}, seriesNames(resp.Results["A"].Series))
+	})
+
+	t.Run("Should rename and exclude table columns with organize", func(t *testing.T) {
+		resp := &Response{Results: map[string]*QueryResult{
+			"A": {RefId: "A", Tables: []*Table{{
+				Columns: []TableColumn{{Text: "host"}, {Text: "secret"}, {Text: "value"}},
+				Rows:    []RowValues{{"a", "s", 1}},
+			}}},
+		}}
+		err := ApplyTransformations(resp, []*Transformation{newTransformation("organize", map[string]interface{}{
+			"renameByName":  map[string]interface{}{"host": "Host"},
+			"excludeByName": map[string]interface{}{"secret": true},
+		})})
+		require.NoError(t, err)
+
+		table := resp.Results["A"].Tables[0]
+		require.Equal(t, []TableColumn{{Text: "Host"}, {Text: "value"}}, table.Columns)
+		require.Equal(t, []RowValues{{"a", 1}}, table.Rows)
+	})
+
+	t.Run("Should merge the results into the first one", func(t *testing.T) {
+		resp := newTransformationsResponse()
+		err := ApplyTransformations(resp, []*Transformation{{Id: "merge"}})
+		require.NoError(t, err)
+		require.Len(t, resp.Results, 1)
+		require.Equal(t, []string{"errors", "debug", "requests"}, seriesNames(resp.Results["A"].Series))
+	})
+
+	t.Run("Should reduce the series to a table", func(t *testing.T) {
+		resp := newTransformationsResponse()
+		err := ApplyTransformations(resp, []*Transformation{newTransformation("reduce", map[string]interface{}{
+			"reducers": []interface{}{"max", "lastNotNull"},
+		})})
+		require.NoError(t, err)
+
+		result := resp.Results["A"]
+		require.Empty(t, result.Series)
+		require.Len(t, result.Tables, 1)
+		require.Equal(t, []TableColumn{{Text: "Field"}, {Text: "Max"}, {Text: "Last (not null)"}}, result.Tables[0].Columns)
+		require.Equal(t, RowValues{"errors", null.FloatFrom(4), null.FloatFrom(4)}, result.Tables[0].Rows[0])
+	})
+
+	t.Run("Should calculate a series from two queries", func(t *testing.T) {
+		resp := newTransformationsResponse()
+		err := ApplyTransformations(resp, []*Transformation{newTransformation("calculateField", map[string]interface{}{
+			"mode":   "binary",
+			"binary": map[string]interface{}{"left": "errors", "operator": "/", "right": "B"},
+			"alias":  "error rate",
+		})})
+		require.NoError(t, err)
+
+		series := resp.Results["A"].Series
+		require.Equal(t, []string{"errors", "debug", "error rate"}, seriesNames(series))
+		require.Equal(t, null.FloatFrom(0.1), series[2].Points[0][0])
+		// divided by zero
+		require.False(t, series[2].Points[1][0].Valid)
+	})
+
+	t.Run("Should calculate a series from a series and a number", func(t *testing.T) {
+		resp := newTransformationsResponse()
+		err := ApplyTransformations(resp, []*Transformation{newTransformation("calculateField", map[string]interface{}{
+			"binary":        map[string]interface{}{"left": "requests", "operator": "*", "right": "100"},
+			"replaceFields": true,
+		})})
+		require.NoError(t, err)
+
+		series := resp.Results["B"].Series
+		require.Equal(t, []string{"requests * 100"}, seriesNames(series))
+		require.Equal(t, null.FloatFrom(1000), series[0].Points[0][0])
+	})
+
+	t.Run("Should fail with unknown transformations and operands", func(t *testing.T) {
+		var transformationErr *TransformationError
+
+		err := ApplyTransformations(newTransformationsResponse(), []*Transformation{{Id: "unknown"}})
+		require.True(t, errors.As(err, &transformationErr))
+
+		err = ApplyTransformations(newTransformationsResponse(), []*Transformation{newTransformation("calculateField", map[string]interface{}{
+			"binary": map[string]interface{}{"left": "missing", "operator": "+", "right": "1"},
+		})})
+		require.True(t, errors.As(err, &transformationErr))
+	})
+
+	t.Run("Should transform the responses of the requests", func(t *testing.T) {
+		executor := registerFakeExecutor()
+		executor.Return("A", TimeSeriesSlice{NewTimeSeries("up", NewTimeSeriesPointsFromArgs(1, 1000))})
+		ds := &models.DataSource{Id: 1, Type: "test"}
+		req := &TsdbQuery{
+			Queries: []*Query{{RefId: "A", DataSource: ds}},
+			Transformations: []*Transformation{newTransformation("organize", map[string]interface{}{
+				"renameByName": map[string]interface{}{"up": "Up"},
+			})},
+		}
+
+		resp, err := HandleRequest(context.Background(), ds, req)
+		require.NoError(t, err)
+		require.Equal(t, []string{"Up"}, seriesNames(resp.Results["A"].Series))
+	})
+}
@@ -2,10 +2,18 @@ package tsdb
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMetricQuery(t *testing.T) {
@@ -61,6 +69,117 @@ func TestMetricQuery(t *testing.T) {
 	})
 }
 
+func TestQueryMetrics(t *testing.T) {
+	ds := &models.DataSource{Id: 1, Uid: "metrics-test", Type: "test"}
+	req := &TsdbQuery{
+		Queries: []*Query{
+			{RefId: "A", DataSource: ds},
+			{RefId: "B", DataSource: ds},
+		},
+	}
+
+	fakeExecutor := registerFakeExecutor()
+	fakeExecutor.Return("A", TimeSeriesSlice{&TimeSeries{Name: "a"}, &TimeSeries{Name: "b"}})
+	fakeExecutor.HandleQuery("B", func(context *TsdbQuery) *QueryResult {
+		return &QueryResult{RefId: "B", Error: errors.New("query failed")}
+	})
+
+	_, err := HandleRequest(context.Background(), ds, req)
+	require.NoError(t, err)
+
+	histogram := func(vec *prometheus.HistogramVec) *dto.Histogram {
+		m := &dto.Metric{}
+		err := vec.WithLabelValues(ds.Type, ds.Uid).(prometheus.Histogram).Write(m)
+		require.NoError(t, err)
+		return m.Histogram
+	}
+
+	require.Equal(t, uint64(1), histogram(metrics.MDataSourceQueryDuration).GetSampleCount())
+	require.Equal(t, float64(2), histogram(metrics.MDataSourceQuerySeries).GetSampleSum())
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.MDataSourceQueryErrors.WithLabelValues(ds.Type, ds.Uid)))
+}
+
+// concurrentExecutor runs the queries of a request concurrently, each query waiting until all the
+// queries of the request started
+type concurrentExecutor struct {
+	started sync.WaitGroup
+	errs    map[string]error
+}
+
+func (e *concurrentExecutor) ConcurrentQueries() bool {
+	return true
+}
+
+func (e *concurrentExecutor) Query(ctx context.Context, dsInfo *models.DataSource, req *TsdbQuery) (*Response, error) {
+	if len(req.Queries) != 1 {
+		return nil, errors.New("expected one query by request")
+	}
+	query := req.Queries[0]
+
+	e.started.Done()
+	started := make(chan struct{})
+	go func() {
+		e.started.Wait()
+		close(started)
+	}()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		return nil, errors.New("the queries didn't run concurrently")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if err := e.errs[query.RefId]; err != nil {
+		return nil, err
+	}
+	return &Response{Results: map[string]*QueryResult{
+		query.RefId: {RefId: query.RefId, Series: TimeSeriesSlice{&TimeSeries{Name: query.RefId}}},
+	}}, nil
+}
+
+func TestConcurrentQueries(t *testing.T) {
+	ds := &models.DataSource{Id: 1, Type: "test-concurrent"}
+	executor := &concurrentExecutor{}
+	RegisterTsdbQueryEndpoint("test-concurrent", func(dsInfo *models.DataSource) (TsdbQueryEndpoint, error) {
+		return executor, nil
+	})
+	newRequest := func(refIDs ...string) *TsdbQuery {
+		req := &TsdbQuery{}
+		for _, refID := range refIDs {
+			req.Queries = append(req.Queries, &Query{RefId: refID, DataSource: ds})
+		}
+		executor.started = sync.WaitGroup{}
+		executor.started.Add(len(refIDs))
+		return req
+	}
+
+	t.Run("Should run the queries concurrently", func(t *testing.T) {
+		executor.errs = nil
+		res, err := HandleRequest(context.Background(), ds, newRequest("A", "B", "C"))
+		require.NoError(t, err)
+		require.Len(t, res.Results, 3)
+		for _, refID := range []string{"A", "B", "C"} {
+			require.NoError(t, res.Results[refID].Error)
+			require.Equal(t, refID, res.Results[refID].Series[0].Name)
+		}
+	})
+
+	t.Run("Should return the error of a failed query in its result", func(t *testing.T) {
+		executor.errs = map[string]error{"B": errors.New("query failed")}
+		res, err := HandleRequest(context.Background(), ds, newRequest("A", "B"))
+		require.NoError(t, err)
+		require.Equal(t, "A", res.Results["A"].Series[0].Name)
+		require.EqualError(t, res.Results["B"].Error, "query failed")
+	})
+
+	t.Run("Should fail the request when all the queries failed", func(t *testing.T) {
+		executor.errs = map[string]error{"A": errors.New("query A failed"), "B": errors.New("query B failed")}
+		_, err := HandleRequest(context.Background(), ds, newRequest("A", "B"))
+		require.EqualError(t, err, "query A failed")
+	})
+}
+
 func registerFakeExecutor() *FakeExecutor {
 	executor, _ := NewFakeExecutor(nil)
 	RegisterTsdbQueryEndpoint("test", func(dsInfo *models.DataSource) (TsdbQueryEndpoint, error) {
@@ -0,0 +1,17 @@
+package zipkin
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// CheckHealth lists the services of Zipkin, like the test of the data source in the frontend.
+func (e *ZipkinExecutor) CheckHealth(ctx context.Context, dsInfo *models.DataSource) (*tsdb.HealthCheckResult, error) {
+	if err := e.get(ctx, dsInfo, "api/v2/services", nil, nil); err != nil {
+		return nil, err
+	}
+
+	return tsdb.HealthOk("Data source is working"), nil
+}
@@ -0,0 +1,55 @@
+package zipkin
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+// servicesCacheTTL is how long the services and span names are cached, they only change when
+// services are deployed
+const servicesCacheTTL = 5 * time.Minute
+
+// servicesCache holds the services and span names of the data sources, by data source id and
+// version so that the entries of a data source are dropped when it's updated
+var servicesCache = localcache.New(servicesCacheTTL, 2*servicesCacheTTL)
+
+// CallResource serves the services and the span names of a service of the query editor, the
+// parameter of the span names is the service.
+func (e *ZipkinExecutor) CallResource(ctx context.Context, dsInfo *models.DataSource, resourcePath string, params url.Values) (interface{}, error) {
+	var apiParams url.Values
+	var apiPath string
+	switch resourcePath {
+	case "services":
+		apiPath = "api/v2/services"
+	case "spans":
+		service := params.Get("service")
+		if service == "" {
+			return nil, fmt.Errorf("%w: missing service", tsdb.ErrInvalidResourceRequest)
+		}
+		apiPath = "api/v2/spans"
+		apiParams = url.Values{"serviceName": []string{service}}
+	default:
+		return nil, tsdb.ErrResourceNotFound
+	}
+
+	key := fmt.Sprintf("%d/%d/%s?%s", dsInfo.Id, dsInfo.Version, resourcePath, params.Encode())
+	if cached, ok := servicesCache.Get(key); ok {
+		return cached, nil
+	}
+
+	result := make([]string, 0)
+	if err := e.get(ctx, dsInfo, apiPath, apiParams, &result); err != nil {
+		return nil, err
+	}
+	sort.Strings(result)
+
+	servicesCache.Set(key, result, servicesCacheTTL)
+	return result, nil
+}
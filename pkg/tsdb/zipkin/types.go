@@ -0,0 +1,110 @@
+package zipkin
+
+import (
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+type zipkinSpan struct {
+	TraceID        string             `json:"traceId"`
+	ParentID       string             `json:"parentId"`
+	ID             string             `json:"id"`
+	Name           string             `json:"name"`
+	Kind           string             `json:"kind"`
+	Timestamp      int64              `json:"timestamp"`
+	Duration       int64              `json:"duration"`
+	LocalEndpoint  *zipkinEndpoint    `json:"localEndpoint"`
+	RemoteEndpoint *zipkinEndpoint    `json:"remoteEndpoint"`
+	Annotations    []zipkinAnnotation `json:"annotations"`
+	Tags           map[string]string  `json:"tags"`
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+	IPv4        string `json:"ipv4"`
+	IPv6        string `json:"ipv6"`
+	Port        int    `json:"port"`
+}
+
+type zipkinAnnotation struct {
+	Timestamp int64  `json:"timestamp"`
+	Value     string `json:"value"`
+}
+
+// traceSpans converts the spans of a trace, whose times are in microseconds, to the spans of the
+// trace frame. The service of a span is its local endpoint, or its remote endpoint.
+func traceSpans(zSpans []zipkinSpan) []*tsdb.TraceSpan {
+	spans := make([]*tsdb.TraceSpan, 0, len(zSpans))
+	for _, s := range zSpans {
+		span := &tsdb.TraceSpan{
+			TraceID:       s.TraceID,
+			SpanID:        s.ID,
+			ParentSpanID:  s.ParentID,
+			OperationName: s.Name,
+			ServiceName:   "unknown",
+			ServiceTags:   []tsdb.TraceKeyValue{},
+			StartTime:     time.Unix(0, s.Timestamp*int64(time.Microsecond)),
+			Duration:      time.Duration(s.Duration) * time.Microsecond,
+			Logs:          make([]tsdb.TraceLog, 0, len(s.Annotations)),
+			Tags:          spanTags(s),
+		}
+
+		endpoint := s.LocalEndpoint
+		if endpoint == nil || endpoint.ServiceName == "" {
+			endpoint = s.RemoteEndpoint
+		}
+		if endpoint != nil && endpoint.ServiceName != "" {
+			span.ServiceName = endpoint.ServiceName
+			span.ServiceTags = endpointTags(endpoint)
+		}
+
+		// annotations are the closest thing to the logs of a span
+		for _, annotation := range s.Annotations {
+			span.Logs = append(span.Logs, tsdb.TraceLog{
+				Timestamp: float64(annotation.Timestamp) / 1000,
+				Fields:    []tsdb.TraceKeyValue{{Key: "annotation", Value: annotation.Value}},
+			})
+		}
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+// spanTags returns the kind of a span and its tags sorted by key. The error tag is a boolean so that
+// the trace view shows the span as failed.
+func spanTags(s zipkinSpan) []tsdb.TraceKeyValue {
+	tags := make([]tsdb.TraceKeyValue, 0, len(s.Tags)+1)
+	if s.Kind != "" {
+		tags = append(tags, tsdb.TraceKeyValue{Key: "kind", Value: s.Kind})
+	}
+
+	keys := make([]string, 0, len(s.Tags))
+	for key := range s.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key == "error" {
+			tags = append(tags, tsdb.TraceKeyValue{Key: key, Value: true})
+			continue
+		}
+		tags = append(tags, tsdb.TraceKeyValue{Key: key, Value: s.Tags[key]})
+	}
+	return tags
+}
+
+func endpointTags(endpoint *zipkinEndpoint) []tsdb.TraceKeyValue {
+	tags := make([]tsdb.TraceKeyValue, 0, 3)
+	if endpoint.IPv4 != "" {
+		tags = append(tags, tsdb.TraceKeyValue{Key: "ipv4", Value: endpoint.IPv4})
+	}
+	if endpoint.IPv6 != "" {
+		tags = append(tags, tsdb.TraceKeyValue{Key: "ipv6", Value: endpoint.IPv6})
+	}
+	if endpoint.Port != 0 {
+		tags = append(tags, tsdb.TraceKeyValue{Key: "port", Value: endpoint.Port})
+	}
+	return tags
+}
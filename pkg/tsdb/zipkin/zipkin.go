@@ -0,0 +1,186 @@
+package zipkin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+)
+
+type ZipkinExecutor struct {
+}
+
+func NewZipkinExecutor(datasource *models.DataSource) (tsdb.TsdbQueryEndpoint, error) {
+	return &ZipkinExecutor{}, nil
+}
+
+var (
+	plog log.Logger
+)
+
+func init() {
+	plog = log.New("tsdb.zipkin")
+	tsdb.RegisterTsdbQueryEndpoint("zipkin", NewZipkinExecutor)
+}
+
+const (
+	queryTypeTrace  = "trace"
+	queryTypeSearch = "search"
+)
+
+// Query returns a trace frame for the queries of a trace ID, and a frame of the found traces for the
+// searches, of a service and a span name, an annotation query, durations and the time range of the query.
+func (e *ZipkinExecutor) Query(ctx context.Context, dsInfo *models.DataSource, tsdbQuery *tsdb.TsdbQuery) (*tsdb.Response, error) {
+	result := &tsdb.Response{
+		Results: map[string]*tsdb.QueryResult{},
+	}
+
+	for _, query := range tsdbQuery.Queries {
+		queryResult := &tsdb.QueryResult{RefId: query.RefId}
+		frames, err := e.executeQuery(ctx, dsInfo, query, tsdbQuery.TimeRange)
+		if err != nil {
+			queryResult.Error = err
+		} else {
+			queryResult.Dataframes = tsdb.NewDecodedDataFrames(frames)
+		}
+		result.Results[query.RefId] = queryResult
+	}
+
+	return result, nil
+}
+
+func (e *ZipkinExecutor) executeQuery(ctx context.Context, dsInfo *models.DataSource, query *tsdb.Query, timeRange *tsdb.TimeRange) (data.Frames, error) {
+	queryType := query.Model.Get("queryType").MustString(queryTypeTrace)
+	switch queryType {
+	case queryTypeTrace:
+		traceID := query.Model.Get("query").MustString("")
+		if traceID == "" {
+			return data.Frames{}, nil
+		}
+
+		var spans []zipkinSpan
+		if err := e.get(ctx, dsInfo, "api/v2/trace/"+url.PathEscape(traceID), nil, &spans); err != nil {
+			return nil, err
+		}
+
+		frame, err := tsdb.NewTraceFrame(traceID, traceSpans(spans))
+		if err != nil {
+			return nil, err
+		}
+		return data.Frames{frame}, nil
+	case queryTypeSearch:
+		params, err := searchParams(query, timeRange)
+		if err != nil {
+			return nil, err
+		}
+
+		var traces [][]zipkinSpan
+		if err := e.get(ctx, dsInfo, "api/v2/traces", params, &traces); err != nil {
+			return nil, err
+		}
+
+		summaries := make([]*tsdb.TraceSummary, 0, len(traces))
+		for _, trace := range traces {
+			if len(trace) == 0 {
+				continue
+			}
+			summaries = append(summaries, tsdb.SummarizeTrace(trace[0].TraceID, traceSpans(trace)))
+		}
+		return data.Frames{tsdb.NewTraceSearchFrame(summaries)}, nil
+	default:
+		return nil, fmt.Errorf("unknown query type %q", queryType)
+	}
+}
+
+// searchParams returns the parameters of the search of traces of the Zipkin API, whose durations are
+// in microseconds and times in milliseconds
+func searchParams(query *tsdb.Query, timeRange *tsdb.TimeRange) (url.Values, error) {
+	params := url.Values{}
+	if service := query.Model.Get("service").MustString(""); service != "" {
+		params.Set("serviceName", service)
+	}
+	if spanName := query.Model.Get("spanName").MustString(""); spanName != "" {
+		params.Set("spanName", spanName)
+	}
+	if annotationQuery := query.Model.Get("annotationQuery").MustString(""); annotationQuery != "" {
+		params.Set("annotationQuery", annotationQuery)
+	}
+	for _, key := range []string{"minDuration", "maxDuration"} {
+		if value := query.Model.Get(key).MustString(""); value != "" {
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			params.Set(key, strconv.FormatInt(duration.Microseconds(), 10))
+		}
+	}
+	params.Set("limit", strconv.Itoa(query.Model.Get("limit").MustInt(20)))
+
+	if timeRange != nil {
+		from, to := timeRange.GetFromAsMsEpoch(), timeRange.GetToAsMsEpoch()
+		params.Set("endTs", strconv.FormatInt(to, 10))
+		params.Set("lookback", strconv.FormatInt(to-from, 10))
+	}
+	return params, nil
+}
+
+// get calls the HTTP API of Zipkin and decodes its response, the errors of Zipkin are plain text
+func (e *ZipkinExecutor) get(ctx context.Context, dsInfo *models.DataSource, apiPath string, params url.Values, result interface{}) error {
+	u, err := url.Parse(dsInfo.Url)
+	if err != nil {
+		return err
+	}
+	u.Path = path.Join(u.Path, apiPath)
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	if dsInfo.BasicAuth {
+		password, err := dsInfo.DecryptedBasicAuthPassword()
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(dsInfo.BasicAuthUser, password)
+	}
+
+	httpClient, err := dsInfo.GetHttpClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := ctxhttp.Do(ctx, httpClient, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		message, _ := ioutil.ReadAll(io.LimitReader(res.Body, 512))
+		plog.Info("Request failed", "status", res.Status, "body", string(message))
+		if len(message) > 0 {
+			return fmt.Errorf("zipkin error: %s", strings.TrimSpace(string(message)))
+		}
+		return fmt.Errorf("request failed status: %v", res.Status)
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(result)
+}
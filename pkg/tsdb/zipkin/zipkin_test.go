@@ -0,0 +1,127 @@
+package zipkin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const traceResponse = `[
+	{"traceId": "abc", "id": "1", "name": "get /api", "kind": "SERVER", "timestamp": 1600000000000000, "duration": 2500,
+		"localEndpoint": {"serviceName": "api", "ipv4": "10.0.0.1", "port": 8080}, "tags": {"http.path": "/api", "error": "500"}},
+	{"traceId": "abc", "parentId": "1", "id": "2", "name": "select", "timestamp": 1600000000001000, "duration": 1000,
+		"remoteEndpoint": {"serviceName": "db"}, "annotations": [{"timestamp": 1600000000001500, "value": "query"}]}
+]`
+
+func TestZipkin(t *testing.T) {
+	var requestPath string
+	var params url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestPath = r.URL.Path
+		params = r.URL.Query()
+		switch r.URL.Path {
+		case "/api/v2/trace/abc":
+			_, _ = w.Write([]byte(traceResponse))
+		case "/api/v2/traces":
+			_, _ = w.Write([]byte(`[` + traceResponse + `, []]`))
+		case "/api/v2/services", "/api/v2/spans":
+			_, _ = w.Write([]byte(`["db", "api"]`))
+		default:
+			http.Error(w, "Trace not found", http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	dsInfo := &models.DataSource{Id: 1, Type: "zipkin", Url: server.URL, JsonData: simplejson.New()}
+	executor := &ZipkinExecutor{}
+	timeRange := tsdb.NewTimeRange("1600000000000", "1600003600000")
+
+	t.Run("Returns the spans of a trace", func(t *testing.T) {
+		query := &tsdb.Query{RefId: "A", Model: simplejson.NewFromAny(map[string]interface{}{"query": "abc"})}
+		result, err := executor.Query(context.Background(), dsInfo, &tsdb.TsdbQuery{TimeRange: timeRange, Queries: []*tsdb.Query{query}})
+		require.NoError(t, err)
+		require.NoError(t, result.Results["A"].Error)
+
+		frames, err := result.Results["A"].Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		frame := frames[0]
+		require.Equal(t, 2, frame.Rows())
+
+		assert.Equal(t, []interface{}{
+			"abc", "1", "", "get /api", "api", `[{"key":"ipv4","value":"10.0.0.1"},{"key":"port","value":8080}]`, 1600000000000.0, 2.5,
+			"[]", `[{"key":"kind","value":"SERVER"},{"key":"error","value":true},{"key":"http.path","value":"/api"}]`,
+		}, frame.RowCopy(0))
+		assert.Equal(t, []interface{}{
+			"abc", "2", "1", "select", "db", "[]", 1600000000001.0, 1.0,
+			`[{"timestamp":1600000000001.5,"fields":[{"key":"annotation","value":"query"}]}]`, "[]",
+		}, frame.RowCopy(1))
+	})
+
+	t.Run("Fails with the error of Zipkin", func(t *testing.T) {
+		query := &tsdb.Query{RefId: "A", Model: simplejson.NewFromAny(map[string]interface{}{"query": "missing"})}
+		result, err := executor.Query(context.Background(), dsInfo, &tsdb.TsdbQuery{TimeRange: timeRange, Queries: []*tsdb.Query{query}})
+		require.NoError(t, err)
+		assert.EqualError(t, result.Results["A"].Error, "zipkin error: Trace not found")
+	})
+
+	t.Run("Searches traces", func(t *testing.T) {
+		query := &tsdb.Query{RefId: "A", Model: simplejson.NewFromAny(map[string]interface{}{
+			"queryType":       "search",
+			"service":         "api",
+			"spanName":        "get /api",
+			"annotationQuery": "error",
+			"maxDuration":     "1s",
+		})}
+		result, err := executor.Query(context.Background(), dsInfo, &tsdb.TsdbQuery{TimeRange: timeRange, Queries: []*tsdb.Query{query}})
+		require.NoError(t, err)
+		require.NoError(t, result.Results["A"].Error)
+		assert.Equal(t, url.Values{
+			"serviceName":     {"api"},
+			"spanName":        {"get /api"},
+			"annotationQuery": {"error"},
+			"maxDuration":     {"1000000"},
+			"limit":           {"20"},
+			"endTs":           {"1600003600000"},
+			"lookback":        {"3600000"},
+		}, params)
+
+		frames, err := result.Results["A"].Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		require.Equal(t, 1, frames[0].Rows())
+		assert.Equal(t, []interface{}{"abc", "api: get /api", time.Unix(1600000000, 0), 2.5, int64(2)}, frames[0].RowCopy(0))
+	})
+
+	t.Run("Lists and caches the span names of a service", func(t *testing.T) {
+		result, err := executor.CallResource(context.Background(), dsInfo, "spans", url.Values{"service": {"api"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"api", "db"}, result)
+		assert.Equal(t, "/api/v2/spans", requestPath)
+		assert.Equal(t, "api", params.Get("serviceName"))
+
+		requestPath = ""
+		_, err = executor.CallResource(context.Background(), dsInfo, "spans", url.Values{"service": {"api"}})
+		require.NoError(t, err)
+		assert.Empty(t, requestPath)
+
+		_, err = executor.CallResource(context.Background(), dsInfo, "dependencies", url.Values{})
+		assert.Equal(t, tsdb.ErrResourceNotFound, err)
+	})
+
+	t.Run("Checks the health with the services", func(t *testing.T) {
+		result, err := executor.CheckHealth(context.Background(), dsInfo)
+		require.NoError(t, err)
+		assert.Equal(t, tsdb.HealthStatusOk, result.Status)
+		assert.Equal(t, "/api/v2/services", requestPath)
+	})
+}
@@ -28,6 +28,7 @@ export interface State {
   homeDashboardId: number;
   theme: string;
   timezone: string;
+  weekStart: string;
   dashboards: DashboardSearchHit[];
 }
 
@@ -37,6 +38,13 @@ const themes: SelectableValue[] = [
   { value: 'light', label: 'Light' },
 ];
 
+const weekStarts: SelectableValue[] = [
+  { value: '', label: 'Default' },
+  { value: 'saturday', label: 'Saturday' },
+  { value: 'sunday', label: 'Sunday' },
+  { value: 'monday', label: 'Monday' },
+];
+
 export class SharedPreferences extends PureComponent<Props, State> {
   backendSrv = backendSrv;
 
@@ -47,6 +55,7 @@ export class SharedPreferences extends PureComponent<Props, State> {
       homeDashboardId: 0,
       theme: '',
       timezone: '',
+      weekStart: '',
       dashboards: [],
     };
   }
@@ -82,17 +91,19 @@ export class SharedPreferences extends PureComponent<Props, State> {
       homeDashboardId: prefs.homeDashboardId,
       theme: prefs.theme,
       timezone: prefs.timezone,
+      weekStart: prefs.weekStart,
       dashboards: [defaultDashboardHit, ...dashboards],
     });
   }
 
   onSubmitForm = async () => {
-    const { homeDashboardId, theme, timezone } = this.state;
+    const { homeDashboardId, theme, timezone, weekStart } = this.state;
 
     await backendSrv.put(`/api/${this.props.resourceUri}/preferences`, {
       homeDashboardId,
       theme,
       timezone,
+      weekStart,
     });
     window.location.reload();
   };
@@ -108,6 +119,10 @@ export class SharedPreferences extends PureComponent<Props, State> {
     this.setState({ timezone: timezone });
   };
 
+  onWeekStartChanged = (weekStart: string) => {
+    this.setState({ weekStart });
+  };
+
   onHomeDashboardChanged = (dashboardId: number) => {
     this.setState({ homeDashboardId: dashboardId });
   };
@@ -120,7 +135,7 @@ export class SharedPreferences extends PureComponent<Props, State> {
   };
 
   render() {
-    const { theme, timezone, homeDashboardId, dashboards } = this.state;
+    const { theme, timezone, weekStart, homeDashboardId, dashboards } = this.state;
     const styles = getStyles();
 
     return (
@@ -159,6 +174,14 @@ export class SharedPreferences extends PureComponent<Props, State> {
               <Field label="Timezone" aria-label={selectors.components.TimeZonePicker.container}>
                 <TimeZonePicker includeInternal={true} value={timezone} onChange={this.onTimeZoneChanged} />
               </Field>
+
+              <Field label="Week start">
+                <Select
+                  value={weekStarts.find(item => item.value === weekStart)}
+                  options={weekStarts}
+                  onChange={(item: SelectableValue<string>) => this.onWeekStartChanged(item.value ?? '')}
+                />
+              </Field>
               <div className="gf-form-button-row">
                 <Button variant="primary">Save</Button>
               </div>
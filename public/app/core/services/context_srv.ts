@@ -13,6 +13,7 @@ export class User {
   login: string;
   orgCount: number;
   timezone: string;
+  weekStart: string;
   helpFlags1: number;
   lightTheme: boolean;
   hasEditPermissionInFolders: boolean;
@@ -116,6 +116,7 @@ export default class TimeSeries {
   transform: any;
   flotpairs: any;
   unit: any;
+  seriesOverride: any;
 
   constructor(opts: any) {
     this.datapoints = opts.datapoints;
@@ -131,6 +132,7 @@ export default class TimeSeries {
     this.unit = opts.unit;
     this.dataFrameIndex = opts.dataFrameIndex;
     this.fieldIndex = opts.fieldIndex;
+    this.seriesOverride = opts.seriesOverride;
     this.hasMsResolution = this.isMsResolutionNeeded();
   }
 
@@ -146,9 +148,11 @@ export default class TimeSeries {
     delete this.stack;
     delete this.bars.show;
 
-    for (let i = 0; i < overrides.length; i++) {
-      const override = overrides[i];
-      if (!matchSeriesOverride(override.alias, this.alias)) {
+    // the override set by the data source applies first, whatever the alias of the series
+    const allOverrides = this.seriesOverride ? [this.seriesOverride, ...overrides] : overrides;
+    for (let i = 0; i < allOverrides.length; i++) {
+      const override = allOverrides[i];
+      if (override !== this.seriesOverride && !matchSeriesOverride(override.alias, this.alias)) {
         continue;
       }
       if (override.lines !== void 0) {
@@ -0,0 +1,84 @@
+import { ArrayVector, DataFrame, FieldType, MutableDataFrame } from '@grafana/data';
+import { traceFrameToTraceData, transformTraceResponse } from './tracing';
+
+const traceFrame: DataFrame = new MutableDataFrame({
+  name: 'abc',
+  meta: { custom: { resultType: 'trace' } },
+  fields: [
+    { name: 'traceID', values: ['abc', 'abc'] },
+    { name: 'spanID', values: ['1', '2'] },
+    { name: 'parentSpanID', values: ['', '1'] },
+    { name: 'operationName', values: ['get /api', 'select'] },
+    { name: 'serviceName', values: ['api', 'db'] },
+    { name: 'serviceTags', values: ['[{"key":"ipv4","value":"10.0.0.1"},{"key":"port","value":8080}]', '[]'] },
+    { name: 'startTime', values: [1600000000000, 1600000000001] },
+    { name: 'duration', values: [2.5, 1] },
+    { name: 'logs', values: ['[]', '[{"timestamp":1600000000001.5,"fields":[{"key":"annotation","value":"query"}]}]'] },
+    { name: 'tags', values: ['[{"key":"kind","value":"SERVER"},{"key":"error","value":true}]', '[]'] },
+  ],
+});
+
+describe('traceFrameToTraceData', () => {
+  it('converts the spans of the frame to the trace of the trace view', () => {
+    expect(traceFrameToTraceData(traceFrame)).toEqual({
+      traceID: 'abc',
+      warnings: null,
+      processes: {
+        api: {
+          serviceName: 'api',
+          tags: [
+            { key: 'ipv4', type: 'string', value: '10.0.0.1' },
+            { key: 'port', type: 'number', value: 8080 },
+          ],
+        },
+        db: { serviceName: 'db', tags: [] },
+      },
+      spans: [
+        {
+          traceID: 'abc',
+          spanID: '1',
+          processID: 'api',
+          operationName: 'get /api',
+          startTime: 1600000000000000,
+          duration: 2500,
+          logs: [],
+          tags: [
+            { key: 'kind', type: 'string', value: 'SERVER' },
+            { key: 'error', type: 'bool', value: true },
+          ],
+          references: [],
+          warnings: null,
+          flags: 1,
+        },
+        {
+          traceID: 'abc',
+          spanID: '2',
+          processID: 'db',
+          operationName: 'select',
+          startTime: 1600000000001000,
+          duration: 1000,
+          logs: [{ timestamp: 1600000000001500, fields: [{ key: 'annotation', type: 'string', value: 'query' }] }],
+          tags: [],
+          references: [{ refType: 'CHILD_OF', spanID: '1', traceID: 'abc' }],
+          warnings: null,
+          flags: 1,
+        },
+      ],
+    });
+  });
+});
+
+describe('transformTraceResponse', () => {
+  it('replaces the trace frames and keeps the other frames', () => {
+    const searchFrame = new MutableDataFrame({
+      meta: { custom: { resultType: 'traceSearch' } },
+      fields: [{ name: 'traceID', values: new ArrayVector(['abc']) }],
+    });
+
+    const response = transformTraceResponse({ data: [traceFrame, searchFrame] });
+    expect(response.data[0].fields[0].name).toBe('trace');
+    expect(response.data[0].fields[0].type).toBe(FieldType.trace);
+    expect(response.data[0].fields[0].values.get(0).spans).toHaveLength(2);
+    expect(response.data[1]).toBe(searchFrame);
+  });
+});
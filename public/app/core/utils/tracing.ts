@@ -0,0 +1,104 @@
+import {
+  DataFrame,
+  DataQueryResponse,
+  FieldType,
+  MutableDataFrame,
+  TraceData,
+  TraceKeyValuePair,
+  TraceLog,
+  TraceProcess,
+  TraceSpanData,
+  Vector,
+} from '@grafana/data';
+
+/**
+ * Result type of the frames of the traces returned by the tracing data sources, a row by span.
+ */
+export const TRACE_RESULT_TYPE = 'trace';
+
+type TraceFrameKeyValue = { key: string; value: any };
+
+export function isTraceFrame(frame: DataFrame): boolean {
+  return frame.meta?.custom?.resultType === TRACE_RESULT_TYPE;
+}
+
+/**
+ * Converts the trace frame of the backend, whose times are in milliseconds, to the trace of the
+ * trace view, whose times are in microseconds like the traces of Jaeger.
+ */
+export function traceFrameToTraceData(frame: DataFrame): TraceData & { spans: TraceSpanData[] } {
+  const fields: Record<string, Vector> = {};
+  for (const field of frame.fields) {
+    fields[field.name] = field.values;
+  }
+  const value = (name: string, i: number) => fields[name]?.get(i);
+
+  const processes: Record<string, TraceProcess> = {};
+  const spans: TraceSpanData[] = [];
+  for (let i = 0; i < frame.length; i++) {
+    const serviceName: string = value('serviceName', i);
+    if (!processes[serviceName]) {
+      processes[serviceName] = { serviceName, tags: parseKeyValues(value('serviceTags', i)) };
+    }
+
+    const parentSpanID: string = value('parentSpanID', i);
+    spans.push({
+      traceID: value('traceID', i),
+      spanID: value('spanID', i),
+      processID: serviceName,
+      operationName: value('operationName', i),
+      startTime: value('startTime', i) * 1000,
+      duration: value('duration', i) * 1000,
+      logs: parseLogs(value('logs', i)),
+      tags: parseKeyValues(value('tags', i)),
+      references: parentSpanID ? [{ refType: 'CHILD_OF', spanID: parentSpanID, traceID: value('traceID', i) }] : [],
+      warnings: null,
+      flags: 1,
+    });
+  }
+
+  return { traceID: frame.name || value('traceID', 0), processes, spans, warnings: null };
+}
+
+/**
+ * Replaces the trace frames of a response with the frame of the trace view, a single trace field.
+ * The other frames, like the traces found by a search, are kept.
+ */
+export function transformTraceResponse(response: DataQueryResponse): DataQueryResponse {
+  return {
+    ...response,
+    data: response.data.map((frame: DataFrame) => {
+      if (!isTraceFrame(frame)) {
+        return frame;
+      }
+      return new MutableDataFrame({
+        refId: frame.refId,
+        fields: [{ name: 'trace', type: FieldType.trace, values: [traceFrameToTraceData(frame)] }],
+      });
+    }),
+  };
+}
+
+function parseKeyValues(json?: string): TraceKeyValuePair[] {
+  const keyValues: TraceFrameKeyValue[] = json ? JSON.parse(json) : [];
+  return keyValues.map(({ key, value }) => ({ key, value, type: valueType(value) }));
+}
+
+function parseLogs(json?: string): TraceLog[] {
+  const logs: Array<{ timestamp: number; fields: TraceFrameKeyValue[] }> = json ? JSON.parse(json) : [];
+  return logs.map(log => ({
+    timestamp: log.timestamp * 1000,
+    fields: log.fields.map(({ key, value }) => ({ key, value, type: valueType(value) })),
+  }));
+}
+
+function valueType(value: any): string {
+  switch (typeof value) {
+    case 'boolean':
+      return 'bool';
+    case 'number':
+      return 'number';
+    default:
+      return 'string';
+  }
+}
@@ -0,0 +1,175 @@
+import React from 'react';
+import {
+  DataSourceJsonData,
+  DataSourcePluginOptionsEditorProps,
+  onUpdateDatasourceJsonDataOption,
+  onUpdateDatasourceJsonDataOptionChecked,
+  onUpdateDatasourceJsonDataOptionSelect,
+  onUpdateDatasourceResetOption,
+  onUpdateDatasourceSecureJsonDataOption,
+  SelectableValue,
+} from '@grafana/data';
+import { InlineFormLabel, LegacyForms } from '@grafana/ui';
+import config from 'app/core/config';
+const { Input, Select, SecretFormField, Switch } = LegacyForms;
+
+export interface SigV4Options extends DataSourceJsonData {
+  sigV4Auth?: boolean;
+  sigV4AuthType?: string;
+  sigV4Region?: string;
+  sigV4Service?: string;
+  sigV4Profile?: string;
+  sigV4AssumeRoleArn?: string;
+  sigV4ExternalId?: string;
+}
+
+export interface SigV4SecureOptions {
+  sigV4AccessKey?: string;
+  sigV4SecretKey?: string;
+}
+
+// The auth types of the credential chain of the CloudWatch data source
+const authTypeOptions: Array<SelectableValue<string>> = [
+  { label: 'Default credentials', value: 'default' },
+  { label: 'Access & secret key', value: 'keys' },
+  { label: 'Credentials file', value: 'credentials' },
+  { label: 'ARN', value: 'arn' },
+];
+
+export interface Props<J extends SigV4Options, S extends SigV4SecureOptions>
+  extends DataSourcePluginOptionsEditorProps<J, S> {
+  // Service the requests are signed for when the data source doesn't set one
+  defaultService: string;
+  serviceTooltip?: string;
+}
+
+/**
+ * Settings of the data sources signing the requests the Grafana server sends them with AWS Signature Version 4
+ */
+export const SigV4AuthSettings = <J extends SigV4Options, S extends SigV4SecureOptions>(props: Props<J, S>) => {
+  const { options, defaultService, serviceTooltip } = props;
+  const { jsonData } = options;
+  const secureJsonData: SigV4SecureOptions = options.secureJsonData || {};
+  const authType = jsonData.sigV4AuthType || 'default';
+  // The server refuses the auth providers it doesn't allow, and SigV4 altogether unless it enables it
+  const allowedAuthTypeOptions = authTypeOptions.filter(option =>
+    config.sigV4AllowedAuthProviders.includes(option.value!)
+  );
+
+  if (!config.sigV4AuthEnabled && !jsonData.sigV4Auth) {
+    return null;
+  }
+
+  return (
+    <>
+      <h3 className="page-heading">AWS Signature Version 4</h3>
+      <div className="gf-form-group">
+        <Switch
+          label="SigV4 auth"
+          labelClass="width-14"
+          tooltip="Signs the requests with AWS Signature Version 4, for the APIs behind an AWS service or API Gateway"
+          checked={!!jsonData.sigV4Auth}
+          onChange={onUpdateDatasourceJsonDataOptionChecked(props, 'sigV4Auth')}
+        />
+        {jsonData.sigV4Auth && (
+          <>
+            <div className="gf-form">
+              <InlineFormLabel className="width-14">Auth Provider</InlineFormLabel>
+              <Select
+                className="width-30"
+                options={allowedAuthTypeOptions}
+                value={authTypeOptions.find(option => option.value === authType)}
+                onChange={onUpdateDatasourceJsonDataOptionSelect(props, 'sigV4AuthType')}
+              />
+            </div>
+            {authType === 'credentials' && (
+              <div className="gf-form">
+                <InlineFormLabel className="width-14" tooltip="Profile of the credentials file, default when empty">
+                  Credentials Profile Name
+                </InlineFormLabel>
+                <Input
+                  className="width-30"
+                  placeholder="default"
+                  value={jsonData.sigV4Profile || ''}
+                  onChange={onUpdateDatasourceJsonDataOption(props, 'sigV4Profile')}
+                />
+              </div>
+            )}
+            {authType === 'keys' && (
+              <>
+                <div className="gf-form">
+                  <SecretFormField
+                    label="Access Key ID"
+                    labelWidth={14}
+                    inputWidth={30}
+                    isConfigured={!!options.secureJsonFields?.sigV4AccessKey}
+                    value={secureJsonData.sigV4AccessKey || ''}
+                    onChange={onUpdateDatasourceSecureJsonDataOption(props, 'sigV4AccessKey')}
+                    onReset={onUpdateDatasourceResetOption(props, 'sigV4AccessKey')}
+                  />
+                </div>
+                <div className="gf-form">
+                  <SecretFormField
+                    label="Secret Access Key"
+                    labelWidth={14}
+                    inputWidth={30}
+                    isConfigured={!!options.secureJsonFields?.sigV4SecretKey}
+                    value={secureJsonData.sigV4SecretKey || ''}
+                    onChange={onUpdateDatasourceSecureJsonDataOption(props, 'sigV4SecretKey')}
+                    onReset={onUpdateDatasourceResetOption(props, 'sigV4SecretKey')}
+                  />
+                </div>
+              </>
+            )}
+            {authType === 'arn' && (
+              <>
+                <div className="gf-form">
+                  <InlineFormLabel className="width-14" tooltip="ARN of the role to assume">
+                    Assume Role ARN
+                  </InlineFormLabel>
+                  <Input
+                    className="width-30"
+                    placeholder="arn:aws:iam:*"
+                    value={jsonData.sigV4AssumeRoleArn || ''}
+                    onChange={onUpdateDatasourceJsonDataOption(props, 'sigV4AssumeRoleArn')}
+                  />
+                </div>
+                <div className="gf-form">
+                  <InlineFormLabel
+                    className="width-14"
+                    tooltip="External ID of the role, if its trust policy needs one"
+                  >
+                    External ID
+                  </InlineFormLabel>
+                  <Input
+                    className="width-30"
+                    value={jsonData.sigV4ExternalId || ''}
+                    onChange={onUpdateDatasourceJsonDataOption(props, 'sigV4ExternalId')}
+                  />
+                </div>
+              </>
+            )}
+            <div className="gf-form">
+              <InlineFormLabel className="width-14">Region</InlineFormLabel>
+              <Input
+                className="width-30"
+                placeholder="us-east-1"
+                value={jsonData.sigV4Region || ''}
+                onChange={onUpdateDatasourceJsonDataOption(props, 'sigV4Region')}
+              />
+            </div>
+            <div className="gf-form">
+              <InlineFormLabel className="width-14" tooltip={serviceTooltip}>Service</InlineFormLabel>
+              <Input
+                className="width-30"
+                placeholder={defaultService}
+                value={jsonData.sigV4Service || ''}
+                onChange={onUpdateDatasourceJsonDataOption(props, 'sigV4Service')}
+              />
+            </div>
+          </>
+        )}
+      </div>
+    </>
+  );
+};
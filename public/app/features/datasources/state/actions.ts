@@ -3,7 +3,8 @@ import { getBackendSrv } from 'app/core/services/backend_srv';
 import { getDatasourceSrv } from 'app/features/plugins/datasource_srv';
 import { updateLocation, updateNavIndex } from 'app/core/actions';
 import { buildNavModel } from './navModel';
-import { DataSourcePluginMeta, DataSourceSettings } from '@grafana/data';
+import { AppEvents, DataSourcePluginMeta, DataSourceSettings } from '@grafana/data';
+import appEvents from 'app/core/app_events';
 import { DataSourcePluginCategory, ThunkResult, ThunkDispatch } from 'app/types';
 import { getPluginSettings } from 'app/features/plugins/PluginSettingsCache';
 import { importDataSourcePlugin } from 'app/features/plugins/plugin_loader';
@@ -170,7 +171,10 @@ export function updateDataSource(dataSource: DataSourceSettings): ThunkResult<vo
 export function deleteDataSource(): ThunkResult<void> {
   return async (dispatch, getStore) => {
     const dataSource = getStore().dataSources.dataSource;
-    await getBackendSrv().delete(`/api/datasources/${dataSource.id}`);
+    const result = await getBackendSrv().delete(`/api/datasources/${dataSource.id}`);
+    if (result?.warning) {
+      appEvents.emit(AppEvents.alertWarning, [result.warning]);
+    }
     await updateFrontendSettings();
     dispatch(updateLocation({ path: '/datasources' }));
   };
@@ -20,6 +20,8 @@ export class CloudWatchAnnotationsQueryCtrl {
       prefixMatching: false,
       actionPrefix: '',
       alarmNamePrefix: '',
+      alarmType: 'metric',
+      regions: [],
     });
 
     this.onChange = this.onChange.bind(this);
@@ -1,8 +1,8 @@
-import React, { ChangeEvent } from 'react';
-import { LegacyForms } from '@grafana/ui';
+import React, { ChangeEvent, useEffect, useState } from 'react';
+import { LegacyForms, MultiSelect, Segment } from '@grafana/ui';
 const { Switch } = LegacyForms;
-import { PanelData } from '@grafana/data';
-import { AnnotationQuery } from '../types';
+import { PanelData, SelectableValue } from '@grafana/data';
+import { AlarmType, AnnotationQuery, SelectableStrings } from '../types';
 import { CloudWatchDatasource } from '../datasource';
 import { QueryField, PanelQueryEditor } from './';
 
@@ -13,28 +13,80 @@ export type Props = {
   data?: PanelData;
 };
 
+const alarmTypes: Array<SelectableValue<AlarmType>> = [
+  { label: 'Metric alarms', value: 'metric' },
+  { label: 'Composite alarms', value: 'composite' },
+];
+
 export function AnnotationQueryEditor(props: React.PropsWithChildren<Props>) {
-  const { query, onChange } = props;
+  const { query, datasource, onChange } = props;
+  const [regions, setRegions] = useState<SelectableStrings>([]);
+  const composite = query.alarmType === 'composite';
+  const prefixMatching = composite || query.prefixMatching;
+
+  useEffect(() => {
+    datasource.getRegions().then(options => {
+      const variables = datasource.variables.map(value => ({ label: value, value }));
+      setRegions([...options, ...variables]);
+    });
+  }, []);
+
   return (
     <>
-      <PanelQueryEditor
-        {...props}
-        onChange={(editorQuery: AnnotationQuery) => onChange({ ...query, ...editorQuery })}
-        onRunQuery={() => {}}
-        history={[]}
-      ></PanelQueryEditor>
       <div className="gf-form-inline">
-        <Switch
-          label="Enable Prefix Matching"
-          labelClass="query-keyword"
-          checked={query.prefixMatching}
-          onChange={() => onChange({ ...query, prefixMatching: !query.prefixMatching })}
-        />
+        <div className="gf-form">
+          <QueryField label="Alarm Type">
+            <Segment
+              value={alarmTypes.find(({ value }) => value === (query.alarmType || 'metric'))}
+              options={alarmTypes}
+              onChange={({ value: alarmType }) => onChange({ ...query, alarmType })}
+            />
+          </QueryField>
+        </div>
+        {/* the metric alarms get their regions from the fields of the metric */}
+        {composite && (
+          <div className="gf-form gf-form--grow">
+            <QueryField
+              label="Regions"
+              tooltip="Alarms of several regions, tagged with their region. The region of the query is used when empty."
+            >
+              <MultiSelect
+                className="width-30"
+                options={regions}
+                value={(query.regions || []).map(value => ({ label: value, value }))}
+                onChange={(values: SelectableStrings) =>
+                  onChange({ ...query, regions: values.map(({ value }) => value!) })
+                }
+                placeholder="Region of the query"
+                closeMenuOnSelect={false}
+                isClearable={true}
+              />
+            </QueryField>
+          </div>
+        )}
+      </div>
+      {!composite && (
+        <PanelQueryEditor
+          {...props}
+          onChange={(editorQuery: AnnotationQuery) => onChange({ ...query, ...editorQuery })}
+          onRunQuery={() => {}}
+          history={[]}
+        ></PanelQueryEditor>
+      )}
+      <div className="gf-form-inline">
+        {!composite && (
+          <Switch
+            label="Enable Prefix Matching"
+            labelClass="query-keyword"
+            checked={query.prefixMatching}
+            onChange={() => onChange({ ...query, prefixMatching: !query.prefixMatching })}
+          />
+        )}
 
         <div className="gf-form gf-form--grow">
           <QueryField label="Action">
             <input
-              disabled={!query.prefixMatching}
+              disabled={!prefixMatching}
               className="gf-form-input width-12"
               value={query.actionPrefix || ''}
               onChange={(event: ChangeEvent<HTMLInputElement>) =>
@@ -44,7 +96,7 @@ export function AnnotationQueryEditor(props: React.PropsWithChildren<Props>) {
           </QueryField>
           <QueryField label="Alarm Name">
             <input
-              disabled={!query.prefixMatching}
+              disabled={!prefixMatching}
               className="gf-form-input width-12"
               value={query.alarmNamePrefix || ''}
               onChange={(event: ChangeEvent<HTMLInputElement>) =>
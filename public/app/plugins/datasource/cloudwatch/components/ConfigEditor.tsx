@@ -1,12 +1,13 @@
 import React, { PureComponent } from 'react';
 import { InlineFormLabel, LegacyForms, Button } from '@grafana/ui';
-const { Select, Input } = LegacyForms;
+const { Select, Input, Switch } = LegacyForms;
 import {
   DataSourcePluginOptionsEditorProps,
   onUpdateDatasourceJsonDataOptionSelect,
   onUpdateDatasourceOption,
   onUpdateDatasourceResetOption,
   onUpdateDatasourceJsonDataOption,
+  onUpdateDatasourceJsonDataOptionChecked,
   onUpdateDatasourceSecureJsonDataOption,
 } from '@grafana/data';
 import { SelectableValue } from '@grafana/data';
@@ -19,6 +20,7 @@ const authProviderOptions = [
   { label: 'Access & secret key', value: 'keys' },
   { label: 'Credentials file', value: 'credentials' },
   { label: 'ARN', value: 'arn' },
+  { label: 'Web identity', value: 'webIdentity' },
 ] as SelectableValue[];
 
 export type Props = DataSourcePluginOptionsEditorProps<CloudWatchJsonData, CloudWatchSecureJsonData>;
@@ -130,7 +132,17 @@ export class ConfigEditor extends PureComponent<Props, State> {
                 onChange={option => {
                   if (options.jsonData.authType === 'arn' && option.value !== 'arn') {
                     delete this.props.options.jsonData.assumeRoleArn;
+                    delete this.props.options.jsonData.assumeRoleDuration;
                     delete this.props.options.jsonData.externalId;
+                    delete this.props.options.jsonData.intermediateRoleArn;
+                    delete this.props.options.jsonData.intermediateRoleExternalId;
+                  }
+                  if (options.jsonData.authType === 'webIdentity' && option.value !== 'webIdentity') {
+                    delete this.props.options.jsonData.webIdentityRoleArn;
+                    delete this.props.options.jsonData.webIdentityTokenFile;
+                  }
+                  if (option.value !== 'arn' && option.value !== 'webIdentity') {
+                    delete this.props.options.jsonData.stsEndpoint;
                   }
                   onUpdateDatasourceJsonDataOptionSelect(this.props, 'authType')(option);
                 }}
@@ -225,6 +237,42 @@ export class ConfigEditor extends PureComponent<Props, State> {
               )}
             </div>
           )}
+          {options.jsonData.authType === 'webIdentity' && (
+            <div className="gf-form-inline">
+              <div className="gf-form">
+                <InlineFormLabel
+                  className="width-14"
+                  tooltip="ARN of the role of the web identity, such as the IAM role of a Kubernetes service account. Only used by provisioned data sources, the others use AWS_ROLE_ARN of the Grafana server."
+                >
+                  Web Identity Role ARN
+                </InlineFormLabel>
+                <div className="width-30">
+                  <Input
+                    className="width-30"
+                    placeholder="arn:aws:iam:*"
+                    value={options.jsonData.webIdentityRoleArn || ''}
+                    onChange={onUpdateDatasourceJsonDataOption(this.props, 'webIdentityRoleArn')}
+                  />
+                </div>
+              </div>
+              <div className="gf-form">
+                <InlineFormLabel
+                  className="width-14"
+                  tooltip="Path of the web identity token on the Grafana server. Only used by provisioned data sources, the others use AWS_WEB_IDENTITY_TOKEN_FILE of the Grafana server."
+                >
+                  Web Identity Token File
+                </InlineFormLabel>
+                <div className="width-30">
+                  <Input
+                    className="width-30"
+                    placeholder="/var/run/secrets/eks.amazonaws.com/serviceaccount/token"
+                    value={options.jsonData.webIdentityTokenFile || ''}
+                    onChange={onUpdateDatasourceJsonDataOption(this.props, 'webIdentityTokenFile')}
+                  />
+                </div>
+              </div>
+            </div>
+          )}
           {options.jsonData.authType === 'arn' && (
             <div className="gf-form-inline">
               <div className="gf-form">
@@ -256,6 +304,74 @@ export class ConfigEditor extends PureComponent<Props, State> {
                   />
                 </div>
               </div>
+              <div className="gf-form">
+                <InlineFormLabel
+                  className="width-14"
+                  tooltip="How long the session of the assumed role lasts, such as 1h. Long sessions avoid assuming the role again during long queries. The longest duration is set in the server configuration."
+                >
+                  Assume Role Duration
+                </InlineFormLabel>
+                <div className="width-30">
+                  <Input
+                    className="width-30"
+                    placeholder="15m"
+                    value={options.jsonData.assumeRoleDuration || ''}
+                    onChange={onUpdateDatasourceJsonDataOption(this.props, 'assumeRoleDuration')}
+                  />
+                </div>
+              </div>
+              <div className="gf-form">
+                <InlineFormLabel
+                  className="width-14"
+                  tooltip="ARN of a role assumed before the Assume Role ARN, when the role can only be assumed from another account, such as a bastion account. Sessions of chained roles last one hour at most."
+                >
+                  Intermediate Role ARN
+                </InlineFormLabel>
+                <div className="width-30">
+                  <Input
+                    className="width-30"
+                    placeholder="arn:aws:iam:*"
+                    value={options.jsonData.intermediateRoleArn || ''}
+                    onChange={onUpdateDatasourceJsonDataOption(this.props, 'intermediateRoleArn')}
+                  />
+                </div>
+              </div>
+              <div className="gf-form">
+                <InlineFormLabel
+                  className="width-14"
+                  tooltip="The external ID of the intermediate role, if it has been created with one."
+                >
+                  Intermediate External ID
+                </InlineFormLabel>
+                <div className="width-30">
+                  <Input
+                    className="width-30"
+                    placeholder="External ID"
+                    value={options.jsonData.intermediateRoleExternalId || ''}
+                    onChange={onUpdateDatasourceJsonDataOption(this.props, 'intermediateRoleExternalId')}
+                  />
+                </div>
+              </div>
+            </div>
+          )}
+          {(options.jsonData.authType === 'arn' || options.jsonData.authType === 'webIdentity') && (
+            <div className="gf-form-inline">
+              <div className="gf-form">
+                <InlineFormLabel
+                  className="width-14"
+                  tooltip="HTTPS endpoint of AWS STS, such as a VPC endpoint when STS can only be reached privately. Leave blank to use the endpoint of the default region."
+                >
+                  STS Endpoint
+                </InlineFormLabel>
+                <div className="width-30">
+                  <Input
+                    className="width-30"
+                    placeholder="https://sts.us-gov-west-1.amazonaws.com"
+                    value={options.jsonData.stsEndpoint || ''}
+                    onChange={onUpdateDatasourceJsonDataOption(this.props, 'stsEndpoint')}
+                  />
+                </div>
+              </div>
             </div>
           )}
           <div className="gf-form-inline">
@@ -275,6 +391,72 @@ export class ConfigEditor extends PureComponent<Props, State> {
               />
             </div>
           </div>
+          <div className="gf-form-inline">
+            <Switch
+              label="FIPS Endpoints"
+              labelClass="width-14"
+              checked={!!options.jsonData.useFipsEndpoint}
+              onChange={onUpdateDatasourceJsonDataOptionChecked(this.props, 'useFipsEndpoint')}
+              tooltip="Call the FIPS endpoints of STS, CloudWatch and CloudWatch Logs, available in the US and GovCloud regions."
+            />
+          </div>
+          <div className="gf-form-inline">
+            <Switch
+              label="Recently Active Metrics"
+              labelClass="width-14"
+              checked={!!options.jsonData.listMetricsRecentlyActive}
+              onChange={onUpdateDatasourceJsonDataOptionChecked(this.props, 'listMetricsRecentlyActive')}
+              tooltip="Suggest only the metrics published in the past three hours, which lists large custom namespaces faster."
+            />
+          </div>
+          <div className="gf-form-inline">
+            <div className="gf-form">
+              <InlineFormLabel
+                className="width-14"
+                tooltip="HTTPS endpoint of AWS of the CloudWatch, CloudWatch Logs, EC2 and Resource Groups Tagging API calls, such as a VPC endpoint. Only provisioned data sources can use other endpoints, such as a proxy or localstack. Leave blank to use the endpoints of the region."
+              >
+                Endpoint
+              </InlineFormLabel>
+              <Input
+                className="width-30"
+                placeholder="http://localhost:4566"
+                value={options.jsonData.endpoint || ''}
+                onChange={onUpdateDatasourceJsonDataOption(this.props, 'endpoint')}
+              />
+            </div>
+          </div>
+          <div className="gf-form-inline">
+            <div className="gf-form">
+              <InlineFormLabel
+                className="width-14"
+                tooltip="Most AWS API calls in flight at once, so that a heavy dashboard can't use all the API quota of the account. The other calls wait for them to finish. Leave blank to use the limit of the server configuration, 0 for no limit."
+              >
+                Max Concurrent Calls
+              </InlineFormLabel>
+              <Input
+                className="width-30"
+                placeholder="20"
+                value={options.jsonData.maxConcurrentCalls ?? ''}
+                onChange={onUpdateDatasourceJsonDataOption(this.props, 'maxConcurrentCalls')}
+              />
+            </div>
+          </div>
+          <div className="gf-form-inline">
+            <div className="gf-form">
+              <InlineFormLabel
+                className="width-14"
+                tooltip="How long the GetMetricData responses are cached, e.g. 5m, so that the refreshes of identical queries within it don't call AWS again. Leave blank to not cache them."
+              >
+                Metric Data Cache TTL
+              </InlineFormLabel>
+              <Input
+                className="width-30"
+                placeholder="5m"
+                value={options.jsonData.metricDataCacheTTL || ''}
+                onChange={onUpdateDatasourceJsonDataOption(this.props, 'metricDataCacheTTL')}
+              />
+            </div>
+          </div>
           <div className="gf-form-inline">
             <div className="gf-form">
               <InlineFormLabel className="width-14" tooltip="Namespaces of Custom Metrics.">
@@ -37,6 +37,7 @@ const setup = () => {
 
   const datasource = new CloudWatchDatasource(instanceSettings, templateSrv as any, {} as any);
   datasource.metricFindQuery = async () => [{ value: 'test', label: 'test' }];
+  datasource.getRegionSuggestions = async () => [{ value: 'test', label: 'test', text: 'test' }];
 
   const props: Props = {
     query: {
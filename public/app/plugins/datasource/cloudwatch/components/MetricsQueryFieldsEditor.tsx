@@ -1,6 +1,6 @@
 import React, { useState, useEffect } from 'react';
 import { SelectableValue } from '@grafana/data';
-import { Segment, SegmentAsync } from '@grafana/ui';
+import { MultiSelect, Segment, SegmentAsync } from '@grafana/ui';
 import { CloudWatchQuery, SelectableStrings, CloudWatchMetricsQuery } from '../types';
 import { CloudWatchDatasource } from '../datasource';
 import { Stats, Dimensions, QueryInlineField } from '.';
@@ -14,12 +14,20 @@ export type Props = {
 
 interface State {
   regions: SelectableStrings;
-  namespaces: SelectableStrings;
   metricNames: SelectableStrings;
   variableOptionGroup: SelectableValue<string>;
   showMeta: boolean;
 }
 
+// The dimensions with a wildcard as selected value don't filter the listed metrics
+const withoutWildcards = (dimensions: { [key: string]: string | string[] }) =>
+  Object.entries(dimensions).reduce(
+    (result, [key, value]) => (value === '*' ? result : { ...result, [key]: value }),
+    {}
+  );
+
+const allRegionsOption = { label: 'All regions', value: '*' };
+
 export function MetricsQueryFieldsEditor({
   query,
   datasource,
@@ -30,7 +38,6 @@ export function MetricsQueryFieldsEditor({
 
   const [state, setState] = useState<State>({
     regions: [],
-    namespaces: [],
     metricNames: [],
     variableOptionGroup: {},
     showMeta: false,
@@ -42,18 +49,20 @@ export function MetricsQueryFieldsEditor({
       options: datasource.variables.map(toOption),
     };
 
-    Promise.all([datasource.metricFindQuery('regions()'), datasource.metricFindQuery('namespaces()')]).then(
-      ([regions, namespaces]) => {
-        setState({
-          ...state,
-          regions: [...regions, variableOptionGroup],
-          namespaces: [...namespaces, variableOptionGroup],
-          variableOptionGroup,
-        });
-      }
-    );
+    datasource.getRegionSuggestions().then(regions => {
+      setState({
+        ...state,
+        regions: [...regions, variableOptionGroup],
+        variableOptionGroup,
+      });
+    });
   }, []);
 
+  // The namespaces of the region of the query include the custom namespaces of its metrics
+  const loadNamespaces = async () => {
+    return datasource.getNamespaceSuggestions(query.region, metricsQuery.accountId).then(appendTemplateVariables);
+  };
+
   const loadMetricNames = async () => {
     const { namespace, region } = query;
     return datasource.metricFindQuery(`metrics(${namespace},${region})`).then(appendTemplateVariables);
@@ -75,17 +84,13 @@ export function MetricsQueryFieldsEditor({
   // Remove the new dimension key and all dimensions that has a wildcard as selected value
   const loadDimensionValues = (newKey: string) => {
     const { [newKey]: value, ...dim } = metricsQuery.dimensions;
-    const newDimensions = Object.entries(dim).reduce(
-      (result, [key, value]) => (value === '*' ? result : { ...result, [key]: value }),
-      {}
-    );
     return datasource
-      .getDimensionValues(query.region, query.namespace, metricsQuery.metricName, newKey, newDimensions)
+      .getDimensionValueSuggestions(query.region, query.namespace, metricsQuery.metricName, newKey, withoutWildcards(dim))
       .then(values => (values.length ? [{ value: '*', text: '*', label: '*' }, ...values] : values))
       .then(appendTemplateVariables);
   };
 
-  const { regions, namespaces, variableOptionGroup } = state;
+  const { regions, variableOptionGroup } = state;
   return (
     <>
       <QueryInlineField label="Region">
@@ -98,14 +103,31 @@ export function MetricsQueryFieldsEditor({
         />
       </QueryInlineField>
 
+      <QueryInlineField
+        label="Regions"
+        tooltip="Sends the query to several regions at once, its series are tagged with their region. The metrics and dimensions are listed in the region above."
+      >
+        <MultiSelect
+          className="width-30"
+          options={[allRegionsOption, ...regions]}
+          value={(metricsQuery.regions || []).map(value => (value === '*' ? allRegionsOption : toOption(value)))}
+          onChange={(values: SelectableStrings) =>
+            onQueryChange({ ...metricsQuery, regions: values.map(({ value }) => value!) })
+          }
+          placeholder="Region of the query"
+          closeMenuOnSelect={false}
+          isClearable={true}
+        />
+      </QueryInlineField>
+
       {query.expression.length === 0 && (
         <>
           <QueryInlineField label="Namespace">
-            <Segment
+            <SegmentAsync
               value={query.namespace}
               placeholder="Select namespace"
               allowCustomValue
-              options={namespaces}
+              loadOptions={loadNamespaces}
               onChange={({ value: namespace }) => onQueryChange({ ...query, namespace })}
             />
           </QueryInlineField>
@@ -133,7 +155,16 @@ export function MetricsQueryFieldsEditor({
             <Dimensions
               dimensions={metricsQuery.dimensions}
               onChange={dimensions => onQueryChange({ ...metricsQuery, dimensions })}
-              loadKeys={() => datasource.getDimensionKeys(query.namespace, query.region).then(appendTemplateVariables)}
+              loadKeys={() =>
+                datasource
+                  .getDimensionKeySuggestions(
+                    query.namespace,
+                    query.region,
+                    metricsQuery.metricName,
+                    withoutWildcards(metricsQuery.dimensions)
+                  )
+                  .then(appendTemplateVariables)
+              }
               loadValues={loadDimensionValues}
             />
           </QueryInlineField>
@@ -13,6 +13,7 @@ import {
   DataSourceApi,
   DataSourceInstanceSettings,
   dateMath,
+  FieldType,
   LoadingState,
   LogRowModel,
   ScopedVars,
@@ -25,6 +26,7 @@ import { TimeSrv } from 'app/features/dashboard/services/TimeSrv';
 import { ThrottlingErrorMessage } from './components/ThrottlingErrorMessage';
 import memoizedDebounce from './memoizedDebounce';
 import {
+  AnomalyDetectionBand,
   CloudWatchJsonData,
   CloudWatchLogsQuery,
   CloudWatchLogsQueryStatus,
@@ -161,6 +163,12 @@ export class CloudWatchDatasource extends DataSourceApi<CloudWatchQuery, CloudWa
           item.period = String(this.getPeriod(item, options)); // use string format for period in graph query, and alerting
           item.id = this.templateSrv.replace(item.id, options.scopedVars);
           item.expression = this.templateSrv.replace(item.expression, options.scopedVars);
+          if (item.accountId) {
+            item.accountId = this.templateSrv.replace(item.accountId, options.scopedVars);
+          }
+          if (item.regions?.length) {
+            item.regions = this.replaceRegions(item.regions, options.scopedVars);
+          }
 
           // valid ExtendedStatistics is like p90.00, check the pattern
           const hasInvalidStatistics = item.statistics.some(s => {
@@ -483,6 +491,7 @@ export class CloudWatchDatasource extends DataSourceApi<CloudWatchQuery, CloudWa
                     refId: queryRequest.refId,
                     meta: queryResult.meta,
                   });
+                  setAnomalyDetectionBandOverride(dataFrame, name, queryResult.meta.anomalyDetectionBands);
                   if (link) {
                     for (const field of dataFrame.fields) {
                       field.config.links = [
@@ -503,7 +512,7 @@ export class CloudWatchDatasource extends DataSourceApi<CloudWatchQuery, CloudWa
         );
       })
       .catch((err: any = { data: { error: '' } }) => {
-        if (/^Throttling:.*/.test(err.data.message)) {
+        if (/^(Throttling:|throttled by AWS)/.test(err.data.message)) {
           const failedRedIds = Object.keys(err.data.results);
           const regionsAffected = Object.values(request.queries).reduce(
             (res: string[], { refId, region }) =>
@@ -611,8 +620,10 @@ export class CloudWatchDatasource extends DataSourceApi<CloudWatchQuery, CloudWa
     ]);
   }
 
-  getNamespaces() {
-    return this.doMetricQueryRequest('namespaces', null);
+  getNamespaces(region?: string) {
+    return this.doMetricQueryRequest('namespaces', {
+      region: this.templateSrv.replace(this.getActualRegion(region)),
+    });
   }
 
   async getMetrics(namespace: string, region?: string) {
@@ -659,6 +670,59 @@ export class CloudWatchDatasource extends DataSourceApi<CloudWatchQuery, CloudWa
     return values;
   }
 
+  // The regions, namespaces, dimension keys and values of the query editor are listed by the backend, which caches them
+  async getRegionSuggestions() {
+    return this.getResourceSuggestions('regions', {});
+  }
+
+  async getNamespaceSuggestions(region: string, accountId?: string) {
+    return this.getResourceSuggestions('namespaces', {
+      region: this.templateSrv.replace(this.getActualRegion(region)),
+      accountId: accountId ? this.templateSrv.replace(accountId) : undefined,
+    });
+  }
+
+  async getDimensionKeySuggestions(namespace: string, region: string, metricName?: string, filterDimensions = {}) {
+    if (!namespace) {
+      return [];
+    }
+
+    return this.getResourceSuggestions('dimension-keys', {
+      region: this.templateSrv.replace(this.getActualRegion(region)),
+      namespace: this.templateSrv.replace(namespace),
+      metricName: metricName ? this.templateSrv.replace(metricName.trim()) : undefined,
+      dimensions: JSON.stringify(this.convertDimensionFormat(filterDimensions, {})),
+    });
+  }
+
+  async getDimensionValueSuggestions(
+    region: string,
+    namespace: string,
+    metricName: string,
+    dimensionKey: string,
+    filterDimensions: {}
+  ) {
+    if (!namespace || !dimensionKey) {
+      return [];
+    }
+
+    return this.getResourceSuggestions('dimension-values', {
+      region: this.templateSrv.replace(this.getActualRegion(region)),
+      namespace: this.templateSrv.replace(namespace),
+      metricName: metricName ? this.templateSrv.replace(metricName.trim()) : undefined,
+      dimensionKey: this.templateSrv.replace(dimensionKey),
+      dimensions: JSON.stringify(this.convertDimensionFormat(filterDimensions, {})),
+    });
+  }
+
+  async getResourceSuggestions(path: string, params: Record<string, string | undefined>) {
+    const suggestions: Array<{ text: string; value: string }> = await getBackendSrv().get(
+      `/api/datasources/${this.id}/resources/${path}`,
+      params
+    );
+    return suggestions.map(({ text, value }) => ({ text, value, label: text }));
+  }
+
   getEbsVolumeIds(region: string, instanceId: string) {
     return this.doMetricQueryRequest('ebs_volume_ids', {
       region: this.templateSrv.replace(this.getActualRegion(region)),
@@ -682,6 +746,20 @@ export class CloudWatchDatasource extends DataSourceApi<CloudWatchQuery, CloudWa
     });
   }
 
+  getResourceIds(region: string, resourceType: string, tags: any) {
+    return this.doMetricQueryRequest('resource_ids', {
+      region: this.templateSrv.replace(this.getActualRegion(region)),
+      resourceType: this.templateSrv.replace(resourceType),
+      tags: tags,
+    });
+  }
+
+  getAccounts(region: string) {
+    return this.doMetricQueryRequest('accounts', {
+      region: this.templateSrv.replace(this.getActualRegion(region)),
+    });
+  }
+
   async metricFindQuery(query: string) {
     let region;
     let namespace;
@@ -693,9 +771,9 @@ export class CloudWatchDatasource extends DataSourceApi<CloudWatchQuery, CloudWa
       return this.getRegions();
     }
 
-    const namespaceQuery = query.match(/^namespaces\(\)/);
+    const namespaceQuery = query.match(/^namespaces\(\s*([^,\)]*?)\s*\)/);
     if (namespaceQuery) {
-      return this.getNamespaces();
+      return this.getNamespaces(namespaceQuery[1]);
     }
 
     const metricNameQuery = query.match(/^metrics\(([^\)]+?)(,\s?([^,]+?))?\)/);
@@ -751,6 +829,19 @@ export class CloudWatchDatasource extends DataSourceApi<CloudWatchQuery, CloudWa
       return this.getResourceARNs(region, resourceType, tagsJSON);
     }
 
+    const resourceIdsQuery = query.match(/^resource_ids\(([^,]+?),\s?([^,]+?),\s?(.+?)\)/);
+    if (resourceIdsQuery) {
+      region = resourceIdsQuery[1];
+      const resourceType = resourceIdsQuery[2];
+      const tagsJSON = JSON.parse(this.templateSrv.replace(resourceIdsQuery[3]));
+      return this.getResourceIds(region, resourceType, tagsJSON);
+    }
+
+    const accountsQuery = query.match(/^accounts\(([^\)]*?)\)/);
+    if (accountsQuery) {
+      return this.getAccounts(accountsQuery[1]);
+    }
+
     const statsQuery = query.match(/^statistics\(\)/);
     if (statsQuery) {
       return this.standardStatistics.map((s: string) => ({ value: s, label: s, text: s }));
@@ -775,6 +866,8 @@ export class CloudWatchDatasource extends DataSourceApi<CloudWatchQuery, CloudWa
       period: period,
       actionPrefix: annotation.actionPrefix || '',
       alarmNamePrefix: annotation.alarmNamePrefix || '',
+      alarmType: annotation.alarmType || 'metric',
+      regions: this.replaceRegions(annotation.regions),
     };
 
     return this.awsRequest(TSDB_QUERY_ENDPOINT, {
@@ -793,12 +886,20 @@ export class CloudWatchDatasource extends DataSourceApi<CloudWatchQuery, CloudWa
         annotation: annotation,
         time: Date.parse(v[0]),
         title: v[1],
-        tags: [v[2]],
+        tags: v[4] ? [v[2], v[4]] : [v[2]],
         text: v[3],
       }));
     });
   }
 
+  // the regions of multi-value variables are all queried, the wildcard of all the regions is kept
+  replaceRegions(regions: string[] = [], scopedVars: ScopedVars = {}): string[] {
+    const replaced = _.flatMap(regions, region =>
+      this.templateSrv.replace(this.getActualRegion(region), scopedVars, 'pipe').split('|')
+    );
+    return _.uniq(replaced.filter(region => region));
+  }
+
   targetContainsTemplate(target: any) {
     return (
       this.templateSrv.variableExists(target.region) ||
@@ -929,3 +1030,25 @@ function parseLogGroupName(logIdentifier: string): string {
   const colonIndex = logIdentifier.lastIndexOf(':');
   return logIdentifier.substr(colonIndex + 1);
 }
+
+// setAnomalyDetectionBandOverride makes the graph panel fill the area between the upper and lower series of
+// an anomaly detection band, without drawing their lines
+export function setAnomalyDetectionBandOverride(frame: DataFrame, name: string, bands: AnomalyDetectionBand[] = []) {
+  let seriesOverride: any;
+  for (const band of bands) {
+    if (band.upper === name) {
+      seriesOverride = { fillBelowTo: band.lower, lines: false };
+    } else if (band.lower === name) {
+      seriesOverride = { lines: false };
+    }
+  }
+  if (!seriesOverride) {
+    return;
+  }
+
+  for (const field of frame.fields) {
+    if (field.type === FieldType.number) {
+      field.config.custom = { ...field.config.custom, seriesOverride };
+    }
+  }
+}
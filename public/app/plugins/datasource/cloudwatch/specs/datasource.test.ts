@@ -1,7 +1,14 @@
 import '../datasource';
-import { CloudWatchDatasource, MAX_ATTEMPTS } from '../datasource';
+import { CloudWatchDatasource, MAX_ATTEMPTS, setAnomalyDetectionBandOverride } from '../datasource';
 import * as redux from 'app/store/store';
-import { DataFrame, DataQueryResponse, DataSourceInstanceSettings, dateMath, getFrameDisplayName } from '@grafana/data';
+import {
+  DataFrame,
+  DataQueryResponse,
+  DataSourceInstanceSettings,
+  dateMath,
+  getFrameDisplayName,
+  toDataFrame,
+} from '@grafana/data';
 import { TemplateSrv } from 'app/features/templating/template_srv';
 import { CloudWatchLogsQueryStatus, CloudWatchMetricsQuery, CloudWatchQuery, LogAction } from '../types';
 import { backendSrv } from 'app/core/services/backend_srv'; // will use the version in __mocks__
@@ -328,6 +335,11 @@ describe('CloudWatchDatasource', () => {
       );
     });
 
+    it('should send the regions of the query once each', async () => {
+      await ctx.ds.query({ ...query, targets: [{ ...query.targets[0], regions: ['default', 'us-east-1', '*'] }] });
+      expect(datasourceRequestMock.mock.calls[0][0].data.queries[0].regions).toEqual(['us-east-1', '*']);
+    });
+
     it('should generate the correct query with interval variable', async () => {
       const period: CustomVariableModel = {
         id: 'period',
@@ -617,6 +629,50 @@ describe('CloudWatchDatasource', () => {
     });
   });
 
+  describe('When performing CloudWatch annotation query across regions', () => {
+    const annotation = {
+      region: 'default',
+      regions: ['default', 'eu-west-1', 'us-east-1'],
+      alarmType: 'composite',
+      alarmNamePrefix: 'service-',
+      statistics: [],
+      dimensions: {},
+    };
+
+    beforeEach(() => {
+      datasourceRequestMock.mockImplementation(() =>
+        Promise.resolve({
+          data: {
+            results: {
+              annotationQuery: {
+                tables: [
+                  {
+                    rows: [
+                      ['2016-12-31T15:00:00Z', 'service-down', 'StateUpdate', 'Alarm updated', 'eu-west-1'],
+                      ['2016-12-31T15:30:00Z', 'service-down', 'StateUpdate', 'Alarm updated', ''],
+                    ],
+                  },
+                ],
+              },
+            },
+          },
+        })
+      );
+    });
+
+    it('should query the alarms of each region once', async () => {
+      await ctx.ds.annotationQuery({ annotation, range: defaultTimeRange });
+      const query = datasourceRequestMock.mock.calls[0][0].data.queries[0];
+      expect(query.alarmType).toBe('composite');
+      expect(query.regions).toEqual(['us-east-1', 'eu-west-1']);
+    });
+
+    it('should tag the annotations with their region', async () => {
+      const annotations = await ctx.ds.annotationQuery({ annotation, range: defaultTimeRange });
+      expect(annotations.map((a: any) => a.tags)).toEqual([['StateUpdate', 'eu-west-1'], ['StateUpdate']]);
+    });
+  });
+
   describe('When performing CloudWatch query for extended statistics', () => {
     const query = {
       range: defaultTimeRange,
@@ -947,6 +1003,24 @@ describe('CloudWatchDatasource', () => {
     });
   });
 
+  describeMetricFindQuery('namespaces(us-east-2)', async (scenario: any) => {
+    await scenario.setup(() => {
+      scenario.requestResponse = {
+        results: {
+          metricFindQuery: {
+            tables: [{ rows: [['MyApp', 'MyApp']] }],
+          },
+        },
+      };
+    });
+
+    it('should call __GetNamespaces with the region and return result', () => {
+      expect(scenario.result[0].text).toContain('MyApp');
+      expect(scenario.request.queries[0].subtype).toBe('namespaces');
+      expect(scenario.request.queries[0].region).toBe('us-east-2');
+    });
+  });
+
   describeMetricFindQuery('metrics(AWS/EC2, us-east-2)', async (scenario: any) => {
     await scenario.setup(() => {
       scenario.requestResponse = {
@@ -1048,6 +1122,45 @@ describe('CloudWatchDatasource', () => {
       });
     }
   );
+
+  describeMetricFindQuery('resource_ids(default,rds:cluster,{"environment":"production"})', async (scenario: any) => {
+    await scenario.setup(() => {
+      scenario.requestResponse = {
+        results: {
+          metricFindQuery: {
+            tables: [{ rows: [['orders', 'payments']] }],
+          },
+        },
+      };
+    });
+
+    it('should call resource_ids and return result', () => {
+      expect(scenario.result[0].text).toBe('orders');
+      expect(scenario.request.queries[0].subtype).toBe('resource_ids');
+      expect(scenario.request.queries[0].tags).toEqual({ environment: 'production' });
+    });
+  });
+});
+
+describe('setAnomalyDetectionBandOverride', () => {
+  const bands = [{ upper: 'expected Upper', lower: 'expected Lower' }];
+  const frameOf = (name: string) => toDataFrame({ target: name, datapoints: [[1, 1000]] });
+
+  it('should fill the area below the upper series to the lower series', () => {
+    const upper = frameOf('expected Upper');
+    setAnomalyDetectionBandOverride(upper, 'expected Upper', bands);
+    expect(upper.fields[1].config.custom.seriesOverride).toEqual({ fillBelowTo: 'expected Lower', lines: false });
+
+    const lower = frameOf('expected Lower');
+    setAnomalyDetectionBandOverride(lower, 'expected Lower', bands);
+    expect(lower.fields[1].config.custom.seriesOverride).toEqual({ lines: false });
+  });
+
+  it('should leave the other series alone', () => {
+    const frame = frameOf('CPUUtilization');
+    setAnomalyDetectionBandOverride(frame, 'CPUUtilization', bands);
+    expect(frame.fields.every(field => field.config.custom === undefined)).toBe(true);
+  });
 });
 
 function genMockFrames(numResponses: number): DataFrame[] {
@@ -16,6 +16,10 @@ export interface CloudWatchMetricsQuery extends DataQuery {
   period: string;
   alias: string;
   matchExact: boolean;
+  // the source account of the metrics, when they're queried from a monitoring account
+  accountId?: string;
+  // the regions the query is sent to besides its region, * for all the regions
+  regions?: string[];
 }
 
 export type LogAction =
@@ -48,10 +52,13 @@ export interface CloudWatchLogsQuery extends DataQuery {
 
 export type CloudWatchQuery = CloudWatchMetricsQuery | CloudWatchLogsQuery;
 
+export type AlarmType = 'metric' | 'composite';
+
 export interface AnnotationQuery extends CloudWatchMetricsQuery {
   prefixMatching: boolean;
   actionPrefix: string;
   alarmNamePrefix: string;
+  alarmType?: AlarmType;
 }
 
 export type SelectableStrings = Array<SelectableValue<string>>;
@@ -59,7 +66,21 @@ export type SelectableStrings = Array<SelectableValue<string>>;
 export interface CloudWatchJsonData extends DataSourceJsonData {
   timeField?: string;
   assumeRoleArn?: string;
+  assumeRoleDuration?: string;
   externalId?: string;
+  intermediateRoleArn?: string;
+  intermediateRoleExternalId?: string;
+  webIdentityRoleArn?: string;
+  webIdentityTokenFile?: string;
+  stsEndpoint?: string;
+  useFipsEndpoint?: boolean;
+  endpoint?: string;
+  // Most AWS API calls in flight at once, the limit of the server configuration when empty
+  maxConcurrentCalls?: string;
+  // How long the GetMetricData responses are cached, like 5m, not cached when empty
+  metricDataCacheTTL?: string;
+  // List only the metrics published in the past three hours
+  listMetricsRecentlyActive?: boolean;
   database?: string;
   customMetricsNamespaces?: string;
 }
@@ -194,6 +215,12 @@ export interface TSDBTimeSeries {
 }
 export type TSDBTimePoint = [number, number];
 
+// AnomalyDetectionBand holds the names of the upper and lower series of an ANOMALY_DETECTION_BAND expression
+export interface AnomalyDetectionBand {
+  upper: string;
+  lower: string;
+}
+
 export interface LogGroup {
   /**
    * The name of the log group.
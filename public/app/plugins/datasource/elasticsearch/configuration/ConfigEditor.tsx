@@ -1,6 +1,7 @@
 import React, { useEffect } from 'react';
 import { DataSourceHttpSettings } from '@grafana/ui';
 import { DataSourcePluginOptionsEditorProps } from '@grafana/data';
+import { SigV4AuthSettings } from 'app/features/datasources/settings/SigV4AuthSettings';
 import { ElasticsearchOptions } from '../types';
 import { defaultMaxConcurrentShardRequests, ElasticDetails } from './ElasticDetails';
 import { LogsConfig } from './LogsConfig';
@@ -36,6 +37,14 @@ export const ConfigEditor = (props: Props) => {
         onChange={onOptionsChange}
       />
 
+      {options.access === 'proxy' && (
+        <SigV4AuthSettings
+          {...props}
+          defaultService="es"
+          serviceTooltip="Service of the signature, es for Amazon OpenSearch Service"
+        />
+      )}
+
       <ElasticDetails value={options} onChange={onOptionsChange} />
 
       <LogsConfig
@@ -1,6 +1,7 @@
-import { DataQuery, DataSourceJsonData } from '@grafana/data';
+import { DataQuery } from '@grafana/data';
+import { SigV4Options } from 'app/features/datasources/settings/SigV4AuthSettings';
 
-export interface ElasticsearchOptions extends DataSourceJsonData {
+export interface ElasticsearchOptions extends SigV4Options {
   timeField: string;
   esVersion: number;
   interval: string;
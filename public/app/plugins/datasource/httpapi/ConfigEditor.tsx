@@ -0,0 +1,28 @@
+import React from 'react';
+import { DataSourcePluginOptionsEditorProps } from '@grafana/data';
+import { DataSourceHttpSettings } from '@grafana/ui';
+import { SigV4AuthSettings } from 'app/features/datasources/settings/SigV4AuthSettings';
+import { HttpApiOptions, HttpApiSecureOptions } from './types';
+
+export type Props = DataSourcePluginOptionsEditorProps<HttpApiOptions, HttpApiSecureOptions>;
+
+export const ConfigEditor: React.FC<Props> = props => {
+  const { options, onOptionsChange } = props;
+
+  return (
+    <>
+      <DataSourceHttpSettings
+        defaultUrl="http://localhost:8080"
+        dataSourceConfig={options}
+        showAccessOptions={false}
+        onChange={onOptionsChange}
+      />
+
+      <SigV4AuthSettings
+        {...props}
+        defaultService="execute-api"
+        serviceTooltip="Service of the signature, execute-api for API Gateway"
+      />
+    </>
+  );
+};
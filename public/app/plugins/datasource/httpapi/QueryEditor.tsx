@@ -0,0 +1,231 @@
+import React from 'react';
+import { QueryEditorProps, SelectableValue } from '@grafana/data';
+import { Button, IconButton, InlineFormLabel, LegacyForms, TextArea } from '@grafana/ui';
+import { HttpApiDatasource } from './datasource';
+import { HttpApiField, HttpApiKeyValue, HttpApiOptions, HttpApiQuery } from './types';
+const { Input, Select } = LegacyForms;
+
+type Props = QueryEditorProps<HttpApiDatasource, HttpApiQuery, HttpApiOptions>;
+
+const methodOptions: Array<SelectableValue<string>> = [
+  { label: 'GET', value: 'GET' },
+  { label: 'POST', value: 'POST' },
+];
+
+const formatOptions: Array<SelectableValue<string>> = [
+  { label: 'Auto', value: '', description: 'Detected from the content type of the response' },
+  { label: 'JSON', value: 'json' },
+  { label: 'CSV', value: 'csv' },
+];
+
+const fieldTypeOptions: Array<SelectableValue<string>> = [
+  { label: 'Auto', value: '', description: 'Inferred from the values' },
+  { label: 'Number', value: 'number' },
+  { label: 'String', value: 'string' },
+  { label: 'Boolean', value: 'boolean' },
+  { label: 'Time', value: 'time', description: 'Epoch seconds or milliseconds, or RFC 3339 times' },
+];
+
+interface KeyValueListProps {
+  label: string;
+  items: HttpApiKeyValue[];
+  onChange: (items: HttpApiKeyValue[]) => void;
+  onBlur: () => void;
+}
+
+const KeyValueList: React.FC<KeyValueListProps> = ({ label, items, onChange, onBlur }) => {
+  const update = (index: number, item: HttpApiKeyValue) => onChange(items.map((v, i) => (i === index ? item : v)));
+  return (
+    <>
+      {items.map((item, index) => (
+        <div className="gf-form-inline" key={index}>
+          <div className="gf-form">
+            <InlineFormLabel width={8}>{index === 0 ? label : ''}</InlineFormLabel>
+            <Input
+              className="width-14"
+              placeholder="Key"
+              value={item.key}
+              onChange={e => update(index, { ...item, key: e.currentTarget.value })}
+              onBlur={onBlur}
+            />
+            <Input
+              className="width-20"
+              placeholder="Value"
+              value={item.value}
+              onChange={e => update(index, { ...item, value: e.currentTarget.value })}
+              onBlur={onBlur}
+            />
+            <IconButton name="trash-alt" onClick={() => onChange(items.filter((_, i) => i !== index))} />
+          </div>
+        </div>
+      ))}
+    </>
+  );
+};
+
+export const QueryEditor: React.FC<Props> = ({ query, onChange, onRunQuery }) => {
+  const params = query.params || [];
+  const headers = query.headers || [];
+  const fields = query.fields || [];
+  const format = query.format || '';
+
+  const update = (changes: Partial<HttpApiQuery>, run = true) => {
+    onChange({ ...query, ...changes });
+    if (run) {
+      onRunQuery();
+    }
+  };
+  const updateField = (index: number, field: HttpApiField, run = false) =>
+    update({ fields: fields.map((f, i) => (i === index ? field : f)) }, run);
+
+  return (
+    <>
+      <div className="gf-form-inline">
+        <div className="gf-form">
+          <InlineFormLabel width={8}>Method</InlineFormLabel>
+          <Select
+            width={10}
+            options={methodOptions}
+            value={methodOptions.find(o => o.value === (query.method || 'GET'))}
+            onChange={o => update({ method: o.value as HttpApiQuery['method'] })}
+          />
+        </div>
+        <div className="gf-form gf-form--grow">
+          <InlineFormLabel width={6} tooltip="Path of the endpoint relative to the URL of the data source">
+            Path
+          </InlineFormLabel>
+          <Input
+            className="gf-form-input"
+            placeholder="/api/items?status=active"
+            value={query.path || ''}
+            onChange={e => update({ path: e.currentTarget.value }, false)}
+            onBlur={onRunQuery}
+          />
+        </div>
+      </div>
+
+      <KeyValueList
+        label="Params"
+        items={params}
+        onChange={items => update({ params: items }, false)}
+        onBlur={onRunQuery}
+      />
+      <KeyValueList
+        label="Headers"
+        items={headers}
+        onChange={items => update({ headers: items }, false)}
+        onBlur={onRunQuery}
+      />
+      <div className="gf-form-inline">
+        <div className="gf-form">
+          <InlineFormLabel width={8}> </InlineFormLabel>
+          <Button
+            variant="secondary"
+            size="sm"
+            icon="plus"
+            onClick={() => update({ params: [...params, { key: '', value: '' }] }, false)}
+          >
+            Param
+          </Button>
+        </div>
+        <div className="gf-form">
+          <Button
+            variant="secondary"
+            size="sm"
+            icon="plus"
+            onClick={() => update({ headers: [...headers, { key: '', value: '' }] }, false)}
+          >
+            Header
+          </Button>
+        </div>
+      </div>
+
+      {query.method === 'POST' && (
+        <div className="gf-form">
+          <InlineFormLabel width={8} tooltip="$__from and $__to are the time range in epoch milliseconds">
+            Body
+          </InlineFormLabel>
+          <TextArea
+            rows={4}
+            value={query.body || ''}
+            onChange={e => update({ body: e.currentTarget.value }, false)}
+            onBlur={onRunQuery}
+          />
+        </div>
+      )}
+
+      <div className="gf-form-inline">
+        <div className="gf-form">
+          <InlineFormLabel width={8}>Format</InlineFormLabel>
+          <Select
+            width={10}
+            options={formatOptions}
+            value={formatOptions.find(o => o.value === format)}
+            onChange={o => update({ format: (o.value || undefined) as HttpApiQuery['format'] })}
+          />
+        </div>
+        {format !== 'csv' && (
+          <div className="gf-form gf-form--grow">
+            <InlineFormLabel width={6} tooltip="Path of the rows in the JSON of the response, an array or an object">
+              Rows
+            </InlineFormLabel>
+            <Input
+              className="gf-form-input"
+              placeholder="$.data.items"
+              value={query.rootPath || ''}
+              onChange={e => update({ rootPath: e.currentTarget.value }, false)}
+              onBlur={onRunQuery}
+            />
+          </div>
+        )}
+      </div>
+
+      {fields.map((field, index) => (
+        <div className="gf-form-inline" key={index}>
+          <div className="gf-form">
+            <InlineFormLabel
+              width={8}
+              tooltip={index === 0 ? 'Fields of the frame, all the values of the rows when there are none' : undefined}
+            >
+              {index === 0 ? 'Fields' : ''}
+            </InlineFormLabel>
+            <Input
+              className="width-12"
+              placeholder="Name"
+              value={field.name}
+              onChange={e => updateField(index, { ...field, name: e.currentTarget.value })}
+              onBlur={onRunQuery}
+            />
+            <Input
+              className="width-20"
+              placeholder={format === 'csv' ? 'Column' : 'Path, like $.reading.value'}
+              value={field.path || ''}
+              onChange={e => updateField(index, { ...field, path: e.currentTarget.value })}
+              onBlur={onRunQuery}
+            />
+            <Select
+              width={12}
+              options={fieldTypeOptions}
+              value={fieldTypeOptions.find(o => o.value === (field.type || ''))}
+              onChange={o =>
+                updateField(index, { ...field, type: (o.value || undefined) as HttpApiField['type'] }, true)
+              }
+            />
+            <IconButton name="trash-alt" onClick={() => update({ fields: fields.filter((_, i) => i !== index) })} />
+          </div>
+        </div>
+      ))}
+      <div className="gf-form">
+        <InlineFormLabel width={8}> </InlineFormLabel>
+        <Button
+          variant="secondary"
+          size="sm"
+          icon="plus"
+          onClick={() => update({ fields: [...fields, { name: '' }] }, false)}
+        >
+          Field
+        </Button>
+      </div>
+    </>
+  );
+};
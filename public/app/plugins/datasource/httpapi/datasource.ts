@@ -0,0 +1,30 @@
+import { DataSourceInstanceSettings, ScopedVars } from '@grafana/data';
+import { DataSourceWithBackend, getTemplateSrv } from '@grafana/runtime';
+import { HttpApiOptions, HttpApiQuery } from './types';
+
+export class HttpApiDatasource extends DataSourceWithBackend<HttpApiQuery, HttpApiOptions> {
+  constructor(instanceSettings: DataSourceInstanceSettings<HttpApiOptions>) {
+    super(instanceSettings);
+  }
+
+  filterQuery(query: HttpApiQuery): boolean {
+    return !query.hide;
+  }
+
+  // The backend replaces $__from and $__to with the time range of the query
+  applyTemplateVariables(query: HttpApiQuery, scopedVars: ScopedVars): Record<string, any> {
+    const templateSrv = getTemplateSrv();
+    const replace = (value?: string) => (value ? templateSrv.replace(value, scopedVars) : value);
+    return {
+      ...query,
+      path: replace(query.path),
+      params: query.params?.map(param => ({ ...param, value: replace(param.value) })),
+      headers: query.headers?.map(header => ({ ...header, value: replace(header.value) })),
+      body: replace(query.body),
+    };
+  }
+
+  getQueryDisplayText(query: HttpApiQuery) {
+    return `${query.method || 'GET'} ${query.path || '/'}`;
+  }
+}
@@ -0,0 +1,8 @@
+import { DataSourcePlugin } from '@grafana/data';
+import { HttpApiDatasource } from './datasource';
+import { ConfigEditor } from './ConfigEditor';
+import { QueryEditor } from './QueryEditor';
+
+export const plugin = new DataSourcePlugin(HttpApiDatasource)
+  .setConfigEditor(ConfigEditor)
+  .setQueryEditor(QueryEditor);
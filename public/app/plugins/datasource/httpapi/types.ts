@@ -0,0 +1,35 @@
+import { DataQuery } from '@grafana/data';
+import { SigV4Options, SigV4SecureOptions } from 'app/features/datasources/settings/SigV4AuthSettings';
+
+export type HttpApiFieldType = 'number' | 'string' | 'boolean' | 'time';
+
+export interface HttpApiKeyValue {
+  key: string;
+  value: string;
+}
+
+export interface HttpApiField {
+  name: string;
+  // Path of the value in a JSON row, like $.sensor.readings[0].value, or the column of the CSV
+  path?: string;
+  // Inferred from the values when empty
+  type?: HttpApiFieldType;
+}
+
+export interface HttpApiQuery extends DataQuery {
+  // Path of the endpoint relative to the URL of the data source
+  path?: string;
+  method?: 'GET' | 'POST';
+  params?: HttpApiKeyValue[];
+  headers?: HttpApiKeyValue[];
+  body?: string;
+  // Detected from the response when empty
+  format?: 'json' | 'csv';
+  // Path of the rows in the JSON of the response
+  rootPath?: string;
+  fields?: HttpApiField[];
+}
+
+export type HttpApiOptions = SigV4Options;
+
+export type HttpApiSecureOptions = SigV4SecureOptions;
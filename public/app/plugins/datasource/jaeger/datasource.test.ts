@@ -1,7 +1,20 @@
 import { JaegerDatasource, JaegerQuery } from './datasource';
-import { DataQueryRequest, DataSourceInstanceSettings, FieldType, PluginType, dateTime } from '@grafana/data';
+import {
+  DataQueryRequest,
+  DataSourceInstanceSettings,
+  FieldType,
+  MutableDataFrame,
+  PluginType,
+  dateTime,
+} from '@grafana/data';
 import { BackendSrv, BackendSrvRequest, getBackendSrv, setBackendSrv } from '@grafana/runtime';
 
+jest.mock('@grafana/runtime', () => ({
+  ...jest.requireActual('@grafana/runtime'),
+  // the trace frames are arrow encoded by the backend
+  toDataQueryResponse: (response: any) => ({ data: response.data.frames }),
+}));
+
 describe('JaegerDatasource', () => {
   it('returns trace when queried', async () => {
     await withMockedBackendSrv(makeBackendSrvMock('12345'), async () => {
@@ -10,8 +23,10 @@ describe('JaegerDatasource', () => {
       const field = response.data[0].fields[0];
       expect(field.name).toBe('trace');
       expect(field.type).toBe(FieldType.trace);
-      expect(field.values.get(0)).toEqual({
-        traceId: '12345',
+      expect(field.values.get(0)).toMatchObject({
+        traceID: '12345',
+        processes: { api: { serviceName: 'api', tags: [] } },
+        spans: [{ spanID: '1', processID: 'api', operationName: 'get /api', startTime: 1000, duration: 2500 }],
       });
     });
   });
@@ -34,13 +49,27 @@ describe('JaegerDatasource', () => {
 function makeBackendSrvMock(traceId: string) {
   return {
     datasourceRequest(options: BackendSrvRequest): Promise<any> {
-      expect(options.url.substr(options.url.length - 17, options.url.length)).toBe(`/api/traces/${traceId}`);
+      expect(options.url).toBe('/api/tsdb/query');
+      expect(options.data.queries).toEqual([{ query: traceId, refId: '1', datasourceId: 0 }]);
       return Promise.resolve({
         data: {
-          data: [
-            {
-              traceId,
-            },
+          frames: [
+            new MutableDataFrame({
+              name: traceId,
+              meta: { custom: { resultType: 'trace' } },
+              fields: [
+                { name: 'traceID', values: [traceId] },
+                { name: 'spanID', values: ['1'] },
+                { name: 'parentSpanID', values: [''] },
+                { name: 'operationName', values: ['get /api'] },
+                { name: 'serviceName', values: ['api'] },
+                { name: 'serviceTags', values: ['[]'] },
+                { name: 'startTime', values: [1] },
+                { name: 'duration', values: [2.5] },
+                { name: 'logs', values: ['[]'] },
+                { name: 'tags', values: ['[]'] },
+              ],
+            }),
           ],
         },
       });
@@ -9,16 +9,26 @@ import {
   DataQuery,
   FieldType,
 } from '@grafana/data';
-import { getBackendSrv } from '@grafana/runtime';
+import { getBackendSrv, toDataQueryResponse } from '@grafana/runtime';
 import { Observable, from, of } from 'rxjs';
 import { map } from 'rxjs/operators';
 
 import { getTimeSrv } from 'app/features/dashboard/services/TimeSrv';
 import { DatasourceRequestOptions } from 'app/core/services/backend_srv';
 import { serializeParams } from 'app/core/utils/fetch';
+import { transformTraceResponse } from 'app/core/utils/tracing';
 
 export type JaegerQuery = {
+  // The trace ID of the trace queries
   query: string;
+  queryType?: 'trace' | 'search';
+  service?: string;
+  operation?: string;
+  // JSON object of the tag values of the spans
+  tags?: string;
+  minDuration?: string;
+  maxDuration?: string;
+  limit?: number;
 } & DataQuery;
 
 export class JaegerDatasource extends DataSourceApi<JaegerQuery> {
@@ -32,47 +42,29 @@ export class JaegerDatasource extends DataSourceApi<JaegerQuery> {
   }
 
   query(options: DataQueryRequest<JaegerQuery>): Observable<DataQueryResponse> {
-    // At this moment we expect only one target. In case we somehow change the UI to be able to show multiple
-    // traces at one we need to change this.
-    const id = options.targets[0]?.query;
-    if (id) {
-      // TODO: this api is internal, used in jaeger ui. Officially they have gRPC api that should be used.
-      return this._request(`/api/traces/${id}`).pipe(
-        map(response => {
-          return {
-            data: [
-              new MutableDataFrame({
-                fields: [
-                  {
-                    name: 'trace',
-                    type: FieldType.trace,
-                    values: response?.data?.data || [],
-                  },
-                ],
-              }),
-            ],
-          };
-        })
-      );
-    } else {
-      return of({
-        data: [
-          new MutableDataFrame({
-            fields: [
-              {
-                name: 'trace',
-                type: FieldType.trace,
-                values: [],
-              },
-            ],
-          }),
-        ],
-      });
+    const targets = options.targets.filter(target => !target.hide && (target.query || target.queryType === 'search'));
+    if (!targets.length) {
+      return of(emptyDataQueryResponse);
     }
+
+    // The backend returns the spans of a trace in a trace frame, which is converted to the trace of the trace view
+    return from(
+      getBackendSrv().datasourceRequest({
+        url: '/api/tsdb/query',
+        method: 'POST',
+        data: {
+          from: options.range.from.valueOf().toString(),
+          to: options.range.to.valueOf().toString(),
+          queries: targets.map(target => ({ ...target, datasourceId: this.instanceSettings.id })),
+        },
+        requestId: options.requestId,
+      })
+    ).pipe(map(response => transformTraceResponse(toDataQueryResponse(response))));
   }
 
   async testDatasource(): Promise<any> {
-    return true;
+    const { message } = await getBackendSrv().get(`/api/datasources/${this.instanceSettings.id}/health`);
+    return { status: 'success', message };
   }
 
   getTimeRange(): { start: number; end: number } {
@@ -107,3 +99,17 @@ function getTime(date: string | DateTime, roundUp: boolean) {
   }
   return date.valueOf() * 1000;
 }
+
+const emptyDataQueryResponse = {
+  data: [
+    new MutableDataFrame({
+      fields: [
+        {
+          name: 'trace',
+          type: FieldType.trace,
+          values: [],
+        },
+      ],
+    }),
+  ],
+};
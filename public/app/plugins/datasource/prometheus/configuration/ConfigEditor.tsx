@@ -1,6 +1,7 @@
 import React from 'react';
 import { DataSourceHttpSettings } from '@grafana/ui';
 import { DataSourcePluginOptionsEditorProps } from '@grafana/data';
+import { SigV4AuthSettings } from 'app/features/datasources/settings/SigV4AuthSettings';
 import { PromSettings } from './PromSettings';
 import { PromOptions } from '../types';
 
@@ -16,6 +17,14 @@ export const ConfigEditor = (props: Props) => {
         onChange={onOptionsChange}
       />
 
+      {options.access === 'proxy' && (
+        <SigV4AuthSettings
+          {...props}
+          defaultService="aps"
+          serviceTooltip="Service of the signature, aps for Amazon Managed Service for Prometheus"
+        />
+      )}
+
       <PromSettings options={options} onOptionsChange={onOptionsChange} />
     </>
   );
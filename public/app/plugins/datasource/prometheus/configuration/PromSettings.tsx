@@ -13,6 +13,11 @@ const httpOptions = [
   { value: 'POST', label: 'POST' },
 ];
 
+const remoteReadResponseTypes = [
+  { value: 'auto', label: 'Streamed chunks' },
+  { value: 'samples', label: 'Samples' },
+];
+
 type Props = Pick<DataSourcePluginOptionsEditorProps<PromOptions>, 'options' | 'onOptionsChange'>;
 
 export const PromSettings = (props: Props) => {
@@ -104,6 +109,56 @@ export const PromSettings = (props: Props) => {
           </div>
         </div>
       </div>
+      <h3 className="page-heading">Remote read</h3>
+      <div className="gf-form-group">
+        <div className="gf-form">
+          <Switch
+            checked={options.jsonData.remoteRead}
+            label="Query with remote read"
+            labelClass="width-14"
+            onChange={onUpdateDatasourceJsonDataOptionChecked(props, 'remoteRead')}
+            tooltip="Read the series of the queries with the remote read protocol, for long-term stores which don't evaluate PromQL. Queries must be series selectors, like up{job=&quot;api&quot;}."
+          />
+        </div>
+        {options.jsonData.remoteRead && (
+          <>
+            <div className="gf-form-inline">
+              <div className="gf-form max-width-30">
+                <FormField
+                  label="Remote read URL"
+                  labelWidth={14}
+                  tooltip="Defaults to the /api/v1/read endpoint of the URL of the data source."
+                  inputEl={
+                    <Input
+                      className="width-25"
+                      value={options.jsonData.remoteReadUrl}
+                      onChange={onChangeHandler('remoteReadUrl', options, onOptionsChange)}
+                      spellCheck={false}
+                      placeholder="http://localhost:9090/api/v1/read"
+                    />
+                  }
+                />
+              </div>
+            </div>
+            <div className="gf-form">
+              <InlineFormLabel
+                width={14}
+                tooltip="Streamed chunks use less memory, stores which can't stream answer with samples. Choose samples for stores whose streaming is broken."
+              >
+                Response type
+              </InlineFormLabel>
+              <Select
+                options={remoteReadResponseTypes}
+                value={remoteReadResponseTypes.find(
+                  o => o.value === (options.jsonData.remoteReadResponseType || 'auto')
+                )}
+                onChange={onChangeHandler('remoteReadResponseType', options, onOptionsChange)}
+                width={16}
+              />
+            </div>
+          </>
+        )}
+      </div>
     </>
   );
 };
@@ -29,6 +29,7 @@ jest.mock('@grafana/runtime', () => ({
   getBackendSrv: () => ({
     datasourceRequest: datasourceRequestMock,
   }),
+  toDataQueryResponse: jest.requireActual('@grafana/runtime').toDataQueryResponse,
 }));
 
 jest.mock('app/features/templating/template_srv', () => {
@@ -133,6 +134,53 @@ describe('PrometheusDatasource', () => {
     });
   });
 
+  describe('Query with remote read', () => {
+    it('sends the queries to the backend and returns its frames', async () => {
+      const remoteReadDs = new PrometheusDatasource({
+        ...instanceSettings,
+        id: 3,
+        jsonData: { remoteRead: true } as any,
+      });
+      datasourceRequestMock.mockImplementationOnce(() =>
+        Promise.resolve({
+          data: {
+            results: {
+              A: {
+                series: [{ name: 'api', points: [[1, 1531468681000]] }],
+              },
+            },
+          },
+        })
+      );
+
+      const target = { expr: 'up{job="api"}', refId: 'A', legendFormat: '{{job}}', interval: '1m' };
+      const response = await remoteReadDs
+        .query({
+          range: { from: time({ seconds: 63 }), to: time({ seconds: 183 }) },
+          targets: [target],
+          interval: '60s',
+          requestId: 'Q1',
+        } as any)
+        .toPromise();
+
+      const request = datasourceRequestMock.mock.calls[0][0];
+      expect(request.url).toBe('/api/tsdb/query');
+      expect(request.data.queries).toEqual([
+        {
+          refId: 'A',
+          datasourceId: 3,
+          expr: 'up{job="api"}',
+          legendFormat: '{{job}}',
+          interval: '1m',
+          intervalFactor: undefined,
+        },
+      ]);
+      expect(response.key).toBe('Q1');
+      expect(response.data).toHaveLength(1);
+      expect(response.data[0].name).toBe('api');
+    });
+  });
+
   describe('Datasource metadata requests', () => {
     it('should perform a GET request with the default config', () => {
       ds.metadataRequest('/foo');
@@ -21,12 +21,12 @@ import {
   TimeSeries,
 } from '@grafana/data';
 import { forkJoin, from, merge, Observable, of } from 'rxjs';
-import { filter, map, tap } from 'rxjs/operators';
+import { catchError, filter, map, tap } from 'rxjs/operators';
 
 import PrometheusMetricFindQuery from './metric_find_query';
 import { ResultTransformer } from './result_transformer';
 import PrometheusLanguageProvider from './language_provider';
-import { getBackendSrv } from '@grafana/runtime';
+import { getBackendSrv, toDataQueryResponse } from '@grafana/runtime';
 import addLabelToQuery from './add_label_to_query';
 import { getQueryHints } from './query_hints';
 import { expandRecordingRules } from './language_utils';
@@ -86,6 +86,7 @@ export class PrometheusDatasource extends DataSourceApi<PromQuery, PromOptions>
   lookupsDisabled: boolean;
   resultTransformer: ResultTransformer;
   customQueryParameters: any;
+  remoteRead: boolean;
 
   constructor(instanceSettings: DataSourceInstanceSettings<PromOptions>) {
     super(instanceSettings);
@@ -104,6 +105,7 @@ export class PrometheusDatasource extends DataSourceApi<PromQuery, PromOptions>
     this.languageProvider = new PrometheusLanguageProvider(this);
     this.lookupsDisabled = instanceSettings.jsonData.disableMetricsLookup;
     this.customQueryParameters = new URLSearchParams(instanceSettings.jsonData.customQueryParameters);
+    this.remoteRead = instanceSettings.jsonData.remoteRead ?? false;
   }
 
   init = () => {
@@ -260,6 +262,10 @@ export class PrometheusDatasource extends DataSourceApi<PromQuery, PromOptions>
       });
     }
 
+    if (this.remoteRead) {
+      return this.remoteReadQuery(queries, activeTargets, options);
+    }
+
     if (options.app === CoreApp.Explore) {
       return this.exploreQuery(queries, activeTargets, end);
     }
@@ -332,6 +338,44 @@ export class PrometheusDatasource extends DataSourceApi<PromQuery, PromOptions>
     );
   }
 
+  // The series of the queries are read with the remote read protocol by the backend, which aligns their
+  // samples to the steps of the queries.
+  private remoteReadQuery(
+    queries: PromQueryRequest[],
+    activeTargets: PromQuery[],
+    options: DataQueryRequest<PromQuery>
+  ): Observable<DataQueryResponse> {
+    const data = {
+      from: options.range.from.valueOf().toString(),
+      to: options.range.to.valueOf().toString(),
+      queries: queries.map((query, index) => ({
+        refId: query.refId,
+        datasourceId: this.id,
+        expr: query.expr,
+        legendFormat: activeTargets[index].legendFormat,
+        interval: templateSrv.replace(activeTargets[index].interval, options.scopedVars),
+        intervalFactor: activeTargets[index].intervalFactor,
+      })),
+    };
+
+    return from(
+      getBackendSrv().datasourceRequest({
+        url: '/api/tsdb/query',
+        method: 'POST',
+        data,
+        requestId: options.requestId,
+      })
+    ).pipe(
+      map((response: any) => ({ ...toDataQueryResponse(response), key: options.requestId })),
+      catchError(err => {
+        if (err.cancelled) {
+          return of({ data: [], key: options.requestId });
+        }
+        throw this.handleErrors(err, queries[0]);
+      })
+    );
+  }
+
   createQuery(target: PromQuery, options: DataQueryRequest<PromQuery>, start: number, end: number) {
     const query: PromQueryRequest = {
       hinting: target.hinting,
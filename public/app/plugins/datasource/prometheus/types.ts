@@ -1,4 +1,5 @@
-import { DataQuery, DataSourceJsonData } from '@grafana/data';
+import { DataQuery } from '@grafana/data';
+import { SigV4Options } from 'app/features/datasources/settings/SigV4AuthSettings';
 
 export interface PromQuery extends DataQuery {
   expr: string;
@@ -14,15 +15,20 @@ export interface PromQuery extends DataQuery {
   showingTable?: boolean;
 }
 
-export interface PromOptions extends DataSourceJsonData {
+export interface PromOptions extends SigV4Options {
   timeInterval: string;
   queryTimeout: string;
   httpMethod: string;
   directUrl: string;
   customQueryParameters?: string;
   disableMetricsLookup?: boolean;
+  remoteRead?: boolean;
+  remoteReadUrl?: string;
+  remoteReadResponseType?: RemoteReadResponseType;
 }
 
+export type RemoteReadResponseType = 'auto' | 'samples';
+
 export interface PromQueryRequest extends PromQuery {
   step?: number;
   requestId?: string;
@@ -21,7 +21,7 @@ import { runStream } from './runStreams';
 import templateSrv from 'app/features/templating/template_srv';
 import { getSearchFilterScopedVar } from 'app/features/variables/utils';
 
-type TestData = TimeSeries | TableData;
+type TestData = TimeSeries | TableData | DataFrame;
 
 export class TestDataDataSource extends DataSourceApi<TestDataQuery> {
   constructor(instanceSettings: DataSourceInstanceSettings) {
@@ -93,6 +93,12 @@ export class TestDataDataSource extends DataSourceApi<TestDataQuery> {
         data.push({ target: series.name, datapoints: series.points, refId: query.refId, tags: series.tags });
       }
 
+      // the frames of the scenarios returning data frames, like CSV Content, are Arrow encoded
+      for (const encoded of results.dataframes || []) {
+        const frame = arrowTableToDataFrame(base64StringToArrowTable(encoded));
+        data.push({ ...frame, refId: query.refId });
+      }
+
       if (results.error) {
         error = {
           message: results.error,
@@ -21,7 +21,9 @@ export const defaultCSVWave: any = {
   valuesCSV: '0,0,2,2,1,1',
 };
 
-const showLabelsFor = ['random_walk', 'predictable_pulse', 'predictable_csv_wave'];
+export const defaultCSVContent = 'time,host,value\n1600000000000,srv-1,1.5\n1600000060000,srv-2,2.5';
+
+const showLabelsFor = ['random_walk', 'predictable_pulse', 'predictable_csv_wave', 'scripted_waveform'];
 
 export class TestDataQueryCtrl extends QueryCtrl {
   static templateUrl = 'partials/query.editor.html';
@@ -113,6 +115,12 @@ export class TestDataQueryCtrl extends QueryCtrl {
       delete this.target.csvWave;
     }
 
+    if (this.target.scenarioId === 'csv_content') {
+      this.target.csvContent = this.target.csvContent || defaultCSVContent;
+    } else {
+      delete this.target.csvContent;
+    }
+
     if (this.target.scenarioId === 'grafana_api') {
       this.target.stringInput = 'datasources';
     } else {
@@ -11,6 +11,11 @@ export interface TestDataQuery extends DataQuery {
   stringInput: string;
   points?: any[];
   stream?: StreamingQuery;
+  csvContent?: string;
+  seed?: number;
+  latency?: string;
+  errorRate?: number;
+  errorMessage?: string;
 }
 
 export interface StreamingQuery {
@@ -1,15 +1,44 @@
 import { ZipkinDatasource, ZipkinQuery } from './datasource';
-import { DataQueryRequest, DataSourceInstanceSettings } from '@grafana/data';
+import { DataQueryRequest, DataSourceInstanceSettings, MutableDataFrame, dateTime } from '@grafana/data';
 import { BackendSrv, BackendSrvRequest, setBackendSrv } from '@grafana/runtime';
-import { jaegerTrace, zipkinResponse } from './utils/testData';
+
+jest.mock('@grafana/runtime', () => ({
+  ...jest.requireActual('@grafana/runtime'),
+  // the trace frames are arrow encoded by the backend
+  toDataQueryResponse: (response: any) => ({ data: response.data.frames }),
+}));
 
 describe('ZipkinDatasource', () => {
   describe('query', () => {
     it('runs query', async () => {
-      setupBackendSrv({ url: '/api/datasources/proxy/1/api/v2/trace/12345', response: zipkinResponse });
+      setupBackendSrv({ url: '/api/tsdb/query', response: { frames: [traceFrame] } });
+      const ds = new ZipkinDatasource(defaultSettings);
+      const response = await ds
+        .query({ targets: [{ query: '12345', refId: 'A' }], range: dateRange } as DataQueryRequest<ZipkinQuery>)
+        .toPromise();
+      expect(response.data[0].fields[0].values.get(0)).toMatchObject({
+        traceID: '12345',
+        processes: {
+          'service 1': { serviceName: 'service 1', tags: [{ key: 'ipv4', type: 'string', value: '1.0.0.1' }] },
+        },
+        spans: [{ spanID: 'span 1 id', processID: 'service 1', startTime: 1000, duration: 10000, references: [] }],
+      });
+    });
+
+    it('returns search results as they are', async () => {
+      const searchFrame = new MutableDataFrame({
+        meta: { custom: { resultType: 'traceSearch' } },
+        fields: [{ name: 'traceID', values: ['12345'] }],
+      });
+      setupBackendSrv({ url: '/api/tsdb/query', response: { frames: [searchFrame] } });
       const ds = new ZipkinDatasource(defaultSettings);
-      const response = await ds.query({ targets: [{ query: '12345' }] } as DataQueryRequest<ZipkinQuery>).toPromise();
-      expect(response.data[0].fields[0].values.get(0)).toEqual(jaegerTrace);
+      const response = await ds
+        .query({
+          targets: [{ query: '', queryType: 'search', service: 'service 1', refId: 'A' }],
+          range: dateRange,
+        } as DataQueryRequest<ZipkinQuery>)
+        .toPromise();
+      expect(response.data[0]).toBe(searchFrame);
     });
   });
 
@@ -42,3 +71,22 @@ const defaultSettings: DataSourceInstanceSettings = {
   meta: {} as any,
   jsonData: {},
 };
+
+const dateRange = { from: dateTime(0), to: dateTime(3600000) };
+
+const traceFrame = new MutableDataFrame({
+  name: '12345',
+  meta: { custom: { resultType: 'trace' } },
+  fields: [
+    { name: 'traceID', values: ['12345'] },
+    { name: 'spanID', values: ['span 1 id'] },
+    { name: 'parentSpanID', values: [''] },
+    { name: 'operationName', values: ['span 1'] },
+    { name: 'serviceName', values: ['service 1'] },
+    { name: 'serviceTags', values: ['[{"key":"ipv4","value":"1.0.0.1"}]'] },
+    { name: 'startTime', values: [1] },
+    { name: 'duration', values: [10] },
+    { name: 'logs', values: ['[]'] },
+    { name: 'tags', values: ['[]'] },
+  ],
+});
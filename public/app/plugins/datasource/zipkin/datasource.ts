@@ -10,15 +10,22 @@ import {
 import { from, Observable, of } from 'rxjs';
 import { DatasourceRequestOptions } from '../../../core/services/backend_srv';
 import { serializeParams } from '../../../core/utils/fetch';
-import { getBackendSrv } from '@grafana/runtime';
+import { getBackendSrv, toDataQueryResponse } from '@grafana/runtime';
 import { map } from 'rxjs/operators';
 import { apiPrefix } from './constants';
-import { ZipkinSpan } from './types';
-import { transformResponse } from './utils/transforms';
+import { transformTraceResponse } from '../../../core/utils/tracing';
 
 export type ZipkinQuery = {
-  // At the moment this should be simply the trace ID to get
+  // The trace ID of the trace queries
   query: string;
+  queryType?: 'trace' | 'search';
+  service?: string;
+  spanName?: string;
+  // Annotations and tags of the spans, like http.path=/api and error
+  annotationQuery?: string;
+  minDuration?: string;
+  maxDuration?: string;
+  limit?: number;
 } & DataQuery;
 
 export class ZipkinDatasource extends DataSourceApi<ZipkinQuery> {
@@ -27,12 +34,24 @@ export class ZipkinDatasource extends DataSourceApi<ZipkinQuery> {
   }
 
   query(options: DataQueryRequest<ZipkinQuery>): Observable<DataQueryResponse> {
-    const traceId = options.targets[0]?.query;
-    if (traceId) {
-      return this.request<ZipkinSpan[]>(`${apiPrefix}/trace/${traceId}`).pipe(map(responseToDataQueryResponse));
-    } else {
+    const targets = options.targets.filter(target => !target.hide && (target.query || target.queryType === 'search'));
+    if (!targets.length) {
       return of(emptyDataQueryResponse);
     }
+
+    // The backend returns the spans of a trace in a trace frame, which is converted to the trace of the trace view
+    return from(
+      getBackendSrv().datasourceRequest({
+        url: '/api/tsdb/query',
+        method: 'POST',
+        data: {
+          from: options.range.from.valueOf().toString(),
+          to: options.range.to.valueOf().toString(),
+          queries: targets.map(target => ({ ...target, datasourceId: this.instanceSettings.id })),
+        },
+        requestId: options.requestId,
+      })
+    ).pipe(map(response => transformTraceResponse(toDataQueryResponse(response))));
   }
 
   async metadataRequest(url: string, params?: Record<string, any>): Promise<any> {
@@ -63,24 +82,6 @@ export class ZipkinDatasource extends DataSourceApi<ZipkinQuery> {
   }
 }
 
-function responseToDataQueryResponse(response: { data: ZipkinSpan[] }): DataQueryResponse {
-  return {
-    data: [
-      new MutableDataFrame({
-        fields: [
-          {
-            name: 'trace',
-            type: FieldType.trace,
-            // There is probably better mapping than just putting everything in as a single value but that's how
-            // we do it with jaeger and is the simplest right now.
-            values: response?.data ? [transformResponse(response?.data)] : [],
-          },
-        ],
-      }),
-    ],
-  };
-}
-
 const emptyDataQueryResponse = {
   data: [
     new MutableDataFrame({
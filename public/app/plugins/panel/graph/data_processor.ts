@@ -86,6 +86,7 @@ export class DataProcessor {
       alias: alias,
       color: getColorFromHexRgbOrName(color, config.theme.type),
       unit: field.config ? field.config.unit : undefined,
+      seriesOverride: field.config?.custom?.seriesOverride,
       dataFrameIndex,
       fieldIndex,
     });